@@ -16,6 +16,8 @@ limitations under the License.
 
 package v1
 
+import corev1 "k8s.io/api/core/v1"
+
 // BackupStorageProvider defines the configuration of storage for storing backup files.
 type BackupStorageProvider struct {
 	// StorageName defines the storage medium used to perform backup
@@ -23,6 +25,16 @@ type BackupStorageProvider struct {
 
 	// Sink defines the storage configuration choose to perform backup
 	Sink string `json:"sink,omitempty"`
+
+	// RetentionCredential references the secret which holds the privileged credential to delete the
+	// backup files once the backup is out of retention, with keys "endpoint", "bucket", "accessKey"
+	// and "accessSecret". It's only read by the operator, so the credential of the sink which the
+	// backup jobs upload with can be write-only, i.e. without the permission to delete, and a
+	// compromised cluster is unable to wipe its own backups. The backup files are kept in the storage when the
+	// backup is removed if not specified. Only supported by OSS and S3, the secret of S3 may also
+	// hold keys "region" and "pathStyle".
+	// +optional
+	RetentionCredential *corev1.LocalObjectReference `json:"retentionCredential,omitempty"`
 	// TODO: Add Nas Provider
 }
 
@@ -32,4 +44,11 @@ type BackupStorage string
 const (
 	OSS  BackupStorage = "oss"
 	SFTP BackupStorage = "sftp"
+	// Archive streams backups to an archival gateway, e.g. a tape library, through an adapter
+	// serving the http contract of the archive file service of hpfs. The sink is configured with
+	// type "archive" in the filestream config.
+	Archive BackupStorage = "archive"
+	// S3 uploads backups to an S3 compatible object storage, e.g. AWS S3, MinIO or GCS with HMAC
+	// keys, in parts with retries. The sink is configured with type "s3" in the filestream config.
+	S3 BackupStorage = "s3"
 )
@@ -0,0 +1,54 @@
+/*
+Copyright 2021 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// MaxProgressStages is the max count of stages kept in the progress of an operation.
+const MaxProgressStages = 32
+
+// StageProgress records a stage the operation has entered.
+type StageProgress struct {
+	// Name is the name of the stage, e.g. "Upgrading" or "Upgrading/Canary".
+	Name string `json:"name"`
+
+	// StartTime is when the stage is entered.
+	StartTime metav1.Time `json:"startTime"`
+
+	// CompletionTime is when the stage is left, empty for the current stage.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// OperationProgress records the stages of the last long-running operation driven by the operator,
+// e.g. creating, upgrading or restoring, until the object is steady again.
+type OperationProgress struct {
+	// Operation is the name of the operation, e.g. the phase it starts with.
+	Operation string `json:"operation"`
+
+	// StartTime is when the operation starts.
+	StartTime metav1.Time `json:"startTime"`
+
+	// CompletionTime is when the operation completes, empty if it's in progress.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Stages are the stages of the operation, oldest first. Only the latest MaxProgressStages
+	// stages are kept.
+	// +optional
+	Stages []StageProgress `json:"stages,omitempty"`
+}
@@ -64,6 +64,33 @@ func (in *ExtendedResourceRequirements) DeepCopy() *ExtendedResourceRequirements
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperationProgress) DeepCopyInto(out *OperationProgress) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Stages != nil {
+		in, out := &in.Stages, &out.Stages
+		*out = make([]StageProgress, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperationProgress.
+func (in *OperationProgress) DeepCopy() *OperationProgress {
+	if in == nil {
+		return nil
+	}
+	out := new(OperationProgress)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PartialObjectMeta) DeepCopyInto(out *PartialObjectMeta) {
 	*out = *in
@@ -93,6 +120,26 @@ func (in *PartialObjectMeta) DeepCopy() *PartialObjectMeta {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StageProgress) DeepCopyInto(out *StageProgress) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StageProgress.
+func (in *StageProgress) DeepCopy() *StageProgress {
+	if in == nil {
+		return nil
+	}
+	out := new(StageProgress)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Value) DeepCopyInto(out *Value) {
 	*out = *in
@@ -0,0 +1,38 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package polardbx
+
+import (
+	"github.com/alibaba/polardbx-operator/api/v1/xstore"
+)
+
+// LogCollector defines the log shipping of the cluster. The collector runs as a sidecar of
+// the pods of the enabled components.
+type LogCollector struct {
+	// CNAuditLog ships the audit logs (sql.log) of CNs. The audit log must be enabled with
+	// config.cn.enableAuditLog. Default is false.
+	// +optional
+	CNAuditLog bool `json:"cnAuditLog,omitempty"`
+
+	// DNSlowLog ships the slow logs of GMS and DNs, and turns on the slow query log of the
+	// engines unless it's configured explicitly. Pods of the xstores are rebuilt one by one
+	// when it's changed. Default is false.
+	// +optional
+	DNSlowLog bool `json:"dnSlowLog,omitempty"`
+
+	xstore.LogCollector `json:",inline"`
+}
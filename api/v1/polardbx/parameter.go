@@ -19,6 +19,20 @@ package polardbx
 const (
 	RolingRestart = "rollingRestart"
 	Restart       = "restart"
+	// DeferredRestart writes the static parameters into the config without restarting the pods,
+	// they take effect on the next restart of the pods.
+	DeferredRestart = "deferredRestart"
+)
+
+// ParameterDriftPolicy defines what to do when the running value of a dynamic parameter drifts
+// from the spec.
+type ParameterDriftPolicy string
+
+const (
+	// ParameterDriftReport only reports the drifts in status.
+	ParameterDriftReport ParameterDriftPolicy = "Report"
+	// ParameterDriftCorrect applies the parameters again to correct the drifts.
+	ParameterDriftCorrect ParameterDriftPolicy = "Correct"
 )
 
 type ParameterTemplate struct {
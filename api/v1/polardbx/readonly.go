@@ -16,10 +16,33 @@ limitations under the License.
 
 package polardbx
 
-import "k8s.io/apimachinery/pkg/util/intstr"
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
 
 type ReadonlyParam struct {
 	CnReplicas  int                           `json:"cnReplicas,omitempty"`
 	Name        string                        `json:"name,omitempty"`
 	ExtraParams map[string]intstr.IntOrString `json:"extraParams,omitempty"`
 }
+
+// ReadonlyReplicationStatus represents the replication of the DNs of readonly cluster from the
+// primary cluster.
+type ReadonlyReplicationStatus struct {
+	// MaxLag is the count of log entries the slowest learner of DNs is behind the primary leader.
+	// +optional
+	MaxLag int64 `json:"maxLag,omitempty"`
+
+	// LaggingDN is the DN of the slowest learner.
+	// +optional
+	LaggingDN string `json:"laggingDN,omitempty"`
+
+	// Message is the reason why the replication of some DN is not healthy, empty if all are.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// UpdateTime is the time of the last observation.
+	// +optional
+	UpdateTime *metav1.Time `json:"updateTime,omitempty"`
+}
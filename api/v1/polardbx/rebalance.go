@@ -0,0 +1,99 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package polardbx
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RebalanceSpec defines how the data is rebalanced onto the DNs added or off the DNs removed on
+// scaling.
+type RebalanceSpec struct {
+	// Paused pauses the moves of the rebalance in flight, and resumes them once unset. The scaling
+	// doesn't finish until the rebalance is resumed and finished.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+
+	// +kubebuilder:validation:Minimum=0
+
+	// SpeedLimit bounds the rows per second backfilled by each move, 0 for the default of the cluster.
+	// +optional
+	SpeedLimit int64 `json:"speedLimit,omitempty"`
+
+	// +kubebuilder:validation:Minimum=0
+
+	// Parallelism bounds the parallelism of backfill of each move, 0 for the default of the cluster.
+	// +optional
+	Parallelism int32 `json:"parallelism,omitempty"`
+}
+
+// RebalanceMove represents a move of data of the rebalance, e.g. a table group or a database group.
+type RebalanceMove struct {
+	// JobId is the id of DDL job of the move.
+	JobId string `json:"jobId"`
+
+	// Schema is the schema of the moved object.
+	// +optional
+	Schema string `json:"schema,omitempty"`
+
+	// Object is the moved object, e.g. the table.
+	// +optional
+	Object string `json:"object,omitempty"`
+
+	// Type is the type of DDL job.
+	// +optional
+	Type string `json:"type,omitempty"`
+
+	// State is the state of DDL job.
+	// +optional
+	State string `json:"state,omitempty"`
+
+	// Progress is the progress of the move, in percent.
+	// +optional
+	Progress int32 `json:"progress,omitempty"`
+}
+
+// RebalanceStatus represents the rebalance of data in flight.
+type RebalanceStatus struct {
+	// PlanId is the id of rebalance plan.
+	// +optional
+	PlanId string `json:"planId,omitempty"`
+
+	// State is the state of rebalance plan.
+	// +optional
+	State string `json:"state,omitempty"`
+
+	// Progress is the progress of rebalance plan, in percent.
+	// +optional
+	Progress int32 `json:"progress,omitempty"`
+
+	// Paused represents whether the moves are paused.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+
+	// Moves are the moves not finished.
+	// +optional
+	Moves []RebalanceMove `json:"moves,omitempty"`
+
+	// StartTime is when the rebalance started.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// UpdateTime is the time of the last observation.
+	// +optional
+	UpdateTime *metav1.Time `json:"updateTime,omitempty"`
+}
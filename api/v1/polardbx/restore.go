@@ -16,6 +16,13 @@ limitations under the License.
 
 package polardbx
 
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/alibaba/polardbx-operator/api/v1/xstore"
+)
+
 type RestoreSpec struct {
 	// BackupSet defines the source of backup set
 	BackupSet string `json:"backupset,omitempty"`
@@ -26,10 +33,15 @@ type RestoreSpec struct {
 	// Time defines the specified time of the restored data, in the format of 'yyyy-MM-dd HH:mm:ss'. Required.
 	Time string `json:"time,omitempty"`
 
-	// TimeZone defines the specified time zone of the restore time. Default is the location of current cluster.
+	// TimeZone defines the specified time zone of the restore time, e.g. "Asia/Shanghai". Default is UTC.
 	// +optional
 	TimeZone string `json:"timezone,omitempty"`
 
+	// PointInTime replays the binlog backups after the restored backup set up to the restore time
+	// on each DN before the cluster is opened, see the same field of xstore restore. Default is false.
+	// +optional
+	PointInTime bool `json:"pointInTime,omitempty"`
+
 	// +kubebuilder:default=false
 
 	// SyncSpecWithOriginalCluster identifies whether restored cluster should use the same spec as the original cluster.
@@ -37,6 +49,13 @@ type RestoreSpec struct {
 	// use default spec, but replicas of dn will be forced to sync with original cluster now. Default is false
 	// +optional
 	SyncSpecWithOriginalCluster bool `json:"syncSpecWithOriginalCluster,omitempty"`
+
+	// CompatibilityStrictness defines how the incompatibilities between the backup and the restored
+	// xstores are handled, see the same field of xstore restore. Default is Default.
+	// +kubebuilder:validation:Enum=Strict;Default;WarnOnly
+	// +kubebuilder:default=Default
+	// +optional
+	CompatibilityStrictness xstore.RestoreCompatibilityStrictness `json:"compatibilityStrictness,omitempty"`
 }
 
 // PolarDBXRestoreFrom defines the source information of the restored cluster.
@@ -48,4 +67,81 @@ type PolarDBXRestoreFrom struct {
 	// BackupSelector defines the selector for the backups to be selected. Optional.
 	// +optional
 	BackupSelector map[string]string `json:"backupSelector,omitempty"`
+
+	// Namespace is the namespace of the backup set if it's produced by a cluster in another namespace.
+	// The backup set along with its xstore backups and secrets is imported into the namespace of the
+	// restored cluster before restoring, the files are shared and never deleted through the imported
+	// ones. Requires the backup set. Default is the namespace of the restored cluster.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// BackupLocation is the backup set in the remote storage, e.g. produced in another kubernetes
+	// cluster, so that no object of the backup is required. The backup set is imported from the
+	// manifest exported along with it. Optional.
+	// +optional
+	BackupLocation *RestoreBackupLocation `json:"backupLocation,omitempty"`
+}
+
+// RestoreBackupLocation defines the backup set in the remote storage.
+type RestoreBackupLocation struct {
+	// StorageName is the storage of the backup set, only oss and s3 are supported.
+	// +kubebuilder:validation:Enum=oss;s3
+	StorageName string `json:"storageName"`
+
+	// Sink is the sink of the storage which the restore jobs download with, it must be configured
+	// in the operator.
+	Sink string `json:"sink"`
+
+	// Credential references the secret in the namespace which holds the credential to read the
+	// manifest, with the same keys as the retention credential of backups.
+	Credential corev1.LocalObjectReference `json:"credential"`
+
+	// Path is the root path of the backup set in the storage, i.e. the backup root path in the
+	// status of the backup.
+	Path string `json:"path"`
+}
+
+// RestoreShardPhase defines the restore phase of a single shard (GMS or DN) of the cluster.
+type RestoreShardPhase string
+
+// Valid restore shard phases.
+const (
+	RestoreShardPending   RestoreShardPhase = "Pending"
+	RestoreShardRestoring RestoreShardPhase = "Restoring"
+	RestoreShardSucceeded RestoreShardPhase = "Succeeded"
+	RestoreShardFailed    RestoreShardPhase = "Failed"
+)
+
+// RestoreShardStatus records the restore progress of a single shard.
+type RestoreShardStatus struct {
+	// Phase represents the restore phase of the shard.
+	Phase RestoreShardPhase `json:"phase,omitempty"`
+
+	// Attempts represents how many times the shard has been restored.
+	// +optional
+	Attempts int32 `json:"attempts,omitempty"`
+
+	// LastTransitionTime represents the last time the phase changed.
+	// +optional
+	LastTransitionTime *metav1.Time `json:"lastTransitionTime,omitempty"`
+
+	// Message represents the detail of the last failure, if any.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// RestoreStatus records the restore progress of the cluster per shard.
+type RestoreStatus struct {
+	// Shards represents the restore status per shard. The key is the name of xstore.
+	// +optional
+	Shards map[string]RestoreShardStatus `json:"shards,omitempty"`
+
+	// ImportedFrom represents the source of the backup set imported for the restore, if any.
+	// +optional
+	ImportedFrom string `json:"importedFrom,omitempty"`
+
+	// Message represents the failure of the restore before any shard is restored, e.g. the
+	// backup set can't be imported.
+	// +optional
+	Message string `json:"message,omitempty"`
 }
@@ -16,7 +16,29 @@ limitations under the License.
 
 package polardbx
 
-import corev1 "k8s.io/api/core/v1"
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/alibaba/polardbx-operator/api/v1/xstore"
+)
+
+// CertificateIssuerReference refers to an issuer of cert-manager.
+type CertificateIssuerReference struct {
+	// Name of the issuer.
+	Name string `json:"name"`
+
+	// +kubebuilder:default=Issuer
+	// +kubebuilder:validation:Enum=Issuer;ClusterIssuer
+
+	// Kind of the issuer, either Issuer or ClusterIssuer. Default is Issuer.
+	// +optional
+	Kind string `json:"kind,omitempty"`
+
+	// Group of the issuer. Default is cert-manager.io.
+	// +optional
+	Group string `json:"group,omitempty"`
+}
 
 // TLS defines the TLS config.
 type TLS struct {
@@ -25,6 +47,38 @@ type TLS struct {
 
 	// GenerateSelfSigned represents if let the operator generate and use a self-signed cert.
 	GenerateSelfSigned bool `json:"generateSelfSigned,omitempty"`
+
+	// IssuerRef lets the operator request the certificate from the issuer of cert-manager,
+	// which renews it before expiry.
+	// +optional
+	IssuerRef *CertificateIssuerReference `json:"issuerRef,omitempty"`
+
+	// Internal enables TLS on the DN and GMS engines as well, which covers the traffic from
+	// CN to DN and the replication among the consensus members.
+	// +optional
+	Internal bool `json:"internal,omitempty"`
+
+	// Duration is the validity of the certificates generated or requested. Default is 8760h.
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+
+	// RenewBefore is how long before the expiry the certificates are rotated. Default is 720h.
+	// +optional
+	RenewBefore *metav1.Duration `json:"renewBefore,omitempty"`
+}
+
+// TLSStatus represents the certificate in use.
+type TLSStatus struct {
+	// CertificateHash is the hash of the server certificate in use.
+	CertificateHash string `json:"certificateHash,omitempty"`
+
+	// NotAfter is the expiry of the server certificate in use.
+	// +optional
+	NotAfter *metav1.Time `json:"notAfter,omitempty"`
+
+	// LastRotationTime is the last time the certificate is rotated.
+	// +optional
+	LastRotationTime *metav1.Time `json:"lastRotationTime,omitempty"`
 }
 
 // Security represents the security config of the cluster.
@@ -37,4 +91,10 @@ type Security struct {
 	// operator will generate a random key.
 	// +optional
 	EncodeKey *corev1.SecretKeySelector `json:"encodeKey,omitempty"`
+
+	// TDE enables the transparent data encryption on the engines of GMS and DNs. Pods of the xstores
+	// are rebuilt one by one when it's enabled. Once enabled, it can't be disabled, nor can the master
+	// key source be changed, while the master key version can be increased.
+	// +optional
+	TDE *xstore.TDE `json:"tde,omitempty"`
 }
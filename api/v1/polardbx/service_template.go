@@ -0,0 +1,86 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package polardbx
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ServiceTarget is the pods which the service routes to.
+type ServiceTarget string
+
+// Valid service targets.
+const (
+	// ServiceTargetCN routes to the CNs of the cluster.
+	ServiceTargetCN ServiceTarget = "CN"
+
+	// ServiceTargetReadonlyCN routes to the CNs of all the readonly clusters of the primary
+	// cluster. It's ignored on readonly clusters.
+	ServiceTargetReadonlyCN ServiceTarget = "ReadonlyCN"
+
+	// ServiceTargetDN creates a headless service for each DN, which resolves to the pods of
+	// the DN for direct access.
+	ServiceTargetDN ServiceTarget = "DN"
+)
+
+// ServiceTemplate defines an additional service of the cluster. The selector and ports of
+// the services are kept in sync by the operator, e.g. services of DNs are created and
+// removed along with the scaling of DNs.
+type ServiceTemplate struct {
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=20
+
+	// Name of the template. The service is named "{service name}-{name}" for CN targets, and
+	// "{DN name}-{name}" for each DN.
+	Name string `json:"name"`
+
+	// +kubebuilder:default=CN
+	// +kubebuilder:validation:Enum=CN;ReadonlyCN;DN
+
+	// Target of the service. Default is CN.
+	// +optional
+	Target ServiceTarget `json:"target,omitempty"`
+
+	// +kubebuilder:default=ClusterIP
+
+	// Type of the service. Services of DNs are always headless and ignore the type.
+	// Default is ClusterIP.
+	// +optional
+	Type corev1.ServiceType `json:"type,omitempty"`
+
+	// Labels of the service. Optional.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations of the service, e.g. the annotations of cloud providers to provision an
+	// internal load balancer. Optional.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// NodePort of the MySQL port if type is NodePort or LoadBalancer. It's allocated from the
+	// node port range of the Kubernetes cluster if not specified.
+	// +optional
+	NodePort int32 `json:"nodePort,omitempty"`
+
+	// LoadBalancerSourceRanges restricts the client IPs of the load balancer if supported.
+	// +optional
+	LoadBalancerSourceRanges []string `json:"loadBalancerSourceRanges,omitempty"`
+
+	// ExternalTrafficPolicy of the service if type is NodePort or LoadBalancer.
+	// +optional
+	ExternalTrafficPolicy corev1.ServiceExternalTrafficPolicyType `json:"externalTrafficPolicy,omitempty"`
+}
@@ -38,6 +38,8 @@ const (
 	PhaseDeleting   Phase = "Deleting"
 	PhaseFailed     Phase = "Failed"
 	PhaseRestarting Phase = "Restarting"
+	PhaseSuspended  Phase = "Suspended"
+	PhaseResuming   Phase = "Resuming"
 	PhaseUnknown    Phase = "Unknown"
 )
 
@@ -48,6 +50,7 @@ type Stage string
 // Valid stages.
 const (
 	StageEmpty          Stage = ""
+	StageCanary         Stage = "Canary"
 	StageRebalanceStart Stage = "RebalanceStart"
 	StageRebalanceWatch Stage = "RebalanceWatch"
 	StageClean          Stage = "Clean"
@@ -63,6 +66,22 @@ const (
 	DnsReady     ConditionType = "DnsReady"
 	CdcReady     ConditionType = "CdcReady"
 	ClusterReady ConditionType = "ClusterReady"
+
+	// Ready indicates whether the cluster is running with all replicas available.
+	Ready ConditionType = "Ready"
+
+	// Progressing indicates whether an operation driven by the operator is in progress, e.g.
+	// creating, upgrading or restoring.
+	Progressing ConditionType = "Progressing"
+
+	// Degraded indicates whether the cluster is failed, or running with replicas unavailable.
+	Degraded ConditionType = "Degraded"
+
+	// ReplicationHealthy indicates whether the replicas of GMS and DNs are within the lag bound.
+	ReplicationHealthy ConditionType = "ReplicationHealthy"
+
+	// BackupInProgress indicates whether any backup of the cluster is in progress.
+	BackupInProgress ConditionType = "BackupInProgress"
 )
 
 // Condition defines the condition and its status.
@@ -87,6 +106,10 @@ type Condition struct {
 	// Human-readable message indicating details about last transition.
 	// +optional
 	Message string `json:"message,omitempty"`
+
+	// ObservedGeneration is the generation of the spec the condition is set upon.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 }
 
 // ReplicaStatusForPrint represents the printable status for replica status of nodes.
@@ -58,9 +58,33 @@ type XStoreTopologyRuleNodeSetItem struct {
 	NodeSelector *NodeSelectorReference `json:"selector,omitempty"`
 }
 
+// XStoreTopologySpread defines how the consensus members of each xstore are spread
+// across the failure domains, e.g. zones or racks.
+type XStoreTopologySpread struct {
+	// +kubebuilder:default="topology.kubernetes.io/zone"
+
+	// TopologyKey is the key of node labels that defines the failure domain.
+	// Default is topology.kubernetes.io/zone.
+	// +optional
+	TopologyKey string `json:"topologyKey,omitempty"`
+
+	// +kubebuilder:default=DoNotSchedule
+	// +kubebuilder:validation:Enum=DoNotSchedule;ScheduleAnyway
+
+	// WhenUnsatisfiable indicates how to deal with a member if it can't be placed in
+	// a distinct domain. Default is DoNotSchedule.
+	// +optional
+	WhenUnsatisfiable corev1.UnsatisfiableConstraintAction `json:"whenUnsatisfiable,omitempty"`
+}
+
 type XStoreTopologyRule struct {
 	Rolling  *XStoreTopologyRuleRolling      `json:"rolling,omitempty"`
 	NodeSets []XStoreTopologyRuleNodeSetItem `json:"nodeSets,omitempty"`
+
+	// Spread places the consensus members (candidates and voters, or learners if
+	// readonly) of each xstore in distinct failure domains. Not compatible with rolling.
+	// +optional
+	Spread *XStoreTopologySpread `json:"spread,omitempty"`
 }
 
 type TopologyRuleComponents struct {
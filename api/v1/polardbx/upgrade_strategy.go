@@ -16,10 +16,67 @@ limitations under the License.
 
 package polardbx
 
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
 type UpgradeStrategyType string
 
 // Valid upgrade strategies.
 const (
 	RecreateUpgradeStrategy UpgradeStrategyType = "Recreate"
 	RollingUpgradeStrategy  UpgradeStrategyType = "RollingUpgrade"
+	// CanaryUpgradeStrategy upgrades one CN and one DN first, and continues rolling the rest
+	// after the canary runs healthily for the soak period.
+	CanaryUpgradeStrategy UpgradeStrategyType = "Canary"
+)
+
+// CanaryUpgrade defines how the canary upgrade proceeds.
+type CanaryUpgrade struct {
+	// Pause holds the upgrade after the canary is soaked, until it's resumed by the annotation
+	// "polardbx/upgrade.resume".
+	// +optional
+	Pause bool `json:"pause,omitempty"`
+
+	// SoakPeriod is how long the canary must run healthily before the upgrade continues.
+	// Default is 5m.
+	// +optional
+	SoakPeriod *metav1.Duration `json:"soakPeriod,omitempty"`
+}
+
+// CanaryPhase defines the phase of the canary.
+type CanaryPhase string
+
+// Valid canary phases.
+const (
+	CanaryRolling   CanaryPhase = "Rolling"
+	CanarySoaking   CanaryPhase = "Soaking"
+	CanaryPaused    CanaryPhase = "Paused"
+	CanaryFailed    CanaryPhase = "Failed"
+	CanaryCompleted CanaryPhase = "Completed"
 )
+
+// CanaryStatus represents the canary of the upgrade.
+type CanaryStatus struct {
+	// Generation is the generation of the cluster upgraded by the canary.
+	Generation int64 `json:"generation,omitempty"`
+
+	// Phase is the phase of the canary.
+	Phase CanaryPhase `json:"phase,omitempty"`
+
+	// CNDeployment is the name of the canary deployment of CN, empty if CN isn't changed.
+	// +optional
+	CNDeployment string `json:"cnDeployment,omitempty"`
+
+	// DNStore is the name of the DN upgraded as the canary, empty if DN isn't changed.
+	// +optional
+	DNStore string `json:"dnStore,omitempty"`
+
+	// SoakStartTime is the time when the canary becomes ready.
+	// +optional
+	SoakStartTime *metav1.Time `json:"soakStartTime,omitempty"`
+
+	// Message is the message about the canary.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
@@ -22,7 +22,9 @@ limitations under the License.
 package polardbx
 
 import (
+	"github.com/alibaba/polardbx-operator/api/v1/xstore"
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
@@ -123,6 +125,60 @@ func (in *CNTemplate) DeepCopy() *CNTemplate {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryStatus) DeepCopyInto(out *CanaryStatus) {
+	*out = *in
+	if in.SoakStartTime != nil {
+		in, out := &in.SoakStartTime, &out.SoakStartTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryStatus.
+func (in *CanaryStatus) DeepCopy() *CanaryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryUpgrade) DeepCopyInto(out *CanaryUpgrade) {
+	*out = *in
+	if in.SoakPeriod != nil {
+		in, out := &in.SoakPeriod, &out.SoakPeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryUpgrade.
+func (in *CanaryUpgrade) DeepCopy() *CanaryUpgrade {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryUpgrade)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateIssuerReference) DeepCopyInto(out *CertificateIssuerReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateIssuerReference.
+func (in *CertificateIssuerReference) DeepCopy() *CertificateIssuerReference {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateIssuerReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterReplicasStatus) DeepCopyInto(out *ClusterReplicasStatus) {
 	*out = *in
@@ -218,6 +274,22 @@ func (in *FileStorageInfo) DeepCopy() *FileStorageInfo {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogCollector) DeepCopyInto(out *LogCollector) {
+	*out = *in
+	in.LogCollector.DeepCopyInto(&out.LogCollector)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogCollector.
+func (in *LogCollector) DeepCopy() *LogCollector {
+	if in == nil {
+		return nil
+	}
+	out := new(LogCollector)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NodeSelectorItem) DeepCopyInto(out *NodeSelectorItem) {
 	*out = *in
@@ -279,6 +351,11 @@ func (in *PolarDBXRestoreFrom) DeepCopyInto(out *PolarDBXRestoreFrom) {
 			(*out)[key] = val
 		}
 	}
+	if in.BackupLocation != nil {
+		in, out := &in.BackupLocation, &out.BackupLocation
+		*out = new(RestoreBackupLocation)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXRestoreFrom.
@@ -328,6 +405,83 @@ func (in *ReadonlyParam) DeepCopy() *ReadonlyParam {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReadonlyReplicationStatus) DeepCopyInto(out *ReadonlyReplicationStatus) {
+	*out = *in
+	if in.UpdateTime != nil {
+		in, out := &in.UpdateTime, &out.UpdateTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReadonlyReplicationStatus.
+func (in *ReadonlyReplicationStatus) DeepCopy() *ReadonlyReplicationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ReadonlyReplicationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RebalanceMove) DeepCopyInto(out *RebalanceMove) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RebalanceMove.
+func (in *RebalanceMove) DeepCopy() *RebalanceMove {
+	if in == nil {
+		return nil
+	}
+	out := new(RebalanceMove)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RebalanceSpec) DeepCopyInto(out *RebalanceSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RebalanceSpec.
+func (in *RebalanceSpec) DeepCopy() *RebalanceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RebalanceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RebalanceStatus) DeepCopyInto(out *RebalanceStatus) {
+	*out = *in
+	if in.Moves != nil {
+		in, out := &in.Moves, &out.Moves
+		*out = make([]RebalanceMove, len(*in))
+		copy(*out, *in)
+	}
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.UpdateTime != nil {
+		in, out := &in.UpdateTime, &out.UpdateTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RebalanceStatus.
+func (in *RebalanceStatus) DeepCopy() *RebalanceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RebalanceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReplicaStatusForPrint) DeepCopyInto(out *ReplicaStatusForPrint) {
 	*out = *in
@@ -378,6 +532,41 @@ func (in *RestartingPods) DeepCopy() *RestartingPods {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreBackupLocation) DeepCopyInto(out *RestoreBackupLocation) {
+	*out = *in
+	out.Credential = in.Credential
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreBackupLocation.
+func (in *RestoreBackupLocation) DeepCopy() *RestoreBackupLocation {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreBackupLocation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreShardStatus) DeepCopyInto(out *RestoreShardStatus) {
+	*out = *in
+	if in.LastTransitionTime != nil {
+		in, out := &in.LastTransitionTime, &out.LastTransitionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreShardStatus.
+func (in *RestoreShardStatus) DeepCopy() *RestoreShardStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreShardStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RestoreSpec) DeepCopyInto(out *RestoreSpec) {
 	*out = *in
@@ -394,19 +583,46 @@ func (in *RestoreSpec) DeepCopy() *RestoreSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreStatus) DeepCopyInto(out *RestoreStatus) {
+	*out = *in
+	if in.Shards != nil {
+		in, out := &in.Shards, &out.Shards
+		*out = make(map[string]RestoreShardStatus, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreStatus.
+func (in *RestoreStatus) DeepCopy() *RestoreStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Security) DeepCopyInto(out *Security) {
 	*out = *in
 	if in.TLS != nil {
 		in, out := &in.TLS, &out.TLS
 		*out = new(TLS)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.EncodeKey != nil {
 		in, out := &in.EncodeKey, &out.EncodeKey
 		*out = new(v1.SecretKeySelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.TDE != nil {
+		in, out := &in.TDE, &out.TDE
+		*out = new(xstore.TDE)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Security.
@@ -419,6 +635,40 @@ func (in *Security) DeepCopy() *Security {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceTemplate) DeepCopyInto(out *ServiceTemplate) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.LoadBalancerSourceRanges != nil {
+		in, out := &in.LoadBalancerSourceRanges, &out.LoadBalancerSourceRanges
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceTemplate.
+func (in *ServiceTemplate) DeepCopy() *ServiceTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SpecSnapshot) DeepCopyInto(out *SpecSnapshot) {
 	*out = *in
@@ -484,6 +734,21 @@ func (in *StatusForPrint) DeepCopy() *StatusForPrint {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TLS) DeepCopyInto(out *TLS) {
 	*out = *in
+	if in.IssuerRef != nil {
+		in, out := &in.IssuerRef, &out.IssuerRef
+		*out = new(CertificateIssuerReference)
+		**out = **in
+	}
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.RenewBefore != nil {
+		in, out := &in.RenewBefore, &out.RenewBefore
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLS.
@@ -496,6 +761,29 @@ func (in *TLS) DeepCopy() *TLS {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TLSStatus) DeepCopyInto(out *TLSStatus) {
+	*out = *in
+	if in.NotAfter != nil {
+		in, out := &in.NotAfter, &out.NotAfter
+		*out = (*in).DeepCopy()
+	}
+	if in.LastRotationTime != nil {
+		in, out := &in.LastRotationTime, &out.LastRotationTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLSStatus.
+func (in *TLSStatus) DeepCopy() *TLSStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TLSStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Topology) DeepCopyInto(out *Topology) {
 	*out = *in
@@ -717,6 +1005,11 @@ func (in *XStoreTopologyRule) DeepCopyInto(out *XStoreTopologyRule) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Spread != nil {
+		in, out := &in.Spread, &out.Spread
+		*out = new(XStoreTopologySpread)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new XStoreTopologyRule.
@@ -768,3 +1061,18 @@ func (in *XStoreTopologyRuleRolling) DeepCopy() *XStoreTopologyRuleRolling {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *XStoreTopologySpread) DeepCopyInto(out *XStoreTopologySpread) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new XStoreTopologySpread.
+func (in *XStoreTopologySpread) DeepCopy() *XStoreTopologySpread {
+	if in == nil {
+		return nil
+	}
+	out := new(XStoreTopologySpread)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AutoscalerMetricType defines the type of metric which the CNs are scaled by.
+type AutoscalerMetricType string
+
+const (
+	// AutoscalerMetricCPU is the average CPU utilization of the CN engine containers, in percent of
+	// the requests (or limits if requests not set). It requires the metrics server.
+	AutoscalerMetricCPU AutoscalerMetricType = "CPU"
+	// AutoscalerMetricConnections is the average count of client connections of the CNs.
+	AutoscalerMetricConnections AutoscalerMetricType = "Connections"
+	// AutoscalerMetricPrometheus is the value of a Prometheus query, divided by the count of CNs.
+	AutoscalerMetricPrometheus AutoscalerMetricType = "Prometheus"
+)
+
+// AutoscalerMetric defines a metric and its target value per CN.
+type AutoscalerMetric struct {
+	// +kubebuilder:validation:Enum=CPU;Connections;Prometheus
+
+	// Type is the type of metric.
+	Type AutoscalerMetricType `json:"type"`
+
+	// Target is the target value per CN, e.g. 70 for CPU utilization of 70%.
+	Target resource.Quantity `json:"target"`
+
+	// Query is the Prometheus query of metric type Prometheus. The samples of the result vector are
+	// summed up as the total value of the cluster.
+	// +optional
+	Query string `json:"query,omitempty"`
+}
+
+// PolarDBXAutoscalerSpec defines the desired state of PolarDBXAutoscaler
+type PolarDBXAutoscalerSpec struct {
+	// Cluster represents the reference of the cluster whose CNs are scaled.
+	Cluster PolarDBXClusterReference `json:"cluster,omitempty"`
+
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+
+	// MinReplicas is the lower bound of CN replicas. Default is 1.
+	// +optional
+	MinReplicas int32 `json:"minReplicas,omitempty"`
+
+	// +kubebuilder:validation:Minimum=1
+
+	// MaxReplicas is the upper bound of CN replicas.
+	MaxReplicas int32 `json:"maxReplicas"`
+
+	// +kubebuilder:validation:MinItems=1
+
+	// Metrics is the metrics which the CNs are scaled by. The largest replicas desired by the metrics
+	// is taken.
+	Metrics []AutoscalerMetric `json:"metrics"`
+
+	// PrometheusEndpoint is the endpoint of Prometheus which the Prometheus metrics are queried from,
+	// e.g. http://prometheus-k8s.monitoring:9090.
+	// +optional
+	PrometheusEndpoint string `json:"prometheusEndpoint,omitempty"`
+
+	// +kubebuilder:default="30s"
+
+	// Interval is the interval between evaluations. Default is 30s.
+	// +optional
+	Interval metav1.Duration `json:"interval,omitempty"`
+
+	// +kubebuilder:default="5m"
+
+	// ScaleDownStabilizationWindow is the window which scaling down looks back, i.e. the CNs are
+	// scaled down to the largest replicas desired within the window, so that the replicas don't
+	// flap with the metrics. Default is 5m.
+	// +optional
+	ScaleDownStabilizationWindow metav1.Duration `json:"scaleDownStabilizationWindow,omitempty"`
+
+	// +kubebuilder:validation:Minimum=0
+
+	// MaxScaleStep bounds the CN replicas added or removed by a scaling, 0 for unlimited.
+	// +optional
+	MaxScaleStep int32 `json:"maxScaleStep,omitempty"`
+}
+
+// AutoscalerMetricStatus is the current value of a metric.
+type AutoscalerMetricStatus struct {
+	// Type is the type of metric.
+	Type AutoscalerMetricType `json:"type"`
+
+	// Current is the current value per CN.
+	// +optional
+	Current *resource.Quantity `json:"current,omitempty"`
+
+	// DesiredReplicas is the replicas desired by the metric.
+	// +optional
+	DesiredReplicas int32 `json:"desiredReplicas,omitempty"`
+
+	// Message represents why the metric is unavailable, empty if available.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// AutoscalerRecommendation is the replicas desired at some time.
+type AutoscalerRecommendation struct {
+	Replicas int32       `json:"replicas"`
+	Time     metav1.Time `json:"time"`
+}
+
+// PolarDBXAutoscalerStatus defines the observed state of PolarDBXAutoscaler
+type PolarDBXAutoscalerStatus struct {
+	// CurrentReplicas is the CN replicas of the cluster.
+	// +optional
+	CurrentReplicas int32 `json:"currentReplicas,omitempty"`
+
+	// DesiredReplicas is the CN replicas desired after stabilization and bounds.
+	// +optional
+	DesiredReplicas int32 `json:"desiredReplicas,omitempty"`
+
+	// Metrics is the current value of metrics.
+	// +optional
+	Metrics []AutoscalerMetricStatus `json:"metrics,omitempty"`
+
+	// Recommendations is the replicas desired within the scale down stabilization window.
+	// +optional
+	Recommendations []AutoscalerRecommendation `json:"recommendations,omitempty"`
+
+	// LastEvaluateTime is when the metrics are evaluated last time.
+	// +optional
+	LastEvaluateTime *metav1.Time `json:"lastEvaluateTime,omitempty"`
+
+	// LastScaleTime is when the CNs are scaled last time.
+	// +optional
+	LastScaleTime *metav1.Time `json:"lastScaleTime,omitempty"`
+
+	// Message represents why the CNs are not scaled to the desired replicas, e.g. the cluster is
+	// upgrading.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=pxcas;pxas
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="CLUSTER",type=string,JSONPath=`.spec.cluster.name`
+// +kubebuilder:printcolumn:name="MIN",type=integer,JSONPath=`.spec.minReplicas`
+// +kubebuilder:printcolumn:name="MAX",type=integer,JSONPath=`.spec.maxReplicas`
+// +kubebuilder:printcolumn:name="CURRENT",type=integer,JSONPath=`.status.currentReplicas`
+// +kubebuilder:printcolumn:name="DESIRED",type=integer,JSONPath=`.status.desiredReplicas`
+// +kubebuilder:printcolumn:name="LAST_SCALE",type=date,JSONPath=`.status.lastScaleTime`
+// +kubebuilder:printcolumn:name="AGE",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// PolarDBXAutoscaler is the Schema for the polardbxautoscalers API. It scales the CNs of a cluster
+// by metrics within the bounds, and only when the cluster is running, i.e. no topology change is in
+// flight.
+type PolarDBXAutoscaler struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PolarDBXAutoscalerSpec   `json:"spec,omitempty"`
+	Status PolarDBXAutoscalerStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PolarDBXAutoscalerList contains a list of PolarDBXAutoscaler
+type PolarDBXAutoscalerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PolarDBXAutoscaler `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PolarDBXAutoscaler{}, &PolarDBXAutoscalerList{})
+}
@@ -17,8 +17,12 @@ limitations under the License.
 package v1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/alibaba/polardbx-operator/api/v1/common"
+	"github.com/alibaba/polardbx-operator/api/v1/xstore"
 )
 
 type PolarDBXClusterReference struct {
@@ -29,34 +33,718 @@ type PolarDBXClusterReference struct {
 type CleanPolicyType string
 
 const (
-	// CleanPolicyRetain represents that the backup files will be retained when the cluster is deleted.
+	// CleanPolicyRetain represents that the backup files will be retained when the backup object is deleted.
 	CleanPolicyRetain CleanPolicyType = "Retain"
 
-	// CleanPolicyDelete represents that the backup files will be deleted when the cluster is deleted.
+	// CleanPolicyDelete represents that the backup files will be deleted along with the backup object,
+	// and the expired files left by the deleted backups are collected by the backup schedule.
 	CleanPolicyDelete CleanPolicyType = "Delete"
 
-	// CleanPolicyOnFailure represents that the backup object will be deleted when the backup is failed.
+	// CleanPolicyOnFailure represents that the backup object will be deleted when the backup is failed,
+	// while the files are retained.
 	CleanPolicyOnFailure CleanPolicyType = "OnFailure"
+
+	// CleanPolicyDeleteOnFailure represents that the backup object will be deleted along with the files
+	// when the backup is failed. The files of finished backups are retained.
+	CleanPolicyDeleteOnFailure CleanPolicyType = "DeleteOnFailure"
+)
+
+// BackupJobVersionPolicy defines how the backup jobs created by operator of another version are
+// handled, e.g. when the operator is upgraded during the backup.
+type BackupJobVersionPolicy string
+
+const (
+	// BackupJobVersionAdopt represents that the jobs are adopted as is.
+	BackupJobVersionAdopt BackupJobVersionPolicy = "Adopt"
+
+	// BackupJobVersionRecreate represents that the jobs are let finish and then recreated,
+	// discarding the results.
+	BackupJobVersionRecreate BackupJobVersionPolicy = "Recreate"
+
+	// BackupJobVersionFail represents that the backup is failed.
+	BackupJobVersionFail BackupJobVersionPolicy = "Fail"
 )
 
+// BackupRetention defines the retention rules besides the retention time.
+type BackupRetention struct {
+	// MaxTotalBytes is the budget of total storage used by backups of the cluster. The oldest
+	// backups (except the latest one) are deleted until the usage is under the budget.
+	// Zero means no limit.
+	// +optional
+	MaxTotalBytes int64 `json:"maxTotalBytes,omitempty"`
+
+	// GracePeriod delays the deletion of backups past the retention time, so that a backup aging
+	// out while it's picked for a restore isn't deleted in the meantime. Regardless of it, backups
+	// referenced by an active restore are never deleted by the retention. Zero means no delay.
+	// +optional
+	GracePeriod metav1.Duration `json:"gracePeriod,omitempty"`
+
+	// DeleteConcurrency bounds how many aged backups are deleted in parallel by a retention pass,
+	// which deletes the aged backups of the same xstore oldest first. Zero means 1, i.e. serially.
+	// +optional
+	DeleteConcurrency int32 `json:"deleteConcurrency,omitempty"`
+
+	// ThrottleBackoff defines how long the retention waits before the next pass once the storage
+	// throttles the deletions. The deletions not started are left to the next pass. Zero means
+	// the default 30s.
+	// +optional
+	ThrottleBackoff metav1.Duration `json:"throttleBackoff,omitempty"`
+
+	// Immutable locks the backup files at the storage until the backup is deleted by the retention,
+	// i.e. the same as spec.immutableUntil is the retention time plus the grace period after the
+	// backup ends. The later one applies if spec.immutableUntil is also specified.
+	// +optional
+	Immutable bool `json:"immutable,omitempty"`
+}
+
 // PolarDBXBackupSpec defines the desired state of PolarDBXBackup
 type PolarDBXBackupSpec struct {
 	// Cluster represents the reference of target polardbx cluster to perform the backup action.
 	Cluster PolarDBXClusterReference `json:"cluster,omitempty"`
 
+	// XStores restricts the backup to the group of listed xstores (DN or GMS) of the cluster,
+	// which are backed up at a single consistent point as the whole cluster does. Status.backups
+	// links the xstore backups of the group, each of which is restored to the consistent point
+	// by an xstore with spec.restore.backupset. A group backup can't be used to restore the
+	// cluster. Empty means the whole cluster.
+	// +optional
+	XStores []string `json:"xstores,omitempty"`
+
 	// RetentionTime defines the retention time of the backup. The format is the same
 	// with metav1.Duration. Must be provided.
 	RetentionTime metav1.Duration `json:"retentionTime,omitempty"`
 
+	// Retention defines the retention rules besides the retention time.
+	// +optional
+	Retention BackupRetention `json:"retention,omitempty"`
+
 	// +kubebuilder:default=Retain
-	// +kubebuilder:validation:Enum=Retain;Delete;OnFailure
+	// +kubebuilder:validation:Enum=Retain;Delete;OnFailure;DeleteOnFailure
 
-	// CleanPolicy defines the clean policy when cluster is deleted. Default is Retain.
+	// CleanPolicy defines how the backup files are cleaned. The files are deleted with the retention
+	// credential of the storage provider, and kept if it's not specified. Default is Retain.
 	// +optional
 	CleanPolicy CleanPolicyType `json:"cleanPolicy,omitempty"`
 
 	// StorageProvider defines the backend storage to store the backup files.
 	StorageProvider BackupStorageProvider `json:"storageProvider,omitempty"`
+
+	// EnableDedupReport enables chunk checksums recording of full backups and reports
+	// the dedup ratio against the previous backup in status of xstore backups.
+	// +optional
+	EnableDedupReport bool `json:"enableDedupReport,omitempty"`
+
+	// PreferSourceZone defines the zone from which the backups are preferred to be taken, usually
+	// the zone of the storage endpoint to save the cross-zone traffic. Follower in the zone is
+	// preferred and falls back to any follower if none. Zones of pods are resolved from the
+	// label "topology.kubernetes.io/zone" of nodes.
+	// +optional
+	PreferSourceZone string `json:"preferSourceZone,omitempty"`
+
+	// OverlapCollect allows the binlog collection to start once the consistent point of the full
+	// backup is captured, i.e. overlapping with the tail of the full backup job, to shorten the
+	// wall-clock time. The backup still waits for the full backup job before finishing. Default
+	// is false.
+	// +optional
+	OverlapCollect bool `json:"overlapCollect,omitempty"`
+
+	// +kubebuilder:validation:Minimum=0
+
+	// MaxConcurrentXStoreBackups limits the number of xstores taking full backups at the same time,
+	// to bound the load on the cluster and the storage. The xstores are queued with the largest
+	// first and the next one is started once a full backup finishes. The consistent point is still
+	// captured after all full backups finish. Default is 0, i.e. no limit.
+	// +optional
+	MaxConcurrentXStoreBackups int32 `json:"maxConcurrentXStoreBackups,omitempty"`
+
+	// MaxFollowerLag bounds the replication lag of the follower which the backups are taken from,
+	// so that the recovery point of the backup is known to be fresh. The lag is checked before
+	// the full backup starts and recorded in status of xstore backups. Default is no bound.
+	// +optional
+	MaxFollowerLag metav1.Duration `json:"maxFollowerLag,omitempty"`
+
+	// FallbackToLeaderOnLag takes the backup from leader if the follower lags more than
+	// MaxFollowerLag, or its lag is still unknown 10 minutes after the backup starts. The
+	// backup fails otherwise. Default is false.
+	// +optional
+	FallbackToLeaderOnLag bool `json:"fallbackToLeaderOnLag,omitempty"`
+
+	// PreferredBackupRole defines the role of the pods which the backups are taken from. Backups
+	// are taken from followers by default, or from learners which fall back to followers if the DN
+	// has no learner. The lag of follower or learner is bounded by MaxFollowerLag. It takes
+	// precedence over the label of preferred backup node.
+	// +kubebuilder:validation:Enum=follower;learner;leader
+	// +optional
+	PreferredBackupRole BackupSourceRole `json:"preferredBackupRole,omitempty"`
+
+	// EphemeralLearner takes the backups from learners provisioned just for the backup, so that
+	// the serving replicas are fully isolated from the backup load. The learners are removed once
+	// the backup finishes or fails. It's heavy since the learners are built from scratch. Default
+	// is false.
+	// +optional
+	EphemeralLearner bool `json:"ephemeralLearner,omitempty"`
+
+	// SkipEmptyBinlog skips uploading the tail binlog of the binlog backups if no change events are
+	// found in the binlogs, e.g. on idle clusters. The backup set timestamp is the end time of the
+	// backup in that case. Default is false, the empty binlog is uploaded.
+	// +optional
+	SkipEmptyBinlog bool `json:"skipEmptyBinlog,omitempty"`
+
+	// StorageClass defines the storage class (tier) of the uploaded full backups and binlogs, e.g.
+	// "IA", "Archive" or "ColdArchive" of OSS, to lower the cost of long-retention backups. Small
+	// metadata files are always uploaded with the default class. Objects in archive classes are
+	// thawed before being downloaded during restore, which may take hours. Default is the class
+	// of the bucket. Only supported by OSS.
+	// +optional
+	StorageClass string `json:"storageClass,omitempty"`
+
+	// +kubebuilder:validation:Minimum=0
+
+	// FullBackupThreads defines the parallelism of the full backups, i.e. the threads to copy the
+	// data files and the parts uploaded in parallel (only for OSS). Each upload thread buffers a
+	// part (64MB at least) in memory of the filestream server. Zero or one means single-threaded.
+	// +optional
+	FullBackupThreads int32 `json:"fullBackupThreads,omitempty"`
+
+	// CollectBatchBytes splits the binlog collection of each DN into batches of binlog files, about
+	// the size in bytes each, and uploads the collected transaction events of a batch as a segment
+	// once the batch is done, instead of a single pass over all binlogs. It bounds the staging space
+	// and memory on write-heavy DNs. Batches are split on file boundaries, so a batch always contains
+	// one file at least. Zero means a single pass, which is the default.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	CollectBatchBytes int64 `json:"collectBatchBytes,omitempty"`
+
+	// UploadRetry retries the failed requests of each uploaded object of the backup jobs, e.g. a
+	// part of multipart upload, instead of failing the whole job on a transient storage error. The
+	// retried parts are buffered in memory of the filestream server. Only supported by OSS and S3,
+	// the count of retries is recorded in status of the xstore backups.
+	// +optional
+	UploadRetry *BackupUploadRetry `json:"uploadRetry,omitempty"`
+
+	// ConsistencyWait polls each just-uploaded object of the backup jobs until it's readable before
+	// it's taken as durable, and before the objects are checked by restore preview. It's for storages
+	// of eventual consistency, on which an uploaded object may be invisible for a while. The backup
+	// job fails if an object is still unreadable after the timeout. The waits are recorded in status
+	// of the xstore backups.
+	// +optional
+	ConsistencyWait *BackupConsistencyWait `json:"consistencyWait,omitempty"`
+
+	// Catalog exports each xstore backup to a relational catalog, a row per backup keyed by its uid,
+	// once it's finished or failed. The export is retried with backoff and recorded in status of the
+	// xstore backups.
+	// +optional
+	Catalog *BackupCatalog `json:"catalog,omitempty"`
+
+	// +kubebuilder:default=Adopt
+	// +kubebuilder:validation:Enum=Adopt;Recreate;Fail
+
+	// JobVersionPolicy defines how the backup jobs created by operator of another version are
+	// handled, e.g. when the operator is upgraded during the backup. Recreate lets the job finish
+	// and recreates it from the current operator, the full backup restarts from the beginning
+	// then. A full backup job overlapped with the binlog collection can't be recreated and fails
+	// the backup instead. Default is Adopt.
+	// +optional
+	JobVersionPolicy BackupJobVersionPolicy `json:"jobVersionPolicy,omitempty"`
+
+	// CDCConsistency coordinates the binlog checkpoint of the backup with the global binlog emitted
+	// by CDC, so that downstream pipelines rebuilt from the backup can resume from a coherent point.
+	// The CDC position is captured right after the heartbeat of the checkpoint and recorded in status
+	// and along with the binlog offsets. Ignored if the cluster has no CDC.
+	// +optional
+	CDCConsistency *BackupCDCConsistency `json:"cdcConsistency,omitempty"`
+
+	// +kubebuilder:default=SeekCp
+
+	// CheckpointCoordinator selects how the consistent checkpoint across the shards is coordinated
+	// after the binlogs are collected, by the name the coordinator is registered with in operator.
+	// SeekCp seeks the checkpoint in the collected transaction events with a job, which is the only
+	// built-in one and the default. The backup fails if the coordinator is unknown.
+	// +optional
+	CheckpointCoordinator string `json:"checkpointCoordinator,omitempty"`
+
+	// +kubebuilder:default=GMSAfterDNs
+	// +kubebuilder:validation:Enum=GMSAfterDNs;GMSBeforeDNs;Barrier
+
+	// CheckpointOrdering defines when the binlog offset of GMS, which the metadata is restored to, is
+	// captured relative to the checkpoint of DNs, i.e. the heartbeat. GMSAfterDNs captures it after
+	// the binlog offsets of DNs, so that the restored metadata always covers the restored data, which
+	// is the default. GMSBeforeDNs captures it before the heartbeat. Barrier captures it both before
+	// the heartbeat and after DNs, and retries the checkpoint if the metadata changes in between. The
+	// backup fails after MaxCheckpointBarrierRetries retries.
+	// +optional
+	CheckpointOrdering BackupCheckpointOrdering `json:"checkpointOrdering,omitempty"`
+
+	// +kubebuilder:default=Fail
+	// +kubebuilder:validation:Enum=Fail;Record
+
+	// TopologyChangePolicy defines what to do if the topology of an xstore, i.e. the node sets and
+	// their replicas, changes during its backup, e.g. it's scaled. Fail fails the backup with reason
+	// TopologyChanged, which is the default. Record keeps the backup and records the topology before
+	// the change, which is the shape the backup is restored into, in status of the xstore backup.
+	// +optional
+	TopologyChangePolicy BackupTopologyChangePolicy `json:"topologyChangePolicy,omitempty"`
+
+	// +kubebuilder:default=Default
+	// +kubebuilder:validation:Enum=Default;SnapshotLock
+
+	// ConsistencyMode defines how the full backup of DN keeps the snapshot consistent. Default
+	// blocks DDLs during the whole copy. SnapshotLock takes the engine-native backup lock only for
+	// the final metadata phase, so DDLs are blocked much shorter. It falls back to Default if it's
+	// not supported by the engine or xtrabackup, and the mode used is recorded in status of the
+	// xstore backup.
+	// +optional
+	ConsistencyMode BackupConsistencyMode `json:"consistencyMode,omitempty"`
+
+	// ImmutableUntil locks the backup files at the storage until the time, so that they can't be
+	// deleted nor overwritten even by the operator. The lock is enforced by the storage, e.g. the
+	// OSS bucket is WORM (write once read many) locked with a retention period covering the time,
+	// which is verified with the retention credential once the backup finishes. The retention never
+	// deletes the backup until the time, and the files still locked by the storage are kept.
+	// +optional
+	ImmutableUntil *metav1.Time `json:"immutableUntil,omitempty"`
+
+	// +kubebuilder:default=Full
+	// +kubebuilder:validation:Enum=Full;Incremental
+
+	// BackupType defines how the data files of each DN are copied. Full copies all of them.
+	// Incremental only copies the pages changed since the latest finished backup of the DN, which is
+	// the base of the incremental one, and the restore applies the incremental backups onto their base
+	// full backup in order. It falls back to Full if no base is found, and the type taken is recorded
+	// in status of the xstore backup. The binlogs are backed up the same way as Full.
+	// +optional
+	BackupType BackupType `json:"backupType,omitempty"`
+
+	// +kubebuilder:default=xtrabackup
+	// +kubebuilder:validation:Enum=xtrabackup;volumesnapshot
+
+	// Engine defines how the data of each DN is backed up. xtrabackup copies the data files and
+	// uploads them along with the binlogs to the storage. volumesnapshot takes a crash-consistent CSI
+	// snapshot of the data PVC under a brief global read lock, and records the binlog position
+	// captured under the lock. Snapshots of different xstores aren't consistent with each other, so
+	// volumesnapshot is only allowed for the backup of a single xstore in xstores. It requires the
+	// xstore on PVCs and a CSI driver supporting snapshots. Nothing is uploaded, the backup is restored to the snapshots by provisioning the
+	// PVCs from them, i.e. neither point in time nor continuous restore is supported. The snapshots
+	// are deleted along with the backup object.
+	// +optional
+	Engine BackupEngine `json:"engine,omitempty"`
+
+	// VolumeSnapshotClassName is the class of the volume snapshots taken by engine volumesnapshot.
+	// The default class of the CSI driver is used if not specified.
+	// +optional
+	VolumeSnapshotClassName string `json:"volumeSnapshotClassName,omitempty"`
+
+	// +kubebuilder:default="24h"
+
+	// FailedArtifactRetention defines how long the artifacts of failed backup, i.e. the xstore
+	// backups along with their jobs and task config maps, are kept for diagnosis after the
+	// failure. They're purged once it elapses, along with the files partially uploaded if the
+	// retention credential is specified. Zero purges them immediately. Default is 24h.
+	// +optional
+	FailedArtifactRetention metav1.Duration `json:"failedArtifactRetention,omitempty"`
+
+	// CircuitBreakerThreshold defines how many consecutive failures of the same step with the same
+	// reason open the circuit of the backup, i.e. the backup and its xstore backups stop retrying and
+	// condition CircuitOpen is set, until annotation "polardbx/backup.resume" is set to true. Zero
+	// means the default 10, negative disables the circuit breaker.
+	// +optional
+	CircuitBreakerThreshold int32 `json:"circuitBreakerThreshold,omitempty"`
+
+	// Share defines a time-limited grant to download a single object of the backup, e.g. for
+	// the support team or vendors. A pre-signed url is generated and recorded in status once
+	// the backup is finished. Only supported by OSS.
+	// +optional
+	Share *BackupObjectShare `json:"share,omitempty"`
+
+	// CopyFrom makes the backup a copy of an existing finished backup instead of backing up the
+	// cluster, e.g. to promote it to a longer retention. The backup files are copied on the server
+	// side to the root path of this backup, and the xstore backups are cloned, so that the copy is
+	// managed independently with its own retention. Cluster is taken from the source if not
+	// specified. Only supported by OSS, and the storage provider must be the same as the source.
+	// The cluster of the source must exist since the copy is done through its pods.
+	// +optional
+	CopyFrom *BackupCopySource `json:"copyFrom,omitempty"`
+
+	// RestorePreview validates that the finished backup is restorable without restoring it, i.e.
+	// the objects are present and readable, the binlog chain is intact, the engine version is
+	// compatible and the restore time is covered. The objects are checked by a job removed once the
+	// preview finishes, nothing else is provisioned, and nothing is written to the backup storage
+	// or the cluster. The result is recorded in status and the preview runs again once the spec of
+	// backup changes.
+	// +optional
+	RestorePreview *BackupRestorePreview `json:"restorePreview,omitempty"`
+
+	// Encryption encrypts the full backups and binlogs with the AES-256 key in a secret on the client
+	// side, i.e. in the backup jobs before they're uploaded, and the restore decrypts them with the
+	// same key. Metadata files, e.g. the binlog offsets and manifests, are kept unencrypted. The secret
+	// must be kept as long as the backup, since the backup can't be restored without the key. Keys of
+	// KMS are not supported.
+	// +optional
+	Encryption *BackupEncryption `json:"encryption,omitempty"`
+
+	// Verification verifies each finished xstore backup with a restore drill, i.e. a throwaway
+	// xstore of a single node is restored from it, the tables are checked and the checks are run on
+	// the restored data, and the xstore is removed then. The binlogs of the backup are replayed by
+	// the restore. The result is recorded in status of the xstore backups. The drills of the DNs run
+	// at the same time, each of which takes the resources of a node of the DN.
+	// +optional
+	Verification *BackupVerification `json:"verification,omitempty"`
+
+	// Throttle bounds the disk IO and network bandwidth of the backup jobs of each DN, so that the
+	// full backups don't saturate the node which they're taken from, e.g. the leader. The IO is
+	// throttled by xtrabackup, and the bandwidth by the uploader of both the full backups and binlogs.
+	// +optional
+	Throttle *BackupThrottle `json:"throttle,omitempty"`
+
+	// ThrottleOverrides overrides the throttle of specified DNs, keyed by name of the xstore, e.g.
+	// a looser one for the DNs on dedicated nodes. The override takes the place of Throttle as a whole.
+	// +optional
+	ThrottleOverrides map[string]BackupThrottle `json:"throttleOverrides,omitempty"`
+
+	// Hooks defines the commands executed before and after the backup of each xstore, e.g. to flush
+	// the caches of applications. The hooks are executed by the backups of the xstores respectively.
+	// +optional
+	Hooks *BackupHooks `json:"hooks,omitempty"`
+
+	// JobTemplate defines the scheduling controls and resources of the jobs spawned by the backups of
+	// the xstores, i.e. the full backup, binlog backup, binlog collect and seek checkpoint jobs, e.g.
+	// to tolerate the taints of the nodes dedicated to databases or run at a lower priority.
+	// +optional
+	JobTemplate *BackupJobTemplate `json:"jobTemplate,omitempty"`
+
+	// Notifications defines the channels notified once the backup of each xstore is finished or
+	// failed. The channels are notified by the backups of the xstores respectively.
+	// +kubebuilder:validation:MaxItems=5
+	// +optional
+	Notifications []BackupNotification `json:"notifications,omitempty"`
+}
+
+// BackupCDCConsistency defines how the backup checkpoint is coordinated with the CDC position.
+type BackupCDCConsistency struct {
+	// +kubebuilder:default="1m"
+
+	// MaxDivergence bounds the time between the heartbeat of the backup checkpoint and the capture
+	// of the CDC position. Condition CDCDiverged is set if it's exceeded. Default is 1m.
+	// +optional
+	MaxDivergence metav1.Duration `json:"maxDivergence,omitempty"`
+}
+
+// BackupCheckpointOrdering defines the ordering of GMS offset and the checkpoint of DNs.
+type BackupCheckpointOrdering string
+
+const (
+	CheckpointGMSAfterDNs  BackupCheckpointOrdering = "GMSAfterDNs"
+	CheckpointGMSBeforeDNs BackupCheckpointOrdering = "GMSBeforeDNs"
+	CheckpointBarrier      BackupCheckpointOrdering = "Barrier"
+)
+
+// BackupTopologyChangePolicy defines how a backup is handled if the topology of xstore changes during it.
+type BackupTopologyChangePolicy string
+
+const (
+	TopologyChangeFail   BackupTopologyChangePolicy = "Fail"
+	TopologyChangeRecord BackupTopologyChangePolicy = "Record"
+)
+
+// BackupType defines how the data files are copied by the full backup part of backup.
+type BackupType string
+
+const (
+	BackupTypeFull        BackupType = "Full"
+	BackupTypeIncremental BackupType = "Incremental"
+)
+
+// BackupEngine defines how the data of the backup is taken.
+type BackupEngine string
+
+const (
+	BackupEngineXtrabackup     BackupEngine = "xtrabackup"
+	BackupEngineVolumeSnapshot BackupEngine = "volumesnapshot"
+)
+
+// BackupConsistencyMode defines how the full backup keeps the snapshot consistent.
+type BackupConsistencyMode string
+
+const (
+	ConsistencyModeDefault      BackupConsistencyMode = "Default"
+	ConsistencyModeSnapshotLock BackupConsistencyMode = "SnapshotLock"
+)
+
+// BackupSourceRole defines the role of the pod which the backup is taken from.
+type BackupSourceRole string
+
+const (
+	BackupSourceFollower BackupSourceRole = "follower"
+	BackupSourceLearner  BackupSourceRole = "learner"
+	BackupSourceLeader   BackupSourceRole = "leader"
+)
+
+// MaxCheckpointBarrierRetries is the max retries of the checkpoint with the barrier ordering.
+const MaxCheckpointBarrierRetries = 5
+
+// BackupCheckpointOrderingStatus records the coordinated checkpoint of GMS and DNs.
+type BackupCheckpointOrderingStatus struct {
+	// Ordering is the ordering applied.
+	Ordering BackupCheckpointOrdering `json:"ordering,omitempty"`
+
+	// GMSOffset is the binlog offset of GMS captured as the end of its binlog backup.
+	// +optional
+	GMSOffset string `json:"gmsOffset,omitempty"`
+
+	// GMSCaptureTime is the time when the GMS offset is captured.
+	// +optional
+	GMSCaptureTime *metav1.Time `json:"gmsCaptureTime,omitempty"`
+
+	// HeartbeatTime is the time when the heartbeat is sent, i.e. the checkpoint of DNs.
+	// +optional
+	HeartbeatTime *metav1.Time `json:"heartbeatTime,omitempty"`
+
+	// BarrierRetries is the count of retries since the metadata changes during the checkpoint,
+	// only for the barrier ordering.
+	// +optional
+	BarrierRetries int32 `json:"barrierRetries,omitempty"`
+}
+
+// BackupCDCCheckpoint records the CDC position captured at the backup checkpoint.
+type BackupCDCCheckpoint struct {
+	// HeartBeatName is the heartbeat of the backup checkpoint which the position is captured with.
+	HeartBeatName string `json:"heartbeat,omitempty"`
+
+	// Position is the position of the global binlog emitted by CDC, in format "file:position".
+	Position string `json:"position,omitempty"`
+
+	// CaptureTime is the time when the position is captured.
+	// +optional
+	CaptureTime *metav1.Time `json:"captureTime,omitempty"`
+
+	// Divergence is the time between the heartbeat and the capture of the position.
+	// +optional
+	Divergence string `json:"divergence,omitempty"`
+}
+
+// BackupUploadRetry defines how the failed requests of an uploaded object are retried.
+type BackupUploadRetry struct {
+	// MaxRetries is the max times a failed request of an object is retried
+	// +kubebuilder:default=3
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxRetries int32 `json:"maxRetries,omitempty"`
+	// Backoff is the interval before the first retry, doubled on each retry and up to 30s
+	// +kubebuilder:default="1s"
+	// +optional
+	Backoff metav1.Duration `json:"backoff,omitempty"`
+}
+
+// BackupConsistencyWait defines how a just-uploaded object is polled until it's readable.
+type BackupConsistencyWait struct {
+	// Timeout is the max time to wait for an object to be readable
+	// +kubebuilder:default="1m"
+	// +optional
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+	// Interval is the interval between the polls
+	// +kubebuilder:default="1s"
+	// +optional
+	Interval metav1.Duration `json:"interval,omitempty"`
+}
+
+// BackupEncryption defines the key which the backup files are encrypted with.
+type BackupEncryption struct {
+	// SecretName is the name of the secret holding the key, in the same namespace.
+	SecretName string `json:"secretName"`
+	// Key is the key of the secret data holding the AES-256 key, i.e. 32 bytes, raw or encoded in
+	// base64 or hex. Default is "key".
+	// +kubebuilder:default=key
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// BackupVerification defines the restore drill verifying a finished backup.
+type BackupVerification struct {
+	// Checks are the commands run one by one in the engine container of the restored node besides
+	// the table check, e.g. a canary query or a check of sentinel rows.
+	// +optional
+	Checks []XStoreRestoreExec `json:"checks,omitempty"`
+	// SkipTableCheck skips the built-in check which checks and checksums all the tables.
+	// +optional
+	SkipTableCheck bool `json:"skipTableCheck,omitempty"`
+	// Timeout is the time within which the drill must finish, it fails otherwise. Default is 6h.
+	// +kubebuilder:default="6h"
+	// +optional
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+}
+
+// BackupThrottle defines the limits of disk IO and network bandwidth of the backup jobs.
+type BackupThrottle struct {
+	// MaxIOPS is the max IO operations per second of copying the data files by xtrabackup, i.e.
+	// the --throttle of xtrabackup. Zero means unlimited.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxIOPS int64 `json:"maxIOPS,omitempty"`
+	// MaxBandwidthMB is the max upload bandwidth in MB/s of each backup job, shared by all the
+	// upload threads. Zero means unlimited.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxBandwidthMB int64 `json:"maxBandwidthMB,omitempty"`
+}
+
+// BackupJobTemplate defines the scheduling controls and resources of the backup jobs. The jobs read
+// the data and binlogs of the target pod, so they're always run on the node of the target pod; the
+// node selector and affinity aren't used to choose the node, but a job whose node doesn't match them
+// fails to start.
+type BackupJobTemplate struct {
+	// NodeSelector is merged into the node selector of the job pods.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// Tolerations are appended to the tolerations of the job pods, which are inherited from the target pod.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// Affinity replaces the affinity of the job pods, which is inherited from the target pod.
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+	// Resources defines the resource requests and limits of the job containers. Default is none.
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+	// PriorityClassName is the priority class of the job pods. Default is the one of the target pod.
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// TTLSecondsAfterFinished is the TTL of the finished jobs. The jobs are removed by the backup once
+	// observed finished, the TTL cleans up the ones left behind, e.g. of the backups deleted during
+	// running. It must be long enough for the backup to observe the jobs. Default is no TTL.
+	// +kubebuilder:validation:Minimum=60
+	// +optional
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+}
+
+// BackupCopySource defines the backup to copy from.
+type BackupCopySource struct {
+	// BackupName is the name of the backup to copy from, in the same namespace.
+	BackupName string `json:"backupName,omitempty"`
+}
+
+// BackupObjectShare defines the object of backup to share.
+type BackupObjectShare struct {
+	// Object is the path of the object relative to the backup root path,
+	// e.g. "fullbackup/pxc-dn-0.xbstream".
+	Object string `json:"object,omitempty"`
+
+	// Expiry defines how long the pre-signed url is valid. Default is 1h.
+	// +optional
+	Expiry metav1.Duration `json:"expiry,omitempty"`
+}
+
+// BackupObjectShareStatus records the pre-signed url of the shared object.
+type BackupObjectShareStatus struct {
+	// Object is the path of the shared object relative to the backup root path.
+	Object string `json:"object,omitempty"`
+
+	// Expiry is the expiry used to sign the url.
+	Expiry metav1.Duration `json:"expiry,omitempty"`
+
+	// URL is the pre-signed url to download the object.
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// ExpireTime is the time when the url expires.
+	// +optional
+	ExpireTime *metav1.Time `json:"expireTime,omitempty"`
+
+	// Message represents the reason if the url isn't generated.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// BackupRestorePreview defines the restore to validate.
+type BackupRestorePreview struct {
+	// Time defines the time to restore to, in the format of 'yyyy-MM-dd HH:mm:ss'. Default is the
+	// latest recoverable timestamp of the backup.
+	// +optional
+	Time string `json:"time,omitempty"`
+
+	// TimeZone defines the time zone of the restore time. Default is UTC, the same as the restore.
+	// +optional
+	TimeZone string `json:"timezone,omitempty"`
+
+	// EngineVersion defines the engine version of the cluster to restore to. Default is the
+	// current engine version of each xstore of the cluster.
+	// +optional
+	EngineVersion string `json:"engineVersion,omitempty"`
+
+	// VerifyChecksums verifies the full backups against their chunk manifests, which are recorded
+	// only if the dedup report is enabled. It downloads each full backup entirely, so it takes as
+	// long as downloading the backup. Default is false.
+	// +optional
+	VerifyChecksums bool `json:"verifyChecksums,omitempty"`
+
+	// +kubebuilder:default="1h"
+
+	// Timeout defines the max duration of the preview. Default is 1h.
+	// +optional
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+}
+
+// BackupRestorePreviewResult defines the result of restore preview.
+type BackupRestorePreviewResult string
+
+const (
+	RestorePreviewRunning BackupRestorePreviewResult = "Running"
+	RestorePreviewPassed  BackupRestorePreviewResult = "Passed"
+	RestorePreviewFailed  BackupRestorePreviewResult = "Failed"
+)
+
+// Valid checks of restore preview.
+const (
+	RestorePreviewCheckRestoreTime     = "RestoreTimeCovered"
+	RestorePreviewCheckEngineVersion   = "EngineVersionCompatible"
+	RestorePreviewCheckObjects         = "ObjectsReadable"
+	RestorePreviewCheckBinlogChain     = "BinlogChainIntact"
+	RestorePreviewCheckChecksums       = "ChecksumsMatch"
+	RestorePreviewCheckPreviewFinished = "PreviewFinished"
+)
+
+// BackupRestorePreviewCheck records the result of a single check of restore preview.
+type BackupRestorePreviewCheck struct {
+	// Name is the name of the check, e.g. ObjectsReadable.
+	Name string `json:"name,omitempty"`
+
+	// XStore is the xstore checked, empty if the check is about the whole backup.
+	// +optional
+	XStore string `json:"xstore,omitempty"`
+
+	// Passed tells whether the check is passed.
+	Passed bool `json:"passed"`
+
+	// Message represents the details of the check.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// BackupRestorePreviewStatus records the result of restore preview.
+type BackupRestorePreviewStatus struct {
+	// ObservedGeneration is the generation of backup which the preview runs for.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Result is Running until all checks are done, then Passed only if all checks are passed.
+	Result BackupRestorePreviewResult `json:"result,omitempty"`
+
+	// Time is the time to restore to validated.
+	// +optional
+	Time *metav1.Time `json:"time,omitempty"`
+
+	// Job is the job which checks the objects.
+	// +optional
+	Job string `json:"job,omitempty"`
+
+	// StartTime is the time when the preview starts.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// FinishTime is the time when the result is decided.
+	// +optional
+	FinishTime *metav1.Time `json:"finishTime,omitempty"`
+
+	// Checks records the details of each check.
+	// +optional
+	Checks []BackupRestorePreviewCheck `json:"checks,omitempty"`
 }
 
 // PolarDBXBackupPhase defines the phase of backup
@@ -67,11 +755,66 @@ const (
 	FullBackuping     PolarDBXBackupPhase = "FullBackuping"
 	BackupCollecting  PolarDBXBackupPhase = "Collecting"
 	BackupCalculating PolarDBXBackupPhase = "Calculating"
+	BackupCopying     PolarDBXBackupPhase = "Copying"
 	BinlogBackuping   PolarDBXBackupPhase = "BinlogBackuping"
 	BackupFinished    PolarDBXBackupPhase = "Finished"
 	BackupFailed      PolarDBXBackupPhase = "Failed"
 )
 
+// BackupFailureReason is the machine-stable reason of a failed backup, alerting and
+// runbooks are supposed to key off it rather than the human message.
+type BackupFailureReason string
+
+const (
+	// BackupFailureTimeout means a backup job exceeded its active deadline.
+	BackupFailureTimeout BackupFailureReason = "Timeout"
+	// BackupFailureJobCrash means a backup job failed, e.g. exceeded its backoff limit.
+	BackupFailureJobCrash BackupFailureReason = "JobCrash"
+	// BackupFailureBroken means some of the underlying xstore backups are missing.
+	BackupFailureBroken BackupFailureReason = "BackupBroken"
+	// BackupFailureSourceLag means the follower to back up lags more than the bound.
+	BackupFailureSourceLag BackupFailureReason = "SourceLagExceeded"
+	// BackupFailureSourceLagUnknown means the lag of the follower to back up is unknown until the timeout.
+	BackupFailureSourceLagUnknown BackupFailureReason = "SourceLagUnknown"
+	// BackupFailureInvalidTimestamp means the last event timestamp of the binlog backup is missing or insane.
+	BackupFailureInvalidTimestamp BackupFailureReason = "InvalidEventTimestamp"
+	// BackupFailureCopy means the backup to copy from is unavailable or the copy failed.
+	BackupFailureCopy BackupFailureReason = "CopyFailed"
+	// BackupFailureStorageClass means the storage class isn't supported by the storage.
+	BackupFailureStorageClass BackupFailureReason = "UnsupportedStorageClass"
+	// BackupFailureOperatorUpgrade means a backup job is created by operator of another version.
+	BackupFailureOperatorUpgrade BackupFailureReason = "OperatorUpgraded"
+	// BackupFailureInvalidGroup means the xstores of backup group are not found in the cluster.
+	BackupFailureInvalidGroup BackupFailureReason = "InvalidGroup"
+	// BackupFailureCheckpointCoordinator means the checkpoint coordinator of the backup is unknown.
+	BackupFailureCheckpointCoordinator BackupFailureReason = "UnknownCheckpointCoordinator"
+	// BackupFailureCheckpointBarrier means the metadata keeps changing during the checkpoint with
+	// the barrier ordering.
+	BackupFailureCheckpointBarrier BackupFailureReason = "CheckpointBarrierExceeded"
+	// BackupFailureTopologyChanged means the topology of xstore changed during the backup.
+	BackupFailureTopologyChanged BackupFailureReason = "TopologyChanged"
+	// BackupFailureIncrementalBase means the specified base of the incremental backup is unusable.
+	BackupFailureIncrementalBase BackupFailureReason = "IncrementalBaseInvalid"
+	// BackupFailureEncryptionKey means the encryption key of the backup is not found or invalid.
+	BackupFailureEncryptionKey BackupFailureReason = "EncryptionKeyInvalid"
+	// BackupFailureHook means a pre or post backup hook failed.
+	BackupFailureHook BackupFailureReason = "HookFailed"
+	// BackupFailureVolumeSnapshot means the volume snapshot of the data PVC can't be taken.
+	BackupFailureVolumeSnapshot BackupFailureReason = "VolumeSnapshotFailed"
+)
+
+// BackupTriggerSource represents how a backup came to exist.
+type BackupTriggerSource string
+
+const (
+	// BackupTriggerManual means the backup is created by hand, e.g. by kubectl. It's the default.
+	BackupTriggerManual BackupTriggerSource = "Manual"
+	// BackupTriggerScheduled means the backup is created by a backup schedule.
+	BackupTriggerScheduled BackupTriggerSource = "Scheduled"
+	// BackupTriggerAPI means the backup is created by an external service through the api.
+	BackupTriggerAPI BackupTriggerSource = "API"
+)
+
 // PolarDBXBackupStatus defines the observed state of PolarDBXBackup
 type PolarDBXBackupStatus struct {
 	// StartTime represents the backup start time.
@@ -90,6 +833,14 @@ type PolarDBXBackupStatus struct {
 	// +optional
 	Reason string `json:"reason,omitempty"`
 
+	// FailureReason represents the machine-stable reason of failure.
+	// +optional
+	FailureReason BackupFailureReason `json:"failureReason,omitempty"`
+
+	// TriggerSource represents how the backup came to exist, i.e. Manual, Scheduled or API.
+	// +optional
+	TriggerSource BackupTriggerSource `json:"triggerSource,omitempty"`
+
 	// Backups represents the underlying backup objects of xstore. The key is
 	// cluster name, and the value is the backup name.
 	// +optional
@@ -99,6 +850,11 @@ type PolarDBXBackupStatus struct {
 	// +optional
 	XStores []string `json:"xstores,omitempty"`
 
+	// QueuedXStores represents the xstores whose backups are not started yet due to the limit of
+	// concurrent xstore backups, in the order they are going to start.
+	// +optional
+	QueuedXStores []string `json:"queuedXStores,omitempty"`
+
 	// ClusterSpecSnapshot records the snapshot of polardbx cluster spec
 	// +optional
 	ClusterSpecSnapshot *PolarDBXClusterSpec `json:"clusterSpecSnapshot,omitempty"`
@@ -112,11 +868,114 @@ type PolarDBXBackupStatus struct {
 	// BackupRootPath stores the root path of backup set
 	BackupRootPath string `json:"backupRootPath,omitempty"`
 
+	// StorageClass records the storage class of the uploaded backup files, empty means the default.
+	// +optional
+	StorageClass string `json:"storageClass,omitempty"`
+
+	// CDCCheckpoint records the CDC position captured at the backup checkpoint.
+	// +optional
+	CDCCheckpoint *BackupCDCCheckpoint `json:"cdcCheckpoint,omitempty"`
+
+	// CheckpointOrdering records how the GMS offset is ordered with the checkpoint of DNs.
+	// +optional
+	CheckpointOrdering *BackupCheckpointOrderingStatus `json:"checkpointOrdering,omitempty"`
+
 	// BackupSetTimestamp records timestamp of last event included in tailored binlog per xstore
 	BackupSetTimestamp map[string]*metav1.Time `json:"backupSetTimestamp,omitempty"`
 
 	// LatestRecoverableTimestamp records the latest timestamp that can recover from current backup set
 	LatestRecoverableTimestamp *metav1.Time `json:"latestRecoverableTimestamp,omitempty"`
+
+	// EarliestRecoverableTimestamp records the earliest timestamp that can recover from current backup
+	// set, i.e. the start of the backup. Both timestamps are also labeled in unix seconds once the backup
+	// is finished.
+	// +optional
+	EarliestRecoverableTimestamp *metav1.Time `json:"earliestRecoverableTimestamp,omitempty"`
+
+	// Share records the pre-signed url of the shared object.
+	// +optional
+	Share *BackupObjectShareStatus `json:"share,omitempty"`
+
+	// RestorePreview records the result of restore preview.
+	// +optional
+	RestorePreview *BackupRestorePreviewStatus `json:"restorePreview,omitempty"`
+
+	// CopiedFrom represents the backup which this backup is copied from.
+	// +optional
+	CopiedFrom string `json:"copiedFrom,omitempty"`
+
+	// CopiedFiles represents the number of backup files copied.
+	// +optional
+	CopiedFiles int64 `json:"copiedFiles,omitempty"`
+
+	// FailedFilesRemoved tells whether the files partially uploaded by the failed backup are removed
+	// with the retention credential once the retention of failed artifacts elapses.
+	// +optional
+	FailedFilesRemoved bool `json:"failedFilesRemoved,omitempty"`
+
+	// CircuitBreaker records the consecutive failures of the backup.
+	// +optional
+	CircuitBreaker *BackupCircuitBreakerStatus `json:"circuitBreaker,omitempty"`
+
+	// ManifestPath represents the path of the manifest exported along with the backup files, with
+	// which the backup can be restored when no object of it exists, e.g. in another kubernetes cluster.
+	// It's only exported with the retention credential of the storage provider. The manifest holds
+	// the account passwords of the backup, encrypted with the encryption key if the backup is encrypted.
+	// +optional
+	ManifestPath string `json:"manifestPath,omitempty"`
+
+	// Trace records the trace context of the backup, only if tracing is enabled in operator.
+	// +optional
+	Trace *BackupTrace `json:"trace,omitempty"`
+
+	// ImmutableUntil represents the time until which the backup files are required to be locked,
+	// either by spec.immutableUntil or derived from the retention. The lock is verified once it's
+	// recorded, see the WormLocked condition.
+	// +optional
+	ImmutableUntil *metav1.Time `json:"immutableUntil,omitempty"`
+
+	// Conditions represents the conditions of the backup.
+	// +optional
+	Conditions []xstore.Condition `json:"conditions,omitempty"`
+
+	// Progress represents the phases the backup has gone through.
+	// +optional
+	Progress *common.OperationProgress `json:"progress,omitempty"`
+}
+
+// BackupTrace records the trace context of backup. The spans of the backup and its phases are
+// emitted once they end, across the reconciliations, with the start times recorded here.
+type BackupTrace struct {
+	// TraceParent is the w3c trace context of the span of backup, e.g. "00-<trace-id>-<span-id>-01".
+	TraceParent string `json:"traceParent"`
+
+	// Phase is the phase of the current phase span.
+	// +optional
+	Phase string `json:"phase,omitempty"`
+
+	// PhaseStartTime is when the current phase span starts.
+	// +optional
+	PhaseStartTime *metav1.Time `json:"phaseStartTime,omitempty"`
+
+	// Ended indicates the span of backup has been emitted.
+	// +optional
+	Ended bool `json:"ended,omitempty"`
+}
+
+// BackupCircuitBreakerStatus records the consecutive failures of the same step with the same reason.
+type BackupCircuitBreakerStatus struct {
+	// Step is the name of the failed step.
+	Step string `json:"step,omitempty"`
+
+	// Reason is the error of the failures.
+	Reason string `json:"reason,omitempty"`
+
+	// Failures is the count of consecutive failures.
+	Failures int32 `json:"failures,omitempty"`
+
+	// LastFailureTime is the time of the last failure.
+	// +optional
+	LastFailureTime *metav1.Time `json:"lastFailureTime,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -126,8 +985,12 @@ type PolarDBXBackupStatus struct {
 // +kubebuilder:printcolumn:name="START",type=string,JSONPath=`.status.startTime`
 // +kubebuilder:printcolumn:name="END",type=string,JSONPath=`.status.endTime`
 // +kubebuilder:printcolumn:name="RESTORE_TIME",type=string,JSONPath=`.status.latestRecoverableTimestamp`
+// +kubebuilder:printcolumn:name="RECOVERABLE_FROM",type=string,priority=1,JSONPath=`.status.earliestRecoverableTimestamp`
 // +kubebuilder:printcolumn:name="PHASE",type=string,JSONPath=`.status.phase`
 // +kubebuilder:printcolumn:name="RETENTION",type=string,priority=1,JSONPath=`.spec.retentionTime`
+// +kubebuilder:printcolumn:name="FAILURE",type=string,priority=1,JSONPath=`.status.failureReason`
+// +kubebuilder:printcolumn:name="TRIGGER",type=string,priority=1,JSONPath=`.status.triggerSource`
+// +kubebuilder:printcolumn:name="PREVIEW",type=string,priority=1,JSONPath=`.status.restorePreview.result`
 // +kubebuilder:printcolumn:name="AGE",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // PolarDBXBackup is the Scheme for the polardbxbackups API
@@ -0,0 +1,147 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PolarDBXBackupReapSpec defines the desired state of PolarDBXBackupReap
+type PolarDBXBackupReapSpec struct {
+	// Cluster represents the reference of the deleted polardbx cluster, whose backups are reaped.
+	// The reap fails if the cluster still exists.
+	Cluster PolarDBXClusterReference `json:"cluster,omitempty"`
+
+	// Confirm must be the same as the name of cluster, otherwise nothing is reaped. It guards
+	// against reaping the backups of a wrong cluster by accident.
+	Confirm string `json:"confirm,omitempty"`
+}
+
+// PolarDBXBackupReapPhase defines the phase of backup reap
+type PolarDBXBackupReapPhase string
+
+const (
+	ReapNew      PolarDBXBackupReapPhase = ""
+	ReapReaping  PolarDBXBackupReapPhase = "Reaping"
+	ReapFinished PolarDBXBackupReapPhase = "Finished"
+	ReapFailed   PolarDBXBackupReapPhase = "Failed"
+)
+
+// BackupReapSkipReason defines why a backup is skipped by the reap
+type BackupReapSkipReason string
+
+const (
+	// ReapSkipProtected means the backup, or an xstore backup of it, is annotated as protected.
+	ReapSkipProtected BackupReapSkipReason = "Protected"
+	// ReapSkipInProgress means the backup is neither finished nor failed.
+	ReapSkipInProgress BackupReapSkipReason = "InProgress"
+	// ReapSkipNoRetentionCredential means the files of backup can't be deleted without the
+	// retention credential of the storage provider.
+	ReapSkipNoRetentionCredential BackupReapSkipReason = "NoRetentionCredential"
+	// ReapSkipInUse means the backup is read by a restore in progress, e.g. of a cluster restored
+	// or cloned from it.
+	ReapSkipInUse BackupReapSkipReason = "InUse"
+	// ReapSkipImmutable means the backup is required to be immutable for now, or its files are
+	// locked by the WORM retention of the storage.
+	ReapSkipImmutable BackupReapSkipReason = "Immutable"
+)
+
+// ReapedBackup records a backup deleted or skipped by the reap.
+type ReapedBackup struct {
+	// Kind is the kind of the backup, i.e. PolarDBXBackup or XStoreBackup.
+	Kind string `json:"kind"`
+
+	// Name is the name of the backup.
+	Name string `json:"name"`
+
+	// Reason represents why the backup is skipped, only for the skipped ones.
+	// +optional
+	Reason BackupReapSkipReason `json:"reason,omitempty"`
+
+	// DeletedFiles is the count of files deleted from the storage, only for the deleted ones.
+	// +optional
+	DeletedFiles int64 `json:"deletedFiles,omitempty"`
+}
+
+// PolarDBXBackupReapStatus defines the observed state of PolarDBXBackupReap
+type PolarDBXBackupReapStatus struct {
+	// Phase represents the phase of the reap.
+	// +optional
+	Phase PolarDBXBackupReapPhase `json:"phase,omitempty"`
+
+	// Message represents the reason of failure.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// StartTime represents the start time of the reap.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// EndTime represents the end time of the reap.
+	// +optional
+	EndTime *metav1.Time `json:"endTime,omitempty"`
+
+	// Deleted records the backups deleted, along with their files.
+	// +optional
+	Deleted []ReapedBackup `json:"deleted,omitempty"`
+
+	// Skipped records the backups skipped and why.
+	// +optional
+	Skipped []ReapedBackup `json:"skipped,omitempty"`
+
+	// DeletedCount is the count of backups deleted.
+	// +optional
+	DeletedCount int32 `json:"deletedCount,omitempty"`
+
+	// SkippedCount is the count of backups skipped.
+	// +optional
+	SkippedCount int32 `json:"skippedCount,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=pxcbackupreap;pxbreap
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="CLUSTER",type=string,JSONPath=`.spec.cluster.name`
+// +kubebuilder:printcolumn:name="PHASE",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="DELETED",type=integer,JSONPath=`.status.deletedCount`
+// +kubebuilder:printcolumn:name="SKIPPED",type=integer,JSONPath=`.status.skippedCount`
+// +kubebuilder:printcolumn:name="MESSAGE",type=string,priority=1,JSONPath=`.status.message`
+// +kubebuilder:printcolumn:name="AGE",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// PolarDBXBackupReap is the Scheme for the polardbxbackupreaps API. It deletes all the backups of
+// a deleted cluster along with their files in storage, except the protected and in-progress ones,
+// and reports what's deleted and what's skipped.
+type PolarDBXBackupReap struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PolarDBXBackupReapSpec   `json:"spec,omitempty"`
+	Status PolarDBXBackupReapStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PolarDBXBackupReapList contains a list of PolarDBXBackupReap
+type PolarDBXBackupReapList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PolarDBXBackupReap `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PolarDBXBackupReap{}, &PolarDBXBackupReapList{})
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BackupConcurrencyPolicy defines how a scheduled backup is treated if the previous one is still in progress.
+type BackupConcurrencyPolicy string
+
+const (
+	// BackupConcurrencyAllow allows the backups to run concurrently.
+	BackupConcurrencyAllow BackupConcurrencyPolicy = "Allow"
+	// BackupConcurrencyForbid skips the scheduled backup if the previous one is in progress.
+	BackupConcurrencyForbid BackupConcurrencyPolicy = "Forbid"
+	// BackupConcurrencyReplace deletes the backups in progress and takes the scheduled one.
+	BackupConcurrencyReplace BackupConcurrencyPolicy = "Replace"
+)
+
+// PolarDBXBackupScheduleSpec defines the desired state of PolarDBXBackupSchedule
+type PolarDBXBackupScheduleSpec struct {
+	// Schedule is the cron expression of the backups, i.e. "minute hour day-of-month month day-of-week",
+	// e.g. "0 2 * * *". Descriptors like "@daily" are also accepted.
+	Schedule string `json:"schedule"`
+
+	// TimeZone is the IANA name of the time zone which the schedule is interpreted in, e.g.
+	// "Asia/Shanghai". Default is UTC.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// Suspend stops scheduling the backups. The backups taken are kept and pruned as usual.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// +kubebuilder:default=Forbid
+	// +kubebuilder:validation:Enum=Allow;Forbid;Replace
+
+	// ConcurrencyPolicy defines how a scheduled backup is treated if the previous one is still in
+	// progress. Default is Forbid, which skips the scheduled one.
+	// +optional
+	ConcurrencyPolicy BackupConcurrencyPolicy `json:"concurrencyPolicy,omitempty"`
+
+	// StartingDeadline defines how late a backup may be taken after its scheduled time, e.g. when the
+	// operator is down. Only the latest missed one is taken, and it's skipped if the deadline is
+	// exceeded. Default is no deadline.
+	// +optional
+	StartingDeadline metav1.Duration `json:"startingDeadline,omitempty"`
+
+	// MaxBackupCount is the count of finished backups kept by the schedule. The oldest ones beyond it
+	// are deleted along with their files, unless they're protected, immutable, in use by a restore or
+	// the base of incremental backups. The retention time of the backup still applies. Zero means no
+	// limit.
+	// +optional
+	MaxBackupCount int32 `json:"maxBackupCount,omitempty"`
+
+	// +kubebuilder:default=3
+
+	// MaxFailedBackupCount is the count of failed backups kept by the schedule for diagnosis. Zero
+	// means no limit. Default is 3.
+	// +optional
+	MaxFailedBackupCount int32 `json:"maxFailedBackupCount,omitempty"`
+
+	// BackupSpec is the template of the backups, e.g. the cluster, backup type, retention and storage.
+	BackupSpec PolarDBXBackupSpec `json:"backupSpec,omitempty"`
+}
+
+// PolarDBXBackupScheduleStatus defines the observed state of PolarDBXBackupSchedule
+type PolarDBXBackupScheduleStatus struct {
+	// LastScheduleTime is the scheduled time of the last backup taken or skipped.
+	// +optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+
+	// NextScheduleTime is when the next backup is scheduled, empty if suspended.
+	// +optional
+	NextScheduleTime *metav1.Time `json:"nextScheduleTime,omitempty"`
+
+	// LastBackup is the name of the last backup taken.
+	// +optional
+	LastBackup string `json:"lastBackup,omitempty"`
+
+	// Active lists the backups in progress.
+	// +optional
+	Active []string `json:"active,omitempty"`
+
+	// Message represents why the last scheduled backup is skipped, or why the schedule is invalid.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// LastGarbageCollectTime is when the expired files of the deleted backups are last collected. It's
+	// only done if the clean policy of the backups is Delete.
+	// +optional
+	LastGarbageCollectTime *metav1.Time `json:"lastGarbageCollectTime,omitempty"`
+
+	// GarbageCollected lists the backup root paths whose files are deleted by the last collection.
+	// +optional
+	GarbageCollected []string `json:"garbageCollected,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=pxcbackupschedule;pxbschedule
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="CLUSTER",type=string,JSONPath=`.spec.backupSpec.cluster.name`
+// +kubebuilder:printcolumn:name="SCHEDULE",type=string,JSONPath=`.spec.schedule`
+// +kubebuilder:printcolumn:name="SUSPEND",type=boolean,JSONPath=`.spec.suspend`
+// +kubebuilder:printcolumn:name="LAST_SCHEDULE",type=date,JSONPath=`.status.lastScheduleTime`
+// +kubebuilder:printcolumn:name="NEXT_SCHEDULE",type=string,JSONPath=`.status.nextScheduleTime`
+// +kubebuilder:printcolumn:name="LAST_BACKUP",type=string,JSONPath=`.status.lastBackup`
+// +kubebuilder:printcolumn:name="MESSAGE",type=string,priority=1,JSONPath=`.status.message`
+// +kubebuilder:printcolumn:name="AGE",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// PolarDBXBackupSchedule is the Scheme for the polardbxbackupschedules API. It takes the backups of
+// a cluster from the template on the cron schedule, prunes the history of them and collects the
+// expired files left by the deleted ones. The backups are
+// labeled with the schedule but not owned by it, so they're kept after the schedule is deleted.
+type PolarDBXBackupSchedule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PolarDBXBackupScheduleSpec   `json:"spec,omitempty"`
+	Status PolarDBXBackupScheduleStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PolarDBXBackupScheduleList contains a list of PolarDBXBackupSchedule
+type PolarDBXBackupScheduleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PolarDBXBackupSchedule `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PolarDBXBackupSchedule{}, &PolarDBXBackupScheduleList{})
+}
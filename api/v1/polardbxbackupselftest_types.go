@@ -0,0 +1,174 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PolarDBXBackupSelfTestSpec defines the desired state of PolarDBXBackupSelfTest
+type PolarDBXBackupSelfTestSpec struct {
+	// Cluster represents the reference of the disposable polardbx cluster to test against. A full
+	// backup is taken from it and restored to a new cluster to verify. The cluster itself is never
+	// modified or removed.
+	Cluster PolarDBXClusterReference `json:"cluster,omitempty"`
+
+	// StorageProvider defines the backend storage to store the backup files.
+	StorageProvider BackupStorageProvider `json:"storageProvider,omitempty"`
+
+	// +kubebuilder:default="24h"
+
+	// BackupRetentionTime defines the retention time of the backup taken. The backup object is
+	// removed once the self test is over, and the backup files are left to the retention of the
+	// storage. Default is 24h.
+	// +optional
+	BackupRetentionTime metav1.Duration `json:"backupRetentionTime,omitempty"`
+
+	// +kubebuilder:default="6h"
+
+	// Timeout defines the max duration of the whole self test. The self test fails once it's
+	// exceeded. Default is 6h.
+	// +optional
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+}
+
+// PolarDBXBackupSelfTestPhase defines the phase of backup self test
+type PolarDBXBackupSelfTestPhase string
+
+const (
+	SelfTestNew       PolarDBXBackupSelfTestPhase = ""
+	SelfTestBackingUp PolarDBXBackupSelfTestPhase = "BackingUp"
+	SelfTestRestoring PolarDBXBackupSelfTestPhase = "Restoring"
+	SelfTestVerifying PolarDBXBackupSelfTestPhase = "Verifying"
+	SelfTestCleaning  PolarDBXBackupSelfTestPhase = "Cleaning"
+	SelfTestFinished  PolarDBXBackupSelfTestPhase = "Finished"
+)
+
+// PolarDBXBackupSelfTestResult defines the result of backup self test
+type PolarDBXBackupSelfTestResult string
+
+const (
+	SelfTestPassed PolarDBXBackupSelfTestResult = "Passed"
+	SelfTestFailed PolarDBXBackupSelfTestResult = "Failed"
+)
+
+// BackupSelfTestTimings records how long each stage of the self test takes, e.g. "5m30s".
+type BackupSelfTestTimings struct {
+	// Backup is the duration of the backup.
+	// +optional
+	Backup string `json:"backup,omitempty"`
+
+	// Restore is the duration of the restore.
+	// +optional
+	Restore string `json:"restore,omitempty"`
+
+	// Verify is the duration of the verification.
+	// +optional
+	Verify string `json:"verify,omitempty"`
+
+	// Total is the duration of the whole self test, including the cleaning.
+	// +optional
+	Total string `json:"total,omitempty"`
+}
+
+// PolarDBXBackupSelfTestStatus defines the observed state of PolarDBXBackupSelfTest
+type PolarDBXBackupSelfTestStatus struct {
+	// Phase represents the phase of the self test.
+	// +optional
+	Phase PolarDBXBackupSelfTestPhase `json:"phase,omitempty"`
+
+	// Result represents the result of the self test, i.e. Passed or Failed, once it's decided.
+	// +optional
+	Result PolarDBXBackupSelfTestResult `json:"result,omitempty"`
+
+	// FailedPhase represents the phase in which the self test fails.
+	// +optional
+	FailedPhase PolarDBXBackupSelfTestPhase `json:"failedPhase,omitempty"`
+
+	// Message represents the reason of failure.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// StartTime represents the start time of the self test.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// PhaseStartTime represents the start time of the current phase.
+	// +optional
+	PhaseStartTime *metav1.Time `json:"phaseStartTime,omitempty"`
+
+	// EndTime represents the end time of the self test.
+	// +optional
+	EndTime *metav1.Time `json:"endTime,omitempty"`
+
+	// Backup represents the name of the backup taken.
+	// +optional
+	Backup string `json:"backup,omitempty"`
+
+	// RestoreTime represents the time restored to, in the format of 'yyyy-MM-dd HH:mm:ss' in UTC.
+	// +optional
+	RestoreTime string `json:"restoreTime,omitempty"`
+
+	// RestoredCluster represents the name of the cluster restored.
+	// +optional
+	RestoredCluster string `json:"restoredCluster,omitempty"`
+
+	// Schemas represents the schemas of the cluster when the backup starts, they're expected to
+	// exist in the restored cluster.
+	// +optional
+	Schemas []string `json:"schemas,omitempty"`
+
+	// Timings records the duration of each stage.
+	// +optional
+	Timings BackupSelfTestTimings `json:"timings,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=pxcbackupselftest;pxbst
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="CLUSTER",type=string,JSONPath=`.spec.cluster.name`
+// +kubebuilder:printcolumn:name="PHASE",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="RESULT",type=string,JSONPath=`.status.result`
+// +kubebuilder:printcolumn:name="TOTAL",type=string,JSONPath=`.status.timings.total`
+// +kubebuilder:printcolumn:name="BACKUP",type=string,priority=1,JSONPath=`.status.timings.backup`
+// +kubebuilder:printcolumn:name="RESTORE",type=string,priority=1,JSONPath=`.status.timings.restore`
+// +kubebuilder:printcolumn:name="MESSAGE",type=string,priority=1,JSONPath=`.status.message`
+// +kubebuilder:printcolumn:name="AGE",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// PolarDBXBackupSelfTest is the Scheme for the polardbxbackupselftests API. It runs a full
+// backup, a point-in-time restore and a verification against a disposable cluster, and reports
+// the result with timings. All objects created are removed afterward regardless of the result.
+type PolarDBXBackupSelfTest struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PolarDBXBackupSelfTestSpec   `json:"spec,omitempty"`
+	Status PolarDBXBackupSelfTestStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PolarDBXBackupSelfTestList contains a list of PolarDBXBackupSelfTest
+type PolarDBXBackupSelfTestList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PolarDBXBackupSelfTest `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PolarDBXBackupSelfTest{}, &PolarDBXBackupSelfTestList{})
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PolarDBXBinlogBackupSpec defines the desired state of PolarDBXBinlogBackup
+type PolarDBXBinlogBackupSpec struct {
+	// Cluster represents the reference of the polardbx cluster whose binlogs are shipped.
+	Cluster PolarDBXClusterReference `json:"cluster,omitempty"`
+
+	// StorageProvider defines the storage which the binlogs are shipped to.
+	StorageProvider BackupStorageProvider `json:"storageProvider,omitempty"`
+
+	// +kubebuilder:default="30s"
+
+	// Interval is the interval between the rounds of shipping of each xstore. Each round uploads the
+	// binlogs closed since the last round, so the recovery point is as fresh as the last rotated
+	// binlog. Default is 30s.
+	// +optional
+	Interval metav1.Duration `json:"interval,omitempty"`
+
+	// +kubebuilder:default=16
+	// +kubebuilder:validation:Minimum=1
+
+	// MaxFilesPerRound bounds the binlogs uploaded by a round, so that the rounds are short and the
+	// index is updated along the way when there's a backlog. Default is 16.
+	// +optional
+	MaxFilesPerRound int32 `json:"maxFilesPerRound,omitempty"`
+
+	// Suspend stops the shipping. The binlogs shipped are kept.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// BinlogBackupPhase defines the phase of binlog backup.
+type BinlogBackupPhase string
+
+const (
+	BinlogBackupNew       BinlogBackupPhase = ""
+	BinlogBackupRunning   BinlogBackupPhase = "Running"
+	BinlogBackupSuspended BinlogBackupPhase = "Suspended"
+)
+
+// MaxIndexedBinlogFiles is the max count of binlog files indexed in status of each xstore. The older
+// ones are dropped from status, their index is kept next to them in the storage.
+const MaxIndexedBinlogFiles = 256
+
+// BinlogFileIndex records the range of a shipped binlog file.
+type BinlogFileIndex struct {
+	// Name is the name of the binlog file, e.g. mysql_bin.000001.
+	Name string `json:"name"`
+
+	// Size is the size of the binlog file in bytes.
+	Size int64 `json:"size,omitempty"`
+
+	// Gtids is the set of GTIDs in the binlog file, empty if none, e.g.
+	// "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5".
+	// +optional
+	Gtids string `json:"gtids,omitempty"`
+
+	// FirstEventTime and LastEventTime are the time of the first and last change event.
+	// +optional
+	FirstEventTime *metav1.Time `json:"firstEventTime,omitempty"`
+	// +optional
+	LastEventTime *metav1.Time `json:"lastEventTime,omitempty"`
+
+	// EventsCount is the count of change events in the binlog file.
+	// +optional
+	EventsCount int64 `json:"eventsCount,omitempty"`
+}
+
+// XStoreBinlogBackupStatus records the shipping of an xstore.
+type XStoreBinlogBackupStatus struct {
+	// XStore is the name of the xstore.
+	XStore string `json:"xstore"`
+
+	// Pod is the pod which the binlogs are shipped from in the last round, i.e. the leader.
+	// +optional
+	Pod string `json:"pod,omitempty"`
+
+	// LastBinlog is the last binlog file shipped.
+	// +optional
+	LastBinlog string `json:"lastBinlog,omitempty"`
+
+	// LastShipTime is when the last round of shipping finished or failed.
+	// +optional
+	LastShipTime *metav1.Time `json:"lastShipTime,omitempty"`
+
+	// ShippedFiles is the total count of binlog files shipped.
+	// +optional
+	ShippedFiles int64 `json:"shippedFiles,omitempty"`
+
+	// Binlogs is the index of the latest shipped binlog files, oldest first. At most
+	// MaxIndexedBinlogFiles are kept.
+	// +optional
+	Binlogs []BinlogFileIndex `json:"binlogs,omitempty"`
+
+	// Message represents why the last round failed, empty if succeeded.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// PolarDBXBinlogBackupStatus defines the observed state of PolarDBXBinlogBackup
+type PolarDBXBinlogBackupStatus struct {
+	// Phase is the phase of binlog backup.
+	// +optional
+	Phase BinlogBackupPhase `json:"phase,omitempty"`
+
+	// StartTime is when the shipping started.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// BackupRootPath is the root path of the shipped binlogs in the storage, the binlogs of each
+	// xstore are in the directory named by the xstore.
+	// +optional
+	BackupRootPath string `json:"backupRootPath,omitempty"`
+
+	// XStores records the shipping of each xstore, i.e. the GMS and DNs.
+	// +optional
+	XStores []XStoreBinlogBackupStatus `json:"xstores,omitempty"`
+
+	// LatestEventTime is the time of the last event shipped of the xstore which lags most, i.e. the
+	// time which the cluster is recoverable to with these binlogs.
+	// +optional
+	LatestEventTime *metav1.Time `json:"latestEventTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=pxcbinlogbackup;pxblb
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="CLUSTER",type=string,JSONPath=`.spec.cluster.name`
+// +kubebuilder:printcolumn:name="PHASE",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="LATEST_EVENT",type=date,JSONPath=`.status.latestEventTime`
+// +kubebuilder:printcolumn:name="START",type=date,JSONPath=`.status.startTime`
+// +kubebuilder:printcolumn:name="AGE",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// PolarDBXBinlogBackup is the Schema for the polardbxbinlogbackups API. It ships the closed binlog files
+// of every xstore of a cluster to the storage continuously, and indexes the GTIDs and time range of each
+// file. Combined with the full backups, the cluster is recoverable to the latest shipped events.
+type PolarDBXBinlogBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PolarDBXBinlogBackupSpec   `json:"spec,omitempty"`
+	Status PolarDBXBinlogBackupStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PolarDBXBinlogBackupList contains a list of PolarDBXBinlogBackup
+type PolarDBXBinlogBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PolarDBXBinlogBackup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PolarDBXBinlogBackup{}, &PolarDBXBinlogBackupList{})
+}
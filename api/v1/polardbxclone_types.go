@@ -0,0 +1,141 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PolarDBXCloneSpec defines the desired state of PolarDBXClone
+type PolarDBXCloneSpec struct {
+	// Source represents the reference of the running polardbx cluster to clone from. The cluster
+	// itself is never modified.
+	Source PolarDBXClusterReference `json:"source,omitempty"`
+
+	// ClusterName is the name of the new cluster in the same namespace. The clone fails if a
+	// cluster of the name not created by the clone exists. Default is the name of the clone.
+	// +optional
+	ClusterName string `json:"clusterName,omitempty"`
+
+	// StorageProvider defines the backend storage to store the backup files of the source cluster.
+	StorageProvider BackupStorageProvider `json:"storageProvider,omitempty"`
+
+	// +kubebuilder:default="24h"
+
+	// BackupRetentionTime defines the retention time of the backup taken. The backup object is
+	// owned by the clone and removed along with it, while the files are left to the retention of
+	// the storage. Default is 24h.
+	// +optional
+	BackupRetentionTime metav1.Duration `json:"backupRetentionTime,omitempty"`
+
+	// SkipParameters skips copying the polardbx parameters of the source cluster to the new
+	// cluster. Default is false.
+	// +optional
+	SkipParameters bool `json:"skipParameters,omitempty"`
+
+	// +kubebuilder:default="6h"
+
+	// Timeout defines the max duration of the whole clone. The clone fails once it's exceeded,
+	// and the new cluster is left as is. Default is 6h.
+	// +optional
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+}
+
+// PolarDBXClonePhase defines the phase of clone
+type PolarDBXClonePhase string
+
+const (
+	CloneNew       PolarDBXClonePhase = ""
+	CloneBackingUp PolarDBXClonePhase = "BackingUp"
+	CloneRestoring PolarDBXClonePhase = "Restoring"
+	CloneFinished  PolarDBXClonePhase = "Finished"
+	CloneFailed    PolarDBXClonePhase = "Failed"
+)
+
+// PolarDBXCloneStatus defines the observed state of PolarDBXClone
+type PolarDBXCloneStatus struct {
+	// Phase represents the phase of the clone.
+	// +optional
+	Phase PolarDBXClonePhase `json:"phase,omitempty"`
+
+	// FailedPhase represents the phase in which the clone fails.
+	// +optional
+	FailedPhase PolarDBXClonePhase `json:"failedPhase,omitempty"`
+
+	// Message represents the reason of failure.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// StartTime represents the start time of the clone.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// EndTime represents the end time of the clone.
+	// +optional
+	EndTime *metav1.Time `json:"endTime,omitempty"`
+
+	// Backup represents the name of the backup taken from the source cluster.
+	// +optional
+	Backup string `json:"backup,omitempty"`
+
+	// RestoreTime represents the time restored to, in the format of 'yyyy-MM-dd HH:mm:ss' in UTC.
+	// +optional
+	RestoreTime string `json:"restoreTime,omitempty"`
+
+	// Cluster represents the name of the new cluster.
+	// +optional
+	Cluster string `json:"cluster,omitempty"`
+
+	// Parameters represents the names of the polardbx parameters copied to the new cluster.
+	// +optional
+	Parameters []string `json:"parameters,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=pxcclone;pxclone
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="SOURCE",type=string,JSONPath=`.spec.source.name`
+// +kubebuilder:printcolumn:name="CLUSTER",type=string,JSONPath=`.status.cluster`
+// +kubebuilder:printcolumn:name="PHASE",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="RESTORE_TIME",type=string,JSONPath=`.status.restoreTime`
+// +kubebuilder:printcolumn:name="MESSAGE",type=string,priority=1,JSONPath=`.status.message`
+// +kubebuilder:printcolumn:name="AGE",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// PolarDBXClone is the Scheme for the polardbxclones API. It takes a consistent backup of a
+// running cluster and provisions a new independent cluster from it in one step, along with
+// the accounts and the parameters. The new cluster isn't owned by the clone and is kept when
+// the clone is removed.
+type PolarDBXClone struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PolarDBXCloneSpec   `json:"spec,omitempty"`
+	Status PolarDBXCloneStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PolarDBXCloneList contains a list of PolarDBXClone
+type PolarDBXCloneList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PolarDBXClone `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PolarDBXClone{}, &PolarDBXCloneList{})
+}
@@ -21,6 +21,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
+	"github.com/alibaba/polardbx-operator/api/v1/common"
 	"github.com/alibaba/polardbx-operator/api/v1/polardbx"
 )
 
@@ -48,6 +49,13 @@ type PolarDBXClusterSpec struct {
 	// Default is ClusterIP.
 	ServiceType corev1.ServiceType `json:"serviceType,omitempty"`
 
+	// ServiceTemplates defines the additional services of the cluster, e.g. load balancers,
+	// a readonly endpoint for readonly CNs and headless services of DNs.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	ServiceTemplates []polardbx.ServiceTemplate `json:"serviceTemplates,omitempty"`
+
 	// Topology defines the desired node topology and templates.
 	Topology polardbx.Topology `json:"topology,omitempty"`
 
@@ -67,6 +75,15 @@ type PolarDBXClusterSpec struct {
 	// UpgradeStrategy defines the upgrade strategy for stateless nodes.
 	UpgradeStrategy polardbx.UpgradeStrategyType `json:"upgradeStrategy,omitempty"`
 
+	// Canary defines how the upgrade proceeds after the canary, works only when upgrade
+	// strategy is Canary.
+	// +optional
+	Canary *polardbx.CanaryUpgrade `json:"canary,omitempty"`
+
+	// Rebalance defines how the data is rebalanced on scaling of DNs, e.g. throttling and pausing.
+	// +optional
+	Rebalance *polardbx.RebalanceSpec `json:"rebalance,omitempty"`
+
 	// Restore defines the restore specification. When provided, the operator
 	// will create the cluster in restore mode. Restore might fail due to lack of
 	// backups silently.
@@ -89,6 +106,17 @@ type PolarDBXClusterSpec struct {
 	// InitReadonly is the list of readonly cluster that needs to be created and initialized
 	// +optional
 	InitReadonly []*polardbx.ReadonlyParam `json:"initReadonly,omitempty"`
+
+	// Suspended scales the CN and CDC to zero and stops the pods of GMS and DNs, while the
+	// volumes, services and secrets are kept. The cluster is resumed once it's unset.
+	// Default is false.
+	// +optional
+	Suspended bool `json:"suspended,omitempty"`
+
+	// LogCollector ships the audit logs of CNs and the slow logs of DNs to the configured sink
+	// with a sidecar collector.
+	// +optional
+	LogCollector *polardbx.LogCollector `json:"logCollector,omitempty"`
 }
 
 type PolarDBXClusterStatus struct {
@@ -128,6 +156,30 @@ type PolarDBXClusterStatus struct {
 
 	// RestartingPods represents pods need to restart
 	RestartingPods polardbx.RestartingPods `json:"restartingPods,omitempty"`
+
+	// RestoreStatus represents the restore progress per shard when restoring from backup.
+	// +optional
+	RestoreStatus *polardbx.RestoreStatus `json:"restoreStatus,omitempty"`
+
+	// ReadonlyReplication represents the replication from the primary cluster if it's readonly.
+	// +optional
+	ReadonlyReplication *polardbx.ReadonlyReplicationStatus `json:"readonlyReplication,omitempty"`
+
+	// Rebalance represents the rebalance of data in flight on scaling of DNs.
+	// +optional
+	Rebalance *polardbx.RebalanceStatus `json:"rebalance,omitempty"`
+
+	// Canary represents the canary of the last upgrade with Canary strategy.
+	// +optional
+	Canary *polardbx.CanaryStatus `json:"canary,omitempty"`
+
+	// TLS represents the certificate in use if TLS is enabled.
+	// +optional
+	TLS *polardbx.TLSStatus `json:"tls,omitempty"`
+
+	// Progress represents the stages of the last operation, e.g. creating, upgrading or restoring.
+	// +optional
+	Progress *common.OperationProgress `json:"progress,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -143,6 +195,7 @@ type PolarDBXClusterStatus struct {
 // +kubebuilder:printcolumn:name="STAGE",type=string,priority=1,JSONPath=`.status.stage`
 // +kubebuilder:printcolumn:name="REBALANCE",type=string,priority=1,JSONPath=`.status.statusForPrint.rebalanceProcess`
 // +kubebuilder:printcolumn:name="VERSION",type=string,priority=1,JSONPath=`.status.statusForPrint.detailedVersion`
+// +kubebuilder:printcolumn:name="LAG",type=integer,priority=1,JSONPath=`.status.readonlyReplication.maxLag`
 // +kubebuilder:printcolumn:name="AGE",type=date,JSONPath=`.metadata.creationTimestamp`
 
 type PolarDBXCluster struct {
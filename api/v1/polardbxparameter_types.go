@@ -29,6 +29,8 @@ type Params struct {
 type ParamNode struct {
 	// +kubebuilder:validation:Required
 	Name string `json:"name,omitempty"`
+	// RestartType is how the pods are restarted when static parameters changed, one of
+	// "restart", "rollingRestart" and "deferredRestart".
 	// +optional
 	RestartType string `json:"restartType,omitempty"`
 	// +kubebuilder:validation:Required
@@ -53,6 +55,48 @@ type PolarDBXParameterSpec struct {
 
 	// NodeType represents the type of the node parameters runs on
 	NodeType ParamNodeType `json:"nodeType"`
+
+	// DriftPolicy represents what to do when the running value of a dynamic parameter differs from
+	// the spec. Default is Report.
+	// +kubebuilder:default=Report
+	// +kubebuilder:validation:Enum=Report;Correct
+	// +optional
+	DriftPolicy polardbxv1polardbx.ParameterDriftPolicy `json:"driftPolicy,omitempty"`
+}
+
+// ParameterPendingRestart is a static parameter which is written into the config but doesn't take
+// effect until the pods of the role are restarted.
+type ParameterPendingRestart struct {
+	// Role is the role of the nodes, i.e. cn, dn or gms.
+	Role string `json:"role,omitempty"`
+
+	// Name is the name of the parameter.
+	Name string `json:"name,omitempty"`
+
+	// Value is the value of the parameter.
+	Value string `json:"value,omitempty"`
+
+	// Since is the time when the parameter is written into the config.
+	Since metav1.Time `json:"since,omitempty"`
+}
+
+// ParameterDrift is a dynamic parameter whose running value differs from the spec.
+type ParameterDrift struct {
+	// Role is the role of the nodes, i.e. cn, dn or gms.
+	Role string `json:"role,omitempty"`
+
+	// Target is the name of the xstore for dn and gms, and empty for cn.
+	// +optional
+	Target string `json:"target,omitempty"`
+
+	// Name is the name of the parameter.
+	Name string `json:"name,omitempty"`
+
+	// Expected is the value in spec.
+	Expected string `json:"expected,omitempty"`
+
+	// Actual is the running value.
+	Actual string `json:"actual,omitempty"`
 }
 
 type PolarDBXParameterStatus struct {
@@ -67,6 +111,23 @@ type PolarDBXParameterStatus struct {
 
 	// ParameterSpecSnapshot represents the snapshot of the parameter.
 	ParameterSpecSnapshot *PolarDBXParameterSpec `json:"parameterSpecSnapshot,omitempty"`
+
+	// RollbackParameterSpecSnapshot represents the snapshot of the parameter applied before the
+	// last modification, which is restored on rollback.
+	// +optional
+	RollbackParameterSpecSnapshot *PolarDBXParameterSpec `json:"rollbackParameterSpecSnapshot,omitempty"`
+
+	// PendingRestart represents the static parameters waiting for the next restart.
+	// +optional
+	PendingRestart []ParameterPendingRestart `json:"pendingRestart,omitempty"`
+
+	// Drifts represents the dynamic parameters drifted from the spec, found by the last check.
+	// +optional
+	Drifts []ParameterDrift `json:"drifts,omitempty"`
+
+	// LastDriftCheckTime is the time of the last drift check.
+	// +optional
+	LastDriftCheckTime *metav1.Time `json:"lastDriftCheckTime,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -0,0 +1,184 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// StandbySource defines where the binlogs of the primary cluster are shipped to.
+type StandbySource struct {
+	// BinlogRootPath is the root path of the binlogs shipped by the binlog backup of the primary
+	// cluster, i.e. status.backupRootPath of the PolarDBXBinlogBackup.
+	BinlogRootPath string `json:"binlogRootPath"`
+
+	// StorageProvider defines the storage which the binlogs are shipped to. The retention credential
+	// is required to list the shipped binlogs.
+	StorageProvider BackupStorageProvider `json:"storageProvider,omitempty"`
+
+	// XStores maps the xstores of the standby cluster to the ones of the primary cluster. Default is
+	// the source xstore which each xstore is restored from.
+	// +optional
+	XStores map[string]string `json:"xstores,omitempty"`
+
+	// Cluster is the reference of the primary cluster if it's in the same namespace, which is fenced
+	// when promoting, i.e. super_read_only is set on its GMS and DNs before the last binlogs are applied.
+	// Otherwise, the primary must be fenced by the user before promoting.
+	// +optional
+	Cluster *PolarDBXClusterReference `json:"cluster,omitempty"`
+}
+
+// PolarDBXStandbySpec defines the desired state of PolarDBXStandby
+type PolarDBXStandbySpec struct {
+	// Cluster represents the reference of the standby cluster, which must be restored from a backup
+	// set of the primary cluster, e.g. copied to the storage of the standby site.
+	Cluster PolarDBXClusterReference `json:"cluster,omitempty"`
+
+	// Source defines where the binlogs of the primary cluster are shipped to.
+	Source StandbySource `json:"source"`
+
+	// +kubebuilder:default="30s"
+
+	// Interval is the interval between the rounds of applying of each xstore. Each round applies
+	// the binlogs shipped since the last round. Default is 30s.
+	// +optional
+	Interval metav1.Duration `json:"interval,omitempty"`
+
+	// +kubebuilder:default=16
+	// +kubebuilder:validation:Minimum=1
+
+	// MaxFilesPerRound bounds the binlogs applied by a round, so that the rounds are short and the
+	// status is updated along the way when there's a backlog. Default is 16.
+	// +optional
+	MaxFilesPerRound int32 `json:"maxFilesPerRound,omitempty"`
+
+	// Promote stops the standby once all shipped binlogs are applied, so that the standby cluster
+	// can take over as the primary. The primary is fenced first if it's referenced by source, and
+	// the standby cluster, which is kept super_read_only while replicating, is made writable once
+	// promoted. It can't be reverted.
+	// +optional
+	Promote bool `json:"promote,omitempty"`
+}
+
+// StandbyPhase defines the phase of standby.
+type StandbyPhase string
+
+const (
+	StandbyNew         StandbyPhase = ""
+	StandbyReplicating StandbyPhase = "Replicating"
+	StandbyPromoting   StandbyPhase = "Promoting"
+	StandbyPromoted    StandbyPhase = "Promoted"
+)
+
+// XStoreStandbyStatus records the applying of an xstore.
+type XStoreStandbyStatus struct {
+	// XStore is the name of the xstore of the standby cluster.
+	XStore string `json:"xstore"`
+
+	// SourceXStore is the name of the xstore of the primary cluster whose binlogs are applied.
+	// +optional
+	SourceXStore string `json:"sourceXStore,omitempty"`
+
+	// Pod is the pod which the binlogs are applied to in the last round, i.e. the leader.
+	// +optional
+	Pod string `json:"pod,omitempty"`
+
+	// LastAppliedBinlog is the position applied, in the format of "file:offset".
+	// +optional
+	LastAppliedBinlog string `json:"lastAppliedBinlog,omitempty"`
+
+	// LastAppliedEventTime is the time of the last event applied.
+	// +optional
+	LastAppliedEventTime *metav1.Time `json:"lastAppliedEventTime,omitempty"`
+
+	// LastApplyTime is when the last round of applying finished or failed.
+	// +optional
+	LastApplyTime *metav1.Time `json:"lastApplyTime,omitempty"`
+
+	// AppliedFiles is the total count of binlog files applied.
+	// +optional
+	AppliedFiles int64 `json:"appliedFiles,omitempty"`
+
+	// PendingFiles is the count of binlog files shipped but not applied yet.
+	// +optional
+	PendingFiles int32 `json:"pendingFiles,omitempty"`
+
+	// Message represents why the last round failed, empty if succeeded.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// PolarDBXStandbyStatus defines the observed state of PolarDBXStandby
+type PolarDBXStandbyStatus struct {
+	// Phase is the phase of standby.
+	// +optional
+	Phase StandbyPhase `json:"phase,omitempty"`
+
+	// StartTime is when the applying started.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// XStores records the applying of each xstore, i.e. the GMS and DNs.
+	// +optional
+	XStores []XStoreStandbyStatus `json:"xstores,omitempty"`
+
+	// LatestAppliedEventTime is the time of the last event applied of the xstore which lags most, i.e.
+	// the time which the standby cluster is consistent to.
+	// +optional
+	LatestAppliedEventTime *metav1.Time `json:"latestAppliedEventTime,omitempty"`
+
+	// PrimaryFenceTime is when the primary cluster is fenced while promoting.
+	// +optional
+	PrimaryFenceTime *metav1.Time `json:"primaryFenceTime,omitempty"`
+
+	// PromoteTime is when the standby is promoted.
+	// +optional
+	PromoteTime *metav1.Time `json:"promoteTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=pxcstandby;pxsb
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="CLUSTER",type=string,JSONPath=`.spec.cluster.name`
+// +kubebuilder:printcolumn:name="PHASE",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="LATEST_APPLIED",type=date,JSONPath=`.status.latestAppliedEventTime`
+// +kubebuilder:printcolumn:name="PROMOTED",type=date,JSONPath=`.status.promoteTime`
+// +kubebuilder:printcolumn:name="AGE",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// PolarDBXStandby is the Schema for the polardbxstandbys API. It keeps a cluster, e.g. in another
+// Kubernetes cluster or region, as the disaster recovery standby of the primary cluster, by applying
+// the binlogs shipped by the binlog backup of the primary continuously, and promotes it on demand.
+type PolarDBXStandby struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PolarDBXStandbySpec   `json:"spec,omitempty"`
+	Status PolarDBXStandbyStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PolarDBXStandbyList contains a list of PolarDBXStandby
+type PolarDBXStandbyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PolarDBXStandby `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PolarDBXStandby{}, &PolarDBXStandbyList{})
+}
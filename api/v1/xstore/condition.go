@@ -36,6 +36,61 @@ const (
 
 	// Restorable indicates whether the cluster is restorable.
 	Restorable ConditionType = "Restorable"
+
+	// ContinuousRestoreLagging indicates whether the apply lag of continuous restore exceeds the bound.
+	ContinuousRestoreLagging ConditionType = "ContinuousRestoreLagging"
+
+	// BackupObjectsThawing indicates whether the archived objects of the backup to restore are being thawed.
+	BackupObjectsThawing ConditionType = "BackupObjectsThawing"
+
+	// RestoreCompatibilityWarning indicates whether soft incompatibilities are found between the backup
+	// to restore and the target, which don't block the restore.
+	RestoreCompatibilityWarning ConditionType = "RestoreCompatibilityWarning"
+
+	// NetworkIsolated indicates whether the egress of the restored nodes is restricted.
+	NetworkIsolated ConditionType = "NetworkIsolated"
+
+	// BackupImmutable indicates whether the xstore backup is sealed, i.e. spec edits that would
+	// re-trigger the upload are rejected.
+	BackupImmutable ConditionType = "Immutable"
+
+	// BackupNoChanges indicates whether the binlog backup finds no change events, the backup set
+	// timestamp is the end time of the backup if so.
+	BackupNoChanges ConditionType = "InfoNoChanges"
+
+	// BackupCDCDiverged indicates whether the CDC position is captured too late after the backup
+	// checkpoint, i.e. they diverge beyond the bound.
+	BackupCDCDiverged ConditionType = "CDCDiverged"
+
+	// BackupCircuitOpen indicates whether the backup stops retrying after consecutive failures.
+	BackupCircuitOpen ConditionType = "CircuitOpen"
+
+	// BackupIncompleteUploads indicates whether incomplete multipart uploads are found under the backup
+	// files. They are probed and aborted with the retention credential only.
+	BackupIncompleteUploads ConditionType = "IncompleteUploads"
+
+	// BackupRestorable indicates whether the finished backup is restorable, i.e. its metadata and the
+	// dependencies are present, and its files are found in the storage. It's recomputed periodically.
+	BackupRestorable ConditionType = "Restorable"
+
+	// BackupWormLocked indicates whether the backup files are locked by the storage until the time
+	// the backup is required to be immutable, e.g. by the WORM retention policy of the bucket.
+	BackupWormLocked ConditionType = "WormLocked"
+
+	// Ready indicates whether the xstore is running with all pods ready, or the backup is finished.
+	Ready ConditionType = "Ready"
+
+	// Progressing indicates whether an operation driven by the operator is in progress, e.g.
+	// creating, upgrading, restoring or backing up.
+	Progressing ConditionType = "Progressing"
+
+	// Degraded indicates whether the xstore is failed or running with pods not ready, or the backup
+	// is failed or stops retrying.
+	Degraded ConditionType = "Degraded"
+
+	// ReplicationHealthy indicates whether the members are within the lag bound of the leader, or
+	// the learners of readonly xstore replicate from the primary without errors.
+	ReplicationHealthy ConditionType = "ReplicationHealthy"
 )
 
 type Condition struct {
@@ -59,4 +114,8 @@ type Condition struct {
 	// Human-readable message indicating details about last transition.
 	// +optional
 	Message string `json:"message,omitempty"`
+
+	// ObservedGeneration is the generation of the spec the condition is set upon.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 }
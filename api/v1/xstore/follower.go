@@ -3,22 +3,23 @@ package xstore
 type FollowerPhase string
 
 const (
-	FollowerPhaseNew           FollowerPhase = ""
-	FollowerPhaseCheck         FollowerPhase = "FollowerPhaseCheck"
-	FollowerPhaseBackupPrepare FollowerPhase = "FollowerPhaseBackupPrepare"
-	FollowerPhaseBackupStart   FollowerPhase = "FollowerPhaseBackupStart"
-	FollowerPhaseBackup        FollowerPhase = "FollowerPhaseBackup"
-	FollowerPhaseLoggerRebuild FollowerPhase = "FollowerPhaseLoggerRebuild"
-	FollowerPhaseMonitorBackup FollowerPhase = "FollowerPhaseMonitorBackup"
-	FollowerPhaseBeforeRestore FollowerPhase = "FollowerPhaseBeforeRestore"
-	FollowerPhaseRestore       FollowerPhase = "FollowerPhaseRestore"
-	FollowerPhaseAfterRestore  FollowerPhase = "FollowerPhaseAfterRestore"
-	FollowerPhaseSuccess       FollowerPhase = "FollowerPhaseSuccess"
-	FollowerPhaseWaitSwitch    FollowerPhase = "FollowerPhaseWaitSwitch"
-	FollowerPhaseFailed        FollowerPhase = "FollowerPhaseFailed"
-	FollowerPhaseLoggerCreate  FollowerPhase = "FollowerPhaseLoggerCreate"
-	FollowerCreateTmpPod       FollowerPhase = "FollowerCreateRemotePod"
-	FollowerPhaseDeleting      FollowerPhase = "FollowerPhaseDeleting"
+	FollowerPhaseNew            FollowerPhase = ""
+	FollowerPhaseCheck          FollowerPhase = "FollowerPhaseCheck"
+	FollowerPhaseBackupPrepare  FollowerPhase = "FollowerPhaseBackupPrepare"
+	FollowerPhaseBackupStart    FollowerPhase = "FollowerPhaseBackupStart"
+	FollowerPhaseBackupDownload FollowerPhase = "FollowerPhaseBackupDownload"
+	FollowerPhaseBackup         FollowerPhase = "FollowerPhaseBackup"
+	FollowerPhaseLoggerRebuild  FollowerPhase = "FollowerPhaseLoggerRebuild"
+	FollowerPhaseMonitorBackup  FollowerPhase = "FollowerPhaseMonitorBackup"
+	FollowerPhaseBeforeRestore  FollowerPhase = "FollowerPhaseBeforeRestore"
+	FollowerPhaseRestore        FollowerPhase = "FollowerPhaseRestore"
+	FollowerPhaseAfterRestore   FollowerPhase = "FollowerPhaseAfterRestore"
+	FollowerPhaseSuccess        FollowerPhase = "FollowerPhaseSuccess"
+	FollowerPhaseWaitSwitch     FollowerPhase = "FollowerPhaseWaitSwitch"
+	FollowerPhaseFailed         FollowerPhase = "FollowerPhaseFailed"
+	FollowerPhaseLoggerCreate   FollowerPhase = "FollowerPhaseLoggerCreate"
+	FollowerCreateTmpPod        FollowerPhase = "FollowerCreateRemotePod"
+	FollowerPhaseDeleting       FollowerPhase = "FollowerPhaseDeleting"
 )
 
 type FollowerRole string
@@ -28,3 +29,15 @@ const (
 	FollowerRoleFollower FollowerRole = "follower"
 	FollowerRoleLogger   FollowerRole = "logger"
 )
+
+// FollowerRecoverPolicy defines how the data of the follower is rebuilt.
+type FollowerRecoverPolicy string
+
+const (
+	// FollowerRecoverClone streams a full copy of data from the from-pod.
+	FollowerRecoverClone FollowerRecoverPolicy = "Clone"
+	// FollowerRecoverBackup restores from the latest xstore backup, and catches up the logs from the leader.
+	FollowerRecoverBackup FollowerRecoverPolicy = "Backup"
+	// FollowerRecoverAuto restores from the latest xstore backup if it's recent enough, or clones otherwise.
+	FollowerRecoverAuto FollowerRecoverPolicy = "Auto"
+)
@@ -0,0 +1,121 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package xstore
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// LogSinkType is the type of the sink which the logs are shipped to.
+type LogSinkType string
+
+// Valid log sink types.
+const (
+	LogSinkStdout LogSinkType = "Stdout"
+	LogSinkLoki   LogSinkType = "Loki"
+	LogSinkKafka  LogSinkType = "Kafka"
+)
+
+// LokiSink defines the Loki instance which the logs are pushed to.
+type LokiSink struct {
+	// Host of the Loki instance.
+	Host string `json:"host"`
+
+	// +kubebuilder:default=3100
+
+	// Port of the Loki instance. Default is 3100.
+	// +optional
+	Port int32 `json:"port,omitempty"`
+
+	// TLS enables TLS when pushing logs. Default is false.
+	// +optional
+	TLS bool `json:"tls,omitempty"`
+
+	// TenantID is the tenant id (X-Scope-OrgID) of the pushed logs. Optional.
+	// +optional
+	TenantID string `json:"tenantId,omitempty"`
+
+	// Labels are the extra stream labels attached to the pushed logs. Optional.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// KafkaSink defines the Kafka topic which the logs are produced to.
+type KafkaSink struct {
+	// Brokers of the Kafka cluster, e.g. "kafka-0.kafka:9092".
+	Brokers []string `json:"brokers"`
+
+	// Topic which the logs are produced to.
+	Topic string `json:"topic"`
+}
+
+// LogSink defines where the logs are shipped to.
+type LogSink struct {
+	// +kubebuilder:default=Stdout
+	// +kubebuilder:validation:Enum=Stdout;Loki;Kafka
+
+	// Type of the sink. Stdout writes the logs as JSON lines to the stdout of the collector
+	// container, which can be picked up by the cluster logging. Default is Stdout.
+	// +optional
+	Type LogSinkType `json:"type,omitempty"`
+
+	// Loki sink, required if the type is Loki.
+	// +optional
+	Loki *LokiSink `json:"loki,omitempty"`
+
+	// Kafka sink, required if the type is Kafka.
+	// +optional
+	Kafka *KafkaSink `json:"kafka,omitempty"`
+}
+
+// LogRotation defines the size limits of the collected log files. The files are rotated by copy
+// and truncate once exceeding the max size.
+type LogRotation struct {
+	// MaxSize is the max size of a log file before it's rotated. Default is 256Mi.
+	// +optional
+	MaxSize *resource.Quantity `json:"maxSize,omitempty"`
+
+	// MaxFiles is the number of rotated files kept. Zero truncates the file without keeping
+	// any rotated ones. Default is 3.
+	// +optional
+	MaxFiles *int32 `json:"maxFiles,omitempty"`
+}
+
+// LogCollector defines the sidecar which tails the logs and ships them to the sink.
+type LogCollector struct {
+	// Image of the collector, which must be a fluent-bit image with a shell, e.g. the debug ones.
+	// Default is operator dependent.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// ImagePullPolicy of the collector.
+	// +optional
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+
+	// Resources of the collector container.
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Sink defines where the logs are shipped to.
+	// +optional
+	Sink LogSink `json:"sink,omitempty"`
+
+	// Rotation defines the size limits of the collected log files.
+	// +optional
+	Rotation LogRotation `json:"rotation,omitempty"`
+}
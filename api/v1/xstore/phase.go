@@ -31,6 +31,8 @@ const (
 	PhaseDeleting   Phase = "Deleting"
 	PhaseFailed     Phase = "Failed"
 	PhaseRestarting Phase = "Restarting"
+	PhaseSuspended  Phase = "Suspended"
+	PhaseResuming   Phase = "Resuming"
 	PhaseUnknown    Phase = "Unknown"
 )
 
@@ -38,8 +40,9 @@ type Stage string
 
 // Valid stages of xstore.
 const (
-	StageEmpty   Stage = ""
-	StageLocking Stage = "Locking"
-	StageClean   Stage = "Clean"
-	StageUpdate  Stage = "Update"
+	StageEmpty      Stage = ""
+	StageLocking    Stage = "Locking"
+	StageClean      Stage = "Clean"
+	StageUpdate     Stage = "Update"
+	StageSwitchover Stage = "Switchover"
 )
@@ -0,0 +1,49 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package xstore
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// ReadonlyReplicationStatus represents the replication of the learners of a readonly xstore from
+// the primary xstore.
+type ReadonlyReplicationStatus struct {
+	// PrimaryLeaderPod is the leader pod of the primary xstore last observed.
+	// +optional
+	PrimaryLeaderPod string `json:"primaryLeaderPod,omitempty"`
+
+	// LearnerAddrs are the addresses of the learners added to the primary xstore. Addresses no longer
+	// used by the learner pods, e.g. after the pods are rebuilt on other hosts, are dropped from the
+	// primary.
+	// +optional
+	LearnerAddrs []string `json:"learnerAddrs,omitempty"`
+
+	// Members is the last observed membership of the learners in the consensus group of the primary.
+	// +optional
+	Members []ConsensusMemberStatus `json:"members,omitempty"`
+
+	// MaxLag is the count of log entries the slowest learner is behind the primary leader.
+	// +optional
+	MaxLag int64 `json:"maxLag,omitempty"`
+
+	// Message is the reason why the replication is not healthy, empty if it is.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// UpdateTime is the time of the last observation.
+	// +optional
+	UpdateTime *metav1.Time `json:"updateTime,omitempty"`
+}
@@ -0,0 +1,311 @@
+/*
+Copyright 2021 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package xstore
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// ContinuousRestoreStatus represents the status of continuous restore.
+type ContinuousRestoreStatus struct {
+	// LastAppliedBackup is the name of the last xstore backup whose binlogs are applied.
+	LastAppliedBackup string `json:"lastAppliedBackup,omitempty"`
+
+	// LastAppliedBinlog is the position ("file:offset") of the source binlog applied.
+	// +optional
+	LastAppliedBinlog string `json:"lastAppliedBinlog,omitempty"`
+
+	// LastAppliedTimestamp is the timestamp of the last event applied.
+	// +optional
+	LastAppliedTimestamp *metav1.Time `json:"lastAppliedTimestamp,omitempty"`
+
+	// ApplyingBackup is the name of the xstore backup whose binlogs are being applied.
+	// +optional
+	ApplyingBackup string `json:"applyingBackup,omitempty"`
+
+	// ApplyLag is the lag between now and the last applied timestamp, e.g. "2m30s".
+	// +optional
+	ApplyLag string `json:"applyLag,omitempty"`
+}
+
+// PointInTimeRestoreStatus represents the progress of the binlog replay to the restore time.
+type PointInTimeRestoreStatus struct {
+	// RestoreTime is the time restored to.
+	RestoreTime *metav1.Time `json:"restoreTime,omitempty"`
+
+	// LastAppliedBackup is the name of the last xstore backup whose binlogs are applied, it's the
+	// restored backup before any binlog backup is applied.
+	LastAppliedBackup string `json:"lastAppliedBackup,omitempty"`
+
+	// LastAppliedBinlog is the position ("file:offset") of the source binlog applied.
+	// +optional
+	LastAppliedBinlog string `json:"lastAppliedBinlog,omitempty"`
+
+	// ApplyingBackup is the name of the xstore backup whose binlogs are being applied.
+	// +optional
+	ApplyingBackup string `json:"applyingBackup,omitempty"`
+
+	// Reached is true once the binlogs are applied up to the restore time, or all the binlog backups
+	// available are applied.
+	// +optional
+	Reached bool `json:"reached,omitempty"`
+}
+
+// RestoreFallbackStatus records the substitution of backup when restore falls back.
+type RestoreFallbackStatus struct {
+	// OriginalBackup is the name of the xstore backup selected originally.
+	OriginalBackup string `json:"originalBackup,omitempty"`
+
+	// FallbackBackup is the name of the xstore backup actually used.
+	FallbackBackup string `json:"fallbackBackup,omitempty"`
+
+	// Reason is the reason of falling back.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// RPOImpact is the extra data loss in time caused by falling back, e.g. "24h0m0s".
+	// +optional
+	RPOImpact string `json:"rpoImpact,omitempty"`
+}
+
+// RestoreDownloadStatus represents the download progress of the backup set on a node.
+type RestoreDownloadStatus struct {
+	// DownloadedBytes is the size of the backup set downloaded.
+	DownloadedBytes int64 `json:"downloadedBytes,omitempty"`
+
+	// TotalBytes is the size of the backup set.
+	// +optional
+	TotalBytes int64 `json:"totalBytes,omitempty"`
+}
+
+// RestoreReplayStatus represents the checkpoint of binlog replay of the restore on a node. The
+// replay resumes from the checkpoint if it's interrupted, e.g. the pod restarts.
+type RestoreReplayStatus struct {
+	// Stage is the stage of the replay, Replaying or Replayed.
+	Stage string `json:"stage,omitempty"`
+
+	// StartIndex is the consensus log index which the replay starts from.
+	// +optional
+	StartIndex int64 `json:"startIndex,omitempty"`
+
+	// AppliedIndex is the last applied consensus log index checkpointed.
+	// +optional
+	AppliedIndex int64 `json:"appliedIndex,omitempty"`
+
+	// EndIndex is the consensus log index which the replay ends at.
+	// +optional
+	EndIndex int64 `json:"endIndex,omitempty"`
+
+	// Resumes is the count of the replay resumed from the checkpoint.
+	// +optional
+	Resumes int32 `json:"resumes,omitempty"`
+
+	// UpdateTime is the time when the checkpoint is updated.
+	// +optional
+	UpdateTime *metav1.Time `json:"updateTime,omitempty"`
+}
+
+// RestoreThawStatus represents the thaw progress of the archived objects of the backup set.
+type RestoreThawStatus struct {
+	// Backup is the name of the xstore backup whose objects are thawed.
+	Backup string `json:"backup,omitempty"`
+
+	// StorageClass is the storage class of the backup objects.
+	StorageClass string `json:"storageClass,omitempty"`
+
+	// ArchivedObjects is the count of archived objects found.
+	// +optional
+	ArchivedObjects int64 `json:"archivedObjects,omitempty"`
+
+	// ThawingObjects is the count of objects still being thawed.
+	// +optional
+	ThawingObjects int64 `json:"thawingObjects,omitempty"`
+
+	// StartTime is the time when the thaw started.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+}
+
+// PostRestoreExecStatus represents the result of a post restore command.
+type PostRestoreExecStatus struct {
+	// Name is the name of the command.
+	Name string `json:"name,omitempty"`
+
+	// Succeeded indicates whether the command succeeded.
+	Succeeded bool `json:"succeeded,omitempty"`
+
+	// Output is the tail of the combined stdout and stderr of the command.
+	// +optional
+	Output string `json:"output,omitempty"`
+
+	// FinishTime is the time when the command finished.
+	// +optional
+	FinishTime *metav1.Time `json:"finishTime,omitempty"`
+}
+
+// RestoreMaskingStatus represents the progress of the masking of restored data.
+type RestoreMaskingStatus struct {
+	// ConfigMap is the configmap of masking rules.
+	ConfigMap string `json:"configMap,omitempty"`
+
+	// AppliedRules is the count of rules applied, in the order of the rules.
+	AppliedRules int32 `json:"appliedRules,omitempty"`
+
+	// TotalRules is the count of rules.
+	TotalRules int32 `json:"totalRules,omitempty"`
+
+	// FinishTime is the time when all the rules are applied.
+	// +optional
+	FinishTime *metav1.Time `json:"finishTime,omitempty"`
+}
+
+// RestoreRejoinStatus represents the progress of the restored nodes rejoining the consensus group.
+type RestoreRejoinStatus struct {
+	// StartTime is the time when the wait started.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// FinishTime is the time when all the restored nodes have rejoined.
+	// +optional
+	FinishTime *metav1.Time `json:"finishTime,omitempty"`
+
+	// Members is the last observed consensus membership of the restored nodes.
+	// +optional
+	Members []ConsensusMemberStatus `json:"members,omitempty"`
+
+	// Message is the reason why the restored nodes are not rejoined yet.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// ConsensusMemberStatus represents a node observed in the consensus group.
+type ConsensusMemberStatus struct {
+	// Pod is the name of the pod.
+	Pod string `json:"pod,omitempty"`
+
+	// Role is the consensus role of the node, empty if it's not a member.
+	// +optional
+	Role string `json:"role,omitempty"`
+
+	// Lag is the count of log entries the node is behind the leader.
+	// +optional
+	Lag int64 `json:"lag,omitempty"`
+
+	// Healthy indicates whether the node is a member in the expected role within the lag bound.
+	Healthy bool `json:"healthy,omitempty"`
+}
+
+// RestoreCompatibilityStrictness defines which incompatibilities between the backup and the target
+// block the restore.
+type RestoreCompatibilityStrictness string
+
+// Valid restore compatibility strictness.
+const (
+	// CompatibilityStrict blocks the restore on both hard and soft incompatibilities.
+	CompatibilityStrict RestoreCompatibilityStrictness = "Strict"
+	// CompatibilityDefault blocks the restore on hard incompatibilities and warns on soft ones.
+	CompatibilityDefault RestoreCompatibilityStrictness = "Default"
+	// CompatibilityWarnOnly never blocks the restore, the incompatibilities are only reported.
+	CompatibilityWarnOnly RestoreCompatibilityStrictness = "WarnOnly"
+)
+
+// CompatibilitySeverity defines the severity of an incompatibility.
+type CompatibilitySeverity string
+
+// Valid compatibility severities.
+const (
+	// CompatibilityHard incompatibilities make the restore fail or corrupt the data, e.g. page size.
+	CompatibilityHard CompatibilitySeverity = "Hard"
+	// CompatibilitySoft incompatibilities may only manifest as runtime errors later, e.g. charset.
+	CompatibilitySoft CompatibilitySeverity = "Soft"
+)
+
+// Valid checks of restore compatibility.
+const (
+	CompatibilityCheckEngineVersion       = "EngineVersion"
+	CompatibilityCheckPageSize            = "PageSize"
+	CompatibilityCheckLowerCaseTableNames = "LowerCaseTableNames"
+	CompatibilityCheckCharacterSet        = "CharacterSet"
+	CompatibilityCheckCollation           = "Collation"
+	CompatibilityCheckPlugins             = "Plugins"
+)
+
+// EngineCompatibility records the engine facts that decide whether a physical backup can be restored
+// by an engine. Empty fields are unknown.
+type EngineCompatibility struct {
+	// EngineVersion is the version of the engine, e.g. "8.0.18-X-Cluster".
+	// +optional
+	EngineVersion string `json:"engineVersion,omitempty"`
+
+	// PageSize is the innodb page size in bytes.
+	// +optional
+	PageSize int64 `json:"pageSize,omitempty"`
+
+	// LowerCaseTableNames is the value of lower_case_table_names.
+	// +optional
+	LowerCaseTableNames *int32 `json:"lowerCaseTableNames,omitempty"`
+
+	// CharacterSet is the default character set of the server.
+	// +optional
+	CharacterSet string `json:"characterSet,omitempty"`
+
+	// Collation is the default collation of the server.
+	// +optional
+	Collation string `json:"collation,omitempty"`
+
+	// Plugins are the plugins loaded by the engine config.
+	// +optional
+	Plugins []string `json:"plugins,omitempty"`
+}
+
+// CompatibilityCheck records the result of a single check of restore compatibility.
+type CompatibilityCheck struct {
+	// Name is the name of the check, e.g. PageSize.
+	Name string `json:"name,omitempty"`
+
+	// Severity is the severity if the check is incompatible.
+	Severity CompatibilitySeverity `json:"severity,omitempty"`
+
+	// Compatible tells whether the backup and the target are compatible, true if either is unknown.
+	Compatible bool `json:"compatible"`
+
+	// Message represents the details of the check.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// RestoreCompatibilityReport represents the compatibility between the backup to restore and the target.
+type RestoreCompatibilityReport struct {
+	// Backup is the name of the xstore backup checked.
+	Backup string `json:"backup,omitempty"`
+
+	// Strictness is the strictness which the report is evaluated with.
+	Strictness RestoreCompatibilityStrictness `json:"strictness,omitempty"`
+
+	// Blocked tells whether the restore is blocked by the incompatibilities.
+	Blocked bool `json:"blocked,omitempty"`
+
+	// Checks are the results of the checks.
+	// +optional
+	Checks []CompatibilityCheck `json:"checks,omitempty"`
+
+	// Target records the engine facts of the target.
+	// +optional
+	Target *EngineCompatibility `json:"target,omitempty"`
+
+	// CheckTime is the time when the checks are done.
+	// +optional
+	CheckTime *metav1.Time `json:"checkTime,omitempty"`
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package xstore
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// SwitchoverPhase is the phase of a planned switchover.
+type SwitchoverPhase string
+
+// Valid switchover phases.
+const (
+	SwitchoverDraining     SwitchoverPhase = "Draining"
+	SwitchoverCatchingUp   SwitchoverPhase = "CatchingUp"
+	SwitchoverTransferring SwitchoverPhase = "Transferring"
+	SwitchoverSucceeded    SwitchoverPhase = "Succeeded"
+	SwitchoverFailed       SwitchoverPhase = "Failed"
+)
+
+// IsTerminal returns true if the switchover is succeeded or failed.
+func (p SwitchoverPhase) IsTerminal() bool {
+	return p == SwitchoverSucceeded || p == SwitchoverFailed
+}
+
+// SwitchoverStatus represents the last planned switchover of the leader, which is requested by the
+// annotation "xstore/switchover" with the target pod, or empty to let the operator choose the follower
+// caught up most.
+type SwitchoverStatus struct {
+	// From is the leader pod before the switchover.
+	// +optional
+	From string `json:"from,omitempty"`
+
+	// Target is the pod to transfer the leadership to.
+	// +optional
+	Target string `json:"target,omitempty"`
+
+	// Phase is the phase of the switchover.
+	// +optional
+	Phase SwitchoverPhase `json:"phase,omitempty"`
+
+	// Message is the reason of the failure, if any.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// StartTime is when the switchover started.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is when the switchover succeeded or failed.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
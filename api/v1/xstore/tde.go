@@ -0,0 +1,88 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package xstore
+
+import "errors"
+
+// TDEKMSProvider is the provider of the external KMS.
+type TDEKMSProvider string
+
+// Valid TDE KMS providers.
+const (
+	TDEKMSProviderAWS TDEKMSProvider = "AWS"
+)
+
+// TDEKMS defines the external KMS which the master key is sourced from.
+type TDEKMS struct {
+	// +kubebuilder:default=AWS
+	// +kubebuilder:validation:Enum=AWS
+
+	// Provider of the KMS, which decides the keyring plugin. Only AWS (keyring_aws) is supported
+	// now. Default is AWS.
+	// +optional
+	Provider TDEKMSProvider `json:"provider,omitempty"`
+
+	// KeyID is the id of the customer master key in KMS.
+	KeyID string `json:"keyId"`
+
+	// Region of the KMS.
+	Region string `json:"region"`
+}
+
+// TDE defines the transparent data encryption of the engine.
+type TDE struct {
+	// SecretName of the secret which sources the master key. Without KMS, the key "password" of the
+	// secret encrypts the keyring file (keyring_encrypted_file) on the data volume, and it must never
+	// be changed once the keyring is created. With KMS, the key "keyring_aws.conf" of the secret
+	// contains the credentials to access the KMS.
+	SecretName string `json:"secretName"`
+
+	// KMS sources the master key from the external KMS instead of the keyring file. Optional.
+	// +optional
+	KMS *TDEKMS `json:"kms,omitempty"`
+
+	// MasterKeyVersion is the version of the master key, increase it to rotate the master key
+	// online. Default is 0.
+	// +optional
+	MasterKeyVersion int64 `json:"masterKeyVersion,omitempty"`
+}
+
+// ValidateTDETransition checks whether the TDE config can be changed from old to new. The encrypted
+// data can't be read without the keyring it's encrypted with, so once enabled, TDE can't be disabled,
+// nor can the master key source be changed, i.e. switched between the keyring file and KMS, or to
+// another password secret or KMS key. Only the master key version can be increased.
+func ValidateTDETransition(old, new *TDE) error {
+	if old == nil {
+		return nil
+	}
+	if new == nil {
+		return errors.New("tde can't be disabled once enabled")
+	}
+	if (old.KMS == nil) != (new.KMS == nil) {
+		return errors.New("master key source can't be switched between keyring file and kms")
+	}
+	if old.KMS == nil && old.SecretName != new.SecretName {
+		return errors.New("secret of the keyring password can't be changed")
+	}
+	if old.KMS != nil && *old.KMS != *new.KMS {
+		return errors.New("kms can't be changed")
+	}
+	if new.MasterKeyVersion < old.MasterKeyVersion {
+		return errors.New("master key version can't be decreased")
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package xstore
+
+// TLS defines the TLS config of the engine.
+type TLS struct {
+	// SecretName of the secret which contains the root.crt, server.crt and server.key. The
+	// certificates are loaded by engine on start and reloaded online when they are rotated.
+	SecretName string `json:"secretName,omitempty"`
+}
@@ -46,6 +46,11 @@ type NodeSpec struct {
 
 	// Resources is the requested resources of the node.
 	Resources *common.ExtendedResourceRequirements `json:"resources,omitempty"`
+
+	// TopologySpreadConstraints describes how the nodes (pods) are spread across the
+	// failure domains.
+	// +optional
+	TopologySpreadConstraints []v1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
 }
 
 // NodeTemplate defines the template of a xstore node.
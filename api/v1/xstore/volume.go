@@ -31,6 +31,9 @@ type HostPathVolume struct {
 	//Log HostPath of the file/dir
 	LogHostPath string `json:"logHostPath,omitempty"`
 
+	// PersistentVolumeClaim of the data and log if the volume is migrated from host path.
+	PersistentVolumeClaim string `json:"persistentVolumeClaim,omitempty"`
+
 	// Type of the host path.
 	Type corev1.HostPathType `json:"type,omitempty"`
 
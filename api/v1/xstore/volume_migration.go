@@ -0,0 +1,69 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package xstore
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// VolumeMigrationPhase is the phase of the migration from host path volumes to persistent volume claims.
+type VolumeMigrationPhase string
+
+// Valid volume migration phases.
+const (
+	VolumeMigrationRunning   VolumeMigrationPhase = "Running"
+	VolumeMigrationCompleted VolumeMigrationPhase = "Completed"
+)
+
+// VolumeMigrationStatus represents the migration of the data from host path volumes to persistent
+// volume claims, which is requested by the annotation "xstore/volume-migration.storage-class". The
+// pods are rebuilt one at a time and copy the data to the claims on start, followers go first and
+// the leader is switched over before being rebuilt.
+type VolumeMigrationStatus struct {
+	// StorageClassName of the persistent volume claims.
+	// +optional
+	StorageClassName string `json:"storageClassName,omitempty"`
+
+	// Size requested by the persistent volume claims.
+	// +optional
+	Size resource.Quantity `json:"size,omitempty"`
+
+	// Phase is the phase of the migration.
+	// +optional
+	Phase VolumeMigrationPhase `json:"phase,omitempty"`
+
+	// Message is the reason why the migration is blocked, if any.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// CurrentPod is the pod being rebuilt to copy the data.
+	// +optional
+	CurrentPod string `json:"currentPod,omitempty"`
+
+	// MigratedPods are the pods already running on the persistent volume claims.
+	// +optional
+	MigratedPods []string `json:"migratedPods,omitempty"`
+
+	// StartTime is when the migration started.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is when the migration completed.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
@@ -27,6 +27,21 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CompatibilityCheck) DeepCopyInto(out *CompatibilityCheck) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CompatibilityCheck.
+func (in *CompatibilityCheck) DeepCopy() *CompatibilityCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(CompatibilityCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Condition) DeepCopyInto(out *Condition) {
 	*out = *in
@@ -64,6 +79,40 @@ func (in *Config) DeepCopy() *Config {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsensusMemberStatus) DeepCopyInto(out *ConsensusMemberStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsensusMemberStatus.
+func (in *ConsensusMemberStatus) DeepCopy() *ConsensusMemberStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsensusMemberStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContinuousRestoreStatus) DeepCopyInto(out *ContinuousRestoreStatus) {
+	*out = *in
+	if in.LastAppliedTimestamp != nil {
+		in, out := &in.LastAppliedTimestamp, &out.LastAppliedTimestamp
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContinuousRestoreStatus.
+func (in *ContinuousRestoreStatus) DeepCopy() *ContinuousRestoreStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ContinuousRestoreStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ControllerConfig) DeepCopyInto(out *ControllerConfig) {
 	*out = *in
@@ -90,6 +139,31 @@ func (in *ControllerConfig) DeepCopy() *ControllerConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EngineCompatibility) DeepCopyInto(out *EngineCompatibility) {
+	*out = *in
+	if in.LowerCaseTableNames != nil {
+		in, out := &in.LowerCaseTableNames, &out.LowerCaseTableNames
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Plugins != nil {
+		in, out := &in.Plugins, &out.Plugins
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EngineCompatibility.
+func (in *EngineCompatibility) DeepCopy() *EngineCompatibility {
+	if in == nil {
+		return nil
+	}
+	out := new(EngineCompatibility)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EngineConfig) DeepCopyInto(out *EngineConfig) {
 	*out = *in
@@ -130,6 +204,116 @@ func (in *HostPathVolume) DeepCopy() *HostPathVolume {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaSink) DeepCopyInto(out *KafkaSink) {
+	*out = *in
+	if in.Brokers != nil {
+		in, out := &in.Brokers, &out.Brokers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaSink.
+func (in *KafkaSink) DeepCopy() *KafkaSink {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaSink)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogCollector) DeepCopyInto(out *LogCollector) {
+	*out = *in
+	in.Resources.DeepCopyInto(&out.Resources)
+	in.Sink.DeepCopyInto(&out.Sink)
+	in.Rotation.DeepCopyInto(&out.Rotation)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogCollector.
+func (in *LogCollector) DeepCopy() *LogCollector {
+	if in == nil {
+		return nil
+	}
+	out := new(LogCollector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogRotation) DeepCopyInto(out *LogRotation) {
+	*out = *in
+	if in.MaxSize != nil {
+		in, out := &in.MaxSize, &out.MaxSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.MaxFiles != nil {
+		in, out := &in.MaxFiles, &out.MaxFiles
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogRotation.
+func (in *LogRotation) DeepCopy() *LogRotation {
+	if in == nil {
+		return nil
+	}
+	out := new(LogRotation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogSink) DeepCopyInto(out *LogSink) {
+	*out = *in
+	if in.Loki != nil {
+		in, out := &in.Loki, &out.Loki
+		*out = new(LokiSink)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Kafka != nil {
+		in, out := &in.Kafka, &out.Kafka
+		*out = new(KafkaSink)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogSink.
+func (in *LogSink) DeepCopy() *LogSink {
+	if in == nil {
+		return nil
+	}
+	out := new(LogSink)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LokiSink) DeepCopyInto(out *LokiSink) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LokiSink.
+func (in *LokiSink) DeepCopy() *LokiSink {
+	if in == nil {
+		return nil
+	}
+	out := new(LokiSink)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NodeSet) DeepCopyInto(out *NodeSet) {
 	*out = *in
@@ -173,6 +357,13 @@ func (in *NodeSpec) DeepCopyInto(out *NodeSpec) {
 		*out = new(common.ExtendedResourceRequirements)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]corev1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeSpec.
@@ -238,6 +429,44 @@ func (in PodPorts) DeepCopy() PodPorts {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PointInTimeRestoreStatus) DeepCopyInto(out *PointInTimeRestoreStatus) {
+	*out = *in
+	if in.RestoreTime != nil {
+		in, out := &in.RestoreTime, &out.RestoreTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PointInTimeRestoreStatus.
+func (in *PointInTimeRestoreStatus) DeepCopy() *PointInTimeRestoreStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PointInTimeRestoreStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostRestoreExecStatus) DeepCopyInto(out *PostRestoreExecStatus) {
+	*out = *in
+	if in.FinishTime != nil {
+		in, out := &in.FinishTime, &out.FinishTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostRestoreExecStatus.
+func (in *PostRestoreExecStatus) DeepCopy() *PostRestoreExecStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PostRestoreExecStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Privilege) DeepCopyInto(out *Privilege) {
 	*out = *in
@@ -253,6 +482,35 @@ func (in *Privilege) DeepCopy() *Privilege {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReadonlyReplicationStatus) DeepCopyInto(out *ReadonlyReplicationStatus) {
+	*out = *in
+	if in.LearnerAddrs != nil {
+		in, out := &in.LearnerAddrs, &out.LearnerAddrs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make([]ConsensusMemberStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.UpdateTime != nil {
+		in, out := &in.UpdateTime, &out.UpdateTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReadonlyReplicationStatus.
+func (in *ReadonlyReplicationStatus) DeepCopy() *ReadonlyReplicationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ReadonlyReplicationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RestartingPods) DeepCopyInto(out *RestartingPods) {
 	*out = *in
@@ -273,6 +531,223 @@ func (in *RestartingPods) DeepCopy() *RestartingPods {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreCompatibilityReport) DeepCopyInto(out *RestoreCompatibilityReport) {
+	*out = *in
+	if in.Checks != nil {
+		in, out := &in.Checks, &out.Checks
+		*out = make([]CompatibilityCheck, len(*in))
+		copy(*out, *in)
+	}
+	if in.Target != nil {
+		in, out := &in.Target, &out.Target
+		*out = new(EngineCompatibility)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CheckTime != nil {
+		in, out := &in.CheckTime, &out.CheckTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreCompatibilityReport.
+func (in *RestoreCompatibilityReport) DeepCopy() *RestoreCompatibilityReport {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreCompatibilityReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreDownloadStatus) DeepCopyInto(out *RestoreDownloadStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreDownloadStatus.
+func (in *RestoreDownloadStatus) DeepCopy() *RestoreDownloadStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreDownloadStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreFallbackStatus) DeepCopyInto(out *RestoreFallbackStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreFallbackStatus.
+func (in *RestoreFallbackStatus) DeepCopy() *RestoreFallbackStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreFallbackStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreMaskingStatus) DeepCopyInto(out *RestoreMaskingStatus) {
+	*out = *in
+	if in.FinishTime != nil {
+		in, out := &in.FinishTime, &out.FinishTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreMaskingStatus.
+func (in *RestoreMaskingStatus) DeepCopy() *RestoreMaskingStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreMaskingStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreRejoinStatus) DeepCopyInto(out *RestoreRejoinStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.FinishTime != nil {
+		in, out := &in.FinishTime, &out.FinishTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make([]ConsensusMemberStatus, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreRejoinStatus.
+func (in *RestoreRejoinStatus) DeepCopy() *RestoreRejoinStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreRejoinStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreReplayStatus) DeepCopyInto(out *RestoreReplayStatus) {
+	*out = *in
+	if in.UpdateTime != nil {
+		in, out := &in.UpdateTime, &out.UpdateTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreReplayStatus.
+func (in *RestoreReplayStatus) DeepCopy() *RestoreReplayStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreReplayStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreThawStatus) DeepCopyInto(out *RestoreThawStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreThawStatus.
+func (in *RestoreThawStatus) DeepCopy() *RestoreThawStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreThawStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SwitchoverStatus) DeepCopyInto(out *SwitchoverStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SwitchoverStatus.
+func (in *SwitchoverStatus) DeepCopy() *SwitchoverStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SwitchoverStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TDE) DeepCopyInto(out *TDE) {
+	*out = *in
+	if in.KMS != nil {
+		in, out := &in.KMS, &out.KMS
+		*out = new(TDEKMS)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TDE.
+func (in *TDE) DeepCopy() *TDE {
+	if in == nil {
+		return nil
+	}
+	out := new(TDE)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TDEKMS) DeepCopyInto(out *TDEKMS) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TDEKMS.
+func (in *TDEKMS) DeepCopy() *TDEKMS {
+	if in == nil {
+		return nil
+	}
+	out := new(TDEKMS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TLS) DeepCopyInto(out *TLS) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLS.
+func (in *TLS) DeepCopy() *TLS {
+	if in == nil {
+		return nil
+	}
+	out := new(TLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Topology) DeepCopyInto(out *Topology) {
 	*out = *in
@@ -295,3 +770,32 @@ func (in *Topology) DeepCopy() *Topology {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeMigrationStatus) DeepCopyInto(out *VolumeMigrationStatus) {
+	*out = *in
+	out.Size = in.Size.DeepCopy()
+	if in.MigratedPods != nil {
+		in, out := &in.MigratedPods, &out.MigratedPods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeMigrationStatus.
+func (in *VolumeMigrationStatus) DeepCopy() *VolumeMigrationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeMigrationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -49,6 +49,37 @@ type XStoreFollowerSpec struct {
 
 	//XStoreName represents the name of xstore which the follower belongs to
 	XStoreName string `json:"xStoreName,omitempty"`
+
+	// +kubebuilder:validation:Enum=Clone;Backup;Auto
+
+	// RecoverPolicy represents how the data is rebuilt. Clone streams a full copy from the from-pod, Backup
+	// restores from the latest finished xstore backup and then catches up the logs from the leader, and Auto
+	// restores from the backup only if it's finished within BackupMaxAge and clones otherwise. Loggers are
+	// always rebuilt from the leader's log position. Default is Clone.
+	// +optional
+	RecoverPolicy polardbxv1xstore.FollowerRecoverPolicy `json:"recoverPolicy,omitempty"`
+
+	// BackupMaxAge is the max age of the backup used by the Auto recover policy. Default is 24h.
+	// +optional
+	BackupMaxAge *metav1.Duration `json:"backupMaxAge,omitempty"`
+}
+
+// FollowerRecoverBackup records the xstore backup which the follower is rebuilt from.
+type FollowerRecoverBackup struct {
+	// Name is the name of the xstore backup.
+	Name string `json:"name,omitempty"`
+
+	// BackupFilePath is the remote path of the full backup file.
+	BackupFilePath string `json:"backupFilePath,omitempty"`
+
+	// StorageName is the kind of the storage.
+	StorageName BackupStorage `json:"storageName,omitempty"`
+
+	// Sink is the sink of the storage.
+	Sink string `json:"sink,omitempty"`
+
+	// CommitIndex is the commit index of the backup, the logs after it are caught up from the leader.
+	CommitIndex int64 `json:"commitIndex,omitempty"`
 }
 
 type FlowFlagType struct {
@@ -92,6 +123,10 @@ type XStoreFollowerStatus struct {
 
 	//FlowFlags represent flow flags
 	FlowFlags []FlowFlagType `json:"flowFlags,omitempty"`
+
+	// RecoverBackup represents the xstore backup which the data is rebuilt from, empty if it's cloned.
+	// +optional
+	RecoverBackup *FollowerRecoverBackup `json:"recoverBackup,omitempty"`
 }
 
 // +kubebuilder:object:root=true
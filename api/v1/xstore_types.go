@@ -20,6 +20,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/alibaba/polardbx-operator/api/v1/common"
 	"github.com/alibaba/polardbx-operator/api/v1/xstore"
 )
 
@@ -46,9 +47,129 @@ type XStoreRestoreSpec struct {
 	// Time defines the specified time of the restored data, in the format of 'yyyy-MM-dd HH:mm:ss'. Required.
 	Time string `json:"time,omitempty"`
 
-	// TimeZone defines the specified time zone of the restore time. Default is the location of current cluster.
+	// TimeZone defines the specified time zone of the restore time, e.g. "Asia/Shanghai". Default is UTC.
 	// +optional
 	TimeZone string `json:"timezone,omitempty"`
+
+	// Continuous enables the continuous restore, i.e. the restored xstore works as a warm standby
+	// and keeps applying the binlog backups of the source xstore. Optional.
+	// +optional
+	Continuous *XStoreContinuousRestore `json:"continuous,omitempty"`
+
+	// PointInTime replays the binlog backups of the source xstore newer than the restored backup
+	// up to the restore time after the backup set is restored, so that the data is restored to the
+	// time instead of the backup set. Only for restores by time, and not supported by continuous
+	// restore. Default is false.
+	// +optional
+	PointInTime bool `json:"pointInTime,omitempty"`
+
+	// Fallback enables falling back to the previous finished backup automatically if the restore
+	// from the selected backup fails, e.g. the backup fails the verification. The substitution is
+	// recorded in status. Default is false.
+	// +optional
+	Fallback bool `json:"fallback,omitempty"`
+
+	// Isolated restricts the egress of the restored nodes to the nodes of the same xstore, so that
+	// the restored nodes can't accidentally connect to the live cluster. Set it to false to promote
+	// the xstore. Not effective for nodes in host network. Default is false.
+	// +optional
+	Isolated bool `json:"isolated,omitempty"`
+
+	// Download configures the download of the backup set. Optional.
+	// +optional
+	Download *XStoreRestoreDownload `json:"download,omitempty"`
+
+	// PostRestoreExec defines the commands validating the restored data, e.g. a canary query or a
+	// check of sentinel rows. They are run one by one in the engine container of the restored leader
+	// before the restore finishes, and the restore fails with the captured output if any of them
+	// fails. Optional.
+	// +optional
+	PostRestoreExec []XStoreRestoreExec `json:"postRestoreExec,omitempty"`
+
+	// Masking defines the masking of the restored data, e.g. for populating the test environments
+	// from production backups. It's applied before the post restore commands, and the restore fails
+	// if any of the rules can't be applied. Not supported by continuous restore. Optional.
+	// +optional
+	Masking *XStoreRestoreMasking `json:"masking,omitempty"`
+
+	// Rejoin configures the wait for the restored nodes to rejoin the consensus group, the restore
+	// doesn't finish until all the nodes are members in the expected roles and caught up with the
+	// leader. Optional.
+	// +optional
+	Rejoin *XStoreRestoreRejoin `json:"rejoin,omitempty"`
+
+	// CompatibilityStrictness defines how the incompatibilities between the backup and the target are
+	// handled before restoring, e.g. engine version, page size and charset defaults recorded at backup
+	// time. Hard incompatibilities block the restore and soft ones are warned by default, Strict blocks
+	// on both and WarnOnly never blocks. The compatibility report is recorded in status.
+	// +kubebuilder:validation:Enum=Strict;Default;WarnOnly
+	// +kubebuilder:default=Default
+	// +optional
+	CompatibilityStrictness xstore.RestoreCompatibilityStrictness `json:"compatibilityStrictness,omitempty"`
+}
+
+// XStoreRestoreRejoin defines the bound of the restored nodes rejoining the consensus group.
+type XStoreRestoreRejoin struct {
+	// MaxLag is the max count of log entries a node can be behind the leader. Default is 1000.
+	// +optional
+	MaxLag int64 `json:"maxLag,omitempty"`
+
+	// Timeout is the timeout of the wait, the restore fails with the observed membership if
+	// the nodes haven't rejoined then. Default is 10m.
+	// +optional
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+}
+
+// XStoreRestoreMasking defines the masking of the restored data.
+type XStoreRestoreMasking struct {
+	// ConfigMap is the name of the configmap in the same namespace, whose key "rules" contains the
+	// masking rules in yaml, e.g.
+	//
+	//   - table: db1.users
+	//     column: email
+	//     method: hash
+	//   - table: db1.users
+	//     column: phone
+	//     method: shuffle
+	//     key: id
+	//
+	// Methods are "hash" (replaced by the sha256 of the value, truncated to the original length),
+	// "redact" (replaced by asterisks of the same length) and "shuffle" (values permuted among rows,
+	// requires the unique key column).
+	ConfigMap string `json:"configMap"`
+}
+
+// XStoreRestoreExec defines a command run against the restored xstore.
+type XStoreRestoreExec struct {
+	// Name is the name of the command.
+	Name string `json:"name"`
+
+	// Command is the command line, which succeeds if it exits with 0.
+	Command []string `json:"command"`
+
+	// Timeout is the timeout of the command. Default is 30s.
+	// +optional
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+}
+
+// XStoreRestoreDownload defines the download of the backup set during restore.
+type XStoreRestoreDownload struct {
+	// RateLimit limits the download speed of each node in bytes per second. Default is unlimited.
+	// +optional
+	RateLimit int64 `json:"rateLimit,omitempty"`
+
+	// Retries is the number of times the restore pod is restarted after failure. The interrupted
+	// download is resumed from where it left off. Default is 0.
+	// +optional
+	Retries int32 `json:"retries,omitempty"`
+}
+
+// XStoreContinuousRestore defines the continuous restore from the binlog backups.
+type XStoreContinuousRestore struct {
+	// MaxLag defines the bound of apply lag. Condition ContinuousRestoreLagging is set to true
+	// if the lag exceeds. Default is no bound.
+	// +optional
+	MaxLag metav1.Duration `json:"maxLag,omitempty"`
 }
 
 type XStoreSpec struct {
@@ -110,6 +231,35 @@ type XStoreSpec struct {
 	// Restore defines the spec of restore.
 	// +optional
 	Restore *XStoreRestoreSpec `json:"restore,omitempty"`
+
+	// RestoreConfigOverlay is a partial my.cnf merged over the config of the restored instance before
+	// it starts, e.g. to fit the buffer pool to the target hardware. Parameters are put into the mysqld
+	// section if no section is given. It's validated against the engine version recorded in the backup,
+	// and only effective during restore. Optional.
+	// +optional
+	RestoreConfigOverlay string `json:"restoreConfigOverlay,omitempty"`
+
+	// Suspended stops all the pods of the xstore while keeping the volumes, services and secrets.
+	// The pods are recreated on the same hosts and rejoin the consensus group once it's unset.
+	// Default is false.
+	// +optional
+	Suspended bool `json:"suspended,omitempty"`
+
+	// TLS enables TLS on the access ports of the engine with the certificates in the secret.
+	// Pods are rebuilt when it's changed.
+	// +optional
+	TLS *xstore.TLS `json:"tls,omitempty"`
+
+	// TDE enables the transparent data encryption on the engine. Pods are rebuilt when it's enabled.
+	// Once enabled, it can't be disabled, nor can the master key source be changed, while the master
+	// key version can be increased to rotate the master key online.
+	// +optional
+	TDE *xstore.TDE `json:"tde,omitempty"`
+
+	// LogCollector ships the slow logs of the engine to the configured sink with a sidecar
+	// collector. Pods are rebuilt when it's changed.
+	// +optional
+	LogCollector *xstore.LogCollector `json:"logCollector,omitempty"`
 }
 
 type XStoreStatus struct {
@@ -181,6 +331,75 @@ type XStoreStatus struct {
 
 	// RestartingPods represents pods need to restart
 	RestartingPods xstore.RestartingPods `json:"restartingPods,omitempty"`
+
+	// ContinuousRestore represents the status of continuous restore.
+	// +optional
+	ContinuousRestore *xstore.ContinuousRestoreStatus `json:"continuousRestore,omitempty"`
+
+	// RestoreFallback records the substitution of backup if the restore falls back.
+	// +optional
+	RestoreFallback *xstore.RestoreFallbackStatus `json:"restoreFallback,omitempty"`
+
+	// RestoreDownload represents the download progress of the backup set, keyed by pod name.
+	// +optional
+	RestoreDownload map[string]*xstore.RestoreDownloadStatus `json:"restoreDownload,omitempty"`
+
+	// RestoreReplay represents the checkpoint of binlog replay of the restore, keyed by pod name.
+	// +optional
+	RestoreReplay map[string]*xstore.RestoreReplayStatus `json:"restoreReplay,omitempty"`
+
+	// RestorePointInTime represents the progress of the binlog replay to the restore time.
+	// +optional
+	RestorePointInTime *xstore.PointInTimeRestoreStatus `json:"restorePointInTime,omitempty"`
+
+	// RestoreThaw represents the thaw progress of the archived backup objects if the backup set
+	// is in an archive storage class.
+	// +optional
+	RestoreThaw *xstore.RestoreThawStatus `json:"restoreThaw,omitempty"`
+
+	// RestoreConfigOverlay records the restore config overlay applied, it's kept in the engine config.
+	// +optional
+	RestoreConfigOverlay string `json:"restoreConfigOverlay,omitempty"`
+
+	// PostRestoreExec records the results of the post restore commands, in the order of spec.
+	// +optional
+	PostRestoreExec []xstore.PostRestoreExecStatus `json:"postRestoreExec,omitempty"`
+
+	// RestoreMasking records the progress of the masking of restored data.
+	// +optional
+	RestoreMasking *xstore.RestoreMaskingStatus `json:"restoreMasking,omitempty"`
+
+	// RestoreRejoin records the progress of the restored nodes rejoining the consensus group.
+	// +optional
+	RestoreRejoin *xstore.RestoreRejoinStatus `json:"restoreRejoin,omitempty"`
+
+	// RestoreCompatibility records the compatibility between the restored backup and the xstore.
+	// +optional
+	RestoreCompatibility *xstore.RestoreCompatibilityReport `json:"restoreCompatibility,omitempty"`
+
+	// ReadonlyReplication represents the replication from the primary xstore if it's readonly.
+	// +optional
+	ReadonlyReplication *xstore.ReadonlyReplicationStatus `json:"readonlyReplication,omitempty"`
+
+	// Switchover represents the last planned switchover of the leader.
+	// +optional
+	Switchover *xstore.SwitchoverStatus `json:"switchover,omitempty"`
+
+	// TLSCertificateHash is the hash of the server certificate loaded by the engines.
+	// +optional
+	TLSCertificateHash string `json:"tlsCertificateHash,omitempty"`
+
+	// TDEMasterKeyVersion is the version of the master key rotated to.
+	// +optional
+	TDEMasterKeyVersion int64 `json:"tdeMasterKeyVersion,omitempty"`
+
+	// VolumeMigration represents the migration from host path volumes to persistent volume claims.
+	// +optional
+	VolumeMigration *xstore.VolumeMigrationStatus `json:"volumeMigration,omitempty"`
+
+	// Progress represents the stages of the last operation, e.g. creating, upgrading or restoring.
+	// +optional
+	Progress *common.OperationProgress `json:"progress,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -18,6 +18,8 @@ package v1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/alibaba/polardbx-operator/api/v1/xstore"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -38,8 +40,346 @@ type XStoreBackupSpec struct {
 	Timezone string          `json:"timezone,omitempty"`
 	// RetentionTime defines how long will this backup set be kept
 	RetentionTime metav1.Duration `json:"retentionTime,omitempty"`
+	// Retention defines the retention rules besides the retention time
+	// +optional
+	Retention BackupRetention `json:"retention,omitempty"`
 	// StorageProvider defines backup storage configuration
 	StorageProvider BackupStorageProvider `json:"storageProvider,omitempty"`
+	// EnableDedupReport records content-defined chunk checksums of the full backup and
+	// reports how many chunks are shared with the previous backup.
+	// +optional
+	EnableDedupReport bool `json:"enableDedupReport,omitempty"`
+	// PreferSourceZone defines the zone where the target pod is preferred to be in
+	// +optional
+	PreferSourceZone string `json:"preferSourceZone,omitempty"`
+	// OverlapCollect allows the binlog collection to start before the full backup job finishes
+	// +optional
+	OverlapCollect bool `json:"overlapCollect,omitempty"`
+	// MaxFollowerLag bounds the replication lag of the follower which the backup is taken from
+	// +optional
+	MaxFollowerLag metav1.Duration `json:"maxFollowerLag,omitempty"`
+	// FallbackToLeaderOnLag takes the backup from leader if the follower lags more than MaxFollowerLag,
+	// or its lag is still unknown 10 minutes after the backup starts
+	// +optional
+	FallbackToLeaderOnLag bool `json:"fallbackToLeaderOnLag,omitempty"`
+	// PreferredBackupRole defines the role of the pod which the backup is taken from, default is follower
+	// +kubebuilder:validation:Enum=follower;learner;leader
+	// +optional
+	PreferredBackupRole BackupSourceRole `json:"preferredBackupRole,omitempty"`
+	// EphemeralLearner takes the backup from a learner provisioned just for the backup
+	// +optional
+	EphemeralLearner bool `json:"ephemeralLearner,omitempty"`
+	// FailedArtifactRetention defines how long the jobs of failed backup are kept for diagnosis
+	// +optional
+	FailedArtifactRetention metav1.Duration `json:"failedArtifactRetention,omitempty"`
+	// SkipEmptyBinlog skips uploading the tail binlog if no change events are found in the binlogs
+	// +optional
+	SkipEmptyBinlog bool `json:"skipEmptyBinlog,omitempty"`
+	// CircuitBreakerThreshold defines how many consecutive failures of the same step with the same
+	// reason open the circuit, zero means the default and negative disables it
+	// +optional
+	CircuitBreakerThreshold int32 `json:"circuitBreakerThreshold,omitempty"`
+	// StorageClass defines the storage class of the uploaded full backup and binlogs, empty means the default
+	// +optional
+	StorageClass string `json:"storageClass,omitempty"`
+	// FullBackupThreads defines the threads to copy the data files and the parts uploaded in parallel
+	// of the full backup, zero or one means single-threaded
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	FullBackupThreads int32 `json:"fullBackupThreads,omitempty"`
+	// CollectBatchBytes defines the size in bytes of binlog files collected and uploaded as a segment
+	// of transaction events, zero means a single pass over all binlogs
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	CollectBatchBytes int64 `json:"collectBatchBytes,omitempty"`
+	// UploadRetry retries the failed requests of each uploaded object of the backup jobs
+	// +optional
+	UploadRetry *BackupUploadRetry `json:"uploadRetry,omitempty"`
+	// ConsistencyWait polls each just-uploaded object of the backup jobs until it's readable
+	// +optional
+	ConsistencyWait *BackupConsistencyWait `json:"consistencyWait,omitempty"`
+	// JobVersionPolicy defines how the backup jobs created by operator of another version are handled
+	// +kubebuilder:default=Adopt
+	// +kubebuilder:validation:Enum=Adopt;Recreate;Fail
+	// +optional
+	JobVersionPolicy BackupJobVersionPolicy `json:"jobVersionPolicy,omitempty"`
+	// TopologyChangePolicy defines how the backup is handled if the topology of xstore changes during it
+	// +kubebuilder:default=Fail
+	// +kubebuilder:validation:Enum=Fail;Record
+	// +optional
+	TopologyChangePolicy BackupTopologyChangePolicy `json:"topologyChangePolicy,omitempty"`
+	// ConsistencyMode defines how the full backup keeps the snapshot consistent
+	// +kubebuilder:default=Default
+	// +kubebuilder:validation:Enum=Default;SnapshotLock
+	// +optional
+	ConsistencyMode BackupConsistencyMode `json:"consistencyMode,omitempty"`
+	// ImmutableUntil defines the time until which the backup files are locked by the storage, and the
+	// backup is never deleted by the retention
+	// +optional
+	ImmutableUntil *metav1.Time `json:"immutableUntil,omitempty"`
+	// BackupType defines whether the full backup copies all the data files, or only the pages changed
+	// since the base backup
+	// +kubebuilder:default=Full
+	// +kubebuilder:validation:Enum=Full;Incremental
+	// +optional
+	BackupType BackupType `json:"backupType,omitempty"`
+	// BaseBackupName defines the base backup of the incremental backup, which must be a finished backup
+	// of the same xstore in the same storage. Empty means the latest finished one
+	// +optional
+	BaseBackupName string `json:"baseBackupName,omitempty"`
+	// BackupEngine defines how the data is backed up, either copied by xtrabackup or taken as a CSI
+	// volume snapshot of the data PVC
+	// +kubebuilder:default=xtrabackup
+	// +kubebuilder:validation:Enum=xtrabackup;volumesnapshot
+	// +optional
+	BackupEngine BackupEngine `json:"backupEngine,omitempty"`
+	// VolumeSnapshotClassName defines the class of the volume snapshot, empty means the default one
+	// +optional
+	VolumeSnapshotClassName string `json:"volumeSnapshotClassName,omitempty"`
+	// CopyFrom makes the backup a clone of an existing finished xstore backup, whose files are
+	// already copied by the polardbx backup
+	// +optional
+	CopyFrom *BackupCopySource `json:"copyFrom,omitempty"`
+	// Notifications defines the channels notified once the backup is finished or failed
+	// +kubebuilder:validation:MaxItems=5
+	// +optional
+	Notifications []BackupNotification `json:"notifications,omitempty"`
+	// Catalog defines the relational catalog which the backup is exported to once it's finished or failed
+	// +optional
+	Catalog *BackupCatalog `json:"catalog,omitempty"`
+	// Encryption defines the key which the full backup and binlogs are encrypted with before uploaded
+	// +optional
+	Encryption *BackupEncryption `json:"encryption,omitempty"`
+	// Verification defines the restore drill verifying the backup once it's finished
+	// +optional
+	Verification *BackupVerification `json:"verification,omitempty"`
+	// Throttle defines the limits of disk IO and upload bandwidth of the backup jobs
+	// +optional
+	Throttle *BackupThrottle `json:"throttle,omitempty"`
+	// Hooks defines the commands executed before and after the backup
+	// +optional
+	Hooks *BackupHooks `json:"hooks,omitempty"`
+	// JobTemplate defines the scheduling controls and resources of the backup jobs
+	// +optional
+	JobTemplate *BackupJobTemplate `json:"jobTemplate,omitempty"`
+}
+
+// BackupHookErrorPolicy defines how a failed hook is treated.
+type BackupHookErrorPolicy string
+
+const (
+	// BackupHookErrorFail fails the backup if the hook fails.
+	BackupHookErrorFail BackupHookErrorPolicy = "Fail"
+	// BackupHookErrorContinue ignores the failure of the hook, which is still recorded.
+	BackupHookErrorContinue BackupHookErrorPolicy = "Continue"
+)
+
+// BackupExecHook defines a command executed in a container of the pods, like the exec hooks of Velero.
+type BackupExecHook struct {
+	// Name identifies the hook, unique among the hooks of the same stage.
+	Name string `json:"name"`
+	// Selector selects the pods in the namespace which the command is executed in, one by one.
+	// Default is the target pod of the backup, i.e. the one which the backup is taken from.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+	// Container is the container which the command is executed in. Default is the engine container
+	// of the target pod, or the first container of the selected pods.
+	// +optional
+	Container string `json:"container,omitempty"`
+	// Command is the command executed along with the args, e.g. ["/bin/sh", "-c", "..."]. It's not
+	// run in a shell.
+	// +kubebuilder:validation:MinItems=1
+	Command []string `json:"command"`
+	// Timeout is the time within which the command must finish in each pod, it fails otherwise.
+	// Default is 30s. The reconciliation is blocked while the command is executed, keep it short.
+	// +kubebuilder:default="30s"
+	// +optional
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+	// +kubebuilder:default=Fail
+	// +kubebuilder:validation:Enum=Fail;Continue
+
+	// OnError defines how a failure of the command, i.e. a non-zero exit or timeout, is treated.
+	// Default is Fail, which fails the backup without executing the rest hooks of the stage.
+	// +optional
+	OnError BackupHookErrorPolicy `json:"onError,omitempty"`
+}
+
+// BackupHooks defines the hooks executed before and after the backup. Each hook is executed once,
+// which is recorded in the status.
+type BackupHooks struct {
+	// PreBackup are executed in order right before the full backup job is started.
+	// +optional
+	PreBackup []BackupExecHook `json:"preBackup,omitempty"`
+	// PostBackup are executed in order once the full backup and binlogs are all uploaded. They're
+	// also executed once the backup fails, when the failures are only recorded.
+	// +optional
+	PostBackup []BackupExecHook `json:"postBackup,omitempty"`
+}
+
+// BackupHookStage is the stage of backup when a hook is executed.
+type BackupHookStage string
+
+const (
+	BackupHookPreBackup  BackupHookStage = "PreBackup"
+	BackupHookPostBackup BackupHookStage = "PostBackup"
+)
+
+// BackupHookResult records the execution of a hook.
+type BackupHookResult struct {
+	// Name is the name of the hook.
+	Name string `json:"name"`
+	// Stage is the stage of the hook.
+	Stage BackupHookStage `json:"stage"`
+	// Pods are the pods which the command is executed in.
+	// +optional
+	Pods []string `json:"pods,omitempty"`
+	// Succeeded tells whether the command succeeded in all the pods.
+	Succeeded bool `json:"succeeded"`
+	// Message is the error of the failed command, along with the truncated stderr.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// ExecutedAt is when the hook is executed.
+	ExecutedAt metav1.Time `json:"executedAt"`
+}
+
+// BackupCatalog defines a MySQL compatible database as the catalog of backups. A row per completed
+// backup is upserted into the table, keyed by the uid of backup. Export is retried with backoff and
+// never blocks the reconciliation.
+type BackupCatalog struct {
+	// Endpoint is the address of the database, e.g. "catalog.example.com:3306".
+	Endpoint string `json:"endpoint"`
+	// Database is the database of the table.
+	Database string `json:"database"`
+	// Table is the table of the backups, which is created if not found. Default is "polardbx_backups".
+	// +kubebuilder:default=polardbx_backups
+	// +kubebuilder:validation:Pattern=`^[A-Za-z_][A-Za-z0-9_]*$`
+	// +optional
+	Table string `json:"table,omitempty"`
+	// SecretName is the name of the secret holding the "username" and "password" of the database.
+	SecretName string `json:"secretName"`
+}
+
+// BackupCatalogStatus records the export of the backup to the catalog.
+type BackupCatalogStatus struct {
+	// Phase is the phase of the backup which is exported.
+	Phase XStoreBackupPhase `json:"phase,omitempty"`
+	// Exported indicates whether the row of backup is upserted.
+	Exported bool `json:"exported,omitempty"`
+	// Attempts is the count of export attempts.
+	Attempts int32 `json:"attempts,omitempty"`
+	// LastAttemptTime is the time of the last attempt.
+	// +optional
+	LastAttemptTime *metav1.Time `json:"lastAttemptTime,omitempty"`
+	// NextAttemptTime is the time of the next attempt, empty if there is none.
+	// +optional
+	NextAttemptTime *metav1.Time `json:"nextAttemptTime,omitempty"`
+	// Message is the error of the last attempt.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// BackupNotification defines a webhook which is called once the backup is finished or failed.
+// Delivery is retried with backoff and never blocks the reconciliation.
+type BackupNotification struct {
+	// Name identifies the channel, must be unique in the backup.
+	Name string `json:"name"`
+	// Webhook is the http(s) url which the payload is posted to, e.g. an incoming webhook of Slack.
+	// Only public addresses are allowed, i.e. not the ones of loopback, link-local or private
+	// networks, and redirects are not followed.
+	Webhook string `json:"webhook"`
+	// PayloadTemplate is a Go text/template rendering the payload. The fields available are Name,
+	// Namespace, XStore, Phase, StartTime, EndTime, BackupSetTimestamp, BackupSize, BackupRootPath,
+	// FailureReason and Message. Default is the JSON of these fields.
+	// +optional
+	PayloadTemplate string `json:"payloadTemplate,omitempty"`
+	// ContentType is the content type of the payload. Default is "application/json".
+	// +optional
+	ContentType string `json:"contentType,omitempty"`
+}
+
+// BackupNotificationStatus records the delivery of a notification channel.
+type BackupNotificationStatus struct {
+	// Name is the name of the channel.
+	Name string `json:"name,omitempty"`
+	// Phase is the phase of the backup which is notified.
+	Phase XStoreBackupPhase `json:"phase,omitempty"`
+	// Delivered indicates whether the notification is delivered.
+	Delivered bool `json:"delivered,omitempty"`
+	// Attempts is the count of delivery attempts.
+	Attempts int32 `json:"attempts,omitempty"`
+	// LastAttemptTime is the time of the last attempt.
+	// +optional
+	LastAttemptTime *metav1.Time `json:"lastAttemptTime,omitempty"`
+	// NextAttemptTime is the time of the next attempt, empty if there is none.
+	// +optional
+	NextAttemptTime *metav1.Time `json:"nextAttemptTime,omitempty"`
+	// Message is the error of the last attempt.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// BackupDedupReport describes the potential savings if the backup is stored in a dedup store.
+type BackupDedupReport struct {
+	// BaseBackup is the name of previous backup which is compared with, empty if there is none.
+	// +optional
+	BaseBackup string `json:"baseBackup,omitempty"`
+	// TotalChunks is the count of chunks in the full backup.
+	TotalChunks int64 `json:"totalChunks,omitempty"`
+	// TotalBytes is the size of the full backup.
+	TotalBytes int64 `json:"totalBytes,omitempty"`
+	// SharedChunks is the count of chunks which exist in base backup or appear more than once.
+	SharedChunks int64 `json:"sharedChunks,omitempty"`
+	// SharedBytes is the size of shared chunks.
+	SharedBytes int64 `json:"sharedBytes,omitempty"`
+	// DedupRatio is the ratio of shared bytes to total bytes, formatted like "0.85".
+	DedupRatio string `json:"dedupRatio,omitempty"`
+}
+
+// BackupProgress records the progress of the job uploading the files of the backup, reported by
+// the job and refreshed on each reconcile.
+type BackupProgress struct {
+	// BytesTransferred is the size in bytes uploaded so far.
+	BytesTransferred int64 `json:"bytesTransferred,omitempty"`
+	// EstimatedTotalBytes is the estimated size in bytes to upload, zero if it's unknown.
+	// +optional
+	EstimatedTotalBytes int64 `json:"estimatedTotalBytes,omitempty"`
+	// Throughput is the average throughput since the job started, formatted like "12.5MiB/s".
+	// +optional
+	Throughput string `json:"throughput,omitempty"`
+	// Percent is the percent complete, which stays below 100 until the job completes. It's absent
+	// if the estimated total is unknown.
+	// +optional
+	Percent *int32 `json:"percent,omitempty"`
+	// UpdateTime is when the progress is last reported by the job.
+	// +optional
+	UpdateTime *metav1.Time `json:"updateTime,omitempty"`
+}
+
+// BackupRetentionUsage records the storage usage of backups of the cluster against the budget.
+type BackupRetentionUsage struct {
+	// UsedBytes is the total size of finished backups of the cluster.
+	UsedBytes int64 `json:"usedBytes,omitempty"`
+	// MaxTotalBytes is the budget of total storage.
+	MaxTotalBytes int64 `json:"maxTotalBytes,omitempty"`
+}
+
+// BackupRetentionPass records the result of the last retention pass run by the backup.
+type BackupRetentionPass struct {
+	// Time is when the pass is run.
+	Time metav1.Time `json:"time,omitempty"`
+	// Deleted is the number of aged backups deleted.
+	Deleted int32 `json:"deleted,omitempty"`
+	// Skipped is the number of aged backups kept since they're referenced by active restores.
+	Skipped int32 `json:"skipped,omitempty"`
+	// Failed is the number of aged backups failed to delete, including the throttled ones.
+	Failed int32 `json:"failed,omitempty"`
+	// Locked is the number of aged backups kept since they're still immutable, or their files are
+	// locked by the storage.
+	// +optional
+	Locked int32 `json:"locked,omitempty"`
+	// Throttled indicates the storage throttled the deletions, and the rest is left to the next pass.
+	// +optional
+	Throttled bool `json:"throttled,omitempty"`
 }
 
 // XStoreBackupStatus defines the observed state of XStoreBackup
@@ -53,19 +393,339 @@ type XStoreBackupStatus struct {
 	StorageName BackupStorage `json:"storageName,omitempty"`
 	// BackupRootPath stores the root path of backup set
 	BackupRootPath string `json:"backupRootPath,omitempty"`
-	// BackupSetTimestamp records timestamp of last event included in tailored binlog
+	// StorageClass records the storage class of the uploaded full backup and binlogs
+	// +optional
+	StorageClass string `json:"storageClass,omitempty"`
+	// BackupSetTimestamp records timestamp of last event included in tailored binlog, always in UTC
 	BackupSetTimestamp *metav1.Time `json:"backupSetTimestamp,omitempty"`
+	// EarliestRecoverableTimestamp records the earliest timestamp the backup can restore to, i.e. the
+	// start of the full backup, while the latest one is the backup set timestamp. Both are also labeled
+	// in unix seconds once the backup is finished
+	// +optional
+	EarliestRecoverableTimestamp *metav1.Time `json:"earliestRecoverableTimestamp,omitempty"`
+	// SourceTimeZoneOffset records the offset of the server time zone when the binlog is backed up, e.g. "+08:00",
+	// empty if it's unknown, e.g. backed up by tools of older versions
+	// +optional
+	SourceTimeZoneOffset string `json:"sourceTimeZoneOffset,omitempty"`
+	// BackupSize records the size of full backup in bytes
+	BackupSize int64 `json:"backupSize,omitempty"`
+	// EstimatedSizeBytes is the estimated size of the full backup in bytes, i.e. the size of data
+	// of the xstore when the backup started
+	// +optional
+	EstimatedSizeBytes int64 `json:"estimatedSizeBytes,omitempty"`
+	// EstimatedDuration is the estimated duration of the full backup, e.g. "1h30m0s", by the
+	// throughput of recent backups of the xstore. Empty if there's no history
+	// +optional
+	EstimatedDuration string `json:"estimatedDuration,omitempty"`
+	// FullBackupProgress records the progress of the full backup job
+	// +optional
+	FullBackupProgress *BackupProgress `json:"fullBackupProgress,omitempty"`
+	// BinlogBackupProgress records the progress of the binlog backup job
+	// +optional
+	BinlogBackupProgress *BackupProgress `json:"binlogBackupProgress,omitempty"`
+	// CollectSegments records the count of transaction event segments uploaded by the batched binlog
+	// collection, it's set once the final segment is flushed
+	// +optional
+	CollectSegments int32 `json:"collectSegments,omitempty"`
+	// EngineVersion records the engine version of the xstore when the backup started
+	// +optional
+	EngineVersion string `json:"engineVersion,omitempty"`
+	// Compatibility records the engine facts of the full backup checked before restoring it, absent
+	// if the backup is taken by tools of older versions
+	// +optional
+	Compatibility *xstore.EngineCompatibility `json:"compatibility,omitempty"`
+	// BinlogEventsCount is the count of change events in the binlogs of the backup, zero means
+	// nothing changed and condition InfoNoChanges is set
+	// +optional
+	BinlogEventsCount *int64 `json:"binlogEventsCount,omitempty"`
+	// RetentionUsage records the backup storage usage of the cluster once the budget is enforced, only
+	// by the first backup by name of the xstore backups of the pxc backup, and only if the budget is set
+	// +optional
+	RetentionUsage *BackupRetentionUsage `json:"retentionUsage,omitempty"`
+	// RetentionPass records the result of the last retention pass run by the backup
+	// +optional
+	RetentionPass *BackupRetentionPass `json:"retentionPass,omitempty"`
+	// KeyringPath records the path of the keyring uploaded along with the full backup, only if TDE is
+	// enabled. It's restored before the backup is prepared, so that the encrypted tablespaces can be read
+	// +optional
+	KeyringPath string `json:"keyringPath,omitempty"`
+	// DedupReport records chunk dedup statistics of the full backup, only if dedup report enabled
+	// +optional
+	DedupReport *BackupDedupReport `json:"dedupReport,omitempty"`
+	// TargetZone records the zone of the target pod, only if the source zone is preferred
+	// +optional
+	TargetZone string `json:"targetZone,omitempty"`
+	// SourceLag records the observed replication lag of the target pod, only if the lag is bounded
+	// +optional
+	SourceLag string `json:"sourceLag,omitempty"`
+	// EphemeralLearner records the name of the learner xstore provisioned for the backup
+	// +optional
+	EphemeralLearner string `json:"ephemeralLearner,omitempty"`
+	// QueuePosition is the position of the backup in the backup queue of the namespace, starting
+	// from 1, only if the backup is pending since the quota of concurrent backups is used up
+	// +optional
+	QueuePosition int32 `json:"queuePosition,omitempty"`
+	// UploadRetries records the retries of the uploaded objects, only if upload retry is set
+	// +optional
+	UploadRetries *BackupUploadRetryStats `json:"uploadRetries,omitempty"`
+	// ConsistencyWaits records the waits of the uploaded objects until readable, only if
+	// consistency wait is set
+	// +optional
+	ConsistencyWaits *BackupConsistencyWaitStats `json:"consistencyWaits,omitempty"`
+	// Topology records the topology of xstore when the backup started
+	// +optional
+	Topology *BackupTopology `json:"topology,omitempty"`
+	// Consistency records the consistency mode used by the full backup
+	// +optional
+	Consistency *BackupConsistencyStatus `json:"consistency,omitempty"`
+	// BackupType records the type of the full backup taken, which is Full if the incremental backup
+	// falls back for lack of base
+	// +optional
+	BackupType BackupType `json:"backupType,omitempty"`
+	// ToLsn records the LSN which the data files are copied up to, i.e. the starting LSN of the
+	// incremental backups based on this one
+	// +optional
+	ToLsn string `json:"toLsn,omitempty"`
+	// Incremental records the base of the incremental backup
+	// +optional
+	Incremental *IncrementalBackupStatus `json:"incremental,omitempty"`
+	// VolumeSnapshot records the volume snapshot of the data PVC, only if taken by engine volumesnapshot
+	// +optional
+	VolumeSnapshot *BackupVolumeSnapshotStatus `json:"volumeSnapshot,omitempty"`
+	// Verification records the restore drill verifying the backup
+	// +optional
+	Verification *BackupVerificationStatus `json:"verification,omitempty"`
+	// Hooks records the executions of the pre and post backup hooks
+	// +optional
+	Hooks []BackupHookResult `json:"hooks,omitempty"`
+	// CircuitBreaker records the consecutive failures of the backup
+	// +optional
+	CircuitBreaker *BackupCircuitBreakerStatus `json:"circuitBreaker,omitempty"`
+	// Conditions represents the conditions of the backup
+	// +optional
+	Conditions []xstore.Condition `json:"conditions,omitempty"`
+	// Message represents the human readable reason of failure
+	// +optional
+	Message string `json:"message,omitempty"`
+	// FailureReason represents the machine-stable reason of failure
+	// +optional
+	FailureReason BackupFailureReason `json:"failureReason,omitempty"`
+	// TriggerSource represents how the backup came to exist, inherited from the pxc backup
+	// +optional
+	TriggerSource BackupTriggerSource `json:"triggerSource,omitempty"`
+	// Notifications records the delivery of the notification channels
+	// +optional
+	Notifications []BackupNotificationStatus `json:"notifications,omitempty"`
+	// Catalog records the export of the backup to the catalog
+	// +optional
+	Catalog *BackupCatalogStatus `json:"catalog,omitempty"`
+	// PhaseHistory records the phase transitions of the backup, oldest first. Only the latest
+	// MaxBackupPhaseHistory transitions are kept
+	// +optional
+	PhaseHistory []BackupPhaseTransition `json:"phaseHistory,omitempty"`
+	// IncompleteUploads records the incomplete multipart uploads found under the backup files, e.g.
+	// left by crashed or recreated jobs. Only the first MaxBackupIncompleteUploads are kept
+	// +optional
+	IncompleteUploads []IncompleteUpload `json:"incompleteUploads,omitempty"`
+	// Trace records the trace context of the backup, whose span is a child of the pxc backup's
+	// +optional
+	Trace *BackupTrace `json:"trace,omitempty"`
+}
+
+// BackupVolumeSnapshotStatus records the CSI volume snapshot taken by the backup and the binlog
+// position captured under the global read lock.
+type BackupVolumeSnapshotStatus struct {
+	// Name is the name of the VolumeSnapshot, in the namespace of the backup.
+	Name string `json:"name,omitempty"`
+	// SourcePod is the pod whose data PVC is snapshotted.
+	SourcePod string `json:"sourcePod,omitempty"`
+	// SourcePVC is the data PVC which is snapshotted.
+	SourcePVC string `json:"sourcePVC,omitempty"`
+	// StorageClassName is the storage class of the source PVC, with which the PVCs are restored.
+	// +optional
+	StorageClassName string `json:"storageClassName,omitempty"`
+	// ContentName is the name of the VolumeSnapshotContent bound to the snapshot.
+	// +optional
+	ContentName string `json:"contentName,omitempty"`
+	// SnapshotHandle is the handle of the snapshot in the storage system.
+	// +optional
+	SnapshotHandle string `json:"snapshotHandle,omitempty"`
+	// RestoreSize is the minimum size of the volume restored from the snapshot, e.g. "100Gi".
+	// +optional
+	RestoreSize string `json:"restoreSize,omitempty"`
+	// ReadyToUse indicates whether the snapshot is ready to be restored.
+	// +optional
+	ReadyToUse bool `json:"readyToUse,omitempty"`
+	// BinlogFile is the binlog file when the snapshot is cut.
+	// +optional
+	BinlogFile string `json:"binlogFile,omitempty"`
+	// BinlogPosition is the position in the binlog file when the snapshot is cut.
+	// +optional
+	BinlogPosition int64 `json:"binlogPosition,omitempty"`
+	// LockDuration is how long the global read lock is held, e.g. "1.2s".
+	// +optional
+	LockDuration string `json:"lockDuration,omitempty"`
+	// CreationTime is when the snapshot is cut by the storage system.
+	// +optional
+	CreationTime *metav1.Time `json:"creationTime,omitempty"`
+}
+
+// BackupConsistencyStatus records the consistency mode of the full backup.
+type BackupConsistencyStatus struct {
+	// Requested is the consistency mode in spec.
+	Requested BackupConsistencyMode `json:"requested,omitempty"`
+	// Used is the consistency mode the full backup actually used.
+	Used BackupConsistencyMode `json:"used,omitempty"`
+	// Reason is why the full backup fell back to the default mode.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+}
+
+// IncrementalBackupStatus records the chain of backups which the incremental backup is based on.
+type IncrementalBackupStatus struct {
+	// BaseBackup is the backup which the incremental backup is directly based on.
+	BaseBackup string `json:"baseBackup,omitempty"`
+	// FromLsn is the LSN since which the changed pages are copied, i.e. the ToLsn of base.
+	FromLsn string `json:"fromLsn,omitempty"`
+	// Chain is the backups to apply before this one by the restore, the full backup first and the base
+	// last. All of them are kept by the retention as long as this one exists.
+	Chain []string `json:"chain,omitempty"`
+}
+
+// BackupVerificationPhase is the phase of the restore drill.
+type BackupVerificationPhase string
+
+const (
+	BackupVerificationRunning BackupVerificationPhase = "Running"
+	BackupVerificationPassed  BackupVerificationPhase = "Passed"
+	BackupVerificationFailed  BackupVerificationPhase = "Failed"
+)
+
+// BackupVerificationStatus records the restore drill verifying the backup.
+type BackupVerificationStatus struct {
+	// Phase is the phase of the drill.
+	Phase BackupVerificationPhase `json:"phase,omitempty"`
+	// XStore is the throwaway xstore restored from the backup, which is removed once the drill finishes.
+	XStore string `json:"xstore,omitempty"`
+	// StartTime is the time when the drill starts.
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// FinishTime is the time when the drill passes or fails.
+	FinishTime *metav1.Time `json:"finishTime,omitempty"`
+	// Checks are the results of the checks run on the restored data.
+	Checks []xstore.PostRestoreExecStatus `json:"checks,omitempty"`
+	// Message is the reason of failure.
+	Message string `json:"message,omitempty"`
+}
+
+// BackupTopology is the snapshot of the topology of xstore taken by the backup.
+type BackupTopology struct {
+	// Generation is the generation of xstore when the snapshot is taken.
+	Generation int64 `json:"generation,omitempty"`
+	// NodeSets are the node sets of xstore.
+	NodeSets []BackupTopologyNodeSet `json:"nodeSets,omitempty"`
+	// Changed indicates the topology changed during the backup, and the backup is restored into
+	// the recorded one. It's only set if the topology change policy is Record.
+	// +optional
+	Changed bool `json:"changed,omitempty"`
+	// ChangedGeneration is the generation of xstore when the change is detected.
+	// +optional
+	ChangedGeneration int64 `json:"changedGeneration,omitempty"`
+}
+
+// BackupTopologyNodeSet is a node set in the topology snapshot.
+type BackupTopologyNodeSet struct {
+	// Name is the name of node set.
+	Name string `json:"name"`
+	// Role is the role of nodes of the node set.
+	Role xstore.NodeRole `json:"role,omitempty"`
+	// Replicas is the number of nodes of the node set.
+	Replicas int32 `json:"replicas,omitempty"`
+}
+
+// MaxBackupIncompleteUploads is the max length of the incomplete uploads of backup.
+const MaxBackupIncompleteUploads = 32
+
+// IncompleteUpload is a multipart upload which is neither completed nor aborted.
+type IncompleteUpload struct {
+	// Path is the path of the file being uploaded
+	Path string `json:"path"`
+	// UploadId is the id of the multipart upload
+	UploadId string `json:"uploadId"`
+	// Initiated is the time when the upload is initiated
+	Initiated metav1.Time `json:"initiated"`
+	// Aborted indicates whether the upload is aborted, i.e. the uploaded parts are removed
+	// +optional
+	Aborted bool `json:"aborted,omitempty"`
+}
+
+// MaxBackupRetriedObjects is the max count of retried objects recorded in the upload retry stats.
+const MaxBackupRetriedObjects = 32
+
+// BackupUploadRetryStats records the retries of the objects uploaded by the backup jobs.
+type BackupUploadRetryStats struct {
+	// TotalRetries is the total retries of all the uploaded objects
+	TotalRetries int64 `json:"totalRetries"`
+	// Objects are the retried objects, most retried first. Only the top MaxBackupRetriedObjects
+	// objects are kept
+	// +optional
+	Objects []ObjectUploadRetries `json:"objects,omitempty"`
+}
+
+// ObjectUploadRetries records the retries of an uploaded object.
+type ObjectUploadRetries struct {
+	// Path is the remote path of the object
+	Path string `json:"path"`
+	// Retries is the count of retried requests of the object
+	Retries int64 `json:"retries"`
+}
+
+// MaxBackupWaitedObjects is the max count of waited objects recorded in the consistency wait stats.
+const MaxBackupWaitedObjects = 32
+
+// BackupConsistencyWaitStats records the waits of the objects uploaded by the backup jobs until
+// they are readable.
+type BackupConsistencyWaitStats struct {
+	// TotalWaitMillis is the total milliseconds waited for all the uploaded objects
+	TotalWaitMillis int64 `json:"totalWaitMillis"`
+	// Objects are the waited objects, longest waited first. Only the top MaxBackupWaitedObjects
+	// objects are kept
+	// +optional
+	Objects []ObjectConsistencyWait `json:"objects,omitempty"`
+}
+
+// ObjectConsistencyWait records the wait of an uploaded object until it's readable.
+type ObjectConsistencyWait struct {
+	// Path is the remote path of the object
+	Path string `json:"path"`
+	// WaitMillis is the milliseconds waited until the object is readable
+	WaitMillis int64 `json:"waitMillis"`
+}
+
+// MaxBackupPhaseHistory is the max length of the phase history of backup.
+const MaxBackupPhaseHistory = 32
+
+// BackupPhaseTransition records a phase the backup has entered.
+type BackupPhaseTransition struct {
+	// Phase is the entered phase
+	Phase XStoreBackupPhase `json:"phase"`
+	// EnteredAt is the time when the phase is entered
+	EnteredAt metav1.Time `json:"enteredAt"`
+	// Duration is how long the backup stayed in the phase, empty for the current phase
+	// +optional
+	Duration string `json:"duration,omitempty"`
 }
 
 type XStoreBackupPhase string
 
 const (
 	XStoreBackupNew        XStoreBackupPhase = ""
+	XStoreBackupPending    XStoreBackupPhase = "Pending"
 	XStoreFullBackuping    XStoreBackupPhase = "Backuping"
 	XStoreBackupCollecting XStoreBackupPhase = "Collecting"
 	XStoreBinlogBackuping  XStoreBackupPhase = "Binloging"
 	XStoreBinlogWaiting    XStoreBackupPhase = "Waiting"
 	XStoreBackupFinished   XStoreBackupPhase = "Finished"
+	XStoreBackupFailed     XStoreBackupPhase = "Failed"
 )
 
 // +kubebuilder:object:root=true
@@ -75,7 +735,13 @@ const (
 // +kubebuilder:printcolumn:name="START",type=string,JSONPath=`.status.startTime`
 // +kubebuilder:printcolumn:name="END",type=string,JSONPath=`.status.endTime`
 // +kubebuilder:printcolumn:name="PHASE",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="PROGRESS",type=integer,priority=1,JSONPath=`.status.fullBackupProgress.percent`
+// +kubebuilder:printcolumn:name="RECOVERABLE_FROM",type=string,priority=1,JSONPath=`.status.earliestRecoverableTimestamp`
+// +kubebuilder:printcolumn:name="RECOVERABLE_TO",type=string,priority=1,JSONPath=`.status.backupSetTimestamp`
 // +kubebuilder:printcolumn:name="RETENTION",type=string,priority=1,JSONPath=`.spec.retentionTime`
+// +kubebuilder:printcolumn:name="DEDUP",type=string,priority=1,JSONPath=`.status.dedupReport.dedupRatio`
+// +kubebuilder:printcolumn:name="FAILURE",type=string,priority=1,JSONPath=`.status.failureReason`
+// +kubebuilder:printcolumn:name="VERIFICATION",type=string,priority=1,JSONPath=`.status.verification.phase`
 // +kubebuilder:printcolumn:name="AGE",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // XStoreBackup is the Schema for the XStorebackups API
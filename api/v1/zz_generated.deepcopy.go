@@ -22,176 +22,1946 @@ limitations under the License.
 package v1
 
 import (
+	"github.com/alibaba/polardbx-operator/api/v1/common"
 	"github.com/alibaba/polardbx-operator/api/v1/polardbx"
 	"github.com/alibaba/polardbx-operator/api/v1/xstore"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoscalerMetric) DeepCopyInto(out *AutoscalerMetric) {
+	*out = *in
+	out.Target = in.Target.DeepCopy()
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoscalerMetric.
+func (in *AutoscalerMetric) DeepCopy() *AutoscalerMetric {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoscalerMetric)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoscalerMetricStatus) DeepCopyInto(out *AutoscalerMetricStatus) {
+	*out = *in
+	if in.Current != nil {
+		in, out := &in.Current, &out.Current
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoscalerMetricStatus.
+func (in *AutoscalerMetricStatus) DeepCopy() *AutoscalerMetricStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoscalerMetricStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoscalerRecommendation) DeepCopyInto(out *AutoscalerRecommendation) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoscalerRecommendation.
+func (in *AutoscalerRecommendation) DeepCopy() *AutoscalerRecommendation {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoscalerRecommendation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupCDCCheckpoint) DeepCopyInto(out *BackupCDCCheckpoint) {
+	*out = *in
+	if in.CaptureTime != nil {
+		in, out := &in.CaptureTime, &out.CaptureTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupCDCCheckpoint.
+func (in *BackupCDCCheckpoint) DeepCopy() *BackupCDCCheckpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupCDCCheckpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupCDCConsistency) DeepCopyInto(out *BackupCDCConsistency) {
+	*out = *in
+	out.MaxDivergence = in.MaxDivergence
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupCDCConsistency.
+func (in *BackupCDCConsistency) DeepCopy() *BackupCDCConsistency {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupCDCConsistency)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupCatalog) DeepCopyInto(out *BackupCatalog) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupCatalog.
+func (in *BackupCatalog) DeepCopy() *BackupCatalog {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupCatalog)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupCatalogStatus) DeepCopyInto(out *BackupCatalogStatus) {
+	*out = *in
+	if in.LastAttemptTime != nil {
+		in, out := &in.LastAttemptTime, &out.LastAttemptTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NextAttemptTime != nil {
+		in, out := &in.NextAttemptTime, &out.NextAttemptTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupCatalogStatus.
+func (in *BackupCatalogStatus) DeepCopy() *BackupCatalogStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupCatalogStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupCheckpointOrderingStatus) DeepCopyInto(out *BackupCheckpointOrderingStatus) {
+	*out = *in
+	if in.GMSCaptureTime != nil {
+		in, out := &in.GMSCaptureTime, &out.GMSCaptureTime
+		*out = (*in).DeepCopy()
+	}
+	if in.HeartbeatTime != nil {
+		in, out := &in.HeartbeatTime, &out.HeartbeatTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupCheckpointOrderingStatus.
+func (in *BackupCheckpointOrderingStatus) DeepCopy() *BackupCheckpointOrderingStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupCheckpointOrderingStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupCircuitBreakerStatus) DeepCopyInto(out *BackupCircuitBreakerStatus) {
+	*out = *in
+	if in.LastFailureTime != nil {
+		in, out := &in.LastFailureTime, &out.LastFailureTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupCircuitBreakerStatus.
+func (in *BackupCircuitBreakerStatus) DeepCopy() *BackupCircuitBreakerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupCircuitBreakerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupConsistencyStatus) DeepCopyInto(out *BackupConsistencyStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupConsistencyStatus.
+func (in *BackupConsistencyStatus) DeepCopy() *BackupConsistencyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupConsistencyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupConsistencyWait) DeepCopyInto(out *BackupConsistencyWait) {
+	*out = *in
+	out.Timeout = in.Timeout
+	out.Interval = in.Interval
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupConsistencyWait.
+func (in *BackupConsistencyWait) DeepCopy() *BackupConsistencyWait {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupConsistencyWait)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupConsistencyWaitStats) DeepCopyInto(out *BackupConsistencyWaitStats) {
+	*out = *in
+	if in.Objects != nil {
+		in, out := &in.Objects, &out.Objects
+		*out = make([]ObjectConsistencyWait, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupConsistencyWaitStats.
+func (in *BackupConsistencyWaitStats) DeepCopy() *BackupConsistencyWaitStats {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupConsistencyWaitStats)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupCopySource) DeepCopyInto(out *BackupCopySource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupCopySource.
+func (in *BackupCopySource) DeepCopy() *BackupCopySource {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupCopySource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupDedupReport) DeepCopyInto(out *BackupDedupReport) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupDedupReport.
+func (in *BackupDedupReport) DeepCopy() *BackupDedupReport {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupDedupReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupEncryption) DeepCopyInto(out *BackupEncryption) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupEncryption.
+func (in *BackupEncryption) DeepCopy() *BackupEncryption {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupEncryption)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupExecHook) DeepCopyInto(out *BackupExecHook) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.Timeout = in.Timeout
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupExecHook.
+func (in *BackupExecHook) DeepCopy() *BackupExecHook {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupExecHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupHookResult) DeepCopyInto(out *BackupHookResult) {
+	*out = *in
+	if in.Pods != nil {
+		in, out := &in.Pods, &out.Pods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.ExecutedAt.DeepCopyInto(&out.ExecutedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupHookResult.
+func (in *BackupHookResult) DeepCopy() *BackupHookResult {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupHookResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupHooks) DeepCopyInto(out *BackupHooks) {
+	*out = *in
+	if in.PreBackup != nil {
+		in, out := &in.PreBackup, &out.PreBackup
+		*out = make([]BackupExecHook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PostBackup != nil {
+		in, out := &in.PostBackup, &out.PostBackup
+		*out = make([]BackupExecHook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupHooks.
+func (in *BackupHooks) DeepCopy() *BackupHooks {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupHooks)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupJobTemplate) DeepCopyInto(out *BackupJobTemplate) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]corev1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(corev1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(corev1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TTLSecondsAfterFinished != nil {
+		in, out := &in.TTLSecondsAfterFinished, &out.TTLSecondsAfterFinished
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupJobTemplate.
+func (in *BackupJobTemplate) DeepCopy() *BackupJobTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupJobTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupNotification) DeepCopyInto(out *BackupNotification) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupNotification.
+func (in *BackupNotification) DeepCopy() *BackupNotification {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupNotification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupNotificationStatus) DeepCopyInto(out *BackupNotificationStatus) {
+	*out = *in
+	if in.LastAttemptTime != nil {
+		in, out := &in.LastAttemptTime, &out.LastAttemptTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NextAttemptTime != nil {
+		in, out := &in.NextAttemptTime, &out.NextAttemptTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupNotificationStatus.
+func (in *BackupNotificationStatus) DeepCopy() *BackupNotificationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupNotificationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupObjectShare) DeepCopyInto(out *BackupObjectShare) {
+	*out = *in
+	out.Expiry = in.Expiry
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupObjectShare.
+func (in *BackupObjectShare) DeepCopy() *BackupObjectShare {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupObjectShare)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupObjectShareStatus) DeepCopyInto(out *BackupObjectShareStatus) {
+	*out = *in
+	out.Expiry = in.Expiry
+	if in.ExpireTime != nil {
+		in, out := &in.ExpireTime, &out.ExpireTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupObjectShareStatus.
+func (in *BackupObjectShareStatus) DeepCopy() *BackupObjectShareStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupObjectShareStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupPhaseTransition) DeepCopyInto(out *BackupPhaseTransition) {
+	*out = *in
+	in.EnteredAt.DeepCopyInto(&out.EnteredAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupPhaseTransition.
+func (in *BackupPhaseTransition) DeepCopy() *BackupPhaseTransition {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupPhaseTransition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupProgress) DeepCopyInto(out *BackupProgress) {
+	*out = *in
+	if in.Percent != nil {
+		in, out := &in.Percent, &out.Percent
+		*out = new(int32)
+		**out = **in
+	}
+	if in.UpdateTime != nil {
+		in, out := &in.UpdateTime, &out.UpdateTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupProgress.
+func (in *BackupProgress) DeepCopy() *BackupProgress {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupProgress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupRestorePreview) DeepCopyInto(out *BackupRestorePreview) {
+	*out = *in
+	out.Timeout = in.Timeout
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupRestorePreview.
+func (in *BackupRestorePreview) DeepCopy() *BackupRestorePreview {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupRestorePreview)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupRestorePreviewCheck) DeepCopyInto(out *BackupRestorePreviewCheck) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupRestorePreviewCheck.
+func (in *BackupRestorePreviewCheck) DeepCopy() *BackupRestorePreviewCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupRestorePreviewCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupRestorePreviewStatus) DeepCopyInto(out *BackupRestorePreviewStatus) {
+	*out = *in
+	if in.Time != nil {
+		in, out := &in.Time, &out.Time
+		*out = (*in).DeepCopy()
+	}
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.FinishTime != nil {
+		in, out := &in.FinishTime, &out.FinishTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Checks != nil {
+		in, out := &in.Checks, &out.Checks
+		*out = make([]BackupRestorePreviewCheck, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupRestorePreviewStatus.
+func (in *BackupRestorePreviewStatus) DeepCopy() *BackupRestorePreviewStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupRestorePreviewStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupRetention) DeepCopyInto(out *BackupRetention) {
+	*out = *in
+	out.GracePeriod = in.GracePeriod
+	out.ThrottleBackoff = in.ThrottleBackoff
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupRetention.
+func (in *BackupRetention) DeepCopy() *BackupRetention {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupRetention)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupRetentionPass) DeepCopyInto(out *BackupRetentionPass) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupRetentionPass.
+func (in *BackupRetentionPass) DeepCopy() *BackupRetentionPass {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupRetentionPass)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupRetentionUsage) DeepCopyInto(out *BackupRetentionUsage) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupRetentionUsage.
+func (in *BackupRetentionUsage) DeepCopy() *BackupRetentionUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupRetentionUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupSelfTestTimings) DeepCopyInto(out *BackupSelfTestTimings) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupSelfTestTimings.
+func (in *BackupSelfTestTimings) DeepCopy() *BackupSelfTestTimings {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupSelfTestTimings)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackupStorageProvider) DeepCopyInto(out *BackupStorageProvider) {
 	*out = *in
+	if in.RetentionCredential != nil {
+		in, out := &in.RetentionCredential, &out.RetentionCredential
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupStorageProvider.
+func (in *BackupStorageProvider) DeepCopy() *BackupStorageProvider {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupStorageProvider)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupThrottle) DeepCopyInto(out *BackupThrottle) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupThrottle.
+func (in *BackupThrottle) DeepCopy() *BackupThrottle {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupThrottle)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupTopology) DeepCopyInto(out *BackupTopology) {
+	*out = *in
+	if in.NodeSets != nil {
+		in, out := &in.NodeSets, &out.NodeSets
+		*out = make([]BackupTopologyNodeSet, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupTopology.
+func (in *BackupTopology) DeepCopy() *BackupTopology {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupTopology)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupTopologyNodeSet) DeepCopyInto(out *BackupTopologyNodeSet) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupTopologyNodeSet.
+func (in *BackupTopologyNodeSet) DeepCopy() *BackupTopologyNodeSet {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupTopologyNodeSet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupTrace) DeepCopyInto(out *BackupTrace) {
+	*out = *in
+	if in.PhaseStartTime != nil {
+		in, out := &in.PhaseStartTime, &out.PhaseStartTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupTrace.
+func (in *BackupTrace) DeepCopy() *BackupTrace {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupTrace)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupUploadRetry) DeepCopyInto(out *BackupUploadRetry) {
+	*out = *in
+	out.Backoff = in.Backoff
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupUploadRetry.
+func (in *BackupUploadRetry) DeepCopy() *BackupUploadRetry {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupUploadRetry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupUploadRetryStats) DeepCopyInto(out *BackupUploadRetryStats) {
+	*out = *in
+	if in.Objects != nil {
+		in, out := &in.Objects, &out.Objects
+		*out = make([]ObjectUploadRetries, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupUploadRetryStats.
+func (in *BackupUploadRetryStats) DeepCopy() *BackupUploadRetryStats {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupUploadRetryStats)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupVerification) DeepCopyInto(out *BackupVerification) {
+	*out = *in
+	if in.Checks != nil {
+		in, out := &in.Checks, &out.Checks
+		*out = make([]XStoreRestoreExec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	out.Timeout = in.Timeout
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupVerification.
+func (in *BackupVerification) DeepCopy() *BackupVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupVerificationStatus) DeepCopyInto(out *BackupVerificationStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.FinishTime != nil {
+		in, out := &in.FinishTime, &out.FinishTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Checks != nil {
+		in, out := &in.Checks, &out.Checks
+		*out = make([]xstore.PostRestoreExecStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupVerificationStatus.
+func (in *BackupVerificationStatus) DeepCopy() *BackupVerificationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupVerificationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupVolumeSnapshotStatus) DeepCopyInto(out *BackupVolumeSnapshotStatus) {
+	*out = *in
+	if in.CreationTime != nil {
+		in, out := &in.CreationTime, &out.CreationTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupVolumeSnapshotStatus.
+func (in *BackupVolumeSnapshotStatus) DeepCopy() *BackupVolumeSnapshotStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupVolumeSnapshotStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BinlogFileIndex) DeepCopyInto(out *BinlogFileIndex) {
+	*out = *in
+	if in.FirstEventTime != nil {
+		in, out := &in.FirstEventTime, &out.FirstEventTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastEventTime != nil {
+		in, out := &in.LastEventTime, &out.LastEventTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BinlogFileIndex.
+func (in *BinlogFileIndex) DeepCopy() *BinlogFileIndex {
+	if in == nil {
+		return nil
+	}
+	out := new(BinlogFileIndex)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlowFlagType) DeepCopyInto(out *FlowFlagType) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlowFlagType.
+func (in *FlowFlagType) DeepCopy() *FlowFlagType {
+	if in == nil {
+		return nil
+	}
+	out := new(FlowFlagType)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FollowerRecoverBackup) DeepCopyInto(out *FollowerRecoverBackup) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FollowerRecoverBackup.
+func (in *FollowerRecoverBackup) DeepCopy() *FollowerRecoverBackup {
+	if in == nil {
+		return nil
+	}
+	out := new(FollowerRecoverBackup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IncompleteUpload) DeepCopyInto(out *IncompleteUpload) {
+	*out = *in
+	in.Initiated.DeepCopyInto(&out.Initiated)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IncompleteUpload.
+func (in *IncompleteUpload) DeepCopy() *IncompleteUpload {
+	if in == nil {
+		return nil
+	}
+	out := new(IncompleteUpload)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IncrementalBackupStatus) DeepCopyInto(out *IncrementalBackupStatus) {
+	*out = *in
+	if in.Chain != nil {
+		in, out := &in.Chain, &out.Chain
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IncrementalBackupStatus.
+func (in *IncrementalBackupStatus) DeepCopy() *IncrementalBackupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(IncrementalBackupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogCollectorConfigStatus) DeepCopyInto(out *LogCollectorConfigStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogCollectorConfigStatus.
+func (in *LogCollectorConfigStatus) DeepCopy() *LogCollectorConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LogCollectorConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogCollectorSpec) DeepCopyInto(out *LogCollectorSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogCollectorSpec.
+func (in *LogCollectorSpec) DeepCopy() *LogCollectorSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LogCollectorSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogCollectorStatus) DeepCopyInto(out *LogCollectorStatus) {
+	*out = *in
+	if in.ConfigStatus != nil {
+		in, out := &in.ConfigStatus, &out.ConfigStatus
+		*out = new(LogCollectorConfigStatus)
+		**out = **in
+	}
+	if in.SpecSnapshot != nil {
+		in, out := &in.SpecSnapshot, &out.SpecSnapshot
+		*out = new(LogCollectorSpec)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogCollectorStatus.
+func (in *LogCollectorStatus) DeepCopy() *LogCollectorStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LogCollectorStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectConsistencyWait) DeepCopyInto(out *ObjectConsistencyWait) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectConsistencyWait.
+func (in *ObjectConsistencyWait) DeepCopy() *ObjectConsistencyWait {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectConsistencyWait)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectUploadRetries) DeepCopyInto(out *ObjectUploadRetries) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectUploadRetries.
+func (in *ObjectUploadRetries) DeepCopy() *ObjectUploadRetries {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectUploadRetries)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ParamNode) DeepCopyInto(out *ParamNode) {
+	*out = *in
+	if in.ParamList != nil {
+		in, out := &in.ParamList, &out.ParamList
+		*out = make([]Params, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ParamNode.
+func (in *ParamNode) DeepCopy() *ParamNode {
+	if in == nil {
+		return nil
+	}
+	out := new(ParamNode)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ParamNodeType) DeepCopyInto(out *ParamNodeType) {
+	*out = *in
+	in.CN.DeepCopyInto(&out.CN)
+	in.DN.DeepCopyInto(&out.DN)
+	if in.GMS != nil {
+		in, out := &in.GMS, &out.GMS
+		*out = new(ParamNode)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ParamNodeType.
+func (in *ParamNodeType) DeepCopy() *ParamNodeType {
+	if in == nil {
+		return nil
+	}
+	out := new(ParamNodeType)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ParameterDrift) DeepCopyInto(out *ParameterDrift) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ParameterDrift.
+func (in *ParameterDrift) DeepCopy() *ParameterDrift {
+	if in == nil {
+		return nil
+	}
+	out := new(ParameterDrift)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ParameterPendingRestart) DeepCopyInto(out *ParameterPendingRestart) {
+	*out = *in
+	in.Since.DeepCopyInto(&out.Since)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ParameterPendingRestart.
+func (in *ParameterPendingRestart) DeepCopy() *ParameterPendingRestart {
+	if in == nil {
+		return nil
+	}
+	out := new(ParameterPendingRestart)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Params) DeepCopyInto(out *Params) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Params.
+func (in *Params) DeepCopy() *Params {
+	if in == nil {
+		return nil
+	}
+	out := new(Params)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolarDBXAutoscaler) DeepCopyInto(out *PolarDBXAutoscaler) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXAutoscaler.
+func (in *PolarDBXAutoscaler) DeepCopy() *PolarDBXAutoscaler {
+	if in == nil {
+		return nil
+	}
+	out := new(PolarDBXAutoscaler)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PolarDBXAutoscaler) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolarDBXAutoscalerList) DeepCopyInto(out *PolarDBXAutoscalerList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PolarDBXAutoscaler, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXAutoscalerList.
+func (in *PolarDBXAutoscalerList) DeepCopy() *PolarDBXAutoscalerList {
+	if in == nil {
+		return nil
+	}
+	out := new(PolarDBXAutoscalerList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PolarDBXAutoscalerList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolarDBXAutoscalerSpec) DeepCopyInto(out *PolarDBXAutoscalerSpec) {
+	*out = *in
+	out.Cluster = in.Cluster
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		*out = make([]AutoscalerMetric, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	out.Interval = in.Interval
+	out.ScaleDownStabilizationWindow = in.ScaleDownStabilizationWindow
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXAutoscalerSpec.
+func (in *PolarDBXAutoscalerSpec) DeepCopy() *PolarDBXAutoscalerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PolarDBXAutoscalerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolarDBXAutoscalerStatus) DeepCopyInto(out *PolarDBXAutoscalerStatus) {
+	*out = *in
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		*out = make([]AutoscalerMetricStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Recommendations != nil {
+		in, out := &in.Recommendations, &out.Recommendations
+		*out = make([]AutoscalerRecommendation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastEvaluateTime != nil {
+		in, out := &in.LastEvaluateTime, &out.LastEvaluateTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastScaleTime != nil {
+		in, out := &in.LastScaleTime, &out.LastScaleTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXAutoscalerStatus.
+func (in *PolarDBXAutoscalerStatus) DeepCopy() *PolarDBXAutoscalerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PolarDBXAutoscalerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolarDBXBackup) DeepCopyInto(out *PolarDBXBackup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXBackup.
+func (in *PolarDBXBackup) DeepCopy() *PolarDBXBackup {
+	if in == nil {
+		return nil
+	}
+	out := new(PolarDBXBackup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PolarDBXBackup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolarDBXBackupList) DeepCopyInto(out *PolarDBXBackupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PolarDBXBackup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXBackupList.
+func (in *PolarDBXBackupList) DeepCopy() *PolarDBXBackupList {
+	if in == nil {
+		return nil
+	}
+	out := new(PolarDBXBackupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PolarDBXBackupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolarDBXBackupReap) DeepCopyInto(out *PolarDBXBackupReap) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXBackupReap.
+func (in *PolarDBXBackupReap) DeepCopy() *PolarDBXBackupReap {
+	if in == nil {
+		return nil
+	}
+	out := new(PolarDBXBackupReap)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PolarDBXBackupReap) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolarDBXBackupReapList) DeepCopyInto(out *PolarDBXBackupReapList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PolarDBXBackupReap, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXBackupReapList.
+func (in *PolarDBXBackupReapList) DeepCopy() *PolarDBXBackupReapList {
+	if in == nil {
+		return nil
+	}
+	out := new(PolarDBXBackupReapList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PolarDBXBackupReapList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolarDBXBackupReapSpec) DeepCopyInto(out *PolarDBXBackupReapSpec) {
+	*out = *in
+	out.Cluster = in.Cluster
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXBackupReapSpec.
+func (in *PolarDBXBackupReapSpec) DeepCopy() *PolarDBXBackupReapSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PolarDBXBackupReapSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolarDBXBackupReapStatus) DeepCopyInto(out *PolarDBXBackupReapStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.EndTime != nil {
+		in, out := &in.EndTime, &out.EndTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Deleted != nil {
+		in, out := &in.Deleted, &out.Deleted
+		*out = make([]ReapedBackup, len(*in))
+		copy(*out, *in)
+	}
+	if in.Skipped != nil {
+		in, out := &in.Skipped, &out.Skipped
+		*out = make([]ReapedBackup, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXBackupReapStatus.
+func (in *PolarDBXBackupReapStatus) DeepCopy() *PolarDBXBackupReapStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PolarDBXBackupReapStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolarDBXBackupSchedule) DeepCopyInto(out *PolarDBXBackupSchedule) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXBackupSchedule.
+func (in *PolarDBXBackupSchedule) DeepCopy() *PolarDBXBackupSchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(PolarDBXBackupSchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PolarDBXBackupSchedule) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolarDBXBackupScheduleList) DeepCopyInto(out *PolarDBXBackupScheduleList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PolarDBXBackupSchedule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupStorageProvider.
-func (in *BackupStorageProvider) DeepCopy() *BackupStorageProvider {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXBackupScheduleList.
+func (in *PolarDBXBackupScheduleList) DeepCopy() *PolarDBXBackupScheduleList {
 	if in == nil {
 		return nil
 	}
-	out := new(BackupStorageProvider)
+	out := new(PolarDBXBackupScheduleList)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PolarDBXBackupScheduleList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *FlowFlagType) DeepCopyInto(out *FlowFlagType) {
+func (in *PolarDBXBackupScheduleSpec) DeepCopyInto(out *PolarDBXBackupScheduleSpec) {
 	*out = *in
+	out.StartingDeadline = in.StartingDeadline
+	in.BackupSpec.DeepCopyInto(&out.BackupSpec)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlowFlagType.
-func (in *FlowFlagType) DeepCopy() *FlowFlagType {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXBackupScheduleSpec.
+func (in *PolarDBXBackupScheduleSpec) DeepCopy() *PolarDBXBackupScheduleSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(FlowFlagType)
+	out := new(PolarDBXBackupScheduleSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *LogCollectorConfigStatus) DeepCopyInto(out *LogCollectorConfigStatus) {
+func (in *PolarDBXBackupScheduleStatus) DeepCopyInto(out *PolarDBXBackupScheduleStatus) {
 	*out = *in
+	if in.LastScheduleTime != nil {
+		in, out := &in.LastScheduleTime, &out.LastScheduleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NextScheduleTime != nil {
+		in, out := &in.NextScheduleTime, &out.NextScheduleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Active != nil {
+		in, out := &in.Active, &out.Active
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastGarbageCollectTime != nil {
+		in, out := &in.LastGarbageCollectTime, &out.LastGarbageCollectTime
+		*out = (*in).DeepCopy()
+	}
+	if in.GarbageCollected != nil {
+		in, out := &in.GarbageCollected, &out.GarbageCollected
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogCollectorConfigStatus.
-func (in *LogCollectorConfigStatus) DeepCopy() *LogCollectorConfigStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXBackupScheduleStatus.
+func (in *PolarDBXBackupScheduleStatus) DeepCopy() *PolarDBXBackupScheduleStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(LogCollectorConfigStatus)
+	out := new(PolarDBXBackupScheduleStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *LogCollectorSpec) DeepCopyInto(out *LogCollectorSpec) {
+func (in *PolarDBXBackupSelfTest) DeepCopyInto(out *PolarDBXBackupSelfTest) {
 	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogCollectorSpec.
-func (in *LogCollectorSpec) DeepCopy() *LogCollectorSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXBackupSelfTest.
+func (in *PolarDBXBackupSelfTest) DeepCopy() *PolarDBXBackupSelfTest {
 	if in == nil {
 		return nil
 	}
-	out := new(LogCollectorSpec)
+	out := new(PolarDBXBackupSelfTest)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PolarDBXBackupSelfTest) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *LogCollectorStatus) DeepCopyInto(out *LogCollectorStatus) {
+func (in *PolarDBXBackupSelfTestList) DeepCopyInto(out *PolarDBXBackupSelfTestList) {
 	*out = *in
-	if in.ConfigStatus != nil {
-		in, out := &in.ConfigStatus, &out.ConfigStatus
-		*out = new(LogCollectorConfigStatus)
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PolarDBXBackupSelfTest, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXBackupSelfTestList.
+func (in *PolarDBXBackupSelfTestList) DeepCopy() *PolarDBXBackupSelfTestList {
+	if in == nil {
+		return nil
+	}
+	out := new(PolarDBXBackupSelfTestList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PolarDBXBackupSelfTestList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolarDBXBackupSelfTestSpec) DeepCopyInto(out *PolarDBXBackupSelfTestSpec) {
+	*out = *in
+	out.Cluster = in.Cluster
+	in.StorageProvider.DeepCopyInto(&out.StorageProvider)
+	out.BackupRetentionTime = in.BackupRetentionTime
+	out.Timeout = in.Timeout
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXBackupSelfTestSpec.
+func (in *PolarDBXBackupSelfTestSpec) DeepCopy() *PolarDBXBackupSelfTestSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PolarDBXBackupSelfTestSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolarDBXBackupSelfTestStatus) DeepCopyInto(out *PolarDBXBackupSelfTestStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.PhaseStartTime != nil {
+		in, out := &in.PhaseStartTime, &out.PhaseStartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.EndTime != nil {
+		in, out := &in.EndTime, &out.EndTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Schemas != nil {
+		in, out := &in.Schemas, &out.Schemas
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.Timings = in.Timings
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXBackupSelfTestStatus.
+func (in *PolarDBXBackupSelfTestStatus) DeepCopy() *PolarDBXBackupSelfTestStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PolarDBXBackupSelfTestStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolarDBXBackupSpec) DeepCopyInto(out *PolarDBXBackupSpec) {
+	*out = *in
+	out.Cluster = in.Cluster
+	if in.XStores != nil {
+		in, out := &in.XStores, &out.XStores
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.RetentionTime = in.RetentionTime
+	out.Retention = in.Retention
+	in.StorageProvider.DeepCopyInto(&out.StorageProvider)
+	out.MaxFollowerLag = in.MaxFollowerLag
+	if in.UploadRetry != nil {
+		in, out := &in.UploadRetry, &out.UploadRetry
+		*out = new(BackupUploadRetry)
 		**out = **in
 	}
-	if in.SpecSnapshot != nil {
-		in, out := &in.SpecSnapshot, &out.SpecSnapshot
-		*out = new(LogCollectorSpec)
+	if in.ConsistencyWait != nil {
+		in, out := &in.ConsistencyWait, &out.ConsistencyWait
+		*out = new(BackupConsistencyWait)
+		**out = **in
+	}
+	if in.Catalog != nil {
+		in, out := &in.Catalog, &out.Catalog
+		*out = new(BackupCatalog)
+		**out = **in
+	}
+	if in.CDCConsistency != nil {
+		in, out := &in.CDCConsistency, &out.CDCConsistency
+		*out = new(BackupCDCConsistency)
+		**out = **in
+	}
+	if in.ImmutableUntil != nil {
+		in, out := &in.ImmutableUntil, &out.ImmutableUntil
+		*out = (*in).DeepCopy()
+	}
+	out.FailedArtifactRetention = in.FailedArtifactRetention
+	if in.Share != nil {
+		in, out := &in.Share, &out.Share
+		*out = new(BackupObjectShare)
+		**out = **in
+	}
+	if in.CopyFrom != nil {
+		in, out := &in.CopyFrom, &out.CopyFrom
+		*out = new(BackupCopySource)
+		**out = **in
+	}
+	if in.RestorePreview != nil {
+		in, out := &in.RestorePreview, &out.RestorePreview
+		*out = new(BackupRestorePreview)
+		**out = **in
+	}
+	if in.Encryption != nil {
+		in, out := &in.Encryption, &out.Encryption
+		*out = new(BackupEncryption)
+		**out = **in
+	}
+	if in.Verification != nil {
+		in, out := &in.Verification, &out.Verification
+		*out = new(BackupVerification)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Throttle != nil {
+		in, out := &in.Throttle, &out.Throttle
+		*out = new(BackupThrottle)
 		**out = **in
 	}
+	if in.ThrottleOverrides != nil {
+		in, out := &in.ThrottleOverrides, &out.ThrottleOverrides
+		*out = make(map[string]BackupThrottle, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Hooks != nil {
+		in, out := &in.Hooks, &out.Hooks
+		*out = new(BackupHooks)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.JobTemplate != nil {
+		in, out := &in.JobTemplate, &out.JobTemplate
+		*out = new(BackupJobTemplate)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Notifications != nil {
+		in, out := &in.Notifications, &out.Notifications
+		*out = make([]BackupNotification, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXBackupSpec.
+func (in *PolarDBXBackupSpec) DeepCopy() *PolarDBXBackupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PolarDBXBackupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolarDBXBackupStatus) DeepCopyInto(out *PolarDBXBackupStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.EndTime != nil {
+		in, out := &in.EndTime, &out.EndTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Backups != nil {
+		in, out := &in.Backups, &out.Backups
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.XStores != nil {
+		in, out := &in.XStores, &out.XStores
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.QueuedXStores != nil {
+		in, out := &in.QueuedXStores, &out.QueuedXStores
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ClusterSpecSnapshot != nil {
+		in, out := &in.ClusterSpecSnapshot, &out.ClusterSpecSnapshot
+		*out = new(PolarDBXClusterSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CDCCheckpoint != nil {
+		in, out := &in.CDCCheckpoint, &out.CDCCheckpoint
+		*out = new(BackupCDCCheckpoint)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CheckpointOrdering != nil {
+		in, out := &in.CheckpointOrdering, &out.CheckpointOrdering
+		*out = new(BackupCheckpointOrderingStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BackupSetTimestamp != nil {
+		in, out := &in.BackupSetTimestamp, &out.BackupSetTimestamp
+		*out = make(map[string]*metav1.Time, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.LatestRecoverableTimestamp != nil {
+		in, out := &in.LatestRecoverableTimestamp, &out.LatestRecoverableTimestamp
+		*out = (*in).DeepCopy()
+	}
+	if in.EarliestRecoverableTimestamp != nil {
+		in, out := &in.EarliestRecoverableTimestamp, &out.EarliestRecoverableTimestamp
+		*out = (*in).DeepCopy()
+	}
+	if in.Share != nil {
+		in, out := &in.Share, &out.Share
+		*out = new(BackupObjectShareStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RestorePreview != nil {
+		in, out := &in.RestorePreview, &out.RestorePreview
+		*out = new(BackupRestorePreviewStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CircuitBreaker != nil {
+		in, out := &in.CircuitBreaker, &out.CircuitBreaker
+		*out = new(BackupCircuitBreakerStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Trace != nil {
+		in, out := &in.Trace, &out.Trace
+		*out = new(BackupTrace)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ImmutableUntil != nil {
+		in, out := &in.ImmutableUntil, &out.ImmutableUntil
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]xstore.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Progress != nil {
+		in, out := &in.Progress, &out.Progress
+		*out = new(common.OperationProgress)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXBackupStatus.
+func (in *PolarDBXBackupStatus) DeepCopy() *PolarDBXBackupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PolarDBXBackupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolarDBXBinlogBackup) DeepCopyInto(out *PolarDBXBinlogBackup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogCollectorStatus.
-func (in *LogCollectorStatus) DeepCopy() *LogCollectorStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXBinlogBackup.
+func (in *PolarDBXBinlogBackup) DeepCopy() *PolarDBXBinlogBackup {
 	if in == nil {
 		return nil
 	}
-	out := new(LogCollectorStatus)
+	out := new(PolarDBXBinlogBackup)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PolarDBXBinlogBackup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ParamNode) DeepCopyInto(out *ParamNode) {
+func (in *PolarDBXBinlogBackupList) DeepCopyInto(out *PolarDBXBinlogBackupList) {
 	*out = *in
-	if in.ParamList != nil {
-		in, out := &in.ParamList, &out.ParamList
-		*out = make([]Params, len(*in))
-		copy(*out, *in)
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PolarDBXBinlogBackup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ParamNode.
-func (in *ParamNode) DeepCopy() *ParamNode {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXBinlogBackupList.
+func (in *PolarDBXBinlogBackupList) DeepCopy() *PolarDBXBinlogBackupList {
 	if in == nil {
 		return nil
 	}
-	out := new(ParamNode)
+	out := new(PolarDBXBinlogBackupList)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PolarDBXBinlogBackupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ParamNodeType) DeepCopyInto(out *ParamNodeType) {
+func (in *PolarDBXBinlogBackupSpec) DeepCopyInto(out *PolarDBXBinlogBackupSpec) {
 	*out = *in
-	in.CN.DeepCopyInto(&out.CN)
-	in.DN.DeepCopyInto(&out.DN)
-	if in.GMS != nil {
-		in, out := &in.GMS, &out.GMS
-		*out = new(ParamNode)
-		(*in).DeepCopyInto(*out)
-	}
+	out.Cluster = in.Cluster
+	in.StorageProvider.DeepCopyInto(&out.StorageProvider)
+	out.Interval = in.Interval
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ParamNodeType.
-func (in *ParamNodeType) DeepCopy() *ParamNodeType {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXBinlogBackupSpec.
+func (in *PolarDBXBinlogBackupSpec) DeepCopy() *PolarDBXBinlogBackupSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(ParamNodeType)
+	out := new(PolarDBXBinlogBackupSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Params) DeepCopyInto(out *Params) {
+func (in *PolarDBXBinlogBackupStatus) DeepCopyInto(out *PolarDBXBinlogBackupStatus) {
 	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.XStores != nil {
+		in, out := &in.XStores, &out.XStores
+		*out = make([]XStoreBinlogBackupStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LatestEventTime != nil {
+		in, out := &in.LatestEventTime, &out.LatestEventTime
+		*out = (*in).DeepCopy()
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Params.
-func (in *Params) DeepCopy() *Params {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXBinlogBackupStatus.
+func (in *PolarDBXBinlogBackupStatus) DeepCopy() *PolarDBXBinlogBackupStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(Params)
+	out := new(PolarDBXBinlogBackupStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PolarDBXBackup) DeepCopyInto(out *PolarDBXBackup) {
+func (in *PolarDBXClone) DeepCopyInto(out *PolarDBXClone) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXBackup.
-func (in *PolarDBXBackup) DeepCopy() *PolarDBXBackup {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXClone.
+func (in *PolarDBXClone) DeepCopy() *PolarDBXClone {
 	if in == nil {
 		return nil
 	}
-	out := new(PolarDBXBackup)
+	out := new(PolarDBXClone)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *PolarDBXBackup) DeepCopyObject() runtime.Object {
+func (in *PolarDBXClone) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -199,31 +1969,31 @@ func (in *PolarDBXBackup) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PolarDBXBackupList) DeepCopyInto(out *PolarDBXBackupList) {
+func (in *PolarDBXCloneList) DeepCopyInto(out *PolarDBXCloneList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]PolarDBXBackup, len(*in))
+		*out = make([]PolarDBXClone, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXBackupList.
-func (in *PolarDBXBackupList) DeepCopy() *PolarDBXBackupList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXCloneList.
+func (in *PolarDBXCloneList) DeepCopy() *PolarDBXCloneList {
 	if in == nil {
 		return nil
 	}
-	out := new(PolarDBXBackupList)
+	out := new(PolarDBXCloneList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *PolarDBXBackupList) DeepCopyObject() runtime.Object {
+func (in *PolarDBXCloneList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -231,25 +2001,26 @@ func (in *PolarDBXBackupList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PolarDBXBackupSpec) DeepCopyInto(out *PolarDBXBackupSpec) {
+func (in *PolarDBXCloneSpec) DeepCopyInto(out *PolarDBXCloneSpec) {
 	*out = *in
-	out.Cluster = in.Cluster
-	out.RetentionTime = in.RetentionTime
-	out.StorageProvider = in.StorageProvider
+	out.Source = in.Source
+	in.StorageProvider.DeepCopyInto(&out.StorageProvider)
+	out.BackupRetentionTime = in.BackupRetentionTime
+	out.Timeout = in.Timeout
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXBackupSpec.
-func (in *PolarDBXBackupSpec) DeepCopy() *PolarDBXBackupSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXCloneSpec.
+func (in *PolarDBXCloneSpec) DeepCopy() *PolarDBXCloneSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(PolarDBXBackupSpec)
+	out := new(PolarDBXCloneSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PolarDBXBackupStatus) DeepCopyInto(out *PolarDBXBackupStatus) {
+func (in *PolarDBXCloneStatus) DeepCopyInto(out *PolarDBXCloneStatus) {
 	*out = *in
 	if in.StartTime != nil {
 		in, out := &in.StartTime, &out.StartTime
@@ -259,42 +2030,19 @@ func (in *PolarDBXBackupStatus) DeepCopyInto(out *PolarDBXBackupStatus) {
 		in, out := &in.EndTime, &out.EndTime
 		*out = (*in).DeepCopy()
 	}
-	if in.Backups != nil {
-		in, out := &in.Backups, &out.Backups
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	if in.XStores != nil {
-		in, out := &in.XStores, &out.XStores
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
-	if in.ClusterSpecSnapshot != nil {
-		in, out := &in.ClusterSpecSnapshot, &out.ClusterSpecSnapshot
-		*out = new(PolarDBXClusterSpec)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.BackupSetTimestamp != nil {
-		in, out := &in.BackupSetTimestamp, &out.BackupSetTimestamp
-		*out = make(map[string]*metav1.Time, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val.DeepCopy()
-		}
-	}
-	if in.LatestRecoverableTimestamp != nil {
-		in, out := &in.LatestRecoverableTimestamp, &out.LatestRecoverableTimestamp
-		*out = (*in).DeepCopy()
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXBackupStatus.
-func (in *PolarDBXBackupStatus) DeepCopy() *PolarDBXBackupStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXCloneStatus.
+func (in *PolarDBXCloneStatus) DeepCopy() *PolarDBXCloneStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(PolarDBXBackupStatus)
+	out := new(PolarDBXCloneStatus)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -474,6 +2222,13 @@ func (in *PolarDBXClusterReference) DeepCopy() *PolarDBXClusterReference {
 func (in *PolarDBXClusterSpec) DeepCopyInto(out *PolarDBXClusterSpec) {
 	*out = *in
 	out.ProtocolVersion = in.ProtocolVersion
+	if in.ServiceTemplates != nil {
+		in, out := &in.ServiceTemplates, &out.ServiceTemplates
+		*out = make([]polardbx.ServiceTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	in.Topology.DeepCopyInto(&out.Topology)
 	in.Config.DeepCopyInto(&out.Config)
 	if in.Privileges != nil {
@@ -486,6 +2241,16 @@ func (in *PolarDBXClusterSpec) DeepCopyInto(out *PolarDBXClusterSpec) {
 		*out = new(polardbx.Security)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(polardbx.CanaryUpgrade)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Rebalance != nil {
+		in, out := &in.Rebalance, &out.Rebalance
+		*out = new(polardbx.RebalanceSpec)
+		**out = **in
+	}
 	if in.Restore != nil {
 		in, out := &in.Restore, &out.Restore
 		*out = new(polardbx.RestoreSpec)
@@ -503,6 +2268,11 @@ func (in *PolarDBXClusterSpec) DeepCopyInto(out *PolarDBXClusterSpec) {
 			}
 		}
 	}
+	if in.LogCollector != nil {
+		in, out := &in.LogCollector, &out.LogCollector
+		*out = new(polardbx.LogCollector)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXClusterSpec.
@@ -533,6 +2303,36 @@ func (in *PolarDBXClusterStatus) DeepCopyInto(out *PolarDBXClusterStatus) {
 		(*in).DeepCopyInto(*out)
 	}
 	in.RestartingPods.DeepCopyInto(&out.RestartingPods)
+	if in.RestoreStatus != nil {
+		in, out := &in.RestoreStatus, &out.RestoreStatus
+		*out = new(polardbx.RestoreStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReadonlyReplication != nil {
+		in, out := &in.ReadonlyReplication, &out.ReadonlyReplication
+		*out = new(polardbx.ReadonlyReplicationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Rebalance != nil {
+		in, out := &in.Rebalance, &out.Rebalance
+		*out = new(polardbx.RebalanceStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(polardbx.CanaryStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(polardbx.TLSStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Progress != nil {
+		in, out := &in.Progress, &out.Progress
+		*out = new(common.OperationProgress)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXClusterStatus.
@@ -788,6 +2588,27 @@ func (in *PolarDBXParameterStatus) DeepCopyInto(out *PolarDBXParameterStatus) {
 		*out = new(PolarDBXParameterSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.RollbackParameterSpecSnapshot != nil {
+		in, out := &in.RollbackParameterSpecSnapshot, &out.RollbackParameterSpecSnapshot
+		*out = new(PolarDBXParameterSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PendingRestart != nil {
+		in, out := &in.PendingRestart, &out.PendingRestart
+		*out = make([]ParameterPendingRestart, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Drifts != nil {
+		in, out := &in.Drifts, &out.Drifts
+		*out = make([]ParameterDrift, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastDriftCheckTime != nil {
+		in, out := &in.LastDriftCheckTime, &out.LastDriftCheckTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXParameterStatus.
@@ -876,16 +2697,174 @@ func (in *PolarDBXParameterTemplateSpec) DeepCopy() *PolarDBXParameterTemplateSp
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PolarDBXParameterTemplateStatus) DeepCopyInto(out *PolarDBXParameterTemplateStatus) {
+func (in *PolarDBXParameterTemplateStatus) DeepCopyInto(out *PolarDBXParameterTemplateStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXParameterTemplateStatus.
+func (in *PolarDBXParameterTemplateStatus) DeepCopy() *PolarDBXParameterTemplateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PolarDBXParameterTemplateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolarDBXStandby) DeepCopyInto(out *PolarDBXStandby) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXStandby.
+func (in *PolarDBXStandby) DeepCopy() *PolarDBXStandby {
+	if in == nil {
+		return nil
+	}
+	out := new(PolarDBXStandby)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PolarDBXStandby) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolarDBXStandbyList) DeepCopyInto(out *PolarDBXStandbyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PolarDBXStandby, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXStandbyList.
+func (in *PolarDBXStandbyList) DeepCopy() *PolarDBXStandbyList {
+	if in == nil {
+		return nil
+	}
+	out := new(PolarDBXStandbyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PolarDBXStandbyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolarDBXStandbySpec) DeepCopyInto(out *PolarDBXStandbySpec) {
+	*out = *in
+	out.Cluster = in.Cluster
+	in.Source.DeepCopyInto(&out.Source)
+	out.Interval = in.Interval
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXStandbySpec.
+func (in *PolarDBXStandbySpec) DeepCopy() *PolarDBXStandbySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PolarDBXStandbySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolarDBXStandbyStatus) DeepCopyInto(out *PolarDBXStandbyStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.XStores != nil {
+		in, out := &in.XStores, &out.XStores
+		*out = make([]XStoreStandbyStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LatestAppliedEventTime != nil {
+		in, out := &in.LatestAppliedEventTime, &out.LatestAppliedEventTime
+		*out = (*in).DeepCopy()
+	}
+	if in.PrimaryFenceTime != nil {
+		in, out := &in.PrimaryFenceTime, &out.PrimaryFenceTime
+		*out = (*in).DeepCopy()
+	}
+	if in.PromoteTime != nil {
+		in, out := &in.PromoteTime, &out.PromoteTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXStandbyStatus.
+func (in *PolarDBXStandbyStatus) DeepCopy() *PolarDBXStandbyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PolarDBXStandbyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReapedBackup) DeepCopyInto(out *ReapedBackup) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReapedBackup.
+func (in *ReapedBackup) DeepCopy() *ReapedBackup {
+	if in == nil {
+		return nil
+	}
+	out := new(ReapedBackup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StandbySource) DeepCopyInto(out *StandbySource) {
 	*out = *in
+	in.StorageProvider.DeepCopyInto(&out.StorageProvider)
+	if in.XStores != nil {
+		in, out := &in.XStores, &out.XStores
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Cluster != nil {
+		in, out := &in.Cluster, &out.Cluster
+		*out = new(PolarDBXClusterReference)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolarDBXParameterTemplateStatus.
-func (in *PolarDBXParameterTemplateStatus) DeepCopy() *PolarDBXParameterTemplateStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StandbySource.
+func (in *StandbySource) DeepCopy() *StandbySource {
 	if in == nil {
 		return nil
 	}
-	out := new(PolarDBXParameterTemplateStatus)
+	out := new(StandbySource)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -975,7 +2954,7 @@ func (in *XStoreBackup) DeepCopyInto(out *XStoreBackup) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -1034,7 +3013,64 @@ func (in *XStoreBackupSpec) DeepCopyInto(out *XStoreBackupSpec) {
 	*out = *in
 	out.XStore = in.XStore
 	out.RetentionTime = in.RetentionTime
-	out.StorageProvider = in.StorageProvider
+	out.Retention = in.Retention
+	in.StorageProvider.DeepCopyInto(&out.StorageProvider)
+	out.MaxFollowerLag = in.MaxFollowerLag
+	out.FailedArtifactRetention = in.FailedArtifactRetention
+	if in.UploadRetry != nil {
+		in, out := &in.UploadRetry, &out.UploadRetry
+		*out = new(BackupUploadRetry)
+		**out = **in
+	}
+	if in.ConsistencyWait != nil {
+		in, out := &in.ConsistencyWait, &out.ConsistencyWait
+		*out = new(BackupConsistencyWait)
+		**out = **in
+	}
+	if in.ImmutableUntil != nil {
+		in, out := &in.ImmutableUntil, &out.ImmutableUntil
+		*out = (*in).DeepCopy()
+	}
+	if in.CopyFrom != nil {
+		in, out := &in.CopyFrom, &out.CopyFrom
+		*out = new(BackupCopySource)
+		**out = **in
+	}
+	if in.Notifications != nil {
+		in, out := &in.Notifications, &out.Notifications
+		*out = make([]BackupNotification, len(*in))
+		copy(*out, *in)
+	}
+	if in.Catalog != nil {
+		in, out := &in.Catalog, &out.Catalog
+		*out = new(BackupCatalog)
+		**out = **in
+	}
+	if in.Encryption != nil {
+		in, out := &in.Encryption, &out.Encryption
+		*out = new(BackupEncryption)
+		**out = **in
+	}
+	if in.Verification != nil {
+		in, out := &in.Verification, &out.Verification
+		*out = new(BackupVerification)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Throttle != nil {
+		in, out := &in.Throttle, &out.Throttle
+		*out = new(BackupThrottle)
+		**out = **in
+	}
+	if in.Hooks != nil {
+		in, out := &in.Hooks, &out.Hooks
+		*out = new(BackupHooks)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.JobTemplate != nil {
+		in, out := &in.JobTemplate, &out.JobTemplate
+		*out = new(BackupJobTemplate)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new XStoreBackupSpec.
@@ -1062,6 +3098,130 @@ func (in *XStoreBackupStatus) DeepCopyInto(out *XStoreBackupStatus) {
 		in, out := &in.BackupSetTimestamp, &out.BackupSetTimestamp
 		*out = (*in).DeepCopy()
 	}
+	if in.EarliestRecoverableTimestamp != nil {
+		in, out := &in.EarliestRecoverableTimestamp, &out.EarliestRecoverableTimestamp
+		*out = (*in).DeepCopy()
+	}
+	if in.FullBackupProgress != nil {
+		in, out := &in.FullBackupProgress, &out.FullBackupProgress
+		*out = new(BackupProgress)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BinlogBackupProgress != nil {
+		in, out := &in.BinlogBackupProgress, &out.BinlogBackupProgress
+		*out = new(BackupProgress)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Compatibility != nil {
+		in, out := &in.Compatibility, &out.Compatibility
+		*out = new(xstore.EngineCompatibility)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BinlogEventsCount != nil {
+		in, out := &in.BinlogEventsCount, &out.BinlogEventsCount
+		*out = new(int64)
+		**out = **in
+	}
+	if in.RetentionUsage != nil {
+		in, out := &in.RetentionUsage, &out.RetentionUsage
+		*out = new(BackupRetentionUsage)
+		**out = **in
+	}
+	if in.RetentionPass != nil {
+		in, out := &in.RetentionPass, &out.RetentionPass
+		*out = new(BackupRetentionPass)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DedupReport != nil {
+		in, out := &in.DedupReport, &out.DedupReport
+		*out = new(BackupDedupReport)
+		**out = **in
+	}
+	if in.UploadRetries != nil {
+		in, out := &in.UploadRetries, &out.UploadRetries
+		*out = new(BackupUploadRetryStats)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConsistencyWaits != nil {
+		in, out := &in.ConsistencyWaits, &out.ConsistencyWaits
+		*out = new(BackupConsistencyWaitStats)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Topology != nil {
+		in, out := &in.Topology, &out.Topology
+		*out = new(BackupTopology)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Consistency != nil {
+		in, out := &in.Consistency, &out.Consistency
+		*out = new(BackupConsistencyStatus)
+		**out = **in
+	}
+	if in.Incremental != nil {
+		in, out := &in.Incremental, &out.Incremental
+		*out = new(IncrementalBackupStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.VolumeSnapshot != nil {
+		in, out := &in.VolumeSnapshot, &out.VolumeSnapshot
+		*out = new(BackupVolumeSnapshotStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Verification != nil {
+		in, out := &in.Verification, &out.Verification
+		*out = new(BackupVerificationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Hooks != nil {
+		in, out := &in.Hooks, &out.Hooks
+		*out = make([]BackupHookResult, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CircuitBreaker != nil {
+		in, out := &in.CircuitBreaker, &out.CircuitBreaker
+		*out = new(BackupCircuitBreakerStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]xstore.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Notifications != nil {
+		in, out := &in.Notifications, &out.Notifications
+		*out = make([]BackupNotificationStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Catalog != nil {
+		in, out := &in.Catalog, &out.Catalog
+		*out = new(BackupCatalogStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PhaseHistory != nil {
+		in, out := &in.PhaseHistory, &out.PhaseHistory
+		*out = make([]BackupPhaseTransition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.IncompleteUploads != nil {
+		in, out := &in.IncompleteUploads, &out.IncompleteUploads
+		*out = make([]IncompleteUpload, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Trace != nil {
+		in, out := &in.Trace, &out.Trace
+		*out = new(BackupTrace)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new XStoreBackupStatus.
@@ -1074,12 +3234,54 @@ func (in *XStoreBackupStatus) DeepCopy() *XStoreBackupStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *XStoreBinlogBackupStatus) DeepCopyInto(out *XStoreBinlogBackupStatus) {
+	*out = *in
+	if in.LastShipTime != nil {
+		in, out := &in.LastShipTime, &out.LastShipTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Binlogs != nil {
+		in, out := &in.Binlogs, &out.Binlogs
+		*out = make([]BinlogFileIndex, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new XStoreBinlogBackupStatus.
+func (in *XStoreBinlogBackupStatus) DeepCopy() *XStoreBinlogBackupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(XStoreBinlogBackupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *XStoreContinuousRestore) DeepCopyInto(out *XStoreContinuousRestore) {
+	*out = *in
+	out.MaxLag = in.MaxLag
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new XStoreContinuousRestore.
+func (in *XStoreContinuousRestore) DeepCopy() *XStoreContinuousRestore {
+	if in == nil {
+		return nil
+	}
+	out := new(XStoreContinuousRestore)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *XStoreFollower) DeepCopyInto(out *XStoreFollower) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -1136,6 +3338,11 @@ func (in *XStoreFollowerList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *XStoreFollowerSpec) DeepCopyInto(out *XStoreFollowerSpec) {
 	*out = *in
+	if in.BackupMaxAge != nil {
+		in, out := &in.BackupMaxAge, &out.BackupMaxAge
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new XStoreFollowerSpec.
@@ -1161,6 +3368,11 @@ func (in *XStoreFollowerStatus) DeepCopyInto(out *XStoreFollowerStatus) {
 		*out = make([]FlowFlagType, len(*in))
 		copy(*out, *in)
 	}
+	if in.RecoverBackup != nil {
+		in, out := &in.RecoverBackup, &out.RecoverBackup
+		*out = new(FollowerRecoverBackup)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new XStoreFollowerStatus.
@@ -1220,6 +3432,42 @@ func (in *XStoreReference) DeepCopy() *XStoreReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *XStoreRestoreDownload) DeepCopyInto(out *XStoreRestoreDownload) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new XStoreRestoreDownload.
+func (in *XStoreRestoreDownload) DeepCopy() *XStoreRestoreDownload {
+	if in == nil {
+		return nil
+	}
+	out := new(XStoreRestoreDownload)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *XStoreRestoreExec) DeepCopyInto(out *XStoreRestoreExec) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.Timeout = in.Timeout
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new XStoreRestoreExec.
+func (in *XStoreRestoreExec) DeepCopy() *XStoreRestoreExec {
+	if in == nil {
+		return nil
+	}
+	out := new(XStoreRestoreExec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *XStoreRestoreFrom) DeepCopyInto(out *XStoreRestoreFrom) {
 	*out = *in
@@ -1242,10 +3490,68 @@ func (in *XStoreRestoreFrom) DeepCopy() *XStoreRestoreFrom {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *XStoreRestoreMasking) DeepCopyInto(out *XStoreRestoreMasking) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new XStoreRestoreMasking.
+func (in *XStoreRestoreMasking) DeepCopy() *XStoreRestoreMasking {
+	if in == nil {
+		return nil
+	}
+	out := new(XStoreRestoreMasking)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *XStoreRestoreRejoin) DeepCopyInto(out *XStoreRestoreRejoin) {
+	*out = *in
+	out.Timeout = in.Timeout
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new XStoreRestoreRejoin.
+func (in *XStoreRestoreRejoin) DeepCopy() *XStoreRestoreRejoin {
+	if in == nil {
+		return nil
+	}
+	out := new(XStoreRestoreRejoin)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *XStoreRestoreSpec) DeepCopyInto(out *XStoreRestoreSpec) {
 	*out = *in
 	in.From.DeepCopyInto(&out.From)
+	if in.Continuous != nil {
+		in, out := &in.Continuous, &out.Continuous
+		*out = new(XStoreContinuousRestore)
+		**out = **in
+	}
+	if in.Download != nil {
+		in, out := &in.Download, &out.Download
+		*out = new(XStoreRestoreDownload)
+		**out = **in
+	}
+	if in.PostRestoreExec != nil {
+		in, out := &in.PostRestoreExec, &out.PostRestoreExec
+		*out = make([]XStoreRestoreExec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Masking != nil {
+		in, out := &in.Masking, &out.Masking
+		*out = new(XStoreRestoreMasking)
+		**out = **in
+	}
+	if in.Rejoin != nil {
+		in, out := &in.Rejoin, &out.Rejoin
+		*out = new(XStoreRestoreRejoin)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new XStoreRestoreSpec.
@@ -1281,6 +3587,21 @@ func (in *XStoreSpec) DeepCopyInto(out *XStoreSpec) {
 		*out = new(XStoreRestoreSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(xstore.TLS)
+		**out = **in
+	}
+	if in.TDE != nil {
+		in, out := &in.TDE, &out.TDE
+		*out = new(xstore.TDE)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LogCollector != nil {
+		in, out := &in.LogCollector, &out.LogCollector
+		*out = new(xstore.LogCollector)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new XStoreSpec.
@@ -1293,6 +3614,29 @@ func (in *XStoreSpec) DeepCopy() *XStoreSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *XStoreStandbyStatus) DeepCopyInto(out *XStoreStandbyStatus) {
+	*out = *in
+	if in.LastAppliedEventTime != nil {
+		in, out := &in.LastAppliedEventTime, &out.LastAppliedEventTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastApplyTime != nil {
+		in, out := &in.LastApplyTime, &out.LastApplyTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new XStoreStandbyStatus.
+func (in *XStoreStandbyStatus) DeepCopy() *XStoreStandbyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(XStoreStandbyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *XStoreStatus) DeepCopyInto(out *XStoreStatus) {
 	*out = *in
@@ -1354,6 +3698,98 @@ func (in *XStoreStatus) DeepCopyInto(out *XStoreStatus) {
 		}
 	}
 	in.RestartingPods.DeepCopyInto(&out.RestartingPods)
+	if in.ContinuousRestore != nil {
+		in, out := &in.ContinuousRestore, &out.ContinuousRestore
+		*out = new(xstore.ContinuousRestoreStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RestoreFallback != nil {
+		in, out := &in.RestoreFallback, &out.RestoreFallback
+		*out = new(xstore.RestoreFallbackStatus)
+		**out = **in
+	}
+	if in.RestoreDownload != nil {
+		in, out := &in.RestoreDownload, &out.RestoreDownload
+		*out = make(map[string]*xstore.RestoreDownloadStatus, len(*in))
+		for key, val := range *in {
+			var outVal *xstore.RestoreDownloadStatus
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = new(xstore.RestoreDownloadStatus)
+				**out = **in
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.RestoreReplay != nil {
+		in, out := &in.RestoreReplay, &out.RestoreReplay
+		*out = make(map[string]*xstore.RestoreReplayStatus, len(*in))
+		for key, val := range *in {
+			var outVal *xstore.RestoreReplayStatus
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = new(xstore.RestoreReplayStatus)
+				(*in).DeepCopyInto(*out)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.RestorePointInTime != nil {
+		in, out := &in.RestorePointInTime, &out.RestorePointInTime
+		*out = new(xstore.PointInTimeRestoreStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RestoreThaw != nil {
+		in, out := &in.RestoreThaw, &out.RestoreThaw
+		*out = new(xstore.RestoreThawStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PostRestoreExec != nil {
+		in, out := &in.PostRestoreExec, &out.PostRestoreExec
+		*out = make([]xstore.PostRestoreExecStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RestoreMasking != nil {
+		in, out := &in.RestoreMasking, &out.RestoreMasking
+		*out = new(xstore.RestoreMaskingStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RestoreRejoin != nil {
+		in, out := &in.RestoreRejoin, &out.RestoreRejoin
+		*out = new(xstore.RestoreRejoinStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RestoreCompatibility != nil {
+		in, out := &in.RestoreCompatibility, &out.RestoreCompatibility
+		*out = new(xstore.RestoreCompatibilityReport)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReadonlyReplication != nil {
+		in, out := &in.ReadonlyReplication, &out.ReadonlyReplication
+		*out = new(xstore.ReadonlyReplicationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Switchover != nil {
+		in, out := &in.Switchover, &out.Switchover
+		*out = new(xstore.SwitchoverStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.VolumeMigration != nil {
+		in, out := &in.VolumeMigration, &out.VolumeMigration
+		*out = new(xstore.VolumeMigrationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Progress != nil {
+		in, out := &in.Progress, &out.Progress
+		*out = new(common.OperationProgress)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new XStoreStatus.
@@ -7,9 +7,11 @@ import (
 	"fmt"
 	"github.com/alibaba/polardbx-operator/pkg/hpfs/discovery"
 	. "github.com/alibaba/polardbx-operator/pkg/hpfs/filestream"
+	polarxIo "github.com/alibaba/polardbx-operator/pkg/util/io"
 	"github.com/google/uuid"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 )
 
@@ -27,6 +29,12 @@ Examples:
 5. uploadOss
 
 6. downOss
+
+7. signOss
+
+8. copyOss
+
+9. thawOss
 */
 var (
 	host             string //filestream server host
@@ -42,6 +50,13 @@ var (
 	stream           string
 	sink             string
 	ossBufferSize    string
+	offset           string
+	storageClass     string
+	uploadThreads    string
+	uploadRetries    string
+	uploadBackoff    string
+	retriesFile      string
+	limitRate        int
 )
 
 var activeHosts map[string]discovery.HostInfo
@@ -57,6 +72,13 @@ func init() {
 	flag.StringVar(&retentionTime, "meta.retentionTime", "", "Field RetentionTime of metadata")
 	flag.StringVar(&sink, "meta.sink", "", "Sink name of metadata")
 	flag.StringVar(&ossBufferSize, "meta.ossBufferSize", "", "oss buffer size of metadata")
+	flag.StringVar(&offset, "meta.offset", "", "The offset in bytes to download from, used to resume a download")
+	flag.StringVar(&storageClass, "meta.storageClass", "", "The storage class of uploaded objects, e.g. IA or Archive of oss")
+	flag.StringVar(&uploadThreads, "meta.uploadThreads", "", "The number of parts uploaded in parallel, only for oss")
+	flag.StringVar(&uploadRetries, "meta.uploadRetries", "", "The max retries of each failed upload request, only for oss")
+	flag.StringVar(&uploadBackoff, "meta.uploadRetryBackoff", "", "The initial backoff of upload retries, doubled after each retry, e.g. 1s")
+	flag.StringVar(&retriesFile, "retriesFile", "", "The file to write the count of retried upload requests to")
+	flag.IntVar(&limitRate, "limitRate", 0, "The max download or upload speed in bytes/s, default: 0, unlimited")
 	flag.StringVar(&destNodeName, "destNodeName", "", "The name of the destination node name")
 	flag.StringVar(&hostInfoFilePath, "hostInfoFilePath", "/tools/xstore/hdfs-nodes.json", "The file path of the host info file")
 	flag.StringVar(&stream, "stream", "", "The file stream type such as tar, default: empty string")
@@ -117,22 +139,39 @@ func main() {
 		Filename:      filename,
 		RedirectAddr:  redirectAddr,
 		Filepath:      filepath,
+		RetentionTime: retentionTime,
 		Stream:        stream,
 		RequestId:     uuid.New().String(),
 		Sink:          sink,
 		OssBufferSize: ossBufferSize,
+		Offset:        offset,
+		StorageClass:  storageClass,
+		UploadThreads: uploadThreads,
+		UploadRetries: uploadRetries,
+		UploadBackoff: uploadBackoff,
 	}
 	if strings.HasPrefix(strings.ToLower(action), "upload") {
-		len, err := client.Upload(os.Stdin, metadata)
+		len, err := client.Upload(polarxIo.NewRateLimitedReader(os.Stdin, limitRate), metadata)
 		if err != nil {
 			printErrAndExit(err, metadata)
 		}
-		err = client.Check(metadata)
+		retries, err := client.CheckUpload(metadata)
 		if err != nil {
 			printErrAndExit(err, metadata)
 		}
+		if retriesFile != "" {
+			if err := os.WriteFile(retriesFile, []byte(strconv.FormatInt(retries, 10)), 0644); err != nil {
+				printErrAndExit(err, metadata)
+			}
+		}
 		fmt.Print(len)
 	} else if strings.HasPrefix(strings.ToLower(action), "download") {
+		_, err := client.Download(polarxIo.NewRateLimitedWriter(os.Stdout, limitRate), metadata)
+		if err != nil {
+			printErrAndExit(err, metadata)
+		}
+	} else if strings.HasPrefix(strings.ToLower(action), "sign") || strings.HasPrefix(strings.ToLower(action), "copy") ||
+		strings.HasPrefix(strings.ToLower(action), "thaw") {
 		_, err := client.Download(os.Stdout, metadata)
 		if err != nil {
 			printErrAndExit(err, metadata)
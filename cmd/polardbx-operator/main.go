@@ -18,6 +18,7 @@ package main
 
 import (
 	"flag"
+	"os"
 	"strings"
 
 	"github.com/go-logr/logr"
@@ -49,6 +50,8 @@ func init() {
 	flag.StringVar(&operatorOptions.LeaderElectionNamespace, "leader-election-namespace", "", "The namespace where leader election happens. "+
 		"If not specified, the namespace where this operator's running is used.")
 	flag.StringVar(&operatorOptions.ConfigPath, "config-path", "/etc/operator/polardbx", "The path that contains configs of polardbx operator.")
+	flag.StringVar(&operatorOptions.OTLPTracesEndpoint, "otlp-traces-endpoint", os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"),
+		"The OTLP/HTTP endpoint to export traces to, e.g. http://collector:4318/v1/traces. Tracing is disabled if not specified.")
 	flag.StringVar(&featureGates, "feature-gates", "", "Feature gates to enable.")
 
 	flag.Parse()
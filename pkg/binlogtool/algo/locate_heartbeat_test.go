@@ -22,6 +22,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/alibaba/polardbx-operator/pkg/binlogtool/binlog"
 	"github.com/alibaba/polardbx-operator/pkg/binlogtool/tx"
 	"github.com/alibaba/polardbx-operator/pkg/binlogtool/utils"
 )
@@ -55,6 +56,9 @@ func testLocateHeartbeat_Perform(t *testing.T, binlogFile string, opt LocateHear
 }
 
 func TestLocateHeartbeat_Perform(t *testing.T) {
+	if testing.Short() {
+		t.Skip("requires local binlog files")
+	}
 	const binlogFile = "/Users/shunjie.dsj/Documents/mysql-bin.000351"
 	testLocateHeartbeat_Perform(t, binlogFile, WithCommitTSPolicy(6914146952644919360, ExactCommitTS), 1445212168193581057)
 }
@@ -0,0 +1,108 @@
+//go:build polardbx
+
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chunk
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"math/rand"
+)
+
+const DefaultAvgSize = 1 << 20
+
+// gearTable is the random table used by the gear rolling hash. It is generated
+// with a fixed seed so that chunk boundaries are stable across runs and versions.
+var gearTable [256]uint64
+
+func init() {
+	r := rand.New(rand.NewSource(0x706f6c617264627))
+	for i := range gearTable {
+		gearTable[i] = r.Uint64()
+	}
+}
+
+// Chunk describes a content-defined chunk of the stream.
+type Chunk struct {
+	Checksum string
+	Size     int64
+}
+
+// Chunker splits a byte stream into content-defined chunks with the gear hash
+// and records the sha256 checksum of each chunk.
+type Chunker struct {
+	minSize int64
+	maxSize int64
+	mask    uint64
+
+	fp      uint64
+	size    int64
+	digest  hash.Hash
+	onChunk func(c Chunk)
+}
+
+// NewChunker creates a chunker with the given average chunk size (must be a power of 2),
+// chunk sizes are limited to [avgSize/4, avgSize*4]. Callback is invoked for each chunk.
+func NewChunker(avgSize int64, onChunk func(c Chunk)) *Chunker {
+	if avgSize <= 0 || avgSize&(avgSize-1) != 0 {
+		avgSize = DefaultAvgSize
+	}
+	return &Chunker{
+		minSize: avgSize / 4,
+		maxSize: avgSize * 4,
+		mask:    uint64(avgSize - 1),
+		digest:  sha256.New(),
+		onChunk: onChunk,
+	}
+}
+
+func (c *Chunker) cut() {
+	c.onChunk(Chunk{
+		Checksum: hex.EncodeToString(c.digest.Sum(nil)),
+		Size:     c.size,
+	})
+	c.digest.Reset()
+	c.fp, c.size = 0, 0
+}
+
+// Write feeds data into the chunker. It never fails.
+func (c *Chunker) Write(p []byte) (int, error) {
+	start := 0
+	for i, b := range p {
+		c.fp = (c.fp << 1) + gearTable[b]
+		c.size++
+		if (c.size >= c.minSize && c.fp&c.mask == 0) || c.size >= c.maxSize {
+			c.digest.Write(p[start : i+1])
+			start = i + 1
+			c.cut()
+		}
+	}
+	if start < len(p) {
+		c.digest.Write(p[start:])
+	}
+	return len(p), nil
+}
+
+// Close emits the trailing chunk if any.
+func (c *Chunker) Close() error {
+	if c.size > 0 {
+		c.cut()
+	}
+	return nil
+}
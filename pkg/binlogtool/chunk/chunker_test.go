@@ -0,0 +1,108 @@
+//go:build polardbx
+
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chunk
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func chunksOf(data []byte, avgSize int64, writeSize int) Manifest {
+	m := make(Manifest, 0)
+	c := NewChunker(avgSize, func(c Chunk) {
+		m = append(m, c)
+	})
+	for len(data) > 0 {
+		n := writeSize
+		if n > len(data) {
+			n = len(data)
+		}
+		_, _ = c.Write(data[:n])
+		data = data[n:]
+	}
+	_ = c.Close()
+	return m
+}
+
+func TestChunker_Boundaries(t *testing.T) {
+	data := make([]byte, 1<<20)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	a := chunksOf(data, 16<<10, 4096)
+	b := chunksOf(data, 16<<10, 999)
+	if len(a) != len(b) {
+		t.Fatalf("chunks differ with write size: %d vs %d", len(a), len(b))
+	}
+	var total int64
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("chunk %d differs: %v vs %v", i, a[i], b[i])
+		}
+		total += a[i].Size
+	}
+	if total != int64(len(data)) {
+		t.Fatalf("total size mismatch: %d", total)
+	}
+}
+
+func TestManifest_CompareShifted(t *testing.T) {
+	data := make([]byte, 1<<20)
+	rand.New(rand.NewSource(2)).Read(data)
+	shifted := append([]byte("some inserted bytes"), data...)
+
+	base := chunksOf(data, 16<<10, 4096)
+	m := chunksOf(shifted, 16<<10, 4096)
+
+	r := m.Compare(base)
+	if r.SharedChunks < r.TotalChunks-2 {
+		t.Fatalf("expect most chunks shared after shift, report: %+v", r)
+	}
+
+	buf := &bytes.Buffer{}
+	if _, err := m.WriteTo(buf); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadManifest(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != len(m) || read.Compare(m).SharedChunks != int64(len(m)) {
+		t.Fatal("manifest not restored")
+	}
+}
+
+func TestManifest_Verify(t *testing.T) {
+	data := make([]byte, 1<<20)
+	rand.New(rand.NewSource(3)).Read(data)
+	expect := chunksOf(data, 16<<10, 4096)
+
+	if err := chunksOf(data, 16<<10, 999).Verify(expect); err != nil {
+		t.Fatalf("expect verified, got %v", err)
+	}
+
+	corrupted := append([]byte(nil), data...)
+	corrupted[len(corrupted)/2] ^= 0xff
+	if err := chunksOf(corrupted, 16<<10, 4096).Verify(expect); err == nil {
+		t.Fatal("expect corrupted stream not verified")
+	}
+	if err := chunksOf(data[:len(data)/2], 16<<10, 4096).Verify(expect); err == nil {
+		t.Fatal("expect truncated stream not verified")
+	}
+}
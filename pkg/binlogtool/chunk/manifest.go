@@ -0,0 +1,114 @@
+//go:build polardbx
+
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chunk
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Manifest is the ordered list of chunks of a backup stream. It is serialized as
+// one "<sha256> <size>" line per chunk.
+type Manifest []Chunk
+
+func (m Manifest) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	var n int64
+	for _, c := range m {
+		k, err := fmt.Fprintf(bw, "%s %d\n", c.Checksum, c.Size)
+		n += int64(k)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, bw.Flush()
+}
+
+func ReadManifest(r io.Reader) (Manifest, error) {
+	m := make(Manifest, 0)
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if len(line) == 0 {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid manifest line: %s", line)
+		}
+		size, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chunk size in line: %s", line)
+		}
+		m = append(m, Chunk{Checksum: fields[0], Size: size})
+	}
+	return m, s.Err()
+}
+
+// DedupReport describes how many chunks of a backup are shared with the base one.
+type DedupReport struct {
+	TotalChunks  int64 `json:"totalChunks"`
+	TotalBytes   int64 `json:"totalBytes"`
+	SharedChunks int64 `json:"sharedChunks"`
+	SharedBytes  int64 `json:"sharedBytes"`
+}
+
+// Compare calculates the dedup report of m against base. Duplicated chunks inside m
+// are counted as shared as well, since a dedup store would keep them only once.
+func (m Manifest) Compare(base Manifest) DedupReport {
+	seen := make(map[string]struct{}, len(base)+len(m))
+	for _, c := range base {
+		seen[c.Checksum] = struct{}{}
+	}
+	var r DedupReport
+	for _, c := range m {
+		r.TotalChunks++
+		r.TotalBytes += c.Size
+		if _, ok := seen[c.Checksum]; ok {
+			r.SharedChunks++
+			r.SharedBytes += c.Size
+		} else {
+			seen[c.Checksum] = struct{}{}
+		}
+	}
+	return r
+}
+
+// Verify checks that m is exactly the expected manifest, i.e. the stream is the same as the one
+// the expected manifest is recorded from. It reports the first chunk differs.
+func (m Manifest) Verify(expect Manifest) error {
+	var offset int64
+	for i := range expect {
+		if i >= len(m) {
+			return fmt.Errorf("stream truncated at chunk %d, offset %d", i, offset)
+		}
+		if m[i] != expect[i] {
+			return fmt.Errorf("chunk %d mismatch at offset %d, expect %s (%d bytes), got %s (%d bytes)",
+				i, offset, expect[i].Checksum, expect[i].Size, m[i].Checksum, m[i].Size)
+		}
+		offset += m[i].Size
+	}
+	if len(m) > len(expect) {
+		return fmt.Errorf("stream has %d more chunks than expected after offset %d", len(m)-len(expect), offset)
+	}
+	return nil
+}
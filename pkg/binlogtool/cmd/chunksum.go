@@ -0,0 +1,118 @@
+//go:build polardbx
+
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/alibaba/polardbx-operator/pkg/binlogtool/chunk"
+)
+
+var (
+	chunkSumAvgSize  int64
+	chunkSumManifest string
+	chunkSumBase     string
+	chunkSumReport   string
+	chunkSumVerify   string
+)
+
+func init() {
+	chunkSumCmd.Flags().Int64Var(&chunkSumAvgSize, "avg-size", chunk.DefaultAvgSize, "average chunk size in bytes (power of 2)")
+	chunkSumCmd.Flags().StringVar(&chunkSumManifest, "manifest", "", "output file of chunk manifest")
+	chunkSumCmd.Flags().StringVar(&chunkSumBase, "base", "", "chunk manifest of the base (previous) backup to compare with")
+	chunkSumCmd.Flags().StringVar(&chunkSumReport, "report", "", "output file of dedup report (json)")
+	chunkSumCmd.Flags().StringVar(&chunkSumVerify, "verify", "", "chunk manifest to verify the stream against, exit with error if mismatch")
+
+	rootCmd.AddCommand(chunkSumCmd)
+}
+
+var chunkSumCmd = &cobra.Command{
+	Use:   "chunksum [flags]",
+	Short: "Copy stdin to stdout and record content-defined chunk checksums",
+	Long:  "Copy stdin to stdout and record content-defined chunk checksums",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(chunkSumManifest) == 0 && len(chunkSumVerify) == 0 {
+			return errors.New("please specify the manifest file or the one to verify")
+		}
+		return nil
+	},
+	Run: wrap(func(cmd *cobra.Command, args []string) error {
+		manifest := make(chunk.Manifest, 0)
+		chunker := chunk.NewChunker(chunkSumAvgSize, func(c chunk.Chunk) {
+			manifest = append(manifest, c)
+		})
+
+		if _, err := io.Copy(io.MultiWriter(os.Stdout, chunker), os.Stdin); err != nil {
+			return err
+		}
+		_ = chunker.Close()
+
+		if len(chunkSumVerify) > 0 {
+			vf, err := os.Open(chunkSumVerify)
+			if err != nil {
+				return err
+			}
+			defer vf.Close()
+			expect, err := chunk.ReadManifest(vf)
+			if err != nil {
+				return err
+			}
+			if err := manifest.Verify(expect); err != nil {
+				return err
+			}
+			if len(chunkSumManifest) == 0 {
+				return nil
+			}
+		}
+
+		f, err := os.OpenFile(chunkSumManifest, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if _, err := manifest.WriteTo(f); err != nil {
+			return err
+		}
+
+		if len(chunkSumReport) == 0 {
+			return nil
+		}
+		base := make(chunk.Manifest, 0)
+		if len(chunkSumBase) > 0 {
+			bf, err := os.Open(chunkSumBase)
+			if err != nil {
+				return err
+			}
+			defer bf.Close()
+			if base, err = chunk.ReadManifest(bf); err != nil {
+				return err
+			}
+		}
+		report, err := json.Marshal(manifest.Compare(base))
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(chunkSumReport, report, 0644)
+	}),
+}
@@ -0,0 +1,92 @@
+//go:build polardbx
+
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/alibaba/polardbx-operator/pkg/util/crypt"
+)
+
+var (
+	encryptKeyFile string
+	decryptKeyFile string
+)
+
+func init() {
+	encryptCmd.Flags().StringVar(&encryptKeyFile, "key-file", "", "file of the AES-256 key, raw or encoded in base64 or hex")
+	decryptCmd.Flags().StringVar(&decryptKeyFile, "key-file", "", "file of the AES-256 key, raw or encoded in base64 or hex")
+
+	rootCmd.AddCommand(encryptCmd)
+	rootCmd.AddCommand(decryptCmd)
+}
+
+func requireKeyFile(keyFile *string) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if len(*keyFile) == 0 {
+			return errors.New("please specify the key file")
+		}
+		return nil
+	}
+}
+
+var encryptCmd = &cobra.Command{
+	Use:   "encrypt [flags]",
+	Short: "Encrypt stdin to stdout",
+	Long:  "Encrypt stdin to stdout with AES-256-GCM",
+	Args:  requireKeyFile(&encryptKeyFile),
+	Run: wrap(func(cmd *cobra.Command, args []string) error {
+		key, err := crypt.LoadKeyFile(encryptKeyFile)
+		if err != nil {
+			return err
+		}
+		out := bufio.NewWriterSize(os.Stdout, crypt.DefaultSegmentSize)
+		w, err := crypt.NewWriter(out, key)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(w, os.Stdin); err != nil {
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+		return out.Flush()
+	}),
+}
+
+var decryptCmd = &cobra.Command{
+	Use:   "decrypt [flags]",
+	Short: "Decrypt stdin to stdout",
+	Long:  "Decrypt stdin encrypted by the encrypt command to stdout, exit with error if tampered or truncated",
+	Args:  requireKeyFile(&decryptKeyFile),
+	Run: wrap(func(cmd *cobra.Command, args []string) error {
+		key, err := crypt.LoadKeyFile(decryptKeyFile)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(os.Stdout, crypt.NewReader(os.Stdin, key))
+		return err
+	}),
+}
@@ -0,0 +1,168 @@
+//go:build polardbx
+
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+
+	"github.com/alibaba/polardbx-operator/pkg/binlogtool/binlog"
+	"github.com/alibaba/polardbx-operator/pkg/binlogtool/binlog/event"
+	"github.com/alibaba/polardbx-operator/pkg/binlogtool/binlog/spec"
+	"github.com/alibaba/polardbx-operator/pkg/binlogtool/utils"
+)
+
+var (
+	gtidRangeBinlogFile string
+	gtidRangeChecksum   string
+)
+
+func init() {
+	gtidRangeCmd.Flags().StringVar(&gtidRangeChecksum, "checksum", "crc32", "binary log checksum (ignored for binary log version v1, v3 and v4 after 3.6.1)")
+
+	rootCmd.AddCommand(gtidRangeCmd)
+}
+
+// gtidSet is the set of GTIDs, i.e. the intervals of transaction numbers of each source id.
+type gtidSet map[uuid.UUID][]event.GTIDInterval
+
+// Add adds the GTID into the set, the intervals are kept sorted and merged.
+func (s gtidSet) Add(sid uuid.UUID, gno uint64) {
+	intervals := s[sid]
+	i := sort.Search(len(intervals), func(i int) bool {
+		return intervals[i].End >= gno
+	})
+	if i < len(intervals) && intervals[i].Start <= gno {
+		return
+	}
+	switch {
+	case i > 0 && intervals[i-1].End+1 == gno:
+		intervals[i-1].End = gno
+		if i < len(intervals) && intervals[i].Start == gno+1 {
+			intervals[i-1].End = intervals[i].End
+			intervals = append(intervals[:i], intervals[i+1:]...)
+		}
+	case i < len(intervals) && intervals[i].Start == gno+1:
+		intervals[i].Start = gno
+	default:
+		intervals = append(intervals, event.GTIDInterval{})
+		copy(intervals[i+1:], intervals[i:])
+		intervals[i] = event.GTIDInterval{Start: gno, End: gno}
+	}
+	s[sid] = intervals
+}
+
+// String formats the set as MySQL does, e.g. "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5:7".
+func (s gtidSet) String() string {
+	sids := make([]uuid.UUID, 0, len(s))
+	for sid := range s {
+		sids = append(sids, sid)
+	}
+	sort.Slice(sids, func(i, j int) bool {
+		return sids[i].String() < sids[j].String()
+	})
+	parts := make([]string, 0, len(sids))
+	for _, sid := range sids {
+		b := &strings.Builder{}
+		b.WriteString(sid.String())
+		for _, interval := range s[sid] {
+			if interval.Start == interval.End {
+				_, _ = fmt.Fprintf(b, ":%d", interval.Start)
+			} else {
+				_, _ = fmt.Fprintf(b, ":%d-%d", interval.Start, interval.End)
+			}
+		}
+		parts = append(parts, b.String())
+	}
+	return strings.Join(parts, ",")
+}
+
+// gtidRange is the range of the binlog file.
+type gtidRange struct {
+	Gtids               string `json:"gtids"`
+	FirstEventTimestamp uint32 `json:"firstEventTimestamp,omitempty"`
+	LastEventTimestamp  uint32 `json:"lastEventTimestamp,omitempty"`
+	EventsCount         int64  `json:"eventsCount"`
+}
+
+var gtidRangeCmd = &cobra.Command{
+	Use:   "gtidrange [flags] file",
+	Short: "Show the GTIDs and the time range of binlog file in json",
+	Long:  "Show the GTIDs and the time range of binlog file in json",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("please specify a binlog file")
+		}
+		gtidRangeBinlogFile = args[0]
+		return nil
+	},
+	Run: wrap(func(cmd *cobra.Command, args []string) error {
+		lazyScanner := binlog.NewLazyLogEventScanCloser(
+			func() (io.ReadCloser, error) {
+				f, err := os.Open(gtidRangeBinlogFile)
+				if err != nil {
+					return nil, err
+				}
+				return utils.NewSeekableBufferReader(f), nil
+			},
+			0,
+			binlog.WithBinlogFile(gtidRangeBinlogFile),
+			binlog.WithChecksumAlgorithm(gtidRangeChecksum),
+		)
+		defer lazyScanner.Close()
+
+		gtids := make(gtidSet)
+		r := gtidRange{}
+		for {
+			_, ev, err := lazyScanner.Next()
+			if err != nil {
+				if err == binlog.EOF {
+					break
+				}
+				return err
+			}
+			eventType := ev.EventHeader().EventTypeCode()
+			if !isChangeEvent(eventType) {
+				continue
+			}
+			timestamp := ev.EventHeader().EventTimestamp()
+			if r.FirstEventTimestamp == 0 {
+				r.FirstEventTimestamp = timestamp
+			}
+			r.LastEventTimestamp = timestamp
+			r.EventsCount++
+			if eventType == spec.GTID_LOG_EVENT {
+				if gtidEvent, ok := ev.EventData().(*event.GTIDLogEvent); ok {
+					gtids.Add(gtidEvent.SID, gtidEvent.GNo)
+				}
+			}
+		}
+		r.Gtids = gtids.String()
+
+		return json.NewEncoder(os.Stdout).Encode(&r)
+	}),
+}
@@ -0,0 +1,50 @@
+//go:build polardbx
+
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestGtidSet(t *testing.T) {
+	a := uuid.MustParse("3e11fa47-71ca-11e1-9e33-c80aa9429562")
+	b := uuid.MustParse("0b4a2c0c-71ca-11e1-9e33-c80aa9429562")
+
+	s := make(gtidSet)
+	if s.String() != "" {
+		t.Fatalf("expect empty, got %s", s)
+	}
+	for _, gno := range []uint64{5, 1, 2, 7, 3, 3, 9, 8} {
+		s.Add(a, gno)
+	}
+	s.Add(b, 10)
+	expect := "0b4a2c0c-71ca-11e1-9e33-c80aa9429562:10,3e11fa47-71ca-11e1-9e33-c80aa9429562:1-3:5:7-9"
+	if s.String() != expect {
+		t.Fatalf("expect %s, got %s", expect, s)
+	}
+
+	s.Add(a, 4)
+	s.Add(a, 6)
+	expect = "0b4a2c0c-71ca-11e1-9e33-c80aa9429562:10,3e11fa47-71ca-11e1-9e33-c80aa9429562:1-9"
+	if s.String() != expect {
+		t.Fatalf("expect intervals merged %s, got %s", expect, s)
+	}
+}
@@ -27,6 +27,7 @@ import (
 	"io"
 	"os"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -447,7 +448,9 @@ func findAndCollectAllBinlogFiles() {
 	fmt.Println()
 }
 
-func buildBinaryEventFiles() map[string]string {
+// buildBinaryEventFiles returns the binary event files of each stream, either a single file like
+// dn-0.evs or the segments like dn-0.00000.evs, dn-0.00001.evs in order.
+func buildBinaryEventFiles() map[string][]string {
 	fmt.Println("=================== COLLECT BINARY EVENTS ====================")
 
 	dir, err := os.Open(seekCpDirectory)
@@ -457,7 +460,7 @@ func buildBinaryEventFiles() map[string]string {
 	}
 	defer dir.Close()
 
-	m := make(map[string]string)
+	m := make(map[string][]string)
 	entries, err := dir.ReadDir(-1)
 	if err != nil {
 		_, _ = fmt.Fprintln(os.Stderr, "ERROR: unable to list dir, "+err.Error())
@@ -467,9 +470,12 @@ func buildBinaryEventFiles() map[string]string {
 	for _, e := range entries {
 		if strings.HasSuffix(e.Name(), ".evs") {
 			streamName := strings.Split(e.Name(), ".")[0]
-			m[streamName] = path.Join(seekCpDirectory, e.Name())
+			m[streamName] = append(m[streamName], path.Join(seekCpDirectory, e.Name()))
 		}
 	}
+	for _, files := range m {
+		sort.Strings(files)
+	}
 
 	fmt.Printf("TOTAL BINARY EVENT STREAMS: %d\n", len(m))
 
@@ -496,18 +502,22 @@ var seekCpCmd = &cobra.Command{
 			binEventFiles := buildBinaryEventFiles()
 
 			txParsers = make(map[string]tx.TransactionEventParser)
-			for streamName, eventFile := range binEventFiles {
-				f, err := os.Open(eventFile)
-				if err != nil {
-					return err
-				}
-				//goland:noinspection ALL
-				defer f.Close()
-				p, err := tx.NewBinaryTransactionEventParser(bufio.NewReader(f))
-				if err != nil {
-					return err
+			for streamName, eventFiles := range binEventFiles {
+				parsers := make([]tx.TransactionEventParser, 0, len(eventFiles))
+				for _, eventFile := range eventFiles {
+					f, err := os.Open(eventFile)
+					if err != nil {
+						return err
+					}
+					//goland:noinspection ALL
+					defer f.Close()
+					p, err := tx.NewBinaryTransactionEventParser(bufio.NewReader(f))
+					if err != nil {
+						return err
+					}
+					parsers = append(parsers, p)
 				}
-				txParsers[streamName] = p
+				txParsers[streamName] = tx.NewMultiTransactionEventParser(parsers...)
 			}
 		} else {
 			// Build from binary logs.
@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"github.com/alibaba/polardbx-operator/pkg/binlogtool/binlog"
 	"github.com/alibaba/polardbx-operator/pkg/binlogtool/binlog/event"
+	"github.com/alibaba/polardbx-operator/pkg/binlogtool/binlog/spec"
 	"github.com/alibaba/polardbx-operator/pkg/binlogtool/utils"
 	"github.com/spf13/cobra"
 	"io"
@@ -74,6 +75,7 @@ var truncateCmd = &cobra.Command{
 		}
 
 		var lastEvent event.LogEvent
+		var eventsCount int64
 		lazyScanner := binlog.NewLazyLogEventScanCloser(
 			func() (io.ReadCloser, error) {
 				f, err := os.Open(inputBinlogFile)
@@ -87,7 +89,10 @@ var truncateCmd = &cobra.Command{
 		)
 		defer lazyScanner.Close()
 		defer func() {
-			fmt.Printf("LAST EVENT TIMESTAMP: %d\n", lastEvent.EventHeader().EventTimestamp())
+			if lastEvent != nil {
+				fmt.Printf("LAST EVENT TIMESTAMP: %d\n", lastEvent.EventHeader().EventTimestamp())
+			}
+			fmt.Printf("EVENTS COUNT: %d\n", eventsCount)
 		}()
 
 		f, err := os.OpenFile(outputBinlogFile, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0644)
@@ -114,11 +119,25 @@ var truncateCmd = &cobra.Command{
 			}
 
 			writer.Write(event)
+			if isChangeEvent(event.EventHeader().EventTypeCode()) {
+				eventsCount++
+			}
 		}
 		return nil
 	}),
 }
 
+// isChangeEvent tells if the event is not one of the events written by the server on its own, e.g.
+// the headers of binlog file and the heartbeats.
+func isChangeEvent(eventType uint8) bool {
+	switch eventType {
+	case spec.FORMAT_DESCRIPTION_EVENT, spec.PREVIOUS_GTIDS_LOG_EVENT, spec.ROTATE_EVENT, spec.STOP_EVENT,
+		spec.HEARTBEAT_LOG_EVENT, spec.HEARTBEAT_LOG_EVENT_V2:
+		return false
+	}
+	return true
+}
+
 func init() {
 	truncateCmd.Flags().StringVar(&binlogChecksum, "checksum", "crc32", "binary log checksum (ignored for binary log version v1, v3 and v4 after 3.6.1)")
 	truncateCmd.Flags().StringVarP(&outputBinlogFile, "output", "o", "", "The output binlog after cut")
@@ -0,0 +1,85 @@
+//go:build polardbx
+
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tx
+
+import (
+	"bytes"
+	"testing"
+)
+
+func binaryEventsOf(t *testing.T, file string, xids ...uint64) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	w, err := NewBinaryTransactionEventWriter(buf, []string{file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, xid := range xids {
+		if err := w.Write(Event{File: file, Type: Commit, XID: xid, Ts: xid}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	return buf
+}
+
+func TestMultiTransactionEventParser(t *testing.T) {
+	newParser := func() TransactionEventParser {
+		parsers := make([]TransactionEventParser, 0, 2)
+		for _, seg := range []*bytes.Buffer{
+			binaryEventsOf(t, "mysql_bin.000001", 1, 2),
+			binaryEventsOf(t, "mysql_bin.000002", 3),
+		} {
+			p, err := NewBinaryTransactionEventParser(seg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsers = append(parsers, p)
+		}
+		return NewMultiTransactionEventParser(parsers...)
+	}
+
+	var xids []uint64
+	var files []string
+	if err := newParser().Parse(func(ev *Event) error {
+		xids = append(xids, ev.XID)
+		files = append(files, ev.File)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(xids) != 3 || xids[0] != 1 || xids[2] != 3 || files[2] != "mysql_bin.000002" {
+		t.Fatalf("unexpected events: %v, %v", xids, files)
+	}
+
+	xids = nil
+	if err := newParser().Parse(func(ev *Event) error {
+		xids = append(xids, ev.XID)
+		if ev.XID == 2 {
+			return StopParse
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(xids) != 2 {
+		t.Fatalf("expect parse stopped across segments, got %v", xids)
+	}
+}
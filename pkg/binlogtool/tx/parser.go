@@ -355,3 +355,35 @@ func NewTransactionEventParser(s binlog.LogEventScanner, opts ...TransactionEven
 
 	return parser
 }
+
+type multiTransactionEventParser struct {
+	parsers []TransactionEventParser
+}
+
+func (m *multiTransactionEventParser) Parse(h EventHandler) error {
+	stopped := false
+	for _, p := range m.parsers {
+		if err := p.Parse(func(ev *Event) error {
+			err := h(ev)
+			if err == StopParse {
+				stopped = true
+			}
+			return err
+		}); err != nil {
+			return err
+		}
+		if stopped {
+			return nil
+		}
+	}
+	return nil
+}
+
+// NewMultiTransactionEventParser chains the parsers of continuous event segments of a stream, e.g.
+// segments of binary events collected in batches.
+func NewMultiTransactionEventParser(parsers ...TransactionEventParser) TransactionEventParser {
+	if len(parsers) == 1 {
+		return parsers[0]
+	}
+	return &multiTransactionEventParser{parsers: parsers}
+}
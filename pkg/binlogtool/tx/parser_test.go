@@ -23,6 +23,8 @@ import (
 	"fmt"
 	"os"
 	"testing"
+
+	"github.com/alibaba/polardbx-operator/pkg/binlogtool/binlog"
 )
 
 func parseBinlogAndOutputTransLog(t *testing.T, binlogFile string, outputFile string) {
@@ -58,16 +60,25 @@ func parseBinlogAndOutputTransLog(t *testing.T, binlogFile string, outputFile st
 }
 
 func TestTransactionEventParser_Parse(t *testing.T) {
+	if testing.Short() {
+		t.Skip("requires local binlog files")
+	}
 	const binlogFile = "/Users/shunjie.dsj/Documents/mysql-bin.000351"
 	parseBinlogAndOutputTransLog(t, binlogFile, "/tmp/trans.57.log")
 }
 
 func TestTransactionEventParser_Parse_MySQL8(t *testing.T) {
+	if testing.Short() {
+		t.Skip("requires local binlog files")
+	}
 	const binlogFile = "/Users/shunjie.dsj/Documents/master-bin.000288"
 	parseBinlogAndOutputTransLog(t, binlogFile, "/tmp/trans.80.log")
 }
 
 func TestTransactionEventParser_ParseSpeed(t *testing.T) {
+	if testing.Short() {
+		t.Skip("requires local binlog files")
+	}
 	const binlogFile = "/Users/shunjie.dsj/Documents/mysql-bin.000351"
 	f, err := os.Open(binlogFile)
 	if err != nil {
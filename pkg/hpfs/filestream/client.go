@@ -90,10 +90,17 @@ func (f *FileClient) Upload(reader io.Reader, actionMetadata ActionMetadata) (in
 }
 
 func (f *FileClient) Check(actionMetadata ActionMetadata) error {
+	_, err := f.CheckUpload(actionMetadata)
+	return err
+}
+
+// CheckUpload waits until the upload task finishes, and returns the count of retried requests of
+// the upload if retries are asked for in the metadata.
+func (f *FileClient) CheckUpload(actionMetadata ActionMetadata) (int64, error) {
 	conn, err := net.Dial("tcp", f.addr())
 	if err != nil {
 		fmt.Fprint(os.Stderr, "Failed to connect"+f.addr())
-		return err
+		return 0, err
 	}
 	defer conn.Close()
 	f.writeMagicNumber(conn)
@@ -103,13 +110,16 @@ func (f *FileClient) Check(actionMetadata ActionMetadata) error {
 	for {
 		cnt, err := conn.Read(buf)
 		if err != nil {
-			return err
+			return 0, err
 		}
 		if cnt == 1 {
 			if buf[0] == 0 {
-				return nil
+				if actionMetadata.UploadRetries == "" {
+					return 0, nil
+				}
+				return ReadInt64(conn)
 			} else if buf[0] == 1 {
-				return errors.New("task failed")
+				return 0, errors.New("task failed")
 			}
 		}
 	}
@@ -29,8 +29,10 @@ import (
 var ConfigFilepath = "/config/config.yaml"
 
 const (
-	SinkTypeOss  = "oss"
-	SinkTypeSftp = "sftp"
+	SinkTypeOss     = "oss"
+	SinkTypeSftp    = "sftp"
+	SinkTypeArchive = "archive"
+	SinkTypeS3      = "s3"
 )
 
 type OssSink struct {
@@ -47,11 +49,27 @@ type SftpSink struct {
 	Password string `json:"password,omitempty"`
 	RootPath string `json:"rootPath,omitempty"`
 }
+
+// ArchiveSink is an archival gateway served by an archive adapter, see remote.NewArchiveHandler.
+type ArchiveSink struct {
+	Url   string `json:"url,omitempty"`
+	Token string `json:"token,omitempty"`
+}
+
+// S3Sink is an S3 compatible object storage, e.g. AWS S3, MinIO or GCS with HMAC keys. The endpoint,
+// credential and bucket are the ones of OssSink.
+type S3Sink struct {
+	Region    string `json:"region,omitempty"`
+	PathStyle bool   `json:"pathStyle,omitempty"`
+}
+
 type Sink struct {
 	Name string `json:"name,omitempty"`
 	Type string `json:"type,omitempty"`
 	OssSink
 	SftpSink
+	ArchiveSink
+	S3Sink
 }
 
 type Config struct {
@@ -49,6 +49,13 @@ func TestConfig3(t *testing.T) {
 	g.Expect(sink.User).Should(BeEquivalentTo("admin"))
 	g.Expect(sink.Password).Should(BeEquivalentTo("xxxx"))
 	g.Expect(sink.RootPath).Should(BeEquivalentTo("/xxx"))
+
+	sink, err = GetSink("default", "s3")
+	g.Expect(err).Should(BeNil())
+	g.Expect(sink.Endpoint).Should(BeEquivalentTo("http://minio:9000"))
+	g.Expect(sink.Region).Should(BeEquivalentTo("us-west-2"))
+	g.Expect(sink.PathStyle).Should(BeTrue())
+	g.Expect(sink.Bucket).Should(BeEquivalentTo("backup"))
 }
 
 func TestConfig4(t *testing.T) {
@@ -73,7 +80,7 @@ func PrepareConfig() {
 		panic(err)
 	}
 	defer f.Close()
-	f.Write([]byte("sinks:\n  - name: default\n    type: oss\n    endpoint: xxx\n    accessKey: xxx\n    accessSecret: xxxxx\n    bucket: xxx\n  - name: default\n    type: sftp\n    host: xxxxx\n    port: 22\n    user: admin\n    password: xxxx\n    rootPath: /xxx"))
+	f.Write([]byte("sinks:\n  - name: default\n    type: oss\n    endpoint: xxx\n    accessKey: xxx\n    accessSecret: xxxxx\n    bucket: xxx\n  - name: default\n    type: sftp\n    host: xxxxx\n    port: 22\n    user: admin\n    password: xxxx\n    rootPath: /xxx\n  - name: default\n    type: s3\n    endpoint: http://minio:9000\n    region: us-west-2\n    pathStyle: true\n    bucket: backup"))
 	InitConfig()
 }
 
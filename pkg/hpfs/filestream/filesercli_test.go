@@ -19,11 +19,16 @@ package filestream
 import (
 	"archive/tar"
 	"bytes"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/binary"
 	"github.com/google/uuid"
 	. "github.com/onsi/gomega"
 	"io"
+	"math/rand"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -296,6 +301,46 @@ func TestDownloadLocal(t *testing.T) {
 	checkFile(g)
 }
 
+func TestDownloadLocalResume(t *testing.T) {
+	g := NewGomegaWithT(t)
+	flowControl := NewFlowControl(FlowControlConfig{
+		MaxFlow:    1 << 40,
+		TotalFlow:  1 << 40,
+		MinFlow:    1,
+		BufferSize: 1 << 9,
+	})
+	flowControl.Start()
+	defer flowControl.Stop()
+	fileServer := NewFileServer("127.0.0.1", 22222, t.TempDir(), flowControl)
+
+	data := make([]byte, 1<<16)
+	rand.New(rand.NewSource(1)).Read(data)
+	source := filepath.Join(t.TempDir(), "full.xbstream")
+	g.Expect(os.WriteFile(source, data, 0644)).Should(BeNil())
+
+	for _, offset := range []int{0, 1000, len(data)} {
+		// The partially downloaded file which the download is resumed after.
+		local := bytes.NewBuffer(append([]byte{}, data[:offset]...))
+		stream := &bytes.Buffer{}
+		err := fileServer.processDownloadLocal(fileServer.logger, ActionMetadata{
+			Action:   DownloadLocal,
+			Filepath: source,
+			Offset:   strconv.Itoa(offset),
+		}, stream)
+		g.Expect(err).Should(BeNil())
+		g.Expect(binary.BigEndian.Uint64(stream.Next(8))).Should(BeEquivalentTo(len(data) - offset))
+		local.Write(stream.Bytes())
+		g.Expect(sha256.Sum256(local.Bytes())).Should(Equal(sha256.Sum256(data)))
+	}
+
+	err := fileServer.processDownloadLocal(fileServer.logger, ActionMetadata{
+		Action:   DownloadLocal,
+		Filepath: source,
+		Offset:   strconv.Itoa(len(data) + 1),
+	}, &bytes.Buffer{})
+	g.Expect(err).ShouldNot(BeNil())
+}
+
 func TestDownloadRemote(t *testing.T) {
 	g := NewGomegaWithT(t)
 	fileServer := startFileServer()
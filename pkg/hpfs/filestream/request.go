@@ -21,15 +21,22 @@ import "strings"
 type Action string
 
 const (
-	UploadLocal    Action = "uploadLocal"
-	UploadRemote   Action = "uploadRemote"
-	DownloadLocal  Action = "downloadLocal"
-	DownloadRemote Action = "downloadRemote"
-	UploadOss      Action = "uploadOss"
-	DownloadOss    Action = "downloadOss"
-	CheckTask      Action = "CheckTask"
-	UploadSsh      Action = "uploadSsh"
-	DownloadSsh    Action = "DownloadSsh"
+	UploadLocal     Action = "uploadLocal"
+	UploadRemote    Action = "uploadRemote"
+	DownloadLocal   Action = "downloadLocal"
+	DownloadRemote  Action = "downloadRemote"
+	UploadOss       Action = "uploadOss"
+	DownloadOss     Action = "downloadOss"
+	CheckTask       Action = "CheckTask"
+	UploadSsh       Action = "uploadSsh"
+	DownloadSsh     Action = "DownloadSsh"
+	SignOss         Action = "signOss"
+	CopyOss         Action = "copyOss"
+	ThawOss         Action = "thawOss"
+	UploadArchive   Action = "uploadArchive"
+	DownloadArchive Action = "downloadArchive"
+	UploadS3        Action = "uploadS3"
+	DownloadS3      Action = "downloadS3"
 )
 
 const (
@@ -40,7 +47,7 @@ const (
 
 const (
 	MetaDataLenLen              = 4
-	MetaFiledLen                = 10
+	MetaFiledLen                = 15
 	MetadataActionOffset        = 0
 	MetadataInstanceIdOffset    = 1
 	MetadataFilenameOffset      = 2
@@ -51,6 +58,11 @@ const (
 	MetadataSinkOffset          = 7
 	MetadataRequestIdOffset     = 8
 	MetadataOssBufferSizeOffset = 9
+	MetadataOffsetOffset        = 10
+	MetadataStorageClassOffset  = 11
+	MetadataUploadThreadsOffset = 12
+	MetadataUploadRetriesOffset = 13
+	MetadataUploadBackoffOffset = 14
 )
 
 var ActionLocal2Remote2 = map[Action]Action{
@@ -71,9 +83,14 @@ type ActionMetadata struct {
 	Sink          string `json:"sink,omitempty"`
 	RequestId     string `json:"requestId,omitempty"`
 	OssBufferSize string `json:"ossBufferSize,omitempty"`
+	Offset        string `json:"offset,omitempty"`
+	StorageClass  string `json:"storageClass,omitempty"`
+	UploadThreads string `json:"uploadThreads,omitempty"`
+	UploadRetries string `json:"uploadRetries,omitempty"`
+	UploadBackoff string `json:"uploadBackoff,omitempty"`
 	redirect      bool
 }
 
 func (action *ActionMetadata) ToString() string {
-	return strings.Join([]string{string(action.Action), action.InstanceId, action.Filename, action.RedirectAddr, action.Filepath, action.RetentionTime, action.Stream, action.Sink, action.RequestId, action.OssBufferSize}, ",")
+	return strings.Join([]string{string(action.Action), action.InstanceId, action.Filename, action.RedirectAddr, action.Filepath, action.RetentionTime, action.Stream, action.Sink, action.RequestId, action.OssBufferSize, action.Offset, action.StorageClass, action.UploadThreads, action.UploadRetries, action.UploadBackoff}, ",")
 }
@@ -34,6 +34,7 @@ import (
 	"os"
 	"path/filepath"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -53,6 +54,10 @@ var OssParams = map[string]string{"write_len": "true"}
 
 var TaskMap = sync.Map{}
 
+// TaskRetries records the count of retried requests of the upload tasks, reported to the clients
+// asking for retries on check.
+var TaskRetries = sync.Map{}
+
 type SftpConfigType struct {
 	Host      string
 	Port      int
@@ -156,12 +161,30 @@ func (f *FileServer) handleRequest(conn net.Conn) error {
 		f.processTaskResult(err, metadata)
 	case strings.ToLower(string(DownloadOss)):
 		f.processDownloadOss(logger, metadata, conn)
+	case strings.ToLower(string(SignOss)):
+		f.processSignOss(logger, metadata, conn)
+	case strings.ToLower(string(CopyOss)):
+		f.processCopyOss(logger, metadata, conn)
+	case strings.ToLower(string(ThawOss)):
+		f.processThawOss(logger, metadata, conn)
 	case strings.ToLower(string(UploadSsh)):
 		f.markTask(logger, metadata, TaskStateDoing)
 		err := f.processUploadSsh(logger, metadata, conn)
 		f.processTaskResult(err, metadata)
 	case strings.ToLower(string(DownloadSsh)):
 		f.processDownloadSsh(logger, metadata, conn)
+	case strings.ToLower(string(UploadArchive)):
+		f.markTask(logger, metadata, TaskStateDoing)
+		err := f.processUploadArchive(logger, metadata, conn)
+		f.processTaskResult(err, metadata)
+	case strings.ToLower(string(DownloadArchive)):
+		f.processDownloadArchive(logger, metadata, conn)
+	case strings.ToLower(string(UploadS3)):
+		f.markTask(logger, metadata, TaskStateDoing)
+		err := f.processUploadS3(logger, metadata, conn)
+		f.processTaskResult(err, metadata)
+	case strings.ToLower(string(DownloadS3)):
+		f.processDownloadS3(logger, metadata, conn)
 	case strings.ToLower(string(CheckTask)):
 		f.processCheckTask(logger, metadata, conn)
 	default:
@@ -187,6 +210,7 @@ func (f *FileServer) processTaskResult(err error, metadata ActionMetadata) {
 func (f *FileServer) clearTaskLater(metadata ActionMetadata) {
 	time.Sleep(1 * time.Minute)
 	TaskMap.Delete(metadata.RequestId)
+	TaskRetries.Delete(metadata.RequestId)
 }
 
 func (f *FileServer) processCheckTask(logger logr.Logger, metadata ActionMetadata, conn net.Conn) {
@@ -204,6 +228,16 @@ func (f *FileServer) processCheckTask(logger logr.Logger, metadata ActionMetadat
 		}
 		if val == TaskStateSuccess {
 			conn.Write([]byte{0})
+			// Only the clients asking for retries read the count.
+			if metadata.UploadRetries != "" {
+				var retries int64
+				if r, ok := TaskRetries.Load(metadata.RequestId); ok {
+					retries = r.(int64)
+				}
+				retriesBytes := make([]byte, 8)
+				binary.BigEndian.PutUint64(retriesBytes, uint64(retries))
+				conn.Write(retriesBytes)
+			}
 			break
 		}
 		conn.Write([]byte{1})
@@ -313,7 +347,11 @@ func (f *FileServer) processDownloadSsh(logger logr.Logger, metadata ActionMetad
 		logger.Error(err, "Failed to stat file")
 		return err
 	}
-	size := fileInfo.Size()
+	size, err := seekToOffset(fd, fileInfo.Size(), metadata)
+	if err != nil {
+		logger.Error(err, "Failed to seek to offset", "offset", metadata.Offset)
+		return err
+	}
 	sizeBytes := make([]byte, 8)
 	binary.BigEndian.PutUint64(sizeBytes, uint64(size))
 	writer.Write(sizeBytes[:])
@@ -370,6 +408,18 @@ func (f *FileServer) processUploadOss(logger logr.Logger, metadata ActionMetadat
 	if metadata.OssBufferSize != "" {
 		nowOssParams["limit_reader_size"] = metadata.OssBufferSize
 	}
+	if metadata.StorageClass != "" {
+		nowOssParams["storage_class"] = metadata.StorageClass
+	}
+	if metadata.UploadThreads != "" {
+		nowOssParams["upload_threads"] = metadata.UploadThreads
+	}
+	if metadata.UploadRetries != "" {
+		nowOssParams["upload_retries"] = metadata.UploadRetries
+	}
+	if metadata.UploadBackoff != "" {
+		nowOssParams["upload_retry_backoff"] = metadata.UploadBackoff
+	}
 	nowOssParams["bucket"] = sink.Bucket
 	ossAuth := getOssAuth(*sink)
 	ft, err := fileService.UploadFile(ctx, reader, metadata.Filepath, ossAuth, nowOssParams)
@@ -378,6 +428,10 @@ func (f *FileServer) processUploadOss(logger logr.Logger, metadata ActionMetadat
 		return err
 	}
 	err = ft.Wait()
+	if rft, ok := ft.(remote.RetriedFileTask); ok && rft.Retries() > 0 {
+		logger.Info("Upload requests retried", "retries", rft.Retries())
+		TaskRetries.Store(metadata.RequestId, rft.Retries())
+	}
 	reader.Close()
 	if err != nil {
 		logger.Error(err, "Failed to upload file to oss after wait")
@@ -420,6 +474,9 @@ func (f *FileServer) processDownloadOss(logger logr.Logger, metadata ActionMetad
 	ctx := context.Background()
 	nowOssParams := polarxMap.MergeMap(map[string]string{}, OssParams, false).(map[string]string)
 	nowOssParams["bucket"] = sink.Bucket
+	if metadata.Offset != "" {
+		nowOssParams["offset"] = metadata.Offset
+	}
 	ossAuth := getOssAuth(*sink)
 	ft, err := fileService.DownloadFile(ctx, writer, metadata.Filepath, ossAuth, nowOssParams)
 	if err != nil {
@@ -436,6 +493,356 @@ func (f *FileServer) processDownloadOss(logger logr.Logger, metadata ActionMetad
 	return nil
 }
 
+func getArchiveAuth(sink Sink) map[string]string {
+	return map[string]string{
+		"url":   sink.Url,
+		"token": sink.Token,
+	}
+}
+
+func (f *FileServer) processUploadArchive(logger logr.Logger, metadata ActionMetadata, conn net.Conn) error {
+	sink, err := GetSink(metadata.Sink, SinkTypeArchive)
+	if err != nil {
+		logger.Error(err, "fail to get sink", "sinkName", metadata.Sink)
+		return err
+	}
+	fileService, err := remote.GetFileService("archive")
+	if err != nil {
+		logger.Error(err, "Failed to get file service of archive")
+		return err
+	}
+	if metadata.Filepath == "" {
+		filepath := filepath.Join(metadata.InstanceId, metadata.Filename)
+		metadata.Filepath = filepath
+	}
+	reader, writer := io.Pipe()
+	defer func() {
+		reader.Close()
+		writer.Close()
+	}()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer func() {
+			writer.Close()
+			wg.Done()
+		}()
+		len, _ := f.flowControl.LimitFlow(conn, writer, conn)
+		logger.Info("limitFlow", "len", len)
+	}()
+	ft, err := fileService.UploadFile(context.Background(), reader, metadata.Filepath, getArchiveAuth(*sink), nil)
+	if err != nil {
+		logger.Error(err, "Failed to upload file to archive")
+		return err
+	}
+	err = ft.Wait()
+	reader.Close()
+	if err != nil {
+		logger.Error(err, "Failed to upload file to archive after wait")
+		return err
+	}
+	wg.Wait()
+	return nil
+}
+
+func (f *FileServer) processDownloadArchive(logger logr.Logger, metadata ActionMetadata, conn net.Conn) error {
+	sink, err := GetSink(metadata.Sink, SinkTypeArchive)
+	if err != nil {
+		logger.Error(err, "fail to get sink", "sinkName", metadata.Sink)
+		return err
+	}
+	fileService, err := remote.GetFileService("archive")
+	if err != nil {
+		logger.Error(err, "Failed to get file service of archive")
+		return err
+	}
+	if metadata.Filepath == "" {
+		filepath := filepath.Join(metadata.InstanceId, metadata.Filename)
+		metadata.Filepath = filepath
+	}
+	reader, writer := io.Pipe()
+	defer func() {
+		writer.Close()
+		reader.Close()
+	}()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer func() {
+			reader.Close()
+			wg.Done()
+		}()
+		len, _ := f.flowControl.LimitFlow(reader, conn, nil)
+		logger.Info("limitFlow", "len", len)
+	}()
+	ft, err := fileService.DownloadFile(context.Background(), writer, metadata.Filepath, getArchiveAuth(*sink), downloadParamsOf(metadata))
+	if err != nil {
+		logger.Error(err, "Failed to download file from archive")
+		return err
+	}
+	err = ft.Wait()
+	writer.Close()
+	if err != nil {
+		logger.Error(err, "Failed to download file from archive")
+		return err
+	}
+	wg.Wait()
+	return nil
+}
+
+func getS3Auth(sink Sink) map[string]string {
+	return map[string]string{
+		"endpoint":      sink.Endpoint,
+		"region":        sink.Region,
+		"access_key":    sink.AccessKey,
+		"access_secret": sink.AccessSecret,
+	}
+}
+
+func getS3Params(sink Sink) map[string]string {
+	return map[string]string{
+		"bucket":     sink.Bucket,
+		"path_style": strconv.FormatBool(sink.PathStyle),
+	}
+}
+
+func (f *FileServer) processUploadS3(logger logr.Logger, metadata ActionMetadata, conn net.Conn) error {
+	sink, err := GetSink(metadata.Sink, SinkTypeS3)
+	if err != nil {
+		logger.Error(err, "fail to get sink", "sinkName", metadata.Sink)
+		return err
+	}
+	fileService, err := remote.GetFileService("s3")
+	if err != nil {
+		logger.Error(err, "Failed to get file service of s3")
+		return err
+	}
+	if metadata.Filepath == "" {
+		filepath := filepath.Join(metadata.InstanceId, metadata.Filename)
+		metadata.Filepath = filepath
+	}
+	reader, writer := io.Pipe()
+	defer func() {
+		reader.Close()
+		writer.Close()
+	}()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer func() {
+			writer.Close()
+			wg.Done()
+		}()
+		len, _ := f.flowControl.LimitFlow(conn, writer, conn)
+		logger.Info("limitFlow", "len", len)
+	}()
+	params := getS3Params(*sink)
+	if metadata.UploadThreads != "" {
+		params["upload_threads"] = metadata.UploadThreads
+	}
+	if metadata.UploadRetries != "" {
+		params["upload_retries"] = metadata.UploadRetries
+	}
+	if metadata.UploadBackoff != "" {
+		params["upload_retry_backoff"] = metadata.UploadBackoff
+	}
+	ft, err := fileService.UploadFile(context.Background(), reader, metadata.Filepath, getS3Auth(*sink), params)
+	if err != nil {
+		logger.Error(err, "Failed to upload file to s3")
+		return err
+	}
+	err = ft.Wait()
+	if rft, ok := ft.(remote.RetriedFileTask); ok && rft.Retries() > 0 {
+		logger.Info("Upload requests retried", "retries", rft.Retries())
+		TaskRetries.Store(metadata.RequestId, rft.Retries())
+	}
+	reader.Close()
+	if err != nil {
+		logger.Error(err, "Failed to upload file to s3 after wait")
+		return err
+	}
+	wg.Wait()
+	return nil
+}
+
+func (f *FileServer) processDownloadS3(logger logr.Logger, metadata ActionMetadata, conn net.Conn) error {
+	sink, err := GetSink(metadata.Sink, SinkTypeS3)
+	if err != nil {
+		logger.Error(err, "fail to get sink", "sinkName", metadata.Sink)
+		return err
+	}
+	fileService, err := remote.GetFileService("s3")
+	if err != nil {
+		logger.Error(err, "Failed to get file service of s3")
+		return err
+	}
+	if metadata.Filepath == "" {
+		filepath := filepath.Join(metadata.InstanceId, metadata.Filename)
+		metadata.Filepath = filepath
+	}
+	reader, writer := io.Pipe()
+	defer func() {
+		writer.Close()
+		reader.Close()
+	}()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer func() {
+			reader.Close()
+			wg.Done()
+		}()
+		len, _ := f.flowControl.LimitFlow(reader, conn, nil)
+		logger.Info("limitFlow", "len", len)
+	}()
+	params := polarxMap.MergeMap(getS3Params(*sink), downloadParamsOf(metadata), false).(map[string]string)
+	ft, err := fileService.DownloadFile(context.Background(), writer, metadata.Filepath, getS3Auth(*sink), params)
+	if err != nil {
+		logger.Error(err, "Failed to download file from s3")
+		return err
+	}
+	err = ft.Wait()
+	writer.Close()
+	if err != nil {
+		logger.Error(err, "Failed to download file from s3")
+		return err
+	}
+	wg.Wait()
+	return nil
+}
+
+// processSignOss writes a pre-signed url of the file, prefixed with the length. The expiry of url
+// is carried by field RetentionTime of metadata.
+func (f *FileServer) processSignOss(logger logr.Logger, metadata ActionMetadata, conn net.Conn) error {
+	sink, err := GetSink(metadata.Sink, SinkTypeOss)
+	if err != nil {
+		logger.Error(err, "fail to get sink", "sinkName", metadata.Sink)
+		return err
+	}
+	fileService, err := remote.GetFileService("aliyun-oss")
+	if err != nil {
+		logger.Error(err, "Failed to get file service of aliyun-oss")
+		return err
+	}
+	signer, ok := fileService.(remote.FileUrlSigner)
+	if !ok {
+		err := errors.New("file service of aliyun-oss is unable to sign url")
+		logger.Error(err, "")
+		return err
+	}
+	if metadata.Filepath == "" {
+		filepath := filepath.Join(metadata.InstanceId, metadata.Filename)
+		metadata.Filepath = filepath
+	}
+	expiry, err := time.ParseDuration(metadata.RetentionTime)
+	if err != nil {
+		logger.Error(err, "Invalid expiry of signed url", "expiry", metadata.RetentionTime)
+		return err
+	}
+	nowOssParams := polarxMap.MergeMap(map[string]string{}, OssParams, false).(map[string]string)
+	nowOssParams["bucket"] = sink.Bucket
+	url, err := signer.SignFileUrl(context.Background(), metadata.Filepath, expiry, getOssAuth(*sink), nowOssParams)
+	if err != nil {
+		logger.Error(err, "Failed to sign url of oss file")
+		return err
+	}
+	lenBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(lenBytes, uint64(len(url)))
+	if _, err := conn.Write(lenBytes); err != nil {
+		return err
+	}
+	_, err = conn.Write([]byte(url))
+	return err
+}
+
+// processCopyOss copies the files under Filepath to Filename on the server side and writes the
+// number of files copied, prefixed with the length. The retention of copies is carried by
+// RetentionTime.
+func (f *FileServer) processCopyOss(logger logr.Logger, metadata ActionMetadata, conn net.Conn) error {
+	sink, err := GetSink(metadata.Sink, SinkTypeOss)
+	if err != nil {
+		logger.Error(err, "fail to get sink", "sinkName", metadata.Sink)
+		return err
+	}
+	fileService, err := remote.GetFileService("aliyun-oss")
+	if err != nil {
+		logger.Error(err, "Failed to get file service of aliyun-oss")
+		return err
+	}
+	copier, ok := fileService.(remote.FileCopier)
+	if !ok {
+		err := errors.New("file service of aliyun-oss is unable to copy files")
+		logger.Error(err, "")
+		return err
+	}
+	if metadata.Filepath == "" || metadata.Filename == "" || metadata.Filepath == metadata.Filename {
+		err := errors.New("invalid source or destination to copy")
+		logger.Error(err, "", "source", metadata.Filepath, "destination", metadata.Filename)
+		return err
+	}
+	nowOssParams := polarxMap.MergeMap(map[string]string{
+		"retention-time": metadata.RetentionTime,
+	}, OssParams, false).(map[string]string)
+	if nowOssParams["retention-time"] == "" {
+		delete(nowOssParams, "retention-time")
+	}
+	nowOssParams["bucket"] = sink.Bucket
+	copied, err := copier.CopyFiles(context.Background(), metadata.Filepath, metadata.Filename, getOssAuth(*sink), nowOssParams)
+	if err != nil {
+		logger.Error(err, "Failed to copy oss files", "copied", copied)
+		return err
+	}
+	result := strconv.FormatInt(copied, 10)
+	lenBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(lenBytes, uint64(len(result)))
+	if _, err := conn.Write(lenBytes); err != nil {
+		return err
+	}
+	_, err = conn.Write([]byte(result))
+	return err
+}
+
+// processThawOss requests the thaw of the archived files under Filepath and writes the number of
+// archived files and the ones still being thawed, separated by space and prefixed with the length.
+func (f *FileServer) processThawOss(logger logr.Logger, metadata ActionMetadata, conn net.Conn) error {
+	sink, err := GetSink(metadata.Sink, SinkTypeOss)
+	if err != nil {
+		logger.Error(err, "fail to get sink", "sinkName", metadata.Sink)
+		return err
+	}
+	fileService, err := remote.GetFileService("aliyun-oss")
+	if err != nil {
+		logger.Error(err, "Failed to get file service of aliyun-oss")
+		return err
+	}
+	thawer, ok := fileService.(remote.FileThawer)
+	if !ok {
+		err := errors.New("file service of aliyun-oss is unable to thaw files")
+		logger.Error(err, "")
+		return err
+	}
+	if metadata.Filepath == "" {
+		err := errors.New("invalid prefix to thaw")
+		logger.Error(err, "")
+		return err
+	}
+	nowOssParams := polarxMap.MergeMap(map[string]string{}, OssParams, false).(map[string]string)
+	nowOssParams["bucket"] = sink.Bucket
+	archived, thawing, err := thawer.ThawFiles(context.Background(), metadata.Filepath, getOssAuth(*sink), nowOssParams)
+	if err != nil {
+		logger.Error(err, "Failed to thaw oss files", "prefix", metadata.Filepath)
+		return err
+	}
+	result := strconv.FormatInt(archived, 10) + " " + strconv.FormatInt(thawing, 10)
+	lenBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(lenBytes, uint64(len(result)))
+	if _, err := conn.Write(lenBytes); err != nil {
+		return err
+	}
+	_, err = conn.Write([]byte(result))
+	return err
+}
+
 func (f *FileServer) processUploadRemote(logger logr.Logger, metadata ActionMetadata, conn net.Conn) error {
 	host, port := ParseNetAddr(metadata.RedirectAddr)
 	fileClient := NewFileClient(host, port, f.flowControl)
@@ -653,7 +1060,11 @@ func (f *FileServer) processDownloadLocal(logger logr.Logger, metadata ActionMet
 		logger.Error(err, "Failed to stat file")
 		return err
 	}
-	size := fileInfo.Size()
+	size, err := seekToOffset(fd, fileInfo.Size(), metadata)
+	if err != nil {
+		logger.Error(err, "Failed to seek to offset", "offset", metadata.Offset)
+		return err
+	}
 	sizeBytes := make([]byte, 8)
 	binary.BigEndian.PutUint64(sizeBytes, uint64(size))
 	writer.Write(sizeBytes[:])
@@ -662,6 +1073,35 @@ func (f *FileServer) processDownloadLocal(logger logr.Logger, metadata ActionMet
 	return nil
 }
 
+// downloadParamsOf returns the params of file services to download, i.e. with the length of content
+// written ahead as the client expects, from the offset to resume if specified.
+func downloadParamsOf(metadata ActionMetadata) map[string]string {
+	params := map[string]string{"write_len": "true"}
+	if metadata.Offset != "" {
+		params["offset"] = metadata.Offset
+	}
+	return params
+}
+
+// seekToOffset seeks the file of size to the offset to resume the download from, and returns the
+// size of content left.
+func seekToOffset(fd io.Seeker, size int64, metadata ActionMetadata) (int64, error) {
+	if metadata.Offset == "" {
+		return size, nil
+	}
+	offset, err := strconv.ParseInt(metadata.Offset, 10, 64)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid offset: %s", metadata.Offset)
+	}
+	if offset > size {
+		return 0, fmt.Errorf("offset %d exceeds file size %d", offset, size)
+	}
+	if _, err := fd.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return size - offset, nil
+}
+
 func (f *FileServer) processDownloadRemote(logger logr.Logger, metadata ActionMetadata, conn net.Conn) error {
 	host, port := ParseNetAddr(metadata.RedirectAddr)
 	fileClient := NewFileClient(host, port, f.flowControl)
@@ -705,6 +1145,10 @@ func (f *FileServer) readMetadata(reader io.Reader) (actionMeta ActionMetadata,
 		return
 	}
 	metadata := strings.Split(string(bytes), ",")
+	// Clients without the offset, storage class, upload threads or upload retry fields are still accepted.
+	for len(metadata) >= MetaFiledLen-5 && len(metadata) < MetaFiledLen {
+		metadata = append(metadata, "")
+	}
 	if len(metadata) != MetaFiledLen {
 		err = errors.New("invalid metadata")
 		return
@@ -720,6 +1164,11 @@ func (f *FileServer) readMetadata(reader io.Reader) (actionMeta ActionMetadata,
 		Sink:          metadata[MetadataSinkOffset],
 		RequestId:     metadata[MetadataRequestIdOffset],
 		OssBufferSize: metadata[MetadataOssBufferSizeOffset],
+		Offset:        metadata[MetadataOffsetOffset],
+		StorageClass:  metadata[MetadataStorageClassOffset],
+		UploadThreads: metadata[MetadataUploadThreadsOffset],
+		UploadRetries: metadata[MetadataUploadRetriesOffset],
+		UploadBackoff: metadata[MetadataUploadBackoffOffset],
 	}
 	return
 }
@@ -17,21 +17,31 @@ limitations under the License.
 package remote
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	polarxIo "github.com/alibaba/polardbx-operator/pkg/util/io"
 	"github.com/aliyun/aliyun-oss-go-sdk/oss"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 )
 
 const (
 	LimitedReaderSize = 1 << 20 * 600 //600MB
+	ParallelPartSize  = 1 << 20 * 64  //64MB
 	MaxPartSize       = (1 << 30) * 5 //5GB
+	CopyPartSize      = 1 << 30       //1GB
+
+	DefaultUploadRetryBackoff = time.Second
+	MaxUploadRetryBackoff     = 30 * time.Second
 )
 
 func init() {
@@ -62,6 +72,290 @@ func (o *aliyunOssFs) DeleteFile(ctx context.Context, path string, auth, params
 	return bucket.DeleteObject(path)
 }
 
+func (o *aliyunOssFs) SignFileUrl(ctx context.Context, path string, expiry time.Duration, auth, params map[string]string) (string, error) {
+	ossCtx, err := newAliyunOssContext(ctx, auth, params)
+	if err != nil {
+		return "", err
+	}
+
+	client, err := o.newClient(ossCtx)
+	if err != nil {
+		return "", fmt.Errorf("failed to create oss client: %w", err)
+	}
+	bucket, err := client.Bucket(ossCtx.bucket)
+	if err != nil {
+		return "", fmt.Errorf("failed to open oss bucket: %w", err)
+	}
+
+	return bucket.SignURL(path, oss.HTTPGet, int64(expiry.Seconds()))
+}
+
+func (o *aliyunOssFs) CopyFiles(ctx context.Context, srcPrefix, destPrefix string, auth, params map[string]string) (int64, error) {
+	ossCtx, err := newAliyunOssContext(ctx, auth, params)
+	if err != nil {
+		return 0, err
+	}
+
+	client, err := o.newClient(ossCtx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create oss client: %w", err)
+	}
+	bucket, err := client.Bucket(ossCtx.bucket)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open oss bucket: %w", err)
+	}
+
+	// The metadata is replaced so that the copies expire by their own retention.
+	opts := []oss.Option{oss.MetadataDirective(oss.MetaReplace)}
+	if ossCtx.retentionTime > 0 {
+		opts = append(opts, oss.Expires(time.Now().Add(ossCtx.retentionTime)))
+	}
+
+	srcPrefix = strings.TrimSuffix(srcPrefix, "/") + "/"
+	destPrefix = strings.TrimSuffix(destPrefix, "/") + "/"
+	var copied int64
+	listOpts := []oss.Option{oss.Prefix(srcPrefix), oss.MaxKeys(1000)}
+	for {
+		result, err := bucket.ListObjectsV2(listOpts...)
+		if err != nil {
+			return copied, fmt.Errorf("failed to list oss objects: %w", err)
+		}
+		for _, object := range result.Objects {
+			destKey := destPrefix + strings.TrimPrefix(object.Key, srcPrefix)
+			// Single copy is limited to 5GB, larger ones are copied by parts.
+			if object.Size > CopyPartSize {
+				err = bucket.CopyFile(ossCtx.bucket, object.Key, destKey, CopyPartSize, opts...)
+			} else {
+				_, err = bucket.CopyObject(object.Key, destKey, opts...)
+			}
+			if err != nil {
+				return copied, fmt.Errorf("failed to copy oss object %s: %w", object.Key, err)
+			}
+			copied++
+		}
+		if !result.IsTruncated {
+			return copied, nil
+		}
+		listOpts = []oss.Option{oss.Prefix(srcPrefix), oss.MaxKeys(1000), oss.ContinuationToken(result.NextContinuationToken)}
+	}
+}
+
+func (o *aliyunOssFs) DeleteFiles(ctx context.Context, prefix string, auth, params map[string]string) (int64, error) {
+	ossCtx, err := newAliyunOssContext(ctx, auth, params)
+	if err != nil {
+		return 0, err
+	}
+
+	client, err := o.newClient(ossCtx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create oss client: %w", err)
+	}
+	bucket, err := client.Bucket(ossCtx.bucket)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open oss bucket: %w", err)
+	}
+
+	var deleted int64
+	listOpts := []oss.Option{oss.Prefix(prefix), oss.MaxKeys(1000)}
+	for {
+		result, err := bucket.ListObjectsV2(listOpts...)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to list oss objects: %w", err)
+		}
+		keys := make([]string, 0, len(result.Objects))
+		for _, object := range result.Objects {
+			keys = append(keys, object.Key)
+		}
+		if len(keys) > 0 {
+			if _, err := bucket.DeleteObjects(keys, oss.DeleteObjectsQuiet(true)); err != nil {
+				return deleted, fmt.Errorf("failed to delete oss objects: %w", err)
+			}
+			deleted += int64(len(keys))
+		}
+		if !result.IsTruncated {
+			return deleted, nil
+		}
+		listOpts = []oss.Option{oss.Prefix(prefix), oss.MaxKeys(1000), oss.ContinuationToken(result.NextContinuationToken)}
+	}
+}
+
+func (o *aliyunOssFs) StatFiles(ctx context.Context, prefix string, auth, params map[string]string) ([]FileStat, error) {
+	bucket, err := o.openBucket(ctx, auth, params)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]FileStat, 0)
+	listOpts := []oss.Option{oss.Prefix(prefix), oss.MaxKeys(1000)}
+	for {
+		result, err := bucket.ListObjectsV2(listOpts...)
+		if err != nil {
+			return files, fmt.Errorf("failed to list oss objects: %w", err)
+		}
+		for _, object := range result.Objects {
+			files = append(files, FileStat{Path: object.Key, Size: object.Size, StorageClass: object.StorageClass})
+		}
+		if !result.IsTruncated {
+			return files, nil
+		}
+		listOpts = []oss.Option{oss.Prefix(prefix), oss.MaxKeys(1000), oss.ContinuationToken(result.NextContinuationToken)}
+	}
+}
+
+func (o *aliyunOssFs) ProbeStorage(ctx context.Context, prefix string, auth, params map[string]string) error {
+	bucket, err := o.openBucket(ctx, auth, params)
+	if err != nil {
+		return err
+	}
+	if _, err := bucket.ListObjectsV2(oss.Prefix(prefix), oss.MaxKeys(1)); err != nil {
+		return fmt.Errorf("failed to list oss objects: %w", err)
+	}
+	return nil
+}
+
+func (o *aliyunOssFs) GetWormRetention(ctx context.Context, auth, params map[string]string) (WormRetention, error) {
+	ossCtx, err := newAliyunOssContext(ctx, auth, params)
+	if err != nil {
+		return WormRetention{}, err
+	}
+	client, err := o.newClient(ossCtx)
+	if err != nil {
+		return WormRetention{}, fmt.Errorf("failed to create oss client: %w", err)
+	}
+	worm, err := client.GetBucketWorm(ossCtx.bucket)
+	if err != nil {
+		var serviceErr oss.ServiceError
+		if errors.As(err, &serviceErr) && serviceErr.Code == "NoSuchWORMConfiguration" {
+			return WormRetention{}, nil
+		}
+		return WormRetention{}, fmt.Errorf("failed to get bucket worm: %w", err)
+	}
+	// The retention policy in progress can still be aborted, only the locked one is enforced.
+	return WormRetention{
+		Locked: worm.State == "Locked",
+		Period: time.Duration(worm.RetentionPeriodInDays) * 24 * time.Hour,
+	}, nil
+}
+
+func (o *aliyunOssFs) openBucket(ctx context.Context, auth, params map[string]string) (*oss.Bucket, error) {
+	ossCtx, err := newAliyunOssContext(ctx, auth, params)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := o.newClient(ossCtx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create oss client: %w", err)
+	}
+	bucket, err := client.Bucket(ossCtx.bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open oss bucket: %w", err)
+	}
+	return bucket, nil
+}
+
+func (o *aliyunOssFs) ListMultipartUploads(ctx context.Context, prefix string, auth, params map[string]string) ([]MultipartUpload, error) {
+	bucket, err := o.openBucket(ctx, auth, params)
+	if err != nil {
+		return nil, err
+	}
+
+	uploads := make([]MultipartUpload, 0)
+	listOpts := []oss.Option{oss.Prefix(prefix), oss.MaxUploads(1000)}
+	for {
+		result, err := bucket.ListMultipartUploads(listOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list oss multipart uploads: %w", err)
+		}
+		for _, upload := range result.Uploads {
+			uploads = append(uploads, MultipartUpload{
+				Path:      upload.Key,
+				UploadId:  upload.UploadID,
+				Initiated: upload.Initiated,
+			})
+		}
+		if !result.IsTruncated {
+			return uploads, nil
+		}
+		listOpts = []oss.Option{oss.Prefix(prefix), oss.MaxUploads(1000),
+			oss.KeyMarker(result.NextKeyMarker), oss.UploadIDMarker(result.NextUploadIDMarker)}
+	}
+}
+
+func (o *aliyunOssFs) AbortMultipartUpload(ctx context.Context, upload MultipartUpload, auth, params map[string]string) error {
+	bucket, err := o.openBucket(ctx, auth, params)
+	if err != nil {
+		return err
+	}
+	err = bucket.AbortMultipartUpload(oss.InitiateMultipartUploadResult{
+		Bucket:   bucket.BucketName,
+		Key:      upload.Path,
+		UploadID: upload.UploadId,
+	})
+	if serviceErr, ok := err.(oss.ServiceError); ok && serviceErr.Code == "NoSuchUpload" {
+		return nil
+	}
+	return err
+}
+
+func isOssArchiveStorageClass(storageClass string) bool {
+	return storageClass == string(oss.StorageArchive) || storageClass == string(oss.StorageColdArchive)
+}
+
+func (o *aliyunOssFs) ThawFiles(ctx context.Context, prefix string, auth, params map[string]string) (int64, int64, error) {
+	ossCtx, err := newAliyunOssContext(ctx, auth, params)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	client, err := o.newClient(ossCtx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to create oss client: %w", err)
+	}
+	bucket, err := client.Bucket(ossCtx.bucket)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to open oss bucket: %w", err)
+	}
+
+	var archived, thawing int64
+	listOpts := []oss.Option{oss.Prefix(prefix), oss.MaxKeys(1000)}
+	for {
+		result, err := bucket.ListObjectsV2(listOpts...)
+		if err != nil {
+			return archived, thawing, fmt.Errorf("failed to list oss objects: %w", err)
+		}
+		for _, object := range result.Objects {
+			if !isOssArchiveStorageClass(object.StorageClass) {
+				continue
+			}
+			archived++
+			meta, err := bucket.GetObjectDetailedMeta(object.Key)
+			if err != nil {
+				return archived, thawing, fmt.Errorf("failed to get meta of oss object %s: %w", object.Key, err)
+			}
+			// The header is absent if not requested, and ongoing-request="false" once thawed.
+			restore := meta.Get("X-Oss-Restore")
+			if len(restore) == 0 {
+				if object.StorageClass == string(oss.StorageColdArchive) {
+					err = bucket.RestoreObjectDetail(object.Key, oss.RestoreConfiguration{Days: 1, Tier: string(oss.RestoreStandard)})
+				} else {
+					err = bucket.RestoreObject(object.Key)
+				}
+				if err != nil {
+					return archived, thawing, fmt.Errorf("failed to thaw oss object %s: %w", object.Key, err)
+				}
+				thawing++
+			} else if strings.Contains(restore, `ongoing-request="true"`) {
+				thawing++
+			}
+		}
+		if !result.IsTruncated {
+			return archived, thawing, nil
+		}
+		listOpts = []oss.Option{oss.Prefix(prefix), oss.MaxKeys(1000), oss.ContinuationToken(result.NextContinuationToken)}
+	}
+}
+
 type ossProgressListener4FileTask struct {
 	*fileTask
 }
@@ -95,6 +389,9 @@ func (o *aliyunOssFs) UploadFileNormally(ctx context.Context, reader io.Reader,
 		if ossCtx.retentionTime > 0 {
 			opts = append(opts, oss.Expires(time.Now().Add(ossCtx.retentionTime)))
 		}
+		if len(ossCtx.storageClass) > 0 {
+			opts = append(opts, oss.ObjectStorageClass(oss.StorageClassType(ossCtx.storageClass)))
+		}
 
 		ft.complete(bucket.PutObject(path, reader, opts...))
 	}()
@@ -135,10 +432,18 @@ func (o *aliyunOssFs) UploadFile(ctx context.Context, reader io.Reader, path str
 		if ossCtx.retentionTime > 0 {
 			opts = append(opts, oss.Expires(time.Now().Add(ossCtx.retentionTime)))
 		}
+		if len(ossCtx.storageClass) > 0 {
+			opts = append(opts, oss.ObjectStorageClass(oss.StorageClassType(ossCtx.storageClass)))
+		}
 		if err != nil {
 			ft.complete(err)
 			return
 		}
+		// Retries need the parts buffered, they are uploaded as in parallel then.
+		if ossCtx.uploadThreads > 1 || ossCtx.uploadRetries > 0 {
+			ft.complete(uploadPartsInParallel(bucket, reader, path, ossCtx, &ft.retries, opts))
+			return
+		}
 
 		var partIndex int = 1
 		imur, err := bucket.InitiateMultipartUpload(path, opts...)
@@ -240,6 +545,165 @@ func (o *aliyunOssFs) UploadFile(ctx context.Context, reader io.Reader, path str
 	return ft, nil
 }
 
+// parallelPartSizeOf returns the size of the part, which is doubled every 2000 parts so that
+// streams up to ~4TB fit in the 10000 parts limit.
+func parallelPartSizeOf(partNumber int) int64 {
+	return partSizeOf(ParallelPartSize, partNumber)
+}
+
+// partSizeOf returns the size of the part starting from the base size, see parallelPartSizeOf.
+func partSizeOf(base int64, partNumber int) int64 {
+	size := base
+	for n := partNumber - 1; n >= 2000 && size < MaxPartSize; n -= 2000 {
+		size *= 2
+	}
+	return size
+}
+
+// IsThrottled tells whether the request is rejected since the storage throttles the requests, e.g.
+// the bucket is over its QPS limit. The request is expected to succeed after backing off.
+func IsThrottled(err error) bool {
+	var s3Err *S3Error
+	if errors.As(err, &s3Err) {
+		return s3Err.StatusCode == 429 || s3Err.StatusCode == 503 || s3Err.Code == "SlowDown"
+	}
+	var serviceErr oss.ServiceError
+	if !errors.As(err, &serviceErr) {
+		return false
+	}
+	return serviceErr.StatusCode == 429 || serviceErr.StatusCode == 503 || serviceErr.Code == "SlowDown"
+}
+
+// IsImmutable tells whether the request is rejected since the object is locked by the WORM retention
+// policy of the bucket. The object can't be deleted until the retention period ends.
+func IsImmutable(err error) bool {
+	var serviceErr oss.ServiceError
+	if !errors.As(err, &serviceErr) {
+		return false
+	}
+	return serviceErr.Code == "FileImmutable" || serviceErr.Code == "ObjectImmutable"
+}
+
+// retryWithBackoff calls the function until it succeeds or the retries are used up. The backoff
+// is doubled after each retry, up to MaxUploadRetryBackoff. The retries are counted into retried.
+func retryWithBackoff(retries int, backoff time.Duration, retried *int64, do func() error) error {
+	err := do()
+	for i := 0; err != nil && i < retries; i++ {
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > MaxUploadRetryBackoff {
+			backoff = MaxUploadRetryBackoff
+		}
+		atomic.AddInt64(retried, 1)
+		err = do()
+	}
+	return err
+}
+
+// uploadPartsInParallel uploads the stream in parts with the configured number of routines, one at
+// least. Each routine buffers a part in memory, and the parts have the exact size, so no padding is
+// needed. The failed requests are retried with backoff and counted into retried.
+func uploadPartsInParallel(bucket *oss.Bucket, reader io.Reader, path string, ossCtx *aliyunOssContext, retried *int64, opts []oss.Option) error {
+	threads := ossCtx.uploadThreads
+	if threads < 1 {
+		threads = 1
+	}
+	retry := func(do func() error) error {
+		return retryWithBackoff(ossCtx.uploadRetries, ossCtx.uploadRetryBackoff, retried, do)
+	}
+
+	var imur oss.InitiateMultipartUploadResult
+	err := retry(func() (err error) {
+		imur, err = bucket.InitiateMultipartUpload(path, opts...)
+		return
+	})
+	if err != nil {
+		return err
+	}
+	completed := false
+	defer func() {
+		if !completed {
+			bucket.AbortMultipartUpload(imur)
+		}
+	}()
+
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		uploadErr error
+		total     int64
+	)
+	parts := make([]oss.UploadPart, 0)
+	sem := make(chan struct{}, threads)
+	for partNumber := 1; ; partNumber++ {
+		sem <- struct{}{}
+		mu.Lock()
+		failed := uploadErr != nil
+		mu.Unlock()
+		if failed {
+			<-sem
+			break
+		}
+
+		buf := make([]byte, parallelPartSizeOf(partNumber))
+		n, readErr := io.ReadFull(reader, buf)
+		if n > 0 {
+			total += int64(n)
+			wg.Add(1)
+			go func(buf []byte, partNumber int) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				var part oss.UploadPart
+				err := retry(func() (err error) {
+					part, err = bucket.UploadPart(imur, bytes.NewReader(buf), int64(len(buf)), partNumber)
+					return
+				})
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					if uploadErr == nil {
+						uploadErr = fmt.Errorf("failed to upload part %d: %w", partNumber, err)
+					}
+					return
+				}
+				parts = append(parts, part)
+			}(buf[:n], partNumber)
+		} else {
+			<-sem
+		}
+
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			wg.Wait()
+			return readErr
+		}
+	}
+	wg.Wait()
+	if uploadErr != nil {
+		return uploadErr
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].PartNumber < parts[j].PartNumber
+	})
+	err = retry(func() error {
+		_, err := bucket.CompleteMultipartUpload(imur, parts, opts...)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	completed = true
+	SetTags(bucket, path, total)
+	return nil
+}
+
 func SetTags(bucket *oss.Bucket, objKey string, actualSize int64) {
 	uploaderTag := oss.Tag{
 		Key:   "uploader",
@@ -312,6 +776,9 @@ func (o *aliyunOssFs) UploadFileTmpFile(ctx context.Context, reader io.Reader, p
 		if ossCtx.retentionTime > 0 {
 			opts = append(opts, oss.Expires(time.Now().Add(ossCtx.retentionTime)))
 		}
+		if len(ossCtx.storageClass) > 0 {
+			opts = append(opts, oss.ObjectStorageClass(oss.StorageClassType(ossCtx.storageClass)))
+		}
 		imur, err := bucket.InitiateMultipartUpload(path, opts...)
 		complete := false
 		if err != nil {
@@ -435,10 +902,23 @@ func (o *aliyunOssFs) DownloadFile(ctx context.Context, writer io.Writer, path s
 			if actualSize != -1 {
 				bytesCount = actualSize
 			}
+			if ossCtx.offset > bytesCount {
+				ft.complete(fmt.Errorf("offset %d exceeds object size %d", ossCtx.offset, bytesCount))
+				return
+			}
+			bytesCount -= ossCtx.offset
 			polarxIo.WriteUint64(writer, uint64(bytesCount))
+			if bytesCount == 0 {
+				ft.complete(nil)
+				return
+			}
 		}
 
-		r, err := bucket.GetObject(path)
+		var opts []oss.Option
+		if ossCtx.offset > 0 {
+			opts = append(opts, oss.NormalizedRange(fmt.Sprintf("%d-", ossCtx.offset)))
+		}
+		r, err := bucket.GetObject(path, opts...)
 		if err != nil {
 			ft.complete(fmt.Errorf("failed to get object: %w", err))
 			return
@@ -469,6 +949,12 @@ type aliyunOssContext struct {
 	writeLen      bool
 	bufferSize    int64
 	useTmpFile    bool
+	offset        int64
+	storageClass  string
+	uploadThreads int
+
+	uploadRetries      int
+	uploadRetryBackoff time.Duration
 }
 
 func newAliyunOssContext(ctx context.Context, auth, params map[string]string) (*aliyunOssContext, error) {
@@ -496,15 +982,53 @@ func newAliyunOssContext(ctx context.Context, auth, params map[string]string) (*
 		}
 		useTmpFile = toUseTmpFile
 	}
+	var offset int64
+	if val, ok := params["offset"]; ok {
+		toOffset, err := strconv.ParseInt(val, 10, 64)
+		if err != nil || toOffset < 0 {
+			return nil, fmt.Errorf("invalid offset: %s", val)
+		}
+		offset = toOffset
+	}
+	var uploadThreads int
+	if val, ok := params["upload_threads"]; ok {
+		toUploadThreads, err := strconv.Atoi(val)
+		if err != nil || toUploadThreads < 0 {
+			return nil, fmt.Errorf("invalid upload threads: %s", val)
+		}
+		uploadThreads = toUploadThreads
+	}
+	var uploadRetries int
+	if val, ok := params["upload_retries"]; ok && val != "" {
+		toUploadRetries, err := strconv.Atoi(val)
+		if err != nil || toUploadRetries < 0 {
+			return nil, fmt.Errorf("invalid upload retries: %s", val)
+		}
+		uploadRetries = toUploadRetries
+	}
+	uploadRetryBackoff := DefaultUploadRetryBackoff
+	if val, ok := params["upload_retry_backoff"]; ok && val != "" {
+		d, err := time.ParseDuration(val)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid upload retry backoff: %s", val)
+		}
+		uploadRetryBackoff = d
+	}
 	ossCtx := &aliyunOssContext{
-		ctx:          ctx,
-		endpoint:     auth["endpoint"],
-		accessKey:    auth["access_key"],
-		accessSecret: auth["access_secret"],
-		bucket:       params["bucket"],
-		writeLen:     writeLen,
-		bufferSize:   bufferSize,
-		useTmpFile:   useTmpFile,
+		ctx:           ctx,
+		endpoint:      auth["endpoint"],
+		accessKey:     auth["access_key"],
+		accessSecret:  auth["access_secret"],
+		bucket:        params["bucket"],
+		writeLen:      writeLen,
+		bufferSize:    bufferSize,
+		useTmpFile:    useTmpFile,
+		offset:        offset,
+		storageClass:  params["storage_class"],
+		uploadThreads: uploadThreads,
+
+		uploadRetries:      uploadRetries,
+		uploadRetryBackoff: uploadRetryBackoff,
 	}
 
 	if t, ok := params["retention-time"]; ok {
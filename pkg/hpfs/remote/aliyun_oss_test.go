@@ -0,0 +1,67 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+func TestRetryWithBackoff(t *testing.T) {
+	var retried int64
+	calls := 0
+	err := retryWithBackoff(3, time.Millisecond, &retried, func() error {
+		if calls++; calls < 3 {
+			return errors.New("transient")
+		}
+		return nil
+	})
+	if err != nil || calls != 3 || retried != 2 {
+		t.Fatalf("expect succeeded after 2 retries, got err %v, calls %d, retried %d", err, calls, retried)
+	}
+
+	calls = 0
+	err = retryWithBackoff(2, time.Millisecond, &retried, func() error {
+		calls++
+		return errors.New("permanent")
+	})
+	if err == nil || calls != 3 || retried != 4 {
+		t.Fatalf("expect failed after 2 retries, got err %v, calls %d, retried %d", err, calls, retried)
+	}
+}
+
+func TestIsThrottled(t *testing.T) {
+	if !IsThrottled(fmt.Errorf("failed to delete oss objects: %w", oss.ServiceError{StatusCode: 503})) {
+		t.Fatal("expect wrapped 503 throttled")
+	}
+	if IsThrottled(oss.ServiceError{StatusCode: 403, Code: "AccessDenied"}) || IsThrottled(errors.New("timeout")) {
+		t.Fatal("expect others not throttled")
+	}
+}
+
+func TestIsImmutable(t *testing.T) {
+	if !IsImmutable(fmt.Errorf("failed to delete oss objects: %w", oss.ServiceError{StatusCode: 409, Code: "FileImmutable"})) {
+		t.Fatal("expect wrapped FileImmutable immutable")
+	}
+	if IsImmutable(oss.ServiceError{StatusCode: 503}) || IsImmutable(errors.New("timeout")) {
+		t.Fatal("expect others not immutable")
+	}
+}
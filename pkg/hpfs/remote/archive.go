@@ -0,0 +1,248 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// The archive file service talks to an archival gateway, e.g. an S3-to-tape gateway or a custom
+// archival API, through a small adapter serving the HTTP contract below. The path of objects is
+// relative and slash separated.
+//
+//	PUT    {url}/objects/{path}          uploads the object from the request body
+//	GET    {url}/objects/{path}          downloads the object, 404 if not found. A "Range: bytes={offset}-"
+//	                                     header may be honored with 206, otherwise the whole object is sent
+//	DELETE {url}/objects/{path}          deletes the object, 404 if not found
+//	GET    {url}/objects?prefix={prefix} lists the objects under the prefix, in json of ArchiveObjectList
+//
+// Requests carry "Authorization: Bearer {token}" if the token is configured. NewArchiveHandler
+// serves the contract with an ArchiveStore, which is all an adapter needs to implement.
+
+func init() {
+	MustRegisterFileService("archive", &archiveFs{})
+}
+
+// ArchiveObject is an object in the archival storage.
+type ArchiveObject struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// ArchiveObjectList is the response of listing objects.
+type ArchiveObjectList struct {
+	Objects []ArchiveObject `json:"objects"`
+}
+
+type archiveFs struct{}
+
+type archiveContext struct {
+	ctx   context.Context
+	url   string
+	token string
+}
+
+func newArchiveContext(ctx context.Context, auth, params map[string]string) (*archiveContext, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if len(auth["url"]) == 0 {
+		return nil, fmt.Errorf("url of archive adapter not specified")
+	}
+	return &archiveContext{
+		ctx:   ctx,
+		url:   strings.TrimSuffix(auth["url"], "/"),
+		token: auth["token"],
+	}, nil
+}
+
+func (a *archiveContext) objectUrl(objectPath string) string {
+	return a.url + "/objects/" + strings.TrimPrefix(path.Clean("/"+objectPath), "/")
+}
+
+func (a *archiveContext) do(method, url string, body io.Reader) (*http.Response, error) {
+	return a.doWithHeader(method, url, nil, body)
+}
+
+func (a *archiveContext) doWithHeader(method, url string, header http.Header, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(a.ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if len(a.token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+a.token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("archive adapter responds %s to %s %s: %s", resp.Status, method, url, strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
+
+func (a *archiveFs) DeleteFile(ctx context.Context, path string, auth, params map[string]string) error {
+	archiveCtx, err := newArchiveContext(ctx, auth, params)
+	if err != nil {
+		return err
+	}
+	resp, err := archiveCtx.do(http.MethodDelete, archiveCtx.objectUrl(path), nil)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (a *archiveFs) UploadFile(ctx context.Context, reader io.Reader, path string, auth, params map[string]string) (FileTask, error) {
+	archiveCtx, err := newArchiveContext(ctx, auth, params)
+	if err != nil {
+		return nil, err
+	}
+
+	ft := newFileTask(ctx)
+	go func() {
+		// The body is streamed, i.e. the size is unknown in advance.
+		resp, err := archiveCtx.do(http.MethodPut, archiveCtx.objectUrl(path), io.NopCloser(reader))
+		if err != nil {
+			ft.complete(fmt.Errorf("failed to upload file: %w", err))
+			return
+		}
+		ft.complete(resp.Body.Close())
+	}()
+	return ft, nil
+}
+
+func (a *archiveFs) DownloadFile(ctx context.Context, writer io.Writer, path string, auth, params map[string]string) (FileTask, error) {
+	archiveCtx, err := newArchiveContext(ctx, auth, params)
+	if err != nil {
+		return nil, err
+	}
+
+	download, err := parseDownloadParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	ft := newFileTask(ctx)
+	go func() {
+		if download.writeLen || download.offset > 0 {
+			size, err := archiveCtx.sizeOf(path)
+			if err != nil {
+				ft.complete(err)
+				return
+			}
+			left, err := download.writeLenFrom(writer, size)
+			if err != nil {
+				ft.complete(err)
+				return
+			}
+			if left == 0 {
+				ft.complete(nil)
+				return
+			}
+		}
+
+		var header http.Header
+		if download.offset > 0 {
+			header = http.Header{"Range": {fmt.Sprintf("bytes=%d-", download.offset)}}
+		}
+		resp, err := archiveCtx.doWithHeader(http.MethodGet, archiveCtx.objectUrl(path), header, nil)
+		if err != nil {
+			ft.complete(fmt.Errorf("failed to download file: %w", err))
+			return
+		}
+		defer resp.Body.Close()
+		// Adapters not honoring the range send the whole object, skip what's downloaded.
+		if download.offset > 0 && resp.StatusCode != http.StatusPartialContent {
+			if _, err := io.CopyN(io.Discard, resp.Body, download.offset); err != nil {
+				ft.complete(fmt.Errorf("failed to skip to offset: %w", err))
+				return
+			}
+		}
+		if _, err := io.Copy(writer, resp.Body); err != nil {
+			ft.complete(fmt.Errorf("failed to copy file: %w", err))
+			return
+		}
+		ft.complete(nil)
+	}()
+	return ft, nil
+}
+
+// ListFiles lists the objects under the prefix.
+func (a *archiveFs) ListFiles(ctx context.Context, prefix string, auth, params map[string]string) ([]ArchiveObject, error) {
+	archiveCtx, err := newArchiveContext(ctx, auth, params)
+	if err != nil {
+		return nil, err
+	}
+	return archiveCtx.list(prefix)
+}
+
+func (a *archiveContext) list(prefix string) ([]ArchiveObject, error) {
+	resp, err := a.do(http.MethodGet, a.url+"/objects?prefix="+url.QueryEscape(prefix), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var list ArchiveObjectList
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, fmt.Errorf("failed to decode object list: %w", err)
+	}
+	return list.Objects, nil
+}
+
+// sizeOf returns the size of the object from the listing, since the contract has no HEAD.
+func (a *archiveContext) sizeOf(objectPath string) (int64, error) {
+	objectPath = strings.TrimPrefix(path.Clean("/"+objectPath), "/")
+	objects, err := a.list(objectPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list archive objects: %w", err)
+	}
+	for _, object := range objects {
+		if object.Path == objectPath {
+			return object.Size, nil
+		}
+	}
+	return 0, fmt.Errorf("archive object not found: %s", objectPath)
+}
+
+func (a *archiveFs) DeleteFiles(ctx context.Context, prefix string, auth, params map[string]string) (int64, error) {
+	objects, err := a.ListFiles(ctx, prefix, auth, params)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list archive objects: %w", err)
+	}
+	var deleted int64
+	for _, object := range objects {
+		if err := a.DeleteFile(ctx, object.Path, auth, params); err != nil {
+			return deleted, fmt.Errorf("failed to delete archive object %s: %w", object.Path, err)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
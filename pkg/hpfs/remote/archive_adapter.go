@@ -0,0 +1,195 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remote
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ArchiveStore is the storage behind the archive adapter. Adapters of archival gateways only need
+// to implement the interface and serve it with NewArchiveHandler.
+type ArchiveStore interface {
+	Put(path string, reader io.Reader) error
+	Get(path string) (io.ReadCloser, error)
+	List(prefix string) ([]ArchiveObject, error)
+	// Delete deletes the object. Both Get and Delete must return an error satisfying
+	// errors.Is(err, fs.ErrNotExist) if the object doesn't exist.
+	Delete(path string) error
+}
+
+type archiveHandler struct {
+	store ArchiveStore
+	token string
+}
+
+// NewArchiveHandler returns a http handler serving the archive adapter contract with the store.
+// Requests are authorized with the token if it's not empty.
+func NewArchiveHandler(store ArchiveStore, token string) http.Handler {
+	return &archiveHandler{store: store, token: token}
+}
+
+func (h *archiveHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if len(h.token) > 0 && r.Header.Get("Authorization") != "Bearer "+h.token {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if r.URL.Path == "/objects" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		objects, err := h.store.List(r.URL.Query().Get("prefix"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(&ArchiveObjectList{Objects: objects})
+		return
+	}
+
+	objectPath := strings.TrimPrefix(r.URL.Path, "/objects/")
+	if objectPath == r.URL.Path || len(objectPath) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	var err error
+	switch r.Method {
+	case http.MethodPut:
+		err = h.store.Put(objectPath, r.Body)
+	case http.MethodGet:
+		var rc io.ReadCloser
+		if rc, err = h.store.Get(objectPath); err == nil {
+			defer rc.Close()
+			w.Header().Set("Content-Type", "application/octet-stream")
+			_, _ = io.Copy(w, rc)
+			return
+		}
+	case http.MethodDelete:
+		err = h.store.Delete(objectPath)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if errors.Is(err, fs.ErrNotExist) {
+		http.NotFound(w, r)
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+type dirArchiveStore struct {
+	root string
+}
+
+// NewDirArchiveStore returns an archive store keeping objects as files under the root directory,
+// e.g. a mounted tape library file system. It's also the reference of ArchiveStore.
+func NewDirArchiveStore(root string) ArchiveStore {
+	return &dirArchiveStore{root: root}
+}
+
+func (s *dirArchiveStore) pathOf(objectPath string) (string, error) {
+	p := path.Clean("/" + objectPath)
+	if p == "/" {
+		return "", errors.New("invalid object path: " + objectPath)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(p)), nil
+}
+
+func (s *dirArchiveStore) Put(objectPath string, reader io.Reader) error {
+	p, err := s.pathOf(objectPath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so that a broken upload leaves no partial object.
+	tmp := p + ".uploading"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, reader); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, p)
+}
+
+func (s *dirArchiveStore) Get(objectPath string) (io.ReadCloser, error) {
+	p, err := s.pathOf(objectPath)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(p)
+}
+
+func (s *dirArchiveStore) List(prefix string) ([]ArchiveObject, error) {
+	objects := make([]ArchiveObject, 0)
+	err := filepath.WalkDir(s.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasSuffix(p, ".uploading") {
+			return nil
+		}
+		rel, err := filepath.Rel(s.root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !strings.HasPrefix(rel, prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, ArchiveObject{Path: rel, Size: info.Size()})
+		return nil
+	})
+	return objects, err
+}
+
+func (s *dirArchiveStore) Delete(objectPath string) error {
+	p, err := s.pathOf(objectPath)
+	if err != nil {
+		return err
+	}
+	return os.Remove(p)
+}
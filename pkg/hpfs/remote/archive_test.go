@@ -0,0 +1,109 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remote
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"math/rand"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestArchiveFs_RoundTrip(t *testing.T) {
+	server := httptest.NewServer(NewArchiveHandler(NewDirArchiveStore(t.TempDir()), "secret"))
+	defer server.Close()
+
+	fs, err := GetFileService("archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	auth := map[string]string{"url": server.URL, "token": "secret"}
+
+	ft, err := fs.UploadFile(ctx, strings.NewReader("backup data"), "bk/xstore/full.xbstream", auth, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ft.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	ft, err = fs.DownloadFile(ctx, buf, "bk/xstore/full.xbstream", auth, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ft.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "backup data" {
+		t.Fatalf("unexpected content: %s", buf.String())
+	}
+
+	ft, _ = fs.DownloadFile(ctx, &bytes.Buffer{}, "bk/xstore/full.xbstream", map[string]string{"url": server.URL}, nil)
+	if err := ft.Wait(); err == nil {
+		t.Fatal("expect unauthorized without token")
+	}
+
+	deleted, err := fs.(FileRemover).DeleteFiles(ctx, "bk/", auth, nil)
+	if err != nil || deleted != 1 {
+		t.Fatalf("expect 1 deleted, got %d, err: %v", deleted, err)
+	}
+	ft, _ = fs.DownloadFile(ctx, &bytes.Buffer{}, "bk/xstore/full.xbstream", auth, nil)
+	if err := ft.Wait(); err == nil {
+		t.Fatal("expect not found after deletion")
+	}
+}
+
+func TestArchiveFs_ResumeDownload(t *testing.T) {
+	server := httptest.NewServer(NewArchiveHandler(NewDirArchiveStore(t.TempDir()), ""))
+	defer server.Close()
+
+	fs, _ := GetFileService("archive")
+	auth := map[string]string{"url": server.URL}
+	data := make([]byte, 1<<16)
+	rand.New(rand.NewSource(1)).Read(data)
+	ft, _ := fs.UploadFile(context.Background(), bytes.NewReader(data), "bk/xstore/full.xbstream", auth, nil)
+	if err := ft.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The adapter doesn't honor the range, what's downloaded is skipped from the whole object.
+	offset := 1000
+	local := bytes.NewBuffer(append([]byte{}, data[:offset]...))
+	stream := &bytes.Buffer{}
+	ft, err := fs.DownloadFile(context.Background(), stream, "bk/xstore/full.xbstream", auth,
+		map[string]string{"write_len": "true", "offset": strconv.Itoa(offset)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ft.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if left := binary.BigEndian.Uint64(stream.Next(8)); left != uint64(len(data)-offset) {
+		t.Fatalf("expect %d bytes left, got %d", len(data)-offset, left)
+	}
+	local.Write(stream.Bytes())
+	if sha256.Sum256(local.Bytes()) != sha256.Sum256(data) {
+		t.Fatal("checksum mismatch after resumed")
+	}
+}
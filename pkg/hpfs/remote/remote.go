@@ -19,7 +19,13 @@ package remote
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	polarxIo "github.com/alibaba/polardbx-operator/pkg/util/io"
 )
 
 type FileTask interface {
@@ -27,15 +33,96 @@ type FileTask interface {
 	Wait() error
 }
 
+// RetriedFileTask is implemented by file tasks which retry the failed requests, e.g. the upload
+// of parts. The count of retries is final once the task is done.
+type RetriedFileTask interface {
+	FileTask
+	Retries() int64
+}
+
 type FileService interface {
 	DeleteFile(ctx context.Context, path string, auth, params map[string]string) error
 	UploadFile(ctx context.Context, reader io.Reader, path string, auth, params map[string]string) (FileTask, error)
 	DownloadFile(ctx context.Context, writer io.Writer, path string, auth, params map[string]string) (FileTask, error)
 }
 
+// FileUrlSigner is implemented by file services which are able to generate pre-signed urls
+// to download a single file without credentials.
+type FileUrlSigner interface {
+	SignFileUrl(ctx context.Context, path string, expiry time.Duration, auth, params map[string]string) (string, error)
+}
+
+// FileCopier is implemented by file services which are able to copy all files under a prefix
+// to another prefix on the server side, i.e. the data never goes through the client. It returns
+// the number of files copied.
+type FileCopier interface {
+	CopyFiles(ctx context.Context, srcPrefix, destPrefix string, auth, params map[string]string) (int64, error)
+}
+
+// FileThawer is implemented by file services with archive storage classes, whose files must be
+// thawed, i.e. restored, before downloading. It requests the thaw of the archived files under the
+// prefix if not requested yet, and returns the number of archived files and the ones still being
+// thawed.
+type FileThawer interface {
+	ThawFiles(ctx context.Context, prefix string, auth, params map[string]string) (archived, thawing int64, err error)
+}
+
+// FileRemover is implemented by file services which are able to delete all files under a prefix.
+// It returns the number of files deleted.
+type FileRemover interface {
+	DeleteFiles(ctx context.Context, prefix string, auth, params map[string]string) (int64, error)
+}
+
+// FileStat is the stat of a file listed by FileLister.
+type FileStat struct {
+	Path         string
+	Size         int64
+	StorageClass string
+}
+
+// FileLister is implemented by file services which are able to list all files under a prefix
+// along with their stats.
+type FileLister interface {
+	StatFiles(ctx context.Context, prefix string, auth, params map[string]string) ([]FileStat, error)
+}
+
+// StorageProber is implemented by file services which are able to tell whether the storage is
+// reachable with the credential, by listing at most one file under the prefix.
+type StorageProber interface {
+	ProbeStorage(ctx context.Context, prefix string, auth, params map[string]string) error
+}
+
+// WormRetention is the WORM (write once read many) retention policy of the storage, which locks
+// each object for the period since it's written, i.e. it can't be deleted nor overwritten.
+type WormRetention struct {
+	Locked bool
+	Period time.Duration
+}
+
+// WormInspector is implemented by file services whose storage supports WORM retention policies.
+type WormInspector interface {
+	GetWormRetention(ctx context.Context, auth, params map[string]string) (WormRetention, error)
+}
+
+// MultipartUpload is an incomplete multipart upload, whose uploaded parts are charged until it's
+// completed or aborted.
+type MultipartUpload struct {
+	Path      string
+	UploadId  string
+	Initiated time.Time
+}
+
+// MultipartUploadManager is implemented by file services uploading files in parts, which are able
+// to list the incomplete multipart uploads under a prefix and abort them.
+type MultipartUploadManager interface {
+	ListMultipartUploads(ctx context.Context, prefix string, auth, params map[string]string) ([]MultipartUpload, error)
+	AbortMultipartUpload(ctx context.Context, upload MultipartUpload, auth, params map[string]string) error
+}
+
 type fileTask struct {
 	ctx      context.Context
 	progress int32
+	retries  int64
 	errC     chan error
 }
 
@@ -50,6 +137,10 @@ func (f *fileTask) Progress() int {
 	return int(f.progress)
 }
 
+func (f *fileTask) Retries() int64 {
+	return atomic.LoadInt64(&f.retries)
+}
+
 func (f *fileTask) Wait() error {
 	return <-f.errC
 }
@@ -62,6 +153,46 @@ func (f *fileTask) complete(err error) {
 	close(f.errC)
 }
 
+// downloadParams are the params of downloads shared by the file services, i.e. "write_len" to write
+// the length of content ahead of it, and "offset" to resume the download from.
+type downloadParams struct {
+	writeLen bool
+	offset   int64
+}
+
+func parseDownloadParams(params map[string]string) (downloadParams, error) {
+	var p downloadParams
+	if val, ok := params["write_len"]; ok && val != "" {
+		writeLen, err := strconv.ParseBool(val)
+		if err != nil {
+			return p, fmt.Errorf("invalid write len: %s", val)
+		}
+		p.writeLen = writeLen
+	}
+	if val, ok := params["offset"]; ok && val != "" {
+		offset, err := strconv.ParseInt(val, 10, 64)
+		if err != nil || offset < 0 {
+			return p, fmt.Errorf("invalid offset: %s", val)
+		}
+		p.offset = offset
+	}
+	return p, nil
+}
+
+// writeLenFrom writes the length of content left from the offset of the object of size if told to,
+// and returns the length.
+func (p downloadParams) writeLenFrom(writer io.Writer, size int64) (int64, error) {
+	if p.offset > size {
+		return 0, fmt.Errorf("offset %d exceeds object size %d", p.offset, size)
+	}
+	if p.writeLen {
+		if err := polarxIo.WriteUint64(writer, uint64(size-p.offset)); err != nil {
+			return 0, err
+		}
+	}
+	return size - p.offset, nil
+}
+
 var fileServices = make(map[string]FileService)
 
 func RegisterFileService(protocol string, service FileService) error {
@@ -0,0 +1,651 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remote
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The s3 file service talks to S3 compatible object storages, e.g. AWS S3, MinIO, Ceph RGW and
+// GCS through its XML API with HMAC keys. Requests are signed with AWS signature version 4 and
+// only the common subset of the API is used, i.e. objects, ListObjectsV2 and multipart uploads.
+//
+// Auth keys are "endpoint", "region", "access_key" and "access_secret". Params are "bucket",
+// "path_style" (true for MinIO and others without virtual hosted buckets), "part_size", the
+// upload retries as the aliyun-oss one, and "write_len" and "offset" of downloads.
+
+func init() {
+	MustRegisterFileService("s3", &s3Fs{})
+}
+
+const (
+	s3DefaultRegion    = "us-east-1"
+	s3EmptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// S3Error is the error responded by the S3 compatible storage.
+type S3Error struct {
+	StatusCode int    `xml:"-"`
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *S3Error) Error() string {
+	return fmt.Sprintf("s3 responds %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+type s3Fs struct{}
+
+type s3Context struct {
+	ctx          context.Context
+	endpoint     *url.URL
+	region       string
+	accessKey    string
+	accessSecret string
+	bucket       string
+	pathStyle    bool
+	partSize     int64
+
+	uploadThreads      int
+	uploadRetries      int
+	uploadRetryBackoff time.Duration
+}
+
+func newS3Context(ctx context.Context, auth, params map[string]string) (*s3Context, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	endpoint := auth["endpoint"]
+	if len(endpoint) == 0 {
+		return nil, fmt.Errorf("endpoint of s3 not specified")
+	}
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	endpointUrl, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint of s3: %w", err)
+	}
+	if len(params["bucket"]) == 0 {
+		return nil, fmt.Errorf("bucket of s3 not specified")
+	}
+	s3Ctx := &s3Context{
+		ctx:                ctx,
+		endpoint:           endpointUrl,
+		region:             auth["region"],
+		accessKey:          auth["access_key"],
+		accessSecret:       auth["access_secret"],
+		bucket:             params["bucket"],
+		partSize:           ParallelPartSize,
+		uploadRetryBackoff: DefaultUploadRetryBackoff,
+	}
+	if len(s3Ctx.region) == 0 {
+		s3Ctx.region = s3DefaultRegion
+	}
+	if val, ok := params["path_style"]; ok && val != "" {
+		if s3Ctx.pathStyle, err = strconv.ParseBool(val); err != nil {
+			return nil, fmt.Errorf("invalid path style: %s", val)
+		}
+	}
+	if val, ok := params["part_size"]; ok && val != "" {
+		if s3Ctx.partSize, err = strconv.ParseInt(val, 10, 64); err != nil || s3Ctx.partSize <= 0 {
+			return nil, fmt.Errorf("invalid part size: %s", val)
+		}
+	}
+	if val, ok := params["upload_threads"]; ok && val != "" {
+		if s3Ctx.uploadThreads, err = strconv.Atoi(val); err != nil || s3Ctx.uploadThreads < 0 {
+			return nil, fmt.Errorf("invalid upload threads: %s", val)
+		}
+	}
+	if val, ok := params["upload_retries"]; ok && val != "" {
+		if s3Ctx.uploadRetries, err = strconv.Atoi(val); err != nil || s3Ctx.uploadRetries < 0 {
+			return nil, fmt.Errorf("invalid upload retries: %s", val)
+		}
+	}
+	if val, ok := params["upload_retry_backoff"]; ok && val != "" {
+		if s3Ctx.uploadRetryBackoff, err = time.ParseDuration(val); err != nil || s3Ctx.uploadRetryBackoff <= 0 {
+			return nil, fmt.Errorf("invalid upload retry backoff: %s", val)
+		}
+	}
+	return s3Ctx, nil
+}
+
+// s3Escape escapes the string as required by signature version 4, i.e. everything except the
+// unreserved characters, and the slash if not told to keep.
+func s3Escape(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (keepSlash && c == '/') {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// s3CanonicalQuery encodes the query sorted by keys, which is both sent and signed.
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			pairs = append(pairs, s3Escape(k, false)+"="+s3Escape(v, false))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// signS3Request signs the request with AWS signature version 4, all headers of the request are
+// signed along with the host.
+func signS3Request(req *http.Request, payloadHash, accessKey, accessSecret, region string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	scope := date + "/" + region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSha256([]byte("AWS4"+accessSecret), date)
+	key = hmacSha256(key, region)
+	key = hmacSha256(key, "s3")
+	key = hmacSha256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSha256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+accessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+// objectUrl returns the url of the object, or the bucket if the key is empty.
+func (s *s3Context) objectUrl(key string, query url.Values) *url.URL {
+	u := &url.URL{
+		Scheme:   s.endpoint.Scheme,
+		Host:     s.endpoint.Host,
+		Path:     "/" + strings.TrimPrefix(key, "/"),
+		RawQuery: s3CanonicalQuery(query),
+	}
+	if s.pathStyle {
+		u.Path = "/" + s.bucket + u.Path
+	} else {
+		u.Host = s.bucket + "." + u.Host
+	}
+	u.RawPath = s3Escape(u.Path, true)
+	return u
+}
+
+func (s *s3Context) do(method, key string, query url.Values, body []byte) (*http.Response, error) {
+	return s.doWithHeader(method, key, query, nil, body)
+}
+
+func (s *s3Context) doWithHeader(method, key string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	u := s.objectUrl(key, query)
+	req, err := http.NewRequestWithContext(s.ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	// The escaped path is signed as is, keep it from being normalized.
+	req.URL = u
+	req.ContentLength = int64(len(body))
+	if body == nil {
+		req.Body = http.NoBody
+	}
+	payloadHash := s3EmptyPayloadHash
+	if len(body) > 0 {
+		sum := sha256.Sum256(body)
+		payloadHash = hex.EncodeToString(sum[:])
+	}
+	signS3Request(req, payloadHash, s.accessKey, s.accessSecret, s.region, time.Now())
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		return nil, readS3Error(resp.StatusCode, resp.Body)
+	}
+	return resp, nil
+}
+
+func readS3Error(statusCode int, body io.Reader) error {
+	s3Err := &S3Error{StatusCode: statusCode}
+	msg, _ := io.ReadAll(io.LimitReader(body, 4096))
+	if err := xml.Unmarshal(msg, s3Err); err != nil {
+		s3Err.Message = strings.TrimSpace(string(msg))
+	}
+	return s3Err
+}
+
+// doXml sends the request and decodes the xml response into result if not nil. Errors may come with
+// status 200, e.g. of completing multipart uploads, so they are checked in the body as well.
+func (s *s3Context) doXml(method, key string, query url.Values, body []byte, result interface{}) error {
+	resp, err := s.do(method, key, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if bytes.Contains(data, []byte("<Error>")) {
+		return readS3Error(resp.StatusCode, bytes.NewReader(data))
+	}
+	if result == nil {
+		return nil
+	}
+	return xml.Unmarshal(data, result)
+}
+
+func (s3 *s3Fs) DeleteFile(ctx context.Context, path string, auth, params map[string]string) error {
+	s3Ctx, err := newS3Context(ctx, auth, params)
+	if err != nil {
+		return err
+	}
+	return s3Ctx.doXml(http.MethodDelete, path, nil, nil, nil)
+}
+
+type s3CompletedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type s3CompleteMultipartUpload struct {
+	XMLName xml.Name          `xml:"CompleteMultipartUpload"`
+	Parts   []s3CompletedPart `xml:"Part"`
+}
+
+func (s *s3Context) putObject(key string, data []byte) error {
+	return s.doXml(http.MethodPut, key, nil, data, nil)
+}
+
+func (s *s3Context) createMultipartUpload(key string) (string, error) {
+	var result struct {
+		UploadId string `xml:"UploadId"`
+	}
+	if err := s.doXml(http.MethodPost, key, url.Values{"uploads": {""}}, nil, &result); err != nil {
+		return "", err
+	}
+	return result.UploadId, nil
+}
+
+func (s *s3Context) uploadPart(key, uploadId string, partNumber int, data []byte) (string, error) {
+	resp, err := s.do(http.MethodPut, key, url.Values{
+		"partNumber": {strconv.Itoa(partNumber)},
+		"uploadId":   {uploadId},
+	}, data)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	return resp.Header.Get("ETag"), nil
+}
+
+func (s *s3Context) completeMultipartUpload(key, uploadId string, parts []s3CompletedPart) error {
+	body, err := xml.Marshal(s3CompleteMultipartUpload{Parts: parts})
+	if err != nil {
+		return err
+	}
+	return s.doXml(http.MethodPost, key, url.Values{"uploadId": {uploadId}}, body, nil)
+}
+
+func (s *s3Context) abortMultipartUpload(key, uploadId string) error {
+	err := s.doXml(http.MethodDelete, key, url.Values{"uploadId": {uploadId}}, nil, nil)
+	if s3Err, ok := err.(*S3Error); ok && s3Err.Code == "NoSuchUpload" {
+		return nil
+	}
+	return err
+}
+
+// upload uploads the stream in a single request if it fits in a part, otherwise in parts with the
+// configured number of routines, one at least. Each routine buffers a part in memory. The failed
+// requests are retried with backoff and counted into retried.
+func (s *s3Context) upload(reader io.Reader, key string, retried *int64) error {
+	threads := s.uploadThreads
+	if threads < 1 {
+		threads = 1
+	}
+	retry := func(do func() error) error {
+		return retryWithBackoff(s.uploadRetries, s.uploadRetryBackoff, retried, do)
+	}
+
+	buf := make([]byte, partSizeOf(s.partSize, 1))
+	n, readErr := io.ReadFull(reader, buf)
+	if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+		return retry(func() error {
+			return s.putObject(key, buf[:n])
+		})
+	}
+	if readErr != nil {
+		return readErr
+	}
+
+	var uploadId string
+	err := retry(func() (err error) {
+		uploadId, err = s.createMultipartUpload(key)
+		return
+	})
+	if err != nil {
+		return err
+	}
+	completed := false
+	defer func() {
+		if !completed {
+			s.abortMultipartUpload(key, uploadId)
+		}
+	}()
+
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		uploadErr error
+	)
+	parts := make([]s3CompletedPart, 0)
+	sem := make(chan struct{}, threads)
+	for partNumber := 1; ; partNumber++ {
+		sem <- struct{}{}
+		mu.Lock()
+		failed := uploadErr != nil
+		mu.Unlock()
+		if failed {
+			<-sem
+			break
+		}
+
+		if partNumber > 1 {
+			buf = make([]byte, partSizeOf(s.partSize, partNumber))
+			n, readErr = io.ReadFull(reader, buf)
+		}
+		if n > 0 {
+			wg.Add(1)
+			go func(buf []byte, partNumber int) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				var etag string
+				err := retry(func() (err error) {
+					etag, err = s.uploadPart(key, uploadId, partNumber, buf)
+					return
+				})
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					if uploadErr == nil {
+						uploadErr = fmt.Errorf("failed to upload part %d: %w", partNumber, err)
+					}
+					return
+				}
+				parts = append(parts, s3CompletedPart{PartNumber: partNumber, ETag: etag})
+			}(buf[:n], partNumber)
+		} else {
+			<-sem
+		}
+
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			wg.Wait()
+			return readErr
+		}
+	}
+	wg.Wait()
+	if uploadErr != nil {
+		return uploadErr
+	}
+
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].PartNumber < parts[j].PartNumber
+	})
+	err = retry(func() error {
+		return s.completeMultipartUpload(key, uploadId, parts)
+	})
+	if err != nil {
+		return err
+	}
+	completed = true
+	return nil
+}
+
+func (s3 *s3Fs) UploadFile(ctx context.Context, reader io.Reader, path string, auth, params map[string]string) (FileTask, error) {
+	s3Ctx, err := newS3Context(ctx, auth, params)
+	if err != nil {
+		return nil, err
+	}
+
+	ft := newFileTask(ctx)
+	go func() {
+		ft.complete(s3Ctx.upload(reader, path, &ft.retries))
+	}()
+	return ft, nil
+}
+
+func (s3 *s3Fs) DownloadFile(ctx context.Context, writer io.Writer, path string, auth, params map[string]string) (FileTask, error) {
+	s3Ctx, err := newS3Context(ctx, auth, params)
+	if err != nil {
+		return nil, err
+	}
+
+	download, err := parseDownloadParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	ft := newFileTask(ctx)
+	go func() {
+		if download.writeLen || download.offset > 0 {
+			resp, err := s3Ctx.do(http.MethodHead, path, nil, nil)
+			if err != nil {
+				ft.complete(fmt.Errorf("failed to head object: %w", err))
+				return
+			}
+			resp.Body.Close()
+			left, err := download.writeLenFrom(writer, resp.ContentLength)
+			if err != nil {
+				ft.complete(err)
+				return
+			}
+			if left == 0 {
+				ft.complete(nil)
+				return
+			}
+		}
+
+		var header http.Header
+		if download.offset > 0 {
+			header = http.Header{"Range": {fmt.Sprintf("bytes=%d-", download.offset)}}
+		}
+		resp, err := s3Ctx.doWithHeader(http.MethodGet, path, nil, header, nil)
+		if err != nil {
+			ft.complete(fmt.Errorf("failed to download file: %w", err))
+			return
+		}
+		defer resp.Body.Close()
+		if _, err := io.Copy(writer, resp.Body); err != nil {
+			ft.complete(fmt.Errorf("failed to copy file: %w", err))
+			return
+		}
+		ft.complete(nil)
+	}()
+	return ft, nil
+}
+
+type s3ListObjectsResult struct {
+	Contents []struct {
+		Key          string `xml:"Key"`
+		Size         int64  `xml:"Size"`
+		StorageClass string `xml:"StorageClass"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func (s3 *s3Fs) StatFiles(ctx context.Context, prefix string, auth, params map[string]string) ([]FileStat, error) {
+	s3Ctx, err := newS3Context(ctx, auth, params)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]FileStat, 0)
+	query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+	for {
+		var result s3ListObjectsResult
+		if err := s3Ctx.doXml(http.MethodGet, "", query, nil, &result); err != nil {
+			return files, fmt.Errorf("failed to list s3 objects: %w", err)
+		}
+		for _, object := range result.Contents {
+			files = append(files, FileStat{Path: object.Key, Size: object.Size, StorageClass: object.StorageClass})
+		}
+		if !result.IsTruncated || len(result.NextContinuationToken) == 0 {
+			return files, nil
+		}
+		query.Set("continuation-token", result.NextContinuationToken)
+	}
+}
+
+func (s3 *s3Fs) ProbeStorage(ctx context.Context, prefix string, auth, params map[string]string) error {
+	s3Ctx, err := newS3Context(ctx, auth, params)
+	if err != nil {
+		return err
+	}
+	query := url.Values{"list-type": {"2"}, "prefix": {prefix}, "max-keys": {"1"}}
+	if err := s3Ctx.doXml(http.MethodGet, "", query, nil, &s3ListObjectsResult{}); err != nil {
+		return fmt.Errorf("failed to list s3 objects: %w", err)
+	}
+	return nil
+}
+
+// DeleteFiles deletes the objects under the prefix one by one, since deleting multiple objects in
+// a request isn't supported by all the compatible storages, e.g. GCS.
+func (s3 *s3Fs) DeleteFiles(ctx context.Context, prefix string, auth, params map[string]string) (int64, error) {
+	files, err := s3.StatFiles(ctx, prefix, auth, params)
+	if err != nil {
+		return 0, err
+	}
+	var deleted int64
+	for _, file := range files {
+		if err := s3.DeleteFile(ctx, file.Path, auth, params); err != nil {
+			return deleted, fmt.Errorf("failed to delete s3 object %s: %w", file.Path, err)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+type s3ListMultipartUploadsResult struct {
+	Uploads []struct {
+		Key       string    `xml:"Key"`
+		UploadId  string    `xml:"UploadId"`
+		Initiated time.Time `xml:"Initiated"`
+	} `xml:"Upload"`
+	IsTruncated        bool   `xml:"IsTruncated"`
+	NextKeyMarker      string `xml:"NextKeyMarker"`
+	NextUploadIdMarker string `xml:"NextUploadIdMarker"`
+}
+
+func (s3 *s3Fs) ListMultipartUploads(ctx context.Context, prefix string, auth, params map[string]string) ([]MultipartUpload, error) {
+	s3Ctx, err := newS3Context(ctx, auth, params)
+	if err != nil {
+		return nil, err
+	}
+
+	uploads := make([]MultipartUpload, 0)
+	query := url.Values{"uploads": {""}, "prefix": {prefix}}
+	for {
+		var result s3ListMultipartUploadsResult
+		if err := s3Ctx.doXml(http.MethodGet, "", query, nil, &result); err != nil {
+			return nil, fmt.Errorf("failed to list s3 multipart uploads: %w", err)
+		}
+		for _, upload := range result.Uploads {
+			uploads = append(uploads, MultipartUpload{
+				Path:      upload.Key,
+				UploadId:  upload.UploadId,
+				Initiated: upload.Initiated,
+			})
+		}
+		if !result.IsTruncated {
+			return uploads, nil
+		}
+		query.Set("key-marker", result.NextKeyMarker)
+		query.Set("upload-id-marker", result.NextUploadIdMarker)
+	}
+}
+
+func (s3 *s3Fs) AbortMultipartUpload(ctx context.Context, upload MultipartUpload, auth, params map[string]string) error {
+	s3Ctx, err := newS3Context(ctx, auth, params)
+	if err != nil {
+		return err
+	}
+	return s3Ctx.abortMultipartUpload(upload.Path, upload.UploadId)
+}
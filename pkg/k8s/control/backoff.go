@@ -0,0 +1,102 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+type backoffState struct {
+	progress string
+	attempts int
+}
+
+// Backoff tracks the requeue interval of polling steps. The interval grows exponentially
+// (with jitter) while no progress is observed and resets once progress is observed, so that
+// lots of objects waiting at the same time won't poll the api server at the same cadence.
+type Backoff struct {
+	initial time.Duration
+	max     time.Duration
+	factor  float64
+	jitter  float64
+	rand    func() float64
+
+	mu     sync.Mutex
+	states map[string]*backoffState
+}
+
+// NewBackoff creates a backoff starting from initial and capped at max. The interval is
+// doubled on each attempt and randomized in [d, d*(1+jitter)].
+func NewBackoff(initial, max time.Duration, jitter float64) *Backoff {
+	return &Backoff{
+		initial: initial,
+		max:     max,
+		factor:  2,
+		jitter:  jitter,
+		rand:    rand.Float64,
+		states:  make(map[string]*backoffState),
+	}
+}
+
+// Next returns the next interval of the key. A progress different from the last observed one
+// resets the interval to the initial one.
+func (b *Backoff) Next(key, progress string) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s, ok := b.states[key]
+	if !ok || s.progress != progress {
+		s = &backoffState{progress: progress}
+		b.states[key] = s
+	}
+
+	d := float64(b.initial)
+	for i := 0; i < s.attempts && d < float64(b.max); i++ {
+		d *= b.factor
+	}
+	if d > float64(b.max) {
+		d = float64(b.max)
+	}
+	s.attempts++
+
+	if b.jitter > 0 {
+		d += d * b.jitter * b.rand()
+	}
+	return time.Duration(d)
+}
+
+// Reset forgets the state of the key, it should be invoked when the wait is over.
+func (b *Backoff) Reset(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.states, key)
+}
+
+// ResetPrefix forgets the states of all the keys with the prefix, e.g. the keys of an object
+// which is gone.
+func (b *Backoff) ResetPrefix(prefix string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for key := range b.states {
+		if strings.HasPrefix(key, prefix) {
+			delete(b.states, key)
+		}
+	}
+}
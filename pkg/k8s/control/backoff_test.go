@@ -0,0 +1,79 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff_GrowsWhenIdleAndResetsOnProgress(t *testing.T) {
+	b := NewBackoff(time.Second, 10*time.Second, 0)
+
+	expected := []time.Duration{1, 2, 4, 8, 10, 10}
+	for i, e := range expected {
+		if d := b.Next("obj", "p0"); d != e*time.Second {
+			t.Fatalf("attempt %d: expect %s, got %s", i, e*time.Second, d)
+		}
+	}
+
+	if d := b.Next("obj", "p1"); d != time.Second {
+		t.Fatalf("expect reset on progress, got %s", d)
+	}
+	if d := b.Next("obj", "p1"); d != 2*time.Second {
+		t.Fatalf("expect grow after reset, got %s", d)
+	}
+
+	b.Reset("obj")
+	if d := b.Next("obj", "p1"); d != time.Second {
+		t.Fatalf("expect reset, got %s", d)
+	}
+
+	if d := b.Next("another", "p1"); d != time.Second {
+		t.Fatalf("expect keys isolated, got %s", d)
+	}
+}
+
+func TestBackoff_Jitter(t *testing.T) {
+	b := NewBackoff(time.Second, 10*time.Second, 0.5)
+	b.rand = func() float64 { return 1 }
+
+	if d := b.Next("obj", ""); d != 1500*time.Millisecond {
+		t.Fatalf("expect 1.5s, got %s", d)
+	}
+	for i := 0; i < 10; i++ {
+		b.Next("obj", "")
+	}
+	if d := b.Next("obj", ""); d != 15*time.Second {
+		t.Fatalf("expect jitter applied on capped interval, got %s", d)
+	}
+}
+
+func TestBackoff_ResetPrefix(t *testing.T) {
+	b := NewBackoff(time.Second, 10*time.Second, 0)
+	b.Next("default/b1/WaitA", "")
+	b.Next("default/b1/WaitB", "")
+	b.Next("default/b10/WaitA", "")
+
+	b.ResetPrefix("default/b1/")
+	if len(b.states) != 1 {
+		t.Fatalf("expect only the states of other objects kept, got %v", b.states)
+	}
+	if d := b.Next("default/b10/WaitA", ""); d != 2*time.Second {
+		t.Fatalf("expect state of other object kept, got %s", d)
+	}
+}
@@ -27,6 +27,29 @@ import (
 	"github.com/alibaba/polardbx-operator/pkg/debug"
 )
 
+// StepError is the error returned by the executor if a step fails, it records the name of the step.
+type StepError struct {
+	Step string
+	Err  error
+}
+
+func (e *StepError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// FailedStepOf returns the name of the failed step if the error is returned by a step.
+func FailedStepOf(err error) (string, bool) {
+	var stepErr *StepError
+	if errors.As(err, &stepErr) {
+		return stepErr.Step, true
+	}
+	return "", false
+}
+
 type Executor interface {
 	Execute(rc ReconcileContext, task *Task) (reconcile.Result, error)
 }
@@ -157,6 +180,9 @@ func (e *executor) Execute(rc ReconcileContext, task *Task) (result reconcile.Re
 	for task.hasNextStep() {
 		step := task.nextStep()
 		result, err = e.execute(rc, step, e.logger, false, !task.hasNextStep() && !task.hasNextDeferredStep())
+		if err != nil {
+			err = &StepError{Step: step.Name(), Err: err}
+		}
 		if e.flow.BreakLoop() {
 			return
 		}
@@ -34,3 +34,31 @@ func IsJobCompleted(job *batchv1.Job) bool {
 
 	return false
 }
+
+func IsJobFailed(job *batchv1.Job) bool {
+	if job == nil {
+		return false
+	}
+
+	for _, cond := range job.Status.Conditions {
+		if cond.Type == batchv1.JobFailed && cond.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+
+	return false
+}
+
+func IsJobDeadlineExceeded(job *batchv1.Job) bool {
+	if job == nil {
+		return false
+	}
+
+	for _, cond := range job.Status.Conditions {
+		if cond.Type == batchv1.JobFailed && cond.Status == corev1.ConditionTrue {
+			return cond.Reason == "DeadlineExceeded"
+		}
+	}
+
+	return false
+}
@@ -69,6 +69,7 @@ type DDLResult struct {
 
 type DDLPlanStatus struct {
 	PlanId   string `json:"plan_id,omitempty"`  // PLAN_ID
+	JobId    string `json:"job_id,omitempty"`   // JOB_ID, empty if the job isn't launched
 	State    string `json:"state,omitempty"`    // State
 	Progress int    `json:"progress,omitempty"` // Progress
 }
@@ -77,6 +78,8 @@ type DDLPlanStatus struct {
 type SlaveStatus struct {
 	SlaveSQLRunning string `json:"slave_sql_running,omitempty"` // Slave_SQL_Running
 	LastError       string `json:"last_error,omitempty"`        // Last_Error
+
+	SecondsBehindMaster *int64 `json:"seconds_behind_master,omitempty"` // Seconds_Behind_Master, nil if unknown
 }
 
 // ClusterStatus describes status of xstore cluster
@@ -114,7 +117,11 @@ type GroupManager interface {
 	ShowDDL(jobId string) (*DDLStatus, error)
 	ShowDDLResult(jobId string) (*DDLResult, error)
 	ShowDDLPlanStatus(planId string) (*DDLPlanStatus, error)
+	ListDDL() ([]DDLStatus, error)
+	PauseDDL(jobId string) error
+	ContinueDDL(jobId string) error
 	SetGlobalVariables(map[string]string) error
+	ShowGlobalVariables(names ...string) (map[string]string, error)
 	Close() error
 	GetBinlogOffset() (string, error)
 	GetTrans(column string, table string) (map[string]bool, error)
@@ -180,11 +187,15 @@ func (m *groupManager) ShowDDLPlanStatus(planId string) (*DDLPlanStatus, error)
 	}
 	defer dbutil.DeferClose(conn)
 
-	row := conn.QueryRowContext(m.ctx, "SELECT plan_id, state, progress FROM information_schema.ddl_plan WHERE plan_id = ?", planId)
+	row := conn.QueryRowContext(m.ctx, "SELECT plan_id, job_id, state, progress FROM information_schema.ddl_plan WHERE plan_id = ?", planId)
 	var s DDLPlanStatus
-	if err := row.Scan(&s.PlanId, &s.State, &s.Progress); err != nil {
+	var jobId sql.NullString
+	if err := row.Scan(&s.PlanId, &jobId, &s.State, &s.Progress); err != nil {
 		return nil, err
 	}
+	if jobId.Valid && jobId.String != "0" {
+		s.JobId = jobId.String
+	}
 	return &s, nil
 }
 
@@ -218,6 +229,10 @@ func (m *groupManager) ShowDDL(jobId string) (*DDLStatus, error) {
 		return nil, nil
 	}
 
+	return scanDDLStatus(rs)
+}
+
+func scanDDLStatus(rs *sql.Rows) (*DDLStatus, error) {
 	status := &DDLStatus{}
 	var progress sql.NullString
 	dest := map[string]interface{}{
@@ -228,7 +243,7 @@ func (m *groupManager) ShowDDL(jobId string) (*DDLStatus, error) {
 		"STATE":         &status.State,
 		"PROGRESS":      &progress,
 	}
-	err = dbutil.Scan(rs, dest, dbutil.ScanOpt{CaseInsensitive: true})
+	err := dbutil.Scan(rs, dest, dbutil.ScanOpt{CaseInsensitive: true})
 	if err != nil {
 		return nil, err
 	}
@@ -242,6 +257,53 @@ func (m *groupManager) ShowDDL(jobId string) (*DDLStatus, error) {
 	return status, nil
 }
 
+// ListDDL lists the DDL jobs not finished, e.g. the moves of rebalance.
+func (m *groupManager) ListDDL() ([]DDLStatus, error) {
+	conn, err := m.getConn("")
+	if err != nil {
+		return nil, err
+	}
+	defer dbutil.DeferClose(conn)
+
+	rs, err := conn.QueryContext(m.ctx, "SHOW DDL")
+	if err != nil {
+		return nil, err
+	}
+	defer dbutil.DeferClose(rs)
+
+	jobs := make([]DDLStatus, 0)
+	for rs.Next() {
+		status, err := scanDDLStatus(rs)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *status)
+	}
+	return jobs, rs.Err()
+}
+
+func (m *groupManager) PauseDDL(jobId string) error {
+	conn, err := m.getConn("")
+	if err != nil {
+		return err
+	}
+	defer dbutil.DeferClose(conn)
+
+	_, err = conn.ExecContext(m.ctx, fmt.Sprintf("PAUSE DDL %s", jobId))
+	return err
+}
+
+func (m *groupManager) ContinueDDL(jobId string) error {
+	conn, err := m.getConn("")
+	if err != nil {
+		return err
+	}
+	defer dbutil.DeferClose(conn)
+
+	_, err = conn.ExecContext(m.ctx, fmt.Sprintf("CONTINUE DDL %s", jobId))
+	return err
+}
+
 func (m *groupManager) ShowDDLResult(jobId string) (*DDLResult, error) {
 	conn, err := m.getConn("")
 	if err != nil {
@@ -857,6 +919,39 @@ func (m *groupManager) SetGlobalVariables(variables map[string]string) error {
 	return nil
 }
 
+// ShowGlobalVariables returns the global variables with lower-cased names, all of them if names are not specified.
+func (m *groupManager) ShowGlobalVariables(names ...string) (map[string]string, error) {
+	conn, err := m.getConn("")
+	if err != nil {
+		return nil, err
+	}
+	defer dbutil.DeferClose(conn)
+
+	rs, err := conn.QueryContext(m.ctx, "SHOW GLOBAL VARIABLES")
+	if err != nil {
+		return nil, err
+	}
+	defer dbutil.DeferClose(rs)
+
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[strings.ToLower(name)] = true
+	}
+
+	variables := make(map[string]string)
+	for rs.Next() {
+		var name, value string
+		if err := rs.Scan(&name, &value); err != nil {
+			return nil, err
+		}
+		name = strings.ToLower(name)
+		if len(wanted) == 0 || wanted[name] {
+			variables[name] = value
+		}
+	}
+	return variables, rs.Err()
+}
+
 // ShowSlaveStatus aims to check slave status, which should only be used for follower/logger of xstore
 func (m *groupManager) ShowSlaveStatus() (*SlaveStatus, error) {
 	conn, err := m.getConn("")
@@ -877,8 +972,9 @@ func (m *groupManager) ShowSlaveStatus() (*SlaveStatus, error) {
 
 	status := &SlaveStatus{}
 	dest := map[string]interface{}{
-		"Slave_SQL_Running": &status.SlaveSQLRunning,
-		"Last_Error":        &status.LastError,
+		"Slave_SQL_Running":     &status.SlaveSQLRunning,
+		"Last_Error":            &status.LastError,
+		"Seconds_Behind_Master": &status.SecondsBehindMaster,
 	}
 	err = dbutil.Scan(rs, dest, dbutil.ScanOpt{CaseInsensitive: true})
 	if err != nil {
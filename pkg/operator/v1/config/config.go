@@ -32,6 +32,7 @@ type config struct {
 	SecurityConfig  securityConfig  `json:"security,omitempty"`
 	OssConfig       ossConfig       `json:"oss,omitempty"`
 	NfsConfig       nfsConfig       `json:"nfs,omitempty"`
+	BackupConfig    backupConfig    `json:"backup,omitempty"`
 }
 
 func (c *config) Security() SecurityConfig {
@@ -62,6 +63,10 @@ func (c *config) Nfs() NfsConfig {
 	return &c.NfsConfig
 }
 
+func (c *config) Backup() BackupConfig {
+	return &c.BackupConfig
+}
+
 type imagesConfig struct {
 	Repo          string                       `json:"repo,omitempty"`
 	Common        map[string]string            `json:"common,omitempty"`
@@ -147,13 +152,18 @@ func (c *imagesConfig) DefaultImageForStore(engine, container string, version st
 }
 
 type schedulerConfig struct {
-	EnableMaster bool `json:"enable_master,omitempty"`
+	EnableMaster              bool  `json:"enable_master,omitempty"`
+	EnablePodDisruptionBudget *bool `json:"enable_pod_disruption_budget,omitempty"`
 }
 
 func (c *schedulerConfig) AllowScheduleToMasterNode() bool {
 	return c.EnableMaster
 }
 
+func (c *schedulerConfig) ManagePodDisruptionBudget() bool {
+	return c.EnablePodDisruptionBudget == nil || *c.EnablePodDisruptionBudget
+}
+
 type clusterConfig struct {
 	OptionEnableExporters                   bool `json:"enable_exporters,omitempty"`
 	OptionEnableAliyunAckResourceController bool `json:"enable_aliyun_ack_resource_controller,omitempty"`
@@ -255,3 +265,11 @@ func (c *nfsConfig) Path() string {
 func (c *nfsConfig) Server() string {
 	return c.NfsServer
 }
+
+type backupConfig struct {
+	MaxConcurrent int `json:"max_concurrent_per_namespace,omitempty"`
+}
+
+func (c *backupConfig) MaxConcurrentPerNamespace() int {
+	return c.MaxConcurrent
+}
@@ -24,6 +24,7 @@ type Config interface {
 	Security() SecurityConfig
 	Oss() OssConfig
 	Nfs() NfsConfig
+	Backup() BackupConfig
 }
 
 type SecurityConfig interface {
@@ -32,6 +33,7 @@ type SecurityConfig interface {
 
 type SchedulerConfig interface {
 	AllowScheduleToMasterNode() bool
+	ManagePodDisruptionBudget() bool
 }
 
 type ImagesConfig interface {
@@ -70,3 +72,9 @@ type NfsConfig interface {
 	Path() string
 	Server() string
 }
+
+type BackupConfig interface {
+	// MaxConcurrentPerNamespace is the default max count of in-flight xstore backups in a
+	// namespace, non-positive means unlimited.
+	MaxConcurrentPerNamespace() int
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"os"
+	"strings"
+	"sync"
+)
+
+// versionFile is written by the image build, see build/images/polardbx-operator/Dockerfile.
+const versionFile = "/version"
+
+var (
+	operatorVersion     string
+	operatorVersionOnce sync.Once
+)
+
+// OperatorVersion returns the version of the running operator, or "unknown" if it's not
+// running in the image.
+func OperatorVersion() string {
+	operatorVersionOnce.Do(func() {
+		operatorVersion = "unknown"
+		if data, err := os.ReadFile(versionFile); err == nil {
+			if version := strings.TrimSpace(string(data)); len(version) > 0 {
+				operatorVersion = version
+			}
+		}
+	})
+	return operatorVersion
+}
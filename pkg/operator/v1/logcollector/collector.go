@@ -0,0 +1,220 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package logcollector builds the sidecar container which tails the logs of CN and DN and ships
+// them to the configured sink with fluent-bit.
+package logcollector
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/util/defaults"
+)
+
+const (
+	// ContainerName is the name of the collector container.
+	ContainerName = "log-collector"
+
+	// DefaultImage is the image of collector if not specified. The debug image is required as
+	// the rotation runs in the shell.
+	DefaultImage = "fluent/fluent-bit:2.1.10-debug"
+
+	fluentBitBin = "/fluent-bit/bin/fluent-bit"
+
+	defaultMaxFiles       = 3
+	rotateIntervalSeconds = 30
+)
+
+var defaultMaxSize = resource.MustParse("256Mi")
+
+// Input is a log file (or glob) tailed by the collector.
+type Input struct {
+	// Tag of the records, e.g. "cn.audit".
+	Tag string
+
+	// Path of the log files, globs are allowed.
+	Path string
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func newLokiLabels(sink *polardbxv1xstore.LokiSink, labels map[string]string) string {
+	all := make(map[string]string, len(labels)+len(sink.Labels))
+	for k, v := range sink.Labels {
+		all[k] = v
+	}
+	for k, v := range labels {
+		all[k] = v
+	}
+	keys := make([]string, 0, len(all))
+	for k := range all {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys)+1)
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+all[k])
+	}
+	// Record accessor of the pod name added by the record modifier.
+	pairs = append(pairs, "pod=$pod")
+	return strings.Join(pairs, ",")
+}
+
+func newOutputArgs(sink *polardbxv1xstore.LogSink, labels map[string]string) ([]string, error) {
+	switch sink.Type {
+	case "", polardbxv1xstore.LogSinkStdout:
+		return []string{"-o", "stdout", "-m", "*", "-p", "format=json_lines"}, nil
+	case polardbxv1xstore.LogSinkLoki:
+		loki := sink.Loki
+		if loki == nil || len(loki.Host) == 0 {
+			return nil, fmt.Errorf("loki sink requires host")
+		}
+		port := loki.Port
+		if port == 0 {
+			port = 3100
+		}
+		args := []string{"-o", "loki", "-m", "*",
+			"-p", "host=" + loki.Host,
+			"-p", "port=" + strconv.Itoa(int(port)),
+			"-p", "labels=" + newLokiLabels(loki, labels),
+		}
+		if loki.TLS {
+			args = append(args, "-p", "tls=on")
+		}
+		if len(loki.TenantID) > 0 {
+			args = append(args, "-p", "tenant_id="+loki.TenantID)
+		}
+		return args, nil
+	case polardbxv1xstore.LogSinkKafka:
+		kafka := sink.Kafka
+		if kafka == nil || len(kafka.Brokers) == 0 || len(kafka.Topic) == 0 {
+			return nil, fmt.Errorf("kafka sink requires brokers and topic")
+		}
+		return []string{"-o", "kafka", "-m", "*",
+			"-p", "brokers=" + strings.Join(kafka.Brokers, ","),
+			"-p", "topics=" + kafka.Topic,
+			"-p", "format=json",
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported log sink type: %s", sink.Type)
+	}
+}
+
+// newRotateScript returns the shell function which rotates the log files exceeding the max
+// size by copy and truncate, since the writers keep the files open.
+func newRotateScript(inputs []Input, rotation *polardbxv1xstore.LogRotation) string {
+	maxSize := defaultMaxSize
+	if rotation.MaxSize != nil {
+		maxSize = *rotation.MaxSize
+	}
+	maxFiles := int32(defaultMaxFiles)
+	if rotation.MaxFiles != nil {
+		maxFiles = *rotation.MaxFiles
+	}
+
+	paths := make([]string, 0, len(inputs))
+	for _, in := range inputs {
+		paths = append(paths, in.Path)
+	}
+
+	b := &strings.Builder{}
+	b.WriteString("rotate() {\n")
+	fmt.Fprintf(b, "  for f in %s; do\n", strings.Join(paths, " "))
+	b.WriteString("    [ -f \"$f\" ] || continue\n")
+	fmt.Fprintf(b, "    [ \"$(stat -c %%s \"$f\")\" -gt %d ] || continue\n", maxSize.Value())
+	if maxFiles > 0 {
+		fmt.Fprintf(b, "    for i in $(seq %d -1 1); do [ -f \"$f.$i\" ] && mv -f \"$f.$i\" \"$f.$((i+1))\"; done\n", maxFiles-1)
+		b.WriteString("    cp -f \"$f\" \"$f.1\"\n")
+	}
+	b.WriteString("    : > \"$f\"\n")
+	b.WriteString("  done\n")
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// NewScript returns the script run by the collector container, which rotates the inputs in
+// background and runs fluent-bit to tail them. Labels are attached to the streams if the sink
+// supports.
+func NewScript(spec *polardbxv1xstore.LogCollector, inputs []Input, labels map[string]string) (string, error) {
+	if len(inputs) == 0 {
+		return "", fmt.Errorf("no inputs")
+	}
+	outputArgs, err := newOutputArgs(&spec.Sink, labels)
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{fluentBitBin}
+	for _, in := range inputs {
+		args = append(args, "-i", "tail",
+			"-p", "path="+in.Path,
+			"-p", "tag="+in.Tag,
+			"-p", "refresh_interval=5",
+			"-p", "rotate_wait=30",
+			"-p", "skip_long_lines=on",
+			"-p", "mem_buf_limit=16MB",
+		)
+	}
+	args = append(args, outputArgs...)
+
+	quoted := make([]string, 0, len(args)+4)
+	for _, a := range args {
+		quoted = append(quoted, shellQuote(a))
+	}
+	// Expanded by shell.
+	quoted = append(quoted, "-F", "record_modifier", "-m", shellQuote("*"), "-p", `"record=pod ${POD_NAME}"`)
+
+	b := &strings.Builder{}
+	b.WriteString(newRotateScript(inputs, &spec.Rotation))
+	fmt.Fprintf(b, "(while true; do rotate; sleep %d; done) &\n", rotateIntervalSeconds)
+	b.WriteString("exec " + strings.Join(quoted, " ") + "\n")
+	return b.String(), nil
+}
+
+// NewContainer returns the collector container which tails the inputs on the volume mounts.
+func NewContainer(spec *polardbxv1xstore.LogCollector, inputs []Input, labels map[string]string,
+	volumeMounts []corev1.VolumeMount) (*corev1.Container, error) {
+	script, err := NewScript(spec, inputs, labels)
+	if err != nil {
+		return nil, err
+	}
+	return &corev1.Container{
+		Name:            ContainerName,
+		Image:           defaults.NonEmptyStrOrDefault(spec.Image, DefaultImage),
+		ImagePullPolicy: spec.ImagePullPolicy,
+		Command:         []string{"/bin/sh", "-c", script},
+		Env: []corev1.EnvVar{
+			{
+				Name: "POD_NAME",
+				ValueFrom: &corev1.EnvVarSource{
+					FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
+				},
+			},
+		},
+		Resources:    *spec.Resources.DeepCopy(),
+		VolumeMounts: volumeMounts,
+	}, nil
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logcollector
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/pointer"
+
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+)
+
+var testInputs = []Input{{Tag: "dn.slow", Path: "/data/mysql/log/slow.log"}}
+
+func TestNewScriptSinks(t *testing.T) {
+	testcases := map[string]struct {
+		sink   polardbxv1xstore.LogSink
+		expect []string
+		err    bool
+	}{
+		"default": {
+			expect: []string{"'-o' 'stdout'", "'format=json_lines'"},
+		},
+		"loki": {
+			sink: polardbxv1xstore.LogSink{
+				Type: polardbxv1xstore.LogSinkLoki,
+				Loki: &polardbxv1xstore.LokiSink{
+					Host:     "loki.monitoring",
+					TLS:      true,
+					TenantID: "dba",
+					Labels:   map[string]string{"env": "prod", "log": "overwritten"},
+				},
+			},
+			expect: []string{"'-o' 'loki'", "'host=loki.monitoring'", "'port=3100'", "'tls=on'", "'tenant_id=dba'",
+				"'labels=env=prod,log=slow,xstore=dn-0,pod=$pod'"},
+		},
+		"loki without host": {
+			sink: polardbxv1xstore.LogSink{Type: polardbxv1xstore.LogSinkLoki},
+			err:  true,
+		},
+		"kafka": {
+			sink: polardbxv1xstore.LogSink{
+				Type: polardbxv1xstore.LogSinkKafka,
+				Kafka: &polardbxv1xstore.KafkaSink{
+					Brokers: []string{"kafka-0:9092", "kafka-1:9092"},
+					Topic:   "polardbx-logs",
+				},
+			},
+			expect: []string{"'-o' 'kafka'", "'brokers=kafka-0:9092,kafka-1:9092'", "'topics=polardbx-logs'"},
+		},
+		"kafka without topic": {
+			sink: polardbxv1xstore.LogSink{
+				Type:  polardbxv1xstore.LogSinkKafka,
+				Kafka: &polardbxv1xstore.KafkaSink{Brokers: []string{"kafka-0:9092"}},
+			},
+			err: true,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			script, err := NewScript(&polardbxv1xstore.LogCollector{Sink: tc.sink}, testInputs,
+				map[string]string{"xstore": "dn-0", "log": "slow"})
+			if tc.err {
+				if err == nil {
+					t.Fatal("expect error, but not")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range append(tc.expect, "'path=/data/mysql/log/slow.log' '-p' 'tag=dn.slow'", `"record=pod ${POD_NAME}"`) {
+				if !strings.Contains(script, s) {
+					t.Errorf("expect %q in script, but not:\n%s", s, script)
+				}
+			}
+		})
+	}
+}
+
+func TestNewScriptRotation(t *testing.T) {
+	maxSize := resource.MustParse("1Mi")
+	script, err := NewScript(&polardbxv1xstore.LogCollector{
+		Rotation: polardbxv1xstore.LogRotation{MaxSize: &maxSize, MaxFiles: pointer.Int32(2)},
+	}, testInputs, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"-gt 1048576 ]", "$(seq 1 -1 1)", `cp -f "$f" "$f.1"`, `: > "$f"`} {
+		if !strings.Contains(script, s) {
+			t.Errorf("expect %q in script, but not:\n%s", s, script)
+		}
+	}
+
+	script, err = NewScript(&polardbxv1xstore.LogCollector{
+		Rotation: polardbxv1xstore.LogRotation{MaxFiles: pointer.Int32(0)},
+	}, testInputs, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(script, "cp -f") || !strings.Contains(script, "-gt 268435456 ]") {
+		t.Errorf("expect truncate only with default max size, but is:\n%s", script)
+	}
+}
@@ -0,0 +1,218 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics defines the domain metrics of operator, which are exposed along with the
+// controller-runtime metrics on the metrics endpoint of the controller manager.
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const metricNamespace = "polardbx_operator"
+
+var (
+	backupDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricNamespace,
+		Subsystem: "xstore_backup",
+		Name:      "duration_seconds",
+		Help:      "Duration of the finished backups of xstore, from started to finished.",
+		Buckets:   prometheus.ExponentialBuckets(60, 2, 10),
+	}, []string{"namespace", "xstore"})
+
+	backupSize = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricNamespace,
+		Subsystem: "xstore_backup",
+		Name:      "size_bytes",
+		Help:      "Size of the last finished full backup of xstore.",
+	}, []string{"namespace", "xstore"})
+
+	backupFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricNamespace,
+		Subsystem: "xstore_backup",
+		Name:      "failures_total",
+		Help:      "Count of the failed backups of xstore by reason.",
+	}, []string{"namespace", "xstore", "reason"})
+
+	restoreDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricNamespace,
+		Subsystem: "xstore_restore",
+		Name:      "duration_seconds",
+		Help:      "Duration of the restores of xstore, from created to restored.",
+		Buckets:   prometheus.ExponentialBuckets(60, 2, 10),
+	}, []string{"namespace", "xstore"})
+
+	upgradeProgress = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricNamespace,
+		Subsystem: "polardbx",
+		Name:      "upgrade_progress_ratio",
+		Help:      "Ratio of the components rolled out of the upgrading cluster, only present while upgrading.",
+	}, []string{"namespace", "polardbxcluster"})
+
+	leaderChanges = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricNamespace,
+		Subsystem: "xstore",
+		Name:      "leader_changes_total",
+		Help:      "Count of the leader changes of xstore observed by operator.",
+	}, []string{"namespace", "xstore"})
+
+	replicationLag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricNamespace,
+		Subsystem: "xstore",
+		Name:      "replication_lag_entries",
+		Help:      "Count of the log entries the member of xstore is behind the leader.",
+	}, []string{"namespace", "xstore", "pod"})
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(
+		backupDuration,
+		backupSize,
+		backupFailures,
+		restoreDuration,
+		upgradeProgress,
+		leaderChanges,
+		replicationLag,
+	)
+}
+
+var (
+	// startTime is used to skip the events happened before the operator started, which are
+	// reconciled again on start.
+	startTime = time.Now()
+
+	mu sync.Mutex
+
+	// observed records the keys of the events observed to avoid counting them twice.
+	observed = make(map[string]struct{})
+
+	// leaders records the last observed leader of xstores.
+	leaders = make(map[string]string)
+
+	// lagPods records the pods with replication lag of xstores.
+	lagPods = make(map[string]map[string]struct{})
+)
+
+func xstoreKey(namespace, xstore string) string {
+	return namespace + "/" + xstore
+}
+
+// observeOnce returns true if the event happened after the operator started and isn't observed yet.
+func observeOnce(key string, at time.Time) bool {
+	if at.Before(startTime) {
+		return false
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := observed[key]; ok {
+		return false
+	}
+	observed[key] = struct{}{}
+	return true
+}
+
+// ObserveBackupFinished observes the duration and size of the backup finished at the time. Each backup,
+// identified by the key, is observed only once.
+func ObserveBackupFinished(key, namespace, xstore string, finishedAt time.Time, duration time.Duration, size int64) {
+	if !observeOnce(key, finishedAt) {
+		return
+	}
+	backupDuration.WithLabelValues(namespace, xstore).Observe(duration.Seconds())
+	backupSize.WithLabelValues(namespace, xstore).Set(float64(size))
+}
+
+// ObserveBackupFailed counts the backup failed at the time. Each backup, identified by the key, is
+// counted only once.
+func ObserveBackupFailed(key, namespace, xstore, reason string, failedAt time.Time) {
+	if !observeOnce(key, failedAt) {
+		return
+	}
+	backupFailures.WithLabelValues(namespace, xstore, reason).Inc()
+}
+
+// ObserveRestoreFinished observes the duration of the restore finished at the time. Each restore,
+// identified by the key, is observed only once.
+func ObserveRestoreFinished(key, namespace, xstore string, finishedAt time.Time, duration time.Duration) {
+	if !observeOnce(key, finishedAt) {
+		return
+	}
+	restoreDuration.WithLabelValues(namespace, xstore).Observe(duration.Seconds())
+}
+
+// SetUpgradeProgress sets the ratio of components rolled out of the upgrading cluster.
+func SetUpgradeProgress(namespace, polardbx string, ratio float64) {
+	upgradeProgress.WithLabelValues(namespace, polardbx).Set(ratio)
+}
+
+// ForgetUpgradeProgress removes the upgrade progress of the cluster once it's not upgrading.
+func ForgetUpgradeProgress(namespace, polardbx string) {
+	upgradeProgress.DeleteLabelValues(namespace, polardbx)
+}
+
+// ObserveLeader counts a leader change if the leader is different from the last observed one.
+// Empty leader, i.e. the leader is lost, isn't recorded, so a failover is counted once the new
+// leader is elected.
+func ObserveLeader(namespace, xstore, leader string) {
+	if len(leader) == 0 {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	key := xstoreKey(namespace, xstore)
+	last, ok := leaders[key]
+	leaders[key] = leader
+	if ok && last != leader {
+		leaderChanges.WithLabelValues(namespace, xstore).Inc()
+	}
+}
+
+// SetReplicationLags sets the replication lags of the members of xstore, and removes the members
+// not found any more.
+func SetReplicationLags(namespace, xstore string, lags map[string]int64) {
+	pods := make(map[string]struct{}, len(lags))
+	for pod, lag := range lags {
+		replicationLag.WithLabelValues(namespace, xstore, pod).Set(float64(lag))
+		pods[pod] = struct{}{}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	key := xstoreKey(namespace, xstore)
+	last := lagPods[key]
+	lagPods[key] = pods
+	for pod := range last {
+		if _, ok := pods[pod]; !ok {
+			replicationLag.DeleteLabelValues(namespace, xstore, pod)
+		}
+	}
+}
+
+// ForgetXStore removes the metrics of xstore once it's deleted.
+func ForgetXStore(namespace, xstore string) {
+	mu.Lock()
+	defer mu.Unlock()
+	key := xstoreKey(namespace, xstore)
+	delete(leaders, key)
+	leaderChanges.DeleteLabelValues(namespace, xstore)
+	backupSize.DeleteLabelValues(namespace, xstore)
+	for pod := range lagPods[key] {
+		replicationLag.DeleteLabelValues(namespace, xstore, pod)
+	}
+	delete(lagPods, key)
+}
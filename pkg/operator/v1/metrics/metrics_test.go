@@ -0,0 +1,71 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestObserveLeader(t *testing.T) {
+	ObserveLeader("default", "dn-0", "dn-0-cand-0")
+	ObserveLeader("default", "dn-0", "dn-0-cand-0")
+	// Leader lost and then another one is elected.
+	ObserveLeader("default", "dn-0", "")
+	ObserveLeader("default", "dn-0", "dn-0-cand-1")
+
+	if v := testutil.ToFloat64(leaderChanges.WithLabelValues("default", "dn-0")); v != 1 {
+		t.Fatalf("expect 1 leader change, but is %v", v)
+	}
+
+	ForgetXStore("default", "dn-0")
+	ObserveLeader("default", "dn-0", "dn-0-cand-0")
+	if v := testutil.ToFloat64(leaderChanges.WithLabelValues("default", "dn-0")); v != 0 {
+		t.Fatalf("expect no leader change after forgotten, but is %v", v)
+	}
+}
+
+func TestObserveBackupOnce(t *testing.T) {
+	now := time.Now()
+	ObserveBackupFailed("uid-1", "default", "dn-1", "JobCrash", now)
+	ObserveBackupFailed("uid-1", "default", "dn-1", "JobCrash", now)
+	// Failed before the operator started.
+	ObserveBackupFailed("uid-2", "default", "dn-1", "JobCrash", startTime.Add(-time.Minute))
+
+	if v := testutil.ToFloat64(backupFailures.WithLabelValues("default", "dn-1", "JobCrash")); v != 1 {
+		t.Fatalf("expect 1 failure, but is %v", v)
+	}
+}
+
+func TestSetReplicationLags(t *testing.T) {
+	SetReplicationLags("default", "dn-2", map[string]int64{"dn-2-cand-0": 0, "dn-2-cand-1": 10})
+	SetReplicationLags("default", "dn-2", map[string]int64{"dn-2-cand-0": 0, "dn-2-cand-2": 5})
+
+	if n := testutil.CollectAndCount(replicationLag); n != 2 {
+		t.Fatalf("expect 2 series, but is %d", n)
+	}
+	if v := testutil.ToFloat64(replicationLag.WithLabelValues("default", "dn-2", "dn-2-cand-2")); v != 5 {
+		t.Fatalf("expect lag 5, but is %v", v)
+	}
+
+	ForgetXStore("default", "dn-2")
+	if n := testutil.CollectAndCount(replicationLag); n != 0 {
+		t.Fatalf("expect no series after forgotten, but is %d", n)
+	}
+}
@@ -35,6 +35,7 @@ import (
 	"github.com/alibaba/polardbx-operator/pkg/operator/v1/config"
 	polardbxv1controllers "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/controllers"
 	xstorev1controllers "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/controllers"
+	"github.com/alibaba/polardbx-operator/pkg/util/tracing"
 	"github.com/alibaba/polardbx-operator/pkg/webhook"
 	"github.com/alibaba/polardbx-operator/pkg/webhook/polardbxcluster"
 )
@@ -66,6 +67,9 @@ type Options struct {
 	CertDir                 string
 
 	ConfigPath string
+
+	// OTLPTracesEndpoint is the OTLP/HTTP endpoint to export traces to, tracing is disabled if empty.
+	OTLPTracesEndpoint string
 }
 
 var setupLog = ctrl.Log.WithName("setup")
@@ -162,6 +166,17 @@ func setupPolarDBXControllers(opts controllerOptions) error {
 		return err
 	}
 
+	autoscalerReconciler := polardbxv1controllers.PolarDBXAutoscalerReconciler{
+		BaseRc:         opts.BaseReconcileContext,
+		LoaderFactory:  opts.LoaderFactory,
+		Logger:         ctrl.Log.WithName("controller").WithName("polardbxautoscaler"),
+		MaxConcurrency: opts.opts.MaxConcurrentReconciles,
+	}
+
+	if err := autoscalerReconciler.SetupWithManager(opts.Manager); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -175,6 +190,66 @@ func setupPolarDBXBackupControllers(opts controllerOptions) error {
 	if err := pxcBackupReconciler.SetupWithManager(opts.Manager); err != nil {
 		return err
 	}
+
+	selfTestReconciler := polardbxv1controllers.PolarDBXBackupSelfTestReconciler{
+		BaseRc:         opts.BaseReconcileContext,
+		LoaderFactory:  opts.LoaderFactory,
+		Logger:         ctrl.Log.WithName("controller").WithName("polardbxbackupselftest"),
+		MaxConcurrency: opts.opts.MaxConcurrentReconciles,
+	}
+	if err := selfTestReconciler.SetupWithManager(opts.Manager); err != nil {
+		return err
+	}
+
+	cloneReconciler := polardbxv1controllers.PolarDBXCloneReconciler{
+		BaseRc:         opts.BaseReconcileContext,
+		LoaderFactory:  opts.LoaderFactory,
+		Logger:         ctrl.Log.WithName("controller").WithName("polardbxclone"),
+		MaxConcurrency: opts.opts.MaxConcurrentReconciles,
+	}
+	if err := cloneReconciler.SetupWithManager(opts.Manager); err != nil {
+		return err
+	}
+
+	reapReconciler := polardbxv1controllers.PolarDBXBackupReapReconciler{
+		BaseRc:         opts.BaseReconcileContext,
+		LoaderFactory:  opts.LoaderFactory,
+		Logger:         ctrl.Log.WithName("controller").WithName("polardbxbackupreap"),
+		MaxConcurrency: opts.opts.MaxConcurrentReconciles,
+	}
+	if err := reapReconciler.SetupWithManager(opts.Manager); err != nil {
+		return err
+	}
+
+	binlogBackupReconciler := polardbxv1controllers.PolarDBXBinlogBackupReconciler{
+		BaseRc:         opts.BaseReconcileContext,
+		LoaderFactory:  opts.LoaderFactory,
+		Logger:         ctrl.Log.WithName("controller").WithName("polardbxbinlogbackup"),
+		MaxConcurrency: opts.opts.MaxConcurrentReconciles,
+	}
+	if err := binlogBackupReconciler.SetupWithManager(opts.Manager); err != nil {
+		return err
+	}
+
+	standbyReconciler := polardbxv1controllers.PolarDBXStandbyReconciler{
+		BaseRc:         opts.BaseReconcileContext,
+		LoaderFactory:  opts.LoaderFactory,
+		Logger:         ctrl.Log.WithName("controller").WithName("polardbxstandby"),
+		MaxConcurrency: opts.opts.MaxConcurrentReconciles,
+	}
+	if err := standbyReconciler.SetupWithManager(opts.Manager); err != nil {
+		return err
+	}
+
+	scheduleReconciler := polardbxv1controllers.PolarDBXBackupScheduleReconciler{
+		BaseRc:         opts.BaseReconcileContext,
+		LoaderFactory:  opts.LoaderFactory,
+		Logger:         ctrl.Log.WithName("controller").WithName("polardbxbackupschedule"),
+		MaxConcurrency: opts.opts.MaxConcurrentReconciles,
+	}
+	if err := scheduleReconciler.SetupWithManager(opts.Manager); err != nil {
+		return err
+	}
 	return nil
 }
 func setupXStoreBackupControllers(opts controllerOptions) error {
@@ -213,9 +288,9 @@ func setupXStoreBackupControllers(opts controllerOptions) error {
 // Currently, these controllers are included:
 //   1. Controller for PolarDBXCluster (v1)
 //   2. Controller for XStore (v1)
-//   3. Controllers for PolarDBXBackup, PolarDBXBinlogBackup (v1)
+//   3. Controllers for PolarDBXBackup, PolarDBXBinlogBackup, PolarDBXBackupSelfTest, PolarDBXClone, PolarDBXBackupReap (v1)
 //   4. Controllers for XStoreBackup, XStoreBinlogBackup (v1)
-//   5. Controllers for PolarDBXBackupSchedule, PolarDBXBinlogBackupSchedule (v1)
+//   5. Controllers for PolarDBXBackupSchedule (v1)
 //   6. Controllers for PolarDBXParameter (v1)
 func Start(ctx context.Context, opts Options) {
 	// Start instruction loader.
@@ -231,6 +306,17 @@ func Start(ctx context.Context, opts Options) {
 		os.Exit(1)
 	}
 
+	// Start exporting traces of backups.
+	if len(opts.OTLPTracesEndpoint) > 0 {
+		exporter, err := tracing.NewOTLPExporter(ctx, opts.OTLPTracesEndpoint,
+			"polardbx-operator", ctrl.Log.WithName("tracing"))
+		if err != nil {
+			setupLog.Error(err, "Unable to start exporting traces.")
+			os.Exit(1)
+		}
+		tracing.SetExporter(exporter)
+	}
+
 	// Get REST config.
 	restConfig := ctrl.GetConfigOrDie()
 	clientset, err := kubernetes.NewForConfig(restConfig)
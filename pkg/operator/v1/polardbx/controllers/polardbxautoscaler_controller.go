@@ -0,0 +1,105 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"golang.org/x/time/rate"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	"github.com/alibaba/polardbx-operator/pkg/operator/hint"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/config"
+	polardbxreconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+	autoscalersteps "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/steps/autoscaler"
+)
+
+type PolarDBXAutoscalerReconciler struct {
+	BaseRc *control.BaseReconcileContext
+	Logger logr.Logger
+	config.LoaderFactory
+
+	MaxConcurrency int
+}
+
+func (r *PolarDBXAutoscalerReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	log := r.Logger.WithValues("namespace", request.Namespace, "polardbxautoscaler", request.Name)
+
+	if hint.IsNamespacePaused(request.Namespace) {
+		log.Info("Reconciling is paused, skip")
+		return reconcile.Result{}, nil
+	}
+
+	rc := polardbxreconcile.NewContext(
+		control.NewBaseReconcileContextFrom(r.BaseRc, ctx, request),
+		r.LoaderFactory(),
+	)
+	rc.SetPolarDBXAutoscalerKey(request.NamespacedName)
+	defer rc.Close()
+
+	autoscaler, err := rc.GetPolarDBXAutoscaler()
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Info("The polardbx autoscaler object not found, might be deleted. Just ignore.")
+			return reconcile.Result{}, nil
+		}
+		log.Error(err, "Unable to get polardbx autoscaler object.")
+		return reconcile.Result{}, err
+	}
+	if !autoscaler.DeletionTimestamp.IsZero() {
+		log.Info("The polardbx autoscaler is being deleted, skip.")
+		return reconcile.Result{}, nil
+	}
+	rc.SetPolarDBXKey(types.NamespacedName{
+		Namespace: request.Namespace,
+		Name:      autoscaler.Spec.Cluster.Name,
+	})
+
+	task := r.newReconcileTask()
+	return control.NewExecutor(log).Execute(rc, task)
+}
+
+func (r *PolarDBXAutoscalerReconciler) newReconcileTask() *control.Task {
+	task := control.NewTask()
+	defer autoscalersteps.PersistentStatusChanges(task, true)
+
+	autoscalersteps.ScaleCN(task)
+	return task
+}
+
+func (r *PolarDBXAutoscalerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: r.MaxConcurrency,
+			RateLimiter: workqueue.NewMaxOfRateLimiter(
+				workqueue.NewItemExponentialFailureRateLimiter(5*time.Millisecond, 300*time.Second),
+				// 10 qps, 100 bucket size.  This is only for retry speed. It's only the overall factor (not per item).
+				&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(10), 100)},
+			),
+		}).
+		For(&polardbxv1.PolarDBXAutoscaler{}).
+		Complete(r)
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
 	"github.com/alibaba/polardbx-operator/pkg/operator/hint"
 	"github.com/alibaba/polardbx-operator/pkg/operator/v1/config"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
 	polardbxreconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
 	commonsteps "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/steps/backup/common"
 	"github.com/go-logr/logr"
@@ -69,6 +70,12 @@ func (r *PolarDBXBackupReconciler) Reconcile(ctx context.Context, request reconc
 		return reconcile.Result{}, err
 	}
 
+	// Imported backups share the files of the source, which is managed in its own place.
+	if _, ok := polardbxBackup.Annotations[polardbxmeta.AnnotationBackupImportedFrom]; ok {
+		log.Info("The polardbx backup is imported, skip.")
+		return reconcile.Result{}, nil
+	}
+
 	rc.SetPolarDBXKey(types.NamespacedName{
 		Namespace: request.Namespace,
 		Name:      polardbxBackup.Spec.Cluster.Name,
@@ -82,45 +89,91 @@ func (r *PolarDBXBackupReconciler) newReconcileTask(rc *polardbxreconcile.Contex
 	log = log.WithValues("phase", backup.Status.Phase)
 
 	task := control.NewTask()
+	// The files are cleaned by the clean policy before the backup object is gone.
+	if !backup.DeletionTimestamp.IsZero() {
+		commonsteps.CleanBackupFilesOnDeletion(task)
+		return task
+	}
+
 	defer commonsteps.PersistentStatusChanges(task, true)
+	defer commonsteps.TraceBackupLifecycle(task, true)
+	defer commonsteps.UpdateBackupConditionsAndProgress(task, true)
+
+	commonsteps.CheckBackupCircuitBreaker(task)
+	commonsteps.SyncBackupFilesFinalizer(task)
+
+	// Volume snapshots have no binlogs to collect, nor files to upload.
+	volumeSnapshot := backup.Spec.Engine == polardbxv1.BackupEngineVolumeSnapshot
 
 	switch backup.Status.Phase {
 	case polardbxv1.BackupNew:
+		if backup.Spec.CopyFrom != nil {
+			commonsteps.PrepareBackupCopy(task)
+			commonsteps.TransferPhaseTo(polardbxv1.BackupCopying, false)(task)
+			break
+		}
 		commonsteps.UpdateBackupStartInfo(task)
 		//locked binlog purge
-		commonsteps.LockXStoreBinlogPurge(task)
+		control.When(!volumeSnapshot, commonsteps.LockXStoreBinlogPurge)(task)
 		commonsteps.CreateBackupJobsForXStore(task)
 		commonsteps.TransferPhaseTo(polardbxv1.FullBackuping, false)(task)
 	case polardbxv1.FullBackuping:
+		// Start the queued xstore backups once running ones finish their full backups.
+		if len(backup.Status.QueuedXStores) > 0 {
+			commonsteps.CreateBackupJobsForXStore(task)
+		}
 		commonsteps.WaitAllBackupJobsFinished(task)
 		if backup.Status.Phase == polardbxv1.BackupFailed {
 			commonsteps.TransferPhaseTo(polardbxv1.BackupFailed, false)(task)
+		} else if volumeSnapshot {
+			commonsteps.TransferPhaseTo(polardbxv1.BinlogBackuping, false)(task)
 		} else {
 			commonsteps.TransferPhaseTo(polardbxv1.BackupCollecting, false)(task)
 		}
 	case polardbxv1.BackupCollecting:
 		commonsteps.CollectBinlogStartIndex(task)
 		commonsteps.DrainCommittingTrans(task)
+		commonsteps.CollectGMSBinlogEndIndex(task)
 		commonsteps.SendHeartBeat(task)
+		commonsteps.CollectCDCCheckpoint(task)
 		commonsteps.WaitHeartbeatSentToFollower(task)
 		commonsteps.CollectBinlogEndIndex(task)
 		commonsteps.TransferPhaseTo(polardbxv1.BackupCalculating, false)(task)
 	case polardbxv1.BackupCalculating:
 		commonsteps.WaitAllCollectBinlogJobFinished(task)
-		commonsteps.PrepareSeekCpJobContext(task)
-		commonsteps.CreateSeekCpJob(task)
-		commonsteps.WaitUntilSeekCpJobFinished(task)
+		commonsteps.PrepareCheckpoint(task)
+		commonsteps.StartCheckpoint(task)
+		commonsteps.WaitUntilCheckpointFinished(task)
 		commonsteps.TransferPhaseTo(polardbxv1.BinlogBackuping, false)(task)
 	case polardbxv1.BinlogBackuping:
-		commonsteps.WaitAllBinlogJobFinished(task)
+		control.When(!volumeSnapshot, commonsteps.WaitAllBinlogJobFinished)(task)
 		commonsteps.SavePXCSecrets(task)
 		commonsteps.TransferPhaseTo(polardbxv1.BackupFinished, false)(task)
+	case polardbxv1.BackupCopying:
+		commonsteps.CopyBackupFiles(task)
+		commonsteps.CloneXStoreBackups(task)
+		commonsteps.WaitAllXStoreBackupClonesFinished(task)
+		commonsteps.CloneBackupSecrets(task)
+		commonsteps.TransferPhaseTo(polardbxv1.BackupFinished, false)(task)
 	case polardbxv1.BackupFinished:
-		commonsteps.UnLockXStoreBinlogPurge(task)
-		commonsteps.RemoveSeekCpJob(task)
+		// Copies never lock the binlog purge of the cluster.
+		control.When(backup.Spec.CopyFrom == nil && !volumeSnapshot, commonsteps.UnLockXStoreBinlogPurge)(task)
+		commonsteps.CleanupCheckpoint(task)
+		commonsteps.IndexRecoverableWindow(task)
+		control.When(!volumeSnapshot,
+			commonsteps.ShareBackupObject,
+			commonsteps.PreviewRestore,
+			commonsteps.CheckBackupRestorable,
+			commonsteps.ExportBackupManifest,
+			commonsteps.VerifyBackupImmutability,
+		)(task)
 		commonsteps.RemoveBackupOverRetention(task)
 		log.Info("Finished phase.")
 	case polardbxv1.BackupFailed:
+		control.When(backup.Spec.CopyFrom == nil && !volumeSnapshot, commonsteps.UnLockXStoreBinlogPurge)(task)
+		commonsteps.WaitFailedArtifactRetention(task)
+		commonsteps.CleanupCheckpoint(task)
+		control.When(!volumeSnapshot, commonsteps.RemoveFailedBackupFiles)(task)
 		commonsteps.DeleteBackupJobsOnFailure(task)
 		log.Info("Failed phase.")
 	default:
@@ -133,7 +186,17 @@ func (r *PolarDBXBackupReconciler) reconcile(rc *polardbxreconcile.Context, pola
 	log = log.WithValues("phase", polardbxBackup.Status.Phase)
 
 	task := r.newReconcileTask(rc, polardbxBackup, log)
-	return control.NewExecutor(log).Execute(rc, task)
+	result, err := control.NewExecutor(log).Execute(rc, task)
+
+	open, observeErr := commonsteps.ObserveBackupCircuitBreaker(rc, err)
+	if observeErr != nil {
+		log.Error(observeErr, "Unable to update circuit breaker of backup.")
+	}
+	if open {
+		log.Info("Circuit of backup is open, stop retrying.", "error", err.Error())
+		return reconcile.Result{}, nil
+	}
+	return result, err
 }
 
 func (r *PolarDBXBackupReconciler) SetupWithManager(mgr ctrl.Manager) error {
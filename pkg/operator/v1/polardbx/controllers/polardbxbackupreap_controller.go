@@ -0,0 +1,111 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"golang.org/x/time/rate"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	"github.com/alibaba/polardbx-operator/pkg/operator/hint"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/config"
+	polardbxreconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+	reapsteps "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/steps/backup/reap"
+)
+
+type PolarDBXBackupReapReconciler struct {
+	BaseRc *control.BaseReconcileContext
+	Logger logr.Logger
+	config.LoaderFactory
+
+	MaxConcurrency int
+}
+
+func (r *PolarDBXBackupReapReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	log := r.Logger.WithValues("namespace", request.Namespace, "polardbxbackupreap", request.Name)
+
+	if hint.IsNamespacePaused(request.Namespace) {
+		log.Info("Reconciling is paused, skip")
+		return reconcile.Result{}, nil
+	}
+
+	rc := polardbxreconcile.NewContext(
+		control.NewBaseReconcileContextFrom(r.BaseRc, ctx, request),
+		r.LoaderFactory(),
+	)
+	rc.SetPolarDBXBackupReapKey(request.NamespacedName)
+	defer rc.Close()
+
+	reap, err := rc.GetPolarDBXBackupReap()
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Info("The polardbx backup reap object not found, might be deleted. Just ignore.")
+			return reconcile.Result{}, nil
+		}
+		log.Error(err, "Unable to get polardbx backup reap object.")
+		return reconcile.Result{}, err
+	}
+	rc.SetPolarDBXKey(types.NamespacedName{
+		Namespace: request.Namespace,
+		Name:      reap.Spec.Cluster.Name,
+	})
+
+	log = log.WithValues("phase", reap.Status.Phase)
+	task := r.newReconcileTask(rc, reap, log)
+	return control.NewExecutor(log).Execute(rc, task)
+}
+
+func (r *PolarDBXBackupReapReconciler) newReconcileTask(rc *polardbxreconcile.Context, reap *polardbxv1.PolarDBXBackupReap, log logr.Logger) *control.Task {
+	task := control.NewTask()
+	defer reapsteps.PersistentStatusChanges(task, true)
+
+	switch reap.Status.Phase {
+	case polardbxv1.ReapNew:
+		reapsteps.StartReap(task)
+	case polardbxv1.ReapReaping:
+		reapsteps.ReapClusterBackups(task)
+	case polardbxv1.ReapFinished, polardbxv1.ReapFailed:
+		log.Info("Backup reap is over.", "deleted", reap.Status.DeletedCount, "skipped", reap.Status.SkippedCount)
+	default:
+		log.Info("Unrecognized phase for backup reap")
+	}
+	return task
+}
+
+func (r *PolarDBXBackupReapReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: r.MaxConcurrency,
+			RateLimiter: workqueue.NewMaxOfRateLimiter(
+				workqueue.NewItemExponentialFailureRateLimiter(5*time.Millisecond, 300*time.Second),
+				// 10 qps, 100 bucket size.  This is only for retry speed. It's only the overall factor (not per item).
+				&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(10), 100)},
+			),
+		}).
+		For(&polardbxv1.PolarDBXBackupReap{}).
+		Complete(r)
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"golang.org/x/time/rate"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	"github.com/alibaba/polardbx-operator/pkg/operator/hint"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/config"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	polardbxreconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+	schedulesteps "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/steps/backup/schedule"
+)
+
+type PolarDBXBackupScheduleReconciler struct {
+	BaseRc *control.BaseReconcileContext
+	Logger logr.Logger
+	config.LoaderFactory
+
+	MaxConcurrency int
+}
+
+func (r *PolarDBXBackupScheduleReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	log := r.Logger.WithValues("namespace", request.Namespace, "polardbxbackupschedule", request.Name)
+
+	if hint.IsNamespacePaused(request.Namespace) {
+		log.Info("Reconciling is paused, skip")
+		return reconcile.Result{}, nil
+	}
+
+	rc := polardbxreconcile.NewContext(
+		control.NewBaseReconcileContextFrom(r.BaseRc, ctx, request),
+		r.LoaderFactory(),
+	)
+	rc.SetPolarDBXBackupScheduleKey(request.NamespacedName)
+	defer rc.Close()
+
+	schedule, err := rc.GetPolarDBXBackupSchedule()
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Info("The polardbx backup schedule object not found, might be deleted. Just ignore.")
+			return reconcile.Result{}, nil
+		}
+		log.Error(err, "Unable to get polardbx backup schedule object.")
+		return reconcile.Result{}, err
+	}
+	if !schedule.DeletionTimestamp.IsZero() {
+		log.Info("The polardbx backup schedule is being deleted, skip.")
+		return reconcile.Result{}, nil
+	}
+	rc.SetPolarDBXKey(types.NamespacedName{
+		Namespace: request.Namespace,
+		Name:      schedule.Spec.BackupSpec.Cluster.Name,
+	})
+
+	task := r.newReconcileTask()
+	return control.NewExecutor(log).Execute(rc, task)
+}
+
+func (r *PolarDBXBackupScheduleReconciler) newReconcileTask() *control.Task {
+	task := control.NewTask()
+	defer schedulesteps.PersistentStatusChanges(task, true)
+
+	schedulesteps.PruneScheduledBackups(task)
+	schedulesteps.CollectExpiredBackupFiles(task)
+	schedulesteps.ScheduleBackup(task)
+	return task
+}
+
+// mapRequestsOfScheduledBackup enqueues the schedule of the backup, so that its status is kept fresh
+// and the history is pruned once the backup finishes.
+func mapRequestsOfScheduledBackup(object client.Object) []reconcile.Request {
+	if scheduleName, ok := object.GetLabels()[polardbxmeta.LabelBackupSchedule]; ok {
+		return []reconcile.Request{
+			{
+				NamespacedName: types.NamespacedName{
+					Name:      scheduleName,
+					Namespace: object.GetNamespace(),
+				},
+			},
+		}
+	}
+	return nil
+}
+
+func (r *PolarDBXBackupScheduleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: r.MaxConcurrency,
+			RateLimiter: workqueue.NewMaxOfRateLimiter(
+				workqueue.NewItemExponentialFailureRateLimiter(5*time.Millisecond, 300*time.Second),
+				// 10 qps, 100 bucket size.  This is only for retry speed. It's only the overall factor (not per item).
+				&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(10), 100)},
+			),
+		}).
+		For(&polardbxv1.PolarDBXBackupSchedule{}).
+		// Watches the backups of schedules, which aren't owned by them.
+		Watches(
+			&source.Kind{Type: &polardbxv1.PolarDBXBackup{}},
+			handler.EnqueueRequestsFromMapFunc(mapRequestsOfScheduledBackup),
+		).
+		Complete(r)
+}
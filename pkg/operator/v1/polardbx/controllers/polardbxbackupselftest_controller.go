@@ -0,0 +1,129 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"golang.org/x/time/rate"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	"github.com/alibaba/polardbx-operator/pkg/operator/hint"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/config"
+	polardbxreconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+	selfteststeps "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/steps/backup/selftest"
+)
+
+type PolarDBXBackupSelfTestReconciler struct {
+	BaseRc *control.BaseReconcileContext
+	Logger logr.Logger
+	config.LoaderFactory
+
+	MaxConcurrency int
+}
+
+func (r *PolarDBXBackupSelfTestReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	log := r.Logger.WithValues("namespace", request.Namespace, "polardbxbackupselftest", request.Name)
+
+	if hint.IsNamespacePaused(request.Namespace) {
+		log.Info("Reconciling is paused, skip")
+		return reconcile.Result{}, nil
+	}
+
+	rc := polardbxreconcile.NewContext(
+		control.NewBaseReconcileContextFrom(r.BaseRc, ctx, request),
+		r.LoaderFactory(),
+	)
+	rc.SetPolarDBXBackupSelfTestKey(request.NamespacedName)
+	defer rc.Close()
+
+	selfTest, err := rc.GetPolarDBXBackupSelfTest()
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Info("The polardbx backup self test object not found, might be deleted. Just ignore.")
+			return reconcile.Result{}, nil
+		}
+		log.Error(err, "Unable to get polardbx backup self test object.")
+		return reconcile.Result{}, err
+	}
+
+	// The restored cluster is verified, and the tested cluster otherwise.
+	clusterName := selfTest.Spec.Cluster.Name
+	if selfTest.Status.Phase == polardbxv1.SelfTestVerifying {
+		clusterName = selfteststeps.RestoredClusterNameOf(selfTest)
+	}
+	rc.SetPolarDBXKey(types.NamespacedName{
+		Namespace: request.Namespace,
+		Name:      clusterName,
+	})
+
+	log = log.WithValues("phase", selfTest.Status.Phase)
+	task := r.newReconcileTask(rc, selfTest, log)
+	return control.NewExecutor(log).Execute(rc, task)
+}
+
+func (r *PolarDBXBackupSelfTestReconciler) newReconcileTask(rc *polardbxreconcile.Context, selfTest *polardbxv1.PolarDBXBackupSelfTest, log logr.Logger) *control.Task {
+	task := control.NewTask()
+	defer selfteststeps.PersistentStatusChanges(task, true)
+
+	selfteststeps.CheckSelfTestTimeout(task)
+
+	switch selfTest.Status.Phase {
+	case polardbxv1.SelfTestNew:
+		selfteststeps.StartSelfTest(task)
+	case polardbxv1.SelfTestBackingUp:
+		selfteststeps.CreateSelfTestBackup(task)
+		selfteststeps.WaitUntilSelfTestBackupFinished(task)
+	case polardbxv1.SelfTestRestoring:
+		selfteststeps.CreateSelfTestRestoredCluster(task)
+		selfteststeps.WaitUntilSelfTestClusterRestored(task)
+	case polardbxv1.SelfTestVerifying:
+		selfteststeps.VerifySelfTestRestoredCluster(task)
+	case polardbxv1.SelfTestCleaning:
+		selfteststeps.CleanSelfTestObjects(task)
+	case polardbxv1.SelfTestFinished:
+		log.Info("Finished phase.", "result", selfTest.Status.Result)
+	default:
+		log.Info("Unrecognized phase for backup self test")
+	}
+	return task
+}
+
+func (r *PolarDBXBackupSelfTestReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: r.MaxConcurrency,
+			RateLimiter: workqueue.NewMaxOfRateLimiter(
+				workqueue.NewItemExponentialFailureRateLimiter(5*time.Millisecond, 300*time.Second),
+				// 10 qps, 100 bucket size.  This is only for retry speed. It's only the overall factor (not per item).
+				&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(10), 100)},
+			),
+		}).
+		For(&polardbxv1.PolarDBXBackupSelfTest{}).
+		Owns(&polardbxv1.PolarDBXBackup{}).
+		Owns(&polardbxv1.PolarDBXCluster{}).
+		Complete(r)
+}
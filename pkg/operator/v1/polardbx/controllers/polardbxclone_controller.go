@@ -0,0 +1,122 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"golang.org/x/time/rate"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	"github.com/alibaba/polardbx-operator/pkg/operator/hint"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/config"
+	polardbxreconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+	clonesteps "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/steps/backup/clone"
+)
+
+type PolarDBXCloneReconciler struct {
+	BaseRc *control.BaseReconcileContext
+	Logger logr.Logger
+	config.LoaderFactory
+
+	MaxConcurrency int
+}
+
+func (r *PolarDBXCloneReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	log := r.Logger.WithValues("namespace", request.Namespace, "polardbxclone", request.Name)
+
+	if hint.IsNamespacePaused(request.Namespace) {
+		log.Info("Reconciling is paused, skip")
+		return reconcile.Result{}, nil
+	}
+
+	rc := polardbxreconcile.NewContext(
+		control.NewBaseReconcileContextFrom(r.BaseRc, ctx, request),
+		r.LoaderFactory(),
+	)
+	rc.SetPolarDBXCloneKey(request.NamespacedName)
+	defer rc.Close()
+
+	clone, err := rc.GetPolarDBXClone()
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Info("The polardbx clone object not found, might be deleted. Just ignore.")
+			return reconcile.Result{}, nil
+		}
+		log.Error(err, "Unable to get polardbx clone object.")
+		return reconcile.Result{}, err
+	}
+
+	rc.SetPolarDBXKey(types.NamespacedName{
+		Namespace: request.Namespace,
+		Name:      clone.Spec.Source.Name,
+	})
+
+	log = log.WithValues("phase", clone.Status.Phase)
+	task := r.newReconcileTask(rc, clone, log)
+	return control.NewExecutor(log).Execute(rc, task)
+}
+
+func (r *PolarDBXCloneReconciler) newReconcileTask(rc *polardbxreconcile.Context, clone *polardbxv1.PolarDBXClone, log logr.Logger) *control.Task {
+	task := control.NewTask()
+	defer clonesteps.PersistentStatusChanges(task, true)
+
+	clonesteps.CheckCloneTimeout(task)
+
+	switch clone.Status.Phase {
+	case polardbxv1.CloneNew:
+		clonesteps.StartClone(task)
+	case polardbxv1.CloneBackingUp:
+		clonesteps.CreateCloneBackup(task)
+		clonesteps.WaitUntilCloneBackupFinished(task)
+	case polardbxv1.CloneRestoring:
+		clonesteps.CreateClonedCluster(task)
+		clonesteps.CopyCloneParameters(task)
+		clonesteps.WaitUntilClonedClusterRunning(task)
+	case polardbxv1.CloneFinished:
+		log.Info("Finished phase.", "cluster", clone.Status.Cluster)
+	case polardbxv1.CloneFailed:
+		log.Info("Failed phase.", "message", clone.Status.Message)
+	default:
+		log.Info("Unrecognized phase for clone")
+	}
+	return task
+}
+
+func (r *PolarDBXCloneReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: r.MaxConcurrency,
+			RateLimiter: workqueue.NewMaxOfRateLimiter(
+				workqueue.NewItemExponentialFailureRateLimiter(5*time.Millisecond, 300*time.Second),
+				// 10 qps, 100 bucket size.  This is only for retry speed. It's only the overall factor (not per item).
+				&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(10), 100)},
+			),
+		}).
+		For(&polardbxv1.PolarDBXClone{}).
+		Owns(&polardbxv1.PolarDBXBackup{}).
+		Complete(r)
+}
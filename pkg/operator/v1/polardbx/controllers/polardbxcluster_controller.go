@@ -52,6 +52,7 @@ import (
 	guidesteps "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/steps/instance/guide"
 	rebalancesteps "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/steps/instance/rebalance"
 	restartsteps "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/steps/instance/restart"
+	restoresteps "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/steps/instance/restore"
 )
 
 type PolarDBXReconciler struct {
@@ -96,6 +97,7 @@ func (r *PolarDBXReconciler) newReconcileTask(rc *polardbxreconcile.Context, pol
 
 	defer commonsteps.PersistentStatus(task, true)
 	defer commonsteps.PersistentPolarDBXCluster(task, true)
+	defer commonsteps.UpdateStatusConditionsAndProgress(task, true)
 	defer commonsteps.UpdateDisplayReplicas(task, true)
 
 	// Abort immediately if operator is hinted to be forbidden.
@@ -127,6 +129,7 @@ func (r *PolarDBXReconciler) newReconcileTask(rc *polardbxreconcile.Context, pol
 		commonsteps.GenerateRandInStatus(task)
 		commonsteps.InitializeServiceName(task)
 		control.When(polardbx.Spec.Restore != nil,
+			restoresteps.ImportBackupSet,
 			commonsteps.SyncSpecFromBackupSet)(task)
 		commonsteps.TransferPhaseTo(polardbxv1polardbx.PhasePending, true)(task)
 
@@ -134,6 +137,7 @@ func (r *PolarDBXReconciler) newReconcileTask(rc *polardbxreconcile.Context, pol
 		checksteps.CheckStorageEngines(task)
 		commonsteps.UpdateSnapshotAndObservedGeneration(task)
 		instancesteps.CreateSecretsIfNotFound(task)
+		instancesteps.ReconcileTLSCertificates(task)
 		instancesteps.CreateServicesIfNotFound(task)
 		instancesteps.CreateConfigMapsIfNotFound(task)
 		commonsteps.InitializeParameterTemplate(task)
@@ -155,6 +159,12 @@ func (r *PolarDBXReconciler) newReconcileTask(rc *polardbxreconcile.Context, pol
 		instancesteps.CreateOrReconcileGMS(task)
 		instancesteps.CreateOrReconcileDNs(task)
 
+		// Track the restore progress of each shard.
+		control.When(helper.IsPhaseIn(polardbx, polardbxv1polardbx.PhaseRestoring),
+			restoresteps.UpdateRestoreShardStatus,
+			restoresteps.RetryFailedRestoreShards,
+		)(task)
+
 		// After GMS' ready, do initialization.
 		control.Block(
 			instancesteps.WaitUntilGMSReady,
@@ -203,6 +213,11 @@ func (r *PolarDBXReconciler) newReconcileTask(rc *polardbxreconcile.Context, pol
 		// Schedule after 10 seconds.
 		defer control.ScheduleAfter(10*time.Second)(task, true)
 
+		// Goto suspended if requested.
+		control.When(helper.IsPhaseIn(polardbx, polardbxv1polardbx.PhaseRunning) && polardbx.Spec.Suspended,
+			commonsteps.TransferPhaseTo(polardbxv1polardbx.PhaseSuspended, true),
+		)(task)
+
 		// Restart polardbx when restart parameters changed
 		control.When(rc.GetPolarDBXRestarting(),
 			restartsteps.GetRestartingPods,
@@ -215,6 +230,11 @@ func (r *PolarDBXReconciler) newReconcileTask(rc *polardbxreconcile.Context, pol
 			// gmssteps.SyncDynamicConfigs(false),
 		)(task)
 
+		// Track the replication lag from the primary.
+		control.When(readonly,
+			commonsteps.UpdateReadonlyReplicationStatus,
+		)(task)
+
 		// Deal with lock.
 		control.Branch(helper.IsPhaseIn(polardbx, polardbxv1polardbx.PhaseRunning),
 			control.When(helper.IsAnnotationIndicatesToLock(polardbx),
@@ -227,15 +247,33 @@ func (r *PolarDBXReconciler) newReconcileTask(rc *polardbxreconcile.Context, pol
 			),
 		)(task)
 
+		// The upgrade progress only presents while upgrading.
+		instancesteps.ForgetUpgradeProgressMetrics(task)
+
 		// Detect changes.
 		control.When(helper.IsTopologyOrStaticConfigChanges(polardbx),
 			commonsteps.TransferPhaseTo(polardbxv1polardbx.PhaseUpgrading, true),
 		)(task)
 
+		// Rotate the certificates before expiry, and roll CN and DN to them.
+		control.Block(
+			instancesteps.ReconcileTLSCertificates,
+			instancesteps.RollCNsToCertificateInUse,
+			instancesteps.SyncStoresTLS,
+		)(task)
+
+		// Sync the encryption of GMS and DNs.
+		instancesteps.SyncStoresTDE(task)
+
+		// Sync the slow log collector of GMS and DNs.
+		instancesteps.SyncStoresLogCollector(task)
+
 		// Always reconcile the stateless components (mainly for rebuilt).
 		instancesteps.CreateOrReconcileCNs(task)
 		instancesteps.CreateOrReconcileCDCs(task)
 		instancesteps.CreateFileStorage(task)
+		instancesteps.CreateOrReconcileCNPodDisruptionBudget(task)
+		instancesteps.ReconcileTemplateServices(task)
 
 		//sync cn label to pod without rebuild pod
 		instancesteps.TrySyncCnLabelToPodsDirectly(task)
@@ -251,6 +289,9 @@ func (r *PolarDBXReconciler) newReconcileTask(rc *polardbxreconcile.Context, pol
 			// gmssteps.SyncDynamicConfigs(false),
 		)(task)
 
+		// Report the progress of rolling out.
+		instancesteps.UpdateUpgradeProgressMetrics(task)
+
 		switch polardbx.Status.Stage {
 		case polardbxv1polardbx.StageEmpty:
 			// Before doing rebalancing, the controller always trying to update
@@ -259,6 +300,11 @@ func (r *PolarDBXReconciler) newReconcileTask(rc *polardbxreconcile.Context, pol
 			// Update before doing update.
 			commonsteps.UpdateSnapshotAndObservedGeneration(task)
 
+			// Upgrade the canary first if it's required.
+			control.When(helper.IsCanaryUpgradePending(polardbx),
+				commonsteps.TransferStageTo(polardbxv1polardbx.StageCanary, true),
+			)(task)
+
 			control.Block(
 				// GMS, update & wait
 				control.When(!readonly,
@@ -282,6 +328,21 @@ func (r *PolarDBXReconciler) newReconcileTask(rc *polardbxreconcile.Context, pol
 			// Prepare to rebalance data after DN stores are reconciled if necessary.
 			commonsteps.TransferStageTo(polardbxv1polardbx.StageRebalanceStart, true)(task)
 
+		case polardbxv1polardbx.StageCanary:
+			// Upgrade one CN and one DN, and hold until they are soaked or resumed.
+			instancesteps.StartCanaryUpgrade(task)
+			control.When(!readonly,
+				instancesteps.CreateOrReconcileGMS,
+				instancesteps.WaitUntilGMSReady,
+			)(task)
+			instancesteps.CreateOrReconcileCanaryCN(task)
+			instancesteps.CreateOrReconcileCanaryDN(task)
+			instancesteps.WaitUntilCanarySoaked(task)
+			instancesteps.RemoveCanaryCN(task)
+
+			// Go back to roll the rest.
+			commonsteps.TransferStageTo(polardbxv1polardbx.StageEmpty, true)(task)
+
 		case polardbxv1polardbx.StageRebalanceStart:
 			// Prepare rebalance task context.
 			rebalancesteps.PrepareRebalanceTaskContext(task)
@@ -299,6 +360,9 @@ func (r *PolarDBXReconciler) newReconcileTask(rc *polardbxreconcile.Context, pol
 			commonsteps.TransferStageTo(polardbxv1polardbx.StageRebalanceWatch, true)(task)
 
 		case polardbxv1polardbx.StageRebalanceWatch:
+			// Track moves, throttle and pause or resume.
+			rebalancesteps.ReconcileRebalanceMoves(task)
+
 			// Watch and update progress.
 			rebalancesteps.WatchRebalanceTaskAntUpdateProgress(10 * time.Second)(task)
 
@@ -341,9 +405,46 @@ func (r *PolarDBXReconciler) newReconcileTask(rc *polardbxreconcile.Context, pol
 			restartsteps.RestartingPods,
 		)(task)
 
+		// Re-restore the shards listed in annotation.
+		control.When(isRestoreResumable(polardbx),
+			restoresteps.ResumeFailedRestoreShards,
+			commonsteps.TransferPhaseTo(polardbxv1polardbx.PhaseRestoring, true),
+		)(task)
+
 		restartsteps.ClosePolarDBXRestartPhase(task)
+		commonsteps.TransferPhaseTo(polardbxv1polardbx.PhaseRunning, true)(task)
+	case polardbxv1polardbx.PhaseSuspended:
+		// Stop the CN and CDC first, then the GMS and DNs. Volumes, services and secrets are kept.
+		control.Branch(polardbx.Spec.Suspended,
+			control.Block(
+				instancesteps.ScaleCNAndCDCToZero,
+				instancesteps.WaitUntilCNAndCDCPodsStopped,
+				instancesteps.SuspendStores,
+				instancesteps.WaitUntilStoresSuspended,
+				control.RetryAfter(30*time.Second, "Suspended, check every 30 seconds..."),
+			),
+			commonsteps.TransferPhaseTo(polardbxv1polardbx.PhaseResuming, true),
+		)(task)
+	case polardbxv1polardbx.PhaseResuming:
+		// Resume the GMS and DNs, and wait until they have rejoined the consensus groups.
+		instancesteps.ResumeStores(task)
+		control.When(!readonly,
+			instancesteps.WaitUntilGMSReady,
+		)(task)
+		instancesteps.WaitUntilDNsReady(task)
+
+		// Then scale the CN and CDC back.
+		instancesteps.RestoreCNAndCDCReplicas(task)
+		instancesteps.WaitUntilCNDeploymentsRolledOut(task)
+		instancesteps.WaitUntilCDCDeploymentsRolledOut(task)
+
 		commonsteps.TransferPhaseTo(polardbxv1polardbx.PhaseRunning, true)(task)
 	case polardbxv1polardbx.PhaseFailed:
+		// Resume the restore of failed shards.
+		control.When(isRestoreResumable(polardbx),
+			restoresteps.ResumeFailedRestoreShards,
+			commonsteps.TransferPhaseTo(polardbxv1polardbx.PhaseRestoring, true),
+		)(task)
 	case polardbxv1polardbx.PhaseUnknown:
 
 	}
@@ -351,6 +452,12 @@ func (r *PolarDBXReconciler) newReconcileTask(rc *polardbxreconcile.Context, pol
 	return task
 }
 
+func isRestoreResumable(polardbx *polardbxv1.PolarDBXCluster) bool {
+	return polardbx.Spec.Restore != nil &&
+		polardbx.Status.RestoreStatus != nil &&
+		helper.IsAnnotationIndicatesToResumeRestore(polardbx)
+}
+
 func (r *PolarDBXReconciler) reconcile(rc *polardbxreconcile.Context, polardbx *polardbxv1.PolarDBXCluster, log logr.Logger) (reconcile.Result, error) {
 	log = log.WithValues("phase", polardbx.Status.Phase, "stage", polardbx.Status.Stage)
 
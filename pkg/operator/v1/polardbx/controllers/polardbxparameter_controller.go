@@ -115,15 +115,29 @@ func (r *PolarDBXParameterReconciler) newReconcileTask(rc *polardbxreconcile.Con
 			// Schedule after 10 seconds.
 			defer control.ScheduleAfter(10*time.Second)(task, true)
 
+			parametersteps.RollbackParameterIfRequested(task)
+
+			control.When(helper.IsParameterChanged(parameter),
+				parametersteps.SaveRollbackSnapshot,
+			)(task)
 			parametersteps.SyncPolarDBXParameterStatus(task)
 			control.When(helper.IsParameterChanged(parameter),
 				parametersteps.TransferParameterPhaseTo(polardbxv1polardbx.ParameterStatusModifying, true),
 			)(task)
 
+			// Static parameters queued take effect once the pods restarted.
+			parametersteps.ClearAppliedPendingRestart(task)
+
+			control.When(parametersteps.IsDriftCheckDue(parameter),
+				parametersteps.GetParametersRoleMap,
+				parametersteps.DetectParameterDrift,
+			)(task)
+
 		case polardbxv1polardbx.ParameterStatusModifying:
 			// perform different operations depending on the parameter type
 			parametersteps.GetParametersRoleMap(task)
 			parametersteps.GetRolesToRestart(task)
+			parametersteps.QueueDeferredRestart(task)
 
 			parametersteps.SyncCNRestartType(task)
 			parametersteps.SyncDNRestartType(task)
@@ -95,8 +95,20 @@ const (
 	SecretKeyRootCrt   = "root.crt"
 	SecretKeyServerKey = "server.key"
 	SecretKeyServerCrt = "server.crt"
+	SecretKeyRootKey   = "root.key"
 )
 
+// Keys of the secret issued by cert-manager.
+const (
+	CertManagerSecretKeyCACrt  = "ca.crt"
+	CertManagerSecretKeyTLSCrt = "tls.crt"
+	CertManagerSecretKeyTLSKey = "tls.key"
+)
+
+func NewCertificateName(polardbx *polardbxv1.PolarDBXCluster) string {
+	return fmt.Sprintf("%s-%s-tls", polardbx.Name, polardbx.Status.Rand)
+}
+
 // Conventions for configs.
 
 const (
@@ -144,6 +156,10 @@ func NewDNName(polardbx *polardbxv1.PolarDBXCluster, i int) string {
 	return fmt.Sprintf("%s-%s-dn-%d", polardbx.Name, polardbx.Status.Rand, i)
 }
 
+func NewPodDisruptionBudgetName(polardbx *polardbxv1.PolarDBXCluster, role string) string {
+	return fmt.Sprintf("%s-%s-%s", polardbx.Name, polardbx.Status.Rand, role)
+}
+
 // Conventions for labels.
 
 func ConstLabels(polardbx *polardbxv1.PolarDBXCluster) map[string]string {
@@ -37,7 +37,9 @@ import (
 	"github.com/alibaba/polardbx-operator/pkg/featuregate"
 	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
 	"github.com/alibaba/polardbx-operator/pkg/meta/core/gms"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/logcollector"
 	"github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/convention"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
 	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
 	"github.com/alibaba/polardbx-operator/pkg/probe"
 	copyutil "github.com/alibaba/polardbx-operator/pkg/util/copy"
@@ -261,7 +263,7 @@ func (f *objectFactory) newDeploymentUpgradeStrategy(polardbx *polardbxv1.PolarD
 		return appsv1.DeploymentStrategy{
 			Type: appsv1.RecreateDeploymentStrategyType,
 		}
-	case polardbxv1polardbx.RollingUpgradeStrategy:
+	case polardbxv1polardbx.RollingUpgradeStrategy, polardbxv1polardbx.CanaryUpgradeStrategy:
 		fallthrough
 	default:
 		half := intstr.FromString("50%")
@@ -343,6 +345,9 @@ func (f *objectFactory) newDeployment4CN(group string, mr *matchingRule, mustSta
 	labels[polardbxmeta.LabelGroup] = group
 
 	annotations := f.newPodAnnotations(polardbx)
+	if helper.IsTLSEnabled(polardbx) && polardbx.Status.TLS != nil {
+		annotations[polardbxmeta.AnnotationTLSCertificateHash] = polardbx.Status.TLS.CertificateHash
+	}
 
 	// Ports & Envs
 	ports := portsFactory.NewPortsForCNEngine(mustStaticPorts)
@@ -485,6 +490,25 @@ func (f *objectFactory) newDeployment4CN(group string, mr *matchingRule, mustSta
 		containers = append(containers, exporterContainer)
 	}
 
+	// Container log collector if the audit logs are shipped
+	if logCollectorSpec := helper.GetCNLogCollector(polardbx); logCollectorSpec != nil {
+		logCollectorContainer, err := logcollector.NewContainer(logCollectorSpec,
+			[]logcollector.Input{
+				{Tag: "cn.audit", Path: "/home/admin/drds-server/logs/*/sql.log"},
+			},
+			map[string]string{
+				"cluster": polardbx.Name,
+				"role":    polardbxmeta.RoleCN,
+				"log":     "audit",
+			},
+			volumeFactory.NewVolumeMountsForCNLogCollector(),
+		)
+		if err != nil {
+			return nil, err
+		}
+		containers = append(containers, *logCollectorContainer)
+	}
+
 	// Container init
 	initContainer := corev1.Container{
 		Name:  convention.ContainerInit,
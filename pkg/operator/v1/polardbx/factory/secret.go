@@ -17,10 +17,15 @@ limitations under the License.
 package factory
 
 import (
+	"crypto/x509"
+	"fmt"
+	"time"
+
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/rand"
 
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
 	"github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/convention"
 	"github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
 	"github.com/alibaba/polardbx-operator/pkg/util/defaults"
@@ -104,30 +109,14 @@ func (f *objectFactory) NewSecuritySecret() (*corev1.Secret, error) {
 	if helper.IsTLSEnabled(polardbx) {
 		tls := polardbx.Spec.Security.TLS
 		if tls.GenerateSelfSigned {
-			caCert, caPriv, caPem, err := ssl.GenerateCA(2048, "")
-			if err != nil {
-				return nil, err
-			}
-			_, priv, pem, err := ssl.GenerateSelfSignedCert(caCert, caPriv, 2048, "")
-			if err != nil {
-				return nil, err
-			}
-			caPemBytes, err := ssl.MarshalCert(caPem)
-			if err != nil {
-				return nil, err
-			}
-			pemBytes, err := ssl.MarshalCert(pem)
+			certs, err := NewSelfSignedCertificates(polardbx, nil, nil)
 			if err != nil {
 				return nil, err
 			}
-			keyBytes, err := ssl.MarshalKey(priv)
-			if err != nil {
-				return nil, err
+			for k, v := range certs {
+				stringData[k] = v
 			}
-			stringData[convention.SecretKeyRootCrt] = string(caPemBytes)
-			stringData[convention.SecretKeyServerKey] = string(keyBytes)
-			stringData[convention.SecretKeyServerCrt] = string(pemBytes)
-		} else {
+		} else if len(tls.SecretName) > 0 {
 			// Copy from user-defined secret.
 			secret, err := f.rc.GetSecret(tls.SecretName)
 			if err != nil {
@@ -148,3 +137,92 @@ func (f *objectFactory) NewSecuritySecret() (*corev1.Secret, error) {
 		StringData: stringData,
 	}, nil
 }
+
+// NewCertificateDNSNames returns the DNS names of the access service, and the ones of the services
+// of GMS and DNs if TLS is enabled on their engines. Only the cluster's own services are listed,
+// so the certificate can't be used to impersonate any other service in the namespace.
+func NewCertificateDNSNames(polardbx *polardbxv1.PolarDBXCluster) []string {
+	serviceNames := []string{defaults.NonEmptyStrOrDefault(polardbx.Spec.ServiceName, polardbx.Name)}
+	if helper.IsInternalTLSEnabled(polardbx) {
+		xstoreNames := make([]string, 0)
+		if !polardbx.Spec.ShareGMS {
+			xstoreNames = append(xstoreNames, convention.NewGMSName(polardbx))
+		}
+		// DNs being removed by scaling in are kept until they are gone.
+		dnReplicas := polardbx.Spec.Topology.Nodes.DN.Replicas
+		if snapshot := polardbx.Status.SpecSnapshot; snapshot != nil && snapshot.Topology.Nodes.DN.Replicas > dnReplicas {
+			dnReplicas = snapshot.Topology.Nodes.DN.Replicas
+		}
+		for i := 0; i < int(dnReplicas); i++ {
+			xstoreNames = append(xstoreNames, convention.NewDNName(polardbx, i))
+		}
+		// Services of xstores are named after them, with a suffix for the read-only ones.
+		for _, name := range xstoreNames {
+			serviceNames = append(serviceNames, name, name+"-ro")
+		}
+	}
+
+	dnsNames := make([]string, 0, 4*len(serviceNames))
+	for _, name := range serviceNames {
+		dnsNames = append(dnsNames,
+			name,
+			fmt.Sprintf("%s.%s", name, polardbx.Namespace),
+			fmt.Sprintf("%s.%s.svc", name, polardbx.Namespace),
+			fmt.Sprintf("%s.%s.svc.cluster.local", name, polardbx.Namespace),
+		)
+	}
+	return dnsNames
+}
+
+// NewSelfSignedCertificates signs a new server certificate with the CA given, which is renewed as well
+// if it's missing or about to expire. The CA's private key is kept with the certificates for rotation.
+func NewSelfSignedCertificates(polardbx *polardbxv1.PolarDBXCluster, caCrt, caKey []byte) (map[string]string, error) {
+	notAfter := time.Now().Add(helper.GetTLSDuration(polardbx))
+
+	var caCert *x509.Certificate
+	var caPriv interface{}
+	if len(caCrt) > 0 && len(caKey) > 0 {
+		cert, certErr := ssl.ParseCert(caCrt)
+		priv, keyErr := ssl.ParseKey(caKey)
+		// Keep the CA unless it expires before the new certificate.
+		if certErr == nil && keyErr == nil && cert.NotAfter.After(notAfter) {
+			caCert, caPriv = cert, priv
+		}
+	}
+	if caCert == nil {
+		cert, priv, caPem, err := ssl.GenerateCA(2048, "")
+		if err != nil {
+			return nil, err
+		}
+		caPemBytes, err := ssl.MarshalCert(caPem)
+		if err != nil {
+			return nil, err
+		}
+		caKeyBytes, err := ssl.MarshalKey(priv)
+		if err != nil {
+			return nil, err
+		}
+		caCert, caPriv, caCrt, caKey = cert, priv, caPemBytes, caKeyBytes
+	}
+
+	_, priv, pem, err := ssl.GenerateCertWithValidity(caCert, caPriv, 2048, "",
+		NewCertificateDNSNames(polardbx), notAfter)
+	if err != nil {
+		return nil, err
+	}
+	pemBytes, err := ssl.MarshalCert(pem)
+	if err != nil {
+		return nil, err
+	}
+	keyBytes, err := ssl.MarshalKey(priv)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{
+		convention.SecretKeyRootCrt:   string(caCrt),
+		convention.SecretKeyRootKey:   string(caKey),
+		convention.SecretKeyServerKey: string(keyBytes),
+		convention.SecretKeyServerCrt: string(pemBytes),
+	}, nil
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package factory
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1polardbx "github.com/alibaba/polardbx-operator/api/v1/polardbx"
+	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/convention"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	xstoreconvention "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/convention"
+	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
+)
+
+func isNodePortServiceType(t corev1.ServiceType) bool {
+	return t == corev1.ServiceTypeNodePort || t == corev1.ServiceTypeLoadBalancer
+}
+
+func newTemplateService(polardbx *polardbxv1.PolarDBXCluster, template *polardbxv1polardbx.ServiceTemplate,
+	name string, selector map[string]string) corev1.Service {
+	serviceType := template.Type
+	if len(serviceType) == 0 {
+		serviceType = corev1.ServiceTypeClusterIP
+	}
+
+	service := corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: polardbx.Namespace,
+			Labels: k8shelper.PatchLabels(
+				k8shelper.DeepCopyStrMap(template.Labels),
+				convention.ConstLabels(polardbx),
+				map[string]string{
+					polardbxmeta.LabelServiceTemplate: template.Name,
+				},
+			),
+			Annotations: k8shelper.DeepCopyStrMap(template.Annotations),
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     serviceType,
+			Selector: selector,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "mysql",
+					Protocol:   corev1.ProtocolTCP,
+					Port:       3306,
+					TargetPort: intstr.FromString("mysql"),
+				},
+			},
+		},
+	}
+	if isNodePortServiceType(serviceType) {
+		service.Spec.Ports[0].NodePort = template.NodePort
+		service.Spec.ExternalTrafficPolicy = template.ExternalTrafficPolicy
+	}
+	if serviceType == corev1.ServiceTypeLoadBalancer {
+		service.Spec.LoadBalancerSourceRanges = template.LoadBalancerSourceRanges
+	}
+	return service
+}
+
+func newDNTemplateService(polardbx *polardbxv1.PolarDBXCluster, template *polardbxv1polardbx.ServiceTemplate,
+	dn *polardbxv1.XStore) corev1.Service {
+	service := newTemplateService(polardbx, template, dn.Name+"-"+template.Name,
+		map[string]string{
+			xstoremeta.LabelName: dn.Name,
+		},
+	)
+	service.Spec.Type = corev1.ServiceTypeClusterIP
+	service.Spec.ClusterIP = corev1.ClusterIPNone
+	service.Spec.Ports[0].Name = xstoreconvention.PortAccess
+	service.Spec.Ports[0].TargetPort = intstr.FromString(xstoreconvention.PortAccess)
+	service.Spec.Ports[0].NodePort = 0
+	service.Spec.ExternalTrafficPolicy = ""
+	service.Spec.LoadBalancerSourceRanges = nil
+	return service
+}
+
+// NewTemplateServices returns the services of the service templates. Services of DN targets are
+// created for each of the given DNs.
+func NewTemplateServices(polardbx *polardbxv1.PolarDBXCluster, dns []*polardbxv1.XStore) []corev1.Service {
+	serviceName := convention.GetPolarDBXServiceName(polardbx)
+	cnType := polardbxmeta.CNTypeRW
+	if polardbx.Spec.Readonly {
+		cnType = polardbxmeta.CNTypeRO
+	}
+
+	services := make([]corev1.Service, 0, len(polardbx.Spec.ServiceTemplates))
+	for i := range polardbx.Spec.ServiceTemplates {
+		template := &polardbx.Spec.ServiceTemplates[i]
+		switch template.Target {
+		case "", polardbxv1polardbx.ServiceTargetCN:
+			services = append(services, newTemplateService(polardbx, template, serviceName+"-"+template.Name,
+				convention.ConstLabelsForCN(polardbx, cnType)))
+		case polardbxv1polardbx.ServiceTargetReadonlyCN:
+			if polardbx.Spec.Readonly {
+				continue
+			}
+			// CN pods of readonly clusters are labeled with the primary name.
+			services = append(services, newTemplateService(polardbx, template, serviceName+"-"+template.Name,
+				map[string]string{
+					polardbxmeta.LabelPrimaryName: polardbx.Name,
+					polardbxmeta.LabelRole:        polardbxmeta.RoleCN,
+					polardbxmeta.LabelCNType:      polardbxmeta.CNTypeRO,
+				}))
+		case polardbxv1polardbx.ServiceTargetDN:
+			for _, dn := range dns {
+				services = append(services, newDNTemplateService(polardbx, template, dn))
+			}
+		}
+	}
+	return services
+}
+
+// MergeTemplateService merges the desired service into the observed one and returns true if
+// anything is changed. Fields allocated by Kubernetes, e.g. the cluster IP and node ports not
+// specified, are kept.
+func MergeTemplateService(observed, desired *corev1.Service) bool {
+	changed := false
+
+	for _, m := range []struct{ observed, desired *map[string]string }{
+		{&observed.Labels, &desired.Labels},
+		{&observed.Annotations, &desired.Annotations},
+	} {
+		for k, v := range *m.desired {
+			if (*m.observed)[k] != v {
+				*m.observed = k8shelper.PatchLabels(*m.observed, map[string]string{k: v})
+				changed = true
+			}
+		}
+	}
+
+	ports := make([]corev1.ServicePort, len(desired.Spec.Ports))
+	copy(ports, desired.Spec.Ports)
+	if isNodePortServiceType(desired.Spec.Type) && isNodePortServiceType(observed.Spec.Type) {
+		for i := range ports {
+			if ports[i].NodePort != 0 {
+				continue
+			}
+			for _, p := range observed.Spec.Ports {
+				if p.Name == ports[i].Name {
+					ports[i].NodePort = p.NodePort
+				}
+			}
+		}
+	}
+
+	spec := observed.Spec.DeepCopy()
+	spec.Type = desired.Spec.Type
+	spec.Selector = desired.Spec.Selector
+	spec.Ports = ports
+	spec.ExternalTrafficPolicy = desired.Spec.ExternalTrafficPolicy
+	spec.LoadBalancerSourceRanges = desired.Spec.LoadBalancerSourceRanges
+	// The external traffic policy defaults to Cluster if unspecified.
+	if isNodePortServiceType(spec.Type) && len(spec.ExternalTrafficPolicy) == 0 {
+		spec.ExternalTrafficPolicy = observed.Spec.ExternalTrafficPolicy
+	}
+	if !equality.Semantic.DeepEqual(spec, &observed.Spec) {
+		observed.Spec = *spec
+		changed = true
+	}
+
+	return changed
+}
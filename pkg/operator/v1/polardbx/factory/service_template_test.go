@@ -0,0 +1,95 @@
+package factory
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1polardbx "github.com/alibaba/polardbx-operator/api/v1/polardbx"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
+)
+
+func TestNewTemplateServices(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	polardbx := &polardbxv1.PolarDBXCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "pxc", Namespace: "default"},
+		Spec: polardbxv1.PolarDBXClusterSpec{
+			ServiceTemplates: []polardbxv1polardbx.ServiceTemplate{
+				{
+					Name:        "lb",
+					Type:        corev1.ServiceTypeLoadBalancer,
+					Annotations: map[string]string{"service.beta.kubernetes.io/aws-load-balancer-internal": "true"},
+					NodePort:    30306,
+				},
+				{Name: "ro", Target: polardbxv1polardbx.ServiceTargetReadonlyCN},
+				{Name: "direct", Target: polardbxv1polardbx.ServiceTargetDN, Type: corev1.ServiceTypeNodePort},
+			},
+		},
+	}
+	dns := []*polardbxv1.XStore{
+		{ObjectMeta: metav1.ObjectMeta{Name: "pxc-dn-0"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "pxc-dn-1"}},
+	}
+
+	services := NewTemplateServices(polardbx, dns)
+	g.Expect(services).To(gomega.HaveLen(4))
+
+	lb := services[0]
+	g.Expect(lb.Name).To(gomega.Equal("pxc-lb"))
+	g.Expect(lb.Spec.Type).To(gomega.Equal(corev1.ServiceTypeLoadBalancer))
+	g.Expect(lb.Spec.Ports[0].NodePort).To(gomega.Equal(int32(30306)))
+	g.Expect(lb.Labels[polardbxmeta.LabelServiceTemplate]).To(gomega.Equal("lb"))
+	g.Expect(lb.Annotations).To(gomega.HaveKey("service.beta.kubernetes.io/aws-load-balancer-internal"))
+	g.Expect(lb.Spec.Selector[polardbxmeta.LabelCNType]).To(gomega.Equal(polardbxmeta.CNTypeRW))
+
+	ro := services[1]
+	g.Expect(ro.Spec.Type).To(gomega.Equal(corev1.ServiceTypeClusterIP))
+	g.Expect(ro.Spec.Selector[polardbxmeta.LabelPrimaryName]).To(gomega.Equal("pxc"))
+	g.Expect(ro.Spec.Selector[polardbxmeta.LabelCNType]).To(gomega.Equal(polardbxmeta.CNTypeRO))
+
+	for i, dn := range dns {
+		svc := services[2+i]
+		g.Expect(svc.Name).To(gomega.Equal(dn.Name + "-direct"))
+		g.Expect(svc.Spec.ClusterIP).To(gomega.Equal(corev1.ClusterIPNone))
+		g.Expect(svc.Spec.Type).To(gomega.Equal(corev1.ServiceTypeClusterIP))
+		g.Expect(svc.Spec.Selector).To(gomega.Equal(map[string]string{xstoremeta.LabelName: dn.Name}))
+	}
+
+	// Read-only endpoints are not created for readonly clusters.
+	polardbx.Spec.Readonly = true
+	g.Expect(NewTemplateServices(polardbx, nil)).To(gomega.HaveLen(1))
+}
+
+func TestMergeTemplateService(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	polardbx := &polardbxv1.PolarDBXCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "pxc", Namespace: "default"},
+		Spec: polardbxv1.PolarDBXClusterSpec{
+			ServiceTemplates: []polardbxv1polardbx.ServiceTemplate{
+				{Name: "np", Type: corev1.ServiceTypeNodePort},
+			},
+		},
+	}
+
+	desired := NewTemplateServices(polardbx, nil)[0]
+	observed := desired.DeepCopy()
+	observed.Spec.ClusterIP = "10.0.0.1"
+	observed.Spec.Ports[0].NodePort = 31000
+	observed.Spec.ExternalTrafficPolicy = corev1.ServiceExternalTrafficPolicyTypeCluster
+
+	// Allocated fields are kept.
+	g.Expect(MergeTemplateService(observed, &desired)).To(gomega.BeFalse())
+	g.Expect(observed.Spec.Ports[0].NodePort).To(gomega.Equal(int32(31000)))
+
+	polardbx.Spec.ServiceTemplates[0].NodePort = 32000
+	polardbx.Spec.ServiceTemplates[0].Annotations = map[string]string{"a": "b"}
+	desired = NewTemplateServices(polardbx, nil)[0]
+	g.Expect(MergeTemplateService(observed, &desired)).To(gomega.BeTrue())
+	g.Expect(observed.Spec.Ports[0].NodePort).To(gomega.Equal(int32(32000)))
+	g.Expect(observed.Spec.ClusterIP).To(gomega.Equal("10.0.0.1"))
+	g.Expect(observed.Annotations["a"]).To(gomega.Equal("b"))
+}
@@ -25,6 +25,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"strconv"
+	"strings"
 
 	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
 	polardbxv1common "github.com/alibaba/polardbx-operator/api/v1/common"
@@ -33,6 +34,7 @@ import (
 	"github.com/alibaba/polardbx-operator/pkg/featuregate"
 	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
 	"github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/convention"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
 	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
 	xstoreconvention "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/convention"
 	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
@@ -186,6 +188,71 @@ func (f *objectFactory) newXStoreNodeSetAffinity(polardbx *polardbxv1.PolarDBXCl
 	return affinity
 }
 
+// isXStoreSpreadMember tells if the nodes of the role are counted as the consensus members
+// to be spread, which are learners for readonly and candidates and voters otherwise.
+func isXStoreSpreadMember(role polardbxv1xstore.NodeRole, readonly bool) bool {
+	if readonly {
+		return role == polardbxv1xstore.RoleLearner
+	}
+	return role == polardbxv1xstore.RoleCandidate || role == polardbxv1xstore.RoleVoter
+}
+
+func newXStoreTopologySpreadConstraints(xstoreName string, readonly bool,
+	spread *polardbxv1polardbx.XStoreTopologySpread) []corev1.TopologySpreadConstraint {
+	topologyKey := spread.TopologyKey
+	if len(topologyKey) == 0 {
+		topologyKey = corev1.LabelTopologyZone
+	}
+	whenUnsatisfiable := spread.WhenUnsatisfiable
+	if len(whenUnsatisfiable) == 0 {
+		whenUnsatisfiable = corev1.DoNotSchedule
+	}
+
+	roles := []string{
+		strings.ToLower(string(polardbxv1xstore.RoleCandidate)),
+		strings.ToLower(string(polardbxv1xstore.RoleVoter)),
+	}
+	if readonly {
+		roles = []string{strings.ToLower(string(polardbxv1xstore.RoleLearner))}
+	}
+
+	return []corev1.TopologySpreadConstraint{
+		{
+			MaxSkew:           1,
+			TopologyKey:       topologyKey,
+			WhenUnsatisfiable: whenUnsatisfiable,
+			LabelSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					xstoremeta.LabelName: xstoreName,
+				},
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Key:      xstoremeta.LabelNodeRole,
+						Operator: metav1.LabelSelectorOpIn,
+						Values:   roles,
+					},
+				},
+			},
+		},
+	}
+}
+
+// spreadXStoreNodeSets sets the topology spread constraints on the templates of node
+// sets of consensus members, so that each of them is placed in a distinct domain.
+func spreadXStoreNodeSets(nodeSets []polardbxv1xstore.NodeSet, xstoreName string, readonly bool,
+	spread *polardbxv1polardbx.XStoreTopologySpread) {
+	if spread == nil {
+		return
+	}
+	for i := range nodeSets {
+		nodeSet := &nodeSets[i]
+		if !isXStoreSpreadMember(nodeSet.Role, readonly) || nodeSet.Template == nil {
+			continue
+		}
+		nodeSet.Template.Spec.TopologySpreadConstraints = newXStoreTopologySpreadConstraints(xstoreName, readonly, spread)
+	}
+}
+
 func xstoreNodeTemplateWithResources(template *polardbxv1xstore.NodeTemplate,
 	resources *polardbxv1common.ExtendedResourceRequirements) *polardbxv1xstore.NodeTemplate {
 	t := template.DeepCopy()
@@ -431,6 +498,9 @@ func (f *objectFactory) newXStore(
 	if err != nil {
 		return nil, err
 	}
+	if rule != nil {
+		spreadXStoreNodeSets(nodeSets, name, polardbx.Spec.Readonly, rule.Spread)
+	}
 
 	primaryXStoreName := name
 
@@ -487,6 +557,14 @@ func (f *objectFactory) newXStore(
 			},
 		},
 	}
+	if helper.IsInternalTLSEnabled(polardbx) {
+		xstore.Spec.TLS = &polardbxv1xstore.TLS{
+			SecretName: convention.NewSecretName(polardbx, convention.SecretTypeSecurity),
+		}
+	}
+	xstore.Spec.TDE = helper.GetTDE(polardbx).DeepCopy()
+	xstore.Spec.LogCollector = helper.GetStoreLogCollector(polardbx).DeepCopy()
+
 	restoreOpt := polardbx.Spec.Restore
 	if polardbx.Status.Phase == polardbxv1polardbx.PhaseRestoring && restoreOpt != nil {
 		if restoreOpt.BackupSet == "" || len(restoreOpt.BackupSet) == 0 {
@@ -494,8 +572,10 @@ func (f *objectFactory) newXStore(
 				From: polardbxv1.XStoreRestoreFrom{
 					XStoreName: restoreName,
 				},
-				Time:     restoreOpt.Time,
-				TimeZone: restoreOpt.TimeZone,
+				Time:                    restoreOpt.Time,
+				TimeZone:                restoreOpt.TimeZone,
+				PointInTime:             restoreOpt.PointInTime,
+				CompatibilityStrictness: restoreOpt.CompatibilityStrictness,
 			}
 		} else {
 			backupSet, err := f.GetXStoreBackupName(restoreOpt.BackupSet, restoreName)
@@ -507,6 +587,7 @@ func (f *objectFactory) newXStore(
 				From: polardbxv1.XStoreRestoreFrom{
 					XStoreName: restoreName,
 				},
+				CompatibilityStrictness: restoreOpt.CompatibilityStrictness,
 			}
 
 		}
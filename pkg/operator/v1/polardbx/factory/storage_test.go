@@ -0,0 +1,34 @@
+package factory
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	polardbxv1polardbx "github.com/alibaba/polardbx-operator/api/v1/polardbx"
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
+)
+
+func TestSpreadXStoreNodeSets(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	nodeSets := []polardbxv1xstore.NodeSet{
+		{Name: "cand", Role: polardbxv1xstore.RoleCandidate, Replicas: 2, Template: &polardbxv1xstore.NodeTemplate{}},
+		{Name: "log", Role: polardbxv1xstore.RoleVoter, Replicas: 1, Template: &polardbxv1xstore.NodeTemplate{}},
+		{Name: "learner", Role: polardbxv1xstore.RoleLearner, Replicas: 1, Template: &polardbxv1xstore.NodeTemplate{}},
+	}
+
+	spreadXStoreNodeSets(nodeSets, "pxc-dn-0", false, &polardbxv1polardbx.XStoreTopologySpread{})
+
+	for _, nodeSet := range nodeSets[:2] {
+		constraints := nodeSet.Template.Spec.TopologySpreadConstraints
+		g.Expect(constraints).To(gomega.HaveLen(1))
+		g.Expect(constraints[0].MaxSkew).To(gomega.Equal(int32(1)))
+		g.Expect(constraints[0].TopologyKey).To(gomega.Equal(corev1.LabelTopologyZone))
+		g.Expect(constraints[0].WhenUnsatisfiable).To(gomega.Equal(corev1.DoNotSchedule))
+		g.Expect(constraints[0].LabelSelector.MatchLabels[xstoremeta.LabelName]).To(gomega.Equal("pxc-dn-0"))
+		g.Expect(constraints[0].LabelSelector.MatchExpressions[0].Values).To(gomega.ConsistOf("candidate", "voter"))
+	}
+	g.Expect(nodeSets[2].Template.Spec.TopologySpreadConstraints).To(gomega.BeEmpty())
+}
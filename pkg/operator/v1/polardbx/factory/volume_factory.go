@@ -30,6 +30,7 @@ type VolumeFactory interface {
 	NewVolumesForCN() []corev1.Volume
 	NewVolumeMountsForCNEngine() []corev1.VolumeMount
 	NewVolumeMountsForCNExporter() []corev1.VolumeMount
+	NewVolumeMountsForCNLogCollector() []corev1.VolumeMount
 	NewVolumesForCDC() []corev1.Volume
 	NewVolumeMountsForCDCEngine() []corev1.VolumeMount
 }
@@ -269,6 +270,14 @@ func (v *volumeFactory) NewVolumeMountsForCNExporter() []corev1.VolumeMount {
 	return v.NewSystemVolumeMounts()
 }
 
+func (v *volumeFactory) NewVolumeMountsForCNLogCollector() []corev1.VolumeMount {
+	return append(v.NewSystemVolumeMounts(), corev1.VolumeMount{
+		Name:             "polardbx-log",
+		MountPath:        "/home/admin/drds-server/logs",
+		MountPropagation: mountPropagationModePtr(corev1.MountPropagationHostToContainer),
+	})
+}
+
 func (v *volumeFactory) NewVolumesForCDC() []corev1.Volume {
 	systemVols := v.NewSystemVolumes()
 	volumes := []corev1.Volume{
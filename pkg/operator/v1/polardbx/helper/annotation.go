@@ -18,6 +18,7 @@ package helper
 
 import (
 	"strconv"
+	"strings"
 
 	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
 	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
@@ -40,3 +41,35 @@ func IsAnnotationIndicatesToUnlock(polardbx *polardbxv1.PolarDBXCluster) bool {
 	lock, _ := strconv.ParseBool(lockVal)
 	return !lock
 }
+
+func IsAnnotationIndicatesToResumeRestore(polardbx *polardbxv1.PolarDBXCluster) bool {
+	val, ok := polardbx.Annotations[polardbxmeta.AnnotationRestoreResume]
+	if !ok {
+		return false
+	}
+	resume, _ := strconv.ParseBool(val)
+	return resume
+}
+
+func GetShardsToForceRestore(polardbx *polardbxv1.PolarDBXCluster) []string {
+	val, ok := polardbx.Annotations[polardbxmeta.AnnotationRestoreForceShards]
+	if !ok {
+		return nil
+	}
+	shards := make([]string, 0)
+	for _, s := range strings.Split(val, ",") {
+		if s = strings.TrimSpace(s); len(s) > 0 {
+			shards = append(shards, s)
+		}
+	}
+	return shards
+}
+
+func IsAnnotationIndicatesToResumeUpgrade(polardbx *polardbxv1.PolarDBXCluster) bool {
+	val, ok := polardbx.Annotations[polardbxmeta.AnnotationUpgradeResume]
+	if !ok {
+		return false
+	}
+	resume, _ := strconv.ParseBool(val)
+	return resume
+}
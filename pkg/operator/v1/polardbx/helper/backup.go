@@ -0,0 +1,593 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/hpfs/remote"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	"github.com/alibaba/polardbx-operator/pkg/util/tracing"
+)
+
+const (
+	defaultBackupCircuitBreakerThreshold = 10
+
+	// Errors may be long, e.g. with the output of commands.
+	maxBackupCircuitBreakerReasonLength = 512
+)
+
+// ossBackupStorageClasses are the storage classes of OSS, the value indicates whether objects
+// in the class must be thawed before being downloaded.
+var ossBackupStorageClasses = map[string]bool{
+	"Standard":    false,
+	"IA":          false,
+	"Archive":     true,
+	"ColdArchive": true,
+}
+
+// ValidateBackupStorageClass checks whether the storage class is supported by the storage.
+// Empty storage class means the default one and is always valid.
+func ValidateBackupStorageClass(storage polardbxv1.BackupStorage, storageClass string) error {
+	if len(storageClass) == 0 {
+		return nil
+	}
+	if storage != polardbxv1.OSS {
+		return fmt.Errorf("storage class is not supported by storage %s", storage)
+	}
+	if _, ok := ossBackupStorageClasses[storageClass]; !ok {
+		return fmt.Errorf("unknown storage class %s of storage %s", storageClass, storage)
+	}
+	return nil
+}
+
+// ValidateBackupEngine checks whether the engine can take the backup consistently. Volume snapshots
+// are cut under the read lock of a single xstore, which can't be held across the xstores of the cluster
+// at the same instant, so the backup must be of a single xstore.
+func ValidateBackupEngine(engine polardbxv1.BackupEngine, xstores []string) error {
+	if engine == polardbxv1.BackupEngineVolumeSnapshot && len(xstores) != 1 {
+		return errors.New("engine volumesnapshot only supports the backup of a single xstore")
+	}
+	return nil
+}
+
+// IsArchiveBackupStorageClass returns true if the objects in the storage class must be thawed
+// before being downloaded.
+func IsArchiveBackupStorageClass(storage polardbxv1.BackupStorage, storageClass string) bool {
+	return storage == polardbxv1.OSS && ossBackupStorageClasses[storageClass]
+}
+
+// LastCompletedBackup returns the last started backup of the whole cluster which is finished before
+// the time, nil if there's none. Backups of groups can't be used to restore the cluster.
+func LastCompletedBackup(backups []polardbxv1.PolarDBXBackup, before time.Time) *polardbxv1.PolarDBXBackup {
+	var lastBackup *polardbxv1.PolarDBXBackup
+	for i := range backups {
+		backup := &backups[i]
+		if backup.Status.Phase != polardbxv1.BackupFinished || len(backup.Spec.XStores) > 0 {
+			continue
+		}
+		if backup.Status.EndTime.After(before) {
+			continue
+		}
+		if lastBackup == nil || lastBackup.Status.StartTime.Before(backup.Status.StartTime) {
+			lastBackup = backup
+		}
+	}
+	return lastBackup
+}
+
+// BackupGroupMembers returns the xstores in the backup group, or all the xstores if the group
+// is empty. It fails if any xstore of the group is not found.
+func BackupGroupMembers(group []string, xstores []polardbxv1.XStore) ([]polardbxv1.XStore, error) {
+	if len(group) == 0 {
+		return xstores, nil
+	}
+	xstoreByName := make(map[string]*polardbxv1.XStore, len(xstores))
+	for i := range xstores {
+		xstoreByName[xstores[i].Name] = &xstores[i]
+	}
+	members := make([]polardbxv1.XStore, 0, len(group))
+	for _, name := range group {
+		xstore, ok := xstoreByName[name]
+		if !ok {
+			return nil, fmt.Errorf("xstore %s of backup group not found in cluster", name)
+		}
+		members = append(members, *xstore)
+	}
+	return members, nil
+}
+
+// CDCCheckpointDivergence returns the time between the heartbeat of the backup checkpoint and the
+// capture of the CDC position. The heartbeat name is the unix time when the heartbeat is sent.
+func CDCCheckpointDivergence(heartbeatName string, captureTime time.Time) (time.Duration, error) {
+	sent, err := strconv.ParseInt(heartbeatName, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid heartbeat name %q: %w", heartbeatName, err)
+	}
+	divergence := captureTime.Sub(time.Unix(sent, 0)).Truncate(time.Second)
+	if divergence < 0 {
+		divergence = 0
+	}
+	return divergence, nil
+}
+
+// supportsRetentionCredential tells whether the retention credential is supported by the storage.
+func supportsRetentionCredential(storage polardbxv1.BackupStorage) bool {
+	return storage == polardbxv1.OSS || storage == polardbxv1.S3
+}
+
+// retentionFileService returns the file service of the storage provider along with the auth and
+// params of the retention credential.
+func retentionFileService(ctx context.Context, c client.Reader, namespace string,
+	provider polardbxv1.BackupStorageProvider) (remote.FileService, map[string]string, map[string]string, error) {
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: provider.RetentionCredential.Name}, secret); err != nil {
+		return nil, nil, nil, fmt.Errorf("unable to get retention credential: %w", err)
+	}
+	auth := map[string]string{
+		"endpoint":      string(secret.Data["endpoint"]),
+		"access_key":    string(secret.Data["accessKey"]),
+		"access_secret": string(secret.Data["accessSecret"]),
+	}
+	params := map[string]string{"bucket": string(secret.Data["bucket"])}
+
+	protocol := "aliyun-oss"
+	if provider.StorageName == polardbxv1.S3 {
+		protocol = "s3"
+		auth["region"] = string(secret.Data["region"])
+		params["path_style"] = string(secret.Data["pathStyle"])
+	}
+	fs, err := remote.GetFileService(protocol)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return fs, auth, params, nil
+}
+
+// RemoveBackupFiles deletes the backup files under the prefix with the retention credential of the
+// storage provider, files are kept if the credential isn't specified. It returns the number of files
+// deleted.
+func RemoveBackupFiles(ctx context.Context, c client.Client, namespace string,
+	provider polardbxv1.BackupStorageProvider, prefix string) (int64, error) {
+	if provider.RetentionCredential == nil {
+		return 0, nil
+	}
+	if !supportsRetentionCredential(provider.StorageName) {
+		return 0, errors.New("retention credential is only supported by oss and s3")
+	}
+	// Never delete the whole bucket or the backups of other clusters by mistake.
+	if !strings.HasPrefix(prefix, polardbxmeta.BackupPath+"/") || strings.Count(strings.Trim(prefix, "/"), "/") < 2 {
+		return 0, fmt.Errorf("invalid prefix of backup files: %q", prefix)
+	}
+
+	fs, auth, params, err := retentionFileService(ctx, c, namespace, provider)
+	if err != nil {
+		return 0, err
+	}
+	remover, ok := fs.(remote.FileRemover)
+	if !ok {
+		return 0, errors.New("deleting files by prefix is not supported")
+	}
+	return remover.DeleteFiles(ctx, prefix, auth, params)
+}
+
+// AbortIncompleteUploads aborts the incomplete multipart uploads under the prefix which are initiated
+// before the deadline, with the retention credential of the storage provider. It returns all the
+// uploads found, the aborted ones are marked. Nothing is found if the credential isn't specified.
+func AbortIncompleteUploads(ctx context.Context, c client.Client, namespace string,
+	provider polardbxv1.BackupStorageProvider, prefix string, before time.Time) ([]polardbxv1.IncompleteUpload, error) {
+	if provider.RetentionCredential == nil || !supportsRetentionCredential(provider.StorageName) {
+		return nil, nil
+	}
+	// Never touch the uploads of other clusters by mistake.
+	if !strings.HasPrefix(prefix, polardbxmeta.BackupPath+"/") || strings.Count(strings.Trim(prefix, "/"), "/") < 1 {
+		return nil, fmt.Errorf("invalid prefix of backup files: %q", prefix)
+	}
+
+	fs, auth, params, err := retentionFileService(ctx, c, namespace, provider)
+	if err != nil {
+		return nil, err
+	}
+	manager, ok := fs.(remote.MultipartUploadManager)
+	if !ok {
+		return nil, errors.New("listing multipart uploads is not supported")
+	}
+	uploads, err := manager.ListMultipartUploads(ctx, prefix, auth, params)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make([]polardbxv1.IncompleteUpload, 0, len(uploads))
+	for _, upload := range uploads {
+		u := polardbxv1.IncompleteUpload{
+			Path:      upload.Path,
+			UploadId:  upload.UploadId,
+			Initiated: metav1.NewTime(upload.Initiated),
+		}
+		if upload.Initiated.Before(before) {
+			if err := manager.AbortMultipartUpload(ctx, upload, auth, params); err != nil {
+				return found, fmt.Errorf("unable to abort upload %s of %s: %w", upload.UploadId, upload.Path, err)
+			}
+			u.Aborted = true
+		}
+		found = append(found, u)
+	}
+	return found, nil
+}
+
+// StatBackupFiles lists the backup files under the prefix along with their stats, with the retention
+// credential of the storage provider. Nothing is listed and false is returned if the credential isn't
+// specified.
+func StatBackupFiles(ctx context.Context, c client.Client, namespace string,
+	provider polardbxv1.BackupStorageProvider, prefix string) ([]remote.FileStat, bool, error) {
+	if provider.RetentionCredential == nil || !supportsRetentionCredential(provider.StorageName) {
+		return nil, false, nil
+	}
+	// Never list the files of other clusters by mistake.
+	if !strings.HasPrefix(prefix, polardbxmeta.BackupPath+"/") || strings.Count(strings.Trim(prefix, "/"), "/") < 1 {
+		return nil, false, fmt.Errorf("invalid prefix of backup files: %q", prefix)
+	}
+
+	fs, auth, params, err := retentionFileService(ctx, c, namespace, provider)
+	if err != nil {
+		return nil, false, err
+	}
+	lister, ok := fs.(remote.FileLister)
+	if !ok {
+		return nil, false, errors.New("listing files is not supported")
+	}
+	files, err := lister.StatFiles(ctx, prefix, auth, params)
+	return files, true, err
+}
+
+// StatShippedBinlogFiles lists the binlog files shipped under the prefix along with their stats, with the
+// retention credential of the storage provider, which is required.
+func StatShippedBinlogFiles(ctx context.Context, c client.Client, namespace string,
+	provider polardbxv1.BackupStorageProvider, prefix string) ([]remote.FileStat, error) {
+	if provider.RetentionCredential == nil || !supportsRetentionCredential(provider.StorageName) {
+		return nil, errors.New("retention credential is required to list shipped binlogs, only supported by oss and s3")
+	}
+	// Never list the files other than the shipped binlogs by mistake.
+	if !strings.HasPrefix(prefix, polardbxmeta.BinlogShipPath+"/") || strings.Count(strings.Trim(prefix, "/"), "/") < 2 {
+		return nil, fmt.Errorf("invalid prefix of shipped binlogs: %q", prefix)
+	}
+
+	fs, auth, params, err := retentionFileService(ctx, c, namespace, provider)
+	if err != nil {
+		return nil, err
+	}
+	lister, ok := fs.(remote.FileLister)
+	if !ok {
+		return nil, errors.New("listing files is not supported")
+	}
+	return lister.StatFiles(ctx, prefix, auth, params)
+}
+
+// UploadBackupFile writes the file of backup with the retention credential of the storage provider.
+// Nothing is written and false is returned if the credential isn't specified.
+func UploadBackupFile(ctx context.Context, c client.Client, namespace string,
+	provider polardbxv1.BackupStorageProvider, path string, data []byte) (bool, error) {
+	if provider.RetentionCredential == nil || !supportsRetentionCredential(provider.StorageName) {
+		return false, nil
+	}
+	fs, auth, params, err := retentionFileService(ctx, c, namespace, provider)
+	if err != nil {
+		return false, err
+	}
+	task, err := fs.UploadFile(ctx, bytes.NewReader(data), path, auth, params)
+	if err != nil {
+		return false, err
+	}
+	return true, task.Wait()
+}
+
+// DownloadBackupFile reads the file of backup with the retention credential of the storage provider.
+func DownloadBackupFile(ctx context.Context, c client.Client, namespace string,
+	provider polardbxv1.BackupStorageProvider, path string) ([]byte, error) {
+	if provider.RetentionCredential == nil || !supportsRetentionCredential(provider.StorageName) {
+		return nil, errors.New("retention credential is required to read backup files, only supported by oss and s3")
+	}
+	fs, auth, params, err := retentionFileService(ctx, c, namespace, provider)
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	task, err := fs.DownloadFile(ctx, buf, path, auth, params)
+	if err != nil {
+		return nil, err
+	}
+	if err := task.Wait(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ProbeBackupStorage tells whether the storage is reachable with the retention credential of the storage
+// provider, by listing the backup files under the prefix. Nothing is probed and false is returned if the
+// credential isn't specified.
+func ProbeBackupStorage(ctx context.Context, c client.Reader, namespace string,
+	provider polardbxv1.BackupStorageProvider, prefix string) (bool, error) {
+	if provider.RetentionCredential == nil || !supportsRetentionCredential(provider.StorageName) {
+		return false, nil
+	}
+	fs, auth, params, err := retentionFileService(ctx, c, namespace, provider)
+	if err != nil {
+		return true, err
+	}
+	prober, ok := fs.(remote.StorageProber)
+	if !ok {
+		return false, nil
+	}
+	return true, prober.ProbeStorage(ctx, prefix, auth, params)
+}
+
+// GetBackupWormRetention gets the WORM retention policy of the storage with the retention credential
+// of the storage provider. Nothing is got and false is returned if the credential isn't specified.
+func GetBackupWormRetention(ctx context.Context, c client.Client, namespace string,
+	provider polardbxv1.BackupStorageProvider) (remote.WormRetention, bool, error) {
+	if provider.RetentionCredential == nil || provider.StorageName != polardbxv1.OSS {
+		return remote.WormRetention{}, false, nil
+	}
+	fs, auth, params, err := retentionFileService(ctx, c, namespace, provider)
+	if err != nil {
+		return remote.WormRetention{}, false, err
+	}
+	inspector, ok := fs.(remote.WormInspector)
+	if !ok {
+		return remote.WormRetention{}, false, errors.New("worm retention is not supported")
+	}
+	retention, err := inspector.GetWormRetention(ctx, auth, params)
+	return retention, true, err
+}
+
+func IsAnnotationIndicatesToResumeBackup(annotations map[string]string) bool {
+	val, ok := annotations[polardbxmeta.AnnotationBackupResume]
+	if !ok {
+		return false
+	}
+	resume, _ := strconv.ParseBool(val)
+	return resume
+}
+
+func IsBackupCircuitOpen(conditions []polardbxv1xstore.Condition) bool {
+	for _, c := range conditions {
+		if c.Type == polardbxv1xstore.BackupCircuitOpen {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// SetBackupCondition adds the condition or replaces the one of the same type. The transition time is
+// kept if the status is not changed.
+func SetBackupCondition(conditions []polardbxv1xstore.Condition, condition polardbxv1xstore.Condition) []polardbxv1xstore.Condition {
+	return SetXStoreCondition(conditions, condition)
+}
+
+// NewBackupStatusConditions computes the standard conditions of the backup in the phase, i.e. Ready once
+// it's finished, Progressing until it's finished or failed, and Degraded if it's failed or stops retrying
+// since the circuit is open.
+func NewBackupStatusConditions(phase string, finished, failed bool, failureReason string,
+	conditions []polardbxv1xstore.Condition) []polardbxv1xstore.Condition {
+	newCondition := func(conditionType polardbxv1xstore.ConditionType, status bool, reason, message string) polardbxv1xstore.Condition {
+		c := polardbxv1xstore.Condition{
+			Type:    conditionType,
+			Status:  corev1.ConditionFalse,
+			Reason:  reason,
+			Message: message,
+		}
+		if status {
+			c.Status = corev1.ConditionTrue
+		}
+		return c
+	}
+
+	var ready, progressing, degraded polardbxv1xstore.Condition
+	if finished {
+		ready = newCondition(polardbxv1xstore.Ready, true, "BackupFinished", "")
+	} else {
+		ready = newCondition(polardbxv1xstore.Ready, false, "NotFinished", "phase is "+phase)
+	}
+	if finished || failed {
+		progressing = newCondition(polardbxv1xstore.Progressing, false, "Completed", "phase is "+phase)
+	} else {
+		progressing = newCondition(polardbxv1xstore.Progressing, true, "Backuping", "phase is "+phase)
+	}
+	switch {
+	case failed:
+		if len(failureReason) == 0 {
+			failureReason = "Failed"
+		}
+		degraded = newCondition(polardbxv1xstore.Degraded, true, failureReason, "phase is "+phase)
+	case IsBackupCircuitOpen(conditions):
+		degraded = newCondition(polardbxv1xstore.Degraded, true, "CircuitOpen", "backup stops retrying")
+	default:
+		degraded = newCondition(polardbxv1xstore.Degraded, false, "AsExpected", "")
+	}
+	return []polardbxv1xstore.Condition{ready, progressing, degraded}
+}
+
+// ObserveBackupFailure counts the failure of the step into the circuit breaker, only the failures of
+// the same step with the same reason are counted consecutively. It returns the new breaker and whether
+// the circuit should be opened, i.e. the failures reach the threshold.
+func ObserveBackupFailure(breaker *polardbxv1.BackupCircuitBreakerStatus, threshold int32, step, reason string,
+	now metav1.Time) (*polardbxv1.BackupCircuitBreakerStatus, bool) {
+	if len(reason) > maxBackupCircuitBreakerReasonLength {
+		reason = reason[:maxBackupCircuitBreakerReasonLength]
+	}
+	if breaker == nil || breaker.Step != step || breaker.Reason != reason {
+		breaker = &polardbxv1.BackupCircuitBreakerStatus{Step: step, Reason: reason}
+	} else {
+		breaker = breaker.DeepCopy()
+	}
+	breaker.Failures++
+	breaker.LastFailureTime = &now
+
+	if threshold == 0 {
+		threshold = defaultBackupCircuitBreakerThreshold
+	}
+	return breaker, threshold > 0 && breaker.Failures >= threshold
+}
+
+// NewBackupTrace starts the trace of backup, the span of backup is a child of parent if it's valid.
+func NewBackupTrace(parent tracing.SpanContext) *polardbxv1.BackupTrace {
+	sc := tracing.NewSpanContext()
+	if parent.IsValid() {
+		sc = parent.NewChild()
+	}
+	return &polardbxv1.BackupTrace{TraceParent: sc.String()}
+}
+
+// ObserveBackupTrace observes the phase of backup and returns the spans ended, i.e. the span of the
+// phase left and, once the backup ends, the span of backup built from root. Spans of the phases are
+// children of the span of backup. The trace is updated to the current phase.
+func ObserveBackupTrace(trace *polardbxv1.BackupTrace, phase string, ended bool, root tracing.Span,
+	now time.Time) ([]tracing.Span, error) {
+	if trace.Ended {
+		return nil, nil
+	}
+	sc, err := tracing.ParseTraceParent(trace.TraceParent)
+	if err != nil {
+		return nil, err
+	}
+
+	var spans []tracing.Span
+	if trace.PhaseStartTime == nil || trace.Phase != phase {
+		if trace.PhaseStartTime != nil {
+			spans = append(spans, tracing.Span{
+				Name:       "Phase/" + trace.Phase,
+				Context:    sc.NewChild(),
+				Parent:     sc.SpanID,
+				Start:      trace.PhaseStartTime.Time,
+				End:        now,
+				Attributes: map[string]string{"phase": trace.Phase},
+			})
+		}
+		phaseStartTime := metav1.NewTime(now)
+		trace.Phase = phase
+		trace.PhaseStartTime = &phaseStartTime
+	}
+	if ended {
+		root.Context = sc
+		root.End = now
+		spans = append(spans, root)
+		trace.Ended = true
+	}
+	return spans, nil
+}
+
+// EmitBackupJobSpan emits the span of the job of backup, as a child of the span of backup.
+func EmitBackupJobSpan(trace *polardbxv1.BackupTrace, job *batchv1.Job) {
+	if trace == nil || !tracing.Enabled() {
+		return
+	}
+	if sc, err := tracing.ParseTraceParent(trace.TraceParent); err == nil {
+		tracing.Emit(tracing.JobSpan(sc, job, time.Now()))
+	}
+}
+
+// ApplyBackupJobTemplate applies the job template of backup to the job, whose pod spec is derived from
+// the target pod. The job is still bound to the node of the target pod. The priority resolved for the
+// target pod is cleared once the priority class is overridden, so that it's resolved again.
+func ApplyBackupJobTemplate(job *batchv1.Job, template *polardbxv1.BackupJobTemplate) {
+	if template == nil {
+		return
+	}
+	podSpec := &job.Spec.Template.Spec
+	if len(template.NodeSelector) > 0 {
+		if podSpec.NodeSelector == nil {
+			podSpec.NodeSelector = make(map[string]string, len(template.NodeSelector))
+		}
+		for k, v := range template.NodeSelector {
+			podSpec.NodeSelector[k] = v
+		}
+	}
+	podSpec.Tolerations = append(podSpec.Tolerations, template.Tolerations...)
+	if template.Affinity != nil {
+		podSpec.Affinity = template.Affinity.DeepCopy()
+	}
+	if len(template.PriorityClassName) > 0 && template.PriorityClassName != podSpec.PriorityClassName {
+		podSpec.PriorityClassName = template.PriorityClassName
+		podSpec.Priority = nil
+		podSpec.PreemptionPolicy = nil
+	}
+	if template.Resources != nil {
+		for i := range podSpec.Containers {
+			podSpec.Containers[i].Resources = *template.Resources.DeepCopy()
+		}
+	}
+	if template.TTLSecondsAfterFinished != nil {
+		ttl := *template.TTLSecondsAfterFinished
+		job.Spec.TTLSecondsAfterFinished = &ttl
+	}
+}
+
+// RestoreTimeLayout is the layout of restore time, i.e. the wall clock in the time zone of restore.
+const RestoreTimeLayout = "2006-01-02 15:04:05"
+
+// ParseRestoreTime parses the wall clock of restore in the time zone, UTC if not specified, and returns
+// it in UTC, so that it's compared with the backup timestamps consistently. Wall clocks skipped by DST
+// are rejected, and the ambiguous ones are taken as the earlier. Times with an explicit offset in RFC3339
+// are accepted as well, the time zone is ignored then.
+func ParseRestoreTime(value, timeZone string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	location := time.UTC
+	if len(timeZone) > 0 {
+		var err error
+		if location, err = time.LoadLocation(timeZone); err != nil {
+			return time.Time{}, fmt.Errorf("invalid time zone of restore time: %w", err)
+		}
+	}
+	t, err := time.ParseInLocation(RestoreTimeLayout, value, location)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// Go doesn't guarantee which offset is used around the DST transitions, so try the offsets
+	// before and after the transition and take the earliest one matching the wall clock.
+	var result time.Time
+	for _, around := range []time.Time{t.Add(-24 * time.Hour), t.Add(24 * time.Hour)} {
+		_, offset := around.In(location).Zone()
+		candidate, err := time.ParseInLocation(RestoreTimeLayout, value, time.FixedZone("", offset))
+		if err != nil || candidate.In(location).Format(RestoreTimeLayout) != value {
+			continue
+		}
+		if result.IsZero() || candidate.Before(result) {
+			result = candidate
+		}
+	}
+	if result.IsZero() {
+		return time.Time{}, fmt.Errorf("restore time %s is skipped by DST in time zone %s", value, location)
+	}
+	return result.UTC(), nil
+}
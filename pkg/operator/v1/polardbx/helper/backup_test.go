@@ -0,0 +1,279 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/util/tracing"
+)
+
+func TestObserveBackupFailure(t *testing.T) {
+	now := metav1.Now()
+
+	breaker, open := ObserveBackupFailure(nil, 3, "s1", "e1", now)
+	breaker, open = ObserveBackupFailure(breaker, 3, "s1", "e1", now)
+	if open || breaker.Failures != 2 {
+		t.Fatalf("expect 2 failures and closed, got %d, %v", breaker.Failures, open)
+	}
+	breaker, open = ObserveBackupFailure(breaker, 3, "s1", "e1", now)
+	if !open || breaker.Failures != 3 {
+		t.Fatalf("expect 3 failures and open, got %d, %v", breaker.Failures, open)
+	}
+
+	if breaker, _ = ObserveBackupFailure(breaker, 3, "s1", "e2", now); breaker.Failures != 1 {
+		t.Fatalf("expect reset on another reason, got %d", breaker.Failures)
+	}
+	if breaker, _ = ObserveBackupFailure(breaker, 3, "s2", "e2", now); breaker.Failures != 1 {
+		t.Fatalf("expect reset on another step, got %d", breaker.Failures)
+	}
+
+	for i := 0; i < defaultBackupCircuitBreakerThreshold-1; i++ {
+		breaker, open = ObserveBackupFailure(breaker, 0, "s2", "e2", now)
+	}
+	if !open {
+		t.Fatalf("expect open with default threshold, got %d failures", breaker.Failures)
+	}
+	if _, open = ObserveBackupFailure(breaker, -1, "s2", "e2", now); open {
+		t.Fatal("expect never open if disabled")
+	}
+}
+
+func TestValidateBackupStorageClass(t *testing.T) {
+	testcases := []struct {
+		storage      polardbxv1.BackupStorage
+		storageClass string
+		valid        bool
+	}{
+		{storage: polardbxv1.SFTP, storageClass: "", valid: true},
+		{storage: polardbxv1.OSS, storageClass: "Archive", valid: true},
+		{storage: polardbxv1.OSS, storageClass: "GLACIER", valid: false},
+		{storage: polardbxv1.SFTP, storageClass: "IA", valid: false},
+	}
+	for _, tc := range testcases {
+		if err := ValidateBackupStorageClass(tc.storage, tc.storageClass); (err == nil) != tc.valid {
+			t.Fatalf("%s/%s: expect valid %v, got %v", tc.storage, tc.storageClass, tc.valid, err)
+		}
+	}
+	if IsArchiveBackupStorageClass(polardbxv1.OSS, "IA") || !IsArchiveBackupStorageClass(polardbxv1.OSS, "ColdArchive") {
+		t.Fatal("archive storage class mismatch")
+	}
+}
+
+func TestValidateBackupEngine(t *testing.T) {
+	testcases := []struct {
+		engine  polardbxv1.BackupEngine
+		xstores []string
+		valid   bool
+	}{
+		{engine: "", xstores: nil, valid: true},
+		{engine: polardbxv1.BackupEngineXtrabackup, xstores: []string{"pxc-dn-0", "pxc-dn-1"}, valid: true},
+		{engine: polardbxv1.BackupEngineVolumeSnapshot, xstores: nil, valid: false},
+		{engine: polardbxv1.BackupEngineVolumeSnapshot, xstores: []string{"pxc-dn-0", "pxc-dn-1"}, valid: false},
+		{engine: polardbxv1.BackupEngineVolumeSnapshot, xstores: []string{"pxc-dn-0"}, valid: true},
+	}
+	for _, tc := range testcases {
+		if err := ValidateBackupEngine(tc.engine, tc.xstores); (err == nil) != tc.valid {
+			t.Fatalf("%s/%v: expect valid %v, got %v", tc.engine, tc.xstores, tc.valid, err)
+		}
+	}
+}
+
+func TestBackupGroupMembers(t *testing.T) {
+	xstores := make([]polardbxv1.XStore, 3)
+	for i, name := range []string{"gms", "dn-0", "dn-1"} {
+		xstores[i].Name = name
+	}
+
+	if members, err := BackupGroupMembers(nil, xstores); err != nil || len(members) != 3 {
+		t.Fatalf("expect all xstores, got %v, %v", members, err)
+	}
+	members, err := BackupGroupMembers([]string{"dn-1", "gms"}, xstores)
+	if err != nil || len(members) != 2 || members[0].Name != "dn-1" || members[1].Name != "gms" {
+		t.Fatalf("unexpected members %v, %v", members, err)
+	}
+	if _, err := BackupGroupMembers([]string{"dn-2"}, xstores); err == nil {
+		t.Fatal("expect error for xstore not found")
+	}
+}
+
+func TestCDCCheckpointDivergence(t *testing.T) {
+	sent := time.Unix(1666172319, 0)
+	if d, err := CDCCheckpointDivergence("1666172319", sent.Add(90*time.Second+300*time.Millisecond)); err != nil || d != 90*time.Second {
+		t.Fatalf("expect 1m30s, got %s, %v", d, err)
+	}
+	if d, err := CDCCheckpointDivergence("1666172319", sent.Add(-time.Second)); err != nil || d != 0 {
+		t.Fatalf("expect no divergence, got %s, %v", d, err)
+	}
+	if _, err := CDCCheckpointDivergence("", sent); err == nil {
+		t.Fatal("expect error on invalid heartbeat")
+	}
+}
+
+func TestRemoveBackupFiles_Guard(t *testing.T) {
+	provider := polardbxv1.BackupStorageProvider{StorageName: polardbxv1.OSS, Sink: "default"}
+	if n, err := RemoveBackupFiles(context.Background(), nil, "default", provider, ""); n != 0 || err != nil {
+		t.Fatalf("expect files kept without credential, got %d, %v", n, err)
+	}
+
+	provider.RetentionCredential = &corev1.LocalObjectReference{Name: "retention"}
+	for _, prefix := range []string{"", "polardbx-backup/", "polardbx-backup/pxc/", "other/pxc/backup/"} {
+		if _, err := RemoveBackupFiles(context.Background(), nil, "default", provider, prefix); err == nil {
+			t.Fatalf("expect prefix %q rejected", prefix)
+		}
+	}
+
+	provider.StorageName = polardbxv1.SFTP
+	if _, err := RemoveBackupFiles(context.Background(), nil, "default", provider, "polardbx-backup/pxc/b-1/"); err == nil {
+		t.Fatal("expect sftp rejected")
+	}
+}
+
+func TestObserveBackupTrace(t *testing.T) {
+	parent := tracing.NewSpanContext()
+	trace := NewBackupTrace(parent)
+	sc, err := tracing.ParseTraceParent(trace.TraceParent)
+	if err != nil || sc.TraceID != parent.TraceID {
+		t.Fatalf("expect trace of parent, got %s, %v", trace.TraceParent, err)
+	}
+
+	now := time.Unix(1000, 0)
+	root := tracing.Span{Name: "backup", Parent: parent.SpanID, Start: now}
+	if spans, _ := ObserveBackupTrace(trace, "Backuping", false, root, now); len(spans) != 0 {
+		t.Fatalf("expect no span ended, got %d", len(spans))
+	}
+	if spans, _ := ObserveBackupTrace(trace, "Backuping", false, root, now.Add(time.Minute)); len(spans) != 0 {
+		t.Fatalf("expect no span ended in the same phase, got %d", len(spans))
+	}
+
+	spans, _ := ObserveBackupTrace(trace, "Finished", true, root, now.Add(time.Hour))
+	if len(spans) != 2 {
+		t.Fatalf("expect phase and backup spans ended, got %d", len(spans))
+	}
+	if spans[0].Name != "Phase/Backuping" || spans[0].Parent != sc.SpanID || spans[0].End.Sub(spans[0].Start) != time.Hour {
+		t.Fatalf("unexpected phase span: %+v", spans[0])
+	}
+	if spans[1].Context != sc || spans[1].Parent != parent.SpanID || spans[1].End.Sub(spans[1].Start) != time.Hour {
+		t.Fatalf("unexpected backup span: %+v", spans[1])
+	}
+
+	if spans, _ := ObserveBackupTrace(trace, "Finished", true, root, now.Add(2*time.Hour)); len(spans) != 0 {
+		t.Fatalf("expect spans emitted once, got %d", len(spans))
+	}
+}
+
+func TestParseRestoreTime(t *testing.T) {
+	testcases := map[string]struct {
+		value, timeZone string
+		expect          string
+		err             bool
+	}{
+		"utc-by-default":    {value: "2023-07-22 04:26:40", expect: "2023-07-22T04:26:40Z"},
+		"east":              {value: "2023-07-22 12:26:40", timeZone: "Asia/Shanghai", expect: "2023-07-22T04:26:40Z"},
+		"west-summer":       {value: "2023-07-22 00:26:40", timeZone: "America/New_York", expect: "2023-07-22T04:26:40Z"},
+		"west-winter":       {value: "2023-01-22 00:26:40", timeZone: "America/New_York", expect: "2023-01-22T05:26:40Z"},
+		"dst-skipped":       {value: "2023-03-12 02:30:00", timeZone: "America/New_York", err: true},
+		"dst-ambiguous":     {value: "2023-11-05 01:30:00", timeZone: "America/New_York", expect: "2023-11-05T05:30:00Z"},
+		"dst-after":         {value: "2023-11-05 02:30:00", timeZone: "America/New_York", expect: "2023-11-05T07:30:00Z"},
+		"dst-half-hour":     {value: "2023-10-01 02:15:00", timeZone: "Australia/Lord_Howe", err: true},
+		"rfc3339":           {value: "2023-07-22T12:26:40+08:00", timeZone: "America/New_York", expect: "2023-07-22T04:26:40Z"},
+		"invalid-time-zone": {value: "2023-07-22 04:26:40", timeZone: "Mars/Olympus", err: true},
+		"invalid-time":      {value: "2023/07/22 04:26:40", err: true},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			r, err := ParseRestoreTime(tc.value, tc.timeZone)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expect error, got %s", r)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Location() != time.UTC || r.Format(time.RFC3339) != tc.expect {
+				t.Fatalf("expect %s, got %s", tc.expect, r)
+			}
+		})
+	}
+}
+
+func TestApplyBackupJobTemplate(t *testing.T) {
+	newJob := func() *batchv1.Job {
+		return &batchv1.Job{Spec: batchv1.JobSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			NodeName:          "node-1",
+			NodeSelector:      map[string]string{"pool": "db"},
+			Tolerations:       []corev1.Toleration{{Key: "db", Operator: corev1.TolerationOpExists}},
+			PriorityClassName: "db-critical",
+			Priority:          new(int32),
+			Containers:        []corev1.Container{{Name: "backupjob"}},
+		}}}}
+	}
+
+	job := newJob()
+	ApplyBackupJobTemplate(job, nil)
+	if job.Spec.Template.Spec.Priority == nil || job.Spec.TTLSecondsAfterFinished != nil {
+		t.Fatal("expect the job untouched without template")
+	}
+
+	ttl := int32(3600)
+	template := &polardbxv1.BackupJobTemplate{
+		NodeSelector:      map[string]string{"zone": "a"},
+		Tolerations:       []corev1.Toleration{{Key: "backup", Operator: corev1.TolerationOpExists}},
+		PriorityClassName: "backup-low",
+		Resources: &corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+		},
+		TTLSecondsAfterFinished: &ttl,
+	}
+	job = newJob()
+	ApplyBackupJobTemplate(job, template)
+	podSpec := job.Spec.Template.Spec
+	if podSpec.NodeName != "node-1" {
+		t.Fatalf("expect job bound to node-1, got %s", podSpec.NodeName)
+	}
+	if len(podSpec.NodeSelector) != 2 || podSpec.NodeSelector["zone"] != "a" {
+		t.Fatalf("expect node selector merged, got %v", podSpec.NodeSelector)
+	}
+	if len(podSpec.Tolerations) != 2 || podSpec.Tolerations[1].Key != "backup" {
+		t.Fatalf("expect tolerations appended, got %v", podSpec.Tolerations)
+	}
+	if podSpec.PriorityClassName != "backup-low" || podSpec.Priority != nil {
+		t.Fatalf("expect priority class overridden and priority cleared, got %s, %v",
+			podSpec.PriorityClassName, podSpec.Priority)
+	}
+	if !podSpec.Containers[0].Resources.Limits.Cpu().Equal(resource.MustParse("1")) {
+		t.Fatalf("expect resources applied, got %v", podSpec.Containers[0].Resources)
+	}
+	if job.Spec.TTLSecondsAfterFinished == nil || *job.Spec.TTLSecondsAfterFinished != ttl {
+		t.Fatalf("expect ttl %d, got %v", ttl, job.Spec.TTLSecondsAfterFinished)
+	}
+
+	ttl = 60
+	if *job.Spec.TTLSecondsAfterFinished != 3600 {
+		t.Fatal("expect ttl not shared with the template")
+	}
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/util/crypt"
+)
+
+// BackupManifestName is the name of the manifest under the backup root path.
+const BackupManifestName = "manifest.json"
+
+// BackupSet is what's required to restore from a pxc backup besides the files, i.e. the backup
+// and its xstore backups, and the account secrets of them by name.
+type BackupSet struct {
+	Backup        *polardbxv1.PolarDBXBackup
+	XStoreBackups []polardbxv1.XStoreBackup
+	Secrets       map[string]map[string][]byte
+}
+
+// GetBackupSet gets the backup set of the pxc backup in its namespace.
+func GetBackupSet(ctx context.Context, c client.Client, backup *polardbxv1.PolarDBXBackup) (*BackupSet, error) {
+	set := &BackupSet{
+		Backup:        backup,
+		XStoreBackups: make([]polardbxv1.XStoreBackup, 0, len(backup.Status.Backups)),
+		Secrets:       make(map[string]map[string][]byte),
+	}
+	names := []string{backup.Name}
+	xstoreNames := make([]string, 0, len(backup.Status.Backups))
+	for xstoreName := range backup.Status.Backups {
+		xstoreNames = append(xstoreNames, xstoreName)
+	}
+	sort.Strings(xstoreNames)
+	for _, xstoreName := range xstoreNames {
+		xstoreBackup := polardbxv1.XStoreBackup{}
+		key := types.NamespacedName{Namespace: backup.Namespace, Name: backup.Status.Backups[xstoreName]}
+		if err := c.Get(ctx, key, &xstoreBackup); err != nil {
+			return nil, fmt.Errorf("unable to get xstore backup %s: %w", key.Name, err)
+		}
+		set.XStoreBackups = append(set.XStoreBackups, xstoreBackup)
+		names = append(names, xstoreBackup.Name)
+	}
+	for _, name := range names {
+		secret := &corev1.Secret{}
+		if err := c.Get(ctx, types.NamespacedName{Namespace: backup.Namespace, Name: name}, secret); err != nil {
+			return nil, fmt.Errorf("unable to get secret of backup %s: %w", name, err)
+		}
+		set.Secrets[name] = secret.Data
+	}
+	return set, nil
+}
+
+// Unfinished returns the first unfinished xstore backup of the backup set, nil if all are finished.
+func (s *BackupSet) Unfinished() *polardbxv1.XStoreBackup {
+	for i := range s.XStoreBackups {
+		if s.XStoreBackups[i].Status.Phase != polardbxv1.XStoreBackupFinished {
+			return &s.XStoreBackups[i]
+		}
+	}
+	return nil
+}
+
+// BackupManifest is the backup set exported to the storage along with the backup files. The secrets
+// are encrypted with the encryption key of the backup, if any.
+type BackupManifest struct {
+	Backup        *polardbxv1.PolarDBXBackup `json:"backup"`
+	XStoreBackups []polardbxv1.XStoreBackup  `json:"xstoreBackups"`
+	Secrets       []byte                     `json:"secrets"`
+	Encrypted     bool                       `json:"encrypted,omitempty"`
+}
+
+// exportedObjectMeta keeps only the portable part of the object meta, i.e. nothing bound to the
+// namespace or the kubernetes cluster.
+func exportedObjectMeta(meta metav1.ObjectMeta) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:        meta.Name,
+		Labels:      meta.Labels,
+		Annotations: meta.Annotations,
+	}
+}
+
+// BackupEncryptionKey returns the key of the encryption in the secret.
+func BackupEncryptionKey(secret *corev1.Secret, encryption *polardbxv1.BackupEncryption) ([]byte, error) {
+	name := encryption.Key
+	if len(name) == 0 {
+		name = "key"
+	}
+	data, ok := secret.Data[name]
+	if !ok {
+		return nil, fmt.Errorf("key %q not found in secret %s", name, secret.Name)
+	}
+	return crypt.ParseKey(data)
+}
+
+// NewBackupManifest builds the manifest of the backup set, the secrets are encrypted with the key
+// unless it's nil.
+func NewBackupManifest(set *BackupSet, key []byte) (*BackupManifest, error) {
+	backup := &polardbxv1.PolarDBXBackup{
+		ObjectMeta: exportedObjectMeta(set.Backup.ObjectMeta),
+		Spec:       *set.Backup.Spec.DeepCopy(),
+		Status:     *set.Backup.Status.DeepCopy(),
+	}
+	// The credential is bound to the namespace.
+	backup.Spec.StorageProvider.RetentionCredential = nil
+	xstoreBackups := make([]polardbxv1.XStoreBackup, 0, len(set.XStoreBackups))
+	for i := range set.XStoreBackups {
+		b := &set.XStoreBackups[i]
+		spec := b.Spec.DeepCopy()
+		spec.StorageProvider.RetentionCredential = nil
+		xstoreBackups = append(xstoreBackups, polardbxv1.XStoreBackup{
+			ObjectMeta: exportedObjectMeta(b.ObjectMeta),
+			Spec:       *spec,
+			Status:     *b.Status.DeepCopy(),
+		})
+	}
+
+	secrets, err := json.Marshal(set.Secrets)
+	if err != nil {
+		return nil, err
+	}
+	if key != nil {
+		buf := &bytes.Buffer{}
+		w, err := crypt.NewWriter(buf, key)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(secrets); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		secrets = buf.Bytes()
+	}
+	return &BackupManifest{
+		Backup:        backup,
+		XStoreBackups: xstoreBackups,
+		Secrets:       secrets,
+		Encrypted:     key != nil,
+	}, nil
+}
+
+// BackupSet returns the backup set of the manifest, the secrets are decrypted with the key if
+// they're encrypted.
+func (m *BackupManifest) BackupSet(key []byte) (*BackupSet, error) {
+	if m.Backup == nil {
+		return nil, errors.New("backup not found in manifest")
+	}
+	secrets := m.Secrets
+	if m.Encrypted {
+		if key == nil {
+			return nil, fmt.Errorf("secrets of backup %s are encrypted, key required", m.Backup.Name)
+		}
+		decrypted, err := io.ReadAll(crypt.NewReader(bytes.NewReader(secrets), key))
+		if err != nil {
+			return nil, fmt.Errorf("unable to decrypt secrets of backup %s: %w", m.Backup.Name, err)
+		}
+		secrets = decrypted
+	}
+	set := &BackupSet{
+		Backup:        m.Backup,
+		XStoreBackups: m.XStoreBackups,
+	}
+	if err := json.Unmarshal(secrets, &set.Secrets); err != nil {
+		return nil, fmt.Errorf("invalid secrets of backup %s: %w", m.Backup.Name, err)
+	}
+	return set, nil
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/util/crypt"
+)
+
+func testBackupSet() *BackupSet {
+	backup := &polardbxv1.PolarDBXBackup{}
+	backup.Name = "pxc-backup"
+	backup.Namespace = "prod"
+	backup.UID = "uid-1"
+	backup.Status.Phase = polardbxv1.BackupFinished
+	backup.Spec.StorageProvider.RetentionCredential = &corev1.LocalObjectReference{Name: "cred"}
+
+	xstoreBackup := polardbxv1.XStoreBackup{}
+	xstoreBackup.Name = "pxc-backup-dn-0"
+	xstoreBackup.Namespace = "prod"
+	xstoreBackup.OwnerReferences = []metav1.OwnerReference{{Name: backup.Name, UID: backup.UID}}
+	xstoreBackup.Status.Phase = polardbxv1.XStoreBackupFinished
+
+	return &BackupSet{
+		Backup:        backup,
+		XStoreBackups: []polardbxv1.XStoreBackup{xstoreBackup},
+		Secrets: map[string]map[string][]byte{
+			backup.Name: {"admin": []byte("passwd")},
+		},
+	}
+}
+
+func TestBackupManifest_RoundTrip(t *testing.T) {
+	for _, key := range [][]byte{nil, bytes.Repeat([]byte{7}, crypt.KeySize)} {
+		manifest, err := NewBackupManifest(testBackupSet(), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key != nil && bytes.Contains(manifest.Secrets, []byte("passwd")) {
+			t.Fatal("expect secrets encrypted")
+		}
+		data, err := json.Marshal(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		read := &BackupManifest{}
+		if err := json.Unmarshal(data, read); err != nil {
+			t.Fatal(err)
+		}
+		set, err := read.BackupSet(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if set.Backup.Name != "pxc-backup" || len(set.Backup.Namespace) > 0 || len(set.Backup.UID) > 0 ||
+			set.Backup.Status.Phase != polardbxv1.BackupFinished {
+			t.Fatalf("unexpected backup: %+v", set.Backup.ObjectMeta)
+		}
+		if set.Backup.Spec.StorageProvider.RetentionCredential != nil {
+			t.Fatal("expect retention credential not exported")
+		}
+		if len(set.XStoreBackups) != 1 || len(set.XStoreBackups[0].OwnerReferences) > 0 {
+			t.Fatalf("unexpected xstore backups: %+v", set.XStoreBackups)
+		}
+		if string(set.Secrets["pxc-backup"]["admin"]) != "passwd" {
+			t.Fatalf("unexpected secrets: %v", set.Secrets)
+		}
+	}
+}
+
+func TestBackupManifest_WrongKey(t *testing.T) {
+	manifest, err := NewBackupManifest(testBackupSet(), bytes.Repeat([]byte{7}, crypt.KeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := manifest.BackupSet(nil); err == nil {
+		t.Fatal("expect key required")
+	}
+	if _, err := manifest.BackupSet(bytes.Repeat([]byte{8}, crypt.KeySize)); err == nil {
+		t.Fatal("expect wrong key rejected")
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+)
+
+// SetRecoverableWindowLabels labels the recoverable window in unix seconds, and tells whether the
+// labels are changed. The labels of unknown timestamps are left untouched.
+func SetRecoverableWindowLabels(obj metav1.Object, from, to *metav1.Time) bool {
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	changed := false
+	for key, t := range map[string]*metav1.Time{
+		polardbxmeta.LabelRecoverableFrom: from,
+		polardbxmeta.LabelRecoverableTo:   to,
+	} {
+		if t == nil {
+			continue
+		}
+		if value := strconv.FormatInt(t.Unix(), 10); labels[key] != value {
+			labels[key] = value
+			changed = true
+		}
+	}
+	obj.SetLabels(labels)
+	return changed
+}
+
+// CoversRecoveryPoint tells whether the recoverable window covers the time, bounds included.
+func CoversRecoveryPoint(from, to *metav1.Time, t time.Time) bool {
+	if from == nil || to == nil {
+		return false
+	}
+	return !t.Before(from.Time) && !t.After(to.Time)
+}
+
+// XStoreBackupsCovering returns the finished backups whose recoverable window covers the time, the
+// one of the latest full backup first, i.e. the least binlogs to apply.
+func XStoreBackupsCovering(backups []polardbxv1.XStoreBackup, t time.Time) []polardbxv1.XStoreBackup {
+	covering := make([]polardbxv1.XStoreBackup, 0)
+	for _, b := range backups {
+		if b.Status.Phase == polardbxv1.XStoreBackupFinished &&
+			CoversRecoveryPoint(b.Status.EarliestRecoverableTimestamp, b.Status.BackupSetTimestamp, t) {
+			covering = append(covering, b)
+		}
+	}
+	sort.SliceStable(covering, func(i, j int) bool {
+		return covering[j].Status.EarliestRecoverableTimestamp.Before(covering[i].Status.EarliestRecoverableTimestamp)
+	})
+	return covering
+}
+
+// PolarDBXBackupsCovering returns the finished backups of the whole cluster whose recoverable window
+// covers the time, the one of the latest start first.
+func PolarDBXBackupsCovering(backups []polardbxv1.PolarDBXBackup, t time.Time) []polardbxv1.PolarDBXBackup {
+	covering := make([]polardbxv1.PolarDBXBackup, 0)
+	for _, b := range backups {
+		// Backups of groups can't be used to restore the cluster.
+		if b.Status.Phase == polardbxv1.BackupFinished && len(b.Spec.XStores) == 0 &&
+			CoversRecoveryPoint(b.Status.EarliestRecoverableTimestamp, b.Status.LatestRecoverableTimestamp, t) {
+			covering = append(covering, b)
+		}
+	}
+	sort.SliceStable(covering, func(i, j int) bool {
+		return covering[j].Status.EarliestRecoverableTimestamp.Before(covering[i].Status.EarliestRecoverableTimestamp)
+	})
+	return covering
+}
+
+// ListXStoreBackupsCovering lists the finished backups of the xstore which can restore to the time.
+func ListXStoreBackupsCovering(ctx context.Context, c client.Client, namespace, xstoreName string, t time.Time) ([]polardbxv1.XStoreBackup, error) {
+	var backupList polardbxv1.XStoreBackupList
+	if err := c.List(ctx, &backupList, client.InNamespace(namespace),
+		client.MatchingLabels{polardbxmeta.LabelBackupXStore: xstoreName}); err != nil {
+		return nil, err
+	}
+	return XStoreBackupsCovering(backupList.Items, t), nil
+}
+
+// ListPolarDBXBackupsCovering lists the finished backups of the cluster which can restore to the time.
+func ListPolarDBXBackupsCovering(ctx context.Context, c client.Client, namespace, clusterName string, t time.Time) ([]polardbxv1.PolarDBXBackup, error) {
+	var backupList polardbxv1.PolarDBXBackupList
+	if err := c.List(ctx, &backupList, client.InNamespace(namespace),
+		client.MatchingLabels{polardbxmeta.LabelName: clusterName}); err != nil {
+		return nil, err
+	}
+	return PolarDBXBackupsCovering(backupList.Items, t), nil
+}
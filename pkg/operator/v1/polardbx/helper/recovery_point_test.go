@@ -0,0 +1,73 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+)
+
+func TestXStoreBackupsCovering(t *testing.T) {
+	base := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	newBackup := func(name string, from, to time.Duration, phase polardbxv1.XStoreBackupPhase) polardbxv1.XStoreBackup {
+		b := polardbxv1.XStoreBackup{}
+		b.Name = name
+		b.Status.Phase = phase
+		b.Status.EarliestRecoverableTimestamp = &metav1.Time{Time: base.Add(from)}
+		b.Status.BackupSetTimestamp = &metav1.Time{Time: base.Add(to)}
+		return b
+	}
+	backups := []polardbxv1.XStoreBackup{
+		newBackup("a", 0, 2*time.Hour, polardbxv1.XStoreBackupFinished),
+		newBackup("b", time.Hour, 3*time.Hour, polardbxv1.XStoreBackupFinished),
+		newBackup("c", time.Hour, 3*time.Hour, polardbxv1.XStoreBackupFailed),
+		newBackup("d", 4*time.Hour, 5*time.Hour, polardbxv1.XStoreBackupFinished),
+	}
+
+	covering := XStoreBackupsCovering(backups, base.Add(90*time.Minute))
+	if len(covering) != 2 || covering[0].Name != "b" || covering[1].Name != "a" {
+		t.Fatalf("expect b and a, got %v", covering)
+	}
+	if covering := XStoreBackupsCovering(backups, base.Add(3*time.Hour)); len(covering) != 1 || covering[0].Name != "b" {
+		t.Fatalf("expect the bounds included, got %v", covering)
+	}
+	if covering := XStoreBackupsCovering(backups, base.Add(210*time.Minute)); len(covering) != 0 {
+		t.Fatalf("expect none, got %v", covering)
+	}
+}
+
+func TestSetRecoverableWindowLabels(t *testing.T) {
+	backup := &polardbxv1.PolarDBXBackup{}
+	from := metav1.NewTime(time.Unix(1682899200, 0))
+	if !SetRecoverableWindowLabels(backup, &from, nil) {
+		t.Fatal("expect labels changed")
+	}
+	if v := backup.Labels[polardbxmeta.LabelRecoverableFrom]; v != "1682899200" {
+		t.Fatalf("unexpected label: %s", v)
+	}
+	if _, ok := backup.Labels[polardbxmeta.LabelRecoverableTo]; ok {
+		t.Fatal("expect unknown timestamp not labeled")
+	}
+	if SetRecoverableWindowLabels(backup, &from, nil) {
+		t.Fatal("expect labels unchanged")
+	}
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+)
+
+var engineVersionNumbers = regexp.MustCompile(`^\d+(\.\d+)*`)
+
+func parseEngineVersion(version string) []int {
+	m := engineVersionNumbers.FindString(strings.TrimSpace(version))
+	if len(m) == 0 {
+		return nil
+	}
+	numbers := make([]int, 0, 3)
+	for _, s := range strings.Split(m, ".") {
+		n, _ := strconv.Atoi(s)
+		numbers = append(numbers, n)
+	}
+	return numbers
+}
+
+// CheckRestoreEngineVersion tells whether the physical backup taken by the backup engine version can be
+// restored by the target one. The major versions (e.g. 5.7, 8.0) must be the same, and the target must
+// not be older than the backup.
+func CheckRestoreEngineVersion(backupVersion, targetVersion string) (bool, string) {
+	b, t := parseEngineVersion(backupVersion), parseEngineVersion(targetVersion)
+	if len(b) < 2 || len(t) < 2 {
+		return true, fmt.Sprintf("engine version unknown, backup: %q, target: %q, not checked", backupVersion, targetVersion)
+	}
+	if b[0] != t[0] || b[1] != t[1] {
+		return false, fmt.Sprintf("major version differs, backup: %s, target: %s", backupVersion, targetVersion)
+	}
+	for i := 2; i < len(b) && i < len(t); i++ {
+		if t[i] != b[i] {
+			if t[i] < b[i] {
+				return false, fmt.Sprintf("target %s is older than backup %s", targetVersion, backupVersion)
+			}
+			break
+		}
+	}
+	return true, fmt.Sprintf("backup: %s, target: %s", backupVersion, targetVersion)
+}
+
+func checkCompatibleValue(backup, target string, known bool, equal func(a, b string) bool) (bool, string) {
+	if !known {
+		return true, fmt.Sprintf("unknown, backup: %q, target: %q, not checked", backup, target)
+	}
+	if !equal(backup, target) {
+		return false, fmt.Sprintf("differs, backup: %s, target: %s", backup, target)
+	}
+	return true, "backup: " + backup + ", target: " + target
+}
+
+func checkCompatiblePlugins(backup, target []string) (bool, string) {
+	loaded := make(map[string]bool, len(target))
+	for _, p := range target {
+		loaded[strings.ToLower(p)] = true
+	}
+	missing := make([]string, 0)
+	for _, p := range backup {
+		if !loaded[strings.ToLower(p)] {
+			missing = append(missing, p)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return false, "plugins not loaded by target: " + strings.Join(missing, ", ")
+	}
+	return true, fmt.Sprintf("%d plugins of backup loaded by target", len(backup))
+}
+
+func compatibleLowerCaseTableNames(c *polardbxv1xstore.EngineCompatibility) string {
+	if c.LowerCaseTableNames == nil {
+		return ""
+	}
+	return strconv.Itoa(int(*c.LowerCaseTableNames))
+}
+
+// CheckRestoreCompatibility checks the engine facts recorded in the backup against the ones of the
+// target, and tells whether the restore is blocked under the strictness. Hard incompatibilities block
+// the restore unless it's WarnOnly, and soft ones block only if it's Strict. Facts unknown on either
+// side, e.g. the backup is taken by tools of older versions, are never incompatible.
+func CheckRestoreCompatibility(backup, target *polardbxv1xstore.EngineCompatibility,
+	strictness polardbxv1xstore.RestoreCompatibilityStrictness) ([]polardbxv1xstore.CompatibilityCheck, bool) {
+	if backup == nil {
+		backup = &polardbxv1xstore.EngineCompatibility{}
+	}
+	targetKnown := target != nil
+	if !targetKnown {
+		target = &polardbxv1xstore.EngineCompatibility{}
+	}
+	known := func(b, t bool) bool { return targetKnown && b && t }
+	equalFold := strings.EqualFold
+	equal := func(a, b string) bool { return a == b }
+
+	checks := make([]polardbxv1xstore.CompatibilityCheck, 0, 6)
+	add := func(name string, severity polardbxv1xstore.CompatibilitySeverity, compatible bool, message string) {
+		checks = append(checks, polardbxv1xstore.CompatibilityCheck{
+			Name:       name,
+			Severity:   severity,
+			Compatible: compatible,
+			Message:    message,
+		})
+	}
+
+	compatible, message := CheckRestoreEngineVersion(backup.EngineVersion, target.EngineVersion)
+	add(polardbxv1xstore.CompatibilityCheckEngineVersion, polardbxv1xstore.CompatibilityHard, compatible, message)
+
+	compatible, message = checkCompatibleValue(strconv.FormatInt(backup.PageSize, 10), strconv.FormatInt(target.PageSize, 10),
+		known(backup.PageSize > 0, target.PageSize > 0), equal)
+	add(polardbxv1xstore.CompatibilityCheckPageSize, polardbxv1xstore.CompatibilityHard, compatible, message)
+
+	compatible, message = checkCompatibleValue(compatibleLowerCaseTableNames(backup), compatibleLowerCaseTableNames(target),
+		known(backup.LowerCaseTableNames != nil, target.LowerCaseTableNames != nil), equal)
+	add(polardbxv1xstore.CompatibilityCheckLowerCaseTableNames, polardbxv1xstore.CompatibilityHard, compatible, message)
+
+	compatible, message = checkCompatibleValue(backup.CharacterSet, target.CharacterSet,
+		known(len(backup.CharacterSet) > 0, len(target.CharacterSet) > 0), equalFold)
+	add(polardbxv1xstore.CompatibilityCheckCharacterSet, polardbxv1xstore.CompatibilitySoft, compatible, message)
+
+	compatible, message = checkCompatibleValue(backup.Collation, target.Collation,
+		known(len(backup.Collation) > 0, len(target.Collation) > 0), equalFold)
+	add(polardbxv1xstore.CompatibilityCheckCollation, polardbxv1xstore.CompatibilitySoft, compatible, message)
+
+	if targetKnown {
+		compatible, message = checkCompatiblePlugins(backup.Plugins, target.Plugins)
+	} else {
+		compatible, message = true, "target unknown, not checked"
+	}
+	add(polardbxv1xstore.CompatibilityCheckPlugins, polardbxv1xstore.CompatibilitySoft, compatible, message)
+
+	blocked := false
+	for _, c := range checks {
+		if c.Compatible || strictness == polardbxv1xstore.CompatibilityWarnOnly {
+			continue
+		}
+		if c.Severity == polardbxv1xstore.CompatibilityHard || strictness == polardbxv1xstore.CompatibilityStrict {
+			blocked = true
+		}
+	}
+	return checks, blocked
+}
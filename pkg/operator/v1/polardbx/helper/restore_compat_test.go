@@ -0,0 +1,101 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"testing"
+
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+)
+
+func TestCheckRestoreEngineVersion(t *testing.T) {
+	testcases := map[string]struct {
+		backup, target string
+		expect         bool
+	}{
+		"same":          {backup: "8.0.18", target: "8.0.18", expect: true},
+		"newer-patch":   {backup: "8.0.18-20230501", target: "8.0.32", expect: true},
+		"older-patch":   {backup: "8.0.32", target: "8.0.18", expect: false},
+		"another-major": {backup: "5.7.14", target: "8.0.18", expect: false},
+		"unknown":       {backup: "", target: "8.0.18", expect: true},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			if passed, msg := CheckRestoreEngineVersion(tc.backup, tc.target); passed != tc.expect {
+				t.Fatalf("expect %v, got %v: %s", tc.expect, passed, msg)
+			}
+		})
+	}
+}
+
+func TestCheckRestoreCompatibility(t *testing.T) {
+	one, zero := int32(1), int32(0)
+	backup := &polardbxv1xstore.EngineCompatibility{
+		EngineVersion:       "8.0.18-X-Cluster",
+		PageSize:            16384,
+		LowerCaseTableNames: &one,
+		CharacterSet:        "utf8mb4",
+		Collation:           "utf8mb4_general_ci",
+		Plugins:             []string{"rpl_semi_sync_master"},
+	}
+	incompatibleOf := func(checks []polardbxv1xstore.CompatibilityCheck) []string {
+		names := make([]string, 0)
+		for _, c := range checks {
+			if !c.Compatible {
+				names = append(names, c.Name)
+			}
+		}
+		return names
+	}
+
+	same := backup.DeepCopy()
+	same.CharacterSet = "UTF8MB4"
+	if checks, blocked := CheckRestoreCompatibility(backup, same, polardbxv1xstore.CompatibilityStrict); blocked || len(incompatibleOf(checks)) > 0 {
+		t.Fatalf("expect compatible, got %v", incompatibleOf(checks))
+	}
+
+	soft := backup.DeepCopy()
+	soft.Collation = "utf8mb4_bin"
+	soft.Plugins = nil
+	checks, blocked := CheckRestoreCompatibility(backup, soft, polardbxv1xstore.CompatibilityDefault)
+	if blocked || len(incompatibleOf(checks)) != 2 {
+		t.Fatalf("expect soft incompatibilities warned, got %v, blocked %v", incompatibleOf(checks), blocked)
+	}
+	if _, blocked := CheckRestoreCompatibility(backup, soft, polardbxv1xstore.CompatibilityStrict); !blocked {
+		t.Fatal("expect soft incompatibilities blocked in strict mode")
+	}
+
+	hard := backup.DeepCopy()
+	hard.PageSize = 65536
+	hard.LowerCaseTableNames = &zero
+	checks, blocked = CheckRestoreCompatibility(backup, hard, "")
+	if !blocked || len(incompatibleOf(checks)) != 2 {
+		t.Fatalf("expect hard incompatibilities blocked, got %v, blocked %v", incompatibleOf(checks), blocked)
+	}
+	if _, blocked := CheckRestoreCompatibility(backup, hard, polardbxv1xstore.CompatibilityWarnOnly); blocked {
+		t.Fatal("expect never blocked if warn only")
+	}
+
+	// Facts of older backups or targets are unknown.
+	older := &polardbxv1xstore.EngineCompatibility{EngineVersion: "8.0.18"}
+	if checks, blocked := CheckRestoreCompatibility(older, hard, polardbxv1xstore.CompatibilityStrict); blocked {
+		t.Fatalf("expect unknown facts not checked, got %v", incompatibleOf(checks))
+	}
+	if checks, blocked := CheckRestoreCompatibility(backup, nil, polardbxv1xstore.CompatibilityStrict); blocked {
+		t.Fatalf("expect unknown target not checked, got %v", incompatibleOf(checks))
+	}
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1polardbx "github.com/alibaba/polardbx-operator/api/v1/polardbx"
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+)
+
+// RetentionDeleteTime returns when the finished backup is deleted by the retention, i.e. the
+// retention time plus the grace period after the backup ends.
+func RetentionDeleteTime(endTime time.Time, retentionTime time.Duration, retention polardbxv1.BackupRetention) time.Time {
+	return endTime.Add(retentionTime).Add(retention.GracePeriod.Duration)
+}
+
+// ImmutableUntil returns until when the backup is required to be immutable, i.e. the later of the
+// specified time and the retention delete time if the retention is immutable. Zero means never.
+func ImmutableUntil(endTime time.Time, immutableUntil *metav1.Time, retentionTime time.Duration,
+	retention polardbxv1.BackupRetention) time.Time {
+	var until time.Time
+	if immutableUntil != nil {
+		until = immutableUntil.Time
+	}
+	if retention.Immutable {
+		if d := RetentionDeleteTime(endTime, retentionTime, retention); d.After(until) {
+			until = d
+		}
+	}
+	return until
+}
+
+func isXStoreRestoring(xstore *polardbxv1.XStore) bool {
+	if xstore.Spec.Restore == nil || !xstore.DeletionTimestamp.IsZero() {
+		return false
+	}
+	switch xstore.Status.Phase {
+	case polardbxv1xstore.PhaseNew, polardbxv1xstore.PhasePending, polardbxv1xstore.PhaseRestoring:
+		return true
+	}
+	// Continuous restore keeps applying the backups of the source.
+	return xstore.Spec.Restore.Continuous != nil
+}
+
+// xstoreRestoreReferences tells whether the xstore being restored may read the xstore backup. A
+// restore by time may pick any backup of the source, and a continuous restore reads the backups not
+// applied yet along with the last applied one.
+func xstoreRestoreReferences(xstore *polardbxv1.XStore, backup *polardbxv1.XStoreBackup) bool {
+	if !isXStoreRestoring(xstore) {
+		return false
+	}
+	restore := xstore.Spec.Restore
+	if xstore.Status.Phase != polardbxv1xstore.PhaseRunning {
+		return restore.BackupSet == backup.Name ||
+			(len(restore.BackupSet) == 0 && restore.From.XStoreName == backup.Spec.XStore.Name)
+	}
+	if restore.From.XStoreName != backup.Spec.XStore.Name {
+		return false
+	}
+	status := xstore.Status.ContinuousRestore
+	if status == nil || status.LastAppliedTimestamp == nil ||
+		status.LastAppliedBackup == backup.Name || status.ApplyingBackup == backup.Name {
+		return true
+	}
+	return backup.Status.BackupSetTimestamp != nil && backup.Status.BackupSetTimestamp.After(status.LastAppliedTimestamp.Time)
+}
+
+// polardbxRestoreReferences tells whether the polardbx cluster being restored may read the backup.
+func polardbxRestoreReferences(polardbx *polardbxv1.PolarDBXCluster, backup *polardbxv1.PolarDBXBackup) bool {
+	restore := polardbx.Spec.Restore
+	if restore == nil || !polardbx.DeletionTimestamp.IsZero() {
+		return false
+	}
+	switch polardbx.Status.Phase {
+	case polardbxv1polardbx.PhaseNew, polardbxv1polardbx.PhasePending, polardbxv1polardbx.PhaseCreating,
+		polardbxv1polardbx.PhaseRestoring:
+	default:
+		return false
+	}
+	if len(restore.BackupSet) > 0 {
+		return restore.BackupSet == backup.Name
+	}
+	return restore.From.PolarBDXName == backup.Spec.Cluster.Name
+}
+
+// ActiveRestoreOfXStoreBackup returns the name of the xstore restoring from the xstore backup, empty
+// if there's none.
+func ActiveRestoreOfXStoreBackup(ctx context.Context, c client.Client, backup *polardbxv1.XStoreBackup) (string, error) {
+	var xstoreList polardbxv1.XStoreList
+	if err := c.List(ctx, &xstoreList, client.InNamespace(backup.Namespace)); err != nil {
+		return "", err
+	}
+	for i := range xstoreList.Items {
+		if xstoreRestoreReferences(&xstoreList.Items[i], backup) {
+			return xstoreList.Items[i].Name, nil
+		}
+	}
+	return "", nil
+}
+
+// IncrementalDependentOfPolarDBXBackup returns the name of an xstore backup, of another backup, which is
+// incremental and based on one of the xstore backups of the backup, empty if there's none.
+func IncrementalDependentOfPolarDBXBackup(ctx context.Context, c client.Client, backup *polardbxv1.PolarDBXBackup) (string, error) {
+	var backupList polardbxv1.XStoreBackupList
+	if err := c.List(ctx, &backupList, client.InNamespace(backup.Namespace)); err != nil {
+		return "", err
+	}
+	own := make(map[string]bool)
+	for _, b := range backupList.Items {
+		if b.Labels[polardbxmeta.LabelTopBackup] == backup.Name {
+			own[b.Name] = true
+		}
+	}
+	for _, b := range backupList.Items {
+		if own[b.Name] || b.Status.Incremental == nil || !b.DeletionTimestamp.IsZero() {
+			continue
+		}
+		for _, name := range b.Status.Incremental.Chain {
+			if own[name] {
+				return b.Name, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// ActiveRestoreOfPolarDBXBackup returns the name of the polardbx cluster restoring from the backup,
+// or of the xstore restoring from one of its xstore backups, empty if there's none.
+func ActiveRestoreOfPolarDBXBackup(ctx context.Context, c client.Client, backup *polardbxv1.PolarDBXBackup) (string, error) {
+	var polardbxList polardbxv1.PolarDBXClusterList
+	if err := c.List(ctx, &polardbxList, client.InNamespace(backup.Namespace)); err != nil {
+		return "", err
+	}
+	for i := range polardbxList.Items {
+		if polardbxRestoreReferences(&polardbxList.Items[i], backup) {
+			return polardbxList.Items[i].Name, nil
+		}
+	}
+
+	var backupList polardbxv1.XStoreBackupList
+	if err := c.List(ctx, &backupList, client.InNamespace(backup.Namespace),
+		client.MatchingLabels{polardbxmeta.LabelTopBackup: backup.Name}); err != nil {
+		return "", err
+	}
+	if len(backupList.Items) == 0 {
+		return "", nil
+	}
+	var xstoreList polardbxv1.XStoreList
+	if err := c.List(ctx, &xstoreList, client.InNamespace(backup.Namespace)); err != nil {
+		return "", err
+	}
+	for i := range xstoreList.Items {
+		for j := range backupList.Items {
+			if xstoreRestoreReferences(&xstoreList.Items[i], &backupList.Items[j]) {
+				return xstoreList.Items[i].Name, nil
+			}
+		}
+	}
+	return "", nil
+}
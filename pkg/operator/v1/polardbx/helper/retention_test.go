@@ -0,0 +1,140 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1polardbx "github.com/alibaba/polardbx-operator/api/v1/polardbx"
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+)
+
+func TestRetentionDeleteTime(t *testing.T) {
+	end := time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+	retention := polardbxv1.BackupRetention{GracePeriod: metav1.Duration{Duration: time.Hour}}
+	if d := RetentionDeleteTime(end, 24*time.Hour, retention); !d.Equal(end.Add(25 * time.Hour)) {
+		t.Fatalf("unexpected delete time: %s", d)
+	}
+	if d := RetentionDeleteTime(end, 0, polardbxv1.BackupRetention{}); !d.Equal(end) {
+		t.Fatalf("unexpected delete time: %s", d)
+	}
+}
+
+func TestImmutableUntil(t *testing.T) {
+	end := time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+	retention := polardbxv1.BackupRetention{GracePeriod: metav1.Duration{Duration: time.Hour}}
+	if u := ImmutableUntil(end, nil, 24*time.Hour, retention); !u.IsZero() {
+		t.Fatalf("expect not immutable, got %s", u)
+	}
+	until := &metav1.Time{Time: end.Add(48 * time.Hour)}
+	if u := ImmutableUntil(end, until, 24*time.Hour, retention); !u.Equal(until.Time) {
+		t.Fatalf("unexpected immutable time: %s", u)
+	}
+	retention.Immutable = true
+	if u := ImmutableUntil(end, nil, 24*time.Hour, retention); !u.Equal(end.Add(25 * time.Hour)) {
+		t.Fatalf("expect derived from retention, got %s", u)
+	}
+	if u := ImmutableUntil(end, until, 24*time.Hour, retention); !u.Equal(until.Time) {
+		t.Fatalf("expect the later one, got %s", u)
+	}
+}
+
+func TestXStoreRestoreReferences(t *testing.T) {
+	newBackup := func(name string, at time.Time) *polardbxv1.XStoreBackup {
+		b := &polardbxv1.XStoreBackup{}
+		b.Name = name
+		b.Spec.XStore.Name = "src"
+		b.Status.BackupSetTimestamp = &metav1.Time{Time: at}
+		return b
+	}
+	now := time.Now()
+	old, applied, newer := newBackup("old", now.Add(-2*time.Hour)), newBackup("applied", now.Add(-time.Hour)), newBackup("newer", now)
+
+	newXStore := func(phase polardbxv1xstore.Phase, restore *polardbxv1.XStoreRestoreSpec) *polardbxv1.XStore {
+		x := &polardbxv1.XStore{}
+		x.Spec.Restore = restore
+		x.Status.Phase = phase
+		return x
+	}
+	bySet := &polardbxv1.XStoreRestoreSpec{BackupSet: "applied"}
+	byTime := &polardbxv1.XStoreRestoreSpec{From: polardbxv1.XStoreRestoreFrom{XStoreName: "src"}}
+	continuous := &polardbxv1.XStoreRestoreSpec{
+		BackupSet:  "old",
+		From:       polardbxv1.XStoreRestoreFrom{XStoreName: "src"},
+		Continuous: &polardbxv1.XStoreContinuousRestore{},
+	}
+	standby := newXStore(polardbxv1xstore.PhaseRunning, continuous)
+	standby.Status.ContinuousRestore = &polardbxv1xstore.ContinuousRestoreStatus{
+		LastAppliedBackup:    "applied",
+		LastAppliedTimestamp: applied.Status.BackupSetTimestamp,
+	}
+
+	testcases := map[string]struct {
+		xstore *polardbxv1.XStore
+		backup *polardbxv1.XStoreBackup
+		expect bool
+	}{
+		"by-set":                 {xstore: newXStore(polardbxv1xstore.PhaseRestoring, bySet), backup: applied, expect: true},
+		"by-set-another":         {xstore: newXStore(polardbxv1xstore.PhaseRestoring, bySet), backup: old},
+		"by-time":                {xstore: newXStore(polardbxv1xstore.PhasePending, byTime), backup: old, expect: true},
+		"restored":               {xstore: newXStore(polardbxv1xstore.PhaseRunning, bySet), backup: applied},
+		"no-restore":             {xstore: newXStore(polardbxv1xstore.PhaseNew, nil), backup: applied},
+		"continuous-applied":     {xstore: standby, backup: old},
+		"continuous-last":        {xstore: standby, backup: applied, expect: true},
+		"continuous-not-applied": {xstore: standby, backup: newer, expect: true},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			if r := xstoreRestoreReferences(tc.xstore, tc.backup); r != tc.expect {
+				t.Fatalf("expect %v, got %v", tc.expect, r)
+			}
+		})
+	}
+}
+
+func TestPolarDBXRestoreReferences(t *testing.T) {
+	backup := &polardbxv1.PolarDBXBackup{}
+	backup.Name = "pxc-backup"
+	backup.Spec.Cluster.Name = "pxc"
+
+	newPolarDBX := func(phase polardbxv1polardbx.Phase, restore *polardbxv1polardbx.RestoreSpec) *polardbxv1.PolarDBXCluster {
+		p := &polardbxv1.PolarDBXCluster{}
+		p.Spec.Restore = restore
+		p.Status.Phase = phase
+		return p
+	}
+	if !polardbxRestoreReferences(newPolarDBX(polardbxv1polardbx.PhasePending,
+		&polardbxv1polardbx.RestoreSpec{BackupSet: "pxc-backup"}), backup) {
+		t.Fatal("expect referenced by backup set")
+	}
+	if !polardbxRestoreReferences(newPolarDBX(polardbxv1polardbx.PhaseRestoring,
+		&polardbxv1polardbx.RestoreSpec{From: polardbxv1polardbx.PolarDBXRestoreFrom{PolarBDXName: "pxc"}}), backup) {
+		t.Fatal("expect referenced by time")
+	}
+	if polardbxRestoreReferences(newPolarDBX(polardbxv1polardbx.PhaseRunning,
+		&polardbxv1polardbx.RestoreSpec{BackupSet: "pxc-backup"}), backup) {
+		t.Fatal("expect not referenced once restored")
+	}
+	if polardbxRestoreReferences(newPolarDBX(polardbxv1polardbx.PhaseRestoring,
+		&polardbxv1polardbx.RestoreSpec{BackupSet: "another"}), backup) {
+		t.Fatal("expect not referenced by another backup set")
+	}
+}
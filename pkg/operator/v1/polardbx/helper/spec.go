@@ -17,10 +17,14 @@ limitations under the License.
 package helper
 
 import (
+	"time"
+
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 
 	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1polardbx "github.com/alibaba/polardbx-operator/api/v1/polardbx"
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
 )
 
 func IsTopologyOrStaticConfigChanges(polardbx *polardbxv1.PolarDBXCluster) bool {
@@ -40,6 +44,17 @@ func IsTopologyOrStaticConfigChanges(polardbx *polardbxv1.PolarDBXCluster) bool
 	return topologyChanged || staticConfigChanged
 }
 
+// IsCanaryUpgradePending returns true if the cluster upgrades with Canary strategy and the canary
+// of current generation isn't finished.
+func IsCanaryUpgradePending(polardbx *polardbxv1.PolarDBXCluster) bool {
+	if polardbx.Spec.UpgradeStrategy != polardbxv1polardbx.CanaryUpgradeStrategy {
+		return false
+	}
+	canary := polardbx.Status.Canary
+	return canary == nil || canary.Generation != polardbx.Generation ||
+		canary.Phase != polardbxv1polardbx.CanaryCompleted
+}
+
 func GetEncodeKeySecretKeySelector(polardbx *polardbxv1.PolarDBXCluster) *corev1.SecretKeySelector {
 	if polardbx.Spec.Security != nil {
 		return polardbx.Spec.Security.EncodeKey
@@ -51,7 +66,53 @@ func IsTLSEnabled(polardbx *polardbxv1.PolarDBXCluster) bool {
 	return polardbx.Spec.Security != nil &&
 		polardbx.Spec.Security.TLS != nil &&
 		(len(polardbx.Spec.Security.TLS.SecretName) > 0 ||
-			polardbx.Spec.Security.TLS.GenerateSelfSigned)
+			polardbx.Spec.Security.TLS.GenerateSelfSigned ||
+			polardbx.Spec.Security.TLS.IssuerRef != nil)
+}
+
+// GetTLSDuration returns the validity of the certificates, default is 8760h.
+func GetTLSDuration(polardbx *polardbxv1.PolarDBXCluster) time.Duration {
+	if !IsTLSEnabled(polardbx) || polardbx.Spec.Security.TLS.Duration == nil {
+		return 8760 * time.Hour
+	}
+	return polardbx.Spec.Security.TLS.Duration.Duration
+}
+
+// GetTLSRenewBefore returns how long before the expiry the certificates are rotated, default is 720h.
+func GetTLSRenewBefore(polardbx *polardbxv1.PolarDBXCluster) time.Duration {
+	if !IsTLSEnabled(polardbx) || polardbx.Spec.Security.TLS.RenewBefore == nil {
+		return 720 * time.Hour
+	}
+	return polardbx.Spec.Security.TLS.RenewBefore.Duration
+}
+
+// IsInternalTLSEnabled returns true if TLS is enabled on the engines of GMS and DNs as well.
+func IsInternalTLSEnabled(polardbx *polardbxv1.PolarDBXCluster) bool {
+	return IsTLSEnabled(polardbx) && polardbx.Spec.Security.TLS.Internal
+}
+
+// GetTDE returns the TDE config of the engines of GMS and DNs, or nil if not enabled.
+func GetTDE(polardbx *polardbxv1.PolarDBXCluster) *polardbxv1xstore.TDE {
+	if polardbx.Spec.Security == nil {
+		return nil
+	}
+	return polardbx.Spec.Security.TDE
+}
+
+// GetCNLogCollector returns the log collector of CNs, or nil if the audit logs aren't shipped.
+func GetCNLogCollector(polardbx *polardbxv1.PolarDBXCluster) *polardbxv1xstore.LogCollector {
+	if polardbx.Spec.LogCollector == nil || !polardbx.Spec.LogCollector.CNAuditLog {
+		return nil
+	}
+	return &polardbx.Spec.LogCollector.LogCollector
+}
+
+// GetStoreLogCollector returns the log collector of GMS and DNs, or nil if the slow logs aren't shipped.
+func GetStoreLogCollector(polardbx *polardbxv1.PolarDBXCluster) *polardbxv1xstore.LogCollector {
+	if polardbx.Spec.LogCollector == nil || !polardbx.Spec.LogCollector.DNSlowLog {
+		return nil
+	}
+	return &polardbx.Spec.LogCollector.LogCollector
 }
 
 func IsMonitorConfigChanged(monitor *polardbxv1.PolarDBXMonitor) bool {
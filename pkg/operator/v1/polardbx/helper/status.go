@@ -17,8 +17,14 @@ limitations under the License.
 package helper
 
 import (
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/api/v1/common"
 	polardbxv1polardbx "github.com/alibaba/polardbx-operator/api/v1/polardbx"
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
 )
 
 func IsPhaseIn(polardbx *polardbxv1.PolarDBXCluster, phases ...polardbxv1polardbx.Phase) bool {
@@ -33,7 +39,124 @@ func IsPhaseIn(polardbx *polardbxv1.PolarDBXCluster, phases ...polardbxv1polardb
 	return false
 }
 
+// MaxRestoreShardAttempts is the maximum times a shard is restored automatically before the
+// cluster is considered failed.
+const MaxRestoreShardAttempts int32 = 3
+
+// IsRestoreShardRetriable returns true if the shard is failed while restoring and has attempts
+// left, in which case it is restored again rather than failing the cluster.
+func IsRestoreShardRetriable(polardbx *polardbxv1.PolarDBXCluster, name string) bool {
+	if !IsPhaseIn(polardbx, polardbxv1polardbx.PhaseRestoring) || polardbx.Status.RestoreStatus == nil {
+		return false
+	}
+	status, ok := polardbx.Status.RestoreStatus.Shards[name]
+	return ok && status.Attempts < MaxRestoreShardAttempts
+}
+
+// GetRestoreShardsToRetry returns the failed shards which have attempts left.
+func GetRestoreShardsToRetry(restoreStatus *polardbxv1polardbx.RestoreStatus) []string {
+	if restoreStatus == nil {
+		return nil
+	}
+	shards := make([]string, 0)
+	for name, status := range restoreStatus.Shards {
+		if status.Phase == polardbxv1polardbx.RestoreShardFailed && status.Attempts < MaxRestoreShardAttempts {
+			shards = append(shards, name)
+		}
+	}
+	sort.Strings(shards)
+	return shards
+}
+
 func TransferPhase(polardbx *polardbxv1.PolarDBXCluster, phase polardbxv1polardbx.Phase) {
 	polardbx.Status.Stage = polardbxv1polardbx.StageEmpty
 	polardbx.Status.Phase = phase
 }
+
+// SetClusterCondition adds the condition or replaces the one of the same type in place. The transition
+// time is kept if the status is not changed.
+func SetClusterCondition(conditions []polardbxv1polardbx.Condition, condition polardbxv1polardbx.Condition) []polardbxv1polardbx.Condition {
+	result := make([]polardbxv1polardbx.Condition, 0, len(conditions)+1)
+	found := false
+	for _, c := range conditions {
+		if c.Type == condition.Type {
+			if c.Status == condition.Status {
+				condition.LastTransitionTime = c.LastTransitionTime
+			}
+			c, found = condition, true
+		}
+		result = append(result, c)
+	}
+	if !found {
+		result = append(result, condition)
+	}
+	return result
+}
+
+// SetXStoreCondition adds the condition or replaces the one of the same type in place. The transition
+// time is kept if the status is not changed.
+func SetXStoreCondition(conditions []polardbxv1xstore.Condition, condition polardbxv1xstore.Condition) []polardbxv1xstore.Condition {
+	result := make([]polardbxv1xstore.Condition, 0, len(conditions)+1)
+	found := false
+	for _, c := range conditions {
+		if c.Type == condition.Type {
+			if c.Status == condition.Status {
+				condition.LastTransitionTime = c.LastTransitionTime
+			}
+			c, found = condition, true
+		}
+		result = append(result, c)
+	}
+	if !found {
+		result = append(result, condition)
+	}
+	return result
+}
+
+// GetXStoreCondition returns the condition of the type, nil if not found.
+func GetXStoreCondition(conditions []polardbxv1xstore.Condition, conditionType polardbxv1xstore.ConditionType) *polardbxv1xstore.Condition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// ObserveProgress records the stage observed into the progress of the operation. A new operation is
+// started if the last one is completed, and the operation is completed once the object is observed
+// steady. The progress is returned as is if nothing changes, so that it can be compared for changes.
+func ObserveProgress(progress *common.OperationProgress, operation, stage string, steady bool, now metav1.Time) *common.OperationProgress {
+	inProgress := progress != nil && progress.CompletionTime == nil
+	if steady {
+		if !inProgress {
+			return progress
+		}
+		progress = progress.DeepCopy()
+		progress.CompletionTime = &now
+		if n := len(progress.Stages); n > 0 && progress.Stages[n-1].CompletionTime == nil {
+			progress.Stages[n-1].CompletionTime = &now
+		}
+		return progress
+	}
+
+	if !inProgress {
+		return &common.OperationProgress{
+			Operation: operation,
+			StartTime: now,
+			Stages:    []common.StageProgress{{Name: stage, StartTime: now}},
+		}
+	}
+	if n := len(progress.Stages); n > 0 && progress.Stages[n-1].Name == stage {
+		return progress
+	}
+	progress = progress.DeepCopy()
+	if n := len(progress.Stages); n > 0 {
+		progress.Stages[n-1].CompletionTime = &now
+	}
+	progress.Stages = append(progress.Stages, common.StageProgress{Name: stage, StartTime: now})
+	if len(progress.Stages) > common.MaxProgressStages {
+		progress.Stages = progress.Stages[len(progress.Stages)-common.MaxProgressStages:]
+	}
+	return progress
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/api/v1/common"
+	polardbxv1polardbx "github.com/alibaba/polardbx-operator/api/v1/polardbx"
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+)
+
+func TestObserveProgress(t *testing.T) {
+	t0 := metav1.NewTime(time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC))
+	t1, t2 := metav1.NewTime(t0.Add(time.Minute)), metav1.NewTime(t0.Add(2*time.Minute))
+
+	if progress := ObserveProgress(nil, "Running", "Running", true, t0); progress != nil {
+		t.Fatalf("expect no operation when steady, got %v", progress)
+	}
+
+	progress := ObserveProgress(nil, "Upgrading", "Upgrading", false, t0)
+	if progress.Operation != "Upgrading" || !progress.StartTime.Equal(&t0) || len(progress.Stages) != 1 {
+		t.Fatalf("expect operation started, got %v", progress)
+	}
+	if same := ObserveProgress(progress, "Upgrading", "Upgrading", false, t1); same != progress {
+		t.Fatal("expect progress unchanged in the same stage")
+	}
+
+	progress = ObserveProgress(progress, "Upgrading", "Upgrading/Canary", false, t1)
+	if len(progress.Stages) != 2 || !progress.Stages[0].CompletionTime.Equal(&t1) ||
+		progress.Stages[1].Name != "Upgrading/Canary" || progress.Stages[1].CompletionTime != nil {
+		t.Fatalf("expect stage entered, got %v", progress.Stages)
+	}
+
+	progress = ObserveProgress(progress, "Running", "Running", true, t2)
+	if !progress.CompletionTime.Equal(&t2) || !progress.Stages[1].CompletionTime.Equal(&t2) {
+		t.Fatalf("expect operation completed, got %v", progress)
+	}
+
+	progress = ObserveProgress(progress, "Restarting", "Restarting", false, t2)
+	if progress.Operation != "Restarting" || progress.CompletionTime != nil || len(progress.Stages) != 1 {
+		t.Fatalf("expect new operation started, got %v", progress)
+	}
+
+	for i := 0; i < common.MaxProgressStages*2; i++ {
+		progress = ObserveProgress(progress, "Restarting", string(rune('a'+i%2)), false, t2)
+	}
+	if len(progress.Stages) != common.MaxProgressStages {
+		t.Fatalf("expect stages bounded, got %d", len(progress.Stages))
+	}
+}
+
+func TestSetXStoreCondition(t *testing.T) {
+	t0 := metav1.NewTime(time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC))
+	t1 := metav1.NewTime(t0.Add(time.Minute))
+	conditions := []polardbxv1xstore.Condition{
+		{Type: polardbxv1xstore.Ready, Status: corev1.ConditionFalse, LastTransitionTime: t0},
+		{Type: polardbxv1xstore.Degraded, Status: corev1.ConditionFalse, LastTransitionTime: t0},
+	}
+
+	conditions = SetXStoreCondition(conditions, polardbxv1xstore.Condition{
+		Type: polardbxv1xstore.Degraded, Status: corev1.ConditionFalse, Message: "m", LastTransitionTime: t1,
+	})
+	if len(conditions) != 2 || conditions[1].Message != "m" || !conditions[1].LastTransitionTime.Equal(&t0) {
+		t.Fatalf("expect condition replaced in place with transition time kept, got %v", conditions)
+	}
+
+	conditions = SetXStoreCondition(conditions, polardbxv1xstore.Condition{
+		Type: polardbxv1xstore.Ready, Status: corev1.ConditionTrue, LastTransitionTime: t1,
+	})
+	if conditions[0].Type != polardbxv1xstore.Ready || !conditions[0].LastTransitionTime.Equal(&t1) {
+		t.Fatalf("expect transition time updated, got %v", conditions)
+	}
+
+	conditions = SetXStoreCondition(conditions, polardbxv1xstore.Condition{Type: polardbxv1xstore.Progressing})
+	if len(conditions) != 3 || conditions[2].Type != polardbxv1xstore.Progressing {
+		t.Fatalf("expect condition added, got %v", conditions)
+	}
+}
+
+func TestNewBackupStatusConditions(t *testing.T) {
+	statusOf := func(conditions []polardbxv1xstore.Condition, conditionType polardbxv1xstore.ConditionType) corev1.ConditionStatus {
+		return GetXStoreCondition(conditions, conditionType).Status
+	}
+
+	conditions := NewBackupStatusConditions("Backuping", false, false, "", nil)
+	if statusOf(conditions, polardbxv1xstore.Ready) != corev1.ConditionFalse ||
+		statusOf(conditions, polardbxv1xstore.Progressing) != corev1.ConditionTrue ||
+		statusOf(conditions, polardbxv1xstore.Degraded) != corev1.ConditionFalse {
+		t.Fatalf("unexpected conditions of running backup: %v", conditions)
+	}
+
+	conditions = NewBackupStatusConditions("Finished", true, false, "", nil)
+	if statusOf(conditions, polardbxv1xstore.Ready) != corev1.ConditionTrue ||
+		statusOf(conditions, polardbxv1xstore.Progressing) != corev1.ConditionFalse {
+		t.Fatalf("unexpected conditions of finished backup: %v", conditions)
+	}
+
+	conditions = NewBackupStatusConditions("Failed", false, true, "Timeout", nil)
+	if c := GetXStoreCondition(conditions, polardbxv1xstore.Degraded); c.Status != corev1.ConditionTrue || c.Reason != "Timeout" {
+		t.Fatalf("expect failed backup degraded, got %v", c)
+	}
+
+	conditions = NewBackupStatusConditions("Backuping", false, false, "", []polardbxv1xstore.Condition{
+		{Type: polardbxv1xstore.BackupCircuitOpen, Status: corev1.ConditionTrue},
+	})
+	if c := GetXStoreCondition(conditions, polardbxv1xstore.Degraded); c.Status != corev1.ConditionTrue || c.Reason != "CircuitOpen" {
+		t.Fatalf("expect backup with circuit open degraded, got %v", c)
+	}
+}
+
+func TestRestoreShardsToRetry(t *testing.T) {
+	polardbx := &polardbxv1.PolarDBXCluster{}
+	polardbx.Status.Phase = polardbxv1polardbx.PhaseRestoring
+	polardbx.Status.RestoreStatus = &polardbxv1polardbx.RestoreStatus{
+		Shards: map[string]polardbxv1polardbx.RestoreShardStatus{
+			"pxc-gms":  {Phase: polardbxv1polardbx.RestoreShardSucceeded, Attempts: 1},
+			"pxc-dn-0": {Phase: polardbxv1polardbx.RestoreShardFailed, Attempts: 1},
+			"pxc-dn-1": {Phase: polardbxv1polardbx.RestoreShardRestoring, Attempts: 1},
+		},
+	}
+	shards := polardbx.Status.RestoreStatus.Shards
+
+	// A late failing shard is restored again while the cluster is kept restoring.
+	if toRetry := GetRestoreShardsToRetry(polardbx.Status.RestoreStatus); len(toRetry) != 1 || toRetry[0] != "pxc-dn-0" {
+		t.Fatalf("expect pxc-dn-0 to retry, got %v", toRetry)
+	}
+	if !IsRestoreShardRetriable(polardbx, "pxc-dn-0") {
+		t.Fatal("expect pxc-dn-0 retriable")
+	}
+
+	// Once reset, the shard is pending and not retried twice.
+	shards["pxc-dn-0"] = polardbxv1polardbx.RestoreShardStatus{Phase: polardbxv1polardbx.RestoreShardPending, Attempts: 2}
+	if toRetry := GetRestoreShardsToRetry(polardbx.Status.RestoreStatus); len(toRetry) != 0 {
+		t.Fatalf("expect nothing to retry, got %v", toRetry)
+	}
+
+	// Failed again with attempts left.
+	shards["pxc-dn-0"] = polardbxv1polardbx.RestoreShardStatus{Phase: polardbxv1polardbx.RestoreShardFailed, Attempts: 2}
+	if toRetry := GetRestoreShardsToRetry(polardbx.Status.RestoreStatus); len(toRetry) != 1 {
+		t.Fatalf("expect pxc-dn-0 to retry, got %v", toRetry)
+	}
+
+	// Out of attempts, the cluster is going to fail.
+	shards["pxc-dn-0"] = polardbxv1polardbx.RestoreShardStatus{Phase: polardbxv1polardbx.RestoreShardFailed, Attempts: MaxRestoreShardAttempts}
+	if toRetry := GetRestoreShardsToRetry(polardbx.Status.RestoreStatus); len(toRetry) != 0 {
+		t.Fatalf("expect nothing to retry, got %v", toRetry)
+	}
+	if IsRestoreShardRetriable(polardbx, "pxc-dn-0") {
+		t.Fatal("expect pxc-dn-0 not retriable when out of attempts")
+	}
+
+	// Never retried out of restoring, or for unknown shards.
+	shards["pxc-dn-0"] = polardbxv1polardbx.RestoreShardStatus{Phase: polardbxv1polardbx.RestoreShardFailed, Attempts: 1}
+	if IsRestoreShardRetriable(polardbx, "pxc-dn-2") {
+		t.Fatal("expect unknown shard not retriable")
+	}
+	polardbx.Status.Phase = polardbxv1polardbx.PhaseRunning
+	if IsRestoreShardRetriable(polardbx, "pxc-dn-0") {
+		t.Fatal("expect not retriable out of restoring")
+	}
+}
@@ -33,3 +33,58 @@ const (
 	AnnotationTopologyModeGuide = "polardbx/topology-mode-guide"
 	AnnotationTopologyRuleGuide = "polardbx/topology-rule-guide"
 )
+
+// Upgrade annotations
+const (
+	// AnnotationUpgradeResume indicates the controller to continue the canary upgrade which is
+	// paused or failed. It's removed once the upgrade continues.
+	AnnotationUpgradeResume = "polardbx/upgrade.resume"
+)
+
+// Parameter annotations
+const (
+	// AnnotationParameterRollback indicates the controller to roll back the parameters to the ones
+	// applied before the last modification.
+	AnnotationParameterRollback = "polardbx/parameter.rollback"
+)
+
+// Restore annotations
+const (
+	// AnnotationRestoreResume indicates the controller to re-run the restore of failed shards.
+	AnnotationRestoreResume = "polardbx/restore.resume"
+	// AnnotationRestoreForceShards lists the shards (xstore names, comma separated) to be
+	// restored again even if they have been restored successfully.
+	AnnotationRestoreForceShards = "polardbx/restore.force-shards"
+)
+
+// Backup annotations
+const (
+	// AnnotationBackupTrigger is set by the creator of backup to declare how the backup is
+	// triggered, i.e. "Manual", "Scheduled" or "API".
+	AnnotationBackupTrigger = "polardbx/backup.trigger"
+	// AnnotationBackupResume indicates the controller to resume the backup whose circuit is open.
+	AnnotationBackupResume = "polardbx/backup.resume"
+	// AnnotationBackupSkipXStoreCheck indicates the webhook to skip the check of xstore referenced
+	// by the xstore backup on creation.
+	AnnotationBackupSkipXStoreCheck = "polardbx/backup.skip-xstore-check"
+	// AnnotationSkipPreflightCheck indicates the webhook to skip the pre-flight checks of the backup, or
+	// the restore of the cluster, on creation, e.g. the storage is known to be unreachable from operator.
+	AnnotationSkipPreflightCheck = "polardbx/skip-preflight-check"
+	// AnnotationBackupMaxConcurrent is set on the namespace to cap the count of in-flight xstore
+	// backups in it, which overrides the one in operator config. Non-positive means unlimited.
+	AnnotationBackupMaxConcurrent = "polardbx/backup.max-concurrent"
+	// AnnotationBackupProtected is set on the pxc backup or xstore backup to protect it from being
+	// reaped along with the other backups of the deleted cluster, if "true".
+	AnnotationBackupProtected = "polardbx/backup.protected"
+	// AnnotationBackupImportedFrom is set on the pxc backups, xstore backups and secrets imported for
+	// restoring, with the source of them, i.e. "<namespace>/<name>" or "<storage>:<path>". Imported
+	// backups are never reconciled, and objects of the same name from another source are never taken
+	// as imported.
+	AnnotationBackupImportedFrom = "polardbx/backup.imported-from"
+	// AnnotationStandbyBinlogs is set on the apply jobs of standby with the binlogs applied by the job,
+	// separated by comma.
+	AnnotationStandbyBinlogs = "polardbx/standby.binlogs"
+	// AnnotationTLSCertificateHash is set on the pod template of CN with the hash of the certificate in use,
+	// so that the pods are rolled when the certificate is rotated.
+	AnnotationTLSCertificateHash = "polardbx/tls.certificate-hash"
+)
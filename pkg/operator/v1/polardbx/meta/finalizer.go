@@ -17,3 +17,7 @@ limitations under the License.
 package meta
 
 const Finalizer = "polardbx/finalizer"
+
+// BackupFilesFinalizer guards the backup files to be deleted along with the backup object by the
+// clean policy.
+const BackupFilesFinalizer = "polardbx/backup-files"
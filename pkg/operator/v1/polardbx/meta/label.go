@@ -31,16 +31,37 @@ const (
 	LabelBackupXStore        = "polardbx/xstore"
 	LabelBackupXStoreUID     = "polardbx/xstore-uid"
 	LabelPreferredBackupNode = "polardbx/preferred-backup-node"
+	LabelBackupTrigger       = "polardbx/backup-trigger"
+	LabelBackupSelfTest      = "polardbx/backup-selftest"
+	LabelClone               = "polardbx/clone"
+	LabelBackupSchedule      = "polardbx/backup-schedule"
+	LabelBinlogBackup        = "polardbx/binlog-backup"
+	LabelStandby             = "polardbx/standby"
 	LabelBinlogPurgeLock     = "polardbx/binlogpurge-lock"
 	LabelPrimaryName         = "polardbx/primary-name"
 	LabelType                = "polardbx/type"
 	LabelAuditLog            = "polardbx/enableAuditLog"
+	LabelCanary              = "polardbx/canary"
+
+	// LabelServiceTemplate labels the services created from the service templates with the template name.
+	LabelServiceTemplate = "polardbx/service-template"
+
+	// LabelRecoverableFrom and LabelRecoverableTo label the recoverable window of finished backups
+	// in unix seconds.
+	LabelRecoverableFrom = "polardbx/recoverable-from"
+	LabelRecoverableTo   = "polardbx/recoverable-to"
 )
 const (
 	SeekCpJobLabelPXCName    = "seekcp-job/pxc"
 	SeekCpJobLabelBackupName = "seekcp-job/backup"
 )
 
+// CopyJobLabelBackupName labels the job copying the files of backup with the name of the copy.
+const CopyJobLabelBackupName = "copy-job/backup"
+
+// PreviewJobLabelBackupName labels the job checking the objects of backup for restore preview.
+const PreviewJobLabelBackupName = "preview-job/backup"
+
 const (
 	RoleGMS = "gms"
 	RoleCN  = "cn"
@@ -54,7 +75,9 @@ const (
 	BinlogOffsetPath  = "binlogoffset"
 	CollectBinlogPath = "collect"
 	BinlogBackupPath  = "binlogbackup"
+	BinlogShipPath    = "polardbx-binlogbackup"
 	SeekCpName        = "set.cp"
+	CDCCheckpointName = "cdc.cp"
 	BinlogIndexesName = "indexes"
 )
 
@@ -50,6 +50,7 @@ import (
 	"github.com/alibaba/polardbx-operator/pkg/meta/core/group"
 	"github.com/alibaba/polardbx-operator/pkg/operator/v1/config"
 	"github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/convention"
+	polardbxhelper "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
 	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
 	dbutil "github.com/alibaba/polardbx-operator/pkg/util/database"
 )
@@ -84,6 +85,34 @@ type Context struct {
 	polardbxParamsRoleMap        map[string]map[string]polardbxv1.Params
 	roleToRestart                map[string]bool
 
+	polardbxBackupSelfTest               *polardbxv1.PolarDBXBackupSelfTest
+	polardbxBackupSelfTestKey            types.NamespacedName
+	polardbxBackupSelfTestStatusSnapshot *polardbxv1.PolarDBXBackupSelfTestStatus
+
+	polardbxClone               *polardbxv1.PolarDBXClone
+	polardbxCloneKey            types.NamespacedName
+	polardbxCloneStatusSnapshot *polardbxv1.PolarDBXCloneStatus
+
+	polardbxBackupReap               *polardbxv1.PolarDBXBackupReap
+	polardbxBackupReapKey            types.NamespacedName
+	polardbxBackupReapStatusSnapshot *polardbxv1.PolarDBXBackupReapStatus
+
+	polardbxBackupSchedule               *polardbxv1.PolarDBXBackupSchedule
+	polardbxBackupScheduleKey            types.NamespacedName
+	polardbxBackupScheduleStatusSnapshot *polardbxv1.PolarDBXBackupScheduleStatus
+
+	polardbxBinlogBackup               *polardbxv1.PolarDBXBinlogBackup
+	polardbxBinlogBackupKey            types.NamespacedName
+	polardbxBinlogBackupStatusSnapshot *polardbxv1.PolarDBXBinlogBackupStatus
+
+	polardbxStandby               *polardbxv1.PolarDBXStandby
+	polardbxStandbyKey            types.NamespacedName
+	polardbxStandbyStatusSnapshot *polardbxv1.PolarDBXStandbyStatus
+
+	polardbxAutoscaler               *polardbxv1.PolarDBXAutoscaler
+	polardbxAutoscalerKey            types.NamespacedName
+	polardbxAutoscalerStatusSnapshot *polardbxv1.PolarDBXAutoscalerStatus
+
 	polardbxParameter       *polardbxv1.PolarDBXParameter
 	polardbxParameterKey    types.NamespacedName
 	polardbxParameterStatus *polardbxv1.PolarDBXParameterStatus
@@ -353,6 +382,22 @@ func (rc *Context) SetControllerRefAndCreateToBackup(obj client.Object) error {
 	return rc.Client().Create(rc.Context(), obj)
 }
 
+func (rc *Context) SetControllerRefAndCreateToBackupSelfTest(obj client.Object) error {
+	selfTest := rc.MustGetPolarDBXBackupSelfTest()
+	if err := ctrl.SetControllerReference(selfTest, obj, rc.Scheme()); err != nil {
+		return err
+	}
+	return rc.Client().Create(rc.Context(), obj)
+}
+
+func (rc *Context) SetControllerRefAndCreateToClone(obj client.Object) error {
+	clone := rc.MustGetPolarDBXClone()
+	if err := ctrl.SetControllerReference(clone, obj, rc.Scheme()); err != nil {
+		return err
+	}
+	return rc.Client().Create(rc.Context(), obj)
+}
+
 func (rc *Context) SetControllerRefAndUpdate(obj client.Object) error {
 	if err := rc.SetControllerRef(obj); err != nil {
 		return err
@@ -492,12 +537,7 @@ func (rc *Context) ParseRestoreTime() (time.Time, error) {
 		return time.Time{}, nil
 	}
 
-	location, err := time.LoadLocation(gms.StrOrDefault(polarDBX.Spec.Restore.TimeZone, "Asia/Shanghai"))
-	if err != nil {
-		return time.Time{}, nil
-	}
-
-	return time.ParseInLocation("2006-01-02 15:04:05", polarDBX.Spec.Restore.Time, location)
+	return polardbxhelper.ParseRestoreTime(polarDBX.Spec.Restore.Time, polarDBX.Spec.Restore.TimeZone)
 }
 
 func (rc *Context) MustParseRestoreTime() time.Time {
@@ -788,6 +828,10 @@ func (rc *Context) getDeploymentMap(polardbx *polardbxv1.PolarDBXCluster, role s
 		if err := k8shelper.CheckControllerReference(&deploy, polardbx); err != nil {
 			continue
 		}
+		// Canary deployments are temporary during upgrade and not in any group.
+		if _, ok := deploy.Labels[polardbxmeta.LabelCanary]; ok {
+			continue
+		}
 
 		deployGroup := convention.ParseGroupFromDeployment(&deploy)
 		if _, found := deploymentMap[deployGroup]; found {
@@ -1243,6 +1287,350 @@ func (rc *Context) IsPXCBackupStatusChanged() bool {
 	return !equality.Semantic.DeepEqual(rc.polardbxBackup.Status, *rc.polardbxBackupStatusSnapshot)
 }
 
+func (rc *Context) SetPolarDBXBackupSelfTestKey(key types.NamespacedName) {
+	rc.polardbxBackupSelfTestKey = key
+}
+
+func (rc *Context) GetPolarDBXBackupSelfTest() (*polardbxv1.PolarDBXBackupSelfTest, error) {
+	if rc.polardbxBackupSelfTest == nil {
+		var selfTest polardbxv1.PolarDBXBackupSelfTest
+		err := rc.Client().Get(rc.Context(), rc.polardbxBackupSelfTestKey, &selfTest)
+		if err != nil {
+			return nil, err
+		}
+		rc.polardbxBackupSelfTest = &selfTest
+		rc.polardbxBackupSelfTestStatusSnapshot = rc.polardbxBackupSelfTest.Status.DeepCopy()
+	}
+	return rc.polardbxBackupSelfTest, nil
+}
+
+func (rc *Context) MustGetPolarDBXBackupSelfTest() *polardbxv1.PolarDBXBackupSelfTest {
+	selfTest, err := rc.GetPolarDBXBackupSelfTest()
+	if err != nil {
+		panic(err)
+	}
+	return selfTest
+}
+
+func (rc *Context) UpdatePolarDBXBackupSelfTestStatus() error {
+	if rc.polardbxBackupSelfTestStatusSnapshot == nil {
+		return nil
+	}
+	err := rc.Client().Status().Update(rc.Context(), rc.polardbxBackupSelfTest)
+	if err != nil {
+		return err
+	}
+	rc.polardbxBackupSelfTestStatusSnapshot = rc.polardbxBackupSelfTest.Status.DeepCopy()
+	return nil
+}
+
+func (rc *Context) IsPolarDBXBackupSelfTestStatusChanged() bool {
+	if rc.polardbxBackupSelfTestStatusSnapshot == nil {
+		return false
+	}
+	return !equality.Semantic.DeepEqual(rc.polardbxBackupSelfTest.Status, *rc.polardbxBackupSelfTestStatusSnapshot)
+}
+
+func (rc *Context) SetPolarDBXCloneKey(key types.NamespacedName) {
+	rc.polardbxCloneKey = key
+}
+
+func (rc *Context) GetPolarDBXClone() (*polardbxv1.PolarDBXClone, error) {
+	if rc.polardbxClone == nil {
+		var clone polardbxv1.PolarDBXClone
+		err := rc.Client().Get(rc.Context(), rc.polardbxCloneKey, &clone)
+		if err != nil {
+			return nil, err
+		}
+		rc.polardbxClone = &clone
+		rc.polardbxCloneStatusSnapshot = rc.polardbxClone.Status.DeepCopy()
+	}
+	return rc.polardbxClone, nil
+}
+
+func (rc *Context) MustGetPolarDBXClone() *polardbxv1.PolarDBXClone {
+	clone, err := rc.GetPolarDBXClone()
+	if err != nil {
+		panic(err)
+	}
+	return clone
+}
+
+func (rc *Context) UpdatePolarDBXCloneStatus() error {
+	if rc.polardbxCloneStatusSnapshot == nil {
+		return nil
+	}
+	err := rc.Client().Status().Update(rc.Context(), rc.polardbxClone)
+	if err != nil {
+		return err
+	}
+	rc.polardbxCloneStatusSnapshot = rc.polardbxClone.Status.DeepCopy()
+	return nil
+}
+
+func (rc *Context) IsPolarDBXCloneStatusChanged() bool {
+	if rc.polardbxCloneStatusSnapshot == nil {
+		return false
+	}
+	return !equality.Semantic.DeepEqual(rc.polardbxClone.Status, *rc.polardbxCloneStatusSnapshot)
+}
+
+func (rc *Context) SetPolarDBXBackupReapKey(key types.NamespacedName) {
+	rc.polardbxBackupReapKey = key
+}
+
+func (rc *Context) GetPolarDBXBackupReap() (*polardbxv1.PolarDBXBackupReap, error) {
+	if rc.polardbxBackupReap == nil {
+		var reap polardbxv1.PolarDBXBackupReap
+		err := rc.Client().Get(rc.Context(), rc.polardbxBackupReapKey, &reap)
+		if err != nil {
+			return nil, err
+		}
+		rc.polardbxBackupReap = &reap
+		rc.polardbxBackupReapStatusSnapshot = rc.polardbxBackupReap.Status.DeepCopy()
+	}
+	return rc.polardbxBackupReap, nil
+}
+
+func (rc *Context) MustGetPolarDBXBackupReap() *polardbxv1.PolarDBXBackupReap {
+	reap, err := rc.GetPolarDBXBackupReap()
+	if err != nil {
+		panic(err)
+	}
+	return reap
+}
+
+func (rc *Context) UpdatePolarDBXBackupReapStatus() error {
+	if rc.polardbxBackupReapStatusSnapshot == nil {
+		return nil
+	}
+	err := rc.Client().Status().Update(rc.Context(), rc.polardbxBackupReap)
+	if err != nil {
+		return err
+	}
+	rc.polardbxBackupReapStatusSnapshot = rc.polardbxBackupReap.Status.DeepCopy()
+	return nil
+}
+
+func (rc *Context) IsPolarDBXBackupReapStatusChanged() bool {
+	if rc.polardbxBackupReapStatusSnapshot == nil {
+		return false
+	}
+	return !equality.Semantic.DeepEqual(rc.polardbxBackupReap.Status, *rc.polardbxBackupReapStatusSnapshot)
+}
+
+func (rc *Context) SetPolarDBXBackupScheduleKey(key types.NamespacedName) {
+	rc.polardbxBackupScheduleKey = key
+}
+
+func (rc *Context) GetPolarDBXBackupSchedule() (*polardbxv1.PolarDBXBackupSchedule, error) {
+	if rc.polardbxBackupSchedule == nil {
+		var schedule polardbxv1.PolarDBXBackupSchedule
+		err := rc.Client().Get(rc.Context(), rc.polardbxBackupScheduleKey, &schedule)
+		if err != nil {
+			return nil, err
+		}
+		rc.polardbxBackupSchedule = &schedule
+		rc.polardbxBackupScheduleStatusSnapshot = rc.polardbxBackupSchedule.Status.DeepCopy()
+	}
+	return rc.polardbxBackupSchedule, nil
+}
+
+func (rc *Context) MustGetPolarDBXBackupSchedule() *polardbxv1.PolarDBXBackupSchedule {
+	schedule, err := rc.GetPolarDBXBackupSchedule()
+	if err != nil {
+		panic(err)
+	}
+	return schedule
+}
+
+func (rc *Context) UpdatePolarDBXBackupScheduleStatus() error {
+	if rc.polardbxBackupScheduleStatusSnapshot == nil {
+		return nil
+	}
+	err := rc.Client().Status().Update(rc.Context(), rc.polardbxBackupSchedule)
+	if err != nil {
+		return err
+	}
+	rc.polardbxBackupScheduleStatusSnapshot = rc.polardbxBackupSchedule.Status.DeepCopy()
+	return nil
+}
+
+func (rc *Context) IsPolarDBXBackupScheduleStatusChanged() bool {
+	if rc.polardbxBackupScheduleStatusSnapshot == nil {
+		return false
+	}
+	return !equality.Semantic.DeepEqual(rc.polardbxBackupSchedule.Status, *rc.polardbxBackupScheduleStatusSnapshot)
+}
+
+func (rc *Context) SetPolarDBXBinlogBackupKey(key types.NamespacedName) {
+	rc.polardbxBinlogBackupKey = key
+}
+
+func (rc *Context) GetPolarDBXBinlogBackup() (*polardbxv1.PolarDBXBinlogBackup, error) {
+	if rc.polardbxBinlogBackup == nil {
+		var binlogBackup polardbxv1.PolarDBXBinlogBackup
+		err := rc.Client().Get(rc.Context(), rc.polardbxBinlogBackupKey, &binlogBackup)
+		if err != nil {
+			return nil, err
+		}
+		rc.polardbxBinlogBackup = &binlogBackup
+		rc.polardbxBinlogBackupStatusSnapshot = rc.polardbxBinlogBackup.Status.DeepCopy()
+	}
+	return rc.polardbxBinlogBackup, nil
+}
+
+func (rc *Context) MustGetPolarDBXBinlogBackup() *polardbxv1.PolarDBXBinlogBackup {
+	binlogBackup, err := rc.GetPolarDBXBinlogBackup()
+	if err != nil {
+		panic(err)
+	}
+	return binlogBackup
+}
+
+func (rc *Context) SetControllerRefAndCreateToBinlogBackup(obj client.Object) error {
+	binlogBackup := rc.MustGetPolarDBXBinlogBackup()
+	if err := ctrl.SetControllerReference(binlogBackup, obj, rc.Scheme()); err != nil {
+		return err
+	}
+	return rc.Client().Create(rc.Context(), obj)
+}
+
+func (rc *Context) UpdatePolarDBXBinlogBackupStatus() error {
+	if rc.polardbxBinlogBackupStatusSnapshot == nil {
+		return nil
+	}
+	err := rc.Client().Status().Update(rc.Context(), rc.polardbxBinlogBackup)
+	if err != nil {
+		return err
+	}
+	rc.polardbxBinlogBackupStatusSnapshot = rc.polardbxBinlogBackup.Status.DeepCopy()
+	return nil
+}
+
+func (rc *Context) IsPolarDBXBinlogBackupStatusChanged() bool {
+	if rc.polardbxBinlogBackupStatusSnapshot == nil {
+		return false
+	}
+	return !equality.Semantic.DeepEqual(rc.polardbxBinlogBackup.Status, *rc.polardbxBinlogBackupStatusSnapshot)
+}
+
+func (rc *Context) SetPolarDBXStandbyKey(key types.NamespacedName) {
+	rc.polardbxStandbyKey = key
+}
+
+func (rc *Context) GetPolarDBXStandby() (*polardbxv1.PolarDBXStandby, error) {
+	if rc.polardbxStandby == nil {
+		var standby polardbxv1.PolarDBXStandby
+		err := rc.Client().Get(rc.Context(), rc.polardbxStandbyKey, &standby)
+		if err != nil {
+			return nil, err
+		}
+		rc.polardbxStandby = &standby
+		rc.polardbxStandbyStatusSnapshot = rc.polardbxStandby.Status.DeepCopy()
+	}
+	return rc.polardbxStandby, nil
+}
+
+func (rc *Context) MustGetPolarDBXStandby() *polardbxv1.PolarDBXStandby {
+	standby, err := rc.GetPolarDBXStandby()
+	if err != nil {
+		panic(err)
+	}
+	return standby
+}
+
+func (rc *Context) SetControllerRefAndCreateToStandby(obj client.Object) error {
+	standby := rc.MustGetPolarDBXStandby()
+	if err := ctrl.SetControllerReference(standby, obj, rc.Scheme()); err != nil {
+		return err
+	}
+	return rc.Client().Create(rc.Context(), obj)
+}
+
+func (rc *Context) UpdatePolarDBXStandbyStatus() error {
+	if rc.polardbxStandbyStatusSnapshot == nil {
+		return nil
+	}
+	err := rc.Client().Status().Update(rc.Context(), rc.polardbxStandby)
+	if err != nil {
+		return err
+	}
+	rc.polardbxStandbyStatusSnapshot = rc.polardbxStandby.Status.DeepCopy()
+	return nil
+}
+
+func (rc *Context) IsPolarDBXStandbyStatusChanged() bool {
+	if rc.polardbxStandbyStatusSnapshot == nil {
+		return false
+	}
+	return !equality.Semantic.DeepEqual(rc.polardbxStandby.Status, *rc.polardbxStandbyStatusSnapshot)
+}
+
+func (rc *Context) SetPolarDBXAutoscalerKey(key types.NamespacedName) {
+	rc.polardbxAutoscalerKey = key
+}
+
+func (rc *Context) GetPolarDBXAutoscaler() (*polardbxv1.PolarDBXAutoscaler, error) {
+	if rc.polardbxAutoscaler == nil {
+		var autoscaler polardbxv1.PolarDBXAutoscaler
+		err := rc.Client().Get(rc.Context(), rc.polardbxAutoscalerKey, &autoscaler)
+		if err != nil {
+			return nil, err
+		}
+		rc.polardbxAutoscaler = &autoscaler
+		rc.polardbxAutoscalerStatusSnapshot = rc.polardbxAutoscaler.Status.DeepCopy()
+	}
+	return rc.polardbxAutoscaler, nil
+}
+
+func (rc *Context) MustGetPolarDBXAutoscaler() *polardbxv1.PolarDBXAutoscaler {
+	autoscaler, err := rc.GetPolarDBXAutoscaler()
+	if err != nil {
+		panic(err)
+	}
+	return autoscaler
+}
+
+func (rc *Context) UpdatePolarDBXAutoscalerStatus() error {
+	if rc.polardbxAutoscalerStatusSnapshot == nil {
+		return nil
+	}
+	err := rc.Client().Status().Update(rc.Context(), rc.polardbxAutoscaler)
+	if err != nil {
+		return err
+	}
+	rc.polardbxAutoscalerStatusSnapshot = rc.polardbxAutoscaler.Status.DeepCopy()
+	return nil
+}
+
+func (rc *Context) IsPolarDBXAutoscalerStatusChanged() bool {
+	if rc.polardbxAutoscalerStatusSnapshot == nil {
+		return false
+	}
+	return !equality.Semantic.DeepEqual(rc.polardbxAutoscaler.Status, *rc.polardbxAutoscalerStatusSnapshot)
+}
+
+// GetPolarDBXRootAccountPassword returns the password of the root account of the cluster, which is
+// synced from the primary cluster if it's readonly.
+func (rc *Context) GetPolarDBXRootAccountPassword() (string, error) {
+	polardbx, err := rc.GetPolarDBX()
+	if err != nil {
+		return "", fmt.Errorf("unable to get polardbx object: %w", err)
+	}
+	if polardbx.Spec.Readonly {
+		polardbx, err = rc.GetPrimaryPolarDBX()
+		if err != nil {
+			return "", err
+		}
+	}
+	secret, err := rc.getPolarDBXSecret(polardbx, convention.SecretTypeAccount)
+	if err != nil {
+		return "", err
+	}
+	return string(secret.Data[convention.RootAccount]), nil
+}
+
 func (rc *Context) GetXStoreBackups() (*polardbxv1.XStoreBackupList, error) {
 	backup := rc.MustGetPolarDBXBackup()
 
@@ -1354,24 +1742,7 @@ func (rc *Context) GetLastCompletedPXCBackup(matchLabels map[string]string, befo
 	if err != nil || len(polardbxBackupList.Items) == 0 {
 		return nil, err
 	}
-	var lastBackup *polardbxv1.PolarDBXBackup = nil
-	var lastBackupStartTime *time.Time = nil
-	for i := range polardbxBackupList.Items {
-		backup := &polardbxBackupList.Items[i]
-		if backup.Status.Phase != polardbxv1.BackupFinished {
-			continue
-		}
-		if backup.Status.EndTime.After(beforeTime) {
-			continue
-		}
-		if lastBackupStartTime == nil ||
-			lastBackupStartTime.Before(backup.Status.StartTime.Time) {
-			lastBackupStartTime = &backup.Status.StartTime.Time
-			lastBackup = backup
-		}
-	}
-
-	return lastBackup, nil
+	return polardbxhelper.LastCompletedBackup(polardbxBackupList.Items, beforeTime), nil
 }
 
 func (rc *Context) GetPXCBackupByName(name string) (*polardbxv1.PolarDBXBackup, error) {
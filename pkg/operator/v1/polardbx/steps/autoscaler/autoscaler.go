@@ -0,0 +1,233 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package autoscaler
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1polardbx "github.com/alibaba/polardbx-operator/api/v1/polardbx"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+)
+
+// tolerance is the ratio of current to target value within which the replicas are kept, same as
+// the one of the horizontal pod autoscaler.
+const tolerance = 0.1
+
+// desiredReplicasOf returns the replicas desired by a metric, i.e. ceil(current * value / target).
+func desiredReplicasOf(current int32, value, target float64) int32 {
+	if target <= 0 {
+		return current
+	}
+	ratio := value / target
+	if math.Abs(ratio-1) <= tolerance {
+		return current
+	}
+	return int32(math.Ceil(ratio * float64(current)))
+}
+
+// boundReplicas bounds the replicas within [min, max], and the change from current within step if
+// step is positive.
+func boundReplicas(desired, current, min, max, step int32) int32 {
+	if step > 0 {
+		if desired > current+step {
+			desired = current + step
+		} else if desired < current-step {
+			desired = current - step
+		}
+	}
+	if desired < min {
+		desired = min
+	}
+	if max >= min && desired > max {
+		desired = max
+	}
+	return desired
+}
+
+// holdScaleDownIfUnready keeps the current replicas instead of scaling down while some of them are
+// not ready, e.g. starting or restarting, since the metrics of ready ones don't tell the load of all.
+func holdScaleDownIfUnready(desired, current, ready int32) int32 {
+	if ready < current && desired < current {
+		return current
+	}
+	return desired
+}
+
+// stabilize records the recommendation and drops the ones out of window. Scaling down is
+// stabilized to the largest recommendation within window, while scaling up takes effect at once.
+func stabilize(recommendations []polardbxv1.AutoscalerRecommendation, desired, current int32,
+	now time.Time, window time.Duration) (int32, []polardbxv1.AutoscalerRecommendation) {
+	kept := make([]polardbxv1.AutoscalerRecommendation, 0, len(recommendations)+1)
+	for _, r := range recommendations {
+		if now.Sub(r.Time.Time) < window {
+			kept = append(kept, r)
+		}
+	}
+	kept = append(kept, polardbxv1.AutoscalerRecommendation{Replicas: desired, Time: metav1.NewTime(now)})
+	if desired >= current {
+		return desired, kept
+	}
+	stabilized := desired
+	for _, r := range kept {
+		if r.Replicas > stabilized {
+			stabilized = r.Replicas
+		}
+	}
+	if stabilized > current {
+		stabilized = current
+	}
+	return stabilized, kept
+}
+
+// isTopologyChangeable returns whether the CN replicas can be changed, i.e. the cluster is running
+// and no spec change is in flight.
+func isTopologyChangeable(polardbx *polardbxv1.PolarDBXCluster) (bool, string) {
+	if polardbx.Status.Phase != polardbxv1polardbx.PhaseRunning {
+		return false, "Cluster is " + string(polardbx.Status.Phase) + ", scaling deferred"
+	}
+	if polardbx.Status.ObservedGeneration != polardbx.Generation {
+		return false, "Spec change of cluster is in flight, scaling deferred"
+	}
+	return true, ""
+}
+
+func readyPodsOf(pods []corev1.Pod) []corev1.Pod {
+	ready := make([]corev1.Pod, 0, len(pods))
+	for i := range pods {
+		if k8shelper.IsPodReady(&pods[i]) && pods[i].DeletionTimestamp.IsZero() {
+			ready = append(ready, pods[i])
+		}
+	}
+	return ready
+}
+
+var PersistentStatusChanges = polardbxv1reconcile.NewStepBinder("PersistentStatusChanges",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		if rc.IsPolarDBXAutoscalerStatusChanged() {
+			if err := rc.UpdatePolarDBXAutoscalerStatus(); err != nil {
+				return flow.Error(err, "Unable to update status for autoscaler.")
+			}
+			return flow.Continue("Autoscaler status updated!")
+		}
+		return flow.Continue("Autoscaler status not changed!")
+	})
+
+// ScaleCN evaluates the metrics every interval and scales the CNs of the cluster to the replicas
+// desired, after stabilization and bounds. The scaling is deferred until the cluster is running
+// without spec change in flight, e.g. upgrading, so that no CNs are added during a topology change.
+// Scaling down is held while some CNs are not ready.
+var ScaleCN = polardbxv1reconcile.NewStepBinder("ScaleCN",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		autoscaler := rc.MustGetPolarDBXAutoscaler()
+		interval := autoscaler.Spec.Interval.Duration
+		if interval <= 0 {
+			interval = 30 * time.Second
+		}
+		now := time.Now()
+		if last := autoscaler.Status.LastEvaluateTime; last != nil {
+			if wait := last.Add(interval).Sub(now); wait > 0 {
+				return flow.RetryAfter(wait, "Wait for the next evaluation.")
+			}
+		}
+		autoscaler.Status.LastEvaluateTime = &metav1.Time{Time: now}
+
+		polardbx, err := rc.GetPolarDBX()
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				autoscaler.Status.Message = "Cluster " + autoscaler.Spec.Cluster.Name + " not found"
+				return flow.RetryAfter(interval, "Cluster not found.")
+			}
+			return flow.Error(err, "Unable to get cluster.", "cluster", autoscaler.Spec.Cluster.Name)
+		}
+		var current int32
+		if polardbx.Spec.Topology.Nodes.CN.Replicas != nil {
+			current = *polardbx.Spec.Topology.Nodes.CN.Replicas
+		}
+		autoscaler.Status.CurrentReplicas = current
+
+		pods, err := rc.GetPods(polardbxmeta.RoleCN)
+		if err != nil {
+			return flow.Error(err, "Unable to get CN pods.")
+		}
+		pods = readyPodsOf(pods)
+		ready := int32(len(pods))
+		if len(pods) == 0 {
+			autoscaler.Status.Message = "No CN is ready"
+			return flow.RetryAfter(interval, "No CN is ready.")
+		}
+
+		desired := int32(-1)
+		metrics := make([]polardbxv1.AutoscalerMetricStatus, 0, len(autoscaler.Spec.Metrics))
+		for i := range autoscaler.Spec.Metrics {
+			metric := &autoscaler.Spec.Metrics[i]
+			status := polardbxv1.AutoscalerMetricStatus{Type: metric.Type}
+			value, err := averageValueOf(rc, autoscaler, metric, pods)
+			if err != nil {
+				status.Message = err.Error()
+				flow.Logger().Error(err, "Unable to get metric.", "type", metric.Type)
+			} else {
+				status.Current = resource.NewMilliQuantity(int64(value*1000), resource.DecimalSI)
+				// The replicas of spec are scaled, same as the horizontal pod autoscaler.
+				status.DesiredReplicas = desiredReplicasOf(current, value, metric.Target.AsApproximateFloat64())
+				if status.DesiredReplicas > desired {
+					desired = status.DesiredReplicas
+				}
+			}
+			metrics = append(metrics, status)
+		}
+		autoscaler.Status.Metrics = metrics
+		if desired < 0 {
+			autoscaler.Status.Message = "No metric is available"
+			return flow.RetryAfter(interval, "No metric is available.")
+		}
+
+		desired = holdScaleDownIfUnready(desired, current, ready)
+		desired = boundReplicas(desired, current, autoscaler.Spec.MinReplicas, autoscaler.Spec.MaxReplicas,
+			autoscaler.Spec.MaxScaleStep)
+		desired, autoscaler.Status.Recommendations = stabilize(autoscaler.Status.Recommendations, desired, current,
+			now, autoscaler.Spec.ScaleDownStabilizationWindow.Duration)
+		autoscaler.Status.DesiredReplicas = desired
+		if desired == current {
+			autoscaler.Status.Message = ""
+			return flow.RetryAfter(interval, "CN replicas desired are current.", "replicas", current)
+		}
+
+		if ok, message := isTopologyChangeable(polardbx); !ok {
+			autoscaler.Status.Message = message
+			return flow.RetryAfter(interval, message, "desired", desired)
+		}
+
+		polardbx.Spec.Topology.Nodes.CN.Replicas = &desired
+		if err := rc.UpdatePolarDBX(); err != nil {
+			return flow.Error(err, "Unable to scale CN.", "desired", desired)
+		}
+		autoscaler.Status.LastScaleTime = &metav1.Time{Time: now}
+		autoscaler.Status.Message = "Scaled CN from " + strconv.Itoa(int(current)) + " to " + strconv.Itoa(int(desired))
+		return flow.RetryAfter(interval, "CN scaled.", "from", current, "to", desired)
+	})
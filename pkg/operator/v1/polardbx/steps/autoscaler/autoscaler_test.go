@@ -0,0 +1,122 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package autoscaler
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1polardbx "github.com/alibaba/polardbx-operator/api/v1/polardbx"
+)
+
+func TestDesiredReplicasOf(t *testing.T) {
+	testCases := []struct {
+		current       int32
+		value, target float64
+		expect        int32
+	}{
+		{current: 2, value: 140, target: 70, expect: 4},
+		{current: 2, value: 75, target: 70, expect: 2},
+		{current: 4, value: 20, target: 70, expect: 2},
+		{current: 3, value: 100, target: 70, expect: 5},
+		{current: 2, value: 100, target: 0, expect: 2},
+	}
+	for _, tc := range testCases {
+		if desired := desiredReplicasOf(tc.current, tc.value, tc.target); desired != tc.expect {
+			t.Errorf("desired replicas of %+v: expect %d, got %d", tc, tc.expect, desired)
+		}
+	}
+}
+
+func TestBoundReplicas(t *testing.T) {
+	if r := boundReplicas(10, 2, 1, 8, 0); r != 8 {
+		t.Errorf("expect bounded to max, got %d", r)
+	}
+	if r := boundReplicas(0, 2, 1, 8, 0); r != 1 {
+		t.Errorf("expect bounded to min, got %d", r)
+	}
+	if r := boundReplicas(10, 2, 1, 8, 2); r != 4 {
+		t.Errorf("expect bounded by step, got %d", r)
+	}
+	if r := boundReplicas(1, 6, 1, 8, 2); r != 4 {
+		t.Errorf("expect bounded by step, got %d", r)
+	}
+}
+
+func TestHoldScaleDownIfUnready(t *testing.T) {
+	// Within tolerance, one unready pod of 4 doesn't scale down.
+	if r := holdScaleDownIfUnready(desiredReplicasOf(4, 72, 70), 4, 3); r != 4 {
+		t.Errorf("expect replicas kept, got %d", r)
+	}
+	if r := holdScaleDownIfUnready(2, 4, 3); r != 4 {
+		t.Errorf("expect scaling down held while unready, got %d", r)
+	}
+	if r := holdScaleDownIfUnready(2, 4, 4); r != 2 {
+		t.Errorf("expect scaling down when all ready, got %d", r)
+	}
+	if r := holdScaleDownIfUnready(6, 4, 3); r != 6 {
+		t.Errorf("expect scaling up while unready, got %d", r)
+	}
+}
+
+func TestStabilize(t *testing.T) {
+	now := time.Now()
+	window := 5 * time.Minute
+	recommendations := []polardbxv1.AutoscalerRecommendation{
+		{Replicas: 6, Time: metav1.NewTime(now.Add(-10 * time.Minute))},
+		{Replicas: 4, Time: metav1.NewTime(now.Add(-2 * time.Minute))},
+	}
+
+	desired, kept := stabilize(recommendations, 2, 5, now, window)
+	if desired != 4 {
+		t.Errorf("expect scaling down stabilized to 4, got %d", desired)
+	}
+	if len(kept) != 2 || kept[1].Replicas != 2 {
+		t.Errorf("expect the expired dropped and the new recorded, got %+v", kept)
+	}
+
+	if desired, _ := stabilize(recommendations, 8, 5, now, window); desired != 8 {
+		t.Errorf("expect scaling up at once, got %d", desired)
+	}
+	if desired, _ := stabilize(recommendations, 2, 3, now, window); desired != 3 {
+		t.Errorf("expect kept current, got %d", desired)
+	}
+}
+
+func TestIsTopologyChangeable(t *testing.T) {
+	polardbx := &polardbxv1.PolarDBXCluster{}
+	polardbx.Generation = 2
+	polardbx.Status.ObservedGeneration = 2
+	polardbx.Status.Phase = polardbxv1polardbx.PhaseRunning
+	if ok, _ := isTopologyChangeable(polardbx); !ok {
+		t.Error("expect changeable if running")
+	}
+
+	polardbx.Status.Phase = polardbxv1polardbx.PhaseUpgrading
+	if ok, _ := isTopologyChangeable(polardbx); ok {
+		t.Error("expect not changeable if upgrading")
+	}
+
+	polardbx.Status.Phase = polardbxv1polardbx.PhaseRunning
+	polardbx.Generation = 3
+	if ok, _ := isTopologyChangeable(polardbx); ok {
+		t.Error("expect not changeable if spec change in flight")
+	}
+}
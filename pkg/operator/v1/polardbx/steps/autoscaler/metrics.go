@@ -0,0 +1,216 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package autoscaler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	promapi "github.com/prometheus/client_golang/api"
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/convention"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+	dbutil "github.com/alibaba/polardbx-operator/pkg/util/database"
+)
+
+var podMetricsListGVK = schema.GroupVersionKind{
+	Group:   "metrics.k8s.io",
+	Version: "v1beta1",
+	Kind:    "PodMetricsList",
+}
+
+// engineCPUOf returns the CPU requests of the engine container, or the limits if requests not set.
+func engineCPUOf(pod *corev1.Pod) *resource.Quantity {
+	engine := k8shelper.GetContainerFromPod(pod, convention.ContainerEngine)
+	if engine == nil {
+		return nil
+	}
+	if cpu, ok := engine.Resources.Requests[corev1.ResourceCPU]; ok && !cpu.IsZero() {
+		return &cpu
+	}
+	if cpu, ok := engine.Resources.Limits[corev1.ResourceCPU]; ok && !cpu.IsZero() {
+		return &cpu
+	}
+	return nil
+}
+
+// cpuUtilizationOf returns the average CPU utilization of the engine containers of pods, in percent.
+func cpuUtilizationOf(rc *polardbxv1reconcile.Context, pods []corev1.Pod) (float64, error) {
+	var podMetricsList unstructured.UnstructuredList
+	podMetricsList.SetGroupVersionKind(podMetricsListGVK)
+	if err := rc.Client().List(rc.Context(), &podMetricsList, client.InNamespace(rc.Namespace()),
+		client.MatchingLabels(convention.ConstLabelsWithRole(rc.MustGetPolarDBX(), polardbxmeta.RoleCN))); err != nil {
+		return 0, fmt.Errorf("unable to list pod metrics: %w", err)
+	}
+	usages := make(map[string]resource.Quantity)
+	for _, podMetrics := range podMetricsList.Items {
+		containers, _, _ := unstructured.NestedSlice(podMetrics.Object, "containers")
+		for _, c := range containers {
+			container, ok := c.(map[string]interface{})
+			if !ok || container["name"] != convention.ContainerEngine {
+				continue
+			}
+			cpu, _, _ := unstructured.NestedString(container, "usage", "cpu")
+			if usage, err := resource.ParseQuantity(cpu); err == nil {
+				usages[podMetrics.GetName()] = usage
+			}
+		}
+	}
+
+	var usage, requests int64
+	for i := range pods {
+		pod := &pods[i]
+		u, ok := usages[pod.Name]
+		if !ok {
+			return 0, errors.New("no cpu usage of pod " + pod.Name)
+		}
+		cpu := engineCPUOf(pod)
+		if cpu == nil {
+			return 0, errors.New("no cpu requests or limits of pod " + pod.Name)
+		}
+		usage += u.MilliValue()
+		requests += cpu.MilliValue()
+	}
+	return float64(usage) * 100 / float64(requests), nil
+}
+
+// connectionsOf returns the average count of client connections of pods, excluding the one of
+// operator itself.
+func connectionsOf(rc *polardbxv1reconcile.Context, pods []corev1.Pod) (float64, error) {
+	password, err := rc.GetPolarDBXRootAccountPassword()
+	if err != nil {
+		return 0, err
+	}
+	var total int
+	for i := range pods {
+		pod := &pods[i]
+		engine := k8shelper.GetContainerFromPod(pod, convention.ContainerEngine)
+		if engine == nil {
+			return 0, errors.New("no engine container of pod " + pod.Name)
+		}
+		port := k8shelper.GetPortFromContainer(engine, convention.PortAccess)
+		if port == nil {
+			return 0, errors.New("no access port of pod " + pod.Name)
+		}
+		count, err := countConnections(rc.Context(), &dbutil.MySQLDataSource{
+			Host:     pod.Status.PodIP,
+			Port:     int(port.ContainerPort),
+			Username: convention.RootAccount,
+			Password: password,
+			Timeout:  5 * time.Second,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("unable to count connections of pod %s: %w", pod.Name, err)
+		}
+		total += count
+	}
+	return float64(total) / float64(len(pods)), nil
+}
+
+func countConnections(ctx context.Context, ds *dbutil.MySQLDataSource) (int, error) {
+	db, err := dbutil.OpenMySQLDB(ds)
+	if err != nil {
+		return 0, err
+	}
+	defer dbutil.DeferClose(db)
+
+	rows, err := db.QueryContext(ctx, "SHOW PROCESSLIST")
+	if err != nil {
+		return 0, err
+	}
+	defer dbutil.DeferClose(rows)
+
+	count := 0
+	for rows.Next() {
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	// Exclude the connection of operator itself.
+	if count > 0 {
+		count--
+	}
+	return count, nil
+}
+
+// prometheusValueOf returns the sum of the samples of the query result.
+func prometheusValueOf(ctx context.Context, endpoint, query string) (float64, error) {
+	if len(endpoint) == 0 {
+		return 0, errors.New("prometheus endpoint not specified")
+	}
+	c, err := promapi.NewClient(promapi.Config{Address: endpoint})
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	result, _, err := promv1.NewAPI(c).Query(ctx, query, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("unable to query prometheus: %w", err)
+	}
+	return sumOf(result)
+}
+
+func sumOf(value model.Value) (float64, error) {
+	switch v := value.(type) {
+	case *model.Scalar:
+		return float64(v.Value), nil
+	case model.Vector:
+		if len(v) == 0 {
+			return 0, errors.New("empty result of query")
+		}
+		var sum float64
+		for _, sample := range v {
+			sum += float64(sample.Value)
+		}
+		return sum, nil
+	default:
+		return 0, fmt.Errorf("unsupported result type of query: %s", value.Type())
+	}
+}
+
+// averageValueOf returns the current value per CN of the metric.
+func averageValueOf(rc *polardbxv1reconcile.Context, autoscaler *polardbxv1.PolarDBXAutoscaler,
+	metric *polardbxv1.AutoscalerMetric, pods []corev1.Pod) (float64, error) {
+	switch metric.Type {
+	case polardbxv1.AutoscalerMetricCPU:
+		return cpuUtilizationOf(rc, pods)
+	case polardbxv1.AutoscalerMetricConnections:
+		return connectionsOf(rc, pods)
+	case polardbxv1.AutoscalerMetricPrometheus:
+		sum, err := prometheusValueOf(rc.Context(), autoscaler.Spec.PrometheusEndpoint, metric.Query)
+		if err != nil {
+			return 0, err
+		}
+		return sum / float64(len(pods)), nil
+	default:
+		return 0, errors.New("unknown metric type: " + string(metric.Type))
+	}
+}
@@ -0,0 +1,385 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package binlogbackup
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/config"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/command"
+	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
+)
+
+// shippedBinlog is the index of a shipped binlog written by the ship job.
+type shippedBinlog struct {
+	Name                string `json:"name"`
+	Size                int64  `json:"size"`
+	Gtids               string `json:"gtids"`
+	FirstEventTimestamp int64  `json:"firstEventTimestamp"`
+	LastEventTimestamp  int64  `json:"lastEventTimestamp"`
+	EventsCount         int64  `json:"eventsCount"`
+}
+
+func timeOfTimestamp(ts int64) *metav1.Time {
+	if ts <= 0 {
+		return nil
+	}
+	t := metav1.NewTime(time.Unix(ts, 0).UTC())
+	return &t
+}
+
+// parseShippedBinlogs parses the binlogs shipped by a round into the index.
+func parseShippedBinlogs(data []byte) ([]polardbxv1.BinlogFileIndex, error) {
+	var shipped []shippedBinlog
+	if err := json.Unmarshal(data, &shipped); err != nil {
+		return nil, err
+	}
+	index := make([]polardbxv1.BinlogFileIndex, 0, len(shipped))
+	for _, b := range shipped {
+		index = append(index, polardbxv1.BinlogFileIndex{
+			Name:           b.Name,
+			Size:           b.Size,
+			Gtids:          b.Gtids,
+			FirstEventTime: timeOfTimestamp(b.FirstEventTimestamp),
+			LastEventTime:  timeOfTimestamp(b.LastEventTimestamp),
+			EventsCount:    b.EventsCount,
+		})
+	}
+	return index, nil
+}
+
+// recordShippedBinlogs appends the shipped binlogs to the index of xstore, and drops the oldest ones
+// beyond MaxIndexedBinlogFiles.
+func recordShippedBinlogs(status *polardbxv1.XStoreBinlogBackupStatus, shipped []polardbxv1.BinlogFileIndex) {
+	if len(shipped) == 0 {
+		return
+	}
+	status.Binlogs = append(status.Binlogs, shipped...)
+	if n := len(status.Binlogs) - polardbxv1.MaxIndexedBinlogFiles; n > 0 {
+		status.Binlogs = append([]polardbxv1.BinlogFileIndex(nil), status.Binlogs[n:]...)
+	}
+	status.LastBinlog = shipped[len(shipped)-1].Name
+	status.ShippedFiles += int64(len(shipped))
+}
+
+// latestEventTimeOf returns the time of the last shipped event of the xstore which lags most, nil
+// if any of the xstores has shipped no events.
+func latestEventTimeOf(statuses []polardbxv1.XStoreBinlogBackupStatus) *metav1.Time {
+	var latest *metav1.Time
+	for i := range statuses {
+		var last *metav1.Time
+		for j := len(statuses[i].Binlogs) - 1; j >= 0 && last == nil; j-- {
+			last = statuses[i].Binlogs[j].LastEventTime
+		}
+		if last == nil {
+			return nil
+		}
+		if latest == nil || last.Before(latest) {
+			latest = last
+		}
+	}
+	return latest
+}
+
+func xstoreStatusOf(binlogBackup *polardbxv1.PolarDBXBinlogBackup, xstoreName string) *polardbxv1.XStoreBinlogBackupStatus {
+	for i := range binlogBackup.Status.XStores {
+		if binlogBackup.Status.XStores[i].XStore == xstoreName {
+			return &binlogBackup.Status.XStores[i]
+		}
+	}
+	binlogBackup.Status.XStores = append(binlogBackup.Status.XStores, polardbxv1.XStoreBinlogBackupStatus{
+		XStore: xstoreName,
+	})
+	return &binlogBackup.Status.XStores[len(binlogBackup.Status.XStores)-1]
+}
+
+func binlogBackupRootPath(binlogBackup *polardbxv1.PolarDBXBinlogBackup) string {
+	return fmt.Sprintf("%s/%s/%s-%s", polardbxmeta.BinlogShipPath, binlogBackup.Spec.Cluster.Name,
+		binlogBackup.Name, binlogBackup.Status.StartTime.Format("20060102150405"))
+}
+
+func shipJobName(targetPod *corev1.Pod) string {
+	jobName := "binlogship-job-" + targetPod.Name + "-" + rand.String(4)
+	if len(jobName) >= 60 {
+		jobName = strings.TrimRight(jobName[0:59], "-")
+	}
+	return jobName
+}
+
+func replaceSystemEnvs(podSpec *corev1.PodSpec, targetPod *corev1.Pod) {
+	for i := range podSpec.Containers {
+		c := &podSpec.Containers[i]
+		for j := range c.Env {
+			env := &c.Env[j]
+
+			switch env.Name {
+			case "POD_NAME":
+				env.ValueFrom = nil
+				env.Value = targetPod.ObjectMeta.Name
+			case "POD_IP":
+				env.ValueFrom = nil
+				env.Value = targetPod.Status.PodIP
+			case "NODE_IP":
+				env.ValueFrom = nil
+				env.Value = targetPod.Status.HostIP
+			case "NODE_NAME":
+				env.ValueFrom = nil
+				env.Value = targetPod.Spec.NodeName
+			}
+		}
+	}
+}
+
+func newShipJob(binlogBackup *polardbxv1.PolarDBXBinlogBackup, xstoreName string, targetPod *corev1.Pod,
+	lastBinlog string) *batchv1.Job {
+	jobName := shipJobName(targetPod)
+	podSpec := targetPod.Spec.DeepCopy()
+	podSpec.InitContainers = nil
+	podSpec.RestartPolicy = corev1.RestartPolicyNever
+	podSpec.HostNetwork = false
+
+	podSpec.Containers = []corev1.Container{
+		*k8shelper.GetContainerFromPodSpec(podSpec, "engine"),
+	}
+	podSpec.Containers[0].Name = "binlogshipjob"
+	podSpec.Containers[0].Command = command.NewCanonicalCommandBuilder().BinlogBackup().
+		ShipBinlogs(binlogBackup.Status.BackupRootPath+"/"+xstoreName,
+			string(binlogBackup.Spec.StorageProvider.StorageName), binlogBackup.Spec.StorageProvider.Sink,
+			lastBinlog, binlogBackup.Spec.MaxFilesPerRound, jobName).Build()
+	podSpec.Containers[0].Resources.Limits = nil
+	podSpec.Containers[0].Resources.Requests = nil
+	podSpec.Containers[0].Ports = nil
+	podSpec.Containers[0].StartupProbe = nil
+	podSpec.Containers[0].LivenessProbe = nil
+	podSpec.Containers[0].ReadinessProbe = nil
+
+	replaceSystemEnvs(podSpec, targetPod)
+
+	labels := map[string]string{
+		polardbxmeta.LabelBinlogBackup:    binlogBackup.Name,
+		polardbxmeta.LabelBackupXStore:    xstoreName,
+		xstoremeta.JobLabelTargetPod:      targetPod.Name,
+		xstoremeta.JobLabelTargetNodeName: targetPod.Spec.NodeName,
+	}
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: binlogBackup.Namespace,
+			Labels:    labels,
+			Annotations: map[string]string{
+				xstoremeta.AnnotationOperatorVersion: config.OperatorVersion(),
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: pointer.Int32(0),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: *podSpec,
+			},
+		},
+	}
+}
+
+func getShipJob(rc *polardbxv1reconcile.Context, binlogBackup *polardbxv1.PolarDBXBinlogBackup, xstoreName string) (*batchv1.Job, error) {
+	var jobList batchv1.JobList
+	err := rc.Client().List(rc.Context(), &jobList, client.InNamespace(rc.Namespace()), client.MatchingLabels{
+		polardbxmeta.LabelBinlogBackup: binlogBackup.Name,
+		polardbxmeta.LabelBackupXStore: xstoreName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(jobList.Items) == 0 {
+		return nil, nil
+	}
+	return &jobList.Items[0], nil
+}
+
+// readShippedBinlogs reads what's shipped by the job from its target pod, nil if nothing is recorded,
+// e.g. the job failed before shipping any binlog.
+func readShippedBinlogs(rc *polardbxv1reconcile.Context, job *batchv1.Job) ([]polardbxv1.BinlogFileIndex, error) {
+	pod := &corev1.Pod{}
+	err := rc.Client().Get(rc.Context(), types.NamespacedName{
+		Namespace: rc.Namespace(),
+		Name:      job.Labels[xstoremeta.JobLabelTargetPod],
+	}, pod)
+	if err != nil {
+		return nil, err
+	}
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	cmd := []string{"sh", "-c", "cat /data/mysql/tmp/" + job.Name + ".ship 2>/dev/null || true"}
+	if err := rc.ExecuteCommandOn(pod, "engine", cmd, control.ExecOptions{
+		Stdout: stdout,
+		Stderr: stderr,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to read shipped binlogs: %w, stderr: %s", err, stderr.String())
+	}
+	if len(bytes.TrimSpace(stdout.Bytes())) == 0 {
+		return nil, nil
+	}
+	return parseShippedBinlogs(stdout.Bytes())
+}
+
+// listXStores returns the GMS and DNs of the cluster.
+func listXStores(rc *polardbxv1reconcile.Context) ([]*polardbxv1.XStore, error) {
+	polardbx, err := rc.GetPolarDBX()
+	if err != nil {
+		return nil, err
+	}
+	xstores, err := rc.GetOrderedDNList()
+	if err != nil {
+		return nil, err
+	}
+	if polardbx.Spec.ShareGMS {
+		return xstores, nil
+	}
+	gms, err := rc.GetGMS()
+	if err != nil {
+		return nil, err
+	}
+	return append([]*polardbxv1.XStore{gms}, xstores...), nil
+}
+
+var PersistentStatusChanges = polardbxv1reconcile.NewStepBinder("PersistentStatusChanges",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		if rc.IsPolarDBXBinlogBackupStatusChanged() {
+			if err := rc.UpdatePolarDBXBinlogBackupStatus(); err != nil {
+				return flow.Error(err, "Unable to update status for binlog backup.")
+			}
+			return flow.Continue("Binlog backup status updated!")
+		}
+		return flow.Continue("Binlog backup status not changed!")
+	})
+
+// UpdateBinlogBackupPhase starts the binlog backup, and suspends or resumes it as the spec says.
+var UpdateBinlogBackupPhase = polardbxv1reconcile.NewStepBinder("UpdateBinlogBackupPhase",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		binlogBackup := rc.MustGetPolarDBXBinlogBackup()
+		if binlogBackup.Status.Phase == polardbxv1.BinlogBackupNew {
+			now := metav1.Now()
+			binlogBackup.Status.StartTime = &now
+			binlogBackup.Status.BackupRootPath = binlogBackupRootPath(binlogBackup)
+		}
+		phase := polardbxv1.BinlogBackupRunning
+		if binlogBackup.Spec.Suspend {
+			phase = polardbxv1.BinlogBackupSuspended
+		}
+		if binlogBackup.Status.Phase == phase {
+			return flow.Pass()
+		}
+		binlogBackup.Status.Phase = phase
+		return flow.Continue("Binlog backup phase updated.", "phase", phase)
+	})
+
+// ShipBinlogs runs the rounds of shipping of each xstore, i.e. a job on the leader uploading the
+// binlogs closed since the last round, one round at a time. The index of the shipped binlogs is
+// collected once the job finishes, and the job is removed then. A failed round is retried in the
+// next interval, from the last binlog shipped.
+var ShipBinlogs = polardbxv1reconcile.NewStepBinder("ShipBinlogs",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		binlogBackup := rc.MustGetPolarDBXBinlogBackup()
+		xstores, err := listXStores(rc)
+		if err != nil {
+			return flow.Error(err, "Unable to list xstores of cluster.", "cluster", binlogBackup.Spec.Cluster.Name)
+		}
+
+		interval := binlogBackup.Spec.Interval.Duration
+		if interval <= 0 {
+			interval = 30 * time.Second
+		}
+		now := time.Now()
+		nextRound := interval
+		for _, xstore := range xstores {
+			status := xstoreStatusOf(binlogBackup, xstore.Name)
+			job, err := getShipJob(rc, binlogBackup, xstore.Name)
+			if err != nil {
+				return flow.Error(err, "Unable to get ship job.", "xstore", xstore.Name)
+			}
+
+			if job != nil {
+				failed := k8shelper.IsJobFailed(job)
+				if !failed && !k8shelper.IsJobCompleted(job) {
+					continue
+				}
+				shipped, err := readShippedBinlogs(rc, job)
+				if err != nil {
+					return flow.Error(err, "Unable to read shipped binlogs.", "job", job.Name)
+				}
+				recordShippedBinlogs(status, shipped)
+				status.Pod = job.Labels[xstoremeta.JobLabelTargetPod]
+				status.LastShipTime = &metav1.Time{Time: now}
+				status.Message = ""
+				if failed {
+					status.Message = "Ship job " + job.Name + " failed, retry in the next round"
+				}
+				err = rc.Client().Delete(rc.Context(), job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+				if client.IgnoreNotFound(err) != nil {
+					return flow.Error(err, "Unable to remove ship job.", "job", job.Name)
+				}
+				continue
+			}
+
+			if binlogBackup.Status.Phase != polardbxv1.BinlogBackupRunning {
+				continue
+			}
+			if status.LastShipTime != nil {
+				if wait := status.LastShipTime.Add(interval).Sub(now); wait > 0 {
+					if wait < nextRound {
+						nextRound = wait
+					}
+					continue
+				}
+			}
+			if len(xstore.Status.LeaderPod) == 0 {
+				status.Message = "Leader pod not found"
+				continue
+			}
+			leaderPod, err := rc.GetLeaderOfDN(xstore)
+			if err != nil {
+				return flow.Error(err, "Unable to get leader pod.", "xstore", xstore.Name)
+			}
+			job = newShipJob(binlogBackup, xstore.Name, leaderPod, status.LastBinlog)
+			if err := rc.SetControllerRefAndCreateToBinlogBackup(job); err != nil {
+				return flow.Error(err, "Unable to create ship job.", "xstore", xstore.Name, "pod", leaderPod.Name)
+			}
+			flow.Logger().Info("Ship job created.", "xstore", xstore.Name, "job", job.Name)
+		}
+		binlogBackup.Status.LatestEventTime = latestEventTimeOf(binlogBackup.Status.XStores)
+
+		return flow.RetryAfter(nextRound, "Wait for the next round of shipping.")
+	})
@@ -0,0 +1,102 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package binlogbackup
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+)
+
+func TestParseShippedBinlogs(t *testing.T) {
+	index, err := parseShippedBinlogs([]byte(`[{"name":"mysql_bin.000002","size":1024,"gtids":"3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5",` +
+		`"firstEventTimestamp":1700000000,"lastEventTimestamp":1700000060,"eventsCount":12},` +
+		`{"name":"mysql_bin.000003","size":120,"gtids":"","eventsCount":0}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index) != 2 {
+		t.Fatalf("expect 2 binlogs, got %d", len(index))
+	}
+	if index[0].Name != "mysql_bin.000002" || index[0].Gtids != "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5" ||
+		index[0].EventsCount != 12 || index[0].LastEventTime.Unix() != 1700000060 {
+		t.Fatalf("unexpected index: %+v", index[0])
+	}
+	if index[1].FirstEventTime != nil || index[1].LastEventTime != nil {
+		t.Fatalf("expect no event time of empty binlog, got %+v", index[1])
+	}
+
+	if _, err := parseShippedBinlogs([]byte("not json")); err == nil {
+		t.Fatal("expect error")
+	}
+}
+
+func TestRecordShippedBinlogs(t *testing.T) {
+	status := &polardbxv1.XStoreBinlogBackupStatus{XStore: "pxc-dn-0"}
+	recordShippedBinlogs(status, nil)
+	if status.LastBinlog != "" || status.ShippedFiles != 0 {
+		t.Fatalf("expect nothing recorded, got %+v", status)
+	}
+
+	for round := 0; round < 3; round++ {
+		shipped := make([]polardbxv1.BinlogFileIndex, 0)
+		for i := 0; i < polardbxv1.MaxIndexedBinlogFiles/2; i++ {
+			shipped = append(shipped, polardbxv1.BinlogFileIndex{
+				Name: fmt.Sprintf("mysql_bin.%06d", round*polardbxv1.MaxIndexedBinlogFiles/2+i+1),
+			})
+		}
+		recordShippedBinlogs(status, shipped)
+	}
+	total := 3 * polardbxv1.MaxIndexedBinlogFiles / 2
+	if status.ShippedFiles != int64(total) {
+		t.Fatalf("expect %d shipped, got %d", total, status.ShippedFiles)
+	}
+	if len(status.Binlogs) != polardbxv1.MaxIndexedBinlogFiles {
+		t.Fatalf("expect %d indexed, got %d", polardbxv1.MaxIndexedBinlogFiles, len(status.Binlogs))
+	}
+	last := fmt.Sprintf("mysql_bin.%06d", total)
+	if status.LastBinlog != last || status.Binlogs[len(status.Binlogs)-1].Name != last {
+		t.Fatalf("expect last binlog %s, got %s", last, status.LastBinlog)
+	}
+	if first := fmt.Sprintf("mysql_bin.%06d", total-polardbxv1.MaxIndexedBinlogFiles+1); status.Binlogs[0].Name != first {
+		t.Fatalf("expect oldest dropped, first is %s", status.Binlogs[0].Name)
+	}
+}
+
+func TestLatestEventTimeOf(t *testing.T) {
+	t1 := metav1.NewTime(time.Unix(1700000000, 0))
+	t2 := metav1.NewTime(time.Unix(1700000100, 0))
+	statuses := []polardbxv1.XStoreBinlogBackupStatus{
+		{XStore: "pxc-gms", Binlogs: []polardbxv1.BinlogFileIndex{{Name: "mysql_bin.000001", LastEventTime: &t2}}},
+		{XStore: "pxc-dn-0", Binlogs: []polardbxv1.BinlogFileIndex{
+			{Name: "mysql_bin.000001", LastEventTime: &t1},
+			{Name: "mysql_bin.000002"},
+		}},
+	}
+	if latest := latestEventTimeOf(statuses); latest == nil || !latest.Equal(&t1) {
+		t.Fatalf("expect time of the most lagging xstore %s, got %v", t1, latest)
+	}
+
+	statuses = append(statuses, polardbxv1.XStoreBinlogBackupStatus{XStore: "pxc-dn-1"})
+	if latest := latestEventTimeOf(statuses); latest != nil {
+		t.Fatalf("expect nil if an xstore has shipped nothing, got %v", latest)
+	}
+}
@@ -0,0 +1,322 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clone
+
+import (
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1polardbx "github.com/alibaba/polardbx-operator/api/v1/polardbx"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+)
+
+const restoreTimeLayout = "2006-01-02 15:04:05"
+
+func BackupNameOf(clone *polardbxv1.PolarDBXClone) string {
+	return clone.Name + "-backup"
+}
+
+func ClusterNameOf(clone *polardbxv1.PolarDBXClone) string {
+	if len(clone.Spec.ClusterName) > 0 {
+		return clone.Spec.ClusterName
+	}
+	return clone.Name
+}
+
+// clonedParameterName returns the name of the parameter copied to the new cluster, i.e. the
+// prefix of source cluster name is replaced, or the new cluster name is prepended.
+func clonedParameterName(source, cluster, name string) string {
+	if strings.HasPrefix(name, source) {
+		return cluster + strings.TrimPrefix(name, source)
+	}
+	return cluster + "-" + name
+}
+
+// newClonedClusterSpec returns the spec of the new cluster, which follows the spec of the source
+// cluster when it's backed up and restores to the restore time of the backup.
+func newClonedClusterSpec(clone *polardbxv1.PolarDBXClone, backup *polardbxv1.PolarDBXBackup) *polardbxv1.PolarDBXClusterSpec {
+	spec := backup.Status.ClusterSpecSnapshot.DeepCopy()
+	// Readonly clusters aren't cloned, and the service is named after the new cluster.
+	spec.InitReadonly = nil
+	spec.ServiceName = ""
+	spec.Restore = &polardbxv1polardbx.RestoreSpec{
+		BackupSet: backup.Name,
+		From: polardbxv1polardbx.PolarDBXRestoreFrom{
+			PolarBDXName: clone.Spec.Source.Name,
+		},
+		Time:     clone.Status.RestoreTime,
+		TimeZone: "UTC",
+	}
+	return spec
+}
+
+// failClone marks the clone failed in the current phase. Objects created are left as is.
+func failClone(clone *polardbxv1.PolarDBXClone, flow control.Flow, message string) (reconcile.Result, error) {
+	now := metav1.Now()
+	clone.Status.FailedPhase = clone.Status.Phase
+	clone.Status.Phase = polardbxv1.CloneFailed
+	clone.Status.Message = message
+	clone.Status.EndTime = &now
+	return flow.Retry("Clone failed.", "reason", message)
+}
+
+// getClonedCluster gets the new cluster. It returns an error if the cluster isn't created by the clone.
+func getClonedCluster(rc *polardbxv1reconcile.Context, clone *polardbxv1.PolarDBXClone) (*polardbxv1.PolarDBXCluster, error) {
+	polardbx := &polardbxv1.PolarDBXCluster{}
+	err := rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: clone.Namespace, Name: ClusterNameOf(clone)}, polardbx)
+	if err != nil {
+		return nil, err
+	}
+	if polardbx.Labels[polardbxmeta.LabelClone] != clone.Name {
+		return nil, apierrors.NewAlreadyExists(polardbxv1.GroupVersion.WithResource("polardbxclusters").GroupResource(), polardbx.Name)
+	}
+	return polardbx, nil
+}
+
+var PersistentStatusChanges = polardbxv1reconcile.NewStepBinder("PersistentStatusChanges",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		if rc.IsPolarDBXCloneStatusChanged() {
+			if err := rc.UpdatePolarDBXCloneStatus(); err != nil {
+				return flow.Error(err, "Unable to update status for clone.")
+			}
+			return flow.Continue("Clone status updated!")
+		}
+		return flow.Continue("Clone status not changed!")
+	})
+
+// CheckCloneTimeout fails the clone if it's not done within the timeout.
+var CheckCloneTimeout = polardbxv1reconcile.NewStepBinder("CheckCloneTimeout",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		clone := rc.MustGetPolarDBXClone()
+		if clone.Status.StartTime == nil || clone.Spec.Timeout.Duration <= 0 ||
+			clone.Status.Phase == polardbxv1.CloneFinished || clone.Status.Phase == polardbxv1.CloneFailed {
+			return flow.Pass()
+		}
+		if time.Since(clone.Status.StartTime.Time) > clone.Spec.Timeout.Duration {
+			return failClone(clone, flow, "timeout after "+clone.Spec.Timeout.Duration.String())
+		}
+		return flow.Pass()
+	})
+
+// StartClone waits until the source cluster is running and checks that the new cluster doesn't exist.
+var StartClone = polardbxv1reconcile.NewStepBinder("StartClone",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		clone := rc.MustGetPolarDBXClone()
+		if clone.Status.StartTime == nil {
+			now := metav1.Now()
+			clone.Status.StartTime = &now
+		}
+
+		polardbx, err := rc.GetPolarDBX()
+		if apierrors.IsNotFound(err) {
+			return failClone(clone, flow, "source cluster not found: "+clone.Spec.Source.Name)
+		} else if err != nil {
+			return flow.Error(err, "Unable to get source cluster.")
+		}
+		if polardbx.Spec.Readonly {
+			return failClone(clone, flow, "readonly cluster can't be cloned: "+polardbx.Name)
+		}
+
+		_, err = getClonedCluster(rc, clone)
+		if apierrors.IsAlreadyExists(err) {
+			return failClone(clone, flow, "cluster already exists: "+ClusterNameOf(clone))
+		} else if client.IgnoreNotFound(err) != nil {
+			return flow.Error(err, "Unable to get cluster of clone.")
+		}
+
+		if polardbx.Status.Phase != polardbxv1polardbx.PhaseRunning {
+			return flow.RetryAfter(10*time.Second, "Wait until source cluster is running.", "phase", polardbx.Status.Phase)
+		}
+
+		clone.Status.Cluster = ClusterNameOf(clone)
+		clone.Status.Phase = polardbxv1.CloneBackingUp
+		return flow.Retry("Clone started!", "source", polardbx.Name, "cluster", clone.Status.Cluster)
+	})
+
+// CreateCloneBackup creates the full backup of the source cluster if not found.
+var CreateCloneBackup = polardbxv1reconcile.NewStepBinder("CreateCloneBackup",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		clone := rc.MustGetPolarDBXClone()
+		backup := &polardbxv1.PolarDBXBackup{}
+		err := rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: clone.Namespace, Name: BackupNameOf(clone)}, backup)
+		if err == nil {
+			return flow.Pass()
+		} else if !apierrors.IsNotFound(err) {
+			return flow.Error(err, "Unable to get backup of clone.")
+		}
+
+		backup = &polardbxv1.PolarDBXBackup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      BackupNameOf(clone),
+				Namespace: clone.Namespace,
+				Labels: map[string]string{
+					polardbxmeta.LabelClone: clone.Name,
+				},
+			},
+			Spec: polardbxv1.PolarDBXBackupSpec{
+				Cluster: polardbxv1.PolarDBXClusterReference{
+					Name: clone.Spec.Source.Name,
+				},
+				RetentionTime:   clone.Spec.BackupRetentionTime,
+				StorageProvider: clone.Spec.StorageProvider,
+			},
+		}
+		if err := rc.SetControllerRefAndCreateToClone(backup); err != nil {
+			return flow.Error(err, "Unable to create backup of clone.")
+		}
+		clone.Status.Backup = backup.Name
+		return flow.Continue("Backup of clone created!", "backup", backup.Name)
+	})
+
+// WaitUntilCloneBackupFinished waits until the backup finishes and records the time to restore to.
+var WaitUntilCloneBackupFinished = polardbxv1reconcile.NewStepBinder("WaitUntilCloneBackupFinished",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		clone := rc.MustGetPolarDBXClone()
+		backup := &polardbxv1.PolarDBXBackup{}
+		err := rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: clone.Namespace, Name: BackupNameOf(clone)}, backup)
+		if err != nil {
+			return flow.Error(err, "Unable to get backup of clone.")
+		}
+
+		switch backup.Status.Phase {
+		case polardbxv1.BackupFinished:
+			if backup.Status.LatestRecoverableTimestamp == nil {
+				return failClone(clone, flow, "latest recoverable timestamp of backup not found")
+			}
+			clone.Status.RestoreTime = backup.Status.LatestRecoverableTimestamp.UTC().Format(restoreTimeLayout)
+			clone.Status.Phase = polardbxv1.CloneRestoring
+			return flow.Retry("Backup of clone finished!", "backup", backup.Name)
+		case polardbxv1.BackupFailed:
+			return failClone(clone, flow, "backup failed: "+backup.Status.Reason)
+		default:
+			return flow.RetryAfter(30*time.Second, "Wait until backup finished.", "backup", backup.Name, "phase", backup.Status.Phase)
+		}
+	})
+
+// CreateClonedCluster creates the new cluster restored from the backup if not found. The cluster isn't
+// owned by the clone, so that it's kept after the clone is removed.
+var CreateClonedCluster = polardbxv1reconcile.NewStepBinder("CreateClonedCluster",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		clone := rc.MustGetPolarDBXClone()
+		_, err := getClonedCluster(rc, clone)
+		if err == nil {
+			return flow.Pass()
+		} else if apierrors.IsAlreadyExists(err) {
+			return failClone(clone, flow, "cluster already exists: "+ClusterNameOf(clone))
+		} else if !apierrors.IsNotFound(err) {
+			return flow.Error(err, "Unable to get cluster of clone.")
+		}
+
+		backup := &polardbxv1.PolarDBXBackup{}
+		err = rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: clone.Namespace, Name: BackupNameOf(clone)}, backup)
+		if err != nil {
+			return flow.Error(err, "Unable to get backup of clone.")
+		}
+		if backup.Status.ClusterSpecSnapshot == nil {
+			return failClone(clone, flow, "cluster spec snapshot of backup not found")
+		}
+
+		polardbx := &polardbxv1.PolarDBXCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      ClusterNameOf(clone),
+				Namespace: clone.Namespace,
+				Labels: map[string]string{
+					polardbxmeta.LabelClone: clone.Name,
+				},
+			},
+			Spec: *newClonedClusterSpec(clone, backup),
+		}
+		if err := rc.Client().Create(rc.Context(), polardbx); err != nil {
+			return flow.Error(err, "Unable to create cluster of clone.")
+		}
+		return flow.Continue("Cluster of clone created!", "cluster", polardbx.Name)
+	})
+
+// CopyCloneParameters copies the polardbx parameters of the source cluster to the new cluster if
+// not found. Like the cluster, the parameters aren't owned by the clone.
+var CopyCloneParameters = polardbxv1reconcile.NewStepBinder("CopyCloneParameters",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		clone := rc.MustGetPolarDBXClone()
+		if clone.Spec.SkipParameters {
+			return flow.Pass()
+		}
+
+		var parameterList polardbxv1.PolarDBXParameterList
+		if err := rc.Client().List(rc.Context(), &parameterList, client.InNamespace(clone.Namespace)); err != nil {
+			return flow.Error(err, "Unable to list parameters.")
+		}
+
+		cluster := ClusterNameOf(clone)
+		copied := make([]string, 0)
+		for _, parameter := range parameterList.Items {
+			if parameter.Spec.ClusterName != clone.Spec.Source.Name {
+				continue
+			}
+			spec := parameter.Spec.DeepCopy()
+			spec.ClusterName = cluster
+			cloned := &polardbxv1.PolarDBXParameter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      clonedParameterName(clone.Spec.Source.Name, cluster, parameter.Name),
+					Namespace: clone.Namespace,
+					Labels: map[string]string{
+						polardbxmeta.LabelClone: clone.Name,
+					},
+				},
+				Spec: *spec,
+			}
+			if err := rc.Client().Create(rc.Context(), cloned); err != nil && !apierrors.IsAlreadyExists(err) {
+				return flow.Error(err, "Unable to copy parameter.", "parameter", parameter.Name)
+			}
+			copied = append(copied, cloned.Name)
+		}
+		if len(copied) == 0 {
+			return flow.Pass()
+		}
+		clone.Status.Parameters = copied
+		return flow.Continue("Parameters of clone copied!", "parameters", copied)
+	})
+
+// WaitUntilClonedClusterRunning waits until the new cluster is running and finishes the clone.
+var WaitUntilClonedClusterRunning = polardbxv1reconcile.NewStepBinder("WaitUntilClonedClusterRunning",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		clone := rc.MustGetPolarDBXClone()
+		polardbx, err := getClonedCluster(rc, clone)
+		if err != nil {
+			return flow.Error(err, "Unable to get cluster of clone.")
+		}
+
+		switch polardbx.Status.Phase {
+		case polardbxv1polardbx.PhaseRunning:
+			now := metav1.Now()
+			clone.Status.Phase = polardbxv1.CloneFinished
+			clone.Status.EndTime = &now
+			return flow.Continue("Clone finished!", "cluster", polardbx.Name)
+		case polardbxv1polardbx.PhaseFailed:
+			return failClone(clone, flow, "restore failed, cluster "+polardbx.Name+" is failed")
+		default:
+			return flow.RetryAfter(30*time.Second, "Wait until cluster of clone restored.", "cluster", polardbx.Name, "phase", polardbx.Status.Phase)
+		}
+	})
@@ -0,0 +1,62 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clone
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+)
+
+func TestClonedParameterName(t *testing.T) {
+	if name := clonedParameterName("pxc", "staging", "pxc-param"); name != "staging-param" {
+		t.Fatalf("expect staging-param, got %s", name)
+	}
+	if name := clonedParameterName("pxc", "staging", "param"); name != "staging-param" {
+		t.Fatalf("expect staging-param, got %s", name)
+	}
+}
+
+func TestNewClonedClusterSpec(t *testing.T) {
+	clone := &polardbxv1.PolarDBXClone{
+		ObjectMeta: metav1.ObjectMeta{Name: "staging"},
+		Spec: polardbxv1.PolarDBXCloneSpec{
+			Source: polardbxv1.PolarDBXClusterReference{Name: "pxc"},
+		},
+		Status: polardbxv1.PolarDBXCloneStatus{RestoreTime: "2022-01-01 00:00:00"},
+	}
+	backup := &polardbxv1.PolarDBXBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: "staging-backup"},
+		Status: polardbxv1.PolarDBXBackupStatus{
+			ClusterSpecSnapshot: &polardbxv1.PolarDBXClusterSpec{ServiceName: "pxc-svc"},
+		},
+	}
+
+	spec := newClonedClusterSpec(clone, backup)
+	if spec.ServiceName != "" {
+		t.Fatalf("expect service name cleared, got %s", spec.ServiceName)
+	}
+	if spec.Restore == nil || spec.Restore.BackupSet != "staging-backup" || spec.Restore.From.PolarBDXName != "pxc" ||
+		spec.Restore.Time != "2022-01-01 00:00:00" {
+		t.Fatalf("unexpected restore spec: %+v", spec.Restore)
+	}
+	if backup.Status.ClusterSpecSnapshot.ServiceName != "pxc-svc" {
+		t.Fatal("snapshot of backup modified")
+	}
+}
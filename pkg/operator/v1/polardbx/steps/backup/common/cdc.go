@@ -0,0 +1,130 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	polardbxhelper "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/command"
+)
+
+const defaultCDCMaxDivergence = time.Minute
+
+func isCDCConsistencyRequired(backup *polardbxv1.PolarDBXBackup) bool {
+	if backup.Spec.CDCConsistency == nil || backup.Status.ClusterSpecSnapshot == nil {
+		return false
+	}
+	cdc := backup.Status.ClusterSpecSnapshot.Topology.Nodes.CDC
+	return cdc != nil && cdc.Replicas+cdc.XReplicas > 0
+}
+
+// CollectCDCCheckpoint captures the position of the global binlog emitted by CDC right after the
+// heartbeat of the backup checkpoint is sent, records it in status and uploads it along with the
+// binlog offsets. Condition CDCDiverged is set if the capture is too late after the heartbeat.
+var CollectCDCCheckpoint = polardbxv1reconcile.NewStepBinder("CollectCDCCheckpoint",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetPolarDBXBackup()
+		if !isCDCConsistencyRequired(backup) {
+			return flow.Pass()
+		}
+		checkpoint := backup.Status.CDCCheckpoint
+		if checkpoint != nil && checkpoint.HeartBeatName == backup.Status.HeartBeatName {
+			return flow.Pass()
+		}
+
+		cnManager, err := rc.GetPolarDBXCNGroupManager(backup)
+		defer rc.Close()
+		if err != nil {
+			return flow.Error(err, "get CN DataSource Failed")
+		}
+		position, err := cnManager.GetBinlogOffset()
+		if err != nil {
+			return flow.Error(err, "Unable to get position of CDC.")
+		}
+		captureTime := metav1.Now()
+		divergence, err := polardbxhelper.CDCCheckpointDivergence(backup.Status.HeartBeatName, captureTime.Time)
+		if err != nil {
+			return flow.Error(err, "Unable to determine divergence of CDC position.")
+		}
+
+		backupPods, err := rc.GetXStoreBackupPods()
+		if err != nil {
+			return flow.Error(err, "Unable to get backup pods.")
+		}
+		if len(backupPods) == 0 {
+			return flow.Wait("No backup pod found to upload the CDC position.")
+		}
+		content := fmt.Sprintf("%s\nheartbeat:%s\ntimestamp:%s", position, backup.Status.HeartBeatName,
+			captureTime.Format("2006-01-02 15:04:05"))
+		remotePath := fmt.Sprintf("%s/%s/%s", backup.Status.BackupRootPath, polardbxmeta.BinlogOffsetPath,
+			polardbxmeta.CDCCheckpointName)
+		cmd := command.NewCanonicalCommandBuilder().Collect().
+			UploadOffset(content, remotePath, string(backup.Spec.StorageProvider.StorageName), backup.Spec.StorageProvider.Sink).Build()
+		stdout := &bytes.Buffer{}
+		stderr := &bytes.Buffer{}
+		err = rc.ExecuteCommandOn(&backupPods[0], "engine", cmd, control.ExecOptions{
+			Logger:  flow.Logger(),
+			Stdout:  stdout,
+			Stderr:  stderr,
+			Timeout: 1 * time.Minute,
+		})
+		if err != nil {
+			return flow.Error(err, "Failed to upload CDC position", "pod", backupPods[0].Name,
+				"stdout", stdout.String(), "stderr", stderr.String())
+		}
+
+		backup.Status.CDCCheckpoint = &polardbxv1.BackupCDCCheckpoint{
+			HeartBeatName: backup.Status.HeartBeatName,
+			Position:      position,
+			CaptureTime:   &captureTime,
+			Divergence:    divergence.String(),
+		}
+		maxDivergence := backup.Spec.CDCConsistency.MaxDivergence.Duration
+		if maxDivergence <= 0 {
+			maxDivergence = defaultCDCMaxDivergence
+		}
+		if divergence > maxDivergence {
+			backup.Status.Conditions = polardbxhelper.SetBackupCondition(backup.Status.Conditions, polardbxv1xstore.Condition{
+				Type:               polardbxv1xstore.BackupCDCDiverged,
+				Status:             corev1.ConditionTrue,
+				Reason:             "DivergenceExceeded",
+				Message:            "CDC position is captured " + divergence.String() + " after the checkpoint, exceeds " + maxDivergence.String(),
+				LastTransitionTime: captureTime,
+			})
+			flow.Logger().Info("CDC position diverges from the checkpoint.", "divergence", divergence.String())
+		} else {
+			backup.Status.Conditions = polardbxhelper.SetBackupCondition(backup.Status.Conditions, polardbxv1xstore.Condition{
+				Type:               polardbxv1xstore.BackupCDCDiverged,
+				Status:             corev1.ConditionFalse,
+				Reason:             "DivergenceWithinBound",
+				LastTransitionTime: captureTime,
+			})
+		}
+		return flow.Continue("CDC position collected.", "position", position, "divergence", divergence.String())
+	})
@@ -0,0 +1,293 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
+	polardbxhelper "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+)
+
+// DefaultCheckpointCoordinator is the coordinator used when the backup doesn't specify one.
+const DefaultCheckpointCoordinator = "SeekCp"
+
+// CheckpointFailure describes why the coordination failed, which fails the backup.
+type CheckpointFailure struct {
+	Reason  polardbxv1.BackupFailureReason
+	Message string
+}
+
+// CheckpointCoordinator coordinates the consistent checkpoint across the shards of a cluster
+// backup, after the binlogs of all the xstores are collected. The xstore backups wait until the
+// backup leaves the calculating phase, so a coordinator only decides how the checkpoint is found
+// and recorded. All the methods are invoked during reconciliation and must be idempotent.
+type CheckpointCoordinator interface {
+	// Prepare saves whatever the coordination needs, e.g. the task context of a job.
+	Prepare(rc *polardbxv1reconcile.Context) error
+
+	// Start starts the coordination if not started.
+	Start(rc *polardbxv1reconcile.Context) error
+
+	// Poll reports whether the coordination is done. A non-nil failure means it's done and failed.
+	Poll(rc *polardbxv1reconcile.Context) (done bool, failure *CheckpointFailure, err error)
+
+	// Cleanup releases the resources of the coordination, it's invoked once the backup finishes or fails.
+	Cleanup(rc *polardbxv1reconcile.Context) error
+}
+
+var checkpointCoordinators = make(map[string]CheckpointCoordinator)
+
+func RegisterCheckpointCoordinator(name string, coordinator CheckpointCoordinator) error {
+	if _, ok := checkpointCoordinators[name]; ok {
+		return errors.New("checkpoint coordinator already registered: " + name)
+	}
+	checkpointCoordinators[name] = coordinator
+	return nil
+}
+
+func MustRegisterCheckpointCoordinator(name string, coordinator CheckpointCoordinator) {
+	if err := RegisterCheckpointCoordinator(name, coordinator); err != nil {
+		panic(err)
+	}
+}
+
+// CheckpointCoordinatorOf returns the coordinator specified by the backup, or the default one.
+func CheckpointCoordinatorOf(backup *polardbxv1.PolarDBXBackup) (CheckpointCoordinator, error) {
+	name := backup.Spec.CheckpointCoordinator
+	if len(name) == 0 {
+		name = DefaultCheckpointCoordinator
+	}
+	coordinator, ok := checkpointCoordinators[name]
+	if !ok {
+		return nil, errors.New("unknown checkpoint coordinator: " + name)
+	}
+	return coordinator, nil
+}
+
+func init() {
+	MustRegisterCheckpointCoordinator(DefaultCheckpointCoordinator, &seekCpCoordinator{})
+}
+
+// seekCpCoordinator seeks the checkpoint in the collected transaction events of all the xstores
+// with a job running on one of the backup target pods.
+type seekCpCoordinator struct{}
+
+const seekcpJobKey = "seekcp"
+
+func (c *seekCpCoordinator) Prepare(rc *polardbxv1reconcile.Context) error {
+	exists, err := rc.IsTaskContextExists(seekcpJobKey)
+	if err != nil || exists {
+		return err
+	}
+	polardbxBackup := rc.MustGetPolarDBXBackup()
+
+	backupRootPath := polardbxBackup.Status.BackupRootPath
+	remoteCpPath := fmt.Sprintf("%s/%s/%s",
+		backupRootPath, polardbxmeta.BinlogOffsetPath, polardbxmeta.SeekCpName)
+	txEventsDir := fmt.Sprintf("%s/%s", backupRootPath, polardbxmeta.CollectBinlogPath)
+	indexesPath := fmt.Sprintf("%s/%s", backupRootPath, polardbxmeta.BinlogIndexesName)
+
+	seekCpJobContext := &SeekCpJobContext{
+		RemoteCpPath: remoteCpPath,
+		TxEventsDir:  txEventsDir,
+		IndexesPath:  indexesPath,
+		DnNameList:   strings.Join(polardbxBackup.Status.XStores, ","),
+		StorageName:  string(polardbxBackup.Spec.StorageProvider.StorageName),
+		Sink:         polardbxBackup.Spec.StorageProvider.Sink,
+	}
+	if polardbxBackup.Spec.CollectBatchBytes > 0 {
+		xstoreBackupList, err := rc.GetXStoreBackups()
+		if err != nil {
+			return err
+		}
+		seekCpJobContext.CollectSegments = make(map[string]int32)
+		for _, xstoreBackup := range xstoreBackupList.Items {
+			if xstoreBackup.Status.CollectSegments > 0 {
+				seekCpJobContext.CollectSegments[xstoreBackup.Spec.XStore.Name] = xstoreBackup.Status.CollectSegments
+			}
+		}
+	}
+
+	return rc.SaveTaskContext(seekcpJobKey, seekCpJobContext)
+}
+
+func (c *seekCpCoordinator) Start(rc *polardbxv1reconcile.Context) error {
+	seekCpJobContext := SeekCpJobContext{}
+	if err := rc.GetTaskContext(seekcpJobKey, &seekCpJobContext); err != nil {
+		return err
+	}
+
+	job, err := rc.GetSeekCpJob()
+	if client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	if job != nil {
+		return nil
+	}
+
+	polardbxBackup := rc.MustGetPolarDBXBackup()
+	xstoreBackupList, err := rc.GetXStoreBackups()
+	if err != nil {
+		return err
+	}
+	var targetPod corev1.Pod
+	for _, xstoreBackup := range xstoreBackupList.Items {
+		if len(xstoreBackup.Status.TargetPod) > 0 {
+			targetPodName := types.NamespacedName{Namespace: rc.Namespace(), Name: xstoreBackup.Status.TargetPod}
+			err := rc.Client().Get(rc.Context(), targetPodName, &targetPod)
+			if targetPod.Labels[polardbxmeta.LabelRole] == polardbxmeta.RoleGMS {
+				// do not pick gms to avoid download issue of heartbeat
+				continue
+			}
+			if err == nil {
+				break
+			}
+		}
+	}
+	if len(targetPod.Name) == 0 {
+		return errors.New("no target pod to run seekcp job")
+	}
+	job, err = newSeekCpJob(polardbxBackup, &targetPod, GenerateJobName(polardbxBackup, "seekcp"))
+	if err != nil {
+		return err
+	}
+	return rc.SetControllerRefAndCreateToBackup(job)
+}
+
+func (c *seekCpCoordinator) Poll(rc *polardbxv1reconcile.Context) (bool, *CheckpointFailure, error) {
+	job, err := rc.GetSeekCpJob()
+	if client.IgnoreNotFound(err) != nil {
+		return false, nil, err
+	}
+	if job == nil {
+		return true, nil, nil
+	}
+
+	if k8shelper.IsJobFailed(job) {
+		failure := &CheckpointFailure{
+			Reason:  polardbxv1.BackupFailureJobCrash,
+			Message: "Seekcp job failed, job: " + job.Name,
+		}
+		if k8shelper.IsJobDeadlineExceeded(job) {
+			failure.Reason = polardbxv1.BackupFailureTimeout
+		}
+		return true, failure, nil
+	}
+	return k8shelper.IsJobCompleted(job), nil, nil
+}
+
+func (c *seekCpCoordinator) Cleanup(rc *polardbxv1reconcile.Context) error {
+	job, err := rc.GetSeekCpJob()
+	if client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	if job == nil {
+		return nil
+	}
+	err = rc.Client().Delete(rc.Context(), job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+	if err == nil {
+		polardbxhelper.EmitBackupJobSpan(rc.MustGetPolarDBXBackup().Status.Trace, job)
+	}
+	return client.IgnoreNotFound(err)
+}
+
+// failOnUnknownCheckpointCoordinator fails the backup if its checkpoint coordinator is unknown.
+func failOnUnknownCheckpointCoordinator(backup *polardbxv1.PolarDBXBackup) (CheckpointCoordinator, bool) {
+	coordinator, err := CheckpointCoordinatorOf(backup)
+	if err != nil {
+		backup.Status.Phase = polardbxv1.BackupFailed
+		backup.Status.Reason = err.Error()
+		backup.Status.FailureReason = polardbxv1.BackupFailureCheckpointCoordinator
+		return nil, true
+	}
+	return coordinator, false
+}
+
+var PrepareCheckpoint = polardbxv1reconcile.NewStepBinder("PrepareCheckpoint",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetPolarDBXBackup()
+		coordinator, failed := failOnUnknownCheckpointCoordinator(backup)
+		if failed {
+			return flow.Retry("Backup Failed", "failure-reason", backup.Status.FailureReason)
+		}
+		if err := coordinator.Prepare(rc); err != nil {
+			return flow.Error(err, "Unable to prepare checkpoint coordination!")
+		}
+		return flow.Continue("Checkpoint coordination prepared!")
+	})
+
+var StartCheckpoint = polardbxv1reconcile.NewStepBinder("StartCheckpoint",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetPolarDBXBackup()
+		coordinator, failed := failOnUnknownCheckpointCoordinator(backup)
+		if failed {
+			return flow.Retry("Backup Failed", "failure-reason", backup.Status.FailureReason)
+		}
+		if err := coordinator.Start(rc); err != nil {
+			return flow.Error(err, "Unable to start checkpoint coordination!")
+		}
+		return flow.Continue("Checkpoint coordination started!")
+	})
+
+var WaitUntilCheckpointFinished = polardbxv1reconcile.NewStepBinder("WaitUntilCheckpointFinished",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetPolarDBXBackup()
+		coordinator, failed := failOnUnknownCheckpointCoordinator(backup)
+		if failed {
+			return flow.Retry("Backup Failed", "failure-reason", backup.Status.FailureReason)
+		}
+		done, failure, err := coordinator.Poll(rc)
+		if err != nil {
+			return flow.Error(err, "Unable to poll checkpoint coordination!")
+		}
+		if failure != nil {
+			backup.Status.Phase = polardbxv1.BackupFailed
+			backup.Status.Reason = failure.Message
+			backup.Status.FailureReason = failure.Reason
+			return flow.Retry("Backup Failed", "failure-reason", backup.Status.FailureReason)
+		}
+		if !done {
+			return flow.Wait("Checkpoint coordination is still running!")
+		}
+		return flow.Continue("Checkpoint coordination finished!")
+	})
+
+var CleanupCheckpoint = polardbxv1reconcile.NewStepBinder("CleanupCheckpoint",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		coordinator, err := CheckpointCoordinatorOf(rc.MustGetPolarDBXBackup())
+		if err != nil {
+			// Nothing was started by an unknown coordinator.
+			return flow.Continue("Skip checkpoint cleanup.", "error", err.Error())
+		}
+		if err := coordinator.Cleanup(rc); err != nil {
+			return flow.Error(err, "Unable to clean up checkpoint coordination!")
+		}
+		return flow.Continue("Checkpoint coordination cleaned up!")
+	})
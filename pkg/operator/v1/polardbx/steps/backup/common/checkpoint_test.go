@@ -0,0 +1,86 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"testing"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+)
+
+type fakeCheckpointCoordinator struct{}
+
+func (c *fakeCheckpointCoordinator) Prepare(rc *polardbxv1reconcile.Context) error { return nil }
+
+func (c *fakeCheckpointCoordinator) Start(rc *polardbxv1reconcile.Context) error { return nil }
+
+func (c *fakeCheckpointCoordinator) Poll(rc *polardbxv1reconcile.Context) (bool, *CheckpointFailure, error) {
+	return true, nil, nil
+}
+
+func (c *fakeCheckpointCoordinator) Cleanup(rc *polardbxv1reconcile.Context) error { return nil }
+
+func TestCheckpointCoordinatorOf(t *testing.T) {
+	fake := &fakeCheckpointCoordinator{}
+	MustRegisterCheckpointCoordinator("Fake", fake)
+	defer delete(checkpointCoordinators, "Fake")
+
+	if err := RegisterCheckpointCoordinator(DefaultCheckpointCoordinator, fake); err == nil {
+		t.Fatal("expect duplicate registration rejected")
+	}
+
+	backup := &polardbxv1.PolarDBXBackup{}
+	if c, err := CheckpointCoordinatorOf(backup); err != nil {
+		t.Fatal(err)
+	} else if _, ok := c.(*seekCpCoordinator); !ok {
+		t.Fatalf("expect seekcp by default, got %T", c)
+	}
+
+	backup.Spec.CheckpointCoordinator = "Fake"
+	if c, err := CheckpointCoordinatorOf(backup); err != nil || c != fake {
+		t.Fatalf("expect fake coordinator, got %T, %v", c, err)
+	}
+
+	backup.Spec.CheckpointCoordinator = "Unknown"
+	if _, failed := failOnUnknownCheckpointCoordinator(backup); !failed {
+		t.Fatal("expect unknown coordinator failed")
+	}
+	if backup.Status.Phase != polardbxv1.BackupFailed ||
+		backup.Status.FailureReason != polardbxv1.BackupFailureCheckpointCoordinator {
+		t.Fatalf("expect backup failed, got %s, %s", backup.Status.Phase, backup.Status.FailureReason)
+	}
+}
+
+func TestCheckpointOrderingStatusOf(t *testing.T) {
+	backup := &polardbxv1.PolarDBXBackup{}
+	status := checkpointOrderingStatusOf(backup)
+	if status.Ordering != polardbxv1.CheckpointGMSAfterDNs || backup.Status.CheckpointOrdering != status {
+		t.Fatalf("expect GMSAfterDNs by default, got %+v", status)
+	}
+
+	recordGMSOffset(status, "mysql_bin.000001:256")
+	backup.Spec.CheckpointOrdering = polardbxv1.CheckpointBarrier
+	if s := checkpointOrderingStatusOf(backup); s.Ordering != polardbxv1.CheckpointGMSAfterDNs || s.GMSOffset != "mysql_bin.000001:256" {
+		t.Fatalf("expect recorded status kept, got %+v", s)
+	}
+
+	backup.Status.CheckpointOrdering = nil
+	if s := checkpointOrderingStatusOf(backup); s.Ordering != polardbxv1.CheckpointBarrier || s.GMSCaptureTime != nil {
+		t.Fatalf("expect barrier, got %+v", s)
+	}
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	polardbxhelper "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+)
+
+// CheckBackupCircuitBreaker stops the reconciliation if the circuit of the backup is open, until the
+// resume annotation is set. The resume is propagated to the xstore backups whose circuit is open.
+var CheckBackupCircuitBreaker = polardbxv1reconcile.NewStepBinder("CheckBackupCircuitBreaker",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetPolarDBXBackup()
+		if !polardbxhelper.IsBackupCircuitOpen(backup.Status.Conditions) {
+			return flow.Pass()
+		}
+		if !polardbxhelper.IsAnnotationIndicatesToResumeBackup(backup.Annotations) {
+			return flow.Wait("Circuit of backup is open, set the annotation to resume.",
+				"annotation", polardbxmeta.AnnotationBackupResume)
+		}
+
+		xstoreBackups, err := rc.GetXStoreBackups()
+		if err != nil {
+			return flow.Error(err, "Unable to get xstore backups.")
+		}
+		for i := range xstoreBackups.Items {
+			xstoreBackup := &xstoreBackups.Items[i]
+			if !polardbxhelper.IsBackupCircuitOpen(xstoreBackup.Status.Conditions) ||
+				polardbxhelper.IsAnnotationIndicatesToResumeBackup(xstoreBackup.Annotations) {
+				continue
+			}
+			if xstoreBackup.Annotations == nil {
+				xstoreBackup.Annotations = make(map[string]string)
+			}
+			xstoreBackup.Annotations[polardbxmeta.AnnotationBackupResume] = "true"
+			if err := rc.Client().Update(rc.Context(), xstoreBackup); err != nil {
+				return flow.Error(err, "Unable to resume xstore backup.", "xstore-backup", xstoreBackup.Name)
+			}
+		}
+
+		// Update the annotations first, since the status is overwritten by the update.
+		delete(backup.Annotations, polardbxmeta.AnnotationBackupResume)
+		if err := rc.UpdatePolarDBXBackup(); err != nil {
+			return flow.Error(err, "Unable to remove resume annotation.")
+		}
+		backup.Status.CircuitBreaker = nil
+		backup.Status.Conditions = polardbxhelper.SetBackupCondition(backup.Status.Conditions, polardbxv1xstore.Condition{
+			Type:               polardbxv1xstore.BackupCircuitOpen,
+			Status:             corev1.ConditionFalse,
+			Reason:             "Resumed",
+			Message:            "Backup is resumed by annotation.",
+			LastTransitionTime: metav1.Now(),
+		})
+		return flow.Continue("Backup resumed.")
+	})
+
+// ObserveBackupCircuitBreaker counts the failure of the reconciliation into the circuit breaker and
+// opens the circuit if the failures reach the threshold, the counter is reset on success. It returns
+// true if the circuit is opened, the failure shouldn't be retried then.
+func ObserveBackupCircuitBreaker(rc *polardbxv1reconcile.Context, err error) (bool, error) {
+	backup := rc.MustGetPolarDBXBackup()
+	if polardbxhelper.IsBackupCircuitOpen(backup.Status.Conditions) {
+		return false, nil
+	}
+
+	step, failed := control.FailedStepOf(err)
+	if !failed {
+		if backup.Status.CircuitBreaker == nil {
+			return false, nil
+		}
+		backup.Status.CircuitBreaker = nil
+		return false, rc.UpdatePolarDBXBackupStatus()
+	}
+
+	breaker, open := polardbxhelper.ObserveBackupFailure(backup.Status.CircuitBreaker,
+		backup.Spec.CircuitBreakerThreshold, step, err.Error(), metav1.Now())
+	backup.Status.CircuitBreaker = breaker
+	if open {
+		backup.Status.Conditions = polardbxhelper.SetBackupCondition(backup.Status.Conditions, polardbxv1xstore.Condition{
+			Type:               polardbxv1xstore.BackupCircuitOpen,
+			Status:             corev1.ConditionTrue,
+			Reason:             "ConsecutiveFailures",
+			Message:            "Step " + breaker.Step + " keeps failing: " + breaker.Reason,
+			LastTransitionTime: metav1.Now(),
+		})
+	}
+	return open, rc.UpdatePolarDBXBackupStatus()
+}
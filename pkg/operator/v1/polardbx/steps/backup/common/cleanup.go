@@ -0,0 +1,128 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/hpfs/remote"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	polardbxhelper "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+)
+
+// requiresFilesFinalizer tells whether the files of the backup may be deleted along with the backup
+// object by the clean policy, which is only possible with the retention credential.
+func requiresFilesFinalizer(backup *polardbxv1.PolarDBXBackup) bool {
+	if backup.Spec.StorageProvider.RetentionCredential == nil {
+		return false
+	}
+	return backup.Spec.CleanPolicy == polardbxv1.CleanPolicyDelete ||
+		backup.Spec.CleanPolicy == polardbxv1.CleanPolicyDeleteOnFailure
+}
+
+// isFilesDeletedWithObject tells whether the files of the backup are deleted along with the backup
+// object by the clean policy.
+func isFilesDeletedWithObject(backup *polardbxv1.PolarDBXBackup) bool {
+	switch backup.Spec.CleanPolicy {
+	case polardbxv1.CleanPolicyDelete:
+		return true
+	case polardbxv1.CleanPolicyDeleteOnFailure:
+		return backup.Status.Phase == polardbxv1.BackupFailed
+	default:
+		return false
+	}
+}
+
+// SyncBackupFilesFinalizer adds the finalizer of backup files if they're to be deleted along with the
+// backup object, and removes it once the clean policy is changed to retain them.
+var SyncBackupFilesFinalizer = polardbxv1reconcile.NewStepBinder("SyncBackupFilesFinalizer",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetPolarDBXBackup()
+		required := requiresFilesFinalizer(backup)
+		if required == controllerutil.ContainsFinalizer(backup, polardbxmeta.BackupFilesFinalizer) {
+			return flow.Pass()
+		}
+		if required {
+			controllerutil.AddFinalizer(backup, polardbxmeta.BackupFilesFinalizer)
+		} else {
+			controllerutil.RemoveFinalizer(backup, polardbxmeta.BackupFilesFinalizer)
+		}
+		// The status is overwritten by the one on server, retry so that nothing is lost.
+		if err := rc.UpdatePolarDBXBackup(); err != nil {
+			return flow.Error(err, "Unable to update finalizer of backup.")
+		}
+		return flow.Retry("Finalizer of backup files updated.", "required", required)
+	})
+
+// CleanBackupFilesOnDeletion deletes the files of the backup being deleted by the clean policy, and
+// then removes the finalizer. The deletion is postponed as retention does if the files are immutable,
+// or in use by a restore or by incremental backups. The files are left if the retention credential
+// is gone, e.g. the namespace is being deleted.
+var CleanBackupFilesOnDeletion = polardbxv1reconcile.NewStepBinder("CleanBackupFilesOnDeletion",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetPolarDBXBackup()
+		if !controllerutil.ContainsFinalizer(backup, polardbxmeta.BackupFilesFinalizer) {
+			return flow.Pass()
+		}
+
+		if isFilesDeletedWithObject(backup) {
+			now := time.Now()
+			if until := backupImmutableUntil(backup); now.Before(until) {
+				waitDuration := until.Sub(now)
+				if waitDuration > ImmutableDeleteRetryInterval {
+					waitDuration = ImmutableDeleteRetryInterval
+				}
+				return flow.RetryAfter(waitDuration, "Backup is immutable, not to delete files now!", "until", until)
+			}
+			restore, err := polardbxhelper.ActiveRestoreOfPolarDBXBackup(rc.Context(), rc.Client(), backup)
+			if err != nil {
+				return flow.Error(err, "Unable to determine restores of the backup!")
+			}
+			if len(restore) > 0 {
+				return flow.RetryAfter(time.Minute, "Backup is referenced by an active restore, not to delete files now!", "restore", restore)
+			}
+			dependent, err := polardbxhelper.IncrementalDependentOfPolarDBXBackup(rc.Context(), rc.Client(), backup)
+			if err != nil {
+				return flow.Error(err, "Unable to determine incremental backups based on the backup!")
+			}
+			if len(dependent) > 0 {
+				return flow.RetryAfter(time.Hour, "Backup is the base of incremental backups, not to delete files now!", "dependent", dependent)
+			}
+
+			if err := removeBackupFiles(rc, flow, backup); remote.IsImmutable(err) {
+				return flow.RetryAfter(ImmutableDeleteRetryInterval, "Backup files are locked by the storage, not to delete now!")
+			} else if apierrors.IsNotFound(err) {
+				flow.Logger().Info("Retention credential not found, backup files are left.", "path", backup.Status.BackupRootPath)
+			} else if err != nil {
+				return flow.Error(err, "Unable to delete the backup files!")
+			}
+		}
+
+		controllerutil.RemoveFinalizer(backup, polardbxmeta.BackupFilesFinalizer)
+		if err := rc.UpdatePolarDBXBackup(); client.IgnoreNotFound(err) != nil {
+			return flow.Error(err, "Unable to remove finalizer of backup.")
+		}
+		return flow.Continue("Finalizer of backup files removed.", "files-deleted", isFilesDeletedWithObject(backup))
+	})
@@ -0,0 +1,64 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+)
+
+func TestCleanPolicyOfBackupFiles(t *testing.T) {
+	newBackup := func(policy polardbxv1.CleanPolicyType, phase polardbxv1.PolarDBXBackupPhase) *polardbxv1.PolarDBXBackup {
+		b := &polardbxv1.PolarDBXBackup{}
+		b.Spec.CleanPolicy = policy
+		b.Spec.StorageProvider.RetentionCredential = &corev1.LocalObjectReference{Name: "retention"}
+		b.Status.Phase = phase
+		return b
+	}
+
+	testcases := []struct {
+		policy    polardbxv1.CleanPolicyType
+		phase     polardbxv1.PolarDBXBackupPhase
+		finalizer bool
+		deleted   bool
+	}{
+		{polardbxv1.CleanPolicyRetain, polardbxv1.BackupFinished, false, false},
+		{polardbxv1.CleanPolicyOnFailure, polardbxv1.BackupFailed, false, false},
+		{polardbxv1.CleanPolicyDelete, polardbxv1.BackupFinished, true, true},
+		{polardbxv1.CleanPolicyDelete, polardbxv1.BackupNew, true, true},
+		{polardbxv1.CleanPolicyDeleteOnFailure, polardbxv1.BackupFinished, true, false},
+		{polardbxv1.CleanPolicyDeleteOnFailure, polardbxv1.BackupFailed, true, true},
+	}
+	for _, tc := range testcases {
+		b := newBackup(tc.policy, tc.phase)
+		if got := requiresFilesFinalizer(b); got != tc.finalizer {
+			t.Fatalf("%s/%s: expect finalizer %v, got %v", tc.policy, tc.phase, tc.finalizer, got)
+		}
+		if got := isFilesDeletedWithObject(b); got != tc.deleted {
+			t.Fatalf("%s/%s: expect files deleted %v, got %v", tc.policy, tc.phase, tc.deleted, got)
+		}
+	}
+
+	b := newBackup(polardbxv1.CleanPolicyDelete, polardbxv1.BackupFinished)
+	b.Spec.StorageProvider.RetentionCredential = nil
+	if requiresFilesFinalizer(b) {
+		t.Fatal("expect no finalizer without retention credential")
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"sort"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+)
+
+// dataSizeOf returns the largest data size among the volumes of the xstore, which approximates
+// the size of its full backup.
+func dataSizeOf(xstore *polardbxv1.XStore) int64 {
+	var size int64
+	for _, volume := range xstore.Status.BoundVolumes {
+		if volume != nil && volume.DataSize > size {
+			size = volume.DataSize
+		}
+	}
+	return size
+}
+
+// isFullBackupRunning tells if the xstore backup is still taking its full backup. It's done once
+// it starts collecting binlogs.
+func isFullBackupRunning(phase polardbxv1.XStoreBackupPhase) bool {
+	switch phase {
+	case polardbxv1.XStoreBackupNew, polardbxv1.XStoreBackupPending, polardbxv1.XStoreFullBackuping:
+		return true
+	}
+	return false
+}
+
+// xstoresToBackup returns the members whose backups are to be created now and the names of the
+// rest in the queue. Members not backed up yet are queued with the largest first (then by name),
+// so that the longest backups start early, and started while fewer than limit full backups of
+// existing xstore backups are running. Limit not positive means no limit.
+func xstoresToBackup(members []polardbxv1.XStore, xstoreBackups []polardbxv1.XStoreBackup, limit int32) ([]polardbxv1.XStore, []string) {
+	started := make(map[string]bool)
+	running := 0
+	for _, xstoreBackup := range xstoreBackups {
+		started[xstoreBackup.Spec.XStore.Name] = true
+		if isFullBackupRunning(xstoreBackup.Status.Phase) {
+			running++
+		}
+	}
+
+	pending := make([]polardbxv1.XStore, 0, len(members))
+	for _, xstore := range members {
+		if !started[xstore.Name] {
+			pending = append(pending, xstore)
+		}
+	}
+	sort.SliceStable(pending, func(i, j int) bool {
+		si, sj := dataSizeOf(&pending[i]), dataSizeOf(&pending[j])
+		if si != sj {
+			return si > sj
+		}
+		return pending[i].Name < pending[j].Name
+	})
+
+	n := len(pending)
+	if limit > 0 {
+		n = int(limit) - running
+		if n < 0 {
+			n = 0
+		} else if n > len(pending) {
+			n = len(pending)
+		}
+	}
+	queued := make([]string, 0, len(pending)-n)
+	for _, xstore := range pending[n:] {
+		queued = append(queued, xstore.Name)
+	}
+	return pending[:n], queued
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"reflect"
+	"testing"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/api/v1/xstore"
+)
+
+func TestXStoresToBackup(t *testing.T) {
+	newXStore := func(name string, size int64) polardbxv1.XStore {
+		x := polardbxv1.XStore{}
+		x.Name = name
+		x.Status.BoundVolumes = map[string]*xstore.HostPathVolume{
+			name + "-0": {DataSize: size},
+			name + "-1": {DataSize: size / 2},
+		}
+		return x
+	}
+	newXStoreBackup := func(xstoreName string, phase polardbxv1.XStoreBackupPhase) polardbxv1.XStoreBackup {
+		b := polardbxv1.XStoreBackup{}
+		b.Spec.XStore.Name = xstoreName
+		b.Status.Phase = phase
+		return b
+	}
+	namesOf := func(xstores []polardbxv1.XStore) []string {
+		names := make([]string, 0, len(xstores))
+		for _, x := range xstores {
+			names = append(names, x.Name)
+		}
+		return names
+	}
+	members := []polardbxv1.XStore{
+		newXStore("gms", 10),
+		newXStore("dn-0", 100),
+		newXStore("dn-1", 300),
+		newXStore("dn-2", 100),
+	}
+
+	testCases := map[string]struct {
+		backups []polardbxv1.XStoreBackup
+		limit   int32
+		start   []string
+		queued  []string
+	}{
+		"no limit": {
+			start:  []string{"dn-1", "dn-0", "dn-2", "gms"},
+			queued: []string{},
+		},
+		"largest first": {
+			limit:  2,
+			start:  []string{"dn-1", "dn-0"},
+			queued: []string{"dn-2", "gms"},
+		},
+		"window full": {
+			backups: []polardbxv1.XStoreBackup{
+				newXStoreBackup("dn-1", polardbxv1.XStoreFullBackuping),
+				newXStoreBackup("dn-0", polardbxv1.XStoreBackupPending),
+			},
+			limit:  2,
+			start:  []string{},
+			queued: []string{"dn-2", "gms"},
+		},
+		"refill after full backup": {
+			backups: []polardbxv1.XStoreBackup{
+				newXStoreBackup("dn-1", polardbxv1.XStoreBackupCollecting),
+				newXStoreBackup("dn-0", polardbxv1.XStoreFullBackuping),
+			},
+			limit:  2,
+			start:  []string{"dn-2"},
+			queued: []string{"gms"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			start, queued := xstoresToBackup(members, tc.backups, tc.limit)
+			if !reflect.DeepEqual(namesOf(start), tc.start) {
+				t.Fatalf("expect start %v, got %v", tc.start, namesOf(start))
+			}
+			if !reflect.DeepEqual(queued, tc.queued) {
+				t.Fatalf("expect queued %v, got %v", tc.queued, queued)
+			}
+		})
+	}
+}
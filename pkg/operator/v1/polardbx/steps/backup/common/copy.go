@@ -0,0 +1,309 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
+	polardbxhelper "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+	backupbuilder "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/steps/backup/xstorejobbuilder"
+	"github.com/alibaba/polardbx-operator/pkg/util"
+)
+
+func failBackupCopy(backup *polardbxv1.PolarDBXBackup, flow control.Flow, reason string) (reconcile.Result, error) {
+	backup.Status.Phase = polardbxv1.BackupFailed
+	backup.Status.Reason = reason
+	backup.Status.FailureReason = polardbxv1.BackupFailureCopy
+	return flow.Retry("Backup copy failed.", "reason", reason)
+}
+
+func getBackupCopySource(rc *polardbxv1reconcile.Context, backup *polardbxv1.PolarDBXBackup) (*polardbxv1.PolarDBXBackup, error) {
+	source := &polardbxv1.PolarDBXBackup{}
+	err := rc.Client().Get(rc.Context(), types.NamespacedName{
+		Namespace: backup.Namespace,
+		Name:      backup.Spec.CopyFrom.BackupName,
+	}, source)
+	if err != nil {
+		return nil, err
+	}
+	return source, nil
+}
+
+func listXStoreBackupsOf(rc *polardbxv1reconcile.Context, backup *polardbxv1.PolarDBXBackup) ([]polardbxv1.XStoreBackup, error) {
+	var xstoreBackups polardbxv1.XStoreBackupList
+	err := rc.Client().List(rc.Context(), &xstoreBackups, client.InNamespace(backup.Namespace), client.MatchingLabels{
+		polardbxmeta.LabelName:      backup.Spec.Cluster.Name,
+		polardbxmeta.LabelTopBackup: backup.Name,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return xstoreBackups.Items, nil
+}
+
+// validateBackupCopy checks whether the backup is able to be copied from source.
+func validateBackupCopy(backup, source *polardbxv1.PolarDBXBackup) error {
+	if backup.Name == source.Name {
+		return errors.New("unable to copy from itself")
+	}
+	if len(backup.Spec.Cluster.Name) > 0 && backup.Spec.Cluster.Name != source.Spec.Cluster.Name {
+		return fmt.Errorf("cluster %s mismatches with cluster %s of the source", backup.Spec.Cluster.Name, source.Spec.Cluster.Name)
+	}
+	if backup.Spec.StorageProvider.StorageName != polardbxv1.OSS {
+		return errors.New("copy is not supported by storage " + string(backup.Spec.StorageProvider.StorageName))
+	}
+	if backup.Spec.StorageProvider != source.Spec.StorageProvider {
+		return errors.New("storage provider mismatches with the source")
+	}
+	// Server side copies are always in the default storage class, and archived objects
+	// can't be copied without being thawed.
+	if len(backup.Spec.StorageClass) > 0 {
+		return errors.New("storage class is not supported by copy")
+	}
+	if polardbxhelper.IsArchiveBackupStorageClass(source.Spec.StorageProvider.StorageName, source.Status.StorageClass) {
+		return errors.New("unable to copy from backup in archive storage class " + source.Status.StorageClass)
+	}
+	return nil
+}
+
+// PrepareBackupCopy validates the source backup and records the start info of the copy. The states
+// of the source, e.g. the cluster spec snapshot and the backup set timestamps, are inherited since
+// the copy is meant to restore to the same point.
+var PrepareBackupCopy = polardbxv1reconcile.NewStepBinder("PrepareBackupCopy",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetPolarDBXBackup()
+		source, err := getBackupCopySource(rc, backup)
+		if apierrors.IsNotFound(err) {
+			return failBackupCopy(backup, flow, "backup to copy from not found: "+backup.Spec.CopyFrom.BackupName)
+		} else if err != nil {
+			return flow.Error(err, "Unable to get backup to copy from.", "source", backup.Spec.CopyFrom.BackupName)
+		}
+		if err := validateBackupCopy(backup, source); err != nil {
+			return failBackupCopy(backup, flow, err.Error())
+		}
+		switch source.Status.Phase {
+		case polardbxv1.BackupFinished:
+		case polardbxv1.BackupFailed:
+			return failBackupCopy(backup, flow, "backup to copy from is failed: "+source.Name)
+		default:
+			return flow.RetryAfter(30*time.Second, "Wait until the backup to copy from finished.",
+				"source", source.Name, "source-phase", source.Status.Phase)
+		}
+
+		if len(backup.Spec.Cluster.Name) == 0 {
+			backup.Spec.Cluster = source.Spec.Cluster
+		}
+		backup.Spec.XStores = append([]string(nil), source.Spec.XStores...)
+		if backup.Labels == nil {
+			backup.Labels = make(map[string]string)
+		}
+		backup.Labels[polardbxmeta.LabelName] = backup.Spec.Cluster.Name
+		backup.Labels[polardbxmeta.LabelBackupTrigger] = string(backupTriggerSourceOf(backup))
+		if err := rc.UpdatePolarDBXBackup(); err != nil {
+			return flow.Error(err, "Unable to update PXC backup.")
+		}
+
+		nowTime := metav1.Now()
+		backup.Status.StartTime = &nowTime
+		backup.Status.BackupRootPath = util.BackupRootPath(backup)
+		backup.Status.TriggerSource = backupTriggerSourceOf(backup)
+		backup.Status.CopiedFrom = source.Name
+		backup.Status.ClusterSpecSnapshot = source.Status.ClusterSpecSnapshot.DeepCopy()
+		backup.Status.XStores = append([]string(nil), source.Status.XStores...)
+		backup.Status.HeartBeatName = source.Status.HeartBeatName
+		backup.Status.StorageName = source.Status.StorageName
+		backup.Status.BackupSetTimestamp = make(map[string]*metav1.Time)
+		for xstore, timestamp := range source.Status.BackupSetTimestamp {
+			backup.Status.BackupSetTimestamp[xstore] = timestamp.DeepCopy()
+		}
+		backup.Status.LatestRecoverableTimestamp = source.Status.LatestRecoverableTimestamp.DeepCopy()
+		// The copy recovers to the same window as the source, not from the time it's copied.
+		backup.Status.EarliestRecoverableTimestamp = source.Status.EarliestRecoverableTimestamp.DeepCopy()
+		if backup.Status.EarliestRecoverableTimestamp == nil {
+			backup.Status.EarliestRecoverableTimestamp = source.Status.StartTime.DeepCopy()
+		}
+		backup.Status.CDCCheckpoint = source.Status.CDCCheckpoint.DeepCopy()
+		return flow.Continue("Backup copy prepared.", "source", source.Name)
+	})
+
+// CopyBackupFiles copies the files of source backup to the root path of the copy on the server side,
+// the copies expire by the retention time of the copy rather than the source. It's done by a job on
+// a pod of the source backup, which has access to the storage, and polled until it finishes.
+var CopyBackupFiles = polardbxv1reconcile.NewStepBinder("CopyBackupFiles",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetPolarDBXBackup()
+		if backup.Status.CopiedFiles > 0 {
+			return flow.Pass()
+		}
+		job, err := getCopyJob(rc, backup)
+		if err != nil {
+			return flow.Error(err, "Unable to get copy job.")
+		}
+		if job != nil {
+			if k8shelper.IsJobFailed(job) {
+				return failBackupCopy(backup, flow, "copy job failed: "+job.Name)
+			}
+			if !k8shelper.IsJobCompleted(job) {
+				return flow.RetryAfter(10*time.Second, "Wait for copy job.", "job", job.Name)
+			}
+			copied, err := readCopiedFiles(rc, job)
+			if err != nil {
+				return flow.Error(err, "Unable to read files copied by job.", "job", job.Name)
+			}
+			if copied == 0 {
+				return failBackupCopy(backup, flow, "no file found under the root path of "+backup.Spec.CopyFrom.BackupName)
+			}
+			backup.Status.CopiedFiles = copied
+			err = rc.Client().Delete(rc.Context(), job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+			if client.IgnoreNotFound(err) != nil {
+				return flow.Error(err, "Unable to remove copy job.", "job", job.Name)
+			}
+			return flow.Continue("Backup files copied.", "copied", copied)
+		}
+
+		source, err := getBackupCopySource(rc, backup)
+		if apierrors.IsNotFound(err) {
+			return failBackupCopy(backup, flow, "backup to copy from is gone: "+backup.Spec.CopyFrom.BackupName)
+		} else if err != nil {
+			return flow.Error(err, "Unable to get backup to copy from.", "source", backup.Spec.CopyFrom.BackupName)
+		}
+
+		sourceXStoreBackups, err := listXStoreBackupsOf(rc, source)
+		if err != nil {
+			return flow.Error(err, "Unable to list xstore backups of source.", "source", source.Name)
+		}
+		var pod *corev1.Pod
+		for _, xstoreBackup := range sourceXStoreBackups {
+			p := &corev1.Pod{}
+			err := rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: backup.Namespace, Name: xstoreBackup.Status.TargetPod}, p)
+			if err == nil {
+				pod = p
+				break
+			} else if !apierrors.IsNotFound(err) {
+				return flow.Error(err, "Unable to get target pod of source.", "pod", xstoreBackup.Status.TargetPod)
+			}
+		}
+		if pod == nil {
+			return failBackupCopy(backup, flow, "no pod of the source backup is available to copy through")
+		}
+
+		// Copies are done on the server side, it takes a while for large backups though.
+		job = newCopyJob(backup, source, pod, GenerateJobName(backup, "copy"))
+		if err := rc.SetControllerRefAndCreateToBackup(job); err != nil {
+			return flow.Error(err, "Unable to create copy job.", "pod", pod.Name)
+		}
+		return flow.RetryAfter(10*time.Second, "Copy job created.", "job", job.Name, "source", source.Name)
+	})
+
+// CloneXStoreBackups creates a clone for each xstore backup of the source, the clones fill their
+// status from the source and finish immediately.
+var CloneXStoreBackups = polardbxv1reconcile.NewStepBinder("CloneXStoreBackups",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetPolarDBXBackup()
+		source, err := getBackupCopySource(rc, backup)
+		if apierrors.IsNotFound(err) {
+			return failBackupCopy(backup, flow, "backup to copy from is gone: "+backup.Spec.CopyFrom.BackupName)
+		} else if err != nil {
+			return flow.Error(err, "Unable to get backup to copy from.", "source", backup.Spec.CopyFrom.BackupName)
+		}
+		sourceXStoreBackups, err := listXStoreBackupsOf(rc, source)
+		if err != nil {
+			return flow.Error(err, "Unable to list xstore backups of source.", "source", source.Name)
+		}
+		if len(sourceXStoreBackups) < len(source.Status.Backups) {
+			return failBackupCopy(backup, flow, "xstore backups of the source are missing")
+		}
+
+		if backup.Status.Backups == nil {
+			backup.Status.Backups = make(map[string]string)
+		}
+		for i := range sourceXStoreBackups {
+			sourceXStoreBackup := &sourceXStoreBackups[i]
+			xstoreName := sourceXStoreBackup.Spec.XStore.Name
+			if _, ok := backup.Status.Backups[xstoreName]; ok {
+				continue
+			}
+			xstoreBackup, err := backupbuilder.NewXStoreBackupCopy(rc.Scheme(), backup, sourceXStoreBackup)
+			if err != nil {
+				return flow.Error(err, "Unable to build clone of xstore backup", "xstore-backup", sourceXStoreBackup.Name)
+			}
+			if err := rc.Client().Create(rc.Context(), xstoreBackup); err != nil && !apierrors.IsAlreadyExists(err) {
+				return flow.Error(err, "Unable to create clone of xstore backup", "xstore-backup", sourceXStoreBackup.Name)
+			}
+			backup.Status.Backups[xstoreName] = xstoreBackup.Name
+		}
+		return flow.Continue("XStore backups cloned.")
+	})
+
+var WaitAllXStoreBackupClonesFinished = polardbxv1reconcile.NewStepBinder("WaitAllXStoreBackupClonesFinished",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetPolarDBXBackup()
+		xstoreBackups, err := listXStoreBackupsOf(rc, backup)
+		if err != nil {
+			return flow.Error(err, "Unable to list xstore backups.")
+		}
+		if failOnXStoreBackupFailure(backup, xstoreBackups) {
+			return flow.Retry("Backup Failed", "failure-reason", backup.Status.FailureReason)
+		}
+		if len(xstoreBackups) < len(backup.Status.Backups) {
+			return flow.Wait("XStore backup clones are not all observed.")
+		}
+		for _, xstoreBackup := range xstoreBackups {
+			if xstoreBackup.Status.Phase != polardbxv1.XStoreBackupFinished {
+				return flow.Wait("XStore backup clone is not finished!", "xstore-backup", xstoreBackup.Name)
+			}
+		}
+		nowTime := metav1.Now()
+		backup.Status.EndTime = &nowTime
+		return flow.Continue("All xstore backup clones finished.")
+	})
+
+// CloneBackupSecrets saves the account secrets of the source backup for the copy, the cluster may
+// have changed the passwords since then.
+var CloneBackupSecrets = polardbxv1reconcile.NewStepBinder("CloneBackupSecrets",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetPolarDBXBackup()
+		if backupSecret, _ := rc.GetSecret(backup.Name); backupSecret != nil {
+			return flow.Continue("Already have backup secret")
+		}
+		secret, err := rc.GetSecret(backup.Spec.CopyFrom.BackupName)
+		if err != nil {
+			return flow.Error(err, "Unable to get secret of backup to copy from", "source", backup.Spec.CopyFrom.BackupName)
+		}
+		backupSecret, err := rc.NewSecretFromPolarDBX(secret)
+		if err != nil {
+			return flow.Error(err, "Unable to new account secret while copying")
+		}
+		if err := rc.SetControllerRefAndCreateToBackup(backupSecret); err != nil {
+			return flow.Error(err, "Unable to create account secret while copying")
+		}
+		return flow.Continue("Backup secrets cloned!")
+	})
@@ -0,0 +1,129 @@
+/*
+Copyright 2021 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
+	polardbxhelper "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/command"
+)
+
+// newCopyJob builds the job to copy the files of source backup to the root path of the copy, through
+// the engine container of the target pod which has access to the storage. The number of files copied
+// is written to the termination log.
+func newCopyJob(backup, source *polardbxv1.PolarDBXBackup, targetPod *corev1.Pod, jobName string) *batchv1.Job {
+	podSpec := targetPod.Spec.DeepCopy()
+	podSpec.InitContainers = nil
+	podSpec.RestartPolicy = corev1.RestartPolicyNever
+	podSpec.HostNetwork = false
+
+	podSpec.Containers = []corev1.Container{
+		*k8shelper.GetContainerFromPodSpec(podSpec, "engine"),
+	}
+	podSpec.Containers[0].Name = "copyjob"
+
+	retention := ""
+	if backup.Spec.RetentionTime.Duration > 0 {
+		retention = backup.Spec.RetentionTime.Duration.String()
+	}
+	podSpec.Containers[0].Command = command.NewCanonicalCommandBuilder().Collect().
+		CopyFiles(source.Status.BackupRootPath, backup.Status.BackupRootPath, retention,
+			string(backup.Spec.StorageProvider.StorageName), backup.Spec.StorageProvider.Sink,
+			corev1.TerminationMessagePathDefault).Build()
+	podSpec.Containers[0].TerminationMessagePath = corev1.TerminationMessagePathDefault
+	podSpec.Containers[0].Resources.Limits = nil
+	podSpec.Containers[0].Resources.Requests = nil
+	podSpec.Containers[0].Ports = nil
+	podSpec.Containers[0].StartupProbe = nil
+	podSpec.Containers[0].LivenessProbe = nil
+	podSpec.Containers[0].ReadinessProbe = nil
+
+	// Replace system envs
+	replaceSystemEnvs(podSpec, targetPod)
+
+	labels := map[string]string{
+		meta.CopyJobLabelBackupName: backup.Name,
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: backup.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: pointer.Int32(0),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: *podSpec,
+			},
+		},
+	}
+	polardbxhelper.ApplyBackupJobTemplate(job, backup.Spec.JobTemplate)
+	return job
+}
+
+// getCopyJob returns the copy job owned by the backup, nil if not found.
+func getCopyJob(rc *polardbxv1reconcile.Context, backup *polardbxv1.PolarDBXBackup) (*batchv1.Job, error) {
+	var jobList batchv1.JobList
+	err := rc.Client().List(rc.Context(), &jobList, client.InNamespace(backup.Namespace),
+		client.MatchingLabels{meta.CopyJobLabelBackupName: backup.Name})
+	if err != nil {
+		return nil, err
+	}
+	for i := range jobList.Items {
+		if k8shelper.CheckControllerReference(&jobList.Items[i], backup) == nil {
+			return &jobList.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// readCopiedFiles reads the number of files copied by the completed job from the termination log of
+// its pod.
+func readCopiedFiles(rc *polardbxv1reconcile.Context, job *batchv1.Job) (int64, error) {
+	var podList corev1.PodList
+	err := rc.Client().List(rc.Context(), &podList, client.InNamespace(job.Namespace),
+		client.MatchingLabels{"job-name": job.Name})
+	if err != nil {
+		return 0, err
+	}
+	for _, pod := range podList.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			terminated := status.State.Terminated
+			if terminated == nil || terminated.ExitCode != 0 {
+				continue
+			}
+			return strconv.ParseInt(strings.TrimSpace(terminated.Message), 10, 64)
+		}
+	}
+	return 0, fmt.Errorf("no succeeded pod found of job %s", job.Name)
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+)
+
+func TestNewCopyJob(t *testing.T) {
+	backup := &polardbxv1.PolarDBXBackup{}
+	backup.Name = "copy"
+	backup.Namespace = "default"
+	backup.Spec.RetentionTime = metav1.Duration{Duration: 24 * time.Hour}
+	backup.Spec.StorageProvider.StorageName = polardbxv1.OSS
+	backup.Spec.StorageProvider.Sink = "default"
+	backup.Status.BackupRootPath = "polardbx-backup/pxc/copy"
+	source := &polardbxv1.PolarDBXBackup{}
+	source.Status.BackupRootPath = "polardbx-backup/pxc/source"
+
+	pod := &corev1.Pod{}
+	pod.Name = "pxc-dn-0-cand-1"
+	pod.Spec.NodeName = "node-1"
+	pod.Spec.Containers = []corev1.Container{
+		{Name: "prober"},
+		{
+			Name:  "engine",
+			Ports: []corev1.ContainerPort{{Name: "mysql", ContainerPort: 3306}},
+			Env:   []corev1.EnvVar{{Name: "NODE_NAME", ValueFrom: &corev1.EnvVarSource{}}},
+		},
+	}
+
+	job := newCopyJob(backup, source, pod, "copy-job-copy-abcd")
+	if job.Labels[meta.CopyJobLabelBackupName] != backup.Name {
+		t.Fatalf("unexpected labels: %v", job.Labels)
+	}
+	containers := job.Spec.Template.Spec.Containers
+	if len(containers) != 1 || containers[0].Name != "copyjob" || len(containers[0].Ports) != 0 {
+		t.Fatalf("unexpected containers: %v", containers)
+	}
+	if containers[0].Env[0].Value != "node-1" || containers[0].Env[0].ValueFrom != nil {
+		t.Fatalf("system envs not replaced: %v", containers[0].Env)
+	}
+	cmd := strings.Join(containers[0].Command, " ")
+	for _, arg := range []string{
+		"-s polardbx-backup/pxc/source", "-d polardbx-backup/pxc/copy", "-r 24h0m0s",
+		"-o " + corev1.TerminationMessagePathDefault,
+	} {
+		if !strings.Contains(cmd, arg) {
+			t.Fatalf("expect %q in command: %s", arg, cmd)
+		}
+	}
+	if job.Spec.Template.Spec.RestartPolicy != corev1.RestartPolicyNever {
+		t.Fatalf("unexpected restart policy: %s", job.Spec.Template.Spec.RestartPolicy)
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/hpfs/remote"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	polardbxhelper "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+)
+
+// ImmutableDeleteRetryInterval is the interval to retry deleting the backup whose files are still
+// locked by the storage, e.g. the retention period of bucket is longer than required.
+const ImmutableDeleteRetryInterval = time.Hour
+
+func backupImmutableUntil(backup *polardbxv1.PolarDBXBackup) time.Time {
+	if backup.Status.EndTime == nil {
+		return time.Time{}
+	}
+	return polardbxhelper.ImmutableUntil(backup.Status.EndTime.Time, backup.Spec.ImmutableUntil,
+		backup.Spec.RetentionTime.Duration, backup.Spec.Retention)
+}
+
+// checkWormLock checks whether the WORM retention of the storage locks the files written since the start
+// of backup until the time. Each file is locked for the retention period since it's written.
+func checkWormLock(start, until time.Time, worm remote.WormRetention) (corev1.ConditionStatus, string, string) {
+	if !worm.Locked {
+		return corev1.ConditionFalse, "RetentionNotLocked", "WORM retention of the storage is not locked"
+	}
+	if lockedUntil := start.Add(worm.Period); lockedUntil.Before(until) {
+		return corev1.ConditionFalse, "RetentionPeriodInsufficient", fmt.Sprintf("files are locked until %s, before %s",
+			lockedUntil.UTC().Format(time.RFC3339), until.UTC().Format(time.RFC3339))
+	}
+	return corev1.ConditionTrue, "RetentionLocked", "files are locked until " + until.UTC().Format(time.RFC3339)
+}
+
+func setWormLockedCondition(backup *polardbxv1.PolarDBXBackup, status corev1.ConditionStatus, reason, message string) {
+	now := metav1.Now()
+	backup.Status.Conditions = polardbxhelper.SetBackupCondition(backup.Status.Conditions, polardbxv1xstore.Condition{
+		Type:               polardbxv1xstore.BackupWormLocked,
+		Status:             status,
+		LastProbeTime:      &now,
+		LastTransitionTime: now,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// VerifyBackupImmutability records until when the finished backup is required to be immutable, and
+// verifies that its files are locked by the storage until then with the retention credential. The
+// storage is never configured by the operator, since the WORM retention applies to the whole bucket
+// and can't be undone once locked. It's verified again once the time changes.
+var VerifyBackupImmutability = polardbxv1reconcile.NewStepBinder("VerifyBackupImmutability",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetPolarDBXBackup()
+		until := backupImmutableUntil(backup)
+		if until.IsZero() || backup.Status.StartTime == nil {
+			return flow.Pass()
+		}
+		if backup.Status.ImmutableUntil != nil && backup.Status.ImmutableUntil.Time.Equal(until) {
+			return flow.Pass()
+		}
+
+		worm, verified, err := polardbxhelper.GetBackupWormRetention(rc.Context(), rc.Client(), backup.Namespace,
+			backup.Spec.StorageProvider)
+		if err != nil {
+			return flow.Error(err, "Unable to get the WORM retention of the storage.")
+		}
+		if verified {
+			status, reason, message := checkWormLock(backup.Status.StartTime.Time, until, worm)
+			setWormLockedCondition(backup, status, reason, message)
+		} else {
+			setWormLockedCondition(backup, corev1.ConditionUnknown, "NotVerified",
+				"WORM retention is not verified without the retention credential")
+		}
+		backup.Status.ImmutableUntil = &metav1.Time{Time: until}
+		return flow.Continue("Backup immutability verified.", "until", until)
+	})
@@ -0,0 +1,43 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/alibaba/polardbx-operator/pkg/hpfs/remote"
+)
+
+func TestCheckWormLock(t *testing.T) {
+	start := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	until := start.Add(7 * 24 * time.Hour)
+
+	if status, reason, _ := checkWormLock(start, until, remote.WormRetention{Period: 30 * 24 * time.Hour}); status != corev1.ConditionFalse ||
+		reason != "RetentionNotLocked" {
+		t.Fatalf("expect not locked, got %s", reason)
+	}
+	if status, reason, _ := checkWormLock(start, until, remote.WormRetention{Locked: true, Period: 24 * time.Hour}); status != corev1.ConditionFalse ||
+		reason != "RetentionPeriodInsufficient" {
+		t.Fatalf("expect period insufficient, got %s", reason)
+	}
+	if status, reason, _ := checkWormLock(start, until, remote.WormRetention{Locked: true, Period: 7 * 24 * time.Hour}); status != corev1.ConditionTrue {
+		t.Fatalf("expect locked, got %s", reason)
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"encoding/json"
+	"path"
+
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	polardbxhelper "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+)
+
+// ExportBackupManifest exports the manifest of the finished backup to the storage with the retention
+// credential, so that the backup can be imported and restored when none of its objects exists. It's
+// exported once all the xstore backups are finished, and failures never block the other steps.
+var ExportBackupManifest = polardbxv1reconcile.NewStepBinder("ExportBackupManifest",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetPolarDBXBackup()
+		provider := backup.Spec.StorageProvider
+		if len(backup.Status.ManifestPath) > 0 || len(backup.Status.BackupRootPath) == 0 || provider.RetentionCredential == nil ||
+			(provider.StorageName != polardbxv1.OSS && provider.StorageName != polardbxv1.S3) {
+			return flow.Pass()
+		}
+
+		set, err := polardbxhelper.GetBackupSet(rc.Context(), rc.Client(), backup)
+		if err != nil {
+			flow.Logger().Error(err, "Unable to get backup set to export.")
+			return flow.Continue("Unable to get backup set, export the manifest later.")
+		}
+		if unfinished := set.Unfinished(); unfinished != nil {
+			return flow.Continue("Xstore backup not finished, export the manifest later.", "xstore-backup", unfinished.Name)
+		}
+
+		var key []byte
+		if backup.Spec.Encryption != nil {
+			secret, err := rc.GetSecret(backup.Spec.Encryption.SecretName)
+			if err != nil {
+				return flow.Error(err, "Unable to get secret of encryption key.", "secret", backup.Spec.Encryption.SecretName)
+			}
+			if key, err = polardbxhelper.BackupEncryptionKey(secret, backup.Spec.Encryption); err != nil {
+				return flow.Error(err, "Invalid encryption key.")
+			}
+		}
+		manifest, err := polardbxhelper.NewBackupManifest(set, key)
+		if err != nil {
+			return flow.Error(err, "Unable to build backup manifest.")
+		}
+		data, err := json.Marshal(manifest)
+		if err != nil {
+			return flow.Error(err, "Unable to marshal backup manifest.")
+		}
+
+		manifestPath := path.Join(backup.Status.BackupRootPath, polardbxhelper.BackupManifestName)
+		if _, err := polardbxhelper.UploadBackupFile(rc.Context(), rc.Client(), backup.Namespace, provider, manifestPath, data); err != nil {
+			// Export again in the next reconciliation if the storage is unavailable.
+			flow.Logger().Error(err, "Unable to upload backup manifest.", "path", manifestPath)
+			return flow.Continue("Unable to upload backup manifest.")
+		}
+		backup.Status.ManifestPath = manifestPath
+		return flow.Continue("Backup manifest exported.", "path", manifestPath)
+	})
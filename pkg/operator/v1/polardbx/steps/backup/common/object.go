@@ -23,8 +23,9 @@ import (
 	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
 	xstorev1 "github.com/alibaba/polardbx-operator/api/v1"
 	"github.com/alibaba/polardbx-operator/pkg/debug"
+	"github.com/alibaba/polardbx-operator/pkg/hpfs/remote"
 	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
-	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
+	polardbxhelper "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
 	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
 	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
 	backupbuilder "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/steps/backup/xstorejobbuilder"
@@ -38,6 +39,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"math"
 	"modernc.org/mathutil"
+	"path"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"strconv"
@@ -46,18 +48,50 @@ import (
 )
 
 type SeekCpJobContext struct {
-	RemoteCpPath string `json:"remoteCpPath,omitempty"`
-	TxEventsDir  string `json:"txEventsDir,omitempty"`
-	IndexesPath  string `json:"indexesPath,omitempty"`
-	DnNameList   string `json:"dnNameList,omitempty"`
-	StorageName  string `json:"storageName,omitempty"`
-	Sink         string `json:"sink,omitempty"`
+	RemoteCpPath    string           `json:"remoteCpPath,omitempty"`
+	TxEventsDir     string           `json:"txEventsDir,omitempty"`
+	IndexesPath     string           `json:"indexesPath,omitempty"`
+	DnNameList      string           `json:"dnNameList,omitempty"`
+	StorageName     string           `json:"storageName,omitempty"`
+	Sink            string           `json:"sink,omitempty"`
+	CollectSegments map[string]int32 `json:"collectSegments,omitempty"`
+}
+
+// backupTriggerSourceOf determines how the backup came to exist. The annotation declared by the creator
+// is preferred, then the owner of backup schedule, and it's considered manual otherwise.
+func backupTriggerSourceOf(backup *polardbxv1.PolarDBXBackup) polardbxv1.BackupTriggerSource {
+	switch source := polardbxv1.BackupTriggerSource(backup.Annotations[polardbxmeta.AnnotationBackupTrigger]); source {
+	case polardbxv1.BackupTriggerManual, polardbxv1.BackupTriggerScheduled, polardbxv1.BackupTriggerAPI:
+		return source
+	}
+	for _, ref := range backup.OwnerReferences {
+		if strings.HasSuffix(ref.Kind, "BackupSchedule") {
+			return polardbxv1.BackupTriggerScheduled
+		}
+	}
+	return polardbxv1.BackupTriggerManual
 }
 
 var UpdateBackupStartInfo = polardbxv1reconcile.NewStepBinder("UpdateBackupStartInfo",
 	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
 		backup := rc.MustGetPolarDBXBackup()
 
+		if err := polardbxhelper.ValidateBackupStorageClass(backup.Spec.StorageProvider.StorageName, backup.Spec.StorageClass); err != nil {
+			backup.Status.Phase = polardbxv1.BackupFailed
+			backup.Status.Reason = err.Error()
+			backup.Status.FailureReason = polardbxv1.BackupFailureStorageClass
+			return flow.Retry("Invalid storage class.", "storage-class", backup.Spec.StorageClass)
+		}
+		if err := polardbxhelper.ValidateBackupEngine(backup.Spec.Engine, backup.Spec.XStores); err != nil {
+			backup.Status.Phase = polardbxv1.BackupFailed
+			backup.Status.Reason = err.Error()
+			backup.Status.FailureReason = polardbxv1.BackupFailureVolumeSnapshot
+			return flow.Retry("Invalid backup engine.", "engine", backup.Spec.Engine)
+		}
+		if _, failed := failOnUnknownCheckpointCoordinator(backup); failed {
+			return flow.Retry("Invalid checkpoint coordinator.", "coordinator", backup.Spec.CheckpointCoordinator)
+		}
+
 		nowTime := metav1.Now()
 		backup.Status.StartTime = &nowTime
 		if backup.Labels == nil {
@@ -65,6 +99,8 @@ var UpdateBackupStartInfo = polardbxv1reconcile.NewStepBinder("UpdateBackupStart
 		}
 		backup.Labels[polardbxmeta.LabelName] = backup.Spec.Cluster.Name
 		backup.Status.BackupRootPath = util.BackupRootPath(backup)
+		backup.Status.TriggerSource = backupTriggerSourceOf(backup)
+		backup.Labels[polardbxmeta.LabelBackupTrigger] = string(backup.Status.TriggerSource)
 
 		// record topology of original pxc
 		pxc, err := rc.GetPolarDBX()
@@ -76,6 +112,7 @@ var UpdateBackupStartInfo = polardbxv1reconcile.NewStepBinder("UpdateBackupStart
 		if err := rc.UpdatePolarDBXBackup(); err != nil {
 			return flow.Error(err, "Unable to update PXC backup.")
 		}
+		backup.Status.StorageClass = backup.Spec.StorageClass
 
 		return flow.Continue("Update backup start info")
 	})
@@ -109,12 +146,19 @@ var CreateBackupJobsForXStore = polardbxv1reconcile.NewStepBinder("CreateBackups
 			return flow.Error(err, "Unable to list xstore List")
 		}
 
-		// For each DN and GMS not having a backup, create a backup.
-		for _, xstore := range xstoreList.Items {
-			if _, ok := backup.Status.Backups[xstore.Name]; ok {
-				continue
-			}
+		members, err := polardbxhelper.BackupGroupMembers(backup.Spec.XStores, xstoreList.Items)
+		if err != nil {
+			backup.Status.Phase = polardbxv1.BackupFailed
+			backup.Status.Reason = err.Error()
+			backup.Status.FailureReason = polardbxv1.BackupFailureInvalidGroup
+			return flow.Retry("Invalid backup group.", "xstores", backup.Spec.XStores)
+		}
 
+		// For each DN and GMS (in the group) not having a backup, create a backup, limited
+		// by the concurrent xstore backups. The rest are queued and created later.
+		toBackup, queued := xstoresToBackup(members, xstoreBackups.Items, backup.Spec.MaxConcurrentXStoreBackups)
+		backup.Status.QueuedXStores = queued
+		for _, xstore := range toBackup {
 			xstoreBackup, err := backupbuilder.NewXStoreBackup(rc.Scheme(), backup, &xstore)
 			if err != nil {
 				return flow.Error(err, "Unable to build new physical backup for xstore", "xstore", xstore.Name)
@@ -126,9 +170,26 @@ var CreateBackupJobsForXStore = polardbxv1reconcile.NewStepBinder("CreateBackups
 			backup.Status.XStores = append(backup.Status.XStores, xstoreBackup.Spec.XStore.Name)
 			backup.Status.Backups[xstore.Name] = xstoreBackup.Name
 		}
+		if len(queued) > 0 {
+			return flow.Continue("Create backups for dn and gms, some are queued", "queued", queued)
+		}
 		return flow.Continue("Create backups for dn and gms")
 	})
 
+// failOnXStoreBackupFailure transits the backup to failed phase with the failure reason of
+// the first failed xstore backup, if there is any.
+func failOnXStoreBackupFailure(backup *polardbxv1.PolarDBXBackup, xstoreBackups []polardbxv1.XStoreBackup) bool {
+	for _, xstoreBackup := range xstoreBackups {
+		if xstoreBackup.Status.Phase == polardbxv1.XStoreBackupFailed {
+			backup.Status.Phase = polardbxv1.BackupFailed
+			backup.Status.Reason = fmt.Sprintf("xstore backup %s failed: %s", xstoreBackup.Name, xstoreBackup.Status.Message)
+			backup.Status.FailureReason = xstoreBackup.Status.FailureReason
+			return true
+		}
+	}
+	return false
+}
+
 var WaitAllBackupJobsFinished = polardbxv1reconcile.NewStepBinder("WaitAllBackupJobsFinished",
 	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
 		backup := rc.MustGetPolarDBXBackup()
@@ -145,10 +206,18 @@ var WaitAllBackupJobsFinished = polardbxv1reconcile.NewStepBinder("WaitAllBackup
 		if len(xstoreBackups.Items) < len(backup.Status.Backups) {
 			flow.Logger().Info("Backup Failed", "expect-size:", len(backup.Status.Backups), "actual-size", len(xstoreBackups.Items))
 			backup.Status.Phase = polardbxv1.BackupFailed
-			backup.Status.Phase = "Backup broken detected"
-			return flow.Continue("Backup Failed")
+			backup.Status.Reason = "Backup broken detected"
+			backup.Status.FailureReason = polardbxv1.BackupFailureBroken
+			return flow.Retry("Backup Failed")
+		}
+		if failOnXStoreBackupFailure(backup, xstoreBackups.Items) {
+			return flow.Retry("Backup Failed", "failure-reason", backup.Status.FailureReason)
 		}
 
+		// Checkpoint must be captured after all full backups, so wait for the queued ones.
+		if len(backup.Status.QueuedXStores) > 0 {
+			return flow.Wait("XStore backups are queued!", "queued", backup.Status.QueuedXStores)
+		}
 		for _, xstoreBackup := range xstoreBackups.Items {
 			if xstoreBackup.Status.Phase != polardbxv1.XStoreBackupCollecting {
 				return flow.Wait("XStore backup is still collecting!", "xstore-name", xstoreBackup.Name)
@@ -159,6 +228,39 @@ var WaitAllBackupJobsFinished = polardbxv1reconcile.NewStepBinder("WaitAllBackup
 		return flow.Continue("Create backups for dn and gms")
 	})
 
+// WaitFailedArtifactRetention keeps the artifacts of failed backup for diagnosis until the
+// retention elapses, the failure time is recorded as the end time.
+var WaitFailedArtifactRetention = polardbxv1reconcile.NewStepBinder("WaitFailedArtifactRetention",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetPolarDBXBackup()
+		if backup.Status.EndTime == nil {
+			nowTime := metav1.Now()
+			backup.Status.EndTime = &nowTime
+		}
+		retention := backup.Spec.FailedArtifactRetention.Duration
+		if left := time.Until(backup.Status.EndTime.Add(retention)); left > 0 {
+			return flow.RetryAfter(left, "Keep artifacts of failed backup", "left", left)
+		}
+		return flow.Continue("Retention of failed artifacts elapsed.")
+	})
+
+// RemoveFailedBackupFiles removes the files partially uploaded by the failed backup with the
+// retention credential, if specified, once the retention of failed artifacts elapses. A failure
+// doesn't block the purge of the other artifacts, it's retried in the next reconcile.
+var RemoveFailedBackupFiles = polardbxv1reconcile.NewStepBinder("RemoveFailedBackupFiles",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetPolarDBXBackup()
+		if backup.Status.FailedFilesRemoved {
+			return flow.Pass()
+		}
+		if err := removeBackupFiles(rc, flow, backup); err != nil {
+			flow.Logger().Error(err, "Unable to remove files of failed backup.", "path", backup.Status.BackupRootPath)
+			return flow.Continue("Files of failed backup are kept, retry later.")
+		}
+		backup.Status.FailedFilesRemoved = backup.Spec.StorageProvider.RetentionCredential != nil
+		return flow.Continue("Files of failed backup removed.")
+	})
+
 var DeleteBackupJobsOnFailure = polardbxv1reconcile.NewStepBinder("DeleteBackupJobs",
 	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
 		backup := rc.MustGetPolarDBXBackup()
@@ -180,7 +282,8 @@ var DeleteBackupJobsOnFailure = polardbxv1reconcile.NewStepBinder("DeleteBackupJ
 				return flow.Error(err, "Unable to delete xstore backup", "xstore", xstoreBackup.Spec.XStore.Name, "physical-backup", xstoreBackup.Name)
 			}
 		}
-		if backup.Spec.CleanPolicy == polardbxv1.CleanPolicyOnFailure {
+		if backup.Spec.CleanPolicy == polardbxv1.CleanPolicyOnFailure ||
+			backup.Spec.CleanPolicy == polardbxv1.CleanPolicyDeleteOnFailure {
 			flow.Logger().Info("Delete the failed backup!")
 			if err := rc.Client().Delete(rc.Context(), backup); err != nil {
 				if apierrors.IsNotFound(err) {
@@ -346,6 +449,8 @@ var SendHeartBeat = polardbxv1reconcile.NewStepBinder("SendHeartBeat",
 		}
 
 		backup.Status.HeartBeatName = sname
+		now := metav1.Now()
+		checkpointOrderingStatusOf(backup).HeartbeatTime = &now
 		return flow.Continue("HeartBeat Send!")
 	})
 
@@ -437,6 +542,8 @@ var WaitHeartbeatSentToFollower = polardbxv1reconcile.NewStepBinder("WaitHeartbe
 		}
 	})
 
+// CollectBinlogEndIndex captures the binlog offsets of DNs as the end of their binlog backups, and
+// then the one of GMS according to the checkpoint ordering.
 var CollectBinlogEndIndex = polardbxv1reconcile.NewStepBinder("CollectBinlogEndIndex",
 	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
 		backupPodList, err := rc.GetXStoreBackupPods()
@@ -444,41 +551,48 @@ var CollectBinlogEndIndex = polardbxv1reconcile.NewStepBinder("CollectBinlogEndI
 		if err != nil {
 			return flow.Error(err, "Unable to get XStore list")
 		}
+		gmsPods := make([]corev1.Pod, 0, 1)
 		for _, backupPod := range backupPodList {
-			groupManager, xstore, err := rc.GetPolarDBXGroupManagerByXStorePod(backupPod)
-			if err != nil {
-				return flow.Error(err, "get DN DataSource Failed", "podName:", backupPod)
-			}
-			binlogOffset, err := groupManager.GetBinlogOffset()
-			if err != nil {
-				return flow.Error(err, "get binlogoffset Failed")
+			if backupPod.Labels[polardbxmeta.LabelRole] == polardbxmeta.RoleGMS {
+				gmsPods = append(gmsPods, backupPod)
+				continue
 			}
-			err = rc.Close()
-			if err != nil {
-				return flow.Error(err, "Close Database Failed")
+			if _, err := uploadBinlogEndOffset(rc, flow, pxcBackup, backupPod); err != nil {
+				return failToUploadBinlogEndOffset(flow, err, backupPod)
 			}
+		}
 
-			binlogOffset = fmt.Sprintf("%s\ntimestamp:%s", binlogOffset, time.Now().Format("2006-01-02 15:04:05"))
-			backupRootPath := pxcBackup.Status.BackupRootPath
-			remotePath := fmt.Sprintf("%s/%s/%s-end", backupRootPath, polardbxmeta.BinlogOffsetPath, xstore.Name)
-			command := command.NewCanonicalCommandBuilder().Collect().
-				UploadOffset(binlogOffset, remotePath, string(pxcBackup.Spec.StorageProvider.StorageName), pxcBackup.Spec.StorageProvider.Sink).Build()
-			stdout := &bytes.Buffer{}
-			stderr := &bytes.Buffer{}
-			err = rc.ExecuteCommandOn(&backupPod, "engine", command, control.ExecOptions{
-				Logger:  flow.Logger(),
-				Stdin:   nil,
-				Stdout:  stdout,
-				Stderr:  stderr,
-				Timeout: 1 * time.Minute,
-			})
-			if err != nil {
-				if ee, ok := xstorectrlerrors.ExitError(err); ok {
-					if ee.ExitStatus() != 0 { // TODO(dengli): whether need to retry here
-						return flow.Retry("Failed to upload binlog end index", "pod", backupPod.Name, "exit-status", ee.ExitStatus())
-					}
+		ordering := checkpointOrderingStatusOf(pxcBackup)
+		for _, backupPod := range gmsPods {
+			switch ordering.Ordering {
+			case polardbxv1.CheckpointGMSBeforeDNs:
+				// Captured before the heartbeat.
+				continue
+			case polardbxv1.CheckpointBarrier:
+				offset, err := getBinlogOffset(rc, backupPod)
+				if err != nil {
+					return flow.Error(err, "get binlogoffset Failed", "pod", backupPod.Name)
+				}
+				if offset == ordering.GMSOffset {
+					continue
+				}
+				// The metadata changes during the checkpoint, and the checkpoint is taken again.
+				ordering.BarrierRetries++
+				if ordering.BarrierRetries > polardbxv1.MaxCheckpointBarrierRetries {
+					pxcBackup.Status.Phase = polardbxv1.BackupFailed
+					pxcBackup.Status.FailureReason = polardbxv1.BackupFailureCheckpointBarrier
+					pxcBackup.Status.Reason = fmt.Sprintf("GMS offset keeps changing during checkpoint after %d retries",
+						polardbxv1.MaxCheckpointBarrierRetries)
+					return flow.Retry("Checkpoint barrier exceeded.", "pod", backupPod.Name)
 				}
-				return flow.Error(err, "Failed to upload binlog end index", "pod", backupPod.Name, "stdout", stdout.String(), "stderr", stderr.String())
+				return flow.Retry("GMS offset changed during checkpoint, retry.", "pod", backupPod.Name,
+					"before", ordering.GMSOffset, "after", offset, "retries", ordering.BarrierRetries)
+			default:
+				offset, err := uploadBinlogEndOffset(rc, flow, pxcBackup, backupPod)
+				if err != nil {
+					return failToUploadBinlogEndOffset(flow, err, backupPod)
+				}
+				recordGMSOffset(ordering, offset)
 			}
 		}
 		return flow.Continue("Collect Binlog End Offset!")
@@ -494,6 +608,9 @@ var WaitAllCollectBinlogJobFinished = polardbxv1reconcile.NewStepBinder("WaitAll
 		if err != nil {
 			return flow.Error(err, "Unable to get  xstoreList!")
 		}
+		if failOnXStoreBackupFailure(backup, xstoreBackupList.Items) {
+			return flow.Retry("Backup Failed", "failure-reason", backup.Status.FailureReason)
+		}
 		for _, xstoreBackup := range xstoreBackupList.Items {
 			if xstoreBackup.Status.Phase != xstorev1.XStoreBinlogBackuping {
 				return flow.Wait("xstorebackup is still collecting binlog", "xstoreBackupName", xstoreBackup.Name)
@@ -503,107 +620,6 @@ var WaitAllCollectBinlogJobFinished = polardbxv1reconcile.NewStepBinder("WaitAll
 
 	})
 
-var PrepareSeekCpJobContext = polardbxv1reconcile.NewStepBinder("PrepareSeekCpJobContext",
-	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
-		const seekcpJobKey = "seekcp"
-		exists, err := rc.IsTaskContextExists(seekcpJobKey)
-		if err != nil {
-			return flow.Error(err, "Unable to determine job context for restore!")
-		}
-		if exists {
-			return flow.Pass()
-		}
-		polardbxBackup := rc.MustGetPolarDBXBackup()
-
-		backupRootPath := polardbxBackup.Status.BackupRootPath
-		remoteCpPath := fmt.Sprintf("%s/%s/%s",
-			backupRootPath, polardbxmeta.BinlogOffsetPath, polardbxmeta.SeekCpName)
-		txEventsDir := fmt.Sprintf("%s/%s", backupRootPath, polardbxmeta.CollectBinlogPath)
-		indexesPath := fmt.Sprintf("%s/%s", backupRootPath, polardbxmeta.BinlogIndexesName)
-
-		if err := rc.SaveTaskContext(seekcpJobKey, &SeekCpJobContext{
-			RemoteCpPath: remoteCpPath,
-			TxEventsDir:  txEventsDir,
-			IndexesPath:  indexesPath,
-			DnNameList:   strings.Join(polardbxBackup.Status.XStores, ","),
-			StorageName:  string(polardbxBackup.Spec.StorageProvider.StorageName),
-			Sink:         polardbxBackup.Spec.StorageProvider.Sink,
-		}); err != nil {
-			return flow.Error(err, "Unable to save job context for seekcp!")
-		}
-		return flow.Continue("Job context for seekcp prepared!")
-	})
-
-var CreateSeekCpJob = polardbxv1reconcile.NewStepBinder("CreateSeekCpJob",
-	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
-		const seekcpJobKey = "seekcp"
-		seekCpJobContext := SeekCpJobContext{}
-		err := rc.GetTaskContext(seekcpJobKey, &seekCpJobContext)
-		if err != nil {
-			return flow.Error(err, "Unable to get task context for seekcp")
-		}
-
-		polardbxBackup := rc.MustGetPolarDBXBackup()
-
-		job, err := rc.GetSeekCpJob()
-		if client.IgnoreNotFound(err) != nil {
-			return flow.Error(err, "Unable to get SeekCp job!")
-		}
-		if job != nil {
-			return flow.Continue("SeekCp job already started!", "job-name", job.Name)
-		}
-
-		jobName := GenerateJobName(polardbxBackup, "seekcp")
-		xstoreBackupList, err := rc.GetXStoreBackups()
-		if err != nil {
-			return flow.Error(err, "Unable to get XStoreBackupList!")
-		}
-		var targetPod corev1.Pod
-		for _, xstoreBackup := range xstoreBackupList.Items {
-			if len(xstoreBackup.Status.TargetPod) > 0 {
-				targetPodName := types.NamespacedName{Namespace: rc.Namespace(), Name: xstoreBackup.Status.TargetPod}
-				err := rc.Client().Get(rc.Context(), targetPodName, &targetPod)
-				if targetPod.Labels[polardbxmeta.LabelRole] == polardbxmeta.RoleGMS {
-					// do not pick gms to avoid download issue of heartbeat
-					continue
-				}
-				if err == nil {
-					break
-				}
-			}
-		}
-		if len(targetPod.Name) == 0 {
-			return flow.Error(err, "Unable to get targetPod!")
-		}
-		job, err = newSeekCpJob(polardbxBackup, &targetPod, jobName)
-		if err != nil {
-			return flow.Error(err, "Unable to create SeekCpJob")
-		}
-		if err = rc.SetControllerRefAndCreateToBackup(job); err != nil {
-			return flow.Error(err, "Unable to create job to initialize data")
-		}
-		return flow.Continue("Create SeekCp Job!", "targetPod", targetPod.Name)
-	})
-
-var WaitUntilSeekCpJobFinished = polardbxv1reconcile.NewStepBinder("WaitUtilSeekCpJobFinished",
-	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
-		job, err := rc.GetSeekCpJob()
-		if client.IgnoreNotFound(err) != nil {
-			return flow.Error(err, "Unable to get Seekcp binlog job!")
-		}
-		if job == nil {
-			return flow.Continue("Seekcp binlog job removed!")
-		}
-
-		if !k8shelper.IsJobCompleted(job) {
-			return flow.Wait("Seekcp binlog is still running!", "job-name", job.Name)
-		}
-
-		flow.Logger().Info("Seekcp binlog job completed!", "job-name", job.Name)
-
-		return flow.Continue("SeekCp Job Finished!")
-	})
-
 var WaitAllBinlogJobFinished = polardbxv1reconcile.NewStepBinder("WaitAllBinlogJobFinished",
 	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
 		backup := rc.MustGetPolarDBXBackup()
@@ -615,6 +631,10 @@ var WaitAllBinlogJobFinished = polardbxv1reconcile.NewStepBinder("WaitAllBinlogJ
 			return flow.Error(err, "Unable to get  xstoreList!")
 		}
 
+		if failOnXStoreBackupFailure(backup, xstoreBackupList.Items) {
+			return flow.Retry("Backup Failed", "failure-reason", backup.Status.FailureReason)
+		}
+
 		for _, xstoreBackup := range xstoreBackupList.Items {
 			if xstoreBackup.Status.Phase != xstorev1.XStoreBinlogWaiting {
 				flow.Wait("xstorebackup is still backup binlog", "xstoreBackupName", xstoreBackup.Name)
@@ -639,54 +659,165 @@ var WaitAllBinlogJobFinished = polardbxv1reconcile.NewStepBinder("WaitAllBinlogJ
 		return flow.Continue("All xstorebackups have backup binlog")
 	})
 
-var RemoveSeekCpJob = polardbxv1reconcile.NewStepBinder("RemoveSeekCpJob",
+// removeBackupFiles deletes all the files of the backup with the retention credential, if specified.
+func removeBackupFiles(rc *polardbxv1reconcile.Context, flow control.Flow, backup *polardbxv1.PolarDBXBackup) error {
+	if backup.Spec.StorageProvider.RetentionCredential == nil || len(backup.Status.BackupRootPath) == 0 {
+		return nil
+	}
+	deleted, err := polardbxhelper.RemoveBackupFiles(rc.Context(), rc.Client(), backup.Namespace,
+		backup.Spec.StorageProvider, backup.Status.BackupRootPath+"/")
+	if err != nil {
+		return err
+	}
+	flow.Logger().Info("Backup files deleted with retention credential.", "path", backup.Status.BackupRootPath, "deleted", deleted)
+	return nil
+}
+
+var RemoveBackupOverRetention = polardbxv1reconcile.NewStepBinder("RemoveBackupOverRetention",
 	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
-		job, err := rc.GetSeekCpJob()
-		if client.IgnoreNotFound(err) != nil {
-			return flow.Error(err, "Unable to get seekcp job!")
+		backup := rc.MustGetPolarDBXBackup()
+		toCleanTime := polardbxhelper.RetentionDeleteTime(backup.Status.EndTime.Time,
+			backup.Spec.RetentionTime.Duration, backup.Spec.Retention)
+		now := time.Now()
+		if now.Before(toCleanTime) {
+			waitDuration := toCleanTime.Sub(now)
+			// Requeue no later than the next probe so that the restorable condition is kept fresh.
+			if waitDuration > RestorableProbeInterval {
+				waitDuration = RestorableProbeInterval
+			}
+			return flow.RetryAfter(waitDuration, "Not to delete backup now!")
 		}
-		if job == nil {
-			return flow.Continue("SeekCp already job removed!")
+		if until := backupImmutableUntil(backup); now.Before(until) {
+			waitDuration := until.Sub(now)
+			if waitDuration > RestorableProbeInterval {
+				waitDuration = RestorableProbeInterval
+			}
+			return flow.RetryAfter(waitDuration, "Backup is immutable, not to delete now!", "until", until)
 		}
-
-		err = rc.Client().Delete(rc.Context(), job, client.PropagationPolicy(metav1.DeletePropagationBackground))
-		if client.IgnoreNotFound(err) != nil {
-			return flow.Error(err, "Unable to remove seekcp job", "job-name", job.Name)
+		restore, err := polardbxhelper.ActiveRestoreOfPolarDBXBackup(rc.Context(), rc.Client(), backup)
+		if err != nil {
+			return flow.Error(err, "Unable to determine restores of the backup!")
+		}
+		if len(restore) > 0 {
+			return flow.RetryAfter(time.Minute, "Backup is referenced by an active restore, not to delete now!", "restore", restore)
+		}
+		dependent, err := polardbxhelper.IncrementalDependentOfPolarDBXBackup(rc.Context(), rc.Client(), backup)
+		if err != nil {
+			return flow.Error(err, "Unable to determine incremental backups based on the backup!")
+		}
+		if len(dependent) > 0 {
+			return flow.RetryAfter(time.Hour, "Backup is the base of incremental backups, not to delete now!", "dependent", dependent)
 		}
 
-		return flow.Continue("SeekCp job removed!", "job-name", job.Name)
+		flow.Logger().Info("Ready to delete the backup!")
+		if err := removeBackupFiles(rc, flow, backup); remote.IsImmutable(err) {
+			setWormLockedCondition(backup, corev1.ConditionTrue, "LockedByStorage",
+				"files are still locked by the storage, deletion is retried later")
+			return flow.RetryAfter(ImmutableDeleteRetryInterval, "Backup files are locked by the storage, not to delete now!")
+		} else if err != nil {
+			return flow.Error(err, "Unable to delete the backup files!")
+		}
+		if err := rc.Client().Delete(rc.Context(), backup); err != nil {
+			if apierrors.IsNotFound(err) {
+				flow.Logger().Info("Already deleted!")
+			} else {
+				return flow.Error(err, "Unable to delete the backup!")
+			}
+		}
+		return flow.Continue("PolarDBX backup deleted!", "PolarDBXBackup-name", backup.Name)
 	})
 
-var RemoveBackupOverRetention = polardbxv1reconcile.NewStepBinder("RemoveBackupOverRetention",
+// IndexRecoverableWindow records the earliest recoverable timestamp of the finished backup, and labels
+// the recoverable window so that the backups covering a time can be found without reading the files.
+var IndexRecoverableWindow = polardbxv1reconcile.NewStepBinder("IndexRecoverableWindow",
 	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
 		backup := rc.MustGetPolarDBXBackup()
-		if backup.Spec.RetentionTime.Duration.Seconds() > 0 {
-			toCleanTime := backup.Status.EndTime.Add(backup.Spec.RetentionTime.Duration)
-			now := time.Now()
-			if now.After(toCleanTime) {
-				flow.Logger().Info("Ready to delete the backup!")
-				if err := rc.Client().Delete(rc.Context(), backup); err != nil {
-					if apierrors.IsNotFound(err) {
-						flow.Logger().Info("Already deleted!")
-					} else {
-						return flow.Error(err, "Unable to delete the backup!")
-					}
-				}
-			} else {
-				waitDuration := toCleanTime.Sub(now)
-				return flow.RetryAfter(waitDuration, "Not to delete backup now!")
-			}
-		} else {
-			flow.Logger().Info("Ready to delete the backup!")
-			if err := rc.Client().Delete(rc.Context(), backup); err != nil {
-				if apierrors.IsNotFound(err) {
-					flow.Logger().Info("Already deleted!")
-				} else {
-					return flow.Error(err, "Unable to delete the backup!")
-				}
+		earliest := backup.Status.EarliestRecoverableTimestamp
+		if earliest == nil {
+			earliest = backup.Status.StartTime.DeepCopy()
+		}
+		if polardbxhelper.SetRecoverableWindowLabels(backup, earliest, backup.Status.LatestRecoverableTimestamp) {
+			// The status is overwritten by the one on server, it's set after the update.
+			if err := rc.UpdatePolarDBXBackup(); err != nil {
+				return flow.Error(err, "Unable to update labels of backup.")
 			}
 		}
-		return flow.Continue("PolarDBX backup deleted!", "PolarDBXBackup-name", backup.Name)
+		if backup.Status.EarliestRecoverableTimestamp == nil {
+			backup.Status.EarliestRecoverableTimestamp = earliest
+			return flow.Continue("Recoverable window indexed!", "backup", backup.Name)
+		}
+		return flow.Pass()
+	})
+
+// ShareBackupObject generates a pre-signed url of the object specified in spec and records it in
+// status. The url is regenerated only if the object or the expiry changes. A failure is recorded in
+// status rather than blocking the later steps, e.g. the retention, and changing the object or the
+// expiry retries.
+var ShareBackupObject = polardbxv1reconcile.NewStepBinder("ShareBackupObject",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetPolarDBXBackup()
+		share := backup.Spec.Share
+		if share == nil || len(share.Object) == 0 {
+			return flow.Pass()
+		}
+		expiry := share.Expiry
+		if expiry.Duration <= 0 {
+			expiry = metav1.Duration{Duration: time.Hour}
+		}
+		if backup.Status.Share != nil && backup.Status.Share.Object == share.Object &&
+			backup.Status.Share.Expiry == expiry {
+			return flow.Pass()
+		}
+
+		shareStatus := &polardbxv1.BackupObjectShareStatus{
+			Object: share.Object,
+			Expiry: expiry,
+		}
+		backup.Status.Share = shareStatus
+
+		// The grant is scoped to a single object inside the backup set.
+		object := path.Clean(share.Object)
+		if path.IsAbs(object) || object == "." || strings.HasPrefix(object, "..") {
+			shareStatus.Message = "invalid object, must be a relative path inside the backup set"
+			return flow.Continue("Invalid object to share.", "object", share.Object)
+		}
+		if backup.Spec.StorageProvider.StorageName != polardbxv1.OSS {
+			shareStatus.Message = "pre-signed url is not supported by storage " + string(backup.Spec.StorageProvider.StorageName)
+			return flow.Continue("Storage not supported to share object.", "storage", backup.Spec.StorageProvider.StorageName)
+		}
+
+		backupPodList, err := rc.GetXStoreBackupPods()
+		if err != nil {
+			shareStatus.Message = "unable to get backup pods: " + err.Error()
+			return flow.Continue("Unable to get backup pods to sign url.", "error", err.Error())
+		}
+		if len(backupPodList) == 0 {
+			shareStatus.Message = "no backup pod found to sign url"
+			return flow.Continue("No backup pod found to sign url.")
+		}
+		backupPod := backupPodList[0]
+
+		remotePath := fmt.Sprintf("%s/%s", backup.Status.BackupRootPath, object)
+		command := command.NewCanonicalCommandBuilder().Collect().
+			SignUrl(remotePath, expiry.Duration.String(), string(backup.Spec.StorageProvider.StorageName), backup.Spec.StorageProvider.Sink).Build()
+		stdout := &bytes.Buffer{}
+		stderr := &bytes.Buffer{}
+		signTime := metav1.Now()
+		err = rc.ExecuteCommandOn(&backupPod, "engine", command, control.ExecOptions{
+			Logger:  flow.Logger(),
+			Stdout:  stdout,
+			Stderr:  stderr,
+			Timeout: 1 * time.Minute,
+		})
+		if err != nil {
+			shareStatus.Message = "failed to sign url: " + err.Error()
+			return flow.Continue("Failed to sign url.", "pod", backupPod.Name, "error", err.Error(),
+				"stderr", stderr.String())
+		}
+
+		shareStatus.URL = strings.TrimSpace(stdout.String())
+		shareStatus.ExpireTime = &metav1.Time{Time: signTime.Add(expiry.Duration)}
+		return flow.Continue("Backup object shared!", "object", object, "expire-time", shareStatus.ExpireTime)
 	})
 
 var SavePXCSecrets = polardbxv1reconcile.NewStepBinder("SavePXCSecrets",
@@ -0,0 +1,137 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/command"
+	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
+	xstorectrlerrors "github.com/alibaba/polardbx-operator/pkg/util/error"
+)
+
+// checkpointOrderingOf returns the checkpoint ordering of the backup, GMSAfterDNs if not specified.
+func checkpointOrderingOf(backup *polardbxv1.PolarDBXBackup) polardbxv1.BackupCheckpointOrdering {
+	if len(backup.Spec.CheckpointOrdering) == 0 {
+		return polardbxv1.CheckpointGMSAfterDNs
+	}
+	return backup.Spec.CheckpointOrdering
+}
+
+// checkpointOrderingStatusOf returns the checkpoint ordering status of the backup, which is
+// initialized if not found.
+func checkpointOrderingStatusOf(backup *polardbxv1.PolarDBXBackup) *polardbxv1.BackupCheckpointOrderingStatus {
+	if backup.Status.CheckpointOrdering == nil {
+		backup.Status.CheckpointOrdering = &polardbxv1.BackupCheckpointOrderingStatus{
+			Ordering: checkpointOrderingOf(backup),
+		}
+	}
+	return backup.Status.CheckpointOrdering
+}
+
+func recordGMSOffset(status *polardbxv1.BackupCheckpointOrderingStatus, offset string) {
+	now := metav1.Now()
+	status.GMSOffset = offset
+	status.GMSCaptureTime = &now
+}
+
+func getBinlogOffset(rc *polardbxv1reconcile.Context, backupPod corev1.Pod) (string, error) {
+	groupManager, _, err := rc.GetPolarDBXGroupManagerByXStorePod(backupPod)
+	if err != nil {
+		return "", err
+	}
+	if groupManager == nil {
+		return "", fmt.Errorf("service of pod %s not found", backupPod.Name)
+	}
+	defer rc.Close()
+	return groupManager.GetBinlogOffset()
+}
+
+// uploadBinlogEndOffset captures the binlog offset of the backup pod and uploads it along with the
+// capture time as the end of its binlog backup. It returns the offset captured.
+func uploadBinlogEndOffset(rc *polardbxv1reconcile.Context, flow control.Flow, backup *polardbxv1.PolarDBXBackup, backupPod corev1.Pod) (string, error) {
+	offset, err := getBinlogOffset(rc, backupPod)
+	if err != nil {
+		return "", err
+	}
+
+	content := fmt.Sprintf("%s\ntimestamp:%s", offset, time.Now().Format("2006-01-02 15:04:05"))
+	remotePath := fmt.Sprintf("%s/%s/%s-end", backup.Status.BackupRootPath, polardbxmeta.BinlogOffsetPath,
+		backupPod.Labels[xstoremeta.LabelName])
+	cmd := command.NewCanonicalCommandBuilder().Collect().
+		UploadOffset(content, remotePath, string(backup.Spec.StorageProvider.StorageName), backup.Spec.StorageProvider.Sink).Build()
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	err = rc.ExecuteCommandOn(&backupPod, "engine", cmd, control.ExecOptions{
+		Logger:  flow.Logger(),
+		Stdout:  stdout,
+		Stderr:  stderr,
+		Timeout: 1 * time.Minute,
+	})
+	if err != nil {
+		flow.Logger().Info("Failed to upload binlog end index", "pod", backupPod.Name,
+			"stdout", stdout.String(), "stderr", strings.TrimSpace(stderr.String()))
+		return "", err
+	}
+	return offset, nil
+}
+
+func failToUploadBinlogEndOffset(flow control.Flow, err error, backupPod corev1.Pod) (reconcile.Result, error) {
+	if ee, ok := xstorectrlerrors.ExitError(err); ok && ee.ExitStatus() != 0 {
+		return flow.Retry("Failed to upload binlog end index", "pod", backupPod.Name, "exit-status", ee.ExitStatus())
+	}
+	return flow.Error(err, "Failed to upload binlog end index", "pod", backupPod.Name)
+}
+
+// CollectGMSBinlogEndIndex captures the binlog offset of GMS as the end of its binlog backup before
+// the heartbeat, only if GMS is ordered before DNs or barriered.
+var CollectGMSBinlogEndIndex = polardbxv1reconcile.NewStepBinder("CollectGMSBinlogEndIndex",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetPolarDBXBackup()
+		ordering := checkpointOrderingStatusOf(backup)
+		if ordering.Ordering != polardbxv1.CheckpointGMSBeforeDNs && ordering.Ordering != polardbxv1.CheckpointBarrier {
+			return flow.Pass()
+		}
+
+		backupPodList, err := rc.GetXStoreBackupPods()
+		if err != nil {
+			return flow.Error(err, "Unable to get backup pods")
+		}
+		for _, backupPod := range backupPodList {
+			if backupPod.Labels[polardbxmeta.LabelRole] != polardbxmeta.RoleGMS {
+				continue
+			}
+			offset, err := uploadBinlogEndOffset(rc, flow, backup, backupPod)
+			if err != nil {
+				return failToUploadBinlogEndOffset(flow, err, backupPod)
+			}
+			recordGMSOffset(ordering, offset)
+			return flow.Continue("Collect GMS Binlog End Offset!", "pod", backupPod.Name, "offset", offset)
+		}
+		return flow.Continue("No GMS backup pod, skip.")
+	})
@@ -0,0 +1,287 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
+	polardbxhelper "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+)
+
+type RestorePreviewXStore struct {
+	Name              string `json:"name,omitempty"`
+	FullBackupPath    string `json:"fullBackupPath,omitempty"`
+	BinlogBackupDir   string `json:"binlogBackupDir,omitempty"`
+	ChunkManifestPath string `json:"chunkManifestPath,omitempty"`
+	// Encrypted skips verifying the checksums of the full backup, since the key isn't available to the preview
+	Encrypted bool `json:"encrypted,omitempty"`
+}
+
+type RestorePreviewContext struct {
+	StorageName     string                 `json:"storageName,omitempty"`
+	Sink            string                 `json:"sink,omitempty"`
+	VerifyChecksums bool                   `json:"verifyChecksums,omitempty"`
+	XStores         []RestorePreviewXStore `json:"xstores,omitempty"`
+	// ConsistencyWaitTimeout and ConsistencyWaitInterval are in seconds
+	ConsistencyWaitTimeout  float64 `json:"consistencyWaitTimeout,omitempty"`
+	ConsistencyWaitInterval float64 `json:"consistencyWaitInterval,omitempty"`
+}
+
+type restorePreviewOutput struct {
+	Checks []polardbxv1.BackupRestorePreviewCheck `json:"checks,omitempty"`
+}
+
+// checkRestorePreviewTime checks that the restore time is covered by the backup, i.e. not before the
+// backup starts and not after the latest recoverable timestamp.
+func checkRestorePreviewTime(backup *polardbxv1.PolarDBXBackup, restoreTime time.Time) polardbxv1.BackupRestorePreviewCheck {
+	check := polardbxv1.BackupRestorePreviewCheck{Name: polardbxv1.RestorePreviewCheckRestoreTime}
+	latest := backup.Status.LatestRecoverableTimestamp
+	switch {
+	case latest == nil:
+		check.Message = "latest recoverable timestamp not recorded"
+	case backup.Status.StartTime != nil && restoreTime.Before(backup.Status.StartTime.Time):
+		check.Message = fmt.Sprintf("restore time %s is before the start of backup %s",
+			restoreTime.Format(time.RFC3339), backup.Status.StartTime.Format(time.RFC3339))
+	case restoreTime.After(latest.Time):
+		check.Message = fmt.Sprintf("restore time %s is after the latest recoverable timestamp %s, changes after it are lost",
+			restoreTime.Format(time.RFC3339), latest.Format(time.RFC3339))
+	default:
+		check.Passed = true
+		check.Message = "latest recoverable timestamp: " + latest.Format(time.RFC3339)
+	}
+	return check
+}
+
+func restorePreviewResultOf(checks []polardbxv1.BackupRestorePreviewCheck) polardbxv1.BackupRestorePreviewResult {
+	for _, c := range checks {
+		if !c.Passed {
+			return polardbxv1.RestorePreviewFailed
+		}
+	}
+	return polardbxv1.RestorePreviewPassed
+}
+
+func parseRestorePreviewTime(backup *polardbxv1.PolarDBXBackup) (time.Time, error) {
+	preview := backup.Spec.RestorePreview
+	if len(preview.Time) == 0 {
+		if backup.Status.LatestRecoverableTimestamp == nil {
+			return time.Time{}, errors.New("latest recoverable timestamp not recorded")
+		}
+		return backup.Status.LatestRecoverableTimestamp.Time, nil
+	}
+	return polardbxhelper.ParseRestoreTime(preview.Time, preview.TimeZone)
+}
+
+func restorePreviewTimeout(backup *polardbxv1.PolarDBXBackup) time.Duration {
+	if timeout := backup.Spec.RestorePreview.Timeout.Duration; timeout > 0 {
+		return timeout
+	}
+	return time.Hour
+}
+
+// startRestorePreview runs the checks which can be done by operator, and starts a job checking the
+// objects of the xstore backups.
+func startRestorePreview(rc *polardbxv1reconcile.Context, backup *polardbxv1.PolarDBXBackup, status *polardbxv1.BackupRestorePreviewStatus) error {
+	preview := backup.Spec.RestorePreview
+	restoreTime, err := parseRestorePreviewTime(backup)
+	if err != nil {
+		status.Checks = append(status.Checks, polardbxv1.BackupRestorePreviewCheck{
+			Name:    polardbxv1.RestorePreviewCheckRestoreTime,
+			Message: "invalid restore time: " + err.Error(),
+		})
+	} else {
+		status.Time = &metav1.Time{Time: restoreTime}
+		status.Checks = append(status.Checks, checkRestorePreviewTime(backup, restoreTime))
+	}
+
+	xstoreBackups, err := rc.GetXStoreBackups()
+	if err != nil {
+		return err
+	}
+	previewContext := &RestorePreviewContext{
+		StorageName:     string(backup.Spec.StorageProvider.StorageName),
+		Sink:            backup.Spec.StorageProvider.Sink,
+		VerifyChecksums: preview.VerifyChecksums,
+	}
+	if wait := backup.Spec.ConsistencyWait; wait != nil && wait.Timeout.Duration > 0 {
+		previewContext.ConsistencyWaitTimeout = wait.Timeout.Duration.Seconds()
+		previewContext.ConsistencyWaitInterval = wait.Interval.Duration.Seconds()
+	}
+	// The backup pods are only templates of the job, which has access to the storage.
+	var templatePod *corev1.Pod
+	for _, xstoreBackup := range xstoreBackups.Items {
+		xstoreName := xstoreBackup.Spec.XStore.Name
+		targetVersion := preview.EngineVersion
+		if len(targetVersion) == 0 {
+			xstore := &polardbxv1.XStore{}
+			err := rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: backup.Namespace, Name: xstoreName}, xstore)
+			if err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+			targetVersion = xstore.Status.EngineVersion
+		}
+		passed, message := polardbxhelper.CheckRestoreEngineVersion(xstoreBackup.Status.EngineVersion, targetVersion)
+		status.Checks = append(status.Checks, polardbxv1.BackupRestorePreviewCheck{
+			Name:    polardbxv1.RestorePreviewCheckEngineVersion,
+			XStore:  xstoreName,
+			Passed:  passed,
+			Message: message,
+		})
+
+		rootPath := xstoreBackup.Status.BackupRootPath
+		x := RestorePreviewXStore{
+			Name:            xstoreName,
+			FullBackupPath:  fmt.Sprintf("%s/%s/%s.xbstream", rootPath, polardbxmeta.FullBackupPath, xstoreName),
+			BinlogBackupDir: fmt.Sprintf("%s/%s/%s", rootPath, polardbxmeta.BinlogBackupPath, xstoreName),
+			Encrypted:       xstoreBackup.Spec.Encryption != nil,
+		}
+		if xstoreBackup.Spec.EnableDedupReport {
+			x.ChunkManifestPath = fmt.Sprintf("%s/%s/%s.chunks", rootPath, polardbxmeta.FullBackupPath, xstoreName)
+		}
+		previewContext.XStores = append(previewContext.XStores, x)
+
+		if templatePod == nil && len(xstoreBackup.Status.TargetPod) > 0 {
+			pod := &corev1.Pod{}
+			err := rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: backup.Namespace, Name: xstoreBackup.Status.TargetPod}, pod)
+			if err == nil {
+				templatePod = pod
+			} else if !apierrors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+	if templatePod == nil {
+		return errors.New("no backup pod found as template of the preview job")
+	}
+
+	contextJson, err := json.Marshal(previewContext)
+	if err != nil {
+		return err
+	}
+	job := newPreviewJob(backup, templatePod, string(contextJson),
+		int64(restorePreviewTimeout(backup).Seconds()), GenerateJobName(backup, "preview"))
+	if err := rc.SetControllerRefAndCreateToBackup(job); err != nil {
+		return fmt.Errorf("failed to create restore preview job: %w", err)
+	}
+	status.Job = job.Name
+	return nil
+}
+
+// removeRestorePreviewJob removes the preview job, it's fine if the job is already gone.
+func removeRestorePreviewJob(rc *polardbxv1reconcile.Context, namespace, name string) error {
+	if len(name) == 0 {
+		return nil
+	}
+	job := &batchv1.Job{}
+	job.Namespace = namespace
+	job.Name = name
+	err := rc.Client().Delete(rc.Context(), job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+	return client.IgnoreNotFound(err)
+}
+
+func finishRestorePreview(status *polardbxv1.BackupRestorePreviewStatus, checks ...polardbxv1.BackupRestorePreviewCheck) {
+	status.Checks = append(status.Checks, checks...)
+	status.Result = restorePreviewResultOf(status.Checks)
+	now := metav1.Now()
+	status.FinishTime = &now
+}
+
+// PreviewRestore validates that the backup is restorable as defined in spec without restoring it, and
+// records the result in status. It runs once per generation of the backup.
+var PreviewRestore = polardbxv1reconcile.NewStepBinder("PreviewRestore",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetPolarDBXBackup()
+		preview := backup.Spec.RestorePreview
+		if preview == nil {
+			return flow.Pass()
+		}
+		status := backup.Status.RestorePreview
+		if status != nil && status.ObservedGeneration == backup.Generation && status.Result != polardbxv1.RestorePreviewRunning {
+			return flow.Pass()
+		}
+
+		if status == nil || status.ObservedGeneration != backup.Generation {
+			// The job of the previous generation is outdated.
+			if status != nil {
+				if err := removeRestorePreviewJob(rc, backup.Namespace, status.Job); err != nil {
+					return flow.Error(err, "Unable to remove outdated restore preview job.", "job", status.Job)
+				}
+			}
+			now := metav1.Now()
+			status = &polardbxv1.BackupRestorePreviewStatus{
+				ObservedGeneration: backup.Generation,
+				Result:             polardbxv1.RestorePreviewRunning,
+				StartTime:          &now,
+			}
+			backup.Status.RestorePreview = status
+			if err := startRestorePreview(rc, backup, status); err != nil {
+				finishRestorePreview(status, polardbxv1.BackupRestorePreviewCheck{
+					Name:    polardbxv1.RestorePreviewCheckObjects,
+					Message: err.Error(),
+				})
+				return flow.Continue("Unable to start restore preview.", "error", err.Error())
+			}
+			return flow.RetryAfter(10*time.Second, "Restore preview job created.", "job", status.Job)
+		}
+
+		job := &batchv1.Job{}
+		err := rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: backup.Namespace, Name: status.Job}, job)
+		if apierrors.IsNotFound(err) {
+			finishRestorePreview(status, polardbxv1.BackupRestorePreviewCheck{
+				Name:    polardbxv1.RestorePreviewCheckPreviewFinished,
+				Message: "job " + status.Job + " not found",
+			})
+			return flow.Continue("Restore preview job removed.", "job", status.Job)
+		} else if err != nil {
+			return flow.Error(err, "Unable to get restore preview job.", "job", status.Job)
+		}
+		if k8shelper.IsJobFailed(job) {
+			// The job is killed once the timeout is reached.
+			finishRestorePreview(status, polardbxv1.BackupRestorePreviewCheck{
+				Name:    polardbxv1.RestorePreviewCheckPreviewFinished,
+				Message: "job " + job.Name + " failed or timed out after " + restorePreviewTimeout(backup).String(),
+			})
+		} else if !k8shelper.IsJobCompleted(job) {
+			return flow.RetryAfter(10*time.Second, "Restore preview is still running.", "job", job.Name)
+		} else {
+			output, err := readRestorePreviewOutput(rc, job)
+			if err != nil {
+				return flow.Error(err, "Unable to read restore preview output.", "job", job.Name)
+			}
+			finishRestorePreview(status, output.Checks...)
+		}
+		if err := removeRestorePreviewJob(rc, job.Namespace, job.Name); err != nil {
+			return flow.Error(err, "Unable to remove restore preview job.", "job", job.Name)
+		}
+		return flow.Continue("Restore preview finished.", "result", status.Result)
+	})
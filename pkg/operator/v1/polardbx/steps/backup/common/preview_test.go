@@ -0,0 +1,80 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+)
+
+func TestCheckRestorePreviewTime(t *testing.T) {
+	start := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	backup := &polardbxv1.PolarDBXBackup{
+		Status: polardbxv1.PolarDBXBackupStatus{
+			StartTime:                  &metav1.Time{Time: start},
+			LatestRecoverableTimestamp: &metav1.Time{Time: start.Add(time.Hour)},
+		},
+	}
+	if c := checkRestorePreviewTime(backup, start.Add(-time.Minute)); c.Passed {
+		t.Fatalf("expect failed before the start of backup: %s", c.Message)
+	}
+	if c := checkRestorePreviewTime(backup, start.Add(time.Hour)); !c.Passed {
+		t.Fatalf("expect passed: %s", c.Message)
+	}
+	if c := checkRestorePreviewTime(backup, start.Add(2*time.Hour)); c.Passed {
+		t.Fatalf("expect failed after the latest recoverable timestamp: %s", c.Message)
+	}
+}
+
+func TestNewPreviewJob(t *testing.T) {
+	backup := &polardbxv1.PolarDBXBackup{}
+	backup.Name = "backup"
+	backup.Namespace = "default"
+
+	pod := &corev1.Pod{}
+	pod.Name = "pxc-dn-0-cand-1"
+	pod.Spec.Containers = []corev1.Container{
+		{Name: "prober"},
+		{Name: "engine", Ports: []corev1.ContainerPort{{Name: "mysql", ContainerPort: 3306}}},
+	}
+
+	job := newPreviewJob(backup, pod, `{"xstores":[]}`, 3600, "preview-job-backup-abcd")
+	if job.Labels[meta.PreviewJobLabelBackupName] != backup.Name {
+		t.Fatalf("unexpected labels: %v", job.Labels)
+	}
+	if job.Spec.ActiveDeadlineSeconds == nil || *job.Spec.ActiveDeadlineSeconds != 3600 {
+		t.Fatalf("unexpected active deadline: %v", job.Spec.ActiveDeadlineSeconds)
+	}
+	containers := job.Spec.Template.Spec.Containers
+	if len(containers) != 1 || containers[0].Name != "previewjob" || len(containers[0].Ports) != 0 {
+		t.Fatalf("unexpected containers: %v", containers)
+	}
+	cmd := strings.Join(containers[0].Command, " ")
+	if !strings.Contains(cmd, "-o "+corev1.TerminationMessagePathDefault) || strings.Contains(cmd, "--detach") {
+		t.Fatalf("unexpected command: %s", cmd)
+	}
+	if len(pod.Spec.Containers) != 2 || pod.Spec.Containers[1].Command != nil {
+		t.Fatalf("template pod modified: %v", pod.Spec.Containers)
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2021 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
+	polardbxhelper "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/command"
+)
+
+// newPreviewJob builds the job to check the objects of backup for restore preview, from the engine
+// container of the template pod which has access to the storage. Nothing runs on the template pod
+// itself. The checks are written to the termination log and the job is killed after the timeout.
+func newPreviewJob(backup *polardbxv1.PolarDBXBackup, templatePod *corev1.Pod, previewContext string,
+	timeoutSeconds int64, jobName string) *batchv1.Job {
+	podSpec := templatePod.Spec.DeepCopy()
+	podSpec.InitContainers = nil
+	podSpec.RestartPolicy = corev1.RestartPolicyNever
+	podSpec.HostNetwork = false
+
+	podSpec.Containers = []corev1.Container{
+		*k8shelper.GetContainerFromPodSpec(podSpec, "engine"),
+	}
+	podSpec.Containers[0].Name = "previewjob"
+	podSpec.Containers[0].Command = command.NewCanonicalCommandBuilder().Collect().
+		Preview(previewContext, corev1.TerminationMessagePathDefault).Build()
+	podSpec.Containers[0].TerminationMessagePath = corev1.TerminationMessagePathDefault
+	podSpec.Containers[0].Resources.Limits = nil
+	podSpec.Containers[0].Resources.Requests = nil
+	podSpec.Containers[0].Ports = nil
+	podSpec.Containers[0].StartupProbe = nil
+	podSpec.Containers[0].LivenessProbe = nil
+	podSpec.Containers[0].ReadinessProbe = nil
+
+	// Replace system envs
+	replaceSystemEnvs(podSpec, templatePod)
+
+	labels := map[string]string{
+		meta.PreviewJobLabelBackupName: backup.Name,
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: backup.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          pointer.Int32(0),
+			ActiveDeadlineSeconds: pointer.Int64(timeoutSeconds),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: *podSpec,
+			},
+		},
+	}
+	polardbxhelper.ApplyBackupJobTemplate(job, backup.Spec.JobTemplate)
+	return job
+}
+
+// readRestorePreviewOutput reads the checks of objects from the termination log of the pod of the
+// completed preview job.
+func readRestorePreviewOutput(rc *polardbxv1reconcile.Context, job *batchv1.Job) (*restorePreviewOutput, error) {
+	var podList corev1.PodList
+	err := rc.Client().List(rc.Context(), &podList, client.InNamespace(job.Namespace),
+		client.MatchingLabels{"job-name": job.Name})
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range podList.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			terminated := status.State.Terminated
+			if terminated == nil || terminated.ExitCode != 0 {
+				continue
+			}
+			output := &restorePreviewOutput{}
+			if err := json.Unmarshal([]byte(terminated.Message), output); err != nil {
+				return nil, err
+			}
+			return output, nil
+		}
+	}
+	return nil, fmt.Errorf("no succeeded pod found of job %s", job.Name)
+}
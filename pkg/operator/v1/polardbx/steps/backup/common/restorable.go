@@ -0,0 +1,246 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/hpfs/remote"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	polardbxhelper "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+)
+
+// RestorableProbeInterval is the cadence of probing the backup files for the restorable condition. The
+// metadata and dependencies are checked in every reconciliation, e.g. once an xstore backup is deleted.
+const RestorableProbeInterval = 6 * time.Hour
+
+// Reasons of the restorable condition.
+const (
+	restorableReasonMetadataMissing     = "MetadataMissing"
+	restorableReasonDependencyMissing   = "DependencyMissing"
+	restorableReasonDependencyNotReady  = "DependencyNotFinished"
+	restorableReasonPreviewFailed       = "RestorePreviewFailed"
+	restorableReasonObjectsMissing      = "ObjectsMissing"
+	restorableReasonManifestMissing     = "ManifestMissing"
+	restorableReasonRequiresThaw        = "RequiresThaw"
+	restorableReasonObjectsPresent      = "ObjectsPresent"
+	restorableReasonDependenciesPresent = "DependenciesPresent"
+)
+
+const (
+	binlogManifestName = "binlog_manifest"
+	legacyBinlogList   = "binlog_list"
+)
+
+type restorability struct {
+	status  corev1.ConditionStatus
+	reason  string
+	message string
+}
+
+func notRestorable(reason, format string, args ...interface{}) *restorability {
+	return &restorability{status: corev1.ConditionFalse, reason: reason, message: fmt.Sprintf(format, args...)}
+}
+
+// xstoreBackupDependency is an xstore backup which the backup depends on, nil if it's not found.
+type xstoreBackupDependency struct {
+	xstoreName string
+	backupName string
+	backup     *polardbxv1.XStoreBackup
+	hasSecret  bool
+}
+
+// checkRestorableDependencies checks what's known without reading the storage, i.e. the metadata and the
+// xstore backups along with their secrets. It returns nil if nothing is wrong.
+func checkRestorableDependencies(backup *polardbxv1.PolarDBXBackup, dependencies []xstoreBackupDependency) *restorability {
+	if len(backup.Status.BackupRootPath) == 0 || len(backup.Status.Backups) == 0 {
+		return notRestorable(restorableReasonMetadataMissing, "backup root path or xstore backups not recorded")
+	}
+	for _, d := range dependencies {
+		switch {
+		case d.backup == nil || !d.backup.DeletionTimestamp.IsZero():
+			return notRestorable(restorableReasonDependencyMissing, "xstore backup %s of %s not found", d.backupName, d.xstoreName)
+		case d.backup.Status.Phase != polardbxv1.XStoreBackupFinished:
+			return notRestorable(restorableReasonDependencyNotReady, "xstore backup %s of %s is %s", d.backupName,
+				d.xstoreName, d.backup.Status.Phase)
+		case len(d.backup.Status.BackupRootPath) == 0:
+			return notRestorable(restorableReasonMetadataMissing, "backup root path of xstore backup %s not recorded", d.backupName)
+		case !d.hasSecret:
+			return notRestorable(restorableReasonDependencyMissing, "secret of xstore backup %s not found", d.backupName)
+		}
+	}
+	if preview := backup.Status.RestorePreview; preview != nil && preview.ObservedGeneration == backup.Generation &&
+		preview.Result == polardbxv1.RestorePreviewFailed {
+		return notRestorable(restorableReasonPreviewFailed, "restore preview failed")
+	}
+	return nil
+}
+
+// checkRestorableObjects checks the files of xstore backups listed from the storage, i.e. the full backup
+// and the binlog manifest are present. Archived files are restorable after thawed, which is done by the
+// restore.
+func checkRestorableObjects(dependencies []xstoreBackupDependency, files []remote.FileStat) *restorability {
+	sizes := make(map[string]int64, len(files))
+	var archived int
+	for _, f := range files {
+		sizes[f.Path] = f.Size
+		if polardbxhelper.IsArchiveBackupStorageClass(polardbxv1.OSS, f.StorageClass) {
+			archived++
+		}
+	}
+	hasVersionedManifest := func(binlogDir string) bool {
+		for _, f := range files {
+			if strings.HasPrefix(f.Path, path.Join(binlogDir, binlogManifestName)+".") {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, d := range dependencies {
+		root := d.backup.Status.BackupRootPath
+		fullBackup := fmt.Sprintf("%s/%s/%s.xbstream", root, polardbxmeta.FullBackupPath, d.xstoreName)
+		if sizes[fullBackup] <= 0 {
+			return notRestorable(restorableReasonObjectsMissing, "full backup %s not found or empty", fullBackup)
+		}
+		binlogDir := fmt.Sprintf("%s/%s/%s", root, polardbxmeta.BinlogBackupPath, d.xstoreName)
+		if _, ok := sizes[path.Join(binlogDir, binlogManifestName)]; ok {
+			if !hasVersionedManifest(binlogDir) {
+				return notRestorable(restorableReasonManifestMissing, "versioned binlog manifest of %s not found", binlogDir)
+			}
+		} else if _, ok := sizes[path.Join(binlogDir, legacyBinlogList)]; !ok {
+			return notRestorable(restorableReasonManifestMissing, "binlog manifest of %s not found", binlogDir)
+		}
+	}
+	if archived > 0 {
+		return &restorability{status: corev1.ConditionTrue, reason: restorableReasonRequiresThaw,
+			message: fmt.Sprintf("%d files archived, they are thawed by the restore", archived)}
+	}
+	return &restorability{status: corev1.ConditionTrue, reason: restorableReasonObjectsPresent,
+		message: fmt.Sprintf("%d files found", len(files))}
+}
+
+// isRestorableProbed tells whether the condition is the result of a recent probe of the backup files.
+func isRestorableProbed(condition *polardbxv1xstore.Condition, now time.Time) bool {
+	if condition == nil || condition.LastProbeTime == nil || now.Sub(condition.LastProbeTime.Time) >= RestorableProbeInterval {
+		return false
+	}
+	switch condition.Reason {
+	case restorableReasonObjectsMissing, restorableReasonManifestMissing, restorableReasonRequiresThaw,
+		restorableReasonObjectsPresent, restorableReasonDependenciesPresent:
+		return true
+	}
+	return false
+}
+
+func getXStoreBackupDependencies(rc *polardbxv1reconcile.Context, backup *polardbxv1.PolarDBXBackup) ([]xstoreBackupDependency, error) {
+	dependencies := make([]xstoreBackupDependency, 0, len(backup.Status.Backups))
+	for xstoreName, backupName := range backup.Status.Backups {
+		d := xstoreBackupDependency{xstoreName: xstoreName, backupName: backupName}
+		xstoreBackup := &polardbxv1.XStoreBackup{}
+		err := rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: backup.Namespace, Name: backupName}, xstoreBackup)
+		if client.IgnoreNotFound(err) != nil {
+			return nil, err
+		}
+		if err == nil {
+			d.backup = xstoreBackup
+			err = rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: backup.Namespace, Name: backupName}, &corev1.Secret{})
+			if client.IgnoreNotFound(err) != nil {
+				return nil, err
+			}
+			d.hasSecret = err == nil
+		}
+		dependencies = append(dependencies, d)
+	}
+	sort.Slice(dependencies, func(i, j int) bool {
+		return dependencies[i].xstoreName < dependencies[j].xstoreName
+	})
+	return dependencies, nil
+}
+
+// CheckBackupRestorable recomputes the restorable condition of the finished backup. The dependencies are
+// checked in every reconciliation, and the backup files are probed every RestorableProbeInterval with the
+// retention credential, if specified.
+var CheckBackupRestorable = polardbxv1reconcile.NewStepBinder("CheckBackupRestorable",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetPolarDBXBackup()
+		dependencies, err := getXStoreBackupDependencies(rc, backup)
+		if err != nil {
+			return flow.Error(err, "Unable to get xstore backups of the backup.")
+		}
+
+		var previous *polardbxv1xstore.Condition
+		for i := range backup.Status.Conditions {
+			if backup.Status.Conditions[i].Type == polardbxv1xstore.BackupRestorable {
+				previous = &backup.Status.Conditions[i]
+			}
+		}
+		now := metav1.Now()
+		result := checkRestorableDependencies(backup, dependencies)
+		if result == nil {
+			if isRestorableProbed(previous, now.Time) {
+				return flow.Pass()
+			}
+			files, probed, err := polardbxhelper.StatBackupFiles(rc.Context(), rc.Client(), backup.Namespace,
+				backup.Spec.StorageProvider, backup.Status.BackupRootPath+"/")
+			if err != nil {
+				// Keep the previous result if the storage is unavailable, and probe again in the next reconciliation.
+				flow.Logger().Error(err, "Unable to probe the backup files.")
+				return flow.Continue("Unable to probe the backup files.")
+			}
+			if probed {
+				result = checkRestorableObjects(dependencies, files)
+			} else {
+				result = &restorability{status: corev1.ConditionTrue, reason: restorableReasonDependenciesPresent,
+					message: "backup files are not probed without the retention credential"}
+			}
+		}
+
+		condition := polardbxv1xstore.Condition{
+			Type:               polardbxv1xstore.BackupRestorable,
+			Status:             result.status,
+			LastProbeTime:      &now,
+			LastTransitionTime: now,
+			Reason:             result.reason,
+			Message:            result.message,
+		}
+		// The probe time is kept until the files are probed again.
+		if result.reason == restorableReasonMetadataMissing || result.reason == restorableReasonDependencyMissing ||
+			result.reason == restorableReasonDependencyNotReady || result.reason == restorableReasonPreviewFailed {
+			condition.LastProbeTime = nil
+		}
+		if previous != nil && previous.Status == condition.Status && previous.Reason == condition.Reason &&
+			previous.Message == condition.Message && condition.LastProbeTime == nil {
+			return flow.Pass()
+		}
+		backup.Status.Conditions = polardbxhelper.SetBackupCondition(backup.Status.Conditions, condition)
+		return flow.Continue("Restorable condition updated.", "status", result.status, "reason", result.reason)
+	})
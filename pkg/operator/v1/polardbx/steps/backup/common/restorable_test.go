@@ -0,0 +1,126 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/hpfs/remote"
+)
+
+func restorableTestDependencies() []xstoreBackupDependency {
+	return []xstoreBackupDependency{{
+		xstoreName: "dn-0",
+		backupName: "backup-dn-0",
+		backup: &polardbxv1.XStoreBackup{
+			Status: polardbxv1.XStoreBackupStatus{
+				Phase:          polardbxv1.XStoreBackupFinished,
+				BackupRootPath: "polardbx-backup/ns/pxc/backup",
+			},
+		},
+		hasSecret: true,
+	}}
+}
+
+func TestCheckRestorableDependencies(t *testing.T) {
+	backup := &polardbxv1.PolarDBXBackup{
+		Status: polardbxv1.PolarDBXBackupStatus{
+			BackupRootPath: "polardbx-backup/ns/pxc/backup",
+			Backups:        map[string]string{"dn-0": "backup-dn-0"},
+		},
+	}
+	if r := checkRestorableDependencies(backup, restorableTestDependencies()); r != nil {
+		t.Fatalf("expect dependencies present, got %s: %s", r.reason, r.message)
+	}
+
+	dependencies := restorableTestDependencies()
+	dependencies[0].hasSecret = false
+	if r := checkRestorableDependencies(backup, dependencies); r == nil || r.reason != restorableReasonDependencyMissing {
+		t.Fatalf("expect secret missing, got %v", r)
+	}
+	dependencies[0].backup = nil
+	if r := checkRestorableDependencies(backup, dependencies); r == nil || r.reason != restorableReasonDependencyMissing {
+		t.Fatalf("expect xstore backup missing, got %v", r)
+	}
+
+	backup.Status.RestorePreview = &polardbxv1.BackupRestorePreviewStatus{Result: polardbxv1.RestorePreviewFailed}
+	if r := checkRestorableDependencies(backup, restorableTestDependencies()); r == nil || r.reason != restorableReasonPreviewFailed {
+		t.Fatalf("expect preview failed, got %v", r)
+	}
+
+	backup.Status.Backups = nil
+	if r := checkRestorableDependencies(backup, restorableTestDependencies()); r == nil || r.reason != restorableReasonMetadataMissing {
+		t.Fatalf("expect metadata missing, got %v", r)
+	}
+}
+
+func TestCheckRestorableObjects(t *testing.T) {
+	root := "polardbx-backup/ns/pxc/backup"
+	files := []remote.FileStat{
+		{Path: root + "/fullbackup/dn-0.xbstream", Size: 1024, StorageClass: "Standard"},
+		{Path: root + "/binlogbackup/dn-0/binlog_manifest", Size: 16, StorageClass: "Standard"},
+		{Path: root + "/binlogbackup/dn-0/binlog_manifest.1700000000000", Size: 64, StorageClass: "Standard"},
+	}
+	if r := checkRestorableObjects(restorableTestDependencies(), files); r.status != corev1.ConditionTrue ||
+		r.reason != restorableReasonObjectsPresent {
+		t.Fatalf("expect objects present, got %s: %s", r.reason, r.message)
+	}
+
+	if r := checkRestorableObjects(restorableTestDependencies(), files[:2]); r.reason != restorableReasonManifestMissing {
+		t.Fatalf("expect versioned manifest missing, got %s", r.reason)
+	}
+	legacy := []remote.FileStat{files[0], {Path: root + "/binlogbackup/dn-0/binlog_list", Size: 16}}
+	if r := checkRestorableObjects(restorableTestDependencies(), legacy); r.status != corev1.ConditionTrue {
+		t.Fatalf("expect legacy binlog list restorable, got %s", r.reason)
+	}
+
+	empty := append([]remote.FileStat{{Path: files[0].Path}}, files[1:]...)
+	if r := checkRestorableObjects(restorableTestDependencies(), empty); r.reason != restorableReasonObjectsMissing {
+		t.Fatalf("expect empty full backup missing, got %s", r.reason)
+	}
+
+	archived := append([]remote.FileStat{{Path: files[0].Path, Size: 1024, StorageClass: "ColdArchive"}}, files[1:]...)
+	if r := checkRestorableObjects(restorableTestDependencies(), archived); r.status != corev1.ConditionTrue ||
+		r.reason != restorableReasonRequiresThaw {
+		t.Fatalf("expect archived objects restorable after thawed, got %s", r.reason)
+	}
+}
+
+func TestIsRestorableProbed(t *testing.T) {
+	now := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	condition := &polardbxv1xstore.Condition{
+		Type:          polardbxv1xstore.BackupRestorable,
+		Reason:        restorableReasonObjectsPresent,
+		LastProbeTime: &metav1.Time{Time: now.Add(-time.Hour)},
+	}
+	if !isRestorableProbed(condition, now) {
+		t.Fatal("expect probed recently")
+	}
+	if isRestorableProbed(condition, now.Add(RestorableProbeInterval)) {
+		t.Fatal("expect probe expired")
+	}
+	condition.Reason = restorableReasonDependencyMissing
+	if isRestorableProbed(condition, now) {
+		t.Fatal("expect probe again once the dependencies recover")
+	}
+}
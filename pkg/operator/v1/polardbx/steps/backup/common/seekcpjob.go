@@ -19,6 +19,7 @@ package common
 import (
 	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
 	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
+	polardbxhelper "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
 	"github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
 	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/command"
 	"github.com/alibaba/polardbx-operator/pkg/util"
@@ -121,5 +122,6 @@ func newSeekCpJob(pxcBackup *polardbxv1.PolarDBXBackup, targetPod *corev1.Pod, j
 			},
 		},
 	}
+	polardbxhelper.ApplyBackupJobTemplate(job, pxcBackup.Spec.JobTemplate)
 	return job, nil
 }
@@ -19,7 +19,9 @@ package common
 import (
 	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
 	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	polardbxhelper "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
 	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/rand"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"strings"
@@ -48,3 +50,26 @@ func GenerateJobName(pxcBackup *polardbxv1.PolarDBXBackup, JobLabel string) stri
 	}
 	return jobName
 }
+
+// UpdateBackupConditionsAndProgress publishes the standard conditions of the backup, i.e. Ready,
+// Progressing and Degraded, and records the phases the backup has gone through.
+var UpdateBackupConditionsAndProgress = polardbxv1reconcile.NewStepBinder("UpdateBackupConditionsAndProgress",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetPolarDBXBackup()
+		phase := backup.Status.Phase
+		if phase == polardbxv1.BackupNew {
+			return flow.Pass()
+		}
+
+		finished, failed := phase == polardbxv1.BackupFinished, phase == polardbxv1.BackupFailed
+		now := metav1.Now()
+		for _, c := range polardbxhelper.NewBackupStatusConditions(string(phase), finished, failed,
+			string(backup.Status.FailureReason), backup.Status.Conditions) {
+			c.LastTransitionTime = now
+			c.ObservedGeneration = backup.Generation
+			backup.Status.Conditions = polardbxhelper.SetBackupCondition(backup.Status.Conditions, c)
+		}
+		backup.Status.Progress = polardbxhelper.ObserveProgress(backup.Status.Progress, "Backup", string(phase),
+			finished || failed, now)
+		return flow.Pass()
+	})
@@ -0,0 +1,65 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	polardbxhelper "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+	"github.com/alibaba/polardbx-operator/pkg/util/tracing"
+)
+
+// TraceBackupLifecycle emits the spans of the backup and its phases once they end, the xstore
+// backups and jobs are traced as children. It must be deferred before the status is persisted.
+var TraceBackupLifecycle = polardbxv1reconcile.NewStepBinder("TraceBackupLifecycle",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		if !tracing.Enabled() {
+			return flow.Pass()
+		}
+		backup := rc.MustGetPolarDBXBackup()
+		if backup.Status.Trace == nil {
+			backup.Status.Trace = polardbxhelper.NewBackupTrace(tracing.SpanContext{})
+		}
+
+		root := tracing.Span{
+			Name:  "PolarDBXBackup/" + backup.Name,
+			Start: backup.CreationTimestamp.Time,
+			Attributes: map[string]string{
+				"backup.name":      backup.Name,
+				"backup.namespace": backup.Namespace,
+				"cluster":          backup.Spec.Cluster.Name,
+			},
+		}
+		phase := backup.Status.Phase
+		if phase == polardbxv1.BackupFailed {
+			root.Error = string(backup.Status.FailureReason) + ": " + backup.Status.Reason
+		}
+		spans, err := polardbxhelper.ObserveBackupTrace(backup.Status.Trace, string(phase),
+			phase == polardbxv1.BackupFinished || phase == polardbxv1.BackupFailed, root, time.Now())
+		if err != nil {
+			return flow.Error(err, "Invalid trace context of backup.", "trace-parent", backup.Status.Trace.TraceParent)
+		}
+		for _, span := range spans {
+			tracing.Emit(span)
+		}
+		return flow.Pass()
+	})
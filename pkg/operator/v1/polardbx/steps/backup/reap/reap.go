@@ -0,0 +1,304 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reap
+
+import (
+	"context"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/hpfs/remote"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	polardbxhelper "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+)
+
+const (
+	kindPolarDBXBackup = "PolarDBXBackup"
+	kindXStoreBackup   = "XStoreBackup"
+)
+
+func isProtected(obj metav1.Object) bool {
+	return obj.GetAnnotations()[polardbxmeta.AnnotationBackupProtected] == "true"
+}
+
+func isImmutable(endTime *metav1.Time, immutableUntil *metav1.Time, retentionTime time.Duration,
+	retention polardbxv1.BackupRetention, now time.Time) bool {
+	if endTime == nil {
+		return immutableUntil != nil && immutableUntil.After(now)
+	}
+	return polardbxhelper.ImmutableUntil(endTime.Time, immutableUntil, retentionTime, retention).After(now)
+}
+
+func isXStoreBackupImmutable(backup *polardbxv1.XStoreBackup, now time.Time) bool {
+	return isImmutable(backup.Status.EndTime, backup.Spec.ImmutableUntil, backup.Spec.RetentionTime.Duration,
+		backup.Spec.Retention, now)
+}
+
+// pxcBackupSkipReasonOf returns why the pxc backup can't be reaped, empty if it can. The xstore
+// backups are removed along with the pxc backup, so a protected or immutable one protects the pxc
+// backup.
+func pxcBackupSkipReasonOf(backup *polardbxv1.PolarDBXBackup, xstoreBackups []polardbxv1.XStoreBackup, now time.Time) polardbxv1.BackupReapSkipReason {
+	if isProtected(backup) {
+		return polardbxv1.ReapSkipProtected
+	}
+	for i := range xstoreBackups {
+		if xstoreBackups[i].Labels[polardbxmeta.LabelTopBackup] == backup.Name && isProtected(&xstoreBackups[i]) {
+			return polardbxv1.ReapSkipProtected
+		}
+	}
+	if backup.Status.Phase != polardbxv1.BackupFinished && backup.Status.Phase != polardbxv1.BackupFailed {
+		return polardbxv1.ReapSkipInProgress
+	}
+	if isImmutable(backup.Status.EndTime, backup.Spec.ImmutableUntil, backup.Spec.RetentionTime.Duration,
+		backup.Spec.Retention, now) {
+		return polardbxv1.ReapSkipImmutable
+	}
+	for i := range xstoreBackups {
+		if xstoreBackups[i].Labels[polardbxmeta.LabelTopBackup] == backup.Name && isXStoreBackupImmutable(&xstoreBackups[i], now) {
+			return polardbxv1.ReapSkipImmutable
+		}
+	}
+	if len(backup.Status.BackupRootPath) > 0 && backup.Spec.StorageProvider.RetentionCredential == nil {
+		return polardbxv1.ReapSkipNoRetentionCredential
+	}
+	return ""
+}
+
+// xstoreBackupSkipReasonOf returns why the xstore backup without a pxc backup can't be reaped, empty
+// if it can.
+func xstoreBackupSkipReasonOf(backup *polardbxv1.XStoreBackup, now time.Time) polardbxv1.BackupReapSkipReason {
+	if isProtected(backup) {
+		return polardbxv1.ReapSkipProtected
+	}
+	if backup.Status.Phase != polardbxv1.XStoreBackupFinished && backup.Status.Phase != polardbxv1.XStoreBackupFailed {
+		return polardbxv1.ReapSkipInProgress
+	}
+	if isXStoreBackupImmutable(backup, now) {
+		return polardbxv1.ReapSkipImmutable
+	}
+	if len(backup.Status.BackupRootPath) > 0 && backup.Spec.StorageProvider.RetentionCredential == nil {
+		return polardbxv1.ReapSkipNoRetentionCredential
+	}
+	return ""
+}
+
+// pxcBackupInUseReasonOf returns ReapSkipInUse if the pxc backup, or one of its xstore backups, is
+// read by a restore in progress, empty otherwise.
+func pxcBackupInUseReasonOf(ctx context.Context, c client.Client, backup *polardbxv1.PolarDBXBackup) (polardbxv1.BackupReapSkipReason, error) {
+	restoring, err := polardbxhelper.ActiveRestoreOfPolarDBXBackup(ctx, c, backup)
+	if err != nil || len(restoring) == 0 {
+		return "", err
+	}
+	return polardbxv1.ReapSkipInUse, nil
+}
+
+// xstoreBackupInUseReasonOf returns ReapSkipInUse if the xstore backup is read by a restore in
+// progress, empty otherwise.
+func xstoreBackupInUseReasonOf(ctx context.Context, c client.Client, backup *polardbxv1.XStoreBackup) (polardbxv1.BackupReapSkipReason, error) {
+	restoring, err := polardbxhelper.ActiveRestoreOfXStoreBackup(ctx, c, backup)
+	if err != nil || len(restoring) == 0 {
+		return "", err
+	}
+	return polardbxv1.ReapSkipInUse, nil
+}
+
+func isRecorded(reap *polardbxv1.PolarDBXBackupReap, kind, name string) bool {
+	for _, records := range [][]polardbxv1.ReapedBackup{reap.Status.Deleted, reap.Status.Skipped} {
+		for _, r := range records {
+			if r.Kind == kind && r.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func recordDeleted(reap *polardbxv1.PolarDBXBackupReap, kind, name string, deletedFiles int64) {
+	reap.Status.Deleted = append(reap.Status.Deleted, polardbxv1.ReapedBackup{
+		Kind:         kind,
+		Name:         name,
+		DeletedFiles: deletedFiles,
+	})
+	reap.Status.DeletedCount = int32(len(reap.Status.Deleted))
+}
+
+func recordSkipped(reap *polardbxv1.PolarDBXBackupReap, kind, name string, reason polardbxv1.BackupReapSkipReason) {
+	reap.Status.Skipped = append(reap.Status.Skipped, polardbxv1.ReapedBackup{
+		Kind:   kind,
+		Name:   name,
+		Reason: reason,
+	})
+	reap.Status.SkippedCount = int32(len(reap.Status.Skipped))
+}
+
+func failReap(reap *polardbxv1.PolarDBXBackupReap, flow control.Flow, message string) (reconcile.Result, error) {
+	now := metav1.Now()
+	reap.Status.Phase = polardbxv1.ReapFailed
+	reap.Status.Message = message
+	reap.Status.EndTime = &now
+	return flow.Continue("Backup reap failed.", "reason", message)
+}
+
+// removeFiles deletes all the files under the backup root path with the retention credential.
+func removeFiles(rc *polardbxv1reconcile.Context, provider polardbxv1.BackupStorageProvider, backupRootPath string) (int64, error) {
+	if len(backupRootPath) == 0 {
+		return 0, nil
+	}
+	return polardbxhelper.RemoveBackupFiles(rc.Context(), rc.Client(), rc.Namespace(), provider, backupRootPath+"/")
+}
+
+var PersistentStatusChanges = polardbxv1reconcile.NewStepBinder("PersistentStatusChanges",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		if rc.IsPolarDBXBackupReapStatusChanged() {
+			if err := rc.UpdatePolarDBXBackupReapStatus(); err != nil {
+				return flow.Error(err, "Unable to update status for backup reap.")
+			}
+			return flow.Continue("Backup reap status updated!")
+		}
+		return flow.Continue("Backup reap status not changed!")
+	})
+
+// StartReap checks the confirmation and that the cluster is deleted before anything is reaped.
+var StartReap = polardbxv1reconcile.NewStepBinder("StartReap",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		reap := rc.MustGetPolarDBXBackupReap()
+		now := metav1.Now()
+		reap.Status.StartTime = &now
+
+		clusterName := reap.Spec.Cluster.Name
+		if len(clusterName) == 0 {
+			return failReap(reap, flow, "cluster not specified")
+		}
+		if reap.Spec.Confirm != clusterName {
+			return failReap(reap, flow, "confirm mismatches the cluster name: "+clusterName)
+		}
+		_, err := rc.GetPolarDBX()
+		if err == nil {
+			return failReap(reap, flow, "cluster still exists: "+clusterName)
+		} else if !apierrors.IsNotFound(err) {
+			return flow.Error(err, "Unable to get polardbx cluster.")
+		}
+
+		reap.Status.Phase = polardbxv1.ReapReaping
+		return flow.Retry("Backup reap started!", "cluster", clusterName)
+	})
+
+// ReapClusterBackups deletes the pxc backups of the cluster along with their files and xstore
+// backups, and then the xstore backups left without a pxc backup. Each backup is recorded once it's
+// deleted or skipped, so that it's never handled again if the step is retried.
+var ReapClusterBackups = polardbxv1reconcile.NewStepBinder("ReapClusterBackups",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		reap := rc.MustGetPolarDBXBackupReap()
+		clusterName := reap.Spec.Cluster.Name
+		now := metav1.Now()
+
+		var backupList polardbxv1.PolarDBXBackupList
+		err := rc.Client().List(rc.Context(), &backupList, client.InNamespace(rc.Namespace()), client.MatchingLabels{
+			polardbxmeta.LabelName: clusterName,
+		})
+		if err != nil {
+			return flow.Error(err, "Unable to list pxc backups.")
+		}
+		var xstoreBackupList polardbxv1.XStoreBackupList
+		err = rc.Client().List(rc.Context(), &xstoreBackupList, client.InNamespace(rc.Namespace()), client.MatchingLabels{
+			polardbxmeta.LabelName: clusterName,
+		})
+		if err != nil {
+			return flow.Error(err, "Unable to list xstore backups.")
+		}
+
+		pxcBackups := make(map[string]bool, len(backupList.Items))
+		for i := range backupList.Items {
+			backup := &backupList.Items[i]
+			pxcBackups[backup.Name] = true
+			if isRecorded(reap, kindPolarDBXBackup, backup.Name) || !backup.DeletionTimestamp.IsZero() {
+				continue
+			}
+			reason := pxcBackupSkipReasonOf(backup, xstoreBackupList.Items, now.Time)
+			if len(reason) == 0 {
+				if reason, err = pxcBackupInUseReasonOf(rc.Context(), rc.Client(), backup); err != nil {
+					return flow.Error(err, "Unable to check restores of backup.", "backup", backup.Name)
+				}
+			}
+			if len(reason) > 0 {
+				recordSkipped(reap, kindPolarDBXBackup, backup.Name, reason)
+				flow.Logger().Info("Backup skipped.", "backup", backup.Name, "reason", reason)
+				continue
+			}
+			deleted, err := removeFiles(rc, backup.Spec.StorageProvider, backup.Status.BackupRootPath)
+			if remote.IsImmutable(err) {
+				// Files are locked by the storage longer than required, the rest are kept.
+				recordSkipped(reap, kindPolarDBXBackup, backup.Name, polardbxv1.ReapSkipImmutable)
+				flow.Logger().Info("Backup skipped, files are locked by the storage.", "backup", backup.Name)
+				continue
+			} else if err != nil {
+				return flow.Error(err, "Unable to delete backup files.", "backup", backup.Name)
+			}
+			// The xstore backups are removed along with it.
+			if err := rc.Client().Delete(rc.Context(), backup); client.IgnoreNotFound(err) != nil {
+				return flow.Error(err, "Unable to delete backup.", "backup", backup.Name)
+			}
+			recordDeleted(reap, kindPolarDBXBackup, backup.Name, deleted)
+			flow.Logger().Info("Backup deleted.", "backup", backup.Name, "deleted-files", deleted)
+		}
+
+		for i := range xstoreBackupList.Items {
+			xstoreBackup := &xstoreBackupList.Items[i]
+			// Those of the pxc backups are handled along with the pxc backups.
+			if pxcBackups[xstoreBackup.Labels[polardbxmeta.LabelTopBackup]] {
+				continue
+			}
+			if isRecorded(reap, kindXStoreBackup, xstoreBackup.Name) || !xstoreBackup.DeletionTimestamp.IsZero() {
+				continue
+			}
+			reason := xstoreBackupSkipReasonOf(xstoreBackup, now.Time)
+			if len(reason) == 0 {
+				if reason, err = xstoreBackupInUseReasonOf(rc.Context(), rc.Client(), xstoreBackup); err != nil {
+					return flow.Error(err, "Unable to check restores of xstore backup.", "xstore-backup", xstoreBackup.Name)
+				}
+			}
+			if len(reason) > 0 {
+				recordSkipped(reap, kindXStoreBackup, xstoreBackup.Name, reason)
+				flow.Logger().Info("XStore backup skipped.", "xstore-backup", xstoreBackup.Name, "reason", reason)
+				continue
+			}
+			deleted, err := removeFiles(rc, xstoreBackup.Spec.StorageProvider, xstoreBackup.Status.BackupRootPath)
+			if remote.IsImmutable(err) {
+				recordSkipped(reap, kindXStoreBackup, xstoreBackup.Name, polardbxv1.ReapSkipImmutable)
+				flow.Logger().Info("XStore backup skipped, files are locked by the storage.", "xstore-backup", xstoreBackup.Name)
+				continue
+			} else if err != nil {
+				return flow.Error(err, "Unable to delete xstore backup files.", "xstore-backup", xstoreBackup.Name)
+			}
+			if err := rc.Client().Delete(rc.Context(), xstoreBackup); client.IgnoreNotFound(err) != nil {
+				return flow.Error(err, "Unable to delete xstore backup.", "xstore-backup", xstoreBackup.Name)
+			}
+			recordDeleted(reap, kindXStoreBackup, xstoreBackup.Name, deleted)
+			flow.Logger().Info("XStore backup deleted.", "xstore-backup", xstoreBackup.Name, "deleted-files", deleted)
+		}
+
+		reap.Status.Phase = polardbxv1.ReapFinished
+		reap.Status.EndTime = &now
+		return flow.Continue("Backup reap finished!", "deleted", reap.Status.DeletedCount, "skipped", reap.Status.SkippedCount)
+	})
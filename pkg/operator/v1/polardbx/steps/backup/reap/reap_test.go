@@ -0,0 +1,176 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reap
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1polardbx "github.com/alibaba/polardbx-operator/api/v1/polardbx"
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+)
+
+func TestPXCBackupSkipReasonOf(t *testing.T) {
+	now := time.Now()
+	backup := &polardbxv1.PolarDBXBackup{}
+	backup.Name = "b1"
+	backup.Status.Phase = polardbxv1.BackupFinished
+	backup.Status.BackupRootPath = "polardbx-backup/ns/b1"
+	backup.Spec.StorageProvider.RetentionCredential = &corev1.LocalObjectReference{Name: "cred"}
+
+	xstoreBackup := polardbxv1.XStoreBackup{}
+	xstoreBackup.Labels = map[string]string{polardbxmeta.LabelTopBackup: "b1"}
+	xstoreBackups := []polardbxv1.XStoreBackup{xstoreBackup}
+	if reason := pxcBackupSkipReasonOf(backup, xstoreBackups, now); len(reason) > 0 {
+		t.Fatalf("expect reapable, got %s", reason)
+	}
+
+	xstoreBackups[0].Annotations = map[string]string{polardbxmeta.AnnotationBackupProtected: "true"}
+	if reason := pxcBackupSkipReasonOf(backup, xstoreBackups, now); reason != polardbxv1.ReapSkipProtected {
+		t.Fatalf("expect protected by xstore backup, got %s", reason)
+	}
+
+	backup.Status.Phase = polardbxv1.BinlogBackuping
+	if reason := pxcBackupSkipReasonOf(backup, nil, now); reason != polardbxv1.ReapSkipInProgress {
+		t.Fatalf("expect in progress, got %s", reason)
+	}
+
+	backup.Status.Phase = polardbxv1.BackupFailed
+	backup.Spec.StorageProvider.RetentionCredential = nil
+	if reason := pxcBackupSkipReasonOf(backup, nil, now); reason != polardbxv1.ReapSkipNoRetentionCredential {
+		t.Fatalf("expect no retention credential, got %s", reason)
+	}
+}
+
+func TestBackupSkipReasonOf_Immutable(t *testing.T) {
+	now := time.Now()
+	endTime := metav1.NewTime(now.Add(-time.Hour))
+	backup := &polardbxv1.PolarDBXBackup{}
+	backup.Name = "b1"
+	backup.Status.Phase = polardbxv1.BackupFinished
+	backup.Status.EndTime = &endTime
+	backup.Spec.StorageProvider.RetentionCredential = &corev1.LocalObjectReference{Name: "cred"}
+
+	until := metav1.NewTime(now.Add(time.Hour))
+	backup.Spec.ImmutableUntil = &until
+	if reason := pxcBackupSkipReasonOf(backup, nil, now); reason != polardbxv1.ReapSkipImmutable {
+		t.Fatalf("expect immutable until the time, got %s", reason)
+	}
+	if reason := pxcBackupSkipReasonOf(backup, nil, now.Add(2*time.Hour)); len(reason) > 0 {
+		t.Fatalf("expect reapable once the time passes, got %s", reason)
+	}
+
+	backup.Spec.ImmutableUntil = nil
+	backup.Spec.RetentionTime = metav1.Duration{Duration: 24 * time.Hour}
+	backup.Spec.Retention.Immutable = true
+	if reason := pxcBackupSkipReasonOf(backup, nil, now); reason != polardbxv1.ReapSkipImmutable {
+		t.Fatalf("expect immutable within the retention, got %s", reason)
+	}
+
+	backup.Spec.Retention.Immutable = false
+	xstoreBackup := polardbxv1.XStoreBackup{}
+	xstoreBackup.Labels = map[string]string{polardbxmeta.LabelTopBackup: "b1"}
+	xstoreBackup.Status.Phase = polardbxv1.XStoreBackupFinished
+	xstoreBackup.Status.EndTime = &endTime
+	xstoreBackup.Spec.ImmutableUntil = &until
+	if reason := pxcBackupSkipReasonOf(backup, []polardbxv1.XStoreBackup{xstoreBackup}, now); reason != polardbxv1.ReapSkipImmutable {
+		t.Fatalf("expect immutable by the xstore backup, got %s", reason)
+	}
+	if reason := xstoreBackupSkipReasonOf(&xstoreBackup, now); reason != polardbxv1.ReapSkipImmutable {
+		t.Fatalf("expect xstore backup immutable, got %s", reason)
+	}
+}
+
+func TestRecordReapedBackups(t *testing.T) {
+	reap := &polardbxv1.PolarDBXBackupReap{}
+	recordDeleted(reap, kindPolarDBXBackup, "b1", 10)
+	recordSkipped(reap, kindXStoreBackup, "b2", polardbxv1.ReapSkipProtected)
+	if reap.Status.DeletedCount != 1 || reap.Status.SkippedCount != 1 {
+		t.Fatalf("unexpected counts: %+v", reap.Status)
+	}
+	if !isRecorded(reap, kindPolarDBXBackup, "b1") || !isRecorded(reap, kindXStoreBackup, "b2") ||
+		isRecorded(reap, kindXStoreBackup, "b1") {
+		t.Fatal("unexpected records")
+	}
+}
+
+// restoreLister lists the given clusters, xstores and xstore backups regardless of the options.
+type restoreLister struct {
+	client.Client
+	clusters      []polardbxv1.PolarDBXCluster
+	xstores       []polardbxv1.XStore
+	xstoreBackups []polardbxv1.XStoreBackup
+}
+
+func (l *restoreLister) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	switch list := list.(type) {
+	case *polardbxv1.PolarDBXClusterList:
+		list.Items = l.clusters
+	case *polardbxv1.XStoreList:
+		list.Items = l.xstores
+	case *polardbxv1.XStoreBackupList:
+		list.Items = l.xstoreBackups
+	}
+	return nil
+}
+
+func TestBackupInUseReasonOf(t *testing.T) {
+	backup := &polardbxv1.PolarDBXBackup{}
+	backup.Name = "b1"
+	xstoreBackup := polardbxv1.XStoreBackup{}
+	xstoreBackup.Name = "b1-dn-0"
+	xstoreBackup.Labels = map[string]string{polardbxmeta.LabelTopBackup: "b1"}
+
+	restoring := polardbxv1.PolarDBXCluster{}
+	restoring.Name = "clone"
+	restoring.Spec.Restore = &polardbxv1polardbx.RestoreSpec{BackupSet: "b1"}
+	restoring.Status.Phase = polardbxv1polardbx.PhaseRestoring
+	c := &restoreLister{clusters: []polardbxv1.PolarDBXCluster{restoring}}
+	if reason, err := pxcBackupInUseReasonOf(context.Background(), c, backup); err != nil || reason != polardbxv1.ReapSkipInUse {
+		t.Fatalf("expect in use by the restoring cluster, got %s, %v", reason, err)
+	}
+
+	c.clusters[0].Status.Phase = polardbxv1polardbx.PhaseRunning
+	if reason, err := pxcBackupInUseReasonOf(context.Background(), c, backup); err != nil || len(reason) > 0 {
+		t.Fatalf("expect not in use once restored, got %s, %v", reason, err)
+	}
+
+	xstore := polardbxv1.XStore{}
+	xstore.Name = "restored-dn"
+	xstore.Spec.Restore = &polardbxv1.XStoreRestoreSpec{BackupSet: "b1-dn-0"}
+	xstore.Status.Phase = polardbxv1xstore.PhaseRestoring
+	c.xstores = []polardbxv1.XStore{xstore}
+	c.xstoreBackups = []polardbxv1.XStoreBackup{xstoreBackup}
+	if reason, err := pxcBackupInUseReasonOf(context.Background(), c, backup); err != nil || reason != polardbxv1.ReapSkipInUse {
+		t.Fatalf("expect in use by the xstore restoring from its xstore backup, got %s, %v", reason, err)
+	}
+	if reason, err := xstoreBackupInUseReasonOf(context.Background(), c, &xstoreBackup); err != nil || reason != polardbxv1.ReapSkipInUse {
+		t.Fatalf("expect xstore backup in use, got %s, %v", reason, err)
+	}
+
+	c.xstores[0].Status.Phase = polardbxv1xstore.PhaseRunning
+	if reason, err := xstoreBackupInUseReasonOf(context.Background(), c, &xstoreBackup); err != nil || len(reason) > 0 {
+		t.Fatalf("expect xstore backup not in use once restored, got %s, %v", reason, err)
+	}
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedule
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/hpfs/remote"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	polardbxhelper "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+)
+
+// GarbageCollectInterval is the least interval between two collections of the expired backup files.
+const GarbageCollectInterval = time.Hour
+
+// backupRootTimePattern matches the start time suffixed to the name of the backup root path.
+var backupRootTimePattern = regexp.MustCompile(`-(\d{14})$`)
+
+// backupRootStartTime returns the start time of the backup encoded in the root path, i.e.
+// "<prefix>/<backup>-<yyyyMMddHHmmss>".
+func backupRootStartTime(root string) (time.Time, bool) {
+	m := backupRootTimePattern.FindStringSubmatch(root)
+	if m == nil {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation("20060102150405", m[1], time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// expiredOrphanedBackupRoots returns the backup root paths under the prefix which aren't referenced
+// by any backup and started before the retention time, sorted. Those whose start time is unknown
+// are never returned.
+func expiredOrphanedBackupRoots(files []remote.FileStat, prefix string, referenced map[string]bool,
+	retentionTime time.Duration, now time.Time) []string {
+	roots := make(map[string]bool)
+	for _, f := range files {
+		rel := strings.TrimPrefix(f.Path, prefix)
+		if rel == f.Path {
+			continue
+		}
+		idx := strings.Index(rel, "/")
+		if idx <= 0 {
+			continue
+		}
+		roots[prefix+rel[:idx]] = true
+	}
+
+	expired := make([]string, 0)
+	for root := range roots {
+		if referenced[root] {
+			continue
+		}
+		start, ok := backupRootStartTime(root)
+		if !ok || !now.After(start.Add(retentionTime)) {
+			continue
+		}
+		expired = append(expired, root)
+	}
+	sort.Strings(expired)
+	return expired
+}
+
+// referencedBackupRoots returns the root paths of the backups of the cluster in all namespaces, since
+// the backup files of the clusters of the same name share the prefix.
+func referencedBackupRoots(rc *polardbxv1reconcile.Context, clusterName string) (map[string]bool, error) {
+	var backupList polardbxv1.PolarDBXBackupList
+	if err := rc.Client().List(rc.Context(), &backupList, client.MatchingLabels{
+		polardbxmeta.LabelName: clusterName,
+	}); err != nil {
+		return nil, err
+	}
+	var xstoreBackupList polardbxv1.XStoreBackupList
+	if err := rc.Client().List(rc.Context(), &xstoreBackupList, client.MatchingLabels{
+		polardbxmeta.LabelName: clusterName,
+	}); err != nil {
+		return nil, err
+	}
+
+	referenced := make(map[string]bool)
+	for i := range backupList.Items {
+		referenced[backupList.Items[i].Status.BackupRootPath] = true
+	}
+	for i := range xstoreBackupList.Items {
+		referenced[xstoreBackupList.Items[i].Status.BackupRootPath] = true
+	}
+	return referenced, nil
+}
+
+// CollectExpiredBackupFiles deletes the files of the backups of the cluster which are deleted while
+// the files are left, e.g. the retention credential is specified afterwards, once they're beyond the
+// retention time of the schedule. It's done at most once an interval and only if the clean policy
+// is Delete.
+var CollectExpiredBackupFiles = polardbxv1reconcile.NewStepBinder("CollectExpiredBackupFiles",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		schedule := rc.MustGetPolarDBXBackupSchedule()
+		spec := &schedule.Spec.BackupSpec
+		if spec.CleanPolicy != polardbxv1.CleanPolicyDelete || spec.StorageProvider.RetentionCredential == nil ||
+			spec.RetentionTime.Duration <= 0 {
+			return flow.Pass()
+		}
+		now := time.Now()
+		if last := schedule.Status.LastGarbageCollectTime; last != nil && now.Sub(last.Time) < GarbageCollectInterval {
+			return flow.Pass()
+		}
+
+		prefix := fmt.Sprintf("%s/%s/", polardbxmeta.BackupPath, spec.Cluster.Name)
+		files, ok, err := polardbxhelper.StatBackupFiles(rc.Context(), rc.Client(), rc.Namespace(), spec.StorageProvider, prefix)
+		if err != nil {
+			return flow.Error(err, "Unable to list backup files.", "prefix", prefix)
+		}
+		if !ok {
+			return flow.Pass()
+		}
+		referenced, err := referencedBackupRoots(rc, spec.Cluster.Name)
+		if err != nil {
+			return flow.Error(err, "Unable to list backups of the cluster.")
+		}
+
+		collected := make([]string, 0)
+		for _, root := range expiredOrphanedBackupRoots(files, prefix, referenced, spec.RetentionTime.Duration, now) {
+			deleted, err := polardbxhelper.RemoveBackupFiles(rc.Context(), rc.Client(), rc.Namespace(),
+				spec.StorageProvider, root+"/")
+			if remote.IsImmutable(err) {
+				flow.Logger().Info("Backup files are locked by the storage, skip.", "path", root)
+				continue
+			} else if err != nil {
+				return flow.Error(err, "Unable to delete expired backup files.", "path", root)
+			}
+			flow.Logger().Info("Expired backup files deleted.", "path", root, "deleted", deleted)
+			collected = append(collected, root)
+		}
+
+		lastGarbageCollectTime := metav1.NewTime(now)
+		schedule.Status.LastGarbageCollectTime = &lastGarbageCollectTime
+		if len(collected) == 0 {
+			collected = nil
+		}
+		schedule.Status.GarbageCollected = collected
+		return flow.Continue("Expired backup files collected.", "collected", len(collected))
+	})
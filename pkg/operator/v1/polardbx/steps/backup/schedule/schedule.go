@@ -0,0 +1,310 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedule
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	// The operator image may not ship the zoneinfo.
+	_ "time/tzdata"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/hpfs/remote"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	polardbxhelper "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+	"github.com/alibaba/polardbx-operator/pkg/util/cron"
+)
+
+func isBackupInProgress(backup *polardbxv1.PolarDBXBackup) bool {
+	return backup.Status.Phase != polardbxv1.BackupFinished && backup.Status.Phase != polardbxv1.BackupFailed &&
+		backup.DeletionTimestamp.IsZero()
+}
+
+func containsString(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+func isProtected(obj metav1.Object) bool {
+	return obj.GetAnnotations()[polardbxmeta.AnnotationBackupProtected] == "true"
+}
+
+// scheduledBackupName returns the name of backup scheduled at the time, which is the same for the
+// same time so that a backup is never taken twice.
+func scheduledBackupName(schedule *polardbxv1.PolarDBXBackupSchedule, scheduled time.Time) string {
+	return schedule.Name + "-" + scheduled.UTC().Format("200601021504")
+}
+
+func newScheduledBackup(schedule *polardbxv1.PolarDBXBackupSchedule, scheduled time.Time) *polardbxv1.PolarDBXBackup {
+	return &polardbxv1.PolarDBXBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      scheduledBackupName(schedule, scheduled),
+			Namespace: schedule.Namespace,
+			Labels: map[string]string{
+				polardbxmeta.LabelBackupSchedule: schedule.Name,
+			},
+			Annotations: map[string]string{
+				polardbxmeta.AnnotationBackupTrigger: string(polardbxv1.BackupTriggerScheduled),
+			},
+		},
+		Spec: *schedule.Spec.BackupSpec.DeepCopy(),
+	}
+}
+
+// latestScheduledTime returns the latest scheduled time after the earliest and not after now, zero
+// if there's none. The missed ones before it are never taken.
+func latestScheduledTime(s *cron.Schedule, earliest, now time.Time) time.Time {
+	var latest time.Time
+	for t := s.Next(earliest); !t.IsZero() && !t.After(now); t = s.Next(t) {
+		latest = t
+	}
+	return latest
+}
+
+// backupsToPrune returns the oldest backups in the phase beyond the count to keep. Zero keeps all.
+func backupsToPrune(backups []polardbxv1.PolarDBXBackup, phase polardbxv1.PolarDBXBackupPhase, keep int32) []*polardbxv1.PolarDBXBackup {
+	if keep <= 0 {
+		return nil
+	}
+	candidates := make([]*polardbxv1.PolarDBXBackup, 0)
+	for i := range backups {
+		if backups[i].Status.Phase == phase && backups[i].DeletionTimestamp.IsZero() {
+			candidates = append(candidates, &backups[i])
+		}
+	}
+	if len(candidates) <= int(keep) {
+		return nil
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		ti, tj := candidates[i].CreationTimestamp, candidates[j].CreationTimestamp
+		if ti.Equal(&tj) {
+			return candidates[i].Name < candidates[j].Name
+		}
+		return ti.Before(&tj)
+	})
+	return candidates[:len(candidates)-int(keep)]
+}
+
+func listScheduledBackups(rc *polardbxv1reconcile.Context, schedule *polardbxv1.PolarDBXBackupSchedule) ([]polardbxv1.PolarDBXBackup, error) {
+	var backupList polardbxv1.PolarDBXBackupList
+	err := rc.Client().List(rc.Context(), &backupList, client.InNamespace(rc.Namespace()), client.MatchingLabels{
+		polardbxmeta.LabelBackupSchedule: schedule.Name,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return backupList.Items, nil
+}
+
+// pruneSkipReasonOf returns why the backup is kept beyond the count, empty if it can be pruned.
+func pruneSkipReasonOf(rc *polardbxv1reconcile.Context, backup *polardbxv1.PolarDBXBackup) (string, error) {
+	if isProtected(backup) {
+		return "protected", nil
+	}
+	if backup.Status.Phase == polardbxv1.BackupFinished && backup.Status.EndTime != nil {
+		until := polardbxhelper.ImmutableUntil(backup.Status.EndTime.Time, backup.Spec.ImmutableUntil,
+			backup.Spec.RetentionTime.Duration, backup.Spec.Retention)
+		if time.Now().Before(until) {
+			return "immutable until " + until.Format(time.RFC3339), nil
+		}
+	}
+	restore, err := polardbxhelper.ActiveRestoreOfPolarDBXBackup(rc.Context(), rc.Client(), backup)
+	if err != nil {
+		return "", err
+	}
+	if len(restore) > 0 {
+		return "referenced by restore " + restore, nil
+	}
+	dependent, err := polardbxhelper.IncrementalDependentOfPolarDBXBackup(rc.Context(), rc.Client(), backup)
+	if err != nil {
+		return "", err
+	}
+	if len(dependent) > 0 {
+		return "base of incremental backup " + dependent, nil
+	}
+	return "", nil
+}
+
+var PersistentStatusChanges = polardbxv1reconcile.NewStepBinder("PersistentStatusChanges",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		if rc.IsPolarDBXBackupScheduleStatusChanged() {
+			if err := rc.UpdatePolarDBXBackupScheduleStatus(); err != nil {
+				return flow.Error(err, "Unable to update status for backup schedule.")
+			}
+			return flow.Continue("Backup schedule status updated!")
+		}
+		return flow.Continue("Backup schedule status not changed!")
+	})
+
+// PruneScheduledBackups deletes the oldest finished and failed backups of the schedule beyond the
+// counts to keep, along with their files if the retention credential is specified.
+var PruneScheduledBackups = polardbxv1reconcile.NewStepBinder("PruneScheduledBackups",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		schedule := rc.MustGetPolarDBXBackupSchedule()
+		backups, err := listScheduledBackups(rc, schedule)
+		if err != nil {
+			return flow.Error(err, "Unable to list scheduled backups.")
+		}
+
+		toPrune := append(backupsToPrune(backups, polardbxv1.BackupFinished, schedule.Spec.MaxBackupCount),
+			backupsToPrune(backups, polardbxv1.BackupFailed, schedule.Spec.MaxFailedBackupCount)...)
+		for _, backup := range toPrune {
+			reason, err := pruneSkipReasonOf(rc, backup)
+			if err != nil {
+				return flow.Error(err, "Unable to determine whether the backup can be pruned.", "backup", backup.Name)
+			}
+			if len(reason) > 0 {
+				flow.Logger().Info("Backup kept beyond the count.", "backup", backup.Name, "reason", reason)
+				continue
+			}
+			if backup.Spec.StorageProvider.RetentionCredential != nil && len(backup.Status.BackupRootPath) > 0 {
+				deleted, err := polardbxhelper.RemoveBackupFiles(rc.Context(), rc.Client(), rc.Namespace(),
+					backup.Spec.StorageProvider, backup.Status.BackupRootPath+"/")
+				if remote.IsImmutable(err) {
+					flow.Logger().Info("Backup files are locked by the storage, kept beyond the count.", "backup", backup.Name)
+					continue
+				} else if err != nil {
+					return flow.Error(err, "Unable to delete backup files.", "backup", backup.Name)
+				}
+				flow.Logger().Info("Backup files deleted.", "backup", backup.Name, "deleted", deleted)
+			}
+			if err := rc.Client().Delete(rc.Context(), backup); client.IgnoreNotFound(err) != nil {
+				return flow.Error(err, "Unable to delete backup.", "backup", backup.Name)
+			}
+			flow.Logger().Info("Backup pruned.", "backup", backup.Name, "phase", backup.Status.Phase)
+		}
+
+		active := make([]string, 0)
+		for i := range backups {
+			if isBackupInProgress(&backups[i]) {
+				active = append(active, backups[i].Name)
+			}
+		}
+		sort.Strings(active)
+		if len(active) == 0 {
+			active = nil
+		}
+		schedule.Status.Active = active
+		return flow.Continue("Scheduled backups pruned.")
+	})
+
+func skipScheduledBackup(schedule *polardbxv1.PolarDBXBackupSchedule, flow control.Flow, scheduled, next time.Time,
+	message string) (reconcile.Result, error) {
+	lastScheduleTime := metav1.NewTime(scheduled)
+	schedule.Status.LastScheduleTime = &lastScheduleTime
+	schedule.Status.Message = fmt.Sprintf("backup scheduled at %s skipped: %s", scheduled.Format(time.RFC3339), message)
+	return flow.RetryAfter(time.Until(next), "Scheduled backup skipped.", "reason", message, "next", next)
+}
+
+// ScheduleBackup takes the latest backup scheduled since the last one, if any, with the concurrency
+// policy applied, and requeues at the next scheduled time.
+var ScheduleBackup = polardbxv1reconcile.NewStepBinder("ScheduleBackup",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		schedule := rc.MustGetPolarDBXBackupSchedule()
+		if schedule.Spec.Suspend {
+			schedule.Status.NextScheduleTime = nil
+			return flow.Continue("Backup schedule is suspended.")
+		}
+
+		cronSchedule, err := cron.Parse(schedule.Spec.Schedule)
+		if err != nil {
+			schedule.Status.NextScheduleTime = nil
+			schedule.Status.Message = "invalid schedule: " + err.Error()
+			return flow.Continue("Invalid schedule.", "error", err.Error())
+		}
+		loc, err := time.LoadLocation(schedule.Spec.TimeZone)
+		if err != nil {
+			schedule.Status.NextScheduleTime = nil
+			schedule.Status.Message = "invalid time zone: " + err.Error()
+			return flow.Continue("Invalid time zone.", "error", err.Error())
+		}
+
+		now := time.Now().In(loc)
+		next := cronSchedule.Next(now)
+		if next.IsZero() {
+			schedule.Status.NextScheduleTime = nil
+			schedule.Status.Message = "invalid schedule: no time matches"
+			return flow.Continue("No time matches the schedule.")
+		}
+		nextScheduleTime := metav1.NewTime(next)
+		schedule.Status.NextScheduleTime = &nextScheduleTime
+
+		earliest := schedule.CreationTimestamp.Time
+		if schedule.Status.LastScheduleTime != nil {
+			earliest = schedule.Status.LastScheduleTime.Time
+		}
+		scheduled := latestScheduledTime(cronSchedule, earliest.In(loc), now)
+		if scheduled.IsZero() {
+			return flow.RetryAfter(time.Until(next), "Wait for the next scheduled time.", "next", next)
+		}
+
+		if deadline := schedule.Spec.StartingDeadline.Duration; deadline > 0 && now.Sub(scheduled) > deadline {
+			return skipScheduledBackup(schedule, flow, scheduled, next, "starting deadline exceeded")
+		}
+		if _, err := rc.GetPolarDBX(); apierrors.IsNotFound(err) {
+			return skipScheduledBackup(schedule, flow, scheduled, next, "cluster not found")
+		} else if err != nil {
+			return flow.Error(err, "Unable to get polardbx cluster.")
+		}
+
+		backup := newScheduledBackup(schedule, scheduled)
+		if len(schedule.Status.Active) > 0 && !containsString(schedule.Status.Active, backup.Name) {
+			switch schedule.Spec.ConcurrencyPolicy {
+			case polardbxv1.BackupConcurrencyAllow:
+				// Taken along with the ones in progress.
+			case polardbxv1.BackupConcurrencyReplace:
+				for _, name := range schedule.Status.Active {
+					backup := &polardbxv1.PolarDBXBackup{}
+					backup.Name, backup.Namespace = name, rc.Namespace()
+					if err := rc.Client().Delete(rc.Context(), backup); client.IgnoreNotFound(err) != nil {
+						return flow.Error(err, "Unable to delete backup in progress.", "backup", name)
+					}
+					flow.Logger().Info("Backup in progress replaced.", "backup", name)
+				}
+				schedule.Status.Active = nil
+			default:
+				return skipScheduledBackup(schedule, flow, scheduled, next,
+					"backups in progress: "+strings.Join(schedule.Status.Active, ","))
+			}
+		}
+
+		// It's already created if the status failed to update.
+		if err := rc.Client().Create(rc.Context(), backup); err != nil && !apierrors.IsAlreadyExists(err) {
+			return flow.Error(err, "Unable to create scheduled backup.", "backup", backup.Name)
+		}
+		lastScheduleTime := metav1.NewTime(scheduled)
+		schedule.Status.LastScheduleTime = &lastScheduleTime
+		schedule.Status.LastBackup = backup.Name
+		if !containsString(schedule.Status.Active, backup.Name) {
+			schedule.Status.Active = append(schedule.Status.Active, backup.Name)
+		}
+		schedule.Status.Message = ""
+		return flow.RetryAfter(time.Until(next), "Scheduled backup created.", "backup", backup.Name, "next", next)
+	})
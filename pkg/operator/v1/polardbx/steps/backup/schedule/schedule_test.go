@@ -0,0 +1,118 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/hpfs/remote"
+	"github.com/alibaba/polardbx-operator/pkg/util/cron"
+)
+
+func TestLatestScheduledTime(t *testing.T) {
+	s, err := cron.Parse("0 * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	earliest := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	if latest := latestScheduledTime(s, earliest, earliest.Add(30*time.Minute)); !latest.IsZero() {
+		t.Fatalf("expect none, got %s", latest)
+	}
+	if latest := latestScheduledTime(s, earliest, earliest.Add(time.Hour)); !latest.Equal(earliest.Add(time.Hour)) {
+		t.Fatalf("expect the scheduled time on now, got %s", latest)
+	}
+	if latest := latestScheduledTime(s, earliest, earliest.Add(5*time.Hour+time.Minute)); !latest.Equal(earliest.Add(5 * time.Hour)) {
+		t.Fatalf("expect only the latest missed one, got %s", latest)
+	}
+}
+
+func TestBackupsToPrune(t *testing.T) {
+	base := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+	newBackup := func(name string, phase polardbxv1.PolarDBXBackupPhase, age int) polardbxv1.PolarDBXBackup {
+		b := polardbxv1.PolarDBXBackup{}
+		b.Name = name
+		b.CreationTimestamp = metav1.NewTime(base.Add(-time.Duration(age) * time.Hour))
+		b.Status.Phase = phase
+		return b
+	}
+	backups := []polardbxv1.PolarDBXBackup{
+		newBackup("b2", polardbxv1.BackupFinished, 2),
+		newBackup("b4", polardbxv1.BackupFinished, 4),
+		newBackup("b1", polardbxv1.BackupFinished, 1),
+		newBackup("b3", polardbxv1.BackupFinished, 3),
+		newBackup("f1", polardbxv1.BackupFailed, 1),
+		newBackup("r0", polardbxv1.BinlogBackuping, 0),
+	}
+
+	toPrune := backupsToPrune(backups, polardbxv1.BackupFinished, 2)
+	if len(toPrune) != 2 || toPrune[0].Name != "b4" || toPrune[1].Name != "b3" {
+		t.Fatalf("expect the oldest two pruned, got %v", toPrune)
+	}
+	if toPrune := backupsToPrune(backups, polardbxv1.BackupFinished, 0); len(toPrune) != 0 {
+		t.Fatalf("expect none pruned without limit, got %v", toPrune)
+	}
+	if toPrune := backupsToPrune(backups, polardbxv1.BackupFailed, 1); len(toPrune) != 0 {
+		t.Fatalf("expect none pruned within limit, got %v", toPrune)
+	}
+}
+
+func TestNewScheduledBackup(t *testing.T) {
+	schedule := &polardbxv1.PolarDBXBackupSchedule{}
+	schedule.Name, schedule.Namespace = "daily", "ns"
+	schedule.Spec.BackupSpec.Cluster.Name = "pxc"
+	schedule.Spec.BackupSpec.BackupType = polardbxv1.BackupTypeIncremental
+
+	backup := newScheduledBackup(schedule, time.Date(2022, 3, 1, 2, 0, 0, 0, time.FixedZone("UTC+8", 8*3600)))
+	if backup.Name != "daily-202202281800" || backup.Namespace != "ns" {
+		t.Fatalf("unexpected backup: %s/%s", backup.Namespace, backup.Name)
+	}
+	if backup.Spec.Cluster.Name != "pxc" || backup.Spec.BackupType != polardbxv1.BackupTypeIncremental {
+		t.Fatalf("unexpected spec: %+v", backup.Spec)
+	}
+	if backup.Annotations["polardbx/backup.trigger"] != string(polardbxv1.BackupTriggerScheduled) {
+		t.Fatal("expect triggered by schedule")
+	}
+}
+
+func TestExpiredOrphanedBackupRoots(t *testing.T) {
+	prefix := "polardbx-backup/pxc/"
+	files := []remote.FileStat{
+		{Path: prefix + "b1-20220301000000/fullbackup/dn-0.xbstream"},
+		{Path: prefix + "b1-20220301000000/binlogbackup/dn-0/mysql_bin.000001"},
+		{Path: prefix + "b2-20220305000000/fullbackup/dn-0.xbstream"},
+		{Path: prefix + "b3-20220301000000/fullbackup/dn-0.xbstream"},
+		{Path: prefix + "unknown/fullbackup/dn-0.xbstream"},
+		{Path: prefix + "manifest.json"},
+		{Path: "polardbx-backup/another/b4-20220301000000/fullbackup/dn-0.xbstream"},
+	}
+	referenced := map[string]bool{prefix + "b3-20220301000000": true}
+	now := time.Date(2022, 3, 8, 0, 0, 0, 0, time.Local)
+
+	roots := expiredOrphanedBackupRoots(files, prefix, referenced, 5*24*time.Hour, now)
+	if len(roots) != 1 || roots[0] != prefix+"b1-20220301000000" {
+		t.Fatalf("expect only b1 expired, got %v", roots)
+	}
+	roots = expiredOrphanedBackupRoots(files, prefix, referenced, time.Hour, now)
+	if len(roots) != 2 || roots[0] != prefix+"b1-20220301000000" || roots[1] != prefix+"b2-20220305000000" {
+		t.Fatalf("expect b1 and b2 expired, got %v", roots)
+	}
+}
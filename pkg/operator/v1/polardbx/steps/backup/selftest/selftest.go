@@ -0,0 +1,353 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package selftest
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1polardbx "github.com/alibaba/polardbx-operator/api/v1/polardbx"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+)
+
+const restoreTimeLayout = "2006-01-02 15:04:05"
+
+// Schemas of the system, they're not verified.
+var systemSchemas = map[string]bool{
+	"information_schema": true,
+	"mysql":              true,
+	"performance_schema": true,
+	"sys":                true,
+	"__cdc__":            true,
+}
+
+func BackupNameOf(selfTest *polardbxv1.PolarDBXBackupSelfTest) string {
+	return selfTest.Name + "-backup"
+}
+
+func RestoredClusterNameOf(selfTest *polardbxv1.PolarDBXBackupSelfTest) string {
+	return selfTest.Name + "-restore"
+}
+
+// missingSchemas returns the expected user schemas which are not found.
+func missingSchemas(expected, found []string) []string {
+	foundSet := make(map[string]bool, len(found))
+	for _, s := range found {
+		foundSet[strings.ToLower(s)] = true
+	}
+	missing := make([]string, 0)
+	for _, s := range expected {
+		if !systemSchemas[strings.ToLower(s)] && !foundSet[strings.ToLower(s)] {
+			missing = append(missing, s)
+		}
+	}
+	return missing
+}
+
+// transferPhase moves the self test to the phase and records the duration of the stage just over.
+func transferPhase(selfTest *polardbxv1.PolarDBXBackupSelfTest, phase polardbxv1.PolarDBXBackupSelfTestPhase, now metav1.Time) {
+	if selfTest.Status.PhaseStartTime != nil {
+		elapsed := now.Sub(selfTest.Status.PhaseStartTime.Time).Truncate(time.Second).String()
+		switch selfTest.Status.Phase {
+		case polardbxv1.SelfTestBackingUp:
+			selfTest.Status.Timings.Backup = elapsed
+		case polardbxv1.SelfTestRestoring:
+			selfTest.Status.Timings.Restore = elapsed
+		case polardbxv1.SelfTestVerifying:
+			selfTest.Status.Timings.Verify = elapsed
+		}
+	}
+	selfTest.Status.Phase = phase
+	selfTest.Status.PhaseStartTime = &now
+}
+
+// failSelfTest marks the self test failed in the current phase and goes to clean the objects created.
+func failSelfTest(selfTest *polardbxv1.PolarDBXBackupSelfTest, flow control.Flow, message string) (reconcile.Result, error) {
+	selfTest.Status.Result = polardbxv1.SelfTestFailed
+	selfTest.Status.FailedPhase = selfTest.Status.Phase
+	selfTest.Status.Message = message
+	transferPhase(selfTest, polardbxv1.SelfTestCleaning, metav1.Now())
+	return flow.Retry("Self test failed.", "reason", message)
+}
+
+var PersistentStatusChanges = polardbxv1reconcile.NewStepBinder("PersistentStatusChanges",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		if rc.IsPolarDBXBackupSelfTestStatusChanged() {
+			if err := rc.UpdatePolarDBXBackupSelfTestStatus(); err != nil {
+				return flow.Error(err, "Unable to update status for backup self test.")
+			}
+			return flow.Continue("Backup self test status updated!")
+		}
+		return flow.Continue("Backup self test status not changed!")
+	})
+
+// CheckSelfTestTimeout fails the self test if it's not done within the timeout.
+var CheckSelfTestTimeout = polardbxv1reconcile.NewStepBinder("CheckSelfTestTimeout",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		selfTest := rc.MustGetPolarDBXBackupSelfTest()
+		if selfTest.Status.StartTime == nil || len(selfTest.Status.Result) > 0 || selfTest.Spec.Timeout.Duration <= 0 {
+			return flow.Pass()
+		}
+		if time.Since(selfTest.Status.StartTime.Time) > selfTest.Spec.Timeout.Duration {
+			return failSelfTest(selfTest, flow, "timeout after "+selfTest.Spec.Timeout.Duration.String())
+		}
+		return flow.Pass()
+	})
+
+// StartSelfTest waits until the cluster to test is running and records its schemas.
+var StartSelfTest = polardbxv1reconcile.NewStepBinder("StartSelfTest",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		selfTest := rc.MustGetPolarDBXBackupSelfTest()
+		if selfTest.Status.StartTime == nil {
+			now := metav1.Now()
+			selfTest.Status.StartTime = &now
+		}
+
+		polardbx, err := rc.GetPolarDBX()
+		if apierrors.IsNotFound(err) {
+			return failSelfTest(selfTest, flow, "cluster not found: "+selfTest.Spec.Cluster.Name)
+		} else if err != nil {
+			return flow.Error(err, "Unable to get polardbx cluster.")
+		}
+		if polardbx.Status.Phase != polardbxv1polardbx.PhaseRunning {
+			return flow.RetryAfter(10*time.Second, "Wait until cluster is running.", "phase", polardbx.Status.Phase)
+		}
+
+		groupManager, err := rc.GetPolarDBXGroupManager()
+		if err != nil {
+			return flow.Error(err, "Unable to get group manager.")
+		}
+		schemas, err := groupManager.ListSchemas()
+		if err != nil {
+			return flow.Error(err, "Unable to list schemas of cluster.")
+		}
+		selfTest.Status.Schemas = schemas
+
+		transferPhase(selfTest, polardbxv1.SelfTestBackingUp, metav1.Now())
+		return flow.Retry("Self test started!", "cluster", polardbx.Name)
+	})
+
+// CreateSelfTestBackup creates the full backup of the cluster if not found.
+var CreateSelfTestBackup = polardbxv1reconcile.NewStepBinder("CreateSelfTestBackup",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		selfTest := rc.MustGetPolarDBXBackupSelfTest()
+		backup := &polardbxv1.PolarDBXBackup{}
+		err := rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: selfTest.Namespace, Name: BackupNameOf(selfTest)}, backup)
+		if err == nil {
+			return flow.Pass()
+		} else if !apierrors.IsNotFound(err) {
+			return flow.Error(err, "Unable to get backup of self test.")
+		}
+
+		backup = &polardbxv1.PolarDBXBackup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      BackupNameOf(selfTest),
+				Namespace: selfTest.Namespace,
+				Labels: map[string]string{
+					polardbxmeta.LabelBackupSelfTest: selfTest.Name,
+				},
+			},
+			Spec: polardbxv1.PolarDBXBackupSpec{
+				Cluster: polardbxv1.PolarDBXClusterReference{
+					Name: selfTest.Spec.Cluster.Name,
+				},
+				RetentionTime:   selfTest.Spec.BackupRetentionTime,
+				StorageProvider: selfTest.Spec.StorageProvider,
+			},
+		}
+		if err := rc.SetControllerRefAndCreateToBackupSelfTest(backup); err != nil {
+			return flow.Error(err, "Unable to create backup of self test.")
+		}
+		selfTest.Status.Backup = backup.Name
+		return flow.Continue("Backup of self test created!", "backup", backup.Name)
+	})
+
+// WaitUntilSelfTestBackupFinished waits until the backup finishes and records the time to restore to.
+var WaitUntilSelfTestBackupFinished = polardbxv1reconcile.NewStepBinder("WaitUntilSelfTestBackupFinished",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		selfTest := rc.MustGetPolarDBXBackupSelfTest()
+		backup := &polardbxv1.PolarDBXBackup{}
+		err := rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: selfTest.Namespace, Name: BackupNameOf(selfTest)}, backup)
+		if err != nil {
+			return flow.Error(err, "Unable to get backup of self test.")
+		}
+
+		switch backup.Status.Phase {
+		case polardbxv1.BackupFinished:
+			if backup.Status.LatestRecoverableTimestamp == nil {
+				return failSelfTest(selfTest, flow, "latest recoverable timestamp of backup not found")
+			}
+			selfTest.Status.RestoreTime = backup.Status.LatestRecoverableTimestamp.UTC().Format(restoreTimeLayout)
+			transferPhase(selfTest, polardbxv1.SelfTestRestoring, metav1.Now())
+			return flow.Retry("Backup of self test finished!", "backup", backup.Name)
+		case polardbxv1.BackupFailed:
+			return failSelfTest(selfTest, flow, "backup failed: "+backup.Status.Reason)
+		default:
+			return flow.RetryAfter(30*time.Second, "Wait until backup finished.", "backup", backup.Name, "phase", backup.Status.Phase)
+		}
+	})
+
+// CreateSelfTestRestoredCluster creates the cluster restored to the latest recoverable time of the
+// backup if not found. The spec follows the cluster when it's backed up.
+var CreateSelfTestRestoredCluster = polardbxv1reconcile.NewStepBinder("CreateSelfTestRestoredCluster",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		selfTest := rc.MustGetPolarDBXBackupSelfTest()
+		polardbx := &polardbxv1.PolarDBXCluster{}
+		err := rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: selfTest.Namespace, Name: RestoredClusterNameOf(selfTest)}, polardbx)
+		if err == nil {
+			return flow.Pass()
+		} else if !apierrors.IsNotFound(err) {
+			return flow.Error(err, "Unable to get restored cluster of self test.")
+		}
+
+		backup := &polardbxv1.PolarDBXBackup{}
+		err = rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: selfTest.Namespace, Name: BackupNameOf(selfTest)}, backup)
+		if err != nil {
+			return flow.Error(err, "Unable to get backup of self test.")
+		}
+		if backup.Status.ClusterSpecSnapshot == nil {
+			return failSelfTest(selfTest, flow, "cluster spec snapshot of backup not found")
+		}
+
+		spec := backup.Status.ClusterSpecSnapshot.DeepCopy()
+		// Readonly clusters are not verified.
+		spec.InitReadonly = nil
+		spec.Restore = &polardbxv1polardbx.RestoreSpec{
+			BackupSet: backup.Name,
+			From: polardbxv1polardbx.PolarDBXRestoreFrom{
+				PolarBDXName: selfTest.Spec.Cluster.Name,
+			},
+			Time:     selfTest.Status.RestoreTime,
+			TimeZone: "UTC",
+		}
+		polardbx = &polardbxv1.PolarDBXCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      RestoredClusterNameOf(selfTest),
+				Namespace: selfTest.Namespace,
+				Labels: map[string]string{
+					polardbxmeta.LabelBackupSelfTest: selfTest.Name,
+				},
+			},
+			Spec: *spec,
+		}
+		if err := rc.SetControllerRefAndCreateToBackupSelfTest(polardbx); err != nil {
+			return flow.Error(err, "Unable to create restored cluster of self test.")
+		}
+		selfTest.Status.RestoredCluster = polardbx.Name
+		return flow.Continue("Restored cluster of self test created!", "cluster", polardbx.Name)
+	})
+
+// WaitUntilSelfTestClusterRestored waits until the restored cluster is running.
+var WaitUntilSelfTestClusterRestored = polardbxv1reconcile.NewStepBinder("WaitUntilSelfTestClusterRestored",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		selfTest := rc.MustGetPolarDBXBackupSelfTest()
+		polardbx := &polardbxv1.PolarDBXCluster{}
+		err := rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: selfTest.Namespace, Name: RestoredClusterNameOf(selfTest)}, polardbx)
+		if err != nil {
+			return flow.Error(err, "Unable to get restored cluster of self test.")
+		}
+
+		switch polardbx.Status.Phase {
+		case polardbxv1polardbx.PhaseRunning:
+			transferPhase(selfTest, polardbxv1.SelfTestVerifying, metav1.Now())
+			return flow.Retry("Cluster of self test restored!", "cluster", polardbx.Name)
+		case polardbxv1polardbx.PhaseFailed:
+			return failSelfTest(selfTest, flow, "restore failed, cluster "+polardbx.Name+" is failed")
+		default:
+			return flow.RetryAfter(30*time.Second, "Wait until cluster restored.", "cluster", polardbx.Name, "phase", polardbx.Status.Phase)
+		}
+	})
+
+// VerifySelfTestRestoredCluster verifies that the schemas of the tested cluster are all restored.
+// The polardbx key of the context must point to the restored cluster.
+var VerifySelfTestRestoredCluster = polardbxv1reconcile.NewStepBinder("VerifySelfTestRestoredCluster",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		selfTest := rc.MustGetPolarDBXBackupSelfTest()
+		groupManager, err := rc.GetPolarDBXGroupManager()
+		if err != nil {
+			return flow.Error(err, "Unable to get group manager of restored cluster.")
+		}
+		schemas, err := groupManager.ListSchemas()
+		if err != nil {
+			return flow.Error(err, "Unable to list schemas of restored cluster.")
+		}
+		if missing := missingSchemas(selfTest.Status.Schemas, schemas); len(missing) > 0 {
+			return failSelfTest(selfTest, flow, "schemas not restored: "+strings.Join(missing, ","))
+		}
+
+		selfTest.Status.Result = polardbxv1.SelfTestPassed
+		transferPhase(selfTest, polardbxv1.SelfTestCleaning, metav1.Now())
+		return flow.Retry("Restored cluster of self test verified!")
+	})
+
+func deleteAndCheckGone(rc *polardbxv1reconcile.Context, obj client.Object) (bool, error) {
+	err := rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}, obj)
+	if apierrors.IsNotFound(err) {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+	if obj.GetDeletionTimestamp().IsZero() {
+		err = rc.Client().Delete(rc.Context(), obj, client.PropagationPolicy(metav1.DeletePropagationBackground))
+		if client.IgnoreNotFound(err) != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// CleanSelfTestObjects removes the restored cluster and the backup no matter what the result is, and
+// finishes the self test once they're gone.
+var CleanSelfTestObjects = polardbxv1reconcile.NewStepBinder("CleanSelfTestObjects",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		selfTest := rc.MustGetPolarDBXBackupSelfTest()
+		// The restored cluster is removed first since it's restored from the backup.
+		objects := []client.Object{
+			&polardbxv1.PolarDBXCluster{ObjectMeta: metav1.ObjectMeta{Namespace: selfTest.Namespace, Name: RestoredClusterNameOf(selfTest)}},
+			&polardbxv1.PolarDBXBackup{ObjectMeta: metav1.ObjectMeta{Namespace: selfTest.Namespace, Name: BackupNameOf(selfTest)}},
+		}
+		for _, obj := range objects {
+			gone, err := deleteAndCheckGone(rc, obj)
+			if err != nil {
+				return flow.Error(err, "Unable to remove object of self test.", "name", obj.GetName())
+			}
+			if !gone {
+				return flow.RetryAfter(10*time.Second, "Wait until object of self test removed.", "name", obj.GetName())
+			}
+		}
+
+		if len(selfTest.Status.Result) == 0 {
+			return flow.Error(errors.New("result not found"), "Unable to finish self test.")
+		}
+		now := metav1.Now()
+		transferPhase(selfTest, polardbxv1.SelfTestFinished, now)
+		selfTest.Status.EndTime = &now
+		if selfTest.Status.StartTime != nil {
+			selfTest.Status.Timings.Total = now.Sub(selfTest.Status.StartTime.Time).Truncate(time.Second).String()
+		}
+		return flow.Continue("Self test finished!", "result", selfTest.Status.Result)
+	})
@@ -0,0 +1,45 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package selftest
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+)
+
+func TestMissingSchemas(t *testing.T) {
+	missing := missingSchemas([]string{"information_schema", "db1", "DB2", "db3"}, []string{"db1", "db2"})
+	if len(missing) != 1 || missing[0] != "db3" {
+		t.Fatalf("expect db3 missing, got %v", missing)
+	}
+}
+
+func TestTransferPhase(t *testing.T) {
+	start := metav1.NewTime(time.Unix(1000, 0))
+	selfTest := &polardbxv1.PolarDBXBackupSelfTest{}
+	selfTest.Status.Phase = polardbxv1.SelfTestBackingUp
+	selfTest.Status.PhaseStartTime = &start
+
+	transferPhase(selfTest, polardbxv1.SelfTestRestoring, metav1.NewTime(start.Add(90*time.Second)))
+	if selfTest.Status.Timings.Backup != "1m30s" || selfTest.Status.Phase != polardbxv1.SelfTestRestoring {
+		t.Fatalf("unexpected status: %+v", selfTest.Status)
+	}
+}
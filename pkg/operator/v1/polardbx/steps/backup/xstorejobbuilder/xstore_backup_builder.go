@@ -24,6 +24,14 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
+// throttleOf returns the throttle of the xstore, the override of which takes precedence.
+func throttleOf(backup *polardbxv1.PolarDBXBackup, xstoreName string) *polardbxv1.BackupThrottle {
+	if throttle, ok := backup.Spec.ThrottleOverrides[xstoreName]; ok {
+		return &throttle
+	}
+	return backup.Spec.Throttle.DeepCopy()
+}
+
 func NewXStoreBackup(scheme *runtime.Scheme, backup *polardbxv1.PolarDBXBackup, xstore *polardbxv1.XStore) (*polardbxv1.XStoreBackup, error) {
 
 	xstoreBackup := &polardbxv1.XStoreBackup{
@@ -41,11 +49,45 @@ func NewXStoreBackup(scheme *runtime.Scheme, backup *polardbxv1.PolarDBXBackup,
 			XStore: polardbxv1.XStoreReference{
 				Name: xstore.Name,
 			},
-			RetentionTime:   backup.Spec.RetentionTime,
-			StorageProvider: backup.Spec.StorageProvider,
+			RetentionTime:           backup.Spec.RetentionTime,
+			Retention:               backup.Spec.Retention,
+			StorageProvider:         backup.Spec.StorageProvider,
+			EnableDedupReport:       backup.Spec.EnableDedupReport,
+			PreferSourceZone:        backup.Spec.PreferSourceZone,
+			OverlapCollect:          backup.Spec.OverlapCollect,
+			MaxFollowerLag:          backup.Spec.MaxFollowerLag,
+			FallbackToLeaderOnLag:   backup.Spec.FallbackToLeaderOnLag,
+			PreferredBackupRole:     backup.Spec.PreferredBackupRole,
+			EphemeralLearner:        backup.Spec.EphemeralLearner,
+			SkipEmptyBinlog:         backup.Spec.SkipEmptyBinlog,
+			FailedArtifactRetention: backup.Spec.FailedArtifactRetention,
+			CircuitBreakerThreshold: backup.Spec.CircuitBreakerThreshold,
+			StorageClass:            backup.Spec.StorageClass,
+			FullBackupThreads:       backup.Spec.FullBackupThreads,
+			CollectBatchBytes:       backup.Spec.CollectBatchBytes,
+			UploadRetry:             backup.Spec.UploadRetry,
+			ConsistencyWait:         backup.Spec.ConsistencyWait,
+			Catalog:                 backup.Spec.Catalog,
+			TopologyChangePolicy:    backup.Spec.TopologyChangePolicy,
+			ConsistencyMode:         backup.Spec.ConsistencyMode,
+			ImmutableUntil:          backup.Spec.ImmutableUntil,
+			BackupType:              backup.Spec.BackupType,
+			BackupEngine:            backup.Spec.Engine,
+			VolumeSnapshotClassName: backup.Spec.VolumeSnapshotClassName,
+			JobVersionPolicy:        backup.Spec.JobVersionPolicy,
+			Encryption:              backup.Spec.Encryption,
+			Verification:            backup.Spec.Verification,
+			Throttle:                throttleOf(backup, xstore.Name),
+			Hooks:                   backup.Spec.Hooks,
+			JobTemplate:             backup.Spec.JobTemplate,
+			Notifications:           backup.Spec.Notifications,
 		},
 	}
 
+	if source, ok := backup.Labels[meta.LabelBackupTrigger]; ok {
+		xstoreBackup.Labels[meta.LabelBackupTrigger] = source
+	}
+
 	// set preferred backup node
 	if node, ok := backup.Labels[meta.LabelPreferredBackupNode]; ok {
 		xstoreBackup.Labels[meta.LabelPreferredBackupNode] = node
@@ -56,3 +98,42 @@ func NewXStoreBackup(scheme *runtime.Scheme, backup *polardbxv1.PolarDBXBackup,
 	}
 	return xstoreBackup, nil
 }
+
+// NewXStoreBackupCopy builds a clone of the xstore backup of the source for the copy backup. The
+// clone keeps the spec of source except for the retention and storage, which follow the copy.
+func NewXStoreBackupCopy(scheme *runtime.Scheme, backup *polardbxv1.PolarDBXBackup, source *polardbxv1.XStoreBackup) (*polardbxv1.XStoreBackup, error) {
+	labels := make(map[string]string)
+	for k, v := range source.Labels {
+		labels[k] = v
+	}
+	labels[meta.LabelName] = backup.Spec.Cluster.Name
+	labels[meta.LabelTopBackup] = backup.Name
+	if source, ok := backup.Labels[meta.LabelBackupTrigger]; ok {
+		labels[meta.LabelBackupTrigger] = source
+	}
+
+	spec := source.Spec.DeepCopy()
+	spec.RetentionTime = backup.Spec.RetentionTime
+	// Copies are managed by their own retention time, never by the budget of the cluster.
+	spec.Retention = polardbxv1.BackupRetention{Immutable: backup.Spec.Retention.Immutable}
+	spec.StorageProvider = backup.Spec.StorageProvider
+	spec.FailedArtifactRetention = backup.Spec.FailedArtifactRetention
+	spec.ImmutableUntil = backup.Spec.ImmutableUntil
+	spec.CircuitBreakerThreshold = backup.Spec.CircuitBreakerThreshold
+	// Server side copies are in the default storage class.
+	spec.StorageClass = ""
+	spec.CopyFrom = &polardbxv1.BackupCopySource{BackupName: source.Name}
+
+	xstoreBackup := &polardbxv1.XStoreBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: backup.Namespace,
+			Name:      backup.Name + "-" + source.Spec.XStore.Name,
+			Labels:    labels,
+		},
+		Spec: *spec,
+	}
+	if err := ctrl.SetControllerReference(backup, xstoreBackup, scheme); err != nil {
+		return nil, err
+	}
+	return xstoreBackup, nil
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package xstorebackup
+
+import (
+	"testing"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+)
+
+func TestThrottleOf(t *testing.T) {
+	backup := &polardbxv1.PolarDBXBackup{}
+	if throttle := throttleOf(backup, "pxc-dn-0"); throttle != nil {
+		t.Fatalf("expect no throttle, got %+v", throttle)
+	}
+
+	backup.Spec.Throttle = &polardbxv1.BackupThrottle{MaxIOPS: 100, MaxBandwidthMB: 50}
+	backup.Spec.ThrottleOverrides = map[string]polardbxv1.BackupThrottle{
+		"pxc-dn-1": {MaxBandwidthMB: 200},
+	}
+	throttle := throttleOf(backup, "pxc-dn-0")
+	if throttle == nil || *throttle != *backup.Spec.Throttle {
+		t.Fatalf("expect throttle of backup, got %+v", throttle)
+	}
+	if throttle == backup.Spec.Throttle {
+		t.Fatal("expect throttle copied")
+	}
+	throttle = throttleOf(backup, "pxc-dn-1")
+	if throttle == nil || throttle.MaxIOPS != 0 || throttle.MaxBandwidthMB != 200 {
+		t.Fatalf("expect override taking the place of throttle, got %+v", throttle)
+	}
+}
@@ -0,0 +1,348 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1polardbx "github.com/alibaba/polardbx-operator/api/v1/polardbx"
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/featuregate"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/convention"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/factory"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
+	copyutil "github.com/alibaba/polardbx-operator/pkg/util/copy"
+)
+
+const defaultCanarySoakPeriod = 5 * time.Minute
+
+func canarySoakPeriod(polardbx *polardbxv1.PolarDBXCluster) time.Duration {
+	if polardbx.Spec.Canary != nil && polardbx.Spec.Canary.SoakPeriod != nil {
+		return polardbx.Spec.Canary.SoakPeriod.Duration
+	}
+	return defaultCanarySoakPeriod
+}
+
+func isCanaryPaused(polardbx *polardbxv1.PolarDBXCluster) bool {
+	return polardbx.Spec.Canary != nil && polardbx.Spec.Canary.Pause
+}
+
+// newCanaryDeployment returns a deployment of one replica from the new deployment of the group. Its pods
+// serve along with the old ones and are selected by the canary label only.
+func newCanaryDeployment(deployment *appsv1.Deployment) *appsv1.Deployment {
+	canaryLabels := map[string]string{polardbxmeta.LabelCanary: "true"}
+	canary := deployment.DeepCopy()
+	canary.Name = deployment.Name + "-canary"
+	canary.ResourceVersion = ""
+	canary.Labels = k8shelper.PatchLabels(copyutil.CopyStrMap(deployment.Labels), canaryLabels)
+	canary.Spec.Replicas = pointer.Int32(1)
+	canary.Spec.Selector = &metav1.LabelSelector{
+		MatchLabels: k8shelper.PatchLabels(copyutil.CopyStrMap(deployment.Spec.Selector.MatchLabels), canaryLabels),
+	}
+	canary.Spec.Template.Labels = k8shelper.PatchLabels(copyutil.CopyStrMap(deployment.Spec.Template.Labels), canaryLabels)
+	return canary
+}
+
+// canaryPodsHealth returns whether the canary pods are ready and the reason if they are broken, i.e.
+// any container restarted.
+func canaryPodsHealth(pods []corev1.Pod) (bool, string) {
+	if len(pods) == 0 {
+		return false, ""
+	}
+	for _, pod := range pods {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.RestartCount > 0 {
+				return false, fmt.Sprintf("container %s of canary pod %s restarted", status.Name, pod.Name)
+			}
+		}
+		if !k8shelper.IsPodReady(&pod) {
+			return false, ""
+		}
+	}
+	return true, ""
+}
+
+var StartCanaryUpgrade = polardbxv1reconcile.NewStepBinder("StartCanaryUpgrade",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		polardbx := rc.MustGetPolarDBX()
+		if polardbx.Status.Canary != nil && polardbx.Status.Canary.Generation == polardbx.Status.ObservedGeneration {
+			return flow.Pass()
+		}
+		polardbx.Status.Canary = &polardbxv1polardbx.CanaryStatus{
+			Generation: polardbx.Status.ObservedGeneration,
+			Phase:      polardbxv1polardbx.CanaryRolling,
+		}
+		return flow.Continue("Canary upgrade started.", "generation", polardbx.Status.ObservedGeneration)
+	},
+)
+
+// CreateOrReconcileCanaryCN creates a canary deployment of one CN for the first group of CN to upgrade.
+var CreateOrReconcileCanaryCN = polardbxv1reconcile.NewStepBinder("CreateOrReconcileCanaryCN",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		polardbx := rc.MustGetPolarDBX()
+		canary := polardbx.Status.Canary
+		if canary.Phase != polardbxv1polardbx.CanaryRolling || !rc.HasCNs() {
+			return flow.Pass()
+		}
+
+		observedDeployments, err := rc.GetDeploymentMap(polardbxmeta.RoleCN)
+		if err != nil {
+			return flow.Error(err, "Unable to get deployments of CN.")
+		}
+		deployments, err := factory.NewObjectFactory(rc).NewDeployments4CN()
+		if err != nil {
+			return flow.Error(err, "Unable to new deployments of CN.")
+		}
+
+		var target *appsv1.Deployment
+		if len(canary.CNDeployment) == 0 {
+			groups := make([]string, 0, len(deployments))
+			for group := range deployments {
+				groups = append(groups, group)
+			}
+			sort.Strings(groups)
+			for _, group := range groups {
+				observed, ok := observedDeployments[group]
+				if !ok {
+					continue
+				}
+				deployment := deployments[group]
+				if deployment.Labels[polardbxmeta.LabelHash] != observed.Labels[polardbxmeta.LabelHash] {
+					target = newCanaryDeployment(&deployment)
+					break
+				}
+			}
+			if target == nil {
+				return flow.Continue("No CN to upgrade, skip canary of CN.")
+			}
+		} else {
+			for _, deployment := range deployments {
+				if deployment.Name+"-canary" == canary.CNDeployment {
+					target = newCanaryDeployment(&deployment)
+					break
+				}
+			}
+			if target == nil {
+				return flow.Continue("Group of canary CN not found, skip canary of CN.")
+			}
+		}
+
+		var observedCanary appsv1.Deployment
+		err = rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: rc.Namespace(), Name: target.Name}, &observedCanary)
+		if client.IgnoreNotFound(err) != nil {
+			return flow.Error(err, "Unable to get canary deployment of CN.")
+		}
+		canary.CNDeployment = target.Name
+		if apierrors.IsNotFound(err) {
+			if err := rc.SetControllerRefAndCreate(target); err != nil {
+				return flow.Error(err, "Unable to create canary deployment of CN.", "deployment", target.Name)
+			}
+			return flow.Continue("Canary deployment of CN created.", "deployment", target.Name)
+		}
+		if observedCanary.Labels[polardbxmeta.LabelHash] != target.Labels[polardbxmeta.LabelHash] {
+			convention.CopyMetadataForUpdate(&target.ObjectMeta, &observedCanary.ObjectMeta, polardbx.Status.ObservedGeneration)
+			if err := rc.Client().Update(rc.Context(), target); err != nil {
+				return flow.Error(err, "Unable to update canary deployment of CN.", "deployment", target.Name)
+			}
+		}
+		return flow.Pass()
+	},
+)
+
+// CreateOrReconcileCanaryDN upgrades the first DN as the canary. Followers of the DN are upgraded before
+// the leader by the xstore.
+var CreateOrReconcileCanaryDN = polardbxv1reconcile.NewStepBinder("CreateOrReconcileCanaryDN",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		polardbx := rc.MustGetPolarDBX()
+		canary := polardbx.Status.Canary
+		if canary.Phase != polardbxv1polardbx.CanaryRolling || len(canary.DNStore) > 0 {
+			return flow.Pass()
+		}
+		if !featuregate.StoreUpgrade.Enabled() {
+			return flow.Continue("Feature 'StoreUpgrade' not enabled, skip canary of DN.")
+		}
+
+		dnStores, err := rc.GetDNMap()
+		if err != nil {
+			return flow.Error(err, "Unable to get xstores of DN.")
+		}
+		observedDnStore, ok := dnStores[0]
+		if !ok {
+			return flow.Continue("No DN found, skip canary of DN.")
+		}
+
+		newDnStore, err := factory.NewObjectFactory(rc).NewXStoreDN(0)
+		if err != nil {
+			return flow.Error(err, "Unable to new xstore of DN.", "index", 0)
+		}
+		newDnStoreHash := newDnStore.Labels[xstoremeta.LabelHash]
+		if newDnStoreHash == observedDnStore.Labels[xstoremeta.LabelHash] {
+			return flow.Continue("No DN to upgrade, skip canary of DN.")
+		}
+
+		observedGeneration := polardbx.Status.ObservedGeneration
+		convention.CopyMetadataForUpdate(&newDnStore.ObjectMeta, &observedDnStore.ObjectMeta, observedGeneration)
+		newDnStore.SetLabels(k8shelper.PatchLabels(newDnStore.Labels, map[string]string{
+			xstoremeta.LabelHash: newDnStoreHash,
+		}))
+		if err := rc.Client().Update(rc.Context(), newDnStore); err != nil {
+			return flow.Error(err, "Unable to update xstore of DN.", "xstore", observedDnStore.Name)
+		}
+		canary.DNStore = observedDnStore.Name
+		return flow.Continue("Canary of DN upgrading.", "xstore", observedDnStore.Name)
+	},
+)
+
+func checkCanaryHealth(rc *polardbxv1reconcile.Context, canary *polardbxv1polardbx.CanaryStatus) (bool, string, error) {
+	if len(canary.CNDeployment) > 0 {
+		var podList corev1.PodList
+		err := rc.Client().List(rc.Context(), &podList, client.InNamespace(rc.Namespace()),
+			client.MatchingLabels(k8shelper.PatchLabels(
+				convention.ConstLabelsWithRole(rc.MustGetPolarDBX(), polardbxmeta.RoleCN),
+				map[string]string{polardbxmeta.LabelCanary: "true"},
+			)))
+		if err != nil {
+			return false, "", err
+		}
+		if ready, reason := canaryPodsHealth(podList.Items); !ready {
+			return false, reason, nil
+		}
+	}
+
+	if len(canary.DNStore) > 0 {
+		dnStores, err := rc.GetDNMap()
+		if err != nil {
+			return false, "", err
+		}
+		dnStore, ok := dnStores[0]
+		if !ok || dnStore.Name != canary.DNStore {
+			return false, "canary xstore of DN not found", nil
+		}
+		if dnStore.Status.Phase == polardbxv1xstore.PhaseFailed {
+			return false, "canary xstore of DN is failed", nil
+		}
+		if !isXStoreReady(dnStore) {
+			return false, "", nil
+		}
+	}
+	return true, "", nil
+}
+
+// WaitUntilCanarySoaked waits until the canary is ready and runs healthily for the soak period. The upgrade
+// is held if the canary is broken or it's paused, until it's resumed by annotation.
+var WaitUntilCanarySoaked = polardbxv1reconcile.NewStepBinder("WaitUntilCanarySoaked",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		polardbx := rc.MustGetPolarDBX()
+		canary := polardbx.Status.Canary
+
+		switch canary.Phase {
+		case polardbxv1polardbx.CanaryCompleted:
+			return flow.Pass()
+		case polardbxv1polardbx.CanaryPaused, polardbxv1polardbx.CanaryFailed:
+			if !helper.IsAnnotationIndicatesToResumeUpgrade(polardbx) {
+				return flow.Wait("Canary upgrade is held, wait for resume.", "phase", canary.Phase)
+			}
+			delete(polardbx.Annotations, polardbxmeta.AnnotationUpgradeResume)
+			rc.MarkPolarDBXChanged()
+			canary.Phase = polardbxv1polardbx.CanaryCompleted
+			canary.Message = "Resumed."
+			return flow.Continue("Canary upgrade resumed.")
+		}
+
+		if len(canary.CNDeployment) == 0 && len(canary.DNStore) == 0 {
+			canary.Phase = polardbxv1polardbx.CanaryCompleted
+			canary.Message = "Nothing upgraded by canary."
+			return flow.Continue("Nothing upgraded by canary.")
+		}
+
+		healthy, reason, err := checkCanaryHealth(rc, canary)
+		if err != nil {
+			return flow.Error(err, "Unable to check health of canary.")
+		}
+		if len(reason) > 0 {
+			canary.Phase = polardbxv1polardbx.CanaryFailed
+			canary.Message = reason
+			return flow.Retry("Canary is broken.", "reason", reason)
+		}
+
+		if canary.Phase == polardbxv1polardbx.CanaryRolling {
+			if !healthy {
+				return flow.Wait("Canary isn't ready, wait.")
+			}
+			now := metav1.Now()
+			canary.Phase = polardbxv1polardbx.CanarySoaking
+			canary.SoakStartTime = &now
+			return flow.Retry("Canary is ready, start soaking.")
+		}
+
+		if !healthy {
+			canary.Phase = polardbxv1polardbx.CanaryFailed
+			canary.Message = "canary isn't ready while soaking"
+			return flow.Retry("Canary isn't ready while soaking.")
+		}
+		if remain := canarySoakPeriod(polardbx) - time.Since(canary.SoakStartTime.Time); remain > 0 {
+			return flow.RetryAfter(remain, "Canary is soaking.", "remain", remain.Round(time.Second))
+		}
+		if isCanaryPaused(polardbx) {
+			canary.Phase = polardbxv1polardbx.CanaryPaused
+			canary.Message = "Paused after soaked."
+			return flow.Retry("Canary is soaked, pause.")
+		}
+		canary.Phase = polardbxv1polardbx.CanaryCompleted
+		canary.Message = "Soaked."
+		return flow.Continue("Canary is soaked.")
+	},
+)
+
+var RemoveCanaryCN = polardbxv1reconcile.NewStepBinder("RemoveCanaryCN",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		var deploymentList appsv1.DeploymentList
+		err := rc.Client().List(rc.Context(), &deploymentList, client.InNamespace(rc.Namespace()),
+			client.MatchingLabels(k8shelper.PatchLabels(
+				convention.ConstLabelsWithRole(rc.MustGetPolarDBX(), polardbxmeta.RoleCN),
+				map[string]string{polardbxmeta.LabelCanary: "true"},
+			)))
+		if err != nil {
+			return flow.Error(err, "Unable to list canary deployments of CN.")
+		}
+		for i := range deploymentList.Items {
+			deployment := &deploymentList.Items[i]
+			err := rc.Client().Delete(rc.Context(), deployment, client.PropagationPolicy(metav1.DeletePropagationBackground))
+			if client.IgnoreNotFound(err) != nil {
+				return flow.Error(err, "Unable to delete canary deployment of CN.", "deployment", deployment.Name)
+			}
+		}
+		return flow.Pass()
+	},
+)
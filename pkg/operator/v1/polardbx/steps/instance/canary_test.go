@@ -0,0 +1,92 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+)
+
+func TestNewCanaryDeployment(t *testing.T) {
+	labels := map[string]string{
+		polardbxmeta.LabelName: "pxc",
+		polardbxmeta.LabelRole: polardbxmeta.RoleCN,
+	}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "pxc-cn-default",
+			Labels:          labels,
+			ResourceVersion: "10",
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: pointer.Int32(4),
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+			},
+		},
+	}
+
+	canary := newCanaryDeployment(deployment)
+	if canary.Name != "pxc-cn-default-canary" || len(canary.ResourceVersion) > 0 {
+		t.Fatalf("unexpected canary deployment: %s, %s", canary.Name, canary.ResourceVersion)
+	}
+	if *canary.Spec.Replicas != 1 {
+		t.Fatalf("expect 1 replica, but got %d", *canary.Spec.Replicas)
+	}
+	for _, m := range []map[string]string{canary.Labels, canary.Spec.Selector.MatchLabels, canary.Spec.Template.Labels} {
+		if m[polardbxmeta.LabelCanary] != "true" || m[polardbxmeta.LabelName] != "pxc" {
+			t.Fatalf("unexpected labels: %v", m)
+		}
+	}
+	if _, ok := deployment.Labels[polardbxmeta.LabelCanary]; ok {
+		t.Fatal("labels of the origin deployment modified")
+	}
+}
+
+func TestCanaryPodsHealth(t *testing.T) {
+	pod := func(ready bool, restarts int32) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "canary"},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{Name: "engine", Ready: ready, RestartCount: restarts},
+				},
+			},
+		}
+	}
+
+	if ready, reason := canaryPodsHealth(nil); ready || len(reason) > 0 {
+		t.Error("no pods should be not ready and not broken")
+	}
+	if ready, reason := canaryPodsHealth([]corev1.Pod{pod(false, 0)}); ready || len(reason) > 0 {
+		t.Error("pods not ready should be not ready and not broken")
+	}
+	if ready, reason := canaryPodsHealth([]corev1.Pod{pod(true, 0)}); !ready || len(reason) > 0 {
+		t.Error("pods ready should be ready")
+	}
+	if ready, reason := canaryPodsHealth([]corev1.Pod{pod(true, 0), pod(true, 1)}); ready || len(reason) == 0 {
+		t.Error("pods restarted should be broken")
+	}
+}
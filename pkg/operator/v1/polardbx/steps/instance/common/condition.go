@@ -0,0 +1,205 @@
+/*
+Copyright 2021 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1polardbx "github.com/alibaba/polardbx-operator/api/v1/polardbx"
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+)
+
+// isClusterSteady returns true if no operation is in progress in the phase.
+func isClusterSteady(phase polardbxv1polardbx.Phase) bool {
+	switch phase {
+	case polardbxv1polardbx.PhaseRunning, polardbxv1polardbx.PhaseLocked,
+		polardbxv1polardbx.PhaseSuspended, polardbxv1polardbx.PhaseFailed:
+		return true
+	}
+	return false
+}
+
+// clusterStageOf names the stage of the cluster, e.g. "Upgrading" or "Upgrading/Canary".
+func clusterStageOf(status *polardbxv1.PolarDBXClusterStatus) string {
+	if status.Stage == polardbxv1polardbx.StageEmpty {
+		return string(status.Phase)
+	}
+	return string(status.Phase) + "/" + string(status.Stage)
+}
+
+// unavailableReplicasOf lists the nodes with replicas unavailable, e.g. "DN 1/2".
+func unavailableReplicasOf(polardbx *polardbxv1.PolarDBXCluster) []string {
+	var unavailable []string
+	check := func(role string, rs *polardbxv1polardbx.ReplicasStatus) {
+		if rs != nil && rs.Available < rs.Total {
+			unavailable = append(unavailable, role+" "+rs.Display())
+		}
+	}
+	if !polardbx.Spec.Readonly {
+		check("GMS", &polardbx.Status.ReplicaStatus.GMS)
+	}
+	check("CN", polardbx.Status.ReplicaStatus.CN)
+	check("DN", &polardbx.Status.ReplicaStatus.DN)
+	check("CDC", polardbx.Status.ReplicaStatus.CDC)
+	return unavailable
+}
+
+// replicationConditionOf aggregates the replication of the xstores, which is unhealthy if any xstore is,
+// or unknown if any is not observed.
+func replicationConditionOf(xstores []*polardbxv1.XStore) (corev1.ConditionStatus, string, string) {
+	status, reason, message := corev1.ConditionTrue, "AllReplicationsHealthy", ""
+	for _, xstore := range xstores {
+		c := helper.GetXStoreCondition(xstore.Status.Conditions, polardbxv1xstore.ReplicationHealthy)
+		if c == nil {
+			if status == corev1.ConditionTrue {
+				status, reason, message = corev1.ConditionUnknown, "NotObserved",
+					"replication of xstore "+xstore.Name+" not observed yet"
+			}
+			continue
+		}
+		if c.Status == corev1.ConditionFalse {
+			return corev1.ConditionFalse, c.Reason, "xstore " + xstore.Name + ": " + c.Message
+		}
+	}
+	return status, reason, message
+}
+
+// newClusterConditions computes the standard conditions of the cluster from its status, the xstores
+// of GMS and DNs, and the backups of the cluster in progress.
+func newClusterConditions(polardbx *polardbxv1.PolarDBXCluster, xstores []*polardbxv1.XStore,
+	backups []string) []polardbxv1polardbx.Condition {
+	phase := polardbx.Status.Phase
+	unavailable := strings.Join(unavailableReplicasOf(polardbx), ", ")
+	newCondition := func(conditionType polardbxv1polardbx.ConditionType, status bool, reason, message string) polardbxv1polardbx.Condition {
+		c := polardbxv1polardbx.Condition{
+			Type:    conditionType,
+			Status:  corev1.ConditionFalse,
+			Reason:  reason,
+			Message: message,
+		}
+		if status {
+			c.Status = corev1.ConditionTrue
+		}
+		return c
+	}
+
+	var ready, progressing, degraded polardbxv1polardbx.Condition
+	switch {
+	case phase != polardbxv1polardbx.PhaseRunning:
+		ready = newCondition(polardbxv1polardbx.Ready, false, "NotRunning", "phase is "+string(phase))
+	case len(unavailable) > 0:
+		ready = newCondition(polardbxv1polardbx.Ready, false, "ReplicasUnavailable", "unavailable: "+unavailable)
+	default:
+		ready = newCondition(polardbxv1polardbx.Ready, true, "AllReplicasAvailable", "")
+	}
+	if isClusterSteady(phase) {
+		progressing = newCondition(polardbxv1polardbx.Progressing, false, "Steady", "phase is "+string(phase))
+	} else {
+		progressing = newCondition(polardbxv1polardbx.Progressing, true, string(phase), "stage is "+clusterStageOf(&polardbx.Status))
+	}
+	switch {
+	case phase == polardbxv1polardbx.PhaseFailed:
+		degraded = newCondition(polardbxv1polardbx.Degraded, true, "Failed", "phase is "+string(phase))
+	case phase == polardbxv1polardbx.PhaseRunning && len(unavailable) > 0:
+		degraded = newCondition(polardbxv1polardbx.Degraded, true, "ReplicasUnavailable", "unavailable: "+unavailable)
+	default:
+		degraded = newCondition(polardbxv1polardbx.Degraded, false, "AsExpected", "")
+	}
+
+	replicationStatus, replicationReason, replicationMessage := replicationConditionOf(xstores)
+	replication := polardbxv1polardbx.Condition{
+		Type:    polardbxv1polardbx.ReplicationHealthy,
+		Status:  replicationStatus,
+		Reason:  replicationReason,
+		Message: replicationMessage,
+	}
+
+	var backup polardbxv1polardbx.Condition
+	if len(backups) > 0 {
+		backup = newCondition(polardbxv1polardbx.BackupInProgress, true, "BackupRunning", "running: "+strings.Join(backups, ", "))
+	} else {
+		backup = newCondition(polardbxv1polardbx.BackupInProgress, false, "NoBackupRunning", "")
+	}
+	return []polardbxv1polardbx.Condition{ready, progressing, degraded, replication, backup}
+}
+
+// UpdateStatusConditionsAndProgress publishes the standard conditions of the cluster, i.e. Ready,
+// Progressing, Degraded, ReplicationHealthy and BackupInProgress, and records the stages of the
+// operation in progress. The transition time of a condition is kept if its status is not changed.
+var UpdateStatusConditionsAndProgress = polardbxv1reconcile.NewStepBinder("UpdateStatusConditionsAndProgress",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		polardbx := rc.MustGetPolarDBX()
+		if polardbx.Status.Phase == polardbxv1polardbx.PhaseNew {
+			return flow.Pass()
+		}
+
+		var xstores []*polardbxv1.XStore
+		if !polardbx.Spec.ShareGMS && !polardbx.Spec.Readonly {
+			gmsStore, err := rc.GetGMS()
+			if client.IgnoreNotFound(err) != nil {
+				return flow.Error(err, "Unable to get xstore of GMS.")
+			}
+			if gmsStore != nil {
+				xstores = append(xstores, gmsStore)
+			}
+		}
+		dnStores, err := rc.GetOrderedDNList()
+		if err != nil {
+			return flow.Error(err, "Unable to get xstores of DN.")
+		}
+		xstores = append(xstores, dnStores...)
+
+		var backupList polardbxv1.PolarDBXBackupList
+		err = rc.Client().List(rc.Context(), &backupList, client.InNamespace(rc.Namespace()), client.MatchingLabels{
+			polardbxmeta.LabelName: polardbx.Name,
+		})
+		if err != nil {
+			return flow.Error(err, "Unable to list backups of the cluster.")
+		}
+		var backups []string
+		for _, backup := range backupList.Items {
+			if backup.Spec.Cluster.Name != polardbx.Name || !backup.DeletionTimestamp.IsZero() {
+				continue
+			}
+			if backup.Status.Phase != polardbxv1.BackupFinished && backup.Status.Phase != polardbxv1.BackupFailed {
+				backups = append(backups, backup.Name)
+			}
+		}
+
+		now := metav1.Now()
+		for _, c := range newClusterConditions(polardbx, xstores, backups) {
+			c.LastTransitionTime = now
+			c.ObservedGeneration = polardbx.Status.ObservedGeneration
+			polardbx.Status.Conditions = helper.SetClusterCondition(polardbx.Status.Conditions, c)
+		}
+		polardbx.Status.Progress = helper.ObserveProgress(polardbx.Status.Progress,
+			string(polardbx.Status.Phase), clusterStageOf(&polardbx.Status),
+			isClusterSteady(polardbx.Status.Phase), now)
+
+		return flow.Pass()
+	},
+)
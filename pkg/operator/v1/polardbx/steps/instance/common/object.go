@@ -132,6 +132,10 @@ var SyncSpecFromBackupSet = polardbxv1reconcile.NewStepBinder("SyncSpecFromBacku
 		if err != nil {
 			return flow.Error(err, "Unable to get polardbx backup {}", pxcBackup.Name)
 		}
+		if len(pxcBackup.Spec.XStores) > 0 {
+			helper.TransferPhase(polardbx, polardbxv1polardbx.PhaseFailed)
+			return flow.Retry("Backup of xstore group can't be used to restore the cluster.", "backup", pxcBackup.Name)
+		}
 
 		// TODO(dengli): load spec from remote backup set
 		if polardbx.Spec.Restore.SyncSpecWithOriginalCluster {
@@ -139,7 +143,8 @@ var SyncSpecFromBackupSet = polardbxv1reconcile.NewStepBinder("SyncSpecFromBacku
 		} else { // ensure that restored cluster have the same dn replicas with original cluster
 			polardbx.Spec.Topology.Nodes.DN.Replicas = pxcBackup.Status.ClusterSpecSnapshot.Topology.Nodes.DN.Replicas
 		}
-		err = rc.Client().Update(rc.Context(), polardbx)
+		// Keep the status changes, e.g. the import of backup set.
+		err = rc.UpdatePolarDBX()
 		if err != nil {
 			return flow.Error(err, "Failed to sync topology from backup set")
 		}
@@ -158,6 +158,50 @@ var UpdateDisplayStorageSize = polardbxv1reconcile.NewStepBinder("UpdateDisplayS
 		return flow.Pass()
 	})
 
+// readonlyReplicationOf aggregates the replication status of DNs of readonly cluster, i.e. the
+// max lag and the first unhealthy one.
+func readonlyReplicationOf(dnStores []*polardbxv1.XStore) *polardbxv1polardbx.ReadonlyReplicationStatus {
+	status := &polardbxv1polardbx.ReadonlyReplicationStatus{}
+	for _, dnStore := range dnStores {
+		replication := dnStore.Status.ReadonlyReplication
+		if replication == nil {
+			if len(status.Message) == 0 {
+				status.Message = "replication of DN " + dnStore.Name + " not observed yet"
+			}
+			continue
+		}
+		if replication.MaxLag > status.MaxLag || len(status.LaggingDN) == 0 {
+			status.MaxLag = replication.MaxLag
+			status.LaggingDN = dnStore.Name
+		}
+		if len(replication.Message) > 0 && len(status.Message) == 0 {
+			status.Message = "DN " + dnStore.Name + ": " + replication.Message
+		}
+	}
+	return status
+}
+
+// UpdateReadonlyReplicationStatus records the replication lag of DNs of readonly cluster from the
+// primary cluster.
+var UpdateReadonlyReplicationStatus = polardbxv1reconcile.NewStepBinder("UpdateReadonlyReplicationStatus",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		polardbx := rc.MustGetPolarDBX()
+		if !polardbx.Spec.Readonly {
+			return flow.Pass()
+		}
+
+		dnStores, err := rc.GetOrderedDNList()
+		if err != nil {
+			return flow.Error(err, "Unable to get xstores of DN.")
+		}
+		status := readonlyReplicationOf(dnStores)
+		now := metav1.Now()
+		status.UpdateTime = &now
+		polardbx.Status.ReadonlyReplication = status
+
+		return flow.Continue("Readonly replication status updated.", "max-lag", status.MaxLag, "lagging-dn", status.LaggingDN)
+	})
+
 var UpdateDisplayReplicas = polardbxv1reconcile.NewStepBinder("UpdateDisplayReplicas",
 	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
 		polardbx := rc.MustGetPolarDBX()
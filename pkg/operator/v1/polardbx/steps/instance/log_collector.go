@@ -0,0 +1,52 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+)
+
+// SyncStoresLogCollector syncs the log collector of slow logs to GMS and DNs. The pods of xstores
+// are rebuilt one by one to add, update or remove the collector.
+var SyncStoresLogCollector = polardbxv1reconcile.NewStepBinder("SyncStoresLogCollector",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		polardbx := rc.MustGetPolarDBX()
+		logCollector := helper.GetStoreLogCollector(polardbx)
+
+		stores, err := getOwnedStores(rc)
+		if err != nil {
+			return flow.Error(err, "Unable to get xstores.")
+		}
+		for _, xstore := range stores {
+			if equality.Semantic.DeepEqual(xstore.Spec.LogCollector, logCollector) {
+				continue
+			}
+			xstore.Spec.LogCollector = logCollector.DeepCopy()
+			if err := rc.Client().Update(rc.Context(), xstore); err != nil {
+				return flow.Error(err, "Unable to update xstore.", "xstore", xstore.Name)
+			}
+			flow.Logger().Info("Log collector of xstore updated.", "xstore", xstore.Name, "enabled", logCollector != nil)
+		}
+
+		return flow.Pass()
+	},
+)
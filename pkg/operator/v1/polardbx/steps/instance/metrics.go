@@ -0,0 +1,97 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/metrics"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+)
+
+// upgradeProgressOf returns the ratio of the xstores ready and deployments rolled out.
+func upgradeProgressOf(xstores []*polardbxv1.XStore, deployments []*appsv1.Deployment) float64 {
+	total := len(xstores) + len(deployments)
+	if total == 0 {
+		return 1
+	}
+	done := 0
+	for _, xstore := range xstores {
+		if isXStoreReady(xstore) {
+			done++
+		}
+	}
+	for _, deploy := range deployments {
+		if k8shelper.IsDeploymentRolledOut(deploy) {
+			done++
+		}
+	}
+	return float64(done) / float64(total)
+}
+
+// UpdateUpgradeProgressMetrics sets the upgrade progress of the cluster, by the GMS and DNs ready and
+// the deployments of CN and CDC rolled out.
+var UpdateUpgradeProgressMetrics = polardbxv1reconcile.NewStepBinder("UpdateUpgradeProgressMetrics",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		polardbx := rc.MustGetPolarDBX()
+
+		var xstores []*polardbxv1.XStore
+		if !polardbx.Spec.Readonly && !polardbx.Spec.ShareGMS {
+			gms, err := rc.GetGMS()
+			if client.IgnoreNotFound(err) != nil {
+				return flow.Error(err, "Unable to get xstore of GMS.")
+			}
+			if gms != nil {
+				xstores = append(xstores, gms)
+			}
+		}
+		dnStores, err := rc.GetDNMap()
+		if err != nil {
+			return flow.Error(err, "Unable to list xstores of DNs.")
+		}
+		for _, dnStore := range dnStores {
+			xstores = append(xstores, dnStore)
+		}
+
+		var deployments []*appsv1.Deployment
+		for _, role := range []string{polardbxmeta.RoleCN, polardbxmeta.RoleCDC} {
+			deploymentMap, err := rc.GetDeploymentMap(role)
+			if err != nil {
+				return flow.Error(err, "Unable to get deployments.", "role", role)
+			}
+			for _, deploy := range deploymentMap {
+				deployments = append(deployments, deploy)
+			}
+		}
+
+		metrics.SetUpgradeProgress(polardbx.Namespace, polardbx.Name, upgradeProgressOf(xstores, deployments))
+		return flow.Pass()
+	})
+
+// ForgetUpgradeProgressMetrics removes the upgrade progress once the cluster isn't upgrading.
+var ForgetUpgradeProgressMetrics = polardbxv1reconcile.NewStepBinder("ForgetUpgradeProgressMetrics",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		polardbx := rc.MustGetPolarDBX()
+		metrics.ForgetUpgradeProgress(polardbx.Namespace, polardbx.Name)
+		return flow.Pass()
+	})
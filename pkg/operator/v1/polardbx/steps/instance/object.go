@@ -703,6 +703,9 @@ var WaitUntilGMSReady = polardbxv1reconcile.NewStepBinder("WaitUntilGMSReady",
 		}
 
 		if gms.Status.Phase == polardbxv1xstore.PhaseFailed {
+			if helper.IsRestoreShardRetriable(rc.MustGetPolarDBX(), gms.Name) {
+				return flow.Wait("XStore of GMS is failed, wait until restored again.", "xstore", gms.Name)
+			}
 			helper.TransferPhase(rc.MustGetPolarDBX(), polardbxv1polardbx.PhaseFailed)
 			return flow.Retry("XStore of GMS is failed, transfer phase into failed.")
 		}
@@ -734,6 +737,10 @@ var WaitUntilDNsReady = polardbxv1reconcile.NewStepBinder("WaitUntilDNsReady",
 			}
 
 			if dnStore.Status.Phase == polardbxv1xstore.PhaseFailed {
+				if helper.IsRestoreShardRetriable(polardbx, dnStore.Name) {
+					notReadyCnt++
+					continue
+				}
 				helper.TransferPhase(rc.MustGetPolarDBX(), polardbxv1polardbx.PhaseFailed)
 				return flow.Retry("XStore of DN is failed, transfer phase into failed.", "xstore", dnStore.Name)
 			}
@@ -869,6 +876,20 @@ var TrySyncCnLabelToPodsDirectly = polardbxv1reconcile.NewStepBinder("TrySyncCnL
 				}
 			}
 		}
+		// CN pods of readonly clusters are labeled with the primary name, which the read-only
+		// endpoints of the primary cluster select.
+		if polardbx := rc.MustGetPolarDBX(); polardbx.Spec.Readonly {
+			for _, pod := range cnPods {
+				if pod.Labels[polardbxmeta.LabelPrimaryName] != polardbx.Spec.PrimaryCluster {
+					pod.SetLabels(k8shelper.PatchLabels(pod.Labels, map[string]string{
+						polardbxmeta.LabelPrimaryName: polardbx.Spec.PrimaryCluster,
+					}))
+					if err := rc.Client().Update(rc.Context(), &pod); err != nil {
+						return flow.RetryErr(err, "Failed to Update pod for polardbx/primary-name")
+					}
+				}
+			}
+		}
 		return flow.Continue(" CN Deployment labels are synced", "count", changedCount)
 	},
 )
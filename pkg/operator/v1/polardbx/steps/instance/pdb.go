@@ -0,0 +1,98 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/convention"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+)
+
+// newCNPodDisruptionBudget allows at most one CN pod of the cluster to be disrupted at a time.
+// Replicas changes don't affect it since it's expressed with max unavailable.
+func newCNPodDisruptionBudget(polardbx *polardbxv1.PolarDBXCluster) *policyv1.PodDisruptionBudget {
+	maxUnavailable := intstr.FromInt(1)
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      convention.NewPodDisruptionBudgetName(polardbx, polardbxmeta.RoleCN),
+			Namespace: polardbx.Namespace,
+			Labels:    convention.ConstLabelsWithRole(polardbx, polardbxmeta.RoleCN),
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MaxUnavailable: &maxUnavailable,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: convention.ConstLabelsWithRole(polardbx, polardbxmeta.RoleCN),
+			},
+		},
+	}
+}
+
+// CreateOrReconcileCNPodDisruptionBudget keeps the pod disruption budget of CN pods. It's removed when
+// there are no CNs or the management is disabled, otherwise with the cluster by owner reference.
+var CreateOrReconcileCNPodDisruptionBudget = polardbxv1reconcile.NewStepBinder("CreateOrReconcileCNPodDisruptionBudget",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		polardbx := rc.MustGetPolarDBX()
+
+		var pdb policyv1.PodDisruptionBudget
+		err := rc.Client().Get(rc.Context(), types.NamespacedName{
+			Namespace: rc.Namespace(),
+			Name:      convention.NewPodDisruptionBudgetName(polardbx, polardbxmeta.RoleCN),
+		}, &pdb)
+		if client.IgnoreNotFound(err) != nil {
+			return flow.Error(err, "Unable to get pod disruption budget of CN.")
+		}
+		exists := err == nil
+
+		if !rc.Config().Scheduler().ManagePodDisruptionBudget() || !rc.HasCNs() {
+			if !exists {
+				return flow.Pass()
+			}
+			if err := rc.Client().Delete(rc.Context(), &pdb); client.IgnoreNotFound(err) != nil {
+				return flow.Error(err, "Unable to delete pod disruption budget of CN.")
+			}
+			return flow.Continue("Pod disruption budget of CN removed.")
+		}
+
+		desired := newCNPodDisruptionBudget(polardbx)
+		if !exists {
+			if err := rc.SetControllerRefAndCreate(desired); err != nil && !apierrors.IsAlreadyExists(err) {
+				return flow.Error(err, "Unable to create pod disruption budget of CN.")
+			}
+			return flow.Continue("Pod disruption budget of CN created.")
+		}
+
+		if equality.Semantic.DeepEqual(pdb.Spec, desired.Spec) {
+			return flow.Pass()
+		}
+		pdb.Spec = desired.Spec
+		if err := rc.Client().Update(rc.Context(), &pdb); err != nil {
+			return flow.Error(err, "Unable to update pod disruption budget of CN.")
+		}
+		return flow.Continue("Pod disruption budget of CN updated.")
+	},
+)
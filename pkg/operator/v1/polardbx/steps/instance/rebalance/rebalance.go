@@ -19,11 +19,14 @@ package rebalance
 import (
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	polardbxv1polardbx "github.com/alibaba/polardbx-operator/api/v1/polardbx"
 	"github.com/alibaba/polardbx-operator/pkg/featuregate"
 	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
 	"github.com/alibaba/polardbx-operator/pkg/meta/core/gms"
@@ -37,6 +40,102 @@ type DataRebalanceTask struct {
 	From   int     `json:"from,omitempty"`
 	To     int     `json:"to,omitempty"`
 	PlanId *string `json:"plan_id,omitempty"`
+
+	// Throttle applied to the cluster.
+	SpeedLimit  int64 `json:"speed_limit,omitempty"`
+	Parallelism int32 `json:"parallelism,omitempty"`
+
+	// Values of the throttle variables before applied, restored once the rebalance is over.
+	OriginalThrottle map[string]string `json:"original_throttle,omitempty"`
+}
+
+// Global variables of CN to throttle the backfill of moves.
+const (
+	variableBackfillSpeedLimit  = "SCALEOUT_BACKFILL_SPEED_LIMITATION"
+	variableBackfillParallelism = "SCALEOUT_BACKFILL_PARALLELISM"
+)
+
+// throttleVariablesOf returns the variables to apply if the throttle differs from the applied one.
+// Zero keeps the current.
+func (t *DataRebalanceTask) throttleVariablesOf(spec *polardbxv1polardbx.RebalanceSpec) map[string]string {
+	variables := make(map[string]string)
+	if spec == nil {
+		return variables
+	}
+	if spec.SpeedLimit > 0 && spec.SpeedLimit != t.SpeedLimit {
+		variables[variableBackfillSpeedLimit] = strconv.FormatInt(spec.SpeedLimit, 10)
+	}
+	if spec.Parallelism > 0 && spec.Parallelism != t.Parallelism {
+		variables[variableBackfillParallelism] = strconv.Itoa(int(spec.Parallelism))
+	}
+	return variables
+}
+
+// applyThrottle applies the throttle and records it, returns whether it's changed.
+func (t *DataRebalanceTask) applyThrottle(rc *polardbxv1reconcile.Context) (bool, error) {
+	spec := rc.MustGetPolarDBX().Spec.Rebalance
+	variables := t.throttleVariablesOf(spec)
+	if len(variables) == 0 {
+		return false, nil
+	}
+	groupMgr, err := rc.GetPolarDBXGroupManager()
+	if err != nil {
+		return false, err
+	}
+	if err := t.recordOriginalThrottle(groupMgr, variables); err != nil {
+		return false, err
+	}
+	if err := groupMgr.SetGlobalVariables(variables); err != nil {
+		return false, err
+	}
+	if spec.SpeedLimit > 0 {
+		t.SpeedLimit = spec.SpeedLimit
+	}
+	if spec.Parallelism > 0 {
+		t.Parallelism = spec.Parallelism
+	}
+	return true, nil
+}
+
+// recordOriginalThrottle records the current values of the variables which are going to be applied
+// for the first time. Variables not shown by the cluster are recorded as empty and never restored.
+func (t *DataRebalanceTask) recordOriginalThrottle(groupMgr group.GroupManager, variables map[string]string) error {
+	names := make([]string, 0, len(variables))
+	for name := range variables {
+		if _, ok := t.OriginalThrottle[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	current, err := groupMgr.ShowGlobalVariables(names...)
+	if err != nil {
+		return err
+	}
+	if t.OriginalThrottle == nil {
+		t.OriginalThrottle = make(map[string]string)
+	}
+	for _, name := range names {
+		t.OriginalThrottle[name] = current[strings.ToLower(name)]
+	}
+	return nil
+}
+
+// throttleVariablesToRestore returns the variables to restore to the values before the rebalance.
+func (t *DataRebalanceTask) throttleVariablesToRestore() map[string]string {
+	variables := make(map[string]string)
+	for name, value := range t.OriginalThrottle {
+		if len(value) > 0 {
+			variables[name] = value
+		}
+	}
+	return variables
+}
+
+func isRebalancePaused(rc *polardbxv1reconcile.Context) bool {
+	spec := rc.MustGetPolarDBX().Spec.Rebalance
+	return spec != nil && spec.Paused
 }
 
 func (t *DataRebalanceTask) startRebalanceClusterForScaleOut(rc *polardbxv1reconcile.Context) (string, error) {
@@ -269,6 +368,17 @@ var StartRebalanceTask = polardbxv1reconcile.NewStepBinder("StartRebalanceTask",
 			return flow.Pass()
 		}
 
+		// Block until resumed.
+		if isRebalancePaused(rc) {
+			rc.MustGetPolarDBX().Status.StatusForPrint.RebalanceProcess = "paused"
+			return flow.RetryAfter(10*time.Second, "Rebalance paused, wait for resume.")
+		}
+
+		// Throttle before moving.
+		if _, err := rebalanceTask.applyThrottle(rc); err != nil {
+			return flow.Error(err, "Unable to apply throttle of rebalance.")
+		}
+
 		// Start a new task.
 		planId, err := rebalanceTask.Start(rc)
 		if err != nil {
@@ -335,6 +445,9 @@ func WatchRebalanceTaskAntUpdateProgress(interval time.Duration) control.BindFun
 			}
 
 			polardbx.Status.StatusForPrint.RebalanceProcess = fmt.Sprintf("%.1f%%", float64(progress))
+			if polardbx.Status.Rebalance != nil && polardbx.Status.Rebalance.Paused && progress < 100 {
+				polardbx.Status.StatusForPrint.RebalanceProcess += " (paused)"
+			}
 
 			if progress < 100 {
 				return flow.RetryAfter(interval, "Rebalance not ready, wait for recheck.")
@@ -440,10 +553,13 @@ var EnsureTrailingDNsAreDrainedOrBlock = polardbxv1reconcile.NewStepBinder(
 	},
 )
 
+// ResetRebalanceTask restores the throttle variables changed by the rebalance, and clears the task
+// context and status.
 var ResetRebalanceTask = polardbxv1reconcile.NewStepBinder("ResetRebalanceTask",
 	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
 		polardbx := rc.MustGetPolarDBX()
 		polardbx.Status.StatusForPrint.RebalanceProcess = ""
+		polardbx.Status.Rebalance = nil
 
 		taskCm, err := rc.GetPolarDBXConfigMap(convention.ConfigMapTypeTask)
 		if err != nil {
@@ -451,6 +567,22 @@ var ResetRebalanceTask = polardbxv1reconcile.NewStepBinder("ResetRebalanceTask",
 		}
 
 		contextAccess := task.NewContextAccess(taskCm, "rebalance")
+		rebalanceTask := &DataRebalanceTask{}
+		found, err := contextAccess.Read(rebalanceTask)
+		if err != nil {
+			return flow.Error(err, "Unable to read rebalance task context.")
+		}
+		if variables := rebalanceTask.throttleVariablesToRestore(); found && len(variables) > 0 {
+			groupMgr, err := rc.GetPolarDBXGroupManager()
+			if err != nil {
+				return flow.Error(err, "Unable to get group manager.")
+			}
+			if err := groupMgr.SetGlobalVariables(variables); err != nil {
+				return flow.Error(err, "Unable to restore throttle of rebalance.")
+			}
+			flow.Logger().Info("Throttle of rebalance restored.", "variables", variables)
+		}
+
 		ok := contextAccess.Clear()
 
 		// Update config map if cleared.
@@ -464,3 +596,131 @@ var ResetRebalanceTask = polardbxv1reconcile.NewStepBinder("ResetRebalanceTask",
 		return flow.Pass()
 	},
 )
+
+// movesOf returns the moves of DDL jobs.
+func movesOf(jobs []group.DDLStatus) []polardbxv1polardbx.RebalanceMove {
+	moves := make([]polardbxv1polardbx.RebalanceMove, 0, len(jobs))
+	for _, job := range jobs {
+		moves = append(moves, polardbxv1polardbx.RebalanceMove{
+			JobId:    job.JobId,
+			Schema:   job.Schema,
+			Object:   job.Object,
+			Type:     job.Type,
+			State:    job.State,
+			Progress: int32(job.Progress),
+		})
+	}
+	return moves
+}
+
+// movesOfPlan returns the moves of the DDL jobs launched by the plan. Other DDL jobs of the cluster
+// are never tracked, nor paused or continued.
+func movesOfPlan(jobs []group.DDLStatus, plan *group.DDLPlanStatus) []polardbxv1polardbx.RebalanceMove {
+	planJobs := make([]group.DDLStatus, 0)
+	for _, job := range jobs {
+		if len(plan.JobId) > 0 && job.JobId == plan.JobId {
+			planJobs = append(planJobs, job)
+		}
+	}
+	return movesOf(planJobs)
+}
+
+// movesToToggle returns the jobs of moves to pause if paused, or the ones to continue otherwise.
+func movesToToggle(moves []polardbxv1polardbx.RebalanceMove, paused bool) []string {
+	jobIds := make([]string, 0)
+	for _, move := range moves {
+		state := strings.ToUpper(move.State)
+		if paused && (state == "RUNNING" || state == "QUEUED") {
+			jobIds = append(jobIds, move.JobId)
+		} else if !paused && state == "PAUSED" {
+			jobIds = append(jobIds, move.JobId)
+		}
+	}
+	return jobIds
+}
+
+// ReconcileRebalanceMoves tracks the moves of the rebalance plan in status, applies the throttle once
+// changed, and pauses or resumes the moves as the spec says.
+var ReconcileRebalanceMoves = polardbxv1reconcile.NewStepBinder("ReconcileRebalanceMoves",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		// Read task context from config map.
+		taskCm, err := rc.GetPolarDBXConfigMap(convention.ConfigMapTypeTask)
+		if err != nil {
+			return flow.Error(err, "Unable to get config map for task.")
+		}
+
+		contextAccess := task.NewContextAccess(taskCm, "rebalance")
+		rebalanceTask := &DataRebalanceTask{}
+		ok, err := contextAccess.Read(rebalanceTask)
+		if err != nil {
+			return flow.Error(err, "Unable to read rebalance task context.")
+		}
+		if !ok {
+			return flow.Error(errors.New("no rebalance task context found"), "Unable to find rebalance task context.")
+		}
+
+		// Skip if no plan started.
+		if rebalanceTask.Skip() || rebalanceTask.PlanId == nil || len(*rebalanceTask.PlanId) == 0 {
+			return flow.Pass()
+		}
+
+		throttled, err := rebalanceTask.applyThrottle(rc)
+		if err != nil {
+			return flow.Error(err, "Unable to apply throttle of rebalance.")
+		}
+		if throttled {
+			flow.Logger().Info("Throttle of rebalance applied.", "speed-limit", rebalanceTask.SpeedLimit,
+				"parallelism", rebalanceTask.Parallelism)
+			if err := contextAccess.Write(rebalanceTask); err != nil {
+				return flow.Error(err, "Unable to write rebalance task into config map.")
+			}
+			if err := rc.Client().Update(rc.Context(), taskCm); err != nil {
+				return flow.Error(err, "Unable to update task config map.")
+			}
+		}
+
+		groupMgr, err := rc.GetPolarDBXGroupManager()
+		if err != nil {
+			return flow.Error(err, "Unable to get group manager.")
+		}
+		plan, err := groupMgr.ShowDDLPlanStatus(*rebalanceTask.PlanId)
+		if err != nil {
+			return flow.Error(err, "Unable to get status of rebalance plan.", "plan", *rebalanceTask.PlanId)
+		}
+		jobs, err := groupMgr.ListDDL()
+		if err != nil {
+			return flow.Error(err, "Unable to list DDL jobs.")
+		}
+		moves := movesOfPlan(jobs, plan)
+
+		paused := isRebalancePaused(rc)
+		for _, jobId := range movesToToggle(moves, paused) {
+			if paused {
+				err = groupMgr.PauseDDL(jobId)
+			} else {
+				err = groupMgr.ContinueDDL(jobId)
+			}
+			if err != nil {
+				return flow.Error(err, "Unable to pause or continue move.", "job", jobId, "paused", paused)
+			}
+			flow.Logger().Info("Move paused or continued.", "job", jobId, "paused", paused)
+		}
+
+		polardbx := rc.MustGetPolarDBX()
+		now := metav1.Now()
+		if polardbx.Status.Rebalance == nil || polardbx.Status.Rebalance.PlanId != plan.PlanId {
+			polardbx.Status.Rebalance = &polardbxv1polardbx.RebalanceStatus{
+				PlanId:    plan.PlanId,
+				StartTime: &now,
+			}
+		}
+		status := polardbx.Status.Rebalance
+		status.State = plan.State
+		status.Progress = int32(plan.Progress)
+		status.Paused = paused
+		status.Moves = moves
+		status.UpdateTime = &now
+
+		return flow.Pass()
+	},
+)
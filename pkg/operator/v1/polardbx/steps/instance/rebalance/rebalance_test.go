@@ -0,0 +1,96 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rebalance
+
+import (
+	"reflect"
+	"testing"
+
+	polardbxv1polardbx "github.com/alibaba/polardbx-operator/api/v1/polardbx"
+	"github.com/alibaba/polardbx-operator/pkg/meta/core/group"
+)
+
+func TestMovesToToggle(t *testing.T) {
+	moves := movesOf([]group.DDLStatus{
+		{JobId: "1", Schema: "db", Object: "t1", State: "RUNNING", Progress: 30},
+		{JobId: "2", Schema: "db", Object: "t2", State: "QUEUED"},
+		{JobId: "3", Schema: "db", Object: "t3", State: "PAUSED", Progress: 50},
+		{JobId: "4", Schema: "db", Object: "t4", State: "ROLLBACK_RUNNING"},
+	})
+	if len(moves) != 4 || moves[0].Object != "t1" || moves[0].Progress != 30 {
+		t.Fatalf("unexpected moves: %+v", moves)
+	}
+
+	if jobIds := movesToToggle(moves, true); !reflect.DeepEqual(jobIds, []string{"1", "2"}) {
+		t.Errorf("expect running and queued paused, got %v", jobIds)
+	}
+	if jobIds := movesToToggle(moves, false); !reflect.DeepEqual(jobIds, []string{"3"}) {
+		t.Errorf("expect paused continued, got %v", jobIds)
+	}
+}
+
+func TestThrottleVariablesOf(t *testing.T) {
+	rebalanceTask := &DataRebalanceTask{}
+	if variables := rebalanceTask.throttleVariablesOf(nil); len(variables) != 0 {
+		t.Errorf("expect no variables, got %v", variables)
+	}
+
+	spec := &polardbxv1polardbx.RebalanceSpec{SpeedLimit: 10000, Parallelism: 4}
+	if variables := rebalanceTask.throttleVariablesOf(spec); !reflect.DeepEqual(variables, map[string]string{
+		variableBackfillSpeedLimit:  "10000",
+		variableBackfillParallelism: "4",
+	}) {
+		t.Errorf("unexpected variables: %v", variables)
+	}
+
+	rebalanceTask.SpeedLimit, rebalanceTask.Parallelism = 10000, 2
+	if variables := rebalanceTask.throttleVariablesOf(spec); !reflect.DeepEqual(variables, map[string]string{
+		variableBackfillParallelism: "4",
+	}) {
+		t.Errorf("expect only changed applied, got %v", variables)
+	}
+}
+
+func TestMovesOfPlan(t *testing.T) {
+	jobs := []group.DDLStatus{
+		{JobId: "1", Schema: "db", Object: "db", Type: "REBALANCE", State: "RUNNING"},
+		{JobId: "2", Schema: "app", Object: "t1", Type: "ALTER_TABLE", State: "RUNNING"},
+		{JobId: "3", Schema: "app", Object: "t2", Type: "CREATE_INDEX", State: "PAUSED"},
+	}
+	if moves := movesOfPlan(jobs, &group.DDLPlanStatus{PlanId: "p1"}); len(moves) != 0 {
+		t.Errorf("expect no moves before the job launched, got %+v", moves)
+	}
+	moves := movesOfPlan(jobs, &group.DDLPlanStatus{PlanId: "p1", JobId: "1"})
+	if len(moves) != 1 || moves[0].JobId != "1" {
+		t.Fatalf("expect only the move of plan, got %+v", moves)
+	}
+	if jobIds := movesToToggle(moves, false); len(jobIds) != 0 {
+		t.Errorf("expect DDL jobs out of plan never continued, got %v", jobIds)
+	}
+}
+
+func TestThrottleVariablesToRestore(t *testing.T) {
+	rebalanceTask := &DataRebalanceTask{OriginalThrottle: map[string]string{
+		variableBackfillSpeedLimit:  "300000",
+		variableBackfillParallelism: "",
+	}}
+	if variables := rebalanceTask.throttleVariablesToRestore(); !reflect.DeepEqual(variables, map[string]string{
+		variableBackfillSpeedLimit: "300000",
+	}) {
+		t.Errorf("unexpected variables to restore: %v", variables)
+	}
+}
@@ -0,0 +1,311 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1polardbx "github.com/alibaba/polardbx-operator/api/v1/polardbx"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+)
+
+// importSourceOf returns the source of the backup set to import, empty if the backup set is in the
+// namespace of the cluster.
+func importSourceOf(polardbx *polardbxv1.PolarDBXCluster) string {
+	restore := polardbx.Spec.Restore
+	if location := restore.From.BackupLocation; location != nil {
+		return location.StorageName + ":" + location.Path
+	}
+	if len(restore.From.Namespace) > 0 && restore.From.Namespace != polardbx.Namespace {
+		return restore.From.Namespace + "/" + restore.BackupSet
+	}
+	return ""
+}
+
+// importedObjectMeta returns the object meta of the imported object in the namespace.
+func importedObjectMeta(meta metav1.ObjectMeta, namespace, source string) metav1.ObjectMeta {
+	annotations := make(map[string]string, len(meta.Annotations)+1)
+	for k, v := range meta.Annotations {
+		annotations[k] = v
+	}
+	annotations[polardbxmeta.AnnotationBackupImportedFrom] = source
+	labels := make(map[string]string, len(meta.Labels))
+	for k, v := range meta.Labels {
+		labels[k] = v
+	}
+	// Never taken as the backups of the schedule in the namespace.
+	delete(labels, polardbxmeta.LabelBackupSchedule)
+	return metav1.ObjectMeta{
+		Name:        meta.Name,
+		Namespace:   namespace,
+		Labels:      labels,
+		Annotations: annotations,
+	}
+}
+
+// getBackupSetToImport gets the backup set from the manifest in the storage or from the namespace
+// of the source. The reason is returned if the backup set can't be imported.
+func getBackupSetToImport(rc *polardbxv1reconcile.Context, polardbx *polardbxv1.PolarDBXCluster) (*helper.BackupSet, string, error) {
+	restore := polardbx.Spec.Restore
+	var set *helper.BackupSet
+	if location := restore.From.BackupLocation; location != nil {
+		provider := polardbxv1.BackupStorageProvider{
+			StorageName:         polardbxv1.BackupStorage(location.StorageName),
+			Sink:                location.Sink,
+			RetentionCredential: &location.Credential,
+		}
+		data, err := helper.DownloadBackupFile(rc.Context(), rc.Client(), polardbx.Namespace, provider,
+			path.Join(location.Path, helper.BackupManifestName))
+		if err != nil {
+			return nil, "", err
+		}
+		manifest := &helper.BackupManifest{}
+		if err := json.Unmarshal(data, manifest); err != nil {
+			return nil, "Invalid backup manifest: " + err.Error(), nil
+		}
+
+		var key []byte
+		if manifest.Encrypted {
+			encryption := manifest.Backup.Spec.Encryption
+			if encryption == nil {
+				return nil, "Encryption of the encrypted backup manifest not found.", nil
+			}
+			secret, err := rc.GetSecret(encryption.SecretName)
+			if apierrors.IsNotFound(err) {
+				return nil, "Secret of encryption key " + encryption.SecretName + " not found in namespace.", nil
+			}
+			if err != nil {
+				return nil, "", err
+			}
+			if key, err = helper.BackupEncryptionKey(secret, encryption); err != nil {
+				return nil, "Invalid encryption key: " + err.Error(), nil
+			}
+		}
+		if set, err = manifest.BackupSet(key); err != nil {
+			return nil, err.Error(), nil
+		}
+		// The sink may be named differently in the operator restoring.
+		set.Backup.Spec.StorageProvider.StorageName = provider.StorageName
+		set.Backup.Spec.StorageProvider.Sink = provider.Sink
+		for i := range set.XStoreBackups {
+			set.XStoreBackups[i].Spec.StorageProvider.StorageName = provider.StorageName
+			set.XStoreBackups[i].Spec.StorageProvider.Sink = provider.Sink
+		}
+	} else {
+		if len(restore.BackupSet) == 0 {
+			return nil, "Restore from another namespace requires the backup set.", nil
+		}
+		backup := &polardbxv1.PolarDBXBackup{}
+		err := rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: restore.From.Namespace, Name: restore.BackupSet}, backup)
+		if apierrors.IsNotFound(err) {
+			return nil, "Backup " + restore.BackupSet + " not found in namespace " + restore.From.Namespace + ".", nil
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		if set, err = helper.GetBackupSet(rc.Context(), rc.Client(), backup); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if set.Backup.Status.Phase != polardbxv1.BackupFinished {
+		return nil, "Backup " + set.Backup.Name + " is not finished.", nil
+	}
+	if unfinished := set.Unfinished(); unfinished != nil {
+		return nil, "Xstore backup " + unfinished.Name + " is not finished.", nil
+	}
+	for _, b := range set.XStoreBackups {
+		// The chain of bases isn't imported.
+		if b.Spec.BackupType == polardbxv1.BackupTypeIncremental {
+			return nil, "Incremental xstore backup " + b.Name + " can't be imported.", nil
+		}
+	}
+	return set, "", nil
+}
+
+// createImported creates the imported object, or takes the existing one if it's imported from the
+// same source. The object is updated with the one created or taken. The reason is returned if the
+// object of the same name exists.
+func createImported(rc *polardbxv1reconcile.Context, obj client.Object, kind, source string) (string, error) {
+	err := rc.Client().Create(rc.Context(), obj)
+	if !apierrors.IsAlreadyExists(err) {
+		return "", err
+	}
+	if err := rc.Client().Get(rc.Context(), client.ObjectKeyFromObject(obj), obj); err != nil {
+		return "", err
+	}
+	if obj.GetAnnotations()[polardbxmeta.AnnotationBackupImportedFrom] != source {
+		return fmt.Sprintf("%s %s already exists in namespace and isn't imported from %s.", kind, obj.GetName(), source), nil
+	}
+	return "", nil
+}
+
+// importBackupSet creates the backup set in the namespace of the cluster. The imported pxc backup is
+// owned by the cluster and owns the others, so that they're removed along with the cluster. Files
+// are never deleted through the imported backups since they've no retention credential.
+func importBackupSet(rc *polardbxv1reconcile.Context, polardbx *polardbxv1.PolarDBXCluster, set *helper.BackupSet, source string) (string, error) {
+	backup := &polardbxv1.PolarDBXBackup{
+		ObjectMeta: importedObjectMeta(set.Backup.ObjectMeta, polardbx.Namespace, source),
+		Spec:       *set.Backup.Spec.DeepCopy(),
+	}
+	backup.Spec.StorageProvider.RetentionCredential = nil
+	if err := controllerutil.SetOwnerReference(polardbx, backup, rc.Scheme()); err != nil {
+		return "", err
+	}
+	if reason, err := createImported(rc, backup, "Backup", source); len(reason) > 0 || err != nil {
+		return reason, err
+	}
+	if len(backup.Status.Phase) == 0 {
+		backup.Status = *set.Backup.Status.DeepCopy()
+		if err := rc.Client().Status().Update(rc.Context(), backup); err != nil {
+			return "", err
+		}
+	}
+
+	for i := range set.XStoreBackups {
+		b := &set.XStoreBackups[i]
+		xstoreBackup := &polardbxv1.XStoreBackup{
+			ObjectMeta: importedObjectMeta(b.ObjectMeta, polardbx.Namespace, source),
+			Spec:       *b.Spec.DeepCopy(),
+		}
+		// The xstore backed up doesn't exist in the namespace.
+		xstoreBackup.Annotations[polardbxmeta.AnnotationBackupSkipXStoreCheck] = "true"
+		xstoreBackup.Spec.StorageProvider.RetentionCredential = nil
+		if err := controllerutil.SetControllerReference(backup, xstoreBackup, rc.Scheme()); err != nil {
+			return "", err
+		}
+		if reason, err := createImported(rc, xstoreBackup, "Xstore backup", source); len(reason) > 0 || err != nil {
+			return reason, err
+		}
+		if len(xstoreBackup.Status.Phase) == 0 {
+			xstoreBackup.Status = *b.Status.DeepCopy()
+			if err := rc.Client().Status().Update(rc.Context(), xstoreBackup); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	for name, data := range set.Secrets {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: polardbx.Namespace,
+				Annotations: map[string]string{
+					polardbxmeta.AnnotationBackupImportedFrom: source,
+				},
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: data,
+		}
+		if err := controllerutil.SetControllerReference(backup, secret, rc.Scheme()); err != nil {
+			return "", err
+		}
+		if reason, err := createImported(rc, secret, "Secret", source); len(reason) > 0 || err != nil {
+			return reason, err
+		}
+	}
+
+	// The key of the encrypted backup is mounted to the restore jobs, so it must be in the namespace.
+	// It's copied from the namespace of the source if not found, and never overwritten. The one of
+	// the manifest is already required to be in the namespace.
+	if encryption := set.Backup.Spec.Encryption; encryption != nil && polardbx.Spec.Restore.From.BackupLocation == nil {
+		_, err := rc.GetSecret(encryption.SecretName)
+		if client.IgnoreNotFound(err) != nil {
+			return "", err
+		}
+		if apierrors.IsNotFound(err) {
+			key := &corev1.Secret{}
+			err := rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: set.Backup.Namespace, Name: encryption.SecretName}, key)
+			if apierrors.IsNotFound(err) {
+				return "Secret of encryption key " + encryption.SecretName + " not found in namespace " + set.Backup.Namespace + ".", nil
+			}
+			if err != nil {
+				return "", err
+			}
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      key.Name,
+					Namespace: polardbx.Namespace,
+					Annotations: map[string]string{
+						polardbxmeta.AnnotationBackupImportedFrom: source,
+					},
+				},
+				Type: key.Type,
+				Data: key.Data,
+			}
+			if err := controllerutil.SetControllerReference(backup, secret, rc.Scheme()); err != nil {
+				return "", err
+			}
+			if err := rc.Client().Create(rc.Context(), secret); err != nil && !apierrors.IsAlreadyExists(err) {
+				return "", err
+			}
+		}
+	}
+	return "", nil
+}
+
+// ImportBackupSet imports the backup set into the namespace of the cluster if it's in another
+// namespace or only in the remote storage, and restores from the imported one. It's imported
+// again on failures, the objects imported are taken as is.
+var ImportBackupSet = polardbxv1reconcile.NewStepBinder("ImportBackupSet",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		polardbx := rc.MustGetPolarDBX()
+		source := importSourceOf(polardbx)
+		if len(source) == 0 {
+			return flow.Pass()
+		}
+
+		set, reason, err := getBackupSetToImport(rc, polardbx)
+		if err != nil {
+			return flow.Error(err, "Unable to get backup set to import.", "source", source)
+		}
+		if len(reason) == 0 {
+			if reason, err = importBackupSet(rc, polardbx, set, source); err != nil {
+				return flow.Error(err, "Unable to import backup set.", "source", source)
+			}
+		}
+
+		if polardbx.Status.RestoreStatus == nil {
+			polardbx.Status.RestoreStatus = &polardbxv1polardbx.RestoreStatus{}
+		}
+		if len(reason) > 0 {
+			polardbx.Status.RestoreStatus.Message = reason
+			helper.TransferPhase(polardbx, polardbxv1polardbx.PhaseFailed)
+			return flow.Retry("Unable to import backup set.", "source", source, "reason", reason)
+		}
+		polardbx.Status.RestoreStatus.ImportedFrom = source
+		if polardbx.Spec.Restore.BackupSet != set.Backup.Name {
+			polardbx.Spec.Restore.BackupSet = set.Backup.Name
+			rc.MarkPolarDBXChanged()
+		}
+		return flow.Continue("Backup set imported.", "source", source, "backup", set.Backup.Name)
+	},
+)
@@ -0,0 +1,77 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1polardbx "github.com/alibaba/polardbx-operator/api/v1/polardbx"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+)
+
+func TestImportSourceOf(t *testing.T) {
+	polardbx := &polardbxv1.PolarDBXCluster{}
+	polardbx.Namespace = "staging"
+	polardbx.Spec.Restore = &polardbxv1polardbx.RestoreSpec{BackupSet: "pxc-backup"}
+	if source := importSourceOf(polardbx); len(source) > 0 {
+		t.Fatalf("expect nothing to import, got %s", source)
+	}
+	polardbx.Spec.Restore.From.Namespace = "staging"
+	if source := importSourceOf(polardbx); len(source) > 0 {
+		t.Fatalf("expect nothing to import in the same namespace, got %s", source)
+	}
+	polardbx.Spec.Restore.From.Namespace = "prod"
+	if source := importSourceOf(polardbx); source != "prod/pxc-backup" {
+		t.Fatalf("unexpected source: %s", source)
+	}
+	polardbx.Spec.Restore.From.BackupLocation = &polardbxv1polardbx.RestoreBackupLocation{
+		StorageName: "s3",
+		Sink:        "default",
+		Credential:  corev1.LocalObjectReference{Name: "cred"},
+		Path:        "polardbx-backup/prod/pxc-backup",
+	}
+	if source := importSourceOf(polardbx); source != "s3:polardbx-backup/prod/pxc-backup" {
+		t.Fatalf("unexpected source: %s", source)
+	}
+}
+
+func TestImportedObjectMeta(t *testing.T) {
+	meta := metav1.ObjectMeta{
+		Name:        "pxc-backup",
+		Namespace:   "prod",
+		UID:         "uid-1",
+		Labels:      map[string]string{polardbxmeta.LabelName: "pxc", polardbxmeta.LabelBackupSchedule: "daily"},
+		Annotations: map[string]string{"a": "b"},
+	}
+	imported := importedObjectMeta(meta, "staging", "prod/pxc-backup")
+	if imported.Name != "pxc-backup" || imported.Namespace != "staging" || len(imported.UID) > 0 {
+		t.Fatalf("unexpected object meta: %+v", imported)
+	}
+	if imported.Labels[polardbxmeta.LabelName] != "pxc" || len(imported.Labels[polardbxmeta.LabelBackupSchedule]) > 0 {
+		t.Fatalf("unexpected labels: %v", imported.Labels)
+	}
+	if imported.Annotations["a"] != "b" || imported.Annotations[polardbxmeta.AnnotationBackupImportedFrom] != "prod/pxc-backup" {
+		t.Fatalf("unexpected annotations: %v", imported.Annotations)
+	}
+	if _, ok := meta.Annotations[polardbxmeta.AnnotationBackupImportedFrom]; ok {
+		t.Fatal("expect source object meta untouched")
+	}
+}
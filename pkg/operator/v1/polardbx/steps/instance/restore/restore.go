@@ -0,0 +1,239 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"errors"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1polardbx "github.com/alibaba/polardbx-operator/api/v1/polardbx"
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// getRestoreShards returns the xstores (GMS and DNs) which take part in the restore.
+func getRestoreShards(rc *polardbxv1reconcile.Context) ([]*polardbxv1.XStore, error) {
+	polardbx := rc.MustGetPolarDBX()
+	shards := make([]*polardbxv1.XStore, 0)
+	if !polardbx.Spec.ShareGMS {
+		gms, err := rc.GetGMS()
+		if client.IgnoreNotFound(err) != nil {
+			return nil, err
+		}
+		if gms != nil {
+			shards = append(shards, gms)
+		}
+	}
+	dnMap, err := rc.GetDNMap()
+	if err != nil {
+		return nil, err
+	}
+	for _, dn := range dnMap {
+		shards = append(shards, dn)
+	}
+	return shards, nil
+}
+
+func shardPhaseOf(xstore *polardbxv1.XStore) polardbxv1polardbx.RestoreShardPhase {
+	switch xstore.Status.Phase {
+	case polardbxv1xstore.PhaseRunning:
+		return polardbxv1polardbx.RestoreShardSucceeded
+	case polardbxv1xstore.PhaseFailed:
+		return polardbxv1polardbx.RestoreShardFailed
+	default:
+		return polardbxv1polardbx.RestoreShardRestoring
+	}
+}
+
+func shardMessageOf(xstore *polardbxv1.XStore) string {
+	for _, cond := range xstore.Status.Conditions {
+		if cond.Type == polardbxv1xstore.Restorable {
+			return cond.Message
+		}
+	}
+	return ""
+}
+
+var UpdateRestoreShardStatus = polardbxv1reconcile.NewStepBinder("UpdateRestoreShardStatus",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		polardbx := rc.MustGetPolarDBX()
+		if polardbx.Spec.Restore == nil {
+			return flow.Pass()
+		}
+
+		shards, err := getRestoreShards(rc)
+		if err != nil {
+			return flow.Error(err, "Unable to get xstores of restore shards.")
+		}
+
+		if polardbx.Status.RestoreStatus == nil {
+			polardbx.Status.RestoreStatus = &polardbxv1polardbx.RestoreStatus{}
+		}
+		if polardbx.Status.RestoreStatus.Shards == nil {
+			polardbx.Status.RestoreStatus.Shards = make(map[string]polardbxv1polardbx.RestoreShardStatus)
+		}
+
+		now := metav1.Now()
+		for _, xstore := range shards {
+			// Shards being reset are kept pending until re-created.
+			if !xstore.DeletionTimestamp.IsZero() {
+				continue
+			}
+			status := polardbx.Status.RestoreStatus.Shards[xstore.Name]
+			phase := shardPhaseOf(xstore)
+			if status.Attempts == 0 {
+				status.Attempts = 1
+			}
+			if status.Phase != phase {
+				status.Phase = phase
+				status.LastTransitionTime = &now
+			}
+			status.Message = shardMessageOf(xstore)
+			polardbx.Status.RestoreStatus.Shards[xstore.Name] = status
+		}
+
+		return flow.Continue("Restore shard status updated.")
+	},
+)
+
+// resetRestoreShards marks the shards pending and deletes their xstores, so that they are
+// re-created and restored again. It returns true once all the xstores are gone.
+func resetRestoreShards(rc *polardbxv1reconcile.Context, targets map[string]bool) (bool, error) {
+	polardbx := rc.MustGetPolarDBX()
+	restoreStatus := polardbx.Status.RestoreStatus
+
+	now := metav1.Now()
+	allDeleted := true
+	for name := range targets {
+		var xstore polardbxv1.XStore
+		err := rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: polardbx.Namespace, Name: name}, &xstore)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return false, err
+		}
+		allDeleted = false
+
+		status := restoreStatus.Shards[name]
+		if status.Phase != polardbxv1polardbx.RestoreShardPending {
+			status.Phase = polardbxv1polardbx.RestoreShardPending
+			status.Attempts++
+			status.LastTransitionTime = &now
+			status.Message = ""
+			restoreStatus.Shards[name] = status
+		}
+
+		if controllerutil.ContainsFinalizer(&xstore, polardbxmeta.Finalizer) {
+			controllerutil.RemoveFinalizer(&xstore, polardbxmeta.Finalizer)
+			if err := rc.Client().Update(rc.Context(), &xstore); err != nil {
+				return false, err
+			}
+		}
+		if xstore.DeletionTimestamp.IsZero() {
+			if err := rc.Client().Delete(rc.Context(), &xstore, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil {
+				if !apierrors.IsNotFound(err) {
+					return false, err
+				}
+			}
+		}
+	}
+	return allDeleted, nil
+}
+
+// RetryFailedRestoreShards restores the failed shards again while restoring, until they run out of
+// attempts. The cluster is kept in restoring meanwhile.
+var RetryFailedRestoreShards = polardbxv1reconcile.NewStepBinder("RetryFailedRestoreShards",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		polardbx := rc.MustGetPolarDBX()
+		restoreStatus := polardbx.Status.RestoreStatus
+
+		targets := make(map[string]bool)
+		for _, name := range helper.GetRestoreShardsToRetry(restoreStatus) {
+			targets[name] = true
+		}
+		if restoreStatus != nil {
+			for name, status := range restoreStatus.Shards {
+				if status.Phase == polardbxv1polardbx.RestoreShardPending {
+					targets[name] = true
+				}
+			}
+		}
+		if len(targets) == 0 {
+			return flow.Pass()
+		}
+
+		allDeleted, err := resetRestoreShards(rc, targets)
+		if err != nil {
+			return flow.Error(err, "Unable to reset xstores of failed shards.")
+		}
+		if !allDeleted {
+			return flow.Retry("Wait until xstores of failed shards deleted.")
+		}
+		return flow.Continue("Failed shards are going to be restored again.")
+	},
+)
+
+var ResumeFailedRestoreShards = polardbxv1reconcile.NewStepBinder("ResumeFailedRestoreShards",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		polardbx := rc.MustGetPolarDBX()
+		restoreStatus := polardbx.Status.RestoreStatus
+		if restoreStatus == nil {
+			return flow.Error(errors.New("restore status not found"), "Unable to resume restore.")
+		}
+
+		// Failed and pending (deleted but not yet re-created) shards are always resumed,
+		// shards listed in the annotation are re-restored as well.
+		targets := make(map[string]bool)
+		for name, status := range restoreStatus.Shards {
+			if status.Phase == polardbxv1polardbx.RestoreShardFailed ||
+				status.Phase == polardbxv1polardbx.RestoreShardPending {
+				targets[name] = true
+			}
+		}
+		for _, name := range helper.GetShardsToForceRestore(polardbx) {
+			if _, ok := restoreStatus.Shards[name]; ok {
+				targets[name] = true
+			} else {
+				flow.Logger().Info("Ignore unknown shard to force restore.", "shard", name)
+			}
+		}
+
+		allDeleted, err := resetRestoreShards(rc, targets)
+		if err != nil {
+			return flow.Error(err, "Unable to reset xstores of resumed shards.")
+		}
+		if !allDeleted {
+			return flow.Retry("Wait until xstores of resumed shards deleted.")
+		}
+
+		delete(polardbx.Annotations, polardbxmeta.AnnotationRestoreResume)
+		delete(polardbx.Annotations, polardbxmeta.AnnotationRestoreForceShards)
+		rc.MarkPolarDBXChanged()
+
+		return flow.Continue("Restore shards resumed.")
+	},
+)
@@ -0,0 +1,98 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/convention"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/factory"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+)
+
+// ReconcileTemplateServices keeps the services of the service templates in sync with the templates
+// and the current topology, i.e. services of DN targets follow the DNs. Services of templates removed
+// are deleted.
+var ReconcileTemplateServices = polardbxv1reconcile.NewStepBinder("ReconcileTemplateServices",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		polardbx := rc.MustGetPolarDBX()
+
+		var serviceList corev1.ServiceList
+		err := rc.Client().List(rc.Context(), &serviceList,
+			client.InNamespace(rc.Namespace()),
+			client.MatchingLabels(convention.ConstLabels(polardbx)),
+			client.HasLabels{polardbxmeta.LabelServiceTemplate},
+		)
+		if err != nil {
+			return flow.Error(err, "Unable to list template services.")
+		}
+		if len(serviceList.Items) == 0 && len(polardbx.Spec.ServiceTemplates) == 0 {
+			return flow.Pass()
+		}
+
+		dns, err := rc.GetOrderedDNList()
+		if err != nil {
+			return flow.Error(err, "Unable to get DNs.")
+		}
+		desiredServices := factory.NewTemplateServices(polardbx, dns)
+
+		observedServices := make(map[string]*corev1.Service)
+		for i := range serviceList.Items {
+			observedServices[serviceList.Items[i].Name] = &serviceList.Items[i]
+		}
+
+		changed := false
+		for i := range desiredServices {
+			desired := &desiredServices[i]
+			observed, ok := observedServices[desired.Name]
+			delete(observedServices, desired.Name)
+
+			if !ok {
+				if err := rc.SetControllerRefAndCreate(desired); err != nil && !apierrors.IsAlreadyExists(err) {
+					return flow.Error(err, "Unable to create template service.", "service", desired.Name)
+				}
+				changed = true
+				continue
+			}
+
+			if !factory.MergeTemplateService(observed, desired) {
+				continue
+			}
+			if err := rc.Client().Update(rc.Context(), observed); err != nil {
+				return flow.Error(err, "Unable to update template service.", "service", observed.Name)
+			}
+			changed = true
+		}
+
+		for _, svc := range observedServices {
+			if err := rc.Client().Delete(rc.Context(), svc); client.IgnoreNotFound(err) != nil {
+				return flow.Error(err, "Unable to delete template service.", "service", svc.Name)
+			}
+			changed = true
+		}
+
+		if changed {
+			return flow.Continue("Template services reconciled.")
+		}
+		return flow.Pass()
+	},
+)
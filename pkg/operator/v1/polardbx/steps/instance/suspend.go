@@ -0,0 +1,198 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/factory"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+)
+
+// getOwnedStores returns the DN stores and the standalone GMS store if it's owned by the cluster.
+func getOwnedStores(rc *polardbxv1reconcile.Context) ([]*polardbxv1.XStore, error) {
+	polardbx := rc.MustGetPolarDBX()
+
+	dnStores, err := rc.GetDNMap()
+	if err != nil {
+		return nil, err
+	}
+	stores := make([]*polardbxv1.XStore, 0, len(dnStores)+1)
+	for _, dnStore := range dnStores {
+		stores = append(stores, dnStore)
+	}
+
+	if !polardbx.Spec.Readonly && !polardbx.Spec.ShareGMS {
+		gmsStore, err := rc.GetGMS()
+		if err != nil {
+			return nil, err
+		}
+		stores = append(stores, gmsStore)
+	}
+	return stores, nil
+}
+
+func countStoresNotSuspended(stores []*polardbxv1.XStore) int {
+	cnt := 0
+	for _, xstore := range stores {
+		if !xstore.Spec.Suspended || xstore.Status.Phase != polardbxv1xstore.PhaseSuspended {
+			cnt++
+		}
+	}
+	return cnt
+}
+
+var ScaleCNAndCDCToZero = polardbxv1reconcile.NewStepBinder("ScaleCNAndCDCToZero",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		for _, role := range []string{polardbxmeta.RoleCN, polardbxmeta.RoleCDC} {
+			deployments, err := rc.GetDeploymentMap(role)
+			if err != nil {
+				return flow.Error(err, "Unable to get deployments.", "role", role)
+			}
+
+			for _, deployment := range deployments {
+				if deployment.Spec.Replicas != nil && *deployment.Spec.Replicas == 0 {
+					continue
+				}
+				deployment.Spec.Replicas = pointer.Int32(0)
+				if err := rc.Client().Update(rc.Context(), deployment); err != nil {
+					return flow.Error(err, "Unable to scale deployment to zero.", "deployment", deployment.Name)
+				}
+			}
+		}
+
+		return flow.Continue("Deployments of CN and CDC are scaled to zero.")
+	},
+)
+
+var WaitUntilCNAndCDCPodsStopped = polardbxv1reconcile.NewStepBinder("WaitUntilCNAndCDCPodsStopped",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		for _, role := range []string{polardbxmeta.RoleCN, polardbxmeta.RoleCDC} {
+			pods, err := rc.GetPods(role)
+			if err != nil {
+				return flow.Error(err, "Unable to get pods.", "role", role)
+			}
+			if len(pods) > 0 {
+				return flow.RetryAfter(5*time.Second, "Wait until pods are stopped.", "role", role, "pods", len(pods))
+			}
+		}
+
+		return flow.Continue("Pods of CN and CDC are stopped.")
+	},
+)
+
+// RestoreCNAndCDCReplicas scales the deployments of CN and CDC back to the replicas
+// in the observed topology.
+var RestoreCNAndCDCReplicas = polardbxv1reconcile.NewStepBinder("RestoreCNAndCDCReplicas",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		polardbx := rc.MustGetPolarDBX()
+
+		for _, role := range []string{polardbxmeta.RoleCN, polardbxmeta.RoleCDC} {
+			observedDeployments, err := rc.GetDeploymentMap(role)
+			if err != nil {
+				return flow.Error(err, "Unable to get deployments.", "role", role)
+			}
+
+			var deployments map[string]appsv1.Deployment
+			if role == polardbxmeta.RoleCN {
+				if !rc.HasCNs() {
+					continue
+				}
+				deployments, err = factory.NewObjectFactory(rc).NewDeployments4CN()
+			} else {
+				if polardbx.Spec.Readonly {
+					continue
+				}
+				deployments, err = factory.NewObjectFactory(rc).NewDeployments4CDC()
+			}
+			if err != nil {
+				return flow.Error(err, "Unable to new deployments.", "role", role)
+			}
+
+			for group, observedDeployment := range observedDeployments {
+				deployment, ok := deployments[group]
+				if !ok || deployment.Spec.Replicas == nil {
+					continue
+				}
+				if observedDeployment.Spec.Replicas != nil &&
+					*observedDeployment.Spec.Replicas == *deployment.Spec.Replicas {
+					continue
+				}
+				observedDeployment.Spec.Replicas = pointer.Int32(*deployment.Spec.Replicas)
+				if err := rc.Client().Update(rc.Context(), observedDeployment); err != nil {
+					return flow.Error(err, "Unable to restore replicas of deployment.",
+						"deployment", observedDeployment.Name)
+				}
+			}
+		}
+
+		return flow.Continue("Replicas of CN and CDC are restored.")
+	},
+)
+
+func setStoresSuspended(suspended bool) control.BindFunc {
+	name := "ResumeStores"
+	if suspended {
+		name = "SuspendStores"
+	}
+	return polardbxv1reconcile.NewStepBinder(name,
+		func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+			stores, err := getOwnedStores(rc)
+			if err != nil {
+				return flow.Error(err, "Unable to get xstores.")
+			}
+
+			for _, xstore := range stores {
+				if xstore.Spec.Suspended == suspended {
+					continue
+				}
+				xstore.Spec.Suspended = suspended
+				if err := rc.Client().Update(rc.Context(), xstore); err != nil {
+					return flow.Error(err, "Unable to update xstore.", "xstore", xstore.Name, "suspended", suspended)
+				}
+			}
+
+			return flow.Continue("XStores updated.", "suspended", suspended)
+		},
+	)
+}
+
+var SuspendStores = setStoresSuspended(true)
+
+var ResumeStores = setStoresSuspended(false)
+
+var WaitUntilStoresSuspended = polardbxv1reconcile.NewStepBinder("WaitUntilStoresSuspended",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		stores, err := getOwnedStores(rc)
+		if err != nil {
+			return flow.Error(err, "Unable to get xstores.")
+		}
+
+		if cnt := countStoresNotSuspended(stores); cnt > 0 {
+			return flow.RetryAfter(5*time.Second, "Wait until xstores are suspended.", "not-suspended", cnt)
+		}
+		return flow.Continue("XStores are suspended.")
+	},
+)
@@ -0,0 +1,71 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"testing"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+)
+
+func TestCountStoresNotSuspended(t *testing.T) {
+	store := func(suspended bool, phase polardbxv1xstore.Phase) *polardbxv1.XStore {
+		return &polardbxv1.XStore{
+			Spec:   polardbxv1.XStoreSpec{Suspended: suspended},
+			Status: polardbxv1.XStoreStatus{Phase: phase},
+		}
+	}
+
+	testcases := map[string]struct {
+		stores []*polardbxv1.XStore
+		expect int
+	}{
+		"none": {
+			stores: nil,
+			expect: 0,
+		},
+		"all-suspended": {
+			stores: []*polardbxv1.XStore{
+				store(true, polardbxv1xstore.PhaseSuspended),
+				store(true, polardbxv1xstore.PhaseSuspended),
+			},
+			expect: 0,
+		},
+		"still-running": {
+			stores: []*polardbxv1.XStore{
+				store(true, polardbxv1xstore.PhaseSuspended),
+				store(true, polardbxv1xstore.PhaseRunning),
+			},
+			expect: 1,
+		},
+		"resume-requested": {
+			stores: []*polardbxv1.XStore{
+				store(false, polardbxv1xstore.PhaseSuspended),
+			},
+			expect: 1,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			if cnt := countStoresNotSuspended(tc.stores); cnt != tc.expect {
+				t.Fatalf("expect %d, but got %d", tc.expect, cnt)
+			}
+		})
+	}
+}
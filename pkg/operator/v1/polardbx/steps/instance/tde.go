@@ -0,0 +1,58 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+)
+
+// SyncStoresTDE syncs the TDE config to GMS and DNs. The pods of xstores are rebuilt one by one
+// when it's enabled, and the master keys are rotated online on version increased. Changes which
+// can't be applied to the encrypted data are never synced.
+var SyncStoresTDE = polardbxv1reconcile.NewStepBinder("SyncStoresTDE",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		polardbx := rc.MustGetPolarDBX()
+		tde := helper.GetTDE(polardbx)
+
+		stores, err := getOwnedStores(rc)
+		if err != nil {
+			return flow.Error(err, "Unable to get xstores.")
+		}
+		for _, xstore := range stores {
+			if equality.Semantic.DeepEqual(xstore.Spec.TDE, tde) {
+				continue
+			}
+			if err := polardbxv1xstore.ValidateTDETransition(xstore.Spec.TDE, tde); err != nil {
+				flow.Logger().Info("TDE of xstore can't be changed, skip.", "xstore", xstore.Name, "reason", err.Error())
+				continue
+			}
+			xstore.Spec.TDE = tde.DeepCopy()
+			if err := rc.Client().Update(rc.Context(), xstore); err != nil {
+				return flow.Error(err, "Unable to update xstore.", "xstore", xstore.Name)
+			}
+			flow.Logger().Info("TDE of xstore updated.", "xstore", xstore.Name, "enabled", tde != nil)
+		}
+
+		return flow.Pass()
+	},
+)
@@ -0,0 +1,342 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"bytes"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1polardbx "github.com/alibaba/polardbx-operator/api/v1/polardbx"
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	"github.com/alibaba/polardbx-operator/pkg/meta/core/gms/security"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/convention"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/factory"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+	"github.com/alibaba/polardbx-operator/pkg/util/defaults"
+	"github.com/alibaba/polardbx-operator/pkg/util/ssl"
+)
+
+var certificateGVK = schema.GroupVersionKind{
+	Group:   "cert-manager.io",
+	Version: "v1",
+	Kind:    "Certificate",
+}
+
+// certificateKeys are the keys of certificates in the security secret.
+var certificateKeys = []string{
+	convention.SecretKeyRootCrt,
+	convention.SecretKeyServerCrt,
+	convention.SecretKeyServerKey,
+}
+
+// isCertificateAboutToExpire returns true if the certificate is invalid or expires in the renew window.
+func isCertificateAboutToExpire(crt []byte, renewBefore time.Duration, now time.Time) bool {
+	cert, err := ssl.ParseCert(crt)
+	if err != nil {
+		return true
+	}
+	return !now.Add(renewBefore).Before(cert.NotAfter)
+}
+
+// isCertificateCoveringDNSNames returns true if the certificate is valid for all the DNS names.
+func isCertificateCoveringDNSNames(crt []byte, dnsNames []string) bool {
+	cert, err := ssl.ParseCert(crt)
+	if err != nil {
+		return false
+	}
+	for _, name := range dnsNames {
+		if cert.VerifyHostname(name) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func newCertificateSpec(polardbx *polardbxv1.PolarDBXCluster) map[string]interface{} {
+	issuerRef := polardbx.Spec.Security.TLS.IssuerRef
+	dnsNames := make([]interface{}, 0)
+	for _, name := range factory.NewCertificateDNSNames(polardbx) {
+		dnsNames = append(dnsNames, name)
+	}
+
+	return map[string]interface{}{
+		"secretName":  convention.NewCertificateName(polardbx),
+		"duration":    helper.GetTLSDuration(polardbx).String(),
+		"renewBefore": helper.GetTLSRenewBefore(polardbx).String(),
+		"dnsNames":    dnsNames,
+		"usages":      []interface{}{"server auth", "client auth"},
+		"issuerRef": map[string]interface{}{
+			"name":  issuerRef.Name,
+			"kind":  defaults.NonEmptyStrOrDefault(issuerRef.Kind, "Issuer"),
+			"group": defaults.NonEmptyStrOrDefault(issuerRef.Group, certificateGVK.Group),
+		},
+	}
+}
+
+// reconcileCertificate ensures the certificate of cert-manager and returns the certificates in
+// the secret issued. Nil is returned if it's not issued yet.
+func reconcileCertificate(rc *polardbxv1reconcile.Context, polardbx *polardbxv1.PolarDBXCluster) (map[string][]byte, error) {
+	spec := newCertificateSpec(polardbx)
+
+	cert := &unstructured.Unstructured{}
+	cert.SetGroupVersionKind(certificateGVK)
+	err := rc.Client().Get(rc.Context(), types.NamespacedName{
+		Namespace: polardbx.Namespace,
+		Name:      convention.NewCertificateName(polardbx),
+	}, cert)
+	if client.IgnoreNotFound(err) != nil {
+		return nil, err
+	}
+	if apierrors.IsNotFound(err) {
+		cert = &unstructured.Unstructured{}
+		cert.SetGroupVersionKind(certificateGVK)
+		cert.SetNamespace(polardbx.Namespace)
+		cert.SetName(convention.NewCertificateName(polardbx))
+		cert.SetLabels(convention.ConstLabels(polardbx))
+		if err := unstructured.SetNestedMap(cert.Object, spec, "spec"); err != nil {
+			return nil, err
+		}
+		if err := rc.SetControllerRefAndCreate(cert); err != nil && !apierrors.IsAlreadyExists(err) {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	// Only the fields managed are compared, others may be defaulted by cert-manager.
+	observedSpec, _, _ := unstructured.NestedMap(cert.Object, "spec")
+	specChanged := false
+	for k, v := range spec {
+		if !equality.Semantic.DeepEqual(observedSpec[k], v) {
+			if observedSpec == nil {
+				observedSpec = make(map[string]interface{})
+			}
+			observedSpec[k] = v
+			specChanged = true
+		}
+	}
+	if specChanged {
+		if err := unstructured.SetNestedMap(cert.Object, observedSpec, "spec"); err != nil {
+			return nil, err
+		}
+		if err := rc.Client().Update(rc.Context(), cert); err != nil {
+			return nil, err
+		}
+	}
+
+	var secret corev1.Secret
+	err = rc.Client().Get(rc.Context(), types.NamespacedName{
+		Namespace: polardbx.Namespace,
+		Name:      convention.NewCertificateName(polardbx),
+	}, &secret)
+	if err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	if len(secret.Data[convention.CertManagerSecretKeyTLSCrt]) == 0 {
+		return nil, nil
+	}
+	return map[string][]byte{
+		convention.SecretKeyRootCrt:   secret.Data[convention.CertManagerSecretKeyCACrt],
+		convention.SecretKeyServerCrt: secret.Data[convention.CertManagerSecretKeyTLSCrt],
+		convention.SecretKeyServerKey: secret.Data[convention.CertManagerSecretKeyTLSKey],
+	}, nil
+}
+
+// resolveCertificates returns the certificates expected in the security secret, from the
+// self-signed ones (renewed if about to expire or not covering the services), the user's
+// secret or cert-manager.
+func resolveCertificates(rc *polardbxv1reconcile.Context, polardbx *polardbxv1.PolarDBXCluster,
+	securitySecret *corev1.Secret) (map[string][]byte, error) {
+	tls := polardbx.Spec.Security.TLS
+	if tls.GenerateSelfSigned {
+		// Signed again if the services covered changed, e.g. DNs are added.
+		serverCrt := securitySecret.Data[convention.SecretKeyServerCrt]
+		if !isCertificateAboutToExpire(serverCrt, helper.GetTLSRenewBefore(polardbx), time.Now()) &&
+			isCertificateCoveringDNSNames(serverCrt, factory.NewCertificateDNSNames(polardbx)) {
+			return securitySecret.Data, nil
+		}
+		certs, err := factory.NewSelfSignedCertificates(polardbx,
+			securitySecret.Data[convention.SecretKeyRootCrt], securitySecret.Data[convention.SecretKeyRootKey])
+		if err != nil {
+			return nil, err
+		}
+		data := make(map[string][]byte, len(certs))
+		for k, v := range certs {
+			data[k] = []byte(v)
+		}
+		return data, nil
+	}
+
+	if len(tls.SecretName) > 0 {
+		secret, err := rc.GetSecret(tls.SecretName)
+		if err != nil {
+			return nil, err
+		}
+		return secret.Data, nil
+	}
+
+	return reconcileCertificate(rc, polardbx)
+}
+
+// ReconcileTLSCertificates keeps the certificates in the security secret up to date and records the
+// one in use in status. CN and DN are rolled to the new certificate by the following steps.
+var ReconcileTLSCertificates = polardbxv1reconcile.NewStepBinder("ReconcileTLSCertificates",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		polardbx := rc.MustGetPolarDBX()
+		if !helper.IsTLSEnabled(polardbx) {
+			return flow.Pass()
+		}
+
+		securitySecret, err := rc.GetPolarDBXSecret(convention.SecretTypeSecurity)
+		if apierrors.IsNotFound(err) {
+			return flow.RetryAfter(time.Second, "Wait until the security secret is created.")
+		}
+		if err != nil {
+			return flow.Error(err, "Unable to get security secret.")
+		}
+
+		certs, err := resolveCertificates(rc, polardbx, securitySecret)
+		if err != nil {
+			return flow.Error(err, "Unable to resolve certificates.")
+		}
+		if certs == nil {
+			return flow.RetryAfter(10*time.Second, "Wait until the certificate is issued.")
+		}
+
+		cert, err := ssl.ParseCert(certs[convention.SecretKeyServerCrt])
+		if err != nil {
+			return flow.Error(err, "Invalid server certificate.")
+		}
+
+		changed := false
+		for _, k := range append(certificateKeys, convention.SecretKeyRootKey) {
+			v, ok := certs[k]
+			if !ok || bytes.Equal(securitySecret.Data[k], v) {
+				continue
+			}
+			if securitySecret.Data == nil {
+				securitySecret.Data = make(map[string][]byte)
+			}
+			securitySecret.Data[k] = v
+			changed = true
+		}
+		if changed {
+			if err := rc.Client().Update(rc.Context(), securitySecret); err != nil {
+				return flow.Error(err, "Unable to update security secret.")
+			}
+		}
+
+		hash, err := security.Sha1HashBytes(certs[convention.SecretKeyServerCrt])
+		if err != nil {
+			return flow.Error(err, "Unable to hash the certificate.")
+		}
+		status := polardbx.Status.TLS
+		if status != nil && status.CertificateHash == hash {
+			return flow.Pass()
+		}
+
+		notAfter := metav1.NewTime(cert.NotAfter)
+		newStatus := &polardbxv1polardbx.TLSStatus{
+			CertificateHash: hash,
+			NotAfter:        &notAfter,
+		}
+		if status != nil {
+			now := metav1.Now()
+			newStatus.LastRotationTime = &now
+		}
+		polardbx.Status.TLS = newStatus
+
+		return flow.Continue("Certificates updated.", "hash", hash, "not-after", cert.NotAfter)
+	},
+)
+
+// RollCNsToCertificateInUse rolls the pods of CN if they are not using the certificate in use,
+// with the rolling update of deployments, so there's no downtime if there're more than one CN.
+var RollCNsToCertificateInUse = polardbxv1reconcile.NewStepBinder("RollCNsToCertificateInUse",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		polardbx := rc.MustGetPolarDBX()
+		if !helper.IsTLSEnabled(polardbx) || polardbx.Status.TLS == nil {
+			return flow.Pass()
+		}
+		hash := polardbx.Status.TLS.CertificateHash
+
+		deployments, err := rc.GetDeploymentMap(polardbxmeta.RoleCN)
+		if err != nil {
+			return flow.Error(err, "Unable to get deployments of CN.")
+		}
+		for _, deployment := range deployments {
+			if deployment.Spec.Template.Annotations[polardbxmeta.AnnotationTLSCertificateHash] == hash {
+				continue
+			}
+			if deployment.Spec.Template.Annotations == nil {
+				deployment.Spec.Template.Annotations = make(map[string]string)
+			}
+			deployment.Spec.Template.Annotations[polardbxmeta.AnnotationTLSCertificateHash] = hash
+			if err := rc.Client().Update(rc.Context(), deployment); err != nil {
+				return flow.Error(err, "Unable to roll deployment.", "deployment", deployment.Name)
+			}
+			flow.Logger().Info("Rolling deployment to new certificate.", "deployment", deployment.Name)
+		}
+
+		return flow.Pass()
+	},
+)
+
+// SyncStoresTLS enables or disables TLS on the engines of GMS and DNs. The pods of xstores are
+// rebuilt one by one, and the certificates are reloaded online when rotated.
+var SyncStoresTLS = polardbxv1reconcile.NewStepBinder("SyncStoresTLS",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		polardbx := rc.MustGetPolarDBX()
+
+		var tls *polardbxv1xstore.TLS
+		if helper.IsInternalTLSEnabled(polardbx) {
+			tls = &polardbxv1xstore.TLS{
+				SecretName: convention.NewSecretName(polardbx, convention.SecretTypeSecurity),
+			}
+		}
+
+		stores, err := getOwnedStores(rc)
+		if err != nil {
+			return flow.Error(err, "Unable to get xstores.")
+		}
+		for _, xstore := range stores {
+			if equality.Semantic.DeepEqual(xstore.Spec.TLS, tls) {
+				continue
+			}
+			xstore.Spec.TLS = tls
+			if err := rc.Client().Update(rc.Context(), xstore); err != nil {
+				return flow.Error(err, "Unable to update xstore.", "xstore", xstore.Name)
+			}
+			flow.Logger().Info("TLS of xstore updated.", "xstore", xstore.Name, "enabled", tls != nil)
+		}
+
+		return flow.Pass()
+	},
+)
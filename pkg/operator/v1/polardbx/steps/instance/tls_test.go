@@ -0,0 +1,113 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alibaba/polardbx-operator/pkg/util/ssl"
+)
+
+func TestIsCertificateAboutToExpire(t *testing.T) {
+	now := time.Now()
+	notAfter := now.Add(30 * 24 * time.Hour)
+	_, _, der, err := ssl.GenerateCAWithValidity(2048, "polardbx", notAfter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crt, err := ssl.MarshalCert(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := map[string]struct {
+		crt         []byte
+		renewBefore time.Duration
+		expect      bool
+	}{
+		"not-expiring": {
+			crt:         crt,
+			renewBefore: 7 * 24 * time.Hour,
+			expect:      false,
+		},
+		"in-renew-window": {
+			crt:         crt,
+			renewBefore: 60 * 24 * time.Hour,
+			expect:      true,
+		},
+		"invalid": {
+			crt:         []byte("invalid"),
+			renewBefore: time.Hour,
+			expect:      true,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			if got := isCertificateAboutToExpire(tc.crt, tc.renewBefore, now); got != tc.expect {
+				t.Fatalf("expect %v, but got %v", tc.expect, got)
+			}
+		})
+	}
+}
+
+func TestIsCertificateCoveringDNSNames(t *testing.T) {
+	caCert, caPriv, _, err := ssl.GenerateCAWithValidity(2048, "polardbx", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, der, err := ssl.GenerateCertWithValidity(caCert, caPriv, 2048, "",
+		[]string{"pxc", "pxc.default", "pxc-gms", "pxc-gms.default"}, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	crt, err := ssl.MarshalCert(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := map[string]struct {
+		crt      []byte
+		dnsNames []string
+		expect   bool
+	}{
+		"covered": {
+			crt:      crt,
+			dnsNames: []string{"pxc", "pxc-gms.default"},
+			expect:   true,
+		},
+		"new-service": {
+			crt:      crt,
+			dnsNames: []string{"pxc", "pxc-dn-0"},
+			expect:   false,
+		},
+		"invalid": {
+			crt:      []byte("invalid"),
+			dnsNames: []string{"pxc"},
+			expect:   false,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			if got := isCertificateCoveringDNSNames(tc.crt, tc.dnsNames); got != tc.expect {
+				t.Fatalf("expect %v, but got %v", tc.expect, got)
+			}
+		})
+	}
+}
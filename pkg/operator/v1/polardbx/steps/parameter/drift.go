@@ -0,0 +1,230 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parameter
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1polardbx "github.com/alibaba/polardbx-operator/api/v1/polardbx"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+)
+
+const driftCheckInterval = 5 * time.Minute
+
+func IsDriftCheckDue(parameter *polardbxv1.PolarDBXParameter) bool {
+	lastCheck := parameter.Status.LastDriftCheckTime
+	return lastCheck == nil || time.Since(lastCheck.Time) >= driftCheckInterval
+}
+
+func parseBoolValue(s string) (bool, bool) {
+	switch strings.ToUpper(s) {
+	case "ON", "TRUE", "1":
+		return true, true
+	case "OFF", "FALSE", "0":
+		return false, true
+	}
+	return false, false
+}
+
+func parseSizeValue(s string) (float64, bool) {
+	if len(s) == 0 {
+		return 0, false
+	}
+	unit := float64(1)
+	switch strings.ToUpper(s[len(s)-1:]) {
+	case "K":
+		unit = 1 << 10
+	case "M":
+		unit = 1 << 20
+	case "G":
+		unit = 1 << 30
+	}
+	if unit > 1 {
+		s = s[:len(s)-1]
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false
+	}
+	return v * unit, true
+}
+
+// isParamValueEqual compares the value in spec and the one shown by the server, which may be
+// in another form, e.g. "ON" for "1" and "134217728" for "128M".
+func isParamValueEqual(expected, actual string) bool {
+	expected = strings.Trim(strings.TrimSpace(expected), `'"`)
+	actual = strings.TrimSpace(actual)
+	if strings.EqualFold(expected, actual) {
+		return true
+	}
+	if e, ok := parseBoolValue(expected); ok {
+		if a, ok := parseBoolValue(actual); ok {
+			return e == a
+		}
+	}
+	if e, ok := parseSizeValue(expected); ok {
+		if a, ok := parseSizeValue(actual); ok {
+			return e == a
+		}
+	}
+	return false
+}
+
+// findDrifts returns the params whose observed values differ from the expected. Params not observed
+// are drifted with empty actual value. Params calculated by formula are skipped.
+func findDrifts(role, target string, expected map[string]polardbxv1.Params, observed map[string]string) []polardbxv1.ParameterDrift {
+	drifts := make([]polardbxv1.ParameterDrift, 0)
+	for _, p := range expected {
+		if len(p.Value) > 0 && p.Value[0] == '{' {
+			continue
+		}
+		actual := observed[strings.ToLower(p.Name)]
+		if !isParamValueEqual(p.Value, actual) {
+			drifts = append(drifts, polardbxv1.ParameterDrift{
+				Role:     role,
+				Target:   target,
+				Name:     p.Name,
+				Expected: p.Value,
+				Actual:   actual,
+			})
+		}
+	}
+	sort.Slice(drifts, func(i, j int) bool {
+		return drifts[i].Name < drifts[j].Name
+	})
+	return drifts
+}
+
+// dynamicParamsOf returns the read-write params of role which take effect without restart.
+func dynamicParamsOf(rc *polardbxv1reconcile.Context, role string) map[string]polardbxv1.Params {
+	paramsRoleMap := rc.GetPolarDBXParams()
+	templateParams := rc.GetPolarDBXTemplateParams()
+	readWriteKey := polardbxv1.DNReadWrite
+	if role == polardbxmeta.RoleGMS {
+		readWriteKey = polardbxv1.GMSReadWrite
+	}
+	params := make(map[string]polardbxv1.Params)
+	for name, p := range paramsRoleMap[readWriteKey] {
+		if !templateParams[role][name].Restart {
+			params[name] = p
+		}
+	}
+	return params
+}
+
+func detectXStoreDrifts(rc *polardbxv1reconcile.Context, role string, xstore *polardbxv1.XStore, expected map[string]polardbxv1.Params) ([]polardbxv1.ParameterDrift, error) {
+	if len(expected) == 0 {
+		return nil, nil
+	}
+	leader, err := rc.GetLeaderOfDN(xstore)
+	if err != nil {
+		return nil, err
+	}
+	mgr, _, err := rc.GetPolarDBXGroupManagerByXStorePod(*leader)
+	if err != nil || mgr == nil {
+		return nil, err
+	}
+	defer mgr.Close()
+
+	names := make([]string, 0, len(expected))
+	for name := range expected {
+		names = append(names, name)
+	}
+	observed, err := mgr.ShowGlobalVariables(names...)
+	if err != nil {
+		return nil, err
+	}
+	return findDrifts(role, xstore.Name, expected, observed), nil
+}
+
+// DetectParameterDrift compares the running values of dynamic parameters with the spec, records the
+// drifts in status, and goes to apply the parameters again if drift policy is Correct.
+var DetectParameterDrift = polardbxv1reconcile.NewStepBinder("DetectParameterDrift",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		parameter := rc.MustGetPolarDBXParameter()
+		drifts := make([]polardbxv1.ParameterDrift, 0)
+
+		// CN parameters are synced into GMS.
+		if len(parameter.Spec.NodeType.CN.ParamList) > 0 {
+			mgr, err := rc.GetPolarDBXGMSManager()
+			if err != nil {
+				return flow.Error(err, "Unable to get GMS manager.")
+			}
+			observed, err := mgr.ListDynamicParams()
+			if err != nil {
+				return flow.Error(err, "Unable to list current configs.")
+			}
+			lowerObserved := make(map[string]string, len(observed))
+			for k, v := range observed {
+				lowerObserved[strings.ToLower(k)] = v
+			}
+			expected := make(map[string]polardbxv1.Params)
+			for _, p := range parameter.Spec.NodeType.CN.ParamList {
+				expected[p.Name] = p
+			}
+			drifts = append(drifts, findDrifts(polardbxmeta.RoleCN, "", expected, lowerObserved)...)
+		}
+
+		dns, err := rc.GetOrderedDNList()
+		if err != nil {
+			return flow.Error(err, "Unable to get DN list.")
+		}
+		dnParams := dynamicParamsOf(rc, polardbxmeta.RoleDN)
+		for _, dn := range dns {
+			dnDrifts, err := detectXStoreDrifts(rc, polardbxmeta.RoleDN, dn, dnParams)
+			if err != nil {
+				return flow.Error(err, "Unable to detect drifts.", "xstore", dn.Name)
+			}
+			drifts = append(drifts, dnDrifts...)
+		}
+
+		if !rc.MustGetPolarDBX().Spec.ShareGMS {
+			gmsStore, err := rc.GetGMS()
+			if err != nil {
+				return flow.Error(err, "Unable to get GMS.")
+			}
+			gmsDrifts, err := detectXStoreDrifts(rc, polardbxmeta.RoleGMS, gmsStore, dynamicParamsOf(rc, polardbxmeta.RoleGMS))
+			if err != nil {
+				return flow.Error(err, "Unable to detect drifts.", "xstore", gmsStore.Name)
+			}
+			drifts = append(drifts, gmsDrifts...)
+		}
+
+		now := metav1.Now()
+		parameter.Status.LastDriftCheckTime = &now
+		parameter.Status.Drifts = drifts
+
+		if len(drifts) == 0 {
+			return flow.Pass()
+		}
+		if parameter.Spec.DriftPolicy == polardbxv1polardbx.ParameterDriftCorrect {
+			parameter.Status.Phase = polardbxv1polardbx.ParameterStatusModifying
+			return flow.Retry("Parameters drifted, apply them again.", "drifts", len(drifts))
+		}
+		flow.Logger().Info("Parameters drifted.", "drifts", len(drifts))
+		return flow.Pass()
+	},
+)
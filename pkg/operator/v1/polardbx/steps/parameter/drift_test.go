@@ -0,0 +1,127 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parameter
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+)
+
+func TestIsParamValueEqual(t *testing.T) {
+	testCases := []struct {
+		expected, actual string
+		equal            bool
+	}{
+		{"1000", "1000", true},
+		{"ON", "1", true},
+		{"off", "OFF", true},
+		{"'READ-COMMITTED'", "READ-COMMITTED", true},
+		{"128M", "134217728", true},
+		{"1G", "1073741824", true},
+		{"ON", "0", false},
+		{"1000", "2000", false},
+		{"utf8mb4", "utf8", false},
+	}
+	for _, tc := range testCases {
+		if isParamValueEqual(tc.expected, tc.actual) != tc.equal {
+			t.Errorf("isParamValueEqual(%q, %q) should be %v", tc.expected, tc.actual, tc.equal)
+		}
+	}
+}
+
+func TestFindDrifts(t *testing.T) {
+	expected := map[string]polardbxv1.Params{
+		"max_connections":     {Name: "max_connections", Value: "1000"},
+		"innodb_buffer_pool":  {Name: "innodb_buffer_pool", Value: "{DBInstanceClassMemory*3/4}"},
+		"slow_query_log":      {Name: "slow_query_log", Value: "ON"},
+		"sync_binlog":         {Name: "sync_binlog", Value: "1"},
+		"Binlog_Cache_Size":   {Name: "Binlog_Cache_Size", Value: "1M"},
+		"missing_in_observed": {Name: "missing_in_observed", Value: "1"},
+	}
+	observed := map[string]string{
+		"max_connections":   "2000",
+		"slow_query_log":    "ON",
+		"sync_binlog":       "1",
+		"binlog_cache_size": "1048576",
+	}
+
+	drifts := findDrifts(polardbxmeta.RoleDN, "dn-0", expected, observed)
+	if len(drifts) != 2 {
+		t.Fatalf("expect 2 drifts, but got %v", drifts)
+	}
+	if drifts[0].Name != "max_connections" || drifts[0].Actual != "2000" || drifts[0].Target != "dn-0" {
+		t.Errorf("unexpected drift: %v", drifts[0])
+	}
+	if drifts[1].Name != "missing_in_observed" || drifts[1].Actual != "" {
+		t.Errorf("unexpected drift: %v", drifts[1])
+	}
+}
+
+func TestPendingRestart(t *testing.T) {
+	since := metav1.NewTime(time.Now().Add(-time.Hour))
+	static := map[string]polardbxv1.Params{
+		"innodb_log_file_size": {Name: "innodb_log_file_size"},
+		"lower_case":           {Name: "lower_case"},
+	}
+	params := []polardbxv1.Params{
+		{Name: "innodb_log_file_size", Value: "2G"},
+		{Name: "lower_case", Value: "1"},
+		{Name: "max_connections", Value: "1000"},
+	}
+	prev := []polardbxv1.Params{
+		{Name: "innodb_log_file_size", Value: "1G"},
+		{Name: "lower_case", Value: "1"},
+	}
+
+	changed := changedStaticParams(params, prev, true, static)
+	if len(changed) != 1 || changed[0].Name != "innodb_log_file_size" {
+		t.Fatalf("unexpected changed static params: %v", changed)
+	}
+	if changed := changedStaticParams(params, nil, false, static); len(changed) != 2 {
+		t.Fatalf("expect all static params without previous snapshot, but got %v", changed)
+	}
+
+	var pending []polardbxv1.ParameterPendingRestart
+	pending = queuePendingRestart(pending, polardbxmeta.RoleDN, changed[0], since)
+	pending = queuePendingRestart(pending, polardbxmeta.RoleCN, changed[0], since)
+	pending = queuePendingRestart(pending, polardbxmeta.RoleDN, polardbxv1.Params{Name: "innodb_log_file_size", Value: "4G"}, since)
+	if len(pending) != 2 || pending[0].Value != "4G" {
+		t.Fatalf("unexpected pending restart: %v", pending)
+	}
+	if pending = dropPendingRestartOf(pending, polardbxmeta.RoleDN); len(pending) != 1 || pending[0].Role != polardbxmeta.RoleCN {
+		t.Fatalf("unexpected pending restart: %v", pending)
+	}
+
+	pod := func(created time.Time) corev1.Pod {
+		return corev1.Pod{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(created)}}
+	}
+	if isRestartedSince(nil, since.Time) {
+		t.Error("no pods should never be taken as restarted")
+	}
+	if isRestartedSince([]corev1.Pod{pod(time.Now()), pod(since.Add(-time.Minute))}, since.Time) {
+		t.Error("pods created before since are not restarted")
+	}
+	if !isRestartedSince([]corev1.Pod{pod(time.Now()), pod(since.Add(time.Minute))}, since.Time) {
+		t.Error("pods all created after since are restarted")
+	}
+}
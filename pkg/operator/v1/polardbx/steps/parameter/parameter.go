@@ -572,3 +572,33 @@ var SyncModifiedTimestamp = polardbxv1reconcile.NewStepBinder("SyncModifiedTimes
 		return flow.Pass()
 	},
 )
+
+// SaveRollbackSnapshot keeps the snapshot of the applied parameters before they're modified.
+var SaveRollbackSnapshot = polardbxv1reconcile.NewStepBinder("SaveRollbackSnapshot",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		polardbxParameter := rc.MustGetPolarDBXParameter()
+		polardbxParameter.Status.RollbackParameterSpecSnapshot = polardbxParameter.Status.ParameterSpecSnapshot.DeepCopy()
+		return flow.Pass()
+	},
+)
+
+// RollbackParameterIfRequested restores the parameters applied before the last modification into
+// spec, if it's requested by the rollback annotation.
+var RollbackParameterIfRequested = polardbxv1reconcile.NewStepBinder("RollbackParameterIfRequested",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		polardbxParameter := rc.MustGetPolarDBXParameter()
+		if _, ok := polardbxParameter.Annotations[polardbxmeta.AnnotationParameterRollback]; !ok {
+			return flow.Pass()
+		}
+		delete(polardbxParameter.Annotations, polardbxmeta.AnnotationParameterRollback)
+
+		snapshot := polardbxParameter.Status.RollbackParameterSpecSnapshot
+		if snapshot == nil {
+			flow.Logger().Info("No parameters to roll back to, ignore.")
+			return flow.Pass()
+		}
+		polardbxParameter.Spec.TemplateName = snapshot.TemplateName
+		snapshot.NodeType.DeepCopyInto(&polardbxParameter.Spec.NodeType)
+		return flow.Retry("Parameters rolled back.")
+	},
+)
@@ -0,0 +1,227 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parameter
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1polardbx "github.com/alibaba/polardbx-operator/api/v1/polardbx"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
+	"github.com/alibaba/polardbx-operator/pkg/util/gms"
+)
+
+func restartTypeOf(spec *polardbxv1.PolarDBXParameterSpec, role string) string {
+	switch role {
+	case polardbxmeta.RoleCN:
+		return spec.NodeType.CN.RestartType
+	case polardbxmeta.RoleDN:
+		return spec.NodeType.DN.RestartType
+	default:
+		if spec.NodeType.GMS == nil {
+			return spec.NodeType.DN.RestartType
+		}
+		return spec.NodeType.GMS.RestartType
+	}
+}
+
+func paramListOf(spec *polardbxv1.PolarDBXParameterSpec, role string) []polardbxv1.Params {
+	switch role {
+	case polardbxmeta.RoleCN:
+		return spec.NodeType.CN.ParamList
+	case polardbxmeta.RoleDN:
+		return spec.NodeType.DN.ParamList
+	default:
+		return gms.GetGmsParamList(&polardbxv1.PolarDBXParameter{Spec: *spec})
+	}
+}
+
+var restartParamsKeys = map[string]string{
+	polardbxmeta.RoleCN:  polardbxv1.CNRestart,
+	polardbxmeta.RoleDN:  polardbxv1.DNRestart,
+	polardbxmeta.RoleGMS: polardbxv1.GMSRestart,
+}
+
+// changedStaticParams returns the static parameters changed since the previous snapshot, all
+// static parameters if there's no previous snapshot.
+func changedStaticParams(params, prevParams []polardbxv1.Params, hasPrev bool, staticParams map[string]polardbxv1.Params) []polardbxv1.Params {
+	changed := make([]polardbxv1.Params, 0)
+	for _, p := range params {
+		if _, ok := staticParams[p.Name]; !ok {
+			continue
+		}
+		if hasPrev && contains(prevParams, p) {
+			continue
+		}
+		changed = append(changed, p)
+	}
+	return changed
+}
+
+// queuePendingRestart adds the parameter of role into the pending list, replacing the queued one
+// of the same name.
+func queuePendingRestart(pending []polardbxv1.ParameterPendingRestart, role string, param polardbxv1.Params, since metav1.Time) []polardbxv1.ParameterPendingRestart {
+	entry := polardbxv1.ParameterPendingRestart{
+		Role:  role,
+		Name:  param.Name,
+		Value: param.Value,
+		Since: since,
+	}
+	for i := range pending {
+		if pending[i].Role == role && pending[i].Name == param.Name {
+			pending[i] = entry
+			return pending
+		}
+	}
+	return append(pending, entry)
+}
+
+func dropPendingRestartOf(pending []polardbxv1.ParameterPendingRestart, role string) []polardbxv1.ParameterPendingRestart {
+	kept := make([]polardbxv1.ParameterPendingRestart, 0, len(pending))
+	for _, p := range pending {
+		if p.Role != role {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// isRestartedSince returns true if all pods are created after since.
+func isRestartedSince(pods []corev1.Pod, since time.Time) bool {
+	if len(pods) == 0 {
+		return false
+	}
+	for _, pod := range pods {
+		if !pod.CreationTimestamp.Time.After(since) {
+			return false
+		}
+	}
+	return true
+}
+
+// QueueDeferredRestart keeps the roles in "deferredRestart" from being restarted and queues their
+// changed static parameters for the next restart.
+var QueueDeferredRestart = polardbxv1reconcile.NewStepBinder("QueueDeferredRestart",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		parameter := rc.MustGetPolarDBXParameter()
+		paramsRoleMap := rc.GetPolarDBXParams()
+		roleToRestart := rc.GetRoleToRestart()
+		snapshot := parameter.Status.PrevParameterSpecSnapshot
+		now := metav1.Now()
+
+		for _, role := range []string{polardbxmeta.RoleCN, polardbxmeta.RoleDN, polardbxmeta.RoleGMS} {
+			if !roleToRestart[role] {
+				continue
+			}
+			if restartTypeOf(&parameter.Spec, role) != polardbxv1polardbx.DeferredRestart {
+				// Queued ones are applied by this restart.
+				parameter.Status.PendingRestart = dropPendingRestartOf(parameter.Status.PendingRestart, role)
+				continue
+			}
+
+			roleToRestart[role] = false
+			var prevParams []polardbxv1.Params
+			if snapshot != nil {
+				prevParams = paramListOf(snapshot, role)
+			}
+			for _, p := range changedStaticParams(paramListOf(&parameter.Spec, role), prevParams, snapshot != nil,
+				paramsRoleMap[restartParamsKeys[role]]) {
+				parameter.Status.PendingRestart = queuePendingRestart(parameter.Status.PendingRestart, role, p, now)
+			}
+			flow.Logger().Info("Static parameters are queued for the next restart.", "role", role)
+		}
+		rc.SetRoleToRestart(roleToRestart)
+
+		return flow.Pass()
+	},
+)
+
+func listPodsOfXStore(rc *polardbxv1reconcile.Context, xstore *polardbxv1.XStore) ([]corev1.Pod, error) {
+	var podList corev1.PodList
+	err := rc.Client().List(rc.Context(), &podList, client.InNamespace(rc.Namespace()),
+		client.MatchingLabels{xstoremeta.LabelName: xstore.Name})
+	if err != nil {
+		return nil, err
+	}
+	return podList.Items, nil
+}
+
+func listPodsOfRole(rc *polardbxv1reconcile.Context, role string) ([]corev1.Pod, error) {
+	switch role {
+	case polardbxmeta.RoleCN:
+		return rc.GetPods(polardbxmeta.RoleCN)
+	case polardbxmeta.RoleDN:
+		dns, err := rc.GetDNMap()
+		if err != nil {
+			return nil, err
+		}
+		pods := make([]corev1.Pod, 0)
+		for _, dn := range dns {
+			dnPods, err := listPodsOfXStore(rc, dn)
+			if err != nil {
+				return nil, err
+			}
+			pods = append(pods, dnPods...)
+		}
+		return pods, nil
+	default:
+		gmsStore, err := rc.GetGMS()
+		if err != nil {
+			return nil, err
+		}
+		return listPodsOfXStore(rc, gmsStore)
+	}
+}
+
+// ClearAppliedPendingRestart removes the queued static parameters whose pods have all been
+// restarted since they were queued.
+var ClearAppliedPendingRestart = polardbxv1reconcile.NewStepBinder("ClearAppliedPendingRestart",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		parameter := rc.MustGetPolarDBXParameter()
+		if len(parameter.Status.PendingRestart) == 0 {
+			return flow.Pass()
+		}
+
+		podsOfRole := make(map[string][]corev1.Pod)
+		pending := make([]polardbxv1.ParameterPendingRestart, 0, len(parameter.Status.PendingRestart))
+		for _, p := range parameter.Status.PendingRestart {
+			pods, ok := podsOfRole[p.Role]
+			if !ok {
+				var err error
+				pods, err = listPodsOfRole(rc, p.Role)
+				if err != nil {
+					return flow.Error(err, "Unable to list pods.", "role", p.Role)
+				}
+				podsOfRole[p.Role] = pods
+			}
+			if !isRestartedSince(pods, p.Since.Time) {
+				pending = append(pending, p)
+			}
+		}
+		parameter.Status.PendingRestart = pending
+
+		return flow.Pass()
+	},
+)
@@ -0,0 +1,549 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package standby
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/hpfs/remote"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
+	"github.com/alibaba/polardbx-operator/pkg/meta/core/group"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/config"
+	polardbxhelper "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	polardbxv1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/reconcile"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/command"
+	xstoreconvention "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/convention"
+	xstorefactory "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/factory"
+	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
+	dbutil "github.com/alibaba/polardbx-operator/pkg/util/database"
+)
+
+// binlogIndexSuffix is the suffix of the index uploaded next to each shipped binlog.
+const binlogIndexSuffix = ".index"
+
+// binlogIndex is the part of the index of a shipped binlog read by standby.
+type binlogIndex struct {
+	LastEventTimestamp int64 `json:"lastEventTimestamp"`
+}
+
+// sourceXStoreOf returns the xstore of the primary cluster whose binlogs are applied to the xstore,
+// empty if unknown.
+func sourceXStoreOf(standby *polardbxv1.PolarDBXStandby, xstore *polardbxv1.XStore) string {
+	if name, ok := standby.Spec.Source.XStores[xstore.Name]; ok {
+		return name
+	}
+	if xstore.Spec.Restore == nil {
+		return ""
+	}
+	return xstore.Spec.Restore.From.XStoreName
+}
+
+// binlogFileOf returns the file of the applied position, i.e. "file:offset".
+func binlogFileOf(position string) string {
+	if i := strings.LastIndex(position, ":"); i >= 0 {
+		return position[:i]
+	}
+	return position
+}
+
+// binlogSequenceOf returns the sequence number of the binlog, i.e. the suffix of "mysql-bin.000001".
+func binlogSequenceOf(name string) (int64, bool) {
+	i := strings.LastIndex(name, ".")
+	if i < 0 {
+		return 0, false
+	}
+	seq, err := strconv.ParseInt(name[i+1:], 10, 64)
+	return seq, err == nil
+}
+
+// isBinlogBefore tells whether binlog a is before b. Binlogs are ordered by the sequence numbers,
+// whose width grows once it overflows, e.g. "mysql-bin.1000000" is after "mysql-bin.999999".
+func isBinlogBefore(a, b string) bool {
+	seqA, okA := binlogSequenceOf(a)
+	seqB, okB := binlogSequenceOf(b)
+	if okA && okB && seqA != seqB {
+		return seqA < seqB
+	}
+	return a < b
+}
+
+// pendingBinlogs returns the shipped binlogs after the applied position, in order. The indexes are
+// excluded.
+func pendingBinlogs(files []remote.FileStat, lastApplied string) []string {
+	lastFile := binlogFileOf(lastApplied)
+	pending := make([]string, 0)
+	for _, f := range files {
+		name := path.Base(f.Path)
+		if strings.HasSuffix(name, binlogIndexSuffix) || !isBinlogBefore(lastFile, name) {
+			continue
+		}
+		pending = append(pending, name)
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return isBinlogBefore(pending[i], pending[j])
+	})
+	return pending
+}
+
+// latestAppliedEventTimeOf returns the time of the last applied event of the xstore which lags most,
+// nil if any of the xstores has applied no events.
+func latestAppliedEventTimeOf(statuses []polardbxv1.XStoreStandbyStatus) *metav1.Time {
+	var latest *metav1.Time
+	for i := range statuses {
+		last := statuses[i].LastAppliedEventTime
+		if last == nil {
+			return nil
+		}
+		if latest == nil || last.Before(latest) {
+			latest = last
+		}
+	}
+	return latest
+}
+
+func xstoreStatusOf(standby *polardbxv1.PolarDBXStandby, xstoreName string) *polardbxv1.XStoreStandbyStatus {
+	for i := range standby.Status.XStores {
+		if standby.Status.XStores[i].XStore == xstoreName {
+			return &standby.Status.XStores[i]
+		}
+	}
+	standby.Status.XStores = append(standby.Status.XStores, polardbxv1.XStoreStandbyStatus{
+		XStore: xstoreName,
+	})
+	return &standby.Status.XStores[len(standby.Status.XStores)-1]
+}
+
+func applyJobName(targetPod *corev1.Pod) string {
+	jobName := "standby-job-" + targetPod.Name + "-" + rand.String(4)
+	if len(jobName) >= 60 {
+		jobName = strings.TrimRight(jobName[0:59], "-")
+	}
+	return jobName
+}
+
+func replaceSystemEnvs(podSpec *corev1.PodSpec, targetPod *corev1.Pod) {
+	for i := range podSpec.Containers {
+		c := &podSpec.Containers[i]
+		for j := range c.Env {
+			env := &c.Env[j]
+
+			switch env.Name {
+			case "POD_NAME":
+				env.ValueFrom = nil
+				env.Value = targetPod.ObjectMeta.Name
+			case "POD_IP":
+				env.ValueFrom = nil
+				env.Value = targetPod.Status.PodIP
+			case "NODE_IP":
+				env.ValueFrom = nil
+				env.Value = targetPod.Status.HostIP
+			case "NODE_NAME":
+				env.ValueFrom = nil
+				env.Value = targetPod.Spec.NodeName
+			}
+		}
+	}
+}
+
+func newApplyJob(standby *polardbxv1.PolarDBXStandby, xstore *polardbxv1.XStore, binlogDir string, binlogs []string,
+	targetPod *corev1.Pod) *batchv1.Job {
+	jobName := applyJobName(targetPod)
+	podSpec := targetPod.Spec.DeepCopy()
+	podSpec.InitContainers = nil
+	podSpec.RestartPolicy = corev1.RestartPolicyNever
+	podSpec.HostNetwork = false
+
+	podSpec.Containers = []corev1.Container{
+		*k8shelper.GetContainerFromPodSpec(podSpec, "engine"),
+	}
+	podSpec.Containers[0].Name = "standbyjob"
+	podSpec.Containers[0].Command = command.NewCanonicalCommandBuilder().Restore().
+		ApplyShippedBinlogs(binlogDir, string(standby.Spec.Source.StorageProvider.StorageName),
+			standby.Spec.Source.StorageProvider.Sink, binlogs, targetPod.Name, jobName).Build()
+	podSpec.Containers[0].Resources.Limits = nil
+	podSpec.Containers[0].Resources.Requests = nil
+	podSpec.Containers[0].Ports = nil
+	podSpec.Containers[0].StartupProbe = nil
+	podSpec.Containers[0].LivenessProbe = nil
+	podSpec.Containers[0].ReadinessProbe = nil
+
+	replaceSystemEnvs(podSpec, targetPod)
+	podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, xstorefactory.SuperAccountPasswordEnv(xstore))
+
+	labels := map[string]string{
+		polardbxmeta.LabelStandby:         standby.Name,
+		polardbxmeta.LabelBackupXStore:    xstore.Name,
+		xstoremeta.JobLabelTargetPod:      targetPod.Name,
+		xstoremeta.JobLabelTargetNodeName: targetPod.Spec.NodeName,
+	}
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: standby.Namespace,
+			Labels:    labels,
+			Annotations: map[string]string{
+				xstoremeta.AnnotationOperatorVersion:  config.OperatorVersion(),
+				polardbxmeta.AnnotationStandbyBinlogs: strings.Join(binlogs, ","),
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: pointer.Int32(0),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: *podSpec,
+			},
+		},
+	}
+}
+
+func getApplyJob(rc *polardbxv1reconcile.Context, standby *polardbxv1.PolarDBXStandby, xstoreName string) (*batchv1.Job, error) {
+	var jobList batchv1.JobList
+	err := rc.Client().List(rc.Context(), &jobList, client.InNamespace(rc.Namespace()), client.MatchingLabels{
+		polardbxmeta.LabelStandby:      standby.Name,
+		polardbxmeta.LabelBackupXStore: xstoreName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(jobList.Items) == 0 {
+		return nil, nil
+	}
+	return &jobList.Items[0], nil
+}
+
+// readAppliedPosition reads the position applied by the job from its target pod.
+func readAppliedPosition(rc *polardbxv1reconcile.Context, job *batchv1.Job) (string, error) {
+	pod := &corev1.Pod{}
+	err := rc.Client().Get(rc.Context(), types.NamespacedName{
+		Namespace: rc.Namespace(),
+		Name:      job.Labels[xstoremeta.JobLabelTargetPod],
+	}, pod)
+	if err != nil {
+		return "", err
+	}
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	cmd := []string{"cat", "/data/mysql/tmp/" + job.Name + ".pos"}
+	if err := rc.ExecuteCommandOn(pod, "engine", cmd, control.ExecOptions{
+		Stdout: stdout,
+		Stderr: stderr,
+	}); err != nil {
+		return "", fmt.Errorf("failed to read applied position: %w, stderr: %s", err, stderr.String())
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// readLastEventTime reads the time of the last event of the shipped binlog from its index, nil if
+// it has no events.
+func readLastEventTime(rc *polardbxv1reconcile.Context, standby *polardbxv1.PolarDBXStandby, binlogPath string) (*metav1.Time, error) {
+	data, err := polardbxhelper.DownloadBackupFile(rc.Context(), rc.Client(), rc.Namespace(),
+		standby.Spec.Source.StorageProvider, binlogPath+binlogIndexSuffix)
+	if err != nil {
+		return nil, err
+	}
+	var index binlogIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, err
+	}
+	if index.LastEventTimestamp <= 0 {
+		return nil, nil
+	}
+	t := metav1.NewTime(time.Unix(index.LastEventTimestamp, 0).UTC())
+	return &t, nil
+}
+
+// listXStores returns the GMS and DNs of the cluster.
+func listXStores(rc *polardbxv1reconcile.Context) ([]*polardbxv1.XStore, error) {
+	polardbx, err := rc.GetPolarDBX()
+	if err != nil {
+		return nil, err
+	}
+	xstores, err := rc.GetOrderedDNList()
+	if err != nil {
+		return nil, err
+	}
+	if polardbx.Spec.ShareGMS {
+		return xstores, nil
+	}
+	gms, err := rc.GetGMS()
+	if err != nil {
+		return nil, err
+	}
+	return append([]*polardbxv1.XStore{gms}, xstores...), nil
+}
+
+// listXStoresOfPrimary returns the xstores of the primary cluster referenced by source, none if not.
+func listXStoresOfPrimary(rc *polardbxv1reconcile.Context, standby *polardbxv1.PolarDBXStandby) ([]polardbxv1.XStore, error) {
+	if standby.Spec.Source.Cluster == nil || len(standby.Spec.Source.Cluster.Name) == 0 {
+		return nil, nil
+	}
+	var xstoreList polardbxv1.XStoreList
+	if err := rc.Client().List(rc.Context(), &xstoreList, client.InNamespace(rc.Namespace()),
+		client.MatchingLabels{polardbxmeta.LabelName: standby.Spec.Source.Cluster.Name}); err != nil {
+		return nil, err
+	}
+	return xstoreList.Items, nil
+}
+
+// setXStoreReadOnly sets super_read_only on the leader of the xstore, or lifts read_only along with
+// super_read_only, through its read-write service with the super account.
+func setXStoreReadOnly(rc *polardbxv1reconcile.Context, xstore *polardbxv1.XStore, readOnly bool) error {
+	service, err := rc.GetService(xstoreconvention.NewServiceName(xstore, xstoreconvention.ServiceTypeReadWrite))
+	if err != nil {
+		return err
+	}
+	secret, err := rc.GetSecret(xstoreconvention.NewSecretName(xstore))
+	if err != nil {
+		return err
+	}
+	mgr := group.NewGroupManager(rc.Context(), dbutil.MySQLDataSource{
+		Host:     k8shelper.GetServiceDNSRecordWithSvc(service, true),
+		Port:     int(k8shelper.MustGetPortFromService(service, xstoreconvention.PortAccess).Port),
+		Username: xstoreconvention.SuperAccount,
+		Password: string(secret.Data[xstoreconvention.SuperAccount]),
+	}, true)
+	defer mgr.Close()
+
+	// Setting super_read_only implies read_only, and lifting read_only lifts super_read_only.
+	variables := map[string]string{"read_only": "OFF"}
+	if readOnly {
+		variables = map[string]string{"super_read_only": "ON"}
+	}
+	return mgr.SetGlobalVariables(variables)
+}
+
+var PersistentStatusChanges = polardbxv1reconcile.NewStepBinder("PersistentStatusChanges",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		if rc.IsPolarDBXStandbyStatusChanged() {
+			if err := rc.UpdatePolarDBXStandbyStatus(); err != nil {
+				return flow.Error(err, "Unable to update status for standby.")
+			}
+			return flow.Continue("Standby status updated!")
+		}
+		return flow.Continue("Standby status not changed!")
+	})
+
+// UpdateStandbyPhase starts the standby, and starts promoting it once the spec says.
+var UpdateStandbyPhase = polardbxv1reconcile.NewStepBinder("UpdateStandbyPhase",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		standby := rc.MustGetPolarDBXStandby()
+		switch standby.Status.Phase {
+		case polardbxv1.StandbyNew:
+			now := metav1.Now()
+			standby.Status.StartTime = &now
+			standby.Status.Phase = polardbxv1.StandbyReplicating
+			return flow.Continue("Standby started.")
+		case polardbxv1.StandbyReplicating:
+			if standby.Spec.Promote {
+				standby.Status.Phase = polardbxv1.StandbyPromoting
+				return flow.Continue("Standby promoting.")
+			}
+		}
+		return flow.Pass()
+	})
+
+// FencePrimary sets super_read_only on the GMS and DNs of the primary cluster when promoting, if it's
+// referenced by source, so that no more binlogs are produced by the primary once the standby takes over.
+var FencePrimary = polardbxv1reconcile.NewStepBinder("FencePrimary",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		standby := rc.MustGetPolarDBXStandby()
+		if standby.Status.Phase != polardbxv1.StandbyPromoting || standby.Status.PrimaryFenceTime != nil {
+			return flow.Pass()
+		}
+		xstores, err := listXStoresOfPrimary(rc, standby)
+		if err != nil {
+			return flow.Error(err, "Unable to list xstores of primary cluster.")
+		}
+		if len(xstores) == 0 {
+			return flow.Pass()
+		}
+		for i := range xstores {
+			if err := setXStoreReadOnly(rc, &xstores[i], true); err != nil {
+				return flow.Error(err, "Unable to fence xstore of primary cluster.", "xstore", xstores[i].Name)
+			}
+		}
+		now := metav1.Now()
+		standby.Status.PrimaryFenceTime = &now
+		return flow.Continue("Primary cluster fenced.", "cluster", standby.Spec.Source.Cluster.Name)
+	})
+
+// ApplyShippedBinlogs runs the rounds of applying of each xstore, i.e. a job on the leader applying
+// the binlogs shipped since the last round, one round at a time. The applied position is collected
+// once the job finishes, and the job is removed then. A failed round is retried in the next interval,
+// from the last applied position. The xstores are kept super_read_only between the rounds, which is
+// lifted by the job during the round. When promoting, the rounds run without interval, and the standby
+// is promoted and made writable once no binlogs are pending for any xstore, and the binlogs of the
+// fenced primary are shipped, i.e. an interval after the fence.
+var ApplyShippedBinlogs = polardbxv1reconcile.NewStepBinder("ApplyShippedBinlogs",
+	func(rc *polardbxv1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		standby := rc.MustGetPolarDBXStandby()
+		if standby.Status.Phase == polardbxv1.StandbyPromoted {
+			return flow.Pass()
+		}
+		promoting := standby.Status.Phase == polardbxv1.StandbyPromoting
+		xstores, err := listXStores(rc)
+		if err != nil {
+			return flow.Error(err, "Unable to list xstores of cluster.", "cluster", standby.Spec.Cluster.Name)
+		}
+
+		interval := standby.Spec.Interval.Duration
+		if interval <= 0 {
+			interval = 30 * time.Second
+		}
+		maxFiles := int(standby.Spec.MaxFilesPerRound)
+		if maxFiles <= 0 {
+			maxFiles = 16
+		}
+		now := time.Now()
+		nextRound := interval
+		caughtUp := true
+		for _, xstore := range xstores {
+			status := xstoreStatusOf(standby, xstore.Name)
+			status.SourceXStore = sourceXStoreOf(standby, xstore)
+			if len(status.SourceXStore) == 0 {
+				status.Message = "Source xstore unknown, specify it in spec.source.xstores"
+				caughtUp = false
+				continue
+			}
+			binlogDir := standby.Spec.Source.BinlogRootPath + "/" + status.SourceXStore
+			job, err := getApplyJob(rc, standby, xstore.Name)
+			if err != nil {
+				return flow.Error(err, "Unable to get apply job.", "xstore", xstore.Name)
+			}
+
+			if job != nil {
+				caughtUp = false
+				failed := k8shelper.IsJobFailed(job)
+				if !failed && !k8shelper.IsJobCompleted(job) {
+					continue
+				}
+				status.Pod = job.Labels[xstoremeta.JobLabelTargetPod]
+				status.LastApplyTime = &metav1.Time{Time: now}
+				status.Message = ""
+				if failed {
+					status.Message = "Apply job " + job.Name + " failed, retry in the next round"
+				} else {
+					position, err := readAppliedPosition(rc, job)
+					if err != nil {
+						return flow.Error(err, "Unable to read applied position.", "job", job.Name)
+					}
+					binlogs := strings.Split(job.Annotations[polardbxmeta.AnnotationStandbyBinlogs], ",")
+					status.LastAppliedBinlog = position
+					status.AppliedFiles += int64(len(binlogs))
+					lastEventTime, err := readLastEventTime(rc, standby, binlogDir+"/"+binlogFileOf(position))
+					if err != nil {
+						status.Message = "Unable to read index of " + binlogFileOf(position) + ": " + err.Error()
+					} else if lastEventTime != nil {
+						status.LastAppliedEventTime = lastEventTime
+					}
+				}
+				err = rc.Client().Delete(rc.Context(), job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+				if client.IgnoreNotFound(err) != nil {
+					return flow.Error(err, "Unable to remove apply job.", "job", job.Name)
+				}
+				continue
+			}
+
+			if err := setXStoreReadOnly(rc, xstore, true); err != nil {
+				return flow.Error(err, "Unable to set super_read_only.", "xstore", xstore.Name)
+			}
+			if status.LastApplyTime != nil && !promoting {
+				if wait := status.LastApplyTime.Add(interval).Sub(now); wait > 0 {
+					if wait < nextRound {
+						nextRound = wait
+					}
+					continue
+				}
+			}
+			files, err := polardbxhelper.StatShippedBinlogFiles(rc.Context(), rc.Client(), rc.Namespace(),
+				standby.Spec.Source.StorageProvider, binlogDir+"/")
+			if err != nil {
+				status.Message = "Unable to list shipped binlogs: " + err.Error()
+				status.LastApplyTime = &metav1.Time{Time: now}
+				caughtUp = false
+				continue
+			}
+			pending := pendingBinlogs(files, status.LastAppliedBinlog)
+			status.PendingFiles = int32(len(pending))
+			if len(pending) == 0 {
+				status.LastApplyTime = &metav1.Time{Time: now}
+				status.Message = ""
+				continue
+			}
+			caughtUp = false
+			if len(pending) > maxFiles {
+				pending = pending[:maxFiles]
+			}
+
+			if len(xstore.Status.LeaderPod) == 0 {
+				status.Message = "Leader pod not found"
+				continue
+			}
+			leaderPod, err := rc.GetLeaderOfDN(xstore)
+			if err != nil {
+				return flow.Error(err, "Unable to get leader pod.", "xstore", xstore.Name)
+			}
+			job = newApplyJob(standby, xstore, binlogDir, pending, leaderPod)
+			if err := rc.SetControllerRefAndCreateToStandby(job); err != nil {
+				return flow.Error(err, "Unable to create apply job.", "xstore", xstore.Name, "pod", leaderPod.Name)
+			}
+			flow.Logger().Info("Apply job created.", "xstore", xstore.Name, "job", job.Name, "binlogs", pending)
+		}
+		standby.Status.LatestAppliedEventTime = latestAppliedEventTimeOf(standby.Status.XStores)
+
+		if promoting {
+			if !caughtUp {
+				return flow.RetryAfter(5*time.Second, "Wait until all shipped binlogs applied before promoting.")
+			}
+			if fenced := standby.Status.PrimaryFenceTime; fenced != nil {
+				if wait := fenced.Add(interval).Sub(now); wait > 0 {
+					return flow.RetryAfter(wait, "Wait for the last binlogs of primary shipped before promoting.")
+				}
+			}
+			for _, xstore := range xstores {
+				if err := setXStoreReadOnly(rc, xstore, false); err != nil {
+					return flow.Error(err, "Unable to lift read_only.", "xstore", xstore.Name)
+				}
+			}
+			now := metav1.Now()
+			standby.Status.PromoteTime = &now
+			standby.Status.Phase = polardbxv1.StandbyPromoted
+			return flow.Continue("Standby promoted.", "latest-applied", standby.Status.LatestAppliedEventTime)
+		}
+		return flow.RetryAfter(nextRound, "Wait for the next round of applying.")
+	})
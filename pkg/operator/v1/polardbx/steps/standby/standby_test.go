@@ -0,0 +1,112 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package standby
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/hpfs/remote"
+)
+
+func TestPendingBinlogs(t *testing.T) {
+	dir := "polardbx-binlogbackup/pxc/ship-20230101/pxc-dn-0/"
+	files := []remote.FileStat{
+		{Path: dir + "mysql_bin.000003"},
+		{Path: dir + "mysql_bin.000003.index"},
+		{Path: dir + "mysql_bin.000001"},
+		{Path: dir + "mysql_bin.000001.index"},
+		{Path: dir + "mysql_bin.000002"},
+		{Path: dir + "mysql_bin.000002.index"},
+	}
+	if pending := pendingBinlogs(files, ""); !reflect.DeepEqual(pending, []string{
+		"mysql_bin.000001", "mysql_bin.000002", "mysql_bin.000003",
+	}) {
+		t.Fatalf("unexpected pending binlogs: %v", pending)
+	}
+	if pending := pendingBinlogs(files, "mysql_bin.000002:4096"); !reflect.DeepEqual(pending, []string{"mysql_bin.000003"}) {
+		t.Fatalf("unexpected pending binlogs: %v", pending)
+	}
+	if pending := pendingBinlogs(files, "mysql_bin.000003:120"); len(pending) != 0 {
+		t.Fatalf("expect nothing pending, got %v", pending)
+	}
+}
+
+func TestPendingBinlogsAfterSequenceWidens(t *testing.T) {
+	dir := "polardbx-binlogbackup/pxc/ship-20230101/pxc-dn-0/"
+	files := []remote.FileStat{
+		{Path: dir + "mysql_bin.1000001"},
+		{Path: dir + "mysql_bin.999999"},
+		{Path: dir + "mysql_bin.1000000"},
+	}
+	if pending := pendingBinlogs(files, "mysql_bin.999998:120"); !reflect.DeepEqual(pending, []string{
+		"mysql_bin.999999", "mysql_bin.1000000", "mysql_bin.1000001",
+	}) {
+		t.Fatalf("unexpected pending binlogs: %v", pending)
+	}
+	if pending := pendingBinlogs(files, "mysql_bin.999999:4096"); !reflect.DeepEqual(pending, []string{
+		"mysql_bin.1000000", "mysql_bin.1000001",
+	}) {
+		t.Fatalf("unexpected pending binlogs: %v", pending)
+	}
+}
+
+func TestSourceXStoreOf(t *testing.T) {
+	standby := &polardbxv1.PolarDBXStandby{}
+	standby.Spec.Source.XStores = map[string]string{"standby-dn-1": "pxc-dn-1"}
+
+	xstore := &polardbxv1.XStore{}
+	xstore.Name = "standby-dn-0"
+	if source := sourceXStoreOf(standby, xstore); source != "" {
+		t.Fatalf("expect unknown source, got %s", source)
+	}
+
+	xstore.Spec.Restore = &polardbxv1.XStoreRestoreSpec{}
+	xstore.Spec.Restore.From.XStoreName = "pxc-dn-0"
+	if source := sourceXStoreOf(standby, xstore); source != "pxc-dn-0" {
+		t.Fatalf("expect source of restore, got %s", source)
+	}
+
+	xstore.Name = "standby-dn-1"
+	if source := sourceXStoreOf(standby, xstore); source != "pxc-dn-1" {
+		t.Fatalf("expect source of spec, got %s", source)
+	}
+}
+
+func TestLatestAppliedEventTimeOf(t *testing.T) {
+	t0 := metav1.NewTime(time.Unix(1700000000, 0))
+	t1 := metav1.NewTime(time.Unix(1700000060, 0))
+
+	if latest := latestAppliedEventTimeOf(nil); latest != nil {
+		t.Fatalf("expect nil, got %v", latest)
+	}
+	statuses := []polardbxv1.XStoreStandbyStatus{
+		{XStore: "pxc-dn-0", LastAppliedEventTime: &t1},
+		{XStore: "pxc-dn-1", LastAppliedEventTime: &t0},
+	}
+	if latest := latestAppliedEventTimeOf(statuses); latest == nil || !latest.Equal(&t0) {
+		t.Fatalf("expect the time of most lagging xstore, got %v", latest)
+	}
+	statuses = append(statuses, polardbxv1.XStoreStandbyStatus{XStore: "pxc-dn-2"})
+	if latest := latestAppliedEventTimeOf(statuses); latest != nil {
+		t.Fatalf("expect nil if any applied nothing, got %v", latest)
+	}
+}
@@ -111,6 +111,9 @@ func (p *Planner) createNodeRebuildConfig(podName string) map[string]interface{}
 		LogSeparation: strconv.FormatBool(p.xstore.Spec.Config.Dynamic.LogDataSeparation),
 		NodeName:      p.xstore.Status.BoundVolumes[podName].Host,
 	}
+	if p.xstore.Spec.TLS != nil {
+		config.TLSSecretName = p.xstore.Spec.TLS.SecretName
+	}
 	rebuildConfig[xstoremeta.LabelConfigHash] = config.ComputeHash()
 	return rebuildConfig
 }
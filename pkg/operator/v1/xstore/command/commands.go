@@ -19,6 +19,7 @@ package command
 import (
 	"path"
 	"strconv"
+	"strings"
 )
 
 var DefaultXStoreToolsPath = "/tools/xstore/current"
@@ -304,6 +305,26 @@ func (b *commandEngineBuilder) Version() *CommandBuilder {
 	return b.end()
 }
 
+func (b *commandEngineBuilder) Compatibility() *CommandBuilder {
+	b.args = append(b.args, "compatibility")
+	return b.end()
+}
+
+func (b *commandEngineBuilder) CheckTables() *CommandBuilder {
+	b.args = append(b.args, "check_tables")
+	return b.end()
+}
+
+func (b *commandEngineBuilder) ReloadTLS(certHash string) *CommandBuilder {
+	b.args = append(b.args, "reload_tls", "--cert-hash", certHash)
+	return b.end()
+}
+
+func (b *commandEngineBuilder) RotateMasterKey() *CommandBuilder {
+	b.args = append(b.args, "rotate_master_key")
+	return b.end()
+}
+
 type commandProcessBuilder struct {
 	*commandBuilder
 }
@@ -355,6 +376,22 @@ func (b *commandRestoreBuilder) StartRestore(restoreContext string) *CommandBuil
 	return b.end()
 }
 
+// ApplyBinlog applies the binlogs in the restore context to the target pod, with the password of super account
+// read from env MYSQL_PWD.
+func (b *commandRestoreBuilder) ApplyBinlog(restoreContext, targetPod, jobName string) *CommandBuilder {
+	b.args = append(b.args, "apply_binlog", "--restore_context", restoreContext, "-tp", targetPod, "-j", jobName)
+	return b.end()
+}
+
+// ApplyShippedBinlogs applies the binlogs to the target pod, with the password of super account read from
+// env MYSQL_PWD.
+func (b *commandRestoreBuilder) ApplyShippedBinlogs(binlogDir, storageName, sink string, binlogs []string,
+	targetPod, jobName string) *CommandBuilder {
+	b.args = append(b.args, "apply_shipped_binlog", "--binlog_dir", binlogDir, "--storage_name", storageName,
+		"--sink", sink, "--binlogs", strings.Join(binlogs, ","), "-tp", targetPod, "-j", jobName)
+	return b.end()
+}
+
 type commandRecoverBuilder struct {
 	*commandBuilder
 }
@@ -389,6 +426,15 @@ func (b *commandBinlogBackupBuilder) StartBinlogBackup(backupContext, commitInde
 	return b.end()
 }
 
+func (b *commandBinlogBackupBuilder) ShipBinlogs(binlogDir, storageName, sink, lastBinlog string, maxFiles int32, jobName string) *CommandBuilder {
+	b.args = append(b.args, "ship", "--binlog_dir", binlogDir, "--storage_name", storageName, "--sink", sink,
+		"--max_files", strconv.Itoa(int(maxFiles)), "-j", jobName)
+	if len(lastBinlog) > 0 {
+		b.args = append(b.args, "--last_binlog", lastBinlog)
+	}
+	return b.end()
+}
+
 type commandCollectBuilder struct {
 	*commandBuilder
 }
@@ -401,8 +447,8 @@ func (b *nullCommandBuilder) Collect() *commandCollectBuilder {
 	}
 }
 
-func (b *commandCollectBuilder) StartCollect(backupContext, heartBeatName string) *CommandBuilder {
-	b.args = append(b.args, "start", "--backup_context", backupContext, "-hb", heartBeatName)
+func (b *commandCollectBuilder) StartCollect(backupContext, heartBeatName, jobName string) *CommandBuilder {
+	b.args = append(b.args, "start", "--backup_context", backupContext, "-hb", heartBeatName, "-j", jobName)
 	return b.end()
 }
 
@@ -411,6 +457,29 @@ func (b *commandCollectBuilder) UploadOffset(offset, path, storageName, sink str
 	return b.end()
 }
 
+func (b *commandCollectBuilder) SignUrl(path, expiry, storageName, sink string) *CommandBuilder {
+	b.args = append(b.args, "sign_url", "-p", path, "-e", expiry, "--storage_name", storageName, "--sink", sink)
+	return b.end()
+}
+
+// CopyFiles copies the remote files under source to dest, the number of files copied is printed and written
+// to the output file.
+func (b *commandCollectBuilder) CopyFiles(source, dest, retention, storageName, sink, output string) *CommandBuilder {
+	b.args = append(b.args, "copy_files", "-s", source, "-d", dest, "-r", retention, "--storage_name", storageName,
+		"--sink", sink, "-o", output)
+	return b.end()
+}
+
+func (b *commandCollectBuilder) ThawFiles(path, storageName, sink string) *CommandBuilder {
+	b.args = append(b.args, "thaw_files", "-p", path, "--storage_name", storageName, "--sink", sink)
+	return b.end()
+}
+
+func (b *commandCollectBuilder) Preview(previewContext, output string) *CommandBuilder {
+	b.args = append(b.args, "preview", "--preview_context", previewContext, "-o", output)
+	return b.end()
+}
+
 type commandSeekCpBuilder struct {
 	*commandBuilder
 }
@@ -22,8 +22,10 @@ import (
 	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
 	"github.com/alibaba/polardbx-operator/pkg/operator/hint"
 	"github.com/alibaba/polardbx-operator/pkg/operator/v1/config"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
 	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/plugin"
 	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+	backupsteps "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/steps/backup"
 	"github.com/go-logr/logr"
 	"golang.org/x/time/rate"
 	batchv1 "k8s.io/api/batch/v1"
@@ -61,6 +63,7 @@ func (r *XStoreBackupReconciler) Reconcile(ctx context.Context, request reconcil
 	if err != nil {
 		if apierrors.IsNotFound(err) {
 			log.Info("Object for XStoreBackup isn't found, might be deleted!")
+			backupsteps.ForgetPollingBackoff(request.NamespacedName)
 			return reconcile.Result{}, nil
 		} else {
 			log.Error(err, "Unable to get object for XStoreBackup")
@@ -68,6 +71,12 @@ func (r *XStoreBackupReconciler) Reconcile(ctx context.Context, request reconcil
 		}
 	}
 
+	// Imported backups share the files of the source, which is managed in its own place.
+	if _, ok := xstoreBackup.Annotations[polardbxmeta.AnnotationBackupImportedFrom]; ok {
+		log.Info("The xstore backup is imported, skip.")
+		return reconcile.Result{}, nil
+	}
+
 	// Record the context of the corresponding xstore
 	xstore, err := rc.GetXStore()
 	if err != nil {
@@ -103,11 +103,18 @@ func (r *XStoreFollowerReconciler) newReconcileTask(rc *xstorev1reconcile.Follow
 		control.When(!followersteps.IsNotLogger(xstoreFollower), followersteps.UpdatePhaseTemplate(xstore.FollowerPhaseBeforeRestore))(task)
 		followersteps.TryChooseFromPod(task)
 		followersteps.TryLoadFromPod(task)
+		followersteps.ChooseRecoverBackup(task)
 		followersteps.UpdatePhaseTemplate(xstore.FollowerPhaseBackupPrepare)(task)
 	case xstore.FollowerPhaseBackupPrepare:
 		followersteps.DisableFromPodPurgeLog(task)
 		followersteps.CleanBackupJob(task)
 		followersteps.UpdatePhaseTemplate(xstore.FollowerPhaseBackupStart)(task)
+	case xstore.FollowerPhaseBackupDownload:
+		followersteps.DisableFromPodPurgeLog(task)
+		followersteps.CleanBackupJob(task)
+		followersteps.StartDownloadBackupJob(task)
+		followersteps.MonitorCurrentJob(task)
+		followersteps.UpdatePhaseTemplate(xstore.FollowerPhaseBeforeRestore)(task)
 	case xstore.FollowerPhaseBackupStart:
 		followersteps.StartBackupJob(task)
 		followersteps.UpdatePhaseTemplate(xstore.FollowerPhaseMonitorBackup)(task)
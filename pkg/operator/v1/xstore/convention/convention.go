@@ -80,10 +80,29 @@ const (
 
 const SuperAccount = "admin"
 
+// Keys of the certificates in the TLS secret.
+const (
+	SecretKeyTLSRootCrt   = "root.crt"
+	SecretKeyTLSServerCrt = "server.crt"
+	SecretKeyTLSServerKey = "server.key"
+)
+
 func NewSecretName(xstore *polardbxv1.XStore) string {
 	return xstore.Name
 }
 
+func NewIsolationNetworkPolicyName(xstore *polardbxv1.XStore) string {
+	return xstore.Name + "-isolation"
+}
+
+func NewPodDisruptionBudgetName(xstore *polardbxv1.XStore) string {
+	return xstore.Name
+}
+
+func NewPersistentVolumeClaimName(podName string) string {
+	return podName + "-data"
+}
+
 // Convention for labels.
 
 func ConstLabels(xstore *polardbxv1.XStore) map[string]string {
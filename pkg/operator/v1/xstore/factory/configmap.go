@@ -226,9 +226,56 @@ func newConfigDataMap(rc *reconcile.Context, xstore *polardbxv1.XStore) (map[str
 			return nil, err
 		}
 	}
+
+	// The slow log is shipped by the log collector.
+	if xstore.Spec.LogCollector != nil {
+		data[convention.ConfigMyCnfOverride], err = patchSlowQueryLog(data[convention.ConfigMyCnfOverride])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The config overlay applied during restore is kept over the override.
+	if len(xstore.Status.RestoreConfigOverlay) > 0 {
+		data[convention.ConfigMyCnfOverride], err = patchRestoreConfigOverlay(data[convention.ConfigMyCnfOverride],
+			xstore.Status.RestoreConfigOverlay)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return data, nil
 }
 
+// patchSlowQueryLog turns on the slow query log unless it's configured explicitly.
+func patchSlowQueryLog(overrideVal string) (string, error) {
+	override, err := iniutil.ParseMyCnfOverlayFile(strings.NewReader(overrideVal))
+	if err != nil {
+		return "", err
+	}
+	section := override.Section("mysqld")
+	if section.HasKey("slow_query_log") {
+		return overrideVal, nil
+	}
+	section.Key("slow_query_log").SetValue("ON")
+	return iniutil.ToString(override), nil
+}
+
+func patchRestoreConfigOverlay(overrideVal, overlay string) (string, error) {
+	override, err := iniutil.ParseMyCnfOverlayFile(strings.NewReader(overrideVal))
+	if err != nil {
+		return "", err
+	}
+	patch, err := iniutil.ParseMyCnfOverlayFile(strings.NewReader(overlay))
+	if err != nil {
+		return "", err
+	}
+	override, err = iniutil.Patch(override, patch)
+	if err != nil {
+		return "", err
+	}
+	return iniutil.ToString(override), nil
+}
+
 func NewConfigConfigMap(rc *reconcile.Context, xstore *polardbxv1.XStore) (*corev1.ConfigMap, error) {
 	data, err := newConfigDataMap(rc, xstore)
 	if err != nil {
@@ -16,7 +16,13 @@ limitations under the License.
 
 package factory
 
-import corev1 "k8s.io/api/core/v1"
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/convention"
+)
 
 func SystemEnvs() []corev1.EnvVar {
 	return []corev1.EnvVar{
@@ -54,3 +60,34 @@ func SystemEnvs() []corev1.EnvVar {
 		},
 	}
 }
+
+// TDEEnvs returns the envs of the external KMS if TDE with KMS is enabled.
+func TDEEnvs(xstore *polardbxv1.XStore) []corev1.EnvVar {
+	if xstore.Spec.TDE == nil || xstore.Spec.TDE.KMS == nil {
+		return nil
+	}
+	kms := xstore.Spec.TDE.KMS
+	provider := kms.Provider
+	if len(provider) == 0 {
+		provider = polardbxv1xstore.TDEKMSProviderAWS
+	}
+	return []corev1.EnvVar{
+		{Name: "TDE_KMS_PROVIDER", Value: string(provider)},
+		{Name: "TDE_KMS_KEY_ID", Value: kms.KeyID},
+		{Name: "TDE_KMS_REGION", Value: kms.Region},
+	}
+}
+
+// SuperAccountPasswordEnv returns the env of the password of super account from the secret of the
+// xstore, which is read by the mysql client so that it never shows up in the command line.
+func SuperAccountPasswordEnv(xstore *polardbxv1.XStore) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: "MYSQL_PWD",
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: convention.NewSecretName(xstore)},
+				Key:                  convention.SuperAccount,
+			},
+		},
+	}
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package factory
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/meta/core/gms/security"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/logcollector"
+	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
+)
+
+// SlowLogFile is the slow log of the engine, which is kept in the log directory of the data volume.
+const SlowLogFile = "/data/mysql/log/slow.log"
+
+// NewLogCollectorHash returns the hash of the log collector config, or empty if it's disabled.
+func NewLogCollectorHash(xstore *polardbxv1.XStore) string {
+	if xstore.Spec.LogCollector == nil {
+		return ""
+	}
+	hash, err := security.HashObj(xstore.Spec.LogCollector)
+	if err != nil {
+		panic(err)
+	}
+	return hash
+}
+
+// LogCollectorAnnotations returns the annotation of log collector hash if it's enabled.
+func LogCollectorAnnotations(xstore *polardbxv1.XStore) map[string]string {
+	hash := NewLogCollectorHash(xstore)
+	if len(hash) == 0 {
+		return nil
+	}
+	return map[string]string{
+		xstoremeta.AnnotationLogCollectorHash: hash,
+	}
+}
+
+// LogCollectorContainers returns the container which ships the slow log if the log collector is
+// enabled. The volume mounts of engine are shared to locate the slow log.
+func LogCollectorContainers(xstore *polardbxv1.XStore, engineVolumeMounts []corev1.VolumeMount) ([]corev1.Container, error) {
+	if xstore.Spec.LogCollector == nil {
+		return nil, nil
+	}
+	container, err := logcollector.NewContainer(xstore.Spec.LogCollector,
+		[]logcollector.Input{
+			{Tag: "dn.slow", Path: SlowLogFile},
+		},
+		map[string]string{
+			"xstore": xstore.Name,
+			"log":    "slow",
+		},
+		append(SystemVolumeMounts(), engineVolumeMounts...),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return []corev1.Container{*container}, nil
+}
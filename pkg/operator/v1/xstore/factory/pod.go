@@ -184,6 +184,11 @@ func NewPod(rc *reconcile.Context, xstore *polardbxv1.XStore, nodeSet *polardbxv
 	}
 	factoryCtx.envs = envs
 
+	engineImage := defaults.NonEmptyStrOrDefault(
+		template.Spec.Image,
+		rc.Config().Images().DefaultImageForStore(engine, convention.ContainerEngine, ""),
+	)
+
 	// Construct the pod.
 	pod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
@@ -202,13 +207,18 @@ func NewPod(rc *reconcile.Context, xstore *polardbxv1.XStore, nodeSet *polardbxv
 			Annotations: k8shelper.PatchAnnotations(
 				template.ObjectMeta.Annotations,
 				opts.ExtraAnnotations(factoryCtx),
+				TDEAnnotations(xstore),
+				LogCollectorAnnotations(xstore),
 			),
 		},
 		Spec: corev1.PodSpec{
 			Volumes: k8shelper.PatchVolumes(
 				SystemVolumes(),
 				ConfigMapVolumes(xstore),
+				TLSVolumes(xstore),
+				TDEVolumes(xstore),
 				volumes,
+				VolumeMigrationVolumes(hostPathVolume),
 			),
 			EnableServiceLinks:            pointer.BoolPtr(false),
 			ImagePullSecrets:              template.Spec.ImagePullSecrets,
@@ -218,14 +228,14 @@ func NewPod(rc *reconcile.Context, xstore *polardbxv1.XStore, nodeSet *polardbxv
 			HostNetwork:                   boolutil.IsTrue(template.Spec.HostNetwork),
 			ShareProcessNamespace:         pointer.BoolPtr(true),
 			Affinity:                      opts.NewAffinity(factoryCtx),
+			TopologySpreadConstraints:     template.Spec.TopologySpreadConstraints,
 			NodeName:                      hostPathVolume.Host, // If already bound, then assign to the same host.
+			InitContainers: VolumeMigrationInitContainers(engineImage,
+				rc.Config().Store().ContainerPrivileged(), hostPathVolume),
 			Containers: []corev1.Container{
 				{
-					Name: convention.ContainerEngine,
-					Image: defaults.NonEmptyStrOrDefault(
-						template.Spec.Image,
-						rc.Config().Images().DefaultImageForStore(engine, convention.ContainerEngine, ""),
-					),
+					Name:            convention.ContainerEngine,
+					Image:           engineImage,
 					ImagePullPolicy: template.Spec.ImagePullPolicy,
 					Ports:           containerPorts[convention.ContainerEngine],
 					WorkingDir:      opts.WorkDir(factoryCtx, convention.ContainerEngine),
@@ -234,9 +244,11 @@ func NewPod(rc *reconcile.Context, xstore *polardbxv1.XStore, nodeSet *polardbxv
 					VolumeMounts: k8shelper.PatchVolumeMounts(
 						SystemVolumeMounts(),
 						ConfigMapVolumeMounts(xstore),
+						TLSVolumeMounts(xstore),
+						TDEVolumeMounts(xstore),
 						volumeMounts[convention.ContainerEngine],
 					),
-					Env:             k8shelper.PatchEnvs(SystemEnvs(), envs[convention.ContainerEngine]),
+					Env:             k8shelper.PatchEnvs(SystemEnvs(), TDEEnvs(xstore), envs[convention.ContainerEngine]),
 					SecurityContext: k8shelper.NewSecurityContext(rc.Config().Store().ContainerPrivileged()),
 					Lifecycle: &corev1.Lifecycle{
 						PreStop: &corev1.Handler{
@@ -293,5 +305,12 @@ func NewPod(rc *reconcile.Context, xstore *polardbxv1.XStore, nodeSet *polardbxv
 		p.Setup(c)
 	}
 
+	// Ship the slow log if enabled.
+	logCollectorContainers, err := LogCollectorContainers(xstore, volumeMounts[convention.ContainerEngine])
+	if err != nil {
+		return nil, err
+	}
+	pod.Spec.Containers = append(pod.Spec.Containers, logCollectorContainers...)
+
 	return pod, nil
 }
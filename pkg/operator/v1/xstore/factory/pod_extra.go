@@ -254,6 +254,19 @@ func (f *DefaultExtraPodFactory) NewVolumes(ctx *PodFactoryContext, volumes []po
 	})
 
 	for i, vol := range volumes {
+		// Data and log share the claim in different sub paths.
+		if len(vol.PersistentVolumeClaim) > 0 {
+			res = append(res, corev1.Volume{
+				Name: f.newDataVolumeName(i, skipSequence),
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+						ClaimName: vol.PersistentVolumeClaim,
+					},
+				},
+			})
+			continue
+		}
+
 		res = append(res, corev1.Volume{
 			Name: f.newDataVolumeName(i, skipSequence),
 			VolumeSource: corev1.VolumeSource{
@@ -290,7 +303,21 @@ func (f *DefaultExtraPodFactory) NewVolumeMounts(ctx *PodFactoryContext) (map[st
 		MountPath: "/tools/xstore",
 	})
 
-	for i := range ctx.volumes {
+	for i, vol := range ctx.volumes {
+		if len(vol.PersistentVolumeClaim) > 0 {
+			mounts = append(mounts, corev1.VolumeMount{
+				Name:      f.newDataVolumeName(i, skipSequence),
+				MountPath: f.newDataVolumeMountPath(i, skipSequence),
+				SubPath:   persistentVolumeClaimDataSubPath,
+			})
+			mounts = append(mounts, corev1.VolumeMount{
+				Name:      f.newDataVolumeName(i, skipSequence),
+				MountPath: f.newLogVolumeMountPath(i, skipSequence),
+				SubPath:   persistentVolumeClaimLogSubPath,
+			})
+			continue
+		}
+
 		mounts = append(mounts, corev1.VolumeMount{
 			Name:             f.newDataVolumeName(i, skipSequence),
 			MountPath:        f.newDataVolumeMountPath(i, skipSequence),
@@ -348,6 +375,9 @@ func (f *DefaultExtraPodFactory) ExtraLabels(ctx *PodFactoryContext) map[string]
 		LogSeparation: strconv.FormatBool(ctx.xstore.Spec.Config.Dynamic.LogDataSeparation),
 		NodeName:      ctx.volumes[0].Host,
 	}
+	if ctx.xstore.Spec.TLS != nil {
+		config.TLSSecretName = ctx.xstore.Spec.TLS.SecretName
+	}
 	extraLabels[xstoremeta.LabelConfigHash] = config.ComputeHash()
 	return extraLabels
 }
@@ -0,0 +1,49 @@
+/*
+Copyright 2021 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package factory
+
+import (
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/meta/core/gms/security"
+	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
+)
+
+// NewTDEHash returns the hash of the TDE config which requires the pods to be rebuilt, i.e. except
+// the master key version, or empty if TDE is disabled.
+func NewTDEHash(xstore *polardbxv1.XStore) string {
+	if xstore.Spec.TDE == nil {
+		return ""
+	}
+	tde := xstore.Spec.TDE.DeepCopy()
+	tde.MasterKeyVersion = 0
+	hash, err := security.HashObj(tde)
+	if err != nil {
+		panic(err)
+	}
+	return hash
+}
+
+// TDEAnnotations returns the annotation of TDE hash if TDE is enabled.
+func TDEAnnotations(xstore *polardbxv1.XStore) map[string]string {
+	hash := NewTDEHash(xstore)
+	if len(hash) == 0 {
+		return nil
+	}
+	return map[string]string{
+		xstoremeta.AnnotationTDEHash: hash,
+	}
+}
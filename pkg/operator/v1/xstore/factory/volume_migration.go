@@ -0,0 +1,99 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package factory
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
+)
+
+// Sub paths of the data and log in the persistent volume claim.
+const (
+	persistentVolumeClaimDataSubPath = "data"
+	persistentVolumeClaimLogSubPath  = "log"
+)
+
+// ContainerVolumeMigration is the init container which copies the data from host paths to the claim.
+const ContainerVolumeMigration = "volume-migration"
+
+// volumeMigrationScript copies the data and log once, and marks the claim as migrated. It's safe to be
+// re-executed if the copy was interrupted since the marker is only created at the end.
+const volumeMigrationScript = `set -e
+if [ ! -f /pvc/.migrated ]; then
+  mkdir -p /pvc/data /pvc/log
+  cp -a /migrate/data/. /pvc/data/
+  cp -a /migrate/log/. /pvc/log/
+  sync
+  touch /pvc/.migrated
+fi
+`
+
+// isVolumeMigrating returns true if the volume is bound to a claim while the host paths are still kept.
+func isVolumeMigrating(vol *polardbxv1xstore.HostPathVolume) bool {
+	return len(vol.PersistentVolumeClaim) > 0 && len(vol.HostPath) > 0
+}
+
+// VolumeMigrationVolumes returns the host path volumes to copy from if the volume is being migrated.
+func VolumeMigrationVolumes(vol *polardbxv1xstore.HostPathVolume) []corev1.Volume {
+	if !isVolumeMigrating(vol) {
+		return nil
+	}
+	return []corev1.Volume{
+		{
+			Name: "migrate-data",
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{
+					Path: vol.HostPath,
+					Type: newHostPathTypeForHostPathVolume(vol),
+				},
+			},
+		},
+		{
+			Name: "migrate-log",
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{
+					Path: vol.LogHostPath,
+					Type: newHostPathTypeForHostPathVolume(vol),
+				},
+			},
+		},
+	}
+}
+
+// VolumeMigrationInitContainers returns the init container which copies the data from the host paths
+// to the claim before the engine starts, if the volume is being migrated. The pod is bound to the
+// same host, so the copy is done locally on the node.
+func VolumeMigrationInitContainers(image string, privileged bool, vol *polardbxv1xstore.HostPathVolume) []corev1.Container {
+	if !isVolumeMigrating(vol) {
+		return nil
+	}
+	return []corev1.Container{
+		{
+			Name:    ContainerVolumeMigration,
+			Image:   image,
+			Command: []string{"/bin/sh", "-c", volumeMigrationScript},
+			VolumeMounts: []corev1.VolumeMount{
+				{Name: "data", MountPath: "/pvc"},
+				{Name: "migrate-data", ReadOnly: true, MountPath: "/migrate/data"},
+				{Name: "migrate-log", ReadOnly: true, MountPath: "/migrate/log"},
+			},
+			SecurityContext: k8shelper.NewSecurityContext(privileged),
+		},
+	}
+}
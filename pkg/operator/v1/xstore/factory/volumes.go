@@ -18,6 +18,7 @@ package factory
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/pointer"
 
 	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
 	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
@@ -160,3 +161,118 @@ func ConfigMapVolumeMounts(xstore *polardbxv1.XStore) []corev1.VolumeMount {
 		},
 	}
 }
+
+// TLSVolumes returns the volume of the certificates if TLS is enabled.
+func TLSVolumes(xstore *polardbxv1.XStore) []corev1.Volume {
+	if xstore.Spec.TLS == nil {
+		return nil
+	}
+	// Only the certificates and the key of server are mounted, other keys of the secret, e.g. the
+	// private key of CA, never leave it.
+	items := make([]corev1.KeyToPath, 0, 3)
+	for _, key := range []string{
+		convention.SecretKeyTLSRootCrt,
+		convention.SecretKeyTLSServerCrt,
+		convention.SecretKeyTLSServerKey,
+	} {
+		items = append(items, corev1.KeyToPath{Key: key, Path: key})
+	}
+	return []corev1.Volume{
+		{
+			Name: "tls",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: xstore.Spec.TLS.SecretName,
+					Items:      items,
+				},
+			},
+		},
+	}
+}
+
+// TLSVolumeMounts mounts the certificates to /data/tls if TLS is enabled. The mounted files are
+// updated by kubelet when the secret changes.
+func TLSVolumeMounts(xstore *polardbxv1.XStore) []corev1.VolumeMount {
+	if xstore.Spec.TLS == nil {
+		return nil
+	}
+	return []corev1.VolumeMount{
+		{
+			Name:      "tls",
+			ReadOnly:  true,
+			MountPath: "/data/tls",
+		},
+	}
+}
+
+// TDEVolumes returns the volume of the master key source if TDE is enabled.
+func TDEVolumes(xstore *polardbxv1.XStore) []corev1.Volume {
+	if xstore.Spec.TDE == nil {
+		return nil
+	}
+	return []corev1.Volume{
+		{
+			Name: "tde",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: xstore.Spec.TDE.SecretName,
+				},
+			},
+		},
+	}
+}
+
+// TDEVolumeMounts mounts the master key source to /data/tde if TDE is enabled. The keyring itself
+// is kept on the data volume.
+func TDEVolumeMounts(xstore *polardbxv1.XStore) []corev1.VolumeMount {
+	if xstore.Spec.TDE == nil {
+		return nil
+	}
+	return []corev1.VolumeMount{
+		{
+			Name:      "tde",
+			ReadOnly:  true,
+			MountPath: "/data/tde",
+		},
+	}
+}
+
+// BackupEncryptionKeyFile returns the path of the key file which the encryption key named is mounted at.
+func BackupEncryptionKeyFile(name string) string {
+	return "/backup-encryption/" + name + "/key"
+}
+
+// PatchBackupEncryptionVolume mounts the key in the secret of the backup encryption, if any, to all the
+// containers at BackupEncryptionKeyFile(name).
+func PatchBackupEncryptionVolume(podSpec *corev1.PodSpec, name string, encryption *polardbxv1.BackupEncryption) {
+	if encryption == nil {
+		return
+	}
+	key := encryption.Key
+	if len(key) == 0 {
+		key = "key"
+	}
+	volumeName := "encryption-" + name
+	podSpec.Volumes = k8shelper.PatchVolumes(podSpec.Volumes, []corev1.Volume{
+		{
+			Name: volumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName:  encryption.SecretName,
+					Items:       []corev1.KeyToPath{{Key: key, Path: "key"}},
+					DefaultMode: pointer.Int32(0400),
+				},
+			},
+		},
+	})
+	for i := range podSpec.Containers {
+		c := &podSpec.Containers[i]
+		c.VolumeMounts = k8shelper.PatchVolumeMounts(c.VolumeMounts, []corev1.VolumeMount{
+			{
+				Name:      volumeName,
+				ReadOnly:  true,
+				MountPath: "/backup-encryption/" + name,
+			},
+		})
+	}
+}
@@ -40,3 +40,30 @@ const (
 const (
 	AnnotationRebuildFromPod = "xstore/rebuild_from_pod"
 )
+
+// AnnotationApplyBinlogBackup records the xstore backup applied by the apply binlog job of continuous restore.
+const (
+	AnnotationApplyBinlogBackup = "xstore/apply-binlog.backup"
+)
+
+// AnnotationOperatorVersion records the version of operator which creates the backup job.
+const AnnotationOperatorVersion = "xstore/operator-version"
+
+// AnnotationSwitchover requests a planned switchover of the leader to the pod in value, or to the
+// follower caught up most if empty. It's removed once the switchover succeeds or fails.
+const AnnotationSwitchover = "xstore/switchover"
+
+// AnnotationTDEHash records the hash of the TDE config which the pod is created with, regardless of
+// the master key version.
+const AnnotationTDEHash = "xstore/tde.hash"
+
+// AnnotationLogCollectorHash records the hash of the log collector config which the pod is created with.
+const AnnotationLogCollectorHash = "xstore/log-collector.hash"
+
+// Annotations requesting the migration from host path volumes to persistent volume claims of the storage
+// class in value. The size of the claims is from the size annotation, or the storage limit of the template
+// if not specified. They're removed once the migration completes.
+const (
+	AnnotationVolumeMigrationStorageClass = "xstore/volume-migration.storage-class"
+	AnnotationVolumeMigrationSize         = "xstore/volume-migration.size"
+)
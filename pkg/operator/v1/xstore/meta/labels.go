@@ -47,6 +47,7 @@ const (
 	LabelXStoreBinlogBackupName = "xstore/binlogbackup"
 	LabelBinlogPurgeLock        = "xstore/binlogpurge-lock"
 	LabelXStoreCollectName      = "xstore/collect"
+	LabelVerifiedBackup         = "xstore/verified-backup"
 )
 
 const (
@@ -5,6 +5,8 @@ import "github.com/alibaba/polardbx-operator/pkg/meta/core/gms/security"
 type RebuildConfig struct {
 	LogSeparation string
 	NodeName      string
+	// Omitted if empty to keep the hash of the pods without TLS.
+	TLSSecretName string `json:",omitempty"`
 }
 
 func (r *RebuildConfig) ComputeHash() string {
@@ -174,6 +174,34 @@ var UnblockBootstrap = xstorev1reconcile.NewStepBinder("UnblockBootstrap",
 	},
 )
 
+// BlockBootstrap blocks the pods to be started until the nodes' info is refreshed
+// by UnblockBootstrap, e.g. the pods are recreated with new addresses on resume.
+var BlockBootstrap = xstorev1reconcile.NewStepBinder("BlockBootstrap",
+	func(rc *xstorev1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		sharedCm, err := rc.GetXStoreConfigMap(convention.ConfigMapTypeShared)
+		if err != nil {
+			return flow.Error(err, "Unable to get shared config map.")
+		}
+
+		sharedChannel, err := ParseChannelFromConfigMap(sharedCm)
+		if err != nil {
+			return flow.Error(err, "Unable to parse shared channel from config map.")
+		}
+
+		if sharedChannel.IsBlocked() {
+			return flow.Pass()
+		}
+
+		sharedChannel.Block()
+		sharedCm.Data[channel.SharedChannelKey] = sharedChannel.String()
+		err = rc.Client().Update(rc.Context(), sharedCm)
+		if err != nil {
+			return flow.Error(err, "Unable to update shared config map.")
+		}
+		return flow.Continue("Block via shared channel.")
+	},
+)
+
 func setElectionWeightToOne(rc *xstorev1reconcile.Context, log logr.Logger, leaderPod *corev1.Pod, targetPods []corev1.Pod) error {
 	cmd := command.NewCanonicalCommandBuilder().
 		Consensus().
@@ -37,7 +37,17 @@ func (r *GalaxyBackupReconciler) Reconcile(rc *xstorev1reconcile.BackupContext,
 		log.Error(err, "Failed to build reconcile task.")
 		return reconcile.Result{}, err
 	}
-	return control.NewExecutor(log).Execute(rc, task)
+	result, err := control.NewExecutor(log).Execute(rc, task)
+
+	open, observeErr := backupsteps.ObserveBackupCircuitBreaker(rc, err)
+	if observeErr != nil {
+		log.Error(observeErr, "Unable to update circuit breaker of backup.")
+	}
+	if open {
+		log.Info("Circuit of backup is open, stop retrying.", "error", err.Error())
+		return reconcile.Result{}, nil
+	}
+	return result, err
 }
 
 func (r *GalaxyBackupReconciler) newReconcileTask(rc *xstorev1reconcile.BackupContext, xstoreBackup *xstorev1.XStoreBackup, log logr.Logger) (*control.Task, error) {
@@ -45,15 +55,40 @@ func (r *GalaxyBackupReconciler) newReconcileTask(rc *xstorev1reconcile.BackupCo
 	task := control.NewTask()
 
 	defer backupsteps.PersistentStatusChanges(task, true)
+	defer backupsteps.TraceBackupLifecycle(task, true)
+	defer backupsteps.ObserveBackupMetrics(task, true)
+	defer backupsteps.UpdateBackupConditions(task, true)
+
+	backupsteps.CheckBackupCircuitBreaker(task)
+
+	if backupsteps.IsVolumeSnapshotBackup(xstoreBackup) {
+		r.bindVolumeSnapshotBackupSteps(task, xstoreBackup, log)
+		return task, nil
+	}
 
 	switch xstoreBackup.Status.Phase {
-	case xstorev1.XStoreBackupNew:
+	case xstorev1.XStoreBackupNew, xstorev1.XStoreBackupPending:
+		if xstoreBackup.Spec.CopyFrom != nil {
+			backupsteps.CloneXStoreBackup(task)
+			backupsteps.UpdatePhaseTemplate(xstorev1.XStoreBackupFinished)(task)
+			break
+		}
+		backupsteps.WaitBackupQuota(task)
 		backupsteps.UpdateBackupStartInfo(task)
+		backupsteps.RecordBackupTopology(task)
+		backupsteps.EstimateBackupSizeAndDuration(task)
+		backupsteps.PrepareIncrementalBackup(task)
 		backupsteps.CreateBackupConfigMap(task)
+		backupsteps.ProvisionEphemeralLearner(task)
+		backupsteps.WaitEphemeralLearnerReady(task)
+		backupsteps.CheckBackupSourceLag(task)
+		backupsteps.AbortUploadsOfPreviousAttempt(task)
+		backupsteps.RunPreBackupHooks(task)
 		backupsteps.StartXStoreFullBackupJob(task)
 		backupsteps.UpdatePhaseTemplate(xstorev1.XStoreFullBackuping)(task)
 	case xstorev1.XStoreFullBackuping:
 		backupsteps.WaitFullBackupJobFinished(task)
+		backupsteps.CheckBackupTopology(task)
 		backupsteps.UpdatePhaseTemplate(xstorev1.XStoreBackupCollecting)(task)
 	case xstorev1.XStoreBackupCollecting:
 		backupsteps.WaitBinlogOffsetCollected(task)
@@ -65,20 +100,88 @@ func (r *GalaxyBackupReconciler) newReconcileTask(rc *xstorev1reconcile.BackupCo
 		backupsteps.StartBinlogBackupJob(task)
 		backupsteps.WaitBinlogBackupJobFinished(task)
 		backupsteps.ExtractLastEventTimestamp(task)
+		backupsteps.WaitOverlappedFullBackupJobFinished(task)
+		backupsteps.CollectUploadRetries(task)
+		backupsteps.CollectConsistencyWaits(task)
+		backupsteps.CheckBackupTopology(task)
+		backupsteps.RunPostBackupHooks(task)
 		backupsteps.UpdatePhaseTemplate(xstorev1.XStoreBinlogWaiting)(task)
 	case xstorev1.XStoreBinlogWaiting:
 		backupsteps.WaitPXCBackupFinished(task)
 		backupsteps.SaveXStoreSecrets(task)
 		backupsteps.UpdatePhaseTemplate(xstorev1.XStoreBackupFinished)(task)
 	case xstorev1.XStoreBackupFinished:
+		backupsteps.ForgetPollingSteps(task)
+		backupsteps.NotifyBackupOutcome(task)
+		backupsteps.SealXStoreBackup(task)
+		backupsteps.IndexRecoverableWindow(task)
+		backupsteps.ExportBackupToCatalog(task)
 		backupsteps.RemoveFullBackupJob(task)
 		backupsteps.RemoveCollectBinlogJob(task)
 		backupsteps.RemoveBinlogBackupJob(task)
+		backupsteps.CleanIncompleteUploads(task)
+		backupsteps.RemoveEphemeralLearner(task)
+		backupsteps.VerifyBackupByRestoreDrill(task)
+		backupsteps.RemoveVerificationXStore(task)
 		backupsteps.RemoveXSBackupOverRetention(task)
 		log.Info("Finished phase.")
+	case xstorev1.XStoreBackupFailed:
+		backupsteps.ForgetPollingSteps(task)
+		backupsteps.RunPostBackupHooks(task)
+		backupsteps.NotifyBackupOutcome(task)
+		backupsteps.ExportBackupToCatalog(task)
+		// The learner is not kept for diagnosis since it's costly.
+		backupsteps.RemoveEphemeralLearner(task)
+		backupsteps.WaitFailedArtifactRetention(task)
+		backupsteps.RemoveFullBackupJob(task)
+		backupsteps.RemoveCollectBinlogJob(task)
+		backupsteps.RemoveBinlogBackupJob(task)
+		backupsteps.CleanIncompleteUploads(task)
+		log.Info("Failed phase.")
 	default:
 		log.Info("Unrecognized phase.")
 	}
 
 	return task, nil
 }
+
+// bindVolumeSnapshotBackupSteps binds the steps of backup taken as the volume snapshot. Nothing is
+// uploaded, so the phases of binlogs only wait for the pxc backup.
+func (r *GalaxyBackupReconciler) bindVolumeSnapshotBackupSteps(task *control.Task, xstoreBackup *xstorev1.XStoreBackup, log logr.Logger) {
+	switch xstoreBackup.Status.Phase {
+	case xstorev1.XStoreBackupNew, xstorev1.XStoreBackupPending:
+		backupsteps.WaitBackupQuota(task)
+		backupsteps.UpdateBackupStartInfo(task)
+		backupsteps.RecordBackupTopology(task)
+		backupsteps.RunPreBackupHooks(task)
+		backupsteps.TakeVolumeSnapshot(task)
+		backupsteps.UpdatePhaseTemplate(xstorev1.XStoreFullBackuping)(task)
+	case xstorev1.XStoreFullBackuping:
+		backupsteps.WaitVolumeSnapshotReady(task)
+		backupsteps.CheckBackupTopology(task)
+		backupsteps.RunPostBackupHooks(task)
+		backupsteps.UpdatePhaseTemplate(xstorev1.XStoreBackupCollecting)(task)
+	case xstorev1.XStoreBackupCollecting, xstorev1.XStoreBinlogBackuping, xstorev1.XStoreBinlogWaiting:
+		backupsteps.WaitPXCBackupFinished(task)
+		backupsteps.SaveXStoreSecrets(task)
+		backupsteps.UpdatePhaseTemplate(xstorev1.XStoreBackupFinished)(task)
+	case xstorev1.XStoreBackupFinished:
+		backupsteps.ForgetPollingSteps(task)
+		backupsteps.NotifyBackupOutcome(task)
+		backupsteps.SealXStoreBackup(task)
+		backupsteps.IndexRecoverableWindow(task)
+		backupsteps.ExportBackupToCatalog(task)
+		backupsteps.VerifyBackupByRestoreDrill(task)
+		backupsteps.RemoveVerificationXStore(task)
+		backupsteps.RemoveXSBackupOverRetention(task)
+		log.Info("Finished phase.")
+	case xstorev1.XStoreBackupFailed:
+		backupsteps.ForgetPollingSteps(task)
+		backupsteps.RunPostBackupHooks(task)
+		backupsteps.NotifyBackupOutcome(task)
+		backupsteps.ExportBackupToCatalog(task)
+		log.Info("Failed phase.")
+	default:
+		log.Info("Unrecognized phase.")
+	}
+}
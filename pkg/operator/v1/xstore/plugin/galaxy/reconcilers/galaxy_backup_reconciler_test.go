@@ -0,0 +1,119 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcilers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+)
+
+func newFinishedBackupContext(t *testing.T, engine polardbxv1.BackupEngine) (*xstorev1reconcile.BackupContext, client.Client) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := polardbxv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	now := metav1.Now()
+	backup := &polardbxv1.XStoreBackup{}
+	backup.Name = "backup"
+	backup.Namespace = "default"
+	backup.UID = "backup-uid"
+	backup.Spec.XStore.Name = "xstore"
+	backup.Spec.BackupEngine = engine
+	backup.Spec.RetentionTime = metav1.Duration{Duration: 24 * time.Hour}
+	backup.Spec.Verification = &polardbxv1.BackupVerification{}
+	backup.Status.Phase = polardbxv1.XStoreBackupFinished
+	backup.Status.StartTime = &now
+	backup.Status.EndTime = &now
+
+	xstore := &polardbxv1.XStore{}
+	xstore.Name = "xstore"
+	xstore.Namespace = "default"
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(backup, xstore).Build()
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: backup.Name}}
+	base := control.NewBaseReconcileContext(c, nil, nil, scheme, context.Background(), request)
+	return xstorev1reconcile.NewBackupContext(base), c
+}
+
+func TestFinishedBackupVerifiedBeforeRetention(t *testing.T) {
+	engines := map[string]polardbxv1.BackupEngine{
+		"xtrabackup":     polardbxv1.BackupEngineXtrabackup,
+		"volumesnapshot": polardbxv1.BackupEngineVolumeSnapshot,
+	}
+
+	for name, engine := range engines {
+		t.Run(name, func(t *testing.T) {
+			rc, c := newFinishedBackupContext(t, engine)
+
+			r := &GalaxyBackupReconciler{}
+			result, err := r.Reconcile(rc, logr.Discard(), rc.Request())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.RequeueAfter <= 0 {
+				t.Errorf("expect requeue to wait for the retention, got %+v", result)
+			}
+
+			var backup polardbxv1.XStoreBackup
+			if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "backup"}, &backup); err != nil {
+				t.Fatal(err)
+			}
+			verification := backup.Status.Verification
+			if verification == nil || verification.Phase != polardbxv1.BackupVerificationRunning {
+				t.Fatalf("expect the restore drill running, got %+v", verification)
+			}
+			var drill polardbxv1.XStore
+			if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: verification.XStore}, &drill); err != nil {
+				t.Fatalf("restore drill not created: %v", err)
+			}
+
+			sealed := false
+			for _, cond := range backup.Status.Conditions {
+				if cond.Type == polardbxv1xstore.BackupImmutable && cond.Status == corev1.ConditionTrue {
+					sealed = true
+				}
+			}
+			if !sealed {
+				t.Errorf("expect the backup sealed, got conditions %+v", backup.Status.Conditions)
+			}
+			if _, ok := backup.Labels[polardbxmeta.LabelRecoverableFrom]; !ok {
+				t.Errorf("expect the recoverable window indexed, got labels %v", backup.Labels)
+			}
+		})
+	}
+}
@@ -62,6 +62,7 @@ func (r *GalaxyReconciler) newReconcileTask(rc *xstorev1reconcile.Context, xstor
 	// Deferred steps, will always be executed in the deferred sequence.
 	defer instancesteps.PersistentStatus(task, true)
 	defer instancesteps.PersistentXStore(task, true)
+	defer instancesteps.UpdateStatusConditionsAndProgress(task, true)
 	defer instancesteps.UpdateDisplayStatus(task, true)
 
 	// Weave steps according to current status, aka. construct a huge state machine.
@@ -146,6 +147,12 @@ func (r *GalaxyReconciler) newReconcileTask(rc *xstorev1reconcile.Context, xstor
 	case polardbxv1xstore.PhaseRestoring:
 		switch xstore.Status.Stage {
 		case polardbxv1xstore.StageEmpty:
+			// Isolate the restored nodes before they start, if required.
+			instancesteps.ReconcileRestoreNetworkIsolation(task)
+
+			// Provision the claims from the volume snapshot before pods are created, if restored from one.
+			instancesteps.PrepareVolumesFromSnapshot(task)
+
 			// Create pods and save volumes/ports into status.
 			galaxyinstancesteps.CreatePodsAndServices(task)
 			instancesteps.BindHostPathVolumesToHost(task)
@@ -156,8 +163,14 @@ func (r *GalaxyReconciler) newReconcileTask(rc *xstorev1reconcile.Context, xstor
 
 			xstoreplugincommonsteps.SyncNodesInfoAndKeepBlock(task)
 			instancesteps.PrepareRestoreJobContext(task)
+			// Check the backup against the target before anything is downloaded.
+			instancesteps.CheckRestoreCompatibility(task)
+			// Archived backup objects must be thawed before being downloaded.
+			instancesteps.WaitUntilBackupObjectsThawed(task)
 			instancesteps.StartRestoreJob(task)
 			instancesteps.WaitUntilRestoreJobFinished(task)
+			// Apply the config overlay before the restored nodes start.
+			instancesteps.ApplyRestoreConfigOverlay(task)
 			// Unblock bootstrap.
 			xstoreplugincommonsteps.UnblockBootstrap(task)
 
@@ -171,6 +184,8 @@ func (r *GalaxyReconciler) newReconcileTask(rc *xstorev1reconcile.Context, xstor
 
 			instancesteps.StartRecoverJob(task)
 			instancesteps.WaitUntilRecoverJobFinished(task)
+			// Replay the binlogs after the backup set up to the restore time.
+			instancesteps.ReplayBinlogsToRestoreTime(task)
 
 			// Check connectivity and set engine version into status.
 			control.Branch(debug.IsDebugEnabled(),
@@ -178,6 +193,14 @@ func (r *GalaxyReconciler) newReconcileTask(rc *xstorev1reconcile.Context, xstor
 				instancesteps.CheckConnectivityAndSetEngineVersion, // Updates the engine version by accessing directly.
 			)(task)
 
+			// Mask and validate the restored data before it's ready.
+			instancesteps.ApplyRestoreMasking(task)
+			instancesteps.RunPostRestoreExec(task)
+
+			// Wait until the restored nodes are healthy members of the consensus group.
+			instancesteps.WaitUntilRestoredNodesRejoined(task)
+
+			instancesteps.ObserveRestoreMetrics(task)
 			instancesteps.UpdateStageTemplate(polardbxv1xstore.StageClean)(task)
 		case polardbxv1xstore.StageClean:
 			// clean up restore context
@@ -191,6 +214,12 @@ func (r *GalaxyReconciler) newReconcileTask(rc *xstorev1reconcile.Context, xstor
 	case polardbxv1xstore.PhaseRunning:
 		switch xstore.Status.Stage {
 		case polardbxv1xstore.StageEmpty:
+			// Goto suspended if requested, the pods are stopped there.
+			control.When(xstore.Spec.Suspended,
+				instancesteps.UpdatePhaseTemplate(polardbxv1xstore.PhaseSuspended),
+				control.Retry("Start suspending..."),
+			)(task)
+
 			// Restart xstore when restart parameters changed
 			control.When(rc.GetXStoreRestarting(),
 				instancesteps.GetRestartingPods,
@@ -226,9 +255,16 @@ func (r *GalaxyReconciler) newReconcileTask(rc *xstorev1reconcile.Context, xstor
 			instancesteps.ReconcileConsensusRoleLabels(task)
 			control.When(readonly,
 				instancesteps.AddLearnerNodesToClusterOnLeader,
+				instancesteps.ReconcileReadonlyReplication,
 			)(task)
 			instancesteps.WaitUntilLeaderElected(task)
 
+			// Goto switchover if requested, other changes wait until it's done.
+			instancesteps.WhenSwitchoverRequested(
+				instancesteps.UpdateStageTemplate(polardbxv1xstore.StageSwitchover),
+				control.Retry("Start switchover..."),
+			)(task)
+
 			// Purge logs with interval specified (but not less than 2 minutes).
 			logPurgeInterval := 2 * time.Minute
 			if xstore.Spec.Config.Dynamic.LogPurgeInterval != nil {
@@ -242,6 +278,9 @@ func (r *GalaxyReconciler) newReconcileTask(rc *xstorev1reconcile.Context, xstor
 			// Update host path volume sizes.
 			instancesteps.UpdateHostPathVolumeSizesTemplate(time.Minute)(task)
 
+			// Scrape the replication lags of members for metrics.
+			instancesteps.UpdateReplicationLagMetricsTemplate(30 * time.Second)(task)
+
 			// Branch disk quota exceeds, lock all candidates and requeue immediately.
 			instancesteps.WhenDiskQuotaExceeds(
 				instancesteps.UpdateStageTemplate(polardbxv1xstore.StageLocking),
@@ -256,6 +295,33 @@ func (r *GalaxyReconciler) newReconcileTask(rc *xstorev1reconcile.Context, xstor
 			// Sync my.cnf from my.cnf.override
 			instancesteps.UpdateMycnfParameters(task)
 
+			// Keep the restored nodes isolated until promoted.
+			control.When(xstore.Spec.Restore != nil,
+				instancesteps.ReconcileRestoreNetworkIsolation,
+			)(task)
+
+			// Keep the voting members from being disrupted at the same time.
+			instancesteps.ReconcilePodDisruptionBudget(task)
+
+			// Roll pods to mount the TLS secret and reload the certificates once rotated.
+			instancesteps.RollPodsForTLS(task)
+			instancesteps.ReloadTLSCertificates(task)
+
+			// Roll pods to load the keyring and rotate the master key on request.
+			instancesteps.RollPodsForTDE(task)
+			instancesteps.RotateTDEMasterKey(task)
+
+			// Roll pods to add, update or remove the collector of slow logs.
+			instancesteps.RollPodsForLogCollector(task)
+
+			// Migrate the data from host paths to persistent volume claims on request.
+			instancesteps.MigrateVolumesToPersistentVolumeClaims(task)
+
+			// Apply binlog backups of the source xstore if it's a warm standby.
+			control.When(xstore.Spec.Restore != nil && xstore.Spec.Restore.Continuous != nil && !readonly,
+				instancesteps.ApplyBinlogBackupsContinuously,
+			)(task)
+
 			// Goto upgrading if topology changed. (not breaking the task flow)
 			instancesteps.WhenTopologyChanged(
 				instancesteps.UpdatePhaseTemplate(polardbxv1xstore.PhaseUpgrading),
@@ -278,6 +344,12 @@ func (r *GalaxyReconciler) newReconcileTask(rc *xstorev1reconcile.Context, xstor
 
 			instancesteps.UpdateStageTemplate(polardbxv1xstore.StageEmpty)(task)
 			instancesteps.UpdatePhaseTemplate(polardbxv1xstore.PhaseLocked)(task)
+		case polardbxv1xstore.StageSwitchover:
+			// Transfer the leadership gracefully, the labels are reconciled when back to running.
+			instancesteps.ReconcileSwitchover(task)
+			instancesteps.RemoveSwitchoverAnnotation(task)
+
+			instancesteps.UpdateStageTemplate(polardbxv1xstore.StageEmpty, true)(task)
 		}
 	case polardbxv1xstore.PhaseLocked:
 		// Purge logs every 30 seconds.
@@ -371,6 +443,9 @@ func (r *GalaxyReconciler) newReconcileTask(rc *xstorev1reconcile.Context, xstor
 		// Delete host path volumes.
 		instancesteps.DeleteHostPathVolumes(task)
 
+		// Remove the metrics of xstore.
+		instancesteps.ForgetXStoreMetrics(task)
+
 		// Then let it go.
 		instancesteps.RemoveFinalizerFromXStore(task)
 	case polardbxv1xstore.PhaseRestarting:
@@ -386,6 +461,42 @@ func (r *GalaxyReconciler) newReconcileTask(rc *xstorev1reconcile.Context, xstor
 		)(task)
 		instancesteps.CloseXStoreRestartPhase(task)
 		instancesteps.UpdatePhaseTemplate(polardbxv1xstore.PhaseRunning, true)(task)
+	case polardbxv1xstore.PhaseSuspended:
+		// Stop all pods while keeping the volumes, services and secrets. Block the
+		// bootstrap so that the recreated pods wait until the nodes' info is refreshed.
+		control.When(xstore.Spec.Suspended,
+			instancesteps.CancelAsyncTasks,
+			instancesteps.WaitUntilAsyncTasksCanceled,
+			xstoreplugincommonsteps.BlockBootstrap,
+			instancesteps.DeleteAllPods,
+			instancesteps.WaitUntilAllPodsStopped,
+			control.RetryAfter(30*time.Second, "Suspended, check every 30 seconds..."),
+		)(task)
+
+		instancesteps.UpdatePhaseTemplate(polardbxv1xstore.PhaseResuming)(task)
+	case polardbxv1xstore.PhaseResuming:
+		// Recreate the pods on the bound hosts with the same volumes.
+		galaxyinstancesteps.CreatePodsAndServices(task)
+		instancesteps.BindPodPorts(task)
+
+		// Wait until pods' scheduled.
+		instancesteps.WaitUntilPodsScheduled(task)
+
+		// Refresh the nodes' info and unblock, the consensus group is restored from the data.
+		xstoreplugincommonsteps.UnblockBootstrap(task)
+
+		// Wait until leader ready.
+		instancesteps.WaitUntilCandidatesAndVotersReady(task)
+
+		// Role reconciliation.
+		instancesteps.ReconcileConsensusRoleLabels(task)
+		instancesteps.WaitUntilLeaderElected(task)
+		control.When(readonly,
+			instancesteps.AddLearnerNodesToClusterOnLeader,
+		)(task)
+
+		// Go to phase "Running".
+		instancesteps.UpdatePhaseTemplate(polardbxv1xstore.PhaseRunning)(task)
 	case polardbxv1xstore.PhaseFailed:
 		log.Info("Failed.")
 	case polardbxv1xstore.PhaseUnknown:
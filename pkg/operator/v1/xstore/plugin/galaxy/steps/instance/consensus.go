@@ -24,6 +24,7 @@ import (
 	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
 	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
 	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/metrics"
 	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
 	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/plugin"
 	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/plugin/galaxy/galaxy"
@@ -66,6 +67,7 @@ var DummyReconcileConsensusRoleLabels = plugin.NewStepBinder(galaxy.Engine, "Rec
 		} else {
 			currentLeader = ""
 		}
+		metrics.ObserveLeader(xstore.Namespace, xstore.Name, currentLeader)
 
 		if len(currentLeader) == 0 {
 			xstore.Status.LeaderPod = ""
@@ -35,10 +35,14 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/utils/pointer"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"strconv"
+	"time"
 )
 
 type BackupContext struct {
@@ -172,11 +176,40 @@ func (rc *BackupContext) GetXStore() (*polardbxv1.XStore, error) {
 	return rc.xstore, nil
 }
 
+// statusUpdateBackoff bounds the retries of status update on transient errors of api server, so
+// that the progress isn't lost on a brief hiccup of control plane.
+var statusUpdateBackoff = wait.Backoff{
+	Steps:    5,
+	Duration: 200 * time.Millisecond,
+	Factor:   2,
+	Jitter:   0.1,
+}
+
+func isTransientApiError(err error) bool {
+	return apierrors.IsConflict(err) || apierrors.IsServerTimeout(err) || apierrors.IsTimeout(err) ||
+		apierrors.IsTooManyRequests(err) || apierrors.IsServiceUnavailable(err) || apierrors.IsInternalError(err) ||
+		utilnet.IsConnectionRefused(err) || utilnet.IsConnectionReset(err) || utilnet.IsProbableEOF(err)
+}
+
+// UpdateXStoreBackupStatus persists the status, retrying with bounded backoff on transient errors.
+// The status is owned by the controller, so it's applied on the latest version on conflicts.
 func (rc *BackupContext) UpdateXStoreBackupStatus() error {
 	if rc.xstoreBackupStatusSnapshot == nil {
 		return nil
 	}
-	err := rc.Client().Status().Update(rc.Context(), rc.xstoreBackup)
+	status := rc.xstoreBackup.Status.DeepCopy()
+	err := retry.OnError(statusUpdateBackoff, isTransientApiError, func() error {
+		err := rc.Client().Status().Update(rc.Context(), rc.xstoreBackup)
+		if apierrors.IsConflict(err) {
+			var latest polardbxv1.XStoreBackup
+			if err := rc.Client().Get(rc.Context(), rc.Request().NamespacedName, &latest); err != nil {
+				return err
+			}
+			rc.xstoreBackup.ResourceVersion = latest.ResourceVersion
+			rc.xstoreBackup.Status = *status.DeepCopy()
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
@@ -254,7 +287,7 @@ func (rc *BackupContext) GetXStoreTargetPod() (*corev1.Pod, error) {
 
 		// TODO: Take health info and delay into consideration
 		// set target pod for XStoreBackup on which backup will be performed
-		// priority of the target pod: choice made by user > follower > leader
+		// priority of the target pod: role in spec > choice made by user > follower > leader
 		pods, err := rc.GetXStorePods()
 		if err != nil {
 			return nil, err
@@ -266,7 +299,10 @@ func (rc *BackupContext) GetXStoreTargetPod() (*corev1.Pod, error) {
 			rolePodMap[p.Labels[xstoremeta.LabelRole]] = p
 		}
 
-		preferred, _ := xstoreBackup.Labels[meta.LabelPreferredBackupNode]
+		preferred := string(xstoreBackup.Spec.PreferredBackupRole)
+		if len(preferred) == 0 {
+			preferred = xstoreBackup.Labels[meta.LabelPreferredBackupNode]
+		}
 		if preferred == xstoremeta.RoleLeader { // preferred backup node is leader, just set it
 			p, ok := rolePodMap[xstoremeta.RoleLeader]
 			if !ok {
@@ -276,41 +312,78 @@ func (rc *BackupContext) GetXStoreTargetPod() (*corev1.Pod, error) {
 			return p, nil
 		}
 
-		// if `PreferredBackupNode` has not been set or set to something other than leader, then we pick follower as backup pod
-		p, ok := rolePodMap[xstoremeta.RoleFollower]
+		// learner is picked if preferred and present, follower otherwise
+		role := xstoremeta.RoleFollower
+		if _, ok := rolePodMap[xstoremeta.RoleLearner]; ok && preferred == xstoremeta.RoleLearner {
+			role = xstoremeta.RoleLearner
+		}
+		p, ok := rolePodMap[role]
 		if !ok {
 			return nil, errors.New("target pod is follower, but follower not found")
 		}
+		// pod in the preferred zone goes first, fall back to any pod of the role otherwise
+		if len(xstoreBackup.Spec.PreferSourceZone) > 0 {
+			for i := range pods {
+				if pods[i].Labels[xstoremeta.LabelRole] != role {
+					continue
+				}
+				zone, err := rc.GetPodZone(&pods[i])
+				if err != nil {
+					return nil, err
+				}
+				if zone == xstoreBackup.Spec.PreferSourceZone {
+					p = &pods[i]
+					break
+				}
+			}
+		}
 		manager, err := rc.GetXstoreGroupManagerByPod(p)
 		if err != nil {
 			return nil, err
 		}
 		if manager == nil {
-			return nil, errors.New("fail to connect to follower")
+			return nil, errors.New("fail to connect to " + role)
 		}
 		status, err := manager.ShowSlaveStatus()
 		if err != nil {
 			return nil, err
 		}
 		if status.SlaveSQLRunning == "No" || status.LastError != "" {
-			return nil, errors.New("follower status abnormal")
+			return nil, errors.New(role + " status abnormal")
 		}
 		rc.xstoreTargetPod = p
 	}
 	return rc.xstoreTargetPod, nil
 }
 
+// GetPodZone resolves the zone of the pod from the label of the node it's on.
+func (rc *BackupContext) GetPodZone(pod *corev1.Pod) (string, error) {
+	if len(pod.Spec.NodeName) == 0 {
+		return "", nil
+	}
+	var node corev1.Node
+	err := rc.Client().Get(rc.Context(), types.NamespacedName{Name: pod.Spec.NodeName}, &node)
+	if err != nil {
+		return "", err
+	}
+	return node.Labels[corev1.LabelTopologyZone], nil
+}
+
 func (rc *BackupContext) UpdateXStoreBackup() error {
 	if rc.xstoreBackup == nil {
 		return nil
 	}
 
+	// The status is overwritten by the one on server, keep the changes so that they are persisted
+	// along with the status later.
+	status := rc.xstoreBackup.Status.DeepCopy()
 	err := rc.Client().Update(rc.Context(), rc.xstoreBackup)
 	if err != nil {
 		return err
 	}
 
 	rc.xstoreBackupStatusSnapshot = rc.xstoreBackup.Status.DeepCopy()
+	rc.xstoreBackup.Status = *status
 	return nil
 }
 
@@ -501,6 +574,16 @@ func (rc *BackupContext) NewSecretFromXStore(secret *corev1.Secret) (*corev1.Sec
 }
 
 func (rc *BackupContext) GetXstoreGroupManagerByPod(pod *corev1.Pod) (group.GroupManager, error) {
+	ds, err := rc.GetXStorePodDataSource(pod)
+	if err != nil {
+		return nil, err
+	}
+	return group.NewGroupManager(rc.Context(), *ds, true), nil
+}
+
+// GetXStorePodDataSource returns the data source of super account to access the engine of the pod
+// through the service of the pod.
+func (rc *BackupContext) GetXStorePodDataSource(pod *corev1.Pod) (*dbutil.MySQLDataSource, error) {
 	var serviceList corev1.ServiceList
 	err := rc.Client().List(rc.Context(), &serviceList, client.InNamespace(rc.Namespace()), client.MatchingLabels{
 		xstoremeta.LabelPod: pod.Name,
@@ -522,14 +605,10 @@ func (rc *BackupContext) GetXstoreGroupManagerByPod(pod *corev1.Pod) (group.Grou
 	if !ok {
 		return nil, errors.New("can not get passwd for xsotre " + rc.xstore.Name)
 	}
-	return group.NewGroupManager(
-		rc.Context(),
-		dbutil.MySQLDataSource{
-			Host:     host,
-			Port:     port,
-			Username: user,
-			Password: string(passwd),
-		},
-		true,
-	), nil
+	return &dbutil.MySQLDataSource{
+		Host:     host,
+		Port:     port,
+		Username: user,
+		Password: string(passwd),
+	}, nil
 }
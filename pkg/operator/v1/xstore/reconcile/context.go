@@ -20,12 +20,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"github.com/alibaba/polardbx-operator/pkg/meta/core/gms"
 	"github.com/alibaba/polardbx-operator/pkg/util"
 	"k8s.io/utils/pointer"
 	"strings"
 	"time"
 
+	polardbxhelper "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
 	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
 
 	"google.golang.org/grpc"
@@ -681,12 +681,7 @@ func (rc *Context) ParseRestoreTime() (time.Time, error) {
 		return time.Time{}, nil
 	}
 
-	location, err := time.LoadLocation(gms.StrOrDefault(xcluster.Spec.Restore.TimeZone, "Asia/Shanghai"))
-	if err != nil {
-		return time.Time{}, nil
-	}
-
-	return time.ParseInLocation("2006-01-02 15:04:05", xcluster.Spec.Restore.Time, location)
+	return polardbxhelper.ParseRestoreTime(xcluster.Spec.Restore.Time, xcluster.Spec.Restore.TimeZone)
 }
 
 func (rc *Context) MustParseRestoreTime() time.Time {
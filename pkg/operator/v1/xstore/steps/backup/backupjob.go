@@ -19,7 +19,10 @@ package backup
 import (
 	xstorev1 "github.com/alibaba/polardbx-operator/api/v1"
 	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/config"
+	polardbxhelper "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
 	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/command"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/factory"
 	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
 	"github.com/alibaba/polardbx-operator/pkg/util"
 	batchv1 "k8s.io/api/batch/v1"
@@ -102,6 +105,7 @@ func newBackupJob(xstoreBackup *xstorev1.XStoreBackup, targetPod *corev1.Pod, jo
 	// Replace system envs
 	replaceSystemEnvs(podSpec, targetPod)
 	patchTaskConfigMapVolumeAndVolumeMounts(xstoreBackup, podSpec)
+	factory.PatchBackupEncryptionVolume(podSpec, "backup", xstoreBackup.Spec.Encryption)
 
 	job := &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
@@ -112,6 +116,9 @@ func newBackupJob(xstoreBackup *xstorev1.XStoreBackup, targetPod *corev1.Pod, jo
 				xstoremeta.JobLabelTargetNodeName: targetPod.Spec.NodeName,
 				xstoremeta.LabelXStoreBackupName:  xstoreBackup.Name,
 			},
+			Annotations: map[string]string{
+				xstoremeta.AnnotationOperatorVersion: config.OperatorVersion(),
+			},
 		},
 		Spec: batchv1.JobSpec{
 			BackoffLimit: pointer.Int32(0),
@@ -127,5 +134,6 @@ func newBackupJob(xstoreBackup *xstorev1.XStoreBackup, targetPod *corev1.Pod, jo
 			},
 		},
 	}
+	polardbxhelper.ApplyBackupJobTemplate(job, xstoreBackup.Spec.JobTemplate)
 	return job, nil
 }
@@ -19,7 +19,10 @@ package backup
 import (
 	xstorev1 "github.com/alibaba/polardbx-operator/api/v1"
 	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/config"
+	polardbxhelper "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
 	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/command"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/factory"
 	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -54,6 +57,7 @@ func newBinlogBackupJob(xstoreBackup *xstorev1.XStoreBackup, targetPod *corev1.P
 	// Replace system envs
 	replaceSystemEnvs(podSpec, targetPod)
 	patchTaskConfigMapVolumeAndVolumeMounts(xstoreBackup, podSpec)
+	factory.PatchBackupEncryptionVolume(podSpec, "backup", xstoreBackup.Spec.Encryption)
 
 	job := &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
@@ -64,6 +68,9 @@ func newBinlogBackupJob(xstoreBackup *xstorev1.XStoreBackup, targetPod *corev1.P
 				xstoremeta.JobLabelTargetNodeName:      targetPod.Spec.NodeName,
 				xstoremeta.LabelXStoreBinlogBackupName: xstoreBackup.Name,
 			},
+			Annotations: map[string]string{
+				xstoremeta.AnnotationOperatorVersion: config.OperatorVersion(),
+			},
 		},
 		Spec: batchv1.JobSpec{
 			BackoffLimit: pointer.Int32(0),
@@ -79,5 +86,6 @@ func newBinlogBackupJob(xstoreBackup *xstorev1.XStoreBackup, targetPod *corev1.P
 			},
 		},
 	}
+	polardbxhelper.ApplyBackupJobTemplate(job, xstoreBackup.Spec.JobTemplate)
 	return job, nil
 }
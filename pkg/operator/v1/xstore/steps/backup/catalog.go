@@ -0,0 +1,227 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+	dbutil "github.com/alibaba/polardbx-operator/pkg/util/database"
+)
+
+const (
+	catalogTimeout      = 10 * time.Second
+	catalogMaxAttempts  = 10
+	defaultCatalogTable = "polardbx_backups"
+)
+
+var catalogTablePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// catalogColumns are the columns of the catalog table in order, the schema is stable and only
+// appended to in the future.
+var catalogColumns = []string{
+	"backup_uid", "namespace", "name", "cluster", "top_backup", "xstore", "status", "failure_reason",
+	"start_time", "end_time", "recoverable_from", "recoverable_to", "backup_size", "storage_name",
+	"destination", "checksum_manifest", "updated_at",
+}
+
+func catalogTableDDL(table string) string {
+	return "CREATE TABLE IF NOT EXISTS `" + table + "` (\n" +
+		"  `backup_uid` VARCHAR(64) NOT NULL,\n" +
+		"  `namespace` VARCHAR(253) NOT NULL,\n" +
+		"  `name` VARCHAR(253) NOT NULL,\n" +
+		"  `cluster` VARCHAR(253) NOT NULL DEFAULT '',\n" +
+		"  `top_backup` VARCHAR(253) NOT NULL DEFAULT '',\n" +
+		"  `xstore` VARCHAR(253) NOT NULL,\n" +
+		"  `status` VARCHAR(32) NOT NULL,\n" +
+		"  `failure_reason` VARCHAR(64) NOT NULL DEFAULT '',\n" +
+		"  `start_time` DATETIME NULL,\n" +
+		"  `end_time` DATETIME NULL,\n" +
+		"  `recoverable_from` DATETIME NULL,\n" +
+		"  `recoverable_to` DATETIME NULL,\n" +
+		"  `backup_size` BIGINT NOT NULL DEFAULT 0,\n" +
+		"  `storage_name` VARCHAR(32) NOT NULL DEFAULT '',\n" +
+		"  `destination` VARCHAR(1024) NOT NULL DEFAULT '',\n" +
+		"  `checksum_manifest` VARCHAR(1024) NOT NULL DEFAULT '',\n" +
+		"  `updated_at` DATETIME NOT NULL,\n" +
+		"  PRIMARY KEY (`backup_uid`)\n" +
+		") DEFAULT CHARSET = utf8mb4"
+}
+
+// catalogUpsertStatement returns the statement upserting a row, so that exporting the same backup
+// again, e.g. on a phase change or after the status fails to persist, only updates the row.
+func catalogUpsertStatement(table string) string {
+	stmt := "INSERT INTO `" + table + "` ("
+	values := ""
+	updates := ""
+	for i, c := range catalogColumns {
+		if i > 0 {
+			stmt += ", "
+			values += ", "
+		}
+		stmt += "`" + c + "`"
+		values += "?"
+		if c == "backup_uid" {
+			continue
+		}
+		if len(updates) > 0 {
+			updates += ", "
+		}
+		updates += "`" + c + "` = VALUES(`" + c + "`)"
+	}
+	return stmt + ") VALUES (" + values + ") ON DUPLICATE KEY UPDATE " + updates
+}
+
+func catalogTime(t *metav1.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+// catalogRowOf returns the values of the row of backup, in the order of catalogColumns.
+func catalogRowOf(backup *polardbxv1.XStoreBackup, now time.Time) []interface{} {
+	storage := backup.Spec.StorageProvider
+	destination := ""
+	if len(backup.Status.BackupRootPath) > 0 {
+		destination = storage.Sink + ":" + backup.Status.BackupRootPath
+	}
+	checksumManifest := ""
+	if backup.Spec.EnableDedupReport && len(backup.Status.BackupRootPath) > 0 {
+		checksumManifest = chunkManifestPath(backup.Status.BackupRootPath, backup.Spec.XStore.Name)
+	}
+	return []interface{}{
+		string(backup.UID),
+		backup.Namespace,
+		backup.Name,
+		backup.Labels[polardbxmeta.LabelName],
+		backup.Labels[polardbxmeta.LabelTopBackup],
+		backup.Spec.XStore.Name,
+		string(backup.Status.Phase),
+		string(backup.Status.FailureReason),
+		catalogTime(backup.Status.StartTime),
+		catalogTime(backup.Status.EndTime),
+		catalogTime(backup.Status.EarliestRecoverableTimestamp),
+		catalogTime(backup.Status.BackupSetTimestamp),
+		backup.Status.BackupSize,
+		string(storage.StorageName),
+		destination,
+		checksumManifest,
+		now.UTC(),
+	}
+}
+
+func exportBackupToCatalog(rc *xstorev1reconcile.BackupContext, catalog *polardbxv1.BackupCatalog, backup *polardbxv1.XStoreBackup) error {
+	table := catalog.Table
+	if len(table) == 0 {
+		table = defaultCatalogTable
+	}
+	if !catalogTablePattern.MatchString(table) {
+		return fmt.Errorf("invalid table of catalog: %q", table)
+	}
+	secret, err := rc.GetSecret(catalog.SecretName)
+	if err != nil {
+		return fmt.Errorf("unable to get secret of catalog: %w", err)
+	}
+	username, password := string(secret.Data["username"]), string(secret.Data["password"])
+	if len(username) == 0 {
+		return errors.New("username of catalog not found in secret")
+	}
+
+	db, err := dbutil.OpenMySQLDB(&dbutil.MySQLDataSource{
+		Addr:     catalog.Endpoint,
+		Username: username,
+		Password: password,
+		Database: catalog.Database,
+		Timeout:  catalogTimeout,
+	})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(rc.Context(), catalogTimeout)
+	defer cancel()
+	if _, err := db.ExecContext(ctx, catalogTableDDL(table)); err != nil {
+		return fmt.Errorf("unable to create table of catalog: %w", err)
+	}
+	if _, err := db.ExecContext(ctx, catalogUpsertStatement(table), catalogRowOf(backup, time.Now())...); err != nil {
+		return fmt.Errorf("unable to upsert backup into catalog: %w", err)
+	}
+	return nil
+}
+
+// ExportBackupToCatalog upserts the row of backup into the catalog once the backup is finished or
+// failed. Failed exports are retried with backoff by requeueing the backup like the notifications,
+// it never blocks the following steps.
+var ExportBackupToCatalog = NewStepBinder("ExportBackupToCatalog",
+	func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetXStoreBackup()
+		catalog := backup.Spec.Catalog
+		if catalog == nil {
+			return flow.Pass()
+		}
+
+		if backup.Status.Catalog == nil || backup.Status.Catalog.Phase != backup.Status.Phase {
+			backup.Status.Catalog = &polardbxv1.BackupCatalogStatus{Phase: backup.Status.Phase}
+		}
+		status := backup.Status.Catalog
+		if status.Exported || status.Attempts >= catalogMaxAttempts {
+			return flow.Pass()
+		}
+		now := time.Now()
+		if status.NextAttemptTime != nil && now.Before(status.NextAttemptTime.Time) {
+			left := status.NextAttemptTime.Sub(now)
+			if d := rc.ForceRequeueAfter(); d == 0 || d > left {
+				rc.ResetForceRequeueAfter(left)
+			}
+			return flow.Continue("Export to catalog is pending.", "next-attempt", left)
+		}
+
+		status.Attempts++
+		status.LastAttemptTime = &metav1.Time{Time: now}
+		err := exportBackupToCatalog(rc, catalog, backup)
+		if err == nil {
+			status.Exported = true
+			status.NextAttemptTime = nil
+			status.Message = ""
+			return flow.Continue("Backup exported to catalog.", "endpoint", catalog.Endpoint)
+		}
+		status.Message = err.Error()
+		if status.Attempts >= catalogMaxAttempts {
+			status.NextAttemptTime = nil
+			flow.Logger().Info("Give up exporting backup to catalog.", "error", status.Message)
+			return flow.Continue("Export to catalog given up.")
+		}
+		backoff := notificationBackoff(status.Attempts)
+		status.NextAttemptTime = &metav1.Time{Time: now.Add(backoff)}
+		if d := rc.ForceRequeueAfter(); d == 0 || d > backoff {
+			rc.ResetForceRequeueAfter(backoff)
+		}
+		return flow.Continue("Export to catalog failed, retry later.", "next-attempt", backoff, "error", status.Message)
+	})
@@ -0,0 +1,86 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+)
+
+func TestCatalogUpsertStatement(t *testing.T) {
+	stmt := catalogUpsertStatement("backups")
+	if strings.Count(stmt, "?") != len(catalogColumns) {
+		t.Fatalf("placeholders mismatch: %s", stmt)
+	}
+	if strings.Contains(stmt, "`backup_uid` = VALUES") {
+		t.Fatalf("expect key not updated: %s", stmt)
+	}
+	if !strings.Contains(stmt, "ON DUPLICATE KEY UPDATE `namespace` = VALUES(`namespace`)") {
+		t.Fatalf("expect upsert: %s", stmt)
+	}
+	for _, c := range catalogColumns {
+		if !strings.Contains(catalogTableDDL("backups"), "`"+c+"`") {
+			t.Fatalf("column %s not in schema", c)
+		}
+	}
+}
+
+func TestCatalogRowOf(t *testing.T) {
+	end := metav1.NewTime(time.Date(2022, 8, 1, 10, 0, 0, 0, time.UTC))
+	backup := &polardbxv1.XStoreBackup{}
+	backup.UID = "uid"
+	backup.Name = "pxc-dn-0-backup"
+	backup.Labels = map[string]string{polardbxmeta.LabelName: "pxc", polardbxmeta.LabelTopBackup: "pxc-backup"}
+	backup.Spec.XStore.Name = "pxc-dn-0"
+	backup.Spec.StorageProvider = polardbxv1.BackupStorageProvider{StorageName: "oss", Sink: "default"}
+	backup.Status.Phase = polardbxv1.XStoreBackupFinished
+	backup.Status.EndTime = &end
+	backup.Status.BackupRootPath = "polardbx-backup/pxc-backup"
+	backup.Status.BackupSize = 1024
+
+	row := catalogRowOf(backup, time.Now())
+	if len(row) != len(catalogColumns) {
+		t.Fatalf("row size mismatch: %d", len(row))
+	}
+	expect := map[string]interface{}{
+		"backup_uid":        "uid",
+		"cluster":           "pxc",
+		"top_backup":        "pxc-backup",
+		"status":            "Finished",
+		"backup_size":       int64(1024),
+		"destination":       "default:polardbx-backup/pxc-backup",
+		"checksum_manifest": "",
+		"start_time":        sql.NullTime{},
+		"end_time":          sql.NullTime{Time: end.Time, Valid: true},
+	}
+	for i, c := range catalogColumns {
+		if e, ok := expect[c]; ok && row[i] != e {
+			t.Fatalf("column %s: expect %v, got %v", c, e, row[i])
+		}
+	}
+
+	if catalogTablePattern.MatchString("backups; DROP TABLE x") {
+		t.Fatal("expect table name rejected")
+	}
+}
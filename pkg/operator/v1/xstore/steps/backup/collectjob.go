@@ -19,6 +19,8 @@ package backup
 import (
 	xstorev1 "github.com/alibaba/polardbx-operator/api/v1"
 	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/config"
+	polardbxhelper "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
 	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/command"
 	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
 	batchv1 "k8s.io/api/batch/v1"
@@ -40,7 +42,7 @@ func newCollectJob(xstoreBackup *xstorev1.XStoreBackup, targetPod *corev1.Pod, p
 
 	heartBeatName := polarDBXBackup.Status.HeartBeatName
 	podSpec.Containers[0].Command = command.NewCanonicalCommandBuilder().Collect().
-		StartCollect("/backup/backup", heartBeatName).Build()
+		StartCollect("/backup/backup", heartBeatName, jobName).Build()
 	podSpec.Containers[0].Resources.Limits = nil
 	podSpec.Containers[0].Resources.Requests = nil
 	podSpec.Containers[0].Ports = nil
@@ -58,6 +60,9 @@ func newCollectJob(xstoreBackup *xstorev1.XStoreBackup, targetPod *corev1.Pod, p
 				xstoremeta.JobLabelTargetNodeName: targetPod.Spec.NodeName,
 				xstoremeta.LabelXStoreCollectName: xstoreBackup.Name,
 			},
+			Annotations: map[string]string{
+				xstoremeta.AnnotationOperatorVersion: config.OperatorVersion(),
+			},
 		},
 		Spec: batchv1.JobSpec{
 			BackoffLimit: pointer.Int32(0),
@@ -73,5 +78,6 @@ func newCollectJob(xstoreBackup *xstorev1.XStoreBackup, targetPod *corev1.Pod, p
 			},
 		},
 	}
+	polardbxhelper.ApplyBackupJobTemplate(job, xstoreBackup.Spec.JobTemplate)
 	return job, nil
 }
@@ -0,0 +1,54 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/util/crypt"
+)
+
+// encryptionKeyName returns the key of secret data holding the encryption key.
+func encryptionKeyName(encryption *polardbxv1.BackupEncryption) string {
+	if len(encryption.Key) == 0 {
+		return "key"
+	}
+	return encryption.Key
+}
+
+// checkEncryptionKey checks that the secret holds a valid key of the encryption.
+func checkEncryptionKey(secret *corev1.Secret, encryption *polardbxv1.BackupEncryption) error {
+	key, ok := secret.Data[encryptionKeyName(encryption)]
+	if !ok {
+		return fmt.Errorf("key %q not found in secret %s", encryptionKeyName(encryption), secret.Name)
+	}
+	if _, err := crypt.ParseKey(key); err != nil {
+		return fmt.Errorf("invalid key %q in secret %s: %w", encryptionKeyName(encryption), secret.Name, err)
+	}
+	return nil
+}
+
+// sameEncryption tells whether the backups are encrypted with the same key, or both not encrypted.
+func sameEncryption(a, b *polardbxv1.BackupEncryption) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.SecretName == b.SecretName && encryptionKeyName(a) == encryptionKeyName(b)
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+)
+
+func TestCheckEncryptionKey(t *testing.T) {
+	secret := &corev1.Secret{Data: map[string][]byte{
+		"key":   []byte(hex.EncodeToString([]byte(strings.Repeat("k", 32)))),
+		"short": []byte("short"),
+	}}
+	secret.Name = "backup-key"
+
+	if err := checkEncryptionKey(secret, &polardbxv1.BackupEncryption{SecretName: "backup-key"}); err != nil {
+		t.Fatalf("expect default key valid, got %v", err)
+	}
+	if err := checkEncryptionKey(secret, &polardbxv1.BackupEncryption{SecretName: "backup-key", Key: "short"}); err == nil {
+		t.Fatal("expect short key invalid")
+	}
+	if err := checkEncryptionKey(secret, &polardbxv1.BackupEncryption{SecretName: "backup-key", Key: "missing"}); err == nil {
+		t.Fatal("expect missing key invalid")
+	}
+}
+
+func TestSameEncryption(t *testing.T) {
+	a := &polardbxv1.BackupEncryption{SecretName: "backup-key"}
+	if !sameEncryption(nil, nil) || !sameEncryption(a, &polardbxv1.BackupEncryption{SecretName: "backup-key", Key: "key"}) {
+		t.Fatal("expect same encryption")
+	}
+	if sameEncryption(a, nil) || sameEncryption(a, &polardbxv1.BackupEncryption{SecretName: "another"}) {
+		t.Fatal("expect different encryption")
+	}
+}
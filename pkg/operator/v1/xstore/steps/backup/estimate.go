@@ -0,0 +1,125 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"sort"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+)
+
+// Only the recent backups reflect the current throughput.
+const maxEstimateHistory = 5
+
+// fullBackupDurationOf returns how long the full backup phase of the finished backup took, or zero
+// if unknown.
+func fullBackupDurationOf(backup *polardbxv1.XStoreBackup) time.Duration {
+	for _, t := range backup.Status.PhaseHistory {
+		if t.Phase != polardbxv1.XStoreFullBackuping || len(t.Duration) == 0 {
+			continue
+		}
+		if d, err := time.ParseDuration(t.Duration); err == nil {
+			return d
+		}
+	}
+	// Backups before the phase history, the whole backup is taken instead.
+	if backup.Status.StartTime != nil && backup.Status.EndTime != nil {
+		return backup.Status.EndTime.Sub(backup.Status.StartTime.Time)
+	}
+	return 0
+}
+
+// estimateBackup estimates the size and duration of the full backup. The size is the data size of
+// the xstore, or the size of the last backup if unknown. The duration is estimated by the average
+// throughput of the recent finished backups, zero if there is none.
+func estimateBackup(backup *polardbxv1.XStoreBackup, dataSize int64, backups []polardbxv1.XStoreBackup) (int64, time.Duration) {
+	history := make([]*polardbxv1.XStoreBackup, 0)
+	for i := range backups {
+		b := &backups[i]
+		if b.Name == backup.Name || b.Spec.XStore.Name != backup.Spec.XStore.Name ||
+			b.Status.Phase != polardbxv1.XStoreBackupFinished || b.Spec.CopyFrom != nil ||
+			b.Status.BackupSize <= 0 || b.Status.StartTime == nil {
+			continue
+		}
+		history = append(history, b)
+	}
+	sort.Slice(history, func(i, j int) bool {
+		return history[j].Status.StartTime.Before(history[i].Status.StartTime)
+	})
+	if len(history) > maxEstimateHistory {
+		history = history[:maxEstimateHistory]
+	}
+
+	size := dataSize
+	if size <= 0 && len(history) > 0 {
+		size = history[0].Status.BackupSize
+	}
+
+	var bytes int64
+	var elapsed time.Duration
+	for _, b := range history {
+		if d := fullBackupDurationOf(b); d > 0 {
+			bytes += b.Status.BackupSize
+			elapsed += d
+		}
+	}
+	if size <= 0 || bytes <= 0 {
+		return size, 0
+	}
+	return size, time.Duration(float64(elapsed) * float64(size) / float64(bytes)).Truncate(time.Second)
+}
+
+// EstimateBackupSizeAndDuration records the estimated size and duration of the full backup. It only reads
+// the status of the xstore and the backups, so it's cheap. Failures never block the backup.
+var EstimateBackupSizeAndDuration = NewStepBinder("EstimateBackupSizeAndDuration",
+	func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetXStoreBackup()
+		if backup.Status.EstimatedSizeBytes > 0 {
+			return flow.Pass()
+		}
+		xstore, err := rc.GetXStore()
+		if err != nil {
+			flow.Logger().Error(err, "Unable to get xstore, skip estimating.")
+			return flow.Continue("Backup not estimated.")
+		}
+		// Data of the nodes are almost the same, take the largest.
+		var dataSize int64
+		for _, v := range xstore.Status.BoundVolumes {
+			if v != nil && v.DataSize > dataSize {
+				dataSize = v.DataSize
+			}
+		}
+
+		var backupList polardbxv1.XStoreBackupList
+		if err := rc.Client().List(rc.Context(), &backupList, client.InNamespace(backup.Namespace)); err != nil {
+			flow.Logger().Error(err, "Unable to list backups, skip estimating.")
+			return flow.Continue("Backup not estimated.")
+		}
+
+		size, duration := estimateBackup(backup, dataSize, backupList.Items)
+		backup.Status.EstimatedSizeBytes = size
+		if duration > 0 {
+			backup.Status.EstimatedDuration = duration.String()
+		}
+		return flow.Continue("Backup estimated.", "size", size, "duration", duration)
+	})
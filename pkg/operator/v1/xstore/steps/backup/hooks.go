@@ -0,0 +1,208 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+)
+
+const (
+	defaultHookTimeout = 30 * time.Second
+	// Only the tail of stderr is kept in the status.
+	maxHookStderrLength = 512
+)
+
+func isHookExecuted(backup *polardbxv1.XStoreBackup, stage polardbxv1.BackupHookStage, name string) bool {
+	for _, r := range backup.Status.Hooks {
+		if r.Stage == stage && r.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func tailOf(s string, n int) string {
+	s = strings.TrimSpace(s)
+	if len(s) <= n {
+		return s
+	}
+	return "..." + s[len(s)-n:]
+}
+
+// podsOfHook returns the pods which the command of hook is executed in, sorted by name, along with
+// the container of each.
+func podsOfHook(rc *xstorev1reconcile.BackupContext, hook *polardbxv1.BackupExecHook) ([]corev1.Pod, []string, error) {
+	if hook.Selector == nil {
+		targetPod, err := rc.GetXStoreTargetPod()
+		if err != nil {
+			return nil, nil, err
+		}
+		if targetPod == nil {
+			return nil, nil, errors.New("target pod not found")
+		}
+		container := hook.Container
+		if len(container) == 0 {
+			container = "engine"
+		}
+		return []corev1.Pod{*targetPod}, []string{container}, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(hook.Selector)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid selector of hook %s: %w", hook.Name, err)
+	}
+	var podList corev1.PodList
+	if err := rc.Client().List(rc.Context(), &podList, client.InNamespace(rc.Namespace()),
+		client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, nil, err
+	}
+	pods := podList.Items
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+	containers := make([]string, len(pods))
+	for i := range pods {
+		containers[i] = hook.Container
+		if len(containers[i]) == 0 && len(pods[i].Spec.Containers) > 0 {
+			containers[i] = pods[i].Spec.Containers[0].Name
+		}
+	}
+	return pods, containers, nil
+}
+
+// executeBackupHook executes the command of hook in the pods one by one, and stops at the first
+// failure.
+func executeBackupHook(rc *xstorev1reconcile.BackupContext, flow control.Flow, stage polardbxv1.BackupHookStage,
+	hook *polardbxv1.BackupExecHook) polardbxv1.BackupHookResult {
+	result := polardbxv1.BackupHookResult{
+		Name:       hook.Name,
+		Stage:      stage,
+		ExecutedAt: metav1.Now(),
+	}
+	pods, containers, err := podsOfHook(rc, hook)
+	if err != nil {
+		result.Message = "unable to select pods: " + err.Error()
+		return result
+	}
+	if len(pods) == 0 {
+		result.Message = "no pods selected"
+		return result
+	}
+
+	timeout := hook.Timeout.Duration
+	if timeout <= 0 {
+		timeout = defaultHookTimeout
+	}
+	for i := range pods {
+		pod := &pods[i]
+		result.Pods = append(result.Pods, pod.Name)
+		stderr := &bytes.Buffer{}
+		err := rc.ExecuteCommandOn(pod, containers[i], hook.Command, control.ExecOptions{
+			Logger:  flow.Logger(),
+			Stdout:  &bytes.Buffer{},
+			Stderr:  stderr,
+			Timeout: timeout,
+		})
+		if err != nil {
+			result.Message = fmt.Sprintf("%s/%s: %s, stderr: %s", pod.Name, containers[i], err.Error(),
+				tailOf(stderr.String(), maxHookStderrLength))
+			return result
+		}
+	}
+	result.Succeeded = true
+	return result
+}
+
+// runBackupHooks executes the hooks of the stage which aren't executed yet, in order, and records
+// the results. It returns the first failed one which fails the backup, if any.
+func runBackupHooks(rc *xstorev1reconcile.BackupContext, flow control.Flow, stage polardbxv1.BackupHookStage,
+	hooks []polardbxv1.BackupExecHook) *polardbxv1.BackupHookResult {
+	backup := rc.MustGetXStoreBackup()
+	for i := range hooks {
+		hook := &hooks[i]
+		if isHookExecuted(backup, stage, hook.Name) {
+			continue
+		}
+		result := executeBackupHook(rc, flow, stage, hook)
+		backup.Status.Hooks = append(backup.Status.Hooks, result)
+		flow.Logger().Info("Backup hook executed.", "stage", stage, "hook", hook.Name,
+			"pods", result.Pods, "succeeded", result.Succeeded, "message", result.Message)
+		if !result.Succeeded && hook.OnError != polardbxv1.BackupHookErrorContinue {
+			return &backup.Status.Hooks[len(backup.Status.Hooks)-1]
+		}
+	}
+	return nil
+}
+
+func failBackupOnHookFailure(rc *xstorev1reconcile.BackupContext, flow control.Flow, result *polardbxv1.BackupHookResult) (reconcile.Result, error) {
+	backup := rc.MustGetXStoreBackup()
+	transferPhase(backup, polardbxv1.XStoreBackupFailed, time.Now())
+	backup.Status.FailureReason = polardbxv1.BackupFailureHook
+	backup.Status.Message = fmt.Sprintf("%s hook %s failed: %s", result.Stage, result.Name, result.Message)
+	return flow.Retry("Backup hook failed, backup failed.", "stage", result.Stage, "hook", result.Name)
+}
+
+// RunPreBackupHooks executes the pre backup hooks right before the full backup job is started.
+var RunPreBackupHooks = NewStepBinder("RunPreBackupHooks",
+	func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetXStoreBackup()
+		if backup.Spec.Hooks == nil || len(backup.Spec.Hooks.PreBackup) == 0 {
+			return flow.Pass()
+		}
+		// Hooks are never executed again once the job is started.
+		if job, err := rc.GetXStoreBackupJob(); err == nil && job != nil {
+			return flow.Pass()
+		}
+		if failed := runBackupHooks(rc, flow, polardbxv1.BackupHookPreBackup, backup.Spec.Hooks.PreBackup); failed != nil {
+			return failBackupOnHookFailure(rc, flow, failed)
+		}
+		return flow.Continue("Pre backup hooks executed.")
+	})
+
+// RunPostBackupHooks executes the post backup hooks once the files of backup are all uploaded, or
+// once the backup fails, when the failures never fail the backup again.
+var RunPostBackupHooks = NewStepBinder("RunPostBackupHooks",
+	func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetXStoreBackup()
+		if backup.Spec.Hooks == nil || len(backup.Spec.Hooks.PostBackup) == 0 {
+			return flow.Pass()
+		}
+		failed := runBackupHooks(rc, flow, polardbxv1.BackupHookPostBackup, backup.Spec.Hooks.PostBackup)
+		if backup.Status.Phase == polardbxv1.XStoreBackupFailed {
+			// Execute the rest even if one fails, the backup has failed anyway.
+			for failed != nil {
+				failed = runBackupHooks(rc, flow, polardbxv1.BackupHookPostBackup, backup.Spec.Hooks.PostBackup)
+			}
+			return flow.Continue("Post backup hooks of failed backup executed.")
+		}
+		if failed != nil {
+			return failBackupOnHookFailure(rc, flow, failed)
+		}
+		return flow.Continue("Post backup hooks executed.")
+	})
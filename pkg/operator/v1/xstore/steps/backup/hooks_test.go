@@ -0,0 +1,50 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"strings"
+	"testing"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+)
+
+func TestIsHookExecuted(t *testing.T) {
+	backup := &polardbxv1.XStoreBackup{}
+	backup.Status.Hooks = []polardbxv1.BackupHookResult{
+		{Name: "flush", Stage: polardbxv1.BackupHookPreBackup, Succeeded: true},
+	}
+	if !isHookExecuted(backup, polardbxv1.BackupHookPreBackup, "flush") {
+		t.Fatal("expect pre backup hook executed")
+	}
+	if isHookExecuted(backup, polardbxv1.BackupHookPostBackup, "flush") {
+		t.Fatal("expect hooks of stages isolated")
+	}
+	if isHookExecuted(backup, polardbxv1.BackupHookPreBackup, "notify") {
+		t.Fatal("expect hook not executed")
+	}
+}
+
+func TestTailOf(t *testing.T) {
+	if s := tailOf("  error\n", 10); s != "error" {
+		t.Fatalf("expect trimmed, got %q", s)
+	}
+	long := strings.Repeat("a", 20) + "end"
+	if s := tailOf(long, 3); s != "...end" {
+		t.Fatalf("expect tail kept, got %q", s)
+	}
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+)
+
+// checkIncrementalBase returns why the backup can't be the base of the incremental backup, empty if it can.
+func checkIncrementalBase(backup, base *polardbxv1.XStoreBackup) string {
+	switch {
+	case base.Name == backup.Name:
+		return "backup can't be based on itself"
+	case base.Status.Phase != polardbxv1.XStoreBackupFinished || !base.DeletionTimestamp.IsZero():
+		return fmt.Sprintf("base %s is not finished", base.Name)
+	case base.Spec.XStore.Name != backup.Spec.XStore.Name:
+		return fmt.Sprintf("base %s is of another xstore %s", base.Name, base.Spec.XStore.Name)
+	case base.Spec.StorageProvider.StorageName != backup.Spec.StorageProvider.StorageName ||
+		base.Spec.StorageProvider.Sink != backup.Spec.StorageProvider.Sink:
+		return fmt.Sprintf("base %s is in another storage", base.Name)
+	case !sameEncryption(base.Spec.Encryption, backup.Spec.Encryption):
+		return fmt.Sprintf("base %s is encrypted with another key", base.Name)
+	case base.Spec.CopyFrom != nil:
+		return fmt.Sprintf("base %s is a copy", base.Name)
+	case len(base.Status.ToLsn) == 0:
+		return fmt.Sprintf("LSN of base %s not recorded", base.Name)
+	}
+	return ""
+}
+
+// selectIncrementalBase returns the base of the incremental backup among the backups of the xstore, i.e.
+// the one specified or the latest usable one. The reason is returned if the specified one is unusable.
+func selectIncrementalBase(backup *polardbxv1.XStoreBackup, backups []polardbxv1.XStoreBackup) (*polardbxv1.XStoreBackup, string) {
+	if len(backup.Spec.BaseBackupName) > 0 {
+		for i := range backups {
+			if backups[i].Name == backup.Spec.BaseBackupName {
+				if reason := checkIncrementalBase(backup, &backups[i]); len(reason) > 0 {
+					return nil, reason
+				}
+				return &backups[i], ""
+			}
+		}
+		return nil, fmt.Sprintf("base %s not found", backup.Spec.BaseBackupName)
+	}
+
+	var latest *polardbxv1.XStoreBackup
+	for i := range backups {
+		b := &backups[i]
+		if b.Status.EndTime == nil || len(checkIncrementalBase(backup, b)) > 0 {
+			continue
+		}
+		if latest == nil || latest.Status.EndTime.Before(b.Status.EndTime) {
+			latest = b
+		}
+	}
+	return latest, ""
+}
+
+// incrementalChainOf returns the chain of the incremental backup based on the base, the full backup first.
+func incrementalChainOf(base *polardbxv1.XStoreBackup) []string {
+	chain := make([]string, 0)
+	if base.Status.Incremental != nil {
+		chain = append(chain, base.Status.Incremental.Chain...)
+	}
+	return append(chain, base.Name)
+}
+
+// incrementalBasesOf returns the backups which any of the backups is based on, except the deleting ones.
+func incrementalBasesOf(backups []polardbxv1.XStoreBackup) map[string]bool {
+	bases := make(map[string]bool)
+	for _, b := range backups {
+		if b.Status.Incremental == nil || !b.DeletionTimestamp.IsZero() {
+			continue
+		}
+		for _, name := range b.Status.Incremental.Chain {
+			bases[name] = true
+		}
+	}
+	return bases
+}
+
+// listBackupsOfSameXStore lists the backups of the same xstore as the backup. The label of xstore name
+// is missing on the backups of pxc backups, so they're filtered by spec.
+func listBackupsOfSameXStore(rc *xstorev1reconcile.BackupContext, backup *polardbxv1.XStoreBackup) ([]polardbxv1.XStoreBackup, error) {
+	var backupList polardbxv1.XStoreBackupList
+	if err := rc.Client().List(rc.Context(), &backupList, client.InNamespace(backup.Namespace)); err != nil {
+		return nil, err
+	}
+	backups := make([]polardbxv1.XStoreBackup, 0)
+	for _, b := range backupList.Items {
+		if b.Spec.XStore.Name == backup.Spec.XStore.Name {
+			backups = append(backups, b)
+		}
+	}
+	return backups, nil
+}
+
+// isIncrementalBaseOfOthers tells whether any other backup of the same xstore is based on the backup.
+func isIncrementalBaseOfOthers(rc *xstorev1reconcile.BackupContext, backup *polardbxv1.XStoreBackup) (bool, error) {
+	backups, err := listBackupsOfSameXStore(rc, backup)
+	if err != nil {
+		return false, err
+	}
+	return incrementalBasesOf(backups)[backup.Name], nil
+}
+
+// parseXtrabackupCheckpoints parses the xtrabackup_checkpoints written by xtrabackup, e.g. "to_lsn = 1234".
+func parseXtrabackupCheckpoints(content string) map[string]string {
+	checkpoints := make(map[string]string)
+	for _, line := range strings.Split(content, "\n") {
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		checkpoints[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return checkpoints
+}
+
+// PrepareIncrementalBackup determines the base of the incremental backup before the backup config map is
+// created, so that the full backup job copies the pages changed since the base only. It falls back to the
+// full backup if no base is found, and fails the backup if the specified base is unusable.
+var PrepareIncrementalBackup = NewStepBinder("PrepareIncrementalBackup",
+	func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetXStoreBackup()
+		if len(backup.Status.BackupType) > 0 {
+			return flow.Pass()
+		}
+		if backup.Spec.BackupType != polardbxv1.BackupTypeIncremental {
+			backup.Status.BackupType = polardbxv1.BackupTypeFull
+			return flow.Continue("Full backup.")
+		}
+
+		backups, err := listBackupsOfSameXStore(rc, backup)
+		if err != nil {
+			return flow.Error(err, "Unable to list backups of the xstore.")
+		}
+		base, reason := selectIncrementalBase(backup, backups)
+		if len(reason) > 0 {
+			msg := "Incremental backup is unable to start: " + reason
+			transferPhase(backup, polardbxv1.XStoreBackupFailed, time.Now())
+			backup.Status.FailureReason = polardbxv1.BackupFailureIncrementalBase
+			backup.Status.Message = msg
+			return flow.Retry(msg)
+		}
+		if base == nil {
+			backup.Status.BackupType = polardbxv1.BackupTypeFull
+			return flow.Continue("No base found, fall back to full backup.")
+		}
+
+		backup.Status.BackupType = polardbxv1.BackupTypeIncremental
+		backup.Status.Incremental = &polardbxv1.IncrementalBackupStatus{
+			BaseBackup: base.Name,
+			FromLsn:    base.Status.ToLsn,
+			Chain:      incrementalChainOf(base),
+		}
+		return flow.Continue("Incremental backup based on the base.", "base", base.Name, "from-lsn", base.Status.ToLsn)
+	})
+
+func collectBackupLsn(rc *xstorev1reconcile.BackupContext, flow control.Flow, targetPod *corev1.Pod, jobName string, xstoreBackup *polardbxv1.XStoreBackup) error {
+	output, found, err := catFileOnPod(rc, flow, targetPod, "/data/mysql/tmp/"+jobName+".lsn/xtrabackup_checkpoints")
+	if err != nil || !found {
+		return err
+	}
+	xstoreBackup.Status.ToLsn = parseXtrabackupCheckpoints(output)["to_lsn"]
+	return nil
+}
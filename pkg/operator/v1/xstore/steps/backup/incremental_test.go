@@ -0,0 +1,115 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+)
+
+func TestSelectIncrementalBase(t *testing.T) {
+	now := time.Now()
+	newBackup := func(name string, endedAgo time.Duration, toLsn string) polardbxv1.XStoreBackup {
+		b := polardbxv1.XStoreBackup{}
+		b.Name = name
+		b.Spec.XStore.Name = "dn-0"
+		b.Spec.StorageProvider.StorageName = polardbxv1.OSS
+		b.Status.Phase = polardbxv1.XStoreBackupFinished
+		b.Status.EndTime = &metav1.Time{Time: now.Add(-endedAgo)}
+		b.Status.ToLsn = toLsn
+		return b
+	}
+	backup := newBackup("inc", 0, "")
+	backup.Status.Phase = polardbxv1.XStoreBackupNew
+	backup.Status.EndTime = nil
+
+	latestWithoutLsn := newBackup("no-lsn", time.Minute, "")
+	otherStorage := newBackup("other-storage", 2*time.Minute, "300")
+	otherStorage.Spec.StorageProvider.StorageName = polardbxv1.SFTP
+	backups := []polardbxv1.XStoreBackup{
+		newBackup("old", 2*time.Hour, "100"),
+		newBackup("latest", time.Hour, "200"),
+		latestWithoutLsn,
+		otherStorage,
+		backup,
+	}
+
+	base, reason := selectIncrementalBase(&backup, backups)
+	if len(reason) > 0 || base == nil || base.Name != "latest" {
+		t.Fatalf("expect the latest usable base, got %v, reason: %s", base, reason)
+	}
+
+	backup.Spec.BaseBackupName = "old"
+	if base, reason := selectIncrementalBase(&backup, backups); base == nil || base.Name != "old" {
+		t.Fatalf("expect the specified base, got %v, reason: %s", base, reason)
+	}
+	backup.Spec.BaseBackupName = "no-lsn"
+	if base, reason := selectIncrementalBase(&backup, backups); base != nil || len(reason) == 0 {
+		t.Fatalf("expect base without LSN unusable, got %v", base)
+	}
+	backup.Spec.BaseBackupName = "latest"
+	backup.Spec.Encryption = &polardbxv1.BackupEncryption{SecretName: "backup-key"}
+	if base, reason := selectIncrementalBase(&backup, backups); base != nil || len(reason) == 0 {
+		t.Fatalf("expect base encrypted with another key unusable, got %v", base)
+	}
+	backup.Spec.Encryption = nil
+	backup.Spec.BaseBackupName = "missing"
+	if base, reason := selectIncrementalBase(&backup, backups); base != nil || len(reason) == 0 {
+		t.Fatalf("expect missing base unusable, got %v", base)
+	}
+
+	backup.Spec.BaseBackupName = ""
+	if base, reason := selectIncrementalBase(&backup, backups[2:]); base != nil || len(reason) > 0 {
+		t.Fatalf("expect no base found, got %v, reason: %s", base, reason)
+	}
+}
+
+func TestIncrementalChain(t *testing.T) {
+	full := &polardbxv1.XStoreBackup{}
+	full.Name = "full"
+	inc1 := polardbxv1.XStoreBackup{}
+	inc1.Name = "inc-1"
+	inc1.Status.Incremental = &polardbxv1.IncrementalBackupStatus{BaseBackup: "full", Chain: incrementalChainOf(full)}
+	inc2 := polardbxv1.XStoreBackup{}
+	inc2.Name = "inc-2"
+	inc2.Status.Incremental = &polardbxv1.IncrementalBackupStatus{BaseBackup: "inc-1", Chain: incrementalChainOf(&inc1)}
+
+	if chain := inc2.Status.Incremental.Chain; len(chain) != 2 || chain[0] != "full" || chain[1] != "inc-1" {
+		t.Fatalf("unexpected chain: %v", chain)
+	}
+	bases := incrementalBasesOf([]polardbxv1.XStoreBackup{*full, inc1, inc2})
+	if !bases["full"] || !bases["inc-1"] || bases["inc-2"] {
+		t.Fatalf("unexpected bases: %v", bases)
+	}
+
+	deleting := metav1.Now()
+	inc2.DeletionTimestamp = &deleting
+	if bases := incrementalBasesOf([]polardbxv1.XStoreBackup{*full, inc2}); len(bases) != 0 {
+		t.Fatalf("expect bases of deleting backups released, got %v", bases)
+	}
+}
+
+func TestParseXtrabackupCheckpoints(t *testing.T) {
+	checkpoints := parseXtrabackupCheckpoints("backup_type = incremental\nfrom_lsn = 100\nto_lsn = 2048\n\n")
+	if checkpoints["backup_type"] != "incremental" || checkpoints["from_lsn"] != "100" || checkpoints["to_lsn"] != "2048" {
+		t.Fatalf("unexpected checkpoints: %v", checkpoints)
+	}
+}
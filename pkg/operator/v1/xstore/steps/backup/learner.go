@@ -0,0 +1,213 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+)
+
+// The learner is considered caught up if the lag is within, unless the max follower lag is specified.
+const ephemeralLearnerMaxLag = 10 * time.Second
+
+// newEphemeralLearner builds a readonly xstore with a single learner of the xstore. The learner
+// takes the template of candidates, and it's owned by the backup so that it won't be left behind.
+func newEphemeralLearner(xstore *polardbxv1.XStore, name string) *polardbxv1.XStore {
+	spec := xstore.Spec.DeepCopy()
+	spec.Readonly = true
+	spec.PrimaryXStore = xstore.Name
+	spec.Restore = nil
+	spec.ServiceType = corev1.ServiceTypeClusterIP
+
+	var template *polardbxv1xstore.NodeTemplate
+	for _, nodeSet := range spec.Topology.NodeSets {
+		if nodeSet.Role == polardbxv1xstore.RoleCandidate {
+			template = nodeSet.Template
+			break
+		}
+	}
+	spec.Topology.NodeSets = []polardbxv1xstore.NodeSet{
+		{
+			Name:     "learner",
+			Role:     polardbxv1xstore.RoleLearner,
+			Replicas: 1,
+			Template: template,
+		},
+	}
+
+	return &polardbxv1.XStore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: xstore.Namespace,
+			Labels: map[string]string{
+				xstoremeta.LabelPrimaryName: xstore.Name,
+			},
+			Annotations: map[string]string{
+				// Set to empty rand to avoid long xstore/pod names.
+				xstoremeta.AnnotationGuideRand: "",
+			},
+		},
+		Spec: *spec,
+	}
+}
+
+func getEphemeralLearner(rc *xstorev1reconcile.BackupContext) (*polardbxv1.XStore, error) {
+	backup := rc.MustGetXStoreBackup()
+	var learner polardbxv1.XStore
+	err := rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: rc.Namespace(), Name: backup.Status.EphemeralLearner}, &learner)
+	if err != nil {
+		return nil, err
+	}
+	return &learner, nil
+}
+
+// ephemeralLearnerNameOf returns the name of the learner of the backup. It's stable, so that
+// the learner is never created twice.
+func ephemeralLearnerNameOf(xstore *polardbxv1.XStore, backup *polardbxv1.XStoreBackup) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(backup.UID))
+	return fmt.Sprintf("%s-bl%s", xstore.Name, rand.SafeEncodeString(fmt.Sprintf("%x", h.Sum32()))[:4])
+}
+
+// ProvisionEphemeralLearner creates the learner to back up from if required.
+var ProvisionEphemeralLearner = NewStepBinder("ProvisionEphemeralLearner",
+	func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetXStoreBackup()
+		if !backup.Spec.EphemeralLearner || len(backup.Status.EphemeralLearner) > 0 {
+			return flow.Pass()
+		}
+
+		xstore, err := rc.GetXStore()
+		if err != nil {
+			return flow.Error(err, "Unable to get xstore")
+		}
+		learner := newEphemeralLearner(xstore, ephemeralLearnerNameOf(xstore, backup))
+		if err := rc.SetControllerRefAndCreate(learner); err != nil {
+			if !apierrors.IsAlreadyExists(err) {
+				return flow.Error(err, "Unable to create ephemeral learner", "learner", learner.Name)
+			}
+			// Created in a previous reconcile whose status failed to persist, adopt it if it's ours.
+			existing := &polardbxv1.XStore{}
+			if err := rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: rc.Namespace(), Name: learner.Name}, existing); err != nil {
+				return flow.Error(err, "Unable to get ephemeral learner", "learner", learner.Name)
+			}
+			if owner := metav1.GetControllerOf(existing); owner == nil || owner.UID != backup.UID {
+				return flow.Error(err, "Ephemeral learner exists but not owned by the backup", "learner", learner.Name)
+			}
+		}
+		// The learner is owned by the backup, it is removed along with the backup even if the status is lost.
+		backup.Status.EphemeralLearner = learner.Name
+		return flow.Continue("Ephemeral learner created.", "learner", learner.Name)
+	})
+
+// WaitEphemeralLearnerReady waits until the learner is running and caught up, and takes the
+// learner pod as the target pod.
+var WaitEphemeralLearnerReady = NewStepBinder("WaitEphemeralLearnerReady",
+	func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetXStoreBackup()
+		if len(backup.Status.EphemeralLearner) == 0 || len(backup.Status.TargetPod) > 0 {
+			return flow.Pass()
+		}
+
+		learner, err := getEphemeralLearner(rc)
+		if err != nil {
+			return flow.Error(err, "Unable to get ephemeral learner", "learner", backup.Status.EphemeralLearner)
+		}
+		if learner.Status.Phase != polardbxv1xstore.PhaseRunning {
+			return retryWithBackoff(rc, flow, "WaitEphemeralLearnerReady", string(learner.Status.Phase),
+				"Ephemeral learner is not running, wait and retry", "learner", learner.Name)
+		}
+
+		var podList corev1.PodList
+		err = rc.Client().List(rc.Context(), &podList, client.InNamespace(rc.Namespace()), client.MatchingLabels{
+			xstoremeta.LabelName: learner.Name,
+			xstoremeta.LabelRole: xstoremeta.RoleLearner,
+		})
+		if err != nil {
+			return flow.Error(err, "Unable to list pods of ephemeral learner", "learner", learner.Name)
+		}
+		if len(podList.Items) == 0 {
+			return retryWithBackoff(rc, flow, "WaitEphemeralLearnerReady", "",
+				"Pod of ephemeral learner not found, wait and retry", "learner", learner.Name)
+		}
+		pod := &podList.Items[0]
+
+		manager, err := rc.GetXstoreGroupManagerByPod(pod)
+		if err != nil {
+			return flow.RetryErr(err, "Unable to connect to ephemeral learner", "pod", pod.Name)
+		}
+		if manager == nil {
+			return retryWithBackoff(rc, flow, "WaitEphemeralLearnerReady", "connecting",
+				"Unable to connect to ephemeral learner", "pod", pod.Name)
+		}
+		slaveStatus, err := manager.ShowSlaveStatus()
+		if err != nil {
+			return flow.RetryErr(err, "Unable to show slave status", "pod", pod.Name)
+		}
+		maxLag := backup.Spec.MaxFollowerLag.Duration
+		if maxLag <= 0 {
+			maxLag = ephemeralLearnerMaxLag
+		}
+		if slaveStatus == nil || slaveStatus.SecondsBehindMaster == nil ||
+			time.Duration(*slaveStatus.SecondsBehindMaster)*time.Second > maxLag {
+			return retryWithBackoff(rc, flow, "WaitEphemeralLearnerReady", "running",
+				"Ephemeral learner is catching up, wait and retry", "pod", pod.Name)
+		}
+		resetBackoff(rc, "WaitEphemeralLearnerReady")
+
+		backup.Status.TargetPod = pod.Name
+		backup.Status.SourceLag = (time.Duration(*slaveStatus.SecondsBehindMaster) * time.Second).String()
+		return flow.Continue("Ephemeral learner is ready.", "pod", pod.Name)
+	})
+
+// RemoveEphemeralLearner tears down the learner once the backup finishes or fails.
+var RemoveEphemeralLearner = NewStepBinder("RemoveEphemeralLearner",
+	func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetXStoreBackup()
+		if len(backup.Status.EphemeralLearner) == 0 {
+			return flow.Pass()
+		}
+
+		learner, err := getEphemeralLearner(rc)
+		if apierrors.IsNotFound(err) {
+			return flow.Continue("Ephemeral learner already removed.", "learner", backup.Status.EphemeralLearner)
+		}
+		if err != nil {
+			return flow.Error(err, "Unable to get ephemeral learner", "learner", backup.Status.EphemeralLearner)
+		}
+		if learner.DeletionTimestamp.IsZero() {
+			if err := rc.Client().Delete(rc.Context(), learner); client.IgnoreNotFound(err) != nil {
+				return flow.Error(err, "Unable to remove ephemeral learner", "learner", learner.Name)
+			}
+		}
+		return flow.Continue("Ephemeral learner removed.", "learner", learner.Name)
+	})
@@ -0,0 +1,53 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/metrics"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+)
+
+// ObserveBackupMetrics observes the duration and size of the backup once finished, or counts the
+// failure once failed. Copied backups aren't observed since nothing is backed up.
+var ObserveBackupMetrics = NewStepBinder("ObserveBackupMetrics",
+	func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetXStoreBackup()
+		phase := backup.Status.Phase
+		history := backup.Status.PhaseHistory
+		if backup.Spec.CopyFrom != nil || len(history) == 0 || history[len(history)-1].Phase != phase {
+			return flow.Pass()
+		}
+		endTime := history[len(history)-1].EnteredAt.Time
+
+		switch phase {
+		case polardbxv1.XStoreBackupFinished:
+			startTime := backup.CreationTimestamp.Time
+			if backup.Status.StartTime != nil {
+				startTime = backup.Status.StartTime.Time
+			}
+			metrics.ObserveBackupFinished(string(backup.UID), backup.Namespace, backup.Spec.XStore.Name,
+				endTime, endTime.Sub(startTime), backup.Status.BackupSize)
+		case polardbxv1.XStoreBackupFailed:
+			metrics.ObserveBackupFailed(string(backup.UID), backup.Namespace, backup.Spec.XStore.Name,
+				string(backup.Status.FailureReason), endTime)
+		}
+		return flow.Pass()
+	})
@@ -0,0 +1,259 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"text/template"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+	"github.com/alibaba/polardbx-operator/pkg/util/network"
+)
+
+const (
+	notificationTimeout        = 5 * time.Second
+	notificationBudget         = 10 * time.Second
+	notificationMaxAttempts    = 10
+	notificationInitialBackoff = 10 * time.Second
+	notificationMaxBackoff     = 30 * time.Minute
+)
+
+// notificationClient only connects to public addresses and never follows redirects, so that the
+// webhooks can't be used to reach the services inside the cluster or the metadata of nodes. Proxies
+// of the environment are never used, otherwise the address checked would be the proxy's.
+var notificationClient = &http.Client{
+	Timeout: notificationTimeout,
+	Transport: &http.Transport{
+		Proxy: nil,
+		DialContext: (&net.Dialer{
+			Timeout: notificationTimeout,
+			Control: network.PublicOnlyControl,
+		}).DialContext,
+		TLSHandshakeTimeout:   notificationTimeout,
+		ResponseHeaderTimeout: notificationTimeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       time.Minute,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// backupNotificationPayload is the data rendered by the payload template.
+type backupNotificationPayload struct {
+	Name               string `json:"name"`
+	Namespace          string `json:"namespace"`
+	XStore             string `json:"xstore"`
+	Phase              string `json:"phase"`
+	StartTime          string `json:"startTime,omitempty"`
+	EndTime            string `json:"endTime,omitempty"`
+	BackupSetTimestamp string `json:"backupSetTimestamp,omitempty"`
+	BackupSize         int64  `json:"backupSize,omitempty"`
+	BackupRootPath     string `json:"backupRootPath,omitempty"`
+	FailureReason      string `json:"failureReason,omitempty"`
+	Message            string `json:"message,omitempty"`
+}
+
+func formatNotificationTime(t *metav1.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func newBackupNotificationPayload(backup *polardbxv1.XStoreBackup) *backupNotificationPayload {
+	return &backupNotificationPayload{
+		Name:               backup.Name,
+		Namespace:          backup.Namespace,
+		XStore:             backup.Spec.XStore.Name,
+		Phase:              string(backup.Status.Phase),
+		StartTime:          formatNotificationTime(backup.Status.StartTime),
+		EndTime:            formatNotificationTime(backup.Status.EndTime),
+		BackupSetTimestamp: formatNotificationTime(backup.Status.BackupSetTimestamp),
+		BackupSize:         backup.Status.BackupSize,
+		BackupRootPath:     backup.Status.BackupRootPath,
+		FailureReason:      string(backup.Status.FailureReason),
+		Message:            backup.Status.Message,
+	}
+}
+
+func renderBackupNotification(notification *polardbxv1.BackupNotification, payload *backupNotificationPayload) ([]byte, error) {
+	if len(notification.PayloadTemplate) == 0 {
+		return json.Marshal(payload)
+	}
+	tmpl, err := template.New(notification.Name).Option("missingkey=error").Parse(notification.PayloadTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload template: %w", err)
+	}
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, payload); err != nil {
+		return nil, fmt.Errorf("invalid payload template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// notificationBackoff returns the interval before the next attempt, which is doubled on each
+// failed attempt and capped.
+func notificationBackoff(attempts int32) time.Duration {
+	d := notificationInitialBackoff
+	for i := int32(1); i < attempts && d < notificationMaxBackoff; i++ {
+		d *= 2
+	}
+	if d > notificationMaxBackoff {
+		d = notificationMaxBackoff
+	}
+	return d
+}
+
+// notificationIdempotencyKeyOf returns the key identifying the notification of the backup outcome.
+// The delivery is recorded in status only, so it may be repeated if the status fails to persist,
+// the receivers are able to deduplicate with the key.
+func notificationIdempotencyKeyOf(backup *polardbxv1.XStoreBackup, name string) string {
+	return string(backup.UID) + "/" + string(backup.Status.Phase) + "/" + name
+}
+
+func deliverBackupNotification(ctx context.Context, notification *polardbxv1.BackupNotification, key string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, notification.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	contentType := notification.ContentType
+	if len(contentType) == 0 {
+		contentType = "application/json"
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Idempotency-Key", key)
+	resp, err := notificationClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status of webhook: %s", resp.Status)
+	}
+	return nil
+}
+
+func notificationStatusOf(backup *polardbxv1.XStoreBackup, name string) *polardbxv1.BackupNotificationStatus {
+	for i := range backup.Status.Notifications {
+		if backup.Status.Notifications[i].Name == name {
+			return &backup.Status.Notifications[i]
+		}
+	}
+	backup.Status.Notifications = append(backup.Status.Notifications, polardbxv1.BackupNotificationStatus{Name: name})
+	return &backup.Status.Notifications[len(backup.Status.Notifications)-1]
+}
+
+// NotifyBackupOutcome calls the notification channels once the backup is finished or failed. Failed
+// deliveries are retried with backoff by requeueing the backup, it never blocks the following steps.
+// Deliveries in a reconciliation are bounded by notificationBudget, the rest are deferred.
+var NotifyBackupOutcome = NewStepBinder("NotifyBackupOutcome",
+	func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetXStoreBackup()
+		if len(backup.Spec.Notifications) == 0 {
+			return flow.Pass()
+		}
+
+		payload := newBackupNotificationPayload(backup)
+		now := time.Now()
+		ctx, cancel := context.WithTimeout(rc.Context(), notificationBudget)
+		defer cancel()
+		attempted := false
+		var nextAttempt time.Duration
+		for i := range backup.Spec.Notifications {
+			notification := &backup.Spec.Notifications[i]
+			status := notificationStatusOf(backup, notification.Name)
+			if status.Phase != backup.Status.Phase {
+				*status = polardbxv1.BackupNotificationStatus{Name: notification.Name, Phase: backup.Status.Phase}
+			}
+			if status.Delivered || status.Attempts >= notificationMaxAttempts {
+				continue
+			}
+			if status.NextAttemptTime != nil && now.Before(status.NextAttemptTime.Time) {
+				if left := status.NextAttemptTime.Sub(now); nextAttempt == 0 || left < nextAttempt {
+					nextAttempt = left
+				}
+				continue
+			}
+
+			if ctx.Err() != nil {
+				// Out of budget, try again soon.
+				if nextAttempt == 0 || notificationInitialBackoff < nextAttempt {
+					nextAttempt = notificationInitialBackoff
+				}
+				continue
+			}
+
+			attempted = true
+			body, err := renderBackupNotification(notification, payload)
+			if err == nil {
+				if urlErr := network.ValidatePublicURL(notification.Webhook); urlErr != nil {
+					err = fmt.Errorf("invalid webhook: %w", urlErr)
+				}
+			}
+			if err != nil {
+				// Retrying never helps.
+				status.Attempts = notificationMaxAttempts
+				status.NextAttemptTime = nil
+				status.Message = err.Error()
+				continue
+			}
+			status.Attempts++
+			status.LastAttemptTime = &metav1.Time{Time: now}
+			err = deliverBackupNotification(ctx, notification,
+				notificationIdempotencyKeyOf(backup, notification.Name), body)
+			if err == nil {
+				status.Delivered = true
+				status.NextAttemptTime = nil
+				status.Message = ""
+				continue
+			}
+			status.Message = err.Error()
+			if status.Attempts >= notificationMaxAttempts {
+				status.NextAttemptTime = nil
+				flow.Logger().Info("Give up notifying backup outcome.", "notification", notification.Name, "error", status.Message)
+				continue
+			}
+			backoff := notificationBackoff(status.Attempts)
+			status.NextAttemptTime = &metav1.Time{Time: now.Add(backoff)}
+			if nextAttempt == 0 || backoff < nextAttempt {
+				nextAttempt = backoff
+			}
+		}
+
+		if nextAttempt > 0 {
+			if d := rc.ForceRequeueAfter(); d == 0 || d > nextAttempt {
+				rc.ResetForceRequeueAfter(nextAttempt)
+			}
+			return flow.Continue("Some notifications are pending.", "next-attempt", nextAttempt)
+		}
+		if !attempted {
+			return flow.Pass()
+		}
+		return flow.Continue("Notifications processed.")
+	})
@@ -0,0 +1,75 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+)
+
+func TestRenderBackupNotification(t *testing.T) {
+	payload := &backupNotificationPayload{Name: "xs-backup", XStore: "xs", Phase: string(polardbxv1.XStoreBackupFailed), Message: "job failed"}
+
+	body, err := renderBackupNotification(&polardbxv1.BackupNotification{Name: "default"}, payload)
+	if err != nil || string(body) != `{"name":"xs-backup","namespace":"","xstore":"xs","phase":"Failed","message":"job failed"}` {
+		t.Fatalf("unexpected default payload: %s, %v", body, err)
+	}
+
+	body, err = renderBackupNotification(&polardbxv1.BackupNotification{
+		Name:            "slack",
+		PayloadTemplate: `{"text": "Backup {{ .Name }} of {{ .XStore }} {{ .Phase }}: {{ .Message }}"}`,
+	}, payload)
+	if err != nil || string(body) != `{"text": "Backup xs-backup of xs Failed: job failed"}` {
+		t.Fatalf("unexpected templated payload: %s, %v", body, err)
+	}
+
+	if _, err := renderBackupNotification(&polardbxv1.BackupNotification{Name: "bad", PayloadTemplate: "{{ .Unknown }}"}, payload); err == nil {
+		t.Fatal("expect error on unknown field")
+	}
+}
+
+func TestNotificationBackoff(t *testing.T) {
+	expected := []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second}
+	for i, e := range expected {
+		if d := notificationBackoff(int32(i + 1)); d != e {
+			t.Fatalf("attempt %d: expect %s, got %s", i+1, e, d)
+		}
+	}
+	if d := notificationBackoff(20); d != notificationMaxBackoff {
+		t.Fatalf("expect capped, got %s", d)
+	}
+}
+
+func TestDeliverBackupNotificationBehindProxy(t *testing.T) {
+	// The proxy is public, the webhook must still be refused for its private address.
+	t.Setenv("HTTPS_PROXY", "http://8.8.8.8:3128")
+	t.Setenv("HTTP_PROXY", "http://8.8.8.8:3128")
+
+	for _, webhook := range []string{"https://10.0.0.1/hook", "http://169.254.169.254/latest/meta-data"} {
+		err := deliverBackupNotification(context.Background(), &polardbxv1.BackupNotification{
+			Name:    "default",
+			Webhook: webhook,
+		}, "key", []byte("{}"))
+		if err == nil || !strings.Contains(err.Error(), "is not public") {
+			t.Errorf("expect webhook %s refused, got %v", webhook, err)
+		}
+	}
+}
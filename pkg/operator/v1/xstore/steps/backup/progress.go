@@ -0,0 +1,144 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+)
+
+// BackupProgressRefreshInterval is the interval to refresh the progress of the running backup jobs,
+// since there's no event of the jobs until they finish.
+const BackupProgressRefreshInterval = 30 * time.Second
+
+// jobProgress is the progress reported by the backup job.
+type jobProgress struct {
+	BytesTransferred    int64 `json:"bytesTransferred"`
+	EstimatedTotalBytes int64 `json:"estimatedTotalBytes"`
+	StartTime           int64 `json:"startTime"`
+	UpdateTime          int64 `json:"updateTime"`
+}
+
+func formatThroughput(bytesPerSecond float64) string {
+	return fmt.Sprintf("%.1fMiB/s", bytesPerSecond/(1<<20))
+}
+
+// backupProgressOf computes the progress of backup from the one reported by the job. The estimated
+// total reported by the job is preferred to the given one. The percent is capped at 99 until the
+// job completes, since the total is only an estimation.
+func backupProgressOf(p jobProgress, estimatedTotal int64, completed bool) *polardbxv1.BackupProgress {
+	if p.EstimatedTotalBytes > 0 {
+		estimatedTotal = p.EstimatedTotalBytes
+	}
+	if completed {
+		estimatedTotal = p.BytesTransferred
+	}
+	progress := &polardbxv1.BackupProgress{
+		BytesTransferred:    p.BytesTransferred,
+		EstimatedTotalBytes: estimatedTotal,
+	}
+	if elapsed := p.UpdateTime - p.StartTime; elapsed > 0 {
+		progress.Throughput = formatThroughput(float64(p.BytesTransferred) / float64(elapsed))
+	}
+	if p.UpdateTime > 0 {
+		updateTime := metav1.Unix(p.UpdateTime, 0)
+		progress.UpdateTime = &updateTime
+	}
+	if completed {
+		percent := int32(100)
+		progress.Percent = &percent
+	} else if estimatedTotal > 0 {
+		percent := int32(p.BytesTransferred * 100 / estimatedTotal)
+		if percent > 99 {
+			percent = 99
+		}
+		progress.Percent = &percent
+	}
+	return progress
+}
+
+// readBackupProgress reads the progress reported by the job at the path on the pod. It's nil if the
+// job hasn't reported yet, or the one found is reported before the job, e.g. by the job of last backup.
+func readBackupProgress(rc *xstorev1reconcile.BackupContext, flow control.Flow, pod *corev1.Pod, path string,
+	job *batchv1.Job, estimatedTotal int64) (*polardbxv1.BackupProgress, error) {
+	output, found, err := catFileOnPod(rc, flow, pod, path)
+	if err != nil || !found {
+		return nil, err
+	}
+	var p jobProgress
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output)), &p); err != nil {
+		return nil, fmt.Errorf("invalid backup progress: %w", err)
+	}
+	if p.UpdateTime < job.CreationTimestamp.Unix() {
+		return nil, nil
+	}
+	return backupProgressOf(p, estimatedTotal, k8shelper.IsJobCompleted(job)), nil
+}
+
+// refreshFullBackupProgress refreshes the progress of the full backup job. It's only a measurement,
+// never fail the backup for it.
+func refreshFullBackupProgress(rc *xstorev1reconcile.BackupContext, flow control.Flow, pod *corev1.Pod,
+	job *batchv1.Job, backup *polardbxv1.XStoreBackup) {
+	progress, err := readBackupProgress(rc, flow, pod, "/data/mysql/tmp/"+job.Name+".progress", job,
+		backup.Status.EstimatedSizeBytes)
+	if err != nil {
+		flow.Logger().Error(err, "Unable to read full backup progress", "pod", pod.Name)
+		return
+	}
+	if progress != nil {
+		backup.Status.FullBackupProgress = progress
+	}
+}
+
+// refreshBinlogBackupProgress refreshes the progress of the binlog backup job, whose estimated total
+// is reported by the job.
+func refreshBinlogBackupProgress(rc *xstorev1reconcile.BackupContext, flow control.Flow, pod *corev1.Pod,
+	job *batchv1.Job, backup *polardbxv1.XStoreBackup) {
+	progress, err := readBackupProgress(rc, flow, pod, "/data/mysql/backup/binlogbackup/progress", job, 0)
+	if err != nil {
+		flow.Logger().Error(err, "Unable to read binlog backup progress", "pod", pod.Name)
+		return
+	}
+	if progress != nil {
+		backup.Status.BinlogBackupProgress = progress
+	}
+}
+
+// refreshJobProgressOnTargetPod refreshes the progress of the running job with the refresh func, and
+// requeues to refresh it later.
+func refreshJobProgressOnTargetPod(rc *xstorev1reconcile.BackupContext, flow control.Flow, job *batchv1.Job,
+	refresh func(*xstorev1reconcile.BackupContext, control.Flow, *corev1.Pod, *batchv1.Job, *polardbxv1.XStoreBackup),
+	msg string) (reconcile.Result, error) {
+	if targetPod, err := rc.GetXStoreTargetPod(); err != nil {
+		flow.Logger().Error(err, "Unable to get targetPod")
+	} else {
+		refresh(rc, flow, targetPod, job, rc.MustGetXStoreBackup())
+	}
+	return flow.RetryAfter(BackupProgressRefreshInterval, msg, "job-name", job.Name)
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import "testing"
+
+func TestBackupProgressOf(t *testing.T) {
+	p := jobProgress{
+		BytesTransferred: 300 << 20,
+		StartTime:        1000,
+		UpdateTime:       1100,
+	}
+
+	progress := backupProgressOf(p, 1200<<20, false)
+	if progress.Percent == nil || *progress.Percent != 25 {
+		t.Fatalf("expect 25 percent, got %v", progress.Percent)
+	}
+	if progress.Throughput != "3.0MiB/s" {
+		t.Fatalf("expect 3.0MiB/s, got %s", progress.Throughput)
+	}
+	if progress.UpdateTime == nil || progress.UpdateTime.Unix() != 1100 {
+		t.Fatalf("expect update time reported, got %v", progress.UpdateTime)
+	}
+
+	if progress := backupProgressOf(p, 0, false); progress.Percent != nil {
+		t.Fatalf("expect no percent without estimated total, got %d", *progress.Percent)
+	}
+	if progress := backupProgressOf(p, 200<<20, false); *progress.Percent != 99 {
+		t.Fatalf("expect percent capped before completed, got %d", *progress.Percent)
+	}
+
+	p.EstimatedTotalBytes = 600 << 20
+	if progress := backupProgressOf(p, 1200<<20, false); *progress.Percent != 50 || progress.EstimatedTotalBytes != 600<<20 {
+		t.Fatalf("expect estimated total of job preferred, got %+v", progress)
+	}
+	if progress := backupProgressOf(p, 1200<<20, true); *progress.Percent != 100 || progress.EstimatedTotalBytes != p.BytesTransferred {
+		t.Fatalf("expect completed, got %+v", progress)
+	}
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+)
+
+// backupQuotaGroupOf returns the key of the group which the backup is admitted with. Xstore backups
+// of the same polardbx backup are admitted together, since they must run at the same time to reach
+// a consistent point.
+func backupQuotaGroupOf(backup *polardbxv1.XStoreBackup) string {
+	if topBackup := backup.Labels[polardbxmeta.LabelTopBackup]; len(topBackup) > 0 {
+		return "pxcbackup/" + topBackup
+	}
+	return "xsbackup/" + backup.Name
+}
+
+func isBackupQueued(phase polardbxv1.XStoreBackupPhase) bool {
+	return phase == polardbxv1.XStoreBackupNew || phase == polardbxv1.XStoreBackupPending
+}
+
+func isBackupInFlight(phase polardbxv1.XStoreBackupPhase) bool {
+	return !isBackupQueued(phase) && phase != polardbxv1.XStoreBackupFinished && phase != polardbxv1.XStoreBackupFailed
+}
+
+// backupQueuePosition returns the position of the backup in the backup queue of the namespace, starting
+// from 1, or 0 if the backup is admitted. Backups are admitted in groups, first come first served, as
+// long as the in-flight ones plus the group don't exceed the quota. A group larger than the quota is
+// admitted only when nothing is in flight, so that it's never starved.
+func backupQueuePosition(backup *polardbxv1.XStoreBackup, backups []polardbxv1.XStoreBackup, quota int) int32 {
+	if quota <= 0 {
+		return 0
+	}
+
+	type group struct {
+		key     string
+		size    int
+		started bool
+		created metav1.Time
+	}
+	groups := make(map[string]*group)
+	inFlight := 0
+	observe := func(b *polardbxv1.XStoreBackup) {
+		// Clones copy the backup sets only, they never take the quota.
+		if b.Spec.CopyFrom != nil {
+			return
+		}
+		if isBackupInFlight(b.Status.Phase) {
+			inFlight++
+		}
+		key := backupQuotaGroupOf(b)
+		g, ok := groups[key]
+		if !ok {
+			g = &group{key: key, created: b.CreationTimestamp}
+			groups[key] = g
+		}
+		g.size++
+		g.started = g.started || !isBackupQueued(b.Status.Phase)
+		if b.CreationTimestamp.Before(&g.created) {
+			g.created = b.CreationTimestamp
+		}
+	}
+	found := false
+	for i := range backups {
+		if backups[i].Name == backup.Name {
+			found = true
+			observe(backup)
+		} else {
+			observe(&backups[i])
+		}
+	}
+	if !found {
+		observe(backup)
+	}
+
+	key := backupQuotaGroupOf(backup)
+	if g, ok := groups[key]; !ok || g.started {
+		return 0
+	}
+
+	queue := make([]*group, 0, len(groups))
+	for _, g := range groups {
+		if !g.started {
+			queue = append(queue, g)
+		}
+	}
+	sort.Slice(queue, func(i, j int) bool {
+		if !queue[i].created.Equal(&queue[j].created) {
+			return queue[i].created.Before(&queue[j].created)
+		}
+		return queue[i].key < queue[j].key
+	})
+
+	position := int32(0)
+	for _, g := range queue {
+		if position == 0 && (inFlight == 0 || inFlight+g.size <= quota) {
+			inFlight += g.size
+			if g.key == key {
+				return 0
+			}
+			continue
+		}
+		position++
+		if g.key == key {
+			return position
+		}
+	}
+	return 0
+}
+
+// maxConcurrentBackupsOf returns the max count of in-flight xstore backups in the namespace, the
+// annotation of namespace takes precedence over the operator config.
+func maxConcurrentBackupsOf(rc *xstorev1reconcile.BackupContext) (int, error) {
+	namespace := &corev1.Namespace{}
+	if err := rc.Client().Get(rc.Context(), types.NamespacedName{Name: rc.Namespace()}, namespace); err != nil {
+		return 0, err
+	}
+	if val, ok := namespace.Annotations[polardbxmeta.AnnotationBackupMaxConcurrent]; ok {
+		quota, err := strconv.Atoi(val)
+		if err != nil {
+			return 0, fmt.Errorf("invalid annotation %s of namespace: %w", polardbxmeta.AnnotationBackupMaxConcurrent, err)
+		}
+		return quota, nil
+	}
+	return rc.XStoreContext().Config().Backup().MaxConcurrentPerNamespace(), nil
+}
+
+// WaitBackupQuota keeps the backup pending until it's admitted by the quota of concurrent backups
+// of the namespace, and records its position in the queue meanwhile.
+var WaitBackupQuota = NewStepBinder("WaitBackupQuota",
+	func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetXStoreBackup()
+		quota, err := maxConcurrentBackupsOf(rc)
+		if err != nil {
+			return flow.Error(err, "Unable to get backup quota of namespace.")
+		}
+
+		position := int32(0)
+		if quota > 0 {
+			backupList := &polardbxv1.XStoreBackupList{}
+			if err := rc.Client().List(rc.Context(), backupList, client.InNamespace(rc.Namespace())); err != nil {
+				return flow.Error(err, "Unable to list xstore backups of namespace.")
+			}
+			position = backupQueuePosition(backup, backupList.Items, quota)
+		}
+		backup.Status.QueuePosition = position
+		if position > 0 {
+			transferPhase(backup, polardbxv1.XStoreBackupPending, time.Now())
+			return retryWithBackoff(rc, flow, "WaitBackupQuota", strconv.Itoa(int(position)),
+				"Backup quota of namespace is used up, queued.", "quota", quota, "position", position)
+		}
+		resetBackoff(rc, "WaitBackupQuota")
+		if backup.Status.Phase == polardbxv1.XStoreBackupPending {
+			transferPhase(backup, polardbxv1.XStoreBackupNew, time.Now())
+		}
+		return flow.Continue("Backup admitted.", "quota", quota)
+	})
@@ -0,0 +1,94 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+)
+
+func queuedBackup(name, topBackup string, created int, phase polardbxv1.XStoreBackupPhase) polardbxv1.XStoreBackup {
+	backup := polardbxv1.XStoreBackup{}
+	backup.Name = name
+	backup.CreationTimestamp = metav1.NewTime(time.Unix(int64(created), 0))
+	if len(topBackup) > 0 {
+		backup.Labels = map[string]string{polardbxmeta.LabelTopBackup: topBackup}
+	}
+	backup.Status.Phase = phase
+	return backup
+}
+
+func TestBackupQueuePosition(t *testing.T) {
+	backups := []polardbxv1.XStoreBackup{
+		queuedBackup("running", "", 1, polardbxv1.XStoreFullBackuping),
+		queuedBackup("done", "", 2, polardbxv1.XStoreBackupFinished),
+		queuedBackup("a-dn0", "a", 3, polardbxv1.XStoreBackupPending),
+		queuedBackup("a-dn1", "a", 4, polardbxv1.XStoreBackupNew),
+		queuedBackup("single", "", 5, polardbxv1.XStoreBackupPending),
+		queuedBackup("b-dn0", "b", 6, polardbxv1.XStoreBackupNew),
+	}
+	clone := queuedBackup("clone", "", 0, polardbxv1.XStoreBackupNew)
+	clone.Spec.CopyFrom = &polardbxv1.BackupCopySource{}
+	backups = append(backups, clone)
+
+	cases := []struct {
+		quota  int
+		expect map[string]int32
+	}{
+		{quota: 0, expect: map[string]int32{"a-dn0": 0, "single": 0, "b-dn0": 0}},
+		{quota: 1, expect: map[string]int32{"running": 0, "a-dn0": 1, "a-dn1": 1, "single": 2, "b-dn0": 3}},
+		{quota: 3, expect: map[string]int32{"a-dn0": 0, "a-dn1": 0, "single": 1, "b-dn0": 2}},
+		{quota: 4, expect: map[string]int32{"a-dn0": 0, "single": 0, "b-dn0": 1}},
+	}
+	for _, c := range cases {
+		for i := range backups {
+			expect, ok := c.expect[backups[i].Name]
+			if !ok {
+				continue
+			}
+			if position := backupQueuePosition(&backups[i], backups, c.quota); position != expect {
+				t.Fatalf("quota %d, backup %s: expect position %d, got %d", c.quota, backups[i].Name, expect, position)
+			}
+		}
+	}
+}
+
+func TestBackupQueuePosition_LargeGroupNotStarved(t *testing.T) {
+	backups := []polardbxv1.XStoreBackup{
+		queuedBackup("a-dn0", "a", 1, polardbxv1.XStoreBackupPending),
+		queuedBackup("a-dn1", "a", 1, polardbxv1.XStoreBackupPending),
+		queuedBackup("a-dn2", "a", 1, polardbxv1.XStoreBackupPending),
+		queuedBackup("single", "", 2, polardbxv1.XStoreBackupPending),
+	}
+	if position := backupQueuePosition(&backups[0], backups, 2); position != 0 {
+		t.Fatalf("expect large group admitted when nothing in flight, got %d", position)
+	}
+	if position := backupQueuePosition(&backups[3], backups, 2); position != 1 {
+		t.Fatalf("expect single queued after large group, got %d", position)
+	}
+
+	// The rest members are admitted once any member of the group has started.
+	backups[0].Status.Phase = polardbxv1.XStoreFullBackuping
+	if position := backupQueuePosition(&backups[2], backups, 2); position != 0 {
+		t.Fatalf("expect member of started group admitted, got %d", position)
+	}
+}
@@ -0,0 +1,270 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	xstorev1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/hpfs/remote"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	polardbxhelper "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+)
+
+// DefaultRetentionThrottleBackoff is the backoff of retention pass once the storage throttles.
+const DefaultRetentionThrottleBackoff = 30 * time.Second
+
+// DefaultImmutableDeleteRetryInterval is the interval to retry deleting the aged backup whose files
+// are still locked by the storage.
+const DefaultImmutableDeleteRetryInterval = time.Hour
+
+// errBackupImmutable is returned when deleting an aged backup that is still immutable.
+var errBackupImmutable = errors.New("backup is immutable")
+
+// isBackupLocked tells whether the backup failed to delete since it's immutable, or its files
+// are locked by the storage.
+func isBackupLocked(err error) bool {
+	return errors.Is(err, errBackupImmutable) || remote.IsImmutable(err)
+}
+
+func xstoreBackupImmutableUntil(backup *xstorev1.XStoreBackup) time.Time {
+	if backup.Status.EndTime == nil {
+		return time.Time{}
+	}
+	return polardbxhelper.ImmutableUntil(backup.Status.EndTime.Time, backup.Spec.ImmutableUntil,
+		backup.Spec.RetentionTime.Duration, backup.Spec.Retention)
+}
+
+func retentionThrottleBackoffOf(retention xstorev1.BackupRetention) time.Duration {
+	if retention.ThrottleBackoff.Duration > 0 {
+		return retention.ThrottleBackoff.Duration
+	}
+	return DefaultRetentionThrottleBackoff
+}
+
+// isBackupAged tells whether the finished backup is past its retention along with the grace period.
+func isBackupAged(backup *xstorev1.XStoreBackup, now time.Time) bool {
+	if backup.Status.Phase != xstorev1.XStoreBackupFinished || backup.Status.EndTime == nil ||
+		!backup.DeletionTimestamp.IsZero() {
+		return false
+	}
+	return !now.Before(polardbxhelper.RetentionDeleteTime(backup.Status.EndTime.Time,
+		backup.Spec.RetentionTime.Duration, backup.Spec.Retention))
+}
+
+// agedBackupsOldestFirst returns the aged backups, the oldest first so that the most-aged data goes first.
+func agedBackupsOldestFirst(backups []xstorev1.XStoreBackup, now time.Time) []*xstorev1.XStoreBackup {
+	aged := make([]*xstorev1.XStoreBackup, 0)
+	for i := range backups {
+		if isBackupAged(&backups[i], now) {
+			aged = append(aged, &backups[i])
+		}
+	}
+	sort.SliceStable(aged, func(i, j int) bool {
+		if !aged[i].Status.EndTime.Equal(aged[j].Status.EndTime) {
+			return aged[i].Status.EndTime.Before(aged[j].Status.EndTime)
+		}
+		return aged[i].Name < aged[j].Name
+	})
+	return aged
+}
+
+// pxcBackupUsage is the storage used by the finished xstore backups of a pxc backup.
+type pxcBackupUsage struct {
+	name    string
+	endTime *metav1.Time
+	size    int64
+	isBase  bool
+}
+
+// pxcBackupUsagesOf groups the finished xstore backups by pxc backup, the oldest first, and returns
+// the total size of them. Backups not of any pxc backup, e.g. the standalone ones, and the copies
+// managed by their own retention time are not counted.
+func pxcBackupUsagesOf(backups []xstorev1.XStoreBackup) ([]*pxcBackupUsage, int64) {
+	usages := make(map[string]*pxcBackupUsage)
+	bases := incrementalBasesOf(backups)
+	var used int64
+	for _, b := range backups {
+		top := b.Labels[polardbxmeta.LabelTopBackup]
+		if len(top) == 0 || b.Status.Phase != xstorev1.XStoreBackupFinished || !b.DeletionTimestamp.IsZero() ||
+			b.Spec.CopyFrom != nil {
+			continue
+		}
+		u, ok := usages[top]
+		if !ok {
+			u = &pxcBackupUsage{name: top}
+			usages[top] = u
+		}
+		if u.endTime == nil || (b.Status.EndTime != nil && u.endTime.Before(b.Status.EndTime)) {
+			u.endTime = b.Status.EndTime
+		}
+		u.size += b.Status.BackupSize
+		u.isBase = u.isBase || bases[b.Name]
+		used += b.Status.BackupSize
+	}
+
+	sorted := make([]*pxcBackupUsage, 0, len(usages))
+	for _, u := range usages {
+		sorted = append(sorted, u)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].endTime == nil || sorted[j].endTime == nil {
+			if sorted[i].endTime == nil && sorted[j].endTime == nil {
+				return sorted[i].name < sorted[j].name
+			}
+			return sorted[j].endTime != nil
+		}
+		if !sorted[i].endTime.Equal(sorted[j].endTime) {
+			return sorted[i].endTime.Before(sorted[j].endTime)
+		}
+		return sorted[i].name < sorted[j].name
+	})
+	return sorted, used
+}
+
+// isBudgetEnforcer tells whether the budget of the cluster is enforced by the backup, which is the
+// first one by name of the xstore backups of the same pxc backup. So the budget is enforced once per
+// pxc backup, rather than by the backups of all the xstores at the same time.
+func isBudgetEnforcer(backup *xstorev1.XStoreBackup, backups []xstorev1.XStoreBackup) bool {
+	top := backup.Labels[polardbxmeta.LabelTopBackup]
+	if len(top) == 0 {
+		return false
+	}
+	for _, b := range backups {
+		if b.Labels[polardbxmeta.LabelTopBackup] == top && b.Name < backup.Name {
+			return false
+		}
+	}
+	return true
+}
+
+// runRetentionPass deletes the backups in order with at most concurrency deletions in flight. The
+// delete function returns whether the backup is skipped, and the locked ones are counted apart from
+// the failed ones. Once a deletion is throttled by the storage,
+// the ones not started yet are given up and left to the next pass. It returns the result of the pass
+// and the backups deleted.
+func runRetentionPass(backups []*xstorev1.XStoreBackup, concurrency int,
+	deleteBackup func(*xstorev1.XStoreBackup) (bool, error)) (*xstorev1.BackupRetentionPass, map[string]bool) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	pass := &xstorev1.BackupRetentionPass{Time: metav1.Now()}
+	deleted := make(map[string]bool)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, concurrency)
+	for _, backup := range backups {
+		slots <- struct{}{}
+		mu.Lock()
+		throttled := pass.Throttled
+		mu.Unlock()
+		if throttled {
+			<-slots
+			break
+		}
+
+		wg.Add(1)
+		go func(backup *xstorev1.XStoreBackup) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			skipped, err := deleteBackup(backup)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case isBackupLocked(err):
+				pass.Locked++
+			case err != nil:
+				pass.Failed++
+				if remote.IsThrottled(err) {
+					pass.Throttled = true
+				}
+			case skipped:
+				pass.Skipped++
+			default:
+				pass.Deleted++
+				deleted[backup.Name] = true
+			}
+		}(backup)
+	}
+	wg.Wait()
+	return pass, deleted
+}
+
+// deleteAgedXStoreBackup deletes the files and the object of aged backup, unless it's referenced by an
+// active restore or it's the base of incremental backups. The immutable backup is kept with errBackupImmutable.
+func deleteAgedXStoreBackup(rc *xstorev1reconcile.BackupContext, flow control.Flow, backup *xstorev1.XStoreBackup) (bool, error) {
+	if until := xstoreBackupImmutableUntil(backup); time.Now().Before(until) {
+		flow.Logger().Info("Backup is immutable, skip.", "XSBackup-name", backup.Name, "until", until)
+		return false, errBackupImmutable
+	}
+	restore, err := polardbxhelper.ActiveRestoreOfXStoreBackup(rc.Context(), rc.Client(), backup)
+	if err != nil {
+		return false, err
+	}
+	if len(restore) > 0 {
+		flow.Logger().Info("Backup is referenced by an active restore, skip.", "XSBackup-name", backup.Name, "restore", restore)
+		return true, nil
+	}
+	if isBase, err := isIncrementalBaseOfOthers(rc, backup); err != nil {
+		return false, err
+	} else if isBase {
+		flow.Logger().Info("Backup is the base of incremental backups, skip.", "XSBackup-name", backup.Name)
+		return true, nil
+	}
+	if err := removeXStoreBackupFiles(rc, flow, backup); remote.IsImmutable(err) {
+		flow.Logger().Info("Backup files are locked by the storage, skip.", "XSBackup-name", backup.Name)
+		return false, err
+	} else if err != nil {
+		flow.Logger().Error(err, "Unable to delete the backup files.", "XSBackup-name", backup.Name)
+		return false, err
+	}
+	if err := rc.Client().Delete(rc.Context(), backup); client.IgnoreNotFound(err) != nil {
+		flow.Logger().Error(err, "Unable to delete the backup.", "XSBackup-name", backup.Name)
+		return false, err
+	} else if apierrors.IsNotFound(err) {
+		flow.Logger().Info("Already deleted!", "XSBackup-name", backup.Name)
+	}
+	return false, nil
+}
+
+// listAgedXStoreBackups returns the aged backups of the same xstore as the backup, oldest first.
+func listAgedXStoreBackups(rc *xstorev1reconcile.BackupContext, backup *xstorev1.XStoreBackup) ([]*xstorev1.XStoreBackup, error) {
+	xstoreName := backup.Labels[xstoremeta.LabelName]
+	if len(xstoreName) == 0 {
+		return []*xstorev1.XStoreBackup{backup}, nil
+	}
+	var backupList xstorev1.XStoreBackupList
+	if err := rc.Client().List(rc.Context(), &backupList, client.InNamespace(backup.Namespace),
+		client.MatchingLabels{xstoremeta.LabelName: xstoreName}); err != nil {
+		return nil, err
+	}
+	return agedBackupsOldestFirst(backupList.Items, time.Now()), nil
+}
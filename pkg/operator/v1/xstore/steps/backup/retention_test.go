@@ -0,0 +1,169 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+)
+
+func TestAgedBackupsOldestFirst(t *testing.T) {
+	now := time.Now()
+	newBackup := func(name string, endedAgo time.Duration, phase polardbxv1.XStoreBackupPhase) polardbxv1.XStoreBackup {
+		b := polardbxv1.XStoreBackup{}
+		b.Name = name
+		b.Status.Phase = phase
+		b.Status.EndTime = &metav1.Time{Time: now.Add(-endedAgo)}
+		b.Spec.RetentionTime = metav1.Duration{Duration: time.Hour}
+		return b
+	}
+	backups := []polardbxv1.XStoreBackup{
+		newBackup("aged", 2*time.Hour, polardbxv1.XStoreBackupFinished),
+		newBackup("fresh", 30*time.Minute, polardbxv1.XStoreBackupFinished),
+		newBackup("oldest", 3*time.Hour, polardbxv1.XStoreBackupFinished),
+		newBackup("failed", 3*time.Hour, polardbxv1.XStoreBackupFailed),
+	}
+	graced := newBackup("graced", 2*time.Hour, polardbxv1.XStoreBackupFinished)
+	graced.Spec.Retention.GracePeriod = metav1.Duration{Duration: 2 * time.Hour}
+	backups = append(backups, graced)
+
+	aged := agedBackupsOldestFirst(backups, now)
+	if len(aged) != 2 || aged[0].Name != "oldest" || aged[1].Name != "aged" {
+		t.Fatalf("expect oldest and aged, got %v", aged)
+	}
+}
+
+func TestRunRetentionPass(t *testing.T) {
+	backups := make([]*polardbxv1.XStoreBackup, 0)
+	for _, name := range []string{"b0", "b1", "b2", "b3", "b4"} {
+		b := &polardbxv1.XStoreBackup{}
+		b.Name = name
+		backups = append(backups, b)
+	}
+
+	var order []string
+	pass, deleted := runRetentionPass(backups, 0, func(b *polardbxv1.XStoreBackup) (bool, error) {
+		order = append(order, b.Name)
+		switch b.Name {
+		case "b1":
+			return true, nil
+		case "b2":
+			return false, errors.New("access denied")
+		case "b3":
+			return false, errBackupImmutable
+		}
+		return false, nil
+	})
+	if len(order) != 5 || order[0] != "b0" || order[4] != "b4" {
+		t.Fatalf("expect serial deletions in order, got %v", order)
+	}
+	if pass.Deleted != 2 || pass.Skipped != 1 || pass.Failed != 1 || pass.Locked != 1 || pass.Throttled ||
+		!deleted["b4"] || deleted["b2"] || deleted["b3"] {
+		t.Fatalf("unexpected pass: %+v, deleted: %v", pass, deleted)
+	}
+
+	// Deletions not started are left to the next pass once throttled.
+	order = nil
+	pass, _ = runRetentionPass(backups, 1, func(b *polardbxv1.XStoreBackup) (bool, error) {
+		order = append(order, b.Name)
+		if b.Name == "b1" {
+			return false, oss.ServiceError{StatusCode: 503}
+		}
+		return false, nil
+	})
+	if len(order) != 2 || !pass.Throttled || pass.Deleted != 1 || pass.Failed != 1 {
+		t.Fatalf("expect stopped after throttled, got %v, pass: %+v", order, pass)
+	}
+
+	// The deletions in flight are bounded by the concurrency.
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	pass, _ = runRetentionPass(backups, 2, func(b *polardbxv1.XStoreBackup) (bool, error) {
+		mu.Lock()
+		if inFlight++; inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		return false, nil
+	})
+	if pass.Deleted != 5 || maxInFlight != 2 {
+		t.Fatalf("expect 5 deleted with 2 in flight, got %+v, max in flight %d", pass, maxInFlight)
+	}
+}
+
+func newBudgetedBackup(name, top string, endedAgo time.Duration, size int64) polardbxv1.XStoreBackup {
+	b := polardbxv1.XStoreBackup{}
+	b.Name = name
+	b.Labels = map[string]string{polardbxmeta.LabelName: "pxc"}
+	if len(top) > 0 {
+		b.Labels[polardbxmeta.LabelTopBackup] = top
+	}
+	b.Status.Phase = polardbxv1.XStoreBackupFinished
+	b.Status.EndTime = &metav1.Time{Time: time.Now().Add(-endedAgo)}
+	b.Status.BackupSize = size
+	return b
+}
+
+func TestPXCBackupUsagesOf(t *testing.T) {
+	copied := newBudgetedBackup("copy-dn-0", "copy", 4*time.Hour, 1000)
+	copied.Spec.CopyFrom = &polardbxv1.BackupCopySource{}
+	running := newBudgetedBackup("new-dn-0", "new", 0, 1000)
+	running.Status.Phase = polardbxv1.XStoreFullBackuping
+	backups := []polardbxv1.XStoreBackup{
+		newBudgetedBackup("b1-dn-0", "b1", 2*time.Hour, 10),
+		newBudgetedBackup("b0-dn-0", "b0", 3*time.Hour, 20),
+		newBudgetedBackup("b0-dn-1", "b0", 3*time.Hour+time.Minute, 30),
+		newBudgetedBackup("standalone", "", 5*time.Hour, 1000),
+		copied,
+		running,
+	}
+
+	usages, used := pxcBackupUsagesOf(backups)
+	if used != 60 {
+		t.Fatalf("expect 60 bytes used, got %d", used)
+	}
+	if len(usages) != 2 || usages[0].name != "b0" || usages[0].size != 50 || usages[1].name != "b1" {
+		t.Fatalf("unexpected usages: %+v", usages)
+	}
+}
+
+func TestIsBudgetEnforcer(t *testing.T) {
+	backups := []polardbxv1.XStoreBackup{
+		newBudgetedBackup("b0-dn-1", "b0", time.Hour, 10),
+		newBudgetedBackup("b0-dn-0", "b0", time.Hour, 10),
+		newBudgetedBackup("b1-dn-0", "b1", time.Hour, 10),
+		newBudgetedBackup("standalone", "", time.Hour, 10),
+	}
+
+	for i, expect := range []bool{false, true, true, false} {
+		if got := isBudgetEnforcer(&backups[i], backups); got != expect {
+			t.Fatalf("%s: expect %v, got %v", backups[i].Name, expect, got)
+		}
+	}
+}
@@ -0,0 +1,361 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+	dbutil "github.com/alibaba/polardbx-operator/pkg/util/database"
+)
+
+var volumeSnapshotGVK = schema.GroupVersionKind{
+	Group:   "snapshot.storage.k8s.io",
+	Version: "v1",
+	Kind:    "VolumeSnapshot",
+}
+
+var volumeSnapshotContentGVK = schema.GroupVersionKind{
+	Group:   "snapshot.storage.k8s.io",
+	Version: "v1",
+	Kind:    "VolumeSnapshotContent",
+}
+
+const (
+	// The global read lock is held until the snapshot is cut, the backup fails if it takes longer.
+	volumeSnapshotLockTimeout  = 5 * time.Second
+	volumeSnapshotPollInterval = 200 * time.Millisecond
+)
+
+// IsVolumeSnapshotBackup returns true if the backup is taken as the volume snapshot of the data PVC.
+func IsVolumeSnapshotBackup(backup *polardbxv1.XStoreBackup) bool {
+	return backup.Spec.BackupEngine == polardbxv1.BackupEngineVolumeSnapshot
+}
+
+func newVolumeSnapshotName(backup *polardbxv1.XStoreBackup) string {
+	return backup.Name + "-data"
+}
+
+// newVolumeSnapshot returns the volume snapshot of the claim, labeled as the backup.
+func newVolumeSnapshot(backup *polardbxv1.XStoreBackup, claim string) *unstructured.Unstructured {
+	spec := map[string]interface{}{
+		"source": map[string]interface{}{
+			"persistentVolumeClaimName": claim,
+		},
+	}
+	if len(backup.Spec.VolumeSnapshotClassName) > 0 {
+		spec["volumeSnapshotClassName"] = backup.Spec.VolumeSnapshotClassName
+	}
+	labels := make(map[string]string)
+	for k, v := range backup.Labels {
+		labels[k] = v
+	}
+	labels[xstoremeta.LabelName] = backup.Spec.XStore.Name
+
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetGroupVersionKind(volumeSnapshotGVK)
+	snapshot.SetNamespace(backup.Namespace)
+	snapshot.SetName(newVolumeSnapshotName(backup))
+	snapshot.SetLabels(labels)
+	snapshot.Object["spec"] = spec
+	return snapshot
+}
+
+// getVolumeSnapshot returns the volume snapshot of the backup, nil if not found.
+func getVolumeSnapshot(rc *xstorev1reconcile.BackupContext, name string) (*unstructured.Unstructured, error) {
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetGroupVersionKind(volumeSnapshotGVK)
+	err := rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: rc.Namespace(), Name: name}, snapshot)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// volumeSnapshotCreationTime returns the time when the snapshot is cut, nil if it's not cut yet.
+func volumeSnapshotCreationTime(snapshot *unstructured.Unstructured) (*metav1.Time, error) {
+	value, found, err := unstructured.NestedString(snapshot.Object, "status", "creationTime")
+	if err != nil || !found || len(value) == 0 {
+		return nil, err
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &metav1.Time{Time: t}, nil
+}
+
+// volumeSnapshotError returns the error message of the snapshot reported by the CSI driver.
+func volumeSnapshotError(snapshot *unstructured.Unstructured) string {
+	message, _, _ := unstructured.NestedString(snapshot.Object, "status", "error", "message")
+	return message
+}
+
+// showMasterStatus returns the current binlog file and position.
+func showMasterStatus(ctx context.Context, conn *sql.Conn) (string, int64, error) {
+	rows, err := conn.QueryContext(ctx, "SHOW MASTER STATUS")
+	if err != nil {
+		return "", 0, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", 0, err
+	}
+	if len(columns) < 2 {
+		return "", 0, fmt.Errorf("unexpected columns of master status: %v", columns)
+	}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return "", 0, err
+		}
+		return "", 0, errors.New("binlog is not enabled")
+	}
+	var file string
+	var position int64
+	dest := make([]interface{}, len(columns))
+	dest[0], dest[1] = &file, &position
+	for i := 2; i < len(dest); i++ {
+		dest[i] = new(sql.RawBytes)
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return "", 0, err
+	}
+	return file, position, nil
+}
+
+// cutVolumeSnapshotUnderReadLock creates the snapshot while the engine of the pod is locked by the global
+// read lock, and waits until the snapshot is cut. The binlog position and the applied index are captured
+// under the lock, so that they're consistent with the snapshot.
+func cutVolumeSnapshotUnderReadLock(rc *xstorev1reconcile.BackupContext, pod *corev1.Pod,
+	snapshot *unstructured.Unstructured, status *polardbxv1.BackupVolumeSnapshotStatus) (int64, error) {
+	ds, err := rc.GetXStorePodDataSource(pod)
+	if err != nil {
+		return 0, err
+	}
+	ds.Timeout = 5 * time.Second
+	db, err := dbutil.OpenMySQLDB(ds)
+	if err != nil {
+		return 0, err
+	}
+	defer dbutil.DeferClose(db)
+
+	ctx, cancel := context.WithTimeout(rc.Context(), volumeSnapshotLockTimeout+5*time.Second)
+	defer cancel()
+	// The lock is held by the session, so all the statements must be executed on the same connection.
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer dbutil.DeferClose(conn)
+
+	if _, err := conn.ExecContext(ctx, "FLUSH TABLES WITH READ LOCK"); err != nil {
+		return 0, fmt.Errorf("unable to lock tables: %w", err)
+	}
+	lockedAt := time.Now()
+	defer func() {
+		_, _ = conn.ExecContext(ctx, "UNLOCK TABLES")
+	}()
+
+	status.BinlogFile, status.BinlogPosition, err = showMasterStatus(ctx, conn)
+	if err != nil {
+		return 0, fmt.Errorf("unable to show master status: %w", err)
+	}
+	var appliedIndex int64
+	//goland:noinspection SqlNoDataSourceInspection,SqlDialectInspection
+	err = conn.QueryRowContext(ctx, "SELECT LAST_APPLY_INDEX FROM information_schema.ALISQL_CLUSTER_LOCAL").Scan(&appliedIndex)
+	if err != nil {
+		return 0, fmt.Errorf("unable to query applied index: %w", err)
+	}
+
+	if err := rc.SetControllerRefAndCreate(snapshot); err != nil {
+		return 0, fmt.Errorf("unable to create volume snapshot: %w", err)
+	}
+	// Only the cut of the snapshot is waited under the lock, which is short for the CSI drivers
+	// supporting snapshots. The snapshot becomes ready to use later without the lock.
+	waitCtx, waitCancel := context.WithTimeout(ctx, volumeSnapshotLockTimeout)
+	defer waitCancel()
+	err = wait.PollImmediateUntil(volumeSnapshotPollInterval, func() (bool, error) {
+		observed, err := getVolumeSnapshot(rc, snapshot.GetName())
+		if err != nil || observed == nil {
+			return false, err
+		}
+		if message := volumeSnapshotError(observed); len(message) > 0 {
+			return false, errors.New(message)
+		}
+		creationTime, err := volumeSnapshotCreationTime(observed)
+		if err != nil || creationTime == nil {
+			return false, err
+		}
+		status.CreationTime = creationTime
+		return true, nil
+	}, waitCtx.Done())
+	if errors.Is(err, wait.ErrWaitTimeout) {
+		return 0, fmt.Errorf("volume snapshot not cut in %s", volumeSnapshotLockTimeout)
+	}
+	if err != nil {
+		return 0, err
+	}
+	status.LockDuration = time.Since(lockedAt).Truncate(time.Millisecond).String()
+	return appliedIndex, nil
+}
+
+func failBackupOnVolumeSnapshot(rc *xstorev1reconcile.BackupContext, flow control.Flow, err error) (reconcile.Result, error) {
+	backup := rc.MustGetXStoreBackup()
+	transferPhase(backup, polardbxv1.XStoreBackupFailed, time.Now())
+	backup.Status.FailureReason = polardbxv1.BackupFailureVolumeSnapshot
+	backup.Status.Message = err.Error()
+	return flow.Retry("Volume snapshot failed, backup failed.", "error", err.Error())
+}
+
+// TakeVolumeSnapshot cuts the volume snapshot of the data PVC of the target pod under the global read
+// lock. Data and logs share the claim, so the snapshot is crash-consistent. The snapshot is owned by the
+// backup and deleted along with it.
+var TakeVolumeSnapshot = NewStepBinder("TakeVolumeSnapshot",
+	func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetXStoreBackup()
+		if backup.Status.VolumeSnapshot != nil {
+			return flow.Pass()
+		}
+
+		// The binlog position of the snapshot left by the last attempt is lost, take it again.
+		name := newVolumeSnapshotName(backup)
+		existing, err := getVolumeSnapshot(rc, name)
+		if err != nil {
+			return flow.Error(err, "Unable to get volume snapshot.", "snapshot", name)
+		}
+		if existing != nil {
+			if existing.GetDeletionTimestamp().IsZero() {
+				if err := rc.Client().Delete(rc.Context(), existing); client.IgnoreNotFound(err) != nil {
+					return flow.Error(err, "Unable to delete volume snapshot of last attempt.", "snapshot", name)
+				}
+			}
+			return retryWithBackoff(rc, flow, "TakeVolumeSnapshot", "",
+				"Volume snapshot of last attempt is being removed.", "snapshot", name)
+		}
+		resetBackoff(rc, "TakeVolumeSnapshot")
+
+		xstore, err := rc.GetXStore()
+		if err != nil {
+			return flow.Error(err, "Unable to get xstore.")
+		}
+		targetPod, err := rc.GetXStoreTargetPod()
+		if err != nil {
+			return flow.Error(err, "Unable to get target pod.")
+		}
+		vol := xstore.Status.BoundVolumes[targetPod.Name]
+		if vol == nil || len(vol.PersistentVolumeClaim) == 0 || len(vol.HostPath) > 0 {
+			return failBackupOnVolumeSnapshot(rc, flow,
+				fmt.Errorf("data of pod %s is not on a persistent volume claim", targetPod.Name))
+		}
+		var pvc corev1.PersistentVolumeClaim
+		err = rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: rc.Namespace(), Name: vol.PersistentVolumeClaim}, &pvc)
+		if err != nil {
+			return flow.Error(err, "Unable to get persistent volume claim.", "claim", vol.PersistentVolumeClaim)
+		}
+
+		status := &polardbxv1.BackupVolumeSnapshotStatus{
+			Name:      name,
+			SourcePod: targetPod.Name,
+			SourcePVC: pvc.Name,
+		}
+		if pvc.Spec.StorageClassName != nil {
+			status.StorageClassName = *pvc.Spec.StorageClassName
+		}
+		appliedIndex, err := cutVolumeSnapshotUnderReadLock(rc, targetPod, newVolumeSnapshot(backup, pvc.Name), status)
+		if err != nil {
+			return failBackupOnVolumeSnapshot(rc, flow, err)
+		}
+
+		backup.Status.TargetPod = targetPod.Name
+		backup.Status.CommitIndex = appliedIndex
+		backup.Status.BackupSetTimestamp = status.CreationTime.DeepCopy()
+		backup.Status.EarliestRecoverableTimestamp = status.CreationTime.DeepCopy()
+		backup.Status.VolumeSnapshot = status
+		return flow.Continue("Volume snapshot cut.", "snapshot", name, "lock-duration", status.LockDuration,
+			"binlog-file", status.BinlogFile, "binlog-position", status.BinlogPosition)
+	})
+
+// WaitVolumeSnapshotReady waits until the volume snapshot is ready to be restored, and records the
+// handle and size of the snapshot.
+var WaitVolumeSnapshotReady = NewStepBinder("WaitVolumeSnapshotReady",
+	func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetXStoreBackup()
+		status := backup.Status.VolumeSnapshot
+		if status == nil {
+			return failBackupOnVolumeSnapshot(rc, flow, errors.New("volume snapshot not taken"))
+		}
+		if status.ReadyToUse {
+			return flow.Pass()
+		}
+
+		snapshot, err := getVolumeSnapshot(rc, status.Name)
+		if err != nil {
+			return flow.Error(err, "Unable to get volume snapshot.", "snapshot", status.Name)
+		}
+		if snapshot == nil {
+			return failBackupOnVolumeSnapshot(rc, flow, fmt.Errorf("volume snapshot %s not found", status.Name))
+		}
+		if message := volumeSnapshotError(snapshot); len(message) > 0 {
+			return failBackupOnVolumeSnapshot(rc, flow, errors.New(message))
+		}
+
+		status.ContentName, _, _ = unstructured.NestedString(snapshot.Object, "status", "boundVolumeSnapshotContentName")
+		status.RestoreSize, _, _ = unstructured.NestedString(snapshot.Object, "status", "restoreSize")
+		if len(status.ContentName) > 0 && len(status.SnapshotHandle) == 0 {
+			content := &unstructured.Unstructured{}
+			content.SetGroupVersionKind(volumeSnapshotContentGVK)
+			err := rc.Client().Get(rc.Context(), types.NamespacedName{Name: status.ContentName}, content)
+			if client.IgnoreNotFound(err) != nil {
+				return flow.Error(err, "Unable to get volume snapshot content.", "content", status.ContentName)
+			}
+			if err == nil {
+				status.SnapshotHandle, _, _ = unstructured.NestedString(content.Object, "status", "snapshotHandle")
+			}
+		}
+
+		ready, _, _ := unstructured.NestedBool(snapshot.Object, "status", "readyToUse")
+		if !ready {
+			return retryWithBackoff(rc, flow, "WaitVolumeSnapshotReady", "",
+				"Volume snapshot is not ready.", "snapshot", status.Name)
+		}
+		resetBackoff(rc, "WaitVolumeSnapshotReady")
+		status.ReadyToUse = true
+		now := metav1.Now()
+		backup.Status.EndTime = &now
+		return flow.Continue("Volume snapshot is ready.", "snapshot", status.Name, "handle", status.SnapshotHandle)
+	})
@@ -0,0 +1,79 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
+)
+
+func TestNewVolumeSnapshot(t *testing.T) {
+	backup := &polardbxv1.XStoreBackup{}
+	backup.Name = "b1-dn0"
+	backup.Namespace = "ns"
+	backup.Labels = map[string]string{"k": "v"}
+	backup.Spec.XStore.Name = "dn0"
+
+	snapshot := newVolumeSnapshot(backup, "dn0-cand-0-data")
+	if snapshot.GetName() != "b1-dn0-data" || snapshot.GetNamespace() != "ns" {
+		t.Fatalf("unexpected snapshot %s/%s", snapshot.GetNamespace(), snapshot.GetName())
+	}
+	if labels := snapshot.GetLabels(); labels["k"] != "v" || labels[xstoremeta.LabelName] != "dn0" {
+		t.Fatalf("unexpected labels %v", labels)
+	}
+	claim, _, _ := unstructured.NestedString(snapshot.Object, "spec", "source", "persistentVolumeClaimName")
+	if claim != "dn0-cand-0-data" {
+		t.Fatalf("unexpected source claim %s", claim)
+	}
+	if _, found, _ := unstructured.NestedString(snapshot.Object, "spec", "volumeSnapshotClassName"); found {
+		t.Fatal("expect no snapshot class when not specified")
+	}
+
+	backup.Spec.VolumeSnapshotClassName = "csi-snapclass"
+	snapshot = newVolumeSnapshot(backup, "dn0-cand-0-data")
+	if class, _, _ := unstructured.NestedString(snapshot.Object, "spec", "volumeSnapshotClassName"); class != "csi-snapclass" {
+		t.Fatalf("unexpected snapshot class %s", class)
+	}
+}
+
+func TestVolumeSnapshotStatus(t *testing.T) {
+	snapshot := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	if ts, err := volumeSnapshotCreationTime(snapshot); ts != nil || err != nil {
+		t.Fatalf("expect not cut, but is %v, %v", ts, err)
+	}
+	if message := volumeSnapshotError(snapshot); len(message) > 0 {
+		t.Fatalf("expect no error, but is %s", message)
+	}
+
+	snapshot.Object["status"] = map[string]interface{}{
+		"creationTime": "2022-06-01T08:00:00Z",
+		"error": map[string]interface{}{
+			"message": "failed to take snapshot",
+		},
+	}
+	ts, err := volumeSnapshotCreationTime(snapshot)
+	if err != nil || ts == nil || ts.Unix() != 1654070400 {
+		t.Fatalf("unexpected creation time %v, %v", ts, err)
+	}
+	if message := volumeSnapshotError(snapshot); message != "failed to take snapshot" {
+		t.Fatalf("unexpected error %s", message)
+	}
+}
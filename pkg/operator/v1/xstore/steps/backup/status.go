@@ -18,22 +18,33 @@ package backup
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
 	xstorev1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
 	"github.com/alibaba/polardbx-operator/pkg/debug"
+	"github.com/alibaba/polardbx-operator/pkg/hpfs/remote"
 	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
 	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/config"
+	polardbxhelper "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
 	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/factory"
 	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
 	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
 	xstorectrlerrors "github.com/alibaba/polardbx-operator/pkg/util/error"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/rand"
+	"regexp"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -48,6 +59,263 @@ type BackupJobContext struct {
 	OffsetFileName      string `json:"offsetFileName,omitempty"`
 	StorageName         string `json:"storageName,omitempty"`
 	Sink                string `json:"sink,omitempty"`
+	EnableDedupReport   bool   `json:"enableDedupReport,omitempty"`
+	ChunkManifestPath   string `json:"chunkManifestPath,omitempty"`
+	BaseManifestPath    string `json:"baseManifestPath,omitempty"`
+	OverlapCollect      bool   `json:"overlapCollect,omitempty"`
+	SkipEmptyBinlog     bool   `json:"skipEmptyBinlog,omitempty"`
+	StorageClass        string `json:"storageClass,omitempty"`
+	FullBackupThreads   int32  `json:"fullBackupThreads,omitempty"`
+	CollectBatchBytes   int64  `json:"collectBatchBytes,omitempty"`
+	UploadRetries       int32  `json:"uploadRetries,omitempty"`
+	UploadRetryBackoff  string `json:"uploadRetryBackoff,omitempty"`
+	// ConsistencyWaitTimeout and ConsistencyWaitInterval are in seconds
+	ConsistencyWaitTimeout  float64 `json:"consistencyWaitTimeout,omitempty"`
+	ConsistencyWaitInterval float64 `json:"consistencyWaitInterval,omitempty"`
+	ConsistencyMode         string  `json:"consistencyMode,omitempty"`
+	// IncrementalLsn is the LSN since which the pages changed are copied by the incremental backup
+	IncrementalLsn string `json:"incrementalLsn,omitempty"`
+	// EncryptionKeyFile is the key file which the full backup and binlogs are encrypted with, if any
+	EncryptionKeyFile string `json:"encryptionKeyFile,omitempty"`
+	// MaxIOPS is the throttle of xtrabackup, and UploadRateLimit the max upload speed in bytes/s
+	MaxIOPS         int64 `json:"maxIOPS,omitempty"`
+	UploadRateLimit int64 `json:"uploadRateLimit,omitempty"`
+	// KeyringPath is where the keyring is uploaded along with the full backup, only if TDE is enabled
+	KeyringPath string `json:"keyringPath,omitempty"`
+}
+
+func chunkManifestPath(backupRootPath, xstoreName string) string {
+	return fmt.Sprintf("%s/%s/%s.chunks", backupRootPath, polardbxmeta.FullBackupPath, xstoreName)
+}
+
+func keyringPath(backupRootPath, xstoreName string) string {
+	return fmt.Sprintf("%s/%s/%s.keyring", backupRootPath, polardbxmeta.FullBackupPath, xstoreName)
+}
+
+// getDedupBaseBackup returns the latest finished backup of the same xstore and storage
+// which has recorded the chunk manifest, or nil if not found.
+func getDedupBaseBackup(rc *xstorev1reconcile.BackupContext, backup *xstorev1.XStoreBackup) (*xstorev1.XStoreBackup, error) {
+	var backupList xstorev1.XStoreBackupList
+	if err := rc.Client().List(rc.Context(), &backupList, client.InNamespace(backup.Namespace),
+		client.MatchingLabels{polardbxmeta.LabelBackupXStore: backup.Spec.XStore.Name}); err != nil {
+		return nil, err
+	}
+	var base *xstorev1.XStoreBackup
+	for i := range backupList.Items {
+		b := &backupList.Items[i]
+		if b.Name == backup.Name || b.Status.Phase != xstorev1.XStoreBackupFinished ||
+			b.Status.DedupReport == nil || b.Spec.StorageProvider != backup.Spec.StorageProvider {
+			continue
+		}
+		if base == nil || base.CreationTimestamp.Before(&b.CreationTimestamp) {
+			base = b
+		}
+	}
+	return base, nil
+}
+
+// pollingBackoff is shared by the polling steps of xstore backup, e.g. polling status of pxc
+// backup, it smooths the load when lots of backups are running at the same time.
+var pollingBackoff = control.NewBackoff(5*time.Second, time.Minute, 0.2)
+
+// pollingBackoffPrefix returns the prefix of the backoff keys of the xstore backup.
+func pollingBackoffPrefix(key types.NamespacedName) string {
+	return key.String() + "/"
+}
+
+// retryWithBackoff requeues the polling step after the backoff interval, a changed progress
+// resets the interval.
+func retryWithBackoff(rc *xstorev1reconcile.BackupContext, flow control.Flow, step, progress string, msg string, kvs ...interface{}) (reconcile.Result, error) {
+	key := pollingBackoffPrefix(rc.Request().NamespacedName) + step
+	return flow.RetryAfter(pollingBackoff.Next(key, progress), msg, kvs...)
+}
+
+func resetBackoff(rc *xstorev1reconcile.BackupContext, step string) {
+	pollingBackoff.Reset(pollingBackoffPrefix(rc.Request().NamespacedName) + step)
+}
+
+// ForgetPollingBackoff forgets the backoff of all the polling steps of the xstore backup. It's
+// invoked once the backup is finished, failed or gone, so that nothing is left for the backups
+// which end in the middle of a wait.
+func ForgetPollingBackoff(key types.NamespacedName) {
+	pollingBackoff.ResetPrefix(pollingBackoffPrefix(key))
+}
+
+// ForgetPollingSteps forgets the backoff of the polling steps once the backup ends.
+var ForgetPollingSteps = NewStepBinder("ForgetPollingSteps",
+	func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
+		ForgetPollingBackoff(rc.Request().NamespacedName)
+		return flow.Pass()
+	})
+
+func jobFailureReason(job *batchv1.Job) xstorev1.BackupFailureReason {
+	if k8shelper.IsJobDeadlineExceeded(job) {
+		return xstorev1.BackupFailureTimeout
+	}
+	return xstorev1.BackupFailureJobCrash
+}
+
+// failBackupOnJobFailure transits the backup to failed phase, failed jobs are kept for diagnosis
+// until the backup is removed.
+func failBackupOnJobFailure(rc *xstorev1reconcile.BackupContext, flow control.Flow, job *batchv1.Job, msg string) (reconcile.Result, error) {
+	backup := rc.MustGetXStoreBackup()
+	transferPhase(backup, xstorev1.XStoreBackupFailed, time.Now())
+	backup.Status.FailureReason = jobFailureReason(job)
+	backup.Status.Message = msg + ", job: " + job.Name
+	return flow.Retry(msg, "job-name", job.Name, "failure-reason", backup.Status.FailureReason)
+}
+
+// maxFullBackupErrorsLength bounds the errors of the full backup tool recorded in the message.
+const maxFullBackupErrorsLength = 1024
+
+// fullBackupJobErrors returns the errors reported by the full backup tool, i.e. of the copy threads,
+// the upload and the chunksum, empty if there are none or they are unavailable.
+func fullBackupJobErrors(rc *xstorev1reconcile.BackupContext, jobName string) string {
+	targetPod, err := rc.GetXStoreTargetPod()
+	if err != nil {
+		return ""
+	}
+	command := []string{"cat", "/data/mysql/tmp/" + jobName + ".err"}
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	if err := rc.ExecuteCommandOn(targetPod, "engine", command, control.ExecOptions{
+		Stdout: stdout,
+		Stderr: stderr,
+	}); err != nil {
+		return ""
+	}
+	errs := strings.Join(strings.Split(strings.TrimSpace(stdout.String()), "\n"), "; ")
+	if len(errs) > maxFullBackupErrorsLength {
+		errs = errs[:maxFullBackupErrorsLength]
+	}
+	return errs
+}
+
+// failBackupOnFullBackupJobFailure fails the backup with the errors reported by the full backup tool.
+func failBackupOnFullBackupJobFailure(rc *xstorev1reconcile.BackupContext, flow control.Flow, job *batchv1.Job) (reconcile.Result, error) {
+	msg := "Full backup job failed"
+	if errs := fullBackupJobErrors(rc, job.Name); len(errs) > 0 {
+		msg += ": " + errs
+	}
+	return failBackupOnJobFailure(rc, flow, job, msg)
+}
+
+type jobVersionAction int
+
+const (
+	jobVersionAdopt jobVersionAction = iota
+	jobVersionWait
+	jobVersionRecreate
+	jobVersionFail
+)
+
+// jobVersionActionOf decides how to handle the job according to the policy, if it's created by
+// operator of another version. Jobs without the version are created by operator before the
+// version is recorded, which are considered of another version as well.
+func jobVersionActionOf(policy xstorev1.BackupJobVersionPolicy, job *batchv1.Job, version string, recreatable bool) jobVersionAction {
+	if job.Annotations[xstoremeta.AnnotationOperatorVersion] == version {
+		return jobVersionAdopt
+	}
+	switch policy {
+	case xstorev1.BackupJobVersionRecreate:
+		if !recreatable {
+			return jobVersionFail
+		}
+		if !k8shelper.IsJobCompleted(job) && !k8shelper.IsJobFailed(job) {
+			return jobVersionWait
+		}
+		return jobVersionRecreate
+	case xstorev1.BackupJobVersionFail:
+		return jobVersionFail
+	default:
+		return jobVersionAdopt
+	}
+}
+
+// handleJobOfOtherVersion handles the job created by operator of another version. The job is
+// recreated by the start step of the phase once removed, or of the restart phase if specified.
+// It returns true if the step should return with the result.
+func handleJobOfOtherVersion(rc *xstorev1reconcile.BackupContext, flow control.Flow, job *batchv1.Job,
+	recreatable bool, restartPhase xstorev1.XStoreBackupPhase) (bool, reconcile.Result, error) {
+	backup := rc.MustGetXStoreBackup()
+	version := config.OperatorVersion()
+	jobVersion := job.Annotations[xstoremeta.AnnotationOperatorVersion]
+
+	switch jobVersionActionOf(backup.Spec.JobVersionPolicy, job, version, recreatable) {
+	case jobVersionWait:
+		result, err := flow.Wait("Job of another operator version is still running, recreate it once finished.",
+			"job-name", job.Name, "job-version", jobVersion, "version", version)
+		return true, result, err
+	case jobVersionRecreate:
+		if job.DeletionTimestamp.IsZero() {
+			err := rc.Client().Delete(rc.Context(), job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+			if client.IgnoreNotFound(err) != nil {
+				result, err := flow.Error(err, "Unable to remove job of another operator version", "job-name", job.Name)
+				return true, result, err
+			}
+		}
+		if len(restartPhase) > 0 {
+			transferPhase(backup, restartPhase, time.Now())
+		}
+		result, err := flow.RetryAfter(5*time.Second, "Job of another operator version removed, recreate it.",
+			"job-name", job.Name, "job-version", jobVersion, "version", version)
+		return true, result, err
+	case jobVersionFail:
+		transferPhase(backup, xstorev1.XStoreBackupFailed, time.Now())
+		backup.Status.FailureReason = xstorev1.BackupFailureOperatorUpgrade
+		backup.Status.Message = fmt.Sprintf("job %s is created by operator of version %q, current version %q",
+			job.Name, jobVersion, version)
+		result, err := flow.Retry("Job of another operator version, backup failed.",
+			"job-name", job.Name, "job-version", jobVersion, "version", version)
+		return true, result, err
+	default:
+		return false, reconcile.Result{}, nil
+	}
+}
+
+// WaitFailedArtifactRetention keeps the jobs of failed backup for diagnosis until the retention
+// elapses, the failure time is recorded as the end time.
+var WaitFailedArtifactRetention = NewStepBinder("WaitFailedArtifactRetention",
+	func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetXStoreBackup()
+		if backup.Status.EndTime == nil {
+			nowTime := metav1.Now()
+			backup.Status.EndTime = &nowTime
+		}
+		retention := backup.Spec.FailedArtifactRetention.Duration
+		if left := time.Until(backup.Status.EndTime.Add(retention)); left > 0 {
+			return flow.RetryAfter(left, "Keep jobs of failed backup", "left", left)
+		}
+		return flow.Continue("Retention of failed artifacts elapsed.")
+	})
+
+// transferPhase updates the phase of backup and records the transition in the phase history.
+// The duration of the previous phase is closed and only the latest transitions are kept.
+func transferPhase(backup *polardbxv1.XStoreBackup, phase xstorev1.XStoreBackupPhase, now time.Time) {
+	if backup.Status.Phase == phase && len(backup.Status.PhaseHistory) > 0 {
+		return
+	}
+	history := backup.Status.PhaseHistory
+	if len(history) == 0 {
+		// The initial phase is entered when the backup is created.
+		history = append(history, polardbxv1.BackupPhaseTransition{
+			Phase:     backup.Status.Phase,
+			EnteredAt: backup.CreationTimestamp,
+		})
+	}
+	if backup.Status.Phase != phase {
+		last := &history[len(history)-1]
+		last.Duration = now.Sub(last.EnteredAt.Time).Truncate(time.Second).String()
+		history = append(history, polardbxv1.BackupPhaseTransition{
+			Phase:     phase,
+			EnteredAt: metav1.NewTime(now),
+		})
+	}
+	if len(history) > polardbxv1.MaxBackupPhaseHistory {
+		history = history[len(history)-polardbxv1.MaxBackupPhaseHistory:]
+	}
+	backup.Status.PhaseHistory = history
+	backup.Status.Phase = phase
 }
 
 func UpdatePhaseTemplate(phase xstorev1.XStoreBackupPhase, requeue ...bool) control.BindFunc {
@@ -55,7 +323,7 @@ func UpdatePhaseTemplate(phase xstorev1.XStoreBackupPhase, requeue ...bool) cont
 		func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
 			xstoreBackup := rc.MustGetXStoreBackup()
 
-			xstoreBackup.Status.Phase = phase
+			transferPhase(xstoreBackup, phase, time.Now())
 			return flow.Continue(" Phase xstore backup updated!", "phase-new", phase)
 		})
 }
@@ -106,16 +374,97 @@ var UpdateBackupStartInfo = NewStepBinder("UpdateBackupStartInfo",
 			return flow.Error(err, "Unable to get pxc backup")
 		}
 		if pxcBackup.Status.BackupRootPath == "" { // In case that pxc backup status has not been updated
-			return flow.RetryAfter(5*time.Second,
-				"Status of pxc backup has not been updated, wait for 5 seconds and retry")
+			return retryWithBackoff(rc, flow, "UpdateBackupStartInfo", string(pxcBackup.Status.Phase),
+				"Status of pxc backup has not been updated, wait and retry")
+		}
+		xstore, err := rc.GetXStore()
+		if err != nil {
+			return flow.Error(err, "Unable to get xstore")
 		}
+		resetBackoff(rc, "UpdateBackupStartInfo")
 		xstoreBackup.Status.BackupRootPath = pxcBackup.Status.BackupRootPath
+		xstoreBackup.Status.EngineVersion = xstore.Status.EngineVersion
+		xstoreBackup.Status.TriggerSource = pxcBackup.Status.TriggerSource
 		if err := rc.UpdateXStoreBackup(); err != nil {
 			return flow.Error(err, "Unable to update xstore backup.")
 		}
+		xstoreBackup.Status.StorageClass = xstoreBackup.Spec.StorageClass
 		return flow.Continue("Update backup start info!")
 	})
 
+// CloneXStoreBackup fills the status of the clone from the source xstore backup along with the
+// account secrets, the files are already copied to the root path by the polardbx backup.
+var CloneXStoreBackup = NewStepBinder("CloneXStoreBackup",
+	func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetXStoreBackup()
+		source := &polardbxv1.XStoreBackup{}
+		err := rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: backup.Namespace, Name: backup.Spec.CopyFrom.BackupName}, source)
+		if apierrors.IsNotFound(err) {
+			transferPhase(backup, polardbxv1.XStoreBackupFailed, time.Now())
+			backup.Status.FailureReason = polardbxv1.BackupFailureCopy
+			backup.Status.Message = "xstore backup to copy from not found: " + backup.Spec.CopyFrom.BackupName
+			return flow.Retry("XStore backup to copy from not found.")
+		} else if err != nil {
+			return flow.Error(err, "Unable to get xstore backup to copy from", "source", backup.Spec.CopyFrom.BackupName)
+		}
+		// The base isn't copied along with the incremental backup, so the copy is never restorable.
+		if source.Status.Incremental != nil {
+			transferPhase(backup, polardbxv1.XStoreBackupFailed, time.Now())
+			backup.Status.FailureReason = polardbxv1.BackupFailureCopy
+			backup.Status.Message = "xstore backup to copy from is incremental: " + source.Name
+			return flow.Retry("XStore backup to copy from is incremental.")
+		}
+		pxcBackup, err := rc.GetPolarDBXBackup()
+		if err != nil {
+			return flow.Error(err, "Unable to get pxc backup")
+		}
+		if pxcBackup.Status.BackupRootPath == "" {
+			return retryWithBackoff(rc, flow, "CloneXStoreBackup", string(pxcBackup.Status.Phase),
+				"Status of pxc backup has not been updated, wait and retry")
+		}
+		resetBackoff(rc, "CloneXStoreBackup")
+
+		if backupSecret, _ := rc.GetSecret(backup.Name); backupSecret == nil {
+			secret, err := rc.GetSecret(source.Name)
+			if err != nil {
+				return flow.Error(err, "Unable to get secret of xstore backup to copy from", "source", source.Name)
+			}
+			backupSecret, err = rc.NewSecretFromXStore(secret)
+			if err != nil {
+				return flow.Error(err, "Unable to new account secret while copying")
+			}
+			if err := rc.SetControllerRefAndCreate(backupSecret); err != nil {
+				return flow.Error(err, "Unable to create account secret while copying")
+			}
+		}
+
+		nowTime := metav1.Now()
+		backup.Status.StartTime = &nowTime
+		backup.Status.EndTime = &nowTime
+		backup.Status.BackupRootPath = pxcBackup.Status.BackupRootPath
+		backup.Status.TriggerSource = pxcBackup.Status.TriggerSource
+		backup.Status.TargetPod = source.Status.TargetPod
+		backup.Status.TargetZone = source.Status.TargetZone
+		backup.Status.CommitIndex = source.Status.CommitIndex
+		backup.Status.StorageName = source.Status.StorageName
+		backup.Status.BackupSetTimestamp = source.Status.BackupSetTimestamp.DeepCopy()
+		// The clone recovers to the same window as the source, not from the time it's cloned.
+		backup.Status.EarliestRecoverableTimestamp = source.Status.EarliestRecoverableTimestamp.DeepCopy()
+		if backup.Status.EarliestRecoverableTimestamp == nil {
+			backup.Status.EarliestRecoverableTimestamp = source.Status.StartTime.DeepCopy()
+		}
+		backup.Status.BackupSize = source.Status.BackupSize
+		backup.Status.EngineVersion = source.Status.EngineVersion
+		backup.Status.Compatibility = source.Status.Compatibility.DeepCopy()
+		// Server side copies are in the default storage class.
+		backup.Status.StorageClass = backup.Spec.StorageClass
+		if source.Status.BinlogEventsCount != nil {
+			count := *source.Status.BinlogEventsCount
+			backup.Status.BinlogEventsCount = &count
+		}
+		return flow.Continue("XStore backup cloned!", "source", source.Name)
+	})
+
 var CreateBackupConfigMap = NewStepBinder("CreateBackupConfigMap",
 	func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
 		const backupJobkey = "backup"
@@ -141,7 +490,7 @@ var CreateBackupConfigMap = NewStepBinder("CreateBackupConfigMap",
 		offsetFileName := fmt.Sprintf("%s/%s/%s",
 			backupRootPath, polardbxmeta.BinlogOffsetPath, backup.Spec.XStore.Name)
 
-		if err := rc.SaveTaskContext(backupJobkey, &BackupJobContext{
+		backupJobContext := &BackupJobContext{
 			BinlogBackupDir:     binlogBackupDir,
 			IndexesPath:         indexesPath,
 			BinlogEndOffsetPath: binlogEndOffsetPath,
@@ -150,12 +499,177 @@ var CreateBackupConfigMap = NewStepBinder("CreateBackupConfigMap",
 			OffsetFileName:      offsetFileName,
 			StorageName:         string(backup.Spec.StorageProvider.StorageName),
 			Sink:                backup.Spec.StorageProvider.Sink,
-		}); err != nil {
+			OverlapCollect:      backup.Spec.OverlapCollect,
+			SkipEmptyBinlog:     backup.Spec.SkipEmptyBinlog,
+			StorageClass:        backup.Spec.StorageClass,
+			FullBackupThreads:   backup.Spec.FullBackupThreads,
+			CollectBatchBytes:   backup.Spec.CollectBatchBytes,
+			ConsistencyMode:     string(backup.Spec.ConsistencyMode),
+		}
+		if backup.Status.Incremental != nil {
+			backupJobContext.IncrementalLsn = backup.Status.Incremental.FromLsn
+		}
+		if retry := backup.Spec.UploadRetry; retry != nil && retry.MaxRetries > 0 {
+			backupJobContext.UploadRetries = retry.MaxRetries
+			if retry.Backoff.Duration > 0 {
+				backupJobContext.UploadRetryBackoff = retry.Backoff.Duration.String()
+			}
+		}
+		if wait := backup.Spec.ConsistencyWait; wait != nil && wait.Timeout.Duration > 0 {
+			backupJobContext.ConsistencyWaitTimeout = wait.Timeout.Duration.Seconds()
+			backupJobContext.ConsistencyWaitInterval = wait.Interval.Duration.Seconds()
+		}
+		if throttle := backup.Spec.Throttle; throttle != nil {
+			backupJobContext.MaxIOPS = throttle.MaxIOPS
+			backupJobContext.UploadRateLimit = throttle.MaxBandwidthMB << 20
+		}
+		if encryption := backup.Spec.Encryption; encryption != nil {
+			secret, err := rc.GetSecret(encryption.SecretName)
+			if client.IgnoreNotFound(err) != nil {
+				return flow.Error(err, "Unable to get secret of encryption key", "secret", encryption.SecretName)
+			}
+			if err == nil {
+				err = checkEncryptionKey(secret, encryption)
+			}
+			if err != nil {
+				msg := "Encryption key is unavailable: " + err.Error()
+				transferPhase(backup, xstorev1.XStoreBackupFailed, time.Now())
+				backup.Status.FailureReason = xstorev1.BackupFailureEncryptionKey
+				backup.Status.Message = msg
+				return flow.Retry(msg)
+			}
+			backupJobContext.EncryptionKeyFile = factory.BackupEncryptionKeyFile("backup")
+		}
+		xstore, err := rc.GetXStore()
+		if err != nil {
+			return flow.Error(err, "Unable to get xstore!")
+		}
+		if xstore.Spec.TDE != nil {
+			// the keyring isn't copied by xtrabackup, without which the encrypted tablespaces can't be restored
+			backupJobContext.KeyringPath = keyringPath(backupRootPath, backup.Spec.XStore.Name)
+			backup.Status.KeyringPath = backupJobContext.KeyringPath
+		}
+		if backup.Spec.EnableDedupReport {
+			backupJobContext.EnableDedupReport = true
+			backupJobContext.ChunkManifestPath = chunkManifestPath(backupRootPath, backup.Spec.XStore.Name)
+			base, err := getDedupBaseBackup(rc, backup)
+			if err != nil {
+				return flow.Error(err, "Unable to find base backup for dedup report")
+			}
+			backup.Status.DedupReport = &xstorev1.BackupDedupReport{}
+			if base != nil {
+				backupJobContext.BaseManifestPath = chunkManifestPath(base.Status.BackupRootPath, base.Spec.XStore.Name)
+				backup.Status.DedupReport.BaseBackup = base.Name
+			}
+		}
+
+		if err := rc.SaveTaskContext(backupJobkey, backupJobContext); err != nil {
 			return flow.Error(err, "Unable to save job context for backup!")
 		}
 		return flow.Continue("Job context for backup prepared!")
 	})
 
+// sourceLagUnknownTimeout bounds the wait for the replication lag of the follower to back up to be
+// known, counted from the start of the backup.
+const sourceLagUnknownTimeout = 10 * time.Minute
+
+// isSourceLagUnknownTimedOut tells whether the replication lag has been unknown for too long.
+func isSourceLagUnknownTimedOut(backup *xstorev1.XStoreBackup, now time.Time) bool {
+	return backup.Status.StartTime != nil && now.Sub(backup.Status.StartTime.Time) >= sourceLagUnknownTimeout
+}
+
+// CheckBackupSourceLag checks the replication lag of the follower to back up against the bound
+// and records it in status. If exceeded, or unknown until the timeout, the backup falls back to
+// leader or fails.
+var CheckBackupSourceLag = NewStepBinder("CheckBackupSourceLag",
+	func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
+		xstoreBackup := rc.MustGetXStoreBackup()
+		maxLag := xstoreBackup.Spec.MaxFollowerLag.Duration
+		if maxLag <= 0 {
+			return flow.Pass()
+		}
+
+		job, err := rc.GetXStoreBackupJob()
+		if client.IgnoreNotFound(err) != nil {
+			return flow.Error(err, "Unable to get full backup job!")
+		}
+		if job != nil {
+			return flow.Pass()
+		}
+
+		targetPod, err := rc.GetXStoreTargetPod()
+		if err != nil {
+			return flow.Error(err, "Unable to find target pod!")
+		}
+		if targetPod == nil {
+			return flow.Wait("Unable to find target pod!")
+		}
+		role := targetPod.Labels[xstoremeta.LabelRole]
+		if role != xstoremeta.RoleFollower && role != xstoremeta.RoleLearner {
+			return flow.Pass()
+		}
+
+		manager, err := rc.GetXstoreGroupManagerByPod(targetPod)
+		if err != nil {
+			return flow.RetryErr(err, "Unable to connect to target pod", "pod", targetPod.Name)
+		}
+		var secondsBehindMaster *int64
+		if manager != nil {
+			slaveStatus, err := manager.ShowSlaveStatus()
+			if err != nil {
+				return flow.RetryErr(err, "Unable to show slave status", "pod", targetPod.Name)
+			}
+			if slaveStatus != nil {
+				secondsBehindMaster = slaveStatus.SecondsBehindMaster
+			}
+		}
+
+		var msg string
+		failureReason := xstorev1.BackupFailureSourceLag
+		if secondsBehindMaster == nil {
+			if !isSourceLagUnknownTimedOut(xstoreBackup, time.Now()) {
+				return retryWithBackoff(rc, flow, "CheckBackupSourceLag", "",
+					"Replication lag of target pod is unknown, wait and retry", "pod", targetPod.Name)
+			}
+			resetBackoff(rc, "CheckBackupSourceLag")
+			msg = fmt.Sprintf("Replication lag of %s %s is still unknown %s after the backup started",
+				role, targetPod.Name, sourceLagUnknownTimeout)
+			failureReason = xstorev1.BackupFailureSourceLagUnknown
+		} else {
+			resetBackoff(rc, "CheckBackupSourceLag")
+			lag := time.Duration(*secondsBehindMaster) * time.Second
+			xstoreBackup.Status.SourceLag = lag.String()
+			if lag <= maxLag {
+				return flow.Continue("Replication lag of target pod is within bound.", "pod", targetPod.Name, "lag", lag)
+			}
+			msg = fmt.Sprintf("Replication lag %s of %s %s exceeds %s", lag, role, targetPod.Name, maxLag)
+		}
+
+		if !xstoreBackup.Spec.FallbackToLeaderOnLag {
+			transferPhase(xstoreBackup, xstorev1.XStoreBackupFailed, time.Now())
+			xstoreBackup.Status.FailureReason = failureReason
+			xstoreBackup.Status.Message = msg
+			return flow.Retry(msg)
+		}
+		pods, err := rc.GetXStorePods()
+		if err != nil {
+			return flow.Error(err, "Unable to get pods of xstore")
+		}
+		var leaderPod *corev1.Pod
+		for i := range pods {
+			if pods[i].Labels[xstoremeta.LabelRole] == xstoremeta.RoleLeader {
+				leaderPod = &pods[i]
+				break
+			}
+		}
+		if leaderPod == nil {
+			return retryWithBackoff(rc, flow, "CheckBackupSourceLag", "fallback", "Leader pod not found")
+		}
+		// Target pod in status is preferred by the following steps.
+		xstoreBackup.Status.TargetPod = leaderPod.Name
+		return flow.Retry(msg+", fall back to leader", "leader-pod", leaderPod.Name)
+	})
+
 var StartXStoreFullBackupJob = NewStepBinder("StartXStoreFullBackupJob",
 	func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
 		const backupJobKey = "backup"
@@ -187,6 +701,17 @@ var StartXStoreFullBackupJob = NewStepBinder("StartXStoreFullBackupJob",
 
 		jobName := GenerateJobName(targetPod, "backup")
 		xstoreBackup.Status.TargetPod = targetPod.Name
+		if len(xstoreBackup.Spec.PreferSourceZone) > 0 {
+			zone, err := rc.GetPodZone(targetPod)
+			if err != nil {
+				return flow.Error(err, "Unable to get zone of target pod", "pod", targetPod.Name)
+			}
+			xstoreBackup.Status.TargetZone = zone
+			if zone != xstoreBackup.Spec.PreferSourceZone {
+				flow.Logger().Info("Warning: no available pod in preferred zone, fall back",
+					"preferred-zone", xstoreBackup.Spec.PreferSourceZone, "zone", zone, "pod", targetPod.Name)
+			}
+		}
 
 		job, e := newBackupJob(xstoreBackup, targetPod, jobName)
 		if e != nil {
@@ -211,12 +736,21 @@ var WaitFullBackupJobFinished = NewStepBinder("WaitFullBackupJobFinished",
 		if job == nil {
 			return flow.Continue("Full Backup job removed!")
 		}
+		if done, result, err := handleJobOfOtherVersion(rc, flow, job, true, xstorev1.XStoreBackupNew); done {
+			return result, err
+		}
 
-		if !k8shelper.IsJobCompleted(job) {
-			return flow.Wait("Full Backup job is still running!", "job-name", job.Name)
+		if k8shelper.IsJobFailed(job) {
+			return failBackupOnFullBackupJobFailure(rc, flow, job)
+		}
+		if !mayStartCollect(job, xstoreBackup.Spec.OverlapCollect) {
+			return refreshJobProgressOnTargetPod(rc, flow, job, refreshFullBackupProgress, "Full Backup job is still running!")
 		}
 
-		flow.Logger().Info("Full Backup job completed!", "job-name", job.Name)
+		completed := k8shelper.IsJobCompleted(job)
+		if completed {
+			flow.Logger().Info("Full Backup job completed!", "job-name", job.Name)
+		}
 
 		targetPod, err := rc.GetXStoreTargetPod()
 		if err != nil {
@@ -234,6 +768,11 @@ var WaitFullBackupJobFinished = NewStepBinder("WaitFullBackupJobFinished",
 		})
 		if err != nil {
 			if ee, ok := xstorectrlerrors.ExitError(err); ok {
+				if ee.ExitStatus() != 0 && !completed {
+					// Not captured yet, and there's no event of the job until it finishes.
+					return retryWithBackoff(rc, flow, "WaitFullBackupJobFinished", "",
+						"Consistent point of full backup not captured yet", "pod", targetPod.Name)
+				}
 				if ee.ExitStatus() != 0 {
 					return flow.Wait("Failed to cat full backup job index", "pod", targetPod.Name, "exit-status", ee.ExitStatus())
 				}
@@ -244,9 +783,209 @@ var WaitFullBackupJobFinished = NewStepBinder("WaitFullBackupJobFinished",
 		if err != nil {
 			return flow.Error(err, "Failed to parse int for stdout", "pod", targetPod.Name, "stdout", stdout.String())
 		}
+		resetBackoff(rc, "WaitFullBackupJobFinished")
+		if !completed {
+			// The index is written once the consistent point is captured, the statistics are
+			// collected in WaitOverlappedFullBackupJobFinished.
+			return flow.Continue("Consistent point of full backup captured, collect binlog in advance!", "job-name", job.Name)
+		}
+		refreshFullBackupProgress(rc, flow, targetPod, job, xstoreBackup)
+		collectFullBackupStatistics(rc, flow, targetPod, job.Name, xstoreBackup)
 		return flow.Continue("Full Backup job wait finished!", "job-name", job.Name)
 	})
 
+// mayStartCollect tells whether the binlog collection can be started. Without overlap, the full backup
+// job must be completed. With overlap, the collection can start while the job is still running, and
+// it starts only when the consistent point (binlog commit index) is captured.
+func mayStartCollect(job *batchv1.Job, overlap bool) bool {
+	if k8shelper.IsJobFailed(job) {
+		return false
+	}
+	return k8shelper.IsJobCompleted(job) || overlap
+}
+
+func collectFullBackupStatistics(rc *xstorev1reconcile.BackupContext, flow control.Flow, targetPod *corev1.Pod, jobName string, xstoreBackup *xstorev1.XStoreBackup) {
+	if err := collectBackupSize(rc, targetPod, jobName, xstoreBackup); err != nil {
+		flow.Logger().Error(err, "Unable to collect backup size", "pod", targetPod.Name)
+	}
+	if err := collectEngineCompatibility(rc, flow, targetPod, jobName, xstoreBackup); err != nil {
+		flow.Logger().Error(err, "Unable to collect engine compatibility", "pod", targetPod.Name)
+	}
+	if err := collectConsistencyMode(rc, flow, targetPod, jobName, xstoreBackup); err != nil {
+		flow.Logger().Error(err, "Unable to collect consistency mode", "pod", targetPod.Name)
+	}
+	// Incremental backups can't be based on the backup without LSN.
+	if err := collectBackupLsn(rc, flow, targetPod, jobName, xstoreBackup); err != nil {
+		flow.Logger().Error(err, "Unable to collect backup LSN", "pod", targetPod.Name)
+	}
+	if xstoreBackup.Spec.EnableDedupReport {
+		// Dedup report is only a measurement, never fail the backup for it.
+		if err := collectDedupReport(rc, targetPod, jobName, xstoreBackup); err != nil {
+			flow.Logger().Error(err, "Unable to collect dedup report", "pod", targetPod.Name)
+		}
+	}
+}
+
+// WaitOverlappedFullBackupJobFinished waits the full backup job which overlaps with the binlog collection,
+// the backup never reaches the waiting phase before the full backup is uploaded.
+var WaitOverlappedFullBackupJobFinished = NewStepBinder("WaitOverlappedFullBackupJobFinished",
+	func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
+		xstoreBackup := rc.MustGetXStoreBackup()
+		if !xstoreBackup.Spec.OverlapCollect {
+			return flow.Pass()
+		}
+
+		job, err := rc.GetXStoreBackupJob()
+		if client.IgnoreNotFound(err) != nil {
+			return flow.Error(err, "Unable to get full backup job!")
+		}
+		if job == nil {
+			return flow.Continue("Full Backup job removed!")
+		}
+		// The consistent point of the job has been consumed by the binlog collection.
+		if done, result, err := handleJobOfOtherVersion(rc, flow, job, false, ""); done {
+			return result, err
+		}
+		if k8shelper.IsJobFailed(job) {
+			return failBackupOnFullBackupJobFailure(rc, flow, job)
+		}
+		if !k8shelper.IsJobCompleted(job) {
+			return refreshJobProgressOnTargetPod(rc, flow, job, refreshFullBackupProgress, "Full Backup job is still running!")
+		}
+
+		targetPod, err := rc.GetXStoreTargetPod()
+		if err != nil {
+			return flow.Error(err, "Unable to get targetPod")
+		}
+		refreshFullBackupProgress(rc, flow, targetPod, job, xstoreBackup)
+		collectFullBackupStatistics(rc, flow, targetPod, job.Name, xstoreBackup)
+		return flow.Continue("Overlapped full backup job wait finished!", "job-name", job.Name)
+	})
+
+func collectBackupSize(rc *xstorev1reconcile.BackupContext, targetPod *corev1.Pod, jobName string, xstoreBackup *xstorev1.XStoreBackup) error {
+	command := []string{"cat", "/data/mysql/tmp/" + jobName + ".size"}
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	if err := rc.ExecuteCommandOn(targetPod, "engine", command, control.ExecOptions{
+		Stdout: stdout,
+		Stderr: stderr,
+	}); err != nil {
+		return fmt.Errorf("failed to cat backup size: %w, stderr: %s", err, stderr.String())
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(stdout.String()), 10, 64)
+	if err != nil {
+		return err
+	}
+	xstoreBackup.Status.BackupSize = size
+	return nil
+}
+
+// collectBinlogSegments reads the count of segments written by the batched binlog collection, which is
+// recorded only after the final segment is flushed.
+func collectBinlogSegments(rc *xstorev1reconcile.BackupContext, targetPod *corev1.Pod, jobName string) (int32, error) {
+	command := []string{"cat", "/data/mysql/tmp/" + jobName + ".segments"}
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	if err := rc.ExecuteCommandOn(targetPod, "engine", command, control.ExecOptions{
+		Stdout: stdout,
+		Stderr: stderr,
+	}); err != nil {
+		return 0, fmt.Errorf("failed to cat collect segments: %w, stderr: %s", err, stderr.String())
+	}
+	segments, err := strconv.ParseInt(strings.TrimSpace(stdout.String()), 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	if segments <= 0 {
+		return 0, fmt.Errorf("invalid collect segments: %d", segments)
+	}
+	return int32(segments), nil
+}
+
+func collectDedupReport(rc *xstorev1reconcile.BackupContext, targetPod *corev1.Pod, jobName string, xstoreBackup *xstorev1.XStoreBackup) error {
+	command := []string{"cat", "/data/mysql/tmp/" + jobName + ".dedup"}
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	if err := rc.ExecuteCommandOn(targetPod, "engine", command, control.ExecOptions{
+		Stdout: stdout,
+		Stderr: stderr,
+	}); err != nil {
+		return fmt.Errorf("failed to cat dedup report: %w, stderr: %s", err, stderr.String())
+	}
+
+	report := xstorev1.BackupDedupReport{}
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		return err
+	}
+	if xstoreBackup.Status.DedupReport != nil {
+		report.BaseBackup = xstoreBackup.Status.DedupReport.BaseBackup
+	}
+	if report.TotalBytes > 0 {
+		report.DedupRatio = strconv.FormatFloat(float64(report.SharedBytes)/float64(report.TotalBytes), 'f', 2, 64)
+	}
+	xstoreBackup.Status.DedupReport = &report
+	return nil
+}
+
+// collectEngineCompatibility reads the engine facts checked before restoring the backup, which are
+// absent if the backup is done by tools of older versions.
+func collectEngineCompatibility(rc *xstorev1reconcile.BackupContext, flow control.Flow, targetPod *corev1.Pod, jobName string, xstoreBackup *xstorev1.XStoreBackup) error {
+	output, found, err := catFileOnPod(rc, flow, targetPod, "/data/mysql/tmp/"+jobName+".compat")
+	if err != nil || !found {
+		return err
+	}
+	compatibility := &polardbxv1xstore.EngineCompatibility{}
+	if err := json.Unmarshal([]byte(output), compatibility); err != nil {
+		return err
+	}
+	xstoreBackup.Status.Compatibility = compatibility
+	return nil
+}
+
+// consistencyStatusOf parses the consistency mode written by the full backup job. Jobs of older
+// versions write nothing, which means the default mode is used.
+func consistencyStatusOf(requested xstorev1.BackupConsistencyMode, output string) (*xstorev1.BackupConsistencyStatus, error) {
+	if len(requested) == 0 {
+		requested = xstorev1.ConsistencyModeDefault
+	}
+	status := &xstorev1.BackupConsistencyStatus{
+		Requested: requested,
+		Used:      xstorev1.ConsistencyModeDefault,
+	}
+	if len(strings.TrimSpace(output)) == 0 {
+		return status, nil
+	}
+	used := struct {
+		Mode   xstorev1.BackupConsistencyMode `json:"mode"`
+		Reason string                         `json:"reason"`
+	}{}
+	if err := json.Unmarshal([]byte(output), &used); err != nil {
+		return nil, err
+	}
+	if len(used.Mode) > 0 {
+		status.Used = used.Mode
+	}
+	status.Reason = used.Reason
+	return status, nil
+}
+
+func collectConsistencyMode(rc *xstorev1reconcile.BackupContext, flow control.Flow, targetPod *corev1.Pod, jobName string, xstoreBackup *xstorev1.XStoreBackup) error {
+	output, _, err := catFileOnPod(rc, flow, targetPod, "/data/mysql/tmp/"+jobName+".consistency")
+	if err != nil {
+		return err
+	}
+	status, err := consistencyStatusOf(xstoreBackup.Spec.ConsistencyMode, output)
+	if err != nil {
+		return err
+	}
+	xstoreBackup.Status.Consistency = status
+	if status.Used != status.Requested {
+		flow.Logger().Info("Full backup fell back to another consistency mode.", "requested", status.Requested,
+			"used", status.Used, "reason", status.Reason)
+	}
+	return nil
+}
+
 var RemoveFullBackupJob = NewStepBinder("RemoveFullBackupJob",
 	func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
 		job, err := rc.GetXStoreBackupJob()
@@ -261,6 +1000,9 @@ var RemoveFullBackupJob = NewStepBinder("RemoveFullBackupJob",
 		if client.IgnoreNotFound(err) != nil {
 			return flow.Error(err, "Unable to remove full backup job", "job-name", job.Name)
 		}
+		if err == nil {
+			polardbxhelper.EmitBackupJobSpan(rc.MustGetXStoreBackup().Status.Trace, job)
+		}
 
 		return flow.Continue("Full backup job removed!", "job-name", job.Name)
 	})
@@ -272,8 +1014,10 @@ var WaitBinlogOffsetCollected = NewStepBinder("WaitBinlogCollected",
 			flow.Error(err, "Unable to find polardbxBackup")
 		}
 		if polardbxBackup.Status.Phase != polardbxv1.BackupCalculating {
-			return flow.RetryAfter(5*time.Second, "Wait polardbx backup Collected", "pxcBackup", polardbxBackup.Name)
+			return retryWithBackoff(rc, flow, "WaitBinlogOffsetCollected", string(polardbxBackup.Status.Phase),
+				"Wait polardbx backup Collected", "pxcBackup", polardbxBackup.Name)
 		}
+		resetBackoff(rc, "WaitBinlogOffsetCollected")
 		return flow.Continue("Binlog Collected!")
 	})
 
@@ -343,12 +1087,34 @@ var WaitCollectBinlogJobFinished = NewStepBinder("WaitBackupJobFinished",
 		if job == nil {
 			return flow.Continue("Collect binlog job removed!")
 		}
+		if done, result, err := handleJobOfOtherVersion(rc, flow, job, true, ""); done {
+			return result, err
+		}
 
+		if k8shelper.IsJobFailed(job) {
+			return failBackupOnJobFailure(rc, flow, job, "Collect binlog job failed")
+		}
 		if !k8shelper.IsJobCompleted(job) {
 			return flow.Wait("Collect binlog is still running!", "job-name", job.Name)
 		}
 		flow.Logger().Info("Collect binlog job completed!", "job-name", job.Name)
 
+		xstoreBackup := rc.MustGetXStoreBackup()
+		if xstoreBackup.Spec.CollectBatchBytes > 0 && xstoreBackup.Status.CollectSegments == 0 {
+			targetPod, err := rc.GetXStoreTargetPod()
+			if err != nil {
+				return flow.Error(err, "Unable to find target pod!")
+			}
+			if targetPod == nil {
+				return flow.Wait("Unable to find target pod!")
+			}
+			segments, err := collectBinlogSegments(rc, targetPod, job.Name)
+			if err != nil {
+				return flow.Error(err, "Unable to get segments of collected binlog, final segment may not be flushed", "job-name", job.Name)
+			}
+			xstoreBackup.Status.CollectSegments = segments
+		}
+
 		return flow.Continue("Collect binlog wait finished!", "job-name", job.Name)
 	})
 
@@ -366,6 +1132,9 @@ var RemoveCollectBinlogJob = NewStepBinder("RemoveCollectBinlogJob",
 		if client.IgnoreNotFound(err) != nil {
 			return flow.Error(err, "Unable to remove collect binlog job", "job-name", job.Name)
 		}
+		if err == nil {
+			polardbxhelper.EmitBackupJobSpan(rc.MustGetXStoreBackup().Status.Trace, job)
+		}
 
 		return flow.Continue("Collect binlog job removed!", "job-name", job.Name)
 	})
@@ -377,8 +1146,10 @@ var WaitPXCSeekCpJobFinished = NewStepBinder("WaitPXCSeekCpJobFinished",
 			flow.Error(err, "Unable to find polardbxBackup")
 		}
 		if polardbxBackup.Status.Phase != polardbxv1.BinlogBackuping {
-			return flow.RetryAfter(5*time.Second, "Wait polardbx backup Calculating", "polardbxbackup", polardbxBackup.Name)
+			return retryWithBackoff(rc, flow, "WaitPXCSeekCpJobFinished", string(polardbxBackup.Status.Phase),
+				"Wait polardbx backup Calculating", "polardbxbackup", polardbxBackup.Name)
 		}
+		resetBackoff(rc, "WaitPXCSeekCpJobFinished")
 		if err != nil {
 			flow.Error(err, "Unable to get binlogOffset!")
 		}
@@ -439,47 +1210,360 @@ var WaitBinlogBackupJobFinished = NewStepBinder("WaitBinlogBackupJobFinished",
 			flow.Logger().Info("Binlog backup job nil!", "err", err)
 			return flow.Continue("Binlog backup job removed!")
 		}
+		if done, result, err := handleJobOfOtherVersion(rc, flow, job, true, ""); done {
+			return result, err
+		}
+		if k8shelper.IsJobFailed(job) {
+			return failBackupOnJobFailure(rc, flow, job, "Binlog backup job failed")
+		}
 		if !k8shelper.IsJobCompleted(job) {
-			return flow.Wait("Binlog backup job is still running!", "job-name", job.Name)
+			return refreshJobProgressOnTargetPod(rc, flow, job, refreshBinlogBackupProgress, "Binlog backup job is still running!")
+		}
+		if targetPod, err := rc.GetXStoreTargetPod(); err != nil {
+			flow.Logger().Error(err, "Unable to get targetPod")
+		} else {
+			refreshBinlogBackupProgress(rc, flow, targetPod, job, rc.MustGetXStoreBackup())
 		}
 		return flow.Continue("Binlog backup job wait finished!", "job-name", job.Name)
 	})
 
+// lastEventTimestampClockSkew is the tolerated clock skew between the operator and the pod.
+const lastEventTimestampClockSkew = 5 * time.Minute
+
+var lastEventTimestampPattern = regexp.MustCompile(`LAST EVENT TIMESTAMP:\s*(\d+)`)
+
+// parseLastEventTimestamp parses the last event timestamp written by the binlog backup job. Besides
+// the unix seconds, it also accepts unix milliseconds, the raw output line of the truncate command
+// and datetime in RFC3339 or MySQL format. The MySQL datetime is in the server time zone, which is
+// given by zone, or UTC if unknown. The timestamp is returned in UTC.
+func parseLastEventTimestamp(output string, zone *time.Location) (time.Time, error) {
+	s := strings.TrimSpace(output)
+	if len(s) == 0 {
+		return time.Time{}, errors.New("empty last event timestamp")
+	}
+	if m := lastEventTimestampPattern.FindStringSubmatch(s); m != nil {
+		s = m[1]
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		// Unix seconds won't have 13 digits until year 33658.
+		if len(s) >= 13 {
+			return time.UnixMilli(n).UTC(), nil
+		}
+		return time.Unix(n, 0).UTC(), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t.UTC(), nil
+	}
+	if zone == nil {
+		zone = time.UTC
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05", s, zone); err == nil {
+		return t.UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("unrecognized last event timestamp: %q", s)
+}
+
+// parseTimeZoneOffset parses the offset in seconds of the server time zone written by the binlog
+// backup job, and returns the zone along with the offset formatted like "+08:00".
+func parseTimeZoneOffset(output string) (*time.Location, string, error) {
+	s := strings.TrimSpace(output)
+	offset, err := strconv.Atoi(s)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid time zone offset: %q", s)
+	}
+	// Offsets of real time zones are within [-12:00, +14:00].
+	if offset < -12*3600 || offset > 14*3600 {
+		return nil, "", fmt.Errorf("time zone offset out of range: %d", offset)
+	}
+	sign, abs := '+', offset
+	if offset < 0 {
+		sign, abs = '-', -offset
+	}
+	formatted := fmt.Sprintf("%c%02d:%02d", sign, abs/3600, abs%3600/60)
+	return time.FixedZone(formatted, offset), formatted, nil
+}
+
+// validateLastEventTimestamp checks the timestamp is neither zero, in the future, nor before the start
+// of the backup, with the clock skew tolerated.
+func validateLastEventTimestamp(t time.Time, startTime *metav1.Time, now time.Time) error {
+	if t.Unix() <= 0 {
+		return fmt.Errorf("last event timestamp %d is not positive", t.Unix())
+	}
+	if t.After(now.Add(lastEventTimestampClockSkew)) {
+		return fmt.Errorf("last event timestamp %s is in the future", t.UTC().Format(time.RFC3339))
+	}
+	if startTime != nil && t.Before(startTime.Add(-lastEventTimestampClockSkew)) {
+		return fmt.Errorf("last event timestamp %s is before the backup start time %s",
+			t.UTC().Format(time.RFC3339), startTime.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+func failBackupOnInvalidTimestamp(rc *xstorev1reconcile.BackupContext, flow control.Flow, err error) (reconcile.Result, error) {
+	backup := rc.MustGetXStoreBackup()
+	transferPhase(backup, polardbxv1.XStoreBackupFailed, time.Now())
+	backup.Status.FailureReason = polardbxv1.BackupFailureInvalidTimestamp
+	backup.Status.Message = err.Error()
+	return flow.Retry("Invalid last event timestamp, backup failed.", "error", err.Error())
+}
+
+// catBinlogBackupFile reads the file written by the binlog backup job on the pod, found is false if
+// the file doesn't exist.
+func catBinlogBackupFile(rc *xstorev1reconcile.BackupContext, flow control.Flow, pod *corev1.Pod, name string) (content string, found bool, err error) {
+	return catFileOnPod(rc, flow, pod, "/data/mysql/backup/binlogbackup/"+name)
+}
+
+// catFileOnPod reads the file on the pod, found is false if the file doesn't exist.
+func catFileOnPod(rc *xstorev1reconcile.BackupContext, flow control.Flow, pod *corev1.Pod, path string) (content string, found bool, err error) {
+	cmd := []string{"cat", path}
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	err = rc.ExecuteCommandOn(pod, "engine", cmd, control.ExecOptions{
+		Logger: flow.Logger(),
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+	if err != nil {
+		if ee, ok := xstorectrlerrors.ExitError(err); ok && ee.ExitStatus() != 0 {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("failed to cat %s: %w, stderr: %s", path, err, stderr.String())
+	}
+	return stdout.String(), true, nil
+}
+
+// sumObjectStatsOnPod reads the json files of per object stats, e.g. retries, on the pod and sums
+// them up by object. Missing files are skipped, and so are invalid ones since the stats are only
+// a measurement, never fail the backup for them.
+func sumObjectStatsOnPod(rc *xstorev1reconcile.BackupContext, flow control.Flow, pod *corev1.Pod, paths []string) (map[string]int64, error) {
+	stats := make(map[string]int64)
+	for _, path := range paths {
+		output, found, err := catFileOnPod(rc, flow, pod, path)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			continue
+		}
+		objects := make(map[string]int64)
+		if err := json.Unmarshal([]byte(output), &objects); err != nil {
+			flow.Logger().Error(err, "Invalid object stats", "pod", pod.Name, "path", path)
+			continue
+		}
+		for object, n := range objects {
+			stats[object] += n
+		}
+	}
+	return stats, nil
+}
+
+// uploadRetryStatsOf sums up the retries of objects, which are sorted most retried first and cut
+// to MaxBackupRetriedObjects.
+func uploadRetryStatsOf(retries map[string]int64) *xstorev1.BackupUploadRetryStats {
+	stats := &xstorev1.BackupUploadRetryStats{}
+	for path, n := range retries {
+		if n <= 0 {
+			continue
+		}
+		stats.TotalRetries += n
+		stats.Objects = append(stats.Objects, xstorev1.ObjectUploadRetries{Path: path, Retries: n})
+	}
+	sort.Slice(stats.Objects, func(i, j int) bool {
+		if stats.Objects[i].Retries != stats.Objects[j].Retries {
+			return stats.Objects[i].Retries > stats.Objects[j].Retries
+		}
+		return stats.Objects[i].Path < stats.Objects[j].Path
+	})
+	if len(stats.Objects) > xstorev1.MaxBackupRetriedObjects {
+		stats.Objects = stats.Objects[:xstorev1.MaxBackupRetriedObjects]
+	}
+	return stats
+}
+
+// CollectUploadRetries reads the retries of objects uploaded by the full backup and binlog backup
+// jobs into the upload retry stats. It's idempotent, the stats won't be read again once collected.
+var CollectUploadRetries = NewStepBinder("CollectUploadRetries",
+	func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetXStoreBackup()
+		if backup.Spec.UploadRetry == nil || backup.Status.UploadRetries != nil {
+			return flow.Pass()
+		}
+
+		targetPod, err := rc.GetXStoreTargetPod()
+		if err != nil {
+			return flow.Error(err, "Unable to get targetPod")
+		}
+		paths := []string{"/data/mysql/backup/binlogbackup/upload_retries"}
+		job, err := rc.GetXStoreBackupJob()
+		if client.IgnoreNotFound(err) != nil {
+			return flow.Error(err, "Unable to get full backup job!")
+		}
+		if job != nil {
+			paths = append(paths, "/data/mysql/tmp/"+job.Name+".retries")
+		}
+
+		// The files are absent if nothing is retried or the jobs are done by tools of older versions.
+		retries, err := sumObjectStatsOnPod(rc, flow, targetPod, paths)
+		if err != nil {
+			return flow.Error(err, "Failed to read upload retries", "pod", targetPod.Name)
+		}
+		backup.Status.UploadRetries = uploadRetryStatsOf(retries)
+		return flow.Continue("Upload retries collected.", "pod", targetPod.Name,
+			"total-retries", backup.Status.UploadRetries.TotalRetries)
+	})
+
+// consistencyWaitStatsOf sums up the waits of objects, which are sorted longest waited first and
+// cut to MaxBackupWaitedObjects.
+func consistencyWaitStatsOf(waits map[string]int64) *xstorev1.BackupConsistencyWaitStats {
+	stats := &xstorev1.BackupConsistencyWaitStats{}
+	for path, millis := range waits {
+		if millis <= 0 {
+			continue
+		}
+		stats.TotalWaitMillis += millis
+		stats.Objects = append(stats.Objects, xstorev1.ObjectConsistencyWait{Path: path, WaitMillis: millis})
+	}
+	sort.Slice(stats.Objects, func(i, j int) bool {
+		if stats.Objects[i].WaitMillis != stats.Objects[j].WaitMillis {
+			return stats.Objects[i].WaitMillis > stats.Objects[j].WaitMillis
+		}
+		return stats.Objects[i].Path < stats.Objects[j].Path
+	})
+	if len(stats.Objects) > xstorev1.MaxBackupWaitedObjects {
+		stats.Objects = stats.Objects[:xstorev1.MaxBackupWaitedObjects]
+	}
+	return stats
+}
+
+// CollectConsistencyWaits reads the waits of objects uploaded by the full backup and binlog backup
+// jobs until they are readable into the consistency wait stats. It's idempotent like the upload
+// retries.
+var CollectConsistencyWaits = NewStepBinder("CollectConsistencyWaits",
+	func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetXStoreBackup()
+		if backup.Spec.ConsistencyWait == nil || backup.Status.ConsistencyWaits != nil {
+			return flow.Pass()
+		}
+
+		targetPod, err := rc.GetXStoreTargetPod()
+		if err != nil {
+			return flow.Error(err, "Unable to get targetPod")
+		}
+		paths := []string{"/data/mysql/backup/binlogbackup/consistency_waits"}
+		job, err := rc.GetXStoreBackupJob()
+		if client.IgnoreNotFound(err) != nil {
+			return flow.Error(err, "Unable to get full backup job!")
+		}
+		if job != nil {
+			paths = append(paths, "/data/mysql/tmp/"+job.Name+".waits")
+		}
+
+		// The files are absent if all the objects are readable at once.
+		waits, err := sumObjectStatsOnPod(rc, flow, targetPod, paths)
+		if err != nil {
+			return flow.Error(err, "Failed to read consistency waits", "pod", targetPod.Name)
+		}
+		backup.Status.ConsistencyWaits = consistencyWaitStatsOf(waits)
+		return flow.Continue("Consistency waits collected.", "pod", targetPod.Name,
+			"total-wait-millis", backup.Status.ConsistencyWaits.TotalWaitMillis)
+	})
+
+// setBackupCondition adds the condition to the backup or replaces the one of the same type.
+func setBackupCondition(backup *polardbxv1.XStoreBackup, condition polardbxv1xstore.Condition) {
+	backup.Status.Conditions = polardbxhelper.SetBackupCondition(backup.Status.Conditions, condition)
+}
+
+// UpdateBackupConditions publishes the standard conditions of the xstore backup, i.e. Ready, Progressing
+// and Degraded. The phases it has gone through are recorded in the phase history.
+var UpdateBackupConditions = NewStepBinder("UpdateBackupConditions",
+	func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetXStoreBackup()
+		phase := backup.Status.Phase
+		if phase == xstorev1.XStoreBackupNew {
+			return flow.Pass()
+		}
+
+		now := metav1.Now()
+		for _, c := range polardbxhelper.NewBackupStatusConditions(string(phase), phase == xstorev1.XStoreBackupFinished,
+			phase == xstorev1.XStoreBackupFailed, string(backup.Status.FailureReason), backup.Status.Conditions) {
+			c.LastTransitionTime = now
+			c.ObservedGeneration = backup.Generation
+			setBackupCondition(backup, c)
+		}
+		return flow.Pass()
+	})
+
+// ExtractLastEventTimestamp reads the last event timestamp of the binlog backup as the backup set
+// timestamp. It's idempotent, the timestamp won't be read again once extracted. If no change events
+// are found in the binlogs, the end time of the backup is taken as the timestamp.
 var ExtractLastEventTimestamp = NewStepBinder("ExtractLastEventTimestamp",
 	func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
 		backup := rc.MustGetXStoreBackup()
+		if backup.Status.BackupSetTimestamp != nil {
+			return flow.Pass()
+		}
 		nowTime := metav1.Now()
 		backup.Status.EndTime = &nowTime
 
 		targetPod, err := rc.GetXStoreTargetPod()
 		if err != nil {
-			flow.Error(err, "Unable to get targetPod")
+			return flow.Error(err, "Unable to get targetPod")
 		}
-		Command := []string{"cat", "/data/mysql/backup/binlogbackup/last_event_timestamp"}
-		stdout := &bytes.Buffer{}
-		stderr := &bytes.Buffer{}
-		err = rc.ExecuteCommandOn(targetPod, "engine", Command, control.ExecOptions{
-			Logger: flow.Logger(),
-			Stdin:  nil,
-			Stdout: stdout,
-			Stderr: stderr,
-		})
+
+		// The count is absent if the binlog backup is done by tools of older versions.
+		output, found, err := catBinlogBackupFile(rc, flow, targetPod, "events_count")
 		if err != nil {
-			if ee, ok := xstorectrlerrors.ExitError(err); ok {
-				if ee.ExitStatus() != 0 {
-					return flow.Wait("Failed to cat last event timestamp", "pod", targetPod.Name, "exit-status", ee.ExitStatus())
-				}
+			return flow.Error(err, "Failed to read binlog events count", "pod", targetPod.Name)
+		}
+		if found {
+			count, err := strconv.ParseInt(strings.TrimSpace(output), 10, 64)
+			if err != nil {
+				return flow.Error(err, "Invalid binlog events count", "pod", targetPod.Name, "output", output)
 			}
-			return flow.Error(err, "Failed to cat last event timestamp", "pod", targetPod.Name, "stdout", stdout.String(), "stderr", stderr.String())
+			backup.Status.BinlogEventsCount = &count
+		}
+		if backup.Status.BinlogEventsCount != nil && *backup.Status.BinlogEventsCount == 0 {
+			setBackupCondition(backup, polardbxv1xstore.Condition{
+				Type:               polardbxv1xstore.BackupNoChanges,
+				Status:             corev1.ConditionTrue,
+				Reason:             "NoChangeEvents",
+				Message:            "No change events found in binlogs since the last backup.",
+				LastTransitionTime: nowTime,
+			})
+			// Nothing changed up to the end of the backup, so it's recoverable to the end time.
+			backup.Status.BackupSetTimestamp = backup.Status.EndTime.DeepCopy()
+			return flow.Continue("No change events, backup set timestamp set to the end time.",
+				"pod", targetPod.Name, "timestamp", nowTime.Unix())
 		}
-		output := stdout.String()
-		timestampNum, err := strconv.ParseInt(output, 10, 64)
+
+		output, found, err = catBinlogBackupFile(rc, flow, targetPod, "last_event_timestamp")
 		if err != nil {
-			return flow.Error(err, "Invalid last event timestamp", "pod", targetPod.Name, "error", err)
+			return flow.Error(err, "Failed to read last event timestamp", "pod", targetPod.Name)
+		}
+		if !found {
+			// The binlog backup job has finished, the file won't show up anymore.
+			return failBackupOnInvalidTimestamp(rc, flow, fmt.Errorf("last event timestamp not found on pod %s", targetPod.Name))
+		}
+		// The offset is absent if the binlog backup is done by tools of older versions.
+		var zone *time.Location
+		if offset, found, err := catBinlogBackupFile(rc, flow, targetPod, "time_zone_offset"); err != nil {
+			return flow.Error(err, "Failed to read time zone offset", "pod", targetPod.Name)
+		} else if found {
+			if zone, backup.Status.SourceTimeZoneOffset, err = parseTimeZoneOffset(offset); err != nil {
+				return flow.Error(err, "Invalid time zone offset", "pod", targetPod.Name, "output", offset)
+			}
+		}
+		timestamp, err := parseLastEventTimestamp(output, zone)
+		if err == nil {
+			err = validateLastEventTimestamp(timestamp, backup.Status.StartTime, nowTime.Time)
 		}
-		timestamp := metav1.Unix(timestampNum, 0)
-		backup.Status.BackupSetTimestamp = &timestamp
-		return flow.Continue("Extract binlog last event timestamp finished!", "pod", targetPod.Name)
+		if err != nil {
+			return failBackupOnInvalidTimestamp(rc, flow, err)
+		}
+		backupSetTimestamp := metav1.NewTime(timestamp)
+		backup.Status.BackupSetTimestamp = &backupSetTimestamp
+		return flow.Continue("Extract binlog last event timestamp finished!", "pod", targetPod.Name,
+			"timestamp", timestamp.Unix())
 	})
 
 var RemoveBinlogBackupJob = NewStepBinder("RemoveBinlogBackupJob",
@@ -496,40 +1580,215 @@ var RemoveBinlogBackupJob = NewStepBinder("RemoveBinlogBackupJob",
 		if client.IgnoreNotFound(err) != nil {
 			return flow.Error(err, "Unable to remove binlog backup job", "job-name", job.Name)
 		}
+		if err == nil {
+			polardbxhelper.EmitBackupJobSpan(rc.MustGetXStoreBackup().Status.Trace, job)
+		}
 
 		return flow.Continue("Binlog backup job removed!", "job-name", job.Name)
 	})
 
+// removePXCBackupsOverBudget deletes the oldest pxc backups of the cluster until the total size of
+// xstore backups is under the budget. The latest finished pxc backup is protected and never deleted.
+// It's run once by one of the xstore backups of each pxc backup, see isBudgetEnforcer.
+func removePXCBackupsOverBudget(rc *xstorev1reconcile.BackupContext, flow control.Flow, backup *xstorev1.XStoreBackup) error {
+	budget := backup.Spec.Retention.MaxTotalBytes
+	clusterName := backup.Labels[polardbxmeta.LabelName]
+	if budget <= 0 || clusterName == "" || backup.Status.RetentionUsage != nil {
+		return nil
+	}
+
+	var backupList xstorev1.XStoreBackupList
+	if err := rc.Client().List(rc.Context(), &backupList, client.InNamespace(backup.Namespace),
+		client.MatchingLabels{polardbxmeta.LabelName: clusterName}); err != nil {
+		return err
+	}
+	if !isBudgetEnforcer(backup, backupList.Items) {
+		return nil
+	}
+
+	sorted, used := pxcBackupUsagesOf(backupList.Items)
+	for i := 0; i < len(sorted)-1 && used > budget; i++ {
+		u := sorted[i]
+		if u.name == "" {
+			continue
+		}
+		// Keep the base of incremental backups, which can't be restored without it.
+		if u.isBase {
+			flow.Logger().Info("Backup is the base of incremental backups, skip.", "pxcBackup", u.name)
+			continue
+		}
+		flow.Logger().Info("Backup usage over budget, delete the oldest backup.",
+			"used", used, "budget", budget, "pxcBackup", u.name)
+		pxcBackup := &xstorev1.PolarDBXBackup{}
+		err := rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: backup.Namespace, Name: u.name}, pxcBackup)
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		if err == nil {
+			restore, err := polardbxhelper.ActiveRestoreOfPolarDBXBackup(rc.Context(), rc.Client(), pxcBackup)
+			if err != nil {
+				return err
+			}
+			// Keep the backup referenced by an active restore, and try the next oldest one.
+			if len(restore) > 0 {
+				flow.Logger().Info("Backup is referenced by an active restore, skip.", "pxcBackup", u.name, "restore", restore)
+				continue
+			}
+			// So is the immutable backup.
+			if pxcBackup.Status.EndTime != nil {
+				until := polardbxhelper.ImmutableUntil(pxcBackup.Status.EndTime.Time, pxcBackup.Spec.ImmutableUntil,
+					pxcBackup.Spec.RetentionTime.Duration, pxcBackup.Spec.Retention)
+				if time.Now().Before(until) {
+					flow.Logger().Info("Backup is immutable, skip.", "pxcBackup", u.name, "until", until)
+					continue
+				}
+			}
+			if err := removePXCBackupFiles(rc, flow, pxcBackup); remote.IsImmutable(err) {
+				flow.Logger().Info("Backup files are locked by the storage, skip.", "pxcBackup", u.name)
+				continue
+			} else if err != nil {
+				return err
+			}
+			if err := rc.Client().Delete(rc.Context(), pxcBackup); client.IgnoreNotFound(err) != nil {
+				return err
+			}
+		}
+		used -= u.size
+	}
+
+	backup.Status.RetentionUsage = &xstorev1.BackupRetentionUsage{
+		UsedBytes:     used,
+		MaxTotalBytes: budget,
+	}
+	return nil
+}
+
+// removePXCBackupFiles deletes all the files of the pxc backup with the retention credential, if specified.
+func removePXCBackupFiles(rc *xstorev1reconcile.BackupContext, flow control.Flow, pxcBackup *xstorev1.PolarDBXBackup) error {
+	if pxcBackup.Spec.StorageProvider.RetentionCredential == nil || len(pxcBackup.Status.BackupRootPath) == 0 {
+		return nil
+	}
+	deleted, err := polardbxhelper.RemoveBackupFiles(rc.Context(), rc.Client(), pxcBackup.Namespace,
+		pxcBackup.Spec.StorageProvider, pxcBackup.Status.BackupRootPath+"/")
+	if err != nil {
+		return err
+	}
+	flow.Logger().Info("Backup files deleted with retention credential.", "path", pxcBackup.Status.BackupRootPath, "deleted", deleted)
+	return nil
+}
+
+// xstoreBackupFilePrefixes returns the prefixes of the full backup and binlogs of the xstore backup.
+func xstoreBackupFilePrefixes(backup *xstorev1.XStoreBackup) []string {
+	return []string{
+		fmt.Sprintf("%s/%s/%s.", backup.Status.BackupRootPath, polardbxmeta.FullBackupPath, backup.Spec.XStore.Name),
+		fmt.Sprintf("%s/%s/%s/", backup.Status.BackupRootPath, polardbxmeta.BinlogBackupPath, backup.Spec.XStore.Name),
+	}
+}
+
+// removeXStoreBackupFiles deletes the full backup and binlogs of the xstore backup with the retention
+// credential, if specified. Files shared by the pxc backup are deleted along with the pxc backup. Volume
+// snapshots have no files, they're deleted along with the backup by the owner reference.
+func removeXStoreBackupFiles(rc *xstorev1reconcile.BackupContext, flow control.Flow, backup *xstorev1.XStoreBackup) error {
+	if backup.Spec.StorageProvider.RetentionCredential == nil || len(backup.Status.BackupRootPath) == 0 ||
+		IsVolumeSnapshotBackup(backup) {
+		return nil
+	}
+	for _, prefix := range xstoreBackupFilePrefixes(backup) {
+		deleted, err := polardbxhelper.RemoveBackupFiles(rc.Context(), rc.Client(), backup.Namespace,
+			backup.Spec.StorageProvider, prefix)
+		if err != nil {
+			return err
+		}
+		flow.Logger().Info("Backup files deleted with retention credential.", "prefix", prefix, "deleted", deleted)
+	}
+	return nil
+}
+
 var RemoveXSBackupOverRetention = NewStepBinder("RemoveXSBackupOverRetention",
 	func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
 		backup := rc.MustGetXStoreBackup()
-		if backup.Spec.RetentionTime.Duration.Seconds() > 0 {
-			toCleanTime := backup.Status.EndTime.Add(backup.Spec.RetentionTime.Duration)
-			now := time.Now()
-			if now.After(toCleanTime) {
-				flow.Logger().Info("Ready to delete the backup!")
-				if err := rc.Client().Delete(rc.Context(), backup); err != nil {
-					if apierrors.IsNotFound(err) {
-						flow.Logger().Info("Already deleted!")
-					} else {
-						return flow.Error(err, "Unable to delete the backup!")
-					}
+		if err := removePXCBackupsOverBudget(rc, flow, backup); err != nil {
+			return flow.Error(err, "Unable to remove backups over budget!")
+		}
+		toCleanTime := polardbxhelper.RetentionDeleteTime(backup.Status.EndTime.Time,
+			backup.Spec.RetentionTime.Duration, backup.Spec.Retention)
+		now := time.Now()
+		if now.Before(toCleanTime) {
+			waitDuration := toCleanTime.Sub(now)
+			return flow.RetryAfter(waitDuration, "Not to delete backup now!")
+		}
+
+		// Delete the aged backups of the xstore in a pass, including this one.
+		aged, err := listAgedXStoreBackups(rc, backup)
+		if err != nil {
+			return flow.Error(err, "Unable to list the aged backups!")
+		}
+		flow.Logger().Info("Ready to delete the aged backups!", "aged", len(aged))
+		locked := false
+		pass, deleted := runRetentionPass(aged, int(backup.Spec.Retention.DeleteConcurrency),
+			func(b *xstorev1.XStoreBackup) (bool, error) {
+				skipped, err := deleteAgedXStoreBackup(rc, flow, b)
+				if b.Name == backup.Name && isBackupLocked(err) {
+					locked = true
 				}
-			} else {
-				waitDuration := toCleanTime.Sub(now)
-				return flow.RetryAfter(waitDuration, "Not to delete backup now!")
+				return skipped, err
+			})
+		backup.Status.RetentionPass = pass
+		flow.Logger().Info("Retention pass finished.", "deleted", pass.Deleted, "skipped", pass.Skipped,
+			"failed", pass.Failed, "locked", pass.Locked, "throttled", pass.Throttled)
+
+		if deleted[backup.Name] {
+			return flow.Continue("PolarDBX backup deleted!", "XSBackup-name", backup.Name)
+		}
+		if pass.Throttled {
+			return flow.RetryAfter(retentionThrottleBackoffOf(backup.Spec.Retention), "Deletions throttled by the storage!")
+		}
+		if locked {
+			if until := xstoreBackupImmutableUntil(backup); time.Now().Before(until) {
+				return flow.RetryAfter(time.Until(until), "Backup is immutable, not to delete now!", "until", until)
 			}
-		} else {
-			flow.Logger().Info("Ready to delete the backup!")
-			if err := rc.Client().Delete(rc.Context(), backup); err != nil {
-				if apierrors.IsNotFound(err) {
-					flow.Logger().Info("Already deleted!")
-				} else {
-					return flow.Error(err, "Unable to delete the backup!")
-				}
+			return flow.RetryAfter(DefaultImmutableDeleteRetryInterval, "Backup files are locked by the storage, not to delete now!")
+		}
+		// Either skipped for an active restore, or failed to delete.
+		return flow.RetryAfter(time.Minute, "Backup not deleted in the retention pass, retry later!")
+	})
+
+// SealXStoreBackup marks the finished backup as immutable, spec edits of sealed backups
+// are rejected by the validating webhook.
+var SealXStoreBackup = NewStepBinder("SealXStoreBackup",
+	func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetXStoreBackup()
+		for _, c := range backup.Status.Conditions {
+			if c.Type == polardbxv1xstore.BackupImmutable && c.Status == corev1.ConditionTrue {
+				return flow.Pass()
 			}
 		}
-		return flow.Continue("PolarDBX backup deleted!", "XSBackup-name", backup.Name)
+
+		setBackupCondition(backup, polardbxv1xstore.Condition{
+			Type:               polardbxv1xstore.BackupImmutable,
+			Status:             corev1.ConditionTrue,
+			Reason:             "BackupFinished",
+			Message:            "Backup is finished and sealed, spec is immutable.",
+			LastTransitionTime: metav1.Now(),
+		})
+		return flow.Continue("Backup sealed!", "XSBackup-name", backup.Name)
+	})
+
+// IndexRecoverableWindow records the earliest recoverable timestamp of the finished backup, and labels
+// the recoverable window so that the backups covering a time can be found without reading the files.
+var IndexRecoverableWindow = NewStepBinder("IndexRecoverableWindow",
+	func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetXStoreBackup()
+		if backup.Status.EarliestRecoverableTimestamp == nil {
+			backup.Status.EarliestRecoverableTimestamp = backup.Status.StartTime.DeepCopy()
+		}
+		if !polardbxhelper.SetRecoverableWindowLabels(backup, backup.Status.EarliestRecoverableTimestamp, backup.Status.BackupSetTimestamp) {
+			return flow.Pass()
+		}
+		if err := rc.UpdateXStoreBackup(); err != nil {
+			return flow.Error(err, "Unable to update labels of xstore backup.")
+		}
+		return flow.Continue("Recoverable window indexed!", "XSBackup-name", backup.Name)
 	})
 
 var WaitPXCBackupFinished = NewStepBinder("WaitPXCBackupFinished",
@@ -539,8 +1798,10 @@ var WaitPXCBackupFinished = NewStepBinder("WaitPXCBackupFinished",
 			flow.Error(err, "Unable to find polardbxBackup")
 		}
 		if polardbxBackup.Status.Phase != polardbxv1.BackupFinished {
-			return flow.RetryAfter(5*time.Second, "Wait polardbx backup Finished", "pxcBackup", polardbxBackup.Name)
+			return retryWithBackoff(rc, flow, "WaitPXCBackupFinished", string(polardbxBackup.Status.Phase),
+				"Wait polardbx backup Finished", "pxcBackup", polardbxBackup.Name)
 		}
+		resetBackoff(rc, "WaitPXCBackupFinished")
 		return flow.Continue("Backup Finished!")
 	})
 
@@ -0,0 +1,527 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+)
+
+func jobWithCondition(condType batchv1.JobConditionType) *batchv1.Job {
+	job := &batchv1.Job{}
+	if len(condType) > 0 {
+		job.Status.Conditions = []batchv1.JobCondition{
+			{Type: condType, Status: corev1.ConditionTrue},
+		}
+	}
+	return job
+}
+
+func TestMayStartCollect(t *testing.T) {
+	testcases := map[string]struct {
+		job     *batchv1.Job
+		overlap bool
+		expect  bool
+	}{
+		"running-no-overlap":   {job: jobWithCondition(""), overlap: false, expect: false},
+		"running-overlap":      {job: jobWithCondition(""), overlap: true, expect: true},
+		"completed-no-overlap": {job: jobWithCondition(batchv1.JobComplete), overlap: false, expect: true},
+		"completed-overlap":    {job: jobWithCondition(batchv1.JobComplete), overlap: true, expect: true},
+		"failed-no-overlap":    {job: jobWithCondition(batchv1.JobFailed), overlap: false, expect: false},
+		"failed-overlap":       {job: jobWithCondition(batchv1.JobFailed), overlap: true, expect: false},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			if r := mayStartCollect(tc.job, tc.overlap); r != tc.expect {
+				t.Fatalf("expect %v, got %v", tc.expect, r)
+			}
+		})
+	}
+}
+
+func TestParseLastEventTimestamp(t *testing.T) {
+	expect := time.Unix(1690000000, 0)
+	shanghai := time.FixedZone("+08:00", 8*3600)
+	testcases := map[string]struct {
+		output string
+		zone   *time.Location
+		err    bool
+	}{
+		"seconds":             {output: "1690000000"},
+		"seconds-newline":     {output: "1690000000\n"},
+		"seconds-in-zone":     {output: "1690000000", zone: shanghai},
+		"milliseconds":        {output: "1690000000000"},
+		"truncate-output":     {output: "LAST EVENT TIMESTAMP: 1690000000\n"},
+		"rfc3339":             {output: "2023-07-22T04:26:40Z"},
+		"rfc3339-offset":      {output: "2023-07-22T12:26:40+08:00"},
+		"rfc3339-in-zone":     {output: "2023-07-22T04:26:40Z", zone: shanghai},
+		"mysql-datetime":      {output: "2023-07-22 04:26:40"},
+		"mysql-datetime-zone": {output: "2023-07-22 12:26:40", zone: shanghai},
+		"mysql-datetime-west": {output: "2023-07-22 00:26:40", zone: time.FixedZone("-04:00", -4*3600)},
+		"empty":               {output: " \n", err: true},
+		"garbage":             {output: "no such file", err: true},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			r, err := parseLastEventTimestamp(tc.output, tc.zone)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expect error, got %s", r)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !r.Equal(expect) || r.Location() != time.UTC {
+				t.Fatalf("expect %s, got %s", expect, r)
+			}
+		})
+	}
+}
+
+func TestParseTimeZoneOffset(t *testing.T) {
+	testcases := map[string]struct {
+		output    string
+		formatted string
+		err       bool
+	}{
+		"utc":        {output: "0\n", formatted: "+00:00"},
+		"east":       {output: "28800", formatted: "+08:00"},
+		"half-hour":  {output: "19800", formatted: "+05:30"},
+		"west-dst":   {output: "-14400", formatted: "-04:00"},
+		"west":       {output: "-18000", formatted: "-05:00"},
+		"empty":      {output: "", err: true},
+		"overflowed": {output: "86400", err: true},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			zone, formatted, err := parseTimeZoneOffset(tc.output)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expect error, got %s", formatted)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if formatted != tc.formatted {
+				t.Fatalf("expect %s, got %s", tc.formatted, formatted)
+			}
+			if _, offset := time.Now().In(zone).Zone(); strconv.Itoa(offset) != strings.TrimSpace(tc.output) {
+				t.Fatalf("expect offset %s, got %d", tc.output, offset)
+			}
+		})
+	}
+}
+
+func TestValidateLastEventTimestamp(t *testing.T) {
+	now := time.Unix(1690000000, 0)
+	start := metav1.NewTime(now.Add(-time.Hour))
+	testcases := map[string]struct {
+		timestamp time.Time
+		start     *metav1.Time
+		err       bool
+	}{
+		"valid":          {timestamp: now.Add(-time.Minute), start: &start},
+		"no-start":       {timestamp: now.Add(-48 * time.Hour)},
+		"skew-tolerated": {timestamp: now.Add(time.Minute), start: &start},
+		"zero":           {timestamp: time.Unix(0, 0), err: true},
+		"future":         {timestamp: now.Add(time.Hour), start: &start, err: true},
+		"before-start":   {timestamp: start.Add(-time.Hour), start: &start, err: true},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			err := validateLastEventTimestamp(tc.timestamp, tc.start, now)
+			if tc.err != (err != nil) {
+				t.Fatalf("expect error %v, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestTransferPhase(t *testing.T) {
+	created := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	backup := &polardbxv1.XStoreBackup{}
+	backup.CreationTimestamp = metav1.NewTime(created)
+
+	transferPhase(backup, polardbxv1.XStoreFullBackuping, created.Add(time.Minute))
+	transferPhase(backup, polardbxv1.XStoreFullBackuping, created.Add(2*time.Minute))
+	transferPhase(backup, polardbxv1.XStoreBackupFailed, created.Add(time.Hour))
+
+	history := backup.Status.PhaseHistory
+	if backup.Status.Phase != polardbxv1.XStoreBackupFailed || len(history) != 3 {
+		t.Fatalf("unexpected phase history: %v", history)
+	}
+	if history[0].Duration != "1m0s" || history[1].Duration != "59m0s" || history[2].Duration != "" {
+		t.Fatalf("unexpected durations: %v", history)
+	}
+
+	for i := 0; i < polardbxv1.MaxBackupPhaseHistory; i++ {
+		transferPhase(backup, polardbxv1.XStoreBackupPhase(fmt.Sprint(i)), created.Add(2*time.Hour))
+	}
+	history = backup.Status.PhaseHistory
+	if len(history) != polardbxv1.MaxBackupPhaseHistory || history[len(history)-1].Phase != backup.Status.Phase {
+		t.Fatalf("expect history capped, got %d", len(history))
+	}
+}
+
+// newUpgradedBackupContext returns the context of backup in the phase, with a job of the label created
+// by operator of an older version.
+func newUpgradedBackupContext(t *testing.T, phase polardbxv1.XStoreBackupPhase, policy polardbxv1.BackupJobVersionPolicy,
+	jobLabel string, jobCondition batchv1.JobConditionType) (*xstorev1reconcile.BackupContext, client.Client) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := polardbxv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	backup := &polardbxv1.XStoreBackup{}
+	backup.Name = "backup"
+	backup.Namespace = "default"
+	backup.UID = "backup-uid"
+	backup.Spec.XStore.Name = "xstore"
+	backup.Spec.JobVersionPolicy = policy
+	backup.Spec.OverlapCollect = true
+	backup.Status.Phase = phase
+
+	xstore := &polardbxv1.XStore{}
+	xstore.Name = "xstore"
+	xstore.Namespace = "default"
+
+	job := jobWithCondition(jobCondition)
+	job.Name = "job"
+	job.Namespace = "default"
+	job.Labels = map[string]string{jobLabel: backup.Name}
+	job.Annotations = map[string]string{xstoremeta.AnnotationOperatorVersion: "v0.0.1-old"}
+	job.OwnerReferences = []metav1.OwnerReference{{
+		APIVersion: polardbxv1.GroupVersion.String(),
+		Kind:       "XStoreBackup",
+		Name:       backup.Name,
+		UID:        backup.UID,
+		Controller: pointer.Bool(true),
+	}}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(backup, xstore, job).Build()
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: backup.Name}}
+	base := control.NewBaseReconcileContext(c, nil, nil, scheme, context.Background(), request)
+	return xstorev1reconcile.NewBackupContext(base), c
+}
+
+func TestJobOfOtherVersionDuringPhases(t *testing.T) {
+	phases := map[string]struct {
+		phase       polardbxv1.XStoreBackupPhase
+		step        control.BindFunc
+		jobLabel    string
+		recreatable bool
+		// restartPhase is the phase which recreates the job, the same phase if empty
+		restartPhase polardbxv1.XStoreBackupPhase
+	}{
+		"backuping":             {polardbxv1.XStoreFullBackuping, WaitFullBackupJobFinished, xstoremeta.LabelXStoreBackupName, true, polardbxv1.XStoreBackupNew},
+		"collecting-overlapped": {polardbxv1.XStoreBackupCollecting, WaitOverlappedFullBackupJobFinished, xstoremeta.LabelXStoreBackupName, false, ""},
+		"collecting":            {polardbxv1.XStoreBackupCollecting, WaitCollectBinlogJobFinished, xstoremeta.LabelXStoreCollectName, true, ""},
+		"binlog-backuping":      {polardbxv1.XStoreBinlogBackuping, WaitBinlogBackupJobFinished, xstoremeta.LabelXStoreBinlogBackupName, true, ""},
+	}
+
+	execute := func(t *testing.T, rc *xstorev1reconcile.BackupContext, step control.BindFunc) {
+		task := control.NewTask()
+		step(task)
+		if _, err := control.NewExecutor(logr.Discard()).Execute(rc, task); err != nil {
+			t.Fatal(err)
+		}
+	}
+	jobExists := func(t *testing.T, c client.Client) bool {
+		err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "job"}, &batchv1.Job{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			t.Fatal(err)
+		}
+		return err == nil
+	}
+
+	for name, p := range phases {
+		t.Run(name+"/adopt", func(t *testing.T) {
+			rc, _ := newUpgradedBackupContext(t, p.phase, polardbxv1.BackupJobVersionAdopt, p.jobLabel, batchv1.JobFailed)
+			execute(t, rc, p.step)
+			backup := rc.MustGetXStoreBackup()
+			if backup.Status.Phase != polardbxv1.XStoreBackupFailed ||
+				backup.Status.FailureReason == polardbxv1.BackupFailureOperatorUpgrade {
+				t.Fatalf("expect the job handled as is, got %s, %s", backup.Status.Phase, backup.Status.FailureReason)
+			}
+		})
+
+		t.Run(name+"/fail", func(t *testing.T) {
+			rc, c := newUpgradedBackupContext(t, p.phase, polardbxv1.BackupJobVersionFail, p.jobLabel, "")
+			execute(t, rc, p.step)
+			backup := rc.MustGetXStoreBackup()
+			if backup.Status.Phase != polardbxv1.XStoreBackupFailed ||
+				backup.Status.FailureReason != polardbxv1.BackupFailureOperatorUpgrade {
+				t.Fatalf("expect failed on upgrade, got %s, %s", backup.Status.Phase, backup.Status.FailureReason)
+			}
+			if !jobExists(t, c) {
+				t.Fatal("expect job kept for diagnosis")
+			}
+		})
+
+		t.Run(name+"/recreate-running", func(t *testing.T) {
+			rc, c := newUpgradedBackupContext(t, p.phase, polardbxv1.BackupJobVersionRecreate, p.jobLabel, "")
+			execute(t, rc, p.step)
+			backup := rc.MustGetXStoreBackup()
+			if !p.recreatable {
+				if backup.Status.Phase != polardbxv1.XStoreBackupFailed {
+					t.Fatalf("expect failed as the job can't be recreated, got %s", backup.Status.Phase)
+				}
+				return
+			}
+			if backup.Status.Phase != p.phase || !jobExists(t, c) {
+				t.Fatalf("expect waiting for the job in phase %s, got %s", p.phase, backup.Status.Phase)
+			}
+		})
+
+		t.Run(name+"/recreate-finished", func(t *testing.T) {
+			rc, c := newUpgradedBackupContext(t, p.phase, polardbxv1.BackupJobVersionRecreate, p.jobLabel, batchv1.JobComplete)
+			execute(t, rc, p.step)
+			backup := rc.MustGetXStoreBackup()
+			if !p.recreatable {
+				if backup.Status.Phase != polardbxv1.XStoreBackupFailed {
+					t.Fatalf("expect failed as the job can't be recreated, got %s", backup.Status.Phase)
+				}
+				return
+			}
+			expectPhase := p.phase
+			if len(p.restartPhase) > 0 {
+				expectPhase = p.restartPhase
+			}
+			if backup.Status.Phase != expectPhase {
+				t.Fatalf("expect phase %s to recreate the job, got %s", expectPhase, backup.Status.Phase)
+			}
+			if jobExists(t, c) {
+				t.Fatal("expect job of the old version removed")
+			}
+		})
+	}
+}
+
+func TestIsSourceLagUnknownTimedOut(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	backup := &polardbxv1.XStoreBackup{}
+	if isSourceLagUnknownTimedOut(backup, start.Add(time.Hour)) {
+		t.Fatal("expect no timeout before the backup starts")
+	}
+	backup.Status.StartTime = &metav1.Time{Time: start}
+	if isSourceLagUnknownTimedOut(backup, start.Add(sourceLagUnknownTimeout-time.Second)) {
+		t.Fatal("expect no timeout within the bound")
+	}
+	if !isSourceLagUnknownTimedOut(backup, start.Add(sourceLagUnknownTimeout)) {
+		t.Fatal("expect timeout once the bound is reached")
+	}
+}
+
+func TestJobVersionActionOf(t *testing.T) {
+	jobOf := func(version string, condType batchv1.JobConditionType) *batchv1.Job {
+		job := jobWithCondition(condType)
+		if len(version) > 0 {
+			job.Annotations = map[string]string{xstoremeta.AnnotationOperatorVersion: version}
+		}
+		return job
+	}
+
+	testcases := map[string]struct {
+		policy      polardbxv1.BackupJobVersionPolicy
+		job         *batchv1.Job
+		recreatable bool
+		expect      jobVersionAction
+	}{
+		"same version": {polardbxv1.BackupJobVersionFail, jobOf("v2", ""), true, jobVersionAdopt},
+		"full backup running, adopt": {
+			polardbxv1.BackupJobVersionAdopt, jobOf("v1", ""), true, jobVersionAdopt},
+		"full backup running, recreate": {
+			polardbxv1.BackupJobVersionRecreate, jobOf("v1", ""), true, jobVersionWait},
+		"full backup completed, recreate": {
+			polardbxv1.BackupJobVersionRecreate, jobOf("v1", batchv1.JobComplete), true, jobVersionRecreate},
+		"overlapped full backup, recreate": {
+			polardbxv1.BackupJobVersionRecreate, jobOf("v1", ""), false, jobVersionFail},
+		"collect failed, recreate": {
+			polardbxv1.BackupJobVersionRecreate, jobOf("v1", batchv1.JobFailed), true, jobVersionRecreate},
+		"binlog backup without version, fail": {
+			polardbxv1.BackupJobVersionFail, jobOf("", ""), true, jobVersionFail},
+		"binlog backup without version, default": {"", jobOf("", ""), true, jobVersionAdopt},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			if action := jobVersionActionOf(tc.policy, tc.job, "v2", tc.recreatable); action != tc.expect {
+				t.Fatalf("expect %d, got %d", tc.expect, action)
+			}
+		})
+	}
+}
+
+func TestRecordIncompleteUploads(t *testing.T) {
+	backup := &polardbxv1.XStoreBackup{}
+	recordIncompleteUploads(backup, nil, incompleteUploadsReasonCleaned)
+	if c := backup.Status.Conditions[0]; c.Status != corev1.ConditionFalse || c.Reason != incompleteUploadsReasonCleaned {
+		t.Fatalf("unexpected condition: %+v", c)
+	}
+
+	found := make([]polardbxv1.IncompleteUpload, polardbxv1.MaxBackupIncompleteUploads+1)
+	found[0].Aborted = true
+	recordIncompleteUploads(backup, found, incompleteUploadsReasonFound)
+	if len(backup.Status.IncompleteUploads) != polardbxv1.MaxBackupIncompleteUploads {
+		t.Fatalf("expect capped, got %d", len(backup.Status.IncompleteUploads))
+	}
+	c := backup.Status.Conditions[0]
+	if len(backup.Status.Conditions) != 1 || c.Status != corev1.ConditionTrue ||
+		c.Message != fmt.Sprintf("%d incomplete uploads found, 1 aborted", polardbxv1.MaxBackupIncompleteUploads) {
+		t.Fatalf("unexpected condition: %+v", c)
+	}
+}
+
+func TestUploadRetryStatsOf(t *testing.T) {
+	retries := map[string]int64{"a": 1, "b": 3, "c": 0}
+	for i := 0; i < polardbxv1.MaxBackupRetriedObjects; i++ {
+		retries[fmt.Sprintf("x%02d", i)] = 2
+	}
+	stats := uploadRetryStatsOf(retries)
+	if stats.TotalRetries != int64(4+2*polardbxv1.MaxBackupRetriedObjects) {
+		t.Fatalf("unexpected total retries: %d", stats.TotalRetries)
+	}
+	if len(stats.Objects) != polardbxv1.MaxBackupRetriedObjects {
+		t.Fatalf("expect capped, got %d", len(stats.Objects))
+	}
+	if o := stats.Objects[0]; o.Path != "b" || o.Retries != 3 {
+		t.Fatalf("expect most retried first, got %+v", o)
+	}
+	if o := stats.Objects[1]; o.Path != "x00" {
+		t.Fatalf("expect sorted by path on ties, got %+v", o)
+	}
+
+	if stats := uploadRetryStatsOf(nil); stats.TotalRetries != 0 || len(stats.Objects) != 0 {
+		t.Fatalf("expect empty stats, got %+v", stats)
+	}
+}
+
+func TestConsistencyWaitStatsOf(t *testing.T) {
+	waits := map[string]int64{"binlog_list": 1500, "full.xbstream": 3000, "mysql_bin.000001": 0}
+	stats := consistencyWaitStatsOf(waits)
+	if stats.TotalWaitMillis != 4500 || len(stats.Objects) != 2 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if o := stats.Objects[0]; o.Path != "full.xbstream" || o.WaitMillis != 3000 {
+		t.Fatalf("expect longest waited first, got %+v", o)
+	}
+}
+
+func TestEstimateBackup(t *testing.T) {
+	newBackup := func(name string, start time.Time, size int64, fullBackup string) polardbxv1.XStoreBackup {
+		b := polardbxv1.XStoreBackup{}
+		b.Name = name
+		b.Spec.XStore.Name = "xs"
+		b.Status.Phase = polardbxv1.XStoreBackupFinished
+		b.Status.StartTime = &metav1.Time{Time: start}
+		b.Status.BackupSize = size
+		b.Status.PhaseHistory = []polardbxv1.BackupPhaseTransition{
+			{Phase: polardbxv1.XStoreFullBackuping, Duration: fullBackup},
+		}
+		return b
+	}
+	backup := &polardbxv1.XStoreBackup{}
+	backup.Name = "current"
+	backup.Spec.XStore.Name = "xs"
+
+	if size, d := estimateBackup(backup, 100, nil); size != 100 || d != 0 {
+		t.Fatalf("expect no duration without history, got %d, %s", size, d)
+	}
+
+	now := time.Now()
+	backups := []polardbxv1.XStoreBackup{
+		newBackup("b1", now.Add(-2*time.Hour), 100, "1m0s"),
+		newBackup("b2", now.Add(-time.Hour), 200, "3m0s"),
+	}
+	if size, d := estimateBackup(backup, 600, backups); size != 600 || d != 8*time.Minute {
+		t.Fatalf("expect 600 in 8m, got %d, %s", size, d)
+	}
+	if size, _ := estimateBackup(backup, 0, backups); size != 200 {
+		t.Fatalf("expect size of last backup, got %d", size)
+	}
+}
+
+func TestEphemeralLearnerNameOf(t *testing.T) {
+	xstore := &polardbxv1.XStore{}
+	xstore.Name = "pxc-dn-0"
+	backup := &polardbxv1.XStoreBackup{}
+	backup.UID = "8d1f6e2c-0b7a-4c1e-9f5e-3c2b1a0d9e8f"
+
+	name := ephemeralLearnerNameOf(xstore, backup)
+	if name != ephemeralLearnerNameOf(xstore, backup) || len(name) != len("pxc-dn-0-bl")+4 {
+		t.Fatalf("expect stable name, got %s", name)
+	}
+	backup.UID = "another"
+	if name == ephemeralLearnerNameOf(xstore, backup) {
+		t.Fatalf("expect names differ between backups: %s", name)
+	}
+}
+
+func TestConsistencyStatusOf(t *testing.T) {
+	status, err := consistencyStatusOf("", "")
+	if err != nil || status.Requested != polardbxv1.ConsistencyModeDefault || status.Used != polardbxv1.ConsistencyModeDefault {
+		t.Fatalf("expect default used without output, got %+v, %v", status, err)
+	}
+
+	status, err = consistencyStatusOf(polardbxv1.ConsistencyModeSnapshotLock, `{"mode": "SnapshotLock"}`)
+	if err != nil || status.Used != polardbxv1.ConsistencyModeSnapshotLock || len(status.Reason) > 0 {
+		t.Fatalf("expect snapshot lock used, got %+v, %v", status, err)
+	}
+
+	status, err = consistencyStatusOf(polardbxv1.ConsistencyModeSnapshotLock,
+		`{"mode": "Default", "reason": "reduced ddl lock is not supported by xtrabackup"}`)
+	if err != nil || status.Used != polardbxv1.ConsistencyModeDefault || len(status.Reason) == 0 {
+		t.Fatalf("expect fallback recorded, got %+v, %v", status, err)
+	}
+
+	if _, err := consistencyStatusOf(polardbxv1.ConsistencyModeSnapshotLock, "{"); err == nil {
+		t.Fatal("expect error on malformed output")
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"fmt"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+)
+
+func backupTopologyOf(xstore *polardbxv1.XStore) *polardbxv1.BackupTopology {
+	topology := &polardbxv1.BackupTopology{Generation: xstore.Generation}
+	for _, ns := range xstore.Spec.Topology.NodeSets {
+		topology.NodeSets = append(topology.NodeSets, polardbxv1.BackupTopologyNodeSet{
+			Name:     ns.Name,
+			Role:     ns.Role,
+			Replicas: ns.Replicas,
+		})
+	}
+	return topology
+}
+
+// describeTopologyChange returns the change from the recorded topology to the current one, empty
+// if the node sets are the same. Changes of the generation only, e.g. of the config, are ignored.
+func describeTopologyChange(recorded, current *polardbxv1.BackupTopology) string {
+	nodeSets := make(map[string]polardbxv1.BackupTopologyNodeSet, len(recorded.NodeSets))
+	for _, ns := range recorded.NodeSets {
+		nodeSets[ns.Name] = ns
+	}
+	for _, ns := range current.NodeSets {
+		old, ok := nodeSets[ns.Name]
+		if !ok {
+			return fmt.Sprintf("node set %s added", ns.Name)
+		}
+		delete(nodeSets, ns.Name)
+		if old.Role != ns.Role {
+			return fmt.Sprintf("role of node set %s changed from %s to %s", ns.Name, old.Role, ns.Role)
+		}
+		if old.Replicas != ns.Replicas {
+			return fmt.Sprintf("replicas of node set %s changed from %d to %d", ns.Name, old.Replicas, ns.Replicas)
+		}
+	}
+	for name := range nodeSets {
+		return fmt.Sprintf("node set %s removed", name)
+	}
+	return ""
+}
+
+// RecordBackupTopology takes the snapshot of the topology of xstore when the backup starts.
+var RecordBackupTopology = NewStepBinder("RecordBackupTopology",
+	func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetXStoreBackup()
+		if backup.Status.Topology != nil {
+			return flow.Pass()
+		}
+		xstore, err := rc.GetXStore()
+		if err != nil {
+			return flow.Error(err, "Unable to get xstore")
+		}
+		backup.Status.Topology = backupTopologyOf(xstore)
+		return flow.Continue("Topology of xstore recorded.", "generation", xstore.Generation)
+	})
+
+// CheckBackupTopology detects the change of the topology of xstore since the backup started. The
+// backup fails with reason TopologyChanged, or the change is marked in the recorded topology if the
+// policy is Record, so that the backup is restored into the recorded one.
+var CheckBackupTopology = NewStepBinder("CheckBackupTopology",
+	func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetXStoreBackup()
+		recorded := backup.Status.Topology
+		// Absent for backups started by operator of older versions.
+		if recorded == nil || recorded.Changed {
+			return flow.Pass()
+		}
+		xstore, err := rc.GetXStore()
+		if err != nil {
+			return flow.Error(err, "Unable to get xstore")
+		}
+		if xstore.Generation == recorded.Generation {
+			return flow.Pass()
+		}
+		change := describeTopologyChange(recorded, backupTopologyOf(xstore))
+		if len(change) == 0 {
+			return flow.Pass()
+		}
+
+		if backup.Spec.TopologyChangePolicy == polardbxv1.TopologyChangeRecord {
+			recorded.Changed = true
+			recorded.ChangedGeneration = xstore.Generation
+			return flow.Continue("Topology of xstore changed during backup, recorded topology kept.", "change", change)
+		}
+		msg := "Topology of xstore changed during backup: " + change
+		transferPhase(backup, polardbxv1.XStoreBackupFailed, time.Now())
+		backup.Status.FailureReason = polardbxv1.BackupFailureTopologyChanged
+		backup.Status.Message = msg
+		return flow.Retry(msg)
+	})
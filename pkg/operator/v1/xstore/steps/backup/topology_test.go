@@ -0,0 +1,56 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"testing"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/api/v1/xstore"
+)
+
+func TestDescribeTopologyChange(t *testing.T) {
+	topologyOf := func(generation int64, nodeSets ...xstore.NodeSet) *polardbxv1.BackupTopology {
+		x := &polardbxv1.XStore{}
+		x.Generation = generation
+		x.Spec.Topology.NodeSets = nodeSets
+		return backupTopologyOf(x)
+	}
+	cand := xstore.NodeSet{Name: "cand", Role: xstore.RoleCandidate, Replicas: 2}
+	voter := xstore.NodeSet{Name: "voter", Role: xstore.RoleVoter, Replicas: 1}
+	recorded := topologyOf(1, cand, voter)
+
+	scaled := cand
+	scaled.Replicas = 3
+	testcases := map[string]struct {
+		current *polardbxv1.BackupTopology
+		changed bool
+	}{
+		"generation-only": {current: topologyOf(2, voter, cand)},
+		"scaled":          {current: topologyOf(2, scaled, voter), changed: true},
+		"added": {current: topologyOf(2, cand, voter,
+			xstore.NodeSet{Name: "learner", Role: xstore.RoleLearner, Replicas: 1}), changed: true},
+		"removed": {current: topologyOf(2, cand), changed: true},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			if change := describeTopologyChange(recorded, tc.current); (len(change) > 0) != tc.changed {
+				t.Fatalf("expect changed %v, got %q", tc.changed, change)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	polardbxhelper "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+	"github.com/alibaba/polardbx-operator/pkg/util/tracing"
+)
+
+// parentSpanContextOf returns the span context of the pxc backup, which the xstore backup is traced under.
+// It returns false if the pxc backup exists but its trace isn't started yet.
+func parentSpanContextOf(rc *xstorev1reconcile.BackupContext, backup *polardbxv1.XStoreBackup) (tracing.SpanContext, bool, error) {
+	if len(backup.Labels[polardbxmeta.LabelTopBackup]) == 0 {
+		return tracing.SpanContext{}, true, nil
+	}
+	pxcBackup, err := rc.GetPolarDBXBackup()
+	if apierrors.IsNotFound(err) {
+		return tracing.SpanContext{}, true, nil
+	} else if err != nil {
+		return tracing.SpanContext{}, false, err
+	}
+	if pxcBackup.Status.Trace == nil {
+		return tracing.SpanContext{}, false, nil
+	}
+	// Trace under a new root if the trace context of pxc backup is broken.
+	sc, _ := tracing.ParseTraceParent(pxcBackup.Status.Trace.TraceParent)
+	return sc, true, nil
+}
+
+// TraceBackupLifecycle emits the spans of the backup and its phases once they end, the spans of
+// jobs are emitted when they're removed. It must be deferred before the status is persisted.
+var TraceBackupLifecycle = NewStepBinder("TraceBackupLifecycle",
+	func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
+		if !tracing.Enabled() {
+			return flow.Pass()
+		}
+		backup := rc.MustGetXStoreBackup()
+		parent, ok, err := parentSpanContextOf(rc, backup)
+		if err != nil {
+			return flow.Error(err, "Unable to get trace context of pxc backup.")
+		}
+		if backup.Status.Trace == nil {
+			if !ok {
+				return flow.Continue("Trace of pxc backup not started yet.")
+			}
+			backup.Status.Trace = polardbxhelper.NewBackupTrace(parent)
+		}
+
+		root := tracing.Span{
+			Name:   "XStoreBackup/" + backup.Name,
+			Parent: parent.SpanID,
+			Start:  backup.CreationTimestamp.Time,
+			Attributes: map[string]string{
+				"backup.name":      backup.Name,
+				"backup.namespace": backup.Namespace,
+				"xstore":           backup.Spec.XStore.Name,
+				"target.pod":       backup.Status.TargetPod,
+			},
+		}
+		phase := backup.Status.Phase
+		if phase == polardbxv1.XStoreBackupFailed {
+			root.Error = string(backup.Status.FailureReason) + ": " + backup.Status.Message
+		}
+		spans, err := polardbxhelper.ObserveBackupTrace(backup.Status.Trace, string(phase),
+			phase == polardbxv1.XStoreBackupFinished || phase == polardbxv1.XStoreBackupFailed, root, time.Now())
+		if err != nil {
+			return flow.Error(err, "Invalid trace context of backup.", "trace-parent", backup.Status.Trace.TraceParent)
+		}
+		for _, span := range spans {
+			tracing.Emit(span)
+		}
+		return flow.Pass()
+	})
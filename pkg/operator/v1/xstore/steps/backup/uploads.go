@@ -0,0 +1,155 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	polardbxhelper "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+)
+
+// staleUploadAge is the age after which an incomplete upload under the backups of the cluster is
+// considered orphaned, e.g. left by a backup deleted while uploading. It's far longer than any
+// upload of the backup jobs.
+const staleUploadAge = 7 * 24 * time.Hour
+
+const (
+	incompleteUploadsReasonFound   = "Found"
+	incompleteUploadsReasonCleaned = "Cleaned"
+)
+
+// recordIncompleteUploads adds the found uploads to the status, and updates the condition with the
+// total counts found so far. The reason of the condition is Cleaned once the backup is over.
+func recordIncompleteUploads(backup *polardbxv1.XStoreBackup, found []polardbxv1.IncompleteUpload, reason string) {
+	for _, u := range found {
+		if len(backup.Status.IncompleteUploads) >= polardbxv1.MaxBackupIncompleteUploads {
+			break
+		}
+		backup.Status.IncompleteUploads = append(backup.Status.IncompleteUploads, u)
+	}
+
+	total, aborted := 0, 0
+	for _, u := range backup.Status.IncompleteUploads {
+		total++
+		if u.Aborted {
+			aborted++
+		}
+	}
+	status := corev1.ConditionTrue
+	if total == 0 {
+		status = corev1.ConditionFalse
+	}
+	setBackupCondition(backup, polardbxv1xstore.Condition{
+		Type:    polardbxv1xstore.BackupIncompleteUploads,
+		Status:  status,
+		Reason:  reason,
+		Message: fmt.Sprintf("%d incomplete uploads found, %d aborted", total, aborted),
+	})
+}
+
+func abortIncompleteUploads(rc *xstorev1reconcile.BackupContext, backup *polardbxv1.XStoreBackup,
+	prefix string, before time.Time) ([]polardbxv1.IncompleteUpload, error) {
+	return polardbxhelper.AbortIncompleteUploads(rc.Context(), rc.Client(), backup.Namespace,
+		backup.Spec.StorageProvider, prefix, before)
+}
+
+func hasEnteredPhase(backup *polardbxv1.XStoreBackup, phase polardbxv1.XStoreBackupPhase) bool {
+	for _, t := range backup.Status.PhaseHistory {
+		if t.Phase == phase {
+			return true
+		}
+	}
+	return false
+}
+
+// AbortUploadsOfPreviousAttempt aborts the incomplete uploads of the full backup left by the job of
+// a previous attempt, e.g. recreated after the operator is upgraded. They can't be resumed since the
+// backup is streamed, so the full backup always starts over.
+var AbortUploadsOfPreviousAttempt = NewStepBinder("AbortUploadsOfPreviousAttempt",
+	func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetXStoreBackup()
+		if backup.Spec.StorageProvider.RetentionCredential == nil || len(backup.Status.BackupRootPath) == 0 ||
+			!hasEnteredPhase(backup, polardbxv1.XStoreFullBackuping) {
+			return flow.Pass()
+		}
+		// The job of current attempt is already uploading.
+		if job, err := rc.GetXStoreBackupJob(); err == nil && job != nil {
+			return flow.Pass()
+		}
+
+		found, err := abortIncompleteUploads(rc, backup, xstoreBackupFilePrefixes(backup)[0], time.Now())
+		if err != nil {
+			// Never block the backup, the uploads are cleaned when the backup is over.
+			flow.Logger().Error(err, "Unable to abort incomplete uploads of previous attempt.")
+			return flow.Continue("Incomplete uploads left.")
+		}
+		recordIncompleteUploads(backup, found, incompleteUploadsReasonFound)
+		return flow.Continue("Incomplete uploads of previous attempt aborted.", "found", len(found))
+	})
+
+// CleanIncompleteUploads aborts the incomplete uploads of the backup once it's over, i.e. no job
+// is uploading, and the stale ones under the backups of the cluster. It's done only once, which is
+// indicated by the condition.
+var CleanIncompleteUploads = NewStepBinder("CleanIncompleteUploads",
+	func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetXStoreBackup()
+		if backup.Spec.StorageProvider.RetentionCredential == nil || len(backup.Status.BackupRootPath) == 0 {
+			return flow.Pass()
+		}
+		for _, c := range backup.Status.Conditions {
+			if c.Type == polardbxv1xstore.BackupIncompleteUploads && c.Reason == incompleteUploadsReasonCleaned {
+				return flow.Pass()
+			}
+		}
+
+		now := time.Now()
+		found := make([]polardbxv1.IncompleteUpload, 0)
+		for _, prefix := range xstoreBackupFilePrefixes(backup) {
+			uploads, err := abortIncompleteUploads(rc, backup, prefix, now)
+			if err != nil {
+				flow.Logger().Error(err, "Unable to abort incomplete uploads.", "prefix", prefix)
+				return flow.Continue("Incomplete uploads left, retry later.")
+			}
+			found = append(found, uploads...)
+		}
+
+		// Orphans of the backups which are gone. Only the aborted ones are recorded, the others
+		// may be still uploading by other backups.
+		clusterPrefix := path.Dir(backup.Status.BackupRootPath) + "/"
+		uploads, err := abortIncompleteUploads(rc, backup, clusterPrefix, now.Add(-staleUploadAge))
+		if err != nil {
+			flow.Logger().Error(err, "Unable to abort stale uploads.", "prefix", clusterPrefix)
+			return flow.Continue("Stale uploads left, retry later.")
+		}
+		for _, u := range uploads {
+			if u.Aborted {
+				found = append(found, u)
+			}
+		}
+
+		recordIncompleteUploads(backup, found, incompleteUploadsReasonCleaned)
+		return flow.Continue("Incomplete uploads cleaned.", "found", len(found))
+	})
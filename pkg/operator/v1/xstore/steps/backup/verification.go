@@ -0,0 +1,256 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/command"
+	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+)
+
+const (
+	defaultVerificationTimeout = 6 * time.Hour
+
+	// Checking all the tables reads all the data, so it takes way longer than the user checks.
+	tableCheckName    = "check-tables"
+	tableCheckTimeout = time.Hour
+)
+
+// verificationXStoreNameOf returns the name of the drill xstore of the backup. It's stable, so that
+// the drill is never created twice.
+func verificationXStoreNameOf(xstore *polardbxv1.XStore, backup *polardbxv1.XStoreBackup) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(backup.UID))
+	return fmt.Sprintf("%s-bv%s", xstore.Name, rand.SafeEncodeString(fmt.Sprintf("%x", h.Sum32()))[:4])
+}
+
+// newVerificationXStore builds an isolated xstore of a single node restored from the backup, the
+// checks of the verification are run as the post restore commands. The node takes the template of
+// candidates, and it's owned by the backup so that it won't be left behind.
+func newVerificationXStore(xstore *polardbxv1.XStore, backup *polardbxv1.XStoreBackup, name string) *polardbxv1.XStore {
+	verification := backup.Spec.Verification
+
+	spec := xstore.Spec.DeepCopy()
+	spec.Readonly = false
+	spec.PrimaryCluster = ""
+	spec.PrimaryXStore = ""
+	// Service name defaults to the xstore name once set, never take the one of the source.
+	spec.ServiceName = ""
+	spec.ServiceLabels = nil
+	spec.ServiceType = corev1.ServiceTypeClusterIP
+	spec.RestoreConfigOverlay = ""
+
+	var template *polardbxv1xstore.NodeTemplate
+	for _, nodeSet := range spec.Topology.NodeSets {
+		if nodeSet.Role == polardbxv1xstore.RoleCandidate {
+			template = nodeSet.Template
+			break
+		}
+	}
+	spec.Topology.NodeSets = []polardbxv1xstore.NodeSet{
+		{
+			Name:     "cand",
+			Role:     polardbxv1xstore.RoleCandidate,
+			Replicas: 1,
+			Template: template,
+		},
+	}
+
+	checks := make([]polardbxv1.XStoreRestoreExec, 0, len(verification.Checks)+1)
+	if !verification.SkipTableCheck {
+		checks = append(checks, polardbxv1.XStoreRestoreExec{
+			Name:    tableCheckName,
+			Command: command.NewCanonicalCommandBuilder().Engine().CheckTables().Build(),
+			Timeout: metav1.Duration{Duration: tableCheckTimeout},
+		})
+	}
+	checks = append(checks, verification.Checks...)
+	spec.Restore = &polardbxv1.XStoreRestoreSpec{
+		BackupSet: backup.Name,
+		From: polardbxv1.XStoreRestoreFrom{
+			XStoreName: backup.Spec.XStore.Name,
+		},
+		Isolated:        true,
+		PostRestoreExec: checks,
+	}
+
+	return &polardbxv1.XStore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: xstore.Namespace,
+			Labels: map[string]string{
+				xstoremeta.LabelVerifiedBackup: backup.Name,
+			},
+			Annotations: map[string]string{
+				// Set to empty rand to avoid long xstore/pod names.
+				xstoremeta.AnnotationGuideRand: "",
+			},
+		},
+		Spec: *spec,
+	}
+}
+
+// verificationResultOf tells the phase of the verification from the drill xstore. The restore
+// runs the checks before the xstore goes running, and any failure fails the restore.
+func verificationResultOf(drill *polardbxv1.XStore) (polardbxv1.BackupVerificationPhase, string) {
+	switch drill.Status.Phase {
+	case polardbxv1xstore.PhaseRunning:
+		return polardbxv1.BackupVerificationPassed, ""
+	case polardbxv1xstore.PhaseFailed:
+		for _, c := range drill.Status.Conditions {
+			if c.Type == polardbxv1xstore.Restorable && c.Status == corev1.ConditionFalse {
+				return polardbxv1.BackupVerificationFailed, c.Message
+			}
+		}
+		return polardbxv1.BackupVerificationFailed, "Restore failed."
+	default:
+		return polardbxv1.BackupVerificationRunning, ""
+	}
+}
+
+func finishVerification(status *polardbxv1.BackupVerificationStatus, phase polardbxv1.BackupVerificationPhase, message string) {
+	now := metav1.Now()
+	status.Phase = phase
+	status.Message = message
+	status.FinishTime = &now
+}
+
+func getVerificationXStore(rc *xstorev1reconcile.BackupContext) (*polardbxv1.XStore, error) {
+	backup := rc.MustGetXStoreBackup()
+	var drill polardbxv1.XStore
+	err := rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: rc.Namespace(), Name: backup.Status.Verification.XStore}, &drill)
+	if err != nil {
+		return nil, err
+	}
+	return &drill, nil
+}
+
+// VerifyBackupByRestoreDrill restores the finished backup into a throwaway xstore and records whether
+// the checks pass. It must be bound before the retention of the finished backup, which waits until
+// the backup expires; the backup, and the drill owned by it, is only removed once the drill is done.
+var VerifyBackupByRestoreDrill = NewStepBinder("VerifyBackupByRestoreDrill",
+	func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetXStoreBackup()
+		if backup.Spec.Verification == nil {
+			return flow.Pass()
+		}
+		status := backup.Status.Verification
+		if status != nil && status.Phase != polardbxv1.BackupVerificationRunning {
+			return flow.Pass()
+		}
+
+		if status == nil {
+			xstore, err := rc.GetXStore()
+			if apierrors.IsNotFound(err) {
+				backup.Status.Verification = &polardbxv1.BackupVerificationStatus{}
+				finishVerification(backup.Status.Verification, polardbxv1.BackupVerificationFailed,
+					"Source xstore not found, unable to build the restore drill.")
+				return flow.Continue("Source xstore not found, verification failed.")
+			}
+			if err != nil {
+				return flow.Error(err, "Unable to get xstore")
+			}
+			drill := newVerificationXStore(xstore, backup, verificationXStoreNameOf(xstore, backup))
+			if err := rc.SetControllerRefAndCreate(drill); err != nil {
+				if !apierrors.IsAlreadyExists(err) {
+					return flow.Error(err, "Unable to create restore drill", "xstore", drill.Name)
+				}
+				// Created in a previous reconcile whose status failed to persist, adopt it if it's ours.
+				existing := &polardbxv1.XStore{}
+				if err := rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: rc.Namespace(), Name: drill.Name}, existing); err != nil {
+					return flow.Error(err, "Unable to get restore drill", "xstore", drill.Name)
+				}
+				if owner := metav1.GetControllerOf(existing); owner == nil || owner.UID != backup.UID {
+					return flow.Error(err, "Restore drill exists but not owned by the backup", "xstore", drill.Name)
+				}
+			}
+			now := metav1.Now()
+			backup.Status.Verification = &polardbxv1.BackupVerificationStatus{
+				Phase:     polardbxv1.BackupVerificationRunning,
+				XStore:    drill.Name,
+				StartTime: &now,
+			}
+			return flow.Continue("Restore drill created.", "xstore", drill.Name)
+		}
+
+		drill, err := getVerificationXStore(rc)
+		if apierrors.IsNotFound(err) {
+			finishVerification(status, polardbxv1.BackupVerificationFailed, "Restore drill removed before finished.")
+			return flow.Continue("Restore drill not found, verification failed.", "xstore", status.XStore)
+		}
+		if err != nil {
+			return flow.Error(err, "Unable to get restore drill", "xstore", status.XStore)
+		}
+
+		status.Checks = drill.Status.PostRestoreExec
+		phase, message := verificationResultOf(drill)
+		if phase != polardbxv1.BackupVerificationRunning {
+			finishVerification(status, phase, message)
+			return flow.Continue("Restore drill finished.", "xstore", drill.Name, "phase", phase)
+		}
+
+		timeout := backup.Spec.Verification.Timeout.Duration
+		if timeout <= 0 {
+			timeout = defaultVerificationTimeout
+		}
+		if status.StartTime != nil && time.Since(status.StartTime.Time) > timeout {
+			finishVerification(status, polardbxv1.BackupVerificationFailed,
+				fmt.Sprintf("Restore drill not finished within %s, xstore phase: %s.", timeout, drill.Status.Phase))
+			return flow.Continue("Restore drill timed out.", "xstore", drill.Name)
+		}
+		return flow.RetryAfter(30*time.Second, "Restore drill is running, wait.", "xstore", drill.Name,
+			"phase", drill.Status.Phase)
+	})
+
+// RemoveVerificationXStore tears down the drill once the verification passes or fails.
+var RemoveVerificationXStore = NewStepBinder("RemoveVerificationXStore",
+	func(rc *xstorev1reconcile.BackupContext, flow control.Flow) (reconcile.Result, error) {
+		backup := rc.MustGetXStoreBackup()
+		status := backup.Status.Verification
+		if status == nil || status.Phase == polardbxv1.BackupVerificationRunning || len(status.XStore) == 0 {
+			return flow.Pass()
+		}
+
+		drill, err := getVerificationXStore(rc)
+		if apierrors.IsNotFound(err) {
+			return flow.Pass()
+		}
+		if err != nil {
+			return flow.Error(err, "Unable to get restore drill", "xstore", status.XStore)
+		}
+		if drill.DeletionTimestamp.IsZero() {
+			if err := rc.Client().Delete(rc.Context(), drill); client.IgnoreNotFound(err) != nil {
+				return flow.Error(err, "Unable to remove restore drill", "xstore", drill.Name)
+			}
+		}
+		return flow.Continue("Restore drill removed.", "xstore", drill.Name)
+	})
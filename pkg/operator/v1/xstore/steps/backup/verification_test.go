@@ -0,0 +1,91 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+)
+
+func TestNewVerificationXStore(t *testing.T) {
+	xstore := &polardbxv1.XStore{}
+	xstore.Name = "dn-0"
+	xstore.Namespace = "default"
+	xstore.Spec.ServiceName = "dn-0"
+	xstore.Spec.Topology.NodeSets = []polardbxv1xstore.NodeSet{
+		{Name: "cand", Role: polardbxv1xstore.RoleCandidate, Replicas: 2, Template: &polardbxv1xstore.NodeTemplate{}},
+		{Name: "log", Role: polardbxv1xstore.RoleVoter, Replicas: 1},
+	}
+
+	backup := &polardbxv1.XStoreBackup{}
+	backup.Name = "dn-0-backup"
+	backup.Spec.XStore.Name = "dn-0"
+	backup.Spec.Verification = &polardbxv1.BackupVerification{
+		Checks: []polardbxv1.XStoreRestoreExec{{Name: "canary", Command: []string{"true"}}},
+	}
+
+	drill := newVerificationXStore(xstore, backup, "dn-0-bvabcd")
+	if len(drill.Spec.ServiceName) > 0 {
+		t.Fatalf("expect service name of source not taken, got %s", drill.Spec.ServiceName)
+	}
+	nodeSets := drill.Spec.Topology.NodeSets
+	if len(nodeSets) != 1 || nodeSets[0].Replicas != 1 || nodeSets[0].Template == nil {
+		t.Fatalf("expect a single candidate, got %+v", nodeSets)
+	}
+	restore := drill.Spec.Restore
+	if restore == nil || restore.BackupSet != backup.Name || restore.From.XStoreName != "dn-0" || !restore.Isolated {
+		t.Fatalf("expect isolated restore from the backup, got %+v", restore)
+	}
+	if len(restore.PostRestoreExec) != 2 || restore.PostRestoreExec[0].Name != tableCheckName ||
+		restore.PostRestoreExec[1].Name != "canary" {
+		t.Fatalf("expect table check before user checks, got %+v", restore.PostRestoreExec)
+	}
+	if xstore.Spec.Restore != nil || len(xstore.Spec.Topology.NodeSets) != 2 {
+		t.Fatal("expect source xstore untouched")
+	}
+
+	backup.Spec.Verification.SkipTableCheck = true
+	drill = newVerificationXStore(xstore, backup, "dn-0-bvabcd")
+	if len(drill.Spec.Restore.PostRestoreExec) != 1 {
+		t.Fatalf("expect table check skipped, got %+v", drill.Spec.Restore.PostRestoreExec)
+	}
+}
+
+func TestVerificationResultOf(t *testing.T) {
+	drill := &polardbxv1.XStore{}
+	drill.Status.Phase = polardbxv1xstore.PhaseRestoring
+	if phase, _ := verificationResultOf(drill); phase != polardbxv1.BackupVerificationRunning {
+		t.Fatalf("expect running, got %s", phase)
+	}
+
+	drill.Status.Phase = polardbxv1xstore.PhaseRunning
+	if phase, _ := verificationResultOf(drill); phase != polardbxv1.BackupVerificationPassed {
+		t.Fatalf("expect passed, got %s", phase)
+	}
+
+	drill.Status.Phase = polardbxv1xstore.PhaseFailed
+	drill.Status.Conditions = []polardbxv1xstore.Condition{
+		{Type: polardbxv1xstore.Restorable, Status: corev1.ConditionFalse, Message: "check-tables failed"},
+	}
+	if phase, message := verificationResultOf(drill); phase != polardbxv1.BackupVerificationFailed || message != "check-tables failed" {
+		t.Fatalf("expect failed with restore message, got %s: %s", phase, message)
+	}
+}
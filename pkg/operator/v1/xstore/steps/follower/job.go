@@ -55,6 +55,7 @@ type JobContext struct {
 var (
 	JobCommands = map[JobTask]func(JobContext) []string{
 		JobTaskBackup:              JobCommandBackupFunc,
+		JobTaskDownloadBackup:      JobCommandDownloadBackupFunc,
 		JobTaskRestorePrepare:      JobCommandPrepareRestoreFunc,
 		JobTaskBeforeRestore:       JobCommandBeforeRestoreFunc,
 		JobTaskAfterRestore:        JobCommandAfterRestoreFunc,
@@ -65,6 +66,7 @@ var (
 	}
 	JobArgs = map[JobTask]func(JobContext) []string{
 		JobTaskBackup:              JobArgBackupFunc,
+		JobTaskDownloadBackup:      JobArgDownloadBackupFunc,
 		JobTaskRestorePrepare:      JobArgPrepareRestoreFunc,
 		JobTaskBeforeRestore:       JobArgBeforeRestoreFunc,
 		JobTaskAfterRestore:        JobArgAfterRestoreFunc,
@@ -99,6 +101,10 @@ func JobCommandBackupFunc(ctx JobContext) []string {
 	return []string{"/usr/bin/bash"}
 }
 
+func JobCommandDownloadBackupFunc(ctx JobContext) []string {
+	return []string{"/usr/bin/bash"}
+}
+
 func JobCommandCleanDataDirRestoreFunc(ctx JobContext) []string {
 	return []string{"/usr/bin/bash"}
 }
@@ -136,6 +142,16 @@ func JobArgBackupFunc(ctx JobContext) []string {
 	}
 }
 
+func JobArgDownloadBackupFunc(ctx JobContext) []string {
+	backup := ctx.parentContext.MustGetXStoreFollower().Status.RecoverBackup
+	return []string{
+		"-c",
+		"/tools/xstore/current/venv/bin/python3 /tools/xstore/current/cli.py restore download_backup_set" +
+			fmt.Sprintf(" --backup_file_path='%s' --storage_name='%s' --sink='%s' --target_dir='%s'",
+				backup.BackupFilePath, backup.StorageName, backup.Sink, GetFileStreamDir(ctx.jobTargetPod)),
+	}
+}
+
 func JobArgBeforeRestoreFunc(ctx JobContext) []string {
 	return []string{"-c",
 		"/tools/xstore/current/venv/bin/python3 /tools/xstore/current/cli.py engine set_engine_enable --disable",
@@ -20,6 +20,7 @@ type JobTask string
 
 const (
 	JobTaskBackup              JobTask = "backup"
+	JobTaskDownloadBackup      JobTask = "download-backup"
 	JobTaskRestoreCleanDataDir JobTask = "clean-restore"
 	JobTaskRestorePrepare      JobTask = "prepare-restore"
 	JobTaskRestoreMoveBack     JobTask = "move-restore"
@@ -34,7 +35,7 @@ var (
 )
 
 func init() {
-	jobList := []JobTask{JobTaskBackup, JobTaskBeforeRestore, JobTaskRestoreCleanDataDir, JobTaskRestorePrepare, JobTaskRestoreMoveBack, JobTaskFlushConsensusMeta, JobTaskInitLogger, JobTaskAfterRestore}
+	jobList := []JobTask{JobTaskBackup, JobTaskDownloadBackup, JobTaskBeforeRestore, JobTaskRestoreCleanDataDir, JobTaskRestorePrepare, JobTaskRestoreMoveBack, JobTaskFlushConsensusMeta, JobTaskInitLogger, JobTaskAfterRestore}
 	for i, jobTask := range jobList {
 		JobTaskOrderIndexMap[jobTask] = i
 	}
@@ -0,0 +1,129 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package follower
+
+import (
+	"fmt"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polarxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polarxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+	xstoreinstance "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/steps/instance"
+)
+
+const defaultRecoverBackupMaxAge = 24 * time.Hour
+
+// chooseRecoverBackup chooses the latest finished backup to rebuild the follower from by the recover
+// policy. Incremental and encrypted backups are not used. It returns nil and the reason if none is
+// chosen, and the reason is empty if the policy doesn't want a backup at all.
+func chooseRecoverBackup(backups []polarxv1.XStoreBackup, xstoreFollower *polarxv1.XStoreFollower, now time.Time) (*polarxv1.XStoreBackup, string) {
+	policy := xstoreFollower.Spec.RecoverPolicy
+	if policy != polarxv1xstore.FollowerRecoverBackup && policy != polarxv1xstore.FollowerRecoverAuto {
+		return nil, ""
+	}
+	if !IsNotLogger(xstoreFollower) {
+		return nil, ""
+	}
+
+	var latest *polarxv1.XStoreBackup
+	for i := range backups {
+		backup := &backups[i]
+		if backup.Status.Phase != polarxv1.XStoreBackupFinished || backup.Status.EndTime == nil {
+			continue
+		}
+		if backup.Status.Incremental != nil || backup.Spec.Encryption != nil {
+			continue
+		}
+		if latest == nil || latest.Status.EndTime.Before(backup.Status.EndTime) {
+			latest = backup
+		}
+	}
+	if latest == nil {
+		return nil, "no usable backup found"
+	}
+
+	if policy == polarxv1xstore.FollowerRecoverAuto {
+		maxAge := defaultRecoverBackupMaxAge
+		if xstoreFollower.Spec.BackupMaxAge != nil && xstoreFollower.Spec.BackupMaxAge.Duration > 0 {
+			maxAge = xstoreFollower.Spec.BackupMaxAge.Duration
+		}
+		if age := now.Sub(latest.Status.EndTime.Time); age > maxAge {
+			return nil, fmt.Sprintf("latest backup %s is %s old, older than %s", latest.Name, age.Round(time.Second), maxAge)
+		}
+	}
+	return latest, ""
+}
+
+// ChooseRecoverBackup goes to download the backup if the follower is rebuilt from a backup, and continues
+// to clone from the from-pod otherwise.
+var ChooseRecoverBackup = NewStepBinder("ChooseRecoverBackup",
+	func(rc *xstorev1reconcile.FollowerContext, flow control.Flow) (reconcile.Result, error) {
+		xstoreFollower := rc.MustGetXStoreFollower()
+		if xstoreFollower.Status.RecoverBackup != nil {
+			return flow.Pass()
+		}
+
+		backupList := &polarxv1.XStoreBackupList{}
+		err := rc.Client().List(rc.Context(), backupList, client.InNamespace(rc.Namespace()),
+			client.MatchingLabels{xstoremeta.LabelName: xstoreFollower.Spec.XStoreName})
+		if err != nil {
+			return flow.RetryErr(err, "Failed to list xstore backups")
+		}
+
+		backup, reason := chooseRecoverBackup(backupList.Items, xstoreFollower, time.Now())
+		if backup == nil {
+			if len(reason) == 0 {
+				return flow.Pass()
+			}
+			if xstoreFollower.Spec.RecoverPolicy == polarxv1xstore.FollowerRecoverBackup {
+				xstoreFollower.Status.Phase = polarxv1xstore.FollowerPhaseFailed
+				xstoreFollower.Status.Message = "Unable to recover from backup, " + reason
+				rc.MarkChanged()
+				return flow.Wait("Unable to recover from backup.", "reason", reason)
+			}
+			flow.Logger().Info("Fall back to clone.", "reason", reason)
+			return flow.Pass()
+		}
+
+		xstoreFollower.Status.RecoverBackup = &polarxv1.FollowerRecoverBackup{
+			Name:           backup.Name,
+			BackupFilePath: xstoreinstance.FullBackupFilePathOf(backup, backup.Spec.XStore.Name),
+			StorageName:    backup.Spec.StorageProvider.StorageName,
+			Sink:           backup.Spec.StorageProvider.Sink,
+			CommitIndex:    backup.Status.CommitIndex,
+		}
+		xstoreFollower.Status.Phase = polarxv1xstore.FollowerPhaseBackupDownload
+		rc.MarkChanged()
+		return flow.Retry("Recover from backup.", "backup", backup.Name)
+	})
+
+var StartDownloadBackupJob = NewStepBinder("StartDownloadBackupJob", func(rc *xstorev1reconcile.FollowerContext, flow control.Flow) (reconcile.Result, error) {
+	if checkIfJobSkip(rc, JobTaskDownloadBackup) {
+		return flow.Pass()
+	}
+	_, err := CreateJob(rc, JobTaskDownloadBackup)
+	if err != nil {
+		return flow.RetryErr(err, "Create Job Failed")
+	}
+	return flow.Continue("StartDownloadBackupJob Success.")
+})
@@ -0,0 +1,111 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package follower
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	polarxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polarxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+)
+
+func recoverBackup(name string, phase polarxv1.XStoreBackupPhase, endTime time.Time) polarxv1.XStoreBackup {
+	t := metav1.NewTime(endTime)
+	return polarxv1.XStoreBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: polarxv1.XStoreBackupStatus{
+			Phase:   phase,
+			EndTime: &t,
+		},
+	}
+}
+
+func recoverFollower(policy polarxv1xstore.FollowerRecoverPolicy, role polarxv1xstore.FollowerRole) *polarxv1.XStoreFollower {
+	return &polarxv1.XStoreFollower{
+		Spec: polarxv1.XStoreFollowerSpec{
+			RecoverPolicy: policy,
+			Role:          role,
+		},
+	}
+}
+
+func TestChooseRecoverBackup(t *testing.T) {
+	now := time.Now()
+	incremental := recoverBackup("incremental", polarxv1.XStoreBackupFinished, now.Add(-time.Minute))
+	incremental.Status.Incremental = &polarxv1.IncrementalBackupStatus{}
+	backups := []polarxv1.XStoreBackup{
+		recoverBackup("old", polarxv1.XStoreBackupFinished, now.Add(-30*time.Hour)),
+		recoverBackup("latest", polarxv1.XStoreBackupFinished, now.Add(-2*time.Hour)),
+		recoverBackup("running", polarxv1.XStoreBackupPhase("Running"), now.Add(-time.Hour)),
+		incremental,
+	}
+
+	testCases := map[string]struct {
+		backups   []polarxv1.XStoreBackup
+		follower  *polarxv1.XStoreFollower
+		expect    string
+		hasReason bool
+	}{
+		"clone": {
+			backups:  backups,
+			follower: recoverFollower(polarxv1xstore.FollowerRecoverClone, polarxv1xstore.FollowerRoleFollower),
+		},
+		"logger": {
+			backups:  backups,
+			follower: recoverFollower(polarxv1xstore.FollowerRecoverBackup, polarxv1xstore.FollowerRoleLogger),
+		},
+		"backup-latest": {
+			backups:  backups,
+			follower: recoverFollower(polarxv1xstore.FollowerRecoverBackup, polarxv1xstore.FollowerRoleFollower),
+			expect:   "latest",
+		},
+		"backup-none": {
+			backups:   backups[2:],
+			follower:  recoverFollower(polarxv1xstore.FollowerRecoverBackup, polarxv1xstore.FollowerRoleFollower),
+			hasReason: true,
+		},
+		"auto-fresh": {
+			backups:  backups,
+			follower: recoverFollower(polarxv1xstore.FollowerRecoverAuto, polarxv1xstore.FollowerRoleLearner),
+			expect:   "latest",
+		},
+		"auto-stale": {
+			backups:   backups[:1],
+			follower:  recoverFollower(polarxv1xstore.FollowerRecoverAuto, polarxv1xstore.FollowerRoleFollower),
+			hasReason: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			backup, reason := chooseRecoverBackup(tc.backups, tc.follower, now)
+			if len(tc.expect) == 0 {
+				if backup != nil {
+					t.Fatalf("expect no backup, but got %s", backup.Name)
+				}
+			} else if backup == nil || backup.Name != tc.expect {
+				t.Fatalf("expect backup %s, but got %v", tc.expect, backup)
+			}
+			if tc.hasReason != (len(reason) > 0) {
+				t.Fatalf("unexpected reason: %q", reason)
+			}
+		})
+	}
+}
@@ -132,8 +132,14 @@ var SyncBlkioCgroupResourceLimits = xstorev1reconcile.NewStepBinder("SyncBlkioCg
 				continue
 			}
 
+			// Volumes migrated to persistent volume claims aren't on host paths.
+			vol := volumes[pod.Name]
+			if vol == nil || len(vol.HostPath) == 0 {
+				continue
+			}
+
 			// Sync via hpfs.
-			err := SyncBlkioCgroupValuesViaHpfs(ctx, hpfsClient, &pod, volumes[pod.Name], resources)
+			err := SyncBlkioCgroupValuesViaHpfs(ctx, hpfsClient, &pod, vol, resources)
 			if err != nil {
 				if !rc.Config().Cluster().ForceCGroup() {
 					flow.Error(err, "Failed to SyncBlkioCgroupValues")
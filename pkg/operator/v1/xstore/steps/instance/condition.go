@@ -0,0 +1,156 @@
+/*
+Copyright 2021 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	polardbxhelper "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+)
+
+// replicationLagBound is the max count of log entries a member can be behind the leader while the
+// replication is considered healthy.
+const replicationLagBound = 1000
+
+// isXStoreSteady returns true if no operation is in progress in the phase.
+func isXStoreSteady(phase polardbxv1xstore.Phase) bool {
+	switch phase {
+	case polardbxv1xstore.PhaseRunning, polardbxv1xstore.PhaseLocked,
+		polardbxv1xstore.PhaseSuspended, polardbxv1xstore.PhaseFailed:
+		return true
+	}
+	return false
+}
+
+// xstoreStageOf names the stage of the xstore, e.g. "Restoring" or "Running/Switchover".
+func xstoreStageOf(status *polardbxv1.XStoreStatus) string {
+	if status.Stage == polardbxv1xstore.StageEmpty {
+		return string(status.Phase)
+	}
+	return string(status.Phase) + "/" + string(status.Stage)
+}
+
+func newXStoreCondition(conditionType polardbxv1xstore.ConditionType, status bool, reason, message string) polardbxv1xstore.Condition {
+	c := polardbxv1xstore.Condition{
+		Type:    conditionType,
+		Status:  corev1.ConditionFalse,
+		Reason:  reason,
+		Message: message,
+	}
+	if status {
+		c.Status = corev1.ConditionTrue
+	}
+	return c
+}
+
+// replicationConditionOfLags checks the lags of members behind the leader against the bound.
+func replicationConditionOfLags(lags map[string]int64, bound int64) polardbxv1xstore.Condition {
+	pods := make([]string, 0, len(lags))
+	for pod := range lags {
+		pods = append(pods, pod)
+	}
+	sort.Strings(pods)
+	for _, pod := range pods {
+		if lags[pod] > bound {
+			return newXStoreCondition(polardbxv1xstore.ReplicationHealthy, false, "ReplicationLagging",
+				fmt.Sprintf("pod %s is %d log entries behind the leader", pod, lags[pod]))
+		}
+	}
+	return newXStoreCondition(polardbxv1xstore.ReplicationHealthy, true, "WithinLagBound", "")
+}
+
+// newXStoreConditions computes the standard conditions of the xstore from its status. The replication
+// is only computed for readonly xstores here, others are computed when the lags are scraped.
+func newXStoreConditions(xstore *polardbxv1.XStore) []polardbxv1xstore.Condition {
+	status := &xstore.Status
+	notReady := ""
+	if status.ReadyPods < status.TotalPods {
+		notReady = fmt.Sprintf("%d/%d pods ready", status.ReadyPods, status.TotalPods)
+	} else if !xstore.Spec.Readonly && len(status.LeaderPod) == 0 {
+		notReady = "leader not found"
+	}
+
+	var ready, progressing, degraded polardbxv1xstore.Condition
+	switch {
+	case status.Phase != polardbxv1xstore.PhaseRunning:
+		ready = newXStoreCondition(polardbxv1xstore.Ready, false, "NotRunning", "phase is "+string(status.Phase))
+	case len(notReady) > 0:
+		ready = newXStoreCondition(polardbxv1xstore.Ready, false, "PodsNotReady", notReady)
+	default:
+		ready = newXStoreCondition(polardbxv1xstore.Ready, true, "AllPodsReady", "")
+	}
+	if isXStoreSteady(status.Phase) {
+		progressing = newXStoreCondition(polardbxv1xstore.Progressing, false, "Steady", "phase is "+string(status.Phase))
+	} else {
+		progressing = newXStoreCondition(polardbxv1xstore.Progressing, true, string(status.Phase), "stage is "+xstoreStageOf(status))
+	}
+	switch {
+	case status.Phase == polardbxv1xstore.PhaseFailed:
+		degraded = newXStoreCondition(polardbxv1xstore.Degraded, true, "Failed", "phase is "+string(status.Phase))
+	case status.Phase == polardbxv1xstore.PhaseRunning && len(notReady) > 0:
+		degraded = newXStoreCondition(polardbxv1xstore.Degraded, true, "PodsNotReady", notReady)
+	default:
+		degraded = newXStoreCondition(polardbxv1xstore.Degraded, false, "AsExpected", "")
+	}
+	conditions := []polardbxv1xstore.Condition{ready, progressing, degraded}
+
+	if replication := status.ReadonlyReplication; xstore.Spec.Readonly && replication != nil {
+		if len(replication.Message) > 0 {
+			conditions = append(conditions, newXStoreCondition(polardbxv1xstore.ReplicationHealthy, false,
+				"ReplicationUnhealthy", replication.Message))
+		} else {
+			conditions = append(conditions, newXStoreCondition(polardbxv1xstore.ReplicationHealthy, true,
+				"ReplicatingFromPrimary", ""))
+		}
+	}
+	return conditions
+}
+
+// setXStoreConditions sets the conditions with the transition time and the observed generation.
+func setXStoreConditions(xstore *polardbxv1.XStore, now metav1.Time, conditions ...polardbxv1xstore.Condition) {
+	for _, c := range conditions {
+		c.LastTransitionTime = now
+		c.ObservedGeneration = xstore.Status.ObservedGeneration
+		xstore.Status.Conditions = polardbxhelper.SetXStoreCondition(xstore.Status.Conditions, c)
+	}
+}
+
+// UpdateStatusConditionsAndProgress publishes the standard conditions of the xstore, i.e. Ready,
+// Progressing, Degraded and ReplicationHealthy, and records the stages of the operation in progress.
+var UpdateStatusConditionsAndProgress = xstorev1reconcile.NewStepBinder("UpdateStatusConditionsAndProgress",
+	func(rc *xstorev1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		xstore := rc.MustGetXStore()
+		if xstore.Status.Phase == polardbxv1xstore.PhaseNew {
+			return flow.Pass()
+		}
+
+		now := metav1.Now()
+		setXStoreConditions(xstore, now, newXStoreConditions(xstore)...)
+		xstore.Status.Progress = polardbxhelper.ObserveProgress(xstore.Status.Progress,
+			string(xstore.Status.Phase), xstoreStageOf(&xstore.Status),
+			isXStoreSteady(xstore.Status.Phase), now)
+		return flow.Pass()
+	})
@@ -0,0 +1,87 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	polardbxhelper "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
+)
+
+func TestNewXStoreConditions(t *testing.T) {
+	statusOf := func(conditions []polardbxv1xstore.Condition, conditionType polardbxv1xstore.ConditionType) corev1.ConditionStatus {
+		c := polardbxhelper.GetXStoreCondition(conditions, conditionType)
+		if c == nil {
+			return ""
+		}
+		return c.Status
+	}
+	xstore := &polardbxv1.XStore{
+		Status: polardbxv1.XStoreStatus{
+			Phase:     polardbxv1xstore.PhaseRunning,
+			LeaderPod: "xs-cand-0",
+			ReadyPods: 3,
+			TotalPods: 3,
+		},
+	}
+
+	conditions := newXStoreConditions(xstore)
+	if statusOf(conditions, polardbxv1xstore.Ready) != corev1.ConditionTrue ||
+		statusOf(conditions, polardbxv1xstore.Progressing) != corev1.ConditionFalse ||
+		statusOf(conditions, polardbxv1xstore.Degraded) != corev1.ConditionFalse {
+		t.Fatalf("unexpected conditions of running xstore: %v", conditions)
+	}
+	if statusOf(conditions, polardbxv1xstore.ReplicationHealthy) != "" {
+		t.Fatal("expect replication left to the lag scraping")
+	}
+
+	xstore.Status.ReadyPods = 2
+	conditions = newXStoreConditions(xstore)
+	if statusOf(conditions, polardbxv1xstore.Ready) != corev1.ConditionFalse ||
+		statusOf(conditions, polardbxv1xstore.Degraded) != corev1.ConditionTrue {
+		t.Fatalf("expect xstore with pods not ready degraded, got %v", conditions)
+	}
+
+	xstore.Status.Phase, xstore.Status.Stage = polardbxv1xstore.PhaseUpgrading, polardbxv1xstore.StageUpdate
+	conditions = newXStoreConditions(xstore)
+	if c := polardbxhelper.GetXStoreCondition(conditions, polardbxv1xstore.Progressing); c.Status != corev1.ConditionTrue ||
+		c.Reason != "Upgrading" || c.Message != "stage is Upgrading/Update" {
+		t.Fatalf("expect upgrading xstore progressing, got %v", c)
+	}
+
+	xstore.Spec.Readonly = true
+	xstore.Status.ReadonlyReplication = &polardbxv1xstore.ReadonlyReplicationStatus{Message: "leader pod of primary not found"}
+	conditions = newXStoreConditions(xstore)
+	if statusOf(conditions, polardbxv1xstore.ReplicationHealthy) != corev1.ConditionFalse {
+		t.Fatalf("expect replication of readonly xstore unhealthy, got %v", conditions)
+	}
+}
+
+func TestReplicationConditionOfLags(t *testing.T) {
+	c := replicationConditionOfLags(map[string]int64{"xs-cand-0": 0, "xs-cand-1": 10}, 100)
+	if c.Status != corev1.ConditionTrue {
+		t.Fatalf("expect replication healthy, got %v", c)
+	}
+	c = replicationConditionOfLags(map[string]int64{"xs-cand-0": 0, "xs-cand-1": 101}, 100)
+	if c.Status != corev1.ConditionFalse || c.Message != "pod xs-cand-1 is 101 log entries behind the leader" {
+		t.Fatalf("expect replication lagging, got %v", c)
+	}
+}
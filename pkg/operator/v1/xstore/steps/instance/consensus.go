@@ -35,6 +35,7 @@ import (
 	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
 	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
 	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/metrics"
 	xstoreexec "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/command"
 	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/convention"
 	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
@@ -331,6 +332,7 @@ var ReconcileConsensusRoleLabels = xstorev1reconcile.NewStepBinder("ReconcileCon
 		flow.Logger().Info("Try detecting leader and reconciling the labels...")
 
 		currentLeader, leaderSwitched := TryDetectLeaderAndTryReconcileLabels(rc, pods, flow.Logger())
+		metrics.ObserveLeader(xstore.Namespace, xstore.Name, currentLeader)
 
 		if len(currentLeader) == 0 {
 			xstore.Status.LeaderPod = ""
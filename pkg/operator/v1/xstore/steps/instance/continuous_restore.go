@@ -0,0 +1,244 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	xstorev1 "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+)
+
+const (
+	continuousRestoreJobKey = "continuous-restore"
+	applyBinlogJobSuffix    = "apply-binlog"
+)
+
+type ContinuousRestoreJobContext struct {
+	BinlogDirPath     string                   `json:"binlogDirPath,omitempty"`
+	LastBinlogDirPath string                   `json:"lastBinlogDirPath,omitempty"`
+	LastAppliedBinlog string                   `json:"lastAppliedBinlog,omitempty"`
+	StorageName       polardbxv1.BackupStorage `json:"storageName,omitempty"`
+	Sink              string                   `json:"sink,omitempty"`
+	// StopDatetime stops the apply at the first event not before it, in UTC. Only set by point in
+	// time restore.
+	StopDatetime string `json:"stopDatetime,omitempty"`
+	// EncryptionKeyFile and LastEncryptionKeyFile are the key files which the binlogs of the backup and
+	// the last applied backup are encrypted with, if any
+	EncryptionKeyFile     string `json:"encryptionKeyFile,omitempty"`
+	LastEncryptionKeyFile string `json:"lastEncryptionKeyFile,omitempty"`
+}
+
+func binlogBackupDirOf(backup *polardbxv1.XStoreBackup, xstoreName string) string {
+	return fmt.Sprintf("%s/%s/%s", backup.Status.BackupRootPath, polardbxmeta.BinlogBackupPath, xstoreName)
+}
+
+// getNextBackupToApply returns the oldest finished backup of the source xstore which is newer
+// than the last applied one. Backups are applied one by one so that the binlogs are continuous.
+func getNextBackupToApply(rc *xstorev1reconcile.Context, fromXStoreName string, lastApplied *metav1.Time) (*polardbxv1.XStoreBackup, error) {
+	backupList := &polardbxv1.XStoreBackupList{}
+	err := rc.Client().List(rc.Context(), backupList, client.InNamespace(rc.Namespace()),
+		client.MatchingLabels{xstoremeta.LabelName: fromXStoreName})
+	if err != nil {
+		return nil, err
+	}
+
+	var next *polardbxv1.XStoreBackup
+	for i := range backupList.Items {
+		backup := &backupList.Items[i]
+		if backup.Status.Phase != polardbxv1.XStoreBackupFinished || backup.Status.BackupSetTimestamp == nil {
+			continue
+		}
+		if lastApplied != nil && !backup.Status.BackupSetTimestamp.After(lastApplied.Time) {
+			continue
+		}
+		if next == nil || backup.Status.BackupSetTimestamp.Before(next.Status.BackupSetTimestamp) {
+			next = backup
+		}
+	}
+	return next, nil
+}
+
+func readAppliedBinlogPosition(rc *xstorev1reconcile.Context, pod *corev1.Pod, jobName string) (string, error) {
+	cmd := []string{"cat", "/data/mysql/tmp/" + jobName + ".pos"}
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	if err := rc.ExecuteCommandOn(pod, "engine", cmd, control.ExecOptions{
+		Stdout: stdout,
+		Stderr: stderr,
+	}); err != nil {
+		return "", fmt.Errorf("failed to cat applied binlog position: %w, stderr: %s", err, stderr.String())
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+func updateContinuousRestoreLag(rc *xstorev1reconcile.Context, xstore *polardbxv1.XStore, applyFailed bool) {
+	status := xstore.Status.ContinuousRestore
+	if status.LastAppliedTimestamp == nil {
+		return
+	}
+	lag := time.Since(status.LastAppliedTimestamp.Time).Truncate(time.Second)
+	status.ApplyLag = lag.String()
+
+	// Keep the failure until the failed job is removed.
+	if applyFailed {
+		return
+	}
+	maxLag := xstore.Spec.Restore.Continuous.MaxLag.Duration
+	if maxLag > 0 && lag > maxLag {
+		rc.UpdateXStoreCondition(&xstorev1.Condition{
+			Type:    xstorev1.ContinuousRestoreLagging,
+			Status:  corev1.ConditionTrue,
+			Reason:  "ApplyLagExceeded",
+			Message: "Apply lag " + status.ApplyLag + " exceeds " + maxLag.String(),
+		})
+	} else {
+		rc.UpdateXStoreCondition(&xstorev1.Condition{
+			Type:   xstorev1.ContinuousRestoreLagging,
+			Status: corev1.ConditionFalse,
+			Reason: "ApplyLagWithinBound",
+		})
+	}
+}
+
+// ApplyBinlogBackupsContinuously keeps applying the binlog backups of the source xstore to the leader
+// with an apply binlog job, one backup at a time. It never blocks the running loop.
+var ApplyBinlogBackupsContinuously = xstorev1reconcile.NewStepBinder("ApplyBinlogBackupsContinuously",
+	func(rc *xstorev1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		xstore := rc.MustGetXStore()
+		if xstore.Labels[polardbxmeta.LabelRole] == polardbxmeta.RoleGMS {
+			return flow.Pass()
+		}
+		if xstore.Status.ContinuousRestore == nil {
+			xstore.Status.ContinuousRestore = &xstorev1.ContinuousRestoreStatus{}
+		}
+		status := xstore.Status.ContinuousRestore
+		applyFailed := false
+		defer func() {
+			updateContinuousRestoreLag(rc, xstore, applyFailed)
+		}()
+
+		job, err := rc.GetXStoreJob(applyBinlogJobSuffix)
+		if client.IgnoreNotFound(err) != nil {
+			return flow.Error(err, "Unable to get apply binlog job.")
+		}
+
+		if job != nil {
+			if k8shelper.IsJobFailed(job) {
+				applyFailed = true
+				rc.UpdateXStoreCondition(&xstorev1.Condition{
+					Type:    xstorev1.ContinuousRestoreLagging,
+					Status:  corev1.ConditionTrue,
+					Reason:  "ApplyBinlogFailed",
+					Message: "Apply binlog job " + job.Name + " failed, backup: " + status.ApplyingBackup + ". Remove the job to retry.",
+				})
+				// Keep the failed job for diagnosis, e.g. binlog gap, and retry after it's removed.
+				return flow.Continue("Apply binlog job failed.", "job", job.Name, "backup", status.ApplyingBackup)
+			} else if k8shelper.IsJobCompleted(job) {
+				pod := &corev1.Pod{}
+				err := rc.Client().Get(rc.Context(), types.NamespacedName{
+					Namespace: rc.Namespace(),
+					Name:      job.Labels[xstoremeta.JobLabelTargetPod],
+				}, pod)
+				if err != nil {
+					return flow.Error(err, "Unable to get target pod of apply binlog job.", "job", job.Name)
+				}
+				position, err := readAppliedBinlogPosition(rc, pod, job.Name)
+				if err != nil {
+					return flow.Error(err, "Unable to read applied binlog position.", "job", job.Name)
+				}
+
+				backupName := job.Annotations[xstoremeta.AnnotationApplyBinlogBackup]
+				backup := &polardbxv1.XStoreBackup{}
+				if err := rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: rc.Namespace(), Name: backupName}, backup); err != nil {
+					return flow.Error(err, "Unable to get applied xstore backup.", "backup", backupName)
+				}
+				status.LastAppliedBackup = backupName
+				status.LastAppliedBinlog = position
+				status.LastAppliedTimestamp = backup.Status.BackupSetTimestamp
+				status.ApplyingBackup = ""
+			} else {
+				return flow.Continue("Apply binlog job is running.", "job", job.Name, "backup", status.ApplyingBackup)
+			}
+
+			err = rc.Client().Delete(rc.Context(), job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+			if client.IgnoreNotFound(err) != nil {
+				return flow.Error(err, "Unable to remove apply binlog job", "job-name", job.Name)
+			}
+			return flow.Continue("Apply binlog job finished and removed.", "job", job.Name)
+		}
+
+		fromXStoreName := xstore.Spec.Restore.From.XStoreName
+		backup, err := getNextBackupToApply(rc, fromXStoreName, status.LastAppliedTimestamp)
+		if err != nil {
+			return flow.Error(err, "Unable to get next backup to apply.")
+		}
+		if backup == nil {
+			return flow.Continue("No newer backup to apply.")
+		}
+
+		leaderPod, err := rc.TryGetXStoreLeaderPod()
+		if err != nil {
+			return flow.Error(err, "Unable to get leader pod.")
+		}
+		if leaderPod == nil {
+			return flow.Continue("Leader pod not found, skip applying binlogs.")
+		}
+
+		jobContext := &ContinuousRestoreJobContext{
+			BinlogDirPath:     binlogBackupDirOf(backup, fromXStoreName),
+			LastAppliedBinlog: status.LastAppliedBinlog,
+			StorageName:       backup.Spec.StorageProvider.StorageName,
+			Sink:              backup.Spec.StorageProvider.Sink,
+			EncryptionKeyFile: encryptionKeyFileOf(backup.Spec.Encryption, "backup"),
+		}
+		var lastEncryption *polardbxv1.BackupEncryption
+		if len(status.LastAppliedBinlog) == 0 {
+			lastBackup := &polardbxv1.XStoreBackup{}
+			if err := rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: rc.Namespace(), Name: status.LastAppliedBackup}, lastBackup); err != nil {
+				return flow.Error(err, "Unable to get last applied xstore backup.", "backup", status.LastAppliedBackup)
+			}
+			jobContext.LastBinlogDirPath = binlogBackupDirOf(lastBackup, fromXStoreName)
+			lastEncryption = lastBackup.Spec.Encryption
+			jobContext.LastEncryptionKeyFile = encryptionKeyFileOf(lastEncryption, "last-backup")
+		}
+		if err := rc.SaveTaskContext(continuousRestoreJobKey, jobContext); err != nil {
+			return flow.Error(err, "Unable to save job context for continuous restore!")
+		}
+
+		job = newApplyBinlogJob(xstore, leaderPod, backup.Name, backup.Spec.Encryption, lastEncryption)
+		if err := rc.SetControllerRefAndCreate(job); err != nil {
+			return flow.Error(err, "Unable to create job to apply binlog", "pod", leaderPod.Name)
+		}
+		status.ApplyingBackup = backup.Name
+
+		return flow.Continue("Apply binlog job created.", "job", job.Name, "backup", backup.Name)
+	})
@@ -0,0 +1,119 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/metrics"
+	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+)
+
+// ObserveRestoreMetrics observes the duration of restore, from the xstore created to the data restored.
+var ObserveRestoreMetrics = xstorev1reconcile.NewStepBinder("ObserveRestoreMetrics",
+	func(rc *xstorev1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		xstore := rc.MustGetXStore()
+		now := time.Now()
+		metrics.ObserveRestoreFinished("restore/"+string(xstore.UID), xstore.Namespace, xstore.Name,
+			now, now.Sub(xstore.CreationTimestamp.Time))
+		return flow.Pass()
+	})
+
+// replicationLagScrapedAt records the last time the replication lags of xstores are scraped.
+var replicationLagScrapedAt sync.Map
+
+// replicationLagsOf returns the count of log entries each member is behind the leader, by the match
+// index for loggers since they don't apply the logs.
+func replicationLagsOf(rows []map[string]interface{}) map[string]int64 {
+	var leader map[string]interface{}
+	for _, row := range rows {
+		if role, _ := row["role"].(string); role == xstoremeta.RoleLeader {
+			leader = row
+			break
+		}
+	}
+	if leader == nil {
+		return nil
+	}
+	lags := make(map[string]int64)
+	for _, row := range rows {
+		pod, _ := row["pod"].(string)
+		if len(pod) == 0 {
+			continue
+		}
+		var lag int64
+		if role, _ := row["role"].(string); role == xstoremeta.RoleLogger {
+			lag = parseConsensusIndex(leader, "match_index") - parseConsensusIndex(row, "match_index")
+		} else {
+			lag = parseConsensusIndex(leader, "applied_index") - parseConsensusIndex(row, "applied_index")
+		}
+		if lag < 0 {
+			lag = 0
+		}
+		lags[pod] = lag
+	}
+	return lags
+}
+
+// UpdateReplicationLagMetricsTemplate scrapes the replication lags of members from the leader with
+// the interval, failures are only logged. The ReplicationHealthy condition is set with the lags.
+func UpdateReplicationLagMetricsTemplate(d time.Duration) control.BindFunc {
+	return xstorev1reconcile.NewStepBinder("UpdateReplicationLagMetricsPer"+d.String(),
+		func(rc *xstorev1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+			xstore := rc.MustGetXStore()
+			if xstore.Spec.Readonly {
+				return flow.Pass()
+			}
+
+			key := xstore.Namespace + "/" + xstore.Name
+			if last, ok := replicationLagScrapedAt.Load(key); ok && time.Since(last.(time.Time)) < d {
+				return flow.Pass()
+			}
+			replicationLagScrapedAt.Store(key, time.Now())
+
+			leaderPod, err := rc.TryGetXStoreLeaderPod()
+			if err != nil || leaderPod == nil {
+				return flow.Continue("Leader not found, skip scraping replication lags.")
+			}
+			rows, err := listConsensusMembersOnLeader(rc, leaderPod)
+			if err != nil {
+				flow.Logger().Error(err, "Unable to list consensus members.", "pod", leaderPod.Name)
+				return flow.Continue("Skip scraping replication lags.")
+			}
+			lags := replicationLagsOf(rows)
+			metrics.SetReplicationLags(xstore.Namespace, xstore.Name, lags)
+			if lags != nil {
+				setXStoreConditions(xstore, metav1.Now(), replicationConditionOfLags(lags, replicationLagBound))
+			}
+			return flow.Pass()
+		})
+}
+
+// ForgetXStoreMetrics removes the metrics of the deleted xstore.
+var ForgetXStoreMetrics = xstorev1reconcile.NewStepBinder("ForgetXStoreMetrics",
+	func(rc *xstorev1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		xstore := rc.MustGetXStore()
+		metrics.ForgetXStore(xstore.Namespace, xstore.Name)
+		replicationLagScrapedAt.Delete(xstore.Namespace + "/" + xstore.Name)
+		return flow.Pass()
+	})
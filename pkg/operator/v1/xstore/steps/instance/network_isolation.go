@@ -0,0 +1,149 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/convention"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/factory"
+	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+	boolutil "github.com/alibaba/polardbx-operator/pkg/util/bool"
+)
+
+func isInHostNetwork(xstore *polardbxv1.XStore) bool {
+	topology := &xstore.Spec.Topology
+	for i := range topology.NodeSets {
+		template := factory.PatchNodeTemplate(topology.Template.DeepCopy(), topology.NodeSets[i].Template, false)
+		if boolutil.IsTrue(template.Spec.HostNetwork) {
+			return true
+		}
+	}
+	return false
+}
+
+// newIsolationNetworkPolicy restricts the egress of engine pods to the pods of the same xstore
+// and the DNS. Pods of jobs, e.g. restore job, are not restricted.
+func newIsolationNetworkPolicy(xstore *polardbxv1.XStore) *networkingv1.NetworkPolicy {
+	udp, tcp := corev1.ProtocolUDP, corev1.ProtocolTCP
+	dnsPort := intstr.FromInt(53)
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      convention.NewIsolationNetworkPolicyName(xstore),
+			Namespace: xstore.Namespace,
+			Labels:    convention.ConstLabels(xstore),
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					xstoremeta.LabelName: xstore.Name,
+				},
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: xstoremeta.LabelPod, Operator: metav1.LabelSelectorOpExists},
+				},
+			},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+			Egress: []networkingv1.NetworkPolicyEgressRule{
+				{
+					To: []networkingv1.NetworkPolicyPeer{
+						{
+							PodSelector: &metav1.LabelSelector{
+								MatchLabels: map[string]string{
+									xstoremeta.LabelName: xstore.Name,
+								},
+							},
+						},
+					},
+				},
+				{
+					Ports: []networkingv1.NetworkPolicyPort{
+						{Protocol: &udp, Port: &dnsPort},
+						{Protocol: &tcp, Port: &dnsPort},
+					},
+				},
+			},
+		},
+	}
+}
+
+// ReconcileRestoreNetworkIsolation keeps the isolation network policy while the restore is isolated,
+// and removes it once the xstore is promoted.
+var ReconcileRestoreNetworkIsolation = xstorev1reconcile.NewStepBinder("ReconcileRestoreNetworkIsolation",
+	func(rc *xstorev1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		xstore := rc.MustGetXStore()
+		isolated := xstore.Spec.Restore != nil && xstore.Spec.Restore.Isolated
+
+		var policy networkingv1.NetworkPolicy
+		err := rc.Client().Get(rc.Context(), types.NamespacedName{
+			Namespace: rc.Namespace(),
+			Name:      convention.NewIsolationNetworkPolicyName(xstore),
+		}, &policy)
+		if client.IgnoreNotFound(err) != nil {
+			return flow.Error(err, "Unable to get isolation network policy.")
+		}
+		exists := err == nil
+
+		if !isolated {
+			if !exists {
+				return flow.Pass()
+			}
+			if err := rc.Client().Delete(rc.Context(), &policy); client.IgnoreNotFound(err) != nil {
+				return flow.Error(err, "Unable to delete isolation network policy.")
+			}
+			rc.UpdateXStoreCondition(&polardbxv1xstore.Condition{
+				Type:    polardbxv1xstore.NetworkIsolated,
+				Status:  corev1.ConditionFalse,
+				Reason:  "Promoted",
+				Message: "Isolation network policy removed.",
+			})
+			return flow.Continue("Isolation network policy removed, xstore promoted.")
+		}
+
+		if !exists {
+			if err := rc.SetControllerRefAndCreate(newIsolationNetworkPolicy(xstore)); err != nil && !apierrors.IsAlreadyExists(err) {
+				return flow.Error(err, "Unable to create isolation network policy.")
+			}
+		}
+
+		if isInHostNetwork(xstore) {
+			rc.UpdateXStoreCondition(&polardbxv1xstore.Condition{
+				Type:    polardbxv1xstore.NetworkIsolated,
+				Status:  corev1.ConditionFalse,
+				Reason:  "HostNetwork",
+				Message: "Network policy is not effective for nodes in host network.",
+			})
+			return flow.Continue("Isolation isn't effective for nodes in host network.")
+		}
+		rc.UpdateXStoreCondition(&polardbxv1xstore.Condition{
+			Type:    polardbxv1xstore.NetworkIsolated,
+			Status:  corev1.ConditionTrue,
+			Reason:  "Isolated",
+			Message: "Egress is restricted to nodes of the same xstore.",
+		})
+		return flow.Continue("Isolation network policy ensured.")
+	})
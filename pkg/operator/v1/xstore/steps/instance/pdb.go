@@ -0,0 +1,129 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"strings"
+
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/convention"
+	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+)
+
+// countVotingMembers counts the candidates and voters in topology.
+func countVotingMembers(topology *polardbxv1xstore.Topology) int32 {
+	var members int32
+	for _, ns := range topology.NodeSets {
+		if ns.Role == polardbxv1xstore.RoleCandidate || ns.Role == polardbxv1xstore.RoleVoter {
+			members += ns.Replicas
+		}
+	}
+	return members
+}
+
+// newPodDisruptionBudget allows at most one voting member of the xstore to be disrupted
+// at a time, so that the majority is kept during voluntary disruptions, e.g. node drains.
+func newPodDisruptionBudget(xstore *polardbxv1.XStore, members int32) *policyv1.PodDisruptionBudget {
+	minAvailable := intstr.FromInt(int(members - 1))
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      convention.NewPodDisruptionBudgetName(xstore),
+			Namespace: xstore.Namespace,
+			Labels:    convention.ConstLabels(xstore),
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MinAvailable: &minAvailable,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					xstoremeta.LabelName: xstore.Name,
+				},
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Key:      xstoremeta.LabelNodeRole,
+						Operator: metav1.LabelSelectorOpIn,
+						Values: []string{
+							strings.ToLower(string(polardbxv1xstore.RoleCandidate)),
+							strings.ToLower(string(polardbxv1xstore.RoleVoter)),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// ReconcilePodDisruptionBudget keeps the pod disruption budget of the voting members consistent
+// with the observed topology. Readonly xstores (learners only) and single node xstores aren't
+// protected. The budget is owned by the xstore and removed along with it.
+var ReconcilePodDisruptionBudget = xstorev1reconcile.NewStepBinder("ReconcilePodDisruptionBudget",
+	func(rc *xstorev1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		xstore := rc.MustGetXStore()
+		topology := xstore.Status.ObservedTopology
+		if topology == nil {
+			topology = &xstore.Spec.Topology
+		}
+
+		var pdb policyv1.PodDisruptionBudget
+		err := rc.Client().Get(rc.Context(), types.NamespacedName{
+			Namespace: rc.Namespace(),
+			Name:      convention.NewPodDisruptionBudgetName(xstore),
+		}, &pdb)
+		if client.IgnoreNotFound(err) != nil {
+			return flow.Error(err, "Unable to get pod disruption budget.")
+		}
+		exists := err == nil
+
+		members := countVotingMembers(topology)
+		if !rc.Config().Scheduler().ManagePodDisruptionBudget() || xstore.Spec.Readonly || members < 2 {
+			if !exists {
+				return flow.Pass()
+			}
+			if err := rc.Client().Delete(rc.Context(), &pdb); client.IgnoreNotFound(err) != nil {
+				return flow.Error(err, "Unable to delete pod disruption budget.")
+			}
+			return flow.Continue("Pod disruption budget removed.")
+		}
+
+		desired := newPodDisruptionBudget(xstore, members)
+		if !exists {
+			if err := rc.SetControllerRefAndCreate(desired); err != nil && !apierrors.IsAlreadyExists(err) {
+				return flow.Error(err, "Unable to create pod disruption budget.")
+			}
+			return flow.Continue("Pod disruption budget created.", "min-available", members-1)
+		}
+
+		if equality.Semantic.DeepEqual(pdb.Spec, desired.Spec) {
+			return flow.Pass()
+		}
+		pdb.Spec = desired.Spec
+		if err := rc.Client().Update(rc.Context(), &pdb); err != nil {
+			return flow.Error(err, "Unable to update pod disruption budget.")
+		}
+		return flow.Continue("Pod disruption budget updated.", "min-available", members-1)
+	})
@@ -0,0 +1,58 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+)
+
+func TestNewPodDisruptionBudget(t *testing.T) {
+	xstore := &polardbxv1.XStore{
+		ObjectMeta: metav1.ObjectMeta{Name: "dn-0", Namespace: "default"},
+		Spec: polardbxv1.XStoreSpec{
+			Topology: polardbxv1xstore.Topology{
+				NodeSets: []polardbxv1xstore.NodeSet{
+					{Name: "cand", Role: polardbxv1xstore.RoleCandidate, Replicas: 2},
+					{Name: "log", Role: polardbxv1xstore.RoleVoter, Replicas: 1},
+					{Name: "lrn", Role: polardbxv1xstore.RoleLearner, Replicas: 2},
+				},
+			},
+		},
+	}
+
+	members := countVotingMembers(&xstore.Spec.Topology)
+	if members != 3 {
+		t.Fatalf("expect 3 voting members, but got %d", members)
+	}
+
+	pdb := newPodDisruptionBudget(xstore, members)
+	if pdb.Spec.MinAvailable == nil || pdb.Spec.MinAvailable.IntValue() != 2 {
+		t.Fatalf("expect min available 2, but got %v", pdb.Spec.MinAvailable)
+	}
+	if len(pdb.Spec.Selector.MatchExpressions) != 1 {
+		t.Fatalf("expect node role selected, but got %v", pdb.Spec.Selector)
+	}
+	values := pdb.Spec.Selector.MatchExpressions[0].Values
+	if len(values) != 2 || values[0] != "candidate" || values[1] != "voter" {
+		t.Fatalf("expect candidates and voters selected, but got %v", values)
+	}
+}
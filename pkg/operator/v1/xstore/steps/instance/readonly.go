@@ -0,0 +1,201 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	xstorev1 "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
+	xstoreexec "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/command"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/convention"
+	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
+	commonsteps "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/plugin/common/steps"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+)
+
+// checkReadonlyReplication checks the learners, keyed by pod with the consensus addresses, against
+// the consensus membership listed on the primary leader. Learners are matched by address since they
+// are not known by the primary. It returns the status of learners, the max lag and the reason of the
+// first unhealthy one, empty if all are healthy.
+func checkReadonlyReplication(learnerAddrs map[string]string, rows []map[string]interface{}) ([]xstorev1.ConsensusMemberStatus, int64, string) {
+	rowOfAddr := make(map[string]map[string]interface{})
+	var leader map[string]interface{}
+	for _, row := range rows {
+		addr, _ := row["addr"].(string)
+		rowOfAddr[addr] = row
+		if role, _ := row["role"].(string); role == xstoremeta.RoleLeader {
+			leader = row
+		}
+	}
+	if leader == nil {
+		return nil, 0, "leader not found in consensus group of primary"
+	}
+
+	pods := make([]string, 0, len(learnerAddrs))
+	for pod := range learnerAddrs {
+		pods = append(pods, pod)
+	}
+	sort.Strings(pods)
+
+	members := make([]xstorev1.ConsensusMemberStatus, 0, len(pods))
+	var maxLag int64
+	reason := ""
+	for _, pod := range pods {
+		member := xstorev1.ConsensusMemberStatus{Pod: pod}
+		row, ok := rowOfAddr[learnerAddrs[pod]]
+		if ok {
+			member.Role, _ = row["role"].(string)
+			member.Lag = parseConsensusIndex(leader, "applied_index") - parseConsensusIndex(row, "applied_index")
+			if member.Lag < 0 {
+				member.Lag = 0
+			}
+		}
+		member.Healthy = member.Role == xstoremeta.RoleLearner
+		members = append(members, member)
+		if member.Lag > maxLag {
+			maxLag = member.Lag
+		}
+
+		if member.Healthy || len(reason) > 0 {
+			continue
+		}
+		if !ok {
+			reason = fmt.Sprintf("pod %s is not a learner of primary", pod)
+		} else {
+			reason = fmt.Sprintf("pod %s is in unexpected role %s", pod, member.Role)
+		}
+	}
+	return members, maxLag, reason
+}
+
+// staleLearnerAddrs returns the recorded learner addresses not used by any learner pod.
+func staleLearnerAddrs(recorded []string, learnerAddrs map[string]string) []string {
+	used := make(map[string]bool)
+	for _, addr := range learnerAddrs {
+		used[addr] = true
+	}
+	stale := make([]string, 0)
+	for _, addr := range recorded {
+		if !used[addr] {
+			stale = append(stale, addr)
+		}
+	}
+	return stale
+}
+
+func getLearnerAddrs(rc *xstorev1reconcile.Context) (map[string]string, error) {
+	pods, err := rc.GetXStorePods()
+	if err != nil {
+		return nil, err
+	}
+	learnerPods := k8shelper.FilterPodsBy(pods, xstoremeta.IsPodRoleLearner)
+	if len(learnerPods) == 0 {
+		return nil, nil
+	}
+
+	sharedCm, err := rc.GetXStoreConfigMap(convention.ConfigMapTypeShared)
+	if err != nil {
+		return nil, err
+	}
+	sharedChannel, err := commonsteps.ParseChannelFromConfigMap(sharedCm)
+	if err != nil {
+		return nil, err
+	}
+	addrOfPod := make(map[string]string)
+	for _, node := range sharedChannel.Nodes {
+		addrOfPod[node.Pod] = fmt.Sprintf("%s:%d", node.Host, node.Port)
+	}
+	learnerAddrs := make(map[string]string)
+	for _, pod := range learnerPods {
+		addr, ok := addrOfPod[pod.Name]
+		if !ok {
+			return nil, fmt.Errorf("failed to get node of pod %s in the shared channel", pod.Name)
+		}
+		learnerAddrs[pod.Name] = addr
+	}
+	return learnerAddrs, nil
+}
+
+// ReconcileReadonlyReplication drops the learners left on the primary by the rebuilt pods of readonly
+// xstore, and records the replication lag of the learners. It never blocks the running loop.
+var ReconcileReadonlyReplication = xstorev1reconcile.NewStepBinder("ReconcileReadonlyReplication",
+	func(rc *xstorev1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		xstore := rc.MustGetXStore()
+		if !xstore.Spec.Readonly {
+			return flow.Pass()
+		}
+		if xstore.Status.ReadonlyReplication == nil {
+			xstore.Status.ReadonlyReplication = &xstorev1.ReadonlyReplicationStatus{}
+		}
+		status := xstore.Status.ReadonlyReplication
+		now := metav1.Now()
+		status.UpdateTime = &now
+
+		learnerAddrs, err := getLearnerAddrs(rc)
+		if err != nil {
+			return flow.Error(err, "Unable to get addresses of learners.")
+		}
+		if len(learnerAddrs) == 0 {
+			return flow.Pass()
+		}
+
+		leaderPod, err := rc.TryGetXStoreLeaderPod()
+		if err != nil {
+			return flow.Error(err, "Unable to get leader pod of primary.")
+		}
+		if leaderPod == nil {
+			status.Message = "leader pod of primary not found"
+			return flow.Continue("Leader of primary not found, skip checking replication.")
+		}
+		status.PrimaryLeaderPod = leaderPod.Name
+
+		// Keep the stale ones failed to drop, and retry next time.
+		recorded := make([]string, 0, len(learnerAddrs))
+		for _, addr := range staleLearnerAddrs(status.LearnerAddrs, learnerAddrs) {
+			cmd := xstoreexec.NewCanonicalCommandBuilder().Consensus().DropLearner(addr).Build()
+			err := rc.ExecuteCommandOn(leaderPod, convention.ContainerEngine, cmd, control.ExecOptions{
+				Logger:  flow.Logger(),
+				Timeout: 2 * time.Second,
+			})
+			if err != nil {
+				flow.Logger().Error(err, "Unable to drop stale learner.", "addr", addr, "leader", leaderPod.Name)
+				recorded = append(recorded, addr)
+			}
+		}
+		for _, addr := range learnerAddrs {
+			recorded = append(recorded, addr)
+		}
+		sort.Strings(recorded)
+		status.LearnerAddrs = recorded
+
+		rows, err := listConsensusMembersOnLeader(rc, leaderPod)
+		if err != nil {
+			status.Message = "unable to list consensus members on primary leader: " + err.Error()
+			return flow.Continue("Unable to list consensus members on primary leader.", "leader", leaderPod.Name)
+		}
+		status.Members, status.MaxLag, status.Message = checkReadonlyReplication(learnerAddrs, rows)
+
+		return flow.Continue("Readonly replication checked.", "max-lag", status.MaxLag)
+	},
+)
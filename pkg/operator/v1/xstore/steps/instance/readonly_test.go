@@ -0,0 +1,80 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func learnerRow(addr, role, appliedIndex string) map[string]interface{} {
+	return map[string]interface{}{
+		"pod":           "",
+		"addr":          addr,
+		"role":          role,
+		"applied_index": appliedIndex,
+	}
+}
+
+func TestCheckReadonlyReplication(t *testing.T) {
+	learnerAddrs := map[string]string{
+		"ro-lear-0": "10.0.0.4:11306",
+		"ro-lear-1": "10.0.0.5:11306",
+	}
+	rows := []map[string]interface{}{
+		learnerRow("10.0.0.1:11306", "leader", "5000"),
+		learnerRow("10.0.0.2:11306", "follower", "5000"),
+		learnerRow("10.0.0.4:11306", "learner", "4800"),
+		learnerRow("10.0.0.5:11306", "learner", "4500"),
+	}
+
+	members, maxLag, reason := checkReadonlyReplication(learnerAddrs, rows)
+	if len(reason) > 0 {
+		t.Fatalf("expect healthy, got %s", reason)
+	}
+	if maxLag != 500 || len(members) != 2 || members[0].Pod != "ro-lear-0" || members[0].Lag != 200 {
+		t.Fatalf("unexpected members: %d, %+v", maxLag, members)
+	}
+
+	if _, _, reason := checkReadonlyReplication(learnerAddrs, rows[:3]); !strings.Contains(reason, "ro-lear-1 is not a learner") {
+		t.Fatalf("expect missing learner, got %s", reason)
+	}
+
+	rows[3] = learnerRow("10.0.0.5:11306", "follower", "5000")
+	if _, _, reason := checkReadonlyReplication(learnerAddrs, rows); !strings.Contains(reason, "unexpected role follower") {
+		t.Fatalf("expect unexpected role, got %s", reason)
+	}
+
+	if _, _, reason := checkReadonlyReplication(learnerAddrs, rows[1:]); !strings.Contains(reason, "leader not found") {
+		t.Fatalf("expect leader not found, got %s", reason)
+	}
+}
+
+func TestStaleLearnerAddrs(t *testing.T) {
+	learnerAddrs := map[string]string{
+		"ro-lear-0": "10.0.0.4:11306",
+		"ro-lear-1": "10.0.0.6:11306",
+	}
+	stale := staleLearnerAddrs([]string{"10.0.0.4:11306", "10.0.0.5:11306"}, learnerAddrs)
+	if !reflect.DeepEqual(stale, []string{"10.0.0.5:11306"}) {
+		t.Fatalf("unexpected stale learners: %v", stale)
+	}
+	if stale := staleLearnerAddrs(nil, learnerAddrs); len(stale) != 0 {
+		t.Fatalf("expect no stale learners, got %v", stale)
+	}
+}
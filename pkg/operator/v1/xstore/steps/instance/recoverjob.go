@@ -20,6 +20,7 @@ import (
 	xstorev1 "github.com/alibaba/polardbx-operator/api/v1"
 	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
 	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/command"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/factory"
 	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
 	"github.com/alibaba/polardbx-operator/pkg/util"
 	batchv1 "k8s.io/api/batch/v1"
@@ -79,3 +80,63 @@ func newRecoverDataJob(xstore *xstorev1.XStore, targetPod *corev1.Pod, secret st
 		},
 	}
 }
+
+func newApplyBinlogJob(xstore *xstorev1.XStore, targetPod *corev1.Pod, backupName string,
+	encryption, lastEncryption *xstorev1.BackupEncryption) *batchv1.Job {
+	podSpec := targetPod.Spec.DeepCopy()
+	podSpec.InitContainers = nil
+	podSpec.RestartPolicy = corev1.RestartPolicyNever
+	podSpec.HostNetwork = false
+
+	// Remove containers except engine
+	podSpec.Containers = []corev1.Container{
+		*k8shelper.GetContainerFromPodSpec(podSpec, "engine"),
+	}
+	podSpec.Containers[0].Name = "applybinlogjob"
+
+	jobName := util.StableName(xstore, applyBinlogJobSuffix)
+	podSpec.Containers[0].Command = command.NewCanonicalCommandBuilder().Restore().
+		ApplyBinlog("/restore/"+continuousRestoreJobKey, targetPod.Name, jobName).Build()
+	podSpec.Containers[0].Resources.Limits = nil
+	podSpec.Containers[0].Resources.Requests = nil
+	podSpec.Containers[0].Ports = nil
+
+	podSpec.Containers[0].LivenessProbe = nil
+	podSpec.Containers[0].ReadinessProbe = nil
+	podSpec.Containers[0].StartupProbe = nil
+
+	// Replace system envs.
+	replaceSystemEnvs(podSpec, targetPod)
+	podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, factory.SuperAccountPasswordEnv(xstore))
+	patchTaskConfigMapVolumeAndVolumeMounts(xstore, podSpec)
+	factory.PatchBackupEncryptionVolume(podSpec, "backup", encryption)
+	factory.PatchBackupEncryptionVolume(podSpec, "last-backup", lastEncryption)
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: xstore.Namespace,
+			Labels: map[string]string{
+				xstoremeta.LabelName:              xstore.Name,
+				xstoremeta.LabelRand:              xstore.Status.Rand,
+				xstoremeta.JobLabelTargetPod:      targetPod.Name,
+				xstoremeta.JobLabelTargetNodeName: targetPod.Spec.NodeName,
+			},
+			Annotations: map[string]string{
+				xstoremeta.AnnotationApplyBinlogBackup: backupName,
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: pointer.Int32(0),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						xstoremeta.LabelName: xstore.Name,
+						xstoremeta.LabelRand: xstore.Status.Rand,
+					},
+				},
+				Spec: *podSpec,
+			},
+		},
+	}
+}
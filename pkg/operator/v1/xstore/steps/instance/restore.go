@@ -17,6 +17,7 @@ limitations under the License.
 package instance
 
 import (
+	"bytes"
 	"fmt"
 	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
 	xstorev1 "github.com/alibaba/polardbx-operator/api/v1/xstore"
@@ -24,6 +25,7 @@ import (
 	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
 	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
 	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/convention"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/factory"
 	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
 	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/plugin/common/channel"
 	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
@@ -33,10 +35,13 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type RestoreJobContext struct {
+	BackupName          string                   `json:"backupName,omitempty"`
 	BackupFilePath      string                   `json:"backupFilePath,omitempty"`
 	BackupCommitIndex   *int64                   `json:"backupCommitIndex,omitempty"`
 	BinlogDirPath       string                   `json:"binlogDirPath,omitempty"`
@@ -45,6 +50,94 @@ type RestoreJobContext struct {
 	CpFilePath          string                   `json:"cpfilePath,omitempty"`
 	StorageName         polardbxv1.BackupStorage `json:"storageName,omitempty"`
 	Sink                string                   `json:"sink,omitempty"`
+	DownloadRateLimit   int64                    `json:"downloadRateLimit,omitempty"`
+	// IncrementalBackupFilePaths are the incremental backups applied onto the full backup in order
+	IncrementalBackupFilePaths []string `json:"incrementalBackupFilePaths,omitempty"`
+	// Encryption is the encryption of the backup, whose key is mounted to the restore jobs at EncryptionKeyFile
+	Encryption        *polardbxv1.BackupEncryption `json:"encryption,omitempty"`
+	EncryptionKeyFile string                       `json:"encryptionKeyFile,omitempty"`
+	// KeyringPath is the keyring uploaded along with the backup, which is restored before preparing
+	KeyringPath string `json:"keyringPath,omitempty"`
+	// Snapshot is true if the data is on the claims provisioned from the volume snapshot of the backup,
+	// so that nothing is downloaded and only the metadata is initialized by the restore jobs
+	Snapshot bool `json:"snapshot,omitempty"`
+}
+
+// encryptionKeyFileOf returns the key file which the encryption is mounted at as name, empty if not encrypted.
+func encryptionKeyFileOf(encryption *polardbxv1.BackupEncryption, name string) string {
+	if encryption == nil {
+		return ""
+	}
+	return factory.BackupEncryptionKeyFile(name)
+}
+
+func FullBackupFilePathOf(backup *polardbxv1.XStoreBackup, xstoreName string) string {
+	return fmt.Sprintf("%s/%s/%s.xbstream", backup.Status.BackupRootPath, polardbxmeta.FullBackupPath, xstoreName)
+}
+
+// incrementalRestoreFilePaths returns the full backup which the incremental backup is based on, and the
+// incremental ones to apply onto it in order, ending with the backup itself.
+func incrementalRestoreFilePaths(rc *xstorev1reconcile.Context, backup *polardbxv1.XStoreBackup, xstoreName string) (string, []string, error) {
+	chain := backup.Status.Incremental.Chain
+	if len(chain) == 0 {
+		return "", nil, fmt.Errorf("chain of incremental backup %s is empty", backup.Name)
+	}
+	paths := make([]string, 0, len(chain)+1)
+	for _, name := range chain {
+		b := &polardbxv1.XStoreBackup{}
+		if err := rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: rc.Namespace(), Name: name}, b); err != nil {
+			return "", nil, fmt.Errorf("unable to get backup %s in chain of %s: %w", name, backup.Name, err)
+		}
+		paths = append(paths, FullBackupFilePathOf(b, xstoreName))
+	}
+	paths = append(paths, FullBackupFilePathOf(backup, xstoreName))
+	return paths[0], paths[1:], nil
+}
+
+const restoreTempDir = "/data/mysql/restore"
+
+// updateRestoreDownloadProgress records the size of the backup set downloaded on the pod. The downloaded
+// file is kept across restarts of the restore pod, so the progress continues after the restart.
+func updateRestoreDownloadProgress(rc *xstorev1reconcile.Context, xstore *polardbxv1.XStore, pod *corev1.Pod) error {
+	restoreJobContext := &RestoreJobContext{}
+	if err := rc.GetTaskContext("restore", &restoreJobContext); err != nil {
+		return err
+	}
+	if len(restoreJobContext.BackupFilePath) == 0 {
+		return nil
+	}
+	backupFileName := restoreJobContext.BackupFilePath[strings.LastIndex(restoreJobContext.BackupFilePath, "/")+1:]
+
+	cmd := []string{"stat", "-c", "%s", restoreTempDir + "/" + backupFileName}
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	if err := rc.ExecuteCommandOn(pod, "engine", cmd, control.ExecOptions{
+		Stdout: stdout,
+		Stderr: stderr,
+	}); err != nil {
+		return fmt.Errorf("failed to stat downloaded backup file: %w, stderr: %s", err, stderr.String())
+	}
+	downloaded, err := strconv.ParseInt(strings.TrimSpace(stdout.String()), 10, 64)
+	if err != nil {
+		return err
+	}
+
+	if xstore.Status.RestoreDownload == nil {
+		xstore.Status.RestoreDownload = make(map[string]*xstorev1.RestoreDownloadStatus)
+	}
+	status, ok := xstore.Status.RestoreDownload[pod.Name]
+	if !ok {
+		status = &xstorev1.RestoreDownloadStatus{}
+		backup := &polardbxv1.XStoreBackup{}
+		err := rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: rc.Namespace(), Name: restoreJobContext.BackupName}, backup)
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		status.TotalBytes = backup.Status.BackupSize
+		xstore.Status.RestoreDownload[pod.Name] = status
+	}
+	status.DownloadedBytes = downloaded
+	return nil
 }
 
 var CheckXStoreRestoreSpec = xstorev1reconcile.NewStepBinder("CheckXStoreRestoreSpec",
@@ -69,6 +162,19 @@ var CheckXStoreRestoreSpec = xstorev1reconcile.NewStepBinder("CheckXStoreRestore
 				return flow.Error(err, "Unable to parse restore time!")
 			}
 		}
+		if restoreSpec.PointInTime && (len(restoreSpec.BackupSet) > 0 || restoreSpec.Continuous != nil) {
+			return flow.Wait("Restore spec invalid, point in time restore requires the restore time and isn't supported by continuous restore!")
+		}
+		if restoreSpec.Masking != nil && (len(restoreSpec.Masking.ConfigMap) == 0 || restoreSpec.Continuous != nil) {
+			return flow.Wait("Restore spec invalid, masking requires the configmap and isn't supported by continuous restore!")
+		}
+		names := make(map[string]bool)
+		for _, exec := range restoreSpec.PostRestoreExec {
+			if len(exec.Name) == 0 || len(exec.Command) == 0 || names[exec.Name] {
+				return flow.Wait("Restore spec invalid, post restore exec must have unique name and command!", "name", exec.Name)
+			}
+			names[exec.Name] = true
+		}
 		return flow.Pass()
 	})
 
@@ -96,9 +202,14 @@ var StartRestoreJob = xstorev1reconcile.NewStepBinder("StartRestoreJob",
 				return flow.Error(err, "Unable to get restore data job.", "pod", pod.Name)
 			}
 
+			// Wait until the job removed, e.g. restore falls back.
+			if job != nil && !job.DeletionTimestamp.IsZero() {
+				return flow.RetryAfter(5*time.Second, "Restore data job is being removed.", "pod", pod.Name)
+			}
+
 			// If not found, create one.
 			if job == nil {
-				job = newRestoreDataJob(xstore, &pod)
+				job = newRestoreDataJob(xstore, &pod, restoreJobContext.Encryption)
 				if err := rc.SetControllerRefAndCreate(job); err != nil {
 					return flow.Error(err, "Unable to create job to restore data", "pod", pod.Name)
 				}
@@ -127,14 +238,62 @@ var WaitUntilRestoreJobFinished = xstorev1reconcile.NewStepBinder("WaitUntilRest
 				return flow.Error(err, "Unable to get xstore restore data job", "pod", pod.Name)
 			}
 
+			if k8shelper.IsJobFailed(job) {
+				if xstore.Spec.Restore.Fallback && xstore.Status.RestoreFallback == nil {
+					fellBack, err := fallbackToPreviousBackup(rc, "Restore job "+job.Name+" failed on pod "+pod.Name)
+					if err != nil {
+						return flow.Error(err, "Unable to fall back to previous backup.")
+					}
+					if fellBack {
+						flow.Logger().Info("Restore falls back to previous backup!",
+							"original-backup", xstore.Status.RestoreFallback.OriginalBackup,
+							"fallback-backup", xstore.Status.RestoreFallback.FallbackBackup,
+							"rpo-impact", xstore.Status.RestoreFallback.RPOImpact)
+						return flow.RetryAfter(5*time.Second, "Restore falls back to previous backup, restart restore jobs.")
+					}
+				}
+				rc.UpdateXStoreCondition(&xstorev1.Condition{
+					Type:    xstorev1.Restorable,
+					Status:  corev1.ConditionFalse,
+					Reason:  "RestoreJobFailed",
+					Message: "Restore job " + job.Name + " failed on pod " + pod.Name,
+				})
+				xstore.Status.Phase = xstorev1.PhaseFailed
+				return flow.Wait("Restore job failed!", "job", job.Name, "pod", pod.Name)
+			}
+
 			if !k8shelper.IsJobCompleted(job) {
-				return flow.Wait("Job's not completed! Wait... ", "job", job.Name, "pod", pod.Name)
+				// The backup file may not be created yet, just log it.
+				if err := updateRestoreDownloadProgress(rc, xstore, &pod); err != nil {
+					flow.Logger().Info("Unable to update restore download progress.", "pod", pod.Name, "error", err.Error())
+				}
+				if err := updateRestoreReplayProgress(rc, xstore, &pod); err != nil {
+					flow.Logger().Info("Unable to update restore replay progress.", "pod", pod.Name, "error", err.Error())
+				}
+				return flow.RetryAfter(30*time.Second, "Job's not completed! Wait... ", "job", job.Name, "pod", pod.Name)
 			}
 		}
 
 		return flow.Continue("Restore Job completed!")
 	})
 
+// findRestoreBackup returns the backup set to restore, or the last completed backup before the restore
+// time if not specified. It returns nil if there's no such backup.
+func findRestoreBackup(rc *xstorev1reconcile.Context) (*polardbxv1.XStoreBackup, error) {
+	xstore := rc.MustGetXStore()
+	if len(xstore.Spec.Restore.BackupSet) == 0 {
+		return rc.GetLastCompletedXStoreBackup(map[string]string{
+			xstoremeta.LabelName: xstore.Spec.Restore.From.XStoreName,
+		}, rc.MustParseRestoreTime())
+	}
+	backup := &polardbxv1.XStoreBackup{}
+	xstoreBackupKey := types.NamespacedName{Namespace: rc.Namespace(), Name: xstore.Spec.Restore.BackupSet}
+	if err := rc.Client().Get(rc.Context(), xstoreBackupKey, backup); err != nil {
+		return nil, err
+	}
+	return backup, nil
+}
+
 var PrepareRestoreJobContext = xstorev1reconcile.NewStepBinder("PrepareRestoreJobContext",
 	func(rc *xstorev1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
 		const restoreJobKey = "restore"
@@ -152,78 +311,25 @@ var PrepareRestoreJobContext = xstorev1reconcile.NewStepBinder("PrepareRestoreJo
 		// Prepare context.
 		xstore := rc.MustGetXStore()
 
-		fromXStoreName := xstore.Spec.Restore.From.XStoreName
-		backup := &polardbxv1.XStoreBackup{}
-		if xstore.Spec.Restore.BackupSet == "" || len(xstore.Spec.Restore.BackupSet) == 0 {
-			// Parse restore time.
-			restoreTime := rc.MustParseRestoreTime()
-			// Get last backup
-			backup, err = rc.GetLastCompletedXStoreBackup(map[string]string{
-				xstoremeta.LabelName: fromXStoreName,
-			}, restoreTime)
-			if err != nil {
-				return flow.Error(err, "Unable to get last usable backup", "restore-time", restoreTime)
-			}
-			// Quit if last backup not found
-			if backup == nil {
-				// Update the phase to failed
-				rc.UpdateXStoreCondition(&xstorev1.Condition{
-					Type:    xstorev1.Restorable,
-					Status:  corev1.ConditionFalse,
-					Reason:  "BackupNotFound",
-					Message: "Last usable backup isn't found! Restore time is " + restoreTime.String(),
-				})
-				xstore.Status.Phase = xstorev1.PhaseFailed
-
-				return flow.Wait("Last usable backup isn't found!", "restore-time", restoreTime)
-			}
-		} else {
-			xstoreBackupKey := types.NamespacedName{Namespace: rc.Namespace(), Name: xstore.Spec.Restore.BackupSet}
-			err := rc.Client().Get(rc.Context(), xstoreBackupKey, backup)
-			if err != nil {
-				return flow.Error(err, "Unable to get xstoreBackup by BackupSet")
-			}
-		}
-		//Update sharedchannel
-		sharedCm, err := rc.GetXStoreConfigMap(convention.ConfigMapTypeShared)
-		if err != nil {
-			return flow.Error(err, "Unable to get shared config map.")
-		}
-
-		sharedChannel, err := parseChannelFromConfigMap(sharedCm)
+		backup, err := findRestoreBackup(rc)
 		if err != nil {
-			return flow.Error(err, "Unable to parse shared channel from config map.")
+			return flow.Error(err, "Unable to get the backup to restore")
 		}
-
-		sharedChannel.UpdateLastBackupBinlogIndex(&backup.Status.CommitIndex)
-		sharedCm.Data[channel.SharedChannelKey] = sharedChannel.String()
-		err = rc.Client().Update(rc.Context(), sharedCm)
-		if err != nil {
-			return flow.Error(err, "Unable to update shared config map.")
-		}
-
-		backupRootPath := backup.Status.BackupRootPath
-		fullBackupPath := fmt.Sprintf("%s/%s/%s.xbstream",
-			backupRootPath, polardbxmeta.FullBackupPath, fromXStoreName)
-		binlogEndOffsetPath := fmt.Sprintf("%s/%s/%s-end",
-			backupRootPath, polardbxmeta.BinlogOffsetPath, fromXStoreName)
-		indexesPath := fmt.Sprintf("%s/%s", backupRootPath, polardbxmeta.BinlogIndexesName)
-		binlogBackupDir := fmt.Sprintf("%s/%s/%s",
-			backupRootPath, polardbxmeta.BinlogBackupPath, fromXStoreName)
-		cpFilePath := fmt.Sprintf("%s/%s/%s",
-			backupRootPath, polardbxmeta.BinlogOffsetPath, polardbxmeta.SeekCpName)
-
-		// Save.
-		if err := rc.SaveTaskContext(restoreJobKey, &RestoreJobContext{
-			BackupFilePath:      fullBackupPath,
-			BackupCommitIndex:   &backup.Status.CommitIndex,
-			BinlogDirPath:       binlogBackupDir,
-			BinlogEndOffsetPath: binlogEndOffsetPath,
-			IndexesPath:         indexesPath,
-			CpFilePath:          cpFilePath,
-			StorageName:         backup.Spec.StorageProvider.StorageName,
-			Sink:                backup.Spec.StorageProvider.Sink,
-		}); err != nil {
+		// Quit if last backup not found
+		if backup == nil {
+			restoreTime := rc.MustParseRestoreTime()
+			// Update the phase to failed
+			rc.UpdateXStoreCondition(&xstorev1.Condition{
+				Type:    xstorev1.Restorable,
+				Status:  corev1.ConditionFalse,
+				Reason:  "BackupNotFound",
+				Message: "Last usable backup isn't found! Restore time is " + restoreTime.String(),
+			})
+			xstore.Status.Phase = xstorev1.PhaseFailed
+
+			return flow.Wait("Last usable backup isn't found!", "restore-time", restoreTime)
+		}
+		if err := saveRestoreJobContext(rc, backup); err != nil {
 			return flow.Error(err, "Unable to save job context for restore!")
 		}
 		return flow.Continue("Job context for restore prepared!")
@@ -254,6 +360,159 @@ var RemoveRestoreJob = xstorev1reconcile.NewStepBinder("RemoveRestoreJob",
 		return flow.Continue("Restore job removed!")
 	})
 
+// fallbackToPreviousBackup replaces the backup in restore job context with the previous finished one,
+// and removes the restore jobs so that they're recreated. It returns false if there's no such backup.
+func fallbackToPreviousBackup(rc *xstorev1reconcile.Context, reason string) (bool, error) {
+	xstore := rc.MustGetXStore()
+
+	restoreJobContext := &RestoreJobContext{}
+	if err := rc.GetTaskContext("restore", &restoreJobContext); err != nil {
+		return false, err
+	}
+	if len(restoreJobContext.BackupName) == 0 {
+		return false, nil
+	}
+	original := &polardbxv1.XStoreBackup{}
+	err := rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: rc.Namespace(), Name: restoreJobContext.BackupName}, original)
+	if err != nil {
+		return false, err
+	}
+	if original.Status.StartTime == nil {
+		return false, nil
+	}
+
+	backup, err := rc.GetLastCompletedXStoreBackup(map[string]string{
+		xstoremeta.LabelName: xstore.Spec.Restore.From.XStoreName,
+	}, original.Status.StartTime.Time)
+	if err != nil || backup == nil {
+		return false, err
+	}
+
+	// Remove the failed jobs.
+	pods, err := rc.GetXStorePods()
+	if err != nil {
+		return false, err
+	}
+	for _, pod := range pods {
+		job, err := rc.GetXStoreJob(util.GetStableNameSuffix(xstore, pod.Name) + "-restore")
+		if client.IgnoreNotFound(err) != nil {
+			return false, err
+		}
+		if job == nil {
+			continue
+		}
+		err = rc.Client().Delete(rc.Context(), job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+		if client.IgnoreNotFound(err) != nil {
+			return false, err
+		}
+	}
+
+	if err := saveRestoreJobContext(rc, backup); err != nil {
+		return false, err
+	}
+
+	fallback := &xstorev1.RestoreFallbackStatus{
+		OriginalBackup: original.Name,
+		FallbackBackup: backup.Name,
+		Reason:         reason,
+	}
+	if original.Status.BackupSetTimestamp != nil && backup.Status.BackupSetTimestamp != nil {
+		fallback.RPOImpact = original.Status.BackupSetTimestamp.Sub(backup.Status.BackupSetTimestamp.Time).String()
+	}
+	xstore.Status.RestoreFallback = fallback
+	rc.UpdateXStoreCondition(&xstorev1.Condition{
+		Type:    xstorev1.Restorable,
+		Status:  corev1.ConditionTrue,
+		Reason:  "FallbackToPreviousBackup",
+		Message: "Restore falls back from backup " + original.Name + " to " + backup.Name + ", " + reason,
+	})
+	return true, nil
+}
+
+func saveRestoreJobContext(rc *xstorev1reconcile.Context, backup *polardbxv1.XStoreBackup) error {
+	xstore := rc.MustGetXStore()
+	fromXStoreName := xstore.Spec.Restore.From.XStoreName
+
+	//Update sharedchannel
+	sharedCm, err := rc.GetXStoreConfigMap(convention.ConfigMapTypeShared)
+	if err != nil {
+		return err
+	}
+
+	sharedChannel, err := parseChannelFromConfigMap(sharedCm)
+	if err != nil {
+		return err
+	}
+
+	sharedChannel.UpdateLastBackupBinlogIndex(&backup.Status.CommitIndex)
+	sharedCm.Data[channel.SharedChannelKey] = sharedChannel.String()
+	err = rc.Client().Update(rc.Context(), sharedCm)
+	if err != nil {
+		return err
+	}
+
+	// Nothing to download for volume snapshots, the snapshot name is used to tell the restore.
+	if backup.Spec.BackupEngine == polardbxv1.BackupEngineVolumeSnapshot && backup.Status.VolumeSnapshot != nil {
+		return rc.SaveTaskContext("restore", &RestoreJobContext{
+			BackupName:        backup.Name,
+			BackupFilePath:    backup.Status.VolumeSnapshot.Name,
+			BackupCommitIndex: &backup.Status.CommitIndex,
+			StorageName:       backup.Spec.StorageProvider.StorageName,
+			Sink:              backup.Spec.StorageProvider.Sink,
+			Snapshot:          true,
+		})
+	}
+
+	backupRootPath := backup.Status.BackupRootPath
+	fullBackupPath := FullBackupFilePathOf(backup, fromXStoreName)
+	var incrementalBackupPaths []string
+	if backup.Status.Incremental != nil {
+		fullBackupPath, incrementalBackupPaths, err = incrementalRestoreFilePaths(rc, backup, fromXStoreName)
+		if err != nil {
+			return err
+		}
+	}
+	binlogEndOffsetPath := fmt.Sprintf("%s/%s/%s-end",
+		backupRootPath, polardbxmeta.BinlogOffsetPath, fromXStoreName)
+	indexesPath := fmt.Sprintf("%s/%s", backupRootPath, polardbxmeta.BinlogIndexesName)
+	binlogBackupDir := fmt.Sprintf("%s/%s/%s",
+		backupRootPath, polardbxmeta.BinlogBackupPath, fromXStoreName)
+	cpFilePath := fmt.Sprintf("%s/%s/%s",
+		backupRootPath, polardbxmeta.BinlogOffsetPath, polardbxmeta.SeekCpName)
+
+	// Binlogs after the backup set are applied continuously if required.
+	if xstore.Spec.Restore.Continuous != nil {
+		xstore.Status.ContinuousRestore = &xstorev1.ContinuousRestoreStatus{
+			LastAppliedBackup:    backup.Name,
+			LastAppliedTimestamp: backup.Status.BackupSetTimestamp,
+		}
+	}
+
+	var downloadRateLimit int64
+	if xstore.Spec.Restore.Download != nil {
+		downloadRateLimit = xstore.Spec.Restore.Download.RateLimit
+	}
+
+	// Save.
+	return rc.SaveTaskContext("restore", &RestoreJobContext{
+		BackupName:          backup.Name,
+		BackupFilePath:      fullBackupPath,
+		BackupCommitIndex:   &backup.Status.CommitIndex,
+		BinlogDirPath:       binlogBackupDir,
+		BinlogEndOffsetPath: binlogEndOffsetPath,
+		IndexesPath:         indexesPath,
+		CpFilePath:          cpFilePath,
+		StorageName:         backup.Spec.StorageProvider.StorageName,
+		Sink:                backup.Spec.StorageProvider.Sink,
+		DownloadRateLimit:   downloadRateLimit,
+
+		IncrementalBackupFilePaths: incrementalBackupPaths,
+		Encryption:                 backup.Spec.Encryption,
+		EncryptionKeyFile:          encryptionKeyFileOf(backup.Spec.Encryption, "backup"),
+		KeyringPath:                backup.Status.KeyringPath,
+	})
+}
+
 func parseChannelFromConfigMap(cm *corev1.ConfigMap) (*channel.SharedChannel, error) {
 	sharedChannel := &channel.SharedChannel{}
 	err := sharedChannel.Load(cm.Data[channel.SharedChannelKey])
@@ -263,6 +522,15 @@ func parseChannelFromConfigMap(cm *corev1.ConfigMap) (*channel.SharedChannel, er
 	return sharedChannel, nil
 }
 
+// isRestoredFromVolumeSnapshot returns true if the restore job context is of a volume snapshot.
+func isRestoredFromVolumeSnapshot(rc *xstorev1reconcile.Context) (bool, error) {
+	restoreJobContext := &RestoreJobContext{}
+	if err := rc.GetTaskContext("restore", &restoreJobContext); err != nil {
+		return false, err
+	}
+	return restoreJobContext.Snapshot, nil
+}
+
 var StartRecoverJob = xstorev1reconcile.NewStepBinder("StartRecoverJob",
 	func(rc *xstorev1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
 		xstore := rc.MustGetXStore()
@@ -276,6 +544,9 @@ var StartRecoverJob = xstorev1reconcile.NewStepBinder("StartRecoverJob",
 		if err != nil {
 			return flow.Error(err, "Unable to get task context for restore")
 		}
+		if restoreJobContext.Snapshot {
+			return flow.Continue("Volume snapshot doesn't need recover data", "xstore-name", xstore.Name)
+		}
 
 		// Create restore job for leader pod.
 		leaderPod, err := rc.TryGetXStoreLeaderPod()
@@ -318,6 +589,11 @@ var WaitUntilRecoverJobFinished = xstorev1reconcile.NewStepBinder("WaitUntilReco
 		if xstore.Labels[polardbxmeta.LabelRole] == polardbxmeta.RoleGMS {
 			return flow.Continue("GMS don not need recover data", "xstore-name", xstore.Name)
 		}
+		if snapshot, err := isRestoredFromVolumeSnapshot(rc); err != nil {
+			return flow.Error(err, "Unable to get task context for restore")
+		} else if snapshot {
+			return flow.Continue("Volume snapshot doesn't need recover data", "xstore-name", xstore.Name)
+		}
 		leaderPod, err := rc.TryGetXStoreLeaderPod()
 		if err != nil {
 			return flow.Error(err, "Unable to get leaderPod for xcluster.")
@@ -344,6 +620,11 @@ var RemoveRecoverJob = xstorev1reconcile.NewStepBinder("RemoveRecoverJob",
 		if xstore.Labels[polardbxmeta.LabelRole] == polardbxmeta.RoleGMS {
 			return flow.Continue("GMS don not need remove recover job", "xstore-name", xstore.Name)
 		}
+		if snapshot, err := isRestoredFromVolumeSnapshot(rc); err != nil {
+			return flow.Error(err, "Unable to get task context for restore")
+		} else if snapshot {
+			return flow.Continue("Volume snapshot doesn't need recover job", "xstore-name", xstore.Name)
+		}
 		leaderPod, err := rc.TryGetXStoreLeaderPod()
 		if err != nil {
 			return flow.Error(err, "Unable to get leaderPod for xcluster.")
@@ -0,0 +1,157 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	xstorev1 "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
+	polardbxhelper "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/command"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+)
+
+// readTargetEngineCompatibility reads the engine facts of the target from the engine binary and config
+// on the pod, nil if the tools of the pod are of older versions.
+func readTargetEngineCompatibility(rc *xstorev1reconcile.Context, flow control.Flow, pod *corev1.Pod) (*xstorev1.EngineCompatibility, error) {
+	cmd := command.NewCanonicalCommandBuilder().Engine().Compatibility().Build()
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	if err := rc.ExecuteCommandOn(pod, "engine", cmd, control.ExecOptions{
+		Logger:  flow.Logger(),
+		Stdout:  stdout,
+		Stderr:  stderr,
+		Timeout: 1 * time.Minute,
+	}); err != nil {
+		if k8shelper.IsExitError(err) {
+			flow.Logger().Error(err, "Unable to read engine compatibility, skip.", "pod", pod.Name, "stderr", stderr.String())
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read engine compatibility: %w, stderr: %s", err, stderr.String())
+	}
+	compatibility := &xstorev1.EngineCompatibility{}
+	if err := json.Unmarshal(stdout.Bytes(), compatibility); err != nil {
+		return nil, fmt.Errorf("invalid engine compatibility %q: %w", stdout.String(), err)
+	}
+	return compatibility, nil
+}
+
+func incompatibleChecksOf(checks []xstorev1.CompatibilityCheck) string {
+	messages := make([]string, 0)
+	for _, c := range checks {
+		if !c.Compatible {
+			messages = append(messages, fmt.Sprintf("%s (%s): %s", c.Name, c.Severity, c.Message))
+		}
+	}
+	return strings.Join(messages, "; ")
+}
+
+// CheckRestoreCompatibility checks the engine facts recorded in the backup to restore against the
+// target before the restore jobs start. The restore fails on the incompatibilities blocking it under
+// the strictness, and the others are warned by condition. The report is recorded in status.
+var CheckRestoreCompatibility = xstorev1reconcile.NewStepBinder("CheckRestoreCompatibility",
+	func(rc *xstorev1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		xstore := rc.MustGetXStore()
+
+		restoreJobContext := &RestoreJobContext{}
+		if err := rc.GetTaskContext("restore", &restoreJobContext); err != nil {
+			return flow.Error(err, "Unable to get restore job context.")
+		}
+		// The backup may be replaced when restore falls back.
+		report := xstore.Status.RestoreCompatibility
+		if report != nil && report.Backup == restoreJobContext.BackupName {
+			return flow.Pass()
+		}
+
+		backup := &polardbxv1.XStoreBackup{}
+		err := rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: rc.Namespace(), Name: restoreJobContext.BackupName}, backup)
+		if err != nil {
+			return flow.Error(err, "Unable to get restored xstore backup.", "backup", restoreJobContext.BackupName)
+		}
+		pods, err := rc.GetXStorePods()
+		if err != nil {
+			return flow.Error(err, "Unable to get pods for xcluster.")
+		}
+		if len(pods) == 0 {
+			return flow.RetryAfter(5*time.Second, "No pod found to check restore compatibility.")
+		}
+		target, err := readTargetEngineCompatibility(rc, flow, &pods[0])
+		if err != nil {
+			return flow.Error(err, "Unable to read engine compatibility of target.", "pod", pods[0].Name)
+		}
+
+		source := backup.Status.Compatibility.DeepCopy()
+		if source == nil {
+			source = &xstorev1.EngineCompatibility{}
+		}
+		if len(source.EngineVersion) == 0 {
+			source.EngineVersion = backup.Status.EngineVersion
+		}
+		strictness := xstore.Spec.Restore.CompatibilityStrictness
+		if len(strictness) == 0 {
+			strictness = xstorev1.CompatibilityDefault
+		}
+		checks, blocked := polardbxhelper.CheckRestoreCompatibility(source, target, strictness)
+		now := metav1.Now()
+		xstore.Status.RestoreCompatibility = &xstorev1.RestoreCompatibilityReport{
+			Backup:     backup.Name,
+			Strictness: strictness,
+			Blocked:    blocked,
+			Checks:     checks,
+			Target:     target,
+			CheckTime:  &now,
+		}
+
+		incompatible := incompatibleChecksOf(checks)
+		if blocked {
+			rc.UpdateXStoreCondition(&xstorev1.Condition{
+				Type:    xstorev1.Restorable,
+				Status:  corev1.ConditionFalse,
+				Reason:  "IncompatibleBackup",
+				Message: "Backup " + backup.Name + " is incompatible with the target: " + incompatible,
+			})
+			xstore.Status.Phase = xstorev1.PhaseFailed
+			return flow.Wait("Backup is incompatible with the target!", "backup", backup.Name, "incompatible", incompatible)
+		}
+		if len(incompatible) > 0 {
+			rc.UpdateXStoreCondition(&xstorev1.Condition{
+				Type:    xstorev1.RestoreCompatibilityWarning,
+				Status:  corev1.ConditionTrue,
+				Reason:  "SoftIncompatibilities",
+				Message: incompatible,
+			})
+		} else {
+			rc.UpdateXStoreCondition(&xstorev1.Condition{
+				Type:   xstorev1.RestoreCompatibilityWarning,
+				Status: corev1.ConditionFalse,
+				Reason: "Compatible",
+			})
+		}
+		return flow.Continue("Restore compatibility checked.", "backup", backup.Name, "incompatible", incompatible)
+	})
@@ -0,0 +1,156 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	xstorev1 "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	xstorecommonfactory "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/factory"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+	iniutil "github.com/alibaba/polardbx-operator/pkg/util/ini"
+)
+
+// Parameters fixed by the data files, they can't be changed over a physical backup.
+var restoreImmutableParams = map[string]bool{
+	"innodb_page_size":       true,
+	"innodb_data_file_path":  true,
+	"lower_case_table_names": true,
+}
+
+// Parameters removed in 8.0.
+var restoreRemovedParams80 = map[string]bool{
+	"query_cache_size":    true,
+	"query_cache_type":    true,
+	"query_cache_limit":   true,
+	"innodb_file_format":  true,
+	"innodb_large_prefix": true,
+	"innodb_support_xa":   true,
+	"tx_isolation":        true,
+	"tx_read_only":        true,
+}
+
+// Parameters introduced in 8.0.
+var restoreAddedParams80 = map[string]bool{
+	"innodb_dedicated_server":   true,
+	"innodb_redo_log_capacity":  true,
+	"innodb_log_writer_threads": true,
+}
+
+var engineVersionPattern = regexp.MustCompile(`^(\d+)\.(\d+)`)
+
+// parseEngineMajorVersion parses the major version, e.g. 80 of "8.0.18-X-Cluster", false if unknown.
+func parseEngineMajorVersion(version string) (int, bool) {
+	m := engineVersionPattern.FindStringSubmatch(strings.TrimSpace(version))
+	if m == nil {
+		return 0, false
+	}
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[2])
+	return major*10 + minor, true
+}
+
+// validateRestoreConfigOverlay checks the parameters of the overlay against the engine version
+// recorded in the backup. The version check is skipped if the version is unknown, and for the
+// parameters with the "loose_" prefix, which are ignored by the engine if not supported. Dashes in
+// the names are the same as underscores to the engine, e.g. innodb-page-size.
+func validateRestoreConfigOverlay(overlay string, engineVersion string) error {
+	f, err := iniutil.ParseMyCnfOverlayFile(strings.NewReader(overlay))
+	if err != nil {
+		return fmt.Errorf("invalid config overlay: %w", err)
+	}
+	version, versionKnown := parseEngineMajorVersion(engineVersion)
+
+	invalid := make([]string, 0)
+	for _, key := range f.Section("mysqld").Keys() {
+		name := strings.ReplaceAll(strings.TrimSuffix(key.Name(), "-"), "-", "_")
+		if restoreImmutableParams[strings.TrimPrefix(name, "loose_")] {
+			invalid = append(invalid, name+" (fixed by data files)")
+			continue
+		}
+		if !versionKnown || strings.HasPrefix(name, "loose_") {
+			continue
+		}
+		if version >= 80 && restoreRemovedParams80[name] {
+			invalid = append(invalid, name+" (removed in 8.0)")
+		} else if version < 80 && restoreAddedParams80[name] {
+			invalid = append(invalid, name+" (requires 8.0)")
+		}
+	}
+	if len(invalid) > 0 {
+		sort.Strings(invalid)
+		return fmt.Errorf("parameters not applicable to engine version %q: %s", engineVersion,
+			strings.Join(invalid, ", "))
+	}
+	return nil
+}
+
+// ApplyRestoreConfigOverlay validates the restore config overlay and merges it into the engine config
+// before the restored nodes start, so that they don't have to be reconfigured after the restore.
+var ApplyRestoreConfigOverlay = xstorev1reconcile.NewStepBinder("ApplyRestoreConfigOverlay",
+	func(rc *xstorev1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		xstore := rc.MustGetXStore()
+		overlay := xstore.Spec.RestoreConfigOverlay
+		if len(overlay) == 0 || xstore.Status.RestoreConfigOverlay == overlay {
+			return flow.Pass()
+		}
+
+		restoreJobContext := &RestoreJobContext{}
+		if err := rc.GetTaskContext("restore", &restoreJobContext); err != nil {
+			return flow.Error(err, "Unable to get restore job context.")
+		}
+		backup := &polardbxv1.XStoreBackup{}
+		err := rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: rc.Namespace(), Name: restoreJobContext.BackupName}, backup)
+		if err != nil {
+			return flow.Error(err, "Unable to get restored xstore backup.", "backup", restoreJobContext.BackupName)
+		}
+		if err := validateRestoreConfigOverlay(overlay, backup.Status.EngineVersion); err != nil {
+			rc.UpdateXStoreCondition(&xstorev1.Condition{
+				Type:    xstorev1.Restorable,
+				Status:  corev1.ConditionFalse,
+				Reason:  "InvalidRestoreConfigOverlay",
+				Message: err.Error(),
+			})
+			return flow.Wait("Restore config overlay invalid, please fix it.", "error", err.Error())
+		}
+
+		// Record the overlay only after the configmap is updated, so that it's retried on failure.
+		applied := xstore.Status.RestoreConfigOverlay
+		xstore.Status.RestoreConfigOverlay = overlay
+		configMap, err := xstorecommonfactory.NewConfigConfigMap(rc, xstore)
+		if err == nil {
+			err = rc.SetControllerRef(configMap)
+		}
+		if err == nil {
+			err = rc.Client().Update(rc.Context(), configMap)
+		}
+		if err != nil {
+			xstore.Status.RestoreConfigOverlay = applied
+			return flow.Error(err, "Unable to update config configmap.")
+		}
+		return flow.Continue("Restore config overlay applied.", "backup", backup.Name)
+	})
@@ -0,0 +1,48 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import "testing"
+
+func TestValidateRestoreConfigOverlay(t *testing.T) {
+	testcases := map[string]struct {
+		overlay string
+		version string
+		err     bool
+	}{
+		"valid":                {overlay: "innodb_buffer_pool_size=8G\ninnodb_io_capacity=4000", version: "8.0.18-X-Cluster-8.2.0"},
+		"dash-linked":          {overlay: "[mysqld]\ninnodb-read-io-threads=8", version: "5.7.14-AliSQL-X-Cluster-1.6.1.1"},
+		"immutable":            {overlay: "innodb_page_size=32768", version: "8.0.18-X-Cluster-8.2.0", err: true},
+		"immutable-loose":      {overlay: "loose_lower_case_table_names=0", err: true},
+		"immutable-dashed":     {overlay: "innodb-page-size=32768", version: "8.0.18-X-Cluster-8.2.0", err: true},
+		"immutable-loose-dash": {overlay: "loose-lower-case-table-names=0", err: true},
+		"removed-in-80-dashed": {overlay: "query-cache-size=0", version: "8.0.18-X-Cluster-8.2.0", err: true},
+		"removed-in-80":        {overlay: "query_cache_size=0", version: "8.0.18-X-Cluster-8.2.0", err: true},
+		"requires-80":          {overlay: "innodb_dedicated_server=ON", version: "5.7.14-AliSQL-X-Cluster-1.6.1.1", err: true},
+		"loose-skips-version":  {overlay: "loose_innodb_dedicated_server=ON", version: "5.7.14-AliSQL-X-Cluster-1.6.1.1"},
+		"unknown-version":      {overlay: "query_cache_size=0"},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			err := validateRestoreConfigOverlay(tc.overlay, tc.version)
+			if tc.err != (err != nil) {
+				t.Fatalf("expect error %v, got %v", tc.err, err)
+			}
+		})
+	}
+}
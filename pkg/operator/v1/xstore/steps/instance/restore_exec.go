@@ -0,0 +1,112 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"bytes"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	xstorev1 "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/convention"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+)
+
+const (
+	defaultPostRestoreExecTimeout = 30 * time.Second
+
+	// Only the tail of output is kept, which is where the error usually is.
+	maxPostRestoreExecOutputLength = 1024
+)
+
+func tailOfOutput(output string) string {
+	if len(output) > maxPostRestoreExecOutputLength {
+		return "..." + output[len(output)-maxPostRestoreExecOutputLength:]
+	}
+	return output
+}
+
+// succeededPostRestoreExecs returns the count of leading succeeded post restore commands.
+func succeededPostRestoreExecs(results []xstorev1.PostRestoreExecStatus) int {
+	for i, r := range results {
+		if !r.Succeeded {
+			return i
+		}
+	}
+	return len(results)
+}
+
+// RunPostRestoreExec runs the post restore commands on the restored leader one by one, and fails
+// the restore if any of them fails. The succeeded ones are not run again.
+var RunPostRestoreExec = xstorev1reconcile.NewStepBinder("RunPostRestoreExec",
+	func(rc *xstorev1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		xstore := rc.MustGetXStore()
+		execs := xstore.Spec.Restore.PostRestoreExec
+		succeeded := succeededPostRestoreExecs(xstore.Status.PostRestoreExec)
+		if succeeded >= len(execs) {
+			return flow.Pass()
+		}
+
+		leaderPod, err := rc.TryGetXStoreLeaderPod()
+		if err != nil {
+			return flow.Error(err, "Unable to get leader pod.")
+		}
+		if leaderPod == nil {
+			return flow.RetryAfter(5*time.Second, "Leader pod not found, wait.")
+		}
+
+		// Failed ones are run again, e.g. the restore is retried.
+		xstore.Status.PostRestoreExec = xstore.Status.PostRestoreExec[:succeeded]
+		for _, exec := range execs[succeeded:] {
+			timeout := exec.Timeout.Duration
+			if timeout <= 0 {
+				timeout = defaultPostRestoreExecTimeout
+			}
+			output := &bytes.Buffer{}
+			err := rc.ExecuteCommandOn(leaderPod, convention.ContainerEngine, exec.Command, control.ExecOptions{
+				Logger:  flow.Logger(),
+				Stdout:  output,
+				Stderr:  output,
+				Timeout: timeout,
+			})
+			now := metav1.Now()
+			result := xstorev1.PostRestoreExecStatus{
+				Name:       exec.Name,
+				Succeeded:  err == nil,
+				Output:     tailOfOutput(output.String()),
+				FinishTime: &now,
+			}
+			xstore.Status.PostRestoreExec = append(xstore.Status.PostRestoreExec, result)
+			if err != nil {
+				rc.UpdateXStoreCondition(&xstorev1.Condition{
+					Type:    xstorev1.Restorable,
+					Status:  corev1.ConditionFalse,
+					Reason:  "PostRestoreExecFailed",
+					Message: "Post restore exec " + exec.Name + " failed: " + err.Error() + ", output: " + result.Output,
+				})
+				xstore.Status.Phase = xstorev1.PhaseFailed
+				return flow.Wait("Post restore exec failed!", "name", exec.Name, "pod", leaderPod.Name)
+			}
+			flow.Logger().Info("Post restore exec succeeded.", "name", exec.Name)
+		}
+
+		return flow.Continue("Post restore exec all succeeded.")
+	})
@@ -0,0 +1,42 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"strings"
+	"testing"
+
+	xstorev1 "github.com/alibaba/polardbx-operator/api/v1/xstore"
+)
+
+func TestSucceededPostRestoreExecs(t *testing.T) {
+	results := []xstorev1.PostRestoreExecStatus{
+		{Name: "connect", Succeeded: true},
+		{Name: "canary", Succeeded: false},
+	}
+	if n := succeededPostRestoreExecs(results); n != 1 {
+		t.Fatalf("expect 1, got %d", n)
+	}
+	if n := succeededPostRestoreExecs(results[:1]); n != 1 {
+		t.Fatalf("expect 1, got %d", n)
+	}
+
+	output := tailOfOutput(strings.Repeat("x", maxPostRestoreExecOutputLength) + "ERROR 1146")
+	if !strings.HasSuffix(output, "ERROR 1146") || len(output) != maxPostRestoreExecOutputLength+3 {
+		t.Fatalf("unexpected tail: %d", len(output))
+	}
+}
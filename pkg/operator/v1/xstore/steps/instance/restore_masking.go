@@ -0,0 +1,196 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	xstorev1 "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/convention"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+)
+
+const restoreMaskingRulesKey = "rules"
+
+const (
+	maskingMethodHash    = "hash"
+	maskingMethodRedact  = "redact"
+	maskingMethodShuffle = "shuffle"
+)
+
+type maskingRule struct {
+	Table  string `json:"table"`
+	Column string `json:"column"`
+	Method string `json:"method"`
+	Key    string `json:"key,omitempty"`
+}
+
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func quoteTable(table string) (string, error) {
+	parts := strings.Split(table, ".")
+	if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+		return "", fmt.Errorf("table must be in form of db.table: %q", table)
+	}
+	return quoteIdentifier(parts[0]) + "." + quoteIdentifier(parts[1]), nil
+}
+
+// maskingStatementsOf returns the statements applying the rule.
+func maskingStatementsOf(rule maskingRule) ([]string, error) {
+	if len(rule.Column) == 0 {
+		return nil, fmt.Errorf("column not specified, table: %s", rule.Table)
+	}
+	table, err := quoteTable(rule.Table)
+	if err != nil {
+		return nil, err
+	}
+	column := quoteIdentifier(rule.Column)
+
+	switch rule.Method {
+	case maskingMethodHash:
+		return []string{fmt.Sprintf("UPDATE %s SET %s = LEFT(SHA2(%s, 256), CHAR_LENGTH(%s)) WHERE %s IS NOT NULL",
+			table, column, column, column, column)}, nil
+	case maskingMethodRedact:
+		return []string{fmt.Sprintf("UPDATE %s SET %s = REPEAT('*', CHAR_LENGTH(%s)) WHERE %s IS NOT NULL",
+			table, column, column, column)}, nil
+	case maskingMethodShuffle:
+		if len(rule.Key) == 0 {
+			return nil, fmt.Errorf("key not specified for shuffle, table: %s", rule.Table)
+		}
+		key := quoteIdentifier(rule.Key)
+		// Rows ordered by key are paired with the values in random order. Derived tables are
+		// materialized, so the updated table is readable in them.
+		return []string{
+			"SET @masking_row = 0, @masking_value = 0",
+			fmt.Sprintf("UPDATE %[1]s AS t JOIN ("+
+				"SELECT k.id, v.val FROM "+
+				"(SELECT %[2]s AS id, @masking_row := @masking_row + 1 AS rn FROM (SELECT %[2]s FROM %[1]s ORDER BY %[2]s) o) k JOIN "+
+				"(SELECT val, @masking_value := @masking_value + 1 AS rn FROM (SELECT %[3]s AS val FROM %[1]s ORDER BY RAND()) r) v "+
+				"ON k.rn = v.rn) m ON t.%[2]s = m.id SET t.%[3]s = m.val", table, key, column),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown masking method %q, table: %s", rule.Method, rule.Table)
+	}
+}
+
+// parseMaskingRules parses and validates the rules, all of them must be valid.
+func parseMaskingRules(data string) ([]maskingRule, error) {
+	var rules []maskingRule
+	if err := yaml.Unmarshal([]byte(data), &rules); err != nil {
+		return nil, fmt.Errorf("invalid masking rules: %w", err)
+	}
+	if len(rules) == 0 {
+		return nil, errors.New("no masking rules found")
+	}
+	for i, rule := range rules {
+		if _, err := maskingStatementsOf(rule); err != nil {
+			return nil, fmt.Errorf("invalid masking rule %d: %w", i, err)
+		}
+	}
+	return rules, nil
+}
+
+func failRestoreMasking(rc *xstorev1reconcile.Context, flow control.Flow, err error) (reconcile.Result, error) {
+	xstore := rc.MustGetXStore()
+	rc.UpdateXStoreCondition(&xstorev1.Condition{
+		Type:    xstorev1.Restorable,
+		Status:  corev1.ConditionFalse,
+		Reason:  "MaskingFailed",
+		Message: err.Error(),
+	})
+	xstore.Status.Phase = xstorev1.PhaseFailed
+	return flow.Wait("Masking of restored data failed!", "error", err.Error())
+}
+
+// ApplyRestoreMasking applies the masking rules on the restored leader one by one. It fails closed,
+// i.e. the restore fails if the rules are missing, invalid or failed to apply.
+var ApplyRestoreMasking = xstorev1reconcile.NewStepBinder("ApplyRestoreMasking",
+	func(rc *xstorev1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		xstore := rc.MustGetXStore()
+		masking := xstore.Spec.Restore.Masking
+		if masking == nil {
+			return flow.Pass()
+		}
+		status := xstore.Status.RestoreMasking
+		if status != nil && status.FinishTime != nil {
+			return flow.Pass()
+		}
+
+		configMap := &corev1.ConfigMap{}
+		err := rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: rc.Namespace(), Name: masking.ConfigMap}, configMap)
+		if err != nil {
+			return failRestoreMasking(rc, flow, fmt.Errorf("unable to get configmap of masking rules %s: %w", masking.ConfigMap, err))
+		}
+		rules, err := parseMaskingRules(configMap.Data[restoreMaskingRulesKey])
+		if err != nil {
+			return failRestoreMasking(rc, flow, err)
+		}
+		if status == nil {
+			status = &xstorev1.RestoreMaskingStatus{ConfigMap: masking.ConfigMap}
+			xstore.Status.RestoreMasking = status
+		}
+		status.TotalRules = int32(len(rules))
+
+		passwd, err := rc.GetXStoreAccountPassword(convention.SuperAccount)
+		if err != nil {
+			return flow.Error(err, "Unable to get password for super account.")
+		}
+		clusterAddr, err := rc.GetXStoreClusterAddr(convention.ServiceTypeReadWrite, convention.PortAccess)
+		if err != nil {
+			return flow.Error(err, "Unable to get cluster address.")
+		}
+		db, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s)/information_schema?timeout=5s",
+			convention.SuperAccount, passwd, clusterAddr))
+		if err != nil {
+			return flow.Error(err, "Unable to open connection to cluster address.")
+		}
+		defer db.Close()
+		// User variables of shuffle are session scoped.
+		conn, err := db.Conn(rc.Context())
+		if err != nil {
+			return flow.Error(err, "Unable to connect to cluster address.")
+		}
+		defer conn.Close()
+
+		// Rules applied already are skipped if resumed, hash and redact are idempotent anyway.
+		for i := int(status.AppliedRules); i < len(rules); i++ {
+			stmts, _ := maskingStatementsOf(rules[i])
+			for _, stmt := range stmts {
+				if _, err := conn.ExecContext(rc.Context(), stmt); err != nil {
+					return failRestoreMasking(rc, flow, fmt.Errorf("unable to apply masking rule %d on %s.%s: %w",
+						i, rules[i].Table, rules[i].Column, err))
+				}
+			}
+			status.AppliedRules = int32(i + 1)
+		}
+		now := metav1.Now()
+		status.FinishTime = &now
+
+		return flow.Continue("Masking of restored data applied.", "rules", len(rules))
+	})
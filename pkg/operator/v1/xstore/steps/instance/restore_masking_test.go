@@ -0,0 +1,61 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMaskingRules(t *testing.T) {
+	rules, err := parseMaskingRules(`
+- table: db1.users
+  column: email
+  method: hash
+- table: db1.users
+  column: phone
+  method: shuffle
+  key: id
+`)
+	if err != nil || len(rules) != 2 {
+		t.Fatalf("unexpected rules: %v, %v", rules, err)
+	}
+	stmts, _ := maskingStatementsOf(rules[0])
+	if len(stmts) != 1 || stmts[0] != "UPDATE `db1`.`users` SET `email` = LEFT(SHA2(`email`, 256), CHAR_LENGTH(`email`)) WHERE `email` IS NOT NULL" {
+		t.Fatalf("unexpected statements: %v", stmts)
+	}
+	stmts, _ = maskingStatementsOf(rules[1])
+	if len(stmts) != 2 || !strings.HasSuffix(stmts[1], "ON t.`id` = m.id SET t.`phone` = m.val") {
+		t.Fatalf("unexpected statements: %v", stmts)
+	}
+
+	for _, invalid := range []string{
+		"",
+		"- table: users\n  column: email\n  method: hash",
+		"- table: db1.users\n  column: email\n  method: drop",
+		"- table: db1.users\n  column: phone\n  method: shuffle",
+	} {
+		if _, err := parseMaskingRules(invalid); err == nil {
+			t.Fatalf("expect rules rejected: %q", invalid)
+		}
+	}
+
+	stmts, _ = maskingStatementsOf(maskingRule{Table: "db1.t`x", Column: "c", Method: maskingMethodRedact})
+	if !strings.HasPrefix(stmts[0], "UPDATE `db1`.`t``x` SET") {
+		t.Fatalf("expect identifier quoted: %s", stmts[0])
+	}
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	xstorev1 "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+)
+
+// stopDatetimeLayout is the layout of the stop datetime of mysqlbinlog, which is read in UTC.
+const stopDatetimeLayout = "2006-01-02 15:04:05"
+
+// isRestoreTimeReached tells whether the binlogs of the backup span the restore time, i.e. no more
+// binlog backups are needed once they're applied.
+func isRestoreTimeReached(backup *polardbxv1.XStoreBackup, restoreTime time.Time) bool {
+	return backup.Status.BackupSetTimestamp != nil && !backup.Status.BackupSetTimestamp.Time.Before(restoreTime)
+}
+
+func failPointInTimeRestore(rc *xstorev1reconcile.Context, xstore *polardbxv1.XStore, reason, message string) {
+	rc.UpdateXStoreCondition(&xstorev1.Condition{
+		Type:    xstorev1.Restorable,
+		Status:  corev1.ConditionFalse,
+		Reason:  reason,
+		Message: message,
+	})
+	xstore.Status.Phase = xstorev1.PhaseFailed
+}
+
+// ReplayBinlogsToRestoreTime applies the binlog backups of the source xstore newer than the restored
+// backup to the leader with the apply binlog job, one backup at a time and each stopped at the restore
+// time, until the binlogs spanning the restore time are applied.
+var ReplayBinlogsToRestoreTime = xstorev1reconcile.NewStepBinder("ReplayBinlogsToRestoreTime",
+	func(rc *xstorev1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		xstore := rc.MustGetXStore()
+		restoreSpec := xstore.Spec.Restore
+		if !restoreSpec.PointInTime || len(restoreSpec.BackupSet) > 0 {
+			return flow.Pass()
+		}
+		if xstore.Labels[polardbxmeta.LabelRole] == polardbxmeta.RoleGMS {
+			return flow.Continue("GMS don not need replay binlogs", "xstore-name", xstore.Name)
+		}
+		restoreTime := rc.MustParseRestoreTime()
+
+		status := xstore.Status.RestorePointInTime
+		if status == nil {
+			restoreJobContext := &RestoreJobContext{}
+			if err := rc.GetTaskContext("restore", &restoreJobContext); err != nil {
+				return flow.Error(err, "Unable to get task context for restore")
+			}
+			status = &xstorev1.PointInTimeRestoreStatus{
+				RestoreTime:       &metav1.Time{Time: restoreTime},
+				LastAppliedBackup: restoreJobContext.BackupName,
+			}
+			xstore.Status.RestorePointInTime = status
+		}
+		if status.Reached {
+			return flow.Pass()
+		}
+
+		job, err := rc.GetXStoreJob(applyBinlogJobSuffix)
+		if client.IgnoreNotFound(err) != nil {
+			return flow.Error(err, "Unable to get apply binlog job.")
+		}
+		if job != nil {
+			if k8shelper.IsJobFailed(job) {
+				failPointInTimeRestore(rc, xstore, "ReplayBinlogFailed",
+					"Apply binlog job "+job.Name+" failed, backup: "+status.ApplyingBackup)
+				return flow.Wait("Apply binlog job failed.", "job", job.Name, "backup", status.ApplyingBackup)
+			}
+			if !k8shelper.IsJobCompleted(job) {
+				return flow.RetryAfter(10*time.Second, "Apply binlog job is running.", "job", job.Name, "backup", status.ApplyingBackup)
+			}
+
+			pod := &corev1.Pod{}
+			err := rc.Client().Get(rc.Context(), types.NamespacedName{
+				Namespace: rc.Namespace(),
+				Name:      job.Labels[xstoremeta.JobLabelTargetPod],
+			}, pod)
+			if err != nil {
+				return flow.Error(err, "Unable to get target pod of apply binlog job.", "job", job.Name)
+			}
+			position, err := readAppliedBinlogPosition(rc, pod, job.Name)
+			if err != nil {
+				return flow.Error(err, "Unable to read applied binlog position.", "job", job.Name)
+			}
+			backup := &polardbxv1.XStoreBackup{}
+			if err := rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: rc.Namespace(), Name: status.ApplyingBackup}, backup); err != nil {
+				return flow.Error(err, "Unable to get applied xstore backup.", "backup", status.ApplyingBackup)
+			}
+			status.LastAppliedBackup = backup.Name
+			status.LastAppliedBinlog = position
+			status.ApplyingBackup = ""
+			status.Reached = isRestoreTimeReached(backup, restoreTime)
+
+			err = rc.Client().Delete(rc.Context(), job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+			if client.IgnoreNotFound(err) != nil {
+				return flow.Error(err, "Unable to remove apply binlog job", "job-name", job.Name)
+			}
+			return flow.Retry("Apply binlog job finished and removed.", "job", job.Name)
+		}
+
+		lastBackup := &polardbxv1.XStoreBackup{}
+		if err := rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: rc.Namespace(), Name: status.LastAppliedBackup}, lastBackup); err != nil {
+			return flow.Error(err, "Unable to get last applied xstore backup.", "backup", status.LastAppliedBackup)
+		}
+		if isRestoreTimeReached(lastBackup, restoreTime) {
+			status.Reached = true
+			return flow.Pass()
+		}
+		fromXStoreName := restoreSpec.From.XStoreName
+		backup, err := getNextBackupToApply(rc, fromXStoreName, lastBackup.Status.BackupSetTimestamp)
+		if err != nil {
+			return flow.Error(err, "Unable to get next backup to apply.")
+		}
+		// The binlogs after the latest backup aren't backed up yet, the data is restored to the latest
+		// binlog backup then.
+		if backup == nil {
+			status.Reached = true
+			rc.UpdateXStoreCondition(&xstorev1.Condition{
+				Type:    xstorev1.Restorable,
+				Status:  corev1.ConditionTrue,
+				Reason:  "RestoreTimeNotCovered",
+				Message: "No binlog backup spans the restore time " + restoreTime.UTC().String() + ", restored to backup " + lastBackup.Name,
+			})
+			return flow.Pass()
+		}
+
+		leaderPod, err := rc.TryGetXStoreLeaderPod()
+		if err != nil {
+			return flow.Error(err, "Unable to get leader pod.")
+		}
+		if leaderPod == nil {
+			return flow.RetryAfter(5*time.Second, "Leader pod not found")
+		}
+
+		jobContext := &ContinuousRestoreJobContext{
+			BinlogDirPath:     binlogBackupDirOf(backup, fromXStoreName),
+			LastAppliedBinlog: status.LastAppliedBinlog,
+			StorageName:       backup.Spec.StorageProvider.StorageName,
+			Sink:              backup.Spec.StorageProvider.Sink,
+			StopDatetime:      restoreTime.UTC().Format(stopDatetimeLayout),
+			EncryptionKeyFile: encryptionKeyFileOf(backup.Spec.Encryption, "backup"),
+		}
+		var lastEncryption *polardbxv1.BackupEncryption
+		if len(status.LastAppliedBinlog) == 0 {
+			jobContext.LastBinlogDirPath = binlogBackupDirOf(lastBackup, fromXStoreName)
+			lastEncryption = lastBackup.Spec.Encryption
+			jobContext.LastEncryptionKeyFile = encryptionKeyFileOf(lastEncryption, "last-backup")
+		}
+		if err := rc.SaveTaskContext(continuousRestoreJobKey, jobContext); err != nil {
+			return flow.Error(err, "Unable to save job context for point in time restore!")
+		}
+
+		job = newApplyBinlogJob(xstore, leaderPod, backup.Name, backup.Spec.Encryption, lastEncryption)
+		if err := rc.SetControllerRefAndCreate(job); err != nil {
+			return flow.Error(err, "Unable to create job to apply binlog", "pod", leaderPod.Name)
+		}
+		status.ApplyingBackup = backup.Name
+
+		return flow.RetryAfter(10*time.Second, "Apply binlog job created.", "job", job.Name, "backup", backup.Name)
+	})
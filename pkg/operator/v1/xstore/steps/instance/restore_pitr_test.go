@@ -0,0 +1,48 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+)
+
+func TestIsRestoreTimeReached(t *testing.T) {
+	restoreTime := time.Date(2023, 7, 1, 12, 0, 0, 0, time.UTC)
+	backupAt := func(ts time.Time) *polardbxv1.XStoreBackup {
+		backup := &polardbxv1.XStoreBackup{}
+		backup.Status.BackupSetTimestamp = &metav1.Time{Time: ts}
+		return backup
+	}
+
+	if isRestoreTimeReached(backupAt(restoreTime.Add(-time.Minute)), restoreTime) {
+		t.Fatal("expect not reached by backup before restore time")
+	}
+	if !isRestoreTimeReached(backupAt(restoreTime), restoreTime) || !isRestoreTimeReached(backupAt(restoreTime.Add(time.Hour)), restoreTime) {
+		t.Fatal("expect reached by backup spanning restore time")
+	}
+	if isRestoreTimeReached(&polardbxv1.XStoreBackup{}, restoreTime) {
+		t.Fatal("expect not reached by backup without timestamp")
+	}
+	if s := restoreTime.In(time.FixedZone("CST", 8*3600)).UTC().Format(stopDatetimeLayout); s != "2023-07-01 12:00:00" {
+		t.Fatalf("unexpected stop datetime: %s", s)
+	}
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	xstorev1 "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	xstoreexec "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/command"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/convention"
+	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+)
+
+const (
+	defaultRestoreRejoinMaxLag  = 1000
+	defaultRestoreRejoinTimeout = 10 * time.Minute
+)
+
+func parseConsensusIndex(row map[string]interface{}, key string) int64 {
+	val, _ := row[key].(string)
+	index, _ := strconv.ParseInt(val, 10, 64)
+	return index
+}
+
+// checkConsensusRejoined checks the consensus membership listed on the leader against the pods. Pods
+// of learner node sets must be learners and the others must be voting members, i.e. leader, follower
+// or logger, and none of them is behind the leader by more than the max lag. Loggers don't apply the
+// logs so the lag is by the match index for them. It returns the status of members and the reason of
+// the first unhealthy one, empty if all are healthy.
+func checkConsensusRejoined(pods []corev1.Pod, rows []map[string]interface{}, maxLag int64) ([]xstorev1.ConsensusMemberStatus, string) {
+	rowOfPod := make(map[string]map[string]interface{})
+	var leader map[string]interface{}
+	for _, row := range rows {
+		pod, _ := row["pod"].(string)
+		rowOfPod[pod] = row
+		if role, _ := row["role"].(string); role == xstoremeta.RoleLeader {
+			leader = row
+		}
+	}
+	if leader == nil {
+		return nil, "leader not found in consensus group"
+	}
+
+	members := make([]xstorev1.ConsensusMemberStatus, 0, len(pods))
+	reason := ""
+	for i := range pods {
+		pod := &pods[i]
+		member := xstorev1.ConsensusMemberStatus{Pod: pod.Name}
+		row, ok := rowOfPod[pod.Name]
+		if ok {
+			member.Role, _ = row["role"].(string)
+			if member.Role == xstoremeta.RoleLogger {
+				member.Lag = parseConsensusIndex(leader, "match_index") - parseConsensusIndex(row, "match_index")
+			} else {
+				member.Lag = parseConsensusIndex(leader, "applied_index") - parseConsensusIndex(row, "applied_index")
+			}
+			if member.Lag < 0 {
+				member.Lag = 0
+			}
+		}
+
+		var expectRole bool
+		if xstoremeta.IsPodRoleLearner(pod) {
+			expectRole = member.Role == xstoremeta.RoleLearner
+		} else {
+			expectRole = member.Role == xstoremeta.RoleLeader || member.Role == xstoremeta.RoleFollower ||
+				member.Role == xstoremeta.RoleLogger
+		}
+		member.Healthy = expectRole && member.Lag <= maxLag
+		members = append(members, member)
+
+		if member.Healthy || len(reason) > 0 {
+			continue
+		}
+		if !ok {
+			reason = fmt.Sprintf("pod %s is not a member of consensus group", pod.Name)
+		} else if !expectRole {
+			reason = fmt.Sprintf("pod %s is in unexpected role %s", pod.Name, member.Role)
+		} else {
+			reason = fmt.Sprintf("pod %s is behind the leader by %d log entries, more than %d", pod.Name, member.Lag, maxLag)
+		}
+	}
+	return members, reason
+}
+
+func listConsensusMembersOnLeader(rc *xstorev1reconcile.Context, leaderPod *corev1.Pod) ([]map[string]interface{}, error) {
+	stdout := &bytes.Buffer{}
+	cmd := xstoreexec.NewCanonicalCommandBuilder().Consensus().List(true).Build()
+	if err := rc.ExecuteCommandOn(leaderPod, convention.ContainerEngine, cmd, control.ExecOptions{
+		Stdout:  stdout,
+		Timeout: 10 * time.Second,
+	}); err != nil {
+		return nil, err
+	}
+	return xstoreexec.ParseCommandResultGenerally(strings.TrimSpace(stdout.String()))
+}
+
+// WaitUntilRestoredNodesRejoined waits until the restored nodes have rejoined the consensus group and
+// caught up with the leader, and fails the restore with the observed membership on timeout.
+var WaitUntilRestoredNodesRejoined = xstorev1reconcile.NewStepBinder("WaitUntilRestoredNodesRejoined",
+	func(rc *xstorev1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		xstore := rc.MustGetXStore()
+		if xstore.Spec.Readonly {
+			return flow.Pass()
+		}
+		if xstore.Status.RestoreRejoin == nil {
+			now := metav1.Now()
+			xstore.Status.RestoreRejoin = &xstorev1.RestoreRejoinStatus{StartTime: &now}
+		}
+		status := xstore.Status.RestoreRejoin
+		if status.FinishTime != nil {
+			return flow.Pass()
+		}
+
+		maxLag, timeout := int64(defaultRestoreRejoinMaxLag), defaultRestoreRejoinTimeout
+		if rejoin := xstore.Spec.Restore.Rejoin; rejoin != nil {
+			if rejoin.MaxLag > 0 {
+				maxLag = rejoin.MaxLag
+			}
+			if rejoin.Timeout.Duration > 0 {
+				timeout = rejoin.Timeout.Duration
+			}
+		}
+
+		pods, err := rc.GetXStorePods()
+		if err != nil {
+			return flow.Error(err, "Unable to get pods.")
+		}
+		leaderPod, err := rc.TryGetXStoreLeaderPod()
+		if err != nil {
+			return flow.Error(err, "Unable to get leader pod.")
+		}
+
+		reason := "leader pod not found"
+		if leaderPod != nil {
+			rows, err := listConsensusMembersOnLeader(rc, leaderPod)
+			if err != nil {
+				reason = "unable to list consensus members on leader: " + err.Error()
+			} else {
+				status.Members, reason = checkConsensusRejoined(pods, rows, maxLag)
+			}
+		}
+		status.Message = reason
+		if len(reason) == 0 {
+			now := metav1.Now()
+			status.FinishTime = &now
+			return flow.Continue("Restored nodes rejoined.")
+		}
+
+		if time.Since(status.StartTime.Time) > timeout {
+			rc.UpdateXStoreCondition(&xstorev1.Condition{
+				Type:    xstorev1.Restorable,
+				Status:  corev1.ConditionFalse,
+				Reason:  "ConsensusRejoinTimeout",
+				Message: "Restored nodes haven't rejoined the consensus group in " + timeout.String() + ": " + reason,
+			})
+			xstore.Status.Phase = xstorev1.PhaseFailed
+			return flow.Wait("Restored nodes haven't rejoined in time!", "reason", reason)
+		}
+		return flow.RetryAfter(10*time.Second, "Wait until restored nodes rejoined.", "reason", reason)
+	})
@@ -0,0 +1,83 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
+)
+
+func rejoinPod(name, nodeRole string) corev1.Pod {
+	pod := corev1.Pod{}
+	pod.Name = name
+	pod.Labels = map[string]string{xstoremeta.LabelNodeRole: nodeRole}
+	return pod
+}
+
+func consensusRow(pod, role, matchIndex, appliedIndex string) map[string]interface{} {
+	return map[string]interface{}{
+		"pod":           pod,
+		"role":          role,
+		"match_index":   matchIndex,
+		"applied_index": appliedIndex,
+	}
+}
+
+func TestCheckConsensusRejoined(t *testing.T) {
+	pods := []corev1.Pod{
+		rejoinPod("cand-0", "candidate"),
+		rejoinPod("cand-1", "candidate"),
+		rejoinPod("log-0", "voter"),
+		rejoinPod("learner-0", "learner"),
+	}
+	rows := []map[string]interface{}{
+		consensusRow("cand-0", "leader", "5000", "5000"),
+		consensusRow("cand-1", "follower", "5000", "4500"),
+		consensusRow("log-0", "logger", "4900", "0"),
+		consensusRow("learner-0", "learner", "5000", "4800"),
+	}
+
+	members, reason := checkConsensusRejoined(pods, rows, 1000)
+	if len(reason) > 0 {
+		t.Fatalf("expect rejoined, got %s", reason)
+	}
+	if len(members) != 4 || members[1].Lag != 500 || members[2].Lag != 100 {
+		t.Fatalf("unexpected members: %+v", members)
+	}
+
+	if _, reason := checkConsensusRejoined(pods, rows, 200); !strings.Contains(reason, "cand-1 is behind") {
+		t.Fatalf("expect lagging follower, got %s", reason)
+	}
+
+	rows[1] = consensusRow("cand-1", "learner", "5000", "5000")
+	if _, reason := checkConsensusRejoined(pods, rows, 1000); !strings.Contains(reason, "unexpected role learner") {
+		t.Fatalf("expect unexpected role, got %s", reason)
+	}
+
+	members, reason = checkConsensusRejoined(pods, rows[:1], 1000)
+	if !strings.Contains(reason, "cand-1 is not a member") || members[1].Healthy {
+		t.Fatalf("expect missing member, got %s", reason)
+	}
+
+	if _, reason := checkConsensusRejoined(pods, rows[1:], 1000); !strings.Contains(reason, "leader not found") {
+		t.Fatalf("expect leader not found, got %s", reason)
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	xstorev1 "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+)
+
+// restoreReplayCheckpointPath is the checkpoint of binlog replay written by the restore job.
+const restoreReplayCheckpointPath = restoreTempDir + "/replay.checkpoint"
+
+type restoreReplayCheckpoint struct {
+	BackupFilePath string `json:"backupFilePath"`
+	Stage          string `json:"stage"`
+	StartIndex     int64  `json:"startIndex"`
+	AppliedIndex   int64  `json:"appliedIndex"`
+	EndIndex       int64  `json:"endIndex"`
+	Resumes        int32  `json:"resumes"`
+	UpdateTime     int64  `json:"updateTime"`
+}
+
+// parseRestoreReplayCheckpoint parses the checkpoint, it returns nil if there's none or it's of
+// another backup file, e.g. left by the restore before falling back.
+func parseRestoreReplayCheckpoint(content, backupFilePath string) (*xstorev1.RestoreReplayStatus, error) {
+	content = strings.TrimSpace(content)
+	if len(content) == 0 {
+		return nil, nil
+	}
+	checkpoint := &restoreReplayCheckpoint{}
+	if err := json.Unmarshal([]byte(content), checkpoint); err != nil {
+		return nil, fmt.Errorf("invalid replay checkpoint: %w", err)
+	}
+	if checkpoint.BackupFilePath != backupFilePath {
+		return nil, nil
+	}
+	status := &xstorev1.RestoreReplayStatus{
+		Stage:        checkpoint.Stage,
+		StartIndex:   checkpoint.StartIndex,
+		AppliedIndex: checkpoint.AppliedIndex,
+		EndIndex:     checkpoint.EndIndex,
+		Resumes:      checkpoint.Resumes,
+	}
+	if checkpoint.UpdateTime > 0 {
+		status.UpdateTime = &metav1.Time{Time: time.Unix(checkpoint.UpdateTime, 0)}
+	}
+	return status, nil
+}
+
+func updateRestoreReplayProgress(rc *xstorev1reconcile.Context, xstore *polardbxv1.XStore, pod *corev1.Pod) error {
+	restoreJobContext := &RestoreJobContext{}
+	if err := rc.GetTaskContext("restore", &restoreJobContext); err != nil {
+		return err
+	}
+
+	// The checkpoint doesn't exist until the replay starts.
+	cmd := []string{"sh", "-c", "cat " + restoreReplayCheckpointPath + " 2>/dev/null || true"}
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	if err := rc.ExecuteCommandOn(pod, "engine", cmd, control.ExecOptions{
+		Stdout: stdout,
+		Stderr: stderr,
+	}); err != nil {
+		return fmt.Errorf("failed to cat replay checkpoint: %w, stderr: %s", err, stderr.String())
+	}
+	status, err := parseRestoreReplayCheckpoint(stdout.String(), restoreJobContext.BackupFilePath)
+	if err != nil || status == nil {
+		return err
+	}
+
+	if xstore.Status.RestoreReplay == nil {
+		xstore.Status.RestoreReplay = make(map[string]*xstorev1.RestoreReplayStatus)
+	}
+	xstore.Status.RestoreReplay[pod.Name] = status
+	return nil
+}
@@ -0,0 +1,43 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import "testing"
+
+func TestParseRestoreReplayCheckpoint(t *testing.T) {
+	const path = "polardbx-backup/pxc/fullbackup/pxc-dn-0.xbstream"
+
+	status, err := parseRestoreReplayCheckpoint(`{"backupFilePath": "`+path+`", "stage": "Replaying", `+
+		`"startIndex": 100, "appliedIndex": 500, "endIndex": 1000, "resumes": 2, "updateTime": 1690000000}`+"\n", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status == nil || status.Stage != "Replaying" || status.AppliedIndex != 500 || status.EndIndex != 1000 ||
+		status.Resumes != 2 || status.UpdateTime == nil || status.UpdateTime.Unix() != 1690000000 {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+
+	if status, err := parseRestoreReplayCheckpoint(" \n", path); err != nil || status != nil {
+		t.Fatalf("expect no checkpoint, got %+v, %v", status, err)
+	}
+	if status, err := parseRestoreReplayCheckpoint(`{"backupFilePath": "another", "stage": "Replayed"}`, path); err != nil || status != nil {
+		t.Fatalf("expect checkpoint of another backup ignored, got %+v, %v", status, err)
+	}
+	if _, err := parseRestoreReplayCheckpoint("{", path); err == nil {
+		t.Fatal("expect invalid checkpoint")
+	}
+}
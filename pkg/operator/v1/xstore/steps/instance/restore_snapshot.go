@@ -0,0 +1,147 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"errors"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	xstorev1 "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/convention"
+	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+)
+
+// newPersistentVolumeClaimFromSnapshot returns the claim of the pod, provisioned from the volume snapshot
+// if the pod holds the data, i.e. candidates and learners. Voters log only and start with empty claims.
+func newPersistentVolumeClaimFromSnapshot(xstore *polardbxv1.XStore, podName string, role xstorev1.NodeRole,
+	snapshot *polardbxv1.BackupVolumeSnapshotStatus) (*corev1.PersistentVolumeClaim, error) {
+	size, err := resource.ParseQuantity(snapshot.RestoreSize)
+	if err != nil {
+		return nil, err
+	}
+	var storageClassName *string
+	if len(snapshot.StorageClassName) > 0 {
+		storageClassName = &snapshot.StorageClassName
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      convention.NewPersistentVolumeClaimName(podName),
+			Namespace: xstore.Namespace,
+			Labels: k8shelper.PatchLabels(convention.ConstLabels(xstore), map[string]string{
+				xstoremeta.LabelPod: podName,
+			}),
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			StorageClassName: storageClassName,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: size,
+				},
+			},
+		},
+	}
+	if role == xstorev1.RoleCandidate || role == xstorev1.RoleLearner {
+		apiGroup := "snapshot.storage.k8s.io"
+		pvc.Spec.DataSource = &corev1.TypedLocalObjectReference{
+			APIGroup: &apiGroup,
+			Kind:     "VolumeSnapshot",
+			Name:     snapshot.Name,
+		}
+	}
+	return pvc, nil
+}
+
+// podRolesOf returns the node roles of the pods in topology.
+func podRolesOf(xstore *polardbxv1.XStore) map[string]xstorev1.NodeRole {
+	roles := make(map[string]xstorev1.NodeRole)
+	for _, nodeSet := range xstore.Spec.Topology.NodeSets {
+		for i := 0; i < int(nodeSet.Replicas); i++ {
+			roles[convention.NewPodName(xstore, &nodeSet, i)] = nodeSet.Role
+		}
+	}
+	return roles
+}
+
+// PrepareVolumesFromSnapshot pre-provisions the claims of the pods from the volume snapshot if the xstore
+// is restored from a backup taken by the volume snapshot engine. It must be done before the pods are
+// created, so that they're bound to the claims instead of host paths. Snapshots are crash consistent at
+// the time they're cut, so neither point in time nor continuous restore is supported.
+var PrepareVolumesFromSnapshot = xstorev1reconcile.NewStepBinder("PrepareVolumesFromSnapshot",
+	func(rc *xstorev1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		xstore := rc.MustGetXStore()
+
+		backup, err := findRestoreBackup(rc)
+		if err != nil {
+			return flow.Error(err, "Unable to find the backup to restore.")
+		}
+		// Leave it to the restore job context if not found.
+		if backup == nil || backup.Spec.BackupEngine != polardbxv1.BackupEngineVolumeSnapshot {
+			return flow.Pass()
+		}
+
+		restoreSpec := xstore.Spec.Restore
+		snapshot := backup.Status.VolumeSnapshot
+		var reason string
+		if restoreSpec.PointInTime || restoreSpec.Continuous != nil {
+			reason = "Volume snapshot backup " + backup.Name + " doesn't support point in time or continuous restore"
+		} else if snapshot == nil || !snapshot.ReadyToUse {
+			reason = "Volume snapshot of backup " + backup.Name + " isn't ready to use"
+		}
+		if len(reason) > 0 {
+			rc.UpdateXStoreCondition(&xstorev1.Condition{
+				Type:    xstorev1.Restorable,
+				Status:  corev1.ConditionFalse,
+				Reason:  "VolumeSnapshotNotRestorable",
+				Message: reason,
+			})
+			xstore.Status.Phase = xstorev1.PhaseFailed
+			return flow.Wait("Unable to restore from volume snapshot.", "backup", backup.Name, "reason", reason)
+		}
+
+		roles := podRolesOf(xstore)
+		for podName, vol := range xstore.Status.BoundVolumes {
+			if len(vol.PersistentVolumeClaim) > 0 {
+				continue
+			}
+			role, ok := roles[podName]
+			if !ok {
+				return flow.Error(errors.New("pod not found in topology"), "Unable to prepare volume.", "pod", podName)
+			}
+			pvc, err := newPersistentVolumeClaimFromSnapshot(xstore, podName, role, snapshot)
+			if err != nil {
+				return flow.Error(err, "Unable to parse restore size of volume snapshot.", "size", snapshot.RestoreSize)
+			}
+			if err := rc.SetControllerRefAndCreate(pvc); err != nil && !apierrors.IsAlreadyExists(err) {
+				return flow.Error(err, "Unable to create persistent volume claim.", "pod", podName)
+			}
+			vol.PersistentVolumeClaim = pvc.Name
+			vol.HostPath = ""
+			vol.LogHostPath = ""
+		}
+
+		return flow.Continue("Volumes prepared from snapshot.", "backup", backup.Name, "snapshot", snapshot.Name)
+	})
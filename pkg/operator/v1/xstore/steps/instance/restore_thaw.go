@@ -0,0 +1,132 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	xstorev1 "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	polardbxhelper "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/command"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+)
+
+// thawBackupObjects requests to thaw the archived objects under the prefix, and returns the
+// count of archived objects and the count of those still being thawed.
+func thawBackupObjects(rc *xstorev1reconcile.Context, flow control.Flow, pod *corev1.Pod, prefix string,
+	storageName polardbxv1.BackupStorage, sink string) (int64, int64, error) {
+	cmd := command.NewCanonicalCommandBuilder().Collect().ThawFiles(prefix, string(storageName), sink).Build()
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	if err := rc.ExecuteCommandOn(pod, "engine", cmd, control.ExecOptions{
+		Logger:  flow.Logger(),
+		Stdout:  stdout,
+		Stderr:  stderr,
+		Timeout: 5 * time.Minute,
+	}); err != nil {
+		return 0, 0, fmt.Errorf("failed to thaw backup objects: %w, stderr: %s", err, stderr.String())
+	}
+	var archived, thawing int64
+	if _, err := fmt.Sscanf(stdout.String(), "%d %d", &archived, &thawing); err != nil {
+		return 0, 0, fmt.Errorf("failed to parse thaw result %q: %w", stdout.String(), err)
+	}
+	return archived, thawing, nil
+}
+
+// WaitUntilBackupObjectsThawed thaws the full backup and binlogs of the backup to restore if they're
+// in an archive storage class, and waits until they're downloadable before the restore jobs start.
+var WaitUntilBackupObjectsThawed = xstorev1reconcile.NewStepBinder("WaitUntilBackupObjectsThawed",
+	func(rc *xstorev1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		xstore := rc.MustGetXStore()
+
+		restoreJobContext := &RestoreJobContext{}
+		if err := rc.GetTaskContext("restore", &restoreJobContext); err != nil {
+			return flow.Error(err, "Unable to get restore job context.")
+		}
+		status := xstore.Status.RestoreThaw
+		if status != nil && status.Backup == restoreJobContext.BackupName && status.ThawingObjects == 0 {
+			return flow.Pass()
+		}
+
+		backup := &polardbxv1.XStoreBackup{}
+		err := rc.Client().Get(rc.Context(), types.NamespacedName{Namespace: rc.Namespace(), Name: restoreJobContext.BackupName}, backup)
+		if err != nil {
+			return flow.Error(err, "Unable to get restored xstore backup.", "backup", restoreJobContext.BackupName)
+		}
+		if !polardbxhelper.IsArchiveBackupStorageClass(restoreJobContext.StorageName, backup.Status.StorageClass) {
+			return flow.Pass()
+		}
+
+		// The backup may be replaced when restore falls back.
+		if status == nil || status.Backup != backup.Name {
+			now := metav1.Now()
+			status = &xstorev1.RestoreThawStatus{
+				Backup:       backup.Name,
+				StorageClass: backup.Status.StorageClass,
+				StartTime:    &now,
+			}
+			xstore.Status.RestoreThaw = status
+		}
+
+		pods, err := rc.GetXStorePods()
+		if err != nil {
+			return flow.Error(err, "Unable to get pods for xcluster.")
+		}
+		if len(pods) == 0 {
+			return flow.RetryAfter(5*time.Second, "No pod found to thaw backup objects.")
+		}
+
+		var archived, thawing int64
+		prefixes := append([]string{restoreJobContext.BackupFilePath, restoreJobContext.BinlogDirPath},
+			restoreJobContext.IncrementalBackupFilePaths...)
+		for _, prefix := range prefixes {
+			a, t, err := thawBackupObjects(rc, flow, &pods[0], prefix, restoreJobContext.StorageName, restoreJobContext.Sink)
+			if err != nil {
+				return flow.Error(err, "Unable to thaw backup objects.", "pod", pods[0].Name, "prefix", prefix)
+			}
+			archived += a
+			thawing += t
+		}
+		status.ArchivedObjects = archived
+		status.ThawingObjects = thawing
+
+		if thawing > 0 {
+			rc.UpdateXStoreCondition(&xstorev1.Condition{
+				Type:    xstorev1.BackupObjectsThawing,
+				Status:  corev1.ConditionTrue,
+				Reason:  "ThawingArchivedObjects",
+				Message: fmt.Sprintf("%d of %d archived objects of backup %s are being thawed", thawing, archived, backup.Name),
+			})
+			return flow.RetryAfter(time.Minute, "Waiting for archived backup objects thawed.",
+				"backup", backup.Name, "archived", archived, "thawing", thawing)
+		}
+		rc.UpdateXStoreCondition(&xstorev1.Condition{
+			Type:   xstorev1.BackupObjectsThawing,
+			Status: corev1.ConditionFalse,
+			Reason: "ArchivedObjectsThawed",
+		})
+		return flow.Continue("Archived backup objects thawed.", "backup", backup.Name, "archived", archived)
+	})
@@ -20,6 +20,7 @@ import (
 	xstorev1 "github.com/alibaba/polardbx-operator/api/v1"
 	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
 	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/command"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/factory"
 	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
 	"github.com/alibaba/polardbx-operator/pkg/util"
 	batchv1 "k8s.io/api/batch/v1"
@@ -78,7 +79,7 @@ func patchTaskConfigMapVolumeAndVolumeMounts(xstore *xstorev1.XStore, podSpec *c
 	}
 }
 
-func newRestoreDataJob(xstore *xstorev1.XStore, targetPod *corev1.Pod) *batchv1.Job {
+func newRestoreDataJob(xstore *xstorev1.XStore, targetPod *corev1.Pod, encryption *xstorev1.BackupEncryption) *batchv1.Job {
 	podSpec := targetPod.Spec.DeepCopy()
 	podSpec.InitContainers = nil
 	podSpec.RestartPolicy = corev1.RestartPolicyNever
@@ -102,6 +103,13 @@ func newRestoreDataJob(xstore *xstorev1.XStore, targetPod *corev1.Pod) *batchv1.
 	// Replace system envs.
 	replaceSystemEnvs(podSpec, targetPod)
 	patchTaskConfigMapVolumeAndVolumeMounts(xstore, podSpec)
+	factory.PatchBackupEncryptionVolume(podSpec, "backup", encryption)
+
+	// Restarted pods resume the download of backup set.
+	var backoffLimit int32 = 0
+	if download := xstore.Spec.Restore.Download; download != nil && download.Retries > 0 {
+		backoffLimit = download.Retries
+	}
 
 	return &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
@@ -116,7 +124,7 @@ func newRestoreDataJob(xstore *xstorev1.XStore, targetPod *corev1.Pod) *batchv1.
 		},
 		Spec: batchv1.JobSpec{
 			//TTLSecondsAfterFinished: pointer.Int32(100),
-			BackoffLimit: pointer.Int32(0),
+			BackoffLimit: pointer.Int32(backoffLimit),
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Labels: map[string]string{
@@ -0,0 +1,46 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"strings"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+)
+
+// WaitUntilAllPodsStopped waits until all the pods deleted are gone. The volumes are bound to
+// the hosts in status and reused by the pods recreated on resume.
+var WaitUntilAllPodsStopped = xstorev1reconcile.NewStepBinder("WaitUntilAllPodsStopped",
+	func(rc *xstorev1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		pods, err := rc.GetXStorePods()
+		if err != nil {
+			return flow.Error(err, "Unable to get pods.")
+		}
+
+		if len(pods) > 0 {
+			return flow.RetryAfter(5*time.Second, "Wait until all pods are stopped.",
+				"pods", strings.Join(k8shelper.ToObjectNames(pods), ","))
+		}
+
+		return flow.Continue("All pods are stopped.")
+	},
+)
@@ -0,0 +1,256 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	xstorev1 "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
+	xstoreexec "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/command"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/convention"
+	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+)
+
+const (
+	switchoverDrainPeriod = 10 * time.Second
+	switchoverMaxLag      = 100
+	switchoverTimeout     = 5 * time.Minute
+)
+
+func isSwitchoverRequested(xstore *polardbxv1.XStore) bool {
+	_, ok := xstore.Annotations[xstoremeta.AnnotationSwitchover]
+	return ok && !xstore.Spec.Readonly
+}
+
+// pickSwitchoverTarget picks the target of switchover from the consensus membership listed on the
+// leader. The requested target must be a follower of a candidate pod, and the follower caught up
+// most is picked if none is requested.
+func pickSwitchoverTarget(pods []corev1.Pod, rows []map[string]interface{}, requested string) (string, error) {
+	candidates := make(map[string]bool)
+	for i := range pods {
+		pod := &pods[i]
+		if xstoremeta.IsPodRoleCandidate(pod) && pod.DeletionTimestamp.IsZero() && k8shelper.IsPodReady(pod) {
+			candidates[pod.Name] = true
+		}
+	}
+
+	followers := make([]map[string]interface{}, 0)
+	for _, row := range rows {
+		pod, _ := row["pod"].(string)
+		if role, _ := row["role"].(string); role == xstoremeta.RoleFollower && candidates[pod] {
+			followers = append(followers, row)
+		}
+	}
+
+	if len(requested) > 0 {
+		for _, row := range followers {
+			if pod, _ := row["pod"].(string); pod == requested {
+				return requested, nil
+			}
+		}
+		return "", fmt.Errorf("pod %s is not a ready follower of candidate", requested)
+	}
+
+	if len(followers) == 0 {
+		return "", errors.New("no ready follower of candidate found")
+	}
+	sort.SliceStable(followers, func(i, j int) bool {
+		li, lj := parseConsensusIndex(followers[i], "applied_index"), parseConsensusIndex(followers[j], "applied_index")
+		if li != lj {
+			return li > lj
+		}
+		pi, _ := followers[i]["pod"].(string)
+		pj, _ := followers[j]["pod"].(string)
+		return pi < pj
+	})
+	pod, _ := followers[0]["pod"].(string)
+	return pod, nil
+}
+
+// switchoverLag returns the count of log entries the target is behind the leader.
+func switchoverLag(rows []map[string]interface{}, target string) (int64, error) {
+	var leader, follower map[string]interface{}
+	for _, row := range rows {
+		pod, _ := row["pod"].(string)
+		if role, _ := row["role"].(string); role == xstoremeta.RoleLeader {
+			leader = row
+		} else if pod == target {
+			follower = row
+		}
+	}
+	if leader == nil {
+		return 0, errors.New("leader not found in consensus group")
+	}
+	if follower == nil {
+		return 0, fmt.Errorf("pod %s not found in consensus group", target)
+	}
+	lag := parseConsensusIndex(leader, "commit_index") - parseConsensusIndex(follower, "applied_index")
+	if lag < 0 {
+		lag = 0
+	}
+	return lag, nil
+}
+
+func finishSwitchover(xstore *polardbxv1.XStore, phase xstorev1.SwitchoverPhase, message string) {
+	now := metav1.Now()
+	xstore.Status.Switchover.Phase = phase
+	xstore.Status.Switchover.Message = message
+	xstore.Status.Switchover.CompletionTime = &now
+}
+
+func WhenSwitchoverRequested(binders ...control.BindFunc) control.BindFunc {
+	return xstorev1reconcile.NewStepIfBinder("SwitchoverRequested",
+		func(rc *xstorev1reconcile.Context, log logr.Logger) (bool, error) {
+			return isSwitchoverRequested(rc.MustGetXStore()), nil
+		},
+		binders...,
+	)
+}
+
+// ReconcileSwitchover transfers the leadership gracefully to the requested target. The leader is
+// drained out of the service first, then the leadership is transferred once the target has caught
+// up. The role labels are reconciled to the actual leader by the running loop after the switchover,
+// even if it fails.
+var ReconcileSwitchover = xstorev1reconcile.NewStepBinder("ReconcileSwitchover",
+	func(rc *xstorev1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		xstore := rc.MustGetXStore()
+		if !isSwitchoverRequested(xstore) {
+			if xstore.Status.Switchover != nil && !xstore.Status.Switchover.Phase.IsTerminal() {
+				finishSwitchover(xstore, xstorev1.SwitchoverFailed, "canceled")
+			}
+			return flow.Pass()
+		}
+
+		// Start a new one if none in progress.
+		status := xstore.Status.Switchover
+		if status == nil || status.Phase.IsTerminal() {
+			now := metav1.Now()
+			xstore.Status.Switchover = &xstorev1.SwitchoverStatus{
+				From:      xstore.Status.LeaderPod,
+				Target:    xstore.Annotations[xstoremeta.AnnotationSwitchover],
+				Phase:     xstorev1.SwitchoverDraining,
+				StartTime: &now,
+			}
+			status = xstore.Status.Switchover
+		}
+
+		if time.Since(status.StartTime.Time) > switchoverTimeout {
+			finishSwitchover(xstore, xstorev1.SwitchoverFailed, "timeout after "+switchoverTimeout.String())
+			return flow.Continue("Switchover timeout.", "target", status.Target)
+		}
+
+		leaderPod, err := rc.GetXStorePod(status.From)
+		if err != nil {
+			finishSwitchover(xstore, xstorev1.SwitchoverFailed, "leader pod not found: "+err.Error())
+			return flow.Continue("Leader pod not found.", "pod", status.From)
+		}
+
+		switch status.Phase {
+		case xstorev1.SwitchoverDraining:
+			pods, err := rc.GetXStorePods()
+			if err != nil {
+				return flow.Error(err, "Unable to get pods.")
+			}
+			rows, err := listConsensusMembersOnLeader(rc, leaderPod)
+			if err != nil {
+				return flow.Error(err, "Unable to list consensus members on leader.", "leader", leaderPod.Name)
+			}
+			target, err := pickSwitchoverTarget(pods, rows, status.Target)
+			if err != nil {
+				finishSwitchover(xstore, xstorev1.SwitchoverFailed, err.Error())
+				return flow.Continue("Invalid switchover target.", "target", status.Target, "error", err.Error())
+			}
+			status.Target = target
+
+			// Remove the leader from the service, the label is reconciled back by the running loop.
+			if xstoremeta.IsRoleLeader(leaderPod) {
+				leaderPod.Labels[xstoremeta.LabelRole] = xstoremeta.RoleFollower
+				if err := rc.Client().Update(rc.Context(), leaderPod); err != nil {
+					return flow.Error(err, "Unable to drain leader pod.", "pod", leaderPod.Name)
+				}
+			}
+			if time.Since(status.StartTime.Time) < switchoverDrainPeriod {
+				return flow.RetryAfter(switchoverDrainPeriod, "Wait until connections drained.", "leader", leaderPod.Name)
+			}
+			status.Phase = xstorev1.SwitchoverCatchingUp
+			return flow.Retry("Leader drained.", "leader", leaderPod.Name, "target", target)
+		case xstorev1.SwitchoverCatchingUp:
+			rows, err := listConsensusMembersOnLeader(rc, leaderPod)
+			if err != nil {
+				return flow.Error(err, "Unable to list consensus members on leader.", "leader", leaderPod.Name)
+			}
+			lag, err := switchoverLag(rows, status.Target)
+			if err != nil {
+				finishSwitchover(xstore, xstorev1.SwitchoverFailed, err.Error())
+				return flow.Continue("Unable to get lag of switchover target.", "error", err.Error())
+			}
+			if lag > switchoverMaxLag {
+				return flow.RetryAfter(2*time.Second, "Wait until target caught up.", "target", status.Target, "lag", lag)
+			}
+
+			cmd := xstoreexec.NewCanonicalCommandBuilder().Consensus().SetLeader(status.Target).Build()
+			if err := rc.ExecuteCommandOn(leaderPod, convention.ContainerEngine, cmd, control.ExecOptions{
+				Logger:  flow.Logger(),
+				Timeout: 10 * time.Second,
+			}); err != nil {
+				return flow.Error(err, "Unable to change leader.", "leader", leaderPod.Name, "target", status.Target)
+			}
+			status.Phase = xstorev1.SwitchoverTransferring
+			return flow.RetryAfter(2*time.Second, "Leadership transfer started.", "target", status.Target)
+		case xstorev1.SwitchoverTransferring:
+			targetPod, err := rc.GetXStorePod(status.Target)
+			if err != nil {
+				return flow.Error(err, "Unable to get target pod.", "pod", status.Target)
+			}
+			role, _, err := ReportRoleAndCurrentLeader(rc, targetPod, flow.Logger())
+			if err != nil {
+				return flow.Error(err, "Unable to report role of target pod.", "pod", status.Target)
+			}
+			if role != xstoremeta.RoleLeader {
+				return flow.RetryAfter(2*time.Second, "Wait until target elected.", "target", status.Target, "role", role)
+			}
+			xstore.Status.LeaderPod = status.Target
+			finishSwitchover(xstore, xstorev1.SwitchoverSucceeded, "")
+			return flow.Continue("Switchover succeeded.", "from", status.From, "target", status.Target)
+		}
+		return flow.Pass()
+	},
+)
+
+// RemoveSwitchoverAnnotation removes the request of switchover once it's succeeded or failed.
+var RemoveSwitchoverAnnotation = xstorev1reconcile.NewStepBinder("RemoveSwitchoverAnnotation",
+	func(rc *xstorev1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		xstore := rc.MustGetXStore()
+		if _, ok := xstore.Annotations[xstoremeta.AnnotationSwitchover]; ok {
+			delete(xstore.Annotations, xstoremeta.AnnotationSwitchover)
+			rc.MarkXStoreChanged()
+		}
+		return flow.Continue("Switchover request removed.")
+	},
+)
@@ -0,0 +1,101 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
+)
+
+func switchoverPod(name, nodeRole string) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{xstoremeta.LabelNodeRole: nodeRole},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "engine", Ready: true},
+			},
+		},
+	}
+}
+
+func switchoverRow(pod, role, appliedIndex, commitIndex string) map[string]interface{} {
+	return map[string]interface{}{
+		"pod":           pod,
+		"role":          role,
+		"applied_index": appliedIndex,
+		"commit_index":  commitIndex,
+	}
+}
+
+func TestPickSwitchoverTarget(t *testing.T) {
+	pods := []corev1.Pod{
+		switchoverPod("xs-cand-0", "candidate"),
+		switchoverPod("xs-cand-1", "candidate"),
+		switchoverPod("xs-cand-2", "candidate"),
+		switchoverPod("xs-log-0", "voter"),
+	}
+	rows := []map[string]interface{}{
+		switchoverRow("xs-cand-0", "leader", "5000", "5000"),
+		switchoverRow("xs-cand-1", "follower", "4900", "4900"),
+		switchoverRow("xs-cand-2", "follower", "4990", "4990"),
+		switchoverRow("xs-log-0", "logger", "0", "5000"),
+	}
+
+	if target, err := pickSwitchoverTarget(pods, rows, ""); err != nil || target != "xs-cand-2" {
+		t.Fatalf("expect the follower caught up most, got %s, %v", target, err)
+	}
+	if target, err := pickSwitchoverTarget(pods, rows, "xs-cand-1"); err != nil || target != "xs-cand-1" {
+		t.Fatalf("expect the requested, got %s, %v", target, err)
+	}
+	for _, requested := range []string{"xs-cand-0", "xs-log-0", "xs-cand-3"} {
+		if _, err := pickSwitchoverTarget(pods, rows, requested); err == nil {
+			t.Fatalf("expect %s rejected", requested)
+		}
+	}
+
+	pods[2].Status.ContainerStatuses[0].Ready = false
+	if target, err := pickSwitchoverTarget(pods, rows, ""); err != nil || target != "xs-cand-1" {
+		t.Fatalf("expect the ready follower, got %s, %v", target, err)
+	}
+	if _, err := pickSwitchoverTarget(pods, rows[:1], ""); err == nil {
+		t.Fatalf("expect no follower found")
+	}
+}
+
+func TestSwitchoverLag(t *testing.T) {
+	rows := []map[string]interface{}{
+		switchoverRow("xs-cand-0", "leader", "4990", "5000"),
+		switchoverRow("xs-cand-1", "follower", "4900", "4950"),
+	}
+	if lag, err := switchoverLag(rows, "xs-cand-1"); err != nil || lag != 100 {
+		t.Fatalf("unexpected lag: %d, %v", lag, err)
+	}
+	if _, err := switchoverLag(rows, "xs-cand-2"); err == nil {
+		t.Fatalf("expect target not found")
+	}
+	if _, err := switchoverLag(rows[1:], "xs-cand-1"); err == nil {
+		t.Fatalf("expect leader not found")
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/command"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/convention"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/factory"
+	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+)
+
+// podWithConflictingTDE returns the pod created with another TDE config enabled, nil if there's none.
+// The data of such pod is encrypted with another keyring, which can't be read after rebuilt.
+func podWithConflictingTDE(pods []corev1.Pod, hash string) *corev1.Pod {
+	for i := range pods {
+		podHash := pods[i].Annotations[xstoremeta.AnnotationTDEHash]
+		if len(podHash) > 0 && podHash != hash {
+			return &pods[i]
+		}
+	}
+	return nil
+}
+
+// RollPodsForTDE rebuilds the pods which are created with a different TDE config one at a time.
+// Changes of the master key version don't need the rebuild. Only the pods without TDE are rebuilt,
+// neither disabling TDE nor changing the master key source is applied, see ValidateTDETransition.
+var RollPodsForTDE = xstorev1reconcile.NewStepBinder("RollPodsForTDE",
+	func(rc *xstorev1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		xstore := rc.MustGetXStore()
+		hash := factory.NewTDEHash(xstore)
+
+		pods, err := rc.GetXStorePods()
+		if err != nil {
+			return flow.Error(err, "Unable to get pods.")
+		}
+		if pod := podWithConflictingTDE(pods, hash); pod != nil {
+			return flow.Continue("TDE changed from the one enabled on pod, skip rolling.", "pod", pod.Name)
+		}
+
+		return rollPods(rc, flow, "TDE", func(pod *corev1.Pod) bool {
+			return pod.Annotations[xstoremeta.AnnotationTDEHash] == hash
+		})
+	})
+
+// RotateTDEMasterKey rotates the master key on the leader when the master key version is increased.
+// The rotation is replicated to the followers and learners by the binlog.
+var RotateTDEMasterKey = xstorev1reconcile.NewStepBinder("RotateTDEMasterKey",
+	func(rc *xstorev1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		xstore := rc.MustGetXStore()
+		if xstore.Spec.TDE == nil {
+			xstore.Status.TDEMasterKeyVersion = 0
+			return flow.Pass()
+		}
+		version := xstore.Spec.TDE.MasterKeyVersion
+		if xstore.Status.TDEMasterKeyVersion >= version {
+			return flow.Pass()
+		}
+
+		leaderPod, err := rc.TryGetXStoreLeaderPod()
+		if err != nil {
+			return flow.Error(err, "Unable to get leader pod.")
+		}
+		if leaderPod == nil {
+			return flow.Continue("Leader not found, skip rotating master key.")
+		}
+		if leaderPod.Annotations[xstoremeta.AnnotationTDEHash] != factory.NewTDEHash(xstore) {
+			return flow.Continue("Leader not rolled for TDE yet, skip rotating master key.")
+		}
+
+		err = rc.ExecuteCommandOn(leaderPod, convention.ContainerEngine,
+			command.NewCanonicalCommandBuilder().Engine().RotateMasterKey().Build(),
+			control.ExecOptions{
+				Logger:  flow.Logger(),
+				Timeout: 30 * time.Second,
+			},
+		)
+		if err != nil {
+			return flow.Error(err, "Unable to rotate master key.", "pod", leaderPod.Name)
+		}
+
+		xstore.Status.TDEMasterKeyVersion = version
+		return flow.Continue("Master key rotated.", "version", version)
+	})
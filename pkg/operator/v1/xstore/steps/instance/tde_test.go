@@ -0,0 +1,125 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/factory"
+	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
+)
+
+func TestRollPodsForTDE(t *testing.T) {
+	xstore := &polardbxv1.XStore{
+		Spec: polardbxv1.XStoreSpec{
+			TDE: &polardbxv1xstore.TDE{SecretName: "tde"},
+		},
+	}
+	hash := factory.NewTDEHash(xstore)
+
+	// Master key version doesn't require rebuilding the pods.
+	xstore.Spec.TDE.MasterKeyVersion = 1
+	if factory.NewTDEHash(xstore) != hash {
+		t.Fatal("expect hash not changed by master key version")
+	}
+
+	pod := func(name, role, tdeHash string) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Labels:      map[string]string{xstoremeta.LabelRole: role},
+				Annotations: map[string]string{xstoremeta.AnnotationTDEHash: tdeHash},
+			},
+		}
+	}
+	upToDate := func(pod *corev1.Pod) bool {
+		return pod.Annotations[xstoremeta.AnnotationTDEHash] == hash
+	}
+
+	testcases := map[string]struct {
+		pods   []corev1.Pod
+		expect string
+	}{
+		"all-up-to-date": {
+			pods: []corev1.Pod{
+				pod("p0", xstoremeta.RoleLeader, hash),
+				pod("p1", xstoremeta.RoleFollower, hash),
+			},
+			expect: "",
+		},
+		"follower-first": {
+			pods: []corev1.Pod{
+				pod("p0", xstoremeta.RoleLeader, ""),
+				pod("p1", xstoremeta.RoleFollower, ""),
+			},
+			expect: "p1",
+		},
+		"leader-last": {
+			pods: []corev1.Pod{
+				pod("p0", xstoremeta.RoleLeader, ""),
+				pod("p1", xstoremeta.RoleFollower, hash),
+			},
+			expect: "p0",
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			picked := pickPodToRoll(tc.pods, upToDate)
+			got := ""
+			if picked != nil {
+				got = picked.Name
+			}
+			if got != tc.expect {
+				t.Fatalf("expect %q, but got %q", tc.expect, got)
+			}
+		})
+	}
+}
+
+func TestPodWithConflictingTDE(t *testing.T) {
+	hash := factory.NewTDEHash(&polardbxv1.XStore{
+		Spec: polardbxv1.XStoreSpec{
+			TDE: &polardbxv1xstore.TDE{SecretName: "tde"},
+		},
+	})
+	pod := func(name, tdeHash string) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Annotations: map[string]string{xstoremeta.AnnotationTDEHash: tdeHash},
+			},
+		}
+	}
+
+	// Enabling TDE rolls the pods without TDE.
+	if p := podWithConflictingTDE([]corev1.Pod{pod("p0", ""), pod("p1", hash)}, hash); p != nil {
+		t.Fatalf("expect no conflict when enabling, got %s", p.Name)
+	}
+	// Disabling TDE or changing the master key source is never rolled.
+	if p := podWithConflictingTDE([]corev1.Pod{pod("p0", hash)}, ""); p == nil || p.Name != "p0" {
+		t.Fatal("expect conflict when disabling")
+	}
+	if p := podWithConflictingTDE([]corev1.Pod{pod("p0", hash)}, "another"); p == nil || p.Name != "p0" {
+		t.Fatal("expect conflict when master key source changed")
+	}
+}
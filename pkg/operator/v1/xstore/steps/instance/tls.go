@@ -0,0 +1,152 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
+	"github.com/alibaba/polardbx-operator/pkg/meta/core/gms/security"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/command"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/convention"
+	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+)
+
+// isPodTLSUpToDate returns true if the pod mounts the TLS secret as spec, or neither.
+func isPodTLSUpToDate(xstore *polardbxv1.XStore, pod *corev1.Pod) bool {
+	secretName := ""
+	for _, vol := range pod.Spec.Volumes {
+		if vol.Name == "tls" && vol.Secret != nil {
+			secretName = vol.Secret.SecretName
+			break
+		}
+	}
+	if xstore.Spec.TLS == nil {
+		return len(secretName) == 0
+	}
+	return secretName == xstore.Spec.TLS.SecretName
+}
+
+// pickPodToRoll picks a pod not up to date, followers go before the leader.
+func pickPodToRoll(pods []corev1.Pod, upToDate func(pod *corev1.Pod) bool) *corev1.Pod {
+	var leader *corev1.Pod
+	for i := range pods {
+		pod := &pods[i]
+		if upToDate(pod) {
+			continue
+		}
+		if xstoremeta.IsRoleLeader(pod) {
+			leader = pod
+			continue
+		}
+		return pod
+	}
+	return leader
+}
+
+// rollPods deletes the pods not up to date one at a time, and they're recreated on the same hosts
+// by repairing. It only proceeds when all pods are ready, so that the majority is always kept.
+func rollPods(rc *xstorev1reconcile.Context, flow control.Flow, reason string, upToDate func(pod *corev1.Pod) bool) (reconcile.Result, error) {
+	pods, err := rc.GetXStorePods()
+	if err != nil {
+		return flow.Error(err, "Unable to get pods.")
+	}
+
+	pod := pickPodToRoll(pods, upToDate)
+	if pod == nil {
+		return flow.Pass()
+	}
+	for i := range pods {
+		if !k8shelper.IsPodReady(&pods[i]) {
+			return flow.Continue("Some pod isn't ready, skip rolling.", "pod", pods[i].Name, "reason", reason)
+		}
+	}
+
+	if err := rc.Client().Delete(rc.Context(), pod); err != nil {
+		return flow.Error(err, "Unable to delete pod.", "pod", pod.Name)
+	}
+	return flow.Retry("Pod deleted to be rebuilt.", "pod", pod.Name, "reason", reason)
+}
+
+// RollPodsForTLS rebuilds the pods which don't mount the TLS secret as spec one at a time.
+var RollPodsForTLS = xstorev1reconcile.NewStepBinder("RollPodsForTLS",
+	func(rc *xstorev1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		xstore := rc.MustGetXStore()
+		return rollPods(rc, flow, "TLS", func(pod *corev1.Pod) bool {
+			return isPodTLSUpToDate(xstore, pod)
+		})
+	})
+
+// ReloadTLSCertificates reloads the certificates online on all the pods when the secret is updated,
+// e.g. rotated before expiry. The mounted files are synced by kubelet with a delay, so it retries
+// until the certificates reloaded are the expected ones.
+var ReloadTLSCertificates = xstorev1reconcile.NewStepBinder("ReloadTLSCertificates",
+	func(rc *xstorev1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		xstore := rc.MustGetXStore()
+		if xstore.Spec.TLS == nil {
+			xstore.Status.TLSCertificateHash = ""
+			return flow.Pass()
+		}
+
+		var secret corev1.Secret
+		err := rc.Client().Get(rc.Context(), types.NamespacedName{
+			Namespace: rc.Namespace(),
+			Name:      xstore.Spec.TLS.SecretName,
+		}, &secret)
+		if err != nil {
+			return flow.Error(err, "Unable to get TLS secret.", "secret", xstore.Spec.TLS.SecretName)
+		}
+		hash, err := security.Sha1HashBytes(secret.Data[convention.SecretKeyTLSServerCrt])
+		if err != nil {
+			return flow.Error(err, "Unable to hash the certificate.")
+		}
+		if xstore.Status.TLSCertificateHash == hash {
+			return flow.Pass()
+		}
+
+		pods, err := rc.GetXStorePods()
+		if err != nil {
+			return flow.Error(err, "Unable to get pods.")
+		}
+		for i := range pods {
+			pod := &pods[i]
+			if !isPodTLSUpToDate(xstore, pod) {
+				return flow.Continue("Pods not rolled for TLS yet, skip reloading.")
+			}
+			err := rc.ExecuteCommandOn(pod, convention.ContainerEngine,
+				command.NewCanonicalCommandBuilder().Engine().ReloadTLS(hash).Build(),
+				control.ExecOptions{
+					Logger:  flow.Logger(),
+					Timeout: 10 * time.Second,
+				},
+			)
+			if err != nil {
+				return flow.Continue("Unable to reload certificates, might be not synced yet.",
+					"pod", pod.Name, "error", err.Error())
+			}
+		}
+
+		xstore.Status.TLSCertificateHash = hash
+		return flow.Continue("Certificates reloaded.", "hash", hash)
+	})
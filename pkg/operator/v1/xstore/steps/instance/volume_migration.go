@@ -0,0 +1,230 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"errors"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/k8s/control"
+	k8shelper "github.com/alibaba/polardbx-operator/pkg/k8s/helper"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/convention"
+	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
+	xstorev1reconcile "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/reconcile"
+)
+
+// isPodOnPersistentVolumeClaim returns true if the data volume of the pod is a persistent volume claim.
+func isPodOnPersistentVolumeClaim(pod *corev1.Pod) bool {
+	for _, vol := range pod.Spec.Volumes {
+		if vol.Name == "data" {
+			return vol.PersistentVolumeClaim != nil
+		}
+	}
+	return false
+}
+
+// newVolumeMigrationSize returns the size of the claims from the annotation, or the storage limit
+// of the template if not specified.
+func newVolumeMigrationSize(xstore *polardbxv1.XStore) (resource.Quantity, error) {
+	if val, ok := xstore.Annotations[xstoremeta.AnnotationVolumeMigrationSize]; ok {
+		return resource.ParseQuantity(val)
+	}
+	resources := xstore.Spec.Topology.Template.Spec.Resources
+	if resources != nil {
+		if storage, ok := resources.Limits[corev1.ResourceStorage]; ok && !storage.IsZero() {
+			return storage, nil
+		}
+	}
+	return resource.Quantity{}, errors.New("size of claims not specified")
+}
+
+// newPersistentVolumeClaim returns the claim of the pod. The claim is bound to the host where the
+// data is, by setting the selected node as the scheduler does for volumes of delayed binding.
+func newPersistentVolumeClaim(xstore *polardbxv1.XStore, podName, host string,
+	migration *polardbxv1xstore.VolumeMigrationStatus) *corev1.PersistentVolumeClaim {
+	var storageClassName *string
+	if len(migration.StorageClassName) > 0 {
+		storageClassName = &migration.StorageClassName
+	}
+	var annotations map[string]string
+	if len(host) > 0 {
+		annotations = map[string]string{
+			"volume.kubernetes.io/selected-node": host,
+		}
+	}
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      convention.NewPersistentVolumeClaimName(podName),
+			Namespace: xstore.Namespace,
+			Labels: k8shelper.PatchLabels(convention.ConstLabels(xstore), map[string]string{
+				xstoremeta.LabelPod: podName,
+			}),
+			Annotations: annotations,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			StorageClassName: storageClassName,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: migration.Size,
+				},
+			},
+		},
+	}
+}
+
+// isLeaderSwitchoverFailedDuringMigration returns true if the switchover of the leader requested
+// by the migration has failed.
+func isLeaderSwitchoverFailedDuringMigration(xstore *polardbxv1.XStore, leader string) bool {
+	switchover := xstore.Status.Switchover
+	migration := xstore.Status.VolumeMigration
+	return switchover != nil && switchover.Phase == polardbxv1xstore.SwitchoverFailed &&
+		switchover.From == leader && switchover.StartTime != nil && migration.StartTime != nil &&
+		!switchover.StartTime.Before(migration.StartTime)
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// MigrateVolumesToPersistentVolumeClaims migrates the data from host path volumes to persistent volume
+// claims when requested by annotation. The pods are rebuilt one at a time on the same hosts and copy
+// the data to the claims before the engines start. Followers go first, and the leader is switched over
+// before being rebuilt, so the majority is always kept. The host paths are removed in the end.
+var MigrateVolumesToPersistentVolumeClaims = xstorev1reconcile.NewStepBinder("MigrateVolumesToPersistentVolumeClaims",
+	func(rc *xstorev1reconcile.Context, flow control.Flow) (reconcile.Result, error) {
+		xstore := rc.MustGetXStore()
+		storageClassName, requested := xstore.Annotations[xstoremeta.AnnotationVolumeMigrationStorageClass]
+		if !requested {
+			return flow.Pass()
+		}
+
+		migration := xstore.Status.VolumeMigration
+		if migration == nil || migration.Phase == polardbxv1xstore.VolumeMigrationCompleted {
+			size, err := newVolumeMigrationSize(xstore)
+			if err != nil {
+				return flow.Error(err, "Unable to determine the size of claims.")
+			}
+			now := metav1.Now()
+			xstore.Status.VolumeMigration = &polardbxv1xstore.VolumeMigrationStatus{
+				StorageClassName: storageClassName,
+				Size:             size,
+				Phase:            polardbxv1xstore.VolumeMigrationRunning,
+				StartTime:        &now,
+			}
+			return flow.Continue("Volume migration started.", "storage-class", storageClassName, "size", size.String())
+		}
+
+		pods, err := rc.GetXStorePods()
+		if err != nil {
+			return flow.Error(err, "Unable to get pods.")
+		}
+
+		// Record the pods migrated, and wait until all pods are ready before going on.
+		for i := range pods {
+			pod := &pods[i]
+			if !k8shelper.IsPodReady(pod) {
+				return flow.Continue("Some pod isn't ready, skip migrating volumes.", "pod", pod.Name)
+			}
+			if isPodOnPersistentVolumeClaim(pod) && !containsString(migration.MigratedPods, pod.Name) {
+				migration.MigratedPods = append(migration.MigratedPods, pod.Name)
+				if migration.CurrentPod == pod.Name {
+					migration.CurrentPod = ""
+				}
+			}
+		}
+
+		pod := pickPodToRoll(pods, isPodOnPersistentVolumeClaim)
+
+		// All pods are on claims, remove the host paths and complete.
+		if pod == nil {
+			hpfsClient, err := rc.GetHpfsClient()
+			if err != nil {
+				return flow.Error(err, "Unable to get hpfs client.")
+			}
+			for podName, vol := range xstore.Status.BoundVolumes {
+				if len(vol.PersistentVolumeClaim) == 0 || (len(vol.HostPath) == 0 && len(vol.LogHostPath) == 0) {
+					continue
+				}
+				if err := DeleteHostPathVolume(rc.Context(), hpfsClient, vol); err != nil {
+					return flow.Error(err, "Unable to remove host path volume.", "vol.pod", podName,
+						"vol.host", vol.Host, "vol.path", vol.HostPath, "vol.LogHostPath", vol.LogHostPath)
+				}
+				vol.HostPath, vol.LogHostPath = "", ""
+			}
+
+			now := metav1.Now()
+			migration.Phase = polardbxv1xstore.VolumeMigrationCompleted
+			migration.CurrentPod = ""
+			migration.Message = ""
+			migration.CompletionTime = &now
+
+			delete(xstore.Annotations, xstoremeta.AnnotationVolumeMigrationStorageClass)
+			delete(xstore.Annotations, xstoremeta.AnnotationVolumeMigrationSize)
+			rc.MarkXStoreChanged()
+
+			return flow.Continue("Volume migration completed.")
+		}
+
+		// Transfer the leadership before rebuilding the leader, if there are other voting members.
+		topology := xstore.Status.ObservedTopology
+		if topology == nil {
+			topology = &xstore.Spec.Topology
+		}
+		if xstoremeta.IsRoleLeader(pod) && countVotingMembers(topology) > 1 {
+			if isLeaderSwitchoverFailedDuringMigration(xstore, pod.Name) {
+				migration.Message = "Switchover of the leader failed: " + xstore.Status.Switchover.Message
+				return flow.Continue("Switchover of the leader failed, volume migration blocked.", "pod", pod.Name)
+			}
+			xstore.Annotations[xstoremeta.AnnotationSwitchover] = ""
+			rc.MarkXStoreChanged()
+			migration.Message = ""
+			return flow.Retry("Switchover requested before migrating the leader.", "pod", pod.Name)
+		}
+
+		vol := xstore.Status.BoundVolumes[pod.Name]
+		if vol == nil {
+			return flow.Error(errors.New("volume not found"), "Unable to migrate volume.", "pod", pod.Name)
+		}
+		if len(vol.PersistentVolumeClaim) == 0 {
+			pvc := newPersistentVolumeClaim(xstore, pod.Name, vol.Host, migration)
+			if err := rc.SetControllerRefAndCreate(pvc); err != nil && !apierrors.IsAlreadyExists(err) {
+				return flow.Error(err, "Unable to create persistent volume claim.", "pod", pod.Name)
+			}
+			vol.PersistentVolumeClaim = pvc.Name
+		}
+
+		// The pod is rebuilt by repairing and copies the data to the claim on start.
+		if err := rc.Client().Delete(rc.Context(), pod); err != nil {
+			return flow.Error(err, "Unable to delete pod.", "pod", pod.Name)
+		}
+		migration.CurrentPod = pod.Name
+		migration.Message = ""
+		return flow.Retry("Pod deleted to migrate volume.", "pod", pod.Name, "claim", vol.PersistentVolumeClaim)
+	})
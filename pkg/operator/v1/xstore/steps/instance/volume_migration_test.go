@@ -0,0 +1,115 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/api/v1/common"
+	xstoremeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/xstore/meta"
+)
+
+func TestNewVolumeMigrationSize(t *testing.T) {
+	xstore := &polardbxv1.XStore{}
+	if _, err := newVolumeMigrationSize(xstore); err == nil {
+		t.Fatal("expect error when size not specified")
+	}
+
+	xstore.Spec.Topology.Template.Spec.Resources = &common.ExtendedResourceRequirements{
+		ResourceRequirements: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{
+				corev1.ResourceStorage: resource.MustParse("100Gi"),
+			},
+		},
+	}
+	size, err := newVolumeMigrationSize(xstore)
+	if err != nil || size.Cmp(resource.MustParse("100Gi")) != 0 {
+		t.Fatalf("expect size from storage limit, but is %s, %v", size.String(), err)
+	}
+
+	xstore.Annotations = map[string]string{xstoremeta.AnnotationVolumeMigrationSize: "200Gi"}
+	size, err = newVolumeMigrationSize(xstore)
+	if err != nil || size.Cmp(resource.MustParse("200Gi")) != 0 {
+		t.Fatalf("expect size from annotation, but is %s, %v", size.String(), err)
+	}
+}
+
+func TestPickPodToMigrateVolume(t *testing.T) {
+	pod := func(name, role string, onClaim bool) corev1.Pod {
+		vol := corev1.Volume{Name: "data"}
+		if onClaim {
+			vol.PersistentVolumeClaim = &corev1.PersistentVolumeClaimVolumeSource{ClaimName: name + "-data"}
+		} else {
+			vol.HostPath = &corev1.HostPathVolumeSource{Path: "/data/" + name}
+		}
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{xstoremeta.LabelRole: role},
+			},
+			Spec: corev1.PodSpec{Volumes: []corev1.Volume{vol}},
+		}
+	}
+
+	testcases := map[string]struct {
+		pods   []corev1.Pod
+		expect string
+	}{
+		"followers-first": {
+			pods: []corev1.Pod{
+				pod("cand-0", xstoremeta.RoleLeader, false),
+				pod("cand-1", xstoremeta.RoleFollower, false),
+				pod("log-0", xstoremeta.RoleLogger, false),
+			},
+			expect: "cand-1",
+		},
+		"leader-last": {
+			pods: []corev1.Pod{
+				pod("cand-0", xstoremeta.RoleLeader, false),
+				pod("cand-1", xstoremeta.RoleFollower, true),
+				pod("log-0", xstoremeta.RoleLogger, true),
+			},
+			expect: "cand-0",
+		},
+		"all-migrated": {
+			pods: []corev1.Pod{
+				pod("cand-0", xstoremeta.RoleFollower, true),
+				pod("cand-1", xstoremeta.RoleLeader, true),
+				pod("log-0", xstoremeta.RoleLogger, true),
+			},
+			expect: "",
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			picked := pickPodToRoll(tc.pods, isPodOnPersistentVolumeClaim)
+			actual := ""
+			if picked != nil {
+				actual = picked.Name
+			}
+			if actual != tc.expect {
+				t.Fatalf("expect %s, but picked %s", tc.expect, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	testcases := map[string]struct {
+		spec   string
+		from   string
+		expect string
+	}{
+		"every-minute":       {spec: "* * * * *", from: "2022-03-01T10:00:30Z", expect: "2022-03-01T10:01:00Z"},
+		"daily":              {spec: "@daily", from: "2022-03-01T10:00:00Z", expect: "2022-03-02T00:00:00Z"},
+		"hourly-on-boundary": {spec: "@hourly", from: "2022-03-01T10:00:00Z", expect: "2022-03-01T11:00:00Z"},
+		"step":               {spec: "*/15 * * * *", from: "2022-03-01T10:16:00Z", expect: "2022-03-01T10:30:00Z"},
+		"start-step":         {spec: "5/20 * * * *", from: "2022-03-01T10:46:00Z", expect: "2022-03-01T11:05:00Z"},
+		"range-list":         {spec: "0 1-3,22 * * *", from: "2022-03-01T03:30:00Z", expect: "2022-03-01T22:00:00Z"},
+		"month-wrap":         {spec: "0 2 1 jan *", from: "2022-03-01T00:00:00Z", expect: "2023-01-01T02:00:00Z"},
+		"weekday-names":      {spec: "30 3 * * mon-fri", from: "2022-03-04T04:00:00Z", expect: "2022-03-07T03:30:00Z"},
+		"sunday-as-7":        {spec: "0 0 * * 7", from: "2022-03-01T00:00:00Z", expect: "2022-03-06T00:00:00Z"},
+		"dom-or-dow":         {spec: "0 0 15 * sat", from: "2022-03-01T00:00:00Z", expect: "2022-03-05T00:00:00Z"},
+		"leap-day":           {spec: "0 0 29 2 *", from: "2022-03-01T00:00:00Z", expect: "2024-02-29T00:00:00Z"},
+		"never":              {spec: "0 0 30 2 *", from: "2022-03-01T00:00:00Z", expect: ""},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			s, err := Parse(tc.spec)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			from, _ := time.Parse(time.RFC3339, tc.from)
+			next := s.Next(from)
+			if len(tc.expect) == 0 {
+				if !next.IsZero() {
+					t.Fatalf("expect none, got %s", next)
+				}
+				return
+			}
+			if got := next.Format(time.RFC3339); got != tc.expect {
+				t.Fatalf("expect %s, got %s", tc.expect, got)
+			}
+		})
+	}
+}
+
+func TestSchedule_NextInLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	s, err := Parse("0 2 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := s.Next(time.Date(2022, 3, 1, 17, 0, 0, 0, time.UTC).In(loc))
+	if expect := time.Date(2022, 3, 2, 2, 0, 0, 0, loc); !next.Equal(expect) {
+		t.Fatalf("expect %s, got %s", expect, next)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@often",
+	} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("expect error for %q", spec)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package crypt encrypts and decrypts streams with AES-256-GCM in segments, so that backup files of
+// any size can be encrypted and decrypted in pipes without buffering the whole file.
+//
+// The stream starts with a header of magic, segment size, salt and nonce prefix. Each stream is sealed
+// with a key derived from the key and the salt by HKDF-SHA256. The nonce of a segment is the nonce prefix,
+// the segment counter and a flag telling whether it's the last segment, so that reordered, dropped or
+// truncated segments are detected when decrypting.
+package crypt
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// KeySize is the size of AES-256 keys.
+	KeySize = 32
+
+	// DefaultSegmentSize is the size of plaintext sealed in each segment.
+	DefaultSegmentSize = 64 << 10
+
+	magic           = "PXBENC1\n"
+	saltSize        = 16
+	noncePrefixSize = 7
+	headerSize      = len(magic) + 4 + saltSize + noncePrefixSize
+	maxSegmentSize  = 16 << 20
+)
+
+var (
+	ErrNotEncrypted = errors.New("crypt: not an encrypted stream")
+	ErrTruncated    = errors.New("crypt: stream truncated")
+	ErrTooLarge     = errors.New("crypt: too many segments")
+)
+
+// ParseKey parses the key of the raw 32 bytes, or encoded in base64 or hex.
+func ParseKey(data []byte) ([]byte, error) {
+	if len(data) == KeySize {
+		return data, nil
+	}
+	s := bytes.TrimSpace(data)
+	if len(s) == 2*KeySize {
+		if key, err := hex.DecodeString(string(s)); err == nil {
+			return key, nil
+		}
+	}
+	if key, err := base64.StdEncoding.DecodeString(string(s)); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	return nil, fmt.Errorf("crypt: key must be %d bytes, raw or encoded in base64 or hex", KeySize)
+}
+
+// LoadKeyFile reads and parses the key in the file.
+func LoadKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseKey(data)
+}
+
+func newAEAD(key, header []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("crypt: invalid key size %d", len(key))
+	}
+	salt := header[len(magic)+4 : len(magic)+4+saltSize]
+	streamKey := make([]byte, KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, key, salt, header[:len(magic)+4]), streamKey); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(streamKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+type segmentNonce struct {
+	nonce   []byte
+	counter uint32
+}
+
+func newSegmentNonce(prefix []byte) *segmentNonce {
+	nonce := make([]byte, noncePrefixSize+5)
+	copy(nonce, prefix)
+	return &segmentNonce{nonce: nonce}
+}
+
+func (n *segmentNonce) next(last bool) ([]byte, error) {
+	if n.counter == ^uint32(0) {
+		return nil, ErrTooLarge
+	}
+	binary.BigEndian.PutUint32(n.nonce[noncePrefixSize:], n.counter)
+	n.nonce[noncePrefixSize+4] = 0
+	if last {
+		n.nonce[noncePrefixSize+4] = 1
+	}
+	n.counter++
+	return n.nonce, nil
+}
+
+// Writer encrypts the plaintext written into the underlying writer. It must be closed to seal the last
+// segment, otherwise the stream is considered truncated.
+type Writer struct {
+	w     io.Writer
+	aead  cipher.AEAD
+	nonce *segmentNonce
+	buf   []byte
+	out   []byte
+	err   error
+}
+
+// NewWriter writes the header into w and returns the writer encrypting with the key.
+func NewWriter(w io.Writer, key []byte) (*Writer, error) {
+	return newWriter(w, key, DefaultSegmentSize)
+}
+
+func newWriter(w io.Writer, key []byte, segmentSize int) (*Writer, error) {
+	header := make([]byte, headerSize)
+	copy(header, magic)
+	binary.BigEndian.PutUint32(header[len(magic):], uint32(segmentSize))
+	if _, err := rand.Read(header[len(magic)+4:]); err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key, header)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &Writer{
+		w:     w,
+		aead:  aead,
+		nonce: newSegmentNonce(header[headerSize-noncePrefixSize:]),
+		buf:   make([]byte, 0, segmentSize),
+		out:   make([]byte, 0, segmentSize+aead.Overhead()),
+	}, nil
+}
+
+func (w *Writer) seal(last bool) error {
+	nonce, err := w.nonce.next(last)
+	if err != nil {
+		return err
+	}
+	w.out = w.aead.Seal(w.out[:0], nonce, w.buf, nil)
+	w.buf = w.buf[:0]
+	_, err = w.w.Write(w.out)
+	return err
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n := 0
+	for len(p) > 0 {
+		// Seal only when there's more, so that the last segment is always sealed on close.
+		if len(w.buf) == cap(w.buf) {
+			if w.err = w.seal(false); w.err != nil {
+				return n, w.err
+			}
+		}
+		c := copy(w.buf[len(w.buf):cap(w.buf)], p)
+		w.buf = w.buf[:len(w.buf)+c]
+		p = p[c:]
+		n += c
+	}
+	return n, nil
+}
+
+// Close seals the last segment. It doesn't close the underlying writer.
+func (w *Writer) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	w.err = w.seal(true)
+	if w.err != nil {
+		return w.err
+	}
+	w.err = errors.New("crypt: write to closed writer")
+	return nil
+}
+
+// Reader decrypts the stream read from the underlying reader. Any tampering or truncation of the stream
+// is reported as an error, the plaintext read before which must not be trusted.
+type Reader struct {
+	r     *bufio.Reader
+	key   []byte
+	aead  cipher.AEAD
+	nonce *segmentNonce
+	seg   []byte
+	buf   []byte
+	eof   bool
+}
+
+// NewReader returns the reader decrypting r with the key. The header is read on the first read.
+func NewReader(r io.Reader, key []byte) *Reader {
+	return &Reader{r: bufio.NewReader(r), key: key}
+}
+
+func (r *Reader) readHeader() error {
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(r.r, header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return ErrNotEncrypted
+		}
+		return err
+	}
+	if string(header[:len(magic)]) != magic {
+		return ErrNotEncrypted
+	}
+	segmentSize := int(binary.BigEndian.Uint32(header[len(magic):]))
+	if segmentSize <= 0 || segmentSize > maxSegmentSize {
+		return fmt.Errorf("crypt: invalid segment size %d", segmentSize)
+	}
+	aead, err := newAEAD(r.key, header)
+	if err != nil {
+		return err
+	}
+	r.aead = aead
+	r.nonce = newSegmentNonce(header[headerSize-noncePrefixSize:])
+	r.seg = make([]byte, segmentSize+aead.Overhead())
+	r.r = bufio.NewReaderSize(r.r, segmentSize+aead.Overhead()+1)
+	return nil
+}
+
+func (r *Reader) open() error {
+	n, err := io.ReadFull(r.r, r.seg)
+	if err == io.EOF {
+		return ErrTruncated
+	}
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	// The segment is the last one if nothing follows.
+	last := err == io.ErrUnexpectedEOF
+	if !last {
+		if _, err := r.r.Peek(1); err == io.EOF {
+			last = true
+		} else if err != nil {
+			return err
+		}
+	}
+	nonce, err := r.nonce.next(last)
+	if err != nil {
+		return err
+	}
+	r.buf, err = r.aead.Open(r.seg[:0], nonce, r.seg[:n], nil)
+	if err != nil {
+		if last {
+			return fmt.Errorf("crypt: failed to decrypt, wrong key or stream truncated or tampered: %w", err)
+		}
+		return fmt.Errorf("crypt: failed to decrypt, wrong key or stream tampered: %w", err)
+	}
+	r.eof = last
+	return nil
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	if r.aead == nil {
+		if err := r.readHeader(); err != nil {
+			return 0, err
+		}
+	}
+	for len(r.buf) == 0 {
+		if r.eof {
+			return 0, io.EOF
+		}
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crypt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func encrypt(t *testing.T, key, data []byte, segmentSize, writeSize int) []byte {
+	buf := &bytes.Buffer{}
+	w, err := newWriter(buf, key, segmentSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for len(data) > 0 {
+		n := writeSize
+		if n > len(data) {
+			n = len(data)
+		}
+		if _, err := w.Write(data[:n]); err != nil {
+			t.Fatal(err)
+		}
+		data = data[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func decrypt(key, data []byte) ([]byte, error) {
+	return io.ReadAll(NewReader(bytes.NewReader(data), key))
+}
+
+func TestCrypt_RoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, KeySize)
+	for _, size := range []int{0, 1, 1024, 4096, 4097, 100000} {
+		data := make([]byte, size)
+		rand.New(rand.NewSource(int64(size))).Read(data)
+
+		encrypted := encrypt(t, key, data, 1024, 333)
+		if size > 16 && bytes.Contains(encrypted, data[:16]) {
+			t.Fatalf("size %d: plaintext found in encrypted stream", size)
+		}
+		decrypted, err := decrypt(key, encrypted)
+		if err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+		if !bytes.Equal(decrypted, data) {
+			t.Fatalf("size %d: decrypted not equal", size)
+		}
+	}
+}
+
+func TestCrypt_Tampered(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, KeySize)
+	data := make([]byte, 4096)
+	rand.New(rand.NewSource(1)).Read(data)
+	encrypted := encrypt(t, key, data, 1024, 4096)
+
+	if _, err := decrypt(bytes.Repeat([]byte{8}, KeySize), encrypted); err == nil {
+		t.Fatal("expect error with wrong key")
+	}
+
+	flipped := append([]byte(nil), encrypted...)
+	flipped[len(flipped)/2] ^= 0xff
+	if _, err := decrypt(key, flipped); err == nil {
+		t.Fatal("expect error with tampered stream")
+	}
+
+	// Truncated at the segment boundary.
+	segment := 1024 + 16
+	if _, err := decrypt(key, encrypted[:headerSize+2*segment]); err == nil {
+		t.Fatal("expect error with stream truncated at segment boundary")
+	}
+	if _, err := decrypt(key, encrypted[:headerSize]); err != ErrTruncated {
+		t.Fatalf("expect truncated, got %v", err)
+	}
+	if _, err := decrypt(key, data); err != ErrNotEncrypted {
+		t.Fatalf("expect not encrypted, got %v", err)
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	key := make([]byte, KeySize)
+	rand.New(rand.NewSource(1)).Read(key)
+
+	for _, data := range [][]byte{
+		key,
+		[]byte(hex.EncodeToString(key) + "\n"),
+		[]byte(base64.StdEncoding.EncodeToString(key) + "\n"),
+	} {
+		parsed, err := ParseKey(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(parsed, key) {
+			t.Fatalf("expect key parsed from %q", data)
+		}
+	}
+
+	if _, err := ParseKey([]byte("too short")); err == nil {
+		t.Fatal("expect error with invalid key")
+	}
+}
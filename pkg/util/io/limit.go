@@ -0,0 +1,90 @@
+/*
+Copyright 2021 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package io
+
+import (
+	"context"
+	"io"
+
+	"golang.org/x/time/rate"
+)
+
+type rateLimitedWriter struct {
+	writer  io.Writer
+	limiter *rate.Limiter
+}
+
+// NewRateLimitedWriter wraps the writer to write at most bytesPerSecond bytes per second.
+// The writer is returned as is if bytesPerSecond is not positive.
+func NewRateLimitedWriter(writer io.Writer, bytesPerSecond int) io.Writer {
+	if bytesPerSecond <= 0 {
+		return writer
+	}
+	return &rateLimitedWriter{
+		writer:  writer,
+		limiter: rate.NewLimiter(rate.Limit(bytesPerSecond), bytesPerSecond),
+	}
+}
+
+func (w *rateLimitedWriter) Write(p []byte) (int, error) {
+	written := 0
+	for written < len(p) {
+		n := len(p) - written
+		if n > w.limiter.Burst() {
+			n = w.limiter.Burst()
+		}
+		if err := w.limiter.WaitN(context.Background(), n); err != nil {
+			return written, err
+		}
+		m, err := w.writer.Write(p[written : written+n])
+		written += m
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+type rateLimitedReader struct {
+	reader  io.Reader
+	limiter *rate.Limiter
+}
+
+// NewRateLimitedReader wraps the reader to read at most bytesPerSecond bytes per second.
+// The reader is returned as is if bytesPerSecond is not positive.
+func NewRateLimitedReader(reader io.Reader, bytesPerSecond int) io.Reader {
+	if bytesPerSecond <= 0 {
+		return reader
+	}
+	return &rateLimitedReader{
+		reader:  reader,
+		limiter: rate.NewLimiter(rate.Limit(bytesPerSecond), bytesPerSecond),
+	}
+}
+
+func (r *rateLimitedReader) Read(p []byte) (int, error) {
+	if len(p) > r.limiter.Burst() {
+		p = p[:r.limiter.Burst()]
+	}
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		if werr := r.limiter.WaitN(context.Background(), n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
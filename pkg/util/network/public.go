@@ -0,0 +1,67 @@
+/*
+Copyright 2021 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"syscall"
+)
+
+// sharedAddressSpace is the range of carrier-grade NAT (RFC 6598), which is private as well.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// IsPublicIP returns true if the ip is a global unicast address out of the private ranges, i.e.
+// not one of the loopback, link-local (e.g. the metadata service of clouds) or cluster networks.
+func IsPublicIP(ip net.IP) bool {
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !sharedAddressSpace.Contains(ip)
+}
+
+// ValidatePublicURL checks that the url is an absolute http(s) one, and the host is public if it's
+// an ip. Host names are resolved at connecting and must be checked by PublicOnlyControl then.
+func ValidatePublicURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("scheme must be http or https, got %q", u.Scheme)
+	}
+	host := u.Hostname()
+	if len(host) == 0 {
+		return errors.New("host is missing")
+	}
+	if ip := net.ParseIP(host); ip != nil && !IsPublicIP(ip) {
+		return fmt.Errorf("address %s is not public", host)
+	}
+	return nil
+}
+
+// PublicOnlyControl is a control function of net.Dialer refusing to connect to addresses which
+// are not public, checked after the host is resolved so that it can't be bypassed by DNS.
+func PublicOnlyControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !IsPublicIP(ip) {
+		return fmt.Errorf("address %s is not public", host)
+	}
+	return nil
+}
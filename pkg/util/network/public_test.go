@@ -0,0 +1,50 @@
+/*
+Copyright 2021 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import "testing"
+
+func TestValidatePublicURL(t *testing.T) {
+	testcases := map[string]bool{
+		"https://hooks.slack.com/services/x": true,
+		"http://8.8.8.8/notify":              true,
+		"ftp://example.com/":                 false,
+		"https:///path":                      false,
+		"http://127.0.0.1:8080/":             false,
+		"http://169.254.169.254/latest/":     false,
+		"http://10.0.0.1/":                   false,
+		"http://100.100.100.200/":            false,
+		"http://[::1]/":                      false,
+		"http://[fd00::1]/":                  false,
+	}
+	for u, valid := range testcases {
+		t.Run(u, func(t *testing.T) {
+			if err := ValidatePublicURL(u); (err == nil) != valid {
+				t.Fatalf("expect valid %v, but got error %v", valid, err)
+			}
+		})
+	}
+}
+
+func TestPublicOnlyControl(t *testing.T) {
+	if err := PublicOnlyControl("tcp", "8.8.8.8:443", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := PublicOnlyControl("tcp", "192.168.1.1:443", nil); err == nil {
+		t.Fatal("expect error on private address")
+	}
+}
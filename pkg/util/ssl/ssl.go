@@ -25,6 +25,7 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
+	"errors"
 	"math/big"
 	"time"
 )
@@ -72,7 +73,27 @@ func MarshalKey(priv interface{}) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+func ParseCert(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("no certificate found")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func ParseKey(data []byte) (interface{}, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no private key found")
+	}
+	return x509.ParsePKCS8PrivateKey(block.Bytes)
+}
+
 func GenerateCA(rsaBits int, domain string) (*x509.Certificate, *rsa.PrivateKey, []byte, error) {
+	return GenerateCAWithValidity(rsaBits, domain, time.Now().AddDate(10, 0, 0))
+}
+
+func GenerateCAWithValidity(rsaBits int, domain string, notAfter time.Time) (*x509.Certificate, *rsa.PrivateKey, []byte, error) {
 	serialNumberLimit := new(big.Int).Lsh(big.NewInt(1), 128)
 	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
 	if err != nil {
@@ -86,7 +107,7 @@ func GenerateCA(rsaBits int, domain string) (*x509.Certificate, *rsa.PrivateKey,
 			CommonName:   domain,
 		},
 		NotBefore: time.Now(),
-		NotAfter:  time.Now().AddDate(10, 0, 0),
+		NotAfter:  notAfter,
 		IsCA:      true,
 		ExtKeyUsage: []x509.ExtKeyUsage{
 			x509.ExtKeyUsageClientAuth,
@@ -110,6 +131,10 @@ func GenerateCA(rsaBits int, domain string) (*x509.Certificate, *rsa.PrivateKey,
 }
 
 func GenerateSelfSignedCert(caCert *x509.Certificate, caPriv interface{}, rsaBits int, domain string) (*x509.Certificate, *rsa.PrivateKey, []byte, error) {
+	return GenerateCertWithValidity(caCert, caPriv, rsaBits, domain, nil, time.Now().AddDate(10, 0, 0))
+}
+
+func GenerateCertWithValidity(caCert *x509.Certificate, caPriv interface{}, rsaBits int, domain string, dnsNames []string, notAfter time.Time) (*x509.Certificate, *rsa.PrivateKey, []byte, error) {
 	serialNumberLimit := new(big.Int).Lsh(big.NewInt(1), 128)
 	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
 	if err != nil {
@@ -122,8 +147,9 @@ func GenerateSelfSignedCert(caCert *x509.Certificate, caPriv interface{}, rsaBit
 			Organization: []string{"PolarDB-X"},
 			CommonName:   domain,
 		},
+		DNSNames:  dnsNames,
 		NotBefore: time.Now(),
-		NotAfter:  time.Now().AddDate(10, 0, 0),
+		NotAfter:  notAfter,
 		ExtKeyUsage: []x509.ExtKeyUsage{
 			x509.ExtKeyUsageClientAuth,
 			x509.ExtKeyUsageServerAuth,
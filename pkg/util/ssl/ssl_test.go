@@ -21,6 +21,7 @@ import (
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 )
 
 var domain = "localhost"
@@ -50,3 +51,42 @@ func TestGenerateSelfCertificatedCertificates(t *testing.T) {
 	fmt.Println(string(pemBytes))
 	_ = ioutil.WriteFile("/tmp/test-certs/server.crt", pemBytes, 0644)
 }
+
+func TestGenerateCertWithValidity(t *testing.T) {
+	notAfter := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	caCert, caPriv, caPem, err := GenerateCAWithValidity(2048, "", notAfter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caPemBytes, _ := MarshalCert(caPem)
+	caKeyBytes, _ := MarshalKey(caPriv)
+
+	// Sign with the CA parsed back, like rotating with the CA kept in secret.
+	parsedCA, err := ParseCert(caPemBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsedCA.NotAfter.Equal(caCert.NotAfter) {
+		t.Fatalf("expect CA not after %v, but got %v", caCert.NotAfter, parsedCA.NotAfter)
+	}
+	parsedKey, err := ParseKey(caKeyBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, pem, err := GenerateCertWithValidity(parsedCA, parsedKey, 2048, domain, []string{domain}, notAfter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemBytes, _ := MarshalCert(pem)
+	cert, err := ParseCert(pemBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cert.NotAfter.Equal(notAfter) {
+		t.Fatalf("expect not after %v, but got %v", notAfter, cert.NotAfter)
+	}
+	if err := cert.CheckSignatureFrom(parsedCA); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// JobSpan returns the span of the job as a child of parent, from the job started to finished.
+// Unfinished jobs end at now.
+func JobSpan(parent SpanContext, job *batchv1.Job, now time.Time) Span {
+	span := Span{
+		Name:    "Job/" + job.Name,
+		Context: parent.NewChild(),
+		Parent:  parent.SpanID,
+		Start:   job.CreationTimestamp.Time,
+		End:     now,
+		Attributes: map[string]string{
+			"job.name":      job.Name,
+			"job.namespace": job.Namespace,
+		},
+	}
+	if job.Status.StartTime != nil {
+		span.Start = job.Status.StartTime.Time
+	}
+	if job.Status.CompletionTime != nil {
+		span.End = job.Status.CompletionTime.Time
+	}
+	for _, cond := range job.Status.Conditions {
+		if cond.Type == batchv1.JobFailed && cond.Status == corev1.ConditionTrue {
+			if !cond.LastTransitionTime.IsZero() {
+				span.End = cond.LastTransitionTime.Time
+			}
+			span.Error = cond.Reason + ": " + cond.Message
+		}
+	}
+	return span
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	otlpQueueSize     = 1024
+	otlpBatchSize     = 128
+	otlpFlushInterval = 5 * time.Second
+	otlpTimeout       = 10 * time.Second
+)
+
+// OTLPExporter exports spans in batches to the OTLP/HTTP endpoint of collector with the OpenTelemetry
+// SDK. Spans are dropped if the queue is full or the export fails, tracing never slows down reconciliation.
+type OTLPExporter struct {
+	tracer trace.Tracer
+}
+
+// NewOTLPExporter creates an exporter to the endpoint, e.g. http://collector:4318/v1/traces, and
+// exports until the context is done.
+func NewOTLPExporter(ctx context.Context, endpoint, serviceName string, logger logr.Logger) (*OTLPExporter, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	opts := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(u.Host),
+		otlptracehttp.WithURLPath(u.Path),
+		otlptracehttp.WithTimeout(otlpTimeout),
+	}
+	switch u.Scheme {
+	case "http":
+		opts = append(opts, otlptracehttp.WithInsecure())
+	case "https":
+	default:
+		return nil, fmt.Errorf("unsupported scheme of endpoint: %s", endpoint)
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logger.Error(err, "Unable to export spans, drop.")
+	}))
+	processor := sdktrace.NewBatchSpanProcessor(exporter,
+		sdktrace.WithMaxQueueSize(otlpQueueSize),
+		sdktrace.WithMaxExportBatchSize(otlpBatchSize),
+		sdktrace.WithBatchTimeout(otlpFlushInterval),
+		sdktrace.WithExportTimeout(otlpTimeout),
+	)
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(processor),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceNameKey.String(serviceName))),
+		sdktrace.WithIDGenerator(recordedIDGenerator{}),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), otlpTimeout)
+		defer cancel()
+		_ = provider.Shutdown(shutdownCtx)
+	}()
+
+	return &OTLPExporter{
+		tracer: provider.Tracer(serviceName),
+	}, nil
+}
+
+// Export replays the span with the tracer. The spans are built from the times and ids recorded in
+// the objects, so they're started and ended at the recorded times with the recorded ids.
+func (e *OTLPExporter) Export(span Span) {
+	ctx := context.WithValue(context.Background(), recordedSpanKey{}, span.Context)
+	if span.Parent != (SpanID{}) {
+		ctx = trace.ContextWithRemoteSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    trace.TraceID(span.Context.TraceID),
+			SpanID:     trace.SpanID(span.Parent),
+			TraceFlags: trace.FlagsSampled,
+			Remote:     true,
+		}))
+	}
+	_, s := e.tracer.Start(ctx, span.Name,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithTimestamp(span.Start),
+		trace.WithAttributes(attributesOf(span.Attributes)...),
+	)
+	if len(span.Error) > 0 {
+		s.SetStatus(codes.Error, span.Error)
+	} else {
+		s.SetStatus(codes.Ok, "")
+	}
+	s.End(trace.WithTimestamp(span.End))
+}
+
+type recordedSpanKey struct{}
+
+// recordedIDGenerator gives the ids recorded in the context to the span being exported, rather than
+// generating new ones.
+type recordedIDGenerator struct{}
+
+func (recordedIDGenerator) recordedOf(ctx context.Context) SpanContext {
+	sc, _ := ctx.Value(recordedSpanKey{}).(SpanContext)
+	return sc
+}
+
+func (g recordedIDGenerator) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	sc := g.recordedOf(ctx)
+	return trace.TraceID(sc.TraceID), trace.SpanID(sc.SpanID)
+}
+
+func (g recordedIDGenerator) NewSpanID(ctx context.Context, _ trace.TraceID) trace.SpanID {
+	return trace.SpanID(g.recordedOf(ctx).SpanID)
+}
+
+func attributesOf(attrs map[string]string) []attribute.KeyValue {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	kvs := make([]attribute.KeyValue, 0, len(keys))
+	for _, k := range keys {
+		kvs = append(kvs, attribute.String(k, attrs[k]))
+	}
+	return kvs
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+type TraceID [16]byte
+
+type SpanID [8]byte
+
+// SpanContext identifies a span, it's propagated across reconciliations in w3c trace context format.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+}
+
+func randomBytes(b []byte) {
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+}
+
+// NewSpanContext returns the context of a root span of a new trace.
+func NewSpanContext() SpanContext {
+	sc := SpanContext{}
+	randomBytes(sc.TraceID[:])
+	randomBytes(sc.SpanID[:])
+	return sc
+}
+
+// NewChild returns the context of a new span in the same trace.
+func (sc SpanContext) NewChild() SpanContext {
+	child := SpanContext{TraceID: sc.TraceID}
+	randomBytes(child.SpanID[:])
+	return child
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+// String formats the context as a traceparent header, the trace is always sampled.
+func (sc SpanContext) String() string {
+	return fmt.Sprintf("00-%s-%s-01", hex.EncodeToString(sc.TraceID[:]), hex.EncodeToString(sc.SpanID[:]))
+}
+
+// ParseTraceParent parses the context from a traceparent header.
+func ParseTraceParent(s string) (SpanContext, error) {
+	sc := SpanContext{}
+	parts := strings.Split(s, "-")
+	if len(parts) != 4 || parts[0] != "00" {
+		return sc, errors.New("invalid traceparent: " + s)
+	}
+	traceID, err := hex.DecodeString(parts[1])
+	if err != nil || len(traceID) != len(sc.TraceID) {
+		return sc, errors.New("invalid trace id: " + parts[1])
+	}
+	spanID, err := hex.DecodeString(parts[2])
+	if err != nil || len(spanID) != len(sc.SpanID) {
+		return sc, errors.New("invalid span id: " + parts[2])
+	}
+	copy(sc.TraceID[:], traceID)
+	copy(sc.SpanID[:], spanID)
+	if !sc.IsValid() {
+		return SpanContext{}, errors.New("invalid traceparent: " + s)
+	}
+	return sc, nil
+}
+
+// Span is a finished span. Spans of the long-running objects are emitted once they end, with the
+// start time recorded in the objects, since one reconciliation never covers a whole span.
+type Span struct {
+	Name       string
+	Context    SpanContext
+	Parent     SpanID
+	Start      time.Time
+	End        time.Time
+	Attributes map[string]string
+	// Error marks the span failed if not empty.
+	Error string
+}
+
+// Exporter exports the finished spans, it must never block the caller.
+type Exporter interface {
+	Export(span Span)
+}
+
+var (
+	exporterLock sync.RWMutex
+	exporter     Exporter
+)
+
+// SetExporter sets the exporter of spans, tracing is disabled if it's nil.
+func SetExporter(e Exporter) {
+	exporterLock.Lock()
+	defer exporterLock.Unlock()
+	exporter = e
+}
+
+func Enabled() bool {
+	exporterLock.RLock()
+	defer exporterLock.RUnlock()
+	return exporter != nil
+}
+
+// Emit exports the span if tracing is enabled.
+func Emit(span Span) {
+	exporterLock.RLock()
+	defer exporterLock.RUnlock()
+	if exporter != nil {
+		exporter.Export(span)
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	sc := NewSpanContext()
+	parsed, err := ParseTraceParent(sc.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed != sc {
+		t.Fatalf("expect %s, got %s", sc, parsed)
+	}
+
+	child := sc.NewChild()
+	if child.TraceID != sc.TraceID || child.SpanID == sc.SpanID {
+		t.Fatalf("expect child in the same trace, got %s", child)
+	}
+
+	for _, s := range []string{
+		"",
+		"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+	} {
+		if _, err := ParseTraceParent(s); err == nil {
+			t.Fatalf("expect invalid traceparent: %s", s)
+		}
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	received := make(chan *coltracepb.ExportTraceServiceRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		req := &coltracepb.ExportTraceServiceRequest{}
+		if err := proto.Unmarshal(body, req); err != nil {
+			t.Error(err)
+		}
+		received <- req
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	e, err := NewOTLPExporter(ctx, server.URL+"/v1/traces", "test", logr.Discard())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := NewSpanContext()
+	start := time.Unix(100, 0)
+	children := make([]SpanContext, otlpBatchSize)
+	for i := range children {
+		children[i] = root.NewChild()
+		e.Export(Span{
+			Name:       "phase",
+			Context:    children[i],
+			Parent:     root.SpanID,
+			Start:      start,
+			End:        start.Add(time.Second),
+			Attributes: map[string]string{"phase": "Backuping"},
+			Error:      "failed",
+		})
+	}
+
+	select {
+	case req := <-received:
+		spans := req.ResourceSpans[0].InstrumentationLibrarySpans[0].Spans
+		if len(spans) != otlpBatchSize {
+			t.Fatalf("expect %d spans, got %d", otlpBatchSize, len(spans))
+		}
+		s := spans[0]
+		if hex.EncodeToString(s.ParentSpanId) != root.String()[36:52] || hex.EncodeToString(s.TraceId) != root.String()[3:35] ||
+			hex.EncodeToString(s.SpanId) != children[0].String()[36:52] {
+			t.Fatalf("unexpected ids of span: %+v", s)
+		}
+		if s.StartTimeUnixNano != 100000000000 || s.EndTimeUnixNano != 101000000000 {
+			t.Fatalf("unexpected time of span: %+v", s)
+		}
+		if s.Status.Code != tracepb.Status_STATUS_CODE_ERROR || s.Status.Message != "failed" || len(s.Attributes) != 1 {
+			t.Fatalf("unexpected span: %+v", s)
+		}
+	case <-time.After(otlpFlushInterval / 2):
+		t.Fatal("expect batch exported once full")
+	}
+
+	if _, err := NewOTLPExporter(ctx, "collector:4318", "test", logr.Discard()); err == nil {
+		t.Fatal("expect endpoint without scheme rejected")
+	}
+}
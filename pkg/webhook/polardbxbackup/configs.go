@@ -0,0 +1,102 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package polardbxbackup
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	configutil "github.com/alibaba/polardbx-operator/pkg/util/config"
+	"github.com/alibaba/polardbx-operator/pkg/util/config/bundle"
+	"github.com/alibaba/polardbx-operator/pkg/util/config/loader"
+	"github.com/alibaba/polardbx-operator/pkg/util/config/store"
+)
+
+// StorageTarget maps the namespaces to the default storage of backups in them.
+type StorageTarget struct {
+	// NamespaceSelector selects the namespaces by labels, nil selects nothing.
+	NamespaceSelector *metav1.LabelSelector `json:"namespace_selector,omitempty"`
+	// NamespaceAnnotations selects the namespaces having all the annotations, along with the selector.
+	NamespaceAnnotations map[string]string `json:"namespace_annotations,omitempty"`
+	// StorageName and Sink are the storage of the backups.
+	StorageName polardbxv1.BackupStorage `json:"storage_name,omitempty"`
+	Sink        string                   `json:"sink,omitempty"`
+}
+
+type DefaulterConfig struct {
+	// StorageTargets are matched in order, the first one matching the namespace wins.
+	StorageTargets []StorageTarget `json:"storage_targets,omitempty"`
+}
+
+type WebhookAdmissionConfig struct {
+	Defaulter DefaulterConfig `json:"default,omitempty"`
+}
+
+// webhookConfig is the part of the webhook config for backups.
+type webhookConfig struct {
+	Backup WebhookAdmissionConfig `json:"backup,omitempty"`
+}
+
+type WebhookAdmissionConfigLoaderFunc func() *WebhookAdmissionConfig
+
+func NewConfigLoaderAndStartBackgroundRefresh(ctx context.Context, path string, logger logr.Logger) (WebhookAdmissionConfigLoaderFunc, error) {
+	configStore := &store.Store{}
+	driver := configutil.NewConfigWatchDriver(
+		loader.NewFileSystemLoader(path),
+		func(b bundle.Bundle) (interface{}, error) {
+			r, err := bundle.GetOneFromBundle(b, "webhook.yaml", "webhook.json")
+			if err != nil {
+				return nil, err
+			}
+
+			var c webhookConfig
+			decoder := yaml.NewYAMLOrJSONDecoder(r, 512)
+			err = decoder.Decode(&c)
+			if err != nil {
+				return nil, err
+			}
+
+			return &c.Backup, nil
+		},
+		configutil.WatchDriverOptions{
+			Logger:   logger,
+			Interval: time.Second,
+			Watchers: []configutil.Watcher{
+				func(i interface{}) {
+					configStore.Update(i)
+				},
+			},
+		},
+	)
+	err := driver.Start(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return func() *WebhookAdmissionConfig {
+		v := configStore.Get()
+		if v == nil {
+			return nil
+		}
+		return v.(*WebhookAdmissionConfig)
+	}, nil
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package polardbxbackup
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/webhook/extension"
+)
+
+type Defaulter struct {
+	client.Reader
+	configLoader func() *DefaulterConfig
+}
+
+// matchStorageTarget returns the first storage target matching the namespace, nil if there's none.
+func matchStorageTarget(targets []StorageTarget, ns *corev1.Namespace) (*StorageTarget, error) {
+	for i := range targets {
+		target := &targets[i]
+		if target.NamespaceSelector == nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(target.NamespaceSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid namespace selector of storage target %d: %w", i, err)
+		}
+		if !selector.Matches(labels.Set(ns.Labels)) {
+			continue
+		}
+		matched := true
+		for k, v := range target.NamespaceAnnotations {
+			if ns.Annotations[k] != v {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return target, nil
+		}
+	}
+	return nil, nil
+}
+
+// Default resolves the storage of the backup by the storage targets of its namespace if the
+// storage is omitted. Explicit storage, even partially specified, is never overridden. Copies
+// must use the storage of the source and are left untouched.
+func (d *Defaulter) Default(ctx context.Context, obj runtime.Object) error {
+	backup := obj.(*polardbxv1.PolarDBXBackup)
+	storage := &backup.Spec.StorageProvider
+	if len(storage.StorageName) > 0 || len(storage.Sink) > 0 || backup.Spec.CopyFrom != nil {
+		return nil
+	}
+	config := d.configLoader()
+	if config == nil || len(config.StorageTargets) == 0 {
+		return nil
+	}
+
+	ns := &corev1.Namespace{}
+	if err := d.Get(ctx, types.NamespacedName{Name: backup.Namespace}, ns); err != nil {
+		return err
+	}
+	target, err := matchStorageTarget(config.StorageTargets, ns)
+	if err != nil || target == nil {
+		return err
+	}
+	storage.StorageName = target.StorageName
+	storage.Sink = target.Sink
+	return nil
+}
+
+func NewDefaulter(r client.Reader, configLoader func() *DefaulterConfig) extension.CustomDefaulter {
+	return &Defaulter{
+		Reader:       r,
+		configLoader: configLoader,
+	}
+}
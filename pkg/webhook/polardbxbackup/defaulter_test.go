@@ -0,0 +1,108 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package polardbxbackup
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+)
+
+type namespaceReader struct {
+	client.Reader
+	namespaces map[string]*corev1.Namespace
+}
+
+func (r *namespaceReader) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	ns, ok := r.namespaces[key.Name]
+	if !ok {
+		return apierrors.NewNotFound(schema.GroupResource{}, key.Name)
+	}
+	ns.DeepCopyInto(obj.(*corev1.Namespace))
+	return nil
+}
+
+func TestDefaulter_Default(t *testing.T) {
+	namespaceOf := func(name string, labels, annotations map[string]string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels, Annotations: annotations}}
+	}
+	config := &DefaulterConfig{StorageTargets: []StorageTarget{
+		{
+			NamespaceSelector:    &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}},
+			NamespaceAnnotations: map[string]string{"backup/region": "eu"},
+			StorageName:          polardbxv1.OSS,
+			Sink:                 "prod-eu",
+		},
+		{
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}},
+			StorageName:       polardbxv1.OSS,
+			Sink:              "prod",
+		},
+		{
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "staging"}},
+			StorageName:       polardbxv1.SFTP,
+			Sink:              "staging",
+		},
+	}}
+	d := NewDefaulter(&namespaceReader{namespaces: map[string]*corev1.Namespace{
+		"prod-eu": namespaceOf("prod-eu", map[string]string{"env": "prod"}, map[string]string{"backup/region": "eu"}),
+		"prod":    namespaceOf("prod", map[string]string{"env": "prod"}, nil),
+		"staging": namespaceOf("staging", map[string]string{"env": "staging"}, nil),
+		"dev":     namespaceOf("dev", map[string]string{"env": "dev"}, nil),
+	}}, func() *DefaulterConfig { return config })
+
+	testcases := map[string]struct {
+		namespace string
+		storage   polardbxv1.BackupStorageProvider
+		expect    polardbxv1.BackupStorageProvider
+	}{
+		"first-match": {namespace: "prod-eu", expect: polardbxv1.BackupStorageProvider{StorageName: polardbxv1.OSS, Sink: "prod-eu"}},
+		"by-labels":   {namespace: "prod", expect: polardbxv1.BackupStorageProvider{StorageName: polardbxv1.OSS, Sink: "prod"}},
+		"staging":     {namespace: "staging", expect: polardbxv1.BackupStorageProvider{StorageName: polardbxv1.SFTP, Sink: "staging"}},
+		"no-match":    {namespace: "dev"},
+		"explicit": {
+			namespace: "prod",
+			storage:   polardbxv1.BackupStorageProvider{StorageName: polardbxv1.SFTP, Sink: "mine"},
+			expect:    polardbxv1.BackupStorageProvider{StorageName: polardbxv1.SFTP, Sink: "mine"},
+		},
+		"partially-explicit": {
+			namespace: "prod",
+			storage:   polardbxv1.BackupStorageProvider{Sink: "mine"},
+			expect:    polardbxv1.BackupStorageProvider{Sink: "mine"},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			backup := &polardbxv1.PolarDBXBackup{}
+			backup.Namespace = tc.namespace
+			backup.Spec.StorageProvider = tc.storage
+			if err := d.Default(context.Background(), backup); err != nil {
+				t.Fatal(err)
+			}
+			if got := backup.Spec.StorageProvider; got.StorageName != tc.expect.StorageName || got.Sink != tc.expect.Sink {
+				t.Fatalf("expect %+v, got %+v", tc.expect, got)
+			}
+		})
+	}
+}
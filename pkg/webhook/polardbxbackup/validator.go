@@ -0,0 +1,111 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package polardbxbackup
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1polardbx "github.com/alibaba/polardbx-operator/api/v1/polardbx"
+	polardbxhelper "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	"github.com/alibaba/polardbx-operator/pkg/webhook/extension"
+	"github.com/alibaba/polardbx-operator/pkg/webhook/preflight"
+)
+
+type Validator struct {
+	client.Reader
+}
+
+// ValidateCreate checks the backup before it starts, i.e. the notifications are sent to public
+// webhooks, the engine is able to take it consistently, the cluster is there to back up, and the
+// storage is reachable or the volume snapshot class exists.
+// Copies never touch the cluster and are not checked, and the preflight checks are skipped for backups
+// with the skip annotation.
+func (v *Validator) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	backup := obj.(*polardbxv1.PolarDBXBackup)
+	gvk := backup.GroupVersionKind()
+	if errList := preflight.CheckNotifications(field.NewPath("spec", "notifications"), backup.Spec.Notifications); len(errList) > 0 {
+		return apierrors.NewInvalid(gvk.GroupKind(), backup.Name, errList)
+	}
+	if backup.Spec.CopyFrom != nil {
+		return nil
+	}
+	if err := polardbxhelper.ValidateBackupEngine(backup.Spec.Engine, backup.Spec.XStores); err != nil {
+		return apierrors.NewInvalid(gvk.GroupKind(), backup.Name, field.ErrorList{
+			field.Invalid(field.NewPath("spec", "engine"), backup.Spec.Engine, err.Error()),
+		})
+	}
+	if preflight.IsSkipped(backup.Annotations) {
+		return nil
+	}
+
+	fieldPath := field.NewPath("spec", "cluster", "name")
+	polardbx := &polardbxv1.PolarDBXCluster{}
+	err := v.Get(ctx, types.NamespacedName{Namespace: backup.Namespace, Name: backup.Spec.Cluster.Name}, polardbx)
+	if apierrors.IsNotFound(err) {
+		return apierrors.NewInvalid(gvk.GroupKind(), backup.Name, field.ErrorList{
+			field.NotFound(fieldPath, backup.Spec.Cluster.Name),
+		})
+	} else if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+	if !polardbx.DeletionTimestamp.IsZero() || polardbx.Status.Phase == polardbxv1polardbx.PhaseDeleting {
+		return apierrors.NewForbidden(
+			schema.GroupResource{Group: gvk.Group, Resource: gvk.Kind}, backup.Name,
+			field.Forbidden(fieldPath, "cluster is being deleted, set annotation "+
+				polardbxmeta.AnnotationSkipPreflightCheck+" to skip the check"))
+	}
+
+	var errList field.ErrorList
+	if backup.Spec.Engine == polardbxv1.BackupEngineVolumeSnapshot {
+		fieldErr, err := preflight.CheckVolumeSnapshotClass(ctx, v, field.NewPath("spec", "volumeSnapshotClassName"),
+			backup.Spec.VolumeSnapshotClassName)
+		if err != nil {
+			return apierrors.NewInternalError(err)
+		}
+		if fieldErr != nil {
+			errList = append(errList, fieldErr)
+		}
+	} else if fieldErr := preflight.CheckStorage(ctx, v, backup.Namespace, field.NewPath("spec", "storageProvider"),
+		backup.Spec.StorageProvider, polardbxmeta.BackupPath+"/"+backup.Spec.Cluster.Name+"/"); fieldErr != nil {
+		errList = append(errList, fieldErr)
+	}
+	if len(errList) > 0 {
+		return apierrors.NewInvalid(gvk.GroupKind(), backup.Name, errList)
+	}
+	return nil
+}
+
+func (v *Validator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	return nil
+}
+
+func (v *Validator) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	return nil
+}
+
+func NewValidator(r client.Reader) extension.CustomValidator {
+	return &Validator{Reader: r}
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package polardbxbackup
+
+import (
+	"context"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1polardbx "github.com/alibaba/polardbx-operator/api/v1/polardbx"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+)
+
+type clusterReader struct {
+	client.Reader
+	clusters        map[string]*polardbxv1.PolarDBXCluster
+	snapshotClasses map[string]bool
+}
+
+func (r *clusterReader) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	switch obj := obj.(type) {
+	case *polardbxv1.PolarDBXCluster:
+		if polardbx, ok := r.clusters[key.Name]; ok {
+			polardbx.DeepCopyInto(obj)
+			return nil
+		}
+	case *unstructured.Unstructured:
+		if r.snapshotClasses[key.Name] {
+			return nil
+		}
+	}
+	return apierrors.NewNotFound(schema.GroupResource{}, key.Name)
+}
+
+func TestValidator_ValidateCreate(t *testing.T) {
+	v := NewValidator(&clusterReader{
+		clusters: map[string]*polardbxv1.PolarDBXCluster{
+			"running":  {Status: polardbxv1.PolarDBXClusterStatus{Phase: polardbxv1polardbx.PhaseRunning}},
+			"deleting": {Status: polardbxv1.PolarDBXClusterStatus{Phase: polardbxv1polardbx.PhaseDeleting}},
+		},
+		snapshotClasses: map[string]bool{"csi-snapshot": true},
+	})
+	backupOf := func(cluster string) *polardbxv1.PolarDBXBackup {
+		return &polardbxv1.PolarDBXBackup{
+			ObjectMeta: metav1.ObjectMeta{Name: "backup"},
+			Spec: polardbxv1.PolarDBXBackupSpec{
+				Cluster: polardbxv1.PolarDBXClusterReference{Name: cluster},
+			},
+		}
+	}
+
+	if err := v.ValidateCreate(context.Background(), backupOf("running")); err != nil {
+		t.Fatalf("expect running cluster backupable, got %v", err)
+	}
+	if err := v.ValidateCreate(context.Background(), backupOf("deleting")); !apierrors.IsForbidden(err) {
+		t.Fatalf("expect deleting cluster not backupable, got %v", err)
+	}
+	if err := v.ValidateCreate(context.Background(), backupOf("absent")); !apierrors.IsInvalid(err) {
+		t.Fatalf("expect absent cluster rejected, got %v", err)
+	}
+
+	backup := backupOf("absent")
+	backup.Annotations = map[string]string{polardbxmeta.AnnotationSkipPreflightCheck: "true"}
+	if err := v.ValidateCreate(context.Background(), backup); err != nil {
+		t.Fatalf("expect check skipped, got %v", err)
+	}
+
+	backup = backupOf("running")
+	backup.Spec.Engine = polardbxv1.BackupEngineVolumeSnapshot
+	backup.Spec.VolumeSnapshotClassName = "csi-snapshot"
+	if err := v.ValidateCreate(context.Background(), backup); !apierrors.IsInvalid(err) {
+		t.Fatalf("expect volume snapshot of the whole cluster rejected, got %v", err)
+	}
+	backup.Spec.XStores = []string{"running-dn-0"}
+	if err := v.ValidateCreate(context.Background(), backup); err != nil {
+		t.Fatalf("expect volume snapshot class found, got %v", err)
+	}
+	backup.Spec.VolumeSnapshotClassName = "absent"
+	if err := v.ValidateCreate(context.Background(), backup); !apierrors.IsInvalid(err) {
+		t.Fatalf("expect absent volume snapshot class rejected, got %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package polardbxbackup
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/webhook/extension"
+)
+
+func SetupWebhooks(ctx context.Context, mgr ctrl.Manager, configPath string, apiPath string) error {
+	webhookConfigLoader, err := NewConfigLoaderAndStartBackgroundRefresh(ctx,
+		configPath, ctrl.Log.WithName("webhook").WithName("polardbxbackup"))
+	if err != nil {
+		return err
+	}
+
+	gvk := schema.GroupVersionKind{
+		Group:   polardbxv1.GroupVersion.Group,
+		Version: polardbxv1.GroupVersion.Version,
+		Kind:    "PolarDBXBackup",
+	}
+
+	// Default.
+	mgr.GetWebhookServer().Register(extension.GenerateMutatePath(apiPath, gvk),
+		extension.WithCustomDefaulter(&polardbxv1.PolarDBXBackup{}, NewDefaulter(mgr.GetAPIReader(),
+			func() *DefaulterConfig {
+				if c := webhookConfigLoader(); c != nil {
+					return &c.Defaulter
+				}
+				return nil
+			},
+		)),
+	)
+
+	// Validate.
+	mgr.GetWebhookServer().Register(extension.GenerateValidatePath(apiPath, gvk),
+		extension.WithCustomValidator(&polardbxv1.PolarDBXBackup{}, NewValidator(mgr.GetAPIReader())))
+
+	return nil
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package polardbxcluster
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1polardbx "github.com/alibaba/polardbx-operator/api/v1/polardbx"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	"github.com/alibaba/polardbx-operator/pkg/webhook/preflight"
+)
+
+// restoreCapacityOf returns the capacity of the volumes of the restored DNs, i.e. the disk quota or the
+// storage limit, nil if neither is specified.
+func restoreCapacityOf(template *polardbxv1polardbx.XStoreTemplate) (*field.Path, *resource.Quantity) {
+	fldPath := field.NewPath("spec", "topology", "nodes", "dn", "template")
+	if template.DiskQuota != nil && !template.DiskQuota.IsZero() {
+		return fldPath.Child("diskQuota"), template.DiskQuota
+	}
+	if storage, ok := template.Resources.Limits[corev1.ResourceStorage]; ok && !storage.IsZero() {
+		return fldPath.Child("resources", "limits", "storage"), &storage
+	}
+	return nil, nil
+}
+
+// findRestoreBackupSet returns the backup set to restore, nil with the error of field if not found.
+func (v *PolarDBXClusterV1Validator) findRestoreBackupSet(ctx context.Context, polardbx *polardbxv1.PolarDBXCluster,
+	fldPath *field.Path) (*polardbxv1.PolarDBXBackup, *field.Error, error) {
+	restore := polardbx.Spec.Restore
+	namespace := polardbx.Namespace
+	if len(restore.From.Namespace) > 0 {
+		namespace = restore.From.Namespace
+	}
+
+	if len(restore.BackupSet) > 0 {
+		backup := &polardbxv1.PolarDBXBackup{}
+		err := v.Get(ctx, types.NamespacedName{Namespace: namespace, Name: restore.BackupSet}, backup)
+		if apierrors.IsNotFound(err) {
+			return nil, field.NotFound(fldPath.Child("backupset"), restore.BackupSet), nil
+		} else if err != nil {
+			return nil, nil, err
+		}
+		return backup, nil, nil
+	}
+
+	restoreTime, err := helper.ParseRestoreTime(restore.Time, restore.TimeZone)
+	if err != nil {
+		return nil, field.Invalid(fldPath.Child("time"), restore.Time, err.Error()), nil
+	}
+	backups := &polardbxv1.PolarDBXBackupList{}
+	err = v.List(ctx, backups, client.InNamespace(namespace), client.MatchingLabels{
+		polardbxmeta.LabelName: restore.From.PolarBDXName,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	backup := helper.LastCompletedBackup(backups.Items, restoreTime)
+	if backup == nil {
+		return nil, field.Invalid(fldPath.Child("time"), restore.Time,
+			"no complete backup of cluster "+restore.From.PolarBDXName+" is found before the restore time"), nil
+	}
+	return backup, nil, nil
+}
+
+// validateRestore checks the restore before it starts, so that it isn't found failed hours later, i.e.
+// the backup set exists and is complete, the restore time is covered by the retained binlogs, the storage
+// is reachable, and the restored volumes are large enough. Backup sets in the remote storage are only
+// checked by the reachability of the storage since there's no object of them.
+func (v *PolarDBXClusterV1Validator) validateRestore(ctx context.Context, polardbx *polardbxv1.PolarDBXCluster) (field.ErrorList, error) {
+	restore := polardbx.Spec.Restore
+	if restore == nil || preflight.IsSkipped(polardbx.Annotations) {
+		return nil, nil
+	}
+	fldPath := field.NewPath("spec", "restore")
+
+	if location := restore.From.BackupLocation; location != nil {
+		provider := polardbxv1.BackupStorageProvider{
+			StorageName:         polardbxv1.BackupStorage(location.StorageName),
+			Sink:                location.Sink,
+			RetentionCredential: &location.Credential,
+		}
+		if fieldErr := preflight.CheckStorage(ctx, v, polardbx.Namespace, fldPath.Child("from", "backupLocation"),
+			provider, location.Path); fieldErr != nil {
+			return field.ErrorList{fieldErr}, nil
+		}
+		return nil, nil
+	}
+
+	backup, fieldErr, err := v.findRestoreBackupSet(ctx, polardbx, fldPath)
+	if err != nil || fieldErr != nil {
+		return field.ErrorList{fieldErr}, err
+	}
+	backupSetPath := fldPath.Child("backupset")
+	if fieldErr := preflight.CheckBackupSetComplete(backupSetPath, backup); fieldErr != nil {
+		return field.ErrorList{fieldErr}, nil
+	}
+
+	var errList field.ErrorList
+	if len(restore.Time) > 0 {
+		restoreTime, err := helper.ParseRestoreTime(restore.Time, restore.TimeZone)
+		if err != nil {
+			errList = append(errList, field.Invalid(fldPath.Child("time"), restore.Time, err.Error()))
+		} else if fieldErr := preflight.CheckRestoreTimeCovered(fldPath.Child("time"), restore.Time, restoreTime,
+			backup, restore.PointInTime); fieldErr != nil {
+			errList = append(errList, fieldErr)
+		}
+	}
+
+	if backup.Spec.Engine != polardbxv1.BackupEngineVolumeSnapshot {
+		if fieldErr := preflight.CheckStorage(ctx, v, backup.Namespace, backupSetPath, backup.Spec.StorageProvider,
+			backup.Status.BackupRootPath); fieldErr != nil {
+			errList = append(errList, fieldErr)
+		}
+	}
+
+	xstoreBackups := &polardbxv1.XStoreBackupList{}
+	err = v.List(ctx, xstoreBackups, client.InNamespace(backup.Namespace), client.MatchingLabels{
+		polardbxmeta.LabelTopBackup: backup.Name,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// The spec is replaced with the one of the original cluster, which is large enough.
+	var capacityPath *field.Path
+	var capacity *resource.Quantity
+	if !restore.SyncSpecWithOriginalCluster {
+		capacityPath, capacity = restoreCapacityOf(&polardbx.Spec.Topology.Nodes.DN.Template)
+	}
+	capacityErrs, err := preflight.CheckRestoreCapacity(ctx, v, backupSetPath, capacityPath, capacity, xstoreBackups.Items)
+	if err != nil {
+		return nil, err
+	}
+	return append(errList, capacityErrs...), nil
+}
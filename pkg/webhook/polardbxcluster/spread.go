@@ -0,0 +1,211 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package polardbxcluster
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1polardbx "github.com/alibaba/polardbx-operator/api/v1/polardbx"
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	k8sselectorutil "github.com/alibaba/polardbx-operator/pkg/k8s/helper/selector"
+)
+
+// countXStoreSpreadMembers counts the consensus members of each xstore to be spread,
+// which are learners for readonly and candidates and voters otherwise.
+func countXStoreSpreadMembers(rule *polardbxv1polardbx.XStoreTopologyRule, readonly bool) int {
+	if len(rule.NodeSets) == 0 {
+		// Two candidates and a voter by default.
+		if readonly {
+			return 1
+		}
+		return 3
+	}
+
+	members := 0
+	for _, ns := range rule.NodeSets {
+		switch ns.Role {
+		case polardbxv1xstore.RoleCandidate, polardbxv1xstore.RoleVoter:
+			if !readonly {
+				members += int(ns.Replicas)
+			}
+		case polardbxv1xstore.RoleLearner:
+			if readonly {
+				members += int(ns.Replicas)
+			}
+		}
+	}
+	return members
+}
+
+// resolveSpreadNodeSelectors returns the node selectors of the node sets of consensus members.
+// Nil is returned if any of them isn't restricted, i.e. all nodes are available.
+func resolveSpreadNodeSelectors(rule *polardbxv1polardbx.XStoreTopologyRule, readonly bool,
+	selectors []polardbxv1polardbx.NodeSelectorItem) []*corev1.NodeSelector {
+	if len(rule.NodeSets) == 0 {
+		return nil
+	}
+
+	nodeSelectors := make([]*corev1.NodeSelector, 0, len(rule.NodeSets))
+	for _, ns := range rule.NodeSets {
+		isMember := ns.Role == polardbxv1xstore.RoleLearner
+		if !readonly {
+			isMember = ns.Role == polardbxv1xstore.RoleCandidate || ns.Role == polardbxv1xstore.RoleVoter
+		}
+		if !isMember {
+			continue
+		}
+
+		ref := ns.NodeSelector
+		if ref == nil {
+			return nil
+		}
+		if ref.NodeSelector != nil {
+			nodeSelectors = append(nodeSelectors, ref.NodeSelector)
+			continue
+		}
+
+		var found *corev1.NodeSelector
+		for i := range selectors {
+			if selectors[i].Name == ref.Reference {
+				found = &selectors[i].NodeSelector
+				break
+			}
+		}
+		if found == nil {
+			return nil
+		}
+		nodeSelectors = append(nodeSelectors, found)
+	}
+	return nodeSelectors
+}
+
+// countTopologyDomains counts the distinct values of the topology key among the schedulable
+// nodes matching any of the node selectors, or all schedulable nodes if there's no selector.
+func countTopologyDomains(nodes []corev1.Node, topologyKey string, nodeSelectors []*corev1.NodeSelector) (int, error) {
+	domains := make(map[string]struct{})
+	for i := range nodes {
+		node := &nodes[i]
+		if node.Spec.Unschedulable {
+			continue
+		}
+		domain, ok := node.Labels[topologyKey]
+		if !ok {
+			continue
+		}
+
+		matches := len(nodeSelectors) == 0
+		for _, nodeSelector := range nodeSelectors {
+			ok, err := k8sselectorutil.IsNodeMatches(node, nodeSelector)
+			if err != nil {
+				return 0, err
+			}
+			if ok {
+				matches = true
+				break
+			}
+		}
+		if matches {
+			domains[domain] = struct{}{}
+		}
+	}
+	return len(domains), nil
+}
+
+func (v *PolarDBXClusterV1Validator) validateXStoreTopologySpreadSatisfiable(ctx context.Context, fieldPath *field.Path,
+	rule *polardbxv1polardbx.XStoreTopologyRule, rules *polardbxv1polardbx.TopologyRules, readonly bool,
+	nodes []corev1.Node) field.ErrorList {
+	var errList field.ErrorList
+	if rule == nil || rule.Spread == nil || rule.Spread.WhenUnsatisfiable == corev1.ScheduleAnyway {
+		return errList
+	}
+
+	topologyKey := rule.Spread.TopologyKey
+	if len(topologyKey) == 0 {
+		topologyKey = corev1.LabelTopologyZone
+	}
+
+	members := countXStoreSpreadMembers(rule, readonly)
+	domains, err := countTopologyDomains(nodes, topologyKey,
+		resolveSpreadNodeSelectors(rule, readonly, rules.Selectors))
+	if err != nil {
+		errList = append(errList, field.InternalError(fieldPath.Child("spread"), err))
+		return errList
+	}
+
+	if domains < members {
+		errList = append(errList, field.Invalid(
+			fieldPath.Child("spread", "topologyKey"),
+			topologyKey,
+			fmt.Sprintf("unsatisfiable, %d consensus members require %d domains, but only %d found",
+				members, members, domains),
+		))
+	}
+	return errList
+}
+
+// validateTopologySpreadSatisfiable validates that there are enough failure domains for the consensus
+// members of GMS and DNs. It's skipped on update unless the rules of GMS or DNs are changed.
+func (v *PolarDBXClusterV1Validator) validateTopologySpreadSatisfiable(ctx context.Context,
+	polardbx *polardbxv1.PolarDBXCluster, old *polardbxv1.PolarDBXCluster) error {
+	if v.Reader == nil {
+		return nil
+	}
+
+	components := &polardbx.Spec.Topology.Rules.Components
+	if components.GMS == nil || components.GMS.Spread == nil {
+		if components.DN == nil || components.DN.Spread == nil {
+			return nil
+		}
+	}
+	if old != nil {
+		oldComponents := &old.Spec.Topology.Rules.Components
+		if equality.Semantic.DeepEqual(oldComponents.GMS, components.GMS) &&
+			equality.Semantic.DeepEqual(oldComponents.DN, components.DN) {
+			return nil
+		}
+	}
+
+	var nodeList corev1.NodeList
+	if err := v.List(ctx, &nodeList); err != nil {
+		return err
+	}
+
+	rules := &polardbx.Spec.Topology.Rules
+	fieldPath := field.NewPath("spec", "topology", "rules", "components")
+	var errList field.ErrorList
+	if !polardbx.Spec.Readonly {
+		errList = append(errList, v.validateXStoreTopologySpreadSatisfiable(ctx, fieldPath.Child("gms"),
+			components.GMS, rules, false, nodeList.Items)...)
+	}
+	errList = append(errList, v.validateXStoreTopologySpreadSatisfiable(ctx, fieldPath.Child("dn"),
+		components.DN, rules, polardbx.Spec.Readonly, nodeList.Items)...)
+
+	if len(errList) > 0 {
+		return apierrors.NewInvalid(
+			polardbx.GroupVersionKind().GroupKind(),
+			polardbx.Name,
+			errList)
+	}
+	return nil
+}
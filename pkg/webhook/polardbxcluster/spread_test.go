@@ -0,0 +1,93 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package polardbxcluster
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	polardbxv1polardbx "github.com/alibaba/polardbx-operator/api/v1/polardbx"
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+)
+
+func TestCountXStoreSpreadMembers(t *testing.T) {
+	rule := &polardbxv1polardbx.XStoreTopologyRule{}
+	if n := countXStoreSpreadMembers(rule, false); n != 3 {
+		t.Fatalf("expect 3 members by default, but got %d", n)
+	}
+
+	rule.NodeSets = []polardbxv1polardbx.XStoreTopologyRuleNodeSetItem{
+		{Name: "cand", Role: polardbxv1xstore.RoleCandidate, Replicas: 4},
+		{Name: "log", Role: polardbxv1xstore.RoleVoter, Replicas: 1},
+		{Name: "learner", Role: polardbxv1xstore.RoleLearner, Replicas: 2},
+	}
+	if n := countXStoreSpreadMembers(rule, false); n != 5 {
+		t.Fatalf("expect 5 members, but got %d", n)
+	}
+	if n := countXStoreSpreadMembers(rule, true); n != 2 {
+		t.Fatalf("expect 2 members if readonly, but got %d", n)
+	}
+}
+
+func TestCountTopologyDomains(t *testing.T) {
+	node := func(name, zone string, unschedulable bool) corev1.Node {
+		labels := map[string]string{"pool": "db"}
+		if len(zone) > 0 {
+			labels[corev1.LabelTopologyZone] = zone
+		}
+		return corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+			Spec:       corev1.NodeSpec{Unschedulable: unschedulable},
+		}
+	}
+	nodes := []corev1.Node{
+		node("n1", "a", false),
+		node("n2", "a", false),
+		node("n3", "b", false),
+		node("n4", "c", true),
+		node("n5", "", false),
+	}
+	nodes[2].Labels["pool"] = "app"
+
+	domains, err := countTopologyDomains(nodes, corev1.LabelTopologyZone, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if domains != 2 {
+		t.Fatalf("expect 2 domains, but got %d", domains)
+	}
+
+	domains, err = countTopologyDomains(nodes, corev1.LabelTopologyZone, []*corev1.NodeSelector{
+		{
+			NodeSelectorTerms: []corev1.NodeSelectorTerm{
+				{
+					MatchExpressions: []corev1.NodeSelectorRequirement{
+						{Key: "pool", Operator: corev1.NodeSelectorOpIn, Values: []string{"db"}},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if domains != 1 {
+		t.Fatalf("expect 1 domain, but got %d", domains)
+	}
+}
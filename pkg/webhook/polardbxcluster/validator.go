@@ -30,11 +30,13 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
 	polardbxv1common "github.com/alibaba/polardbx-operator/api/v1/common"
 	polardbxv1polardbx "github.com/alibaba/polardbx-operator/api/v1/polardbx"
 	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
 	iniutil "github.com/alibaba/polardbx-operator/pkg/util/ini"
 	"github.com/alibaba/polardbx-operator/pkg/webhook/extension"
 )
@@ -97,6 +99,7 @@ func validateExtendResources(ctx context.Context, fldPath *field.Path, resources
 }
 
 type PolarDBXClusterV1Validator struct {
+	client.Reader
 	configLoader func() *ValidatorConfig
 }
 
@@ -175,6 +178,27 @@ func (v *PolarDBXClusterV1Validator) validateXStoreTopologyRule(ctx context.Cont
 		return errList
 	}
 
+	if rule.Rolling != nil && rule.Spread != nil {
+		errList = append(errList, field.Invalid(fieldPath.Child("spread"),
+			rule.Spread,
+			"spread can not be used with rolling"))
+	}
+
+	if rule.Spread != nil {
+		switch rule.Spread.WhenUnsatisfiable {
+		case "", corev1.DoNotSchedule, corev1.ScheduleAnyway: // break
+		default:
+			errList = append(errList, field.NotSupported(
+				fieldPath.Child("spread", "whenUnsatisfiable"),
+				rule.Spread.WhenUnsatisfiable,
+				[]string{
+					string(corev1.DoNotSchedule),
+					string(corev1.ScheduleAnyway),
+				}),
+			)
+		}
+	}
+
 	if rule.Rolling != nil {
 		if rule.Rolling.Replicas%2 == 0 {
 			errList = append(errList, field.Invalid(
@@ -642,7 +666,8 @@ func (v *PolarDBXClusterV1Validator) validate(ctx context.Context, polardbx *pol
 
 	switch spec.UpgradeStrategy {
 	case polardbxv1polardbx.RecreateUpgradeStrategy,
-		polardbxv1polardbx.RollingUpgradeStrategy: // break
+		polardbxv1polardbx.RollingUpgradeStrategy,
+		polardbxv1polardbx.CanaryUpgradeStrategy: // break
 	default:
 		errList = append(errList, field.NotSupported(
 			field.NewPath("spec", "upgradeStrategy"),
@@ -650,9 +675,17 @@ func (v *PolarDBXClusterV1Validator) validate(ctx context.Context, polardbx *pol
 			[]string{
 				string(polardbxv1polardbx.RecreateUpgradeStrategy),
 				string(polardbxv1polardbx.RollingUpgradeStrategy),
+				string(polardbxv1polardbx.CanaryUpgradeStrategy),
 			}))
 	}
 
+	if spec.Canary != nil && spec.Canary.SoakPeriod != nil && spec.Canary.SoakPeriod.Duration < 0 {
+		errList = append(errList, field.Invalid(
+			field.NewPath("spec", "canary", "soakPeriod"),
+			spec.Canary.SoakPeriod.Duration.String(),
+			"must not be negative"))
+	}
+
 	if len(errList) > 0 {
 		return apierrors.NewInvalid(
 			polardbx.GroupVersionKind().GroupKind(),
@@ -663,7 +696,29 @@ func (v *PolarDBXClusterV1Validator) validate(ctx context.Context, polardbx *pol
 }
 
 func (v *PolarDBXClusterV1Validator) ValidateCreate(ctx context.Context, obj runtime.Object) error {
-	return v.validate(ctx, obj.(*polardbxv1.PolarDBXCluster))
+	polardbx := obj.(*polardbxv1.PolarDBXCluster)
+	if err := v.validate(ctx, polardbx); err != nil {
+		return err
+	}
+	if errList, err := v.validateRestore(ctx, polardbx); err != nil {
+		return apierrors.NewInternalError(err)
+	} else if len(errList) > 0 {
+		return apierrors.NewInvalid(polardbx.GroupVersionKind().GroupKind(), polardbx.Name, errList)
+	}
+	return v.validateTopologySpreadSatisfiable(ctx, polardbx, nil)
+}
+
+// securityWithoutTDE returns a copy of the security without TDE, nil if there's nothing else.
+func securityWithoutTDE(security *polardbxv1polardbx.Security) *polardbxv1polardbx.Security {
+	if security == nil {
+		return nil
+	}
+	security = security.DeepCopy()
+	security.TDE = nil
+	if equality.Semantic.DeepEqual(security, &polardbxv1polardbx.Security{}) {
+		return nil
+	}
+	return security
 }
 
 func (v *PolarDBXClusterV1Validator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
@@ -750,7 +805,19 @@ func (v *PolarDBXClusterV1Validator) ValidateUpdate(ctx context.Context, oldObj,
 		)
 	}
 
-	if !equality.Semantic.DeepEqual(oldSpec.Security, newSpec.Security) {
+	// TDE can be enabled and its master key rotated, while the rest of security is immutable.
+	if err := polardbxv1xstore.ValidateTDETransition(helper.GetTDE(old), helper.GetTDE(new)); err != nil {
+		return apierrors.NewForbidden(
+			schema.GroupResource{
+				Group:    gvk.Group,
+				Resource: gvk.Kind,
+			},
+			new.Name,
+			field.Forbidden(field.NewPath("spec", "security", "tde"), err.Error()),
+		)
+	}
+
+	if !equality.Semantic.DeepEqual(securityWithoutTDE(oldSpec.Security), securityWithoutTDE(newSpec.Security)) {
 		return apierrors.NewForbidden(
 			schema.GroupResource{
 				Group:    gvk.Group,
@@ -793,15 +860,19 @@ func (v *PolarDBXClusterV1Validator) ValidateUpdate(ctx context.Context, oldObj,
 	}
 
 	// Validate the new object at last.
-	return v.validate(ctx, new)
+	if err := v.validate(ctx, new); err != nil {
+		return err
+	}
+	return v.validateTopologySpreadSatisfiable(ctx, new, old)
 }
 
 func (v *PolarDBXClusterV1Validator) ValidateDelete(ctx context.Context, obj runtime.Object) error {
 	return nil
 }
 
-func NewPolarDBXClusterV1Validator(configLoader func() *ValidatorConfig) extension.CustomValidator {
+func NewPolarDBXClusterV1Validator(r client.Reader, configLoader func() *ValidatorConfig) extension.CustomValidator {
 	return &PolarDBXClusterV1Validator{
+		Reader:       r,
 		configLoader: configLoader,
 	}
 }
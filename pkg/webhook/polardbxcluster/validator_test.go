@@ -17,9 +17,15 @@ limitations under the License.
 package polardbxcluster
 
 import (
+	"context"
 	"testing"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/util/intstr"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	polardbxv1polardbx "github.com/alibaba/polardbx-operator/api/v1/polardbx"
+	polardbxv1xstore "github.com/alibaba/polardbx-operator/api/v1/xstore"
 )
 
 func TestIntOrStringSupportedValues(t *testing.T) {
@@ -31,3 +37,49 @@ func TestIntOrStringSupportedValues(t *testing.T) {
 		t.Fatal("v is not supported")
 	}
 }
+
+func TestValidateUpdate_TDE(t *testing.T) {
+	keyring := &polardbxv1xstore.TDE{SecretName: "tde"}
+	kms := &polardbxv1xstore.TDE{SecretName: "kms", KMS: &polardbxv1xstore.TDEKMS{
+		Provider: polardbxv1xstore.TDEKMSProviderAWS, KeyID: "key", Region: "us-east-1"}}
+	clusterWith := func(tde *polardbxv1xstore.TDE) *polardbxv1.PolarDBXCluster {
+		polardbx := &polardbxv1.PolarDBXCluster{}
+		polardbx.Name = "pxc"
+		if tde != nil {
+			polardbx.Spec.Security = &polardbxv1polardbx.Security{TDE: tde.DeepCopy()}
+		}
+		return polardbx
+	}
+
+	forbidden := map[string][2]*polardbxv1xstore.TDE{
+		"disable":        {keyring, nil},
+		"keyring-kms":    {keyring, kms},
+		"kms-keyring":    {kms, keyring},
+		"password":       {keyring, {SecretName: "another"}},
+		"kms-key":        {kms, {SecretName: "kms", KMS: &polardbxv1xstore.TDEKMS{Provider: "AWS", KeyID: "another", Region: "us-east-1"}}},
+		"version-rewind": {{SecretName: "tde", MasterKeyVersion: 2}, {SecretName: "tde", MasterKeyVersion: 1}},
+	}
+	v := &PolarDBXClusterV1Validator{}
+	for name, tc := range forbidden {
+		t.Run(name, func(t *testing.T) {
+			err := v.ValidateUpdate(context.Background(), clusterWith(tc[0]), clusterWith(tc[1]))
+			if !apierrors.IsForbidden(err) {
+				t.Fatalf("expect forbidden, got %v", err)
+			}
+		})
+	}
+
+	allowed := map[string][2]*polardbxv1xstore.TDE{
+		"enable":    {nil, keyring},
+		"unchanged": {kms, kms},
+		"rotate":    {keyring, {SecretName: "tde", MasterKeyVersion: 1}},
+	}
+	for name, tc := range allowed {
+		if err := polardbxv1xstore.ValidateTDETransition(tc[0], tc[1]); err != nil {
+			t.Fatalf("%s: expect allowed, got %v", name, err)
+		}
+		if securityWithoutTDE(clusterWith(tc[0]).Spec.Security) != nil || securityWithoutTDE(clusterWith(tc[1]).Spec.Security) != nil {
+			t.Fatalf("%s: expect nothing but TDE in security", name)
+		}
+	}
+}
@@ -67,6 +67,7 @@ func setupWebhooksForPolarDBXClusterV1(mgr ctrl.Manager, webhookConfigLoader Web
 	mgr.GetWebhookServer().Register(extension.GenerateValidatePath(apiPath, gvk),
 		extension.WithCustomValidator(&polardbxv1.PolarDBXCluster{},
 			NewPolarDBXClusterV1Validator(
+				mgr.GetAPIReader(),
 				func() *ValidatorConfig {
 					return &webhookConfigLoader().Validator
 				},
@@ -115,6 +116,7 @@ func webhookHandlerForPolarDBXClusterV1(mux *http.ServeMux, mgr ctrl.Manager, we
 	err = registerWebhook(mux, generateValidatePath(gvk),
 		extension.WithCustomValidator(&polardbxv1.PolarDBXCluster{},
 			NewPolarDBXClusterV1Validator(
+				mgr.GetAPIReader(),
 				func() *ValidatorConfig {
 					return &webhookConfigLoader().Validator
 				},
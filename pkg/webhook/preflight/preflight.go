@@ -0,0 +1,174 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	"github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/helper"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	"github.com/alibaba/polardbx-operator/pkg/util/network"
+)
+
+// The storage is probed in the admission, which must not be blocked for long.
+const storageProbeTimeout = 5 * time.Second
+
+// IsSkipped returns true if the pre-flight checks are skipped by the annotation.
+func IsSkipped(annotations map[string]string) bool {
+	return annotations[polardbxmeta.AnnotationSkipPreflightCheck] == "true"
+}
+
+// ignoreForbidden ignores the errors of cluster scoped objects which the operator may not be allowed
+// to read, e.g. installed with namespaced roles.
+func ignoreForbidden(err error) error {
+	if apierrors.IsForbidden(err) {
+		return nil
+	}
+	return err
+}
+
+// CheckStorage checks whether the storage is reachable with the retention credential of the provider,
+// by listing the files under the prefix. Credentials of the sinks are only known by the file service,
+// so nothing is checked if the retention credential isn't specified.
+func CheckStorage(ctx context.Context, r client.Reader, namespace string, fldPath *field.Path,
+	provider polardbxv1.BackupStorageProvider, prefix string) *field.Error {
+	ctx, cancel := context.WithTimeout(ctx, storageProbeTimeout)
+	defer cancel()
+	probed, err := helper.ProbeBackupStorage(ctx, r, namespace, provider, prefix)
+	if !probed || err == nil {
+		return nil
+	}
+	return field.Invalid(fldPath.Child("retentionCredential"), provider.RetentionCredential.Name,
+		fmt.Sprintf("storage %s isn't reachable with the credential: %s", provider.StorageName, err))
+}
+
+// CheckVolumeSnapshotClass checks whether the volume snapshot class exists, empty for the default one.
+func CheckVolumeSnapshotClass(ctx context.Context, r client.Reader, fldPath *field.Path, name string) (*field.Error, error) {
+	if len(name) == 0 {
+		return nil, nil
+	}
+	class := &unstructured.Unstructured{}
+	class.SetGroupVersionKind(schema.GroupVersionKind{
+		Group:   "snapshot.storage.k8s.io",
+		Version: "v1",
+		Kind:    "VolumeSnapshotClass",
+	})
+	err := r.Get(ctx, types.NamespacedName{Name: name}, class)
+	if apierrors.IsNotFound(err) {
+		return field.NotFound(fldPath, name), nil
+	}
+	return nil, ignoreForbidden(err)
+}
+
+// CheckNotifications checks whether the webhooks of the notification channels are public http(s) urls.
+func CheckNotifications(fldPath *field.Path, notifications []polardbxv1.BackupNotification) field.ErrorList {
+	var errList field.ErrorList
+	for i, notification := range notifications {
+		if err := network.ValidatePublicURL(notification.Webhook); err != nil {
+			errList = append(errList, field.Invalid(fldPath.Index(i).Child("webhook"), notification.Webhook, err.Error()))
+		}
+	}
+	return errList
+}
+
+// CheckBackupSetComplete checks whether the backup set is finished and of the whole cluster.
+func CheckBackupSetComplete(fldPath *field.Path, backup *polardbxv1.PolarDBXBackup) *field.Error {
+	if backup.Status.Phase != polardbxv1.BackupFinished {
+		return field.Invalid(fldPath, backup.Name, "backup set isn't complete, phase is \""+string(backup.Status.Phase)+"\"")
+	}
+	if len(backup.Spec.XStores) > 0 {
+		return field.Invalid(fldPath, backup.Name, "backup of xstore group can't be used to restore the cluster")
+	}
+	return nil
+}
+
+// CheckRestoreTimeCovered checks whether the restore time is covered by the binlogs retained along with
+// the backup set. Volume snapshots come with no binlogs, so point in time restore isn't supported.
+func CheckRestoreTimeCovered(fldPath *field.Path, value string, restoreTime time.Time,
+	backup *polardbxv1.PolarDBXBackup, pointInTime bool) *field.Error {
+	if backup.Spec.Engine == polardbxv1.BackupEngineVolumeSnapshot {
+		if pointInTime {
+			return field.Invalid(fldPath, value, "point in time restore isn't supported by volume snapshot backup "+backup.Name)
+		}
+		return nil
+	}
+	latest := backup.Status.LatestRecoverableTimestamp
+	if latest != nil && restoreTime.After(latest.Time) {
+		return field.Invalid(fldPath, value, "restore time isn't covered by the binlogs retained along with backup "+
+			backup.Name+", the latest recoverable time is "+latest.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// restoreSizeOf returns the minimum size of the volume to restore the xstore backup, which is the size of
+// the volume snapshot, or the size of the full backup since the restored data is never smaller.
+func restoreSizeOf(backup *polardbxv1.XStoreBackup) int64 {
+	if snapshot := backup.Status.VolumeSnapshot; snapshot != nil {
+		if size, err := resource.ParseQuantity(snapshot.RestoreSize); err == nil {
+			return size.Value()
+		}
+	}
+	return backup.Status.BackupSize
+}
+
+// CheckRestoreCapacity checks whether the volumes of the restored xstores are large enough for the xstore
+// backups, and the storage classes of the volume snapshots exist to provision the volumes. Capacity isn't
+// checked if it's nil.
+func CheckRestoreCapacity(ctx context.Context, r client.Reader, fldPath, capacityPath *field.Path,
+	capacity *resource.Quantity, xstoreBackups []polardbxv1.XStoreBackup) (field.ErrorList, error) {
+	var errList field.ErrorList
+	storageClasses := make(map[string]bool)
+	for i := range xstoreBackups {
+		backup := &xstoreBackups[i]
+		if size := restoreSizeOf(backup); capacity != nil && size > capacity.Value() {
+			errList = append(errList, field.Invalid(capacityPath, capacity.String(),
+				fmt.Sprintf("less than %s required by xstore backup %s",
+					resource.NewQuantity(size, resource.BinarySI).String(), backup.Name)))
+		}
+
+		snapshot := backup.Status.VolumeSnapshot
+		if snapshot == nil || len(snapshot.StorageClassName) == 0 {
+			continue
+		}
+		found, checked := storageClasses[snapshot.StorageClassName]
+		if !checked {
+			err := r.Get(ctx, types.NamespacedName{Name: snapshot.StorageClassName}, &storagev1.StorageClass{})
+			if ignoreForbidden(client.IgnoreNotFound(err)) != nil {
+				return nil, err
+			}
+			found = !apierrors.IsNotFound(err)
+			storageClasses[snapshot.StorageClassName] = found
+		}
+		if !found {
+			errList = append(errList, field.Invalid(fldPath, backup.Name,
+				"storage class "+snapshot.StorageClassName+" of the volume snapshot isn't found"))
+		}
+	}
+	return errList, nil
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preflight
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+)
+
+type storageClassReader struct {
+	client.Reader
+	classes map[string]bool
+}
+
+func (r *storageClassReader) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	if _, ok := obj.(*storagev1.StorageClass); !ok || !r.classes[key.Name] {
+		return apierrors.NewNotFound(schema.GroupResource{}, key.Name)
+	}
+	return nil
+}
+
+func TestCheckBackupSetComplete(t *testing.T) {
+	fldPath := field.NewPath("spec", "restore", "backupset")
+	backup := &polardbxv1.PolarDBXBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup"},
+		Status:     polardbxv1.PolarDBXBackupStatus{Phase: polardbxv1.BackupFinished},
+	}
+	if err := CheckBackupSetComplete(fldPath, backup); err != nil {
+		t.Fatalf("expect finished backup complete, got %v", err)
+	}
+
+	backup.Spec.XStores = []string{"dn-0"}
+	if err := CheckBackupSetComplete(fldPath, backup); err == nil {
+		t.Fatal("expect backup of xstore group incomplete")
+	}
+
+	backup.Spec.XStores = nil
+	backup.Status.Phase = polardbxv1.BinlogBackuping
+	if err := CheckBackupSetComplete(fldPath, backup); err == nil {
+		t.Fatal("expect unfinished backup incomplete")
+	}
+}
+
+func TestCheckRestoreTimeCovered(t *testing.T) {
+	fldPath := field.NewPath("spec", "restore", "time")
+	latest := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	backup := &polardbxv1.PolarDBXBackup{
+		Status: polardbxv1.PolarDBXBackupStatus{
+			LatestRecoverableTimestamp: &metav1.Time{Time: latest},
+		},
+	}
+
+	if err := CheckRestoreTimeCovered(fldPath, "", latest.Add(-time.Minute), backup, true); err != nil {
+		t.Fatalf("expect restore time covered, got %v", err)
+	}
+	if err := CheckRestoreTimeCovered(fldPath, "", latest.Add(time.Minute), backup, true); err == nil {
+		t.Fatal("expect restore time after the latest recoverable time not covered")
+	}
+
+	backup.Spec.Engine = polardbxv1.BackupEngineVolumeSnapshot
+	if err := CheckRestoreTimeCovered(fldPath, "", latest.Add(time.Minute), backup, false); err != nil {
+		t.Fatalf("expect volume snapshot restorable at its time, got %v", err)
+	}
+	if err := CheckRestoreTimeCovered(fldPath, "", latest.Add(-time.Minute), backup, true); err == nil {
+		t.Fatal("expect point in time restore from volume snapshot rejected")
+	}
+}
+
+func TestCheckRestoreCapacity(t *testing.T) {
+	r := &storageClassReader{classes: map[string]bool{"ssd": true}}
+	fldPath := field.NewPath("spec", "restore", "backupset")
+	capacityPath := field.NewPath("spec", "topology", "nodes", "dn", "template", "diskQuota")
+	capacity := resource.MustParse("10Gi")
+	xstoreBackups := []polardbxv1.XStoreBackup{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "full"},
+			Status:     polardbxv1.XStoreBackupStatus{BackupSize: 5 << 30},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "snapshot"},
+			Status: polardbxv1.XStoreBackupStatus{
+				VolumeSnapshot: &polardbxv1.BackupVolumeSnapshotStatus{
+					StorageClassName: "ssd",
+					RestoreSize:      "8Gi",
+				},
+			},
+		},
+	}
+
+	errList, err := CheckRestoreCapacity(context.Background(), r, fldPath, capacityPath, &capacity, xstoreBackups)
+	if err != nil || len(errList) > 0 {
+		t.Fatalf("expect capacity enough, got %v, %v", errList, err)
+	}
+
+	capacity = resource.MustParse("6Gi")
+	errList, _ = CheckRestoreCapacity(context.Background(), r, fldPath, capacityPath, &capacity, xstoreBackups)
+	if len(errList) != 1 || errList[0].Field != capacityPath.String() {
+		t.Fatalf("expect snapshot larger than capacity, got %v", errList)
+	}
+
+	errList, _ = CheckRestoreCapacity(context.Background(), r, fldPath, nil, nil, xstoreBackups)
+	if len(errList) > 0 {
+		t.Fatalf("expect capacity unchecked, got %v", errList)
+	}
+
+	xstoreBackups[1].Status.VolumeSnapshot.StorageClassName = "absent"
+	errList, _ = CheckRestoreCapacity(context.Background(), r, fldPath, nil, nil, xstoreBackups)
+	if len(errList) != 1 || errList[0].Field != fldPath.String() {
+		t.Fatalf("expect storage class not found, got %v", errList)
+	}
+}
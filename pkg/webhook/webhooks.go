@@ -24,7 +24,9 @@ import (
 
 	"github.com/alibaba/polardbx-operator/pkg/webhook/knobs"
 	"github.com/alibaba/polardbx-operator/pkg/webhook/parameter"
+	"github.com/alibaba/polardbx-operator/pkg/webhook/polardbxbackup"
 	"github.com/alibaba/polardbx-operator/pkg/webhook/polardbxcluster"
+	"github.com/alibaba/polardbx-operator/pkg/webhook/xstorebackup"
 )
 
 const ApiPath = "/apis/admission.polardbx.aliyun.com/v1"
@@ -51,5 +53,13 @@ func SetupWebhooks(ctx context.Context, mgr ctrl.Manager, configPath string) err
 		return err
 	}
 
+	if err := xstorebackup.SetupWebhooks(ctx, mgr, ApiPath); err != nil {
+		return err
+	}
+
+	if err := polardbxbackup.SetupWebhooks(ctx, mgr, configPath, ApiPath); err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -0,0 +1,120 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package xstorebackup
+
+import (
+	"context"
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	xstorev1 "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+	"github.com/alibaba/polardbx-operator/pkg/webhook/extension"
+	"github.com/alibaba/polardbx-operator/pkg/webhook/preflight"
+)
+
+type Validator struct {
+	client.Reader
+}
+
+// isXStoreBackupable tells if the backup of xstore can be started, i.e. the xstore has been
+// created and is not going away.
+func isXStoreBackupable(xstore *polardbxv1.XStore) bool {
+	if !xstore.DeletionTimestamp.IsZero() {
+		return false
+	}
+	switch xstore.Status.Phase {
+	case xstorev1.PhaseNew, xstorev1.PhasePending, xstorev1.PhaseCreating, xstorev1.PhaseDeleting, xstorev1.PhaseFailed:
+		return false
+	}
+	return true
+}
+
+// ValidateCreate rejects backups of xstore which does not exist or is not backupable, or notifying
+// webhooks which are not public. Clones never
+// touch the xstore and are not checked, nor backups with the skip annotation, e.g. created by
+// schedulers which may race with the creation of xstore.
+func (v *Validator) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	backup := obj.(*polardbxv1.XStoreBackup)
+	gvk := backup.GroupVersionKind()
+	if len(backup.Spec.BaseBackupName) > 0 && backup.Spec.BackupType != polardbxv1.BackupTypeIncremental {
+		return apierrors.NewInvalid(gvk.GroupKind(), backup.Name, field.ErrorList{
+			field.Invalid(field.NewPath("spec", "baseBackupName"), backup.Spec.BaseBackupName,
+				"base backup is only for incremental backups"),
+		})
+	}
+	if errList := preflight.CheckNotifications(field.NewPath("spec", "notifications"), backup.Spec.Notifications); len(errList) > 0 {
+		return apierrors.NewInvalid(gvk.GroupKind(), backup.Name, errList)
+	}
+	if backup.Spec.CopyFrom != nil || backup.Annotations[polardbxmeta.AnnotationBackupSkipXStoreCheck] == "true" {
+		return nil
+	}
+
+	fieldPath := field.NewPath("spec", "xstore", "name")
+	xstore := &polardbxv1.XStore{}
+	err := v.Get(ctx, types.NamespacedName{Namespace: backup.Namespace, Name: backup.Spec.XStore.Name}, xstore)
+	if apierrors.IsNotFound(err) {
+		return apierrors.NewInvalid(gvk.GroupKind(), backup.Name, field.ErrorList{
+			field.NotFound(fieldPath, backup.Spec.XStore.Name),
+		})
+	} else if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+	if !isXStoreBackupable(xstore) {
+		return apierrors.NewForbidden(
+			schema.GroupResource{Group: gvk.Group, Resource: gvk.Kind}, backup.Name,
+			field.Forbidden(fieldPath, "xstore is not backupable in phase \""+string(xstore.Status.Phase)+
+				"\", set annotation "+polardbxmeta.AnnotationBackupSkipXStoreCheck+" to skip the check"))
+	}
+	return nil
+}
+
+// ValidateUpdate rejects spec edits of finished backups, except the retention ones which never
+// re-trigger the upload and only decide when the backup is removed.
+func (v *Validator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	oldBackup, newBackup := oldObj.(*polardbxv1.XStoreBackup), newObj.(*polardbxv1.XStoreBackup)
+	if oldBackup.Status.Phase != polardbxv1.XStoreBackupFinished {
+		return nil
+	}
+
+	spec := oldBackup.Spec.DeepCopy()
+	spec.RetentionTime = newBackup.Spec.RetentionTime
+	spec.Retention = newBackup.Spec.Retention
+	if !reflect.DeepEqual(*spec, newBackup.Spec) {
+		gvk := oldBackup.GroupVersionKind()
+		return apierrors.NewForbidden(
+			schema.GroupResource{Group: gvk.Group, Resource: gvk.Kind}, newBackup.Name,
+			field.Forbidden(field.NewPath("spec"), "backup is finished and sealed, only retention is mutable"))
+	}
+
+	return nil
+}
+
+func (v *Validator) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	return nil
+}
+
+func NewValidator(r client.Reader) extension.CustomValidator {
+	return &Validator{Reader: r}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2022 Alibaba Group Holding Limited.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package xstorebackup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	polardbxv1 "github.com/alibaba/polardbx-operator/api/v1"
+	xstorev1 "github.com/alibaba/polardbx-operator/api/v1/xstore"
+	polardbxmeta "github.com/alibaba/polardbx-operator/pkg/operator/v1/polardbx/meta"
+)
+
+type xstoreReader struct {
+	client.Reader
+	xstores map[string]*polardbxv1.XStore
+}
+
+func (r *xstoreReader) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	xstore, ok := r.xstores[key.Name]
+	if !ok {
+		return apierrors.NewNotFound(schema.GroupResource{}, key.Name)
+	}
+	xstore.DeepCopyInto(obj.(*polardbxv1.XStore))
+	return nil
+}
+
+func TestValidator_ValidateCreate(t *testing.T) {
+	v := NewValidator(&xstoreReader{xstores: map[string]*polardbxv1.XStore{
+		"running":  {Status: polardbxv1.XStoreStatus{Phase: xstorev1.PhaseRunning}},
+		"creating": {Status: polardbxv1.XStoreStatus{Phase: xstorev1.PhaseCreating}},
+	}})
+	backupOf := func(xstore string) *polardbxv1.XStoreBackup {
+		return &polardbxv1.XStoreBackup{Spec: polardbxv1.XStoreBackupSpec{
+			XStore: polardbxv1.XStoreReference{Name: xstore},
+		}}
+	}
+
+	if err := v.ValidateCreate(context.Background(), backupOf("running")); err != nil {
+		t.Fatalf("expect running xstore backupable, got %v", err)
+	}
+	if err := v.ValidateCreate(context.Background(), backupOf("creating")); err == nil {
+		t.Fatal("expect creating xstore not backupable")
+	}
+	if err := v.ValidateCreate(context.Background(), backupOf("absent")); !apierrors.IsInvalid(err) {
+		t.Fatalf("expect absent xstore rejected, got %v", err)
+	}
+
+	backup := backupOf("absent")
+	backup.Annotations = map[string]string{polardbxmeta.AnnotationBackupSkipXStoreCheck: "true"}
+	if err := v.ValidateCreate(context.Background(), backup); err != nil {
+		t.Fatalf("expect check skipped, got %v", err)
+	}
+
+	backup = backupOf("running")
+	backup.Spec.Notifications = []polardbxv1.BackupNotification{{Name: "metadata", Webhook: "http://169.254.169.254/"}}
+	if err := v.ValidateCreate(context.Background(), backup); !apierrors.IsInvalid(err) {
+		t.Fatalf("expect webhook of link-local address rejected, got %v", err)
+	}
+
+	backup = backupOf("running")
+	backup.Spec.BaseBackupName = "base"
+	if err := v.ValidateCreate(context.Background(), backup); !apierrors.IsInvalid(err) {
+		t.Fatalf("expect base of full backup rejected, got %v", err)
+	}
+	backup.Spec.BackupType = polardbxv1.BackupTypeIncremental
+	if err := v.ValidateCreate(context.Background(), backup); err != nil {
+		t.Fatalf("expect incremental backup with base accepted, got %v", err)
+	}
+}
+
+func TestValidator_ValidateUpdate(t *testing.T) {
+	v := NewValidator(nil)
+	oldBackup := &polardbxv1.XStoreBackup{
+		Spec: polardbxv1.XStoreBackupSpec{
+			XStore: polardbxv1.XStoreReference{Name: "xs"},
+			StorageProvider: polardbxv1.BackupStorageProvider{
+				StorageName: polardbxv1.OSS,
+				Sink:        "default",
+			},
+		},
+		Status: polardbxv1.XStoreBackupStatus{Phase: polardbxv1.XStoreBackupFinished},
+	}
+
+	newBackup := oldBackup.DeepCopy()
+	newBackup.Spec.RetentionTime = metav1.Duration{Duration: time.Hour}
+	if err := v.ValidateUpdate(context.Background(), oldBackup, newBackup); err != nil {
+		t.Fatalf("expect retention mutable, got %v", err)
+	}
+
+	newBackup = oldBackup.DeepCopy()
+	newBackup.Spec.StorageProvider.Sink = "another"
+	if err := v.ValidateUpdate(context.Background(), oldBackup, newBackup); err == nil {
+		t.Fatal("expect storage provider immutable after finished")
+	}
+
+	oldBackup.Status.Phase = polardbxv1.XStoreBinlogWaiting
+	if err := v.ValidateUpdate(context.Background(), oldBackup, newBackup); err != nil {
+		t.Fatalf("expect mutable before finished, got %v", err)
+	}
+}
@@ -17,6 +17,7 @@ import os.path
 import re
 import subprocess
 import shutil
+import threading
 
 import click
 
@@ -24,6 +25,7 @@ from core.context import Context
 from core.convention import *
 from core.log import LogFactory
 from core.backup_restore.storage.filestream_client import FileStreamClient, BackupStorage
+from core.backup_restore.utils import ProgressReporter, StreamCounter, engine_compatibility
 
 
 @click.group(name="backup")
@@ -41,6 +43,23 @@ def start_backup(backup_context, job_name):
         fullbackup_path = params["fullBackupPath"]
         storage_name = params["storageName"]
         sink = params["sink"]
+        enable_dedup_report = params.get("enableDedupReport", False)
+        chunk_manifest_path = params.get("chunkManifestPath", "")
+        base_manifest_path = params.get("baseManifestPath", "")
+        overlap_collect = params.get("overlapCollect", False)
+        storage_class = params.get("storageClass", "")
+        threads = params.get("fullBackupThreads", 0)
+        upload_retries = params.get("uploadRetries", 0)
+        upload_retry_backoff = params.get("uploadRetryBackoff", "")
+        consistency_wait_timeout = params.get("consistencyWaitTimeout", 0)
+        consistency_wait_interval = params.get("consistencyWaitInterval", 1.0)
+        consistency_mode = params.get("consistencyMode", CONSISTENCY_MODE_DEFAULT)
+        incremental_lsn = params.get("incrementalLsn", "")
+        # only the full backup stream is encrypted, the metadata e.g. chunk manifest is uploaded as is
+        encryption_key_file = params.get("encryptionKeyFile", "")
+        max_iops = params.get("maxIOPS", 0)
+        upload_rate_limit = params.get("uploadRateLimit", 0)
+        keyring_path = params.get("keyringPath", "")
 
     try:
         logger.info('start backup')
@@ -50,31 +69,111 @@ def start_backup(backup_context, job_name):
         if os.path.exists(backup_dir):
             shutil.rmtree(backup_dir)
         os.mkdir(backup_dir)
+        err_path = "/data/mysql/tmp/" + job_name + ".err"
+        if os.path.exists(err_path):
+            os.remove(err_path)
 
+        # copy the data files in parallel threads, the stream is still a single xbstream
+        parallel_opts = ["--parallel=%d" % threads] if threads > 1 else []
+        # bound the io of copying data files, so that the queries on the node are not starved
+        throttle_opts = ["--throttle=%d" % max_iops] if max_iops > 0 else []
         backup_cmd = ""
+        lock_opts, consistency = get_consistency_lock_opts(context, consistency_mode, logger)
+        lsn_dir = "/data/mysql/tmp/" + job_name + ".lsn"
+        if os.path.exists(lsn_dir):
+            shutil.rmtree(lsn_dir)
+        incremental_opts = get_incremental_opts(context, incremental_lsn, lsn_dir)
         if context.is_galaxy80():
-            backup_cmd = [context.xtrabackup,
-                          "--stream=xbstream",
-                          "--socket=" + sockfile,
-                          "--slave-info",
-                          "--backup", "--lock-ddl"]
+            backup_cmd = [context.xtrabackup] + get_keyring_opts(context) + [
+                "--stream=xbstream",
+                "--socket=" + sockfile,
+                "--slave-info",
+                "--backup"] + lock_opts + parallel_opts + throttle_opts + incremental_opts
         elif context.is_xcluster57():
             backup_cmd = [context.xtrabackup,
                           "--stream=xbstream",
-                          "--socket=" + sockfile,
-                          backup_dir]
+                          "--socket=" + sockfile] + parallel_opts + throttle_opts + incremental_opts + [backup_dir]
         logger.info("backup_cmd: %s " % backup_cmd)
 
         stderr_path = backup_dir + '/fullbackup-stderr.out'
         upload_stderr_path = backup_dir + '/upload.out'
         stderr_outfile = open(stderr_path, 'w+')
         upload_stderr_outfile = open(upload_stderr_path, 'w+')
-        filestream_client = FileStreamClient(context, BackupStorage[str.upper(storage_name)], sink)
+        filestream_client = FileStreamClient(context, BackupStorage[str.upper(storage_name)], sink,
+                                             upload_retries=upload_retries, upload_retry_backoff=upload_retry_backoff,
+                                             consistency_wait_timeout=consistency_wait_timeout,
+                                             consistency_wait_interval=consistency_wait_interval,
+                                             upload_rate_limit=upload_rate_limit)
+        watcher_stop = threading.Event()
+        watcher = None
+        if overlap_collect:
+            # the operator starts collecting binlog once the commit index is written
+            watcher = threading.Thread(target=watch_binlog_commit_index,
+                                       args=(job_name, stderr_path, watcher_stop, logger), daemon=True)
+            watcher.start()
+        chunksum_returncode = 0
         with subprocess.Popen(backup_cmd, bufsize=8192, stdout=subprocess.PIPE, stderr=stderr_outfile, close_fds=True) as pipe:
-            filestream_client.upload_from_stdin(remote_path=fullbackup_path, stdin=pipe.stdout,
-                                                stderr=upload_stderr_outfile, logger=logger)
+            # the bytes transferred are collected by operator into the progress of backup
+            progress = ProgressReporter("/data/mysql/tmp/" + job_name + ".progress")
+            counter = StreamCounter(pipe.stdout, progress=progress)
+            counter.start()
+            if enable_dedup_report:
+                chunksum_cmd = get_chunksum_cmd(context, job_name, backup_dir, base_manifest_path,
+                                                filestream_client, logger)
+                with subprocess.Popen(chunksum_cmd, bufsize=8192, stdin=counter.stdout, stdout=subprocess.PIPE,
+                                      stderr=upload_stderr_outfile, close_fds=True) as chunksum_pipe:
+                    upload_returncode = filestream_client.upload_from_stdin(remote_path=fullbackup_path,
+                                                                            stdin=chunksum_pipe.stdout,
+                                                                            stderr=upload_stderr_outfile,
+                                                                            logger=logger,
+                                                                            storage_class=storage_class,
+                                                                            threads=threads,
+                                                                            encryption_key_file=encryption_key_file)
+                    chunksum_pipe.stdout.close()
+                chunksum_returncode = chunksum_pipe.returncode
+            else:
+                upload_returncode = filestream_client.upload_from_stdin(remote_path=fullbackup_path,
+                                                                        stdin=counter.stdout,
+                                                                        stderr=upload_stderr_outfile, logger=logger,
+                                                                        storage_class=storage_class,
+                                                                        threads=threads,
+                                                                        encryption_key_file=encryption_key_file)
+            counter.join()
+            counter.stdout.close()
             pipe.stdout.close()
+        watcher_stop.set()
+        if watcher:
+            watcher.join()
+        errors = collect_backup_errors(stderr_path, pipe.returncode, upload_returncode, chunksum_returncode)
+        if errors:
+            # the errors are collected by operator as the failure message of the backup
+            with open(err_path, mode='w+', encoding='utf-8') as f:
+                f.write("\n".join(errors))
+            raise Exception("full backup failed: %s" % "; ".join(errors))
+        filestream_client.ensure_durable(fullbackup_path, logger=logger)
+        if keyring_path:
+            upload_keyring(context, filestream_client, keyring_path, upload_stderr_outfile, encryption_key_file,
+                           logger)
         get_binlog_commit_index(job_name, stderr_path, logger)
+        # the size is collected by operator to enforce the retention budget
+        with open("/data/mysql/tmp/" + job_name + ".size", mode='w+', encoding='utf-8') as f:
+            f.write(str(counter.count))
+        logger.info("backup size: %d" % counter.count)
+        write_engine_compatibility(context, "/data/mysql/tmp/" + job_name + ".compat", logger)
+        # the consistency mode used is collected by operator into the backup status
+        with open("/data/mysql/tmp/" + job_name + ".consistency", mode='w+', encoding='utf-8') as f:
+            json.dump(consistency, f)
+        if enable_dedup_report:
+            filestream_client.upload_from_file(remote=chunk_manifest_path,
+                                               local=os.path.join(backup_dir, "manifest.chunks"),
+                                               stderr=upload_stderr_outfile, logger=logger)
+            logger.info("chunk manifest uploaded")
+        if upload_retries > 0:
+            # the retried objects are collected by operator into the upload retry stats
+            filestream_client.write_retried_objects("/data/mysql/tmp/" + job_name + ".retries")
+        if consistency_wait_timeout > 0:
+            # the waited objects are collected by operator into the consistency wait stats
+            filestream_client.write_waited_objects("/data/mysql/tmp/" + job_name + ".waits")
         logger.info("backup upload finished")
 
     except Exception as e:
@@ -82,6 +181,121 @@ def start_backup(backup_context, job_name):
         raise e
 
 
+CONSISTENCY_MODE_DEFAULT = "Default"
+CONSISTENCY_MODE_SNAPSHOT_LOCK = "SnapshotLock"
+
+
+def get_consistency_lock_opts(context, consistency_mode, logger):
+    # with snapshot lock, xtrabackup takes the engine's backup lock only for the final metadata phase,
+    # fall back to the default locking if it's not supported by the engine or xtrabackup
+    default_opts = ["--lock-ddl"] if context.is_galaxy80() else []
+    if consistency_mode != CONSISTENCY_MODE_SNAPSHOT_LOCK:
+        return default_opts, {"mode": CONSISTENCY_MODE_DEFAULT}
+    reason = ""
+    if not context.is_galaxy80():
+        reason = "backup lock is not supported by engine"
+    else:
+        try:
+            usage = subprocess.run([context.xtrabackup, "--help"], stdout=subprocess.PIPE,
+                                   stderr=subprocess.STDOUT, universal_newlines=True).stdout
+            if "REDUCED" not in usage:
+                reason = "reduced ddl lock is not supported by xtrabackup"
+        except Exception as e:
+            reason = "failed to check xtrabackup: %s" % e
+    if reason:
+        logger.info("snapshot lock unavailable, fall back to default: %s" % reason)
+        return default_opts, {"mode": CONSISTENCY_MODE_DEFAULT, "reason": reason}
+    return ["--lock-ddl=REDUCED"], {"mode": CONSISTENCY_MODE_SNAPSHOT_LOCK}
+
+
+def get_keyring_opts(context):
+    # the keyring plugin is loaded with the same config as the engine, so that the encrypted tablespaces can be read
+    if not context.tde_enabled():
+        return []
+    opts = ["--defaults-file=" + context.mycnf_path]
+    keyring_password_config = context.keyring_password_config()
+    if keyring_password_config:
+        opts.append("--defaults-extra-file=" + keyring_password_config)
+    return opts
+
+
+def upload_keyring(context, filestream_client, keyring_path, stderr, encryption_key_file, logger):
+    # the keyring isn't copied by xtrabackup, it's required to prepare the backup of encrypted tablespaces
+    upload_returncode = filestream_client.upload_from_file(remote=keyring_path, local=context.keyring_path(),
+                                                           stderr=stderr, logger=logger,
+                                                           encryption_key_file=encryption_key_file)
+    if upload_returncode != 0:
+        raise Exception("failed to upload keyring, exit code: %d" % upload_returncode)
+    logger.info("keyring uploaded")
+
+
+def get_incremental_opts(context, incremental_lsn, lsn_dir):
+    # the checkpoints are written to lsn_dir besides the stream, whose to_lsn is collected by operator as
+    # the lsn of the backup. with incremental lsn, only the pages changed since it are copied
+    opts = ["--extra-lsndir=" + lsn_dir]
+    if incremental_lsn:
+        if context.is_xcluster57():
+            opts.append("--incremental")
+        opts.append("--incremental-lsn=" + incremental_lsn)
+    return opts
+
+
+def write_engine_compatibility(context, path, logger):
+    # the compatibility is checked by operator before restoring, it's optional and never fails the backup
+    try:
+        with open(path, mode='w+', encoding='utf-8') as f:
+            json.dump(engine_compatibility(context), f)
+    except Exception as e:
+        logger.info("failed to write engine compatibility: %s" % e)
+
+
+def collect_backup_errors(stderr_path, backup_returncode, upload_returncode, chunksum_returncode, limit=10):
+    # errors of all the copy threads are reported in stderr of xtrabackup, keep the distinct ones
+    errors = []
+    if backup_returncode != 0:
+        with open(stderr_path, 'r') as file:
+            for line in file.read().splitlines():
+                line = line.strip()
+                if "[ERROR]" in line and line not in errors:
+                    errors.append(line)
+        errors = errors[:limit]
+        errors.append("xtrabackup exited with %d" % backup_returncode)
+    if upload_returncode != 0:
+        errors.append("upload exited with %d" % upload_returncode)
+    if chunksum_returncode != 0:
+        errors.append("chunksum exited with %d" % chunksum_returncode)
+    return errors
+
+
+def get_chunksum_cmd(context, job_name, backup_dir, base_manifest_path, filestream_client, logger):
+    # the dedup report is written to /data/mysql/tmp/<job_name>.dedup and collected by operator
+    chunksum_cmd = [context.bb_home, "chunksum",
+                    "--manifest", os.path.join(backup_dir, "manifest.chunks"),
+                    "--report", "/data/mysql/tmp/" + job_name + ".dedup"]
+    if base_manifest_path:
+        base_manifest = os.path.join(backup_dir, "base.chunks")
+        filestream_client.download_to_file(remote=base_manifest_path, local=base_manifest, logger=logger)
+        if os.path.exists(base_manifest) and os.path.getsize(base_manifest) > 0:
+            chunksum_cmd += ["--base", base_manifest]
+        else:
+            logger.info("base chunk manifest not found: %s" % base_manifest_path)
+    logger.info("chunksum_cmd: %s " % chunksum_cmd)
+    return chunksum_cmd
+
+
+def watch_binlog_commit_index(job_name, stderr_path, stop, logger):
+    # xtrabackup prints the binlog position once the consistent point is captured, which is
+    # before the stream is fully uploaded
+    while not stop.wait(1):
+        with open(stderr_path, 'r') as file:
+            stderr_text = file.read()
+        m = re.search(r"MySQL binlog position:.*consensus_apply_index:'\d+'", stderr_text, re.S)
+        if m:
+            get_binlog_commit_index(job_name, stderr_path, logger)
+            logger.info("commit index written before upload finished")
+            return
+
+
 def get_binlog_commit_index(job_name, stderr_path, logger):
     # parse stderr to get commit_index
     with open(stderr_path,'r') as file:
@@ -96,7 +310,10 @@ def get_binlog_commit_index(job_name, stderr_path, logger):
             break
         elif re.search('MySQL slave binlog position:', stderr_text_lines[i]):
             slave_pos = i
-    binlog_line = " ".join(stderr_text_lines[binlog_pos:slave_pos])
+    if slave_pos >= 0:
+        binlog_line = " ".join(stderr_text_lines[binlog_pos:slave_pos])
+    else:
+        binlog_line = " ".join(stderr_text_lines[binlog_pos:])
     # following is for xdb
     slave_status = {}
     m = re.search(r"role: '(\S*)'", binlog_line)
@@ -108,11 +325,14 @@ def get_binlog_commit_index(job_name, stderr_path, logger):
     m = re.search(r"consensus_apply_index:'(\d+)'", binlog_line)
     if m:
         slave_status['CONSENSUS_APPLY_INDEX'] = m.group(1)
-    with open("/data/mysql/tmp/" + job_name + ".idx", mode='w+', encoding='utf-8') as f:
+    # write and rename, the index may be read by operator while the backup is running
+    idx_path = "/data/mysql/tmp/" + job_name + ".idx"
+    with open(idx_path + ".tmp", mode='w+', encoding='utf-8') as f:
         if slave_status['CONSENSUS_APPLY_INDEX'] == "0" or slave_status['CONSENSUS_APPLY_INDEX'] == "1":
             f.write(slave_status['COMMIT_INDEX'])
         else:
             f.write(slave_status['CONSENSUS_APPLY_INDEX'])
+    os.replace(idx_path + ".tmp", idx_path)
     logger.info("binlog line parsed: %s" % slave_status)
 
 
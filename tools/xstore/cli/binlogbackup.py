@@ -22,7 +22,9 @@ from core.context import Context
 from core.log import LogFactory
 from core.convention import *
 from core.backup_restore.xstore_binlog import XStoreBinlog
+from core.backup_restore.binlog_manifest import new_generation, binlog_object_path, commit_binlog_manifest
 from core.backup_restore.storage.filestream_client import FileStreamClient, BackupStorage
+from core.backup_restore.utils import ProgressReporter
 
 
 @click.group(name="binlogbackup")
@@ -45,6 +47,14 @@ def start_binlogbackup(backup_context,  start_index, gms_label, xstore_name):
         remote_binlog_backup_dir = params["binlogBackupDir"]
         storage_name = params["storageName"]
         sink = params["sink"]
+        skip_empty_binlog = params.get("skipEmptyBinlog", False)
+        storage_class = params.get("storageClass", "")
+        upload_retries = params.get("uploadRetries", 0)
+        upload_retry_backoff = params.get("uploadRetryBackoff", "")
+        consistency_wait_timeout = params.get("consistencyWaitTimeout", 0)
+        consistency_wait_interval = params.get("consistencyWaitInterval", 1.0)
+        encryption_key_file = params.get("encryptionKeyFile", "")
+        upload_rate_limit = params.get("uploadRateLimit", 0)
 
     logger.info("start binlog backup")
     context = Context()
@@ -54,9 +64,20 @@ def start_binlogbackup(backup_context,  start_index, gms_label, xstore_name):
     backup_dir = context.volume_path(VOLUME_DATA, 'backup')
     local_binlog_backup_dir = os.path.join(backup_dir, "binlogbackup")
 
-    filestream_client = FileStreamClient(context, BackupStorage[str.upper(storage_name)], sink)
+    filestream_client = FileStreamClient(context, BackupStorage[str.upper(storage_name)], sink,
+                                         upload_retries=upload_retries, upload_retry_backoff=upload_retry_backoff,
+                                         consistency_wait_timeout=consistency_wait_timeout,
+                                         consistency_wait_interval=consistency_wait_interval,
+                                         upload_rate_limit=upload_rate_limit)
 
     os.makedirs(local_binlog_backup_dir, exist_ok=True)
+    # remove the stale one of the last backup, it's optional
+    upload_retries_path = os.path.join(local_binlog_backup_dir, "upload_retries")
+    consistency_waits_path = os.path.join(local_binlog_backup_dir, "consistency_waits")
+    progress_path = os.path.join(local_binlog_backup_dir, "progress")
+    for stale_path in [upload_retries_path, consistency_waits_path, progress_path]:
+        if os.path.exists(stale_path):
+            os.remove(stale_path)
 
     # 获取binlog的起始文件和最终文件
     min_log_name = get_min_log_name(context, log_dir, start_index, logger)
@@ -71,17 +92,42 @@ def start_binlogbackup(backup_context,  start_index, gms_label, xstore_name):
     # 将可上传的binlog上传
     binlog_list = binlog.get_local_binlog(min_binlog_name=min_log_name, max_binglog_name=max_log_name,
                                           left_contain=True, right_contain=False)
-    upload_binlog_info(binlog_list, log_dir, remote_binlog_backup_dir, filestream_client, logger)
-    truncate_and_upload_binlog_info(context, log_dir, local_binlog_backup_dir, remote_binlog_backup_dir,
-                                    filestream_client, max_log_name, max_log_index, logger)
+    events_count = count_binlog_events(context, log_dir, binlog_list, logger)
+    # the bytes transferred are collected by operator into the progress of backup, the truncated
+    # binlog is estimated by its end offset
+    total_bytes = sum(os.path.getsize(os.path.join(log_dir, log_name)) for log_name, _ in binlog_list)
+    progress = ProgressReporter(progress_path, total=total_bytes + int(max_log_index))
+    progress.report()
+    # binlogs are uploaded into a new generation, which is visible to readers only after committed
+    generation = new_generation()
+    logger.info("binlog backup generation: %s" % generation)
+    upload_binlog_info(binlog_list, log_dir, remote_binlog_backup_dir, generation, filestream_client, logger,
+                       storage_class=storage_class, encryption_key_file=encryption_key_file, progress=progress)
+    tail_events_count, tail_uploaded = truncate_and_upload_binlog_info(
+        context, log_dir, local_binlog_backup_dir, remote_binlog_backup_dir, generation, filestream_client,
+        max_log_name, max_log_index, logger, skip_empty=skip_empty_binlog and events_count == 0,
+        storage_class=storage_class, encryption_key_file=encryption_key_file, progress=progress)
+    progress.report()
+    events_count += tail_events_count
+    logger.info("binlog events count: %d" % events_count)
+    with open(os.path.join(local_binlog_backup_dir, "events_count"), 'w') as f:  # use to display in pxb
+        f.write(str(events_count))
+    # the operator interprets the datetime of binlog events in the server time zone with it
+    with open(os.path.join(local_binlog_backup_dir, "time_zone_offset"), 'w') as f:
+        f.write(str(binlog.get_time_zone_offset()))
 
     # 记录所有上传的binlog_name_list，用于后续恢复时下载binlog
     uploaded_binlog_list = [log_name for i, (log_name, start_log_index) in enumerate(binlog_list)]
-    if max_log_name not in uploaded_binlog_list:
+    if tail_uploaded and max_log_name not in uploaded_binlog_list:
         uploaded_binlog_list.append(max_log_name)
-    filestream_client.upload_from_string(remote=os.path.join(remote_binlog_backup_dir, "binlog_list"),
-                                         string='\n'.join(uploaded_binlog_list), logger=logger)
+    commit_binlog_manifest(filestream_client, remote_binlog_backup_dir, generation, uploaded_binlog_list, logger)
     logger.info("List of uploaded binlog:%s", uploaded_binlog_list)
+    if upload_retries > 0:
+        # the retried objects are collected by operator into the upload retry stats
+        filestream_client.write_retried_objects(upload_retries_path)
+    if consistency_wait_timeout > 0:
+        # the waited objects are collected by operator into the consistency wait stats
+        filestream_client.write_waited_objects(consistency_waits_path)
 
     logger.info("upload finished")
 
@@ -133,8 +179,37 @@ def get_max_log_from_cp(filestream_client, indexes_path, binlog_backup_dir, xsto
     return max_log_info.split(':')[0], max_log_info.split(':')[1]
 
 
-def truncate_and_upload_binlog_info(context, log_dir, binlogbackup_dir, binlogbackupdir_path, filestream_client,
-                                    max_log_name, max_log_index, logger):
+def parse_events_count(output):
+    res = re.search(r"EVENTS COUNT:\s*(\d+)", output)
+    return int(res.group(1)) if res else 0
+
+
+def count_binlog_events(context, log_dir, binlog_list, logger):
+    """
+    count the change events of the whole binlog files, the binlog files are scanned till the end
+    """
+    count = 0
+    for log_name, start_log_index in binlog_list:
+        binlog_file_path = os.path.join(log_dir, log_name)
+        count_cmd = "%s truncate %s --end-offset %d -o /dev/null" % (context.bb_home, binlog_file_path,
+                                                                   os.path.getsize(binlog_file_path))
+        logger.info("count_cmd:" + count_cmd)
+        count += parse_events_count(subprocess.check_output(count_cmd, shell=True).decode("utf-8"))
+    return count
+
+
+def truncate_and_upload_binlog_info(context, log_dir, binlogbackup_dir, binlogbackupdir_path, generation,
+                                    filestream_client, max_log_name, max_log_index, logger, skip_empty=False,
+                                    storage_class="", encryption_key_file="", progress=None):
+    """
+    truncate the max binlog to the consistent point and upload it into the generation
+
+    :param skip_empty: skip uploading the truncated binlog if it has no change events
+    :param storage_class: storage class of the uploaded binlog, empty means default
+    :param encryption_key_file: key file to encrypt the uploaded binlog with, empty means not encrypted
+    :param progress: reporter of the bytes transferred, if any
+    :return: the count of change events in the truncated binlog, and whether it's uploaded
+    """
     binlog_file_path = os.path.join(log_dir, max_log_name)
     truncated_log_name = "{}_trunc.{}".format(*max_log_name.split('.'))
     truncate_file_path = os.path.join(binlogbackup_dir, truncated_log_name)
@@ -142,10 +217,15 @@ def truncate_and_upload_binlog_info(context, log_dir, binlogbackup_dir, binlogba
     truncate_cmd = "%s truncate %s --end-offset %s -o %s" % (context.bb_home, binlog_file_path,
                                                              max_log_index, truncate_file_path)
     logger.info("truncate_cmd:" + truncate_cmd)
+    # never leave the timestamp and events count of a previous backup behind
+    for stale_path in [last_event_timestamp_path, os.path.join(binlogbackup_dir, "events_count"),
+                       os.path.join(binlogbackup_dir, "time_zone_offset")]:
+        if os.path.exists(stale_path):
+            os.remove(stale_path)
     with subprocess.Popen(truncate_cmd, shell=True, stdout=subprocess.PIPE) as pipe:
         logger.info("cut max log")
-        output = pipe.stdout.readline().decode("utf-8")
-        res = re.search(r"LAST EVENT TIMESTAMP: (\d+)", output)
+        output = pipe.stdout.read().decode("utf-8")
+        res = re.search(r"LAST EVENT TIMESTAMP:\s*(\d+)", output)
         if res:
             last_event_timestamp = res.group(1)
             logger.info("last event timestamp: " + last_event_timestamp)
@@ -153,16 +233,90 @@ def truncate_and_upload_binlog_info(context, log_dir, binlogbackup_dir, binlogba
                 f.write(last_event_timestamp)
             filestream_client.upload_from_file(remote=os.path.join(binlogbackupdir_path, "last_event_timestamp"),
                                                local=last_event_timestamp_path, logger=logger)
-    filestream_client.upload_from_file(remote=os.path.join(binlogbackupdir_path, max_log_name),
-                                       local=truncate_file_path, logger=logger)
+    events_count = parse_events_count(output)
+    if skip_empty and events_count == 0:
+        logger.info("skip uploading empty binlog: " + max_log_name)
+        return events_count, False
+    remote_path = binlog_object_path(binlogbackupdir_path, generation, max_log_name)
+    if filestream_client.upload_from_file(remote=remote_path, local=truncate_file_path, logger=logger,
+                                          storage_class=storage_class, encryption_key_file=encryption_key_file) != 0:
+        raise Exception("failed to upload binlog: " + remote_path)
+    if progress:
+        progress.add(os.path.getsize(truncate_file_path))
+    if os.path.getsize(truncate_file_path) > 0:
+        filestream_client.ensure_durable(remote_path, logger=logger)
+    return events_count, True
 
 
-def upload_binlog_info(binlog_list, log_dir, binlog_backup_dir_path, filestream_client, logger):
+def upload_binlog_info(binlog_list, log_dir, binlog_backup_dir_path, generation, filestream_client, logger,
+                       storage_class="", encryption_key_file="", progress=None):
     for i, (log_name, start_log_index) in enumerate(binlog_list):
         logger.info("log to upload:%s during binlog backup" % log_name)
         binlog_file_path = os.path.join(log_dir, log_name)
-        filestream_client.upload_from_file(remote=os.path.join(binlog_backup_dir_path, log_name),
-                                           local=binlog_file_path, logger=logger)
+        remote_path = binlog_object_path(binlog_backup_dir_path, generation, log_name)
+        # never commit a generation with a failed upload
+        if filestream_client.upload_from_file(remote=remote_path, local=binlog_file_path, logger=logger,
+                                              storage_class=storage_class,
+                                              encryption_key_file=encryption_key_file) != 0:
+            raise Exception("failed to upload binlog: " + remote_path)
+        if progress:
+            progress.add(os.path.getsize(binlog_file_path))
+        if os.path.getsize(binlog_file_path) > 0:
+            filestream_client.ensure_durable(remote_path, logger=logger)
+
+
+@click.command(name='ship')
+@click.option('--binlog_dir', required=True, type=str)
+@click.option('--storage_name', required=True, type=str)
+@click.option('--sink', required=True, type=str)
+@click.option('--last_binlog', default="", type=str)
+@click.option('--max_files', default=16, type=int)
+@click.option('-j', '--job_name', required=True, type=str)
+def ship_binlogs(binlog_dir, storage_name, sink, last_binlog, max_files, job_name):
+    """
+    upload the closed binlogs after the last shipped one, at most max_files of them. The index of each binlog,
+    i.e. the gtids and time range, is uploaded right after it, and the shipped ones are written for operator
+    to collect
+    """
+    logger = LogFactory.get_logger("binlogship.log")
+    context = Context()
+    binlog = XStoreBinlog(context.port_access())
+    log_dir = context.volume_path(VOLUME_DATA, "log")
+    filestream_client = FileStreamClient(context, BackupStorage[str.upper(storage_name)], sink)
+
+    result_path = "/data/mysql/tmp/" + job_name + ".ship"
+    if os.path.exists(result_path):
+        os.remove(result_path)
+
+    # the binlog being written is excluded, it's shipped once rotated
+    binlog_list = binlog.get_local_binlog(min_binlog_name=last_binlog if last_binlog else None,
+                                          left_contain=False, right_contain=False)
+    logger.info("binlogs to ship: %s" % binlog_list[:max_files])
+    shipped = []
+    for log_name, start_log_index in binlog_list[:max_files]:
+        binlog_file_path = os.path.join(log_dir, log_name)
+        gtid_range_cmd = [context.bb_home, "gtidrange", binlog_file_path]
+        logger.info("gtid_range_cmd: %s" % gtid_range_cmd)
+        index = json.loads(subprocess.check_output(gtid_range_cmd).decode("utf-8"))
+        index["name"] = log_name
+        index["size"] = os.path.getsize(binlog_file_path)
+
+        remote_path = os.path.join(binlog_dir, log_name)
+        if filestream_client.upload_from_file(remote=remote_path, local=binlog_file_path, logger=logger) != 0:
+            raise Exception("failed to upload binlog: " + remote_path)
+        index_path = remote_path + ".index"
+        if filestream_client.upload_from_string(remote=index_path, string=json.dumps(index), logger=logger) != 0:
+            raise Exception("failed to upload binlog index: " + index_path)
+        shipped.append(index)
+        # keep what's shipped so far, so that a failed job is resumed after the last shipped one
+        with open(result_path, 'w') as f:
+            json.dump(shipped, f)
+        logger.info("binlog shipped: %s" % index)
+    if not shipped:
+        with open(result_path, 'w') as f:
+            json.dump(shipped, f)
+    logger.info("ship finished, %d binlogs shipped" % len(shipped))
 
 
 binbackup_group.add_command(start_binlogbackup)
+binbackup_group.add_command(ship_binlogs)
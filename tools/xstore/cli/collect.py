@@ -14,7 +14,9 @@
 
 import json
 import re
+import shutil
 import subprocess
+import tempfile
 import click
 
 from core.context import Context
@@ -23,6 +25,7 @@ from core.convention import *
 from core.backup_restore.xstore_binlog import XStoreBinlog
 from core.backup_restore.storage.filestream_client import BackupStorage, FileStreamClient
 from core.backup_restore.utils import check_run_process
+from core.backup_restore.binlog_manifest import BINLOG_MANIFEST, binlog_object_path, read_committed_binlogs
 
 
 @click.group(name="collect")
@@ -87,6 +90,57 @@ def collect_and_upload(context, start_binlog_name, start_offset, end_binlog_name
     filestream_client.upload_from_file(remote=file_path, local=local_collect_file_path, logger=logger)
 
 
+def split_binlog_batches(binlog_path_list, batch_bytes):
+    """
+    split binlog files into batches of about batch_bytes each, a batch contains one file at least
+    """
+    batches = []
+    batch, size = [], 0
+    for binlog_path in binlog_path_list:
+        batch.append(binlog_path)
+        size += os.path.getsize(binlog_path)
+        if size >= batch_bytes:
+            batches.append(batch)
+            batch, size = [], 0
+    if len(batch) > 0:
+        batches.append(batch)
+    return batches
+
+
+def collect_segment_path(file_path, index):
+    """
+    path of the event segment, e.g. dn-0.00001.evs for dn-0.evs
+    """
+    return "%s.%05d.evs" % (file_path[:-len(".evs")], index)
+
+
+def collect_and_upload_in_batches(context, start_binlog_name, start_offset, end_binlog_name, end_offset,
+                                  binlog_path_list, batch_bytes, file_path, filestream_client, backup_dir, job_name,
+                                  logger):
+    """
+    collect and upload events batch by batch, so only one segment is staged locally at a time. The count of
+    segments is written once the final segment is flushed, which is checked by operator.
+    """
+    collect_local_file = os.path.join(backup_dir, "collect")
+    os.makedirs(collect_local_file, exist_ok=True)
+    batches = split_binlog_batches(binlog_path_list, batch_bytes)
+    for i, batch in enumerate(batches):
+        local_segment_path = os.path.join(collect_local_file, "collect.%05d.evs" % i)
+        collect_cmd = [context.bb_home, 'txdump', '--bin', '--output', local_segment_path]
+        # offsets are only valid for the batch which contains the file
+        if os.path.basename(batch[0]) == start_binlog_name:
+            collect_cmd += ['--start-offset', start_binlog_name + ":" + start_offset]
+        if os.path.basename(batch[-1]) == end_binlog_name:
+            collect_cmd += ['--end-offset', end_binlog_name + ":" + end_offset]
+        check_run_process(collect_cmd + batch, logger=logger)
+        filestream_client.upload_from_file(remote=collect_segment_path(file_path, i), local=local_segment_path,
+                                           logger=logger)
+        os.remove(local_segment_path)
+        logger.info("segment %d of %d uploaded, binlogs: %s", i + 1, len(batches), batch)
+    with open("/data/mysql/tmp/" + job_name + ".segments", mode='w+', encoding='utf-8') as f:
+        f.write(str(len(batches)))
+
+
 def download_binlog_offset(is_start, offsetfile_name, offset_local_file, filestream_client):
     if is_start:
         tmp_file = "-start"
@@ -103,7 +157,8 @@ def download_binlog_offset(is_start, offsetfile_name, offset_local_file, filestr
 @click.command(name="start")
 @click.option('--backup_context', required=True, type=str)
 @click.option('-hb', '--heartbeat_name', required=True, type=str)
-def collect_binlog_index(backup_context, heartbeat_name):
+@click.option('-j', '--job_name', required=True, type=str)
+def collect_binlog_index(backup_context, heartbeat_name, job_name):
     logger = LogFactory.get_logger("collect.log")
     context = Context()
     with open(backup_context) as f:
@@ -112,6 +167,7 @@ def collect_binlog_index(backup_context, heartbeat_name):
         offsetfile_name = params["offsetFileName"]
         storage_name = params["storageName"]
         sink = params["sink"]
+        batch_bytes = params.get("collectBatchBytes", 0)
 
     backup_dir = context.volume_path(VOLUME_DATA, 'backup')
     if not os.path.exists(backup_dir):
@@ -129,8 +185,13 @@ def collect_binlog_index(backup_context, heartbeat_name):
                 start_binlog_name, start_offset, end_binlog_name, end_offset)
     seekhb_and_upload(filestream_client, collect_file, context, binlog_list, heartbeat_name, backup_dir, logger)
 
-    collect_and_upload(context, start_binlog_name, start_offset, end_binlog_name, end_offset, binlog_path_list,
-                       collect_file, filestream_client, backup_dir, logger)
+    if batch_bytes > 0:
+        collect_and_upload_in_batches(context, start_binlog_name, start_offset, end_binlog_name, end_offset,
+                                      binlog_path_list, batch_bytes, collect_file, filestream_client, backup_dir,
+                                      job_name, logger)
+    else:
+        collect_and_upload(context, start_binlog_name, start_offset, end_binlog_name, end_offset, binlog_path_list,
+                           collect_file, filestream_client, backup_dir, logger)
 
 
 collect_group.add_command(collect_binlog_index)
@@ -153,3 +214,191 @@ def upload_offset(offset, path, storage_name, sink):
 
 
 collect_group.add_command(upload_offset)
+
+
+@click.command(name='sign_url')
+@click.option('-p', '--path', required=True, type=str)
+@click.option('-e', '--expiry', required=True, type=str)
+@click.option('--storage_name', required=True, type=str)
+@click.option('--sink', required=True, type=str)
+def sign_url(path, expiry, storage_name, sink):
+    """
+    print pre-signed url of the remote file
+    """
+    logger = LogFactory.get_logger("collect.log")
+    context = Context()
+    filestream_client = FileStreamClient(context, BackupStorage[str.upper(storage_name)], sink)
+    print(filestream_client.sign_url(remote_path=path, expiry=expiry, logger=logger), end='')
+
+
+collect_group.add_command(sign_url)
+
+
+@click.command(name='copy_files')
+@click.option('-s', '--source', required=True, type=str)
+@click.option('-d', '--dest', required=True, type=str)
+@click.option('-r', '--retention', default='', type=str)
+@click.option('--storage_name', required=True, type=str)
+@click.option('--sink', required=True, type=str)
+@click.option('-o', '--output', default='', type=str)
+def copy_files(source, dest, retention, storage_name, sink, output):
+    """
+    copy remote files under the source path to the dest path and print the number of files copied, which is also
+    written to the output file if specified, e.g. the termination log of the copy job
+    """
+    logger = LogFactory.get_logger("collect.log")
+    context = Context()
+    filestream_client = FileStreamClient(context, BackupStorage[str.upper(storage_name)], sink)
+    copied = filestream_client.copy_files(src_prefix=source, dest_prefix=dest, retention=retention, logger=logger)
+    if output:
+        with open(output, 'w') as f:
+            f.write(str(copied))
+    print(copied, end='')
+
+
+collect_group.add_command(copy_files)
+
+
+@click.command(name='thaw_files')
+@click.option('-p', '--path', required=True, type=str)
+@click.option('--storage_name', required=True, type=str)
+@click.option('--sink', required=True, type=str)
+def thaw_files(path, storage_name, sink):
+    """
+    thaw archived remote files under the path and print the number of archived and still thawing files
+    """
+    logger = LogFactory.get_logger("collect.log")
+    context = Context()
+    filestream_client = FileStreamClient(context, BackupStorage[str.upper(storage_name)], sink)
+    archived, thawing = filestream_client.thaw_files(prefix=path, logger=logger)
+    print("%d %d" % (archived, thawing), end='')
+
+
+collect_group.add_command(thaw_files)
+
+
+# the checks are read from the termination log of the preview job, which is limited to 4096 bytes
+PREVIEW_MESSAGE_MAX_LEN = 256
+
+
+def preview_check(name, xstore, passed, message=""):
+    if len(message) > PREVIEW_MESSAGE_MAX_LEN:
+        message = message[:PREVIEW_MESSAGE_MAX_LEN - 3] + "..."
+    return {"name": name, "xstore": xstore, "passed": passed, "message": message}
+
+
+def binlog_chain_gaps(binlog_list):
+    """
+    find the gaps of the binlog chain, e.g. mysql_bin.000003 is missing between 000002 and 000004
+    """
+    gaps = []
+    for prev, cur in zip(binlog_list, binlog_list[1:]):
+        if int(cur.split('.')[-1]) != int(prev.split('.')[-1]) + 1:
+            gaps.append("%s -> %s" % (prev, cur))
+    return gaps
+
+
+def verify_full_backup_checksums(context, filestream_client, full_backup_path, manifest, logger):
+    chunksum_cmd = [context.bb_home, "chunksum", "--verify", manifest]
+    logger.info("chunksum_cmd: %s " % chunksum_cmd)
+    with subprocess.Popen(chunksum_cmd, stdin=subprocess.PIPE, stdout=subprocess.DEVNULL,
+                          stderr=subprocess.PIPE) as chunksum:
+        download_returncode = filestream_client.download_to_stdout(remote_path=full_backup_path,
+                                                                   stdout=chunksum.stdin, logger=logger)
+        chunksum.stdin.close()
+        stderr = chunksum.stderr.read().decode("utf-8").strip()
+    if download_returncode != 0:
+        return "download exited with %d" % download_returncode
+    if chunksum.returncode != 0:
+        return stderr or "chunksum exited with %d" % chunksum.returncode
+    return ""
+
+
+def preview_xstore_backup(context, filestream_client, xstore, verify_checksums, work_dir, logger):
+    name = xstore["name"]
+    checks = []
+
+    unreadable = []
+    if not filestream_client.wait_until_readable(xstore["fullBackupPath"], logger=logger):
+        unreadable.append(xstore["fullBackupPath"])
+
+    binlog_dir = xstore["binlogBackupDir"]
+    local_dir = os.path.join(work_dir, name + ".binlog")
+    os.makedirs(local_dir, exist_ok=True)
+    binlog_list = None
+    committed = read_committed_binlogs(filestream_client, binlog_dir, local_dir, logger)
+    if committed is None:
+        unreadable.append(os.path.join(binlog_dir, BINLOG_MANIFEST))
+    else:
+        generation, binlog_list = committed
+        for binlog in binlog_list:
+            binlog_path = binlog_object_path(binlog_dir, generation, binlog)
+            if not filestream_client.wait_until_readable(binlog_path, logger=logger):
+                unreadable.append(binlog_path)
+    if unreadable:
+        checks.append(preview_check("ObjectsReadable", name, False,
+                                    "missing, empty or unreadable (e.g. archived): " + ", ".join(unreadable)))
+    else:
+        checks.append(preview_check("ObjectsReadable", name, True))
+
+    if binlog_list is None:
+        checks.append(preview_check("BinlogChainIntact", name, False, "binlog list not found"))
+    else:
+        gaps = binlog_chain_gaps(binlog_list)
+        if gaps:
+            checks.append(preview_check("BinlogChainIntact", name, False, "gaps: " + ", ".join(gaps)))
+        else:
+            checks.append(preview_check("BinlogChainIntact", name, True, "%d binlog files" % len(binlog_list)))
+
+    if verify_checksums:
+        manifest_path = xstore.get("chunkManifestPath", "")
+        local_manifest = os.path.join(work_dir, name + ".chunks")
+        if manifest_path:
+            filestream_client.download_to_file(remote=manifest_path, local=local_manifest, logger=logger)
+        if not manifest_path or os.path.getsize(local_manifest) == 0:
+            checks.append(preview_check("ChecksumsMatch", name, False, "chunk manifest not found"))
+        elif xstore["fullBackupPath"] in unreadable:
+            checks.append(preview_check("ChecksumsMatch", name, False, "full backup unreadable"))
+        elif xstore.get("encrypted", False):
+            # the key is only mounted to the backup and restore jobs, the encrypted data is verified on decryption
+            checks.append(preview_check("ChecksumsMatch", name, True, "skipped, full backup is encrypted"))
+        else:
+            err = verify_full_backup_checksums(context, filestream_client, xstore["fullBackupPath"], local_manifest,
+                                               logger)
+            checks.append(preview_check("ChecksumsMatch", name, not err, err))
+    return checks
+
+
+@click.command(name='preview')
+@click.option('--preview_context', required=True, type=str)
+@click.option('-o', '--output', required=True, type=str)
+def preview(preview_context, output):
+    """
+    check the objects of backup for restore preview without restoring, the checks are written to the output as json,
+    e.g. the termination log of the preview job
+    """
+    logger = LogFactory.get_logger("collect.log")
+    context = Context()
+    params = json.loads(preview_context)
+    # the objects of a just-finished backup may be invisible for a while on eventually-consistent storages
+    filestream_client = FileStreamClient(context, BackupStorage[str.upper(params["storageName"])], params["sink"],
+                                         consistency_wait_timeout=params.get("consistencyWaitTimeout", 0),
+                                         consistency_wait_interval=params.get("consistencyWaitInterval", 1.0))
+
+    work_dir = tempfile.mkdtemp(prefix="preview-")
+    checks = []
+    try:
+        for xstore in params["xstores"]:
+            checks += preview_xstore_backup(context, filestream_client, xstore, params.get("verifyChecksums", False),
+                                            work_dir, logger)
+    except Exception as e:
+        logger.exception("restore preview failed")
+        checks.append(preview_check("PreviewFinished", "", False, str(e)))
+    finally:
+        shutil.rmtree(work_dir, ignore_errors=True)
+
+    with open(output, 'w') as f:
+        json.dump({"checks": checks}, f, separators=(',', ':'))
+
+
+collect_group.add_command(preview)
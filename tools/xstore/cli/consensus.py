@@ -121,7 +121,7 @@ def list_nodes(full):
     chan = global_mgr.shared_channel()
 
     def get_role(consensus_node: ConsensusNode, node_info: channel.Node):
-        if consensus_node.role == ConsensusRole.FOLLOWER and \
+        if consensus_node.role == ConsensusRole.FOLLOWER and node_info and \
                 node_info.role == convention.NODE_ROLE_VOTER:
             return 'logger'
         return consensus_node.role.value
@@ -137,13 +137,15 @@ def list_nodes(full):
 
         def to_print_tuple(consensus_node: ConsensusNode):
             node_info = chan.get_node_by_addr(consensus_node.addr)
+            # Learners of readonly xstores are not in the shared channel.
+            pod = node_info.pod if node_info else ''
             if full:
-                return node_info.pod, consensus_node.server_id, consensus_node.addr, \
+                return pod, consensus_node.server_id, consensus_node.addr, \
                        get_role(consensus_node, node_info), consensus_node.global_info.match_index, \
                        consensus_node.global_info.applied_index, consensus_node.global_info.election_weight, \
                        consensus_node.global_info.force_sync, consensus_node.global_info.learner_source
             else:
-                return node_info.pod, consensus_node.addr, get_role(consensus_node, node_info)
+                return pod, consensus_node.addr, get_role(consensus_node, node_info)
 
         if full:
             print_rows(sep=' | ', header=(
@@ -12,8 +12,14 @@
 # See the License for the specific language governing permissions and
 # limitations under the License.
 
+import hashlib
+import json
+
 import click
 
+from core import convention
+from core.backup_restore.utils import engine_compatibility
+from core.context import Context
 from .common import global_mgr
 
 
@@ -34,6 +40,54 @@ def version():
 engine_group.add_command(version)
 
 
+@click.command(name='compatibility')
+def compatibility():
+    print(json.dumps(engine_compatibility(Context())))
+
+
+engine_group.add_command(compatibility)
+
+
+def _quote_identifier(name):
+    return '`' + name.replace('`', '``') + '`'
+
+
+@click.command(name='check_tables')
+def check_tables():
+    """
+    Check and checksum all the user tables, print the summary in json and fail if any table is broken.
+    """
+    failed = []
+    checked = 0
+    with global_mgr.new_connection() as conn:
+        with conn.cursor() as cur:
+            cur.execute("SELECT table_schema, table_name FROM information_schema.tables WHERE table_type = 'BASE TABLE' "
+                        "AND table_schema NOT IN ('mysql', 'information_schema', 'performance_schema', 'sys')")
+            tables = cur.fetchall()
+            for schema, name in tables:
+                table = _quote_identifier(schema) + '.' + _quote_identifier(name)
+                try:
+                    cur.execute('CHECK TABLE ' + table)
+                    # Rows of (table, op, msg_type, msg_text), the status row tells the result.
+                    for row in cur.fetchall():
+                        if row[2] == 'error' or (row[2] == 'status' and row[3] != 'OK'):
+                            failed.append({'table': schema + '.' + name, 'message': row[3]})
+                            break
+                    else:
+                        # Checksum reads all the rows, so that the broken pages are surfaced.
+                        cur.execute('CHECKSUM TABLE ' + table)
+                        cur.fetchall()
+                except Exception as e:
+                    failed.append({'table': schema + '.' + name, 'message': str(e)})
+                checked += 1
+    print(json.dumps({'checked': checked, 'failed': failed}))
+    if failed:
+        raise SystemExit(1)
+
+
+engine_group.add_command(check_tables)
+
+
 @click.command(name='parameter')
 @click.option('-k', '--key', required=True, type=str)
 @click.option('-v', '--value', required=True, type=str)
@@ -48,6 +102,40 @@ def set_global(key, value):
 engine_group.add_command(set_global)
 
 
+@click.command(name='reload_tls')
+@click.option('--cert-hash', required=True, type=str)
+def reload_tls(cert_hash):
+    """
+    Reload the certificates online, fail if the mounted one isn't the expected yet.
+    """
+    ctx = Context()
+    with open(ctx.volume_path(convention.VOLUME_TLS, convention.TLS_SERVER_CRT), 'rb') as f:
+        mounted_hash = hashlib.sha1(f.read()).hexdigest()
+    if mounted_hash != cert_hash:
+        print('certificate not synced, expect %s, but got %s' % (cert_hash, mounted_hash))
+        raise SystemExit(1)
+
+    with global_mgr.new_connection() as conn:
+        with conn.cursor() as cur:
+            cur.execute('ALTER INSTANCE RELOAD TLS')
+
+
+engine_group.add_command(reload_tls)
+
+
+@click.command(name='rotate_master_key')
+def rotate_master_key():
+    """
+    Rotate the master key of TDE online, the rotation is replicated to the other nodes.
+    """
+    with global_mgr.new_connection() as conn:
+        with conn.cursor() as cur:
+            cur.execute('ALTER INSTANCE ROTATE INNODB MASTER KEY')
+
+
+engine_group.add_command(rotate_master_key)
+
+
 @click.command(name='set_engine_enable')
 @click.option('--enable', is_flag=True)
 @click.option('--disable', is_flag=True)
@@ -29,9 +29,15 @@ from core.convention import *
 from core.context.mycnf_renderer import MycnfRenderer
 from core.backup_restore.storage.filestream_client import FileStreamClient, BackupStorage
 from core.backup_restore.utils import check_run_process
+from core.backup_restore.binlog_manifest import binlog_object_path, read_committed_binlogs
 
 
 RESTORE_TEMP_DIR = "/data/mysql/restore"
+DOWNLOAD_MARK_FILE = os.path.join(RESTORE_TEMP_DIR, "download.mark")
+# the checkpoint of binlog replay, read by operator and used to resume the replay after restart
+REPLAY_CHECKPOINT_FILE = os.path.join(RESTORE_TEMP_DIR, "replay.checkpoint")
+REPLAY_STAGE_REPLAYING = "Replaying"
+REPLAY_STAGE_REPLAYED = "Replayed"
 CONN_TIMEOUT = 30
 INTERNAL_MARK = '/* rds internal mark */ '
 
@@ -48,11 +54,16 @@ def start(restore_context):
 
     with open(restore_context, 'r') as f:
         params = json.load(f)
+        snapshot = params.get("snapshot", False)
         commit_index = params["backupCommitIndex"]
         backup_file_path = params["backupFilePath"]
-        binlog_dir_path = params["binlogDirPath"]
-        storage_name = params["storageName"]
-        sink = params["sink"]
+        binlog_dir_path = params.get("binlogDirPath", "")
+        storage_name = params.get("storageName", "")
+        sink = params.get("sink", "")
+        download_rate_limit = params.get("downloadRateLimit", 0)
+        incremental_backup_file_paths = params.get("incrementalBackupFilePaths", [])
+        encryption_key_file = params.get("encryptionKeyFile", "")
+        keyring_path = params.get("keyringPath", "")
     logger.info('start restore: backup_file_path=%s' % backup_file_path)
 
     context = Context()
@@ -61,21 +72,52 @@ def start(restore_context):
         logger.info("pod role is %s, no need to download backup." % node_role)
         return
 
-    filestream_client = FileStreamClient(context, BackupStorage[str.upper(storage_name)], sink)
+    # resume the binlog replay if it's interrupted, e.g. the pod restarts. The metadata is not
+    # initialized again, so that the engine continues from its last applied index and nothing
+    # before it is applied twice
+    checkpoint = read_replay_checkpoint(backup_file_path)
+    if checkpoint:
+        logger.info("resume binlog replay from checkpoint: %s" % checkpoint)
+        checkpoint["resumes"] = checkpoint.get("resumes", 0) + 1
+        write_replay_checkpoint(checkpoint)
+        replay_binlog(context, checkpoint, logger)
+        return
 
-    mkdir_needed(context)
+    # the data and binlogs are already on the volume provisioned from the snapshot, only the metadata of
+    # the new cluster is initialized, and the engine recovers from the crash-consistent data on replay
+    if snapshot:
+        restore_from_volume_snapshot(backup_file_path, commit_index, context, node_role, logger)
+        return
+
+    filestream_client = FileStreamClient(context, BackupStorage[str.upper(storage_name)], sink)
 
     backup_file_name = backup_file_path.split("/")[-1]
 
-    download_backup_file(backup_file_path, backup_file_name, filestream_client, logger)
+    # clean up what's left by the last failed attempt, e.g. restore falls back to another backup,
+    # the partially downloaded backup file is kept if it's the same one
+    clean_restore_dirs(context, backup_file_path, backup_file_name)
+
+    mkdir_needed(context)
 
-    decompress_backup_file(backup_file_name, context, logger)
+    download_backup_file(backup_file_path, backup_file_name, filestream_client, logger, download_rate_limit)
+
+    decompress_backup_file(backup_file_name, context, logger, encryption_key_file=encryption_key_file)
 
     initialize_local_mycnf(context, logger)
 
-    apply_backup_file(context, logger)
+    if keyring_path:
+        download_keyring(context, keyring_path, filestream_client, logger, encryption_key_file=encryption_key_file)
+
+    if incremental_backup_file_paths:
+        apply_incremental_backup_files(incremental_backup_file_paths, context, filestream_client, logger,
+                                       download_rate_limit, encryption_key_file=encryption_key_file)
+    else:
+        apply_backup_file(context, logger)
+
+    verify_backup_prepared(context, logger)
 
-    mysql_bin_list = download_binlogbackup_file(binlog_dir_path, filestream_client, logger)
+    mysql_bin_list = download_binlogbackup_file(binlog_dir_path, filestream_client, logger,
+                                                encryption_key_file=encryption_key_file)
 
     copy_binlog_to_new_path(mysql_bin_list, context, logger)
 
@@ -91,22 +133,106 @@ def start(restore_context):
 
     init_mysqld_metadata(cluster_start_index, commit_index, context, end_term, node_role, logger)
 
-    p = subprocess.Popen([
-        os.path.join(context.engine_home, 'bin', 'mysqld'),
-        "--defaults-file=" + context.mycnf_path,
-        "--user=mysql"
-    ], stdout=sys.stdout)
+    checkpoint = {
+        "backupFilePath": backup_file_path,
+        "stage": REPLAY_STAGE_REPLAYING,
+        "startIndex": int(cluster_start_index) if cluster_start_index else 0,
+        "endIndex": int(end_index),
+    }
+    write_replay_checkpoint(checkpoint)
+    replay_binlog(context, checkpoint, logger)
+
 
-    wait_binlog_apply_ready(context.port_access(), end_index, logger)
+def restore_from_volume_snapshot(backup_file_path, commit_index, context, node_role, logger):
+    initialize_local_mycnf(context, logger)
 
-    p.kill()
-    p.wait()
+    chown_data_dir(context, logger)
+
+    last_binlog, first_binlog = show_last_and_first_binlog(context, logger)
+
+    end_index, end_term = xdb_show_binlog_index(last_binlog, context, logger)
+    logger.info("end_index:%s;end_term:%s" % (end_index, end_term))
+
+    init_mysqld_metadata(commit_index, commit_index, context, end_term, node_role, logger)
+
+    checkpoint = {
+        "backupFilePath": backup_file_path,
+        "stage": REPLAY_STAGE_REPLAYING,
+        "startIndex": int(commit_index),
+        "endIndex": int(end_index),
+    }
+    write_replay_checkpoint(checkpoint)
+    replay_binlog(context, checkpoint, logger)
+
+
+def replay_binlog(context, checkpoint, logger):
+    if checkpoint["stage"] == REPLAY_STAGE_REPLAYING:
+        p = subprocess.Popen([
+            os.path.join(context.engine_home, 'bin', 'mysqld'),
+            "--defaults-file=" + context.mycnf_path,
+            "--user=mysql"
+        ], stdout=sys.stdout)
+
+        wait_binlog_apply_ready(context.port_access(), checkpoint["endIndex"], logger, checkpoint)
+
+        p.kill()
+        p.wait()
+
+        checkpoint["stage"] = REPLAY_STAGE_REPLAYED
+        write_replay_checkpoint(checkpoint)
 
     sync_cluster_metadata(context, logger)
 
     context.mark_node_initialized()
 
 
+def read_replay_checkpoint(backup_file_path):
+    if not os.path.exists(REPLAY_CHECKPOINT_FILE):
+        return None
+    try:
+        with open(REPLAY_CHECKPOINT_FILE, 'r') as f:
+            checkpoint = json.load(f)
+    except ValueError:
+        return None
+    if checkpoint.get("backupFilePath") != backup_file_path:
+        return None
+    return checkpoint
+
+
+def write_replay_checkpoint(checkpoint):
+    checkpoint["updateTime"] = int(time.time())
+    # write to a temp file and rename, so that the checkpoint is never partially written
+    tmp_file = REPLAY_CHECKPOINT_FILE + ".tmp"
+    with open(tmp_file, 'w') as f:
+        json.dump(checkpoint, f)
+        f.flush()
+        os.fsync(f.fileno())
+    os.rename(tmp_file, REPLAY_CHECKPOINT_FILE)
+
+
+def clean_restore_dirs(context, backup_file_path, backup_file_name):
+    data_dir = context.volume_path(VOLUME_DATA, "data")
+    if os.path.exists(data_dir):
+        shutil.rmtree(data_dir)
+    if not os.path.exists(RESTORE_TEMP_DIR):
+        return
+    resumable = False
+    if os.path.exists(DOWNLOAD_MARK_FILE):
+        with open(DOWNLOAD_MARK_FILE, 'r') as f:
+            resumable = f.read() == backup_file_path
+    if not resumable:
+        shutil.rmtree(RESTORE_TEMP_DIR)
+        return
+    for name in os.listdir(RESTORE_TEMP_DIR):
+        path = os.path.join(RESTORE_TEMP_DIR, name)
+        if name in [backup_file_name, os.path.basename(DOWNLOAD_MARK_FILE)]:
+            continue
+        if os.path.isdir(path):
+            shutil.rmtree(path)
+        else:
+            os.remove(path)
+
+
 def mkdir_needed(context):
     if not os.path.exists(RESTORE_TEMP_DIR):
         os.mkdir(RESTORE_TEMP_DIR)
@@ -124,26 +250,48 @@ def mkdir_needed(context):
     shutil.chown(context.volume_path(VOLUME_DATA, "run"), "mysql", "mysql")
 
 
-def download_backup_file(backup_file_path, backup_file_name, filestream_client, logger):
+def download_backup_file(backup_file_path, backup_file_name, filestream_client, logger, limit_rate=0):
     backup_stream_file = os.path.join(RESTORE_TEMP_DIR, backup_file_name)
-    filestream_client.download_to_file(remote=backup_file_path, local=backup_stream_file, logger=logger)
+    # mark the file being downloaded, so that the download is resumed after restart
+    with open(DOWNLOAD_MARK_FILE, 'w') as f:
+        f.write(backup_file_path)
+    exit_code = filestream_client.resume_download_to_file(remote=backup_file_path, local=backup_stream_file,
+                                                          logger=logger, limit_rate=limit_rate)
+    if exit_code != 0:
+        raise Exception("failed to download backup file, exit code: %d" % exit_code)
     logger.info("backup file downloaded!")
 
 
-def download_binlogbackup_file(binlog_dir_path, filestream_client, logger):
-    binlog_list_path = os.path.join(RESTORE_TEMP_DIR, "binlog_list")
-    filestream_client.download_to_file(remote=os.path.join(binlog_dir_path, "binlog_list"), local=binlog_list_path,
-                                       logger=logger)
-    with open(binlog_list_path, 'r') as f:
-        mysql_binlog_list = f.read().splitlines()
+def download_keyring(context, keyring_path, filestream_client, logger, encryption_key_file=""):
+    # the keyring must be the one of backup to decrypt the tablespace keys, and the master key source of the
+    # restored xstore must be the same as the source, e.g. the same password or KMS key
+    os.makedirs(os.path.dirname(context.keyring_path()), exist_ok=True)
+    exit_code = filestream_client.download_to_file(remote=keyring_path, local=context.keyring_path(), logger=logger,
+                                                   encryption_key_file=encryption_key_file)
+    if exit_code != 0:
+        raise Exception("failed to download keyring, exit code: %d" % exit_code)
+    logger.info("keyring downloaded!")
+
+
+def download_binlogbackup_file(binlog_dir_path, filestream_client, logger, encryption_key_file=""):
+    generation, mysql_binlog_list = download_binlog_list(binlog_dir_path, RESTORE_TEMP_DIR, filestream_client,
+                                                         logger)
     for binlog in mysql_binlog_list:
-        filestream_client.download_to_file(remote=os.path.join(binlog_dir_path, binlog),
-                                           local=os.path.join(RESTORE_TEMP_DIR, binlog), logger=logger)
+        download_binlog_file(filestream_client, binlog_object_path(binlog_dir_path, generation, binlog),
+                             os.path.join(RESTORE_TEMP_DIR, binlog), logger, encryption_key_file)
     logger.info("binlog backup file download")
     logger.info("mysql_binlog_list:%s" % mysql_binlog_list)
     return mysql_binlog_list
 
 
+def download_binlog_file(filestream_client, remote, local, logger, encryption_key_file=""):
+    # an encrypted binlog is decrypted on the fly, which fails if it's tampered or truncated
+    exit_code = filestream_client.download_to_file(remote=remote, local=local, logger=logger,
+                                                   encryption_key_file=encryption_key_file)
+    if exit_code != 0:
+        raise Exception("failed to download binlog %s, exit code: %d" % (remote, exit_code))
+
+
 def copy_binlog_to_new_path(mysql_bin_list, context, logger):
     # copy backup binlog to new binlog path
     log_dir = context.volume_path(VOLUME_DATA, "log")
@@ -161,13 +309,20 @@ def copy_binlog_to_new_path(mysql_bin_list, context, logger):
     logger.info("copy binlog to log_path")
 
 
-def decompress_backup_file(backup_file_name, context, logger):
-    decompress_cmd = "%s/xbstream -x < %s -C %s" % (
-        context.xtrabackup_home, os.path.join(RESTORE_TEMP_DIR, backup_file_name),
-        context.volume_path(VOLUME_DATA, "data"))
+def decompress_backup_file(backup_file_name, context, logger, target_dir=None, encryption_key_file=""):
+    backup_stream_file = os.path.join(RESTORE_TEMP_DIR, backup_file_name)
+    target_dir = target_dir or context.volume_path(VOLUME_DATA, "data")
+    if encryption_key_file:
+        # the downloaded file is kept encrypted so that the download is resumable, it's decrypted on the fly
+        decompress_cmd = "set -o pipefail; %s decrypt --key-file %s < %s | %s/xbstream -x -C %s" % (
+            context.bb_home, encryption_key_file, backup_stream_file, context.xtrabackup_home, target_dir)
+    else:
+        decompress_cmd = "%s/xbstream -x < %s -C %s" % (context.xtrabackup_home, backup_stream_file, target_dir)
     logger.info("decompress_cmd:%s" % decompress_cmd)
-    with subprocess.Popen(decompress_cmd, shell=True, stdout=sys.stdout):
+    with subprocess.Popen(["bash", "-c", decompress_cmd], stdout=sys.stdout) as p:
         logger.info("decompress!")
+    if p.returncode != 0:
+        raise Exception("failed to decompress backup file, exit code: %d" % p.returncode)
 
 
 def sort_config(config: configparser.ConfigParser) -> configparser.ConfigParser:
@@ -207,20 +362,68 @@ def initialize_local_mycnf(context: Context, logger):
     logger.info("local mycnf initialized!")
 
 
-def apply_backup_file(context, logger):
+def apply_backup_file(context, logger, redo_only=False, incremental_dir=None):
     # 应用全量备份集
     apply_backup_cmd = ""
     if context.is_galaxy80():
-        apply_backup_cmd = "%s --defaults-file=%s --prepare --target-dir=%s 2> %s/applybackup.log" \
-                       % (context.xtrabackup, context.mycnf_path, context.volume_path(VOLUME_DATA, 'data'),
+        apply_opts = " --apply-log-only" if redo_only else ""
+        if incremental_dir:
+            apply_opts += " --incremental-dir=%s" % incremental_dir
+        apply_backup_cmd = "%s --defaults-file=%s --prepare%s --target-dir=%s 2>> %s/applybackup.log" \
+                       % (context.xtrabackup, context.mycnf_path, apply_opts, context.volume_path(VOLUME_DATA, 'data'),
                           context.volume_path(VOLUME_DATA, "log"))
     elif context.is_xcluster57():
-        apply_backup_cmd = "%s --defaults-file=%s --apply-log  %s 2> %s/applybackup.log" \
-                           % (context.xtrabackup, context.mycnf_path, context.volume_path(VOLUME_DATA, 'data'),
-                              context.volume_path(VOLUME_DATA, "log"))
+        apply_opts = " --redo-only" if redo_only else ""
+        if incremental_dir:
+            apply_opts += " --incremental-dir=%s" % incremental_dir
+        apply_backup_cmd = "%s --defaults-file=%s --apply-log%s  %s 2>> %s/applybackup.log" \
+                           % (context.xtrabackup, context.mycnf_path, apply_opts,
+                              context.volume_path(VOLUME_DATA, 'data'), context.volume_path(VOLUME_DATA, "log"))
     logger.info("apply_backup_cmd:%s" % apply_backup_cmd)
-    with subprocess.Popen(apply_backup_cmd, shell=True, stdout=sys.stdout):
+    with subprocess.Popen(apply_backup_cmd, shell=True, stdout=sys.stdout) as p:
         logger.info("apply backup")
+    if p.returncode != 0:
+        raise Exception("failed to apply backup file, exit code: %d" % p.returncode)
+
+
+def apply_incremental_backup_files(incremental_backup_file_paths, context, filestream_client, logger, limit_rate=0,
+                                   encryption_key_file=""):
+    # the full backup is prepared with redo only, so that the incremental backups are applied onto it in
+    # order, and the last one is prepared as usual to roll back the uncommitted transactions
+    apply_backup_file(context, logger, redo_only=True)
+    for i, backup_file_path in enumerate(incremental_backup_file_paths):
+        backup_file_name = "incremental-%d.xbstream" % i
+        incremental_dir = os.path.join(RESTORE_TEMP_DIR, "incremental-%d" % i)
+        exit_code = filestream_client.resume_download_to_file(remote=backup_file_path,
+                                                              local=os.path.join(RESTORE_TEMP_DIR, backup_file_name),
+                                                              logger=logger, limit_rate=limit_rate)
+        if exit_code != 0:
+            raise Exception("failed to download incremental backup file %s, exit code: %d"
+                            % (backup_file_path, exit_code))
+        if os.path.exists(incremental_dir):
+            shutil.rmtree(incremental_dir)
+        os.mkdir(incremental_dir)
+        decompress_backup_file(backup_file_name, context, logger, target_dir=incremental_dir,
+                               encryption_key_file=encryption_key_file)
+        apply_backup_file(context, logger, redo_only=i < len(incremental_backup_file_paths) - 1,
+                          incremental_dir=incremental_dir)
+        # the applied ones are removed to save the space
+        os.remove(os.path.join(RESTORE_TEMP_DIR, backup_file_name))
+        shutil.rmtree(incremental_dir)
+        logger.info("incremental backup applied: %s" % backup_file_path)
+
+
+def verify_backup_prepared(context, logger):
+    # the backup is usable only if it's fully prepared
+    checkpoints_file = os.path.join(context.volume_path(VOLUME_DATA, "data"), "xtrabackup_checkpoints")
+    if not os.path.exists(checkpoints_file):
+        raise Exception("backup verification failed, xtrabackup_checkpoints not found")
+    with open(checkpoints_file, 'r') as f:
+        checkpoints = dict(line.split('=', 1) for line in f.read().splitlines() if '=' in line)
+    backup_type = checkpoints.get("backup_type", "").strip()
+    if backup_type != "full-prepared":
+        raise Exception("backup verification failed, backup_type: %s" % backup_type)
+    logger.info("backup verified!")
 
 
 def chown_data_dir(context, logger):
@@ -271,21 +474,25 @@ def get_xtrabackup_binlog_info_from_instance_local(context: Context):
             return str_list[1] if len(str_list) >= 2 else str_list[0]
 
 
-def wait_binlog_apply_ready(mysql_port, end_log_index, logger):
+def wait_binlog_apply_ready(mysql_port, end_log_index, logger, checkpoint):
     timeout = 48 * 60 * 60
     deadline = time.time() + timeout
     while time.time() < deadline:
         logger.info("wait applying binlog")
         try:
             time.sleep(10)
-            if check_binlog_apply_index_status(mysql_port, end_log_index, logger):
+            applied_index = get_binlog_apply_index(mysql_port, logger)
+            if applied_index > checkpoint.get("appliedIndex", 0):
+                checkpoint["appliedIndex"] = applied_index
+                write_replay_checkpoint(checkpoint)
+            if applied_index >= int(end_log_index):
                 return
         except Exception as e:
             logger.info(e)
     raise TimeoutError("binlog apply timeout!")
 
 
-def check_binlog_apply_index_status(mysql_port, end_log_index, logger):
+def get_binlog_apply_index(mysql_port, logger):
     sql_list = "select * from information_schema.alisql_cluster_local"
     logger.info("Execute SQL: %s" % sql_list)
 
@@ -294,16 +501,13 @@ def check_binlog_apply_index_status(mysql_port, end_log_index, logger):
     if not output:
         raise Exception("can not get xdb full health info")
 
-    rows = output.split("\n")
-
-    for row in rows:
+    applied_index = None
+    for row in output.split("\n"):
         columns = row.split("\t")
-        logger.info("columns: %s" % columns)
         logger.info("last apply index: %s" % columns[-3])
-        logger.info("end_log_index: %s" % end_log_index)
-        if int(columns[-3]) < int(end_log_index):
-            return False
-    return True
+        if applied_index is None or int(columns[-3]) < applied_index:
+            applied_index = int(columns[-3])
+    return applied_index
 
 
 def execute_mysqlcmd(port, cmd, db=None, host='127.0.0.1', user='root', autocommit=False, **kwargs):
@@ -384,4 +588,159 @@ def xdb_show_binlog_index(binlog_path, context, logger):
     return end_index, end_term
 
 
+@click.command(name='apply_binlog')
+@click.option('--restore_context', required=True, type=str)
+@click.option('-tp', '--target_pod', required=True, type=str)
+@click.option('-j', '--job_name', required=True, type=str)
+def apply_binlog(restore_context, target_pod, job_name):
+    logger = LogFactory.get_logger("applybinlog.log")
+    context = Context()
+    with open(restore_context, 'r') as f:
+        params = json.load(f)
+        binlog_dir_path = params["binlogDirPath"]
+        last_binlog_dir_path = params.get("lastBinlogDirPath", "")
+        last_applied_binlog = params.get("lastAppliedBinlog", "")
+        # the apply stops at the restore time of point in time restore, in UTC
+        stop_datetime = params.get("stopDatetime", "")
+        storage_name = params["storageName"]
+        sink = params["sink"]
+        encryption_key_file = params.get("encryptionKeyFile", "")
+        last_encryption_key_file = params.get("lastEncryptionKeyFile", "")
+
+    filestream_client = FileStreamClient(context, BackupStorage[str.upper(storage_name)], sink)
+    apply_dir = os.path.join(RESTORE_TEMP_DIR, "apply")
+    if os.path.exists(apply_dir):
+        shutil.rmtree(apply_dir)
+    os.makedirs(apply_dir)
+
+    # the binlogs of last applied backup are applied till the end if no position recorded
+    if not last_applied_binlog:
+        last_generation, last_list = download_binlog_list(last_binlog_dir_path, apply_dir, filestream_client, logger)
+        last_file = last_list[-1]
+        download_binlog_file(filestream_client, binlog_object_path(last_binlog_dir_path, last_generation, last_file),
+                             os.path.join(apply_dir, last_file), logger, last_encryption_key_file)
+        last_applied_binlog = "%s:%d" % (last_file, os.path.getsize(os.path.join(apply_dir, last_file)))
+        os.remove(os.path.join(apply_dir, last_file))
+    start_file, start_offset = last_applied_binlog.split(':')
+    start_offset = max(int(start_offset), 4)
+
+    generation, binlog_list = download_binlog_list(binlog_dir_path, apply_dir, filestream_client, logger)
+    if len(binlog_list) == 0 or binlog_list[0] > start_file:
+        raise Exception("binlog gap, last applied: %s, binlog list: %s" % (last_applied_binlog, binlog_list))
+    binlog_list = [b for b in binlog_list if b >= start_file]
+    if len(binlog_list) == 0:
+        logger.info("no binlog newer than %s" % last_applied_binlog)
+        write_applied_binlog(job_name, last_applied_binlog)
+        return
+
+    for binlog in binlog_list:
+        download_binlog_file(filestream_client, binlog_object_path(binlog_dir_path, generation, binlog),
+                             os.path.join(apply_dir, binlog), logger, encryption_key_file)
+    end_binlog = "%s:%d" % (binlog_list[-1], os.path.getsize(os.path.join(apply_dir, binlog_list[-1])))
+
+    if end_binlog != "%s:%d" % (start_file, start_offset):
+        stop_opt = "--stop-datetime='%s'" % stop_datetime if stop_datetime else ""
+        apply_cmd = "TZ=UTC %s/bin/mysqlbinlog --start-position=%d %s %s | %s/bin/mysql -h %s -P %d -u admin" % (
+            context.engine_home, start_offset, stop_opt, ' '.join([os.path.join(apply_dir, b) for b in binlog_list]),
+            context.engine_home, target_pod + "-service", context.port_access())
+        logger.info("apply binlogs from %s to %s, stop datetime: %s" % (last_applied_binlog, end_binlog, stop_datetime))
+        subprocess.check_call(["bash", "-c", "set -o pipefail; " + apply_cmd])
+
+    write_applied_binlog(job_name, end_binlog)
+    shutil.rmtree(apply_dir)
+    logger.info("binlogs applied till %s" % end_binlog)
+
+
+@click.command(name='apply_shipped_binlog')
+@click.option('--binlog_dir', required=True, type=str)
+@click.option('--storage_name', required=True, type=str)
+@click.option('--sink', required=True, type=str)
+@click.option('--binlogs', required=True, type=str)
+@click.option('-tp', '--target_pod', required=True, type=str)
+@click.option('-j', '--job_name', required=True, type=str)
+def apply_shipped_binlog(binlog_dir, storage_name, sink, binlogs, target_pod, job_name):
+    """
+    apply the binlogs shipped by the binlog backup of the primary, in order. The events already included, e.g. by
+    the restored backup set, are skipped by their gtids. The password of admin is read from env MYSQL_PWD, and
+    super_read_only of the standby is lifted during the apply only, in which writes of super accounts are possible
+    """
+    logger = LogFactory.get_logger("applyshippedbinlog.log")
+    context = Context()
+    filestream_client = FileStreamClient(context, BackupStorage[str.upper(storage_name)], sink)
+    apply_dir = os.path.join(RESTORE_TEMP_DIR, "apply")
+    if os.path.exists(apply_dir):
+        shutil.rmtree(apply_dir)
+    os.makedirs(apply_dir)
+
+    binlog_list = binlogs.split(',')
+    for binlog in binlog_list:
+        download_binlog_file(filestream_client, os.path.join(binlog_dir, binlog), os.path.join(apply_dir, binlog),
+                             logger)
+    end_binlog = "%s:%d" % (binlog_list[-1], os.path.getsize(os.path.join(apply_dir, binlog_list[-1])))
+
+    mysql_cmd = "%s/bin/mysql -h %s -P %d -u admin" % (context.engine_home, target_pod + "-service",
+                                                        context.port_access())
+    apply_cmd = "%s/bin/mysqlbinlog %s | %s" % (
+        context.engine_home, ' '.join([os.path.join(apply_dir, b) for b in binlog_list]), mysql_cmd)
+    logger.info("apply shipped binlogs %s" % binlog_list)
+    subprocess.check_call(["bash", "-c", mysql_cmd + " -e 'SET GLOBAL super_read_only = OFF'"])
+    try:
+        subprocess.check_call(["bash", "-c", "set -o pipefail; " + apply_cmd])
+    finally:
+        subprocess.check_call(["bash", "-c", mysql_cmd + " -e 'SET GLOBAL super_read_only = ON'"])
+
+    write_applied_binlog(job_name, end_binlog)
+    shutil.rmtree(apply_dir)
+    logger.info("shipped binlogs applied till %s" % end_binlog)
+
+
+@click.command(name='download_backup_set')
+@click.option('--backup_file_path', required=True, type=str)
+@click.option('--storage_name', required=True, type=str)
+@click.option('--sink', required=True, type=str)
+@click.option('--target_dir', required=True, type=str)
+def download_backup_set(backup_file_path, storage_name, sink, target_dir):
+    """
+    download the full backup set and extract it into the target dir, where it's prepared and moved back like the
+    one streamed from another pod
+    """
+    logger = LogFactory.get_logger("downloadbackupset.log")
+    context = Context()
+    filestream_client = FileStreamClient(context, BackupStorage[str.upper(storage_name)], sink)
+    if os.path.exists(target_dir):
+        shutil.rmtree(target_dir)
+    os.makedirs(target_dir)
+
+    backup_stream_file = target_dir.rstrip('/') + ".xbstream"
+    exit_code = filestream_client.resume_download_to_file(remote=backup_file_path, local=backup_stream_file,
+                                                          logger=logger)
+    if exit_code != 0:
+        raise Exception("failed to download backup file, exit code: %d" % exit_code)
+    logger.info("backup set downloaded from %s" % backup_file_path)
+
+    decompress_cmd = "%s/xbstream -x < %s -C %s" % (context.xtrabackup_home, backup_stream_file, target_dir)
+    logger.info("decompress_cmd:%s" % decompress_cmd)
+    subprocess.check_call(["bash", "-c", decompress_cmd])
+    os.remove(backup_stream_file)
+    logger.info("backup set extracted to %s" % target_dir)
+
+
+def download_binlog_list(binlog_dir_path, local_dir, filestream_client, logger):
+    # only the committed binlogs are read, never the ones of a crashed binlog backup
+    committed = read_committed_binlogs(filestream_client, binlog_dir_path, local_dir, logger)
+    if committed is None:
+        return "", []
+    generation, binlog_list = committed
+    return generation, sorted(binlog_list)
+
+
+def write_applied_binlog(job_name, applied_binlog):
+    # the applied position is written to /data/mysql/tmp/<job_name>.pos and collected by operator
+    with open("/data/mysql/tmp/" + job_name + ".pos", mode='w+', encoding='utf-8') as f:
+        f.write(applied_binlog)
+
+
 restore_group.add_command(start)
+restore_group.add_command(apply_binlog)
+restore_group.add_command(apply_shipped_binlog)
+restore_group.add_command(download_backup_set)
@@ -41,6 +41,7 @@ def start_seekcp(seekcp_context):
         dn_name_list = params["dnNameList"].split(',')
         storage_name = params["storageName"]
         sink = params["sink"]
+        collect_segments = params.get("collectSegments", {})
 
     context = Context()
 
@@ -56,7 +57,7 @@ def start_seekcp(seekcp_context):
     cpfile = os.path.join(local_tx_dir, "a.cp")
 
     # download .evs file
-    download_evs_file(filestream_client, local_tx_dir, tx_events_dir, dn_name_list, logger)
+    download_evs_file(filestream_client, local_tx_dir, tx_events_dir, dn_name_list, collect_segments, logger)
 
     # get heartbeat_id
     hb_tx_id = get_heartbeat_txid(context, filestream_client, tx_events_dir, logger)
@@ -98,10 +99,16 @@ def get_heartbeat_txid(context, filestream_client, tx_events_dir, logger):
     return hb_tx_id.split(':')[-1].strip()
 
 
-def download_evs_file(filestream_client, local_tx_dir, remote_tx_dir, dn_name_list, logger):
+def download_evs_file(filestream_client, local_tx_dir, remote_tx_dir, dn_name_list, collect_segments, logger):
     for dn in dn_name_list:
         if dn.endswith("gms"):  # no need to download evs for gms
             continue
+        if dn in collect_segments:  # events are collected in batches, e.g. dn.00000.evs, dn.00001.evs
+            for i in range(collect_segments[dn]):
+                segment_name = "%s.%05d.evs" % (dn, i)
+                filestream_client.download_to_file(remote=os.path.join(remote_tx_dir, segment_name),
+                                                   local=os.path.join(local_tx_dir, segment_name), logger=logger)
+            continue
         remote_path = os.path.join(remote_tx_dir, dn + ".evs")
         local_path = os.path.join(local_tx_dir, dn + ".evs")
         filestream_client.download_to_file(remote=remote_path, local=local_path, logger=logger)
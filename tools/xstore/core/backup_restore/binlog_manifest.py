@@ -0,0 +1,86 @@
+# Copyright 2021 Alibaba Group Holding Limited.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+import json
+import os
+import time
+
+# the pointer to the committed generation of binlog backup, it's uploaded last and replaced as a whole
+BINLOG_MANIFEST = "binlog_manifest"
+# the list of binlogs uploaded by backups of older versions, which are not versioned
+LEGACY_BINLOG_LIST = "binlog_list"
+
+
+def new_generation():
+    return str(int(time.time() * 1000))
+
+
+def binlog_object_path(binlog_dir, generation, name):
+    """
+    the binlogs of each generation are uploaded into their own directory so that a crashed upload never
+    overwrites the binlogs of the committed one, the legacy ones are right under the binlog dir
+    """
+    if not generation:
+        return os.path.join(binlog_dir, name)
+    return os.path.join(binlog_dir, generation, name)
+
+
+def commit_binlog_manifest(filestream_client, binlog_dir, generation, binlogs, logger):
+    """
+    commit the binlogs uploaded in the generation. The versioned manifest is uploaded before the pointer,
+    so readers see either the last committed generation or this one, never a half-updated one
+
+    :param binlogs: names of the uploaded binlogs
+    """
+    manifest_path = os.path.join(binlog_dir, BINLOG_MANIFEST + "." + generation)
+    manifest = json.dumps({"generation": generation, "binlogs": binlogs})
+    if filestream_client.upload_from_string(remote=manifest_path, string=manifest, logger=logger) != 0:
+        raise Exception("failed to upload binlog manifest: " + manifest_path)
+    filestream_client.ensure_durable(manifest_path, logger=logger)
+
+    pointer_path = os.path.join(binlog_dir, BINLOG_MANIFEST)
+    if filestream_client.upload_from_string(remote=pointer_path, string=generation, logger=logger) != 0:
+        raise Exception("failed to commit binlog manifest: " + pointer_path)
+    filestream_client.ensure_durable(pointer_path, logger=logger)
+    logger.info("binlog manifest committed, generation: %s" % generation)
+
+
+def read_committed_binlogs(filestream_client, binlog_dir, local_dir, logger):
+    """
+    read the binlogs of the committed generation, or the legacy binlog list if there's no manifest
+
+    :return: the generation, empty if legacy, and the names of binlogs. None if neither is found
+    """
+    pointer_local = os.path.join(local_dir, BINLOG_MANIFEST)
+    filestream_client.download_to_file(remote=os.path.join(binlog_dir, BINLOG_MANIFEST), local=pointer_local,
+                                       logger=logger)
+    with open(pointer_local, 'r') as f:
+        generation = f.read().strip()
+    if generation:
+        manifest_local = pointer_local + "." + generation
+        filestream_client.download_to_file(remote=os.path.join(binlog_dir, BINLOG_MANIFEST + "." + generation),
+                                           local=manifest_local, logger=logger)
+        with open(manifest_local, 'r') as f:
+            manifest = json.load(f)
+        if manifest.get("generation") != generation:
+            raise Exception("binlog manifest of generation %s not matched: %s" % (generation, manifest))
+        return generation, manifest.get("binlogs", [])
+
+    list_local = os.path.join(local_dir, LEGACY_BINLOG_LIST)
+    filestream_client.download_to_file(remote=os.path.join(binlog_dir, LEGACY_BINLOG_LIST), local=list_local,
+                                       logger=logger)
+    if os.path.getsize(list_local) == 0:
+        return None
+    with open(list_local, 'r') as f:
+        return "", [line.strip() for line in f.read().splitlines() if line.strip()]
@@ -11,8 +11,12 @@
 # WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
 # See the License for the specific language governing permissions and
 # limitations under the License.
+import json
+import os
 import subprocess
 import sys
+import tempfile
+import time
 
 from enum import Enum
 
@@ -25,6 +29,8 @@ class BackupStorage(Enum):
     """
     OSS = "OSS"
     SFTP = "SFTP"
+    ARCHIVE = "ARCHIVE"
+    S3 = "S3"
 
 
 class ClientAction(Enum):
@@ -35,6 +41,17 @@ class ClientAction(Enum):
     UploadOss = "uploadOss"
     DownloadSsh = "DownloadSsh"
     UploadSsh = "uploadSsh"
+    SignOss = "signOss"
+    CopyOss = "copyOss"
+    ThawOss = "thawOss"
+    DownloadArchive = "downloadArchive"
+    UploadArchive = "uploadArchive"
+    DownloadS3 = "downloadS3"
+    UploadS3 = "uploadS3"
+
+
+# storages whose uploads are in parts and retried by the filestream server
+PARTED_UPLOAD_STORAGES = (BackupStorage.OSS, BackupStorage.S3)
 
 
 class FileStreamClient:
@@ -42,16 +59,46 @@ class FileStreamClient:
     A client to perform stream transmission
     """
 
-    def __init__(self, context: Context, storage: BackupStorage, sink):
+    def __init__(self, context: Context, storage: BackupStorage, sink, upload_retries=0, upload_retry_backoff="",
+                 consistency_wait_timeout=0, consistency_wait_interval=1.0, upload_rate_limit=0):
         self._client = context.filestream_client()
+        self._bb_home = context.bb_home
         self._host_info = context.host_info()
         self._storage = storage
         self._sink = sink
         self._download_action = None
         self._upload_action = None
+        # failed upload requests are retried by the filestream server, only oss and s3 supported
+        self._upload_retries = upload_retries if storage in PARTED_UPLOAD_STORAGES else 0
+        self._upload_retry_backoff = upload_retry_backoff
+        self._retried_objects = {}
+        # just-uploaded objects may be invisible for a while on eventually-consistent storages
+        self._consistency_wait_timeout = consistency_wait_timeout
+        self._consistency_wait_interval = consistency_wait_interval if consistency_wait_interval > 0 else 1.0
+        self._waited_objects = {}
+        # max upload speed in bytes/s of each upload, 0 means unlimited
+        self._upload_rate_limit = upload_rate_limit
         self.init_action()
 
-    def upload_from_stdin(self, remote_path, stdin, stderr=sys.stderr, logger=None, is_string_input=False):
+    def crypt_cmd(self, action, encryption_key_file):
+        """
+        command to encrypt or decrypt stdin to stdout with the key file
+
+        :param action: "encrypt" or "decrypt"
+        """
+        return [self._bb_home, action, "--key-file", encryption_key_file]
+
+    def upload_from_stdin(self, remote_path, stdin, stderr=sys.stderr, logger=None, is_string_input=False,
+                          storage_class="", threads=0, encryption_key_file=""):
+        if encryption_key_file:
+            # the stream is encrypted before it leaves the pod, a failed encryption fails the upload
+            with subprocess.Popen(self.crypt_cmd("encrypt", encryption_key_file), stdin=stdin,
+                                  stdout=subprocess.PIPE, stderr=stderr, close_fds=True) as enc:
+                returncode = self.upload_from_stdin(remote_path=remote_path, stdin=enc.stdout, stderr=stderr,
+                                                    logger=logger, is_string_input=is_string_input,
+                                                    storage_class=storage_class, threads=threads)
+                enc.stdout.close()
+            return returncode or enc.returncode
         upload_cmd = [
             self._client,
             "--meta.action=" + self._upload_action.value,
@@ -61,12 +108,65 @@ class FileStreamClient:
         ]
         if is_string_input and self._storage == BackupStorage.OSS:
             upload_cmd.append("--meta.ossBufferSize=102400")
+        if storage_class and self._storage == BackupStorage.OSS:
+            upload_cmd.append("--meta.storageClass=" + storage_class)
+        if threads > 1 and self._storage in PARTED_UPLOAD_STORAGES:
+            upload_cmd.append("--meta.uploadThreads=%d" % threads)
+        if self._upload_rate_limit > 0:
+            upload_cmd.append("--limitRate=%d" % self._upload_rate_limit)
+        retries_file = None
+        if self._upload_retries > 0:
+            upload_cmd.append("--meta.uploadRetries=%d" % self._upload_retries)
+            if self._upload_retry_backoff:
+                upload_cmd.append("--meta.uploadRetryBackoff=" + self._upload_retry_backoff)
+            fd, retries_file = tempfile.mkstemp(suffix=".retries")
+            os.close(fd)
+            upload_cmd.append("--retriesFile=" + retries_file)
         if logger:
             logger.info("Upload command: %s" % upload_cmd)
         with subprocess.Popen(upload_cmd, stdin=stdin, stderr=stderr, close_fds=True) as up:
             up.wait()
+        if retries_file:
+            self._record_retries(remote_path, retries_file, logger)
+        return up.returncode
+
+    def _record_retries(self, remote_path, retries_file, logger=None):
+        try:
+            with open(retries_file, "r") as f:
+                content = f.read().strip()
+            retries = int(content) if content else 0
+        except (OSError, ValueError) as e:
+            retries = 0
+            if logger:
+                logger.info("Failed to read upload retries of %s: %s" % (remote_path, e))
+        finally:
+            os.remove(retries_file)
+        if retries > 0:
+            self._retried_objects[remote_path] = self._retried_objects.get(remote_path, 0) + retries
+            if logger:
+                logger.info("Upload of %s retried %d times" % (remote_path, retries))
 
-    def download_to_stdout(self, remote_path, stdout, stderr=sys.stderr, logger=None):
+    def write_retried_objects(self, path):
+        """
+        write the retries of the retried objects uploaded by the client, in json keyed by remote path
+
+        :param path: local path of the file to write
+        """
+        with open(path, mode='w+', encoding='utf-8') as f:
+            json.dump(self._retried_objects, f)
+
+    def download_to_stdout(self, remote_path, stdout, stderr=sys.stderr, logger=None, offset=0, limit_rate=0,
+                           encryption_key_file=""):
+        if encryption_key_file:
+            # the stream is only decryptable from the beginning
+            if offset > 0:
+                raise ValueError("encrypted file %s can't be downloaded from offset %d" % (remote_path, offset))
+            with subprocess.Popen(self.crypt_cmd("decrypt", encryption_key_file), stdin=subprocess.PIPE,
+                                  stdout=stdout, stderr=stderr, close_fds=True) as dec:
+                returncode = self.download_to_stdout(remote_path=remote_path, stdout=dec.stdin, stderr=stderr,
+                                                     logger=logger, limit_rate=limit_rate)
+                dec.stdin.close()
+            return returncode or dec.returncode
         download_cmd = [
             self._client,
             "--meta.action=" + self._download_action.value,
@@ -74,12 +174,18 @@ class FileStreamClient:
             "--meta.filename=" + remote_path,
             "--hostInfoFilePath=" + self._host_info
         ]
+        if offset > 0:
+            download_cmd.append("--meta.offset=%d" % offset)
+        if limit_rate > 0:
+            download_cmd.append("--limitRate=%d" % limit_rate)
         if logger:
             logger.info("Download command: %s" % download_cmd)
         with subprocess.Popen(download_cmd, stdout=stdout, stderr=stderr, close_fds=True) as dp:
             dp.wait()  # ensure download finished
+        return dp.returncode
 
-    def upload_from_file(self, remote, local, stderr=sys.stderr, logger=None):
+    def upload_from_file(self, remote, local, stderr=sys.stderr, logger=None, storage_class="",
+                         encryption_key_file=""):
         """
         upload from src file to dest file
 
@@ -87,11 +193,15 @@ class FileStreamClient:
         :param remote: remote path to store uploaded file
         :param stderr: redirect stderr
         :param logger: just a logger
+        :param storage_class: storage class of the uploaded file, only oss supported, empty means default
+        :param encryption_key_file: key file to encrypt the uploaded file with, empty means not encrypted
+        :return: exit code of the upload
         """
         with open(local, "r") as f:
-            self.upload_from_stdin(remote_path=remote, stdin=f, stderr=stderr, logger=logger)
+            return self.upload_from_stdin(remote_path=remote, stdin=f, stderr=stderr, logger=logger,
+                                          storage_class=storage_class, encryption_key_file=encryption_key_file)
 
-    def download_to_file(self, remote, local, stderr=sys.stderr, logger=None):
+    def download_to_file(self, remote, local, stderr=sys.stderr, logger=None, encryption_key_file=""):
         """
         download from src file to dest file
 
@@ -99,21 +209,179 @@ class FileStreamClient:
         :param local: local path to store downloaded file
         :param stderr: redirect stderr
         :param logger: just a logger
+        :param encryption_key_file: key file to decrypt the downloaded file with, empty means not encrypted
+        :return: exit code of the download
         """
         with open(local, 'w') as f:
-            self.download_to_stdout(remote_path=remote, stdout=f, stderr=stderr, logger=logger)
+            return self.download_to_stdout(remote_path=remote, stdout=f, stderr=stderr, logger=logger,
+                                           encryption_key_file=encryption_key_file)
+
+    def resume_download_to_file(self, remote, local, stderr=sys.stderr, logger=None, limit_rate=0):
+        """
+        download from src file to dest file, continue from the end of dest file if it exists
+
+        :param remote: remote path of file to download
+        :param local: local path to store downloaded file
+        :param stderr: redirect stderr
+        :param logger: just a logger
+        :param limit_rate: max download speed in bytes/s, 0 means unlimited
+        :return: exit code of the download
+        """
+        offset = os.path.getsize(local) if os.path.exists(local) else 0
+        if logger:
+            logger.info("Download %s from offset %d" % (remote, offset))
+        with open(local, 'ab') as f:
+            return self.download_to_stdout(remote_path=remote, stdout=f, stderr=stderr, logger=logger,
+                                           offset=offset, limit_rate=limit_rate)
 
     def upload_from_string(self, remote, string, stderr=sys.stderr, logger=None):
         """
         upload from string to remote file
+
+        :return: exit code of the upload
         """
         echo_cmd = [
             "echo",
             string
         ]
         with subprocess.Popen(echo_cmd, stdout=subprocess.PIPE) as pipe:
-            self.upload_from_stdin(remote_path=remote, stdin=pipe.stdout, stderr=stderr,
-                                   logger=logger, is_string_input=True)
+            return self.upload_from_stdin(remote_path=remote, stdin=pipe.stdout, stderr=stderr,
+                                          logger=logger, is_string_input=True)
+
+    def sign_url(self, remote_path, expiry, stderr=sys.stderr, logger=None):
+        """
+        generate a pre-signed url to download the remote file, only oss supported
+
+        :param remote_path: remote path of file to sign
+        :param expiry: expiry of the url, e.g. "24h"
+        :return: the signed url
+        """
+        if self._storage != BackupStorage.OSS:
+            raise NotImplementedError("pre-signed url is only supported by oss")
+        sign_cmd = [
+            self._client,
+            "--meta.action=" + ClientAction.SignOss.value,
+            "--meta.sink=" + self._sink,
+            "--meta.filename=" + remote_path,
+            "--meta.retentionTime=" + expiry,
+            "--hostInfoFilePath=" + self._host_info
+        ]
+        if logger:
+            logger.info("Sign command: %s" % sign_cmd)
+        return subprocess.check_output(sign_cmd, stderr=stderr, close_fds=True).decode("utf-8")
+
+    def copy_files(self, src_prefix, dest_prefix, retention, stderr=sys.stderr, logger=None):
+        """
+        copy all files under the src prefix to the dest prefix on the server side, only oss supported
+
+        :param src_prefix: remote prefix to copy from
+        :param dest_prefix: remote prefix to copy to
+        :param retention: retention of the copies, e.g. "720h", empty means no expiry
+        :return: the number of files copied
+        """
+        if self._storage != BackupStorage.OSS:
+            raise NotImplementedError("server side copy is only supported by oss")
+        copy_cmd = [
+            self._client,
+            "--meta.action=" + ClientAction.CopyOss.value,
+            "--meta.sink=" + self._sink,
+            "--meta.filepath=" + src_prefix,
+            "--meta.filename=" + dest_prefix,
+            "--meta.retentionTime=" + retention,
+            "--hostInfoFilePath=" + self._host_info
+        ]
+        if logger:
+            logger.info("Copy command: %s" % copy_cmd)
+        return int(subprocess.check_output(copy_cmd, stderr=stderr, close_fds=True).decode("utf-8"))
+
+    def thaw_files(self, prefix, stderr=sys.stderr, logger=None):
+        """
+        restore (thaw) all archived files under the prefix so that they can be downloaded, only oss supported
+
+        :param prefix: remote prefix of files to thaw
+        :return: the number of archived files and the number of files still thawing
+        """
+        if self._storage != BackupStorage.OSS:
+            raise NotImplementedError("thawing archived files is only supported by oss")
+        thaw_cmd = [
+            self._client,
+            "--meta.action=" + ClientAction.ThawOss.value,
+            "--meta.sink=" + self._sink,
+            "--meta.filepath=" + prefix,
+            "--hostInfoFilePath=" + self._host_info
+        ]
+        if logger:
+            logger.info("Thaw command: %s" % thaw_cmd)
+        output = subprocess.check_output(thaw_cmd, stderr=stderr, close_fds=True).decode("utf-8")
+        archived, thawing = output.split()
+        return int(archived), int(thawing)
+
+    def probe(self, remote_path, size=512, stderr=subprocess.DEVNULL, logger=None):
+        """
+        read the head of the remote file to tell whether it's present and readable, nothing is written locally
+
+        :param remote_path: remote path of file to probe
+        :param size: max bytes to read
+        :return: the number of bytes read, zero if the file is missing, empty or unreadable
+        """
+        download_cmd = [
+            self._client,
+            "--meta.action=" + self._download_action.value,
+            "--meta.sink=" + self._sink,
+            "--meta.filename=" + remote_path,
+            "--hostInfoFilePath=" + self._host_info
+        ]
+        if logger:
+            logger.info("Probe command: %s" % download_cmd)
+        with subprocess.Popen(download_cmd, stdout=subprocess.PIPE, stderr=stderr, close_fds=True) as dp:
+            head = dp.stdout.read(size)
+            dp.kill()
+        return len(head)
+
+    def wait_until_readable(self, remote_path, logger=None):
+        """
+        probe the remote file until it's readable or the consistency wait times out, it's probed only once if
+        consistency wait isn't set. The wait is recorded if the file isn't readable at the first probe
+
+        :param remote_path: remote path of file to wait
+        :return: whether the file is readable
+        """
+        start = time.monotonic()
+        polls = 0
+        while self.probe(remote_path, logger=logger) == 0:
+            if time.monotonic() - start + self._consistency_wait_interval > self._consistency_wait_timeout:
+                if logger and polls > 0:
+                    logger.info("%s still unreadable after %d polls" % (remote_path, polls))
+                return False
+            time.sleep(self._consistency_wait_interval)
+            polls += 1
+        if polls > 0:
+            waited = int((time.monotonic() - start) * 1000)
+            self._waited_objects[remote_path] = self._waited_objects.get(remote_path, 0) + waited
+            if logger:
+                logger.info("%s readable after waiting %d ms" % (remote_path, waited))
+        return True
+
+    def ensure_durable(self, remote_path, logger=None):
+        """
+        wait until the just-uploaded file is readable if consistency wait is set, before it's taken as durable
+
+        :param remote_path: remote path of the uploaded file
+        :raise Exception: if the file is still unreadable after the timeout
+        """
+        if self._consistency_wait_timeout <= 0:
+            return
+        if not self.wait_until_readable(remote_path, logger=logger):
+            raise Exception("uploaded %s unreadable after %ss" % (remote_path, self._consistency_wait_timeout))
+
+    def write_waited_objects(self, path):
+        """
+        write the waits in milliseconds of the objects until readable, in json keyed by remote path
+
+        :param path: local path of the file to write
+        """
+        with open(path, mode='w+', encoding='utf-8') as f:
+            json.dump(self._waited_objects, f)
 
     def init_action(self):
         if self._storage == BackupStorage.OSS:
@@ -122,5 +390,11 @@ class FileStreamClient:
         elif self._storage == BackupStorage.SFTP:
             self._download_action = ClientAction.DownloadSsh
             self._upload_action = ClientAction.UploadSsh
+        elif self._storage == BackupStorage.ARCHIVE:
+            self._download_action = ClientAction.DownloadArchive
+            self._upload_action = ClientAction.UploadArchive
+        elif self._storage == BackupStorage.S3:
+            self._download_action = ClientAction.DownloadS3
+            self._upload_action = ClientAction.UploadS3
         else:
             raise NotImplementedError
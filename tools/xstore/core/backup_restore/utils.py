@@ -11,8 +11,13 @@
 # WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
 # See the License for the specific language governing permissions and
 # limitations under the License.
+import json
+import os
+import re
 import subprocess
 import shlex
+import threading
+import time
 from typing import Sequence, AnyStr
 
 
@@ -27,3 +32,145 @@ def check_run_process(cmd: Sequence[AnyStr] or AnyStr, *, cwd=None, stdout=None,
         logger.info('%s execute command: %s' % (
             prefix, ' '.join([shlex.quote(s) for s in cmd]) if not isinstance(cmd, str) else cmd))
     return subprocess.check_call(cmd, shell=isinstance(cmd, str), cwd=cwd, stdout=stdout, stderr=stderr)
+
+
+# variables of the engine which decide whether a physical backup can be restored, mapped to the keys
+# of the engine compatibility recorded by operator
+COMPATIBILITY_VARIABLES = {
+    'innodb-page-size': 'pageSize',
+    'lower-case-table-names': 'lowerCaseTableNames',
+    'character-set-server': 'characterSet',
+    'collation-server': 'collation',
+}
+
+
+def _parse_plugin_load(value):
+    # e.g. "rpl_semi_sync_master=semisync_master.so;audit_log.so"
+    plugins = []
+    for item in value.split(';'):
+        item = item.strip()
+        if not item:
+            continue
+        name = item.split('=', 1)[0] if '=' in item else os.path.splitext(os.path.basename(item))[0]
+        plugins.append(name)
+    return plugins
+
+
+def engine_compatibility(context):
+    """
+    read the engine facts which decide whether a physical backup can be restored from the engine binary
+    and config, it doesn't require the engine running
+
+    :param context: the context
+    :return: dict of the engine compatibility, e.g. {"engineVersion": "8.0.18", "pageSize": 16384}
+    """
+    bin_dir = os.path.join(context.engine_home, 'bin')
+    result = {}
+
+    version = subprocess.check_output([os.path.join(bin_dir, 'mysqld'), '--version'],
+                                      stderr=subprocess.DEVNULL).decode('utf-8')
+    m = re.search(r'Ver\s+(\S+)', version)
+    if m:
+        result['engineVersion'] = m.group(1)
+
+    # the effective variables are printed after the separator line, as "name value"
+    output = subprocess.check_output([os.path.join(bin_dir, 'mysqld'), '--defaults-file=' + context.mycnf_path,
+                                      '--verbose', '--help'], stderr=subprocess.DEVNULL).decode('utf-8')
+    started = False
+    for line in output.splitlines():
+        if line.startswith('-----'):
+            started = True
+            continue
+        if not started:
+            continue
+        fields = line.split(None, 1)
+        if len(fields) != 2 or fields[0] not in COMPATIBILITY_VARIABLES:
+            continue
+        key, value = COMPATIBILITY_VARIABLES[fields[0]], fields[1].strip()
+        if key in ('pageSize', 'lowerCaseTableNames'):
+            if value.isdigit():
+                result[key] = int(value)
+        elif value and value != '(No default value)':
+            result[key] = value
+
+    plugins = []
+    output = subprocess.check_output([os.path.join(bin_dir, 'my_print_defaults'),
+                                      '--defaults-file=' + context.mycnf_path, 'mysqld'],
+                                     stderr=subprocess.DEVNULL).decode('utf-8')
+    for line in output.splitlines():
+        option, _, value = line.strip().lstrip('-').partition('=')
+        option = option.replace('_', '-')
+        if option.startswith('loose-'):
+            option = option[len('loose-'):]
+        if option in ('plugin-load', 'plugin-load-add'):
+            plugins += _parse_plugin_load(value)
+    if plugins:
+        result['plugins'] = sorted(set(plugins))
+    return result
+
+
+class ProgressReporter(object):
+    """
+    Record the bytes transferred and write them to a json file at most once an interval, which is
+    collected by operator into the progress of backup. It's only a measurement, never fail for it.
+    """
+
+    def __init__(self, path, total=0, interval=5):
+        self.path = path
+        self.total = total
+        self.transferred = 0
+        self._interval = interval
+        self._start_time = int(time.time())
+        self._last_report = 0
+
+    def add(self, n):
+        self.transferred += n
+        if time.time() - self._last_report >= self._interval:
+            self.report()
+
+    def report(self):
+        self._last_report = time.time()
+        progress = {
+            "bytesTransferred": self.transferred,
+            "estimatedTotalBytes": self.total,
+            "startTime": self._start_time,
+            "updateTime": int(self._last_report),
+        }
+        tmp_path = self.path + ".tmp"
+        try:
+            with open(tmp_path, 'w') as f:
+                json.dump(progress, f)
+            os.replace(tmp_path, self.path)
+        except OSError:
+            pass
+
+
+class StreamCounter(threading.Thread):
+    """
+    Relay the source stream to a pipe and count the bytes, use `stdout` as stdin of next process
+    """
+
+    def __init__(self, src, block_size=1 << 20, progress=None):
+        super().__init__(daemon=True)
+        r, w = os.pipe()
+        self.stdout = os.fdopen(r, 'rb')
+        self._writer = os.fdopen(w, 'wb')
+        self._src = src
+        self._block_size = block_size
+        self._progress = progress
+        self.count = 0
+
+    def run(self):
+        try:
+            while True:
+                data = self._src.read(self._block_size)
+                if not data:
+                    break
+                self._writer.write(data)
+                self.count += len(data)
+                if self._progress:
+                    self._progress.add(len(data))
+        finally:
+            self._writer.close()
+            if self._progress:
+                self._progress.report()
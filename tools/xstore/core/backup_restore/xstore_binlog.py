@@ -50,6 +50,12 @@ class XStoreBinlog():
                                                      right_contain=right_contain)
         return local_binlogs
 
+    # offset in seconds of the server time zone, which the datetime of binlog events is shown in
+    def get_time_zone_offset(self):
+        sql = "select timestampdiff(second, utc_timestamp(), now())"
+        return int(mysql_do_select(self.db_port, sql, host=self.host, user=self.user, passwd=self.decrypted_passwd,
+                                   fetchone=True))
+
 
 def connect(host, port, user, password, database, use_unicode=True, charset='utf8', connect_timeout=3, timeout=30,
             autocommit=False):
@@ -69,6 +69,8 @@ class Context(object):
             convention.VOLUME_LOG: self._env.get(convention.ENV_VOLUME_LOG, '/data-log/mysql'),
             convention.VOLUME_CONFIG: self._env.get(convention.ENV_VOLUME_CONFIG, '/data/config'),
             convention.VOLUME_SHARED: self._env.get(convention.ENV_VOLUME_SHARED, '/data/shared'),
+            convention.VOLUME_TLS: self._env.get(convention.ENV_VOLUME_TLS, '/data/tls'),
+            convention.VOLUME_TDE: self._env.get(convention.ENV_VOLUME_TDE, '/data/tde'),
         }
         if not self.check_log_data_separation():
             self._volumes[convention.ENV_VOLUME_LOG] = self._volumes[convention.VOLUME_DATA]
@@ -141,6 +143,51 @@ class Context(object):
             raise ValueError('invalid volume type: ' + volume_type)
         return os.path.join(vol_root, *sub_paths)
 
+    def tls_enabled(self) -> bool:
+        """
+        TLS is enabled if the certificates are mounted.
+        :return: true if enabled.
+        """
+        return os.path.exists(self.volume_path(convention.VOLUME_TLS, convention.TLS_SERVER_CRT))
+
+    def tde_enabled(self) -> bool:
+        """
+        TDE is enabled if the master key source is mounted.
+        :return: true if enabled.
+        """
+        return os.path.isdir(self.volume_path(convention.VOLUME_TDE))
+
+    def tde_kms_provider(self) -> str or None:
+        """
+        Get the provider of external KMS of TDE.
+        :return: provider if the master key is sourced from KMS, None otherwise.
+        """
+        return self._env.get(convention.ENV_TDE_KMS_PROVIDER)
+
+    def keyring_path(self) -> str:
+        """
+        Get the path of keyring of TDE, which is kept on the data volume.
+        :return: keyring path.
+        """
+        return self.volume_path(convention.VOLUME_DATA, 'keyring', 'keyring')
+
+    def keyring_password_config(self) -> str or None:
+        """
+        Write the option file of the keyring password, which is read from the mounted secret. It's kept
+        in memory and readable only by the owner, so that the password is never written into my.cnf on
+        the data volume.
+        :return: path of the option file, None if the keyring file isn't encrypted by password.
+        """
+        if not self.tde_enabled() or self.tde_kms_provider():
+            return None
+        with open(self.volume_path(convention.VOLUME_TDE, convention.TDE_PASSWORD), 'r') as f:
+            password = f.read().strip()
+        path = os.path.join(convention.TDE_PASSWORD_CONFIG_DIR, 'keyring-password.cnf')
+        fd = os.open(path, os.O_WRONLY | os.O_CREAT | os.O_TRUNC, 0o600)
+        with os.fdopen(fd, 'w') as f:
+            f.write('[mysqld]\nloose_keyring_encrypted_file_password=%s\n' % password)
+        return path
+
     def port_access(self) -> int:
         """
         Get access port.
@@ -24,6 +24,8 @@ ENV_VOLUME_DATA = 'VOLUME_DATA'
 ENV_VOLUME_CONFIG = 'VOLUME_CONFIG'
 ENV_VOLUME_SHARED = 'VOLUME_SHARED'
 ENV_VOLUME_LOG = "VOLUME_LOG"
+ENV_VOLUME_TLS = 'VOLUME_TLS'
+ENV_VOLUME_TDE = 'VOLUME_TDE'
 ENV_LOG_DATA_SEPARATION = "LOG_DATA_SEPARATION"
 LOG_DATA_SEPARATION_ON = "true"
 ENV_RPC_PROTOCOL_VERSION = 'RPC_PROTOCOL_VERSION'
@@ -33,12 +35,32 @@ ENV_PORT_PAXOS = 'PORT_PAXOS'
 
 ENV_NODE_ROLE = 'NODE_ROLE'
 
+ENV_TDE_KMS_PROVIDER = 'TDE_KMS_PROVIDER'
+ENV_TDE_KMS_KEY_ID = 'TDE_KMS_KEY_ID'
+ENV_TDE_KMS_REGION = 'TDE_KMS_REGION'
+
 # Common Volumes
 
 VOLUME_DATA = 'data'
 VOLUME_CONFIG = 'config'
 VOLUME_SHARED = 'shared'
 VOLUME_LOG = "log"
+VOLUME_TLS = 'tls'
+VOLUME_TDE = 'tde'
+
+# Certificates in the TLS volume
+
+TLS_ROOT_CRT = 'root.crt'
+TLS_SERVER_CRT = 'server.crt'
+TLS_SERVER_KEY = 'server.key'
+
+# Master key sources in the TDE volume
+
+TDE_PASSWORD = 'password'
+TDE_KMS_AWS_CONF = 'keyring_aws.conf'
+
+# The option file of keyring password is written to the memory backed directory
+TDE_PASSWORD_CONFIG_DIR = '/dev/shm'
 
 # Common Ports
 
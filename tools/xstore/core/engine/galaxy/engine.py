@@ -104,7 +104,11 @@ class GalaxyEngine(EngineCommon):
             args['loose-cluster-learner-node'] = 'ON'
 
         # build cmd, use --k=v or --k to build the arguments
-        cmd = [os.path.join(self.path_home, 'bin', binary), '--defaults-file=' + self.file_config] + [
+        defaults = ['--defaults-file=' + self.file_config]
+        keyring_password_config = self.context.keyring_password_config()
+        if keyring_password_config:
+            defaults.append('--defaults-extra-file=' + keyring_password_config)
+        cmd = [os.path.join(self.path_home, 'bin', binary)] + defaults + [
             '--%s=%s' % (str(k), v) if v else ('--' + str(k)) for k, v in args.items()]
 
         return cmd
@@ -201,6 +205,35 @@ class GalaxyEngine(EngineCommon):
             system_config['mysqld']['innodb_buffer_pool_chunk_size'] = '134217728'
             system_config['mysqld']['innodb_buffer_pool_instances'] = '2'
 
+        # Certificates are reloaded online with "ALTER INSTANCE RELOAD TLS" when rotated.
+        if self.context.tls_enabled():
+            system_config['mysqld']['ssl_ca'] = self.context.volume_path(convention.VOLUME_TLS, convention.TLS_ROOT_CRT)
+            system_config['mysqld']['ssl_cert'] = self.context.volume_path(convention.VOLUME_TLS,
+                                                                           convention.TLS_SERVER_CRT)
+            system_config['mysqld']['ssl_key'] = self.context.volume_path(convention.VOLUME_TLS,
+                                                                          convention.TLS_SERVER_KEY)
+
+        # The keyring is kept on the data volume, whose master key is either encrypted by the password in
+        # the secret or sourced from the external KMS. It's rotated online with "ALTER INSTANCE ROTATE INNODB
+        # MASTER KEY".
+        if self.context.tde_enabled():
+            keyring_path = self.context.keyring_path()
+            os.makedirs(os.path.dirname(keyring_path), exist_ok=True)
+            if self.context.tde_kms_provider() == 'AWS':
+                system_config['mysqld']['early-plugin-load'] = 'keyring_aws.so'
+                system_config['mysqld']['keyring_aws_cmk_id'] = self.context.env().get(convention.ENV_TDE_KMS_KEY_ID)
+                system_config['mysqld']['keyring_aws_region'] = self.context.env().get(convention.ENV_TDE_KMS_REGION)
+                system_config['mysqld']['keyring_aws_conf_file'] = self.context.volume_path(
+                    convention.VOLUME_TDE, convention.TDE_KMS_AWS_CONF)
+                system_config['mysqld']['keyring_aws_data_file'] = keyring_path
+            else:
+                # The password is passed with the extra defaults file, see _command_mysqld.
+                system_config['mysqld']['early-plugin-load'] = 'keyring_encrypted_file.so'
+                system_config['mysqld']['keyring_encrypted_file_data'] = keyring_path
+            system_config['mysqld']['default_table_encryption'] = 'ON'
+            system_config['mysqld']['innodb_redo_log_encrypt'] = 'ON'
+            system_config['mysqld']['innodb_undo_log_encrypt'] = 'ON'
+
         system_config['mysqld_safe'] = {
             'pid-file': os.path.join(self.path_run, 'mysql.pid')
         }